@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+)
+
+// slaTracker holds the agents flagged for breaching their capabilities' SLAs
+var slaTracker agentRegistry.SLATracker
+
+// SetupAgentSLA sets the registry used by the SLA breach endpoint (called from main.go)
+func SetupAgentSLA(registry agentRegistry.AgentRegistry) {
+	slaTracker, _ = registry.(agentRegistry.SLATracker)
+}
+
+// ListSLABreaches godoc
+// @Summary      List agents breaching their SLA
+// @Description  Returns the agents flagged for answering a capability's requests slower than its declared SLA several times in a row; they are tried after other agents until they recover
+// @Tags         agents
+// @Produce      json
+// @Success      200  {array}   agentRegistry.SLABreach
+// @Failure      503  {object}  map[string]string
+// @Router       /v1/agents/sla-breaches [get]
+func ListSLABreaches(w http.ResponseWriter, r *http.Request) {
+	if slaTracker == nil {
+		WriteJSONError(w, "SLA tracking is not available", http.StatusServiceUnavailable)
+		return
+	}
+	breaches, err := slaTracker.SLABreaches(r.Context())
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(breaches)
+}
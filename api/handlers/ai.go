@@ -7,6 +7,15 @@ import (
 	"os"
 	"strconv"
 	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/krzachariassen/ZTDP/internal/agents/orchestrator"
+	"github.com/krzachariassen/ZTDP/internal/ai"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/features"
+	"github.com/krzachariassen/ZTDP/internal/guardrails"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
 )
 
 // AIProviderInfo represents AI provider information
@@ -19,6 +28,19 @@ type AIProviderInfo struct {
 	Config       map[string]string `json:"config,omitempty"`
 }
 
+// embeddingProvider embeds texts for semantic matching; nil when embeddings are not configured
+var embeddingProvider ai.EmbeddingProvider
+
+// SetupEmbeddings sets the embedding provider (called from main.go)
+func SetupEmbeddings(provider ai.EmbeddingProvider) {
+	embeddingProvider = provider
+}
+
+// GetEmbeddingProvider returns the configured embedding provider, or nil
+func GetEmbeddingProvider() ai.EmbeddingProvider {
+	return embeddingProvider
+}
+
 // AIProviderStatus godoc
 // @Summary      Get AI provider status
 // @Description  Returns information about the current AI provider configuration and availability
@@ -57,6 +79,13 @@ func AIProviderStatus(w http.ResponseWriter, r *http.Request) {
 			"model":    providerInfo.Model,
 		}
 	}
+	if providerInfo.Config == nil {
+		providerInfo.Config = map[string]string{}
+	}
+	providerInfo.Config["embeddings"] = "disabled"
+	if embeddingProvider != nil {
+		providerInfo.Config["embeddings"] = "enabled"
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(providerInfo)
@@ -103,7 +132,10 @@ func AIMetrics(w http.ResponseWriter, r *http.Request) {
 			"avg_response_time": "0ms",
 			"success_rate":      "0%",
 		},
-		"note": "Metrics collection is not yet implemented. This endpoint returns placeholder data.",
+		"agent_queries":  agentframework.GetQueryMetrics(),
+		"event_dedup":    agentframework.GetDedupMetrics(),
+		"expired_events": events.GetExpiryMetrics(),
+		"note":           "AI operation metrics are not yet implemented; agent_queries, event_dedup and expired_events report agent event handling.",
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -124,7 +156,29 @@ type AIChatRequest struct {
 
 // V3ChatRequest represents a request to the V3 AI chat endpoint
 type V3ChatRequest struct {
-	Message string `json:"message" binding:"required"`
+	Message        string `json:"message" binding:"required"`
+	ConversationID string `json:"conversation_id,omitempty"` // keeps feature flag rollouts stable across turns
+	Tenant         string `json:"tenant,omitempty"`
+	Role           string `json:"role,omitempty"` // caller role checked by guardrails; defaults to guardrails.default_role
+	User           string `json:"user,omitempty"` // resolves "my" in requests such as saved searches
+}
+
+// withCaller identifies the conversation, tenant, role and user for feature flags, guardrails
+// and per-user features
+func (req V3ChatRequest) withCaller(ctx context.Context) context.Context {
+	if req.ConversationID != "" {
+		ctx = features.WithConversationID(ctx, req.ConversationID)
+	}
+	if req.Tenant != "" {
+		ctx = features.WithTenant(ctx, req.Tenant)
+	}
+	if req.Role != "" {
+		ctx = guardrails.WithRole(ctx, req.Role)
+	}
+	if req.User != "" {
+		ctx = logging.WithUserID(ctx, req.User)
+	}
+	return ctx
 }
 
 // V3AIChat godoc
@@ -159,6 +213,7 @@ func V3AIChat(w http.ResponseWriter, r *http.Request) {
 
 	ctx, cancel := context.WithTimeout(r.Context(), 120*time.Second)
 	defer cancel()
+	ctx = req.withCaller(ctx)
 
 	// Use the ultra simple Chat method!
 	response, err := orchestrator.Chat(ctx, req.Message)
@@ -171,6 +226,64 @@ func V3AIChat(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// maxBatchInstructions caps how many instructions a single batch request may run
+const maxBatchInstructions = 100
+
+// V3ChatBatchRequest represents a request to run several chat instructions in one conversation
+type V3ChatBatchRequest struct {
+	Instructions    []string `json:"instructions"`
+	ContinueOnError bool     `json:"continue_on_error,omitempty"` // run the remaining instructions after one fails
+	ConversationID  string   `json:"conversation_id,omitempty"`   // generated when empty
+	Tenant          string   `json:"tenant,omitempty"`
+	Role            string   `json:"role,omitempty"`
+	User            string   `json:"user,omitempty"`
+}
+
+// ChatBatch godoc
+// @Summary      Run chat instructions as a script
+// @Description  Runs the instructions one after another as turns of one conversation and returns a result per instruction. By default the instructions after a failed one are skipped; set continue_on_error to run them all. Each instruction has the same timeout as a single chat request.
+// @Tags         ai
+// @Accept       json
+// @Produce      json
+// @Param        request  body      V3ChatBatchRequest  true  "Instructions and caller"
+// @Success      200      {object}  orchestrator.BatchResponse
+// @Failure      400      {object}  map[string]string
+// @Failure      503      {object}  map[string]string
+// @Router       /v1/chat/batch [post]
+func ChatBatch(w http.ResponseWriter, r *http.Request) {
+	var req V3ChatBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteJSONError(w, "Invalid request format", http.StatusBadRequest)
+		return
+	}
+	if len(req.Instructions) == 0 {
+		WriteJSONError(w, "At least one instruction is required", http.StatusBadRequest)
+		return
+	}
+	if len(req.Instructions) > maxBatchInstructions {
+		WriteJSONError(w, "A batch may hold at most "+strconv.Itoa(maxBatchInstructions)+" instructions", http.StatusBadRequest)
+		return
+	}
+	for i, instruction := range req.Instructions {
+		if instruction == "" {
+			WriteJSONError(w, "Instruction "+strconv.Itoa(i)+" is empty", http.StatusBadRequest)
+			return
+		}
+	}
+
+	orch := GetGlobalOrchestrator()
+	if orch == nil {
+		WriteJSONError(w, "Orchestrator not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	caller := V3ChatRequest{ConversationID: req.ConversationID, Tenant: req.Tenant, Role: req.Role, User: req.User}
+	batch := orch.ChatBatch(caller.withCaller(r.Context()), req.Instructions, req.ContinueOnError, 120*time.Second)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(batch)
+}
+
 // Helper function to get environment variable with fallback
 func getEnvOrDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -178,3 +291,65 @@ func getEnvOrDefault(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// chatStreamMessage is written to the chat WebSocket: "chunk" messages carry incremental
+// content, then a "done" message carries the full response, or an "error" message ends the turn
+type chatStreamMessage struct {
+	Type     string                               `json:"type"`
+	Content  string                               `json:"content,omitempty"`
+	Response *orchestrator.ConversationalResponse `json:"response,omitempty"`
+	Error    string                               `json:"error,omitempty"`
+}
+
+// V3AIChatStream godoc
+// @Summary      Stream chat responses over a WebSocket
+// @Description  Each text message sent by the client is a V3ChatRequest. Free-form answers are streamed as "chunk" messages while the AI generates them; every turn ends with a "done" message holding the full response, or an "error" message.
+// @Tags         ai
+// @Success      101  {string}  string  "Switching Protocols"
+// @Router       /v3/ai/chat/stream [get]
+func V3AIChatStream(w http.ResponseWriter, r *http.Request) {
+	logger := logging.GetLogger().ForComponent("chat-websocket")
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logger.ErrorWithErr(err, "WebSocket upgrade failed")
+		return
+	}
+	defer conn.Close()
+
+	for {
+		var req V3ChatRequest
+		if err := conn.ReadJSON(&req); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				logger.Warn("Chat WebSocket error: %v", err)
+			}
+			return
+		}
+		if req.Message == "" {
+			conn.WriteJSON(chatStreamMessage{Type: "error", Error: "Message is required"})
+			continue
+		}
+		orch := GetGlobalOrchestrator()
+		if orch == nil {
+			conn.WriteJSON(chatStreamMessage{Type: "error", Error: "Orchestrator not available"})
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 120*time.Second)
+		response, err := orch.ChatStream(req.withCaller(ctx), req.Message, func(chunk string) {
+			if err := conn.WriteJSON(chatStreamMessage{Type: "chunk", Content: chunk}); err != nil {
+				cancel()
+			}
+		})
+		cancel()
+		if err != nil {
+			if conn.WriteJSON(chatStreamMessage{Type: "error", Error: "Orchestrator chat failed: " + err.Error()}) != nil {
+				return
+			}
+			continue
+		}
+		if err := conn.WriteJSON(chatStreamMessage{Type: "done", Response: response}); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/krzachariassen/ZTDP/internal/analytics"
+)
+
+// intentAnalytics is nil when intent analytics are disabled
+var intentAnalytics analytics.Store
+
+// defaultMisroutedConfidence flags classifications agents were less sure about than this
+const defaultMisroutedConfidence = 0.7
+
+// SetupAnalytics sets the store used by the intent analytics endpoints (called from main.go)
+func SetupAnalytics(store analytics.Store) {
+	intentAnalytics = store
+}
+
+// IntentAnalytics godoc
+// @Summary      Aggregate intent classifications
+// @Description  Groups recorded intent classifications by intent, agent, outcome or tenant with counts, success rate, confidence and latency
+// @Tags         analytics
+// @Produce      json
+// @Param        group_by  query  string  false  "intent (default), agent, outcome or tenant"
+// @Param        intent    query  string  false  "Only this intent"
+// @Param        agent     query  string  false  "Only requests routed to this agent"
+// @Param        outcome   query  string  false  "Only this outcome (success, error, timeout, unroutable, conversation, fallback, failed)"
+// @Param        tenant    query  string  false  "Only this tenant"
+// @Param        feedback  query  string  false  "Only responses users rated up or down"
+// @Param        since     query  string  false  "RFC3339 timestamp or duration ago (e.g. 24h)"
+// @Param        until     query  string  false  "RFC3339 timestamp or duration ago"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Router       /v1/analytics/intents [get]
+func IntentAnalytics(w http.ResponseWriter, r *http.Request) {
+	records, ok := queryIntentRecords(w, r, 0)
+	if !ok {
+		return
+	}
+
+	groupBy := r.URL.Query().Get("group_by")
+	if groupBy == "" {
+		groupBy = analytics.GroupByIntent
+	}
+	groups, err := analytics.Aggregate(records, groupBy)
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"group_by": groupBy,
+		"total":    len(records),
+		"groups":   groups,
+	})
+}
+
+// IntentRecords godoc
+// @Summary      List intent classifications
+// @Description  Returns recorded intent classifications in chronological order
+// @Tags         analytics
+// @Produce      json
+// @Param        intent          query  string  false  "Only this intent"
+// @Param        agent           query  string  false  "Only requests routed to this agent"
+// @Param        outcome         query  string  false  "Only this outcome"
+// @Param        tenant          query  string  false  "Only this tenant"
+// @Param        feedback        query  string  false  "Only responses users rated up or down"
+// @Param        max_confidence  query  number  false  "Only classifications at or below this confidence"
+// @Param        since           query  string  false  "RFC3339 timestamp or duration ago"
+// @Param        until           query  string  false  "RFC3339 timestamp or duration ago"
+// @Param        limit           query  int     false  "Most recent records to return (default 100, max 1000)"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Router       /v1/analytics/intents/records [get]
+func IntentRecords(w http.ResponseWriter, r *http.Request) {
+	records, ok := queryIntentRecords(w, r, defaultLogQueryLimit)
+	if !ok {
+		return
+	}
+	writeIntentRecords(w, records)
+}
+
+// MisroutedIntents godoc
+// @Summary      List likely misrouted intents
+// @Description  Returns requests no agent could handle, agent errors and timeouts, responses rated down, and low-confidence classifications
+// @Tags         analytics
+// @Produce      json
+// @Param        min_confidence  query  number  false  "Classifications below this confidence are flagged (default 0.7)"
+// @Param        since           query  string  false  "RFC3339 timestamp or duration ago"
+// @Param        limit           query  int     false  "Most recent records to return (default 100, max 1000)"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Router       /v1/analytics/intents/misrouted [get]
+func MisroutedIntents(w http.ResponseWriter, r *http.Request) {
+	records, ok := queryIntentRecords(w, r, 0)
+	if !ok {
+		return
+	}
+
+	minConfidence := defaultMisroutedConfidence
+	if v := r.URL.Query().Get("min_confidence"); v != "" {
+		parsed, err := strconv.ParseFloat(v, 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			WriteJSONError(w, "min_confidence must be a number between 0 and 1", http.StatusBadRequest)
+			return
+		}
+		minConfidence = parsed
+	}
+
+	suspects := analytics.Misrouted(records, minConfidence)
+	limit, err := intentLimit(r, defaultLogQueryLimit)
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(suspects) > limit {
+		suspects = suspects[len(suspects)-limit:]
+	}
+	writeIntentRecords(w, suspects)
+}
+
+// queryIntentRecords loads records matching the request filters, writing the error response on failure
+func queryIntentRecords(w http.ResponseWriter, r *http.Request, defaultLimit int) ([]analytics.IntentRecord, bool) {
+	if intentAnalytics == nil {
+		WriteJSONError(w, "Intent analytics are disabled", http.StatusServiceUnavailable)
+		return nil, false
+	}
+
+	params := r.URL.Query()
+	query := analytics.IntentQuery{
+		Intent:   params.Get("intent"),
+		Agent:    params.Get("agent"),
+		Outcome:  analytics.Outcome(params.Get("outcome")),
+		Tenant:   params.Get("tenant"),
+		Feedback: params.Get("feedback"),
+	}
+
+	var err error
+	if query.Since, err = parseLogTime(params.Get("since")); err != nil {
+		WriteJSONError(w, "invalid since: "+err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	if query.Until, err = parseLogTime(params.Get("until")); err != nil {
+		WriteJSONError(w, "invalid until: "+err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	if v := params.Get("max_confidence"); v != "" {
+		if query.MaxConfidence, err = strconv.ParseFloat(v, 64); err != nil {
+			WriteJSONError(w, "max_confidence must be a number", http.StatusBadRequest)
+			return nil, false
+		}
+	}
+	if defaultLimit > 0 {
+		if query.Limit, err = intentLimit(r, defaultLimit); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusBadRequest)
+			return nil, false
+		}
+	}
+
+	records, err := intentAnalytics.Query(r.Context(), query)
+	if err != nil {
+		WriteJSONError(w, "Failed to query intent analytics: "+err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	return records, true
+}
+
+func intentLimit(r *http.Request, defaultLimit int) (int, error) {
+	v := r.URL.Query().Get("limit")
+	if v == "" {
+		return defaultLimit, nil
+	}
+	limit, err := strconv.Atoi(v)
+	if err != nil || limit < 1 {
+		return 0, fmt.Errorf("limit must be a positive integer")
+	}
+	return min(limit, maxLogQueryLimit), nil
+}
+
+func writeIntentRecords(w http.ResponseWriter, records []analytics.IntentRecord) {
+	if records == nil {
+		records = []analytics.IntentRecord{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"records": records,
+		"count":   len(records),
+	})
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krzachariassen/ZTDP/internal/archive"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// applicationArchive keeps retired applications in object storage
+var applicationArchive *archive.Archive
+
+// SetupApplicationArchive sets the archive used by the application archive endpoints (called from main.go)
+func SetupApplicationArchive(a *archive.Archive) {
+	applicationArchive = a
+}
+
+// ArchiveApplicationRequest carries why an application is archived
+type ArchiveApplicationRequest struct {
+	Reason string `json:"reason"`
+}
+
+// ArchiveApplication godoc
+// @Summary      Archive an application
+// @Description  Exports a retired application — the nodes it owns, their versions, the nodes recording its history, their edges and its deployment history — to the archive and removes it from the graph and the AI's context
+// @Tags         applications
+// @Accept       json
+// @Produce      json
+// @Param        app_name  path      string                     true   "Application name"
+// @Param        request   body      ArchiveApplicationRequest  false  "Reason"
+// @Success      200       {object}  archive.ApplicationSnapshot
+// @Failure      404       {object}  map[string]string
+// @Failure      409       {object}  map[string]string
+// @Failure      503       {object}  map[string]string
+// @Router       /v1/applications/{app_name}/archive [post]
+func ArchiveApplication(w http.ResponseWriter, r *http.Request) {
+	if applicationArchive == nil {
+		WriteJSONError(w, "Archive is not configured", http.StatusServiceUnavailable)
+		return
+	}
+	var req ArchiveApplicationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	snapshot, err := applicationArchive.ArchiveApplication(r.Context(), chi.URLParam(r, "app_name"), logging.UserIDFromContext(r.Context()), req.Reason)
+	if err != nil {
+		writeApplicationArchiveError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(snapshot)
+}
+
+// RestoreApplication godoc
+// @Summary      Restore an archived application
+// @Description  Puts the latest archived snapshot of an application back into the graph. Edges from or to nodes deleted since it was archived are skipped and reported.
+// @Tags         applications
+// @Produce      json
+// @Param        app_name  path      string  true  "Application name"
+// @Success      200       {object}  archive.RestoreResult
+// @Failure      404       {object}  map[string]string
+// @Failure      409       {object}  map[string]string
+// @Failure      503       {object}  map[string]string
+// @Router       /v1/applications/{app_name}/restore [post]
+func RestoreApplication(w http.ResponseWriter, r *http.Request) {
+	if applicationArchive == nil {
+		WriteJSONError(w, "Archive is not configured", http.StatusServiceUnavailable)
+		return
+	}
+	result, err := applicationArchive.RestoreApplication(r.Context(), chi.URLParam(r, "app_name"))
+	if err != nil {
+		writeApplicationArchiveError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// ListArchivedApplications godoc
+// @Summary      List archived applications
+// @Description  Returns the applications in the archive that have not been restored, with when they were archived
+// @Tags         applications
+// @Produce      json
+// @Success      200  {array}   archive.ArchivedApplication
+// @Failure      503  {object}  map[string]string
+// @Router       /v1/archive/applications [get]
+func ListArchivedApplications(w http.ResponseWriter, r *http.Request) {
+	if applicationArchive == nil {
+		WriteJSONError(w, "Archive is not configured", http.StatusServiceUnavailable)
+		return
+	}
+	list, err := applicationArchive.ArchivedApplications()
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// GetArchivedApplication godoc
+// @Summary      Get an archived application
+// @Description  Returns the latest archived snapshot of an application: its nodes, edges, deployment history and why and by whom it was archived
+// @Tags         applications
+// @Produce      json
+// @Param        app_name  path      string  true  "Application name"
+// @Success      200       {object}  archive.ApplicationSnapshot
+// @Failure      404       {object}  map[string]string
+// @Failure      503       {object}  map[string]string
+// @Router       /v1/archive/applications/{app_name} [get]
+func GetArchivedApplication(w http.ResponseWriter, r *http.Request) {
+	if applicationArchive == nil {
+		WriteJSONError(w, "Archive is not configured", http.StatusServiceUnavailable)
+		return
+	}
+	snapshot, err := applicationArchive.ApplicationSnapshot(r.Context(), chi.URLParam(r, "app_name"))
+	if err != nil {
+		writeApplicationArchiveError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(snapshot)
+}
+
+func writeApplicationArchiveError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, archive.ErrApplicationNotFound), errors.Is(err, archive.ErrNotArchived):
+		WriteJSONError(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, archive.ErrRestoreConflict), errors.Is(err, archive.ErrDeploymentActive):
+		WriteJSONError(w, err.Error(), http.StatusConflict)
+	default:
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+	}
+}
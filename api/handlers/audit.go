@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/audit"
+)
+
+// auditLog is nil when the API audit log is disabled
+var auditLog *audit.Log
+
+// SetupAudit sets the log served by the audit endpoint (called from main.go)
+func SetupAudit(log *audit.Log) {
+	auditLog = log
+}
+
+// ExportAuditLog godoc
+// @Summary      Export the API audit log
+// @Description  Returns the API calls kept in memory, oldest first: caller, route, status, latency and request body hash. The json format is an array; jsonl and cef write one record per line for SIEM ingestion.
+// @Tags         admin
+// @Produce      json
+// @Produce      plain
+// @Param        from        query     string  false  "RFC3339 timestamp; only calls made at or after it"
+// @Param        to          query     string  false  "RFC3339 timestamp; only calls made before it"
+// @Param        caller      query     string  false  "Only calls by this caller"
+// @Param        route       query     string  false  "Only calls to this route pattern, e.g. /v1/applications/{app_name}/runbook"
+// @Param        min_status  query     int     false  "Only calls answered with at least this status, e.g. 400"
+// @Param        format      query     string  false  "json (default), jsonl or cef"
+// @Success      200         {array}   audit.Record
+// @Failure      400         {object}  map[string]string
+// @Failure      503         {object}  map[string]string
+// @Router       /v1/admin/audit [get]
+func ExportAuditLog(w http.ResponseWriter, r *http.Request) {
+	if auditLog == nil {
+		WriteJSONError(w, "Audit log is disabled", http.StatusServiceUnavailable)
+		return
+	}
+	query := r.URL.Query()
+	filter := audit.Filter{Caller: query.Get("caller"), Route: query.Get("route")}
+	for name, target := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		if value := query.Get(name); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				WriteJSONError(w, name+" must be an RFC3339 timestamp", http.StatusBadRequest)
+				return
+			}
+			*target = parsed
+		}
+	}
+	if value := query.Get("min_status"); value != "" {
+		status, err := strconv.Atoi(value)
+		if err != nil {
+			WriteJSONError(w, "min_status must be an integer", http.StatusBadRequest)
+			return
+		}
+		filter.MinStatus = status
+	}
+	format := query.Get("format")
+	if format == "" {
+		format = audit.FormatJSON
+	}
+	switch format {
+	case audit.FormatJSON, audit.FormatJSONLines, audit.FormatCEF:
+	default:
+		WriteJSONError(w, "format must be json, jsonl or cef", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", audit.ContentType(format))
+	audit.Export(w, auditLog.Query(filter), format)
+}
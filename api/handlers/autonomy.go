@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krzachariassen/ZTDP/internal/guardrails"
+)
+
+// autonomyResponse lists the autonomy settings and the level everything else gets
+type autonomyResponse struct {
+	Default  guardrails.Autonomy           `json:"default"`
+	Settings []*guardrails.AutonomySetting `json:"settings"`
+}
+
+// ListAutonomy godoc
+// @Summary      List AI autonomy levels
+// @Description  Returns the autonomy level set per tenant and application, and the configured default. An application's level wins over its tenant's.
+// @Tags         guardrails
+// @Produce      json
+// @Success      200  {object}  autonomyResponse
+// @Failure      500  {object}  map[string]string
+// @Router       /v1/autonomy [get]
+func ListAutonomy(w http.ResponseWriter, r *http.Request) {
+	settings, err := guardrails.NewAutonomyStore(requestGraph(r)).List()
+	if err != nil {
+		WriteJSONError(w, "Failed to list autonomy settings", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(autonomyResponse{Default: guardrails.Default().DefaultAutonomy(), Settings: settings})
+}
+
+// SetAutonomy godoc
+// @Summary      Set the AI autonomy level of a tenant or application
+// @Description  observe rejects every change the AI proposes, suggest only proposes them (deployments become plans), execute-with-approval needs a caller with an approver role and full-auto runs what the other guardrails allow
+// @Tags         guardrails
+// @Accept       json
+// @Produce      json
+// @Param        setting  body      guardrails.AutonomySetting  true  "Scope (tenant or application), name and level"
+// @Success      200      {object}  guardrails.AutonomySetting
+// @Failure      400      {object}  map[string]string
+// @Router       /v1/autonomy [put]
+func SetAutonomy(w http.ResponseWriter, r *http.Request) {
+	var setting guardrails.AutonomySetting
+	if err := json.NewDecoder(r.Body).Decode(&setting); err != nil {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if err := guardrails.NewAutonomyStore(requestGraph(r)).Set(&setting); err != nil {
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(setting)
+}
+
+// DeleteAutonomy godoc
+// @Summary      Remove the AI autonomy level of a tenant or application
+// @Tags         guardrails
+// @Param        scope  path  string  true  "tenant or application"
+// @Param        name   path  string  true  "Tenant or application name"
+// @Success      204
+// @Failure      404  {object}  map[string]string
+// @Router       /v1/autonomy/{scope}/{name} [delete]
+func DeleteAutonomy(w http.ResponseWriter, r *http.Request) {
+	err := guardrails.NewAutonomyStore(requestGraph(r)).Delete(chi.URLParam(r, "scope"), chi.URLParam(r, "name"))
+	if errors.Is(err, guardrails.ErrAutonomyNotFound) {
+		WriteJSONError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
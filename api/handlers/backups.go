@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krzachariassen/ZTDP/internal/backup"
+)
+
+// backupService is nil when no backup location is configured
+var backupService *backup.Service
+
+// SetupBackups sets the service used by the backup endpoints (called from main.go)
+func SetupBackups(service *backup.Service) {
+	backupService = service
+}
+
+// CreateBackup godoc
+// @Summary      Back up the graph
+// @Description  Writes a checksummed snapshot of the whole graph to the configured backup location
+// @Tags         admin
+// @Produce      json
+// @Success      201  {object}  backup.Info
+// @Failure      503  {object}  map[string]string
+// @Router       /v1/admin/backups [post]
+func CreateBackup(w http.ResponseWriter, r *http.Request) {
+	if backupService == nil {
+		WriteJSONError(w, "Backups are disabled", http.StatusServiceUnavailable)
+		return
+	}
+	info, err := backupService.Create(r.Context())
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(info)
+}
+
+// ListBackups godoc
+// @Summary      List graph backups
+// @Description  Returns the stored backups, newest first
+// @Tags         admin
+// @Produce      json
+// @Success      200  {array}   backup.Info
+// @Failure      503  {object}  map[string]string
+// @Router       /v1/admin/backups [get]
+func ListBackups(w http.ResponseWriter, r *http.Request) {
+	if backupService == nil {
+		WriteJSONError(w, "Backups are disabled", http.StatusServiceUnavailable)
+		return
+	}
+	backups, err := backupService.List(r.Context())
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(backups)
+}
+
+// RestoreBackup godoc
+// @Summary      Restore the graph from a backup
+// @Description  Verifies the backup's checksum and replaces the graph with it, reporting integrity issues the snapshot contains. With dry_run=true the backup is only verified.
+// @Tags         admin
+// @Produce      json
+// @Param        name     path      string  true   "Backup name"
+// @Param        dry_run  query     bool    false  "Verify without restoring"
+// @Success      200      {object}  backup.RestoreResult
+// @Failure      404      {object}  map[string]string
+// @Failure      422      {object}  map[string]string
+// @Failure      503      {object}  map[string]string
+// @Router       /v1/admin/backups/{name}/restore [post]
+func RestoreBackup(w http.ResponseWriter, r *http.Request) {
+	if backupService == nil {
+		WriteJSONError(w, "Backups are disabled", http.StatusServiceUnavailable)
+		return
+	}
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+	result, err := backupService.Restore(r.Context(), chi.URLParam(r, "name"), dryRun)
+	if err != nil {
+		switch {
+		case errors.Is(err, backup.ErrBackupNotFound), errors.Is(err, backup.ErrInvalidName):
+			WriteJSONError(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, backup.ErrCorrupt):
+			WriteJSONError(w, err.Error(), http.StatusUnprocessableEntity)
+		default:
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krzachariassen/ZTDP/internal/bundles"
+)
+
+// bundleService creates release bundles and promotes them across environments
+var bundleService *bundles.Service
+
+// SetupBundles sets the service used by the release bundle endpoints (called from main.go)
+func SetupBundles(service *bundles.Service) {
+	bundleService = service
+}
+
+// CreateReleaseBundle godoc
+// @Summary      Create a release bundle
+// @Description  Pins the application's service versions and configuration into an immutable bundle. Services take the version given in versions, else the one deployed in from, else their latest. Creating a bundle identical to an existing one returns it with 200.
+// @Tags         releases
+// @Accept       json
+// @Produce      json
+// @Param        app_name  path      string                 true   "Application name"
+// @Param        request   body      bundles.CreateRequest  false  "Source environment and explicit versions"
+// @Success      201       {object}  bundles.Bundle
+// @Success      200       {object}  bundles.Bundle
+// @Failure      400       {object}  map[string]string
+// @Failure      404       {object}  map[string]string
+// @Failure      503       {object}  map[string]string
+// @Router       /v1/applications/{app_name}/bundles [post]
+func CreateReleaseBundle(w http.ResponseWriter, r *http.Request) {
+	if bundleService == nil {
+		WriteJSONError(w, "Release bundles are not available", http.StatusServiceUnavailable)
+		return
+	}
+	var req bundles.CreateRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+	}
+	bundle, created, err := bundleService.Create(r.Context(), chi.URLParam(r, "app_name"), req)
+	if err != nil {
+		writeBundleError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if created {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(bundle)
+}
+
+// ListReleaseBundles godoc
+// @Summary      List an application's release bundles
+// @Description  Returns the application's bundles, oldest first, with the environments each was promoted to
+// @Tags         releases
+// @Produce      json
+// @Param        app_name  path      string  true  "Application name"
+// @Success      200       {array}   bundles.Bundle
+// @Failure      404       {object}  map[string]string
+// @Failure      503       {object}  map[string]string
+// @Router       /v1/applications/{app_name}/bundles [get]
+func ListReleaseBundles(w http.ResponseWriter, r *http.Request) {
+	if bundleService == nil {
+		WriteJSONError(w, "Release bundles are not available", http.StatusServiceUnavailable)
+		return
+	}
+	list, err := bundleService.List(chi.URLParam(r, "app_name"))
+	if err != nil {
+		writeBundleError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// GetReleaseBundle godoc
+// @Summary      Get a release bundle
+// @Tags         releases
+// @Produce      json
+// @Param        bundle_id  path      string  true  "Bundle ID"
+// @Success      200        {object}  bundles.Bundle
+// @Failure      404        {object}  map[string]string
+// @Failure      503        {object}  map[string]string
+// @Router       /v1/bundles/{bundle_id} [get]
+func GetReleaseBundle(w http.ResponseWriter, r *http.Request) {
+	if bundleService == nil {
+		WriteJSONError(w, "Release bundles are not available", http.StatusServiceUnavailable)
+		return
+	}
+	bundle, err := bundleService.Get(chi.URLParam(r, "bundle_id"))
+	if err != nil {
+		writeBundleError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(bundle)
+}
+
+// promoteBundleRequest is the body of a bundle promotion
+type promoteBundleRequest struct {
+	Environment string `json:"environment"`
+}
+
+// PromoteReleaseBundle godoc
+// @Summary      Promote a release bundle
+// @Description  Deploys the bundle's pinned versions, unchanged, to an environment through the deployment gates. The bundle must still match what it pinned and must have reached the environment configured before this one in promotion.soak. Refused promotions are recorded as blocked.
+// @Tags         releases
+// @Accept       json
+// @Produce      json
+// @Param        bundle_id  path      string                true  "Bundle ID"
+// @Param        request    body      promoteBundleRequest  true  "Target environment"
+// @Success      200        {object}  bundles.Promotion
+// @Failure      400        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Failure      409        {object}  map[string]string
+// @Failure      503        {object}  map[string]string
+// @Router       /v1/bundles/{bundle_id}/promote [post]
+func PromoteReleaseBundle(w http.ResponseWriter, r *http.Request) {
+	if bundleService == nil {
+		WriteJSONError(w, "Release bundles are not available", http.StatusServiceUnavailable)
+		return
+	}
+	var req promoteBundleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.Environment == "" {
+		WriteJSONError(w, "environment is required", http.StatusBadRequest)
+		return
+	}
+	promotion, err := bundleService.Promote(r.Context(), chi.URLParam(r, "bundle_id"), req.Environment)
+	if err != nil {
+		writeBundleError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(promotion)
+}
+
+func writeBundleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, bundles.ErrApplicationNotFound), errors.Is(err, bundles.ErrBundleNotFound):
+		WriteJSONError(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, bundles.ErrOutOfOrder), errors.Is(err, bundles.ErrDrift), errors.Is(err, bundles.ErrBlocked):
+		WriteJSONError(w, err.Error(), http.StatusConflict)
+	default:
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+	}
+}
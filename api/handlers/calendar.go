@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krzachariassen/ZTDP/internal/calendar"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// calendarViewDays is the window the calendar shows when no end is given
+const calendarViewDays = 30
+
+// GetChangeCalendar godoc
+// @Summary      Show the change calendar
+// @Description  Returns scheduled deployments, maintenance windows and freeze periods overlapping the window, with the conflicts between them. Freezes and maintenance windows without an application or environment apply to every filter.
+// @Tags         calendar
+// @Produce      json
+// @Param        from         query     string  false  "Window start (RFC3339, default now)"
+// @Param        to           query     string  false  "Window end (RFC3339, default 30 days after from)"
+// @Param        application  query     string  false  "Only entries for this application"
+// @Param        environment  query     string  false  "Only entries for this environment"
+// @Success      200          {object}  calendar.View
+// @Failure      400          {object}  map[string]string
+// @Router       /v1/calendar [get]
+func GetChangeCalendar(w http.ResponseWriter, r *http.Request) {
+	from := time.Now().UTC()
+	if value := r.URL.Query().Get("from"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			WriteJSONError(w, "from must be an RFC3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		from = parsed
+	}
+	to := from.AddDate(0, 0, calendarViewDays)
+	if value := r.URL.Query().Get("to"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			WriteJSONError(w, "to must be an RFC3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		to = parsed
+	}
+	if !to.After(from) {
+		WriteJSONError(w, "to must be after from", http.StatusBadRequest)
+		return
+	}
+
+	view, err := calendar.NewService(requestGraph(r)).View(calendar.Filter{
+		From:        from,
+		To:          to,
+		Application: r.URL.Query().Get("application"),
+		Environment: r.URL.Query().Get("environment"),
+	})
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(view)
+}
+
+// ScheduleCalendarEntry godoc
+// @Summary      Schedule a deployment, maintenance window or freeze
+// @Description  Adds the entry to the change calendar. Changes that fall into a freeze are refused with the conflicts; overlapping changes to the same resources are returned alongside the stored entry. With dry_run=true the entry is only checked.
+// @Tags         calendar
+// @Accept       json
+// @Produce      json
+// @Param        entry    body      calendar.Entry  true   "Type, scope and window"
+// @Param        dry_run  query     bool            false  "Check for conflicts without scheduling"
+// @Success      201      {object}  calendar.ScheduleResult
+// @Success      200      {object}  calendar.ScheduleResult
+// @Failure      400      {object}  map[string]string
+// @Failure      409      {object}  calendar.ConflictError
+// @Router       /v1/calendar/entries [post]
+func ScheduleCalendarEntry(w http.ResponseWriter, r *http.Request) {
+	var entry calendar.Entry
+	if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if entry.CreatedBy == "" {
+		entry.CreatedBy = logging.UserIDFromContext(r.Context())
+	}
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+
+	result, err := calendar.NewService(requestGraph(r)).Schedule(entry, dryRun)
+	var conflict *calendar.ConflictError
+	switch {
+	case errors.As(err, &conflict):
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{"error": conflict.Error(), "conflicts": conflict.Conflicts})
+		return
+	case err != nil:
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !dryRun {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(result)
+}
+
+// DeleteCalendarEntry godoc
+// @Summary      Remove a calendar entry
+// @Description  Cancels a scheduled deployment or maintenance window, or lifts a freeze
+// @Tags         calendar
+// @Param        id   path  string  true  "Calendar entry ID"
+// @Success      204
+// @Failure      404  {object}  map[string]string
+// @Router       /v1/calendar/entries/{id} [delete]
+func DeleteCalendarEntry(w http.ResponseWriter, r *http.Request) {
+	err := calendar.NewService(requestGraph(r)).Delete(chi.URLParam(r, "id"))
+	switch {
+	case errors.Is(err, calendar.ErrEntryNotFound):
+		WriteJSONError(w, err.Error(), http.StatusNotFound)
+	case err != nil:
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// GetMaintenanceImpact godoc
+// @Summary      Show who a maintenance window affects
+// @Description  Walks the graph from the window's resources to the services using them, the services consuming those, and their applications, grouped into one notice per owner. The same notices are sent on the event bus (maintenance.impact) when the window is scheduled.
+// @Tags         calendar
+// @Produce      json
+// @Param        id   path      string  true  "Calendar entry ID of a maintenance window"
+// @Success      200  {array}   calendar.Notice
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /v1/calendar/entries/{id}/impact [get]
+func GetMaintenanceImpact(w http.ResponseWriter, r *http.Request) {
+	service := calendar.NewService(requestGraph(r))
+	entry, err := service.Get(chi.URLParam(r, "id"))
+	if errors.Is(err, calendar.ErrEntryNotFound) {
+		WriteJSONError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if entry.Type != calendar.TypeMaintenance {
+		WriteJSONError(w, "only maintenance windows have an impact", http.StatusBadRequest)
+		return
+	}
+	notices, err := service.MaintenanceImpact(*entry)
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(notices)
+}
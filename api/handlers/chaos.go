@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krzachariassen/ZTDP/internal/chaos"
+)
+
+// chaosInjector is nil unless chaos testing is enabled
+var chaosInjector *chaos.Injector
+
+// SetupChaos sets the fault injector used by the chaos endpoints (called from main.go)
+func SetupChaos(injector *chaos.Injector) {
+	chaosInjector = injector
+}
+
+// ListChaosFaults godoc
+// @Summary      List active chaos faults
+// @Description  Returns the injected failures currently affecting events, AI calls and resources
+// @Tags         chaos
+// @Produce      json
+// @Success      200  {array}   chaos.Fault
+// @Failure      503  {object}  map[string]string
+// @Router       /v1/chaos/faults [get]
+func ListChaosFaults(w http.ResponseWriter, r *http.Request) {
+	if chaosInjector == nil {
+		WriteJSONError(w, "Chaos testing is disabled", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(chaosInjector.Faults())
+}
+
+// InjectChaosFault godoc
+// @Summary      Inject a failure
+// @Description  Delays event delivery (event_delay), fails a percentage of AI calls (ai_failure) or marks a resource unhealthy (unhealthy_resource)
+// @Tags         chaos
+// @Accept       json
+// @Produce      json
+// @Param        fault  body      chaos.Fault  true  "Fault definition"
+// @Success      201    {object}  chaos.Fault
+// @Failure      400    {object}  map[string]string
+// @Failure      503    {object}  map[string]string
+// @Router       /v1/chaos/faults [post]
+func InjectChaosFault(w http.ResponseWriter, r *http.Request) {
+	if chaosInjector == nil {
+		WriteJSONError(w, "Chaos testing is disabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	var fault chaos.Fault
+	if err := json.NewDecoder(r.Body).Decode(&fault); err != nil {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	injected, err := chaosInjector.Inject(fault)
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(injected)
+}
+
+// ClearChaosFault godoc
+// @Summary      Clear a chaos fault
+// @Description  Stops an injected failure and restores any resource it marked unhealthy
+// @Tags         chaos
+// @Param        id  path  string  true  "Fault ID"
+// @Success      204
+// @Failure      404  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Router       /v1/chaos/faults/{id} [delete]
+func ClearChaosFault(w http.ResponseWriter, r *http.Request) {
+	if chaosInjector == nil {
+		WriteJSONError(w, "Chaos testing is disabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	if err := chaosInjector.Clear(chi.URLParam(r, "id")); err != nil {
+		if errors.Is(err, chaos.ErrFaultNotFound) {
+			WriteJSONError(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ClearChaosFaults godoc
+// @Summary      Clear all chaos faults
+// @Description  Stops every injected failure
+// @Tags         chaos
+// @Produce      json
+// @Success      200  {object}  map[string]int
+// @Failure      503  {object}  map[string]string
+// @Router       /v1/chaos/faults [delete]
+func ClearChaosFaults(w http.ResponseWriter, r *http.Request) {
+	if chaosInjector == nil {
+		WriteJSONError(w, "Chaos testing is disabled", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"cleared": chaosInjector.ClearAll()})
+}
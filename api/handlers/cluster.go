@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+	"github.com/krzachariassen/ZTDP/internal/cluster"
+)
+
+var (
+	clusterElector  *cluster.Elector
+	clusterRegistry agentRegistry.AgentRegistry
+)
+
+// SetupCluster sets the elector and agent registry reported by the cluster endpoint (called from main.go)
+func SetupCluster(elector *cluster.Elector, registry agentRegistry.AgentRegistry) {
+	clusterElector = elector
+	clusterRegistry = registry
+}
+
+// ClusterStatus is one instance's view of the cluster. With clustering enabled every instance
+// lists the agents of all instances, so their views only differ while an entry expires.
+type ClusterStatus struct {
+	cluster.Status
+	Agents []ClusterAgent `json:"agents"`
+}
+
+// ClusterAgent is an agent and the instances running it
+type ClusterAgent struct {
+	ID           string   `json:"id"`
+	Capabilities []string `json:"capabilities"`
+	Instances    []string `json:"instances"`
+}
+
+// GetClusterStatus godoc
+// @Summary      Cluster leadership and agents
+// @Description  Reports this instance, the leader running singleton duties (scheduled backups, pruning) and the agents of every instance
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  ClusterStatus
+// @Failure      503  {object}  map[string]string
+// @Router       /v1/admin/cluster [get]
+func GetClusterStatus(w http.ResponseWriter, r *http.Request) {
+	if clusterElector == nil {
+		WriteJSONError(w, "Cluster status is not available", http.StatusServiceUnavailable)
+		return
+	}
+	status, err := clusterElector.Status(r.Context())
+	if err != nil {
+		WriteJSONError(w, "Failed to read cluster lease: "+err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	response := ClusterStatus{Status: status, Agents: []ClusterAgent{}}
+	if shared, ok := clusterRegistry.(*agentRegistry.SharedAgentRegistry); ok {
+		agents, err := shared.SharedAgents(r.Context())
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		for _, agent := range agents {
+			if n := len(response.Agents); n > 0 && response.Agents[n-1].ID == agent.ID {
+				response.Agents[n-1].Instances = append(response.Agents[n-1].Instances, agent.Instance)
+				continue
+			}
+			response.Agents = append(response.Agents, clusterAgent(agent.ID, agent.Capabilities, agent.Instance))
+		}
+	} else if clusterRegistry != nil {
+		statuses, err := clusterRegistry.ListAllAgents(r.Context())
+		if err != nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for _, agentStatus := range statuses {
+			agent, err := clusterRegistry.FindAgentByID(r.Context(), agentStatus.ID)
+			if err != nil {
+				continue
+			}
+			response.Agents = append(response.Agents, clusterAgent(agentStatus.ID, agent.GetCapabilities(), status.Instance))
+		}
+		sort.Slice(response.Agents, func(i, j int) bool { return response.Agents[i].ID < response.Agents[j].ID })
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// clusterAgent lists an agent's capability names in order
+func clusterAgent(id string, capabilities []agentRegistry.AgentCapability, instance string) ClusterAgent {
+	agent := ClusterAgent{ID: id, Capabilities: []string{}, Instances: []string{instance}}
+	for _, capability := range capabilities {
+		agent.Capabilities = append(agent.Capabilities, capability.Name)
+	}
+	sort.Strings(agent.Capabilities)
+	return agent
+}
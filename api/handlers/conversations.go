@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krzachariassen/ZTDP/internal/conversations"
+)
+
+// conversationService is nil when transcript storage is disabled
+var conversationService *conversations.Service
+
+// SetupConversations sets the transcript service used by the conversation endpoints (called from main.go)
+func SetupConversations(service *conversations.Service) {
+	conversationService = service
+}
+
+// ListConversations godoc
+// @Summary      List conversation transcripts
+// @Description  Returns stored chat transcripts, most recently updated first
+// @Tags         conversations
+// @Produce      json
+// @Param        entity    query     string  false  "Only conversations that referenced this graph node"
+// @Param        tenant    query     string  false  "Only conversations of this tenant"
+// @Param        since     query     string  false  "RFC3339 timestamp; only conversations updated after it"
+// @Param        limit     query     int     false  "Maximum number of transcripts"
+// @Param        archived  query     bool    false  "Also search transcripts moved to the archive"
+// @Success      200       {array}   conversations.Transcript
+// @Failure      400       {object}  map[string]string
+// @Failure      503       {object}  map[string]string
+// @Router       /v1/conversations [get]
+func ListConversations(w http.ResponseWriter, r *http.Request) {
+	if conversationService == nil {
+		WriteJSONError(w, "Conversation storage is disabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := conversations.ListFilter{
+		Entity:          query.Get("entity"),
+		Tenant:          query.Get("tenant"),
+		IncludeArchived: query.Get("archived") == "true",
+	}
+	if v := query.Get("since"); v != "" {
+		since, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			WriteJSONError(w, "since must be an RFC3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		filter.Since = since
+	}
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			WriteJSONError(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	transcripts, err := conversationService.List(filter)
+	if err != nil {
+		WriteJSONError(w, "Failed to list conversations", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transcripts)
+}
+
+// GetConversation godoc
+// @Summary      Get a conversation transcript
+// @Description  Returns every turn of a conversation: messages, intents, selected agents, responses and actions
+// @Tags         conversations
+// @Produce      json
+// @Param        id   path      string  true  "Conversation ID"
+// @Success      200  {object}  conversations.Transcript
+// @Failure      404  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Router       /v1/conversations/{id} [get]
+func GetConversation(w http.ResponseWriter, r *http.Request) {
+	if conversationService == nil {
+		WriteJSONError(w, "Conversation storage is disabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	transcript, err := conversationService.Get(chi.URLParam(r, "id"))
+	if err != nil {
+		writeConversationError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transcript)
+}
+
+// DeleteConversation godoc
+// @Summary      Delete a conversation transcript
+// @Description  Permanently deletes a transcript, e.g. to honour a data removal request
+// @Tags         conversations
+// @Param        id  path  string  true  "Conversation ID"
+// @Success      204
+// @Failure      404  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Router       /v1/conversations/{id} [delete]
+func DeleteConversation(w http.ResponseWriter, r *http.Request) {
+	if conversationService == nil {
+		WriteJSONError(w, "Conversation storage is disabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	if err := conversationService.Delete(chi.URLParam(r, "id")); err != nil {
+		writeConversationError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// feedbackRequest is the body of a feedback submission
+type feedbackRequest struct {
+	Rating  conversations.Rating `json:"rating"`            // up or down
+	Comment string               `json:"comment,omitempty"` // what was wrong, or what the answer should have been
+	Turn    *int                 `json:"turn,omitempty"`    // index of the rated turn; defaults to the latest
+}
+
+// ConversationFeedback godoc
+// @Summary      Rate an AI response
+// @Description  Stores a thumbs up or down (plus optional free text) on a turn of the conversation and attaches it to intent analytics
+// @Tags         conversations
+// @Accept       json
+// @Produce      json
+// @Param        id        path      string           true  "Conversation ID"
+// @Param        feedback  body      feedbackRequest  true  "Rating, comment and optional turn index"
+// @Success      201       {object}  conversations.Feedback
+// @Failure      400       {object}  map[string]string
+// @Failure      404       {object}  map[string]string
+// @Failure      503       {object}  map[string]string
+// @Router       /v1/conversations/{id}/feedback [post]
+func ConversationFeedback(w http.ResponseWriter, r *http.Request) {
+	if conversationService == nil {
+		WriteJSONError(w, "Conversation storage is disabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req feedbackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	feedback, err := conversationService.AddFeedback(chi.URLParam(r, "id"), req.Turn, req.Rating, req.Comment)
+	if err != nil {
+		writeConversationError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(feedback)
+}
+
+func writeConversationError(w http.ResponseWriter, err error) {
+	if errors.Is(err, conversations.ErrConversationNotFound) {
+		WriteJSONError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, conversations.ErrInvalidFeedback) {
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/krzachariassen/ZTDP/internal/events"
+)
+
+// ListDeadLetters godoc
+// @Summary      Events dropped instead of delivered
+// @Description  Lists the most recent events agents dropped, e.g. requests whose TTL ran out while they were queued, oldest first
+// @Tags         events
+// @Produce      json
+// @Success      200  {array}   events.DeadLetter
+// @Failure      503  {object}  map[string]string
+// @Router       /v1/events/dead-letters [get]
+func ListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	if events.GlobalEventBus == nil {
+		WriteJSONError(w, "Event bus not available", http.StatusServiceUnavailable)
+		return
+	}
+	letters := events.GlobalEventBus.DeadLetters()
+	if letters == nil {
+		letters = []events.DeadLetter{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(letters)
+}
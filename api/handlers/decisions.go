@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krzachariassen/ZTDP/internal/decisions"
+)
+
+// decisionService records the orchestrator's routing decisions
+var decisionService *decisions.Service
+
+// SetupDecisions sets the service used by the decision endpoints (called from main.go)
+func SetupDecisions(service *decisions.Service) {
+	decisionService = service
+}
+
+// ListDecisions godoc
+// @Summary      List AI routing decisions
+// @Description  Returns how the orchestrator routed chat requests, newest first: the request, the intent the AI detected, the candidate agents, the chosen agent, the reasoning and the confidence
+// @Tags         conversations
+// @Produce      json
+// @Param        agent            query     string  false  "Only decisions that selected this agent"
+// @Param        intent           query     string  false  "Only decisions for this intent"
+// @Param        outcome          query     string  false  "completed, error, timeout, cancelled, needs_clarification or unroutable"
+// @Param        conversation_id  query     string  false  "Only decisions made in this conversation"
+// @Param        since            query     string  false  "RFC3339 timestamp; only decisions made after it"
+// @Param        limit            query     int     false  "Maximum number of decisions"
+// @Param        archived         query     bool    false  "Also search decisions moved to the archive"
+// @Success      200              {array}   decisions.Decision
+// @Failure      400              {object}  map[string]string
+// @Failure      503              {object}  map[string]string
+// @Router       /v1/decisions [get]
+func ListDecisions(w http.ResponseWriter, r *http.Request) {
+	if decisionService == nil {
+		WriteJSONError(w, "Decision recording is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := decisions.Filter{
+		Agent:           query.Get("agent"),
+		Intent:          query.Get("intent"),
+		Outcome:         query.Get("outcome"),
+		ConversationID:  query.Get("conversation_id"),
+		IncludeArchived: query.Get("archived") == "true",
+	}
+	if v := query.Get("since"); v != "" {
+		since, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			WriteJSONError(w, "since must be an RFC3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		filter.Since = since
+	}
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			WriteJSONError(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	list, err := decisionService.List(filter)
+	if err != nil {
+		WriteJSONError(w, "Failed to list decisions", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// GetArbitrationStats godoc
+// @Summary      Summarize agent arbitration
+// @Description  For each agent that bid for requests several agents competed for: how often it was asked, won, declined or did not bid in time, its average confidence and its win rate, most wins first
+// @Tags         conversations
+// @Produce      json
+// @Param        intent    query     string  false  "Only arbitrations for this intent"
+// @Param        since     query     string  false  "RFC3339 timestamp; only arbitrations after it"
+// @Param        archived  query     bool    false  "Also count decisions moved to the archive"
+// @Success      200       {array}   decisions.ArbitrationStats
+// @Failure      400       {object}  map[string]string
+// @Failure      503       {object}  map[string]string
+// @Router       /v1/decisions/arbitration [get]
+func GetArbitrationStats(w http.ResponseWriter, r *http.Request) {
+	if decisionService == nil {
+		WriteJSONError(w, "Decision recording is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := decisions.Filter{
+		Intent:          query.Get("intent"),
+		IncludeArchived: query.Get("archived") == "true",
+	}
+	if v := query.Get("since"); v != "" {
+		since, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			WriteJSONError(w, "since must be an RFC3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		filter.Since = since
+	}
+
+	stats, err := decisionService.ArbitrationStats(filter)
+	if err != nil {
+		WriteJSONError(w, "Failed to summarize arbitration", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// GetDecision godoc
+// @Summary      Get an AI routing decision
+// @Tags         conversations
+// @Produce      json
+// @Param        id   path      string  true  "Decision ID"
+// @Success      200  {object}  decisions.Decision
+// @Failure      404  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Router       /v1/decisions/{id} [get]
+func GetDecision(w http.ResponseWriter, r *http.Request) {
+	if decisionService == nil {
+		WriteJSONError(w, "Decision recording is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	decision, err := decisionService.Get(chi.URLParam(r, "id"))
+	if err != nil {
+		if errors.Is(err, decisions.ErrDecisionNotFound) {
+			WriteJSONError(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		WriteJSONError(w, "Failed to get decision", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(decision)
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krzachariassen/ZTDP/internal/deployments"
+)
+
+// GetDeploymentHistory godoc
+// @Summary      List an application's deployments to an environment
+// @Description  Returns every deployment of the application to the environment, oldest first, each with its status changes, when they happened and who caused them
+// @Tags         deployments
+// @Produce      json
+// @Param        app_name  path      string  true  "Application name"
+// @Param        env       path      string  true  "Environment name"
+// @Success      200       {array}   deployments.DeploymentAttempt
+// @Failure      404       {object}  map[string]string
+// @Router       /v1/applications/{app_name}/deployments/{env}/history [get]
+func GetDeploymentHistory(w http.ResponseWriter, r *http.Request) {
+	service := diffService
+	if service == nil {
+		service = deployments.NewDeploymentService(requestGraph(r), nil)
+	}
+	history, err := service.DeploymentHistory(chi.URLParam(r, "app_name"), chi.URLParam(r, "env"))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			WriteJSONError(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(history)
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krzachariassen/ZTDP/internal/deployments"
+)
+
+// diffService compares applications across environments; it summarizes diffs with AI when configured
+var diffService *deployments.Service
+
+// SetupEnvironmentDiff sets the deployment service used by the diff endpoint (called from main.go)
+func SetupEnvironmentDiff(service *deployments.Service) {
+	diffService = service
+}
+
+// DiffApplicationEnvironments godoc
+// @Summary      Compare an application between environments
+// @Description  Returns the differences in deployed release and service versions, resource bindings and configuration overrides, with a summary (AI-written when an AI provider is configured)
+// @Tags         applications
+// @Produce      json
+// @Param        app_name  path      string  true  "Application name"
+// @Param        from      query     string  true  "Environment to compare from, e.g. staging"
+// @Param        to        query     string  true  "Environment to compare to, e.g. prod"
+// @Success      200       {object}  deployments.EnvironmentDiff
+// @Failure      400       {object}  map[string]string
+// @Failure      404       {object}  map[string]string
+// @Router       /v1/applications/{app_name}/diff [get]
+func DiffApplicationEnvironments(w http.ResponseWriter, r *http.Request) {
+	from, to := r.URL.Query().Get("from"), r.URL.Query().Get("to")
+	if from == "" || to == "" {
+		WriteJSONError(w, "from and to query parameters are required", http.StatusBadRequest)
+		return
+	}
+
+	service := diffService
+	if service == nil {
+		service = deployments.NewDeploymentService(requestGraph(r), nil)
+	}
+	diff, err := service.DiffEnvironments(r.Context(), chi.URLParam(r, "app_name"), from, to)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			WriteJSONError(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(diff)
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krzachariassen/ZTDP/internal/explain"
+)
+
+// explainService narrates entity histories; it uses AI, transcripts and logs when main.go provides them
+var explainService *explain.Service
+
+// SetupExplain sets the service used by the explain endpoint (called from main.go)
+func SetupExplain(service *explain.Service) {
+	explainService = service
+}
+
+// ExplainNode godoc
+// @Summary      Explain how a graph entity reached its current state
+// @Description  Assembles the entity's history (relationships, deployments, policy evaluations, conversations and their logs) and narrates it with citations such as [R3] to the returned records (AI-written when an AI provider is configured)
+// @Tags         graph
+// @Produce      json
+// @Param        nodeID  path      string  true  "Graph node ID"
+// @Success      200     {object}  explain.Explanation
+// @Failure      404     {object}  map[string]string
+// @Router       /v1/explain/{nodeID} [get]
+func ExplainNode(w http.ResponseWriter, r *http.Request) {
+	service := explainService
+	if service == nil {
+		service = explain.NewService(requestGraph(r), nil, nil, nil)
+	}
+	explanation, err := service.Explain(r.Context(), chi.URLParam(r, "nodeID"))
+	if err != nil {
+		if errors.Is(err, explain.ErrNodeNotFound) {
+			WriteJSONError(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(explanation)
+}
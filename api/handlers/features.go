@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krzachariassen/ZTDP/internal/features"
+)
+
+// ListFeatureFlags godoc
+// @Summary      List feature flags
+// @Description  Returns all feature flags stored in the graph
+// @Tags         features
+// @Produce      json
+// @Success      200  {array}   features.Flag
+// @Router       /v1/feature-flags [get]
+func ListFeatureFlags(w http.ResponseWriter, r *http.Request) {
+	flags, err := features.NewService(requestGraph(r)).ListFlags()
+	if err != nil {
+		WriteJSONError(w, "Failed to list feature flags", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(flags)
+}
+
+// GetFeatureFlag godoc
+// @Summary      Get a feature flag
+// @Description  Returns a feature flag by name
+// @Tags         features
+// @Produce      json
+// @Param        name  path      string  true  "Flag name"
+// @Success      200   {object}  features.Flag
+// @Failure      404   {object}  map[string]string
+// @Router       /v1/feature-flags/{name} [get]
+func GetFeatureFlag(w http.ResponseWriter, r *http.Request) {
+	flag, err := features.NewService(requestGraph(r)).GetFlag(chi.URLParam(r, "name"))
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(flag)
+}
+
+// SetFeatureFlag godoc
+// @Summary      Create or update a feature flag
+// @Description  Creates or replaces a feature flag; changes take effect immediately without a redeploy
+// @Tags         features
+// @Accept       json
+// @Produce      json
+// @Param        name  path      string         true  "Flag name"
+// @Param        flag  body      features.Flag  true  "Flag definition"
+// @Success      200   {object}  features.Flag
+// @Failure      400   {object}  map[string]string
+// @Router       /v1/feature-flags/{name} [put]
+func SetFeatureFlag(w http.ResponseWriter, r *http.Request) {
+	var flag features.Flag
+	if err := json.NewDecoder(r.Body).Decode(&flag); err != nil {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	flag.Name = chi.URLParam(r, "name")
+
+	if err := features.NewService(requestGraph(r)).SetFlag(&flag); err != nil {
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(flag)
+}
+
+// DeleteFeatureFlag godoc
+// @Summary      Delete a feature flag
+// @Description  Deletes a feature flag; code consulting it falls back to its built-in default
+// @Tags         features
+// @Param        name  path  string  true  "Flag name"
+// @Success      204
+// @Failure      404  {object}  map[string]string
+// @Router       /v1/feature-flags/{name} [delete]
+func DeleteFeatureFlag(w http.ResponseWriter, r *http.Request) {
+	err := features.NewService(requestGraph(r)).DeleteFlag(chi.URLParam(r, "name"))
+	if errors.Is(err, features.ErrFlagNotFound) {
+		WriteJSONError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// EvaluateFeatureFlag godoc
+// @Summary      Evaluate a feature flag
+// @Description  Shows whether a flag is on for a given conversation, tenant and agent
+// @Tags         features
+// @Produce      json
+// @Param        name             path   string  true   "Flag name"
+// @Param        conversation_id  query  string  false  "Conversation ID"
+// @Param        tenant           query  string  false  "Tenant"
+// @Param        agent_id         query  string  false  "Agent ID"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]string
+// @Router       /v1/feature-flags/{name}/evaluate [get]
+func EvaluateFeatureFlag(w http.ResponseWriter, r *http.Request) {
+	service := features.NewService(requestGraph(r))
+	flag, err := service.GetFlag(chi.URLParam(r, "name"))
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	ec := features.EvaluationContext{
+		ConversationID: r.URL.Query().Get("conversation_id"),
+		Tenant:         r.URL.Query().Get("tenant"),
+		AgentID:        r.URL.Query().Get("agent_id"),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"flag":    flag.Name,
+		"context": ec,
+		"enabled": flag.Evaluate(ec),
+	})
+}
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"net/http"
+
 	"github.com/krzachariassen/ZTDP/internal/agents/orchestrator"
 	"github.com/krzachariassen/ZTDP/internal/graph"
 )
@@ -19,3 +21,9 @@ func SetupGlobalOrchestrator(o *orchestrator.Orchestrator) {
 func GetGlobalOrchestrator() *orchestrator.Orchestrator {
 	return globalOrchestrator
 }
+
+// requestGraph returns the global graph bound to the request, so the changes made for it are
+// attributed to the request's caller
+func requestGraph(r *http.Request) *graph.GlobalGraph {
+	return GlobalGraph.WithContext(r.Context())
+}
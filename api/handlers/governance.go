@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/krzachariassen/ZTDP/internal/governance"
+)
+
+// governanceRegistry holds the mutation policies every graph save is checked against
+var governanceRegistry *governance.Registry
+
+// SetupGovernance sets the registry used by the governance endpoint (called from main.go)
+func SetupGovernance(registry *governance.Registry) {
+	governanceRegistry = registry
+}
+
+// ListMutationPolicies godoc
+// @Summary      List mutation policies
+// @Description  Returns the policies every graph save is checked against, however the change was made, and how many mutations each denied since startup
+// @Tags         admin
+// @Produce      json
+// @Success      200  {array}   governance.PolicyStatus
+// @Failure      503  {object}  map[string]string
+// @Router       /v1/admin/governance/policies [get]
+func ListMutationPolicies(w http.ResponseWriter, r *http.Request) {
+	if governanceRegistry == nil {
+		WriteJSONError(w, "Governance is not enabled", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(governanceRegistry.Policies())
+}
@@ -2,7 +2,12 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krzachariassen/ZTDP/internal/graph"
 )
 
 // GetGraph godoc
@@ -56,3 +61,155 @@ func ReloadGraph(w http.ResponseWriter, r *http.Request) {
 	}
 	json.NewEncoder(w).Encode(response)
 }
+
+// QueryGraph godoc
+// @Summary      Run a structured graph query
+// @Description  Selects nodes of a kind, filters them by field and follows edges step by step. Returns the matching nodes or their count.
+// @Tags         graph
+// @Accept       json
+// @Produce      json
+// @Param        query  body      graph.Query  true  "Structured query"
+// @Success      200    {object}  graph.QueryResult
+// @Failure      400    {object}  map[string]string
+// @Router       /v1/graph/query [post]
+func QueryGraph(w http.ResponseWriter, r *http.Request) {
+	var query graph.Query
+	if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	result, err := GlobalGraph.Query(query)
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// GetNodeDependencies godoc
+// @Summary      Rank a node's dependency chains by criticality
+// @Description  Follows depends_on and uses edges from the node and returns the most critical chain to every node reached, most critical first. A chain's criticality is the product of its edges' weights (edge weight, else its criticality: low 0.25, medium 0.5, high 0.75, critical 1). Direction out lists what the node depends on; in lists what depends on it.
+// @Tags         graph
+// @Produce      json
+// @Param        id         path      string  true   "Node ID"
+// @Param        direction  query     string  false  "out (default) or in"
+// @Param        depth      query     int     false  "Longest chain in edges (default 5)"
+// @Param        limit      query     int     false  "Maximum chains returned (default 20)"
+// @Success      200        {array}   graph.DependencyPath
+// @Failure      400        {object}  map[string]string
+// @Failure      404        {object}  map[string]string
+// @Router       /v1/graph/nodes/{id}/dependencies [get]
+func GetNodeDependencies(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	bounds := map[string]int{}
+	for _, name := range []string{"depth", "limit"} {
+		if v := query.Get(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				WriteJSONError(w, name+" must be a positive integer", http.StatusBadRequest)
+				return
+			}
+			bounds[name] = n
+		}
+	}
+
+	currentGraph, err := GlobalGraph.Graph()
+	if err != nil {
+		WriteJSONError(w, "Failed to load graph: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	paths, err := currentGraph.DependencyPaths(chi.URLParam(r, "id"), query.Get("direction"), bounds["depth"], bounds["limit"])
+	if errors.Is(err, graph.ErrDependencyNodeNotFound) {
+		WriteJSONError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(paths)
+}
+
+// EdgeCriticalityRequest sets how critical a dependency edge is
+type EdgeCriticalityRequest struct {
+	From        string  `json:"from"`
+	To          string  `json:"to"`
+	Type        string  `json:"type"`                  // depends_on | uses
+	Criticality string  `json:"criticality,omitempty"` // low | medium | high | critical
+	Weight      float64 `json:"weight,omitempty"`      // 0 < weight <= 1; overrides the criticality's weight
+}
+
+// SetEdgeCriticality godoc
+// @Summary      Set the criticality of a dependency edge
+// @Description  Sets the criticality and/or weight of a depends_on or uses edge, used to rank dependency chains for impact prediction and troubleshooting. Omitting both resets the edge to the medium default.
+// @Tags         graph
+// @Accept       json
+// @Produce      json
+// @Param        request  body      EdgeCriticalityRequest  true  "Edge and criticality"
+// @Success      200      {object}  EdgeCriticalityRequest
+// @Failure      400      {object}  map[string]string
+// @Router       /v1/graph/edges/criticality [put]
+func SetEdgeCriticality(w http.ResponseWriter, r *http.Request) {
+	var req EdgeCriticalityRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.From == "" || req.To == "" || req.Type == "" {
+		WriteJSONError(w, "from, to and type are required", http.StatusBadRequest)
+		return
+	}
+	if err := requestGraph(r).SetEdgeCriticality(req.From, req.To, req.Type, req.Criticality, req.Weight); err != nil {
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(req)
+}
+
+// ExportApplicationGraph godoc
+// @Summary      Export an application's graph as a diagram
+// @Description  Renders the application, the services and resources it owns and what they point at (environments, resource types, other applications' services) as Mermaid or Graphviz DOT, for embedding live architecture diagrams in documentation
+// @Tags         applications
+// @Produce      plain
+// @Param        app_name  path      string  true   "Application name"
+// @Param        format    query     string  false  "mermaid (default) or dot"
+// @Success      200       {string}  string
+// @Failure      400       {object}  map[string]string
+// @Failure      404       {object}  map[string]string
+// @Router       /v1/applications/{app_name}/graph/export [get]
+func ExportApplicationGraph(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = graph.ExportMermaid
+	}
+	contentType, ok := map[string]string{
+		graph.ExportMermaid: "text/vnd.mermaid; charset=utf-8",
+		graph.ExportDOT:     "text/vnd.graphviz; charset=utf-8",
+	}[format]
+	if !ok {
+		WriteJSONError(w, "format must be mermaid or dot", http.StatusBadRequest)
+		return
+	}
+
+	currentGraph, err := GlobalGraph.Graph()
+	if err != nil {
+		WriteJSONError(w, "failed to load graph from backend", http.StatusInternalServerError)
+		return
+	}
+	appName := chi.URLParam(r, "app_name")
+	sub, err := currentGraph.ApplicationSubgraph(appName)
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	diagram, err := sub.Export(format, appName)
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Write([]byte(diagram))
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/krzachariassen/ZTDP/internal/graphstats"
+)
+
+// graphStats computes the graph statistics periodically
+var graphStats *graphstats.Collector
+
+// SetupGraphStats sets the collector used by the statistics endpoints (called from main.go)
+func SetupGraphStats(collector *graphstats.Collector) {
+	graphStats = collector
+}
+
+// GetGraphStats godoc
+// @Summary      Graph statistics
+// @Description  Returns nodes per kind, edges per type, orphaned services, versions and resources, the largest applications, growth over the configured window and growth anomalies
+// @Tags         graph
+// @Produce      json
+// @Success      200  {object}  graphstats.Stats
+// @Failure      503  {object}  map[string]string
+// @Router       /v1/graph/stats [get]
+func GetGraphStats(w http.ResponseWriter, r *http.Request) {
+	if graphStats == nil {
+		WriteJSONError(w, "Graph statistics are not configured", http.StatusServiceUnavailable)
+		return
+	}
+	stats, err := graphStats.Latest()
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// Metrics godoc
+// @Summary      Prometheus metrics
+// @Description  Exposes the graph statistics in the Prometheus text format
+// @Tags         system
+// @Produce      plain
+// @Success      200  {string}  string
+// @Failure      503  {object}  map[string]string
+// @Router       /metrics [get]
+func Metrics(w http.ResponseWriter, r *http.Request) {
+	if graphStats == nil {
+		WriteJSONError(w, "Graph statistics are not configured", http.StatusServiceUnavailable)
+		return
+	}
+	stats, err := graphStats.Latest()
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	stats.WritePrometheus(w)
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krzachariassen/ZTDP/internal/hooks"
+)
+
+// hookRegistry holds the lifecycle hooks the graph backend calls
+var hookRegistry *hooks.Registry
+
+// SetupHooks sets the registry used by the hook admin endpoints (called from main.go)
+func SetupHooks(registry *hooks.Registry) {
+	hookRegistry = registry
+}
+
+// ListHooks godoc
+// @Summary      List lifecycle hooks
+// @Description  Returns the webhooks called before (pre) or after (post) nodes are created, updated or deleted
+// @Tags         admin
+// @Produce      json
+// @Success      200  {array}   hooks.Hook
+// @Failure      503  {object}  map[string]string
+// @Router       /v1/admin/hooks [get]
+func ListHooks(w http.ResponseWriter, r *http.Request) {
+	if hookRegistry == nil {
+		WriteJSONError(w, "Hooks are not available", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hookRegistry.List())
+}
+
+// GetHook godoc
+// @Summary      Get a lifecycle hook
+// @Tags         admin
+// @Produce      json
+// @Param        name  path      string  true  "Hook name"
+// @Success      200   {object}  hooks.Hook
+// @Failure      404   {object}  map[string]string
+// @Failure      503   {object}  map[string]string
+// @Router       /v1/admin/hooks/{name} [get]
+func GetHook(w http.ResponseWriter, r *http.Request) {
+	if hookRegistry == nil {
+		WriteJSONError(w, "Hooks are not available", http.StatusServiceUnavailable)
+		return
+	}
+	hook, err := hookRegistry.Get(chi.URLParam(r, "name"))
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hook)
+}
+
+// PutHook godoc
+// @Summary      Register a lifecycle hook
+// @Description  Creates or replaces a hook. Pre hooks are called synchronously and may deny the change by answering {"allowed": false, "reason": "..."}; with failure_policy block (the default) a denial, error or timeout rejects it, with warn it is only logged. Post hooks are notified asynchronously after the change is saved.
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        name  path      string      true  "Hook name"
+// @Param        hook  body      hooks.Hook  true  "Hook definition"
+// @Success      200   {object}  hooks.Hook
+// @Failure      400   {object}  map[string]string
+// @Failure      503   {object}  map[string]string
+// @Router       /v1/admin/hooks/{name} [put]
+func PutHook(w http.ResponseWriter, r *http.Request) {
+	if hookRegistry == nil {
+		WriteJSONError(w, "Hooks are not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	var hook hooks.Hook
+	if err := json.NewDecoder(r.Body).Decode(&hook); err != nil {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	hook.Name = chi.URLParam(r, "name")
+	registered, err := hookRegistry.Register(hook)
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(registered)
+}
+
+// DeleteHook godoc
+// @Summary      Remove a lifecycle hook
+// @Tags         admin
+// @Param        name  path  string  true  "Hook name"
+// @Success      204
+// @Failure      404  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Router       /v1/admin/hooks/{name} [delete]
+func DeleteHook(w http.ResponseWriter, r *http.Request) {
+	if hookRegistry == nil {
+		WriteJSONError(w, "Hooks are not available", http.StatusServiceUnavailable)
+		return
+	}
+	if err := hookRegistry.Delete(chi.URLParam(r, "name")); err != nil {
+		if errors.Is(err, hooks.ErrHookNotFound) {
+			WriteJSONError(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/krzachariassen/ZTDP/internal/integrity"
+)
+
+// ValidateGraph godoc
+// @Summary      Check graph integrity
+// @Description  Scans the graph for dangling edges, orphan resource instances, nodes that violate their contract and cycles in owns/depends_on edges, and proposes a repair for each issue. POST with repair=true applies the repairs marked safe (removing dangling edges, relinking orphans to their application).
+// @Tags         admin
+// @Produce      json
+// @Param        repair  query     bool  false  "Apply safe repairs (POST only)"
+// @Success      200     {object}  integrity.Report
+// @Failure      500     {object}  map[string]string
+// @Router       /v1/admin/graph/validate [get]
+// @Router       /v1/admin/graph/validate [post]
+func ValidateGraph(w http.ResponseWriter, r *http.Request) {
+	repair := r.Method == http.MethodPost && r.URL.Query().Get("repair") == "true"
+	report, err := integrity.NewService(requestGraph(r)).Validate(repair)
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,309 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/kinds"
+)
+
+// kindService stores user-defined node kinds and their nodes
+var kindService *kinds.Service
+
+// SetupKinds sets the service used by the custom kind endpoints (called from main.go)
+func SetupKinds(service *kinds.Service) {
+	kindService = service
+}
+
+// ListKinds godoc
+// @Summary      List custom node kinds
+// @Description  Returns the user-defined node kinds with their schemas, relationships, hooks and AI context settings
+// @Tags         kinds
+// @Produce      json
+// @Success      200  {array}   kinds.Definition
+// @Failure      503  {object}  map[string]string
+// @Router       /v1/kinds [get]
+func ListKinds(w http.ResponseWriter, r *http.Request) {
+	if kindService == nil {
+		WriteJSONError(w, "Custom kinds are not available", http.StatusServiceUnavailable)
+		return
+	}
+	defs, err := kindService.List()
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(defs)
+}
+
+// GetKind godoc
+// @Summary      Get a custom node kind
+// @Tags         kinds
+// @Produce      json
+// @Param        kind  path      string  true  "Kind name"
+// @Success      200   {object}  kinds.Definition
+// @Failure      404   {object}  map[string]string
+// @Failure      503   {object}  map[string]string
+// @Router       /v1/kinds/{kind} [get]
+func GetKind(w http.ResponseWriter, r *http.Request) {
+	if kindService == nil {
+		WriteJSONError(w, "Custom kinds are not available", http.StatusServiceUnavailable)
+		return
+	}
+	def, err := kindService.Get(chi.URLParam(r, "kind"))
+	if err != nil {
+		writeKindError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(def)
+}
+
+// PutKind godoc
+// @Summary      Register a custom node kind
+// @Description  Creates or replaces a node kind. Node specs must match the JSON schema (type, properties, required, additionalProperties, items, enum, minimum, maximum, minLength, maxLength, pattern); relationships allow edges to and from other kinds; hooks are registered as lifecycle hooks for the kind; ai_context.exclude keeps the kind's nodes out of AI prompts and ai_context.fields lists the spec fields the AI sees. A new schema must accept the kind's existing nodes.
+// @Tags         kinds
+// @Accept       json
+// @Produce      json
+// @Param        kind        path      string            true  "Kind name"
+// @Param        definition  body      kinds.Definition  true  "Kind definition"
+// @Success      200         {object}  kinds.Definition
+// @Failure      400         {object}  map[string]string
+// @Failure      503         {object}  map[string]string
+// @Router       /v1/kinds/{kind} [put]
+func PutKind(w http.ResponseWriter, r *http.Request) {
+	if kindService == nil {
+		WriteJSONError(w, "Custom kinds are not available", http.StatusServiceUnavailable)
+		return
+	}
+	var def kinds.Definition
+	if err := json.NewDecoder(r.Body).Decode(&def); err != nil {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	def.Name = chi.URLParam(r, "kind")
+	registered, err := kindService.Register(def)
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(registered)
+}
+
+// DeleteKind godoc
+// @Summary      Remove a custom node kind
+// @Description  Removes a kind with its edge rules and hooks; kinds that still have nodes are refused
+// @Tags         kinds
+// @Param        kind  path  string  true  "Kind name"
+// @Success      204
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Router       /v1/kinds/{kind} [delete]
+func DeleteKind(w http.ResponseWriter, r *http.Request) {
+	if kindService == nil {
+		WriteJSONError(w, "Custom kinds are not available", http.StatusServiceUnavailable)
+		return
+	}
+	if err := kindService.Delete(chi.URLParam(r, "kind")); err != nil {
+		writeKindError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListKindNodes godoc
+// @Summary      List the nodes of a custom kind
+// @Tags         kinds
+// @Produce      json
+// @Param        kind  path      string  true  "Kind name"
+// @Success      200   {array}   graph.Node
+// @Failure      404   {object}  map[string]string
+// @Failure      503   {object}  map[string]string
+// @Router       /v1/kinds/{kind}/nodes [get]
+func ListKindNodes(w http.ResponseWriter, r *http.Request) {
+	if kindService == nil {
+		WriteJSONError(w, "Custom kinds are not available", http.StatusServiceUnavailable)
+		return
+	}
+	kind := chi.URLParam(r, "kind")
+	if _, err := kindService.Get(kind); err != nil {
+		writeKindError(w, err)
+		return
+	}
+	nodes, err := kindService.Nodes(kind)
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(nodes)
+}
+
+// CreateKindNode godoc
+// @Summary      Create a node of a custom kind
+// @Description  Adds a node whose spec is checked against the kind's schema; the kind's pre hooks may still veto it
+// @Tags         kinds
+// @Accept       json
+// @Produce      json
+// @Param        kind  path      string      true  "Kind name"
+// @Param        node  body      graph.Node  true  "Node with id, metadata and spec"
+// @Success      201   {object}  graph.Node
+// @Failure      400   {object}  map[string]string
+// @Failure      404   {object}  map[string]string
+// @Failure      503   {object}  map[string]string
+// @Router       /v1/kinds/{kind}/nodes [post]
+func CreateKindNode(w http.ResponseWriter, r *http.Request) {
+	if kindService == nil {
+		WriteJSONError(w, "Custom kinds are not available", http.StatusServiceUnavailable)
+		return
+	}
+	var node graph.Node
+	if err := json.NewDecoder(r.Body).Decode(&node); err != nil {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	created, err := kindService.CreateNode(chi.URLParam(r, "kind"), node)
+	if err != nil {
+		writeKindError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// GetKindNode godoc
+// @Summary      Get a node of a custom kind
+// @Tags         kinds
+// @Produce      json
+// @Param        kind  path      string  true  "Kind name"
+// @Param        id    path      string  true  "Node ID"
+// @Success      200   {object}  graph.Node
+// @Failure      404   {object}  map[string]string
+// @Failure      503   {object}  map[string]string
+// @Router       /v1/kinds/{kind}/nodes/{id} [get]
+func GetKindNode(w http.ResponseWriter, r *http.Request) {
+	if kindService == nil {
+		WriteJSONError(w, "Custom kinds are not available", http.StatusServiceUnavailable)
+		return
+	}
+	node, err := kindService.GetNode(chi.URLParam(r, "kind"), chi.URLParam(r, "id"))
+	if err != nil {
+		writeKindError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(node)
+}
+
+// UpdateKindNode godoc
+// @Summary      Replace a node of a custom kind
+// @Tags         kinds
+// @Accept       json
+// @Produce      json
+// @Param        kind  path      string      true  "Kind name"
+// @Param        id    path      string      true  "Node ID"
+// @Param        node  body      graph.Node  true  "Node metadata and spec"
+// @Success      200   {object}  graph.Node
+// @Failure      400   {object}  map[string]string
+// @Failure      404   {object}  map[string]string
+// @Failure      503   {object}  map[string]string
+// @Router       /v1/kinds/{kind}/nodes/{id} [put]
+func UpdateKindNode(w http.ResponseWriter, r *http.Request) {
+	if kindService == nil {
+		WriteJSONError(w, "Custom kinds are not available", http.StatusServiceUnavailable)
+		return
+	}
+	var node graph.Node
+	if err := json.NewDecoder(r.Body).Decode(&node); err != nil {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	node.ID = chi.URLParam(r, "id")
+	updated, err := kindService.UpdateNode(chi.URLParam(r, "kind"), node)
+	if err != nil {
+		writeKindError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
+
+// DeleteKindNode godoc
+// @Summary      Delete a node of a custom kind
+// @Tags         kinds
+// @Param        kind  path  string  true  "Kind name"
+// @Param        id    path  string  true  "Node ID"
+// @Success      204
+// @Failure      404  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Router       /v1/kinds/{kind}/nodes/{id} [delete]
+func DeleteKindNode(w http.ResponseWriter, r *http.Request) {
+	if kindService == nil {
+		WriteJSONError(w, "Custom kinds are not available", http.StatusServiceUnavailable)
+		return
+	}
+	if err := kindService.DeleteNode(chi.URLParam(r, "kind"), chi.URLParam(r, "id")); err != nil {
+		writeKindError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// linkKindNodeRequest is the body of an edge to or from a custom node
+type linkKindNodeRequest struct {
+	Node      string `json:"node"`                // the other end of the edge
+	Type      string `json:"type"`                // edge type
+	Direction string `json:"direction,omitempty"` // out (default): custom node -> node; in: node -> custom node
+}
+
+// LinkKindNode godoc
+// @Summary      Link a node of a custom kind
+// @Description  Adds an edge between the node and another node; the edge must be allowed by a relationship of either kind
+// @Tags         kinds
+// @Accept       json
+// @Param        kind     path  string               true  "Kind name"
+// @Param        id       path  string               true  "Node ID"
+// @Param        request  body  linkKindNodeRequest  true  "Other node, edge type and direction"
+// @Success      204
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Router       /v1/kinds/{kind}/nodes/{id}/edges [post]
+func LinkKindNode(w http.ResponseWriter, r *http.Request) {
+	if kindService == nil {
+		WriteJSONError(w, "Custom kinds are not available", http.StatusServiceUnavailable)
+		return
+	}
+	var req linkKindNodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.Node == "" || req.Type == "" {
+		WriteJSONError(w, "node and type are required", http.StatusBadRequest)
+		return
+	}
+	if err := kindService.Link(chi.URLParam(r, "kind"), chi.URLParam(r, "id"), req.Node, req.Type, req.Direction); err != nil {
+		writeKindError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeKindError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, kinds.ErrKindNotFound), errors.Is(err, kinds.ErrNodeNotFound):
+		WriteJSONError(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, kinds.ErrKindInUse):
+		WriteJSONError(w, err.Error(), http.StatusConflict)
+	default:
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+	}
+}
@@ -1,13 +1,17 @@
 package handlers
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/krzachariassen/ZTDP/internal/deployments"
 	"github.com/krzachariassen/ZTDP/internal/events"
 	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/krzachariassen/ZTDP/internal/redaction"
 )
 
 // WebSocket upgrader
@@ -21,6 +25,19 @@ var upgrader = websocket.Upgrader{
 // Global real-time log sink for WebSocket broadcasting
 var realtimeLogSink *logging.RealtimeLogSink
 
+// Retained log history served by GET /v1/logs
+var logStore logging.LogStore
+
+const (
+	defaultLogQueryLimit = 100
+	maxLogQueryLimit     = 1000
+)
+
+// SetupLogStore sets the log store used by the log query endpoint (called from main.go)
+func SetupLogStore(store logging.LogStore) {
+	logStore = store
+}
+
 // InitLogManager initializes the log manager and sets up event subscriptions
 func InitLogManager() {
 	// Get the real-time sink from the global logger
@@ -51,6 +68,9 @@ func subscribeToEvents() {
 // createEventHandler creates a generic event handler for the simplified event system
 func createEventHandler(logger *logging.Logger, component, message string) events.EventHandler {
 	return func(event events.Event) error {
+		// Payloads can carry graph metadata and user messages, so mask secrets before logging
+		payload := redaction.Default().Map(event.Payload)
+
 		// Use the centralized logger with appropriate context
 		eventLogger := logger.
 			ForComponent(component).
@@ -59,7 +79,7 @@ func createEventHandler(logger *logging.Logger, component, message string) event
 			WithContext("event_subject", event.Subject)
 
 		// Add payload as properties
-		for k, v := range event.Payload {
+		for k, v := range payload {
 			eventLogger = eventLogger.WithContext(k, v)
 		}
 
@@ -72,7 +92,7 @@ func createEventHandler(logger *logging.Logger, component, message string) event
 				"type":    string(event.Type),
 				"source":  event.Source,
 				"subject": event.Subject,
-				"payload": event.Payload,
+				"payload": payload,
 			},
 		}
 
@@ -149,6 +169,16 @@ func createEventHandler(logger *logging.Logger, component, message string) event
 				logEntry["event_category"] = "Application Updated"
 				eventLogger.Info("📝 %s: %s", message, event.Subject)
 			}
+		} else if event.Subject == deployments.ProgressSubject {
+			// Step-level deployment progress drives live progress bars in connected clients
+			logEntry["message"] = fmt.Sprintf("⏳ Deployment %v → %v: %v %v (%v/%v, %.0f%%)",
+				event.Payload["application"], event.Payload["environment"], event.Payload["step"], event.Payload["state"],
+				event.Payload["step_index"], event.Payload["total_steps"], event.Payload["percent"])
+			logEntry["event_category"] = "Deployment Progress"
+			if event.Payload["state"] == string(deployments.StepFailed) {
+				logEntry["level"] = "ERROR"
+			}
+			eventLogger.Info("%s", logEntry["message"])
 		} else if eventType == "application.deleted" {
 			logEntry["message"] = fmt.Sprintf("🗑️ %s: %s", message, event.Subject)
 			logEntry["event_category"] = "Application Deleted"
@@ -245,3 +275,92 @@ func LogsWebSocket(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 }
+
+// QueryLogs godoc
+// @Summary      Query retained logs
+// @Description  Returns retained log entries in chronological order, filtered by component, minimum level, correlation ID and time range
+// @Tags         logs
+// @Produce      json
+// @Param        component       query  string  false  "Component name"
+// @Param        level           query  string  false  "Minimum level (trace, debug, info, warn, error)"
+// @Param        correlation_id  query  string  false  "Correlation ID"
+// @Param        since           query  string  false  "RFC3339 timestamp or duration ago (e.g. 15m)"
+// @Param        until           query  string  false  "RFC3339 timestamp or duration ago"
+// @Param        limit           query  int     false  "Most recent entries to return (default 100, max 1000)"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      400  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Router       /v1/logs [get]
+func QueryLogs(w http.ResponseWriter, r *http.Request) {
+	if logStore == nil {
+		WriteJSONError(w, "Log retention not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	query, err := parseLogQuery(r)
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	entries, err := logStore.Query(r.Context(), query)
+	if err != nil {
+		WriteJSONError(w, "Failed to query logs: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if entries == nil {
+		entries = []logging.LogEntry{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"logs":  entries,
+		"count": len(entries),
+	})
+}
+
+// parseLogQuery builds a log query from URL parameters
+func parseLogQuery(r *http.Request) (logging.LogQuery, error) {
+	params := r.URL.Query()
+	query := logging.LogQuery{
+		Component:     params.Get("component"),
+		CorrelationID: params.Get("correlation_id"),
+		Limit:         defaultLogQueryLimit,
+	}
+
+	if v := params.Get("level"); v != "" {
+		level, err := logging.ParseLevel(v)
+		if err != nil {
+			return query, err
+		}
+		query.MinLevel = &level
+	}
+
+	var err error
+	if query.Since, err = parseLogTime(params.Get("since")); err != nil {
+		return query, fmt.Errorf("invalid since: %w", err)
+	}
+	if query.Until, err = parseLogTime(params.Get("until")); err != nil {
+		return query, fmt.Errorf("invalid until: %w", err)
+	}
+
+	if v := params.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return query, fmt.Errorf("invalid limit: %q", v)
+		}
+		query.Limit = min(limit, maxLogQueryLimit)
+	}
+	return query, nil
+}
+
+// parseLogTime accepts an RFC3339 timestamp or a duration meaning "this long ago"
+func parseLogTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if ago, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-ago), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
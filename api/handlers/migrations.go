@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krzachariassen/ZTDP/internal/migrations"
+)
+
+// migrationService tracks the schema migrations service versions ship
+var migrationService *migrations.Service
+
+// SetupMigrations sets the service used by the migration endpoints (called from main.go)
+func SetupMigrations(service *migrations.Service) {
+	migrationService = service
+}
+
+// DeclareMigration godoc
+// @Summary      Declare a schema migration for a service version
+// @Description  Adds a migration the version ships. Migrations without a down_uri are irreversible; environments in migrations.require_reversible refuse deployments that would run them.
+// @Tags         migrations
+// @Accept       json
+// @Produce      json
+// @Param        app_name     path      string                true  "Application name"
+// @Param        service_name path      string                true  "Service name"
+// @Param        version      path      string                true  "Version"
+// @Param        migration    body      migrations.Migration  true  "Migration with name, order, up_uri and optional down_uri"
+// @Success      201          {object}  migrations.Migration
+// @Failure      400          {object}  map[string]string
+// @Failure      404          {object}  map[string]string
+// @Failure      503          {object}  map[string]string
+// @Router       /v1/applications/{app_name}/services/{service_name}/versions/{version}/migrations [post]
+func DeclareMigration(w http.ResponseWriter, r *http.Request) {
+	if migrationService == nil {
+		WriteJSONError(w, "Migrations are not available", http.StatusServiceUnavailable)
+		return
+	}
+	var migration migrations.Migration
+	if err := json.NewDecoder(r.Body).Decode(&migration); err != nil {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	migration.Service = chi.URLParam(r, "service_name")
+	migration.Version = chi.URLParam(r, "version")
+	declared, err := migrationService.Declare(migration)
+	if err != nil {
+		writeMigrationError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(declared)
+}
+
+// ListVersionMigrations godoc
+// @Summary      List the schema migrations of a service version
+// @Description  Returns the version's migrations in the order they run, with their status per environment
+// @Tags         migrations
+// @Produce      json
+// @Param        app_name     path      string  true  "Application name"
+// @Param        service_name path      string  true  "Service name"
+// @Param        version      path      string  true  "Version"
+// @Success      200          {array}   migrations.Migration
+// @Failure      503          {object}  map[string]string
+// @Router       /v1/applications/{app_name}/services/{service_name}/versions/{version}/migrations [get]
+func ListVersionMigrations(w http.ResponseWriter, r *http.Request) {
+	if migrationService == nil {
+		WriteJSONError(w, "Migrations are not available", http.StatusServiceUnavailable)
+		return
+	}
+	list, err := migrationService.List(chi.URLParam(r, "service_name"), chi.URLParam(r, "version"))
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// ListPendingMigrations godoc
+// @Summary      List the migrations a deployment would run
+// @Description  Returns the migrations of the application's services that have not succeeded in the environment, in the order the next deployment runs them
+// @Tags         migrations
+// @Produce      json
+// @Param        app_name     path      string  true  "Application name"
+// @Param        environment  query     string  true  "Environment"
+// @Success      200          {array}   migrations.Migration
+// @Failure      400          {object}  map[string]string
+// @Failure      503          {object}  map[string]string
+// @Router       /v1/applications/{app_name}/migrations [get]
+func ListPendingMigrations(w http.ResponseWriter, r *http.Request) {
+	if migrationService == nil {
+		WriteJSONError(w, "Migrations are not available", http.StatusServiceUnavailable)
+		return
+	}
+	environment := r.URL.Query().Get("environment")
+	if environment == "" {
+		WriteJSONError(w, "environment is required", http.StatusBadRequest)
+		return
+	}
+	pending, err := migrationService.Pending(chi.URLParam(r, "app_name"), environment)
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pending)
+}
+
+// GetMigration godoc
+// @Summary      Get a schema migration
+// @Tags         migrations
+// @Produce      json
+// @Param        id   path      string  true  "Migration ID (service:version:name)"
+// @Success      200  {object}  migrations.Migration
+// @Failure      404  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Router       /v1/migrations/{id} [get]
+func GetMigration(w http.ResponseWriter, r *http.Request) {
+	if migrationService == nil {
+		WriteJSONError(w, "Migrations are not available", http.StatusServiceUnavailable)
+		return
+	}
+	migration, err := migrationService.Get(chi.URLParam(r, "id"))
+	if err != nil {
+		writeMigrationError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(migration)
+}
+
+// migrationExecutionRequest is the body of a migration status report
+type migrationExecutionRequest struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// RecordMigrationExecution godoc
+// @Summary      Record a migration's status in an environment
+// @Description  Stores the outcome reported by whatever ran the migration (pending, running, succeeded, failed or rolled_back) on the migration's node
+// @Tags         migrations
+// @Accept       json
+// @Produce      json
+// @Param        id           path      string                     true  "Migration ID (service:version:name)"
+// @Param        environment  path      string                     true  "Environment"
+// @Param        request      body      migrationExecutionRequest  true  "Status and message"
+// @Success      200          {object}  migrations.Migration
+// @Failure      400          {object}  map[string]string
+// @Failure      404          {object}  map[string]string
+// @Failure      503          {object}  map[string]string
+// @Router       /v1/migrations/{id}/executions/{environment} [put]
+func RecordMigrationExecution(w http.ResponseWriter, r *http.Request) {
+	if migrationService == nil {
+		WriteJSONError(w, "Migrations are not available", http.StatusServiceUnavailable)
+		return
+	}
+	var req migrationExecutionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	migration, err := migrationService.RecordExecution(chi.URLParam(r, "id"), chi.URLParam(r, "environment"), req.Status, req.Message)
+	if err != nil {
+		writeMigrationError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(migration)
+}
+
+func writeMigrationError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, migrations.ErrMigrationNotFound), errors.Is(err, migrations.ErrVersionNotFound):
+		WriteJSONError(w, err.Error(), http.StatusNotFound)
+	default:
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+	}
+}
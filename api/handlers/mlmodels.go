@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krzachariassen/ZTDP/internal/mlmodels"
+)
+
+// mlModelService registers ML models and deploys their serving endpoints
+var mlModelService *mlmodels.Service
+
+// SetupMLModels sets the service used by the ML model endpoints (called from main.go)
+func SetupMLModels(service *mlmodels.Service) {
+	mlModelService = service
+}
+
+// ListMLModels godoc
+// @Summary      List ML models
+// @Description  Returns ML models with their versions, serving endpoints and the version each endpoint serves per environment
+// @Tags         ml-models
+// @Produce      json
+// @Param        application  query     string  false  "Only models owned by this application"
+// @Success      200          {array}   mlmodels.ModelDetails
+// @Failure      503          {object}  map[string]string
+// @Router       /v1/ml/models [get]
+func ListMLModels(w http.ResponseWriter, r *http.Request) {
+	if mlModelService == nil {
+		WriteJSONError(w, "ML models are not available", http.StatusServiceUnavailable)
+		return
+	}
+	models, err := mlModelService.ListModels(r.URL.Query().Get("application"))
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models)
+}
+
+// RegisterMLModel godoc
+// @Summary      Register an ML model
+// @Description  Adds a model owned by an application; resources lists the resources it is trained from
+// @Tags         ml-models
+// @Accept       json
+// @Produce      json
+// @Param        model  body      mlmodels.Model  true  "Model"
+// @Success      201    {object}  mlmodels.Model
+// @Failure      400    {object}  map[string]string
+// @Failure      503    {object}  map[string]string
+// @Router       /v1/ml/models [post]
+func RegisterMLModel(w http.ResponseWriter, r *http.Request) {
+	if mlModelService == nil {
+		WriteJSONError(w, "ML models are not available", http.StatusServiceUnavailable)
+		return
+	}
+	var model mlmodels.Model
+	if err := json.NewDecoder(r.Body).Decode(&model); err != nil {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	registered, err := mlModelService.RegisterModel(model)
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(registered)
+}
+
+// GetMLModel godoc
+// @Summary      Get an ML model
+// @Tags         ml-models
+// @Produce      json
+// @Param        model  path      string  true  "Model name"
+// @Success      200    {object}  mlmodels.ModelDetails
+// @Failure      404    {object}  map[string]string
+// @Failure      503    {object}  map[string]string
+// @Router       /v1/ml/models/{model} [get]
+func GetMLModel(w http.ResponseWriter, r *http.Request) {
+	if mlModelService == nil {
+		WriteJSONError(w, "ML models are not available", http.StatusServiceUnavailable)
+		return
+	}
+	model, err := mlModelService.DescribeModel(chi.URLParam(r, "model"))
+	if err != nil {
+		writeMLModelError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(model)
+}
+
+// RegisterMLModelVersion godoc
+// @Summary      Register a model version
+// @Description  Adds an immutable trained version to a model
+// @Tags         ml-models
+// @Accept       json
+// @Produce      json
+// @Param        model    path      string            true  "Model name"
+// @Param        version  body      mlmodels.Version  true  "Version with artifact_uri and optional metrics"
+// @Success      201      {object}  mlmodels.Version
+// @Failure      400      {object}  map[string]string
+// @Failure      404      {object}  map[string]string
+// @Failure      503      {object}  map[string]string
+// @Router       /v1/ml/models/{model}/versions [post]
+func RegisterMLModelVersion(w http.ResponseWriter, r *http.Request) {
+	if mlModelService == nil {
+		WriteJSONError(w, "ML models are not available", http.StatusServiceUnavailable)
+		return
+	}
+	var version mlmodels.Version
+	if err := json.NewDecoder(r.Body).Decode(&version); err != nil {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	version.Model = chi.URLParam(r, "model")
+	registered, err := mlModelService.RegisterVersion(version)
+	if err != nil {
+		writeMLModelError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(registered)
+}
+
+// CreateMLModelEndpoint godoc
+// @Summary      Create a model serving endpoint
+// @Description  Adds a serving endpoint for the model; it serves nothing until a version is deployed
+// @Tags         ml-models
+// @Accept       json
+// @Produce      json
+// @Param        model     path      string             true  "Model name"
+// @Param        endpoint  body      mlmodels.Endpoint  true  "Endpoint with replicas, accelerator and resources"
+// @Success      201       {object}  mlmodels.Endpoint
+// @Failure      400       {object}  map[string]string
+// @Failure      404       {object}  map[string]string
+// @Failure      503       {object}  map[string]string
+// @Router       /v1/ml/models/{model}/endpoints [post]
+func CreateMLModelEndpoint(w http.ResponseWriter, r *http.Request) {
+	if mlModelService == nil {
+		WriteJSONError(w, "ML models are not available", http.StatusServiceUnavailable)
+		return
+	}
+	var endpoint mlmodels.Endpoint
+	if err := json.NewDecoder(r.Body).Decode(&endpoint); err != nil {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	endpoint.Model = chi.URLParam(r, "model")
+	created, err := mlModelService.CreateEndpoint(endpoint)
+	if err != nil {
+		writeMLModelError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// ListMLModelDeployments godoc
+// @Summary      List where a model endpoint is deployed
+// @Tags         ml-models
+// @Produce      json
+// @Param        endpoint  path      string  true  "Endpoint name"
+// @Success      200       {array}   mlmodels.Deployment
+// @Failure      404       {object}  map[string]string
+// @Failure      503       {object}  map[string]string
+// @Router       /v1/ml/endpoints/{endpoint}/deployments [get]
+func ListMLModelDeployments(w http.ResponseWriter, r *http.Request) {
+	if mlModelService == nil {
+		WriteJSONError(w, "ML models are not available", http.StatusServiceUnavailable)
+		return
+	}
+	deployments, err := mlModelService.Deployments(chi.URLParam(r, "endpoint"))
+	if err != nil {
+		writeMLModelError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deployments)
+}
+
+// deployMLModelRequest is the body of a model endpoint deployment
+type deployMLModelRequest struct {
+	Version     string `json:"version"`
+	Environment string `json:"environment"`
+}
+
+// DeployMLModelEndpoint godoc
+// @Summary      Deploy a model version to an endpoint
+// @Description  Rolls a model version out on the endpoint in an environment through the guardrails, transition policies, deployment gates, resource lifecycles and change calendar. Refused deployments are recorded as blocked.
+// @Tags         ml-models
+// @Accept       json
+// @Produce      json
+// @Param        endpoint  path      string                true  "Endpoint name"
+// @Param        request   body      deployMLModelRequest  true  "Version and environment"
+// @Success      200       {object}  mlmodels.Deployment
+// @Failure      400       {object}  map[string]string
+// @Failure      404       {object}  map[string]string
+// @Failure      409       {object}  map[string]string
+// @Failure      503       {object}  map[string]string
+// @Router       /v1/ml/endpoints/{endpoint}/deployments [post]
+func DeployMLModelEndpoint(w http.ResponseWriter, r *http.Request) {
+	if mlModelService == nil {
+		WriteJSONError(w, "ML models are not available", http.StatusServiceUnavailable)
+		return
+	}
+	var req deployMLModelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.Version == "" || req.Environment == "" {
+		WriteJSONError(w, "version and environment are required", http.StatusBadRequest)
+		return
+	}
+	deployment, err := mlModelService.DeployEndpoint(r.Context(), chi.URLParam(r, "endpoint"), req.Version, req.Environment)
+	if err != nil {
+		if deployment != nil {
+			// Blocked deployments are recorded; the reason is the error
+			WriteJSONError(w, err.Error(), http.StatusConflict)
+			return
+		}
+		writeMLModelError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deployment)
+}
+
+func writeMLModelError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, mlmodels.ErrModelNotFound), errors.Is(err, mlmodels.ErrVersionNotFound), errors.Is(err, mlmodels.ErrEndpointNotFound):
+		WriteJSONError(w, err.Error(), http.StatusNotFound)
+	default:
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+	}
+}
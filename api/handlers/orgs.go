@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krzachariassen/ZTDP/internal/orgs"
+)
+
+// ListOrgUnits godoc
+// @Summary      List the organization hierarchy
+// @Description  Returns every organization, team and project with the policies, quota and defaults attached to it and its applications
+// @Tags         orgs
+// @Produce      json
+// @Success      200  {array}   orgs.Unit
+// @Failure      500  {object}  map[string]string
+// @Router       /v1/org-units [get]
+func ListOrgUnits(w http.ResponseWriter, r *http.Request) {
+	units, err := orgs.NewService(requestGraph(r)).ListUnits()
+	if err != nil {
+		WriteJSONError(w, "Failed to list organization units", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(units)
+}
+
+// SetOrgUnit godoc
+// @Summary      Define an organization, team or project
+// @Description  Creates or replaces a unit. Teams sit under an organization and projects under a team. Policies, a services-per-application quota and defaults attached to the unit are inherited by every application below it; assigned applications move here from their previous unit.
+// @Tags         orgs
+// @Accept       json
+// @Produce      json
+// @Param        unit  body      orgs.Unit  true  "Kind, name, parent and attachments"
+// @Success      200   {object}  orgs.Unit
+// @Failure      400   {object}  map[string]string
+// @Failure      409   {object}  map[string]string
+// @Router       /v1/org-units [put]
+func SetOrgUnit(w http.ResponseWriter, r *http.Request) {
+	var unit orgs.Unit
+	if err := json.NewDecoder(r.Body).Decode(&unit); err != nil {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if err := orgs.NewService(requestGraph(r)).SetUnit(&unit); err != nil {
+		writeOrgsError(w, err, http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(unit)
+}
+
+// GetOrgUnit godoc
+// @Summary      Get an organization, team or project
+// @Tags         orgs
+// @Produce      json
+// @Param        kind  path      string  true  "organization, team or project"
+// @Param        name  path      string  true  "Unit name"
+// @Success      200   {object}  orgs.Unit
+// @Failure      404   {object}  map[string]string
+// @Router       /v1/org-units/{kind}/{name} [get]
+func GetOrgUnit(w http.ResponseWriter, r *http.Request) {
+	unit, err := orgs.NewService(requestGraph(r)).GetUnit(chi.URLParam(r, "kind"), chi.URLParam(r, "name"))
+	if err != nil {
+		writeOrgsError(w, err, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(unit)
+}
+
+// DeleteOrgUnit godoc
+// @Summary      Delete an organization, team or project
+// @Description  Only units with no units or applications below them can be deleted
+// @Tags         orgs
+// @Param        kind  path  string  true  "organization, team or project"
+// @Param        name  path  string  true  "Unit name"
+// @Success      204
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /v1/org-units/{kind}/{name} [delete]
+func DeleteOrgUnit(w http.ResponseWriter, r *http.Request) {
+	if err := orgs.NewService(requestGraph(r)).DeleteUnit(chi.URLParam(r, "kind"), chi.URLParam(r, "name")); err != nil {
+		writeOrgsError(w, err, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetEffectivePolicy godoc
+// @Summary      Resolve what an application inherits
+// @Description  Returns the policies, services-per-application quota and defaults an application inherits from its project, team and organization, with the unit each comes from. Closer units override defaults unless a unit above locked them, exclude policies unless a unit above enforced them, and can only tighten the quota.
+// @Tags         orgs
+// @Produce      json
+// @Param        app_name  path      string  true  "Application name"
+// @Success      200       {object}  orgs.Effective
+// @Failure      404       {object}  map[string]string
+// @Router       /v1/applications/{app_name}/effective-policy [get]
+func GetEffectivePolicy(w http.ResponseWriter, r *http.Request) {
+	effective, err := orgs.NewService(requestGraph(r)).Effective(chi.URLParam(r, "app_name"))
+	if err != nil {
+		writeOrgsError(w, err, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(effective)
+}
+
+// writeOrgsError maps hierarchy errors to statuses, using fallback for anything else
+func writeOrgsError(w http.ResponseWriter, err error, fallback int) {
+	switch {
+	case errors.Is(err, orgs.ErrUnitNotFound), errors.Is(err, orgs.ErrApplicationNotFound):
+		WriteJSONError(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, orgs.ErrUnitInUse):
+		WriteJSONError(w, err.Error(), http.StatusConflict)
+	default:
+		WriteJSONError(w, err.Error(), fallback)
+	}
+}
@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krzachariassen/ZTDP/internal/plans"
+)
+
+// planService stores proposed plans and their revisions
+var planService *plans.Service
+
+// SetupPlans sets the plan service used by the plan endpoints (called from main.go)
+func SetupPlans(service *plans.Service) {
+	planService = service
+}
+
+// ListPlans godoc
+// @Summary      List execution plans
+// @Description  Returns proposed plans, most recently updated first
+// @Tags         plans
+// @Produce      json
+// @Param        conversation_id  query     string  false  "Only plans proposed in this conversation"
+// @Param        status           query     string  false  "draft, approved or discarded"
+// @Success      200              {array}   plans.Plan
+// @Failure      503              {object}  map[string]string
+// @Router       /v1/plans [get]
+func ListPlans(w http.ResponseWriter, r *http.Request) {
+	if planService == nil {
+		WriteJSONError(w, "Plan storage is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	list, err := planService.List(plans.ListFilter{
+		ConversationID: r.URL.Query().Get("conversation_id"),
+		Status:         r.URL.Query().Get("status"),
+	})
+	if err != nil {
+		WriteJSONError(w, "Failed to list plans", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// CreatePlan godoc
+// @Summary      Store an execution plan
+// @Description  Stores a plan as a draft at revision 1 so it can be revised before it is approved
+// @Tags         plans
+// @Accept       json
+// @Produce      json
+// @Param        plan  body      plans.Plan  true  "Plan with goal and steps"
+// @Success      201   {object}  plans.Plan
+// @Failure      400   {object}  map[string]string
+// @Failure      503   {object}  map[string]string
+// @Router       /v1/plans [post]
+func CreatePlan(w http.ResponseWriter, r *http.Request) {
+	if planService == nil {
+		WriteJSONError(w, "Plan storage is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	var plan plans.Plan
+	if err := json.NewDecoder(r.Body).Decode(&plan); err != nil {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if plan.ProposedBy == "" {
+		plan.ProposedBy = plans.AuthorUser
+	}
+
+	stored, err := planService.Propose(plan)
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(stored)
+}
+
+// GetPlan godoc
+// @Summary      Get an execution plan
+// @Description  Returns the plan's current steps and every revision
+// @Tags         plans
+// @Produce      json
+// @Param        id   path      string  true  "Plan ID"
+// @Success      200  {object}  plans.Plan
+// @Failure      404  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Router       /v1/plans/{id} [get]
+func GetPlan(w http.ResponseWriter, r *http.Request) {
+	if planService == nil {
+		WriteJSONError(w, "Plan storage is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	plan, err := planService.Get(chi.URLParam(r, "id"))
+	if err != nil {
+		writePlanError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(plan)
+}
+
+// revisePlanRequest is the body of a plan revision; give either operations or an instruction
+type revisePlanRequest struct {
+	Operations  []plans.Operation `json:"operations,omitempty"`
+	Instruction string            `json:"instruction,omitempty"` // natural language, turned into operations by the AI
+	Summary     string            `json:"summary,omitempty"`
+}
+
+// RevisePlan godoc
+// @Summary      Revise an execution plan
+// @Description  Applies edit operations (remove, rename, update, add, move) to a draft plan, or lets the AI derive them from a natural language instruction
+// @Tags         plans
+// @Accept       json
+// @Produce      json
+// @Param        id        path      string             true  "Plan ID"
+// @Param        revision  body      revisePlanRequest  true  "Operations or instruction"
+// @Success      201       {object}  plans.Plan
+// @Failure      400       {object}  map[string]string
+// @Failure      404       {object}  map[string]string
+// @Failure      409       {object}  map[string]string
+// @Failure      503       {object}  map[string]string
+// @Router       /v1/plans/{id}/revisions [post]
+func RevisePlan(w http.ResponseWriter, r *http.Request) {
+	if planService == nil {
+		WriteJSONError(w, "Plan storage is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req revisePlanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	var plan *plans.Plan
+	var err error
+	switch {
+	case len(req.Operations) > 0:
+		plan, err = planService.Revise(id, req.Operations, plans.AuthorUser, req.Instruction, req.Summary)
+	case req.Instruction != "":
+		plan, err = planService.ReviseWithAI(r.Context(), id, req.Instruction)
+	default:
+		WriteJSONError(w, "operations or instruction is required", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		writePlanError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(plan)
+}
+
+// ApprovePlan godoc
+// @Summary      Approve an execution plan
+// @Description  Marks a draft plan approved; approved plans can no longer be revised
+// @Tags         plans
+// @Produce      json
+// @Param        id   path      string  true  "Plan ID"
+// @Success      200  {object}  plans.Plan
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Router       /v1/plans/{id}/approve [post]
+func ApprovePlan(w http.ResponseWriter, r *http.Request) {
+	setPlanStatus(w, r, plans.StatusApproved)
+}
+
+// DiscardPlan godoc
+// @Summary      Discard an execution plan
+// @Description  Marks a draft plan discarded; its revisions are kept for reference
+// @Tags         plans
+// @Produce      json
+// @Param        id   path      string  true  "Plan ID"
+// @Success      200  {object}  plans.Plan
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Router       /v1/plans/{id}/discard [post]
+func DiscardPlan(w http.ResponseWriter, r *http.Request) {
+	setPlanStatus(w, r, plans.StatusDiscarded)
+}
+
+func setPlanStatus(w http.ResponseWriter, r *http.Request, status string) {
+	if planService == nil {
+		WriteJSONError(w, "Plan storage is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	plan, err := planService.SetStatus(chi.URLParam(r, "id"), status)
+	if err != nil {
+		writePlanError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(plan)
+}
+
+// DeletePlan godoc
+// @Summary      Delete an execution plan
+// @Tags         plans
+// @Param        id  path  string  true  "Plan ID"
+// @Success      204
+// @Failure      404  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Router       /v1/plans/{id} [delete]
+func DeletePlan(w http.ResponseWriter, r *http.Request) {
+	if planService == nil {
+		WriteJSONError(w, "Plan storage is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	if err := planService.Delete(chi.URLParam(r, "id")); err != nil {
+		writePlanError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writePlanError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, plans.ErrPlanNotFound):
+		WriteJSONError(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, plans.ErrPlanNotEditable):
+		WriteJSONError(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, plans.ErrInvalidOperation):
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, plans.ErrAIUnavailable):
+		WriteJSONError(w, err.Error(), http.StatusServiceUnavailable)
+	default:
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/krzachariassen/ZTDP/internal/policies"
+)
+
+// GetPolicyCacheStats godoc
+// @Summary      Policy decision cache statistics
+// @Description  Returns the cached policy decisions and how many were served, evaluated, invalidated by graph changes or expired
+// @Tags         policies
+// @Produce      json
+// @Success      200  {object}  policies.CacheStats
+// @Failure      503  {object}  map[string]string
+// @Router       /v1/policies/cache [get]
+func GetPolicyCacheStats(w http.ResponseWriter, r *http.Request) {
+	cache := policies.GetDecisionCache()
+	if cache == nil {
+		WriteJSONError(w, "Policy decision caching is disabled", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cache.Stats())
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/policies"
+)
+
+// ListPolicyDecisions godoc
+// @Summary      Sample the policy decision log
+// @Description  Returns recent policy decisions in the OPA decision log format, oldest first: policy path and revision, input digest, decision, confidence and evaluator. With sample set, a random selection of that many matching decisions is returned instead, for compliance teams to verify.
+// @Tags         policies
+// @Produce      json
+// @Param        since      query     string  false  "RFC3339 timestamp; only decisions made at or after it"
+// @Param        evaluator  query     string  false  "Only decisions by this evaluator, e.g. ai"
+// @Param        decision   query     string  false  "Only this decision, e.g. blocked"
+// @Param        policy_id  query     string  false  "Only decisions of this policy"
+// @Param        sample     query     int     false  "Return a random sample of this many decisions"
+// @Success      200        {array}   policies.DecisionLog
+// @Failure      400        {object}  map[string]string
+// @Failure      503        {object}  map[string]string
+// @Router       /v1/policies/decisions [get]
+func ListPolicyDecisions(w http.ResponseWriter, r *http.Request) {
+	decisionLogger := policies.GetDecisionLogger()
+	if decisionLogger == nil {
+		WriteJSONError(w, "Policy decision logging is disabled", http.StatusServiceUnavailable)
+		return
+	}
+	query := r.URL.Query()
+	filter := policies.DecisionFilter{
+		Evaluator: query.Get("evaluator"),
+		Decision:  policies.PolicyStatus(query.Get("decision")),
+		PolicyID:  query.Get("policy_id"),
+	}
+	if value := query.Get("since"); value != "" {
+		since, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			WriteJSONError(w, "since must be an RFC3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		filter.Since = since
+	}
+	decisions := decisionLogger.Query(filter)
+	if value := query.Get("sample"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size <= 0 {
+			WriteJSONError(w, "sample must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		if size < len(decisions) {
+			rand.Shuffle(len(decisions), func(i, j int) { decisions[i], decisions[j] = decisions[j], decisions[i] })
+			decisions = decisions[:size]
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(decisions)
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/krzachariassen/ZTDP/internal/policies"
+)
+
+// driftAnalyzer compares policy coverage across environments; it writes remediations with AI when configured
+var driftAnalyzer *policies.DriftAnalyzer
+
+// SetupPolicyDrift sets the analyzer used by the policy drift endpoint (called from main.go)
+func SetupPolicyDrift(analyzer *policies.DriftAnalyzer) {
+	driftAnalyzer = analyzer
+}
+
+// GetPolicyDrift godoc
+// @Summary      Compare policy coverage across environments
+// @Description  Lists which policies are attached and enforced on transitions into each environment and flags asymmetries, such as a policy enforced in prod but missing in staging, with remediation suggestions (AI-written when an AI provider is configured)
+// @Tags         policies
+// @Produce      json
+// @Param        environments  query     string  false  "Comma-separated environments to compare (default: all)"
+// @Success      200           {object}  policies.DriftReport
+// @Failure      404           {object}  map[string]string
+// @Router       /v1/policies/drift [get]
+func GetPolicyDrift(w http.ResponseWriter, r *http.Request) {
+	var environments []string
+	for _, env := range strings.Split(r.URL.Query().Get("environments"), ",") {
+		if env = strings.TrimSpace(env); env != "" {
+			environments = append(environments, env)
+		}
+	}
+
+	analyzer := driftAnalyzer
+	if analyzer == nil {
+		analyzer = policies.NewDriftAnalyzer(requestGraph(r), nil)
+	}
+	report, err := analyzer.Analyze(r.Context(), environments)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			WriteJSONError(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krzachariassen/ZTDP/internal/promptlog"
+)
+
+// promptLogStore is nil when AI prompt logging is disabled
+var promptLogStore *promptlog.Store
+
+// SetupPromptLogs sets the store behind the prompt log endpoints (called from main.go)
+func SetupPromptLogs(store *promptlog.Store) {
+	promptLogStore = store
+}
+
+// ListPromptLogs godoc
+// @Summary      List sampled AI prompts
+// @Description  Returns the full, redacted prompts and responses of the sampled AI calls still retained, oldest first
+// @Tags         ai
+// @Produce      json
+// @Param        task            query     string  false  "Only calls made for this task, e.g. planning"
+// @Param        correlation_id  query     string  false  "Only calls made while handling this request"
+// @Param        contains        query     string  false  "Case-insensitive text in a prompt or the response"
+// @Param        since           query     string  false  "RFC3339 timestamp; only calls made after it"
+// @Param        errors          query     bool    false  "Only calls that failed"
+// @Param        limit           query     int     false  "Maximum number of calls (most recent kept)"
+// @Success      200             {array}   promptlog.Entry
+// @Failure      400             {object}  map[string]string
+// @Failure      503             {object}  map[string]string
+// @Router       /v1/ai/prompt-logs [get]
+func ListPromptLogs(w http.ResponseWriter, r *http.Request) {
+	if promptLogStore == nil {
+		WriteJSONError(w, "AI prompt logging is disabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	params := r.URL.Query()
+	query := promptlog.Query{
+		Task:          params.Get("task"),
+		CorrelationID: params.Get("correlation_id"),
+		Contains:      params.Get("contains"),
+		ErrorsOnly:    params.Get("errors") == "true",
+	}
+	if v := params.Get("since"); v != "" {
+		since, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			WriteJSONError(w, "since must be an RFC3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		query.Since = since
+	}
+	if v := params.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			WriteJSONError(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		query.Limit = limit
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(promptLogStore.Query(query))
+}
+
+// GetPromptLog godoc
+// @Summary      Get a sampled AI prompt
+// @Description  Returns one sampled AI call with its full, redacted prompts and response
+// @Tags         ai
+// @Produce      json
+// @Param        id   path      string  true  "Prompt log entry ID"
+// @Success      200  {object}  promptlog.Entry
+// @Failure      404  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Router       /v1/ai/prompt-logs/{id} [get]
+func GetPromptLog(w http.ResponseWriter, r *http.Request) {
+	if promptLogStore == nil {
+		WriteJSONError(w, "AI prompt logging is disabled", http.StatusServiceUnavailable)
+		return
+	}
+	entry, ok := promptLogStore.Get(chi.URLParam(r, "id"))
+	if !ok {
+		WriteJSONError(w, "Prompt log entry not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entry)
+}
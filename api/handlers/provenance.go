@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krzachariassen/ZTDP/internal/provenance"
+)
+
+// provenanceService is nil when provenance signing is disabled
+var provenanceService *provenance.Service
+
+// SetupProvenance sets the service used by the provenance endpoints (called from main.go)
+func SetupProvenance(service *provenance.Service) {
+	provenanceService = service
+}
+
+// VerificationResult is the outcome of verifying a provenance record
+type VerificationResult struct {
+	ID    string `json:"id"`
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+}
+
+// ListProvenance godoc
+// @Summary      List signed provenance records
+// @Description  Returns signed records of execution plans and graph mutations, newest first, each attributed to the AI, a human API call or the system
+// @Tags         provenance
+// @Produce      json
+// @Param        type       query     string  false  "plan or mutation"
+// @Param        initiator  query     string  false  "ai, human, system or mixed"
+// @Param        subject    query     string  false  "Plan ID or graph node the change touched"
+// @Param        since      query     string  false  "RFC3339 timestamp; only records signed after it"
+// @Param        limit      query     int     false  "Maximum number of records"
+// @Success      200        {array}   provenance.Record
+// @Failure      400        {object}  map[string]string
+// @Failure      500        {object}  map[string]string
+// @Failure      503        {object}  map[string]string
+// @Router       /v1/provenance [get]
+func ListProvenance(w http.ResponseWriter, r *http.Request) {
+	if provenanceService == nil {
+		WriteJSONError(w, "Provenance signing is disabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := provenance.ListFilter{
+		Type:      query.Get("type"),
+		Initiator: query.Get("initiator"),
+		Subject:   query.Get("subject"),
+	}
+	if v := query.Get("since"); v != "" {
+		since, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			WriteJSONError(w, "since must be an RFC3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		filter.Since = since
+	}
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			WriteJSONError(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	records, err := provenanceService.List(filter)
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if records == nil {
+		records = []*provenance.Record{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(records)
+}
+
+// GetProvenance godoc
+// @Summary      Get a signed provenance record
+// @Tags         provenance
+// @Produce      json
+// @Param        id   path      string  true  "Record ID"
+// @Success      200  {object}  provenance.Record
+// @Failure      404  {object}  map[string]string
+// @Failure      500  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Router       /v1/provenance/{id} [get]
+func GetProvenance(w http.ResponseWriter, r *http.Request) {
+	if provenanceService == nil {
+		WriteJSONError(w, "Provenance signing is disabled", http.StatusServiceUnavailable)
+		return
+	}
+	record, err := provenanceService.Get(chi.URLParam(r, "id"))
+	if errors.Is(err, provenance.ErrRecordNotFound) {
+		WriteJSONError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(record)
+}
+
+// VerifyProvenance godoc
+// @Summary      Verify a provenance record
+// @Description  Checks the record's payload digest and signature against this instance's key and the trusted keys of other instances. Submit a record exactly as it was returned.
+// @Tags         provenance
+// @Accept       json
+// @Produce      json
+// @Param        record  body      provenance.Record  true  "Record to verify"
+// @Success      200     {object}  VerificationResult
+// @Failure      400     {object}  map[string]string
+// @Failure      503     {object}  map[string]string
+// @Router       /v1/provenance/verify [post]
+func VerifyProvenance(w http.ResponseWriter, r *http.Request) {
+	if provenanceService == nil {
+		WriteJSONError(w, "Provenance signing is disabled", http.StatusServiceUnavailable)
+		return
+	}
+	var record provenance.Record
+	if err := json.NewDecoder(r.Body).Decode(&record); err != nil {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	result := VerificationResult{ID: record.ID, Valid: true}
+	if err := provenanceService.Verify(&record); err != nil {
+		if !errors.Is(err, provenance.ErrInvalidSignature) && !errors.Is(err, provenance.ErrUnknownKey) {
+			WriteJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		result.Valid = false
+		result.Error = err.Error()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// ProvenanceKeys godoc
+// @Summary      List provenance verification keys
+// @Description  Returns this instance's public signing key and the keys it trusts from other orchestrator instances
+// @Tags         provenance
+// @Produce      json
+// @Success      200  {array}   provenance.Key
+// @Failure      503  {object}  map[string]string
+// @Router       /v1/provenance/keys [get]
+func ProvenanceKeys(w http.ResponseWriter, r *http.Request) {
+	if provenanceService == nil {
+		WriteJSONError(w, "Provenance signing is disabled", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(provenanceService.Keys())
+}
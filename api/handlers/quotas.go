@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krzachariassen/ZTDP/internal/quotas"
+)
+
+// quotaErrorResponse is the body returned when a creation is refused by a quota
+type quotaErrorResponse struct {
+	Error string                `json:"error"`
+	Quota *quotas.ExceededError `json:"quota"`
+}
+
+// writeQuotaError writes a 403 with the exceeded quota and current usage; it reports whether
+// err was a quota violation
+func writeQuotaError(w http.ResponseWriter, err error) bool {
+	var exceeded *quotas.ExceededError
+	if !errors.As(err, &exceeded) {
+		return false
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(quotaErrorResponse{Error: exceeded.Error(), Quota: exceeded})
+	return true
+}
+
+// GetQuotas godoc
+// @Summary      Report quotas and consumption
+// @Description  Returns the defined quotas and current usage for the platform, every application and every team
+// @Tags         quotas
+// @Produce      json
+// @Success      200  {object}  quotas.Report
+// @Failure      500  {object}  map[string]string
+// @Router       /v1/quotas [get]
+func GetQuotas(w http.ResponseWriter, r *http.Request) {
+	report, err := quotas.NewService(requestGraph(r)).Report()
+	if err != nil {
+		WriteJSONError(w, "Failed to report quotas", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// SetQuota godoc
+// @Summary      Define a quota
+// @Description  Creates or replaces the quota for the default scope, a team or an application. Zero limits defer to less specific quotas.
+// @Tags         quotas
+// @Accept       json
+// @Produce      json
+// @Param        quota  body      quotas.Quota  true  "Scope, name and limits"
+// @Success      200    {object}  quotas.Quota
+// @Failure      400    {object}  map[string]string
+// @Router       /v1/quotas [put]
+func SetQuota(w http.ResponseWriter, r *http.Request) {
+	var quota quotas.Quota
+	if err := json.NewDecoder(r.Body).Decode(&quota); err != nil {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if err := quotas.NewService(requestGraph(r)).SetQuota(&quota); err != nil {
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(quota)
+}
+
+// DeleteQuota godoc
+// @Summary      Delete a quota
+// @Tags         quotas
+// @Param        scope  path  string  true  "default, team or application"
+// @Param        name   path  string  false  "Team or application name"
+// @Success      204
+// @Failure      404  {object}  map[string]string
+// @Router       /v1/quotas/{scope}/{name} [delete]
+func DeleteQuota(w http.ResponseWriter, r *http.Request) {
+	err := quotas.NewService(requestGraph(r)).DeleteQuota(chi.URLParam(r, "scope"), chi.URLParam(r, "name"))
+	if errors.Is(err, quotas.ErrQuotaNotFound) {
+		WriteJSONError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
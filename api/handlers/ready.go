@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/krzachariassen/ZTDP/internal/health"
+)
+
+var readinessChecker *health.Checker
+
+// SetupReadinessChecker sets the dependency checker used by the readiness endpoint (called from main.go)
+func SetupReadinessChecker(c *health.Checker) {
+	readinessChecker = c
+}
+
+// Readiness godoc
+// @Summary      Readiness check
+// @Description  Verifies the graph backend, event transport and AI provider. Returns 503 until startup completes or while a critical dependency is unavailable.
+// @Tags         health
+// @Produce      json
+// @Success      200  {object}  health.Report
+// @Failure      503  {object}  health.Report
+// @Router       /v1/ready [get]
+func Readiness(w http.ResponseWriter, r *http.Request) {
+	if readinessChecker == nil {
+		WriteJSONError(w, "Readiness checks not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	report := readinessChecker.Check(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	if !report.Ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/recording"
+)
+
+// recorder is nil unless recording is enabled
+var recorder *recording.Recorder
+
+// SetupRecordings sets the recorder used by the recording endpoints (called from main.go)
+func SetupRecordings(r *recording.Recorder) {
+	recorder = r
+}
+
+// StartRecordingRequest starts a recording
+type StartRecordingRequest struct {
+	Window string `json:"window"` // Go duration, e.g. 15m
+}
+
+// GetRecordingStatus godoc
+// @Summary      Recording status
+// @Description  Reports whether API requests are being recorded and where the last bundle was written
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  recording.Status
+// @Failure      503  {object}  map[string]string
+// @Router       /v1/admin/recordings [get]
+func GetRecordingStatus(w http.ResponseWriter, r *http.Request) {
+	if recorder == nil {
+		WriteJSONError(w, "Recording is disabled", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(recorder.Status())
+}
+
+// StartRecording godoc
+// @Summary      Start recording
+// @Description  Records every API request, its response and the graph mutations it causes until the window ends or the recording is stopped
+// @Tags         admin
+// @Accept       json
+// @Produce      json
+// @Param        request  body      StartRecordingRequest  true  "Recording window"
+// @Success      201      {object}  recording.Status
+// @Failure      400      {object}  map[string]string
+// @Failure      409      {object}  map[string]string
+// @Failure      503      {object}  map[string]string
+// @Router       /v1/admin/recordings [post]
+func StartRecording(w http.ResponseWriter, r *http.Request) {
+	if recorder == nil {
+		WriteJSONError(w, "Recording is disabled", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req StartRecordingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	window, err := time.ParseDuration(req.Window)
+	if err != nil {
+		WriteJSONError(w, "window must be a duration such as 15m", http.StatusBadRequest)
+		return
+	}
+	status, err := recorder.Start(window)
+	if err != nil {
+		if errors.Is(err, recording.ErrRecording) {
+			WriteJSONError(w, err.Error(), http.StatusConflict)
+			return
+		}
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(status)
+}
+
+// StopRecording godoc
+// @Summary      Stop recording
+// @Description  Ends the recording in progress and returns its bundle, ready for `go run ./cmd/replay`
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  recording.Bundle
+// @Failure      409  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Router       /v1/admin/recordings/stop [post]
+func StopRecording(w http.ResponseWriter, r *http.Request) {
+	if recorder == nil {
+		WriteJSONError(w, "Recording is disabled", http.StatusServiceUnavailable)
+		return
+	}
+	bundle, err := recorder.Stop()
+	if err != nil {
+		if errors.Is(err, recording.ErrNotRecording) {
+			WriteJSONError(w, err.Error(), http.StatusConflict)
+			return
+		}
+		// The bundle could not be written to the recording directory, but can still be returned
+		if bundle == nil {
+			WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(bundle)
+}
+
+// GetLastRecording godoc
+// @Summary      Download the last recording
+// @Description  Returns the bundle of the most recently completed recording
+// @Tags         admin
+// @Produce      json
+// @Success      200  {object}  recording.Bundle
+// @Failure      404  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Router       /v1/admin/recordings/last [get]
+func GetLastRecording(w http.ResponseWriter, r *http.Request) {
+	if recorder == nil {
+		WriteJSONError(w, "Recording is disabled", http.StatusServiceUnavailable)
+		return
+	}
+	bundle, err := recorder.Last()
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="recording-`+bundle.StartedAt.UTC().Format("20060102T150405Z")+`.json"`)
+	json.NewEncoder(w).Encode(bundle)
+}
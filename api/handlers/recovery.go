@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krzachariassen/ZTDP/internal/recovery"
+)
+
+// recoveryService generates DR runbooks, with AI-written procedures when configured, and tracks drills
+var recoveryService *recovery.Service
+
+// SetupRecovery sets the service used by the runbook and drill endpoints (called from main.go)
+func SetupRecovery(service *recovery.Service) {
+	recoveryService = service
+}
+
+func getRecoveryService() *recovery.Service {
+	if recoveryService == nil {
+		return recovery.NewService(GlobalGraph, nil)
+	}
+	return recoveryService
+}
+
+// GenerateRunbook godoc
+// @Summary      Generate an application's DR runbook
+// @Description  Builds the recovery runbook from the graph: the services of other applications it depends on, the restore order of its resources and services, and an RTO estimate. Restore times measured by the last drill replace the defaults. The procedure is AI-written when an AI provider is configured.
+// @Tags         recovery
+// @Produce      json
+// @Param        app_name  path      string  true  "Application name"
+// @Success      201       {object}  recovery.Runbook
+// @Failure      404       {object}  map[string]string
+// @Router       /v1/applications/{app_name}/runbook [post]
+func GenerateRunbook(w http.ResponseWriter, r *http.Request) {
+	runbook, err := getRecoveryService().GenerateRunbook(r.Context(), chi.URLParam(r, "app_name"))
+	if err != nil {
+		writeRecoveryError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(runbook)
+}
+
+// GetRunbook godoc
+// @Summary      Get an application's DR runbook
+// @Description  Returns the current runbook with the result and measured RTO of the last drill
+// @Tags         recovery
+// @Produce      json
+// @Param        app_name  path      string  true  "Application name"
+// @Success      200       {object}  recovery.Runbook
+// @Failure      404       {object}  map[string]string
+// @Router       /v1/applications/{app_name}/runbook [get]
+func GetRunbook(w http.ResponseWriter, r *http.Request) {
+	runbook, err := getRecoveryService().GetRunbook(chi.URLParam(r, "app_name"))
+	if err != nil {
+		writeRecoveryError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(runbook)
+}
+
+// ScheduleDrill godoc
+// @Summary      Schedule a DR drill
+// @Description  Schedules a drill of the application's current runbook in an environment
+// @Tags         recovery
+// @Accept       json
+// @Produce      json
+// @Param        app_name  path      string  true  "Application name"
+// @Param        drill     body      object  true  "environment and scheduled_for (RFC3339)"
+// @Success      201       {object}  recovery.Drill
+// @Failure      400       {object}  map[string]string
+// @Failure      404       {object}  map[string]string
+// @Router       /v1/applications/{app_name}/drills [post]
+func ScheduleDrill(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Environment  string    `json:"environment"`
+		ScheduledFor time.Time `json:"scheduled_for"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.Environment == "" {
+		WriteJSONError(w, "environment is required", http.StatusBadRequest)
+		return
+	}
+	drill, err := getRecoveryService().ScheduleDrill(chi.URLParam(r, "app_name"), req.Environment, req.ScheduledFor)
+	if err != nil {
+		writeRecoveryError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(drill)
+}
+
+// ListDrills godoc
+// @Summary      List an application's DR drills
+// @Description  Returns scheduled and completed drills, most recently scheduled first
+// @Tags         recovery
+// @Produce      json
+// @Param        app_name  path      string  true  "Application name"
+// @Success      200       {array}   recovery.Drill
+// @Router       /v1/applications/{app_name}/drills [get]
+func ListDrills(w http.ResponseWriter, r *http.Request) {
+	drills, err := getRecoveryService().ListDrills(chi.URLParam(r, "app_name"))
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(drills)
+}
+
+// RecordDrillOutcome godoc
+// @Summary      Record a DR drill outcome
+// @Description  Completes a scheduled drill with its status, measured RTO, per-step results and findings. The runbook records the measured RTO and the next regeneration uses the step times.
+// @Tags         recovery
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string            true  "Drill ID"
+// @Param        outcome  body      recovery.Outcome  true  "Drill outcome"
+// @Success      200      {object}  recovery.Drill
+// @Failure      400      {object}  map[string]string
+// @Failure      404      {object}  map[string]string
+// @Failure      409      {object}  map[string]string
+// @Router       /v1/drills/{id}/outcome [put]
+func RecordDrillOutcome(w http.ResponseWriter, r *http.Request) {
+	var outcome recovery.Outcome
+	if err := json.NewDecoder(r.Body).Decode(&outcome); err != nil {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	drill, err := getRecoveryService().RecordOutcome(chi.URLParam(r, "id"), outcome)
+	if err != nil {
+		writeRecoveryError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(drill)
+}
+
+func writeRecoveryError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, recovery.ErrRunbookNotFound), errors.Is(err, recovery.ErrDrillNotFound), strings.Contains(err.Error(), "not found"):
+		WriteJSONError(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, recovery.ErrDrillCompleted):
+		WriteJSONError(w, err.Error(), http.StatusConflict)
+	default:
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+	}
+}
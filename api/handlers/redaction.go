@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/krzachariassen/ZTDP/internal/redaction"
+)
+
+// RedactionStats godoc
+// @Summary      Redaction audit counters
+// @Description  Returns how many secrets and personal data values were masked in AI prompts, event logs and conversations, per rule
+// @Tags         ai
+// @Produce      json
+// @Success      200  {object}  redaction.Stats
+// @Router       /v1/redaction/stats [get]
+func RedactionStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(redaction.Default().Stats())
+}
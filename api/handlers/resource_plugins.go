@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/krzachariassen/ZTDP/internal/resources"
+)
+
+// ListResourcePlugins godoc
+// @Summary      List resource type plugins
+// @Description  Returns the registered resource type plugins (built-in, Go plugins and declarative registrations)
+// @Tags         resources
+// @Produce      json
+// @Success      200  {array}  resources.PluginDefinition
+// @Router       /v1/resource-plugins [get]
+func ListResourcePlugins(w http.ResponseWriter, r *http.Request) {
+	definitions := []resources.PluginDefinition{}
+	for _, p := range resources.Plugins() {
+		definitions = append(definitions, resources.DescribePlugin(p))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(definitions)
+}
+
+// RegisterResourcePlugin godoc
+// @Summary      Register a resource type plugin
+// @Description  Registers a declarative resource type plugin (defaults, required fields, connection string template) so a new infrastructure type can be offered without code changes
+// @Tags         resources
+// @Accept       json
+// @Produce      json
+// @Param        plugin  body      resources.PluginDefinition  true  "Plugin definition"
+// @Success      201     {object}  resources.PluginDefinition
+// @Failure      400     {object}  map[string]string
+// @Failure      409     {object}  map[string]string
+// @Router       /v1/resource-plugins [post]
+func RegisterResourcePlugin(w http.ResponseWriter, r *http.Request) {
+	var def resources.PluginDefinition
+	if err := json.NewDecoder(r.Body).Decode(&def); err != nil {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	plugin, err := resources.NewTemplatePlugin(def)
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if _, exists := resources.GetPlugin(def.Type); exists {
+		WriteJSONError(w, fmt.Sprintf("resource type plugin %s is already registered", def.Type), http.StatusConflict)
+		return
+	}
+	resources.RegisterPlugin(plugin)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(plugin.Definition())
+}
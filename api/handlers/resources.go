@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 
@@ -26,7 +27,7 @@ func CreateResource(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resourceService := resources.NewService(GlobalGraph)
+	resourceService := resources.NewService(requestGraph(r))
 	response, err := resourceService.CreateResource(req)
 	if err != nil {
 		WriteJSONError(w, err.Error(), http.StatusBadRequest)
@@ -39,33 +40,39 @@ func CreateResource(w http.ResponseWriter, r *http.Request) {
 
 // AddResourceToApplication godoc
 // @Summary      Create a resource instance for an application
-// @Description  Creates a named resource instance for an application based on a catalog resource. Uses predictable naming (app-resource) by default, or custom name via query parameter. Operation is idempotent - returns success if resource already exists.
+// @Description  Creates a named resource instance for an application based on a catalog resource. Names come from the configured naming strategy (app-resource by default), or a custom name via query parameter. Operation is idempotent - returns success if resource already exists.
 // @Tags         resources
 // @Produce      json
 // @Param        app_name      path  string  true  "Application name"
 // @Param        resource_name path  string  true  "Resource name from catalog"
 // @Param        instance_name query string  false "Custom instance name (defaults to app-resource format)"
+// @Param        environment   query string  false "Environment for naming templates that include it"
 // @Success      201  {object}  map[string]interface{}  "Resource instance created"
 // @Success      200  {object}  map[string]interface{}  "Resource instance already exists"
 // @Failure      404  {object}  map[string]string       "Application or catalog resource not found"
-// @Failure      409  {object}  map[string]string       "Name conflict with existing non-resource node"
+// @Failure      403  {object}  map[string]interface{}  "Team resource quota exceeded"
+// @Failure      409  {object}  map[string]string       "Name conflict with an existing node or another resource instance"
 // @Router       /v1/applications/{app_name}/resources/{resource_name} [post]
 func AddResourceToApplication(w http.ResponseWriter, r *http.Request) {
 	appName := chi.URLParam(r, "app_name")
 	resourceName := chi.URLParam(r, "resource_name")
 	instanceName := r.URL.Query().Get("instance_name")
+	environment := r.URL.Query().Get("environment")
 
-	resourceService := resources.NewService(GlobalGraph)
-	response, err := resourceService.AddResourceToApplication(appName, resourceName, instanceName)
+	resourceService := resources.NewService(requestGraph(r))
+	response, err := resourceService.AddResourceToApplication(appName, resourceName, instanceName, environment)
 	if err != nil {
 		if err.Error() == "application not found" || err.Error() == "resource not found in catalog" {
 			WriteJSONError(w, err.Error(), http.StatusNotFound)
 			return
 		}
-		if err.Error() == "a node with this name already exists but is not a resource" {
+		if err.Error() == "a node with this name already exists but is not a resource" || errors.Is(err, resources.ErrInstanceNameConflict) {
 			WriteJSONError(w, err.Error(), http.StatusConflict)
 			return
 		}
+		if writeQuotaError(w, err) {
+			return
+		}
 		WriteJSONError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -94,7 +101,7 @@ func LinkServiceToResource(w http.ResponseWriter, r *http.Request) {
 	serviceName := chi.URLParam(r, "service_name")
 	resourceName := chi.URLParam(r, "resource_name")
 
-	resourceService := resources.NewService(GlobalGraph)
+	resourceService := resources.NewService(requestGraph(r))
 	response, err := resourceService.LinkServiceToResource(appName, serviceName, resourceName)
 	if err != nil {
 		if err.Error() == "application not found" || err.Error() == "service not found" {
@@ -124,7 +131,7 @@ func LinkServiceToResource(w http.ResponseWriter, r *http.Request) {
 // @Success      200  {array}  map[string]interface{}
 // @Router       /v1/resources [get]
 func ListResources(w http.ResponseWriter, r *http.Request) {
-	resourceService := resources.NewService(GlobalGraph)
+	resourceService := resources.NewService(requestGraph(r))
 	resourceList, err := resourceService.ListResources()
 	if err != nil {
 		WriteJSONError(w, "Failed to get resources", http.StatusInternalServerError)
@@ -146,7 +153,7 @@ func ListResources(w http.ResponseWriter, r *http.Request) {
 func ListApplicationResources(w http.ResponseWriter, r *http.Request) {
 	appName := chi.URLParam(r, "app_name")
 
-	resourceService := resources.NewService(GlobalGraph)
+	resourceService := resources.NewService(requestGraph(r))
 	resourceList, err := resourceService.ListApplicationResources(appName)
 	if err != nil {
 		if err.Error() == "application not found" {
@@ -174,7 +181,7 @@ func ListApplicationResources(w http.ResponseWriter, r *http.Request) {
 func ListServiceResources(w http.ResponseWriter, r *http.Request) {
 	serviceName := chi.URLParam(r, "service_name")
 
-	resourceService := resources.NewService(GlobalGraph)
+	resourceService := resources.NewService(requestGraph(r))
 	resourceList, err := resourceService.ListServiceResources(serviceName)
 	if err != nil {
 		if err.Error() == "service not found" {
@@ -188,3 +195,74 @@ func ListServiceResources(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resourceList)
 }
+
+// lifecycleRequest is the body of a resource lifecycle transition
+type lifecycleRequest struct {
+	State  resources.LifecycleState `json:"state"`
+	Reason string                   `json:"reason,omitempty"`
+}
+
+// GetResourceLifecycle godoc
+// @Summary      Get a resource's lifecycle state
+// @Description  Returns the lifecycle state (provisioning, active, maintenance, deprecated, decommissioned) and the services that use the resource
+// @Tags         resources
+// @Produce      json
+// @Param        resource_name  path      string  true  "Resource name"
+// @Success      200            {object}  resources.Lifecycle
+// @Failure      404            {object}  map[string]string
+// @Router       /v1/resources/{resource_name}/lifecycle [get]
+func GetResourceLifecycle(w http.ResponseWriter, r *http.Request) {
+	resourceService := resources.NewService(requestGraph(r))
+	lifecycle, err := resourceService.GetLifecycle(chi.URLParam(r, "resource_name"))
+	if err != nil {
+		writeLifecycleError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(lifecycle)
+}
+
+// TransitionResourceLifecycle godoc
+// @Summary      Change a resource's lifecycle state
+// @Description  Moves the resource to a new lifecycle state and notifies dependent services. Deployments of applications using a resource in maintenance are blocked.
+// @Tags         resources
+// @Accept       json
+// @Produce      json
+// @Param        resource_name  path      string            true  "Resource name"
+// @Param        transition     body      lifecycleRequest  true  "Target state and reason"
+// @Success      200            {object}  resources.Lifecycle
+// @Failure      400            {object}  map[string]string
+// @Failure      404            {object}  map[string]string
+// @Failure      409            {object}  map[string]string
+// @Router       /v1/resources/{resource_name}/lifecycle [post]
+func TransitionResourceLifecycle(w http.ResponseWriter, r *http.Request) {
+	var req lifecycleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.State == "" {
+		WriteJSONError(w, "state is required", http.StatusBadRequest)
+		return
+	}
+
+	resourceService := resources.NewService(requestGraph(r))
+	lifecycle, err := resourceService.TransitionResource(chi.URLParam(r, "resource_name"), req.State, req.Reason)
+	if err != nil {
+		writeLifecycleError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(lifecycle)
+}
+
+func writeLifecycleError(w http.ResponseWriter, err error) {
+	switch {
+	case err.Error() == "resource not found":
+		WriteJSONError(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, resources.ErrInvalidTransition):
+		WriteJSONError(w, err.Error(), http.StatusConflict)
+	default:
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/krzachariassen/ZTDP/internal/routing"
+)
+
+// ListRoutingOverrides godoc
+// @Summary      List routing overrides
+// @Description  Returns the overrides in the order they are tried, with how often each routed a request and when it last did
+// @Tags         conversations
+// @Produce      json
+// @Param        expired  query     bool  false  "Also list expired overrides"
+// @Success      200      {array}   routing.Override
+// @Failure      500      {object}  map[string]string
+// @Router       /v1/routing/overrides [get]
+func ListRoutingOverrides(w http.ResponseWriter, r *http.Request) {
+	overrides, err := routing.NewService(requestGraph(r)).List(r.URL.Query().Get("expired") == "true")
+	if err != nil {
+		WriteJSONError(w, "Failed to list routing overrides", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(overrides)
+}
+
+// CreateRoutingOverride godoc
+// @Summary      Add a routing override
+// @Description  Chat requests whose message matches the pattern (a case-insensitive regular expression) go straight to the capability or agent, without AI intent detection, until the override expires. When several match, higher priorities and then older overrides win; overrides whose agents are not registered are skipped.
+// @Tags         conversations
+// @Accept       json
+// @Produce      json
+// @Param        override  body      routing.Override  true  "Pattern, target, priority and expiry"
+// @Success      201       {object}  routing.Override
+// @Failure      400       {object}  map[string]string
+// @Router       /v1/routing/overrides [post]
+func CreateRoutingOverride(w http.ResponseWriter, r *http.Request) {
+	var override routing.Override
+	if err := json.NewDecoder(r.Body).Decode(&override); err != nil {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if override.CreatedBy == "" {
+		override.CreatedBy = logging.UserIDFromContext(r.Context())
+	}
+	if err := routing.NewService(requestGraph(r)).Create(&override); err != nil {
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(override)
+}
+
+// GetRoutingOverride godoc
+// @Summary      Get a routing override
+// @Tags         conversations
+// @Produce      json
+// @Param        id   path      string  true  "Override ID"
+// @Success      200  {object}  routing.Override
+// @Failure      404  {object}  map[string]string
+// @Router       /v1/routing/overrides/{id} [get]
+func GetRoutingOverride(w http.ResponseWriter, r *http.Request) {
+	override, err := routing.NewService(requestGraph(r)).Get(chi.URLParam(r, "id"))
+	if errors.Is(err, routing.ErrOverrideNotFound) {
+		WriteJSONError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(override)
+}
+
+// DeleteRoutingOverride godoc
+// @Summary      Remove a routing override
+// @Tags         conversations
+// @Param        id   path  string  true  "Override ID"
+// @Success      204
+// @Failure      404  {object}  map[string]string
+// @Router       /v1/routing/overrides/{id} [delete]
+func DeleteRoutingOverride(w http.ResponseWriter, r *http.Request) {
+	err := routing.NewService(requestGraph(r)).Delete(chi.URLParam(r, "id"))
+	if errors.Is(err, routing.ErrOverrideNotFound) {
+		WriteJSONError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
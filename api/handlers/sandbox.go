@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/krzachariassen/ZTDP/internal/plans"
+	"github.com/krzachariassen/ZTDP/internal/sandbox"
+)
+
+// sandboxService simulates hypothetical changes on forks of the graph
+var sandboxService *sandbox.Service
+
+// SetupSandbox sets the sandbox used by the simulation endpoint (called from main.go)
+func SetupSandbox(service *sandbox.Service) {
+	sandboxService = service
+}
+
+// Simulate godoc
+// @Summary      Simulate a hypothetical change
+// @Description  Forks the graph into an isolated copy, applies the changes and/or the steps of a stored plan to it, runs the deployment checks and predicts the impact. Production state is never modified.
+// @Tags         sandbox
+// @Accept       json
+// @Produce      json
+// @Param        request  body      sandbox.Request  true  "Changes and/or plan_id to simulate"
+// @Success      200      {object}  sandbox.Result
+// @Failure      400      {object}  map[string]string
+// @Failure      404      {object}  map[string]string
+// @Failure      503      {object}  map[string]string
+// @Router       /v1/sandbox [post]
+func Simulate(w http.ResponseWriter, r *http.Request) {
+	if sandboxService == nil {
+		WriteJSONError(w, "Sandbox is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req sandbox.Request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	result, err := sandboxService.Simulate(req)
+	switch {
+	case errors.Is(err, plans.ErrPlanNotFound):
+		WriteJSONError(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, sandbox.ErrNothingToSimulate), errors.Is(err, sandbox.ErrInvalidChange):
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
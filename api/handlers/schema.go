@@ -2,70 +2,47 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krzachariassen/ZTDP/internal/contracts"
 )
 
 // ContractSchema godoc
 // @Summary      Get contract schemas
-// @Description  Returns example schemas for supported contract kinds
+// @Description  Returns the JSON schema of every contract kind, keyed by kind
 // @Tags         contracts
 // @Produce      json
 // @Success      200  {object}  map[string]interface{}
 // @Router       /v1/contracts/schema [get]
 func ContractSchema(w http.ResponseWriter, r *http.Request) {
-	schemas := map[string]interface{}{
-		"application": map[string]interface{}{
-			"kind": "application",
-			"metadata": map[string]interface{}{
-				"name":  "string",
-				"owner": "string",
-			},
-			"spec": map[string]interface{}{
-				"description":  "string",
-				"tags":         []string{"string"},
-				"environments": []string{"string"},
-				"lifecycle":    map[string]interface{}{},
-			},
-		},
-		"service": map[string]interface{}{
-			"kind": "service",
-			"metadata": map[string]interface{}{
-				"name":  "string",
-				"owner": "string",
-			},
-			"spec": map[string]interface{}{
-				"application": "string",
-				"port":        8080,
-				"public":      true,
-			},
-		},
-	}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(schemas)
+	json.NewEncoder(w).Encode(contracts.Schemas())
+}
+
+// ContractSchemaByKind godoc
+// @Summary      Get contract schema by kind
+// @Description  Returns the JSON schema for one contract kind (application, service, environment, resource, ...)
+// @Tags         contracts
+// @Produce      json
+// @Param        kind  path  string  true  "Contract kind"
+// @Success      200  {object}  map[string]interface{}
+// @Failure      404  {object}  map[string]string
+// @Router       /v1/contracts/schema/{kind} [get]
+func ContractSchemaByKind(w http.ResponseWriter, r *http.Request) {
+	writeContractSchema(w, chi.URLParam(r, "kind"))
 }
 
 // ApplicationSchema godoc
 // @Summary      Get application contract schema
-// @Description  Returns example schema for application contract
+// @Description  Returns the JSON schema for the application contract
 // @Tags         applications
 // @Produce      json
 // @Success      200  {object}  map[string]interface{}
 // @Router       /v1/applications/schema [get]
 func ApplicationSchema(w http.ResponseWriter, r *http.Request) {
-	schema := map[string]interface{}{
-		"metadata": map[string]interface{}{
-			"name":  "string",
-			"owner": "string",
-		},
-		"spec": map[string]interface{}{
-			"description":  "string",
-			"tags":         []string{"string"},
-			"environments": []string{"string"},
-			"lifecycle":    map[string]interface{}{},
-		},
-	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(schema)
+	writeContractSchema(w, contracts.ApplicationContract{}.Kind())
 }
 
 // ServiceSchema godoc
@@ -77,29 +54,15 @@ func ApplicationSchema(w http.ResponseWriter, r *http.Request) {
 // @Success      200  {object}  map[string]interface{}
 // @Router       /v1/applications/{app_name}/services/schema [get]
 func ServiceSchema(w http.ResponseWriter, r *http.Request) {
-	schema := map[string]interface{}{
-		"type": "object",
-		"properties": map[string]interface{}{
-			"metadata": map[string]interface{}{
-				"type": "object",
-				"properties": map[string]interface{}{
-					"name":  map[string]interface{}{"type": "string"},
-					"owner": map[string]interface{}{"type": "string"},
-				},
-				"required": []string{"name", "owner"},
-			},
-			"spec": map[string]interface{}{
-				"type": "object",
-				"properties": map[string]interface{}{
-					"application": map[string]interface{}{"type": "string"},
-					"port":        map[string]interface{}{"type": "integer"},
-					"public":      map[string]interface{}{"type": "boolean"},
-				},
-				"required": []string{"application", "port", "public"},
-			},
-		},
-		"required": []string{"metadata", "spec"},
+	writeContractSchema(w, contracts.ServiceContract{}.Kind())
+}
+
+func writeContractSchema(w http.ResponseWriter, kind string) {
+	schema, err := contracts.Schema(kind)
+	if errors.Is(err, contracts.ErrUnknownKind) {
+		WriteJSONError(w, "Unknown contract kind: "+kind, http.StatusNotFound)
+		return
 	}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(schema)
+	w.Write(schema)
 }
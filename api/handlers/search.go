@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krzachariassen/ZTDP/internal/search"
+)
+
+// Search godoc
+// @Summary      Search platform objects
+// @Description  Finds applications, services, environments, resources and policies by kind, tags, owner and name/description text. kind and tag accept repeated or comma-separated values; every tag must match.
+// @Tags         search
+// @Produce      json
+// @Param        kind   query     string  false  "Node kinds"
+// @Param        tag    query     string  false  "Tags"
+// @Param        owner  query     string  false  "Owner"
+// @Param        q      query     string  false  "Text in name or description"
+// @Param        limit  query     int     false  "Maximum results (default 50)"
+// @Success      200    {object}  search.Results
+// @Failure      400    {object}  map[string]string
+// @Router       /v1/search [get]
+func Search(w http.ResponseWriter, r *http.Request) {
+	query, err := searchQueryFromRequest(r)
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	results, err := search.NewService(requestGraph(r)).Search(query)
+	if err != nil {
+		WriteJSONError(w, "Search failed", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+func searchQueryFromRequest(r *http.Request) (search.Query, error) {
+	params := r.URL.Query()
+	query := search.Query{
+		Kinds: splitParam(params["kind"]),
+		Tags:  splitParam(params["tag"]),
+		Owner: params.Get("owner"),
+		Text:  params.Get("q"),
+	}
+	if limit := params.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 0 {
+			return query, errors.New("limit must be a non-negative integer")
+		}
+		query.Limit = n
+	}
+	return query, nil
+}
+
+// splitParam flattens repeated and comma-separated query values
+func splitParam(values []string) []string {
+	var out []string
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, part)
+			}
+		}
+	}
+	return out
+}
+
+// ListSavedSearches godoc
+// @Summary      List saved searches
+// @Tags         search
+// @Produce      json
+// @Param        user  query     string  true  "User"
+// @Success      200   {array}   search.SavedSearch
+// @Failure      400   {object}  map[string]string
+// @Router       /v1/search/saved [get]
+func ListSavedSearches(w http.ResponseWriter, r *http.Request) {
+	user := r.URL.Query().Get("user")
+	if user == "" {
+		WriteJSONError(w, "user is required", http.StatusBadRequest)
+		return
+	}
+	saved, err := search.NewService(requestGraph(r)).ListSaved(user)
+	if err != nil {
+		WriteJSONError(w, "Failed to list saved searches", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(saved)
+}
+
+// SaveSearch godoc
+// @Summary      Save a search
+// @Description  Stores a query under a name for the user, replacing any saved search with the same name
+// @Tags         search
+// @Accept       json
+// @Produce      json
+// @Param        user   path      string        true  "User"
+// @Param        name   path      string        true  "Search name"
+// @Param        query  body      search.Query  true  "Query"
+// @Success      200    {object}  search.SavedSearch
+// @Failure      400    {object}  map[string]string
+// @Router       /v1/search/saved/{user}/{name} [put]
+func SaveSearch(w http.ResponseWriter, r *http.Request) {
+	var query search.Query
+	if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	saved, err := search.NewService(requestGraph(r)).Save(chi.URLParam(r, "user"), chi.URLParam(r, "name"), query)
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(saved)
+}
+
+// RunSavedSearch godoc
+// @Summary      Run a saved search
+// @Tags         search
+// @Produce      json
+// @Param        user  path      string  true  "User"
+// @Param        name  path      string  true  "Search name"
+// @Success      200   {object}  search.Results
+// @Failure      404   {object}  map[string]string
+// @Router       /v1/search/saved/{user}/{name} [get]
+func RunSavedSearch(w http.ResponseWriter, r *http.Request) {
+	results, err := search.NewService(requestGraph(r)).RunSaved(chi.URLParam(r, "user"), chi.URLParam(r, "name"))
+	if errors.Is(err, search.ErrSavedSearchNotFound) {
+		WriteJSONError(w, "Saved search not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		WriteJSONError(w, "Search failed", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+// DeleteSavedSearch godoc
+// @Summary      Delete a saved search
+// @Tags         search
+// @Param        user  path  string  true  "User"
+// @Param        name  path  string  true  "Search name"
+// @Success      204
+// @Failure      404  {object}  map[string]string
+// @Router       /v1/search/saved/{user}/{name} [delete]
+func DeleteSavedSearch(w http.ResponseWriter, r *http.Request) {
+	err := search.NewService(requestGraph(r)).DeleteSaved(chi.URLParam(r, "user"), chi.URLParam(r, "name"))
+	if errors.Is(err, search.ErrSavedSearchNotFound) {
+		WriteJSONError(w, "Saved search not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		WriteJSONError(w, "Failed to delete saved search", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
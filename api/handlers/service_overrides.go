@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krzachariassen/ZTDP/internal/contracts"
+	servicecore "github.com/krzachariassen/ZTDP/internal/service"
+)
+
+// SetServiceOverride godoc
+// @Summary      Set a service's environment override
+// @Description  Stores replicas, tier and env var overrides applied when the service is deployed to the environment. The merged configuration must satisfy the environment's constraints.
+// @Tags         services
+// @Accept       json
+// @Produce      json
+// @Param        app_name      path      string                     true  "Application name"
+// @Param        service_name  path      string                     true  "Service name"
+// @Param        env           path      string                     true  "Environment name"
+// @Param        override      body      contracts.ServiceOverride  true  "Override"
+// @Success      200           {object}  map[string]interface{}
+// @Failure      400           {object}  map[string]string
+// @Failure      404           {object}  map[string]string
+// @Router       /v1/applications/{app_name}/services/{service_name}/overrides/{env} [put]
+func SetServiceOverride(w http.ResponseWriter, r *http.Request) {
+	var override contracts.ServiceOverride
+	if err := json.NewDecoder(r.Body).Decode(&override); err != nil {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	serviceService := servicecore.NewServiceService(requestGraph(r))
+	svc, err := serviceService.SetEnvironmentOverride(chi.URLParam(r, "app_name"), chi.URLParam(r, "service_name"), chi.URLParam(r, "env"), override)
+	if err != nil {
+		writeOverrideError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(svc)
+}
+
+// DeleteServiceOverride godoc
+// @Summary      Remove a service's environment override
+// @Tags         services
+// @Param        app_name      path  string  true  "Application name"
+// @Param        service_name  path  string  true  "Service name"
+// @Param        env           path  string  true  "Environment name"
+// @Success      204
+// @Failure      404  {object}  map[string]string
+// @Router       /v1/applications/{app_name}/services/{service_name}/overrides/{env} [delete]
+func DeleteServiceOverride(w http.ResponseWriter, r *http.Request) {
+	serviceService := servicecore.NewServiceService(requestGraph(r))
+	if err := serviceService.DeleteEnvironmentOverride(chi.URLParam(r, "app_name"), chi.URLParam(r, "service_name"), chi.URLParam(r, "env")); err != nil {
+		WriteJSONError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetServiceEnvironmentConfig godoc
+// @Summary      Get a service's effective configuration for an environment
+// @Description  Returns the service spec with the environment's override merged in, as a deployment would use it
+// @Tags         services
+// @Produce      json
+// @Param        app_name      path      string  true  "Application name"
+// @Param        service_name  path      string  true  "Service name"
+// @Param        env           path      string  true  "Environment name"
+// @Success      200           {object}  contracts.ServiceSpec
+// @Failure      400           {object}  map[string]string
+// @Failure      404           {object}  map[string]string
+// @Router       /v1/applications/{app_name}/services/{service_name}/environments/{env}/config [get]
+func GetServiceEnvironmentConfig(w http.ResponseWriter, r *http.Request) {
+	serviceService := servicecore.NewServiceService(requestGraph(r))
+	config, err := serviceService.EffectiveConfig(chi.URLParam(r, "app_name"), chi.URLParam(r, "service_name"), chi.URLParam(r, "env"))
+	if err != nil {
+		writeOverrideError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(config)
+}
+
+// GetServiceConfigValues godoc
+// @Summary      Resolve a service's config contract for an environment
+// @Description  Returns the value and origin (env var, resource binding or default) of every config key the service declares, with secret values masked, and the problems that would block a deployment
+// @Tags         services
+// @Produce      json
+// @Param        app_name      path      string  true  "Application name"
+// @Param        service_name  path      string  true  "Service name"
+// @Param        env           path      string  true  "Environment name"
+// @Success      200           {object}  servicecore.ResolvedConfig
+// @Failure      400           {object}  map[string]string
+// @Failure      404           {object}  map[string]string
+// @Router       /v1/applications/{app_name}/services/{service_name}/environments/{env}/config/values [get]
+func GetServiceConfigValues(w http.ResponseWriter, r *http.Request) {
+	serviceService := servicecore.NewServiceService(requestGraph(r))
+	resolved, err := serviceService.EffectiveConfigContract(chi.URLParam(r, "app_name"), chi.URLParam(r, "service_name"), chi.URLParam(r, "env"))
+	if err != nil {
+		writeOverrideError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resolved)
+}
+
+func writeOverrideError(w http.ResponseWriter, err error) {
+	if strings.Contains(err.Error(), "not found") {
+		WriteJSONError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	WriteJSONError(w, err.Error(), http.StatusBadRequest)
+}
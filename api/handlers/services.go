@@ -2,9 +2,12 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/krzachariassen/ZTDP/internal/contracts"
 	servicecore "github.com/krzachariassen/ZTDP/internal/service"
 )
 
@@ -18,6 +21,7 @@ import (
 // @Param        service   body      map[string]interface{} true  "Service payload"
 // @Success      201  {object}  map[string]interface{}
 // @Failure      400  {object}  map[string]string
+// @Failure      403  {object}  map[string]interface{}  "Service quota exceeded"
 // @Router       /v1/applications/{app_name}/services [post]
 func CreateService(w http.ResponseWriter, r *http.Request) {
 	appName := chi.URLParam(r, "app_name")
@@ -26,9 +30,12 @@ func CreateService(w http.ResponseWriter, r *http.Request) {
 		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
 		return
 	}
-	serviceService := servicecore.NewServiceService(GlobalGraph)
+	serviceService := servicecore.NewServiceService(requestGraph(r))
 	createdSvc, err := serviceService.CreateService(appName, svcData)
 	if err != nil {
+		if writeQuotaError(w, err) {
+			return
+		}
 		WriteJSONError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -46,7 +53,7 @@ func CreateService(w http.ResponseWriter, r *http.Request) {
 // @Router       /v1/applications/{app_name}/services [get]
 func ListServices(w http.ResponseWriter, r *http.Request) {
 	appName := chi.URLParam(r, "app_name")
-	serviceService := servicecore.NewServiceService(GlobalGraph)
+	serviceService := servicecore.NewServiceService(requestGraph(r))
 	services, err := serviceService.ListServices(appName)
 	if err != nil {
 		WriteJSONError(w, "Failed to get services", http.StatusInternalServerError)
@@ -69,7 +76,7 @@ func ListServices(w http.ResponseWriter, r *http.Request) {
 func GetService(w http.ResponseWriter, r *http.Request) {
 	appName := chi.URLParam(r, "app_name")
 	serviceName := chi.URLParam(r, "service_name")
-	serviceService := servicecore.NewServiceService(GlobalGraph)
+	serviceService := servicecore.NewServiceService(requestGraph(r))
 	service, err := serviceService.GetService(appName, serviceName)
 	if err != nil {
 		WriteJSONError(w, err.Error(), http.StatusNotFound)
@@ -100,7 +107,7 @@ func CreateServiceVersion(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	serviceService := servicecore.NewServiceService(GlobalGraph)
+	serviceService := servicecore.NewServiceService(requestGraph(r))
 	createdVersion, err := serviceService.CreateServiceVersion(serviceName, versionData)
 	if err != nil {
 		WriteJSONError(w, err.Error(), http.StatusBadRequest)
@@ -111,6 +118,63 @@ func CreateServiceVersion(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(createdVersion)
 }
 
+// AttachServiceVersionScan godoc
+// @Summary      Attach an SBOM and vulnerability scan to a service version
+// @Description  Stores a scan report (e.g. produced in CI) on the version, replacing any earlier one. The Policy Agent blocks deployments of versions with more critical CVEs than vulnerabilities.max_critical.
+// @Tags         services
+// @Accept       json
+// @Produce      json
+// @Param        app_name     path  string                true  "Application name"
+// @Param        service_name path  string                true  "Service name"
+// @Param        version      path  string                true  "Version"
+// @Param        report       body  contracts.ScanReport  true  "Scan report"
+// @Success      200  {object}  contracts.ScanReport
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /v1/applications/{app_name}/services/{service_name}/versions/{version}/scan [post]
+func AttachServiceVersionScan(w http.ResponseWriter, r *http.Request) {
+	var report contracts.ScanReport
+	if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	serviceService := servicecore.NewServiceService(requestGraph(r))
+	stored, err := serviceService.AttachScanReport(chi.URLParam(r, "service_name"), chi.URLParam(r, "version"), report)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			WriteJSONError(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stored)
+}
+
+// GetServiceVersionScan godoc
+// @Summary      Get a service version's vulnerability scan
+// @Description  Returns the SBOM and vulnerability report stored on the version
+// @Tags         services
+// @Produce      json
+// @Param        app_name     path  string  true  "Application name"
+// @Param        service_name path  string  true  "Service name"
+// @Param        version      path  string  true  "Version"
+// @Success      200  {object}  contracts.ScanReport
+// @Failure      404  {object}  map[string]string
+// @Router       /v1/applications/{app_name}/services/{service_name}/versions/{version}/scan [get]
+func GetServiceVersionScan(w http.ResponseWriter, r *http.Request) {
+	serviceService := servicecore.NewServiceService(requestGraph(r))
+	report, err := serviceService.GetScanReport(chi.URLParam(r, "service_name"), chi.URLParam(r, "version"))
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
 // ListServiceVersions godoc
 // @Summary      List all versions for a service
 // @Description  Returns all versions for a service
@@ -122,7 +186,7 @@ func CreateServiceVersion(w http.ResponseWriter, r *http.Request) {
 // @Router       /v1/applications/{app_name}/services/{service_name}/versions [get]
 func ListServiceVersions(w http.ResponseWriter, r *http.Request) {
 	serviceName := chi.URLParam(r, "service_name")
-	serviceService := servicecore.NewServiceService(GlobalGraph)
+	serviceService := servicecore.NewServiceService(requestGraph(r))
 	versions, err := serviceService.ListServiceVersions(serviceName)
 	if err != nil {
 		WriteJSONError(w, "Failed to get service versions", http.StatusInternalServerError)
@@ -131,3 +195,134 @@ func ListServiceVersions(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(versions)
 }
+
+// GetServiceVersionLifecycle godoc
+// @Summary      Get a service version's lifecycle state
+// @Description  Returns the version's lifecycle state (active, deprecated, sunset), its sunset schedule and the services pinned to it
+// @Tags         services
+// @Produce      json
+// @Param        app_name     path  string  true  "Application name"
+// @Param        service_name path  string  true  "Service name"
+// @Param        version      path  string  true  "Version"
+// @Success      200  {object}  servicecore.VersionLifecycle
+// @Failure      404  {object}  map[string]string
+// @Router       /v1/applications/{app_name}/services/{service_name}/versions/{version}/lifecycle [get]
+func GetServiceVersionLifecycle(w http.ResponseWriter, r *http.Request) {
+	serviceService := servicecore.NewServiceService(requestGraph(r))
+	lifecycle, err := serviceService.GetVersionLifecycle(chi.URLParam(r, "service_name"), chi.URLParam(r, "version"))
+	if err != nil {
+		writeVersionLifecycleError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(lifecycle)
+}
+
+// TransitionServiceVersionLifecycle godoc
+// @Summary      Deprecate or sunset a service version
+// @Description  Moves the version to a new lifecycle state and notifies the services pinned to it. New dependencies on deprecated or sunset versions are refused, and deployments of services pinned to a sunset version are blocked.
+// @Tags         services
+// @Accept       json
+// @Produce      json
+// @Param        app_name     path  string                         true  "Application name"
+// @Param        service_name path  string                         true  "Service name"
+// @Param        version      path  string                         true  "Version"
+// @Param        transition   body  servicecore.VersionTransition  true  "Target state, reason, optional sunset date and replacement version"
+// @Success      200  {object}  servicecore.VersionLifecycle
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Router       /v1/applications/{app_name}/services/{service_name}/versions/{version}/lifecycle [post]
+func TransitionServiceVersionLifecycle(w http.ResponseWriter, r *http.Request) {
+	var req servicecore.VersionTransition
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.State == "" {
+		WriteJSONError(w, "state is required", http.StatusBadRequest)
+		return
+	}
+
+	serviceService := servicecore.NewServiceService(requestGraph(r))
+	lifecycle, err := serviceService.TransitionVersion(chi.URLParam(r, "service_name"), chi.URLParam(r, "version"), req)
+	if err != nil {
+		writeVersionLifecycleError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(lifecycle)
+}
+
+// GetSunsetReport godoc
+// @Summary      List versions scheduled for sunset
+// @Description  Returns the deprecated versions with a sunset date and the versions already sunset, with the services still pinned to them, soonest sunset first
+// @Tags         services
+// @Produce      json
+// @Success      200  {array}   servicecore.VersionLifecycle
+// @Failure      500  {object}  map[string]string
+// @Router       /v1/services/sunset-report [get]
+func GetSunsetReport(w http.ResponseWriter, r *http.Request) {
+	serviceService := servicecore.NewServiceService(requestGraph(r))
+	report, err := serviceService.SunsetReport()
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+func writeVersionLifecycleError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		WriteJSONError(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, servicecore.ErrInvalidVersionTransition):
+		WriteJSONError(w, err.Error(), http.StatusConflict)
+	default:
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+// GetAPICatalog godoc
+// @Summary      List the API catalog
+// @Description  Returns every service with the port it exposes, the services that declare a dependency on it and the services it consumes
+// @Tags         services
+// @Produce      json
+// @Success      200  {array}   servicecore.APIEntry
+// @Failure      500  {object}  map[string]string
+// @Router       /v1/services/catalog [get]
+func GetAPICatalog(w http.ResponseWriter, r *http.Request) {
+	serviceService := servicecore.NewServiceService(requestGraph(r))
+	catalog, err := serviceService.APICatalog()
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(catalog)
+}
+
+// ListEnvironmentRoutes godoc
+// @Summary      List an environment's ingress routes
+// @Description  Returns the hostnames and paths public services are routed under in the environment, with their TLS configuration
+// @Tags         services
+// @Produce      json
+// @Param        env  path      string  true  "Environment name"
+// @Success      200  {array}   servicecore.RouteEntry
+// @Failure      404  {object}  map[string]string
+// @Router       /v1/environments/{env}/routes [get]
+func ListEnvironmentRoutes(w http.ResponseWriter, r *http.Request) {
+	serviceService := servicecore.NewServiceService(requestGraph(r))
+	routes, err := serviceService.EnvironmentRoutes(chi.URLParam(r, "env"))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			WriteJSONError(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(routes)
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krzachariassen/ZTDP/internal/resources"
+)
+
+// CreateSharedResource godoc
+// @Summary      Create a shared resource instance
+// @Description  Creates a resource instance from a catalog resource that is owned by a team and used by applications and services through access grants
+// @Tags         resources
+// @Accept       json
+// @Produce      json
+// @Param        resource  body      resources.SharedResourceRequest  true  "Shared instance, access policy and capacity"
+// @Success      201       {object}  resources.ResourceResponse
+// @Failure      400       {object}  map[string]string
+// @Failure      404       {object}  map[string]string
+// @Failure      409       {object}  map[string]string
+// @Router       /v1/shared-resources [post]
+func CreateSharedResource(w http.ResponseWriter, r *http.Request) {
+	var req resources.SharedResourceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	response, err := resources.NewService(requestGraph(r)).CreateSharedResource(req)
+	if err != nil {
+		switch {
+		case err.Error() == "resource not found in catalog":
+			WriteJSONError(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, resources.ErrInstanceNameConflict):
+			WriteJSONError(w, err.Error(), http.StatusConflict)
+		default:
+			WriteJSONError(w, err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+// ListResourceGrants godoc
+// @Summary      List access grants on a shared resource
+// @Description  Returns the applications and services granted access to the shared resource, with their access level and quota
+// @Tags         resources
+// @Produce      json
+// @Param        resource_name  path      string  true  "Shared resource name"
+// @Success      200            {array}   resources.AccessGrant
+// @Failure      404            {object}  map[string]string
+// @Router       /v1/shared-resources/{resource_name}/grants [get]
+func ListResourceGrants(w http.ResponseWriter, r *http.Request) {
+	grants, err := resources.NewService(requestGraph(r)).ListGrants(chi.URLParam(r, "resource_name"))
+	if err != nil {
+		writeGrantError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(grants)
+}
+
+// RequestResourceAccess godoc
+// @Summary      Request access to a shared resource
+// @Description  Grants an application or service access to the shared resource if its access policy allows the consumer's team or application and the requested quota fits the remaining capacity. Requesting again replaces the consumer's grant.
+// @Tags         resources
+// @Accept       json
+// @Produce      json
+// @Param        resource_name  path      string                   true  "Shared resource name"
+// @Param        request        body      resources.AccessRequest  true  "Consumer, access level and quota"
+// @Success      201            {object}  resources.AccessGrant
+// @Failure      400            {object}  map[string]string
+// @Failure      403            {object}  resources.AccessDeniedError
+// @Failure      404            {object}  map[string]string
+// @Router       /v1/shared-resources/{resource_name}/grants [post]
+func RequestResourceAccess(w http.ResponseWriter, r *http.Request) {
+	var req resources.AccessRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.Consumer == "" {
+		WriteJSONError(w, "consumer is required", http.StatusBadRequest)
+		return
+	}
+
+	grant, err := resources.NewService(requestGraph(r)).RequestAccess(chi.URLParam(r, "resource_name"), req)
+	if err != nil {
+		writeGrantError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(grant)
+}
+
+// RevokeResourceAccess godoc
+// @Summary      Revoke access to a shared resource
+// @Description  Removes the consumer's grant on the shared resource
+// @Tags         resources
+// @Param        resource_name  path  string  true  "Shared resource name"
+// @Param        consumer       path  string  true  "Application or service"
+// @Success      204
+// @Failure      404  {object}  map[string]string
+// @Router       /v1/shared-resources/{resource_name}/grants/{consumer} [delete]
+func RevokeResourceAccess(w http.ResponseWriter, r *http.Request) {
+	if err := resources.NewService(requestGraph(r)).RevokeAccess(chi.URLParam(r, "resource_name"), chi.URLParam(r, "consumer")); err != nil {
+		writeGrantError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeGrantError(w http.ResponseWriter, err error) {
+	var denied *resources.AccessDeniedError
+	switch {
+	case errors.As(err, &denied):
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]interface{}{"error": denied.Error(), "denied": denied})
+	case errors.Is(err, resources.ErrSharedResourceNotFound), errors.Is(err, resources.ErrGrantNotFound):
+		WriteJSONError(w, err.Error(), http.StatusNotFound)
+	default:
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+	}
+}
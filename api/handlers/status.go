@@ -3,11 +3,21 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+
+	"github.com/krzachariassen/ZTDP/internal/degradation"
 )
 
+// degradationMonitor is nil when graceful degradation is disabled
+var degradationMonitor *degradation.Monitor
+
+// SetupDegradation sets the monitor whose service tier /v1/status reports (called from main.go)
+func SetupDegradation(monitor *degradation.Monitor) {
+	degradationMonitor = monitor
+}
+
 // Status godoc
 // @Summary      Get platform status
-// @Description  Returns high-level platform status and graph node count
+// @Description  Returns high-level platform status, the graph node count and, when graceful degradation is enabled, the service tier with the dependencies it is degraded around and recent transitions
 // @Tags         status
 // @Produce      json
 // @Success      200  {object}  map[string]interface{}
@@ -22,6 +32,9 @@ func Status(w http.ResponseWriter, r *http.Request) {
 		"graph_nodes": nodeCount,
 		// Add more fields as needed
 	}
+	if degradationMonitor != nil {
+		status["degradation"] = degradationMonitor.Status()
+	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(status)
 }
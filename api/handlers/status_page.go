@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krzachariassen/ZTDP/internal/statuspage"
+)
+
+// statusPageService builds application status pages; nil until set up from main.go
+var statusPageService *statuspage.Service
+
+// SetupStatusPage sets the service behind the status page endpoint (called from main.go)
+func SetupStatusPage(service *statuspage.Service) {
+	statusPageService = service
+}
+
+// GetApplicationStatusPage godoc
+// @Summary      Get an application's status page
+// @Description  Summarizes the deployed release and service versions, health, open incidents and recent deployment changes of an application in each environment, as JSON or as an HTML page for embedding in dashboards
+// @Tags         applications
+// @Produce      json,html
+// @Param        app_name  path      string  true   "Application name"
+// @Param        format    query     string  false  "json (default) or html"
+// @Success      200       {object}  statuspage.Page
+// @Failure      400       {object}  map[string]string
+// @Failure      404       {object}  map[string]string
+// @Failure      503       {object}  map[string]string
+// @Router       /v1/applications/{app_name}/status-page [get]
+func GetApplicationStatusPage(w http.ResponseWriter, r *http.Request) {
+	if statusPageService == nil {
+		WriteJSONError(w, "Status pages are not configured", http.StatusServiceUnavailable)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "html" {
+		WriteJSONError(w, "format must be json or html", http.StatusBadRequest)
+		return
+	}
+
+	page, err := statusPageService.Generate(chi.URLParam(r, "app_name"))
+	if err != nil {
+		if errors.Is(err, statuspage.ErrApplicationNotFound) {
+			WriteJSONError(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if format == "html" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		statuspage.RenderHTML(w, page)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
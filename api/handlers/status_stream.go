@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/statusfeed"
+)
+
+// statusFeedKeepAlive is how often an idle stream sends a comment so proxies keep it open
+const statusFeedKeepAlive = 15 * time.Second
+
+// statusFeed provides the updates served by the status stream
+var statusFeed *statusfeed.Feed
+
+// SetupStatusFeed sets the feed used by the status stream endpoint (called from main.go)
+func SetupStatusFeed(feed *statusfeed.Feed) {
+	statusFeed = feed
+}
+
+// StreamStatus godoc
+// @Summary      Stream deployment and orchestration status
+// @Description  Server-sent events with deployment progress for the selected applications and the status of the user's chat requests. Each event is named after its kind (deployment or orchestration) and carries a statusfeed.Update as JSON.
+// @Tags         status
+// @Produce      text/event-stream
+// @Param        user         query     string  false  "Orchestration updates for this user's chat requests"
+// @Param        owner        query     string  false  "Deployment updates for applications with this owner"
+// @Param        application  query     []string  false  "Deployment updates for these applications (repeatable)"
+// @Success      200          {object}  statusfeed.Update
+// @Failure      400          {object}  map[string]string
+// @Failure      503          {object}  map[string]string
+// @Router       /v1/status/stream [get]
+func StreamStatus(w http.ResponseWriter, r *http.Request) {
+	if statusFeed == nil {
+		WriteJSONError(w, "Status streaming is not available", http.StatusServiceUnavailable)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		WriteJSONError(w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := statusfeed.Filter{
+		User:         query.Get("user"),
+		Owner:        query.Get("owner"),
+		Applications: query["application"],
+	}
+	if filter.Empty() {
+		WriteJSONError(w, "user, owner or application is required", http.StatusBadRequest)
+		return
+	}
+
+	updates, cancel := statusFeed.Subscribe(filter)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(statusFeedKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case update, ok := <-updates:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(update)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", update.Kind, data)
+			flusher.Flush()
+		}
+	}
+}
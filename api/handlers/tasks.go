@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// GetTask godoc
+// @Summary      State of a task dispatched to agents
+// @Description  Reports whether the request was acknowledged, which agents it was delivered to and why earlier deliveries were abandoned (nack or ack timeout)
+// @Tags         agents
+// @Produce      json
+// @Param        correlation_id  path      string  true  "Correlation ID of the request"
+// @Success      200  {object}  orchestrator.Task
+// @Failure      404  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Router       /v1/tasks/{correlation_id} [get]
+func GetTask(w http.ResponseWriter, r *http.Request) {
+	orch := GetGlobalOrchestrator()
+	if orch == nil {
+		WriteJSONError(w, "Orchestrator not available", http.StatusServiceUnavailable)
+		return
+	}
+	task, ok := orch.Task(chi.URLParam(r, "correlation_id"))
+	if !ok {
+		WriteJSONError(w, "Task not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(task)
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krzachariassen/ZTDP/internal/templates"
+)
+
+// templateCatalog holds the golden-path templates
+var templateCatalog *templates.Catalog
+
+// SetupTemplates sets the template catalog used by the template endpoints (called from main.go)
+func SetupTemplates(catalog *templates.Catalog) {
+	templateCatalog = catalog
+}
+
+// TemplateValidation is the validation result for one template definition
+type TemplateValidation struct {
+	Name     string   `json:"name"`
+	Valid    bool     `json:"valid"`
+	Problems []string `json:"problems,omitempty"`
+}
+
+// ListTemplates godoc
+// @Summary      List golden-path templates
+// @Description  Returns the templates platform teams publish, sorted by name
+// @Tags         templates
+// @Produce      json
+// @Success      200  {array}   templates.Template
+// @Failure      503  {object}  map[string]string
+// @Router       /v1/templates [get]
+func ListTemplates(w http.ResponseWriter, r *http.Request) {
+	if templateCatalog == nil {
+		WriteJSONError(w, "Templates are disabled", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(templateCatalog.List())
+}
+
+// GetTemplate godoc
+// @Summary      Get a golden-path template
+// @Tags         templates
+// @Produce      json
+// @Param        name  path      string  true  "Template name"
+// @Success      200   {object}  templates.Template
+// @Failure      404   {object}  map[string]string
+// @Failure      503   {object}  map[string]string
+// @Router       /v1/templates/{name} [get]
+func GetTemplate(w http.ResponseWriter, r *http.Request) {
+	if templateCatalog == nil {
+		WriteJSONError(w, "Templates are disabled", http.StatusServiceUnavailable)
+		return
+	}
+	template, err := templateCatalog.Get(chi.URLParam(r, "name"))
+	if errors.Is(err, templates.ErrTemplateNotFound) {
+		WriteJSONError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(template)
+}
+
+// ValidateTemplates godoc
+// @Summary      Validate template definitions
+// @Description  Checks YAML or JSON template definitions (several YAML documents allowed) without publishing them: names, parameters, step IDs, dependencies and {{parameter}} references
+// @Tags         templates
+// @Accept       json
+// @Produce      json
+// @Success      200  {array}   TemplateValidation
+// @Failure      400  {object}  map[string]string
+// @Router       /v1/templates/validate [post]
+func ValidateTemplates(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		WriteJSONError(w, "Failed to read request body", http.StatusBadRequest)
+		return
+	}
+	definitions, err := templates.Parse(data)
+	if err != nil {
+		WriteJSONError(w, "Invalid template definition: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(definitions) == 0 {
+		WriteJSONError(w, "No template definitions in request body", http.StatusBadRequest)
+		return
+	}
+
+	results := make([]TemplateValidation, 0, len(definitions))
+	for _, definition := range definitions {
+		problems := definition.Validate()
+		results = append(results, TemplateValidation{Name: definition.Name, Valid: len(problems) == 0, Problems: problems})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
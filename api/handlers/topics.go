@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krzachariassen/ZTDP/internal/resources"
+)
+
+// CreateTopic godoc
+// @Summary      Create a topic or queue on a messaging resource
+// @Description  Adds a Kafka topic or RabbitMQ queue to a messaging resource instance
+// @Tags         resources
+// @Accept       json
+// @Produce      json
+// @Param        resource_name  path      string           true  "Messaging resource instance"
+// @Param        topic          body      resources.Topic  true  "Topic with name and optional partitions and retention"
+// @Success      201            {object}  resources.Topic
+// @Failure      400            {object}  map[string]string
+// @Failure      404            {object}  map[string]string
+// @Router       /v1/resources/{resource_name}/topics [post]
+func CreateTopic(w http.ResponseWriter, r *http.Request) {
+	var topic resources.Topic
+	if err := json.NewDecoder(r.Body).Decode(&topic); err != nil {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	created, err := resources.NewService(requestGraph(r)).CreateTopic(chi.URLParam(r, "resource_name"), topic)
+	if err != nil {
+		writeTopicError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// ListTopics godoc
+// @Summary      List the topics of a messaging resource
+// @Description  Returns the resource's topics or queues with the services allowed to produce to and consume from each
+// @Tags         resources
+// @Produce      json
+// @Param        resource_name  path      string  true  "Messaging resource instance"
+// @Success      200            {array}   resources.TopicDetails
+// @Failure      400            {object}  map[string]string
+// @Failure      404            {object}  map[string]string
+// @Router       /v1/resources/{resource_name}/topics [get]
+func ListTopics(w http.ResponseWriter, r *http.Request) {
+	topics, err := resources.NewService(requestGraph(r)).ListTopics(chi.URLParam(r, "resource_name"))
+	if err != nil {
+		writeTopicError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(topics)
+}
+
+// BindTopic godoc
+// @Summary      Allow a service to produce to or consume from a topic
+// @Description  Records a produce or consume binding from the service to the topic and renders the resource's ACLs again. The service must use the resource or have an access grant on it.
+// @Tags         resources
+// @Accept       json
+// @Produce      json
+// @Param        resource_name  path      string                    true  "Messaging resource instance"
+// @Param        topic          path      string                    true  "Topic or queue name"
+// @Param        binding        body      resources.BindingRequest  true  "Service, operation (produce or consume) and optional consumer group"
+// @Success      201            {object}  resources.TopicBinding
+// @Failure      400            {object}  map[string]string
+// @Failure      404            {object}  map[string]string
+// @Router       /v1/resources/{resource_name}/topics/{topic}/bindings [post]
+func BindTopic(w http.ResponseWriter, r *http.Request) {
+	var req resources.BindingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.Service == "" {
+		WriteJSONError(w, "service is required", http.StatusBadRequest)
+		return
+	}
+	binding, err := resources.NewService(requestGraph(r)).BindTopic(chi.URLParam(r, "resource_name"), chi.URLParam(r, "topic"), req)
+	if err != nil {
+		writeTopicError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(binding)
+}
+
+// UnbindTopic godoc
+// @Summary      Remove a service's produce or consume binding
+// @Description  Removes the binding and renders the resource's ACLs again
+// @Tags         resources
+// @Param        resource_name  path  string  true  "Messaging resource instance"
+// @Param        topic          path  string  true  "Topic or queue name"
+// @Param        service        path  string  true  "Service"
+// @Param        operation      path  string  true  "produce or consume"
+// @Success      204
+// @Failure      400  {object}  map[string]string
+// @Failure      404  {object}  map[string]string
+// @Router       /v1/resources/{resource_name}/topics/{topic}/bindings/{service}/{operation} [delete]
+func UnbindTopic(w http.ResponseWriter, r *http.Request) {
+	err := resources.NewService(requestGraph(r)).UnbindTopic(chi.URLParam(r, "resource_name"), chi.URLParam(r, "topic"), chi.URLParam(r, "service"), chi.URLParam(r, "operation"))
+	if err != nil {
+		writeTopicError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetResourceACLs godoc
+// @Summary      Render the ACLs of a messaging resource
+// @Description  Renders the produce and consume bindings on the resource's topics with its resource type plugin, stores them on the instance for the provisioner and returns them
+// @Tags         resources
+// @Produce      json
+// @Param        resource_name  path      string  true  "Messaging resource instance"
+// @Success      200            {array}   string
+// @Failure      400            {object}  map[string]string
+// @Failure      404            {object}  map[string]string
+// @Router       /v1/resources/{resource_name}/acls [get]
+func GetResourceACLs(w http.ResponseWriter, r *http.Request) {
+	acls, err := resources.NewService(requestGraph(r)).RenderACLs(chi.URLParam(r, "resource_name"))
+	if err != nil {
+		writeTopicError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(acls)
+}
+
+func writeTopicError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, resources.ErrTopicNotFound), strings.Contains(err.Error(), "not found"):
+		WriteJSONError(w, err.Error(), http.StatusNotFound)
+	default:
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+	}
+}
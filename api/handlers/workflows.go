@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/krzachariassen/ZTDP/internal/workflows"
+)
+
+// workflowEngine runs durable workflows
+var workflowEngine *workflows.Engine
+
+// SetupWorkflows sets the engine used by the workflow endpoints (called from main.go)
+func SetupWorkflows(engine *workflows.Engine) {
+	workflowEngine = engine
+}
+
+// WorkflowSignalRequest carries the payload of a signal, e.g. who approved and why
+type WorkflowSignalRequest struct {
+	Payload map[string]interface{} `json:"payload,omitempty"`
+}
+
+// WorkflowCancelRequest carries why a workflow is cancelled
+type WorkflowCancelRequest struct {
+	Reason string `json:"reason"`
+}
+
+// WorkflowTemplateRequest carries the parameters and customization of a workflow started from a template
+type WorkflowTemplateRequest struct {
+	Params        map[string]string       `json:"params"`
+	Customization workflows.Customization `json:"customization"`
+}
+
+// ListWorkflows godoc
+// @Summary      List workflows
+// @Description  Returns workflow instances newest first, with their current step, what they wait for and their history
+// @Tags         workflows
+// @Produce      json
+// @Param        status  query     string  false  "running, waiting, completed, failed or cancelled"
+// @Success      200     {array}   workflows.Workflow
+// @Failure      503     {object}  map[string]string
+// @Router       /v1/workflows [get]
+func ListWorkflows(w http.ResponseWriter, r *http.Request) {
+	if workflowEngine == nil {
+		WriteJSONError(w, "Workflows are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+	list, err := workflowEngine.List(r.URL.Query().Get("status"))
+	if err != nil {
+		WriteJSONError(w, "Failed to list workflows", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// StartWorkflow godoc
+// @Summary      Start a workflow
+// @Description  Starts a workflow of action, wait and signal steps and runs it until its first wait. Action steps run a registered action (deploy, verify, promote) with the workflow input and the step's params, retrying with exponential backoff; wait steps wait for a delay or until a time; signal steps wait for a signal such as an approval.
+// @Tags         workflows
+// @Accept       json
+// @Produce      json
+// @Param        workflow  body      workflows.Workflow  true  "Name, input and steps"
+// @Success      201       {object}  workflows.Workflow
+// @Failure      400       {object}  map[string]string
+// @Failure      503       {object}  map[string]string
+// @Router       /v1/workflows [post]
+func StartWorkflow(w http.ResponseWriter, r *http.Request) {
+	if workflowEngine == nil {
+		WriteJSONError(w, "Workflows are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+	var workflow workflows.Workflow
+	if err := json.NewDecoder(r.Body).Decode(&workflow); err != nil {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	workflow.CreatedBy = logging.UserIDFromContext(r.Context())
+	if err := workflowEngine.Start(r.Context(), &workflow); err != nil {
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(workflow)
+}
+
+// ListWorkflowTemplates godoc
+// @Summary      List workflow templates
+// @Description  Returns the parameterized workflows for operational procedures, with their parameters and steps. Optional steps can be skipped and steps performed by agents reassigned when starting one.
+// @Tags         workflows
+// @Produce      json
+// @Success      200  {array}   workflows.Template
+// @Failure      503  {object}  map[string]string
+// @Router       /v1/workflows/templates [get]
+func ListWorkflowTemplates(w http.ResponseWriter, r *http.Request) {
+	if workflowEngine == nil {
+		WriteJSONError(w, "Workflows are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(workflowEngine.Templates())
+}
+
+// StartWorkflowTemplate godoc
+// @Summary      Start a workflow from a template
+// @Description  Fills the template's {{parameter}} placeholders, leaves out the optional steps listed in customization.skip and hands the steps in customization.assignments to agents offering the given capabilities, then starts the workflow
+// @Tags         workflows
+// @Accept       json
+// @Produce      json
+// @Param        name     path      string                   true  "Template name, e.g. incident-response"
+// @Param        request  body      WorkflowTemplateRequest  true  "Parameters and customization"
+// @Success      201      {object}  workflows.Workflow
+// @Failure      400      {object}  map[string]string
+// @Failure      404      {object}  map[string]string
+// @Failure      503      {object}  map[string]string
+// @Router       /v1/workflows/templates/{name} [post]
+func StartWorkflowTemplate(w http.ResponseWriter, r *http.Request) {
+	if workflowEngine == nil {
+		WriteJSONError(w, "Workflows are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+	var req WorkflowTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	workflow, err := workflowEngine.StartTemplate(r.Context(), chi.URLParam(r, "name"), req.Params, req.Customization, logging.UserIDFromContext(r.Context()))
+	if errors.Is(err, workflows.ErrTemplateNotFound) {
+		WriteJSONError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(workflow)
+}
+
+// GetWorkflow godoc
+// @Summary      Get a workflow
+// @Tags         workflows
+// @Produce      json
+// @Param        id   path      string  true  "Workflow ID"
+// @Success      200  {object}  workflows.Workflow
+// @Failure      404  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Router       /v1/workflows/{id} [get]
+func GetWorkflow(w http.ResponseWriter, r *http.Request) {
+	if workflowEngine == nil {
+		WriteJSONError(w, "Workflows are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+	workflow, err := workflowEngine.Get(chi.URLParam(r, "id"))
+	if err != nil {
+		writeWorkflowError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(workflow)
+}
+
+// SignalWorkflow godoc
+// @Summary      Signal a workflow
+// @Description  Delivers a signal, such as an approval, and advances the workflow. Signals a workflow is not waiting for yet are kept until a step waits for them.
+// @Tags         workflows
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                 true   "Workflow ID"
+// @Param        name     path      string                 true   "Signal name, e.g. approval"
+// @Param        request  body      WorkflowSignalRequest  false  "Signal payload"
+// @Success      200      {object}  workflows.Workflow
+// @Failure      400      {object}  map[string]string
+// @Failure      404      {object}  map[string]string
+// @Failure      409      {object}  map[string]string
+// @Failure      503      {object}  map[string]string
+// @Router       /v1/workflows/{id}/signals/{name} [post]
+func SignalWorkflow(w http.ResponseWriter, r *http.Request) {
+	if workflowEngine == nil {
+		WriteJSONError(w, "Workflows are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+	var req WorkflowSignalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	workflow, err := workflowEngine.Signal(r.Context(), chi.URLParam(r, "id"), chi.URLParam(r, "name"), req.Payload, logging.UserIDFromContext(r.Context()))
+	if err != nil {
+		writeWorkflowError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(workflow)
+}
+
+// CancelWorkflow godoc
+// @Summary      Cancel a workflow
+// @Tags         workflows
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                 true   "Workflow ID"
+// @Param        request  body      WorkflowCancelRequest  false  "Reason"
+// @Success      200      {object}  workflows.Workflow
+// @Failure      404      {object}  map[string]string
+// @Failure      409      {object}  map[string]string
+// @Failure      503      {object}  map[string]string
+// @Router       /v1/workflows/{id}/cancel [post]
+func CancelWorkflow(w http.ResponseWriter, r *http.Request) {
+	if workflowEngine == nil {
+		WriteJSONError(w, "Workflows are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+	var req WorkflowCancelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		WriteJSONError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	workflow, err := workflowEngine.Cancel(chi.URLParam(r, "id"), req.Reason, logging.UserIDFromContext(r.Context()))
+	if err != nil {
+		writeWorkflowError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(workflow)
+}
+
+// RetryWorkflow godoc
+// @Summary      Retry a failed workflow
+// @Description  Resumes a failed workflow at the step that failed, with its attempts reset
+// @Tags         workflows
+// @Produce      json
+// @Param        id   path      string  true  "Workflow ID"
+// @Success      200  {object}  workflows.Workflow
+// @Failure      404  {object}  map[string]string
+// @Failure      409  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+// @Router       /v1/workflows/{id}/retry [post]
+func RetryWorkflow(w http.ResponseWriter, r *http.Request) {
+	if workflowEngine == nil {
+		WriteJSONError(w, "Workflows are not enabled", http.StatusServiceUnavailable)
+		return
+	}
+	workflow, err := workflowEngine.Retry(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeWorkflowError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(workflow)
+}
+
+func writeWorkflowError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, workflows.ErrWorkflowNotFound):
+		WriteJSONError(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, workflows.ErrWorkflowFinished), errors.Is(err, workflows.ErrNotRetryable):
+		WriteJSONError(w, err.Error(), http.StatusConflict)
+	default:
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+	}
+}
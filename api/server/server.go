@@ -20,8 +20,41 @@ func SetupRoutes(r *chi.Mux) {
 		// SYSTEM ENDPOINTS
 		// =============================================================================
 		v1.Get("/health", handlers.HealthCheck)
+		v1.Get("/ready", handlers.Readiness)
 		v1.Get("/status", handlers.Status)
+		v1.Get("/status/stream", handlers.StreamStatus)
 		v1.Get("/graph", handlers.GetGraph)
+		v1.Post("/graph/query", handlers.QueryGraph)
+		v1.Get("/graph/nodes/{id}/dependencies", handlers.GetNodeDependencies)
+		v1.Put("/graph/edges/criticality", handlers.SetEdgeCriticality)
+		v1.Get("/graph/stats", handlers.GetGraphStats)
+		v1.Get("/explain/{nodeID}", handlers.ExplainNode)
+
+		// =============================================================================
+		// ADMIN / MAINTENANCE
+		// =============================================================================
+		v1.Get("/admin/graph/validate", handlers.ValidateGraph)
+		v1.Post("/admin/graph/validate", handlers.ValidateGraph)
+		v1.Get("/admin/backups", handlers.ListBackups)
+		v1.Post("/admin/backups", handlers.CreateBackup)
+		v1.Post("/admin/backups/{name}/restore", handlers.RestoreBackup)
+		v1.Get("/admin/cluster", handlers.GetClusterStatus)
+		v1.Get("/admin/hooks", handlers.ListHooks)
+		v1.Get("/admin/hooks/{name}", handlers.GetHook)
+		v1.Put("/admin/hooks/{name}", handlers.PutHook)
+		v1.Delete("/admin/hooks/{name}", handlers.DeleteHook)
+		v1.Get("/admin/governance/policies", handlers.ListMutationPolicies)
+		v1.Get("/admin/recordings", handlers.GetRecordingStatus)
+		v1.Post("/admin/recordings", handlers.StartRecording)
+		v1.Post("/admin/recordings/stop", handlers.StopRecording)
+		v1.Get("/admin/recordings/last", handlers.GetLastRecording)
+		v1.Get("/admin/audit", handlers.ExportAuditLog)
+
+		// =============================================================================
+		// CONTRACT SCHEMAS
+		// =============================================================================
+		v1.Get("/contracts/schema", handlers.ContractSchema)
+		v1.Get("/contracts/schema/{kind}", handlers.ContractSchemaByKind)
 
 		// =============================================================================
 		// APPLICATION MANAGEMENT
@@ -41,6 +74,30 @@ func SetupRoutes(r *chi.Mux) {
 		// v1.Put("/applications/{app_name}/environments/allowed", handlers.UpdateAllowedEnvironments)
 		// v1.Post("/applications/{app_name}/environments/allowed", handlers.AddAllowedEnvironments)
 
+		// Environment Comparison
+		v1.Get("/applications/{app_name}/diff", handlers.DiffApplicationEnvironments)
+
+		// Deployment History
+		v1.Get("/applications/{app_name}/deployments/{env}/history", handlers.GetDeploymentHistory)
+
+		// Status pages
+		v1.Get("/applications/{app_name}/status-page", handlers.GetApplicationStatusPage)
+
+		// Release bundles
+		v1.Post("/applications/{app_name}/bundles", handlers.CreateReleaseBundle)
+		v1.Get("/applications/{app_name}/bundles", handlers.ListReleaseBundles)
+		v1.Get("/bundles/{bundle_id}", handlers.GetReleaseBundle)
+		v1.Post("/bundles/{bundle_id}/promote", handlers.PromoteReleaseBundle)
+
+		// Architecture diagrams
+		v1.Get("/applications/{app_name}/graph/export", handlers.ExportApplicationGraph)
+
+		// Application archival
+		v1.Post("/applications/{app_name}/archive", handlers.ArchiveApplication)
+		v1.Post("/applications/{app_name}/restore", handlers.RestoreApplication)
+		v1.Get("/archive/applications", handlers.ListArchivedApplications)
+		v1.Get("/archive/applications/{app_name}", handlers.GetArchivedApplication)
+
 		// =============================================================================
 		// SERVICE MANAGEMENT
 		// =============================================================================
@@ -48,26 +105,107 @@ func SetupRoutes(r *chi.Mux) {
 		v1.Get("/applications/{app_name}/services", handlers.ListServices)
 		v1.Get("/applications/{app_name}/services/{service_name}", handlers.GetService)
 		v1.Get("/applications/{app_name}/services/schema", handlers.ServiceSchema)
+		v1.Get("/services/catalog", handlers.GetAPICatalog)
 
 		// Service Versioning
 		v1.Post("/applications/{app_name}/services/{service_name}/versions", handlers.CreateServiceVersion)
 		v1.Get("/applications/{app_name}/services/{service_name}/versions", handlers.ListServiceVersions)
+		v1.Post("/applications/{app_name}/services/{service_name}/versions/{version}/scan", handlers.AttachServiceVersionScan)
+		v1.Get("/applications/{app_name}/services/{service_name}/versions/{version}/scan", handlers.GetServiceVersionScan)
+		v1.Get("/applications/{app_name}/services/{service_name}/versions/{version}/lifecycle", handlers.GetServiceVersionLifecycle)
+		v1.Post("/applications/{app_name}/services/{service_name}/versions/{version}/lifecycle", handlers.TransitionServiceVersionLifecycle)
+		v1.Get("/services/sunset-report", handlers.GetSunsetReport)
+
+		// Schema Migrations
+		v1.Post("/applications/{app_name}/services/{service_name}/versions/{version}/migrations", handlers.DeclareMigration)
+		v1.Get("/applications/{app_name}/services/{service_name}/versions/{version}/migrations", handlers.ListVersionMigrations)
+		v1.Get("/applications/{app_name}/migrations", handlers.ListPendingMigrations)
+		v1.Get("/migrations/{id}", handlers.GetMigration)
+		v1.Put("/migrations/{id}/executions/{environment}", handlers.RecordMigrationExecution)
+
+		// Environment Overrides
+		v1.Put("/applications/{app_name}/services/{service_name}/overrides/{env}", handlers.SetServiceOverride)
+		v1.Delete("/applications/{app_name}/services/{service_name}/overrides/{env}", handlers.DeleteServiceOverride)
+		v1.Get("/applications/{app_name}/services/{service_name}/environments/{env}/config", handlers.GetServiceEnvironmentConfig)
+		v1.Get("/applications/{app_name}/services/{service_name}/environments/{env}/config/values", handlers.GetServiceConfigValues)
 
 		// =============================================================================
 		// ENVIRONMENT MANAGEMENT
 		// =============================================================================
 		// v1.Post("/environments", handlers.CreateEnvironment)
 		// v1.Get("/environments", handlers.ListEnvironments)
+		v1.Get("/environments/{env}/routes", handlers.ListEnvironmentRoutes)
 
 		// =============================================================================
 		// RESOURCE MANAGEMENT
 		// =============================================================================
 		v1.Post("/resources", handlers.CreateResource)
 		v1.Get("/resources", handlers.ListResources)
+		v1.Get("/resources/{resource_name}/lifecycle", handlers.GetResourceLifecycle)
+		v1.Post("/resources/{resource_name}/lifecycle", handlers.TransitionResourceLifecycle)
+		v1.Post("/resources/{resource_name}/topics", handlers.CreateTopic)
+		v1.Get("/resources/{resource_name}/topics", handlers.ListTopics)
+		v1.Post("/resources/{resource_name}/topics/{topic}/bindings", handlers.BindTopic)
+		v1.Delete("/resources/{resource_name}/topics/{topic}/bindings/{service}/{operation}", handlers.UnbindTopic)
+		v1.Get("/resources/{resource_name}/acls", handlers.GetResourceACLs)
 		v1.Post("/applications/{app_name}/resources/{resource_name}", handlers.AddResourceToApplication)
 		v1.Get("/applications/{app_name}/resources", handlers.ListApplicationResources)
 		v1.Post("/applications/{app_name}/services/{service_name}/resources/{resource_name}", handlers.LinkServiceToResource)
 		v1.Get("/applications/{app_name}/services/{service_name}/resources", handlers.ListServiceResources)
+		v1.Post("/shared-resources", handlers.CreateSharedResource)
+		v1.Get("/shared-resources/{resource_name}/grants", handlers.ListResourceGrants)
+		v1.Post("/shared-resources/{resource_name}/grants", handlers.RequestResourceAccess)
+		v1.Delete("/shared-resources/{resource_name}/grants/{consumer}", handlers.RevokeResourceAccess)
+		v1.Get("/resource-plugins", handlers.ListResourcePlugins)
+		v1.Post("/resource-plugins", handlers.RegisterResourcePlugin)
+
+		// =============================================================================
+		// QUOTAS
+		// =============================================================================
+		v1.Get("/quotas", handlers.GetQuotas)
+		v1.Put("/quotas", handlers.SetQuota)
+		v1.Delete("/quotas/{scope}", handlers.DeleteQuota)
+		v1.Delete("/quotas/{scope}/{name}", handlers.DeleteQuota)
+
+		// AI autonomy per tenant and application
+		v1.Get("/autonomy", handlers.ListAutonomy)
+		v1.Put("/autonomy", handlers.SetAutonomy)
+		v1.Delete("/autonomy/{scope}/{name}", handlers.DeleteAutonomy)
+
+		// =============================================================================
+		// ORGANIZATION HIERARCHY
+		// =============================================================================
+		v1.Get("/org-units", handlers.ListOrgUnits)
+		v1.Put("/org-units", handlers.SetOrgUnit)
+		v1.Get("/org-units/{kind}/{name}", handlers.GetOrgUnit)
+		v1.Delete("/org-units/{kind}/{name}", handlers.DeleteOrgUnit)
+		v1.Get("/applications/{app_name}/effective-policy", handlers.GetEffectivePolicy)
+
+		// =============================================================================
+		// CHANGE CALENDAR
+		// =============================================================================
+		v1.Get("/calendar", handlers.GetChangeCalendar)
+		v1.Post("/calendar/entries", handlers.ScheduleCalendarEntry)
+		v1.Delete("/calendar/entries/{id}", handlers.DeleteCalendarEntry)
+		v1.Get("/calendar/entries/{id}/impact", handlers.GetMaintenanceImpact)
+
+		// =============================================================================
+		// DISASTER RECOVERY
+		// =============================================================================
+		v1.Post("/applications/{app_name}/runbook", handlers.GenerateRunbook)
+		v1.Get("/applications/{app_name}/runbook", handlers.GetRunbook)
+		v1.Post("/applications/{app_name}/drills", handlers.ScheduleDrill)
+		v1.Get("/applications/{app_name}/drills", handlers.ListDrills)
+		v1.Put("/drills/{id}/outcome", handlers.RecordDrillOutcome)
+
+		// =============================================================================
+		// SEARCH
+		// =============================================================================
+		v1.Get("/search", handlers.Search)
+		v1.Get("/search/saved", handlers.ListSavedSearches)
+		v1.Get("/search/saved/{user}/{name}", handlers.RunSavedSearch)
+		v1.Put("/search/saved/{user}/{name}", handlers.SaveSearch)
+		v1.Delete("/search/saved/{user}/{name}", handlers.DeleteSavedSearch)
 
 		// =============================================================================
 		// POLICY MANAGEMENT
@@ -75,6 +213,9 @@ func SetupRoutes(r *chi.Mux) {
 		// v1.Post("/policies", handlers.PolicyHandler)
 		// v1.Get("/policies", handlers.ListPolicies)
 		// v1.Get("/policies/{policy_id}", handlers.GetPolicy)
+		v1.Get("/policies/drift", handlers.GetPolicyDrift)
+		v1.Get("/policies/cache", handlers.GetPolicyCacheStats)
+		v1.Get("/policies/decisions", handlers.ListPolicyDecisions)
 
 		// =============================================================================
 		// AI ENDPOINTS (Infrastructure/Platform Level)
@@ -91,23 +232,146 @@ func SetupRoutes(r *chi.Mux) {
 		// v1.Post("/ai/learn-deployment", handlers.AILearnFromDeployment) // Available in operations.go
 		v1.Get("/ai/provider/status", handlers.AIProviderStatus) // Available in ai.go
 		v1.Get("/ai/metrics", handlers.AIMetrics)                // Available in ai.go
+		v1.Get("/ai/prompt-logs", handlers.ListPromptLogs)
+		v1.Get("/ai/prompt-logs/{id}", handlers.GetPromptLog)
+		v1.Get("/redaction/stats", handlers.RedactionStats)
+
+		// =============================================================================
+		// FEATURE FLAGS
+		// =============================================================================
+		v1.Get("/feature-flags", handlers.ListFeatureFlags)
+		v1.Get("/feature-flags/{name}", handlers.GetFeatureFlag)
+		v1.Put("/feature-flags/{name}", handlers.SetFeatureFlag)
+		v1.Delete("/feature-flags/{name}", handlers.DeleteFeatureFlag)
+		v1.Get("/feature-flags/{name}/evaluate", handlers.EvaluateFeatureFlag)
+
+		// =============================================================================
+		// CONVERSATIONS
+		// =============================================================================
+		v1.Get("/conversations", handlers.ListConversations)
+		v1.Get("/conversations/{id}", handlers.GetConversation)
+		v1.Delete("/conversations/{id}", handlers.DeleteConversation)
+		v1.Post("/conversations/{id}/feedback", handlers.ConversationFeedback)
+		v1.Get("/decisions", handlers.ListDecisions)
+		v1.Get("/decisions/arbitration", handlers.GetArbitrationStats)
+		v1.Get("/decisions/{id}", handlers.GetDecision)
+		v1.Get("/routing/overrides", handlers.ListRoutingOverrides)
+		v1.Post("/routing/overrides", handlers.CreateRoutingOverride)
+		v1.Get("/routing/overrides/{id}", handlers.GetRoutingOverride)
+		v1.Delete("/routing/overrides/{id}", handlers.DeleteRoutingOverride)
+
+		// Durable workflows
+		v1.Get("/workflows", handlers.ListWorkflows)
+		v1.Post("/workflows", handlers.StartWorkflow)
+		v1.Get("/workflows/templates", handlers.ListWorkflowTemplates)
+		v1.Post("/workflows/templates/{name}", handlers.StartWorkflowTemplate)
+		v1.Get("/workflows/{id}", handlers.GetWorkflow)
+		v1.Post("/workflows/{id}/signals/{name}", handlers.SignalWorkflow)
+		v1.Post("/workflows/{id}/cancel", handlers.CancelWorkflow)
+		v1.Post("/workflows/{id}/retry", handlers.RetryWorkflow)
+
+		// =============================================================================
+		// PLANS
+		// =============================================================================
+		v1.Get("/plans", handlers.ListPlans)
+		v1.Post("/plans", handlers.CreatePlan)
+		v1.Get("/plans/{id}", handlers.GetPlan)
+		v1.Delete("/plans/{id}", handlers.DeletePlan)
+		v1.Post("/plans/{id}/revisions", handlers.RevisePlan)
+		v1.Post("/plans/{id}/approve", handlers.ApprovePlan)
+		v1.Post("/plans/{id}/discard", handlers.DiscardPlan)
+
+		// =============================================================================
+		// SANDBOX
+		// =============================================================================
+		v1.Post("/sandbox", handlers.Simulate)
+
+		// =============================================================================
+		// CUSTOM KINDS
+		// =============================================================================
+		v1.Get("/kinds", handlers.ListKinds)
+		v1.Get("/kinds/{kind}", handlers.GetKind)
+		v1.Put("/kinds/{kind}", handlers.PutKind)
+		v1.Delete("/kinds/{kind}", handlers.DeleteKind)
+		v1.Get("/kinds/{kind}/nodes", handlers.ListKindNodes)
+		v1.Post("/kinds/{kind}/nodes", handlers.CreateKindNode)
+		v1.Get("/kinds/{kind}/nodes/{id}", handlers.GetKindNode)
+		v1.Put("/kinds/{kind}/nodes/{id}", handlers.UpdateKindNode)
+		v1.Delete("/kinds/{kind}/nodes/{id}", handlers.DeleteKindNode)
+		v1.Post("/kinds/{kind}/nodes/{id}/edges", handlers.LinkKindNode)
+
+		// =============================================================================
+		// ML MODELS
+		// =============================================================================
+		v1.Get("/ml/models", handlers.ListMLModels)
+		v1.Post("/ml/models", handlers.RegisterMLModel)
+		v1.Get("/ml/models/{model}", handlers.GetMLModel)
+		v1.Post("/ml/models/{model}/versions", handlers.RegisterMLModelVersion)
+		v1.Post("/ml/models/{model}/endpoints", handlers.CreateMLModelEndpoint)
+		v1.Get("/ml/endpoints/{endpoint}/deployments", handlers.ListMLModelDeployments)
+		v1.Post("/ml/endpoints/{endpoint}/deployments", handlers.DeployMLModelEndpoint)
+
+		// =============================================================================
+		// TEMPLATES
+		// =============================================================================
+		v1.Get("/templates", handlers.ListTemplates)
+		v1.Post("/templates/validate", handlers.ValidateTemplates)
+		v1.Get("/templates/{name}", handlers.GetTemplate)
+
+		// =============================================================================
+		// PROVENANCE
+		// =============================================================================
+		v1.Get("/provenance", handlers.ListProvenance)
+		v1.Get("/provenance/keys", handlers.ProvenanceKeys)
+		v1.Post("/provenance/verify", handlers.VerifyProvenance)
+		v1.Get("/provenance/{id}", handlers.GetProvenance)
+
+		// =============================================================================
+		// CHAOS TESTING
+		// =============================================================================
+		v1.Get("/chaos/faults", handlers.ListChaosFaults)
+		v1.Post("/chaos/faults", handlers.InjectChaosFault)
+		v1.Delete("/chaos/faults", handlers.ClearChaosFaults)
+		v1.Delete("/chaos/faults/{id}", handlers.ClearChaosFault)
+
+		// =============================================================================
+		// INTENT ANALYTICS
+		// =============================================================================
+		v1.Get("/analytics/intents", handlers.IntentAnalytics)
+		v1.Get("/analytics/intents/records", handlers.IntentRecords)
+		v1.Get("/analytics/intents/misrouted", handlers.MisroutedIntents)
+
+		// =============================================================================
+		// SCRIPTED CHAT
+		// =============================================================================
+		v1.Post("/chat/batch", handlers.ChatBatch)
+
+		// =============================================================================
+		// AGENT TASKS
+		// =============================================================================
+		v1.Get("/tasks/{correlation_id}", handlers.GetTask)
+		v1.Get("/agents/sla-breaches", handlers.ListSLABreaches)
 
 		// =============================================================================
 		// REAL-TIME LOGS & EVENTS
 		// =============================================================================
+		v1.Get("/logs", handlers.QueryLogs)
 		v1.Get("/logs/stream", handlers.LogsWebSocket)
+		v1.Get("/events/dead-letters", handlers.ListDeadLetters)
 	})
 
 	// =============================================================================
 	// V3 AI ENDPOINTS - Ultra-simple ChatGPT-style AI-native interface
 	// =============================================================================
 	r.Route("/v3", func(v3 chi.Router) {
-		v3.Post("/ai/chat", handlers.V3AIChat) // ChatGPT-style AI chat endpoint
+		v3.Post("/ai/chat", handlers.V3AIChat)             // ChatGPT-style AI chat endpoint
+		v3.Get("/ai/chat/stream", handlers.V3AIChatStream) // WebSocket chat with incremental responses
 	})
 
 	// =============================================================================
-	// STATIC CONTENT & DOCUMENTATION
+	// METRICS, STATIC CONTENT & DOCUMENTATION
 	// =============================================================================
+	r.Get("/metrics", handlers.Metrics)
 	r.Get("/swagger/*", httpSwagger.WrapHandler)
 	r.Handle("/graph.html", http.FileServer(http.Dir("static")))
 	r.Handle("/graph-modern.html", http.FileServer(http.Dir("static")))
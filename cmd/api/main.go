@@ -2,47 +2,144 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"reflect"
+	"syscall"
+	"time"
 
 	"github.com/krzachariassen/ZTDP/api/handlers"
 	"github.com/krzachariassen/ZTDP/api/server"
 	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
 	"github.com/krzachariassen/ZTDP/internal/agents/orchestrator"
 	"github.com/krzachariassen/ZTDP/internal/ai"
+	"github.com/krzachariassen/ZTDP/internal/analytics"
 	"github.com/krzachariassen/ZTDP/internal/application"
+	"github.com/krzachariassen/ZTDP/internal/archive"
+	"github.com/krzachariassen/ZTDP/internal/audit"
+	"github.com/krzachariassen/ZTDP/internal/backup"
+	"github.com/krzachariassen/ZTDP/internal/bootstrap"
+	"github.com/krzachariassen/ZTDP/internal/bundles"
+	"github.com/krzachariassen/ZTDP/internal/calendar"
+	"github.com/krzachariassen/ZTDP/internal/chaos"
+	"github.com/krzachariassen/ZTDP/internal/checkpoint"
+	"github.com/krzachariassen/ZTDP/internal/cluster"
+	"github.com/krzachariassen/ZTDP/internal/config"
+	"github.com/krzachariassen/ZTDP/internal/conversations"
+	"github.com/krzachariassen/ZTDP/internal/decisions"
+	"github.com/krzachariassen/ZTDP/internal/degradation"
+	"github.com/krzachariassen/ZTDP/internal/deployments"
 	"github.com/krzachariassen/ZTDP/internal/environment"
 	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/explain"
+	"github.com/krzachariassen/ZTDP/internal/governance"
 	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/graphstats"
+	"github.com/krzachariassen/ZTDP/internal/graphwatch"
+	"github.com/krzachariassen/ZTDP/internal/guardrails"
+	"github.com/krzachariassen/ZTDP/internal/health"
+	"github.com/krzachariassen/ZTDP/internal/hooks"
+	"github.com/krzachariassen/ZTDP/internal/kinds"
 	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/krzachariassen/ZTDP/internal/migrations"
+	"github.com/krzachariassen/ZTDP/internal/mlmodels"
+	"github.com/krzachariassen/ZTDP/internal/orgs"
+	"github.com/krzachariassen/ZTDP/internal/plans"
 	"github.com/krzachariassen/ZTDP/internal/policies"
+	"github.com/krzachariassen/ZTDP/internal/promptlog"
+	"github.com/krzachariassen/ZTDP/internal/provenance"
+	"github.com/krzachariassen/ZTDP/internal/quotas"
+	"github.com/krzachariassen/ZTDP/internal/recording"
+	"github.com/krzachariassen/ZTDP/internal/recovery"
+	"github.com/krzachariassen/ZTDP/internal/redaction"
+	"github.com/krzachariassen/ZTDP/internal/resources"
+	"github.com/krzachariassen/ZTDP/internal/routing"
+	"github.com/krzachariassen/ZTDP/internal/sandbox"
+	"github.com/krzachariassen/ZTDP/internal/search"
+	servicecore "github.com/krzachariassen/ZTDP/internal/service"
+	"github.com/krzachariassen/ZTDP/internal/statusfeed"
+	"github.com/krzachariassen/ZTDP/internal/statuspage"
+	"github.com/krzachariassen/ZTDP/internal/templates"
+	"github.com/krzachariassen/ZTDP/internal/workflows"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
+	"github.com/redis/go-redis/v9"
 )
 
 func main() {
-	// Initialize centralized logging system
-	logLevel := logging.LevelInfo
-	if os.Getenv("ZTDP_LOG_LEVEL") == "debug" {
-		logLevel = logging.LevelDebug
+	// Load typed configuration (config file plus environment overrides)
+	configPath := flag.String("config", os.Getenv("ZTDP_CONFIG"), "path to a YAML config file")
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	// Mask secrets and personal data before they reach AI prompts, event logs and transcripts
+	redactor, err := redaction.New(redaction.Config{
+		Disabled:   !cfg.Redaction.Enabled,
+		DenyFields: cfg.Redaction.DenyFields,
+		Patterns:   cfg.Redaction.Patterns,
+	})
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	redaction.SetDefault(redactor)
+
+	// Check AI-proposed actions against role, naming, environment and blast radius rules
+	engine, err := guardrails.New(guardrails.Config{
+		Disabled:              !cfg.Guardrails.Enabled,
+		DefaultRole:           cfg.Guardrails.DefaultRole,
+		Roles:                 cfg.Guardrails.Roles,
+		ApproverRoles:         cfg.Guardrails.ApproverRoles,
+		Naming:                cfg.Guardrails.Naming,
+		ProtectedEnvironments: cfg.Guardrails.ProtectedEnvironments,
+		RoleEnvironments:      cfg.Guardrails.RoleEnvironments,
+		MaxDeletes:            cfg.Guardrails.MaxDeletes,
+		MaxTargets:            cfg.Guardrails.MaxTargets,
+		DefaultAutonomy:       guardrails.Autonomy(cfg.Guardrails.DefaultAutonomy),
+	})
+	if err != nil {
+		log.Fatalf("❌ %v", err)
 	}
-	logging.InitializeLogger("ztdp-api", logLevel)
+	guardrails.SetDefault(engine)
+
+	// Initialize centralized logging system
+	logging.InitializeLoggerWithFormat("ztdp-api", cfg.Level(), cfg.Format())
 
 	// Create real-time log sink for WebSocket broadcasting
 	realtimeSink := logging.NewRealtimeLogSink()
 	logging.GetLogger().AddSink(realtimeSink)
 
+	// Retain log history for GET /v1/logs
+	var logStore logging.LogStore
+	if cfg.Logs.Store == config.LogStoreRedis {
+		logStore = logging.NewRedisLogStore(redis.NewClient(&redis.Options{
+			Addr:     cfg.Graph.Redis.Addr,
+			Password: cfg.Graph.Redis.Password,
+		}), cfg.Logs.Capacity)
+	} else {
+		logStore = logging.NewRingBufferStore(cfg.Logs.Capacity)
+	}
+	logging.GetLogger().AddSink(logStore)
+	handlers.SetupLogStore(logStore)
+
 	logger := logging.GetLogger()
 	logger.Info("🚀 Starting ZTDP API Server")
+	logger.Info("🗄️ Retaining up to %d log entries (%s)", cfg.Logs.Capacity, cfg.Logs.Store)
 
 	// Configure event system
 	var eventTransport events.EventTransport
 
 	// Check if NATS is configured
-	natsURL := os.Getenv("ZTDP_NATS_URL")
-	if natsURL != "" {
-		logger.Info("🔔 Using NATS event transport: %s", natsURL)
+	if cfg.Events.Transport == config.EventTransportNATS {
+		logger.Info("🔔 Using NATS event transport: %s", cfg.Events.NATSURL)
 		natsConfig := events.DefaultNATSConfig()
-		natsConfig.URL = natsURL
+		natsConfig.URL = cfg.Events.NATSURL
 
 		var err error
 		eventTransport, err = events.NewNATSTransport(natsConfig)
@@ -55,8 +152,34 @@ func main() {
 		eventTransport = events.NewMemoryTransport()
 	}
 
-	// Initialize simple event system
+	// Initialize simple event system - agents only consume events once startup checks pass
 	events.InitializeEventBus(eventTransport)
+	events.GlobalEventBus.DeferDelivery()
+	events.GlobalEventBus.SetSchemaRegistry(events.NewSchemaRegistry())
+
+	// Encrypt payloads on sensitive subjects before they leave the process
+	if encryption := cfg.Events.Encryption; len(encryption.Subjects) > 0 {
+		keys, err := events.LoadKeyFile(encryption.KeyFile)
+		if err != nil {
+			log.Fatalf("❌ Failed to load event encryption keys: %v", err)
+		}
+		events.GlobalEventBus.SetEncryption(events.NewEncryption(keys, encryption.Subjects))
+		go keys.WatchKeyFile(context.Background(), encryption.KeyFile, encryption.ReloadInterval)
+		logger.Info("🔒 Encrypting event payloads on %v (key %s)", encryption.Subjects, keys.Current())
+	}
+
+	// Agents skip events the transport redelivers; Redis keeps processed IDs across restarts
+	switch cfg.Events.DedupStore {
+	case config.DedupStoreRedis:
+		agentframework.SetDefaultDedupStore(agentframework.NewRedisDedupStore(redis.NewClient(&redis.Options{
+			Addr:     cfg.Graph.Redis.Addr,
+			Password: cfg.Graph.Redis.Password,
+		}), cfg.Events.DedupTTL))
+	case config.DedupStoreMemory:
+		agentframework.SetDefaultDedupStore(agentframework.NewMemoryDedupStore(cfg.Events.DedupTTL))
+	}
+	// Agents ask the user to clarify requests they are less confident about than this
+	agentframework.SetConfidenceThresholds(cfg.Clarification.Threshold, cfg.Clarification.Capabilities)
 	logger.Info("🔔 Event system initialized")
 
 	// Initialize log manager for real-time WebSocket streaming
@@ -64,14 +187,80 @@ func main() {
 	logger.Info("📊 Log manager initialized")
 
 	var backend graph.GraphBackend
-	switch os.Getenv("ZTDP_GRAPH_BACKEND") {
-	case "redis":
+	switch cfg.Graph.Backend {
+	case config.GraphBackendRedis:
 		logger.Info("⚙️  Using backend: Redis")
-		backend = graph.NewRedisGraph(graph.RedisGraphConfig{})
+		backend = graph.NewRedisGraph(graph.RedisGraphConfig{
+			Addr:     cfg.Graph.Redis.Addr,
+			Password: cfg.Graph.Redis.Password,
+		})
 	default:
 		logger.Info("⚙️  Using backend: Memory")
 		backend = graph.NewMemoryGraph()
 	}
+
+	// Saves are compared with the previous one once; everything watching the graph shares the result
+	watch := graphwatch.NewBackend(backend)
+	backend = watch
+
+	// Cached policy decisions are dropped as soon as a node or edge their evaluation read changes
+	if cfg.PolicyCache.Enabled {
+		policyCache := policies.NewDecisionCache(cfg.PolicyCache.TTL)
+		watch.Subscribe(func(save graphwatch.Save) { policyCache.Invalidate(save.Changes) })
+		policies.SetDecisionCache(policyCache)
+		logger.Info("🗃️ Policy decision cache enabled (ttl: %s)", cfg.PolicyCache.TTL)
+	}
+
+	// Sign every graph mutation with this instance's provenance key
+	var provenanceService *provenance.Service
+	if cfg.Provenance.Enabled {
+		provenanceService = newProvenanceService(cfg.Provenance, cfg.Graph.Redis, logger)
+		provenance.Watch(watch, provenanceService)
+		handlers.SetupProvenance(provenanceService)
+	}
+
+	// Policy decisions are signed; instances sharing the graph need the same key to verify them
+	if cfg.Governance.DecisionKeyFile != "" {
+		key, err := governance.LoadDecisionKey(cfg.Governance.DecisionKeyFile)
+		if err != nil {
+			log.Fatalf("❌ Failed to load decision key: %v", err)
+		}
+		governance.SetDecisionKey(key)
+	}
+
+	// Mutation policies are checked on every save before the hooks
+	if cfg.Governance.Enabled {
+		governanceRegistry := governance.NewRegistry()
+		if err := governanceRegistry.Register(governance.ProtectedDeployments(cfg.Governance.ProtectedEnvironments, cfg.Governance.DecisionTTL)); err != nil {
+			log.Fatalf("❌ Failed to register mutation policy: %v", err)
+		}
+		if err := governanceRegistry.Register(bundles.ImmutablePolicy()); err != nil {
+			log.Fatalf("❌ Failed to register mutation policy: %v", err)
+		}
+		governance.Watch(watch, governanceRegistry)
+		handlers.SetupGovernance(governanceRegistry)
+		logger.Info("🛡️ Graph mutations governed (protected environments: %v)", cfg.Governance.ProtectedEnvironments)
+	}
+
+	// Lifecycle hooks are checked after the mutation policies, so denied changes never reach them
+	hookRegistry := hooks.NewRegistry()
+	hooks.Watch(watch, hookRegistry)
+	handlers.SetupHooks(hookRegistry)
+
+	// Recordings capture only mutations that were actually saved
+	var recorder *recording.Recorder
+	if cfg.Recording.Enabled {
+		recorder = recording.NewRecorder(watch, cfg.Recording.MaxWindow, cfg.Recording.Dir)
+		handlers.SetupRecordings(recorder)
+		logger.Warn("⏺️ API recording enabled: requests can be recorded through /v1/admin/recordings")
+	}
+
+	// Outermost, so writes rejected while the graph backend is down never reach the graph watch
+	var degradedGraph *degradation.Backend
+	if cfg.Degradation.Enabled {
+		degradedGraph = degradation.NewBackend(backend)
+		backend = degradedGraph
+	}
 	handlers.GlobalGraph = graph.NewGlobalGraph(backend)
 
 	// Load persisted graph from backend (Redis)
@@ -79,115 +268,778 @@ func main() {
 		logger.Info("No existing global graph found, starting fresh")
 	}
 
+	// Tenants and applications can lower or raise how far the AI acts on its own
+	guardrails.SetAutonomyStore(guardrails.NewAutonomyStore(handlers.GlobalGraph))
+
+	// Register resource type plugins shipped as Go plugins (built-in types are always available)
+	if cfg.Resources.PluginDir != "" {
+		loaded, err := resources.LoadPlugins(cfg.Resources.PluginDir)
+		if err != nil {
+			log.Fatalf("❌ Failed to load resource type plugins: %v", err)
+		}
+		logger.Info("🧩 Loaded resource type plugins: %v", loaded)
+	}
+
+	// User-defined node kinds: restore their edge rules and hooks from the stored definitions
+	kindService := kinds.NewService(handlers.GlobalGraph, hookRegistry)
+	if err := kindService.Sync(); err != nil {
+		logger.Warn("⚠️ Could not load custom node kinds: %v", err)
+	}
+	handlers.SetupKinds(kindService)
+
+	// Name resource instances after the configured templates and provider restrictions
+	naming := resources.NamingStrategy{
+		Default:   resourceNamingRule(cfg.Resources.Naming.ResourceNamingRule),
+		Providers: map[string]resources.NamingRule{},
+	}
+	for provider, rule := range cfg.Resources.Naming.Providers {
+		naming.Providers[provider] = resourceNamingRule(rule)
+	}
+	if err := resources.SetNamingStrategy(naming); err != nil {
+		log.Fatalf("❌ Invalid resource naming configuration: %v", err)
+	}
+
+	// Reconcile declarative system policies, environments, resource types and checks
+	if cfg.Bootstrap.Dir != "" {
+		logger.Info("📦 Bootstrapping definitions from %s", cfg.Bootstrap.Dir)
+		if _, err := bootstrap.NewLoader(handlers.GlobalGraph).LoadDir(cfg.Bootstrap.Dir); err != nil {
+			log.Fatalf("❌ Bootstrap failed: %v", err)
+		}
+		if err := handlers.GlobalGraph.Save(); err != nil {
+			logger.Warn("⚠️ Failed to persist bootstrapped definitions: %v", err)
+		}
+	}
+
 	// Initialize Global Orchestrator at startup (Clean Architecture - Composition Root)
 	logger.Info("🎯 Initializing Global Orchestrator...")
 
+	// Count prompt tokens with the model's BPE vocabulary, keeping the estimate if it cannot load
+	if cfg.AI.Tokenizer.Type == config.TokenizerBPE {
+		tokenizer, err := ai.NewBPETokenizer(cfg.AI.Tokenizer.Encoding, cfg.AI.Tokenizer.RanksFile)
+		if err != nil {
+			logger.Warn("⚠️ Tokenizer unavailable, estimating prompt tokens: %v", err)
+		} else {
+			ai.DefaultTokenizer = tokenizer
+			logger.Info("🔢 Counting prompt tokens with %s", cfg.AI.Tokenizer.Encoding)
+		}
+	}
+
 	// Create AI Provider
 	logger.Info("🤖 Setting up AI Provider...")
-	apiKey := os.Getenv("OPENAI_API_KEY")
-	aiProvider, err := ai.NewOpenAIProvider(ai.DefaultOpenAIConfig(), apiKey)
-	if err != nil || aiProvider == nil {
-		logger.Warn("⚠️ AI Provider initialization failed: %v - AI features will be unavailable", err)
-		// Continue without AI provider for now
+	var aiProvider ai.AIProvider
+	openAIConfig := ai.DefaultOpenAIConfig()
+	openAIConfig.Model = cfg.AI.Model
+	openAIConfig.BaseURL = cfg.AI.BaseURL
+	openAIConfig.Timeout = cfg.AI.Timeout
+	openAIConfig.TaskModels = taskModels(cfg.AI.Models)
+	openAIProvider, err := ai.NewOpenAIProvider(openAIConfig, cfg.AI.APIKey)
+	if err != nil || openAIProvider == nil {
+		logger.Warn("⚠️ AI Provider initialization failed: %v - running in degraded mode with deterministic fallbacks", err)
 	} else {
+		aiProvider = openAIProvider
 		logger.Info("✅ AI Provider initialized successfully")
 	}
 
-	// Create Agent Registry
+	// Keep the full prompts and responses of a sample of AI calls, redacted, for debugging prompts
+	var promptSampler *promptlog.Sampler
+	if promptLogging := cfg.AI.PromptLogging; promptLogging.Enabled {
+		promptLogStore := promptlog.NewStore(promptLogging.Capacity, promptLogging.Retention)
+		promptSampler = promptlog.NewSampler(promptLogStore, promptLogging.SampleRate)
+		handlers.SetupPromptLogs(promptLogStore)
+		if aiProvider != nil {
+			aiProvider = promptSampler.WrapProvider(aiProvider)
+		}
+		logger.Info("🔎 Logging %.0f%% of AI prompts (keeping %d for %v)", promptLogging.SampleRate*100, promptLogging.Capacity, promptLogging.Retention)
+	}
+
+	// Create the embedding provider for semantic matching when configured
+	if embeddings := cfg.AI.Embeddings; embeddings.Provider != "" {
+		embeddingConfig := ai.EmbeddingConfig{
+			Provider:  embeddings.Provider,
+			Model:     embeddings.Model,
+			BaseURL:   embeddings.URL,
+			APIKey:    cfg.AI.APIKey,
+			Timeout:   cfg.AI.Timeout,
+			BatchSize: embeddings.BatchSize,
+			CacheSize: embeddings.CacheSize,
+		}
+		if embeddingConfig.BaseURL == "" && embeddings.Provider == config.EmbeddingProviderOpenAI {
+			embeddingConfig.BaseURL = cfg.AI.BaseURL
+		}
+		embeddingProvider, err := ai.NewEmbeddingProvider(embeddingConfig)
+		if err != nil {
+			logger.Warn("⚠️ Embedding provider initialization failed: %v - semantic matching disabled", err)
+		} else {
+			handlers.SetupEmbeddings(embeddingProvider)
+		}
+	}
+
+	// Create Agent Registry; clustered instances advertise their agents to each other through
+	// Redis, and route to another instance's agents only when the event bus is shared
 	logger.Info("📋 Setting up Agent Registry...")
-	agentRegistry := agentRegistry.NewInMemoryAgentRegistry()
+	instance := cfg.Cluster.Instance
+	if instance == "" {
+		instance, _ = os.Hostname()
+	}
+	registry := agentRegistry.NewInMemoryAgentRegistry()
+	var sharedRegistry *agentRegistry.SharedAgentRegistry
+	if cfg.Cluster.Enabled {
+		sharedRegistry = agentRegistry.NewSharedAgentRegistry(agentRegistry.NewRedisDirectory(redis.NewClient(&redis.Options{
+			Addr:     cfg.Graph.Redis.Addr,
+			Password: cfg.Graph.Redis.Password,
+		})), instance, cfg.Cluster.AgentTTL, cfg.Events.Transport == config.EventTransportNATS)
+		registry = sharedRegistry
+	}
 	logger.Info("✅ Agent Registry initialized successfully")
 
 	// Get the global event bus that was initialized earlier
 	eventBus := events.GlobalEventBus
 
+	// Inject controlled failures for resilience testing; the chaos agent keeps the unwrapped
+	// provider so it can still clear faults while AI calls are failing
+	var chaosInjector *chaos.Injector
+	chaosAIProvider := aiProvider
+	if cfg.Chaos.Enabled {
+		chaosInjector = chaos.NewInjector(handlers.GlobalGraph)
+		chaosInjector.Attach(eventBus)
+		handlers.SetupChaos(chaosInjector)
+		if aiProvider != nil {
+			aiProvider = chaosInjector.WrapProvider(aiProvider)
+		}
+		logger.Warn("💥 Chaos testing enabled - faults can be injected via /v1/chaos/faults")
+	}
+
+	// Agents competing for an intent bid for requests; the policy ranks their bids
+	arbitrationPolicy, err := orchestrator.NewArbitrationPolicy(cfg.Arbitration.Policy, cfg.Arbitration.MinConfidence)
+	if err != nil {
+		log.Fatalf("❌ Invalid arbitration policy: %v", err)
+	}
+
 	// Create Orchestrator with all dependencies
 	logger.Info("🎯 Creating Orchestrator...")
 	orchestrator := orchestrator.NewOrchestrator(
 		aiProvider,
 		handlers.GlobalGraph,
 		eventBus,
-		agentRegistry,
+		registry,
 	)
 	logger.Info("✅ Global Orchestrator created successfully")
 
 	// Inject orchestrator into handlers (Dependency Injection)
 	handlers.SetupGlobalOrchestrator(orchestrator)
 
+	// The web UI follows deployments and its user's requests over server-sent events
+	handlers.SetupStatusFeed(statusfeed.NewFeed(handlers.GlobalGraph, eventBus))
+
+	// Old transcripts and decisions, and archived applications, move to object storage when an archive is configured
+	var aiArchive *archive.Archive
+	if archiveCfg := cfg.Conversations.Archive; archiveCfg.Dir != "" || archiveCfg.URL != "" {
+		var store backup.Store = archive.NewHTTPStore(archiveCfg.URL)
+		if archiveCfg.Dir != "" {
+			dirStore, err := archive.NewDirStore(archiveCfg.Dir)
+			if err != nil {
+				log.Fatalf("❌ Failed to open archive directory: %v", err)
+			}
+			store = dirStore
+		}
+		aiArchive = archive.New(handlers.GlobalGraph, store)
+		handlers.SetupApplicationArchive(aiArchive)
+		logger.Info("🗄️ Archiving conversations idle for %s in batches of %d", archiveCfg.After, archiveCfg.BatchSize)
+	}
+
+	// Store chat transcripts for the conversations API, in the graph unless Redis or Postgres is configured
+	var transcripts *conversations.Service
+	if cfg.Conversations.Enabled {
+		var transcriptStore conversations.Store
+		switch cfg.Conversations.Store {
+		case config.ConversationStoreRedis:
+			transcriptStore = conversations.NewRedisStore(redis.NewClient(&redis.Options{
+				Addr:     cfg.Graph.Redis.Addr,
+				Password: cfg.Graph.Redis.Password,
+			}))
+		case config.ConversationStorePostgres:
+			postgresStore, err := conversations.NewPostgresStore(context.Background(), cfg.Conversations.PostgresURL)
+			if err != nil {
+				log.Fatalf("❌ Failed to open the postgres conversation store: %v", err)
+			}
+			transcriptStore = postgresStore
+		}
+		transcripts = conversations.NewService(handlers.GlobalGraph, conversations.Options{
+			Retention:    cfg.Conversations.Retention,
+			RedactPII:    cfg.Conversations.RedactPII,
+			Archive:      aiArchive,
+			ArchiveAfter: cfg.Conversations.Archive.After,
+			BatchSize:    cfg.Conversations.Archive.BatchSize,
+			Store:        transcriptStore,
+		})
+		orchestrator.SetTranscripts(transcripts)
+		handlers.SetupConversations(transcripts)
+		logger.Info("💬 Conversation transcripts enabled (store: %s, retention: %v, PII redaction: %t)", cfg.Conversations.Store, cfg.Conversations.Retention, cfg.Conversations.RedactPII)
+	}
+
+	// Keep an audit trail of which agent the orchestrator chose for each request, and why
+	decisionService := decisions.NewService(handlers.GlobalGraph, decisions.DefaultCapacity)
+	if aiArchive != nil {
+		decisionService.ArchiveTo(aiArchive, cfg.Conversations.Archive.BatchSize)
+	}
+	orchestrator.SetDecisions(decisionService)
+	handlers.SetupDecisions(decisionService)
+
+	// Operators can pin requests the AI keeps misrouting to a capability or agent
+	orchestrator.SetRoutingOverrides(routing.NewService(handlers.GlobalGraph))
+	if cfg.Arbitration.Enabled {
+		orchestrator.SetArbitration(arbitrationPolicy, cfg.Arbitration.BidTimeout)
+		logger.Info("⚖️ Arbitrating between competing agents by %s (bid timeout: %v)", arbitrationPolicy.Name(), cfg.Arbitration.BidTimeout)
+	}
+	// Agents repeatedly answering slower than their capability's SLA are flagged and tried last
+	orchestrator.SetSLABreachThreshold(cfg.AgentSLA.BreachThreshold)
+	handlers.SetupAgentSLA(registry)
+
+	// Explanations draw on the graph, transcripts and retained logs; transcripts may be disabled
+	handlers.SetupExplain(explain.NewService(handlers.GlobalGraph, aiProvider, transcripts, logStore))
+
+	// Record intent classifications for the analytics API
+	if cfg.Analytics.Enabled {
+		intentStore := analytics.NewMemoryStore(cfg.Analytics.Capacity)
+		collector := analytics.NewCollector(intentStore)
+		orchestrator.SetAnalytics(collector)
+		handlers.SetupAnalytics(intentStore)
+		if transcripts != nil {
+			// Ratings from /v1/conversations/{id}/feedback show up next to the classification they judge
+			transcripts.OnFeedback(func(_ *conversations.Transcript, feedback conversations.Feedback) {
+				collector.RecordFeedback(feedback.CorrelationID, string(feedback.Rating))
+			})
+		}
+		logger.Info("📊 Intent analytics enabled (retaining %d classifications)", cfg.Analytics.Capacity)
+	}
+
+	// Store plans agents propose so users can revise them before approving
+	planService := plans.NewService(handlers.GlobalGraph, aiProvider)
+	planService.Attach(eventBus)
+	handlers.SetupPlans(planService)
+	sandboxService := sandbox.NewService(handlers.GlobalGraph, planService)
+	handlers.SetupSandbox(sandboxService)
+	// Everything that deploys shares one set of environment locks, so deployments of an
+	// application to an environment exclude each other whichever path they take
+	deploymentLocks := deployments.NewEnvironmentLocks(deployments.DeploymentLockTTL)
+	mlModelService := mlmodels.NewService(handlers.GlobalGraph, eventBus, deploymentLocks)
+	handlers.SetupMLModels(mlModelService)
+	handlers.SetupMigrations(migrations.NewService(handlers.GlobalGraph))
+	if provenanceService != nil {
+		provenanceService.AttachPlans(planService)
+	}
+
+	// Golden-path templates published by platform teams; instantiating one proposes a plan
+	var templateCatalog *templates.Catalog
+	if cfg.Templates.Dir != "" {
+		templateCatalog = templates.NewCatalog()
+		loaded, err := templateCatalog.LoadDir(cfg.Templates.Dir)
+		if err != nil {
+			log.Fatalf("❌ Failed to load templates: %v", err)
+		}
+		handlers.SetupTemplates(templateCatalog)
+		logger.Info("📐 Loaded golden-path templates: %v", loaded)
+	}
+
+	// Environment diffs are computed from the graph; the AI provider only writes the summary
+	handlers.SetupEnvironmentDiff(deployments.NewDeploymentService(handlers.GlobalGraph, aiProvider))
+	handlers.SetupPolicyDrift(policies.NewDriftAnalyzer(handlers.GlobalGraph, aiProvider))
+	handlers.SetupRecovery(recovery.NewService(handlers.GlobalGraph, aiProvider))
+
+	// Durable workflows deploy, verify and promote through the deployment pipeline
+	deployer := deployments.NewDeployer(handlers.GlobalGraph, eventBus, deploymentLocks)
+	var workflowEngine *workflows.Engine
+	if cfg.Workflows.Enabled {
+		workflowEngine = workflows.NewEngine(handlers.GlobalGraph)
+		workflowEngine.RegisterDeploymentActions(deployer)
+		handlers.SetupWorkflows(workflowEngine)
+	}
+
+	// Status pages report open incidents only when workflows are enabled
+	handlers.SetupStatusPage(statuspage.NewService(handlers.GlobalGraph, deployments.NewDeploymentService(handlers.GlobalGraph, nil), workflowEngine))
+
+	// Owners affected by a maintenance window are notified on the event bus; webhooks and Slack are optional
+	if len(cfg.Maintenance.Webhooks) > 0 || cfg.Maintenance.SlackURL != "" {
+		calendar.NewNotifier(cfg.Maintenance.Webhooks, cfg.Maintenance.SlackURL, cfg.Maintenance.Timeout).Subscribe(events.GlobalEventBus)
+		logger.Info("📣 Maintenance notices delivered to %d webhooks (slack: %t)", len(cfg.Maintenance.Webhooks), cfg.Maintenance.SlackURL != "")
+	}
+
 	// Initialize domain agents (environment-agnostic)
 	logger.Info("🤖 Initializing domain agents...")
 
-	// Initialize Application Agent
-	logger.Info("📱 Creating Application Agent...")
-	applicationAgent, err := application.NewApplicationAgent(
-		handlers.GlobalGraph,
-		aiProvider,
-		eventBus,
-		agentRegistry,
-	)
-	if err != nil {
-		log.Fatalf("❌ Failed to create application agent: %v", err)
+	// AI-native agents require an AI provider - in degraded mode the orchestrator
+	// handles core intents itself using deterministic fallback handlers
+	var aiAgents []agentRegistry.AgentInterface
+	if aiProvider != nil {
+		// Each agent gets a graph view that only changes the kinds it owns
+		// Initialize Application Agent
+		logger.Info("📱 Creating Application Agent...")
+		applicationAgent, err := application.NewApplicationAgent(
+			handlers.GlobalGraph.Scoped(graph.WriteScope("application-agent", graph.KindApplication, graph.KindService, graph.KindServiceVersion)),
+			aiProvider,
+			eventBus,
+			registry,
+		)
+		if err != nil {
+			log.Fatalf("❌ Failed to create application agent: %v", err)
+		}
+		logger.Info("✅ Application Agent created successfully")
+
+		// Initialize Environment Agent
+		logger.Info("🚀 Creating Environment Agent...")
+		environmentAgent, err := environment.NewEnvironmentAgent(
+			handlers.GlobalGraph.Scoped(graph.WriteScope("environment-agent", graph.KindEnvironment)),
+			aiProvider,
+			eventBus,
+			registry,
+		)
+		if err != nil {
+			log.Fatalf("❌ Failed to create Environment agent: %v", err)
+		}
+		logger.Info("✅ Environment Agent created successfully")
+
+		// Initialize Plan Agent
+		logger.Info("📋 Creating Plan Agent...")
+		planAgent, err := plans.NewPlanAgent(handlers.GlobalGraph.Scoped(graph.WriteScope("plan-agent", graph.KindPlan)), planService, aiProvider, eventBus, registry)
+		if err != nil {
+			log.Fatalf("❌ Failed to create plan agent: %v", err)
+		}
+
+		// Initialize Sandbox Agent
+		logger.Info("🧪 Creating Sandbox Agent...")
+		sandboxAgent, err := sandbox.NewSandboxAgent(handlers.GlobalGraph.Scoped(graph.ReadOnlyScope("sandbox-agent")), sandboxService, planService, aiProvider, eventBus, registry)
+		if err != nil {
+			log.Fatalf("❌ Failed to create sandbox agent: %v", err)
+		}
+
+		if templateCatalog != nil {
+			logger.Info("📐 Creating Template Agent...")
+			templateAgent, err := templates.NewTemplateAgent(handlers.GlobalGraph.Scoped(graph.ReadOnlyScope("template-agent")), templateCatalog, planService, aiProvider, eventBus, registry)
+			if err != nil {
+				log.Fatalf("❌ Failed to create template agent: %v", err)
+			}
+			aiAgents = append(aiAgents, templateAgent)
+		}
+
+		// Initialize Resource Lifecycle Agent
+		logger.Info("🔧 Creating Resource Lifecycle Agent...")
+		lifecycleGraph := handlers.GlobalGraph.Scoped(graph.WriteScope("resource-lifecycle-agent", graph.KindResourceRegister, graph.KindResourceType, graph.KindResource))
+		lifecycleAgent, err := resources.NewLifecycleAgent(lifecycleGraph, aiProvider, eventBus, registry)
+		if err != nil {
+			log.Fatalf("❌ Failed to create resource lifecycle agent: %v", err)
+		}
+
+		// Initialize Search Agent
+		logger.Info("🔎 Creating Search Agent...")
+		searchGraph := handlers.GlobalGraph.Scoped(graph.WriteScope("search-agent", graph.KindSavedSearch))
+		searchAgent, err := search.NewSearchAgent(searchGraph, search.NewService(searchGraph), aiProvider, eventBus, registry)
+		if err != nil {
+			log.Fatalf("❌ Failed to create search agent: %v", err)
+		}
+
+		// Initialize ML Model Agent
+		logger.Info("🧠 Creating ML Model Agent...")
+		mlModelGraph := handlers.GlobalGraph.Scoped(graph.WriteScope("mlmodel-agent", graph.KindMLModel, graph.KindModelVersion, graph.KindModelEndpoint))
+		mlModelAgent, err := mlmodels.NewMLModelAgent(mlModelGraph, mlModelService, aiProvider, eventBus, registry)
+		if err != nil {
+			log.Fatalf("❌ Failed to create ML model agent: %v", err)
+		}
+
+		aiAgents = append(aiAgents, applicationAgent, environmentAgent, planAgent, sandboxAgent, lifecycleAgent, searchAgent, mlModelAgent)
+
+		// Workflow steps assigned to agents reach them through the workflow agent
+		if workflowEngine != nil {
+			logger.Info("🔁 Creating Workflow Agent...")
+			workflowAgent, err := workflows.NewWorkflowAgent(handlers.GlobalGraph.Scoped(graph.ReadOnlyScope("workflow-agent")), workflowEngine, aiProvider, eventBus, registry)
+			if err != nil {
+				log.Fatalf("❌ Failed to create workflow agent: %v", err)
+			}
+			aiAgents = append(aiAgents, workflowAgent)
+		}
+
+		if chaosInjector != nil {
+			logger.Info("💥 Creating Chaos Agent...")
+			chaosAgent, err := chaos.NewChaosAgent(handlers.GlobalGraph.Scoped(graph.ReadOnlyScope("chaos-agent")), chaosInjector, chaosAIProvider, eventBus, registry)
+			if err != nil {
+				log.Fatalf("❌ Failed to create chaos agent: %v", err)
+			}
+			aiAgents = append(aiAgents, chaosAgent)
+		}
+	} else {
+		logger.Warn("⚠️ Skipping AI-native domain agents - no AI provider available")
 	}
-	logger.Info("✅ Application Agent created successfully")
 
-	// Initialize Environment Agent
-	logger.Info("🚀 Creating Environment Agent...")
-	deploymentAgent, err := environment.NewEnvironmentAgent(
-		handlers.GlobalGraph,
-		aiProvider,
-		eventBus,
-		agentRegistry,
-	)
-	if err != nil {
-		log.Fatalf("❌ Failed to create Environment agent: %v", err)
+	// Scan new service versions and gate deployments on their critical CVEs
+	if cfg.Vulnerabilities.ScannerURL != "" {
+		servicecore.SetScanner(servicecore.NewHTTPScanner(cfg.Vulnerabilities.ScannerURL))
+	}
+	policies.SetVulnerabilityGate(policies.VulnerabilityGate{
+		MaxCritical: cfg.Vulnerabilities.MaxCritical,
+		RequireScan: cfg.Vulnerabilities.RequireScan,
+	})
+
+	// Applications inherit service quotas from their project, team and organization
+	quotas.SetInheritedLimit(orgs.InheritedLimit)
+
+	// Promotions to an environment wait for the configured soak time in the one before it
+	soakRules := map[string]policies.SoakRule{}
+	for environment, soak := range cfg.Promotion.Soak {
+		soakRules[environment] = policies.SoakRule{Environment: environment, After: soak.After, Duration: soak.Duration}
+	}
+	policies.SetSoakGate(policies.SoakGate{Rules: soakRules})
+
+	// Release bundles are promoted along the same order the soak rules give the environments
+	bundleOrder := map[string]string{}
+	for environment, soak := range cfg.Promotion.Soak {
+		bundleOrder[environment] = soak.After
 	}
-	logger.Info("✅ Environment Agent created successfully")
+	handlers.SetupBundles(bundles.NewService(handlers.GlobalGraph, eventBus, bundleOrder, deployer))
+
+	// Environments that only accept reversible schema migrations
+	policies.SetMigrationGate(policies.MigrationGate{ReversibleEnvironments: cfg.Migrations.RequireReversible})
 
 	// Initialize Policy Agent (with correct signature)
 	logger.Info("🛡️ Creating Policy Agent...")
 	policyAgent, err := policies.NewPolicyAgent(
 		nil, // graphStore - using nil for now, will use global graph
-		handlers.GlobalGraph,
+		handlers.GlobalGraph.Scoped(graph.ReadOnlyScope("policy-agent")),
 		nil, // policyStore - using nil for default store
 		eventBus,
-		agentRegistry,
+		registry,
 	)
 	if err != nil {
 		log.Fatalf("❌ Failed to create policy agent: %v", err)
 	}
 	logger.Info("✅ Policy Agent created successfully")
 
-	// Start all agents
+	// Start all agents - they are stopped in reverse order on shutdown
 	logger.Info("▶️ Starting domain agents...")
-	ctx := context.Background()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	if err := applicationAgent.Start(ctx); err != nil {
-		log.Fatalf("❌ Failed to start application agent: %v", err)
+	startedAgents := append(aiAgents, policyAgent)
+	for _, agent := range startedAgents {
+		if err := agent.Start(ctx); err != nil {
+			log.Fatalf("❌ Failed to start %s: %v", agent.GetID(), err)
+		}
+		logger.Info("✅ %s started", agent.GetID())
 	}
-	logger.Info("✅ Application Agent started")
 
-	if err := deploymentAgent.Start(ctx); err != nil {
-		log.Fatalf("❌ Failed to start deployment agent: %v", err)
+	logger.Info("🎯 All domain agents initialized and started successfully")
+
+	// Every instance serves requests and runs agents; duties that must run once per cluster
+	// follow the leader lease
+	var leaderLock cluster.Lock = cluster.NewMemoryLock()
+	if cfg.Cluster.Enabled {
+		leaderLock = cluster.NewRedisLock(redis.NewClient(&redis.Options{
+			Addr:     cfg.Graph.Redis.Addr,
+			Password: cfg.Graph.Redis.Password,
+		}), "")
+		logger.Info("👥 Clustering enabled, %s competes for leadership", instance)
 	}
-	logger.Info("✅ Deployment Agent started")
+	elector := cluster.NewElector(leaderLock, instance, cfg.Cluster.LeaseTTL)
+	handlers.SetupCluster(elector, registry)
 
-	if err := policyAgent.Start(ctx); err != nil {
-		log.Fatalf("❌ Failed to start policy agent: %v", err)
+	// Log every policy decision, labelled with this instance, for compliance sampling
+	if cfg.DecisionLogs.Enabled {
+		var sinks []policies.DecisionSink
+		if cfg.DecisionLogs.File != "" {
+			fileSink, err := policies.NewFileSink(cfg.DecisionLogs.File)
+			if err != nil {
+				log.Fatalf("❌ Failed to open decision log: %v", err)
+			}
+			sinks = append(sinks, fileSink)
+		}
+		if cfg.DecisionLogs.URL != "" {
+			sinks = append(sinks, policies.NewHTTPSink(cfg.DecisionLogs.URL, cfg.DecisionLogs.Timeout))
+		}
+		decisionLogger := policies.NewDecisionLogger(instance, cfg.DecisionLogs.Capacity, cfg.DecisionLogs.BatchSize, sinks...)
+		policies.SetDecisionLogger(decisionLogger)
+		go decisionLogger.Run(ctx, cfg.DecisionLogs.FlushInterval)
 	}
-	logger.Info("✅ Policy Agent started")
 
-	logger.Info("🎯 All domain agents initialized and started successfully")
+	// Take over work a previous instance checkpointed when it was upgraded, now that agents can receive it
+	var handoff *checkpoint.Manager
+	if cfg.Handoff.Enabled {
+		handoff = checkpoint.NewManager(handlers.GlobalGraph, instance)
+		handoff.Register("deployments", deployments.NewInFlight(handlers.GlobalGraph, eventBus))
+		handoff.Register("orchestrator", orchestrator)
+		if _, err := handoff.Restore(); err != nil {
+			logger.Warn("⚠️ Failed to restore checkpoint: %v", err)
+		}
+		go handoff.Watch(ctx, cfg.Handoff.WatchInterval)
+	}
+
+	if transcripts != nil && (cfg.Conversations.Retention > 0 || aiArchive != nil) {
+		elector.Singleton("conversation-pruning", func(ctx context.Context) {
+			pruneConversations(ctx, transcripts, logger)
+		})
+	}
+
+	// Graph backups on request, and on a schedule when an interval is configured
+	if cfg.Backup.Dir != "" || cfg.Backup.URL != "" {
+		var store backup.Store = backup.NewHTTPStore(cfg.Backup.URL)
+		if cfg.Backup.Dir != "" {
+			dirStore, err := backup.NewDirStore(cfg.Backup.Dir)
+			if err != nil {
+				log.Fatalf("❌ Failed to open backup directory: %v", err)
+			}
+			store = dirStore
+		}
+		backupService := backup.NewService(handlers.GlobalGraph, store, cfg.Graph.Backend)
+		handlers.SetupBackups(backupService)
+		if cfg.Backup.Interval > 0 {
+			elector.Singleton("scheduled-backups", func(ctx context.Context) {
+				backupService.Run(ctx, cfg.Backup.Interval, backup.Retention{Keep: cfg.Backup.Keep, MaxAge: cfg.Backup.MaxAge})
+			})
+			logger.Info("💾 Backing up the graph every %s (keeping %d)", cfg.Backup.Interval, cfg.Backup.Keep)
+		}
+	}
+
+	// Every instance starts and signals workflows; only the leader wakes them when timers end
+	if workflowEngine != nil {
+		elector.Singleton("workflows", func(ctx context.Context) {
+			workflowEngine.Run(ctx, cfg.Workflows.TickInterval)
+		})
+		logger.Info("🔁 Workflows enabled (actions: %v)", workflowEngine.Actions())
+	}
+
+	// Graph statistics for /metrics and /v1/graph/stats; every instance computes its own
+	graphStats := graphstats.NewCollector(handlers.GlobalGraph, cfg.GraphStats.Window, cfg.GraphStats.GrowthAlert)
+	handlers.SetupGraphStats(graphStats)
+	go graphStats.Run(ctx, cfg.GraphStats.Interval)
+
+	go elector.Run(ctx)
+	if sharedRegistry != nil {
+		go sharedRegistry.Run(ctx)
+	}
 
 	r := server.NewRouter()
 
 	// Add logging middleware to router
-	loggedRouter := logging.CreateHTTPLoggingMiddleware("api-server")(r)
+	// Direct API calls are attributed to humans in provenance records
+	var routed http.Handler = r
+	if recorder != nil {
+		routed = recorder.Middleware(r)
+	}
+	// Audited calls carry the correlation ID set by the logging middleware outside it
+	if cfg.Audit.Enabled {
+		auditLog, err := audit.NewLog(cfg.Audit.Capacity, cfg.Audit.Retention, cfg.Audit.Dir)
+		if err != nil {
+			log.Fatalf("❌ Failed to open audit log: %v", err)
+		}
+		handlers.SetupAudit(auditLog)
+		routed = auditLog.Middleware(routed)
+		go auditLog.Run(ctx, time.Hour)
+	}
+	loggedRouter := logging.CreateHTTPLoggingMiddleware("api-server")(provenance.Middleware(routed))
+
+	// Hot-reload log level and AI models when the config file changes
+	if *configPath != "" {
+		watcher := config.NewWatcher(*configPath, cfg, 5*time.Second)
+		watcher.OnReload(func(old, updated *config.Config) {
+			logger.SetLevel(updated.Level())
+			if openAIProvider != nil && updated.AI.Model != old.AI.Model {
+				openAIProvider.SetModel(updated.AI.Model)
+			}
+			if openAIProvider != nil && !reflect.DeepEqual(updated.AI.Models, old.AI.Models) {
+				openAIProvider.SetTaskModels(taskModels(updated.AI.Models))
+			}
+			if promptSampler != nil && updated.AI.PromptLogging.SampleRate != old.AI.PromptLogging.SampleRate {
+				promptSampler.SetRate(updated.AI.PromptLogging.SampleRate)
+			}
+		})
+		watcher.Start(ctx)
+	}
+
+	// Readiness: graph backend and event transport are critical, the AI provider is optional
+	// because the orchestrator falls back to deterministic handlers without it. With graceful
+	// degradation none are: the instance keeps serving in read-only or local events mode.
+	critical := !cfg.Degradation.Enabled
+	checker := health.NewChecker(5 * time.Second)
+	checker.Register("graph_backend", critical, handlers.GlobalGraph.Ping)
+	checker.Register("event_transport", critical, eventBus.Ping)
+	checker.Register("ai_provider", false, func(ctx context.Context) error {
+		if openAIProvider == nil {
+			return errors.New("AI provider not configured")
+		}
+		return openAIProvider.Ping(ctx)
+	})
+	handlers.SetupReadinessChecker(checker)
+
+	if cfg.Degradation.Enabled {
+		monitor := degradation.NewMonitor(checker, eventBus)
+		monitor.Register("graph_backend", degradation.ModeReadOnly, degradedGraph.SetReadOnly)
+		monitor.Register("ai_provider", degradation.ModeDeterministic, nil)
+		monitor.Register("event_transport", degradation.ModeLocalEvents, eventBus.SetLocalOnly)
+		orchestrator.SetDegradation(monitor)
+		handlers.SetupDegradation(monitor)
+		go monitor.Run(ctx, cfg.Degradation.Interval)
+		logger.Info("🪂 Graceful degradation enabled (interval: %s)", cfg.Degradation.Interval)
+	}
+
+	srv := &http.Server{
+		Addr:    ":" + cfg.Server.Port,
+		Handler: loggedRouter,
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		logger.Info("🌐 Starting API server on port %s", cfg.Server.Port)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	// Liveness is served while dependencies are verified; readiness and agent
+	// event consumption only start once the critical checks pass
+	logger.Info("🩺 Checking startup dependencies...")
+	report, err := checker.WaitForDependencies(ctx, 2*time.Second)
+	if err == nil {
+		for _, check := range report.Checks {
+			if check.Status != health.StatusOK {
+				logger.Warn("⚠️ Dependency %s unavailable: %s", check.Name, check.Error)
+			}
+		}
+		checker.MarkStarted()
+		eventBus.MarkReady()
+		logger.Info("✅ ZTDP API Server ready")
+	}
+
+	select {
+	case err := <-serverErr:
+		logger.Error("❌ API server failed: %v", err)
+	case <-ctx.Done():
+		logger.Info("🛑 Shutdown signal received")
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	gracefulShutdown(shutdownCtx, logger, srv, orchestrator, handoff, startedAgents, eventBus, aiProvider)
+	logStore.Close()
+}
+
+// taskModels converts the configured per-task models to provider task hints
+func taskModels(models map[string]string) map[ai.Task]string {
+	converted := make(map[ai.Task]string, len(models))
+	for task, model := range models {
+		converted[ai.Task(task)] = model
+	}
+	return converted
+}
+
+// resourceNamingRule converts a configured naming rule for the resources package
+func resourceNamingRule(rule config.ResourceNamingRule) resources.NamingRule {
+	return resources.NamingRule{Template: rule.Template, MaxLength: rule.MaxLength, Charset: rule.Charset}
+}
+
+// pruneConversations archives idle transcripts and deletes expired ones hourly until ctx is cancelled
+func pruneConversations(ctx context.Context, transcripts *conversations.Service, logger *logging.Logger) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		if _, err := transcripts.Archive(ctx, time.Now()); err != nil {
+			logger.Warn("⚠️ Conversation archival failed: %v", err)
+		}
+		if _, err := transcripts.Prune(time.Now()); err != nil {
+			logger.Warn("⚠️ Conversation pruning failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// gracefulShutdown stops accepting requests, drains in-flight work, checkpoints what is still
+// running for the next instance, stops agents in reverse start order and persists the graph
+// before the process exits
+func gracefulShutdown(
+	ctx context.Context,
+	logger *logging.Logger,
+	srv *http.Server,
+	orch *orchestrator.Orchestrator,
+	handoff *checkpoint.Manager,
+	agents []agentRegistry.AgentInterface,
+	eventBus *events.EventBus,
+	aiProvider ai.AIProvider,
+) {
+	logger.Info("🛑 Stopping API server (no new requests)...")
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.Warn("⚠️ API server did not shut down cleanly: %v", err)
+	}
+
+	logger.Info("⏳ Draining in-flight orchestrations...")
+	if err := orch.Drain(ctx); err != nil {
+		logger.Warn("⚠️ %v", err)
+	}
+
+	if handoff != nil {
+		if _, err := handoff.Save(); err != nil {
+			logger.Error("❌ Failed to checkpoint in-flight work: %v", err)
+		}
+	}
+
+	for i := len(agents) - 1; i >= 0; i-- {
+		if err := agents[i].Stop(ctx); err != nil {
+			logger.Warn("⚠️ Failed to stop %s: %v", agents[i].GetID(), err)
+			continue
+		}
+		logger.Info("✅ %s stopped", agents[i].GetID())
+	}
+
+	if eventBus != nil {
+		if err := eventBus.Shutdown(ctx); err != nil {
+			logger.Warn("⚠️ Event bus shutdown: %v", err)
+		}
+	}
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	logger.Info("💾 Persisting graph...")
+	if err := handlers.GlobalGraph.Save(); err != nil {
+		logger.Error("❌ Failed to persist graph: %v", err)
 	}
 
-	logger.Info("🌐 Starting API server on port %s", port)
-	log.Fatal(http.ListenAndServe(":"+port, loggedRouter))
+	if aiProvider != nil {
+		aiProvider.Close()
+	}
+
+	logger.Info("👋 ZTDP API Server stopped")
+}
+
+// newProvenanceService loads or creates this instance's signing key and trusts the configured
+// keys of other instances
+func newProvenanceService(cfg config.ProvenanceConfig, redisCfg config.RedisConfig, logger *logging.Logger) *provenance.Service {
+	instance := cfg.Instance
+	if instance == "" {
+		instance, _ = os.Hostname()
+	}
+
+	var signer *provenance.Signer
+	var err error
+	if cfg.KeyFile != "" {
+		signer, err = provenance.LoadSigner(instance, cfg.KeyFile)
+	} else {
+		signer, err = provenance.GenerateSigner(instance)
+		logger.Warn("⚠️ No provenance key file configured; records can only be verified until restart")
+	}
+	if err != nil {
+		log.Fatalf("❌ Failed to load provenance key: %v", err)
+	}
+
+	var store provenance.Store = provenance.NewMemoryStore(cfg.Capacity)
+	if cfg.Store == config.ProvenanceStoreRedis {
+		store = provenance.NewRedisStore(redis.NewClient(&redis.Options{
+			Addr:     redisCfg.Addr,
+			Password: redisCfg.Password,
+		}), cfg.Capacity)
+	}
+	service := provenance.NewService(signer, store)
+	for name, key := range cfg.TrustedKeys {
+		if _, err := service.Trust(name, key); err != nil {
+			log.Fatalf("❌ Invalid trusted provenance key for %s: %v", name, err)
+		}
+	}
+	logger.Info("🔏 Signing provenance as %s (key %s), keeping up to %d records (%s)", instance, signer.KeyID(), cfg.Capacity, cfg.Store)
+	return service
 }
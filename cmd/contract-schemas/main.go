@@ -0,0 +1,34 @@
+// Command contract-schemas writes the JSON schema of every contract kind so they can be
+// embedded in the contracts package. Run it through go generate:
+//
+//	go generate ./internal/contracts
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/krzachariassen/ZTDP/internal/contracts"
+)
+
+func main() {
+	out := flag.String("out", "internal/contracts/schemas", "directory to write <kind>.json schemas to")
+	flag.Parse()
+
+	schemas, err := contracts.GenerateSchemas()
+	if err != nil {
+		log.Fatalf("failed to generate schemas: %v", err)
+	}
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		log.Fatalf("failed to create %s: %v", *out, err)
+	}
+	for kind, schema := range schemas {
+		path := filepath.Join(*out, kind+".json")
+		if err := os.WriteFile(path, schema, 0o644); err != nil {
+			log.Fatalf("failed to write %s: %v", path, err)
+		}
+		log.Printf("wrote %s", path)
+	}
+}
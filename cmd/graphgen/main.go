@@ -0,0 +1,193 @@
+// Command graphgen generates synthetic platform graphs and load-tests the API and graph
+// backend so performance regressions are measurable.
+//
+//	graphgen generate -backend redis -apps 500 -services 8
+//	graphgen loadtest -url http://localhost:8080 -concurrency 20 -duration 1m
+//	graphgen bench -backend redis -iterations 50
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/graphgen"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "generate":
+		err = runGenerate(os.Args[2:])
+	case "loadtest":
+		err = runLoadTest(os.Args[2:])
+	case "bench":
+		err = runBench(os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: graphgen <generate|loadtest|bench> [flags]")
+	os.Exit(2)
+}
+
+type backendFlags struct {
+	backend       *string
+	redisAddr     *string
+	redisPassword *string
+}
+
+func addBackendFlags(fs *flag.FlagSet) backendFlags {
+	return backendFlags{
+		backend:       fs.String("backend", "memory", "graph backend: memory or redis"),
+		redisAddr:     fs.String("redis-addr", os.Getenv("REDIS_HOST"), "Redis address"),
+		redisPassword: fs.String("redis-password", os.Getenv("REDIS_PASSWORD"), "Redis password"),
+	}
+}
+
+func (b backendFlags) open() (graph.GraphBackend, error) {
+	switch *b.backend {
+	case "memory":
+		return graph.NewMemoryGraph(), nil
+	case "redis":
+		return graph.NewRedisGraph(graph.RedisGraphConfig{Addr: *b.redisAddr, Password: *b.redisPassword}), nil
+	default:
+		return nil, fmt.Errorf("unknown backend %q (want memory or redis)", *b.backend)
+	}
+}
+
+func runGenerate(args []string) error {
+	defaults := graphgen.DefaultSpec()
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	backend := addBackendFlags(fs)
+	apps := fs.Int("apps", defaults.Applications, "number of applications")
+	services := fs.Int("services", defaults.ServicesPerApp, "services per application")
+	resources := fs.Int("resources", defaults.ResourcesPerApp, "resources per application")
+	versions := fs.Int("versions", defaults.VersionsPerService, "versions per service")
+	policies := fs.Int("policies", defaults.Policies, "number of policies")
+	envs := fs.String("envs", strings.Join(defaults.Environments, ","), "comma-separated environments")
+	seed := fs.Int64("seed", defaults.Seed, "random seed")
+	fs.Parse(args)
+
+	spec := defaults
+	spec.Applications = *apps
+	spec.ServicesPerApp = *services
+	spec.ResourcesPerApp = *resources
+	spec.VersionsPerService = *versions
+	spec.Policies = *policies
+	spec.Environments = strings.Split(*envs, ",")
+	spec.Seed = *seed
+
+	store, err := backend.open()
+	if err != nil {
+		return err
+	}
+
+	began := time.Now()
+	g, err := graphgen.Generate(spec)
+	if err != nil {
+		return fmt.Errorf("generate graph: %w", err)
+	}
+	generated := time.Since(began)
+
+	began = time.Now()
+	if err := graphgen.Write(store, g); err != nil {
+		return fmt.Errorf("write graph to %s: %w", *backend.backend, err)
+	}
+	written := time.Since(began)
+
+	stats := graphgen.Summarize(g)
+	log.Printf("✅ Generated %d nodes and %d edges in %v, wrote to %s in %v", stats.Nodes, stats.Edges, generated, *backend.backend, written)
+	kinds := make([]string, 0, len(stats.NodesByKind))
+	for kind := range stats.NodesByKind {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		log.Printf("   %-18s %d", kind, stats.NodesByKind[kind])
+	}
+	return nil
+}
+
+func runLoadTest(args []string) error {
+	fs := flag.NewFlagSet("loadtest", flag.ExitOnError)
+	url := fs.String("url", "http://localhost:8080", "API base URL")
+	concurrency := fs.Int("concurrency", 10, "concurrent workers")
+	duration := fs.Duration("duration", 30*time.Second, "test duration")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	fs.Parse(args)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	log.Printf("🔥 Load testing %s with %d workers for %v", *url, *concurrency, *duration)
+	report, err := graphgen.RunAPILoad(ctx, graphgen.LoadTestConfig{
+		BaseURL:     *url,
+		Concurrency: *concurrency,
+		Duration:    *duration,
+		Requests:    graphgen.DefaultRequests(graphgen.DefaultSpec()),
+	})
+	if err != nil {
+		return err
+	}
+	return printReport(report, *asJSON)
+}
+
+func runBench(args []string) error {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	backend := addBackendFlags(fs)
+	iterations := fs.Int("iterations", 20, "load/save cycles to time")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	fs.Parse(args)
+
+	store, err := backend.open()
+	if err != nil {
+		return err
+	}
+	log.Printf("⏱️ Measuring %s backend latency over %d iterations", *backend.backend, *iterations)
+	report, err := graphgen.MeasureBackend(store, *iterations)
+	if err != nil {
+		return err
+	}
+	return printReport(report, *asJSON)
+}
+
+func printReport(report *graphgen.Report, asJSON bool) error {
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+
+	names := make([]string, 0, len(report.Operations))
+	for name := range report.Operations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Printf("elapsed %v\n", report.Elapsed.Round(time.Millisecond))
+	fmt.Printf("%-12s %8s %7s %10s %10s %10s %10s %10s\n", "operation", "count", "errors", "req/s", "p50", "p95", "p99", "max")
+	for _, name := range names {
+		l := report.Operations[name]
+		fmt.Printf("%-12s %8d %7d %10.1f %10v %10v %10v %10v\n", name, l.Count, l.Errors, l.Throughput,
+			l.P50.Round(time.Microsecond), l.P95.Round(time.Microsecond), l.P99.Round(time.Microsecond), l.Max.Round(time.Microsecond))
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package agentRegistry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis keys: one JSON entry per instance and agent, expiring with its TTL, and a sorted set
+// of the entries scored by when they expire
+const (
+	redisAgentPrefix = "ztdp:cluster:agent:"
+	redisAgentIndex  = "ztdp:cluster:agents"
+)
+
+// redisTimeout bounds each Redis round trip so a slow Redis cannot stall routing
+const redisTimeout = 2 * time.Second
+
+// RedisDirectory is a Directory in Redis, shared by every instance using the same Redis
+type RedisDirectory struct {
+	client *redis.Client
+}
+
+// NewRedisDirectory creates a directory kept in Redis
+func NewRedisDirectory(client *redis.Client) *RedisDirectory {
+	return &RedisDirectory{client: client}
+}
+
+// Put implements Directory
+func (d *RedisDirectory) Put(ctx context.Context, agent SharedAgent, ttl time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+	agent.Heartbeat = time.Now().UTC()
+	agent.ExpiresAt = agent.Heartbeat.Add(ttl)
+	data, err := json.Marshal(agent)
+	if err != nil {
+		return fmt.Errorf("failed to encode agent %s: %w", agent.ID, err)
+	}
+	member := agent.Instance + "/" + agent.ID
+	pipe := d.client.TxPipeline()
+	pipe.Set(ctx, redisAgentPrefix+member, data, ttl)
+	pipe.ZAdd(ctx, redisAgentIndex, redis.Z{Score: float64(agent.ExpiresAt.UnixMilli()), Member: member})
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// Remove implements Directory
+func (d *RedisDirectory) Remove(ctx context.Context, instance, agentID string) error {
+	ctx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+	member := instance + "/" + agentID
+	pipe := d.client.TxPipeline()
+	pipe.Del(ctx, redisAgentPrefix+member)
+	pipe.ZRem(ctx, redisAgentIndex, member)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// List implements Directory, dropping index entries that expired
+func (d *RedisDirectory) List(ctx context.Context) ([]SharedAgent, error) {
+	ctx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	if err := d.client.ZRemRangeByScore(ctx, redisAgentIndex, "-inf", "("+now).Err(); err != nil {
+		return nil, err
+	}
+	members, err := d.client.ZRangeByScore(ctx, redisAgentIndex, &redis.ZRangeBy{Min: now, Max: "+inf"}).Result()
+	if err != nil {
+		return nil, err
+	}
+	agents := []SharedAgent{}
+	if len(members) == 0 {
+		return agents, nil
+	}
+	keys := make([]string, len(members))
+	for i, member := range members {
+		keys[i] = redisAgentPrefix + member
+	}
+	values, err := d.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for _, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var agent SharedAgent
+		if err := json.Unmarshal([]byte(data), &agent); err != nil {
+			continue
+		}
+		agents = append(agents, agent)
+	}
+	return agents, nil
+}
@@ -3,6 +3,7 @@ package agentRegistry
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 )
@@ -56,6 +57,51 @@ type AgentCapability struct {
 	OutputTypes []string `json:"output_types"`
 	RoutingKeys []string `json:"routing_keys"`
 	Version     string   `json:"version"`
+	SLA         string   `json:"sla,omitempty"` // expected response time, e.g. "5s"; empty declares none
+}
+
+// ResponseSLA returns the response time the capability declares, or 0 when it declares none
+func (c AgentCapability) ResponseSLA() (time.Duration, error) {
+	if c.SLA == "" {
+		return 0, nil
+	}
+	sla, err := time.ParseDuration(c.SLA)
+	if err != nil || sla <= 0 {
+		return 0, fmt.Errorf("capability %s: sla %q is not a positive duration", c.Name, c.SLA)
+	}
+	return sla, nil
+}
+
+// SLABreach is an agent that keeps answering a capability's requests slower than it declared
+type SLABreach struct {
+	AgentID    string    `json:"agent_id"`
+	Capability string    `json:"capability"`
+	SLAMS      int64     `json:"sla_ms"`
+	ObservedMS int64     `json:"observed_ms"` // the latest breaching response time
+	Breaches   int       `json:"breaches"`    // consecutive breaches
+	FlaggedAt  time.Time `json:"flagged_at"`
+}
+
+// SLATracker is implemented by registries that flag agents breaching their capabilities' SLAs.
+// A flag stays until the agent answers within the SLA again or is unregistered.
+type SLATracker interface {
+	FlagSLABreach(ctx context.Context, breach SLABreach) error
+	ClearSLABreach(ctx context.Context, agentID, capability string) error
+	SLABreaches(ctx context.Context) ([]SLABreach, error)
+}
+
+// CapabilityRevision is one version of the capabilities an agent has advertised
+type CapabilityRevision struct {
+	Version      int               `json:"version"`
+	Capabilities []AgentCapability `json:"capabilities"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+}
+
+// CapabilityUpdater is implemented by registries that accept new capabilities from a running
+// agent. Each update is a new revision; discovery always uses the latest one.
+type CapabilityUpdater interface {
+	UpdateCapabilities(ctx context.Context, agentID string, capabilities []AgentCapability) (int, error)
+	CapabilityHistory(ctx context.Context, agentID string) ([]CapabilityRevision, error)
 }
 
 // HealthStatus represents the health status of an agent
@@ -69,6 +115,8 @@ type HealthStatus struct {
 type InMemoryAgentRegistry struct {
 	agents       map[string]AgentInterface
 	capabilities map[string][]string // capability -> agent IDs
+	revisions    map[string][]CapabilityRevision
+	breaches     map[string]map[string]SLABreach // agent ID -> capability -> breach
 	mu           sync.RWMutex
 }
 
@@ -77,6 +125,8 @@ func NewInMemoryAgentRegistry() AgentRegistry {
 	return &InMemoryAgentRegistry{
 		agents:       make(map[string]AgentInterface),
 		capabilities: make(map[string][]string),
+		revisions:    make(map[string][]CapabilityRevision),
+		breaches:     make(map[string]map[string]SLABreach),
 	}
 }
 
@@ -95,17 +145,17 @@ func (r *InMemoryAgentRegistry) RegisterAgent(ctx context.Context, agent AgentIn
 		return fmt.Errorf("agent with ID %s already registered", agentID)
 	}
 
-	// Register the agent
-	r.agents[agentID] = agent
-
 	// Register capabilities
 	capabilities := agent.GetCapabilities()
-	for _, cap := range capabilities {
-		for _, intent := range cap.Intents {
-			r.capabilities[intent] = append(r.capabilities[intent], agentID)
-		}
+	if err := validateSLAs(capabilities); err != nil {
+		return err
 	}
 
+	// Register the agent
+	r.agents[agentID] = agent
+	r.revisions[agentID] = []CapabilityRevision{{Version: 1, Capabilities: capabilities, UpdatedAt: time.Now()}}
+	r.indexIntents(agentID, capabilities)
+
 	return nil
 }
 
@@ -114,13 +164,134 @@ func (r *InMemoryAgentRegistry) UnregisterAgent(ctx context.Context, agentID str
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	agent, exists := r.agents[agentID]
-	if !exists {
+	if _, exists := r.agents[agentID]; !exists {
 		return fmt.Errorf("agent with ID %s not found", agentID)
 	}
 
 	// Remove capabilities
-	capabilities := agent.GetCapabilities()
+	r.unindexIntents(agentID, r.current(agentID))
+
+	// Remove agent
+	delete(r.agents, agentID)
+	delete(r.revisions, agentID)
+	delete(r.breaches, agentID)
+	return nil
+}
+
+// UpdateCapabilities records a new revision of an agent's capabilities and reindexes its
+// intents, returning the revision's version
+func (r *InMemoryAgentRegistry) UpdateCapabilities(ctx context.Context, agentID string, capabilities []AgentCapability) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.agents[agentID]; !exists {
+		return 0, fmt.Errorf("agent with ID %s not found", agentID)
+	}
+	if err := validateSLAs(capabilities); err != nil {
+		return 0, err
+	}
+
+	r.unindexIntents(agentID, r.current(agentID))
+	r.indexIntents(agentID, capabilities)
+
+	history := r.revisions[agentID]
+	version := len(history) + 1
+	if len(history) > 0 {
+		version = history[len(history)-1].Version + 1
+	}
+	r.revisions[agentID] = append(history, CapabilityRevision{Version: version, Capabilities: capabilities, UpdatedAt: time.Now()})
+	return version, nil
+}
+
+// CapabilityHistory returns every capability revision of an agent, oldest first
+func (r *InMemoryAgentRegistry) CapabilityHistory(ctx context.Context, agentID string) ([]CapabilityRevision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	history, exists := r.revisions[agentID]
+	if !exists {
+		return nil, fmt.Errorf("agent with ID %s not found", agentID)
+	}
+	return append([]CapabilityRevision(nil), history...), nil
+}
+
+// FlagSLABreach flags the agent as breaching the capability's SLA, replacing an earlier flag
+func (r *InMemoryAgentRegistry) FlagSLABreach(ctx context.Context, breach SLABreach) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.agents[breach.AgentID]; !exists {
+		return fmt.Errorf("agent with ID %s not found", breach.AgentID)
+	}
+	if r.breaches[breach.AgentID] == nil {
+		r.breaches[breach.AgentID] = make(map[string]SLABreach)
+	}
+	if breach.FlaggedAt.IsZero() {
+		breach.FlaggedAt = time.Now()
+	}
+	r.breaches[breach.AgentID][breach.Capability] = breach
+	return nil
+}
+
+// ClearSLABreach removes the agent's flag for the capability, if any
+func (r *InMemoryAgentRegistry) ClearSLABreach(ctx context.Context, agentID, capability string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.breaches[agentID], capability)
+	if len(r.breaches[agentID]) == 0 {
+		delete(r.breaches, agentID)
+	}
+	return nil
+}
+
+// SLABreaches returns the flagged agents by agent ID and capability
+func (r *InMemoryAgentRegistry) SLABreaches(ctx context.Context) ([]SLABreach, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := []SLABreach{}
+	for _, byCapability := range r.breaches {
+		for _, breach := range byCapability {
+			list = append(list, breach)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].AgentID != list[j].AgentID {
+			return list[i].AgentID < list[j].AgentID
+		}
+		return list[i].Capability < list[j].Capability
+	})
+	return list, nil
+}
+
+func validateSLAs(capabilities []AgentCapability) error {
+	for _, capability := range capabilities {
+		if _, err := capability.ResponseSLA(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// current returns the latest capabilities registered for an agent
+func (r *InMemoryAgentRegistry) current(agentID string) []AgentCapability {
+	history := r.revisions[agentID]
+	if len(history) == 0 {
+		return nil
+	}
+	return history[len(history)-1].Capabilities
+}
+
+func (r *InMemoryAgentRegistry) indexIntents(agentID string, capabilities []AgentCapability) {
+	for _, cap := range capabilities {
+		for _, intent := range cap.Intents {
+			r.capabilities[intent] = append(r.capabilities[intent], agentID)
+		}
+	}
+}
+
+func (r *InMemoryAgentRegistry) unindexIntents(agentID string, capabilities []AgentCapability) {
 	for _, cap := range capabilities {
 		for _, intent := range cap.Intents {
 			agents := r.capabilities[intent]
@@ -130,12 +301,11 @@ func (r *InMemoryAgentRegistry) UnregisterAgent(ctx context.Context, agentID str
 					break
 				}
 			}
+			if len(r.capabilities[intent]) == 0 {
+				delete(r.capabilities, intent)
+			}
 		}
 	}
-
-	// Remove agent
-	delete(r.agents, agentID)
-	return nil
 }
 
 // GetAgent returns a specific agent by ID
@@ -170,8 +340,8 @@ func (r *InMemoryAgentRegistry) DiscoverByCapability(capability string) []AgentI
 	defer r.mu.RUnlock()
 
 	var agents []AgentInterface
-	for _, agent := range r.agents {
-		capabilities := agent.GetCapabilities()
+	for id, agent := range r.agents {
+		capabilities := r.current(id)
 		for _, cap := range capabilities {
 			if cap.Name == capability {
 				agents = append(agents, agent)
@@ -209,8 +379,8 @@ func (r *InMemoryAgentRegistry) FindAgentsByCapability(ctx context.Context, capa
 	defer r.mu.RUnlock()
 
 	var statuses []AgentStatus
-	for _, agent := range r.agents {
-		capabilities := agent.GetCapabilities()
+	for id, agent := range r.agents {
+		capabilities := r.current(id)
 		for _, cap := range capabilities {
 			if cap.Name == capability {
 				statuses = append(statuses, agent.GetStatus())
@@ -254,8 +424,8 @@ func (r *InMemoryAgentRegistry) GetAvailableCapabilities(ctx context.Context) ([
 	defer r.mu.RUnlock()
 
 	capabilityMap := make(map[string]AgentCapability)
-	for _, agent := range r.agents {
-		capabilities := agent.GetCapabilities()
+	for id := range r.agents {
+		capabilities := r.current(id)
 		for _, capability := range capabilities {
 			capabilityMap[capability.Name] = capability
 		}
@@ -3,6 +3,7 @@ package agentRegistry
 import (
 	"context"
 	"testing"
+	"time"
 )
 
 // MockAgent for testing - implements only what the registry needs
@@ -192,3 +193,42 @@ func TestAgentRegistry_CapabilityDiscovery(t *testing.T) {
 		t.Errorf("Expected 2 capabilities, got %d", len(capabilities))
 	}
 }
+
+// TestAgentRegistry_SLABreaches tests that capabilities declare valid SLAs and that breach flags
+// are listed until cleared or the agent leaves
+func TestAgentRegistry_SLABreaches(t *testing.T) {
+	registry := NewInMemoryAgentRegistry()
+	ctx := context.Background()
+
+	invalid := &MockAgent{id: "sloppy", capabilities: []AgentCapability{{Name: "deploy", SLA: "soon"}}}
+	if err := registry.RegisterAgent(ctx, invalid); err == nil {
+		t.Error("Expected an invalid SLA to be rejected")
+	}
+
+	agent := &MockAgent{id: "deployer", capabilities: []AgentCapability{{Name: "deploy", SLA: "5s"}}}
+	if err := registry.RegisterAgent(ctx, agent); err != nil {
+		t.Fatalf("Failed to register agent: %v", err)
+	}
+	if sla, err := agent.capabilities[0].ResponseSLA(); err != nil || sla != 5*time.Second {
+		t.Errorf("Expected a 5s SLA, got %v (%v)", sla, err)
+	}
+
+	tracker := registry.(SLATracker)
+	if err := tracker.FlagSLABreach(ctx, SLABreach{AgentID: "unknown", Capability: "deploy"}); err == nil {
+		t.Error("Expected flagging an unknown agent to fail")
+	}
+	if err := tracker.FlagSLABreach(ctx, SLABreach{AgentID: "deployer", Capability: "deploy", SLAMS: 5000, ObservedMS: 9000, Breaches: 3}); err != nil {
+		t.Fatalf("Failed to flag breach: %v", err)
+	}
+	breaches, _ := tracker.SLABreaches(ctx)
+	if len(breaches) != 1 || breaches[0].ObservedMS != 9000 || breaches[0].FlaggedAt.IsZero() {
+		t.Errorf("Expected the flagged breach, got: %v", breaches)
+	}
+
+	if err := registry.UnregisterAgent(ctx, "deployer"); err != nil {
+		t.Fatalf("Failed to unregister agent: %v", err)
+	}
+	if breaches, _ := tracker.SLABreaches(ctx); len(breaches) != 0 {
+		t.Errorf("Expected flags to leave with the agent, got: %v", breaches)
+	}
+}
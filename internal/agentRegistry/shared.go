@@ -0,0 +1,364 @@
+package agentRegistry
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// SharedAgent is an agent as advertised to the other instances of a cluster
+type SharedAgent struct {
+	ID           string            `json:"id"`
+	Instance     string            `json:"instance"`
+	Status       AgentStatus       `json:"status"`
+	Capabilities []AgentCapability `json:"capabilities"`
+	Heartbeat    time.Time         `json:"heartbeat"`
+	ExpiresAt    time.Time         `json:"expires_at"`
+}
+
+// Directory is where the instances of a cluster advertise their agents. An entry stays until
+// its TTL passes without another Put, so the agents of a crashed instance drop out on their own.
+type Directory interface {
+	// Put advertises the agent for ttl, replacing the instance's previous entry for it
+	Put(ctx context.Context, agent SharedAgent, ttl time.Duration) error
+	// Remove withdraws an instance's agent
+	Remove(ctx context.Context, instance, agentID string) error
+	// List returns the entries that have not expired, of every instance
+	List(ctx context.Context) ([]SharedAgent, error)
+}
+
+// MemoryDirectory is a Directory within one process, for single-instance deployments and tests
+type MemoryDirectory struct {
+	mu      sync.Mutex
+	entries map[string]SharedAgent // by instance/agent ID
+	now     func() time.Time
+}
+
+// NewMemoryDirectory creates an empty in-process directory
+func NewMemoryDirectory() *MemoryDirectory {
+	return &MemoryDirectory{entries: map[string]SharedAgent{}, now: time.Now}
+}
+
+// Put implements Directory
+func (d *MemoryDirectory) Put(ctx context.Context, agent SharedAgent, ttl time.Duration) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	agent.Heartbeat = d.now()
+	agent.ExpiresAt = agent.Heartbeat.Add(ttl)
+	d.entries[agent.Instance+"/"+agent.ID] = agent
+	return nil
+}
+
+// Remove implements Directory
+func (d *MemoryDirectory) Remove(ctx context.Context, instance, agentID string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.entries, instance+"/"+agentID)
+	return nil
+}
+
+// List implements Directory
+func (d *MemoryDirectory) List(ctx context.Context) ([]SharedAgent, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := d.now()
+	agents := []SharedAgent{}
+	for key, agent := range d.entries {
+		if !now.Before(agent.ExpiresAt) {
+			delete(d.entries, key)
+			continue
+		}
+		agents = append(agents, agent)
+	}
+	return agents, nil
+}
+
+// SharedAgentRegistry registers this instance's agents locally and advertises them in a
+// Directory shared by the cluster, renewing each entry every third of the TTL. Listing agents
+// shows every live agent of the cluster, so all instances report the same view. Discovery only
+// returns agents of other instances when routeRemote is set, since requests reach them over the
+// event bus only when it is shared by the instances (NATS); agents running here are always
+// preferred to another instance's agent with the same ID.
+type SharedAgentRegistry struct {
+	*InMemoryAgentRegistry
+	directory   Directory
+	instance    string
+	ttl         time.Duration
+	routeRemote bool
+	logger      *logging.Logger
+}
+
+// NewSharedAgentRegistry creates a registry advertising this instance's agents in directory
+// for ttl after each heartbeat
+func NewSharedAgentRegistry(directory Directory, instance string, ttl time.Duration, routeRemote bool) *SharedAgentRegistry {
+	return &SharedAgentRegistry{
+		InMemoryAgentRegistry: NewInMemoryAgentRegistry().(*InMemoryAgentRegistry),
+		directory:             directory,
+		instance:              instance,
+		ttl:                   ttl,
+		routeRemote:           routeRemote,
+		logger:                logging.GetLogger().ForComponent("agent-registry"),
+	}
+}
+
+// RegisterAgent registers the agent here and advertises it to the cluster; an agent the
+// directory refused is not registered
+func (r *SharedAgentRegistry) RegisterAgent(ctx context.Context, agent AgentInterface) error {
+	if err := r.InMemoryAgentRegistry.RegisterAgent(ctx, agent); err != nil {
+		return err
+	}
+	if err := r.advertise(ctx, agent.GetID()); err != nil {
+		r.InMemoryAgentRegistry.UnregisterAgent(ctx, agent.GetID())
+		return err
+	}
+	return nil
+}
+
+// UnregisterAgent removes the agent here and withdraws it from the cluster
+func (r *SharedAgentRegistry) UnregisterAgent(ctx context.Context, agentID string) error {
+	if err := r.InMemoryAgentRegistry.UnregisterAgent(ctx, agentID); err != nil {
+		return err
+	}
+	if err := r.directory.Remove(ctx, r.instance, agentID); err != nil {
+		return fmt.Errorf("failed to withdraw agent %s from the cluster: %w", agentID, err)
+	}
+	return nil
+}
+
+// UpdateCapabilities records the new capabilities and advertises them right away
+func (r *SharedAgentRegistry) UpdateCapabilities(ctx context.Context, agentID string, capabilities []AgentCapability) (int, error) {
+	version, err := r.InMemoryAgentRegistry.UpdateCapabilities(ctx, agentID, capabilities)
+	if err != nil {
+		return 0, err
+	}
+	if err := r.advertise(ctx, agentID); err != nil {
+		return version, err
+	}
+	return version, nil
+}
+
+// advertise puts the local agent's current status and capabilities in the directory
+func (r *SharedAgentRegistry) advertise(ctx context.Context, agentID string) error {
+	agent, err := r.InMemoryAgentRegistry.GetAgent(agentID)
+	if err != nil {
+		return err
+	}
+	r.mu.RLock()
+	capabilities := r.current(agentID)
+	r.mu.RUnlock()
+	entry := SharedAgent{ID: agentID, Instance: r.instance, Status: agent.GetStatus(), Capabilities: capabilities}
+	if err := r.directory.Put(ctx, entry, r.ttl); err != nil {
+		return fmt.Errorf("failed to advertise agent %s to the cluster: %w", agentID, err)
+	}
+	return nil
+}
+
+// Heartbeat renews the entries of every agent registered here
+func (r *SharedAgentRegistry) Heartbeat(ctx context.Context) error {
+	for _, agent := range r.InMemoryAgentRegistry.ListAgents() {
+		if err := r.advertise(ctx, agent.GetID()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run sends heartbeats until ctx is done, then withdraws this instance's agents so the other
+// instances stop seeing them before their entries expire
+func (r *SharedAgentRegistry) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			withdraw, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			for _, agent := range r.InMemoryAgentRegistry.ListAgents() {
+				r.directory.Remove(withdraw, r.instance, agent.GetID())
+			}
+			cancel()
+			return
+		case <-ticker.C:
+			if err := r.Heartbeat(ctx); err != nil {
+				r.logger.Warn("⚠️ Agent heartbeat failed: %v", err)
+			}
+		}
+	}
+}
+
+// SharedAgents returns every live agent of the cluster, ordered by agent ID and instance
+func (r *SharedAgentRegistry) SharedAgents(ctx context.Context) ([]SharedAgent, error) {
+	agents, err := r.directory.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cluster agents: %w", err)
+	}
+	sort.Slice(agents, func(i, j int) bool {
+		if agents[i].ID != agents[j].ID {
+			return agents[i].ID < agents[j].ID
+		}
+		return agents[i].Instance < agents[j].Instance
+	})
+	return agents, nil
+}
+
+// remote returns the agents only other instances run, the most recently renewed entry per ID
+func (r *SharedAgentRegistry) remote(ctx context.Context) ([]SharedAgent, error) {
+	shared, err := r.SharedAgents(ctx)
+	if err != nil {
+		return nil, err
+	}
+	latest := map[string]SharedAgent{}
+	for _, agent := range shared {
+		if agent.Instance == r.instance {
+			continue
+		}
+		if _, err := r.InMemoryAgentRegistry.GetAgent(agent.ID); err == nil {
+			continue
+		}
+		if current, ok := latest[agent.ID]; !ok || agent.Heartbeat.After(current.Heartbeat) {
+			latest[agent.ID] = agent
+		}
+	}
+	agents := make([]SharedAgent, 0, len(latest))
+	for _, agent := range latest {
+		agents = append(agents, agent)
+	}
+	sort.Slice(agents, func(i, j int) bool { return agents[i].ID < agents[j].ID })
+	return agents, nil
+}
+
+// ListAllAgents returns the agents registered here and those only other instances run
+func (r *SharedAgentRegistry) ListAllAgents(ctx context.Context) ([]AgentStatus, error) {
+	statuses, err := r.InMemoryAgentRegistry.ListAllAgents(ctx)
+	if err != nil {
+		return nil, err
+	}
+	remote, err := r.remote(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, agent := range remote {
+		statuses = append(statuses, agent.status())
+	}
+	return statuses, nil
+}
+
+// FindAgentsByCapability includes other instances' agents when requests can reach them
+func (r *SharedAgentRegistry) FindAgentsByCapability(ctx context.Context, capability string) ([]AgentStatus, error) {
+	statuses, err := r.InMemoryAgentRegistry.FindAgentsByCapability(ctx, capability)
+	if err != nil || !r.routeRemote {
+		return statuses, err
+	}
+	remote, err := r.remote(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, agent := range remote {
+		for _, offered := range agent.Capabilities {
+			if offered.Name == capability {
+				statuses = append(statuses, agent.status())
+				break
+			}
+		}
+	}
+	return statuses, nil
+}
+
+// FindAgentByID falls back to another instance's agent when requests can reach it
+func (r *SharedAgentRegistry) FindAgentByID(ctx context.Context, agentID string) (AgentInterface, error) {
+	agent, err := r.InMemoryAgentRegistry.FindAgentByID(ctx, agentID)
+	if err == nil || !r.routeRemote {
+		return agent, err
+	}
+	remote, listErr := r.remote(ctx)
+	if listErr != nil {
+		return nil, listErr
+	}
+	for _, shared := range remote {
+		if shared.ID == agentID {
+			return &remoteAgent{shared}, nil
+		}
+	}
+	return nil, err
+}
+
+// GetAvailableCapabilities includes other instances' capabilities when requests can reach them
+func (r *SharedAgentRegistry) GetAvailableCapabilities(ctx context.Context) ([]AgentCapability, error) {
+	capabilities, err := r.InMemoryAgentRegistry.GetAvailableCapabilities(ctx)
+	if err != nil || !r.routeRemote {
+		return capabilities, err
+	}
+	remote, err := r.remote(ctx)
+	if err != nil {
+		return nil, err
+	}
+	known := map[string]bool{}
+	for _, capability := range capabilities {
+		known[capability.Name] = true
+	}
+	for _, agent := range remote {
+		for _, capability := range agent.Capabilities {
+			if !known[capability.Name] {
+				known[capability.Name] = true
+				capabilities = append(capabilities, capability)
+			}
+		}
+	}
+	return capabilities, nil
+}
+
+// GetAgentHealth reports another instance's agent as healthy while its entry is renewed
+func (r *SharedAgentRegistry) GetAgentHealth(ctx context.Context, agentID string) (HealthStatus, error) {
+	health, err := r.InMemoryAgentRegistry.GetAgentHealth(ctx, agentID)
+	if err == nil {
+		return health, nil
+	}
+	remote, listErr := r.remote(ctx)
+	if listErr != nil {
+		return HealthStatus{}, listErr
+	}
+	for _, agent := range remote {
+		if agent.ID == agentID {
+			return (&remoteAgent{agent}).Health(), nil
+		}
+	}
+	return HealthStatus{}, err
+}
+
+// status is the agent's advertised status, naming the instance running it
+func (a SharedAgent) status() AgentStatus {
+	status := a.Status
+	status.ID = a.ID
+	metadata := make(map[string]interface{}, len(status.Metadata)+1)
+	for key, value := range status.Metadata {
+		metadata[key] = value
+	}
+	metadata["instance"] = a.Instance
+	status.Metadata = metadata
+	return status
+}
+
+// remoteAgent stands for an agent running on another instance; requests reach it over the
+// shared event bus, but it cannot be started or stopped from here
+type remoteAgent struct {
+	agent SharedAgent
+}
+
+func (a *remoteAgent) GetID() string                      { return a.agent.ID }
+func (a *remoteAgent) GetStatus() AgentStatus             { return a.agent.status() }
+func (a *remoteAgent) GetCapabilities() []AgentCapability { return a.agent.Capabilities }
+
+func (a *remoteAgent) Start(ctx context.Context) error {
+	return fmt.Errorf("agent %s runs on instance %s", a.agent.ID, a.agent.Instance)
+}
+
+func (a *remoteAgent) Stop(ctx context.Context) error {
+	return fmt.Errorf("agent %s runs on instance %s", a.agent.ID, a.agent.Instance)
+}
+
+func (a *remoteAgent) Health() HealthStatus {
+	return HealthStatus{Healthy: true, Status: "remote", Message: fmt.Sprintf("running on %s, last heartbeat %s", a.agent.Instance, a.agent.Heartbeat.Format(time.RFC3339))}
+}
@@ -0,0 +1,165 @@
+package agentRegistry
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func sharedTestAgent(id, capability string) *MockAgent {
+	return &MockAgent{
+		id:           id,
+		capabilities: []AgentCapability{{Name: capability, Intents: []string{capability}}},
+		status:       AgentStatus{ID: id, Status: "running"},
+		health:       HealthStatus{Healthy: true, Status: "healthy"},
+	}
+}
+
+func TestSharedAgentRegistry_InstancesSeeEachOthersAgents(t *testing.T) {
+	ctx := context.Background()
+	directory := NewMemoryDirectory()
+	a := NewSharedAgentRegistry(directory, "ztdp-a", 15*time.Second, true)
+	b := NewSharedAgentRegistry(directory, "ztdp-b", 15*time.Second, true)
+
+	if err := a.RegisterAgent(ctx, sharedTestAgent("deployment-agent", "deployment_orchestration")); err != nil {
+		t.Fatalf("RegisterAgent: %v", err)
+	}
+	if err := b.RegisterAgent(ctx, sharedTestAgent("deployment-agent", "deployment_orchestration")); err != nil {
+		t.Fatalf("RegisterAgent: %v", err)
+	}
+	if err := b.RegisterAgent(ctx, sharedTestAgent("ml-agent", "model_deployment")); err != nil {
+		t.Fatalf("RegisterAgent: %v", err)
+	}
+
+	// Both instances list the same agents; the one both run is listed once
+	for _, registry := range []*SharedAgentRegistry{a, b} {
+		statuses, err := registry.ListAllAgents(ctx)
+		if err != nil {
+			t.Fatalf("ListAllAgents: %v", err)
+		}
+		if len(statuses) != 2 {
+			t.Fatalf("%s: expected 2 agents, got %+v", registry.instance, statuses)
+		}
+	}
+	shared, err := a.SharedAgents(ctx)
+	if err != nil || len(shared) != 3 {
+		t.Fatalf("expected 3 advertised agents across instances, got %+v (%v)", shared, err)
+	}
+
+	// a routes to b's agent, which it can neither start nor stop
+	statuses, err := a.FindAgentsByCapability(ctx, "model_deployment")
+	if err != nil || len(statuses) != 1 || statuses[0].Metadata["instance"] != "ztdp-b" {
+		t.Fatalf("expected ml-agent on ztdp-b, got %+v (%v)", statuses, err)
+	}
+	agent, err := a.FindAgentByID(ctx, "ml-agent")
+	if err != nil {
+		t.Fatalf("FindAgentByID: %v", err)
+	}
+	if err := agent.Stop(ctx); err == nil {
+		t.Error("expected stopping another instance's agent to fail")
+	}
+	if health, err := a.GetAgentHealth(ctx, "ml-agent"); err != nil || !health.Healthy {
+		t.Errorf("expected a remote agent with a live entry to be healthy, got %+v (%v)", health, err)
+	}
+
+	// Local agents win over another instance's agent with the same ID
+	statuses, err = a.FindAgentsByCapability(ctx, "deployment_orchestration")
+	if err != nil || len(statuses) != 1 || statuses[0].Metadata["instance"] != nil {
+		t.Fatalf("expected only the local deployment-agent, got %+v (%v)", statuses, err)
+	}
+
+	// Unregistering withdraws the agent from the cluster
+	if err := b.UnregisterAgent(ctx, "ml-agent"); err != nil {
+		t.Fatalf("UnregisterAgent: %v", err)
+	}
+	if _, err := a.FindAgentByID(ctx, "ml-agent"); err == nil {
+		t.Error("expected the unregistered agent to be gone from other instances")
+	}
+}
+
+func TestSharedAgentRegistry_RemoteAgentsNeedASharedBusToBeRouted(t *testing.T) {
+	ctx := context.Background()
+	directory := NewMemoryDirectory()
+	a := NewSharedAgentRegistry(directory, "ztdp-a", 15*time.Second, false)
+	b := NewSharedAgentRegistry(directory, "ztdp-b", 15*time.Second, false)
+	if err := b.RegisterAgent(ctx, sharedTestAgent("ml-agent", "model_deployment")); err != nil {
+		t.Fatalf("RegisterAgent: %v", err)
+	}
+
+	statuses, err := a.ListAllAgents(ctx)
+	if err != nil || len(statuses) != 1 {
+		t.Fatalf("expected ml-agent to be listed, got %+v (%v)", statuses, err)
+	}
+	if statuses, _ := a.FindAgentsByCapability(ctx, "model_deployment"); len(statuses) != 0 {
+		t.Errorf("expected no routing to another instance's agent without a shared bus, got %+v", statuses)
+	}
+	if capabilities, _ := a.GetAvailableCapabilities(ctx); len(capabilities) != 0 {
+		t.Errorf("expected no remote capabilities without a shared bus, got %+v", capabilities)
+	}
+}
+
+func TestSharedAgentRegistry_AgentsExpireWithoutHeartbeats(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	directory := NewMemoryDirectory()
+	directory.now = func() time.Time { return now }
+	a := NewSharedAgentRegistry(directory, "ztdp-a", 15*time.Second, true)
+	b := NewSharedAgentRegistry(directory, "ztdp-b", 15*time.Second, true)
+	if err := b.RegisterAgent(ctx, sharedTestAgent("ml-agent", "model_deployment")); err != nil {
+		t.Fatalf("RegisterAgent: %v", err)
+	}
+
+	// Heartbeats keep the entry alive past the first TTL
+	now = now.Add(10 * time.Second)
+	if err := b.Heartbeat(ctx); err != nil {
+		t.Fatalf("Heartbeat: %v", err)
+	}
+	now = now.Add(10 * time.Second)
+	if _, err := a.FindAgentByID(ctx, "ml-agent"); err != nil {
+		t.Fatalf("expected a heartbeating agent to stay visible: %v", err)
+	}
+
+	// b stops heartbeating, as if it crashed
+	now = now.Add(15 * time.Second)
+	if _, err := a.FindAgentByID(ctx, "ml-agent"); err == nil {
+		t.Error("expected the agent of an instance that stopped heartbeating to expire")
+	}
+}
+
+func TestRedisDirectory(t *testing.T) {
+	addr := os.Getenv("REDIS_HOST")
+	if addr == "" {
+		t.Skip("REDIS_HOST not set, skipping Redis agent directory test")
+	}
+	ctx := context.Background()
+	directory := NewRedisDirectory(redis.NewClient(&redis.Options{Addr: addr, Password: os.Getenv("REDIS_PASSWORD")}))
+	t.Cleanup(func() { directory.Remove(ctx, "redis-test", "ml-agent") })
+
+	if err := directory.Put(ctx, SharedAgent{ID: "ml-agent", Instance: "redis-test", Capabilities: []AgentCapability{{Name: "model_deployment"}}}, time.Second); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if !listed(t, directory, "redis-test") {
+		t.Fatal("expected the advertised agent to be listed")
+	}
+	time.Sleep(1100 * time.Millisecond)
+	if listed(t, directory, "redis-test") {
+		t.Fatal("expected the agent to expire after its TTL")
+	}
+}
+
+func listed(t *testing.T, directory Directory, instance string) bool {
+	t.Helper()
+	agents, err := directory.List(context.Background())
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	for _, agent := range agents {
+		if agent.Instance == instance && agent.ID == "ml-agent" {
+			return true
+		}
+	}
+	return false
+}
@@ -2,15 +2,25 @@ package orchestrator
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
 	"github.com/krzachariassen/ZTDP/internal/ai"
+	"github.com/krzachariassen/ZTDP/internal/analytics"
+	"github.com/krzachariassen/ZTDP/internal/conversations"
+	"github.com/krzachariassen/ZTDP/internal/decisions"
+	"github.com/krzachariassen/ZTDP/internal/degradation"
 	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/features"
 	"github.com/krzachariassen/ZTDP/internal/graph"
 	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/krzachariassen/ZTDP/internal/provenance"
+	"github.com/krzachariassen/ZTDP/internal/routing"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
 )
 
 // Orchestrator - Pure AI-native orchestrator following Clean Architecture
@@ -21,6 +31,12 @@ type Orchestrator struct {
 	graph         *graph.GlobalGraph
 	eventBus      *events.EventBus
 	agentRegistry agentRegistry.AgentRegistry
+	flags         *features.Service
+	transcripts   *conversations.Service // nil disables transcript storage
+	analytics     *analytics.Collector   // nil disables intent analytics
+	decisions     *decisions.Service     // nil disables the routing decision audit trail
+	routes        *routing.Service       // nil disables routing overrides
+	degradation   *degradation.Monitor   // nil only skips the AI when a call to it fails
 
 	// Agent interface properties
 	agentID   string
@@ -34,16 +50,48 @@ type Orchestrator struct {
 
 	// Test mode flag - when true, don't wait for agent responses
 	testMode bool
+
+	// Shutdown tracking - in-flight chats are drained before agents stop
+	inflight sync.WaitGroup
+	mu       sync.RWMutex
+	draining bool
+
+	// Running orchestrations by conversation, for cancel/pause/status interruptions
+	active map[string]*activeOrchestration
+
+	// Questions agents asked by conversation; the conversation's next message answers them
+	clarifications map[string]*pendingClarification
+
+	// Dispatched tasks by correlation ID, with the acks and nacks agents sent for them
+	tasks      *taskTracker
+	tasksOnce  sync.Once
+	ackTimeout time.Duration // zero uses DefaultAckTimeout
+
+	// Competing agents bid for requests when an arbitration policy is set
+	arbitration ArbitrationPolicy
+	bidTimeout  time.Duration
+	bids        pendingBids
+
+	// Consecutive responses slower than the SLA of the agent's capability
+	sla slaMonitor
 }
 
+// FlagAIIntentDetection switches AI intent detection off per conversation, tenant or globally;
+// when off, requests are served by the deterministic fallback handlers
+const FlagAIIntentDetection = "orchestrator.ai-intent-detection"
+
+// ErrShuttingDown is returned for chat requests received while the orchestrator drains
+var ErrShuttingDown = errors.New("orchestrator is shutting down")
+
 // ConversationalResponse represents the response structure for chat interactions
 type ConversationalResponse struct {
-	Message    string   `json:"message"`
-	Answer     string   `json:"answer,omitempty"`
-	Intent     string   `json:"intent,omitempty"`
-	Actions    []Action `json:"actions,omitempty"`
-	Insights   []string `json:"insights,omitempty"`
-	Confidence float64  `json:"confidence,omitempty"`
+	ConversationID string   `json:"conversation_id,omitempty"`
+	Message        string   `json:"message"`
+	Answer         string   `json:"answer,omitempty"`
+	Intent         string   `json:"intent,omitempty"`
+	Actions        []Action `json:"actions,omitempty"`
+	Insights       []string `json:"insights,omitempty"`
+	Confidence     float64  `json:"confidence,omitempty"`
 }
 
 // Action represents an action taken by the orchestrator
@@ -65,24 +113,236 @@ func NewOrchestrator(
 		graph:         globalGraph,
 		eventBus:      eventBus,
 		agentRegistry: agentRegistry,
+		flags:         features.NewService(globalGraph),
 		agentID:       "orchestrator",
 	}
 }
 
 // Chat - Simplified AI-native orchestration interface
 func (o *Orchestrator) Chat(ctx context.Context, userMessage string) (*ConversationalResponse, error) {
-	o.logger.Info("🤖 Orchestrator Chat: %s", userMessage)
+	ctx, _ = logging.EnsureCorrelationID(ctx)
+	o.logger.ForContext(ctx).Info("🤖 Orchestrator Chat: %s", userMessage)
+
+	// Graph changes made for the request are signed as AI-initiated
+	ctx = provenance.WithOperation(ctx, provenance.InitiatorAI, o.agentID)
+
+	// Interruptions act on the conversation's running orchestration ahead of normal routing
+	if kind, ok := detectInterruption(userMessage); ok {
+		if response, handled := o.handleInterruption(ctx, kind); handled {
+			o.recordTranscript(ctx, userMessage, response)
+			return response, nil
+		}
+	}
+
+	o.mu.RLock()
+	if o.draining {
+		o.mu.RUnlock()
+		return nil, ErrShuttingDown
+	}
+	o.inflight.Add(1)
+	o.mu.RUnlock()
+	defer o.inflight.Done()
 
 	// STEP 1: Use AI to determine intent and route accordingly
-	return o.routeUserRequest(ctx, userMessage)
+	start := time.Now()
+	response, err := o.routeUserRequest(ctx, userMessage)
+	o.recordIntent(ctx, userMessage, response, err, time.Since(start))
+	if err != nil {
+		return nil, err
+	}
+
+	// STEP 2: Persist the turn so the conversation can be reviewed later
+	o.recordTranscript(ctx, userMessage, response)
+	return response, nil
+}
+
+// ChatStream is Chat for streaming clients: free-form answers are passed to onChunk as the AI
+// generates them, and responses produced in one piece are passed whole
+func (o *Orchestrator) ChatStream(ctx context.Context, userMessage string, onChunk func(string)) (*ConversationalResponse, error) {
+	sink := &streamSink{onChunk: onChunk}
+	response, err := o.Chat(context.WithValue(ctx, streamSinkKey{}, sink), userMessage)
+	if err != nil {
+		return nil, err
+	}
+	if !sink.streamed && response.Message != "" {
+		onChunk(response.Message)
+	}
+	return response, nil
+}
+
+// streamSink receives incremental output for a ChatStream call
+type streamSink struct {
+	onChunk  func(string)
+	streamed bool
+}
+
+type streamSinkKey struct{}
+
+// callConversationAI streams to the caller's sink when the chat is streaming
+func (o *Orchestrator) callConversationAI(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	sink, ok := ctx.Value(streamSinkKey{}).(*streamSink)
+	if !ok {
+		return o.aiProvider.CallAI(ctx, systemPrompt, userPrompt)
+	}
+	chunks, err := ai.CallAIStream(ctx, o.aiProvider, systemPrompt, userPrompt)
+	if err != nil {
+		return "", err
+	}
+	return ai.CollectStream(chunks, func(chunk string) {
+		sink.streamed = true
+		sink.onChunk(chunk)
+	})
+}
+
+// SetTranscripts enables storing chat transcripts in the graph
+func (o *Orchestrator) SetTranscripts(transcripts *conversations.Service) {
+	o.transcripts = transcripts
+}
+
+// recordTranscript appends the turn to the conversation transcript. Failures are logged
+// rather than returned because the user already has their answer.
+func (o *Orchestrator) recordTranscript(ctx context.Context, userMessage string, response *ConversationalResponse) {
+	if o.transcripts == nil || response == nil {
+		return
+	}
+
+	evalCtx := features.EvaluationContextFrom(ctx)
+	turn := conversations.Turn{
+		CorrelationID: logging.CorrelationIDFromContext(ctx),
+		UserMessage:   userMessage,
+		Intent:        response.Intent,
+		Response:      response.Message,
+	}
+	for _, action := range response.Actions {
+		recorded := conversations.Action{Type: action.Type}
+		if result, ok := action.Result.(map[string]interface{}); ok {
+			recorded.Status, _ = result["status"].(string)
+			if agent, ok := result["selected_agent"].(string); ok {
+				turn.SelectedAgent = agent
+			}
+			if content, ok := result["response_content"].(string); ok {
+				turn.AgentResponses = append(turn.AgentResponses, content)
+			}
+		}
+		turn.Actions = append(turn.Actions, recorded)
+	}
+
+	if _, err := o.transcripts.RecordTurn(evalCtx.ConversationID, evalCtx.Tenant, turn); err != nil {
+		o.logger.ForContext(ctx).Warn("⚠️ Failed to record conversation transcript: %v", err)
+		return
+	}
+	response.ConversationID = evalCtx.ConversationID
+}
+
+// SetDegradation routes requests deterministically, without calling the AI, while the monitor
+// reports the AI provider down
+func (o *Orchestrator) SetDegradation(monitor *degradation.Monitor) {
+	o.degradation = monitor
+}
+
+// SetAnalytics enables recording intent classifications for the analytics API
+func (o *Orchestrator) SetAnalytics(collector *analytics.Collector) {
+	o.analytics = collector
+}
+
+// recordIntent records how the request was classified and routed, and how it ended
+func (o *Orchestrator) recordIntent(ctx context.Context, userMessage string, response *ConversationalResponse, err error, latency time.Duration) {
+	if o.analytics == nil {
+		return
+	}
+
+	evalCtx := features.EvaluationContextFrom(ctx)
+	record := analytics.IntentRecord{
+		ConversationID: evalCtx.ConversationID,
+		Tenant:         evalCtx.Tenant,
+		Message:        userMessage,
+		Outcome:        analytics.OutcomeFailed,
+		LatencyMS:      latency.Milliseconds(),
+	}
+	if err != nil || response == nil {
+		o.analytics.Record(ctx, record)
+		return
+	}
+
+	record.Intent = response.Intent
+	record.Confidence = response.Confidence
+	record.Outcome = analytics.OutcomeUnroutable
+	for _, action := range response.Actions {
+		switch action.Type {
+		case "conversation":
+			record.Outcome = analytics.OutcomeConversation
+		case "fallback":
+			record.Outcome = analytics.OutcomeFallback
+		case "orchestration":
+			result, _ := action.Result.(map[string]interface{})
+			record.Agent, _ = result["selected_agent"].(string)
+			switch result["status"] {
+			case "error":
+				record.Outcome = analytics.OutcomeError
+			case "timeout":
+				record.Outcome = analytics.OutcomeTimeout
+			default:
+				record.Outcome = analytics.OutcomeSuccess
+			}
+			// Agents that extract parameters with AI report how sure they were
+			if payload, ok := result["agent_response"].(map[string]interface{}); ok {
+				if confidence, ok := payload["confidence"].(float64); ok && record.Confidence == 0 {
+					record.Confidence = confidence
+				}
+			}
+		}
+	}
+	o.analytics.Record(ctx, record)
+}
+
+// Drain stops accepting chat requests and waits for in-flight orchestrations to finish
+func (o *Orchestrator) Drain(ctx context.Context) error {
+	o.mu.Lock()
+	o.draining = true
+	o.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		o.inflight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		o.logger.Info("✅ Orchestrator drained")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("timed out waiting for in-flight orchestrations: %w", ctx.Err())
+	}
 }
 
 // routeUserRequest - Simplified routing using AI to determine intent and route accordingly
 func (o *Orchestrator) routeUserRequest(ctx context.Context, userMessage string) (*ConversationalResponse, error) {
+	// An agent asked the user a question; this message answers it
+	if pending, ok := o.takeClarification(ctx); ok {
+		return o.continueClarification(ctx, pending, userMessage), nil
+	}
+
+	// Operators pinned requests like this one to an agent; the AI is not asked
+	if response, ok := o.routeByOverride(ctx, userMessage); ok {
+		return response, nil
+	}
+
 	// Check if AI provider is available
 	if o.aiProvider == nil {
-		o.logger.Warn("AI provider not available, falling back to general conversation")
-		return o.handleGeneralConversation(ctx, userMessage)
+		o.logger.Warn("AI provider not available, using deterministic fallback handlers")
+		return o.handleWithoutAI(ctx, userMessage)
+	}
+
+	// Skip the AI while its outage has put the platform in deterministic mode
+	if o.degradation != nil && o.degradation.Active(degradation.ModeDeterministic) {
+		o.logger.Info("AI provider unavailable, using deterministic fallback handlers")
+		return o.handleWithoutAI(ctx, userMessage)
+	}
+
+	if !o.flags.IsEnabled(ctx, FlagAIIntentDetection, true) {
+		o.logger.Info("🚩 AI intent detection disabled by feature flag, using deterministic handlers")
+		return o.handleWithoutAI(ctx, userMessage)
 	}
 
 	// Use AI to determine the intent based on available agent capabilities
@@ -92,11 +352,11 @@ func (o *Orchestrator) routeUserRequest(ctx context.Context, userMessage string)
 		intentDetectionPrompt = o.getDefaultIntentDetectionPrompt()
 	}
 
-	response, err := o.aiProvider.CallAI(ctx, intentDetectionPrompt, userMessage)
+	response, err := o.aiProvider.CallAI(ai.WithTask(ctx, ai.TaskClassification), intentDetectionPrompt, userMessage)
 	if err != nil {
 		o.logger.Error("Intent detection failed: %v", err)
-		// Fall back to general conversation
-		return o.handleGeneralConversation(ctx, userMessage)
+		// AI is unreachable - fall back to deterministic handlers
+		return o.handleWithoutAI(ctx, userMessage)
 	}
 
 	// Clean up the response
@@ -107,6 +367,11 @@ func (o *Orchestrator) routeUserRequest(ctx context.Context, userMessage string)
 		return o.handleGeneralConversation(ctx, userMessage)
 	}
 
+	// Factual questions are answered from an executed graph query
+	if intent == graphQueryIntent {
+		return o.answerGraphQuery(ctx, userMessage)
+	}
+
 	o.logger.Info("🎯 Detected operational intent: %s", intent)
 
 	// Route to appropriate agent via intent-based orchestration
@@ -114,25 +379,36 @@ func (o *Orchestrator) routeUserRequest(ctx context.Context, userMessage string)
 		"user_message": userMessage,
 		"source":       "orchestrator-chat",
 	})
+	return o.intentConversationalResponse(ctx, intent, userMessage, result, err), nil
+}
 
+// intentConversationalResponse turns the result of an intent orchestration into the chat response
+func (o *Orchestrator) intentConversationalResponse(ctx context.Context, intent, userMessage string, result interface{}, err error) *ConversationalResponse {
 	if err != nil {
 		o.logger.Error("Intent orchestration failed: %v", err)
 		return &ConversationalResponse{
 			Message: fmt.Sprintf("I understood you want to %s, but encountered an error: %v", intent, err),
 			Answer:  fmt.Sprintf("I understood you want to %s, but encountered an error: %v", intent, err),
-		}, nil
+			Intent:  intent,
+		}
 	}
 
 	// Convert result to conversational response
 	var responseMessage string
 	if result != nil {
 		if resultMap, ok := result.(map[string]interface{}); ok {
+			// The agent needs more detail; the user's next message answers its question
+			if status, _ := resultMap["status"].(string); status == agentframework.ClarificationStatus {
+				o.awaitClarification(ctx, intent, userMessage, resultMap)
+			}
 			if status, exists := resultMap["status"].(string); exists && status == "error" {
 				if responseContent, ok := resultMap["response_content"].(string); ok {
 					responseMessage = responseContent
 				} else {
 					responseMessage = fmt.Sprintf("❌ %s request failed", intent)
 				}
+			} else if status, exists := resultMap["status"].(string); exists && status == "cancelled" {
+				responseMessage, _ = resultMap["message"].(string)
 			} else if status, exists := resultMap["status"].(string); exists && status == "timeout" {
 				intent := resultMap["intent"].(string)
 				agentID := resultMap["selected_agent"].(string)
@@ -154,7 +430,7 @@ func (o *Orchestrator) routeUserRequest(ctx context.Context, userMessage string)
 		Answer:  responseMessage,
 		Intent:  intent,
 		Actions: []Action{{Type: "orchestration", Result: result}},
-	}, nil
+	}
 }
 
 // handleGeneralConversation - Simplified general conversation handling
@@ -173,7 +449,7 @@ func (o *Orchestrator) handleGeneralConversation(ctx context.Context, userMessag
 		conversationPrompt = o.getDefaultConversationPrompt()
 	}
 
-	response, err := o.aiProvider.CallAI(ctx, conversationPrompt, userMessage)
+	response, err := o.callConversationAI(ai.WithTask(ctx, ai.TaskConversation), conversationPrompt, userMessage)
 	if err != nil {
 		return nil, fmt.Errorf("AI call failed: %w", err)
 	}
@@ -0,0 +1,255 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+	"github.com/krzachariassen/ZTDP/internal/decisions"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
+)
+
+// DefaultBidTimeout is how long arbitration waits for bids when no timeout is set
+const DefaultBidTimeout = 2 * time.Second
+
+// AgentBid is a candidate agent and the bid it made for a request
+type AgentBid struct {
+	Agent agentRegistry.AgentStatus
+	Bid   agentframework.Bid
+}
+
+// ArbitrationPolicy ranks the bids of agents competing for a request. Rank receives the bids
+// that were made and not declined, in registration order, and returns all of them best first.
+type ArbitrationPolicy interface {
+	Name() string
+	Rank(bids []AgentBid) []AgentBid
+}
+
+// Arbitration policy names
+const (
+	PolicyConfidence = "confidence" // most confident first, then cheapest, then fastest
+	PolicyCost       = "cost"       // cheapest first among bids meeting the minimum confidence
+	PolicyETA        = "eta"        // fastest first among bids meeting the minimum confidence
+)
+
+// NewArbitrationPolicy returns a built-in policy by name. The cost and eta policies try bids
+// below minConfidence only after the others.
+func NewArbitrationPolicy(name string, minConfidence float64) (ArbitrationPolicy, error) {
+	switch name {
+	case PolicyConfidence, "":
+		return confidencePolicy{}, nil
+	case PolicyCost:
+		return thresholdPolicy{name: PolicyCost, minConfidence: minConfidence, less: func(a, b agentframework.Bid) bool {
+			return a.Cost < b.Cost
+		}}, nil
+	case PolicyETA:
+		return thresholdPolicy{name: PolicyETA, minConfidence: minConfidence, less: func(a, b agentframework.Bid) bool {
+			return a.ETASeconds < b.ETASeconds
+		}}, nil
+	}
+	return nil, fmt.Errorf("unknown arbitration policy %q (use confidence, cost or eta)", name)
+}
+
+type confidencePolicy struct{}
+
+func (confidencePolicy) Name() string { return PolicyConfidence }
+
+func (confidencePolicy) Rank(bids []AgentBid) []AgentBid {
+	ranked := append([]AgentBid(nil), bids...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := ranked[i].Bid, ranked[j].Bid
+		if a.Confidence != b.Confidence {
+			return a.Confidence > b.Confidence
+		}
+		if a.Cost != b.Cost {
+			return a.Cost < b.Cost
+		}
+		return a.ETASeconds < b.ETASeconds
+	})
+	return ranked
+}
+
+// thresholdPolicy orders bids meeting the minimum confidence by less, ties going to the more
+// confident, and the remaining bids by confidence after them
+type thresholdPolicy struct {
+	name          string
+	minConfidence float64
+	less          func(a, b agentframework.Bid) bool
+}
+
+func (p thresholdPolicy) Name() string { return p.name }
+
+func (p thresholdPolicy) Rank(bids []AgentBid) []AgentBid {
+	ranked := append([]AgentBid(nil), bids...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := ranked[i].Bid, ranked[j].Bid
+		aOK, bOK := a.Confidence >= p.minConfidence, b.Confidence >= p.minConfidence
+		if aOK != bOK {
+			return aOK
+		}
+		if aOK && p.less(a, b) != p.less(b, a) {
+			return p.less(a, b)
+		}
+		return a.Confidence > b.Confidence
+	})
+	return ranked
+}
+
+// SetArbitration makes the orchestrator ask every agent offering an intent for a bid when more
+// than one does, and try them in the order the policy ranks the bids. Agents that have not bid
+// within bidTimeout are tried after those that did; agents that decline are not tried. A nil
+// policy tries agents in registration order.
+func (o *Orchestrator) SetArbitration(policy ArbitrationPolicy, bidTimeout time.Duration) {
+	if bidTimeout <= 0 {
+		bidTimeout = DefaultBidTimeout
+	}
+	o.arbitration = policy
+	o.bidTimeout = bidTimeout
+}
+
+// pendingBids matches bids on the bus to the arbitration waiting for them
+type pendingBids struct {
+	once    sync.Once
+	mu      sync.Mutex
+	waiting map[string]chan *events.Event
+}
+
+// arbitrate asks the candidates for bids and returns them in the order to try them, with the
+// record of the arbitration. Candidates are returned unchanged when every one of them declines,
+// so the usual rejection handling reports why.
+func (o *Orchestrator) arbitrate(ctx context.Context, intent string, request map[string]interface{}, candidates []agentRegistry.AgentStatus) ([]agentRegistry.AgentStatus, *decisions.Arbitration) {
+	correlationID := "bid-" + uuid.New().String()
+	bids := o.awaitBids(correlationID, len(candidates))
+	defer o.forgetBids(correlationID)
+
+	for _, candidate := range candidates {
+		routingKey, err := o.discoverRoutingKeyForIntent(ctx, intent, candidate.ID)
+		if err != nil {
+			continue
+		}
+		payload := make(map[string]interface{}, len(request)+3)
+		for k, v := range request {
+			payload[k] = v
+		}
+		payload["correlation_id"] = correlationID
+		payload[agentframework.BidRequestKey] = true
+		payload[agentframework.TargetAgentKey] = candidate.ID
+		if err := o.eventBus.EmitWithTTL(events.EventTypeRequest, "orchestrator", routingKey, payload, o.bidTimeout); err != nil {
+			o.logger.Warn("⚠️ Failed to ask agent %s for a bid: %v", candidate.ID, err)
+		}
+	}
+
+	received := make(map[string]agentframework.Bid, len(candidates))
+	timer := time.NewTimer(o.bidTimeout)
+	defer timer.Stop()
+collect:
+	for len(received) < len(candidates) {
+		select {
+		case response := <-bids:
+			if bid, ok := agentframework.BidFrom(response); ok {
+				received[response.Source] = bid
+			}
+		case <-timer.C:
+			break collect
+		case <-ctx.Done():
+			break collect
+		}
+	}
+
+	var made, missed []AgentBid
+	var declined []decisions.Bid
+	for _, candidate := range candidates {
+		bid, ok := received[candidate.ID]
+		switch {
+		case !ok:
+			missed = append(missed, AgentBid{Agent: candidate})
+		case bid.Declined:
+			declined = append(declined, decisions.Bid{Agent: candidate.ID, Answered: true, Declined: true, Reason: bid.Reason})
+		default:
+			made = append(made, AgentBid{Agent: candidate, Bid: bid})
+		}
+	}
+
+	record := &decisions.Arbitration{Policy: o.arbitration.Name()}
+	var ordered []agentRegistry.AgentStatus
+	for _, ranked := range o.arbitration.Rank(made) {
+		ordered = append(ordered, ranked.Agent)
+		record.Bids = append(record.Bids, decisions.Bid{
+			Agent: ranked.Agent.ID, Answered: true, Confidence: ranked.Bid.Confidence,
+			Cost: ranked.Bid.Cost, ETASeconds: ranked.Bid.ETASeconds, Reason: ranked.Bid.Reason,
+		})
+	}
+	for _, candidate := range missed {
+		ordered = append(ordered, candidate.Agent)
+		record.Bids = append(record.Bids, decisions.Bid{Agent: candidate.Agent.ID})
+	}
+	record.Bids = append(record.Bids, declined...)
+
+	if len(ordered) == 0 {
+		o.logger.Warn("⚖️ Every agent declined intent %s", intent)
+		return candidates, record
+	}
+	record.Winner = ordered[0].ID
+	o.logger.ForContext(ctx).Info("⚖️ Arbitration (%s) for intent %s: %s", record.Policy, intent, describeBids(record.Bids))
+	return ordered, record
+}
+
+// describeBids summarizes bids in order, e.g. "deployer (confidence 0.90, cost 2, eta 30s)"
+func describeBids(bids []decisions.Bid) string {
+	parts := make([]string, 0, len(bids))
+	for _, bid := range bids {
+		switch {
+		case !bid.Answered:
+			parts = append(parts, bid.Agent+" (no bid)")
+		case bid.Declined:
+			parts = append(parts, fmt.Sprintf("%s (declined: %s)", bid.Agent, bid.Reason))
+		default:
+			parts = append(parts, fmt.Sprintf("%s (confidence %.2f, cost %g, eta %gs)", bid.Agent, bid.Confidence, bid.Cost, bid.ETASeconds))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// awaitBids returns the channel the bids with correlationID will be delivered on
+func (o *Orchestrator) awaitBids(correlationID string, expected int) <-chan *events.Event {
+	o.bids.once.Do(func() {
+		o.bids.waiting = make(map[string]chan *events.Event)
+		o.eventBus.Subscribe(events.EventTypeResponse, o.deliverBid)
+	})
+	bids := make(chan *events.Event, expected)
+	o.bids.mu.Lock()
+	o.bids.waiting[correlationID] = bids
+	o.bids.mu.Unlock()
+	return bids
+}
+
+func (o *Orchestrator) forgetBids(correlationID string) {
+	o.bids.mu.Lock()
+	delete(o.bids.waiting, correlationID)
+	o.bids.mu.Unlock()
+}
+
+// deliverBid hands a bid to the arbitration waiting for its correlation ID, if any
+func (o *Orchestrator) deliverBid(event events.Event) error {
+	correlationID, _ := event.Payload["correlation_id"].(string)
+	if !strings.HasPrefix(correlationID, "bid-") {
+		return nil
+	}
+	o.bids.mu.Lock()
+	bids, ok := o.bids.waiting[correlationID]
+	o.bids.mu.Unlock()
+	if ok {
+		select {
+		case bids <- &event:
+		default:
+			// More answers than candidates; the extras are duplicates
+		}
+	}
+	return nil
+}
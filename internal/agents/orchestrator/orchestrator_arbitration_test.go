@@ -0,0 +1,135 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+	"github.com/krzachariassen/ZTDP/internal/ai/aitest"
+	"github.com/krzachariassen/ZTDP/internal/decisions"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
+)
+
+// newBiddingAgents registers deployers that bid as given (nil bids the default) and records
+// which of them handled a request
+func newBiddingAgents(t *testing.T, registry agentRegistry.AgentRegistry, bus *events.EventBus, bids map[string]*agentframework.Bid) *[]string {
+	t.Helper()
+	handled := &[]string{}
+	for id, bid := range bids {
+		id, bid := id, bid
+		var agent agentRegistry.AgentInterface
+		builder := agentframework.NewAgent(id).
+			WithCapabilities([]agentRegistry.AgentCapability{deployCapability}).
+			WithEventHandler(func(ctx context.Context, event *events.Event) (*events.Event, error) {
+				*handled = append(*handled, id)
+				return agent.(*agentframework.BaseAgent).CreateResponse("deployed", map[string]interface{}{"message": "deployed by " + id}, event), nil
+			})
+		if bid != nil {
+			builder = builder.WithBidder(func(ctx context.Context, event *events.Event) (agentframework.Bid, error) {
+				if bid.Declined {
+					return agentframework.Bid{}, errors.New("too busy")
+				}
+				return *bid, nil
+			})
+		}
+		var err error
+		if agent, err = builder.Build(agentframework.AgentDependencies{Registry: registry, EventBus: bus}); err != nil {
+			t.Fatalf("Failed to build agent %s: %v", id, err)
+		}
+	}
+	return handled
+}
+
+// TestOrchestratorArbitratesCompetingAgents tests that competing agents bid and the policy's
+// favourite handles the request, with the arbitration recorded on the decision
+func TestOrchestratorArbitratesCompetingAgents(t *testing.T) {
+	g := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	registry := agentRegistry.NewInMemoryAgentRegistry()
+	bus := events.NewEventBus(nil, false)
+	handled := newBiddingAgents(t, registry, bus, map[string]*agentframework.Bid{
+		"fast-deployer":  {Confidence: 0.6, Cost: 1, ETASeconds: 10},
+		"sure-deployer":  {Confidence: 0.95, Cost: 5, ETASeconds: 120},
+		"busy-deployer":  {Declined: true},
+		"plain-deployer": nil,
+	})
+
+	o := NewOrchestrator(&aitest.Provider{}, g, bus, registry)
+	decisionService := decisions.NewService(g, 0)
+	o.SetDecisions(decisionService)
+	policy, err := NewArbitrationPolicy(PolicyConfidence, 0)
+	if err != nil {
+		t.Fatalf("Failed to create policy: %v", err)
+	}
+	o.SetArbitration(policy, time.Second)
+
+	if _, err := o.orchestrateViaIntentBasedAgents(context.Background(), "deploy application", map[string]interface{}{"user_message": "deploy checkout"}); err != nil {
+		t.Fatalf("Orchestration failed: %v", err)
+	}
+	if len(*handled) != 1 || (*handled)[0] != "sure-deployer" {
+		t.Errorf("Expected only sure-deployer to handle the request, got: %v", *handled)
+	}
+
+	recorded, err := decisionService.List(decisions.Filter{})
+	if err != nil || len(recorded) != 1 {
+		t.Fatalf("Expected one decision, got %d (%v)", len(recorded), err)
+	}
+	arbitration := recorded[0].Arbitration
+	if arbitration == nil || arbitration.Winner != "sure-deployer" || arbitration.Policy != PolicyConfidence {
+		t.Fatalf("Expected sure-deployer to win the arbitration, got: %+v", arbitration)
+	}
+	order := make([]string, 0, len(arbitration.Bids))
+	for _, bid := range arbitration.Bids {
+		order = append(order, bid.Agent)
+	}
+	if strings.Join(order, ",") != "sure-deployer,fast-deployer,plain-deployer,busy-deployer" {
+		t.Errorf("Unexpected bid order: %v", order)
+	}
+	if last := arbitration.Bids[3]; !last.Declined || last.Reason != "too busy" {
+		t.Errorf("Expected busy-deployer to decline, got: %+v", last)
+	}
+	if !strings.Contains(recorded[0].Reasoning, "confidence arbitration policy ranked their bids") {
+		t.Errorf("Expected the reasoning to describe the arbitration, got: %s", recorded[0].Reasoning)
+	}
+
+	stats, err := decisionService.ArbitrationStats(decisions.Filter{})
+	if err != nil || len(stats) != 4 || stats[0].Agent != "sure-deployer" || stats[0].Wins != 1 {
+		t.Errorf("Expected sure-deployer to lead the arbitration stats, got: %+v (%v)", stats, err)
+	}
+}
+
+func TestArbitrationPolicies(t *testing.T) {
+	bids := []AgentBid{
+		{Agent: agentRegistry.AgentStatus{ID: "sure"}, Bid: agentframework.Bid{Confidence: 0.95, Cost: 5, ETASeconds: 120}},
+		{Agent: agentRegistry.AgentStatus{ID: "cheap"}, Bid: agentframework.Bid{Confidence: 0.7, Cost: 1, ETASeconds: 60}},
+		{Agent: agentRegistry.AgentStatus{ID: "reckless"}, Bid: agentframework.Bid{Confidence: 0.2, Cost: 0, ETASeconds: 1}},
+	}
+	ids := func(ranked []AgentBid) string {
+		var out []string
+		for _, bid := range ranked {
+			out = append(out, bid.Agent.ID)
+		}
+		return strings.Join(out, ",")
+	}
+
+	for name, expected := range map[string]string{
+		PolicyConfidence: "sure,cheap,reckless",
+		PolicyCost:       "cheap,sure,reckless",
+		PolicyETA:        "cheap,sure,reckless",
+	} {
+		policy, err := NewArbitrationPolicy(name, 0.5)
+		if err != nil {
+			t.Fatalf("Failed to create %s policy: %v", name, err)
+		}
+		if got := ids(policy.Rank(bids)); got != expected {
+			t.Errorf("Policy %s ranked %s, expected %s", name, got, expected)
+		}
+	}
+	if _, err := NewArbitrationPolicy("random", 0); err == nil {
+		t.Error("Expected an unknown policy to be rejected")
+	}
+}
@@ -0,0 +1,117 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/krzachariassen/ZTDP/internal/features"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// Batch instruction statuses
+const (
+	BatchSucceeded = "succeeded"
+	BatchFailed    = "failed"
+	BatchSkipped   = "skipped" // not run because an earlier instruction failed
+)
+
+// BatchResult is the outcome of one instruction of a batch
+type BatchResult struct {
+	Index         int                     `json:"index"`
+	Instruction   string                  `json:"instruction"`
+	Status        string                  `json:"status"`
+	CorrelationID string                  `json:"correlation_id,omitempty"`
+	Response      *ConversationalResponse `json:"response,omitempty"`
+	Error         string                  `json:"error,omitempty"`
+	DurationMs    int64                   `json:"duration_ms"`
+}
+
+// BatchResponse holds the results of a batch, in instruction order
+type BatchResponse struct {
+	ConversationID string        `json:"conversation_id"`
+	Succeeded      int           `json:"succeeded"`
+	Failed         int           `json:"failed"`
+	Skipped        int           `json:"skipped"`
+	Results        []BatchResult `json:"results"`
+}
+
+// ChatBatch runs instructions one after another as turns of a single conversation, so later
+// instructions can refer to what earlier ones did. Each turn gets its own correlation ID and
+// timeout (none when timeout is zero). An instruction fails when the chat fails or one of its
+// actions reports an error or timeout; the rest are skipped unless continueOnError is set.
+func (o *Orchestrator) ChatBatch(ctx context.Context, instructions []string, continueOnError bool, timeout time.Duration) *BatchResponse {
+	conversationID := features.EvaluationContextFrom(ctx).ConversationID
+	if conversationID == "" {
+		conversationID = uuid.New().String()
+	}
+	ctx = features.WithConversationID(ctx, conversationID)
+	base := logging.CorrelationIDFromContext(ctx)
+	if base == "" {
+		base = conversationID
+	}
+
+	batch := &BatchResponse{ConversationID: conversationID, Results: make([]BatchResult, 0, len(instructions))}
+	stopped := false
+	for i, instruction := range instructions {
+		result := BatchResult{Index: i, Instruction: instruction}
+		if stopped {
+			result.Status = BatchSkipped
+			batch.Skipped++
+			batch.Results = append(batch.Results, result)
+			continue
+		}
+
+		result.CorrelationID = fmt.Sprintf("%s-%d", base, i+1)
+		turnCtx := logging.WithCorrelationID(ctx, result.CorrelationID)
+		cancel := context.CancelFunc(func() {})
+		if timeout > 0 {
+			turnCtx, cancel = context.WithTimeout(turnCtx, timeout)
+		}
+		start := time.Now()
+		response, err := o.Chat(turnCtx, instruction)
+		cancel()
+		result.DurationMs = time.Since(start).Milliseconds()
+		result.Response = response
+
+		if err == nil {
+			err = failedAction(response)
+		}
+		if err != nil {
+			result.Status = BatchFailed
+			result.Error = err.Error()
+			batch.Failed++
+			stopped = !continueOnError
+		} else {
+			result.Status = BatchSucceeded
+			batch.Succeeded++
+		}
+		batch.Results = append(batch.Results, result)
+	}
+
+	o.logger.ForContext(ctx).Info("📜 Batch of %d instructions finished: %d succeeded, %d failed, %d skipped",
+		len(instructions), batch.Succeeded, batch.Failed, batch.Skipped)
+	return batch
+}
+
+// failedAction returns an error for the first action of response that reports an error or timeout
+func failedAction(response *ConversationalResponse) error {
+	if response == nil {
+		return nil
+	}
+	for _, action := range response.Actions {
+		result, ok := action.Result.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		switch status, _ := result["status"].(string); status {
+		case "error", "timeout":
+			if message, ok := result["error"].(string); ok && message != "" {
+				return fmt.Errorf("%s action failed: %s", action.Type, message)
+			}
+			return fmt.Errorf("%s action ended with status %s", action.Type, status)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package orchestrator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/features"
+)
+
+var batchInstructions = []string{
+	"create application name=checkout owner=team-a",
+	"create application billing",
+	"list applications",
+}
+
+// TestOrchestratorChatBatchStopsOnError tests that instructions after a failed one are skipped
+func TestOrchestratorChatBatchStopsOnError(t *testing.T) {
+	o := createFallbackTestOrchestrator(t)
+
+	batch := o.ChatBatch(context.Background(), batchInstructions, false, 0)
+	if batch.ConversationID == "" {
+		t.Fatal("Expected the batch to get a conversation ID")
+	}
+	if batch.Succeeded != 1 || batch.Failed != 1 || batch.Skipped != 1 {
+		t.Fatalf("Expected 1 succeeded, 1 failed and 1 skipped, got: %+v", batch)
+	}
+	statuses := []string{BatchSucceeded, BatchFailed, BatchSkipped}
+	for i, result := range batch.Results {
+		if result.Index != i || result.Status != statuses[i] {
+			t.Errorf("Expected result %d to be %s, got: %+v", i, statuses[i], result)
+		}
+	}
+	if batch.Results[1].Error == "" {
+		t.Error("Expected the failed instruction to carry its error")
+	}
+	if batch.Results[0].CorrelationID == batch.Results[1].CorrelationID {
+		t.Error("Expected every instruction to get its own correlation ID")
+	}
+	if node, _ := o.graph.GetNode("checkout"); node == nil {
+		t.Error("Expected the first instruction to create checkout")
+	}
+}
+
+// TestOrchestratorChatBatchContinuesOnError tests that continue_on_error runs every instruction in
+// the caller's conversation
+func TestOrchestratorChatBatchContinuesOnError(t *testing.T) {
+	o := createFallbackTestOrchestrator(t)
+	ctx := features.WithConversationID(context.Background(), "setup-42")
+
+	batch := o.ChatBatch(ctx, batchInstructions, true, 0)
+	if batch.ConversationID != "setup-42" {
+		t.Errorf("Expected the caller's conversation ID, got: %s", batch.ConversationID)
+	}
+	if batch.Succeeded != 2 || batch.Failed != 1 || batch.Skipped != 0 {
+		t.Fatalf("Expected 2 succeeded and 1 failed, got: %+v", batch)
+	}
+	if response := batch.Results[2].Response; response == nil || response.Intent != "list applications" {
+		t.Errorf("Expected the last instruction to list applications, got: %+v", response)
+	}
+}
@@ -0,0 +1,96 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
+)
+
+// clarificationTTL is how long an agent's question stays open; a later message is a new request
+const clarificationTTL = 10 * time.Minute
+
+// pendingClarification is a question an agent asked the user about a request
+type pendingClarification struct {
+	Intent          string
+	Agent           string
+	Question        string
+	OriginalMessage string
+	AskedAt         time.Time
+}
+
+// awaitClarification remembers the question an agent asked, so the conversation's next
+// message is taken as the answer. Without a conversation there is nobody to answer it.
+func (o *Orchestrator) awaitClarification(ctx context.Context, intent, userMessage string, result map[string]interface{}) {
+	key := conversationKey(ctx)
+	if key == "" {
+		return
+	}
+	pending := &pendingClarification{Intent: intent, OriginalMessage: userMessage, AskedAt: time.Now()}
+	pending.Agent, _ = result["selected_agent"].(string)
+	pending.Question, _ = result["response_content"].(string)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.clarifications == nil {
+		o.clarifications = make(map[string]*pendingClarification)
+	}
+	o.clarifications[key] = pending
+}
+
+// takeClarification removes and returns the conversation's open question, if it has one
+func (o *Orchestrator) takeClarification(ctx context.Context) (pendingClarification, bool) {
+	key := conversationKey(ctx)
+	if key == "" {
+		return pendingClarification{}, false
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	pending, ok := o.clarifications[key]
+	if !ok {
+		return pendingClarification{}, false
+	}
+	delete(o.clarifications, key)
+	if time.Since(pending.AskedAt) > clarificationTTL {
+		return pendingClarification{}, false
+	}
+	return *pending, true
+}
+
+// continueClarification sends the user's answer back to the intent that asked for it. The
+// agent gets the original message and the answer together, so it can extract everything again.
+func (o *Orchestrator) continueClarification(ctx context.Context, pending pendingClarification, answer string) *ConversationalResponse {
+	o.logger.ForContext(ctx).Info("💬 Continuing %s with the answer to: %s", pending.Intent, pending.Question)
+	result, err := o.orchestrateViaIntentBasedAgents(ctx, pending.Intent, map[string]interface{}{
+		"user_message":                        clarifiedMessage(pending, answer),
+		agentframework.OriginalMessageKey:     pending.OriginalMessage,
+		agentframework.ClarificationAnswerKey: answer,
+		"source":                              "orchestrator-chat",
+	})
+	return o.intentConversationalResponse(ctx, pending.Intent, clarifiedMessage(pending, answer), result, err)
+}
+
+// clarifiedMessage combines a request with the answer to the agent's question about it
+func clarifiedMessage(pending pendingClarification, answer string) string {
+	if pending.Question == "" {
+		return fmt.Sprintf("%s\n%s", pending.OriginalMessage, answer)
+	}
+	return fmt.Sprintf("%s\n(Asked: %s Answer: %s)", pending.OriginalMessage, pending.Question, answer)
+}
+
+// dropClarification answers a "cancel" while an agent is waiting for clarification
+func (o *Orchestrator) dropClarification(ctx context.Context) (*ConversationalResponse, bool) {
+	pending, ok := o.takeClarification(ctx)
+	if !ok {
+		return nil, false
+	}
+	message := fmt.Sprintf("🛑 Dropped the %s request; %s is no longer waiting for your answer.", pending.Intent, pending.Agent)
+	return &ConversationalResponse{
+		Message:    message,
+		Answer:     message,
+		Intent:     "interrupt_" + interruptCancel,
+		Actions:    []Action{{Type: "interrupt", Result: map[string]interface{}{"action": interruptCancel, "intent": pending.Intent, "agent": pending.Agent}}},
+		Confidence: 1.0,
+	}, true
+}
@@ -0,0 +1,122 @@
+package orchestrator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+	"github.com/krzachariassen/ZTDP/internal/ai/aitest"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/features"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
+)
+
+// buildClarifyingDeployAgent asks which environment unless the request continues a clarification
+func buildClarifyingDeployAgent(t *testing.T, registry agentRegistry.AgentRegistry, bus *events.EventBus, received *[]map[string]interface{}) {
+	t.Helper()
+	var agent agentRegistry.AgentInterface
+	agent, err := agentframework.NewAgent("deployer").
+		WithCapabilities([]agentRegistry.AgentCapability{deployCapability}).
+		WithEventHandler(func(ctx context.Context, event *events.Event) (*events.Event, error) {
+			*received = append(*received, event.Payload)
+			if _, answered := event.Payload[agentframework.ClarificationAnswerKey]; !answered && agentframework.NeedsClarification(ctx, 0.4) {
+				return agentframework.ClarificationResponse(ctx, event, "Which environment?", 0.4), nil
+			}
+			return agent.(*agentframework.BaseAgent).CreateResponse("deployed", map[string]interface{}{"message": "deployed"}, event), nil
+		}).
+		Build(agentframework.AgentDependencies{Registry: registry, EventBus: bus})
+	if err != nil {
+		t.Fatalf("Failed to build agent: %v", err)
+	}
+}
+
+func TestOrchestratorContinuesClarification(t *testing.T) {
+	registry := agentRegistry.NewInMemoryAgentRegistry()
+	bus := events.NewEventBus(nil, false)
+	var received []map[string]interface{}
+	buildClarifyingDeployAgent(t, registry, bus, &received)
+
+	provider := aitest.ByPrompt(map[string]string{"intelligent agent router": "deploy application"})
+	o := NewOrchestrator(provider, graph.NewGlobalGraph(graph.NewMemoryGraph()), bus, registry)
+	ctx := features.WithConversationID(context.Background(), "conv-clarify")
+
+	response, err := o.Chat(ctx, "deploy checkout")
+	if err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if response.Message != "Which environment?" {
+		t.Fatalf("Expected the agent's question, got %q", response.Message)
+	}
+	result := response.Actions[0].Result.(map[string]interface{})
+	if result["status"] != agentframework.ClarificationStatus {
+		t.Errorf("Expected a clarification result, got %+v", result)
+	}
+
+	calls := len(provider.Calls())
+	response, err = o.Chat(ctx, "staging")
+	if err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if response.Message != "deployed" || response.Intent != "deploy application" {
+		t.Fatalf("Expected the answer to complete the deployment, got %+v", response)
+	}
+	if after := provider.Calls(); len(after) != calls {
+		t.Errorf("Expected the answer to skip intent detection, got calls %v", after[calls:])
+	}
+
+	continued := received[len(received)-1]
+	message, _ := continued["user_message"].(string)
+	if !strings.Contains(message, "deploy checkout") || !strings.Contains(message, "staging") {
+		t.Errorf("Expected the agent to get the original message with the answer, got %q", message)
+	}
+	if continued[agentframework.OriginalMessageKey] != "deploy checkout" || continued[agentframework.ClarificationAnswerKey] != "staging" {
+		t.Errorf("Unexpected continuation payload: %+v", continued)
+	}
+}
+
+func TestOrchestratorCancelDropsClarification(t *testing.T) {
+	registry := agentRegistry.NewInMemoryAgentRegistry()
+	bus := events.NewEventBus(nil, false)
+	var received []map[string]interface{}
+	buildClarifyingDeployAgent(t, registry, bus, &received)
+
+	provider := aitest.ByPrompt(map[string]string{"intelligent agent router": "deploy application"})
+	o := NewOrchestrator(provider, graph.NewGlobalGraph(graph.NewMemoryGraph()), bus, registry)
+	ctx := features.WithConversationID(context.Background(), "conv-clarify-cancel")
+
+	if _, err := o.Chat(ctx, "deploy checkout"); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	response, err := o.Chat(ctx, "never mind")
+	if err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if response.Intent != "interrupt_cancel" {
+		t.Fatalf("Expected the cancel to drop the question, got %+v", response)
+	}
+	if _, pending := o.takeClarification(ctx); pending {
+		t.Error("Expected no open question after cancelling")
+	}
+}
+
+func TestClarificationThresholdIsPerCapability(t *testing.T) {
+	agentframework.SetConfidenceThresholds(0, map[string]float64{deployCapability.Name: 0.3})
+	defer agentframework.SetConfidenceThresholds(0, nil)
+
+	registry := agentRegistry.NewInMemoryAgentRegistry()
+	bus := events.NewEventBus(nil, false)
+	var received []map[string]interface{}
+	buildClarifyingDeployAgent(t, registry, bus, &received)
+
+	provider := aitest.ByPrompt(map[string]string{"intelligent agent router": "deploy application"})
+	o := NewOrchestrator(provider, graph.NewGlobalGraph(graph.NewMemoryGraph()), bus, registry)
+	response, err := o.Chat(features.WithConversationID(context.Background(), "conv-threshold"), "deploy checkout")
+	if err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if response.Message != "deployed" {
+		t.Errorf("Expected 0.4 confidence to clear the capability's 0.3 threshold, got %q", response.Message)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+
+	"github.com/krzachariassen/ZTDP/internal/ai"
 )
 
 // executeContract executes a contract by extracting intent and routing to appropriate agents
@@ -47,7 +49,7 @@ Contract data: %s
 
 What is the intent?`, userMessage, string(mustMarshal(contractData)))
 
-	response, err := o.aiProvider.CallAI(ctx, systemPrompt, userPrompt)
+	response, err := o.aiProvider.CallAI(ai.WithTask(ctx, ai.TaskClassification), systemPrompt, userPrompt)
 	if err != nil {
 		return "", fmt.Errorf("AI intent extraction failed: %w", err)
 	}
@@ -0,0 +1,92 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+	"github.com/krzachariassen/ZTDP/internal/decisions"
+	"github.com/krzachariassen/ZTDP/internal/features"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
+)
+
+// SetDecisions enables recording routing decisions for the decisions API
+func (o *Orchestrator) SetDecisions(service *decisions.Service) {
+	o.decisions = service
+}
+
+// recordDecision records how a classified request was routed and how it ended
+func (o *Orchestrator) recordDecision(ctx context.Context, intent, userMessage string, candidates []agentRegistry.AgentStatus, arbitration *decisions.Arbitration, result interface{}, err error) {
+	if o.decisions == nil {
+		return
+	}
+
+	evalCtx := features.EvaluationContextFrom(ctx)
+	decision := decisions.Decision{
+		CorrelationID:  logging.CorrelationIDFromContext(ctx),
+		ConversationID: evalCtx.ConversationID,
+		Tenant:         evalCtx.Tenant,
+		Input:          userMessage,
+		Intent:         intent,
+		Candidates:     make([]string, 0, len(candidates)),
+		Arbitration:    arbitration,
+	}
+	for _, candidate := range candidates {
+		decision.Candidates = append(decision.Candidates, candidate.ID)
+	}
+
+	reasons := []string{fmt.Sprintf("The AI classified the request as %q.", intent)}
+	if route := pinnedRouteFrom(ctx); route != nil {
+		reasons[0] = fmt.Sprintf("Routing override %s (pattern %q) sent the request to %s as %q; the AI did not classify it.",
+			route.override.ID, route.override.Pattern, route.override.Target(), intent)
+	}
+	switch len(candidates) {
+	case 0:
+		reasons = append(reasons, "No registered agent offers this intent.")
+	case 1:
+		reasons = append(reasons, fmt.Sprintf("Only %s offers it.", candidates[0].ID))
+	default:
+		if arbitration != nil {
+			reasons = append(reasons, fmt.Sprintf("%d agents offer it; the %s arbitration policy ranked their bids: %s.", len(candidates), arbitration.Policy, describeBids(arbitration.Bids)))
+		} else {
+			reasons = append(reasons, fmt.Sprintf("%d agents offer it (%s); they are tried in registration order.", len(candidates), strings.Join(decision.Candidates, ", ")))
+		}
+	}
+
+	resultMap, _ := result.(map[string]interface{})
+	decision.SelectedAgent, _ = resultMap["selected_agent"].(string)
+	decision.RoutingKey, _ = resultMap["routing_key"].(string)
+	switch {
+	case err != nil && len(candidates) == 0:
+		decision.Outcome = "unroutable"
+		reasons = append(reasons, err.Error())
+	case err != nil:
+		decision.Outcome = "error"
+		reasons = append(reasons, fmt.Sprintf("Routing failed: %v", err))
+	default:
+		decision.Outcome, _ = resultMap["status"].(string)
+		if decision.SelectedAgent != "" {
+			reasons = append(reasons, fmt.Sprintf("Routed to %s, which ended with %s.", decision.SelectedAgent, decision.Outcome))
+		}
+		if message, ok := resultMap["message"].(string); ok && decision.Outcome != "completed" && decision.Outcome != agentframework.ClarificationStatus {
+			reasons = append(reasons, message)
+		}
+	}
+
+	// Agents that decide with AI report their own reasoning and confidence
+	if payload, ok := resultMap["agent_response"].(map[string]interface{}); ok {
+		if reasoning, ok := payload["reasoning"].(string); ok && reasoning != "" {
+			reasons = append(reasons, "Agent reasoning: "+reasoning)
+		}
+		if confidence, ok := payload["confidence"].(float64); ok {
+			decision.Confidence = confidence
+		}
+	}
+	decision.Reasoning = strings.Join(reasons, " ")
+
+	if _, err := o.decisions.Record(decision); err != nil {
+		o.logger.ForContext(ctx).Warn("⚠️ Failed to record routing decision for intent %s: %v", intent, err)
+	}
+}
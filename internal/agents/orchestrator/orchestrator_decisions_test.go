@@ -0,0 +1,53 @@
+package orchestrator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+	"github.com/krzachariassen/ZTDP/internal/ai/aitest"
+	"github.com/krzachariassen/ZTDP/internal/decisions"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+)
+
+func TestOrchestratorRecordsRoutingDecisions(t *testing.T) {
+	registry := orderedRegistry{agentRegistry.NewInMemoryAgentRegistry().(*agentRegistry.InMemoryAgentRegistry)}
+	bus := events.NewEventBus(nil, false)
+	if err := registry.RegisterAgent(context.Background(), unresponsiveAgent{id: "a-crashed"}); err != nil {
+		t.Fatalf("Failed to register agent: %v", err)
+	}
+	buildDeployAgent(t, registry, bus, "b-deployer")
+
+	gg := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	o := NewOrchestrator(&aitest.Provider{}, gg, bus, registry)
+	o.SetAckTimeout(50 * time.Millisecond)
+	service := decisions.NewService(gg, 10)
+	o.SetDecisions(service)
+
+	dispatchDeploy(t, o, "corr-decision")
+	if _, err := o.orchestrateViaIntentBasedAgents(context.Background(), "launch rockets", map[string]interface{}{"user_message": "launch"}); err == nil {
+		t.Fatal("Expected an intent no agent offers to fail")
+	}
+
+	list, err := service.List(decisions.Filter{Intent: "deploy application"})
+	if err != nil || len(list) != 1 {
+		t.Fatalf("Expected one deploy decision, got %+v (%v)", list, err)
+	}
+	decision := list[0]
+	if decision.CorrelationID != "corr-decision" || decision.Input != "deploy checkout" || decision.SelectedAgent != "b-deployer" || decision.Outcome != "completed" {
+		t.Errorf("Unexpected decision: %+v", decision)
+	}
+	if len(decision.Candidates) != 2 || decision.Candidates[0] != "a-crashed" || decision.Candidates[1] != "b-deployer" {
+		t.Errorf("Expected both capable agents as candidates, got %v", decision.Candidates)
+	}
+	if decision.Reasoning == "" {
+		t.Error("Expected the decision to explain the routing")
+	}
+
+	unroutable, _ := service.List(decisions.Filter{Outcome: "unroutable"})
+	if len(unroutable) != 1 || unroutable[0].Intent != "launch rockets" || len(unroutable[0].Candidates) != 0 {
+		t.Errorf("Expected the unroutable request to be recorded, got %+v", unroutable)
+	}
+}
@@ -0,0 +1,82 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/krzachariassen/ZTDP/internal/conversations"
+	"github.com/krzachariassen/ZTDP/internal/features"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
+)
+
+// envelopeTurns is how many recent turns of the conversation an envelope summarizes
+const envelopeTurns = 3
+
+// envelopeOutcomeLength bounds each prior decision's outcome so envelopes stay small
+const envelopeOutcomeLength = 200
+
+// buildContextEnvelope collects what the orchestrator already knows about a request: the
+// entities the message names in the graph and what happened earlier in the conversation.
+// Nothing here calls the AI provider.
+func (o *Orchestrator) buildContextEnvelope(ctx context.Context, intent, userMessage string) agentframework.ContextEnvelope {
+	envelope := agentframework.ContextEnvelope{Intent: intent}
+
+	if o.graph != nil && userMessage != "" {
+		entities, err := conversations.FindEntities(o.graph, userMessage)
+		if err != nil {
+			o.logger.Warn("⚠️ Failed to find entities for the context envelope: %v", err)
+		}
+		for kind, ids := range entities {
+			// A message naming two applications is left for the agent to interpret
+			if len(ids) != 1 {
+				continue
+			}
+			if envelope.Entities == nil {
+				envelope.Entities = map[string]string{}
+			}
+			envelope.Entities[kind] = ids[0]
+		}
+	}
+
+	conversationID := features.EvaluationContextFrom(ctx).ConversationID
+	if o.transcripts == nil || conversationID == "" {
+		return envelope
+	}
+	transcript, err := o.transcripts.Get(conversationID)
+	if err != nil {
+		return envelope
+	}
+	envelope.ConversationEntities = transcript.Entities
+
+	turns := transcript.Turns
+	if len(turns) > envelopeTurns {
+		turns = turns[len(turns)-envelopeTurns:]
+	}
+	var summary []string
+	for _, turn := range turns {
+		line := fmt.Sprintf("User: %q", turn.UserMessage)
+		if turn.Intent != "" {
+			line += fmt.Sprintf(" (intent %s)", turn.Intent)
+		}
+		summary = append(summary, line)
+		if turn.Intent == "" || turn.SelectedAgent == "" {
+			continue
+		}
+		decision := agentframework.PriorDecision{Intent: turn.Intent, Agent: turn.SelectedAgent, Outcome: truncate(turn.Response, envelopeOutcomeLength)}
+		if len(turn.Actions) > 0 {
+			decision.Status = turn.Actions[len(turn.Actions)-1].Status
+		}
+		envelope.PriorDecisions = append(envelope.PriorDecisions, decision)
+	}
+	envelope.Summary = strings.Join(summary, "\n")
+	return envelope
+}
+
+func truncate(text string, length int) string {
+	runes := []rune(text)
+	if len(runes) <= length {
+		return text
+	}
+	return string(runes[:length]) + "…"
+}
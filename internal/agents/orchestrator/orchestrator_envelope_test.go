@@ -0,0 +1,68 @@
+package orchestrator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+	"github.com/krzachariassen/ZTDP/internal/ai/aitest"
+	"github.com/krzachariassen/ZTDP/internal/conversations"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/features"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
+)
+
+func TestOrchestratorSendsContextEnvelope(t *testing.T) {
+	g := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	g.AddNode(&graph.Node{ID: "checkout", Kind: graph.KindApplication})
+	g.AddNode(&graph.Node{ID: "billing", Kind: graph.KindApplication})
+	g.AddNode(&graph.Node{ID: "dev", Kind: graph.KindEnvironment})
+
+	registry := agentRegistry.NewInMemoryAgentRegistry()
+	bus := events.NewEventBus(nil, false)
+	var received agentframework.ContextEnvelope
+	var agent agentRegistry.AgentInterface
+	agent, err := agentframework.NewAgent("deployer").
+		WithCapabilities([]agentRegistry.AgentCapability{deployCapability}).
+		WithEventHandler(func(ctx context.Context, event *events.Event) (*events.Event, error) {
+			received, _ = agentframework.ContextEnvelopeFrom(event)
+			return agent.(*agentframework.BaseAgent).CreateResponse("deployed", map[string]interface{}{"message": "deployed"}, event), nil
+		}).
+		Build(agentframework.AgentDependencies{Registry: registry, EventBus: bus})
+	if err != nil {
+		t.Fatalf("Failed to build agent: %v", err)
+	}
+
+	o := NewOrchestrator(&aitest.Provider{}, g, bus, registry)
+	transcripts := conversations.NewService(g, conversations.Options{})
+	o.SetTranscripts(transcripts)
+	if _, err := transcripts.RecordTurn("conv-envelope", "", conversations.Turn{
+		UserMessage: "plan checkout for dev", Intent: "plan deployment", SelectedAgent: "deployer",
+		Response: "Proposed a plan", Actions: []conversations.Action{{Type: "orchestration", Status: "completed"}},
+	}); err != nil {
+		t.Fatalf("Failed to record turn: %v", err)
+	}
+
+	ctx := features.WithConversationID(context.Background(), "conv-envelope")
+	if _, err := o.orchestrateViaIntentBasedAgents(ctx, "deploy application", map[string]interface{}{"user_message": "deploy checkout to dev"}); err != nil {
+		t.Fatalf("Orchestration failed: %v", err)
+	}
+
+	if received.Intent != "deploy application" || received.Entity(graph.KindApplication) != "checkout" || received.Entity(graph.KindEnvironment) != "dev" {
+		t.Errorf("Expected the envelope to name checkout in dev, got %+v", received)
+	}
+	if !strings.Contains(received.Summary, "plan checkout for dev") {
+		t.Errorf("Expected the summary to include the earlier turn, got %q", received.Summary)
+	}
+	if len(received.PriorDecisions) != 1 || received.PriorDecisions[0].Agent != "deployer" || received.PriorDecisions[0].Status != "completed" {
+		t.Errorf("Unexpected prior decisions: %+v", received.PriorDecisions)
+	}
+
+	// Two applications in one message are left for the agent to sort out
+	envelope := o.buildContextEnvelope(context.Background(), "deploy application", "deploy checkout and billing to dev")
+	if envelope.Entity(graph.KindApplication) != "" || envelope.Entity(graph.KindEnvironment) != "dev" {
+		t.Errorf("Expected only the environment to be settled, got %+v", envelope.Entities)
+	}
+}
@@ -0,0 +1,198 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/krzachariassen/ZTDP/internal/application"
+	"github.com/krzachariassen/ZTDP/internal/contracts"
+)
+
+// fallbackFieldPattern matches explicit key=value fields, with optional double quotes around the value
+var fallbackFieldPattern = regexp.MustCompile(`(\w+)=("([^"]*)"|(\S+))`)
+
+// fallbackHelpMessage lists the structured commands understood without an AI provider
+const fallbackHelpMessage = `AI features are currently unavailable, so only structured commands are supported:
+  - create application name=<name> owner=<owner> [description="<text>"] [tags=a,b]
+  - list applications
+  - status`
+
+// handleWithoutAI handles core structured intents with deterministic rules when no AI provider is available.
+// This keeps the platform usable in degraded mode and for air-gapped installs.
+func (o *Orchestrator) handleWithoutAI(ctx context.Context, userMessage string) (*ConversationalResponse, error) {
+	message := strings.TrimSpace(userMessage)
+	normalized := strings.ToLower(message)
+
+	switch {
+	case isFallbackCreateApplication(normalized):
+		return o.fallbackCreateApplication(ctx, message)
+	case isFallbackListApplications(normalized):
+		return o.fallbackListApplications(ctx)
+	case isFallbackStatus(normalized):
+		return o.fallbackStatus(ctx)
+	default:
+		return &ConversationalResponse{
+			Message:    fallbackHelpMessage,
+			Answer:     fallbackHelpMessage,
+			Intent:     "help_request",
+			Actions:    []Action{{Type: "fallback", Result: "help"}},
+			Confidence: 1.0,
+		}, nil
+	}
+}
+
+// fallbackCreateApplication creates an application from explicit key=value fields
+func (o *Orchestrator) fallbackCreateApplication(ctx context.Context, message string) (*ConversationalResponse, error) {
+	if o.graph == nil {
+		return nil, fmt.Errorf("graph is not available")
+	}
+
+	fields := parseFallbackFields(message)
+
+	// Allow "create application <name> owner=<owner>" as a shorthand for name=<name>
+	if fields["name"] == "" {
+		fields["name"] = parseFallbackPositionalName(message)
+	}
+
+	app := contracts.ApplicationContract{
+		Metadata: contracts.Metadata{
+			Name:  fields["name"],
+			Owner: fields["owner"],
+		},
+		Spec: contracts.ApplicationSpec{
+			Description: fields["description"],
+			Tags:        splitFallbackList(fields["tags"]),
+		},
+	}
+
+	if err := app.Validate(); err != nil {
+		msg := fmt.Sprintf("Cannot create application: %v. Usage: create application name=<name> owner=<owner>", err)
+		return &ConversationalResponse{
+			Message: msg,
+			Answer:  msg,
+			Intent:  "create application",
+			Actions: []Action{{Type: "fallback", Result: map[string]interface{}{"status": "error", "error": err.Error()}}},
+		}, nil
+	}
+
+	service := application.NewService(o.graph, nil)
+	result, err := service.CreateApplicationFromContract(ctx, &app)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create application: %w", err)
+	}
+
+	o.logger.Info("✅ Created application %s without AI", app.Metadata.Name)
+
+	msg := fmt.Sprintf("✅ Application '%s' created (owner: %s)", app.Metadata.Name, app.Metadata.Owner)
+	return &ConversationalResponse{
+		Message:    msg,
+		Answer:     msg,
+		Intent:     "create application",
+		Actions:    []Action{{Type: "fallback", Result: result}},
+		Confidence: 1.0,
+	}, nil
+}
+
+// fallbackListApplications lists all applications in the graph
+func (o *Orchestrator) fallbackListApplications(ctx context.Context) (*ConversationalResponse, error) {
+	if o.graph == nil {
+		return nil, fmt.Errorf("graph is not available")
+	}
+
+	service := application.NewService(o.graph, nil)
+	apps, err := service.ListApplications()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list applications: %w", err)
+	}
+
+	names := make([]string, 0, len(apps))
+	for _, app := range apps {
+		names = append(names, app.Metadata.Name)
+	}
+	sort.Strings(names)
+
+	msg := "No applications created yet"
+	if len(names) > 0 {
+		msg = fmt.Sprintf("Applications (%d):\n  - %s", len(names), strings.Join(names, "\n  - "))
+	}
+
+	return &ConversationalResponse{
+		Message:    msg,
+		Answer:     msg,
+		Intent:     "list applications",
+		Actions:    []Action{{Type: "fallback", Result: map[string]interface{}{"applications": apps, "count": len(apps)}}},
+		Confidence: 1.0,
+	}, nil
+}
+
+// fallbackStatus reports the platform state straight from the graph
+func (o *Orchestrator) fallbackStatus(ctx context.Context) (*ConversationalResponse, error) {
+	state := o.getPlatformState()
+	msg := fmt.Sprintf("%s\n\nAI provider: unavailable (degraded mode)", state)
+
+	return &ConversationalResponse{
+		Message:    msg,
+		Answer:     msg,
+		Intent:     "get status",
+		Actions:    []Action{{Type: "fallback", Result: "status"}},
+		Confidence: 1.0,
+	}, nil
+}
+
+func isFallbackCreateApplication(normalized string) bool {
+	return strings.HasPrefix(normalized, "create application") || strings.HasPrefix(normalized, "create app ")
+}
+
+func isFallbackListApplications(normalized string) bool {
+	switch normalized {
+	case "list applications", "list apps", "show applications", "show apps", "list all applications", "get applications":
+		return true
+	}
+	return false
+}
+
+func isFallbackStatus(normalized string) bool {
+	switch normalized {
+	case "status", "get status", "platform status", "show status":
+		return true
+	}
+	return false
+}
+
+// parseFallbackFields extracts key=value fields from a structured command
+func parseFallbackFields(message string) map[string]string {
+	fields := make(map[string]string)
+	for _, match := range fallbackFieldPattern.FindAllStringSubmatch(message, -1) {
+		value := match[4]
+		if strings.HasPrefix(match[2], `"`) {
+			value = match[3]
+		}
+		fields[strings.ToLower(match[1])] = value
+	}
+	return fields
+}
+
+// parseFallbackPositionalName returns the word following "create application" when it is not a key=value field
+func parseFallbackPositionalName(message string) string {
+	words := strings.Fields(message)
+	if len(words) < 3 || strings.Contains(words[2], "=") {
+		return ""
+	}
+	return words[2]
+}
+
+func splitFallbackList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -0,0 +1,161 @@
+package orchestrator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/analytics"
+	"github.com/krzachariassen/ZTDP/internal/conversations"
+	"github.com/krzachariassen/ZTDP/internal/features"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+)
+
+func createFallbackTestOrchestrator(t *testing.T) *Orchestrator {
+	t.Helper()
+	return NewOrchestrator(nil, graph.NewGlobalGraph(graph.NewMemoryGraph()), nil, nil)
+}
+
+// TestOrchestratorFallbackCreateAndList tests deterministic handling of core intents without AI
+func TestOrchestratorFallbackCreateAndList(t *testing.T) {
+	o := createFallbackTestOrchestrator(t)
+	ctx := context.Background()
+
+	response, err := o.Chat(ctx, `create application name=checkout owner=team-a description="Checkout flow" tags=web,payments`)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if response.Intent != "create application" {
+		t.Errorf("Expected intent 'create application', got: %s", response.Intent)
+	}
+
+	node, err := o.graph.GetNode("checkout")
+	if err != nil || node == nil {
+		t.Fatalf("Expected application node to be created, got error: %v", err)
+	}
+	if node.Metadata["owner"] != "team-a" {
+		t.Errorf("Expected owner 'team-a', got: %v", node.Metadata["owner"])
+	}
+	if node.Spec["description"] != "Checkout flow" {
+		t.Errorf("Expected description 'Checkout flow', got: %v", node.Spec["description"])
+	}
+
+	response, err = o.Chat(ctx, "list applications")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !strings.Contains(response.Message, "checkout") {
+		t.Errorf("Expected application list to contain 'checkout', got: %s", response.Message)
+	}
+}
+
+// TestOrchestratorFallbackCreateRequiresFields tests that missing explicit fields are reported
+func TestOrchestratorFallbackCreateRequiresFields(t *testing.T) {
+	o := createFallbackTestOrchestrator(t)
+
+	response, err := o.Chat(context.Background(), "create application checkout")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !strings.Contains(response.Message, "owner is required") {
+		t.Errorf("Expected missing owner message, got: %s", response.Message)
+	}
+	if node, _ := o.graph.GetNode("checkout"); node != nil {
+		t.Error("Expected application not to be created without owner")
+	}
+}
+
+// TestOrchestratorFallbackStatusAndHelp tests status reporting and the help response for unknown input
+func TestOrchestratorFallbackStatusAndHelp(t *testing.T) {
+	o := createFallbackTestOrchestrator(t)
+	ctx := context.Background()
+
+	response, err := o.Chat(ctx, "status")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !strings.Contains(response.Message, "degraded mode") {
+		t.Errorf("Expected degraded mode status, got: %s", response.Message)
+	}
+
+	response, err = o.Chat(ctx, "deploy everything please")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if response.Intent != "help_request" {
+		t.Errorf("Expected help_request intent, got: %s", response.Intent)
+	}
+}
+
+// TestOrchestratorDrainRejectsNewRequests tests that chat requests are refused once draining starts
+func TestOrchestratorDrainRejectsNewRequests(t *testing.T) {
+	o := createFallbackTestOrchestrator(t)
+
+	if err := o.Drain(context.Background()); err != nil {
+		t.Fatalf("Expected drain to succeed with no in-flight requests, got: %v", err)
+	}
+
+	if _, err := o.Chat(context.Background(), "status"); err != ErrShuttingDown {
+		t.Errorf("Expected ErrShuttingDown, got: %v", err)
+	}
+}
+
+// TestOrchestratorRecordsTranscripts tests that every chat turn is stored under its conversation
+func TestOrchestratorRecordsTranscripts(t *testing.T) {
+	o := createFallbackTestOrchestrator(t)
+	transcripts := conversations.NewService(o.graph, conversations.Options{})
+	o.SetTranscripts(transcripts)
+	ctx := features.WithConversationID(context.Background(), "conv-42")
+
+	if _, err := o.Chat(ctx, "create application name=checkout owner=team-a"); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	response, err := o.Chat(ctx, "list applications")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if response.ConversationID != "conv-42" {
+		t.Errorf("Expected conversation ID 'conv-42', got: %s", response.ConversationID)
+	}
+
+	transcript, err := transcripts.Get("conv-42")
+	if err != nil {
+		t.Fatalf("Expected transcript to be stored, got: %v", err)
+	}
+	if len(transcript.Turns) != 2 {
+		t.Fatalf("Expected 2 turns, got: %d", len(transcript.Turns))
+	}
+	if transcript.Turns[0].Intent != "create application" {
+		t.Errorf("Expected first turn intent 'create application', got: %s", transcript.Turns[0].Intent)
+	}
+	if len(transcript.Entities) != 1 || transcript.Entities[0] != "checkout" {
+		t.Errorf("Expected transcript to reference checkout, got: %v", transcript.Entities)
+	}
+}
+
+// TestOrchestratorRecordsIntentAnalytics tests that every chat request's classification is recorded
+func TestOrchestratorRecordsIntentAnalytics(t *testing.T) {
+	o := createFallbackTestOrchestrator(t)
+	store := analytics.NewMemoryStore(10)
+	o.SetAnalytics(analytics.NewCollector(store))
+	ctx := features.WithConversationID(context.Background(), "conv-7")
+
+	if _, err := o.Chat(ctx, "create application name=checkout owner=team-a"); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	records, err := store.Query(ctx, analytics.IntentQuery{})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("Expected 1 record, got: %d", len(records))
+	}
+	record := records[0]
+	if record.Intent != "create application" || record.Outcome != analytics.OutcomeFallback {
+		t.Errorf("Expected fallback 'create application' record, got: %+v", record)
+	}
+	if record.ConversationID != "conv-7" || record.Confidence != 1.0 {
+		t.Errorf("Expected conversation conv-7 with confidence 1.0, got: %+v", record)
+	}
+}
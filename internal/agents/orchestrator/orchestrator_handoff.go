@@ -0,0 +1,174 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/conversations"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
+)
+
+// handoffTimeout bounds how long a request taken over from another instance is waited on
+const handoffTimeout = 10 * time.Minute
+
+// PendingRequest is a request an agent had not answered when the orchestrator stopped
+type PendingRequest struct {
+	Conversation  string                 `json:"conversation"` // conversation:<id> or user:<id>
+	CorrelationID string                 `json:"correlation_id"`
+	Intent        string                 `json:"intent"`
+	Agent         string                 `json:"agent"`
+	RoutingKey    string                 `json:"routing_key"`
+	Payload       map[string]interface{} `json:"payload"`
+	StartedAt     time.Time              `json:"started_at"`
+	Paused        bool                   `json:"paused,omitempty"`
+}
+
+// Checkpoint returns the requests still waiting on agents, for the instance taking over
+// (implements checkpoint.Participant)
+func (o *Orchestrator) Checkpoint() (interface{}, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	var pending []PendingRequest
+	for key, active := range o.active {
+		if active.routingKey == "" {
+			continue
+		}
+		pending = append(pending, PendingRequest{
+			Conversation:  key,
+			CorrelationID: active.CorrelationID,
+			Intent:        active.Intent,
+			Agent:         active.Agent,
+			RoutingKey:    active.routingKey,
+			Payload:       active.payload,
+			StartedAt:     active.StartedAt,
+			Paused:        active.Paused,
+		})
+	}
+	if len(pending) == 0 {
+		return nil, nil
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].StartedAt.Before(pending[j].StartedAt) })
+	return pending, nil
+}
+
+// Restore re-sends requests another instance left unanswered under their original correlation
+// IDs. Agents run in the stopped process, so the work starts over; answers are recorded in the
+// conversation's transcript because the original caller is gone. Until then, status, pause and
+// cancel messages in the conversation apply to the resumed request.
+func (o *Orchestrator) Restore(data json.RawMessage) error {
+	var pending []PendingRequest
+	if err := json.Unmarshal(data, &pending); err != nil {
+		return fmt.Errorf("invalid pending requests: %w", err)
+	}
+	if o.eventBus == nil {
+		return fmt.Errorf("event bus not available - cannot resume %d requests", len(pending))
+	}
+	for _, request := range pending {
+		if err := o.resumeRequest(request); err != nil {
+			o.logger.Error("❌ Failed to resume %s request %s: %v", request.Intent, request.CorrelationID, err)
+		}
+	}
+	return nil
+}
+
+func (o *Orchestrator) resumeRequest(request PendingRequest) error {
+	// The addressed agent ran in the stopped process; any agent serving the routing key may take it here
+	delete(request.Payload, agentframework.TargetAgentKey)
+
+	ctx, cancel := context.WithTimeout(context.Background(), handoffTimeout)
+	active := &activeOrchestration{
+		CorrelationID: request.CorrelationID,
+		Intent:        request.Intent,
+		Agent:         request.Agent,
+		StartedAt:     request.StartedAt,
+		Paused:        request.Paused,
+		cancel:        cancel,
+		routingKey:    request.RoutingKey,
+		payload:       request.Payload,
+	}
+	o.mu.Lock()
+	if o.active == nil {
+		o.active = make(map[string]*activeOrchestration)
+	}
+	o.active[request.Conversation] = active
+	o.mu.Unlock()
+	untrack := func() {
+		o.mu.Lock()
+		if o.active[request.Conversation] == active {
+			delete(o.active, request.Conversation)
+		}
+		o.mu.Unlock()
+		cancel()
+	}
+
+	responses := make(chan events.Event, 1)
+	o.eventBus.Subscribe(events.EventTypeResponse, func(event events.Event) error {
+		if id, _ := event.Payload["correlation_id"].(string); id == request.CorrelationID {
+			select {
+			case responses <- event:
+			default:
+			}
+		}
+		return nil
+	})
+
+	if err := o.eventBus.Emit(events.EventTypeRequest, "orchestrator", request.RoutingKey, request.Payload); err != nil {
+		untrack()
+		return err
+	}
+	if request.Paused {
+		o.broadcastInterrupt(ctx, agentframework.InterruptPause, request.CorrelationID)
+	}
+	o.logger.Info("🔁 Resumed %s request %s on %s after handoff", request.Intent, request.CorrelationID, request.Agent)
+
+	go func() {
+		defer untrack()
+		select {
+		case response := <-responses:
+			o.recordResumedResponse(request, response)
+		case <-ctx.Done():
+			o.logger.Warn("⏰ No response to resumed %s request %s: %v", request.Intent, request.CorrelationID, ctx.Err())
+		}
+	}()
+	return nil
+}
+
+// recordResumedResponse adds a resumed request's answer to its conversation's transcript
+func (o *Orchestrator) recordResumedResponse(request PendingRequest, response events.Event) {
+	message, _ := response.Payload["message"].(string)
+	status := "completed"
+	if s, _ := response.Payload["status"].(string); s == "error" {
+		status = "error"
+		if errorMsg, ok := response.Payload["error"].(string); ok {
+			message = "❌ " + errorMsg
+		}
+	}
+	if message == "" {
+		message = fmt.Sprintf("✅ Agent completed the %s request successfully", request.Intent)
+	}
+	o.logger.Info("✅ Resumed %s request %s %s", request.Intent, request.CorrelationID, status)
+
+	conversationID := strings.TrimPrefix(request.Conversation, "conversation:")
+	if o.transcripts == nil || conversationID == request.Conversation {
+		return
+	}
+	userMessage, _ := request.Payload["user_message"].(string)
+	tenant, _ := request.Payload["tenant"].(string)
+	turn := conversations.Turn{
+		CorrelationID:  request.CorrelationID,
+		UserMessage:    userMessage,
+		Intent:         request.Intent,
+		SelectedAgent:  response.Source,
+		Response:       message,
+		AgentResponses: []string{message},
+		Actions:        []conversations.Action{{Type: "handoff", Status: status}},
+	}
+	if _, err := o.transcripts.RecordTurn(conversationID, tenant, turn); err != nil {
+		o.logger.Warn("⚠️ Failed to record resumed response for %s: %v", request.CorrelationID, err)
+	}
+}
@@ -0,0 +1,138 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+	"github.com/krzachariassen/ZTDP/internal/ai/aitest"
+	"github.com/krzachariassen/ZTDP/internal/conversations"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/features"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
+)
+
+var handoffCapability = []agentRegistry.AgentCapability{{
+	Name:        "deployment",
+	Intents:     []string{"deploy application"},
+	RoutingKeys: []string{"handoff.deploy"},
+}}
+
+// savedTurns passes the turns of every saved transcript to the test, which then never has to
+// read the graph the orchestrator writes from its response handler
+type savedTurns struct {
+	conversations.Store
+	saved chan []conversations.Turn
+}
+
+func (s *savedTurns) Save(transcript *conversations.Transcript) error {
+	if err := s.Store.Save(transcript); err != nil {
+		return err
+	}
+	s.saved <- append([]conversations.Turn(nil), transcript.Turns...)
+	return nil
+}
+
+// TestOrchestratorHandsOffPendingRequests tests that a request an agent had not answered when
+// the orchestrator stopped is re-sent by the next instance and its answer recorded
+func TestOrchestratorHandsOffPendingRequests(t *testing.T) {
+	// The stopping instance: its agent never answers
+	oldRegistry := agentRegistry.NewInMemoryAgentRegistry()
+	oldBus := events.NewEventBus(nil, true)
+	started := make(chan struct{})
+	_, err := agentframework.NewAgent("stuck-deployer").
+		WithCapabilities(handoffCapability).
+		WithEventHandler(func(ctx context.Context, event *events.Event) (*events.Event, error) {
+			close(started)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}).
+		Build(agentframework.AgentDependencies{Registry: oldRegistry, EventBus: oldBus})
+	if err != nil {
+		t.Fatalf("Failed to build agent: %v", err)
+	}
+	provider := aitest.ByPrompt(map[string]string{"agent router": "deploy application"})
+	old := NewOrchestrator(provider, graph.NewGlobalGraph(graph.NewMemoryGraph()), oldBus, oldRegistry)
+	ctx := features.WithConversationID(context.Background(), "conv-handoff")
+	go old.Chat(ctx, "deploy checkout to dev")
+
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Agent never received the request")
+	}
+
+	work, err := old.Checkpoint()
+	if err != nil {
+		t.Fatalf("Checkpoint failed: %v", err)
+	}
+	pending, ok := work.([]PendingRequest)
+	if !ok || len(pending) != 1 {
+		t.Fatalf("Expected one pending request, got %#v", work)
+	}
+	if pending[0].RoutingKey != "handoff.deploy" || pending[0].Conversation != "conversation:conv-handoff" || pending[0].Payload["user_message"] != "deploy checkout to dev" {
+		t.Fatalf("Unexpected pending request: %+v", pending[0])
+	}
+	data, _ := json.Marshal(work)
+
+	// The instance taking over: its agent answers, and the answer lands in the transcript
+	newRegistry := agentRegistry.NewInMemoryAgentRegistry()
+	newBus := events.NewEventBus(nil, true)
+	received := make(chan string, 1)
+	_, err = agentframework.NewAgent("deployer").
+		WithCapabilities(handoffCapability).
+		WithEventHandler(func(ctx context.Context, event *events.Event) (*events.Event, error) {
+			received <- event.Payload["correlation_id"].(string)
+			return &events.Event{
+				Type:    events.EventTypeResponse,
+				Source:  "deployer",
+				Subject: "deployed",
+				Payload: map[string]interface{}{"status": "success", "message": "checkout deployed to dev", "correlation_id": event.Payload["correlation_id"]},
+			}, nil
+		}).
+		Build(agentframework.AgentDependencies{Registry: newRegistry, EventBus: newBus})
+	if err != nil {
+		t.Fatalf("Failed to build agent: %v", err)
+	}
+	next := NewOrchestrator(provider, graph.NewGlobalGraph(graph.NewMemoryGraph()), newBus, newRegistry)
+	store := &savedTurns{Store: conversations.NewGraphStore(next.graph), saved: make(chan []conversations.Turn, 1)}
+	transcripts := conversations.NewService(next.graph, conversations.Options{Store: store})
+	next.SetTranscripts(transcripts)
+
+	if err := next.Restore(data); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	select {
+	case correlationID := <-received:
+		if correlationID != pending[0].CorrelationID {
+			t.Errorf("Expected the original correlation ID %s, got %s", pending[0].CorrelationID, correlationID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Resumed request never reached the agent")
+	}
+
+	select {
+	case turns := <-store.saved:
+		if len(turns) != 1 || turns[0].Response != "checkout deployed to dev" || turns[0].CorrelationID != pending[0].CorrelationID {
+			t.Fatalf("Unexpected recorded turns: %+v", turns)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Resumed response was never recorded")
+	}
+
+	// The request is untracked once its answer is recorded
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		work, _ := next.Checkpoint()
+		if work == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected nothing pending once the resumed request was answered, got %#v", work)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
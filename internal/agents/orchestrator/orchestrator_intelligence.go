@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+	"github.com/krzachariassen/ZTDP/internal/ai"
 )
 
 // buildDynamicPlatformKnowledge uses AI to analyze the agent registry and build dynamic platform knowledge
@@ -37,15 +38,18 @@ GUIDELINES:
 6. Include examples of natural language requests users can make
 
 OUTPUT FORMAT:
-Return a structured knowledge base that another AI can use to help users.`
+Return a structured knowledge base that another AI can use to help users.
 
-	capabilityData := fmt.Sprintf(`CURRENT PLATFORM STATE:
-%s
+CONTRACT SCHEMAS (fields users must provide when creating platform objects):
+` + o.loadAllContracts()
 
-AVAILABLE AGENT CAPABILITIES:
-%s`, platformState, o.formatCapabilitiesForAI(capabilities))
+	// Capabilities matter more than the object listing, which is cut first on large platforms
+	capabilityData := o.fitPlatformContext(
+		ai.ContextSection{Name: "CURRENT PLATFORM STATE", Content: platformState, Priority: 1},
+		ai.ContextSection{Name: "AVAILABLE AGENT CAPABILITIES", Content: o.formatCapabilitiesForAI(capabilities), Priority: 2},
+	)
 
-	knowledge, err := o.aiProvider.CallAI(ctx, systemPrompt, capabilityData)
+	knowledge, err := o.aiProvider.CallAI(ai.WithTask(ctx, ai.TaskConversation), systemPrompt, capabilityData)
 	if err != nil {
 		return "", fmt.Errorf("failed to build platform knowledge: %w", err)
 	}
@@ -87,12 +91,14 @@ ROUTING RULES:
 1. Analyze the user's request and understand what they want to accomplish
 2. Match the request to the most appropriate agent capability
 3. Return the specific intent name that best matches their request
-4. If no capability matches, return "general_conversation"
+4. Factual questions about what exists (counts, lists, ownership, relationships) return "graph_query"
+5. If no capability matches, return "general_conversation"
 
 EXAMPLES:
 - "Deploy myapp to production" → "deploy application"
 - "Check if deployment is allowed" → "policy check"
 - "Create a new service called checkout" → "create application"
+- "How many services does checkout have?" → "graph_query"
 - "What is this platform?" → "general_conversation"
 - "Help me understand what I can do" → "general_conversation"
 
@@ -120,13 +126,12 @@ REQUIREMENTS:
 
 OUTPUT: Return ONLY the system prompt that will be used for conversation.`
 
-	knowledge := fmt.Sprintf(`DYNAMIC PLATFORM KNOWLEDGE:
-%s
-
-CURRENT PLATFORM STATE:
-%s`, platformKnowledge, o.getPlatformState())
+	knowledge := o.fitPlatformContext(
+		ai.ContextSection{Name: "DYNAMIC PLATFORM KNOWLEDGE", Content: platformKnowledge, Priority: 2},
+		ai.ContextSection{Name: "CURRENT PLATFORM STATE", Content: o.getPlatformState(), Priority: 1},
+	)
 
-	prompt, err := o.aiProvider.CallAI(ctx, systemPrompt, knowledge)
+	prompt, err := o.aiProvider.CallAI(ai.WithTask(ctx, ai.TaskConversation), systemPrompt, knowledge)
 	if err != nil {
 		return o.getDefaultConversationPrompt(), nil
 	}
@@ -134,6 +139,19 @@ CURRENT PLATFORM STATE:
 	return prompt, nil
 }
 
+// platformContextTokens bounds the platform context sent with knowledge and conversation prompts
+const platformContextTokens = 6000
+
+// fitPlatformContext joins the sections within platformContextTokens, keeping higher priority
+// sections whole
+func (o *Orchestrator) fitPlatformContext(sections ...ai.ContextSection) string {
+	fit := ai.FitSections(ai.DefaultTokenizer, sections, platformContextTokens)
+	if len(fit.Truncated) > 0 || len(fit.Dropped) > 0 {
+		o.logger.Warn("✂️ Platform context over %d tokens (truncated: %v, dropped: %v)", platformContextTokens, fit.Truncated, fit.Dropped)
+	}
+	return fit.Text
+}
+
 // formatCapabilitiesForAI formats capabilities in a way that's easy for AI to understand
 func (o *Orchestrator) formatCapabilitiesForAI(capabilities []agentRegistry.AgentCapability) string {
 	if len(capabilities) == 0 {
@@ -170,7 +188,8 @@ GUIDELINES:
 1. Look at the user's request and understand what they want to do
 2. Match it to the most relevant capability from the available agents
 3. Return the specific intent that matches their request
-4. If no agent capability matches, return "general_conversation"
+4. Factual questions about what exists (counts, lists, ownership, relationships) return "graph_query"
+5. If no agent capability matches, return "general_conversation"
 
 OUTPUT FORMAT: 
 - For agent routing: Return just the intent name (e.g., "deploy application", "policy check", "create application")
@@ -180,6 +199,7 @@ EXAMPLES:
 - "Deploy myapp to production" → "deploy application"
 - "Check if deployment is allowed" → "policy check"  
 - "Create a new service" → "create application"
+- "Which services does checkout have?" → "graph_query"
 - "What is ZTDP?" → "general_conversation"
 - "Help me understand this platform" → "general_conversation"`
 }
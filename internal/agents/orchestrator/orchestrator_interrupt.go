@@ -0,0 +1,178 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/features"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
+)
+
+// Interruption intents act on the conversation's running orchestration instead of starting a new one
+const (
+	interruptCancel = "cancel"
+	interruptPause  = "pause"
+	interruptResume = "resume"
+	interruptStatus = "status"
+)
+
+// interruptPhrases are matched against the whole message so requests such as
+// "cancel the checkout deployment tomorrow" still go through intent detection
+var interruptPhrases = map[string]string{
+	"cancel": interruptCancel, "cancel that": interruptCancel, "cancel it": interruptCancel,
+	"stop": interruptCancel, "stop that": interruptCancel, "stop it": interruptCancel,
+	"abort": interruptCancel, "never mind": interruptCancel, "nevermind": interruptCancel,
+	"pause": interruptPause, "pause that": interruptPause, "pause it": interruptPause,
+	"hold on": interruptPause, "wait": interruptPause,
+	"resume": interruptResume, "continue": interruptResume, "go ahead": interruptResume, "carry on": interruptResume,
+	"status": interruptStatus, "progress": interruptStatus, "what's the status": interruptStatus,
+	"what is the status": interruptStatus, "are you done": interruptStatus, "how is it going": interruptStatus,
+}
+
+// detectInterruption returns the interruption intent of a message, if it is one
+func detectInterruption(userMessage string) (string, bool) {
+	normalized := strings.ToLower(strings.TrimSpace(userMessage))
+	normalized = strings.TrimRight(normalized, ".!? ")
+	normalized = strings.TrimPrefix(normalized, "please ")
+	normalized = strings.TrimSuffix(normalized, " please")
+	kind, ok := interruptPhrases[normalized]
+	return kind, ok
+}
+
+// activeOrchestration is a request the orchestrator is waiting on an agent for
+type activeOrchestration struct {
+	CorrelationID string
+	Intent        string
+	Agent         string
+	StartedAt     time.Time
+	Paused        bool
+	cancel        context.CancelFunc
+
+	// The request as sent, re-sent by the instance taking over after an upgrade
+	routingKey string
+	payload    map[string]interface{}
+}
+
+// conversationKey identifies whose orchestration an interruption targets
+func conversationKey(ctx context.Context) string {
+	if evalCtx := features.EvaluationContextFrom(ctx); evalCtx.ConversationID != "" {
+		return "conversation:" + evalCtx.ConversationID
+	}
+	if userID := logging.UserIDFromContext(ctx); userID != "" {
+		return "user:" + userID
+	}
+	return ""
+}
+
+// trackOrchestration records the conversation's running orchestration so a later
+// interruption can find it. The returned context is cancelled by a "cancel" interruption.
+func (o *Orchestrator) trackOrchestration(ctx context.Context, correlationID, intent, agentID, routingKey string, payload map[string]interface{}) (context.Context, func()) {
+	key := conversationKey(ctx)
+	if key == "" {
+		return ctx, func() {}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	active := &activeOrchestration{CorrelationID: correlationID, Intent: intent, Agent: agentID, StartedAt: time.Now(), cancel: cancel,
+		routingKey: routingKey, payload: payload}
+
+	o.mu.Lock()
+	if o.active == nil {
+		o.active = make(map[string]*activeOrchestration)
+	}
+	o.active[key] = active
+	o.mu.Unlock()
+
+	return ctx, func() {
+		o.mu.Lock()
+		if o.active[key] == active {
+			delete(o.active, key)
+		}
+		o.mu.Unlock()
+		cancel()
+	}
+}
+
+// activeFor returns a copy of the conversation's running orchestration
+func (o *Orchestrator) activeFor(ctx context.Context) (activeOrchestration, bool) {
+	key := conversationKey(ctx)
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	active, ok := o.active[key]
+	if !ok || key == "" {
+		return activeOrchestration{}, false
+	}
+	return *active, true
+}
+
+// handleInterruption applies an interruption to the conversation's running orchestration.
+// It returns false when nothing is running, so the message is handled as a normal request.
+func (o *Orchestrator) handleInterruption(ctx context.Context, kind string) (*ConversationalResponse, bool) {
+	active, ok := o.activeFor(ctx)
+	if !ok {
+		if kind == interruptCancel {
+			return o.dropClarification(ctx)
+		}
+		return nil, false
+	}
+	elapsed := time.Since(active.StartedAt).Round(time.Second)
+	logger := o.logger.ForContext(ctx)
+
+	var message string
+	switch kind {
+	case interruptCancel:
+		o.broadcastInterrupt(ctx, agentframework.InterruptCancel, active.CorrelationID)
+		o.mu.Lock()
+		if current, exists := o.active[conversationKey(ctx)]; exists && current.CorrelationID == active.CorrelationID {
+			current.cancel()
+		}
+		o.mu.Unlock()
+		logger.Info("🛑 Cancelled %s (%s)", active.Intent, active.CorrelationID)
+		message = fmt.Sprintf("🛑 Cancelled the %s request sent to %s. Steps already completed are not rolled back.", active.Intent, active.Agent)
+	case interruptPause:
+		o.broadcastInterrupt(ctx, agentframework.InterruptPause, active.CorrelationID)
+		o.setPaused(ctx, active.CorrelationID, true)
+		message = fmt.Sprintf("⏸️ Paused the %s request at its next safe step. Say \"resume\" to continue or \"cancel\" to stop it.", active.Intent)
+	case interruptResume:
+		o.broadcastInterrupt(ctx, agentframework.InterruptResume, active.CorrelationID)
+		o.setPaused(ctx, active.CorrelationID, false)
+		message = fmt.Sprintf("▶️ Resumed the %s request.", active.Intent)
+	default:
+		state := "running"
+		if active.Paused {
+			state = "paused"
+		}
+		message = fmt.Sprintf("⏳ The %s request is %s on %s (started %s ago).", active.Intent, state, active.Agent, elapsed)
+	}
+
+	return &ConversationalResponse{
+		Message:    message,
+		Answer:     message,
+		Intent:     "interrupt_" + kind,
+		Actions:    []Action{{Type: "interrupt", Result: map[string]interface{}{"action": kind, "correlation_id": active.CorrelationID, "intent": active.Intent, "agent": active.Agent}}},
+		Confidence: 1.0,
+	}, true
+}
+
+func (o *Orchestrator) setPaused(ctx context.Context, correlationID string, paused bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if current, ok := o.active[conversationKey(ctx)]; ok && current.CorrelationID == correlationID {
+		current.Paused = paused
+	}
+}
+
+// broadcastInterrupt tells every agent working on the correlation ID to cancel, pause or resume
+func (o *Orchestrator) broadcastInterrupt(ctx context.Context, subject, correlationID string) {
+	if o.eventBus == nil {
+		return
+	}
+	if err := o.eventBus.Emit(events.EventTypeBroadcast, o.agentID, subject, map[string]interface{}{
+		"correlation_id": correlationID,
+	}); err != nil {
+		o.logger.ForContext(ctx).Warn("⚠️ Failed to broadcast %s for %s: %v", subject, correlationID, err)
+	}
+}
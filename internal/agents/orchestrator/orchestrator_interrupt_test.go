@@ -0,0 +1,122 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+	"github.com/krzachariassen/ZTDP/internal/ai/aitest"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/features"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
+)
+
+func TestDetectInterruption(t *testing.T) {
+	cases := map[string]string{
+		"Cancel that!":       interruptCancel,
+		"please stop":        interruptCancel,
+		"hold on":            interruptPause,
+		"resume please":      interruptResume,
+		"What's the status?": interruptStatus,
+	}
+	for message, expected := range cases {
+		if kind, ok := detectInterruption(message); !ok || kind != expected {
+			t.Errorf("detectInterruption(%q) = %q, %v; expected %q", message, kind, ok, expected)
+		}
+	}
+	if _, ok := detectInterruption("cancel the checkout deployment tomorrow"); ok {
+		t.Error("Expected a full request mentioning cancel not to be an interruption")
+	}
+}
+
+// TestOrchestratorInterruptsRunningRequest tests that status, pause, resume and cancel messages
+// reach the agent working on the conversation's request
+func TestOrchestratorInterruptsRunningRequest(t *testing.T) {
+	registry := agentRegistry.NewInMemoryAgentRegistry()
+	eventBus := events.NewEventBus(nil, true)
+
+	started := make(chan struct{})
+	stopped := make(chan error, 1)
+	_, err := agentframework.NewAgent("slow-deployer").
+		WithCapabilities([]agentRegistry.AgentCapability{{
+			Name:        "slow_deployment",
+			Intents:     []string{"deploy application"},
+			RoutingKeys: []string{"slow.deploy"},
+		}}).
+		WithEventHandler(func(ctx context.Context, event *events.Event) (*events.Event, error) {
+			close(started)
+			for {
+				if err := agentframework.Checkpoint(ctx); err != nil {
+					stopped <- err
+					return nil, err
+				}
+				time.Sleep(5 * time.Millisecond)
+			}
+		}).
+		Build(agentframework.AgentDependencies{Registry: registry, EventBus: eventBus})
+	if err != nil {
+		t.Fatalf("Failed to build agent: %v", err)
+	}
+
+	provider := aitest.ByPrompt(map[string]string{"agent router": "deploy application"})
+	o := NewOrchestrator(provider, graph.NewGlobalGraph(graph.NewMemoryGraph()), eventBus, registry)
+	ctx := features.WithConversationID(context.Background(), "conv-interrupt")
+
+	type chatResult struct {
+		response *ConversationalResponse
+		err      error
+	}
+	first := make(chan chatResult, 1)
+	go func() {
+		response, err := o.Chat(ctx, "deploy checkout to dev")
+		first <- chatResult{response, err}
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Agent never received the request")
+	}
+
+	status, err := o.Chat(ctx, "status")
+	if err != nil || status.Intent != "interrupt_status" || !strings.Contains(status.Message, "deploy application") {
+		t.Fatalf("Expected a status report for the running request, got %+v (%v)", status, err)
+	}
+	if paused, _ := o.Chat(ctx, "pause"); paused.Intent != "interrupt_pause" {
+		t.Errorf("Expected pause to be handled, got %s", paused.Intent)
+	}
+	if status, _ := o.Chat(ctx, "status"); !strings.Contains(status.Message, "paused") {
+		t.Errorf("Expected the request to be reported paused, got %s", status.Message)
+	}
+	o.Chat(ctx, "resume")
+
+	cancelled, err := o.Chat(ctx, "Cancel that!")
+	if err != nil || cancelled.Intent != "interrupt_cancel" {
+		t.Fatalf("Expected cancel to be handled, got %+v (%v)", cancelled, err)
+	}
+
+	select {
+	case err := <-stopped:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected the agent to see a cancelled context, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Cancellation never reached the agent")
+	}
+	select {
+	case result := <-first:
+		if result.err != nil || !strings.Contains(result.response.Message, "cancelled") {
+			t.Errorf("Expected the original request to report cancellation, got %+v (%v)", result.response, result.err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Original request never returned")
+	}
+
+	if _, active := o.activeFor(ctx); active {
+		t.Error("Expected no running request after cancellation")
+	}
+}
@@ -0,0 +1,134 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+	"github.com/krzachariassen/ZTDP/internal/routing"
+)
+
+// SetRoutingOverrides enables the routing override table, consulted before AI intent detection
+func (o *Orchestrator) SetRoutingOverrides(service *routing.Service) {
+	o.routes = service
+}
+
+// pinnedRoute holds the agents and routing key a routing override chose for a request
+type pinnedRoute struct {
+	override   *routing.Override
+	agents     []agentRegistry.AgentStatus
+	routingKey string
+}
+
+type pinnedRouteKey struct{}
+
+// pinnedRouteFrom returns the route an override chose for the request in ctx, or nil
+func pinnedRouteFrom(ctx context.Context) *pinnedRoute {
+	route, _ := ctx.Value(pinnedRouteKey{}).(*pinnedRoute)
+	return route
+}
+
+// routeByOverride sends the request where the first usable matching routing override says. It
+// returns false when no override matches, or the matching ones point at agents that are not
+// registered; AI routing then handles the request as usual.
+func (o *Orchestrator) routeByOverride(ctx context.Context, userMessage string) (*ConversationalResponse, bool) {
+	if o.routes == nil || o.agentRegistry == nil {
+		return nil, false
+	}
+	matched, err := o.routes.Match(userMessage)
+	if err != nil {
+		o.logger.Warn("⚠️ Failed to read routing overrides: %v", err)
+		return nil, false
+	}
+	var override *routing.Override
+	var route *pinnedRoute
+	var intent string
+	for _, candidate := range matched {
+		if route, intent, err = o.resolveOverride(ctx, candidate); err == nil {
+			override = candidate
+			break
+		}
+		o.logger.Warn("⚠️ Routing override %s matched but cannot be used: %v", candidate.ID, err)
+	}
+	if override == nil {
+		return nil, false
+	}
+	if err := o.routes.RecordHit(override.ID); err != nil {
+		o.logger.Warn("⚠️ Failed to count hit of routing override %s: %v", override.ID, err)
+	}
+	o.logger.Info("🔀 Routing override %s sends the request to %s as intent %s", override.ID, override.Target(), intent)
+
+	ctx = context.WithValue(ctx, pinnedRouteKey{}, route)
+	result, err := o.orchestrateViaIntentBasedAgents(ctx, intent, map[string]interface{}{
+		"user_message": userMessage,
+		"source":       "routing-override",
+	})
+	return o.intentConversationalResponse(ctx, intent, userMessage, result, err), true
+}
+
+// resolveOverride finds the agents, routing key and intent an override points at
+func (o *Orchestrator) resolveOverride(ctx context.Context, override *routing.Override) (*pinnedRoute, string, error) {
+	var capability *agentRegistry.AgentCapability
+	var agents []agentRegistry.AgentStatus
+	if override.Agent != "" {
+		agent, err := o.agentRegistry.FindAgentByID(ctx, override.Agent)
+		if err != nil || agent == nil {
+			return nil, "", fmt.Errorf("agent %s is not registered", override.Agent)
+		}
+		if capability = overrideCapability(agent.GetCapabilities(), override); capability == nil {
+			return nil, "", fmt.Errorf("agent %s offers no matching capability", override.Agent)
+		}
+		status := agent.GetStatus()
+		status.ID = agent.GetID()
+		agents = []agentRegistry.AgentStatus{status}
+	} else {
+		capabilities, err := o.agentRegistry.GetAvailableCapabilities(ctx)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to get available capabilities: %w", err)
+		}
+		if capability = overrideCapability(capabilities, override); capability == nil {
+			return nil, "", fmt.Errorf("no registered agent offers capability %s", override.Capability)
+		}
+		if agents, err = o.agentRegistry.FindAgentsByCapability(ctx, capability.Name); err != nil {
+			return nil, "", fmt.Errorf("failed to find agents for capability %s: %w", capability.Name, err)
+		}
+		if agents = o.excludeSelf(o.deduplicate(agents)); len(agents) == 0 {
+			return nil, "", fmt.Errorf("no registered agent offers capability %s", capability.Name)
+		}
+	}
+
+	intent := override.Intent
+	if intent == "" {
+		if len(capability.Intents) == 0 {
+			return nil, "", fmt.Errorf("capability %s has no intents; set the override's intent", capability.Name)
+		}
+		intent = capability.Intents[0]
+	}
+	route := &pinnedRoute{override: override, agents: agents}
+	if len(capability.RoutingKeys) > 0 {
+		route.routingKey = capability.RoutingKeys[0]
+	}
+	return route, intent, nil
+}
+
+// overrideCapability picks the capability named by the override, else the first one offering its
+// intent, else the first one
+func overrideCapability(capabilities []agentRegistry.AgentCapability, override *routing.Override) *agentRegistry.AgentCapability {
+	for i := range capabilities {
+		switch {
+		case override.Capability != "":
+			if capabilities[i].Name == override.Capability {
+				return &capabilities[i]
+			}
+		case override.Intent != "":
+			for _, intent := range capabilities[i].Intents {
+				if intent == override.Intent {
+					return &capabilities[i]
+				}
+			}
+		default:
+			return &capabilities[i]
+		}
+	}
+	return nil
+}
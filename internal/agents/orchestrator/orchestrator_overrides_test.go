@@ -0,0 +1,77 @@
+package orchestrator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+	"github.com/krzachariassen/ZTDP/internal/ai/aitest"
+	"github.com/krzachariassen/ZTDP/internal/decisions"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/routing"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
+)
+
+// TestOrchestratorRoutingOverrideSkipsAI tests that a matching override routes the request
+// without asking the AI, counts the hit and explains itself in the decision trail
+func TestOrchestratorRoutingOverrideSkipsAI(t *testing.T) {
+	g := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	registry := agentRegistry.NewInMemoryAgentRegistry()
+	bus := events.NewEventBus(nil, false)
+	var intents []string
+	var agent agentRegistry.AgentInterface
+	agent, err := agentframework.NewAgent("deployer").
+		WithCapabilities([]agentRegistry.AgentCapability{deployCapability}).
+		WithEventHandler(func(ctx context.Context, event *events.Event) (*events.Event, error) {
+			intent, _ := event.Payload["intent"].(string)
+			intents = append(intents, intent)
+			return agent.(*agentframework.BaseAgent).CreateResponse("deployed", map[string]interface{}{"message": "deployed"}, event), nil
+		}).
+		Build(agentframework.AgentDependencies{Registry: registry, EventBus: bus})
+	if err != nil {
+		t.Fatalf("Failed to build agent: %v", err)
+	}
+
+	provider := &aitest.Provider{}
+	o := NewOrchestrator(provider, g, bus, registry)
+	decisionService := decisions.NewService(g, 0)
+	o.SetDecisions(decisionService)
+	routes := routing.NewService(g)
+	o.SetRoutingOverrides(routes)
+	override := &routing.Override{Pattern: `^ship\b`, Capability: "deployment"}
+	if err := routes.Create(override); err != nil {
+		t.Fatalf("Failed to create override: %v", err)
+	}
+	// Overrides pointing at agents that are not registered are skipped
+	if err := routes.Create(&routing.Override{Pattern: "checkout", Agent: "ghost", Priority: 10}); err != nil {
+		t.Fatalf("Failed to create override: %v", err)
+	}
+
+	response, err := o.Chat(context.Background(), "Ship checkout to dev")
+	if err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if calls := provider.Calls(); len(calls) != 0 {
+		t.Errorf("Expected the AI not to be asked, got calls: %v", calls)
+	}
+	if len(intents) != 1 || intents[0] != "deploy application" {
+		t.Errorf("Expected the deployer to get the capability's first intent, got: %v", intents)
+	}
+	if response.Intent != "deploy application" {
+		t.Errorf("Expected intent 'deploy application', got: %s", response.Intent)
+	}
+
+	stored, err := routes.Get(override.ID)
+	if err != nil || stored.Hits != 1 || stored.LastHitAt == nil {
+		t.Errorf("Expected one recorded hit, got: %+v (%v)", stored, err)
+	}
+	recorded, err := decisionService.List(decisions.Filter{})
+	if err != nil || len(recorded) != 1 {
+		t.Fatalf("Expected one decision, got %d (%v)", len(recorded), err)
+	}
+	if !strings.Contains(recorded[0].Reasoning, "Routing override "+override.ID) || recorded[0].SelectedAgent != "deployer" {
+		t.Errorf("Expected the decision to name the override, got: %+v", recorded[0])
+	}
+}
@@ -2,10 +2,10 @@ package orchestrator
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
 
+	"github.com/krzachariassen/ZTDP/internal/contracts"
 	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/kinds"
 )
 
 // getPlatformState gets current platform state with detailed information
@@ -70,6 +70,9 @@ APPLICATIONS (%d):`, len(currentGraph.Nodes), len(applications))
 		}
 	}
 
+	// User-defined kinds, unless their definition keeps them out of AI context
+	state += kinds.PlatformContext(currentGraph)
+
 	return state
 }
 
@@ -95,18 +98,7 @@ func (o *Orchestrator) getNodesByKind(nodes map[string]*graph.Node, kind string)
 	return result
 }
 
-// loadAllContracts dynamically loads all contract definitions
+// loadAllContracts returns the embedded contract schemas of the kinds users manage through chat
 func (o *Orchestrator) loadAllContracts() string {
-	contractsDir := "/mnt/c/Work/git/ztdp/internal/contracts"
-
-	contracts := ""
-	contractFiles := []string{"application.go", "service.go", "environment.go", "resource.go"}
-
-	for _, file := range contractFiles {
-		if content, err := os.ReadFile(filepath.Join(contractsDir, file)); err == nil {
-			contracts += fmt.Sprintf("\n// %s\n%s\n", file, string(content))
-		}
-	}
-
-	return contracts
+	return contracts.PromptSummary("application", "service", "environment", "resource")
 }
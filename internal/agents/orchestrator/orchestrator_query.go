@@ -0,0 +1,175 @@
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/krzachariassen/ZTDP/internal/ai"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/kinds"
+)
+
+// graphQueryIntent is returned by intent detection for factual questions about platform state.
+// These are answered from an executed graph query instead of the AI reading the whole graph.
+const graphQueryIntent = "graph_query"
+
+// graphQueryPrompt teaches the AI the structured query format
+const graphQueryPrompt = `You translate questions about a developer platform into a structured graph query.
+
+Respond with JSON only:
+{"kind": "<start node kind>", "where": {"<field>": "<value>"}, "traverse": [{"edge": "<edge type>", "direction": "out|in", "kind": "<node kind>", "where": {}}], "return": "nodes|count"}
+
+Node kinds: %s
+Edge types: %s
+Fields: id, name, owner, or any metadata/spec field. Values match case-insensitively.
+
+Common shapes:
+- applications own services and resources: application -owns-> service
+- services have versions: service -has_version-> service_version
+- versions and resources are deployed to environments: service_version -deploy-> environment
+- "how many" questions use "return": "count"
+
+Example: "how many services does checkout have?" →
+{"kind": "application", "where": {"name": "checkout"}, "traverse": [{"edge": "owns", "direction": "out", "kind": "service"}], "return": "count"}`
+
+// graphAnswerPrompt makes the AI compose an answer from executed query results only
+const graphAnswerPrompt = `Answer the user's question in one or two sentences using ONLY the query results provided.
+Do not add facts that are not in the results. If the results are empty, say nothing matched.`
+
+// answerGraphQuery has the AI generate a structured query, executes it against the graph and
+// composes the answer from the real results. The query is returned so the answer can be verified.
+func (o *Orchestrator) answerGraphQuery(ctx context.Context, userMessage string) (*ConversationalResponse, error) {
+	if o.graph == nil {
+		return nil, fmt.Errorf("graph is not available")
+	}
+
+	currentGraph, err := o.graph.Graph()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph: %w", err)
+	}
+	nodeKinds := append(knownNodeKinds(), kinds.AIVisibleKinds(currentGraph)...)
+	prompt := fmt.Sprintf(graphQueryPrompt, strings.Join(nodeKinds, ", "), strings.Join(knownEdgeTypes(), ", "))
+	response, err := o.aiProvider.CallAI(ai.WithTask(ctx, ai.TaskExtraction), prompt, userMessage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate graph query: %w", err)
+	}
+
+	var query graph.Query
+	if err := json.Unmarshal([]byte(cleanJSONResponse(response)), &query); err != nil {
+		o.logger.Warn("⚠️ AI returned an unparseable graph query: %v", err)
+		return o.handleGeneralConversation(ctx, userMessage)
+	}
+
+	// Custom kinds can be kept out of AI context; queries naming them are not run
+	excluded := kinds.AIExcludedKinds(currentGraph)
+	var result *graph.QueryResult
+	if err = checkVisibleKinds(query, excluded); err == nil {
+		result, err = o.graph.Query(query)
+	}
+	if err != nil {
+		msg := fmt.Sprintf("I couldn't answer that from the platform graph: %v", err)
+		return &ConversationalResponse{
+			Message: msg,
+			Answer:  msg,
+			Intent:  graphQueryIntent,
+			Actions: []Action{{Type: "graph_query", Result: map[string]interface{}{"query": query, "error": err.Error()}}},
+		}, nil
+	}
+	o.logger.Info("🔎 Graph query matched %d nodes", result.Count)
+	result.Nodes = withoutKinds(result.Nodes, excluded)
+
+	answer := o.composeGraphAnswer(ctx, userMessage, result)
+	queryJSON, _ := json.Marshal(query)
+	msg := fmt.Sprintf("%s\n\nQuery: %s", answer, queryJSON)
+	return &ConversationalResponse{
+		Message:    msg,
+		Answer:     answer,
+		Intent:     graphQueryIntent,
+		Actions:    []Action{{Type: "graph_query", Result: result}},
+		Confidence: 1.0,
+	}, nil
+}
+
+// composeGraphAnswer phrases the result with AI, falling back to a plain summary so the
+// answer is always grounded in the executed query
+func (o *Orchestrator) composeGraphAnswer(ctx context.Context, userMessage string, result *graph.QueryResult) string {
+	data, err := json.Marshal(result)
+	if err == nil {
+		question := fmt.Sprintf("Question: %s\n\nQuery results:\n%s", userMessage, data)
+		if answer, err := o.aiProvider.CallAI(ai.WithTask(ctx, ai.TaskConversation), graphAnswerPrompt, question); err == nil && strings.TrimSpace(answer) != "" {
+			return strings.TrimSpace(answer)
+		}
+	}
+	return describeQueryResult(result)
+}
+
+// describeQueryResult is the deterministic answer for a query result
+func describeQueryResult(result *graph.QueryResult) string {
+	if result.Query.Return == graph.QueryReturnCount || len(result.Nodes) == 0 {
+		return fmt.Sprintf("Count: %d", result.Count)
+	}
+	names := make([]string, 0, len(result.Nodes))
+	for _, node := range result.Nodes {
+		names = append(names, node.Name)
+	}
+	return fmt.Sprintf("Found %d: %s", result.Count, strings.Join(names, ", "))
+}
+
+// checkVisibleKinds fails when the query starts at or traverses to an excluded kind
+func checkVisibleKinds(query graph.Query, excluded map[string]bool) error {
+	named := []string{query.Kind}
+	for _, step := range query.Traverse {
+		named = append(named, step.Kind)
+	}
+	for _, kind := range named {
+		if excluded[kind] {
+			return fmt.Errorf("%s nodes are not available to the assistant", kind)
+		}
+	}
+	return nil
+}
+
+// withoutKinds drops the nodes of excluded kinds a traversal reached
+func withoutKinds(nodes []graph.QueryNode, excluded map[string]bool) []graph.QueryNode {
+	if len(excluded) == 0 {
+		return nodes
+	}
+	kept := nodes[:0]
+	for _, node := range nodes {
+		if !excluded[node.Kind] {
+			kept = append(kept, node)
+		}
+	}
+	return kept
+}
+
+func knownNodeKinds() []string {
+	return []string{
+		graph.KindApplication, graph.KindService, graph.KindServiceVersion, graph.KindEnvironment,
+		graph.KindResource, graph.KindResourceType, graph.KindPolicy,
+		graph.KindMLModel, graph.KindModelVersion, graph.KindModelEndpoint, graph.KindMigration,
+		graph.KindTopic, graph.KindRunbook, graph.KindDRDrill,
+		graph.KindOrganization, graph.KindTeam, graph.KindProject,
+	}
+}
+
+func knownEdgeTypes() []string {
+	types := make([]string, 0, len(graph.AllowedEdgeTypes))
+	for edgeType := range graph.AllowedEdgeTypes {
+		types = append(types, edgeType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// cleanJSONResponse strips markdown fences the AI sometimes wraps JSON in
+func cleanJSONResponse(response string) string {
+	cleaned := strings.TrimSpace(response)
+	cleaned = strings.TrimPrefix(cleaned, "```json")
+	cleaned = strings.TrimPrefix(cleaned, "```")
+	cleaned = strings.TrimSuffix(cleaned, "```")
+	return strings.TrimSpace(cleaned)
+}
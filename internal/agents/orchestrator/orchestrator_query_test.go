@@ -0,0 +1,98 @@
+package orchestrator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/ai"
+	"github.com/krzachariassen/ZTDP/internal/ai/aitest"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/kinds"
+)
+
+func createQueryTestOrchestrator(t *testing.T, provider ai.AIProvider) *Orchestrator {
+	t.Helper()
+	g := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	g.AddNode(&graph.Node{ID: "checkout", Kind: graph.KindApplication, Metadata: map[string]interface{}{"name": "checkout"}})
+	for _, svc := range []string{"checkout-api", "checkout-worker"} {
+		g.AddNode(&graph.Node{ID: svc, Kind: graph.KindService, Metadata: map[string]interface{}{"name": svc}})
+		if err := g.AddEdge("checkout", svc, graph.EdgeTypeOwns); err != nil {
+			t.Fatalf("failed to add edge: %v", err)
+		}
+	}
+	return NewOrchestrator(provider, g, nil, nil)
+}
+
+// TestOrchestratorGraphQuery tests that factual questions are answered from an executed query
+func TestOrchestratorGraphQuery(t *testing.T) {
+	provider := aitest.ByPrompt(map[string]string{
+		"agent router":       "graph_query",
+		"structured graph":   "```json\n{\"kind\": \"application\", \"where\": {\"name\": \"checkout\"}, \"traverse\": [{\"edge\": \"owns\", \"kind\": \"service\"}], \"return\": \"count\"}\n```",
+		"ONLY the query res": "Checkout has 2 services.",
+	})
+	o := createQueryTestOrchestrator(t, provider)
+
+	response, err := o.Chat(context.Background(), "how many services does checkout have?")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if response.Intent != graphQueryIntent {
+		t.Fatalf("Expected intent %s, got: %s", graphQueryIntent, response.Intent)
+	}
+	if response.Answer != "Checkout has 2 services." {
+		t.Errorf("Unexpected answer: %s", response.Answer)
+	}
+	if !strings.Contains(response.Message, `"return":"count"`) {
+		t.Errorf("Expected the executed query in the message, got: %s", response.Message)
+	}
+	result, ok := response.Actions[0].Result.(*graph.QueryResult)
+	if !ok || result.Count != 2 {
+		t.Fatalf("Expected the query result with count 2, got: %#v", response.Actions[0].Result)
+	}
+	calls := provider.Calls()
+	if !strings.Contains(calls[len(calls)-1], `"count":2`) {
+		t.Errorf("Expected the answer to be composed from the real results, got prompt: %s", calls[len(calls)-1])
+	}
+}
+
+// TestOrchestratorGraphQueryFallbackAnswer tests the deterministic answer when composition fails
+func TestOrchestratorGraphQueryFallbackAnswer(t *testing.T) {
+	provider := aitest.ByPrompt(map[string]string{
+		"agent router":     "graph_query",
+		"structured graph": `{"kind": "application", "traverse": [{"edge": "owns", "kind": "service"}]}`,
+	})
+	o := createQueryTestOrchestrator(t, provider)
+
+	response, err := o.Chat(context.Background(), "which services does checkout have?")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if response.Answer != "Found 2: checkout-api, checkout-worker" {
+		t.Errorf("Unexpected answer: %s", response.Answer)
+	}
+}
+
+// TestOrchestratorGraphQueryExcludedKinds tests that custom kinds excluded from AI context are not queried
+func TestOrchestratorGraphQueryExcludedKinds(t *testing.T) {
+	provider := aitest.ByPrompt(map[string]string{
+		"agent router":     "graph_query",
+		"structured graph": `{"kind": "secret_store"}`,
+	})
+	o := createQueryTestOrchestrator(t, provider)
+	kindService := kinds.NewService(o.graph, nil)
+	if _, err := kindService.Register(kinds.Definition{Name: "secret_store", AIContext: kinds.AIContext{Exclude: true}}); err != nil {
+		t.Fatalf("failed to register kind: %v", err)
+	}
+	if _, err := kindService.CreateNode("secret_store", graph.Node{ID: "vault"}); err != nil {
+		t.Fatalf("failed to create node: %v", err)
+	}
+
+	response, err := o.Chat(context.Background(), "which secret stores do we have?")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !strings.Contains(response.Message, "not available to the assistant") || strings.Contains(response.Message, "vault") {
+		t.Errorf("Expected the query to be refused, got: %s", response.Message)
+	}
+}
@@ -7,43 +7,88 @@ import (
 	"time"
 
 	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+	"github.com/krzachariassen/ZTDP/internal/decisions"
 	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/features"
+	"github.com/krzachariassen/ZTDP/internal/guardrails"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
 )
 
+// intentResponseTimeout is how long an orchestration waits for an agent to answer, AI operations included
+const intentResponseTimeout = 30 * time.Second
+
 // orchestrateViaIntentBasedAgents - PURE ORCHESTRATOR: Discovers agents by intent and routes events
 // This method contains NO domain-specific logic - it's completely generic!
-func (o *Orchestrator) orchestrateViaIntentBasedAgents(ctx context.Context, intent string, context map[string]interface{}) (interface{}, error) {
+func (o *Orchestrator) orchestrateViaIntentBasedAgents(ctx context.Context, intent string, context map[string]interface{}) (result interface{}, err error) {
 	if o.agentRegistry == nil {
 		return nil, fmt.Errorf("agent registry not available - cannot discover agents")
 	}
 
 	o.logger.Info("🔍 Discovering agents for intent: %s", intent)
 
-	// STEP 1: Discover agents by intent (completely generic)
-	availableAgents, err := o.discoverAgentsByIntent(ctx, intent)
-	if err != nil {
+	// STEP 1: Discover agents by intent (completely generic), unless a routing override chose them
+	route := pinnedRouteFrom(ctx)
+	var availableAgents []agentRegistry.AgentStatus
+	if route != nil {
+		availableAgents = route.agents
+	} else if availableAgents, err = o.discoverAgentsByIntent(ctx, intent); err != nil {
 		return nil, fmt.Errorf("agent discovery failed for intent '%s': %w", intent, err)
 	}
 
+	// Whatever happens from here, operators can audit which agents were considered and why
+	// the request ended where it did
+	userMessage, _ := context["user_message"].(string)
+	var arbitration *decisions.Arbitration
+	defer func() {
+		o.recordDecision(ctx, intent, userMessage, availableAgents, arbitration, result, err)
+	}()
+
 	if len(availableAgents) == 0 {
 		return nil, fmt.Errorf("no agents found for intent '%s' - register appropriate agents first", intent)
 	}
 
 	o.logger.Info("🎯 Found %d agents capable of handling intent: %s", len(availableAgents), intent)
 
+	// STEP 1.5: Several agents claim the intent; they bid, and the policy decides who goes first
+	if len(availableAgents) > 1 && o.arbitration != nil && !o.testMode {
+		request := map[string]interface{}{
+			"intent":       intent,
+			"user_message": userMessage,
+			"source_agent": "orchestrator",
+		}
+		request[agentframework.ContextEnvelopeKey] = o.buildContextEnvelope(ctx, intent, userMessage).Payload()
+		addCaller(ctx, request)
+		availableAgents, arbitration = o.arbitrate(ctx, intent, request, availableAgents)
+	}
+
+	// Agents breaching their SLA are tried last, unless a routing override chose them
+	if route == nil {
+		availableAgents = o.deprioritizeBreaching(ctx, intent, availableAgents)
+	}
+
 	// STEP 2: Route to the best agent and get routing key
 	selectedAgent := availableAgents[0] // Simple: use first available agent
 
 	// STEP 2.5: Discover the appropriate routing key for this intent
-	routingKey, err := o.discoverRoutingKeyForIntent(ctx, intent, selectedAgent.ID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to discover routing key for intent '%s' and agent '%s': %w", intent, selectedAgent.ID, err)
+	routingKey := ""
+	if route != nil {
+		routingKey = route.routingKey
+	}
+	if routingKey == "" {
+		if routingKey, err = o.discoverRoutingKeyForIntent(ctx, intent, selectedAgent.ID); err != nil {
+			return nil, fmt.Errorf("failed to discover routing key for intent '%s' and agent '%s': %w", intent, selectedAgent.ID, err)
+		}
 	}
 
 	o.logger.Info("🔑 Using routing key '%s' for agent: %s", routingKey, selectedAgent.ID)
 
 	// STEP 3: Create request-response correlation
-	correlationID := fmt.Sprintf("orchestration-%d", time.Now().UnixNano())
+	// Reuse the request's correlation ID so agent logs line up with the API request that triggered them
+	correlationID := logging.CorrelationIDFromContext(ctx)
+	if correlationID == "" {
+		correlationID = fmt.Sprintf("orchestration-%d", time.Now().UnixNano())
+	}
 	requestID := fmt.Sprintf("req-%d", time.Now().UnixNano())
 
 	// Create a channel to receive the response
@@ -74,6 +119,7 @@ func (o *Orchestrator) orchestrateViaIntentBasedAgents(ctx context.Context, inte
 		"request_id":     requestID,
 		"source_agent":   "orchestrator",
 	}
+	addCaller(ctx, eventPayload)
 
 	// Extract user_message from context to top-level for agent compatibility
 	if userMessage, ok := context["user_message"].(string); ok {
@@ -81,16 +127,43 @@ func (o *Orchestrator) orchestrateViaIntentBasedAgents(ctx context.Context, inte
 		eventPayload["message"] = userMessage // Some agents expect "message" field
 		eventPayload["query"] = userMessage   // Some agents expect "query" field
 	}
-
-	// Targeted event emission using specific routing key for this agent
-	if err := o.eventBus.Emit(events.EventTypeRequest, "orchestrator", routingKey, eventPayload); err != nil {
+	// A request answering an agent's question carries the question's original message and the answer
+	for _, key := range []string{agentframework.OriginalMessageKey, agentframework.ClarificationAnswerKey} {
+		if value, ok := context[key].(string); ok {
+			eventPayload[key] = value
+		}
+	}
+	// What we already know about the request, so the agent need not extract it again
+	eventPayload[agentframework.ContextEnvelopeKey] = o.buildContextEnvelope(ctx, intent, userMessage).Payload()
+
+	// Address the request to the selected agent: agents sharing a capability share its routing key
+	eventPayload[agentframework.TargetAgentKey] = selectedAgent.ID
+
+	// Register the request so the conversation can cancel, pause or ask about it while we wait,
+	// and so it can be handed to the next instance if this one is upgraded meanwhile
+	ctx, untrack := o.trackOrchestration(ctx, correlationID, intent, selectedAgent.ID, routingKey, eventPayload)
+	defer untrack()
+
+	// Track the task so agents' acks and nacks reach us and its state can be queried.
+	// Recorded before emitting: agents on an in-process bus acknowledge during Emit.
+	tasks := o.tasksTracker()
+	outcomes := tasks.start(correlationID, requestID, intent)
+	tasks.dispatched(correlationID, selectedAgent.ID, routingKey)
+
+	// Targeted event emission using specific routing key for this agent. Agents drop the request
+	// once we stop waiting for it, rather than act on it after the caller has given up.
+	expiresAt := time.Now().Add(intentResponseTimeout)
+	dispatchedAt := time.Now() // when the current agent got the request, for its SLA
+	if err := o.eventBus.EmitWithTTL(events.EventTypeRequest, "orchestrator", routingKey, eventPayload, time.Until(expiresAt)); err != nil {
+		tasks.finish(correlationID, TaskFailed)
 		return nil, fmt.Errorf("failed to emit intent request to routing key %s for agent %s: %w", routingKey, selectedAgent.ID, err)
 	}
 
-	o.logger.Info("📤 Routed intent '%s' to agent: %s via routing key: %s", intent, selectedAgent.ID, routingKey)
+	o.logger.ForContext(ctx).Info("📤 Routed intent '%s' to agent: %s via routing key: %s", intent, selectedAgent.ID, routingKey)
 
 	// STEP 5: Handle test mode vs real mode
 	if o.testMode {
+		tasks.finish(correlationID, TaskCompleted)
 		// In test mode, simulate successful routing without waiting for real responses
 		o.logger.Info("🧪 Test mode: Simulating successful routing to agent: %s", selectedAgent.ID)
 		return map[string]interface{}{
@@ -104,51 +177,156 @@ func (o *Orchestrator) orchestrateViaIntentBasedAgents(ctx context.Context, inte
 		}, nil
 	}
 
-	// STEP 5: Wait for response with timeout (real mode)
-	select {
-	case response := <-responseChan:
-		o.logger.Info("✅ Received response from agent for intent: %s", intent)
-
-		// Extract meaningful content from the agent response and check for errors
-		var responseContent string
-		var responseStatus string = "completed"
-
-		// First, check if this is an error response
-		if status, ok := response.Payload["status"].(string); ok && status == "error" {
-			responseStatus = "error"
-			if errorMsg, ok := response.Payload["error"].(string); ok {
-				responseContent = fmt.Sprintf("❌ %s", errorMsg)
-			} else {
-				responseContent = fmt.Sprintf("❌ Agent reported an error for %s request", intent)
+	// redeliver sends the request to the next capable agent that has not had it yet
+	candidates := availableAgents[1:]
+	redeliver := func() bool {
+		for len(candidates) > 0 {
+			next := candidates[0]
+			candidates = candidates[1:]
+			nextKey, err := o.discoverRoutingKeyForIntent(ctx, intent, next.ID)
+			if err != nil {
+				o.logger.Warn("⚠️ Cannot redeliver intent %s to agent %s: %v", intent, next.ID, err)
+				continue
+			}
+			payload := make(map[string]interface{}, len(eventPayload))
+			for k, v := range eventPayload {
+				payload[k] = v
+			}
+			payload[agentframework.TargetAgentKey] = next.ID
+
+			selectedAgent, routingKey = next, nextKey
+			o.retargetOrchestration(ctx, correlationID, next.ID, nextKey, payload)
+			tasks.dispatched(correlationID, next.ID, nextKey)
+			if err := o.eventBus.EmitWithTTL(events.EventTypeRequest, "orchestrator", nextKey, payload, time.Until(expiresAt)); err != nil {
+				tasks.resolved(correlationID, "failed", err.Error())
+				continue
+			}
+			dispatchedAt = time.Now()
+			o.logger.ForContext(ctx).Info("🔁 Redelivered intent '%s' to agent: %s via routing key: %s", intent, next.ID, nextKey)
+			return true
+		}
+		return false
+	}
+
+	// STEP 5: Wait for response with timeout (real mode). An agent that does not acknowledge
+	// the request in time, or rejects it, loses it to the next capable agent.
+	deadline := time.After(time.Until(expiresAt))
+	ackDeadline := time.After(o.currentAckTimeout())
+	for {
+		select {
+		case response := <-responseChan:
+			o.logger.Info("✅ Received response from agent for intent: %s", intent)
+			tasks.completed(correlationID, response.Source)
+			if response.Source == selectedAgent.ID {
+				o.observeLatency(ctx, correlationID, selectedAgent.ID, intent, time.Since(dispatchedAt))
 			}
-		} else if decision, ok := response.Payload["decision"].(string); ok {
-			if reasoning, ok := response.Payload["reasoning"].(string); ok {
-				responseContent = fmt.Sprintf("Decision: %s. Reasoning: %s", decision, reasoning)
-			} else {
-				responseContent = fmt.Sprintf("Decision: %s", decision)
+			return o.intentResponse(intent, response), nil
+		case outcome := <-outcomes:
+			if outcome.agent != selectedAgent.ID {
+				continue // a late answer from an agent the task was already taken from
 			}
-		} else if message, ok := response.Payload["message"].(string); ok {
-			responseContent = message
+			if outcome.acked {
+				tasks.resolved(correlationID, "acked", "")
+				ackDeadline = nil
+				continue
+			}
+			o.logger.Warn("↩️ Agent %s rejected intent %s: %s", outcome.agent, intent, outcome.reason)
+			tasks.resolved(correlationID, "nacked", outcome.reason)
+			if !redeliver() {
+				tasks.finish(correlationID, TaskFailed)
+				return map[string]interface{}{
+					"status":         "error",
+					"intent":         intent,
+					"selected_agent": selectedAgent.ID,
+					"correlation_id": correlationID,
+					"message":        fmt.Sprintf("❌ No agent accepted the %s request: %s", intent, outcome.reason),
+				}, nil
+			}
+			ackDeadline = time.After(o.currentAckTimeout())
+		case <-ackDeadline:
+			o.logger.Warn("⏰ Agent %s did not acknowledge intent %s in time", selectedAgent.ID, intent)
+			tasks.resolved(correlationID, "ack_timeout", "")
+			ackDeadline = nil
+			// With nobody left to try, the silent agent may still answer before the deadline
+			if redeliver() {
+				ackDeadline = time.After(o.currentAckTimeout())
+			}
+		case <-ctx.Done():
+			tasks.finish(correlationID, TaskCancelled)
+			o.logger.Info("🛑 Stopped waiting for intent %s: %v", intent, ctx.Err())
+			return map[string]interface{}{
+				"status":         "cancelled",
+				"intent":         intent,
+				"selected_agent": selectedAgent.ID,
+				"correlation_id": correlationID,
+				"message":        fmt.Sprintf("🛑 The %s request to %s was cancelled.", intent, selectedAgent.ID),
+			}, nil
+		case <-deadline:
+			tasks.finish(correlationID, TaskTimedOut)
+			o.observeLatency(ctx, correlationID, selectedAgent.ID, intent, time.Since(dispatchedAt))
+			o.logger.Warn("⏰ Timeout waiting for response from agent for intent: %s", intent)
+			return map[string]interface{}{
+				"status":         "timeout",
+				"intent":         intent,
+				"selected_agent": selectedAgent.ID,
+				"correlation_id": correlationID,
+				"message":        fmt.Sprintf("Intent '%s' sent to agent %s but no response received within timeout", intent, selectedAgent.ID),
+			}, nil
+		}
+	}
+}
+
+// addCaller adds who the request is for to an agent request payload
+func addCaller(ctx context.Context, payload map[string]interface{}) {
+	// Agents check the caller's role against guardrails before executing anything
+	if role := guardrails.RoleFromContext(ctx); role != "" {
+		payload["caller_role"] = role
+	}
+	// Agents bind conversation state (such as proposed plans) to the conversation and tenant
+	if evalCtx := features.EvaluationContextFrom(ctx); evalCtx.ConversationID != "" || evalCtx.Tenant != "" {
+		payload["conversation_id"] = evalCtx.ConversationID
+		payload["tenant"] = evalCtx.Tenant
+	}
+	if userID := logging.UserIDFromContext(ctx); userID != "" {
+		payload["user_id"] = userID
+	}
+}
+
+// intentResponse turns an agent's response into the orchestration result
+func (o *Orchestrator) intentResponse(intent string, response *events.Event) map[string]interface{} {
+	// Extract meaningful content from the agent response and check for errors
+	var responseContent string
+	var responseStatus string = "completed"
+
+	// First, check if this is an error response
+	if clarification, ok := agentframework.ClarificationFrom(response); ok {
+		responseStatus = agentframework.ClarificationStatus
+		responseContent = clarification.Question
+	} else if status, ok := response.Payload["status"].(string); ok && status == "error" {
+		responseStatus = "error"
+		if errorMsg, ok := response.Payload["error"].(string); ok {
+			responseContent = fmt.Sprintf("❌ %s", errorMsg)
 		} else {
-			responseContent = fmt.Sprintf("✅ Agent completed the %s request successfully", intent)
+			responseContent = fmt.Sprintf("❌ Agent reported an error for %s request", intent)
 		}
+	} else if decision, ok := response.Payload["decision"].(string); ok {
+		if reasoning, ok := response.Payload["reasoning"].(string); ok {
+			responseContent = fmt.Sprintf("Decision: %s. Reasoning: %s", decision, reasoning)
+		} else {
+			responseContent = fmt.Sprintf("Decision: %s", decision)
+		}
+	} else if message, ok := response.Payload["message"].(string); ok {
+		responseContent = message
+	} else {
+		responseContent = fmt.Sprintf("✅ Agent completed the %s request successfully", intent)
+	}
 
-		return map[string]interface{}{
-			"status":           responseStatus,
-			"intent":           intent,
-			"selected_agent":   response.Source,
-			"response_content": responseContent,
-			"agent_response":   response.Payload,
-		}, nil
-	case <-time.After(30 * time.Second): // 30 second timeout for AI operations
-		o.logger.Warn("⏰ Timeout waiting for response from agent for intent: %s", intent)
-		return map[string]interface{}{
-			"status":         "timeout",
-			"intent":         intent,
-			"selected_agent": selectedAgent.ID,
-			"correlation_id": correlationID,
-			"message":        fmt.Sprintf("Intent '%s' sent to agent %s but no response received within timeout", intent, selectedAgent.ID),
-		}, nil
+	return map[string]interface{}{
+		"status":           responseStatus,
+		"intent":           intent,
+		"selected_agent":   response.Source,
+		"response_content": responseContent,
+		"agent_response":   response.Payload,
 	}
 }
 
@@ -0,0 +1,170 @@
+package orchestrator
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+	"github.com/krzachariassen/ZTDP/internal/events"
+)
+
+// DefaultSLABreachThreshold is how many consecutive slow responses flag an agent when no
+// threshold is set
+const DefaultSLABreachThreshold = 3
+
+// SLABreachSubject is the notify event alerting operators that an agent was flagged for
+// breaching a capability's SLA
+const SLABreachSubject = "agent_sla_breached"
+
+// slaMonitor counts consecutive SLA breaches by agent and capability
+type slaMonitor struct {
+	mu        sync.Mutex
+	threshold int
+	streaks   map[string]int // agent ID + "/" + capability -> consecutive breaches
+}
+
+// SetSLABreachThreshold sets how many consecutive responses slower than the capability's SLA
+// flag the agent in the registry. Flagged agents are tried after the others until they answer
+// within the SLA again.
+func (o *Orchestrator) SetSLABreachThreshold(threshold int) {
+	if threshold <= 0 {
+		threshold = DefaultSLABreachThreshold
+	}
+	o.sla.mu.Lock()
+	defer o.sla.mu.Unlock()
+	o.sla.threshold = threshold
+}
+
+// observeLatency compares how long the agent took to answer the request with the SLA its
+// capability for the intent declares, flagging it after repeated breaches and clearing the
+// flag once it is back within the SLA
+func (o *Orchestrator) observeLatency(ctx context.Context, correlationID, agentID, intent string, latency time.Duration) {
+	tracker, ok := o.agentRegistry.(agentRegistry.SLATracker)
+	if !ok {
+		return
+	}
+	capability, found := o.agentCapability(ctx, agentID, intent)
+	if !found {
+		return
+	}
+	sla, err := capability.ResponseSLA()
+	if err != nil || sla == 0 {
+		return
+	}
+
+	key := agentID + "/" + capability.Name
+	o.sla.mu.Lock()
+	threshold := o.sla.threshold
+	if threshold <= 0 {
+		threshold = DefaultSLABreachThreshold
+	}
+	if o.sla.streaks == nil {
+		o.sla.streaks = map[string]int{}
+	}
+	previous := o.sla.streaks[key]
+	if latency <= sla {
+		delete(o.sla.streaks, key)
+	} else {
+		o.sla.streaks[key] = previous + 1
+	}
+	breaches := o.sla.streaks[key]
+	o.sla.mu.Unlock()
+
+	logger := o.logger.ForContext(ctx)
+	if latency <= sla {
+		if previous >= threshold {
+			if err := tracker.ClearSLABreach(ctx, agentID, capability.Name); err != nil {
+				logger.Warn("⚠️ Failed to clear SLA breach of agent %s: %v", agentID, err)
+				return
+			}
+			logger.Info("✅ Agent %s is back within the %v SLA of %s (%v)", agentID, sla, capability.Name, latency)
+		}
+		return
+	}
+
+	logger.Warn("🐢 Agent %s answered %s in %v, over the %v SLA of %s (correlation %s)", agentID, intent, latency, sla, capability.Name, correlationID)
+	if breaches < threshold {
+		return
+	}
+	breach := agentRegistry.SLABreach{
+		AgentID:    agentID,
+		Capability: capability.Name,
+		SLAMS:      sla.Milliseconds(),
+		ObservedMS: latency.Milliseconds(),
+		Breaches:   breaches,
+		FlaggedAt:  time.Now().UTC(),
+	}
+	if err := tracker.FlagSLABreach(ctx, breach); err != nil {
+		logger.Warn("⚠️ Failed to flag SLA breach of agent %s: %v", agentID, err)
+		return
+	}
+	if breaches > threshold {
+		return // already flagged and alerted; the flag now carries the latest latency
+	}
+	logger.Error("🚨 Agent %s breached the %v SLA of %s %d times in a row; it is tried after other agents until it recovers", agentID, sla, capability.Name, breaches)
+	if err := o.eventBus.Emit(events.EventTypeNotify, "orchestrator", SLABreachSubject, map[string]interface{}{
+		"agent_id":       agentID,
+		"capability":     capability.Name,
+		"intent":         intent,
+		"correlation_id": correlationID,
+		"sla_ms":         breach.SLAMS,
+		"observed_ms":    breach.ObservedMS,
+		"breaches":       breaches,
+	}); err != nil {
+		logger.Warn("⚠️ Failed to emit SLA breach alert for agent %s: %v", agentID, err)
+	}
+}
+
+// deprioritizeBreaching moves agents flagged for breaching the SLA of the capability serving
+// the intent behind the others, keeping the order within each group
+func (o *Orchestrator) deprioritizeBreaching(ctx context.Context, intent string, agents []agentRegistry.AgentStatus) []agentRegistry.AgentStatus {
+	tracker, ok := o.agentRegistry.(agentRegistry.SLATracker)
+	if !ok || len(agents) < 2 {
+		return agents
+	}
+	flags, err := tracker.SLABreaches(ctx)
+	if err != nil || len(flags) == 0 {
+		return agents
+	}
+	flagged := map[string]bool{}
+	for _, flag := range flags {
+		flagged[flag.AgentID+"/"+flag.Capability] = true
+	}
+
+	ordered := make([]agentRegistry.AgentStatus, 0, len(agents))
+	var breaching []agentRegistry.AgentStatus
+	for _, agent := range agents {
+		if capability, found := o.agentCapability(ctx, agent.ID, intent); found && flagged[agent.ID+"/"+capability.Name] {
+			breaching = append(breaching, agent)
+			continue
+		}
+		ordered = append(ordered, agent)
+	}
+	if len(breaching) > 0 {
+		o.logger.ForContext(ctx).Info("🐢 Trying %d agents breaching their SLA for %s last", len(breaching), intent)
+	}
+	return append(ordered, breaching...)
+}
+
+// agentCapability returns the agent's current capability offering the intent
+func (o *Orchestrator) agentCapability(ctx context.Context, agentID, intent string) (agentRegistry.AgentCapability, bool) {
+	agent, err := o.agentRegistry.FindAgentByID(ctx, agentID)
+	if err != nil {
+		return agentRegistry.AgentCapability{}, false
+	}
+	capabilities := agent.GetCapabilities()
+	if updater, ok := o.agentRegistry.(agentRegistry.CapabilityUpdater); ok {
+		if history, err := updater.CapabilityHistory(ctx, agentID); err == nil && len(history) > 0 {
+			capabilities = history[len(history)-1].Capabilities
+		}
+	}
+	for _, capability := range capabilities {
+		for _, supported := range capability.Intents {
+			if o.intentMatches(intent, supported) {
+				return capability, true
+			}
+		}
+	}
+	return agentRegistry.AgentCapability{}, false
+}
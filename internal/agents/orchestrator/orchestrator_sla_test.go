@@ -0,0 +1,80 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+	"github.com/krzachariassen/ZTDP/internal/ai/aitest"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
+)
+
+// buildSLADeployAgent builds a deployer promising to answer within 20ms that takes delay
+func buildSLADeployAgent(t *testing.T, registry agentRegistry.AgentRegistry, bus *events.EventBus, id string, delay time.Duration, handled *[]string) {
+	t.Helper()
+	capability := deployCapability
+	capability.SLA = "20ms"
+	var agent agentRegistry.AgentInterface
+	agent, err := agentframework.NewAgent(id).
+		WithCapabilities([]agentRegistry.AgentCapability{capability}).
+		WithEventHandler(func(ctx context.Context, event *events.Event) (*events.Event, error) {
+			*handled = append(*handled, id)
+			time.Sleep(delay)
+			return agent.(*agentframework.BaseAgent).CreateResponse("deployed", map[string]interface{}{"message": "deployed by " + id}, event), nil
+		}).
+		Build(agentframework.AgentDependencies{Registry: registry, EventBus: bus})
+	if err != nil {
+		t.Fatalf("Failed to build %s: %v", id, err)
+	}
+}
+
+// TestOrchestratorFlagsAgentsBreachingSLA tests that an agent repeatedly answering slower than
+// its capability's SLA is flagged, alerted on and tried after the other agents until it recovers
+func TestOrchestratorFlagsAgentsBreachingSLA(t *testing.T) {
+	registry := orderedRegistry{agentRegistry.NewInMemoryAgentRegistry().(*agentRegistry.InMemoryAgentRegistry)}
+	bus := events.NewEventBus(nil, false)
+	handled := &[]string{}
+	buildSLADeployAgent(t, registry, bus, "a-slow", 60*time.Millisecond, handled)
+	buildSLADeployAgent(t, registry, bus, "b-fast", 0, handled)
+
+	var alerts []events.Event
+	bus.Subscribe(events.EventTypeNotify, func(event events.Event) error {
+		if event.Subject == SLABreachSubject {
+			alerts = append(alerts, event)
+		}
+		return nil
+	})
+
+	o := NewOrchestrator(&aitest.Provider{}, graph.NewGlobalGraph(graph.NewMemoryGraph()), bus, registry)
+	o.SetSLABreachThreshold(2)
+	for i := 0; i < 3; i++ {
+		dispatchDeploy(t, o, fmt.Sprintf("corr-sla-%d", i))
+	}
+
+	if got := fmt.Sprint(*handled); got != "[a-slow a-slow b-fast]" {
+		t.Errorf("Expected a-slow to lose requests once flagged, got: %s", got)
+	}
+	breaches, err := registry.SLABreaches(context.Background())
+	if err != nil || len(breaches) != 1 {
+		t.Fatalf("Expected one SLA breach, got %v (%v)", breaches, err)
+	}
+	if breaches[0].AgentID != "a-slow" || breaches[0].Capability != "deployment" || breaches[0].SLAMS != 20 || breaches[0].Breaches != 2 {
+		t.Errorf("Unexpected breach: %+v", breaches[0])
+	}
+	if len(alerts) != 1 || alerts[0].Payload["agent_id"] != "a-slow" {
+		t.Errorf("Expected one alert for a-slow, got: %v", alerts)
+	}
+
+	// Answering within the SLA again clears the flag
+	o.observeLatency(context.Background(), "corr-sla-recovered", "a-slow", "deploy application", time.Millisecond)
+	if breaches, _ := registry.SLABreaches(context.Background()); len(breaches) != 0 {
+		t.Errorf("Expected the flag to be cleared, got: %v", breaches)
+	}
+	if agents := o.deprioritizeBreaching(context.Background(), "deploy application", []agentRegistry.AgentStatus{{ID: "a-slow"}, {ID: "b-fast"}}); agents[0].ID != "a-slow" {
+		t.Errorf("Expected a-slow to be tried first again, got: %v", agents)
+	}
+}
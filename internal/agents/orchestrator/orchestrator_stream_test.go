@@ -0,0 +1,61 @@
+package orchestrator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/ai"
+	"github.com/krzachariassen/ZTDP/internal/ai/aitest"
+)
+
+// streamingProvider streams each word of the conversation answer as its own chunk
+type streamingProvider struct {
+	*aitest.Provider
+}
+
+func (p *streamingProvider) CallAIStream(ctx context.Context, systemPrompt, userPrompt string) (<-chan ai.StreamChunk, error) {
+	response, err := p.CallAI(ctx, systemPrompt, userPrompt)
+	if err != nil {
+		return nil, err
+	}
+	chunks := make(chan ai.StreamChunk, 8)
+	for _, word := range strings.SplitAfter(response, " ") {
+		chunks <- ai.StreamChunk{Content: word}
+	}
+	close(chunks)
+	return chunks, nil
+}
+
+// TestOrchestratorChatStream tests that conversation answers are streamed incrementally
+func TestOrchestratorChatStream(t *testing.T) {
+	provider := &streamingProvider{aitest.ByPrompt(map[string]string{
+		"agent router":    "general_conversation",
+		"prompt engineer": "You are the platform chat assistant.",
+		"chat assistant":  "I can deploy your applications",
+	})}
+	o := createQueryTestOrchestrator(t, provider)
+
+	var chunks []string
+	response, err := o.ChatStream(context.Background(), "what can you do?", func(chunk string) { chunks = append(chunks, chunk) })
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(chunks) != 5 || strings.Join(chunks, "") != response.Message {
+		t.Errorf("Expected the answer in 5 chunks, got %q for %q", chunks, response.Message)
+	}
+}
+
+// TestOrchestratorChatStreamWholeResponse tests that non-streamed responses arrive as one chunk
+func TestOrchestratorChatStreamWholeResponse(t *testing.T) {
+	o := createFallbackTestOrchestrator(t)
+
+	var chunks []string
+	response, err := o.ChatStream(context.Background(), "list applications", func(chunk string) { chunks = append(chunks, chunk) })
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(chunks) != 1 || chunks[0] != response.Message {
+		t.Errorf("Expected the whole response as one chunk, got %q", chunks)
+	}
+}
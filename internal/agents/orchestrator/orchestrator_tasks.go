@@ -0,0 +1,225 @@
+package orchestrator
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
+)
+
+// DefaultAckTimeout is how long a dispatched task may go unacknowledged before it is
+// redelivered to another capable agent
+const DefaultAckTimeout = 5 * time.Second
+
+// maxFinishedTasks bounds how many finished tasks stay queryable
+const maxFinishedTasks = 1000
+
+// TaskState is where a dispatched task is in its lifecycle
+type TaskState string
+
+const (
+	TaskDispatched TaskState = "dispatched" // sent, waiting for an agent to acknowledge it
+	TaskAcked      TaskState = "acked"      // an agent is handling it
+	TaskCompleted  TaskState = "completed"  // an agent responded
+	TaskFailed     TaskState = "failed"     // every capable agent rejected it or never acknowledged it
+	TaskCancelled  TaskState = "cancelled"
+	TaskTimedOut   TaskState = "timeout" // acknowledged, but no response in time
+)
+
+// TaskAttempt is one delivery of a task to an agent
+type TaskAttempt struct {
+	Agent        string     `json:"agent"`
+	RoutingKey   string     `json:"routing_key"`
+	DispatchedAt time.Time  `json:"dispatched_at"`
+	AckedAt      *time.Time `json:"acked_at,omitempty"`
+	// Outcome is "acked", "nacked" or "ack_timeout"; empty while the agent has not answered
+	Outcome string `json:"outcome,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// Task is a request the orchestrator dispatched to agents, queryable by correlation ID
+type Task struct {
+	CorrelationID string        `json:"correlation_id"`
+	RequestID     string        `json:"request_id"`
+	Intent        string        `json:"intent"`
+	State         TaskState     `json:"state"`
+	Agent         string        `json:"agent"` // the agent currently responsible
+	Attempts      []TaskAttempt `json:"attempts"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+}
+
+// taskOutcome is an ack or nack received for a task
+type taskOutcome struct {
+	agent  string
+	acked  bool
+	reason string
+}
+
+// taskTracker keeps dispatched tasks and routes acks and nacks to the orchestration waiting on them
+type taskTracker struct {
+	mu       sync.Mutex
+	tasks    map[string]*Task // by correlation ID
+	outcomes map[string]chan taskOutcome
+	finished []string // correlation IDs of finished tasks, oldest first
+}
+
+func newTaskTracker() *taskTracker {
+	return &taskTracker{tasks: map[string]*Task{}, outcomes: map[string]chan taskOutcome{}}
+}
+
+// start records a new task and returns the channel its acks and nacks arrive on
+func (t *taskTracker) start(correlationID, requestID, intent string) <-chan taskOutcome {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	t.tasks[correlationID] = &Task{CorrelationID: correlationID, RequestID: requestID, Intent: intent,
+		State: TaskDispatched, Attempts: []TaskAttempt{}, CreatedAt: now, UpdatedAt: now}
+	outcomes := make(chan taskOutcome, 8)
+	t.outcomes[correlationID] = outcomes
+	return outcomes
+}
+
+// dispatched records a delivery attempt
+func (t *taskTracker) dispatched(correlationID, agent, routingKey string) {
+	t.update(correlationID, func(task *Task) {
+		task.State = TaskDispatched
+		task.Agent = agent
+		task.Attempts = append(task.Attempts, TaskAttempt{Agent: agent, RoutingKey: routingKey, DispatchedAt: time.Now()})
+	})
+}
+
+// resolved records how the current attempt ended
+func (t *taskTracker) resolved(correlationID, outcome, reason string) {
+	t.update(correlationID, func(task *Task) {
+		if len(task.Attempts) == 0 {
+			return
+		}
+		attempt := &task.Attempts[len(task.Attempts)-1]
+		attempt.Outcome, attempt.Reason = outcome, reason
+		if outcome == "acked" {
+			now := time.Now()
+			attempt.AckedAt = &now
+			task.State = TaskAcked
+		}
+	})
+}
+
+// completed finishes a task an agent responded to. A response implies the agent took the
+// task, so an attempt whose ack is still queued behind the response counts as acknowledged.
+func (t *taskTracker) completed(correlationID, agent string) {
+	t.update(correlationID, func(task *Task) {
+		if n := len(task.Attempts); n > 0 && task.Attempts[n-1].Agent == agent && task.Attempts[n-1].Outcome == "" {
+			now := time.Now()
+			task.Attempts[n-1].Outcome, task.Attempts[n-1].AckedAt = "acked", &now
+		}
+	})
+	t.finish(correlationID, TaskCompleted)
+}
+
+// finish records the final state and stops routing acks to the task
+func (t *taskTracker) finish(correlationID string, state TaskState) {
+	t.update(correlationID, func(task *Task) { task.State = state })
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.outcomes[correlationID]; !ok {
+		return
+	}
+	delete(t.outcomes, correlationID)
+	t.finished = append(t.finished, correlationID)
+	for len(t.finished) > maxFinishedTasks {
+		delete(t.tasks, t.finished[0])
+		t.finished = t.finished[1:]
+	}
+}
+
+func (t *taskTracker) update(correlationID string, change func(task *Task)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if task, ok := t.tasks[correlationID]; ok {
+		change(task)
+		task.UpdatedAt = time.Now()
+	}
+}
+
+// get returns a copy of a task
+func (t *taskTracker) get(correlationID string) (Task, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	task, ok := t.tasks[correlationID]
+	if !ok {
+		return Task{}, false
+	}
+	copied := *task
+	copied.Attempts = append([]TaskAttempt(nil), task.Attempts...)
+	return copied, true
+}
+
+// handleOutcome passes an ack or nack from an agent to the orchestration waiting on the task
+func (t *taskTracker) handleOutcome(event events.Event) error {
+	if event.Subject != agentframework.TaskAckSubject && event.Subject != agentframework.TaskNackSubject {
+		return nil
+	}
+	correlationID, _ := event.Payload["correlation_id"].(string)
+	requestID, _ := event.Payload["request_id"].(string)
+	agent, _ := event.Payload["agent_id"].(string)
+	reason, _ := event.Payload["reason"].(string)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	task, ok := t.tasks[correlationID]
+	outcomes, waiting := t.outcomes[correlationID]
+	if !ok || !waiting || task.RequestID != requestID {
+		return nil
+	}
+	select {
+	case outcomes <- taskOutcome{agent: agent, acked: event.Subject == agentframework.TaskAckSubject, reason: reason}:
+	default:
+	}
+	return nil
+}
+
+// Task returns the state of the task dispatched for a correlation ID
+func (o *Orchestrator) Task(correlationID string) (Task, bool) {
+	return o.tasksTracker().get(correlationID)
+}
+
+// SetAckTimeout sets how long a dispatched task may go unacknowledged before it is redelivered
+func (o *Orchestrator) SetAckTimeout(timeout time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.ackTimeout = timeout
+}
+
+func (o *Orchestrator) currentAckTimeout() time.Duration {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	if o.ackTimeout <= 0 {
+		return DefaultAckTimeout
+	}
+	return o.ackTimeout
+}
+
+// tasksTracker returns the tracker, subscribing it to acks and nacks on first use
+func (o *Orchestrator) tasksTracker() *taskTracker {
+	o.tasksOnce.Do(func() {
+		o.tasks = newTaskTracker()
+		if o.eventBus != nil {
+			o.eventBus.Subscribe(events.EventTypeNotify, o.tasks.handleOutcome)
+		}
+	})
+	return o.tasks
+}
+
+// retargetOrchestration points the conversation's running orchestration at the agent a task
+// was redelivered to, so status replies and upgrade handoffs follow the request
+func (o *Orchestrator) retargetOrchestration(ctx context.Context, correlationID, agentID, routingKey string, payload map[string]interface{}) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if active, ok := o.active[conversationKey(ctx)]; ok && active.CorrelationID == correlationID {
+		active.Agent, active.routingKey, active.payload = agentID, routingKey, payload
+	}
+}
@@ -0,0 +1,140 @@
+package orchestrator
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+	"github.com/krzachariassen/ZTDP/internal/ai/aitest"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
+)
+
+var deployCapability = agentRegistry.AgentCapability{
+	Name:        "deployment",
+	Intents:     []string{"deploy application"},
+	RoutingKeys: []string{"deployment.request"},
+}
+
+// orderedRegistry lists capable agents by ID so tests know which agent gets a task first
+type orderedRegistry struct {
+	*agentRegistry.InMemoryAgentRegistry
+}
+
+func (r orderedRegistry) FindAgentsByCapability(ctx context.Context, capability string) ([]agentRegistry.AgentStatus, error) {
+	agents, err := r.InMemoryAgentRegistry.FindAgentsByCapability(ctx, capability)
+	sort.Slice(agents, func(i, j int) bool { return agents[i].ID < agents[j].ID })
+	return agents, err
+}
+
+// unresponsiveAgent is registered but never receives anything, like a crashed remote agent
+type unresponsiveAgent struct{ id string }
+
+func (a unresponsiveAgent) GetID() string { return a.id }
+func (a unresponsiveAgent) GetStatus() agentRegistry.AgentStatus {
+	return agentRegistry.AgentStatus{ID: a.id, Status: "running"}
+}
+func (a unresponsiveAgent) GetCapabilities() []agentRegistry.AgentCapability {
+	return []agentRegistry.AgentCapability{deployCapability}
+}
+func (a unresponsiveAgent) Start(ctx context.Context) error    { return nil }
+func (a unresponsiveAgent) Stop(ctx context.Context) error     { return nil }
+func (a unresponsiveAgent) Health() agentRegistry.HealthStatus { return agentRegistry.HealthStatus{} }
+
+func buildDeployAgent(t *testing.T, registry agentRegistry.AgentRegistry, bus *events.EventBus, id string) agentRegistry.AgentInterface {
+	t.Helper()
+	var agent agentRegistry.AgentInterface
+	agent, err := agentframework.NewAgent(id).
+		WithCapabilities([]agentRegistry.AgentCapability{deployCapability}).
+		WithEventHandler(func(ctx context.Context, event *events.Event) (*events.Event, error) {
+			return agent.(*agentframework.BaseAgent).CreateResponse("deployed", map[string]interface{}{"message": "deployed by " + id}, event), nil
+		}).
+		Build(agentframework.AgentDependencies{Registry: registry, EventBus: bus})
+	if err != nil {
+		t.Fatalf("Failed to build %s: %v", id, err)
+	}
+	return agent
+}
+
+// buildStoppingDeployAgent builds an agent that is shutting down and rejects new tasks
+func buildStoppingDeployAgent(t *testing.T, registry agentRegistry.AgentRegistry, bus *events.EventBus, id string) {
+	t.Helper()
+	if err := buildDeployAgent(t, registry, bus, id).Stop(context.Background()); err != nil {
+		t.Fatalf("Failed to stop %s: %v", id, err)
+	}
+}
+
+func dispatchDeploy(t *testing.T, o *Orchestrator, correlationID string) (map[string]interface{}, Task) {
+	t.Helper()
+	ctx := logging.WithCorrelationID(context.Background(), correlationID)
+	result, err := o.orchestrateViaIntentBasedAgents(ctx, "deploy application", map[string]interface{}{"user_message": "deploy checkout"})
+	if err != nil {
+		t.Fatalf("Orchestration failed: %v", err)
+	}
+	task, ok := o.Task(correlationID)
+	if !ok {
+		t.Fatalf("Expected task %s to be queryable", correlationID)
+	}
+	return result.(map[string]interface{}), task
+}
+
+func TestOrchestratorRedeliversUnacknowledgedTask(t *testing.T) {
+	registry := orderedRegistry{agentRegistry.NewInMemoryAgentRegistry().(*agentRegistry.InMemoryAgentRegistry)}
+	bus := events.NewEventBus(nil, false)
+	if err := registry.RegisterAgent(context.Background(), unresponsiveAgent{id: "a-crashed"}); err != nil {
+		t.Fatalf("Failed to register agent: %v", err)
+	}
+	buildDeployAgent(t, registry, bus, "b-deployer")
+
+	o := NewOrchestrator(&aitest.Provider{}, graph.NewGlobalGraph(graph.NewMemoryGraph()), bus, registry)
+	o.SetAckTimeout(50 * time.Millisecond)
+
+	result, task := dispatchDeploy(t, o, "corr-redeliver")
+	if result["status"] != "completed" || result["selected_agent"] != "b-deployer" {
+		t.Fatalf("Expected b-deployer to complete the task, got %+v", result)
+	}
+	if task.State != TaskCompleted || task.Agent != "b-deployer" || len(task.Attempts) != 2 {
+		t.Fatalf("Unexpected task state: %+v", task)
+	}
+	if task.Attempts[0].Agent != "a-crashed" || task.Attempts[0].Outcome != "ack_timeout" {
+		t.Errorf("Expected the first attempt to time out waiting for an ack, got %+v", task.Attempts[0])
+	}
+	if task.Attempts[1].Outcome != "acked" || task.Attempts[1].AckedAt == nil {
+		t.Errorf("Expected the second attempt to be acknowledged, got %+v", task.Attempts[1])
+	}
+}
+
+func TestOrchestratorRedeliversRejectedTask(t *testing.T) {
+	registry := orderedRegistry{agentRegistry.NewInMemoryAgentRegistry().(*agentRegistry.InMemoryAgentRegistry)}
+	bus := events.NewEventBus(nil, false)
+	buildStoppingDeployAgent(t, registry, bus, "a-stopping")
+	buildDeployAgent(t, registry, bus, "b-deployer")
+
+	o := NewOrchestrator(&aitest.Provider{}, graph.NewGlobalGraph(graph.NewMemoryGraph()), bus, registry)
+	result, task := dispatchDeploy(t, o, "corr-nack")
+	if result["selected_agent"] != "b-deployer" {
+		t.Fatalf("Expected the rejected task to move to b-deployer, got %+v", result)
+	}
+	if len(task.Attempts) != 2 || task.Attempts[0].Outcome != "nacked" || task.Attempts[0].Reason != "agent is stopping" {
+		t.Errorf("Expected the first attempt to be rejected with its reason, got %+v", task.Attempts)
+	}
+}
+
+func TestOrchestratorFailsTaskEveryAgentRejects(t *testing.T) {
+	registry := agentRegistry.NewInMemoryAgentRegistry()
+	bus := events.NewEventBus(nil, false)
+	buildStoppingDeployAgent(t, registry, bus, "a-stopping")
+
+	o := NewOrchestrator(&aitest.Provider{}, graph.NewGlobalGraph(graph.NewMemoryGraph()), bus, registry)
+	result, task := dispatchDeploy(t, o, "corr-failed")
+	if result["status"] != "error" || task.State != TaskFailed {
+		t.Errorf("Expected the task to fail, got result %+v and task %+v", result, task)
+	}
+	if _, ok := o.Task("corr-unknown"); ok {
+		t.Error("Expected no task for an unknown correlation ID")
+	}
+}
@@ -0,0 +1,83 @@
+// Package aitest provides a scripted AI provider for tests, so agents and services can be
+// exercised without a model or a recorded cassette.
+package aitest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/krzachariassen/ZTDP/internal/ai"
+)
+
+// ErrUnscripted is returned for a call the script has no response for
+var ErrUnscripted = errors.New("no scripted AI response for prompt")
+
+// Provider is an ai.AIProvider answering from a script. A call is answered with the response
+// keyed by the longest key found in the system prompt, else the next queued response, else the
+// response given to Respond. The zero value answers nothing.
+type Provider struct {
+	mu       sync.Mutex
+	byPrompt map[string]string
+	queue    []string
+	fallback *string
+	calls    []string
+}
+
+// Respond returns a provider answering every call with response
+func Respond(response string) *Provider {
+	return &Provider{fallback: &response}
+}
+
+// Sequence returns a provider answering calls with responses in order, one each
+func Sequence(responses ...string) *Provider {
+	return &Provider{queue: responses}
+}
+
+// ByPrompt returns a provider answering each call with the response whose key appears in the
+// system prompt, e.g. "agent router" for intent detection
+func ByPrompt(responses map[string]string) *Provider {
+	return &Provider{byPrompt: responses}
+}
+
+// CallAI answers the call from the script and records its user prompt
+func (p *Provider) CallAI(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls = append(p.calls, userPrompt)
+
+	match := ""
+	for key := range p.byPrompt {
+		if strings.Contains(systemPrompt, key) && (len(key) > len(match) || (len(key) == len(match) && key < match)) {
+			match = key
+		}
+	}
+	switch {
+	case match != "":
+		return p.byPrompt[match], nil
+	case len(p.queue) > 0:
+		response := p.queue[0]
+		p.queue = p.queue[1:]
+		return response, nil
+	case p.fallback != nil:
+		return *p.fallback, nil
+	}
+	return "", fmt.Errorf("%w: %.80q", ErrUnscripted, systemPrompt)
+}
+
+// Calls returns the user prompts of the calls so far, oldest first
+func (p *Provider) Calls() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.calls...)
+}
+
+// GetProviderInfo describes the provider
+func (p *Provider) GetProviderInfo() *ai.ProviderInfo {
+	return &ai.ProviderInfo{Name: "scripted"}
+}
+
+// Close is a no-op
+func (p *Provider) Close() error { return nil }
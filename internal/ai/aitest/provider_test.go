@@ -0,0 +1,39 @@
+package aitest
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestProviderAnswersFromTheScript(t *testing.T) {
+	ctx := context.Background()
+
+	keyed := ByPrompt(map[string]string{"router": "general", "agent router": "deploy application"})
+	if response, _ := keyed.CallAI(ctx, "You are an intelligent agent router", "deploy checkout"); response != "deploy application" {
+		t.Errorf("Expected the longest matching key to answer, got %q", response)
+	}
+	if _, err := keyed.CallAI(ctx, "You are a policy evaluator", "check"); !errors.Is(err, ErrUnscripted) {
+		t.Errorf("Expected ErrUnscripted for an unknown prompt, got %v", err)
+	}
+	if calls := keyed.Calls(); len(calls) != 2 || calls[0] != "deploy checkout" {
+		t.Errorf("Expected both user prompts recorded, got %v", calls)
+	}
+
+	sequence := Sequence("first", "second")
+	for _, want := range []string{"first", "second"} {
+		if response, _ := sequence.CallAI(ctx, "", ""); response != want {
+			t.Errorf("Expected %q, got %q", want, response)
+		}
+	}
+	if _, err := sequence.CallAI(ctx, "", ""); !errors.Is(err, ErrUnscripted) {
+		t.Errorf("Expected ErrUnscripted once the sequence is used up, got %v", err)
+	}
+
+	always := Respond("ok")
+	for i := 0; i < 2; i++ {
+		if response, _ := always.CallAI(ctx, "", ""); response != "ok" {
+			t.Errorf("Expected every call answered with ok, got %q", response)
+		}
+	}
+}
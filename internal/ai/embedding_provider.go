@@ -0,0 +1,295 @@
+package ai
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// EmbeddingProvider turns texts into vectors for semantic matching. Implementations return one
+// vector per input text, in input order.
+type EmbeddingProvider interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// Embedding provider names
+const (
+	EmbeddingProviderOpenAI = "openai"
+	EmbeddingProviderLocal  = "local"
+)
+
+// EmbeddingConfig selects and configures an embedding provider
+type EmbeddingConfig struct {
+	Provider  string        // openai | local
+	Model     string        // model name sent to the provider, e.g. text-embedding-3-small
+	BaseURL   string        // OpenAI API base URL, or the embed endpoint of a local server
+	APIKey    string        // required for openai
+	Timeout   time.Duration // per request
+	BatchSize int           // maximum texts per request; 0 sends everything at once
+	CacheSize int           // number of embeddings kept in memory; 0 disables caching
+}
+
+// NewEmbeddingProvider builds the provider named in config, wrapped in a cache when
+// CacheSize is set
+func NewEmbeddingProvider(config EmbeddingConfig) (EmbeddingProvider, error) {
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	client := &http.Client{Timeout: timeout}
+
+	var provider EmbeddingProvider
+	switch config.Provider {
+	case EmbeddingProviderOpenAI:
+		if config.APIKey == "" {
+			return nil, fmt.Errorf("OpenAI API key is required for embeddings")
+		}
+		baseURL := config.BaseURL
+		if baseURL == "" {
+			baseURL = "https://api.openai.com/v1"
+		}
+		provider = &OpenAIEmbeddingProvider{
+			model:     config.Model,
+			baseURL:   baseURL,
+			apiKey:    config.APIKey,
+			batchSize: config.BatchSize,
+			client:    client,
+		}
+	case EmbeddingProviderLocal:
+		if config.BaseURL == "" {
+			return nil, fmt.Errorf("a URL is required for the local embedding provider")
+		}
+		provider = &LocalEmbeddingProvider{
+			url:       config.BaseURL,
+			batchSize: config.BatchSize,
+			client:    client,
+		}
+	default:
+		return nil, fmt.Errorf("unsupported embedding provider %q (expected openai or local)", config.Provider)
+	}
+
+	if config.CacheSize > 0 {
+		provider = NewCachedEmbeddingProvider(provider, config.CacheSize)
+	}
+	logging.GetLogger().ForComponent("ai-embeddings").Info("✅ Embedding provider %s initialized", config.Provider)
+	return provider, nil
+}
+
+// OpenAIEmbeddingProvider calls the OpenAI embeddings API
+type OpenAIEmbeddingProvider struct {
+	model     string
+	baseURL   string
+	apiKey    string
+	batchSize int
+	client    *http.Client
+}
+
+// Embed implements EmbeddingProvider
+func (p *OpenAIEmbeddingProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return embedInBatches(ctx, texts, p.batchSize, p.embedBatch)
+}
+
+func (p *OpenAIEmbeddingProvider) embedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	var response struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	headers := map[string]string{"Authorization": "Bearer " + p.apiKey}
+	body := map[string]interface{}{"model": p.model, "input": texts}
+	if err := postJSON(ctx, p.client, p.baseURL+"/embeddings", headers, body, &response); err != nil {
+		return nil, fmt.Errorf("OpenAI embeddings request failed: %w", err)
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, item := range response.Data {
+		if item.Index < 0 || item.Index >= len(texts) {
+			return nil, fmt.Errorf("OpenAI embeddings returned unexpected index %d", item.Index)
+		}
+		vectors[item.Index] = item.Embedding
+	}
+	for i, vector := range vectors {
+		if vector == nil {
+			return nil, fmt.Errorf("OpenAI embeddings returned no vector for input %d", i)
+		}
+	}
+	return vectors, nil
+}
+
+// LocalEmbeddingProvider calls a self-hosted embedding server, such as sentence-transformers
+// behind Hugging Face text-embeddings-inference. It POSTs {"inputs": [...]} to the URL and
+// expects a JSON array of vectors back.
+type LocalEmbeddingProvider struct {
+	url       string
+	batchSize int
+	client    *http.Client
+}
+
+// Embed implements EmbeddingProvider
+func (p *LocalEmbeddingProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return embedInBatches(ctx, texts, p.batchSize, p.embedBatch)
+}
+
+func (p *LocalEmbeddingProvider) embedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	var vectors [][]float32
+	if err := postJSON(ctx, p.client, p.url, nil, map[string]interface{}{"inputs": texts}, &vectors); err != nil {
+		return nil, fmt.Errorf("local embeddings request failed: %w", err)
+	}
+	if len(vectors) != len(texts) {
+		return nil, fmt.Errorf("local embeddings returned %d vectors for %d inputs", len(vectors), len(texts))
+	}
+	return vectors, nil
+}
+
+// embedInBatches splits texts into requests of at most size texts and joins the results
+func embedInBatches(ctx context.Context, texts []string, size int, embed func(context.Context, []string) ([][]float32, error)) ([][]float32, error) {
+	if len(texts) == 0 {
+		return [][]float32{}, nil
+	}
+	if size <= 0 {
+		size = len(texts)
+	}
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += size {
+		end := start + size
+		if end > len(texts) {
+			end = len(texts)
+		}
+		batch, err := embed(ctx, texts[start:end])
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, batch...)
+	}
+	return vectors, nil
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, body, out interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d: %s", resp.StatusCode, string(respBody))
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// CachedEmbeddingProvider keeps the most recently used embeddings in memory and only sends
+// texts it has not seen to the wrapped provider
+type CachedEmbeddingProvider struct {
+	provider EmbeddingProvider
+	capacity int
+
+	mu      sync.Mutex
+	order   *list.List // front is most recently used; values are texts
+	entries map[string]*list.Element
+	vectors map[string][]float32
+}
+
+// NewCachedEmbeddingProvider wraps provider with an LRU cache holding up to capacity embeddings
+func NewCachedEmbeddingProvider(provider EmbeddingProvider, capacity int) *CachedEmbeddingProvider {
+	return &CachedEmbeddingProvider{
+		provider: provider,
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+		vectors:  make(map[string][]float32),
+	}
+}
+
+// Embed implements EmbeddingProvider
+func (c *CachedEmbeddingProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	var missing []string
+	missingAt := map[string][]int{}
+
+	c.mu.Lock()
+	for i, text := range texts {
+		if element, ok := c.entries[text]; ok {
+			c.order.MoveToFront(element)
+			vectors[i] = c.vectors[text]
+			continue
+		}
+		if _, queued := missingAt[text]; !queued {
+			missing = append(missing, text)
+		}
+		missingAt[text] = append(missingAt[text], i)
+	}
+	c.mu.Unlock()
+
+	if len(missing) == 0 {
+		return vectors, nil
+	}
+	fetched, err := c.provider.Embed(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	if len(fetched) != len(missing) {
+		return nil, fmt.Errorf("embedding provider returned %d vectors for %d inputs", len(fetched), len(missing))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, text := range missing {
+		for _, at := range missingAt[text] {
+			vectors[at] = fetched[i]
+		}
+		c.store(text, fetched[i])
+	}
+	return vectors, nil
+}
+
+// store adds an embedding, evicting the least recently used one when full; c.mu must be held
+func (c *CachedEmbeddingProvider) store(text string, vector []float32) {
+	if element, ok := c.entries[text]; ok {
+		c.order.MoveToFront(element)
+		c.vectors[text] = vector
+		return
+	}
+	c.entries[text] = c.order.PushFront(text)
+	c.vectors[text] = vector
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(string))
+		delete(c.vectors, oldest.Value.(string))
+	}
+}
+
+// Len returns the number of cached embeddings
+func (c *CachedEmbeddingProvider) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
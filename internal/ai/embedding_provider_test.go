@@ -0,0 +1,122 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// countingEmbedder returns a one-dimensional vector of each text's length
+type countingEmbedder struct {
+	calls  int
+	inputs []string
+}
+
+func (c *countingEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	c.calls++
+	c.inputs = append(c.inputs, texts...)
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = []float32{float32(len(text))}
+	}
+	return vectors, nil
+}
+
+func TestLocalEmbeddingProvider_Batches(t *testing.T) {
+	var batches []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Inputs []string `json:"inputs"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("bad request: %v", err)
+		}
+		batches = append(batches, len(body.Inputs))
+		vectors := make([][]float32, len(body.Inputs))
+		for i, text := range body.Inputs {
+			vectors[i] = []float32{float32(len(text)), 1}
+		}
+		json.NewEncoder(w).Encode(vectors)
+	}))
+	defer server.Close()
+
+	provider, err := NewEmbeddingProvider(EmbeddingConfig{Provider: EmbeddingProviderLocal, BaseURL: server.URL, BatchSize: 2})
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	vectors, err := provider.Embed(context.Background(), []string{"a", "bb", "ccc"})
+	if err != nil {
+		t.Fatalf("embed failed: %v", err)
+	}
+	if len(batches) != 2 || batches[0] != 2 || batches[1] != 1 {
+		t.Errorf("expected batches of 2 and 1, got %v", batches)
+	}
+	if len(vectors) != 3 || vectors[2][0] != 3 {
+		t.Errorf("expected vectors in input order, got %v", vectors)
+	}
+}
+
+func TestOpenAIEmbeddingProvider_OrdersByIndex(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/embeddings" || r.Header.Get("Authorization") != "Bearer key" {
+			t.Errorf("unexpected request %s with auth %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		// Out of order on purpose
+		w.Write([]byte(`{"data": [{"index": 1, "embedding": [2]}, {"index": 0, "embedding": [1]}]}`))
+	}))
+	defer server.Close()
+
+	provider, err := NewEmbeddingProvider(EmbeddingConfig{Provider: EmbeddingProviderOpenAI, BaseURL: server.URL, APIKey: "key", Model: "text-embedding-3-small"})
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	vectors, err := provider.Embed(context.Background(), []string{"first", "second"})
+	if err != nil {
+		t.Fatalf("embed failed: %v", err)
+	}
+	if vectors[0][0] != 1 || vectors[1][0] != 2 {
+		t.Errorf("expected vectors ordered by index, got %v", vectors)
+	}
+
+	if _, err := NewEmbeddingProvider(EmbeddingConfig{Provider: EmbeddingProviderOpenAI}); err == nil {
+		t.Error("expected an error without an API key")
+	}
+	if _, err := NewEmbeddingProvider(EmbeddingConfig{Provider: "bert"}); err == nil {
+		t.Error("expected an error for an unknown provider")
+	}
+}
+
+func TestCachedEmbeddingProvider(t *testing.T) {
+	inner := &countingEmbedder{}
+	cache := NewCachedEmbeddingProvider(inner, 2)
+	ctx := context.Background()
+
+	if _, err := cache.Embed(ctx, []string{"a", "bb", "a"}); err != nil {
+		t.Fatalf("embed failed: %v", err)
+	}
+	if len(inner.inputs) != 2 {
+		t.Errorf("expected duplicates to be embedded once, got %v", inner.inputs)
+	}
+
+	vectors, err := cache.Embed(ctx, []string{"bb", "a"})
+	if err != nil {
+		t.Fatalf("embed failed: %v", err)
+	}
+	if inner.calls != 1 || vectors[0][0] != 2 || vectors[1][0] != 1 {
+		t.Errorf("expected cached vectors without a call, got %v after %d calls", vectors, inner.calls)
+	}
+
+	// "bb" is least recently used and is evicted by "ccc"
+	if _, err := cache.Embed(ctx, []string{"ccc"}); err != nil {
+		t.Fatalf("embed failed: %v", err)
+	}
+	if cache.Len() != 2 {
+		t.Errorf("expected the cache to stay at capacity, got %d", cache.Len())
+	}
+	cache.Embed(ctx, []string{"bb"})
+	if inner.calls != 3 {
+		t.Errorf("expected the evicted text to be embedded again, got %d calls", inner.calls)
+	}
+}
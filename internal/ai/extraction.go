@@ -0,0 +1,323 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMinConfidence is the confidence below which an extraction needs clarification
+const DefaultMinConfidence = 0.7
+
+const (
+	defaultExtractionCacheTTL  = 10 * time.Minute
+	defaultExtractionCacheSize = 256
+)
+
+// ExtractionFieldType is the JSON type of an extracted field
+type ExtractionFieldType string
+
+const (
+	ExtractionString  ExtractionFieldType = "string"
+	ExtractionNumber  ExtractionFieldType = "number"
+	ExtractionBoolean ExtractionFieldType = "boolean"
+)
+
+// ExtractionField describes one parameter a domain extracts from user messages
+type ExtractionField struct {
+	Type        ExtractionFieldType
+	Description string
+	Required    bool     // the extraction fails when the AI leaves it empty
+	Enum        []string // allowed values of a string field
+}
+
+// ExtractionSchema is what a domain extracts from user messages. Every extraction also
+// reports "confidence" (0.0-1.0) and "clarification", so those are not listed in Fields.
+type ExtractionSchema struct {
+	Domain string // e.g. "service", "environment", "deployment"
+	Fields map[string]ExtractionField
+	// Instructions carry the domain's inference rules and examples
+	Instructions string
+	// MinConfidence below which the extraction needs clarification; zero uses DefaultMinConfidence.
+	// WithMinConfidence overrides it per request.
+	MinConfidence float64
+}
+
+type minConfidenceKey struct{}
+
+// WithMinConfidence returns a context whose extractions need at least threshold confidence,
+// overriding the schema's MinConfidence. Agents use it to apply their capability's threshold.
+func WithMinConfidence(ctx context.Context, threshold float64) context.Context {
+	return context.WithValue(ctx, minConfidenceKey{}, threshold)
+}
+
+// minConfidence returns the threshold an extraction in ctx needs
+func (s ExtractionSchema) minConfidence(ctx context.Context) float64 {
+	if threshold, ok := ctx.Value(minConfidenceKey{}).(float64); ok {
+		return threshold
+	}
+	return s.MinConfidence
+}
+
+// ErrLowConfidence is wrapped by LowConfidenceError
+var ErrLowConfidence = errors.New("extraction confidence too low")
+
+// LowConfidenceError is returned when the AI is not sure enough about an extraction.
+// The target is still filled in, so callers can log or build on what was extracted.
+type LowConfidenceError struct {
+	Domain        string
+	Confidence    float64
+	Clarification string // what the AI would ask the user
+}
+
+func (e *LowConfidenceError) Error() string {
+	return fmt.Sprintf("%s extraction confidence too low (%.2f): %s", e.Domain, e.Confidence, e.Clarification)
+}
+
+func (e *LowConfidenceError) Unwrap() error { return ErrLowConfidence }
+
+// Extractor turns user messages into structured parameters for the domains registered with
+// it. It owns the prompt format, validates what the AI returns against the domain's schema
+// and caches results, so a repeated message does not cost another AI call.
+type Extractor struct {
+	provider AIProvider
+	cacheTTL time.Duration
+
+	mu      sync.Mutex
+	schemas map[string]ExtractionSchema
+	prompts map[string]string
+	cache   map[string]extractionCacheEntry
+	order   []string // cache keys, oldest first
+}
+
+type extractionCacheEntry struct {
+	response string
+	stored   time.Time
+}
+
+// NewExtractor creates an extractor calling provider
+func NewExtractor(provider AIProvider) *Extractor {
+	return &Extractor{
+		provider: provider,
+		cacheTTL: defaultExtractionCacheTTL,
+		schemas:  map[string]ExtractionSchema{},
+		prompts:  map[string]string{},
+		cache:    map[string]extractionCacheEntry{},
+	}
+}
+
+// Register adds or replaces a domain's schema. Replacing a schema drops the domain's cached results.
+func (e *Extractor) Register(schema ExtractionSchema) error {
+	if schema.Domain == "" {
+		return fmt.Errorf("extraction schema needs a domain")
+	}
+	if len(schema.Fields) == 0 {
+		return fmt.Errorf("extraction schema %s has no fields", schema.Domain)
+	}
+	for name, field := range schema.Fields {
+		switch field.Type {
+		case ExtractionString, ExtractionNumber, ExtractionBoolean:
+		default:
+			return fmt.Errorf("extraction schema %s: field %s has unknown type %q", schema.Domain, name, field.Type)
+		}
+	}
+	if schema.MinConfidence == 0 {
+		schema.MinConfidence = DefaultMinConfidence
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.schemas[schema.Domain] = schema
+	e.prompts[schema.Domain] = extractionPrompt(schema)
+	prefix := schema.Domain + "\x00"
+	kept := e.order[:0]
+	for _, key := range e.order {
+		if strings.HasPrefix(key, prefix) {
+			delete(e.cache, key)
+			continue
+		}
+		kept = append(kept, key)
+	}
+	e.order = kept
+	return nil
+}
+
+// SetCacheTTL sets how long extraction results are reused; zero or less disables caching
+func (e *Extractor) SetCacheTTL(ttl time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.cacheTTL = ttl
+}
+
+// Extract fills target, a pointer to the domain's parameter struct, from userMessage. It
+// returns a *LowConfidenceError when the AI is not confident enough, and an error when the
+// response does not match the domain's schema.
+func (e *Extractor) Extract(ctx context.Context, domain, userMessage string, target interface{}) error {
+	if e == nil || e.provider == nil {
+		return fmt.Errorf("AI provider not available")
+	}
+	e.mu.Lock()
+	schema, ok := e.schemas[domain]
+	prompt := e.prompts[domain]
+	e.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("no extraction schema registered for %s", domain)
+	}
+
+	key := domain + "\x00" + strings.ToLower(strings.Join(strings.Fields(userMessage), " "))
+	response, cached := e.cached(key)
+	if !cached {
+		raw, err := e.provider.CallAI(WithTask(ctx, TaskExtraction), prompt, userMessage)
+		if err != nil {
+			return fmt.Errorf("AI extraction failed: %w", err)
+		}
+		response = cleanJSONResponse(raw)
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(response), &fields); err != nil {
+		return fmt.Errorf("failed to parse AI response: %w", err)
+	}
+	if err := json.Unmarshal([]byte(response), target); err != nil {
+		return fmt.Errorf("failed to parse AI response: %w", err)
+	}
+
+	confidence, _ := fields["confidence"].(float64)
+	if confidence < schema.minConfidence(ctx) {
+		clarification, _ := fields["clarification"].(string)
+		return &LowConfidenceError{Domain: domain, Confidence: confidence, Clarification: clarification}
+	}
+	if err := schema.validate(fields); err != nil {
+		return err
+	}
+
+	if !cached {
+		e.store(key, response)
+	}
+	return nil
+}
+
+// validate checks an AI response against the schema; null counts as not specified
+func (s ExtractionSchema) validate(fields map[string]interface{}) error {
+	var problems []string
+	for _, name := range sortedFieldNames(s.Fields) {
+		field := s.Fields[name]
+		value, present := fields[name]
+		if !present || value == nil || value == "" {
+			if field.Required {
+				problems = append(problems, fmt.Sprintf("missing %s", name))
+			}
+			continue
+		}
+		switch field.Type {
+		case ExtractionString:
+			text, ok := value.(string)
+			if !ok {
+				problems = append(problems, fmt.Sprintf("%s must be a string", name))
+			} else if len(field.Enum) > 0 && !containsString(field.Enum, text) {
+				problems = append(problems, fmt.Sprintf("%s must be one of %s, got %q", name, strings.Join(field.Enum, ", "), text))
+			}
+		case ExtractionNumber:
+			if _, ok := value.(float64); !ok {
+				problems = append(problems, fmt.Sprintf("%s must be a number", name))
+			}
+		case ExtractionBoolean:
+			if _, ok := value.(bool); !ok {
+				problems = append(problems, fmt.Sprintf("%s must be a boolean", name))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid %s extraction: %s", s.Domain, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func (e *Extractor) cached(key string) (string, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	entry, ok := e.cache[key]
+	if !ok || e.cacheTTL <= 0 || time.Since(entry.stored) > e.cacheTTL {
+		return "", false
+	}
+	return entry.response, true
+}
+
+func (e *Extractor) store(key, response string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.cacheTTL <= 0 {
+		return
+	}
+	if _, exists := e.cache[key]; !exists {
+		e.order = append(e.order, key)
+	}
+	e.cache[key] = extractionCacheEntry{response: response, stored: time.Now()}
+	for len(e.order) > defaultExtractionCacheSize {
+		delete(e.cache, e.order[0])
+		e.order = e.order[1:]
+	}
+}
+
+// extractionPrompt is the system prompt shared by every domain: the response format comes
+// from the schema and the domain only contributes its own rules and examples
+func extractionPrompt(schema ExtractionSchema) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "You extract %s request parameters from user messages.\n\n", schema.Domain)
+	if schema.Instructions != "" {
+		b.WriteString(strings.TrimSpace(schema.Instructions))
+		b.WriteString("\n\n")
+	}
+	b.WriteString("Respond with one JSON object and nothing else, with these fields:\n")
+	for _, name := range sortedFieldNames(schema.Fields) {
+		field := schema.Fields[name]
+		line := fmt.Sprintf("- %s (%s", name, field.Type)
+		if field.Required {
+			line += ", required"
+		}
+		if len(field.Enum) > 0 {
+			line += ", one of: " + strings.Join(field.Enum, "|")
+		}
+		line += ")"
+		if field.Description != "" {
+			line += ": " + field.Description
+		}
+		b.WriteString(line + "\n")
+	}
+	b.WriteString("- confidence (number): 0.0-1.0, how sure you are about the extraction\n")
+	fmt.Fprintf(&b, "- clarification (string): what to ask the user if confidence is below %.1f\n\n", schema.MinConfidence)
+	b.WriteString("Use null for string fields the message does not specify. Numbers must be JSON numbers and booleans JSON booleans, never strings.")
+	return b.String()
+}
+
+// cleanJSONResponse strips the markdown code fences models sometimes wrap JSON in
+func cleanJSONResponse(response string) string {
+	cleaned := strings.TrimSpace(response)
+	cleaned = strings.TrimPrefix(cleaned, "```json")
+	cleaned = strings.TrimPrefix(cleaned, "```")
+	cleaned = strings.TrimSuffix(cleaned, "```")
+	return strings.TrimSpace(cleaned)
+}
+
+func sortedFieldNames(fields map[string]ExtractionField) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+var testExtraction = ExtractionSchema{
+	Domain: "deployment",
+	Fields: map[string]ExtractionField{
+		"app_name": {Type: ExtractionString, Description: "application to deploy", Required: true},
+		"action":   {Type: ExtractionString, Enum: []string{"deploy", "plan"}},
+		"replicas": {Type: ExtractionNumber},
+	},
+	Instructions: "Infer the environment from words like staging or prod.",
+}
+
+type testParams struct {
+	AppName  string  `json:"app_name"`
+	Action   string  `json:"action"`
+	Replicas int     `json:"replicas"`
+	Conf     float64 `json:"confidence"`
+}
+
+func newTestExtractor(t *testing.T, response string) (*Extractor, *stubProvider) {
+	t.Helper()
+	stub := &stubProvider{response: response}
+	extractor := NewExtractor(stub)
+	if err := extractor.Register(testExtraction); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	return extractor, stub
+}
+
+func TestExtractionPromptDescribesSchema(t *testing.T) {
+	prompt := extractionPrompt(testExtraction)
+	for _, want := range []string{"deployment", "app_name (string, required)", "one of: deploy|plan", "replicas (number)", "Infer the environment", "confidence"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q:\n%s", want, prompt)
+		}
+	}
+}
+
+func TestExtractFillsTarget(t *testing.T) {
+	extractor, _ := newTestExtractor(t, "```json\n{\"app_name\":\"checkout\",\"action\":\"deploy\",\"replicas\":3,\"confidence\":0.9}\n```")
+
+	var params testParams
+	if err := extractor.Extract(context.Background(), "deployment", "deploy checkout", &params); err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	if params.AppName != "checkout" || params.Action != "deploy" || params.Replicas != 3 {
+		t.Errorf("unexpected params: %+v", params)
+	}
+}
+
+func TestExtractValidatesAgainstSchema(t *testing.T) {
+	cases := map[string]string{
+		"missing required": `{"app_name":null,"confidence":0.9}`,
+		"not in enum":      `{"app_name":"checkout","action":"destroy","confidence":0.9}`,
+		"wrong type":       `{"app_name":"checkout","replicas":"three","confidence":0.9}`,
+	}
+	for name, response := range cases {
+		t.Run(name, func(t *testing.T) {
+			extractor, _ := newTestExtractor(t, response)
+			var params map[string]interface{}
+			err := extractor.Extract(context.Background(), "deployment", "deploy it", &params)
+			if err == nil || !strings.Contains(err.Error(), "invalid deployment extraction") {
+				t.Errorf("expected a validation error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestExtractLowConfidence(t *testing.T) {
+	extractor, _ := newTestExtractor(t, `{"app_name":"checkout","confidence":0.4,"clarification":"Which environment?"}`)
+
+	var params testParams
+	err := extractor.Extract(context.Background(), "deployment", "deploy checkout", &params)
+	if !errors.Is(err, ErrLowConfidence) {
+		t.Fatalf("expected ErrLowConfidence, got %v", err)
+	}
+	var lowConfidence *LowConfidenceError
+	if !errors.As(err, &lowConfidence) || lowConfidence.Clarification != "Which environment?" {
+		t.Errorf("expected the clarification to be returned, got %v", err)
+	}
+	if params.AppName != "checkout" {
+		t.Errorf("expected the target to be filled in, got %+v", params)
+	}
+}
+
+func TestExtractCachesConfidentResults(t *testing.T) {
+	extractor, stub := newTestExtractor(t, `{"app_name":"checkout","confidence":0.9}`)
+	ctx := context.Background()
+
+	var first, second testParams
+	if err := extractor.Extract(ctx, "deployment", "Deploy  checkout", &first); err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	if err := extractor.Extract(ctx, "deployment", "deploy checkout", &second); err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	if stub.calls != 1 || second.AppName != "checkout" {
+		t.Errorf("expected the second extraction to be cached, calls=%d params=%+v", stub.calls, second)
+	}
+
+	if err := extractor.Register(testExtraction); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if err := extractor.Extract(ctx, "deployment", "deploy checkout", &second); err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	if stub.calls != 2 {
+		t.Errorf("expected re-registering the schema to drop cached results, calls=%d", stub.calls)
+	}
+}
+
+func TestExtractDoesNotCacheLowConfidence(t *testing.T) {
+	extractor, stub := newTestExtractor(t, `{"app_name":"checkout","confidence":0.2}`)
+
+	var params testParams
+	for i := 0; i < 2; i++ {
+		_ = extractor.Extract(context.Background(), "deployment", "deploy", &params)
+	}
+	if stub.calls != 2 {
+		t.Errorf("expected low-confidence results to be asked again, calls=%d", stub.calls)
+	}
+}
+
+func TestExtractUnknownDomain(t *testing.T) {
+	extractor, _ := newTestExtractor(t, `{}`)
+	var params testParams
+	if err := extractor.Extract(context.Background(), "billing", "charge", &params); err == nil {
+		t.Error("expected an error for an unregistered domain")
+	}
+}
@@ -1,6 +1,7 @@
 package ai
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -8,6 +9,8 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/krzachariassen/ZTDP/internal/logging"
@@ -21,6 +24,8 @@ type OpenAIConfig struct {
 	Timeout     time.Duration `json:"timeout"`     // Request timeout
 	MaxTokens   int           `json:"max_tokens"`  // Maximum tokens for responses
 	Temperature float32       `json:"temperature"` // Response creativity (0-1)
+	// TaskModels overrides Model for calls carrying a task hint (see WithTask)
+	TaskModels map[Task]string `json:"task_models,omitempty"`
 }
 
 // DefaultOpenAIConfig returns a default configuration for OpenAI
@@ -49,6 +54,7 @@ type OpenAIProvider struct {
 	config *OpenAIConfig
 	client *http.Client
 	logger *logging.Logger
+	mu     sync.RWMutex // guards config.Model and config.TaskModels, which can be changed at runtime
 }
 
 // NewOpenAIProvider creates a new OpenAI provider instance
@@ -75,11 +81,17 @@ func NewOpenAIProvider(config *OpenAIConfig, apiKey string) (*OpenAIProvider, er
 // CallAI makes a raw AI inference call with system and user prompts
 // This is pure infrastructure - only handles OpenAI API communication
 func (p *OpenAIProvider) CallAI(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
-	p.logger.Info("🔗 Making OpenAI API call")
+	model := p.ModelFor(TaskFromContext(ctx))
+	p.logger.Info("🔗 Making OpenAI API call (model: %s)", model)
+
+	// Fail loudly rather than letting the API reject or silently cut an oversized prompt
+	if err := CheckPromptSize(DefaultTokenizer, systemPrompt, userPrompt, ContextWindow(model), p.config.MaxTokens); err != nil {
+		return "", err
+	}
 
 	// Build the request payload
 	payload := map[string]interface{}{
-		"model": p.config.Model,
+		"model": model,
 		"messages": []map[string]string{
 			{
 				"role":    "system",
@@ -160,11 +172,175 @@ func (p *OpenAIProvider) CallAI(ctx context.Context, systemPrompt, userPrompt st
 	return content, nil
 }
 
+// CallAIStream makes an inference call with streaming enabled and returns the response as it
+// is generated
+func (p *OpenAIProvider) CallAIStream(ctx context.Context, systemPrompt, userPrompt string) (<-chan StreamChunk, error) {
+	model := p.ModelFor(TaskFromContext(ctx))
+	p.logger.Info("🔗 Making streaming OpenAI API call (model: %s)", model)
+
+	if err := CheckPromptSize(DefaultTokenizer, systemPrompt, userPrompt, ContextWindow(model), p.config.MaxTokens); err != nil {
+		return nil, err
+	}
+
+	payload := map[string]interface{}{
+		"model": model,
+		"messages": []map[string]string{
+			{"role": "system", "content": systemPrompt},
+			{"role": "user", "content": userPrompt},
+		},
+		"max_tokens":  p.config.MaxTokens,
+		"temperature": p.config.Temperature,
+		"stream":      true,
+	}
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", p.config.BaseURL+"/chat/completions", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Authorization", "Bearer "+p.config.APIKey)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("OpenAI API request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("OpenAI API error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	chunks := make(chan StreamChunk)
+	go func() {
+		defer close(chunks)
+		defer resp.Body.Close()
+		send := func(chunk StreamChunk) bool {
+			select {
+			case chunks <- chunk:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		// Server-sent events: one "data: {json}" line per delta, terminated by "data: [DONE]"
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if !strings.HasPrefix(line, "data:") {
+				continue
+			}
+			data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+			if data == "[DONE]" {
+				p.logger.Info("✅ OpenAI streaming call completed successfully")
+				return
+			}
+			var event struct {
+				Choices []struct {
+					Delta struct {
+						Content string `json:"content"`
+					} `json:"delta"`
+				} `json:"choices"`
+				Error *struct {
+					Message string `json:"message"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal([]byte(data), &event); err != nil {
+				send(StreamChunk{Err: fmt.Errorf("failed to parse OpenAI stream event: %w", err)})
+				return
+			}
+			if event.Error != nil {
+				send(StreamChunk{Err: fmt.Errorf("OpenAI API error: %s", event.Error.Message)})
+				return
+			}
+			if len(event.Choices) > 0 && event.Choices[0].Delta.Content != "" {
+				if !send(StreamChunk{Content: event.Choices[0].Delta.Content}) {
+					return
+				}
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			send(StreamChunk{Err: fmt.Errorf("failed to read OpenAI stream: %w", err)})
+			return
+		}
+		send(StreamChunk{Err: fmt.Errorf("OpenAI stream ended before completion")})
+	}()
+	return chunks, nil
+}
+
+// PromptBudget implements PromptBudgeter: the window of the model the call would use, less the
+// tokens reserved for the response
+func (p *OpenAIProvider) PromptBudget(ctx context.Context) int {
+	return ContextWindow(p.ModelFor(TaskFromContext(ctx))) - p.config.MaxTokens
+}
+
+// Model returns the model currently used for inference calls
+func (p *OpenAIProvider) Model() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.config.Model
+}
+
+// SetModel switches the model used for subsequent inference calls
+func (p *OpenAIProvider) SetModel(model string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.config.Model = model
+}
+
+// ModelFor returns the model configured for the task, or the default model
+func (p *OpenAIProvider) ModelFor(task Task) string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if model := p.config.TaskModels[task]; model != "" {
+		return model
+	}
+	return p.config.Model
+}
+
+// SetTaskModels replaces the per-task model overrides for subsequent inference calls
+func (p *OpenAIProvider) SetTaskModels(models map[Task]string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.config.TaskModels = models
+}
+
+// Ping verifies connectivity and credentials by listing models, which consumes no tokens
+func (p *OpenAIProvider) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", p.config.BaseURL+"/models", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+p.config.APIKey)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("OpenAI API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("OpenAI API error (status %d)", resp.StatusCode)
+	}
+	return nil
+}
+
 // GetProviderInfo returns information about the OpenAI provider
 func (p *OpenAIProvider) GetProviderInfo() *ProviderInfo {
+	model := p.Model()
+	taskModels := map[string]string{}
+	for _, task := range Tasks {
+		taskModels[string(task)] = p.ModelFor(task)
+	}
 	return &ProviderInfo{
 		Name:    "openai-gpt",
-		Version: p.config.Model,
+		Version: model,
 		Capabilities: []string{
 			"plan_generation",
 			"policy_evaluation",
@@ -174,7 +350,8 @@ func (p *OpenAIProvider) GetProviderInfo() *ProviderInfo {
 		Metadata: map[string]interface{}{
 			"max_tokens":  p.config.MaxTokens,
 			"temperature": p.config.Temperature,
-			"model":       p.config.Model,
+			"model":       model,
+			"task_models": taskModels,
 		},
 	}
 }
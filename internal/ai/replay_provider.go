@@ -0,0 +1,267 @@
+package ai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoRecording is returned by ReplayProvider when no recorded interaction matches a call
+var ErrNoRecording = errors.New("no recorded AI response for prompt")
+
+// Interaction is one recorded AI call
+type Interaction struct {
+	SystemPrompt string `json:"system_prompt"`
+	UserPrompt   string `json:"user_prompt"`
+	Response     string `json:"response"`
+	Error        string `json:"error,omitempty"`
+}
+
+// Cassette is the fixture file format shared by RecordingProvider and ReplayProvider
+type Cassette struct {
+	Provider     string        `json:"provider"`
+	RecordedAt   time.Time     `json:"recorded_at"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// LoadCassette reads a fixture file
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read cassette %s: %w", path, err)
+	}
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		return nil, fmt.Errorf("parse cassette %s: %w", path, err)
+	}
+	return &cassette, nil
+}
+
+// Save writes the cassette as indented JSON so fixture diffs stay reviewable
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// RecordingProvider forwards calls to a real provider and captures every interaction
+type RecordingProvider struct {
+	upstream AIProvider
+	path     string
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecordingProvider records calls made through upstream; Close writes them to path
+func NewRecordingProvider(upstream AIProvider, path string) *RecordingProvider {
+	name := "unknown"
+	if info := upstream.GetProviderInfo(); info != nil {
+		name = info.Name
+	}
+	return &RecordingProvider{
+		upstream: upstream,
+		path:     path,
+		cassette: Cassette{Provider: name, RecordedAt: time.Now().UTC(), Interactions: []Interaction{}},
+	}
+}
+
+// CallAI forwards the call and records the response (or error)
+func (p *RecordingProvider) CallAI(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	response, err := p.upstream.CallAI(ctx, systemPrompt, userPrompt)
+
+	interaction := Interaction{SystemPrompt: systemPrompt, UserPrompt: userPrompt, Response: response}
+	if err != nil {
+		interaction.Error = err.Error()
+	}
+	p.mu.Lock()
+	p.cassette.Interactions = append(p.cassette.Interactions, interaction)
+	p.mu.Unlock()
+
+	return response, err
+}
+
+// GetProviderInfo returns the upstream provider info
+func (p *RecordingProvider) GetProviderInfo() *ProviderInfo {
+	return p.upstream.GetProviderInfo()
+}
+
+// PromptBudget returns the upstream provider's prompt budget
+func (p *RecordingProvider) PromptBudget(ctx context.Context) int {
+	return PromptBudget(ctx, p.upstream)
+}
+
+// Save writes the interactions recorded so far
+func (p *RecordingProvider) Save() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.cassette.Save(p.path)
+}
+
+// Close saves the cassette and closes the upstream provider
+func (p *RecordingProvider) Close() error {
+	if err := p.Save(); err != nil {
+		return fmt.Errorf("save cassette %s: %w", p.path, err)
+	}
+	return p.upstream.Close()
+}
+
+// ReplayProvider answers calls from a cassette without network access.
+//
+// A call matches a recorded interaction when both prompts are equal after normalization
+// (UUIDs, timestamps, long numbers and pointers are masked). Prompts that embed unordered
+// data, like the orchestrator's capability list, can differ between runs, so when no exact
+// match exists the first unused interaction with the same user prompt is used. Recorded
+// interactions are consumed in order; once all matches are used the last one is repeated.
+type ReplayProvider struct {
+	path string
+
+	mu           sync.Mutex
+	interactions []replayEntry
+}
+
+type replayEntry struct {
+	Interaction
+	systemKey string
+	userKey   string
+	used      bool
+}
+
+// NewReplayProvider loads the cassette at path
+func NewReplayProvider(path string) (*ReplayProvider, error) {
+	cassette, err := LoadCassette(path)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &ReplayProvider{path: path}
+	for _, interaction := range cassette.Interactions {
+		p.interactions = append(p.interactions, replayEntry{
+			Interaction: interaction,
+			systemKey:   promptKey(interaction.SystemPrompt),
+			userKey:     promptKey(interaction.UserPrompt),
+		})
+	}
+	return p, nil
+}
+
+// CallAI returns the recorded response for the prompts
+func (p *ReplayProvider) CallAI(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	systemKey, userKey := promptKey(systemPrompt), promptKey(userPrompt)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entry := p.find(func(e *replayEntry) bool { return !e.used && e.userKey == userKey && e.systemKey == systemKey })
+	if entry == nil {
+		entry = p.find(func(e *replayEntry) bool { return !e.used && e.userKey == userKey })
+	}
+	if entry == nil {
+		entry = p.findLast(func(e *replayEntry) bool { return e.userKey == userKey })
+	}
+	if entry == nil {
+		return "", fmt.Errorf("%w in %s (re-record with ZTDP_AI_RECORD=1): %q", ErrNoRecording, p.path, truncatePrompt(userPrompt))
+	}
+
+	entry.used = true
+	if entry.Error != "" {
+		return entry.Response, errors.New(entry.Error)
+	}
+	return entry.Response, nil
+}
+
+// Unused returns recorded interactions that were never replayed, which usually means
+// the flow under test changed and the cassette should be re-recorded
+func (p *ReplayProvider) Unused() []Interaction {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var unused []Interaction
+	for _, e := range p.interactions {
+		if !e.used {
+			unused = append(unused, e.Interaction)
+		}
+	}
+	return unused
+}
+
+// GetProviderInfo identifies the replay provider
+func (p *ReplayProvider) GetProviderInfo() *ProviderInfo {
+	return &ProviderInfo{
+		Name:         "replay",
+		Version:      "1.0.0",
+		Capabilities: []string{"deterministic_replay"},
+		Metadata:     map[string]interface{}{"cassette": p.path},
+	}
+}
+
+// Close is a no-op for the replay provider
+func (p *ReplayProvider) Close() error {
+	return nil
+}
+
+func (p *ReplayProvider) find(match func(*replayEntry) bool) *replayEntry {
+	for i := range p.interactions {
+		if match(&p.interactions[i]) {
+			return &p.interactions[i]
+		}
+	}
+	return nil
+}
+
+func (p *ReplayProvider) findLast(match func(*replayEntry) bool) *replayEntry {
+	for i := len(p.interactions) - 1; i >= 0; i-- {
+		if match(&p.interactions[i]) {
+			return &p.interactions[i]
+		}
+	}
+	return nil
+}
+
+// Values that change from run to run and must not affect matching
+var (
+	uuidPattern      = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+	timestampPattern = regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2})?`)
+	pointerPattern   = regexp.MustCompile(`0x[0-9a-fA-F]+`)
+	longNumPattern   = regexp.MustCompile(`\d{9,}`)
+)
+
+// normalizePrompt masks volatile values and collapses whitespace
+func normalizePrompt(prompt string) string {
+	prompt = uuidPattern.ReplaceAllString(prompt, "<uuid>")
+	prompt = timestampPattern.ReplaceAllString(prompt, "<time>")
+	prompt = pointerPattern.ReplaceAllString(prompt, "<ptr>")
+	prompt = longNumPattern.ReplaceAllString(prompt, "<n>")
+	return strings.Join(strings.Fields(prompt), " ")
+}
+
+// promptKey hashes a normalized prompt
+func promptKey(prompt string) string {
+	sum := sha256.Sum256([]byte(normalizePrompt(prompt)))
+	return hex.EncodeToString(sum[:])
+}
+
+func truncatePrompt(prompt string) string {
+	if len(prompt) > 120 {
+		return prompt[:120] + "..."
+	}
+	return prompt
+}
@@ -0,0 +1,108 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+// stubProvider answers every call with a fixed response
+type stubProvider struct {
+	response string
+	calls    int
+}
+
+func (s *stubProvider) CallAI(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	s.calls++
+	return s.response, nil
+}
+
+func (s *stubProvider) GetProviderInfo() *ProviderInfo { return &ProviderInfo{Name: "stub"} }
+
+func (s *stubProvider) Close() error { return nil }
+
+func TestRecordThenReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	ctx := context.Background()
+
+	upstream := &stubProvider{response: `{"intent":"create application"}`}
+	recorder := NewRecordingProvider(upstream, path)
+	if _, err := recorder.CallAI(ctx, "system at 2024-01-02T03:04:05Z", "Create app checkout"); err != nil {
+		t.Fatalf("record call failed: %v", err)
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	replay, err := NewReplayProvider(path)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	got, err := replay.CallAI(ctx, "system at 2025-06-07T08:09:10Z", "Create  app checkout")
+	if err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	if got != upstream.response {
+		t.Errorf("expected %q, got %q", upstream.response, got)
+	}
+	if unused := replay.Unused(); len(unused) != 0 {
+		t.Errorf("expected all interactions replayed, got %d unused", len(unused))
+	}
+}
+
+func TestReplayProvider_FallsBackToUserPrompt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	cassette := &Cassette{Provider: "stub", Interactions: []Interaction{
+		{SystemPrompt: "capabilities: a, b", UserPrompt: "deploy checkout", Response: "first"},
+		{SystemPrompt: "capabilities: a, b", UserPrompt: "deploy checkout", Response: "second"},
+	}}
+	if err := cassette.Save(path); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	replay, err := NewReplayProvider(path)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	ctx := context.Background()
+
+	// Capability order differs between runs, so the system prompt does not match exactly
+	for _, want := range []string{"first", "second", "second"} {
+		got, err := replay.CallAI(ctx, "capabilities: b, a", "deploy checkout")
+		if err != nil {
+			t.Fatalf("replay failed: %v", err)
+		}
+		if got != want {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+	}
+
+	if _, err := replay.CallAI(ctx, "capabilities: a, b", "delete checkout"); !errors.Is(err, ErrNoRecording) {
+		t.Errorf("expected ErrNoRecording, got %v", err)
+	}
+}
+
+func TestReplayProvider_ReportsUnused(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	cassette := &Cassette{Interactions: []Interaction{
+		{UserPrompt: "create checkout", Response: "ok"},
+		{UserPrompt: "check policy", Response: "allowed"},
+	}}
+	if err := cassette.Save(path); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	replay, err := NewReplayProvider(path)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if _, err := replay.CallAI(context.Background(), "", "create checkout"); err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+
+	unused := replay.Unused()
+	if len(unused) != 1 || unused[0].UserPrompt != "check policy" {
+		t.Errorf("expected only 'check policy' unused, got %+v", unused)
+	}
+}
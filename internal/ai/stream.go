@@ -0,0 +1,51 @@
+package ai
+
+import (
+	"context"
+	"strings"
+)
+
+// StreamChunk is one increment of a streamed response. A chunk with Err set is the last one.
+type StreamChunk struct {
+	Content string
+	Err     error
+}
+
+// StreamingAIProvider is implemented by providers that can return a response incrementally.
+// The channel is closed when the response is complete.
+type StreamingAIProvider interface {
+	AIProvider
+	CallAIStream(ctx context.Context, systemPrompt, userPrompt string) (<-chan StreamChunk, error)
+}
+
+// CallAIStream streams from providers that support it and delivers the whole response as a
+// single chunk from those that do not, so callers can always consume a stream
+func CallAIStream(ctx context.Context, provider AIProvider, systemPrompt, userPrompt string) (<-chan StreamChunk, error) {
+	if streaming, ok := provider.(StreamingAIProvider); ok {
+		return streaming.CallAIStream(ctx, systemPrompt, userPrompt)
+	}
+	response, err := provider.CallAI(ctx, systemPrompt, userPrompt)
+	if err != nil {
+		return nil, err
+	}
+	chunks := make(chan StreamChunk, 1)
+	chunks <- StreamChunk{Content: response}
+	close(chunks)
+	return chunks, nil
+}
+
+// CollectStream reads a stream to the end, calling onChunk for each increment, and returns
+// the full response
+func CollectStream(chunks <-chan StreamChunk, onChunk func(string)) (string, error) {
+	var response strings.Builder
+	for chunk := range chunks {
+		if chunk.Err != nil {
+			return response.String(), chunk.Err
+		}
+		response.WriteString(chunk.Content)
+		if onChunk != nil && chunk.Content != "" {
+			onChunk(chunk.Content)
+		}
+	}
+	return response.String(), nil
+}
@@ -0,0 +1,86 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenAIProvider_CallAIStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, delta := range []string{"Check", "out has", " 2 services"} {
+			w.Write([]byte(`data: {"choices": [{"delta": {"content": "` + delta + `"}}]}` + "\n\n"))
+		}
+		w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	config := DefaultOpenAIConfig()
+	config.BaseURL = server.URL
+	provider, err := NewOpenAIProvider(config, "key")
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+	chunks, err := CallAIStream(context.Background(), provider, "system", "user")
+	if err != nil {
+		t.Fatalf("stream failed: %v", err)
+	}
+	var received []string
+	response, err := CollectStream(chunks, func(chunk string) { received = append(received, chunk) })
+	if err != nil {
+		t.Fatalf("stream returned error: %v", err)
+	}
+	if response != "Checkout has 2 services" || len(received) != 3 {
+		t.Errorf("expected three chunks forming the response, got %q from %q", response, received)
+	}
+}
+
+func TestOpenAIProvider_CallAIStreamTruncated(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`data: {"choices": [{"delta": {"content": "partial"}}]}` + "\n\n"))
+	}))
+	defer server.Close()
+
+	config := DefaultOpenAIConfig()
+	config.BaseURL = server.URL
+	provider, _ := NewOpenAIProvider(config, "key")
+	chunks, err := provider.CallAIStream(context.Background(), "system", "user")
+	if err != nil {
+		t.Fatalf("stream failed: %v", err)
+	}
+	response, err := CollectStream(chunks, nil)
+	if err == nil || response != "partial" {
+		t.Errorf("expected an error after the partial response, got %q, %v", response, err)
+	}
+}
+
+func TestCallAIStream_FallsBackToSingleChunk(t *testing.T) {
+	chunks, err := CallAIStream(context.Background(), &stubProvider{response: "whole answer"}, "system", "user")
+	if err != nil {
+		t.Fatalf("stream failed: %v", err)
+	}
+	var count int
+	response, _ := CollectStream(chunks, func(string) { count++ })
+	if response != "whole answer" || count != 1 {
+		t.Errorf("expected one chunk with the whole answer, got %q in %d chunks", response, count)
+	}
+
+	if _, err := CallAIStream(context.Background(), errorProvider{}, "system", "user"); !errors.Is(err, errUnavailable) {
+		t.Errorf("expected the provider error, got %v", err)
+	}
+}
+
+var errUnavailable = errors.New("unavailable")
+
+type errorProvider struct{}
+
+func (errorProvider) CallAI(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	return "", errUnavailable
+}
+
+func (errorProvider) GetProviderInfo() *ProviderInfo { return &ProviderInfo{Name: "error"} }
+
+func (errorProvider) Close() error { return nil }
@@ -0,0 +1,30 @@
+package ai
+
+import "context"
+
+// Task hints which kind of work an AI call does, so providers can route cheap work to a fast
+// model and planning to a stronger one
+type Task string
+
+const (
+	TaskClassification Task = "classification" // intent detection and routing
+	TaskExtraction     Task = "extraction"     // turning a user message into structured JSON
+	TaskPlanning       Task = "planning"       // deployment plans and plan revisions
+	TaskConversation   Task = "conversation"   // free-form answers and summaries
+)
+
+// Tasks lists every task hint
+var Tasks = []Task{TaskClassification, TaskExtraction, TaskPlanning, TaskConversation}
+
+type taskKey struct{}
+
+// WithTask returns a context carrying the task hint for the next CallAI
+func WithTask(ctx context.Context, task Task) context.Context {
+	return context.WithValue(ctx, taskKey{}, task)
+}
+
+// TaskFromContext returns the task hint, or "" when none was set
+func TaskFromContext(ctx context.Context) Task {
+	task, _ := ctx.Value(taskKey{}).(Task)
+	return task
+}
@@ -0,0 +1,49 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenAIProvider_RoutesModelByTask(t *testing.T) {
+	var models []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		models = append(models, body.Model)
+		w.Write([]byte(`{"choices": [{"message": {"content": "ok"}}]}`))
+	}))
+	defer server.Close()
+
+	config := DefaultOpenAIConfig()
+	config.BaseURL = server.URL
+	config.Model = "gpt-4o"
+	config.TaskModels = map[Task]string{TaskClassification: "gpt-4o-mini"}
+	provider, err := NewOpenAIProvider(config, "key")
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+
+	ctx := context.Background()
+	provider.CallAI(WithTask(ctx, TaskClassification), "system", "user")
+	provider.CallAI(WithTask(ctx, TaskPlanning), "system", "user")
+	provider.CallAI(ctx, "system", "user")
+
+	provider.SetTaskModels(map[Task]string{TaskPlanning: "o3"})
+	provider.CallAI(WithTask(ctx, TaskPlanning), "system", "user")
+
+	expected := []string{"gpt-4o-mini", "gpt-4o", "gpt-4o", "o3"}
+	if len(models) != len(expected) {
+		t.Fatalf("expected %d calls, got %v", len(expected), models)
+	}
+	for i := range expected {
+		if models[i] != expected[i] {
+			t.Errorf("call %d: expected model %s, got %s", i, expected[i], models[i])
+		}
+	}
+}
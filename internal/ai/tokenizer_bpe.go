@@ -0,0 +1,47 @@
+package ai
+
+import (
+	"fmt"
+
+	"github.com/pkoukk/tiktoken-go"
+)
+
+// DefaultEncoding is the BPE encoding used by the gpt-4 and gpt-3.5 model families
+const DefaultEncoding = "cl100k_base"
+
+// BPETokenizer counts tokens exactly with a tiktoken BPE encoding such as cl100k_base
+type BPETokenizer struct {
+	encoding *tiktoken.Tiktoken
+}
+
+// NewBPETokenizer loads an encoding. With ranksFile set the vocabulary is read from that local
+// .tiktoken file, for installations without internet access; otherwise it is downloaded once
+// and cached in TIKTOKEN_CACHE_DIR.
+func NewBPETokenizer(encoding, ranksFile string) (*BPETokenizer, error) {
+	if encoding == "" {
+		encoding = DefaultEncoding
+	}
+	if ranksFile != "" {
+		tiktoken.SetBpeLoader(localRanksLoader{path: ranksFile})
+	}
+	enc, err := tiktoken.GetEncoding(encoding)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tokenizer encoding %s: %w", encoding, err)
+	}
+	return &BPETokenizer{encoding: enc}, nil
+}
+
+// Count implements Tokenizer
+func (t *BPETokenizer) Count(text string) int {
+	return len(t.encoding.EncodeOrdinary(text))
+}
+
+// localRanksLoader reads the vocabulary from a local file instead of the encoding's URL
+type localRanksLoader struct {
+	path string
+}
+
+// LoadTiktokenBpe implements tiktoken.BpeLoader
+func (l localRanksLoader) LoadTiktokenBpe(string) (map[string]int, error) {
+	return tiktoken.NewDefaultBpeLoader().LoadTiktokenBpe(l.path)
+}
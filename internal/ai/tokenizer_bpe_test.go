@@ -0,0 +1,48 @@
+package ai
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeRanks writes a tiny vocabulary: every single byte plus the merges he, ll and hell
+func writeRanks(t *testing.T) string {
+	t.Helper()
+	var ranks strings.Builder
+	for b := 0; b < 256; b++ {
+		fmt.Fprintf(&ranks, "%s %d\n", base64.StdEncoding.EncodeToString([]byte{byte(b)}), b)
+	}
+	for rank, merge := range []string{"he", "ll", "hell"} {
+		fmt.Fprintf(&ranks, "%s %d\n", base64.StdEncoding.EncodeToString([]byte(merge)), 256+rank)
+	}
+	path := filepath.Join(t.TempDir(), "test.tiktoken")
+	if err := os.WriteFile(path, []byte(ranks.String()), 0o644); err != nil {
+		t.Fatalf("write ranks: %v", err)
+	}
+	return path
+}
+
+func TestBPETokenizer(t *testing.T) {
+	tokenizer, err := NewBPETokenizer(DefaultEncoding, writeRanks(t))
+	if err != nil {
+		t.Fatalf("NewBPETokenizer: %v", err)
+	}
+	cases := map[string]int{
+		"":            0,
+		"hello":       2, // hell + o
+		"hello world": 8, // hell + o, then " world" byte by byte
+	}
+	for text, expected := range cases {
+		if got := tokenizer.Count(text); got != expected {
+			t.Errorf("Count(%q) = %d, expected %d", text, got, expected)
+		}
+	}
+
+	if _, err := NewBPETokenizer("no_such_encoding", ""); err == nil {
+		t.Error("expected an unknown encoding to fail")
+	}
+}
@@ -0,0 +1,289 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ErrPromptTooLarge is returned instead of sending a prompt that cannot fit the model's context
+var ErrPromptTooLarge = errors.New("prompt exceeds the model context window")
+
+// Tokenizer counts the tokens a model would see for a text
+type Tokenizer interface {
+	Count(text string) int
+}
+
+// EstimatingTokenizer approximates BPE tokenizers such as cl100k without a vocabulary: words
+// cost one token per four characters, and each punctuation or symbol costs one. It errs on
+// the high side for English prose, which is the safe direction for budgeting.
+type EstimatingTokenizer struct{}
+
+// Count implements Tokenizer
+func (EstimatingTokenizer) Count(text string) int {
+	tokens, word := 0, 0
+	flush := func() {
+		if word > 0 {
+			tokens += (word + 3) / 4
+			word = 0
+		}
+	}
+	for _, r := range text {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word++
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			flush()
+			tokens++
+		}
+	}
+	flush()
+	return tokens
+}
+
+// DefaultTokenizer is used when callers have no model-specific tokenizer
+var DefaultTokenizer Tokenizer = EstimatingTokenizer{}
+
+// contextWindows holds the context size of known models, longest prefix first
+var contextWindows = []struct {
+	prefix string
+	tokens int
+}{
+	{"gpt-4o", 128000},
+	{"gpt-4.1", 1000000},
+	{"gpt-4-turbo", 128000},
+	{"gpt-4-32k", 32768},
+	{"gpt-4", 8192},
+	{"gpt-3.5-turbo", 16385},
+	{"o1", 200000},
+	{"o3", 200000},
+	{"o4", 200000},
+}
+
+// DefaultContextWindow is assumed for unknown models
+const DefaultContextWindow = 8192
+
+// ContextWindow returns the context size in tokens for a model
+func ContextWindow(model string) int {
+	for _, window := range contextWindows {
+		if strings.HasPrefix(model, window.prefix) {
+			return window.tokens
+		}
+	}
+	return DefaultContextWindow
+}
+
+// defaultResponseTokens is reserved for the response when a provider does not report its budget
+const defaultResponseTokens = 4000
+
+// PromptBudgeter is implemented by providers that know how many prompt tokens one call can carry
+type PromptBudgeter interface {
+	PromptBudget(ctx context.Context) int
+}
+
+// PromptBudget returns how many tokens the system and user prompts of one call to provider may
+// use together, assuming the default window for providers that do not say
+func PromptBudget(ctx context.Context, provider AIProvider) int {
+	if budgeter, ok := provider.(PromptBudgeter); ok {
+		return budgeter.PromptBudget(ctx)
+	}
+	return DefaultContextWindow - defaultResponseTokens
+}
+
+// CheckPromptSize returns ErrPromptTooLarge when the prompts plus the reserved completion
+// tokens exceed the window
+func CheckPromptSize(tokenizer Tokenizer, systemPrompt, userPrompt string, window, reserved int) error {
+	used := tokenizer.Count(systemPrompt) + tokenizer.Count(userPrompt)
+	if used+reserved > window {
+		return fmt.Errorf("%w: %d prompt tokens + %d reserved for the response > %d", ErrPromptTooLarge, used, reserved, window)
+	}
+	return nil
+}
+
+// truncationMarker is appended to text cut to fit a budget
+const truncationMarker = "\n...[truncated]"
+
+// TruncateToTokens cuts text to at most max tokens, preferring whole lines, and marks the cut
+func TruncateToTokens(tokenizer Tokenizer, text string, max int) string {
+	if tokenizer.Count(text) <= max {
+		return text
+	}
+	budget := max - tokenizer.Count(truncationMarker)
+	if budget <= 0 {
+		return ""
+	}
+
+	var kept strings.Builder
+	used := 0
+	for _, line := range strings.SplitAfter(text, "\n") {
+		cost := tokenizer.Count(line)
+		if used+cost > budget {
+			// Fill the rest of the budget with whole words from the line that did not fit
+			for _, word := range strings.Fields(line) {
+				cost := tokenizer.Count(word)
+				if used+cost > budget {
+					break
+				}
+				kept.WriteString(word + " ")
+				used += cost
+			}
+			break
+		}
+		kept.WriteString(line)
+		used += cost
+	}
+	return strings.TrimRight(kept.String(), " \n") + truncationMarker
+}
+
+// ContextSection is one part of the platform context given to a prompt. Sections with a
+// higher Priority are kept whole before lower ones are truncated or dropped.
+type ContextSection struct {
+	Name     string
+	Content  string
+	Priority int
+}
+
+// FitResult is the context assembled within a token budget
+type FitResult struct {
+	Text      string
+	Tokens    int
+	Truncated []string // sections cut to fit
+	Dropped   []string // sections left out entirely
+}
+
+// minSectionTokens is the smallest useful remainder of a truncated section
+const minSectionTokens = 32
+
+// FitSections assembles sections within budget tokens, filling the budget in priority order and
+// emitting the kept sections in their original order
+func FitSections(tokenizer Tokenizer, sections []ContextSection, budget int) FitResult {
+	order := make([]int, len(sections))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return sections[order[a]].Priority > sections[order[b]].Priority })
+
+	kept := make([]string, len(sections))
+	result := FitResult{}
+	remaining := budget
+	for _, i := range order {
+		section := sections[i]
+		text := section.Content
+		if section.Name != "" {
+			text = section.Name + ":\n" + section.Content
+		}
+		cost := tokenizer.Count(text) + 1 // separator
+		switch {
+		case cost <= remaining:
+			kept[i] = text
+			remaining -= cost
+		case remaining >= minSectionTokens:
+			kept[i] = TruncateToTokens(tokenizer, text, remaining-1)
+			remaining -= tokenizer.Count(kept[i]) + 1
+			result.Truncated = append(result.Truncated, section.Name)
+		default:
+			result.Dropped = append(result.Dropped, section.Name)
+		}
+	}
+
+	var parts []string
+	for _, text := range kept {
+		if text != "" {
+			parts = append(parts, text)
+		}
+	}
+	result.Text = strings.Join(parts, "\n\n")
+	result.Tokens = tokenizer.Count(result.Text)
+	return result
+}
+
+// Chunk splits text into pieces of at most max tokens on line boundaries, splitting single
+// lines that are too long on word boundaries
+func Chunk(tokenizer Tokenizer, text string, max int) []string {
+	var pieces []string
+	for _, line := range strings.Split(text, "\n") {
+		if tokenizer.Count(line) <= max {
+			pieces = append(pieces, line)
+			continue
+		}
+		var current []string
+		for _, word := range strings.Fields(line) {
+			if len(current) > 0 && tokenizer.Count(strings.Join(append(current, word), " ")) > max {
+				pieces = append(pieces, strings.Join(current, " "))
+				current = nil
+			}
+			current = append(current, word)
+		}
+		if len(current) > 0 {
+			pieces = append(pieces, strings.Join(current, " "))
+		}
+	}
+	return ChunkItems(tokenizer, pieces, max, "\n")
+}
+
+// ChunkItems packs items, such as individual policies, into as few chunks of at most max tokens
+// as possible without splitting an item. An item larger than max gets a chunk of its own.
+func ChunkItems(tokenizer Tokenizer, items []string, max int, separator string) []string {
+	var chunks []string
+	var current strings.Builder
+	used := 0
+	sepCost := tokenizer.Count(separator)
+	for _, item := range items {
+		cost := tokenizer.Count(item)
+		if current.Len() > 0 && used+sepCost+cost > max {
+			chunks = append(chunks, current.String())
+			current.Reset()
+			used = 0
+		}
+		if current.Len() > 0 {
+			current.WriteString(separator)
+			used += sepCost
+		}
+		current.WriteString(item)
+		used += cost
+	}
+	if current.Len() > 0 {
+		chunks = append(chunks, current.String())
+	}
+	return chunks
+}
+
+// MapReduce answers a prompt over input too large for one call: each chunk is sent with
+// mapPrompt, then the partial answers are combined with reducePrompt, repeatedly if the
+// partial answers themselves do not fit in one call
+func MapReduce(ctx context.Context, provider AIProvider, tokenizer Tokenizer, mapPrompt, reducePrompt string, chunks []string, maxTokens int) (string, error) {
+	if len(chunks) == 0 {
+		return "", fmt.Errorf("nothing to process")
+	}
+	partials := make([]string, 0, len(chunks))
+	for i, chunk := range chunks {
+		response, err := provider.CallAI(ctx, mapPrompt, chunk)
+		if err != nil {
+			return "", fmt.Errorf("chunk %d of %d: %w", i+1, len(chunks), err)
+		}
+		partials = append(partials, strings.TrimSpace(response))
+	}
+
+	for len(partials) > 1 {
+		groups := ChunkItems(tokenizer, partials, maxTokens, "\n---\n")
+		if len(groups) == len(partials) && len(groups) > 1 {
+			// No two partial answers fit together; reducing further cannot make progress
+			return "", fmt.Errorf("%w: partial answers are too large to combine", ErrPromptTooLarge)
+		}
+		reduced := make([]string, 0, len(groups))
+		for _, group := range groups {
+			response, err := provider.CallAI(ctx, reducePrompt, group)
+			if err != nil {
+				return "", fmt.Errorf("combining partial answers: %w", err)
+			}
+			reduced = append(reduced, strings.TrimSpace(response))
+		}
+		partials = reduced
+	}
+	return partials[0], nil
+}
@@ -0,0 +1,119 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEstimatingTokenizer(t *testing.T) {
+	tokenizer := EstimatingTokenizer{}
+	cases := map[string]int{
+		"":                     0,
+		"deploy":               2,
+		"deploy checkout":      4,
+		`{"name": "checkout"}`: 10,
+		"   spaces   only   ":  3,
+	}
+	for text, expected := range cases {
+		if got := tokenizer.Count(text); got != expected {
+			t.Errorf("Count(%q) = %d, expected %d", text, got, expected)
+		}
+	}
+}
+
+func TestCheckPromptSize(t *testing.T) {
+	long := strings.Repeat("word ", 100)
+	if err := CheckPromptSize(DefaultTokenizer, "system", long, 1000, 100); err != nil {
+		t.Errorf("expected prompt to fit, got %v", err)
+	}
+	err := CheckPromptSize(DefaultTokenizer, "system", long, 150, 100)
+	if !errors.Is(err, ErrPromptTooLarge) {
+		t.Errorf("expected ErrPromptTooLarge, got %v", err)
+	}
+	if ContextWindow("gpt-4o-mini") != 128000 || ContextWindow("gpt-4") != 8192 || ContextWindow("unknown") != DefaultContextWindow {
+		t.Error("unexpected context windows")
+	}
+}
+
+func TestTruncateAndFitSections(t *testing.T) {
+	tokenizer := DefaultTokenizer
+	text := strings.Repeat("service checkout-api owned by payments\n", 50)
+	truncated := TruncateToTokens(tokenizer, text, 40)
+	if tokenizer.Count(truncated) > 40 || !strings.HasSuffix(truncated, truncationMarker) {
+		t.Errorf("expected at most 40 tokens ending in the marker, got %d: %q", tokenizer.Count(truncated), truncated)
+	}
+	if TruncateToTokens(tokenizer, "short", 40) != "short" {
+		t.Error("expected text within budget to be unchanged")
+	}
+
+	fit := FitSections(tokenizer, []ContextSection{
+		{Name: "STATE", Content: text, Priority: 1},
+		{Name: "CAPABILITIES", Content: "deploy, create, policy check", Priority: 3},
+		{Name: "HISTORY", Content: text, Priority: 0},
+	}, 120)
+	if fit.Tokens > 120 {
+		t.Errorf("expected at most 120 tokens, got %d", fit.Tokens)
+	}
+	if !strings.HasPrefix(fit.Text, "STATE:") || !strings.Contains(fit.Text, "CAPABILITIES:\ndeploy, create, policy check") {
+		t.Errorf("expected sections in original order with capabilities whole, got %q", fit.Text)
+	}
+	if len(fit.Truncated) != 1 || fit.Truncated[0] != "STATE" || len(fit.Dropped) != 1 || fit.Dropped[0] != "HISTORY" {
+		t.Errorf("expected STATE truncated and HISTORY dropped, got %v / %v", fit.Truncated, fit.Dropped)
+	}
+}
+
+func TestChunk(t *testing.T) {
+	tokenizer := DefaultTokenizer
+	text := strings.Repeat("policy: no direct prod deploys\n", 20) + strings.Repeat("word ", 60)
+	chunks := Chunk(tokenizer, text, 30)
+	if len(chunks) < 2 {
+		t.Fatalf("expected several chunks, got %d", len(chunks))
+	}
+	for i, chunk := range chunks {
+		if tokenizer.Count(chunk) > 30 {
+			t.Errorf("chunk %d has %d tokens", i, tokenizer.Count(chunk))
+		}
+	}
+
+	items := ChunkItems(tokenizer, []string{"a", "b", strings.Repeat("x", 400), "c"}, 10, "\n")
+	if len(items) != 3 || items[0] != "a\nb" || items[2] != "c" {
+		t.Errorf("expected oversized items to get their own chunk, got %q", items)
+	}
+}
+
+// echoReducer answers map calls with the chunk's first word and reduce calls with the
+// number of partial answers it combined
+type echoReducer struct {
+	mapCalls, reduceCalls int
+}
+
+func (e *echoReducer) CallAI(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	if systemPrompt == "reduce" {
+		e.reduceCalls++
+		return "combined " + strings.Join(strings.Split(userPrompt, "\n---\n"), "+"), nil
+	}
+	e.mapCalls++
+	return strings.Fields(userPrompt)[0], nil
+}
+
+func (e *echoReducer) GetProviderInfo() *ProviderInfo { return &ProviderInfo{Name: "echo"} }
+
+func (e *echoReducer) Close() error { return nil }
+
+func TestMapReduce(t *testing.T) {
+	provider := &echoReducer{}
+	answer, err := MapReduce(context.Background(), provider, DefaultTokenizer, "map", "reduce", []string{"p1 rules", "p2 rules", "p3 rules"}, 1000)
+	if err != nil {
+		t.Fatalf("map-reduce failed: %v", err)
+	}
+	if answer != "combined p1+p2+p3" || provider.mapCalls != 3 || provider.reduceCalls != 1 {
+		t.Errorf("unexpected answer %q after %d map and %d reduce calls", answer, provider.mapCalls, provider.reduceCalls)
+	}
+
+	single, _ := MapReduce(context.Background(), &echoReducer{}, DefaultTokenizer, "map", "reduce", []string{"only chunk"}, 1000)
+	if single != "only" {
+		t.Errorf("expected a single chunk to skip the reduce step, got %q", single)
+	}
+}
@@ -0,0 +1,129 @@
+package analytics
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Group-by dimensions supported by Aggregate
+const (
+	GroupByIntent  = "intent"
+	GroupByAgent   = "agent"
+	GroupByOutcome = "outcome"
+	GroupByTenant  = "tenant"
+)
+
+// IntentStats summarizes the records sharing one group-by value
+type IntentStats struct {
+	Key           string          `json:"key"`
+	Count         int             `json:"count"`
+	SuccessRate   float64         `json:"success_rate"` // share of records with outcome success
+	AvgConfidence float64         `json:"avg_confidence"`
+	AvgLatencyMS  int64           `json:"avg_latency_ms"`
+	P95LatencyMS  int64           `json:"p95_latency_ms"`
+	Outcomes      map[Outcome]int `json:"outcomes"`
+	ThumbsUp      int             `json:"thumbs_up"`
+	ThumbsDown    int             `json:"thumbs_down"`
+	Agents        map[string]int  `json:"agents,omitempty"` // agents selected for the group; empty when grouping by agent
+}
+
+// Aggregate groups records by intent, agent, outcome or tenant, busiest group first
+func Aggregate(records []IntentRecord, groupBy string) ([]IntentStats, error) {
+	key, err := groupKey(groupBy)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := map[string][]IntentRecord{}
+	for _, r := range records {
+		k := key(r)
+		groups[k] = append(groups[k], r)
+	}
+
+	stats := make([]IntentStats, 0, len(groups))
+	for k, group := range groups {
+		s := summarize(group)
+		s.Key = k
+		if groupBy == GroupByAgent {
+			s.Agents = nil
+		}
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Count != stats[j].Count {
+			return stats[i].Count > stats[j].Count
+		}
+		return stats[i].Key < stats[j].Key
+	})
+	return stats, nil
+}
+
+// Misrouted returns records that suggest the intent prompt or agent capabilities need tuning:
+// requests no agent could handle, agent errors and timeouts, responses users rated down, and
+// classifications with a reported confidence below minConfidence.
+func Misrouted(records []IntentRecord, minConfidence float64) []IntentRecord {
+	var suspects []IntentRecord
+	for _, r := range records {
+		switch {
+		case r.Outcome == OutcomeUnroutable, r.Outcome == OutcomeError, r.Outcome == OutcomeTimeout, r.Feedback == "down":
+			suspects = append(suspects, r)
+		case r.Confidence > 0 && r.Confidence < minConfidence:
+			suspects = append(suspects, r)
+		}
+	}
+	return suspects
+}
+
+func groupKey(groupBy string) (func(IntentRecord) string, error) {
+	switch groupBy {
+	case GroupByIntent, "":
+		return func(r IntentRecord) string { return r.Intent }, nil
+	case GroupByAgent:
+		return func(r IntentRecord) string { return r.Agent }, nil
+	case GroupByOutcome:
+		return func(r IntentRecord) string { return string(r.Outcome) }, nil
+	case GroupByTenant:
+		return func(r IntentRecord) string { return r.Tenant }, nil
+	default:
+		return nil, fmt.Errorf("unsupported group_by %q (expected intent, agent, outcome or tenant)", groupBy)
+	}
+}
+
+func summarize(records []IntentRecord) IntentStats {
+	s := IntentStats{Count: len(records), Outcomes: map[Outcome]int{}, Agents: map[string]int{}}
+
+	var successes, confident int
+	var confidence float64
+	var latency int64
+	latencies := make([]int64, 0, len(records))
+	for _, r := range records {
+		s.Outcomes[r.Outcome]++
+		switch r.Feedback {
+		case "up":
+			s.ThumbsUp++
+		case "down":
+			s.ThumbsDown++
+		}
+		if r.Agent != "" {
+			s.Agents[r.Agent]++
+		}
+		if r.Outcome == OutcomeSuccess {
+			successes++
+		}
+		if r.Confidence > 0 {
+			confident++
+			confidence += r.Confidence
+		}
+		latency += r.LatencyMS
+		latencies = append(latencies, r.LatencyMS)
+	}
+
+	s.SuccessRate = float64(successes) / float64(len(records))
+	if confident > 0 {
+		s.AvgConfidence = confidence / float64(confident)
+	}
+	s.AvgLatencyMS = latency / int64(len(records))
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	s.P95LatencyMS = latencies[(len(latencies)*95+99)/100-1]
+	return s
+}
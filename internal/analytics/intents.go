@@ -0,0 +1,211 @@
+// Package analytics records how the orchestrator classifies and routes chat requests so
+// platform teams can find misrouted intents and tune prompts and agent capabilities.
+package analytics
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/krzachariassen/ZTDP/internal/redaction"
+)
+
+// Outcome is how a classified request ended
+type Outcome string
+
+const (
+	OutcomeSuccess      Outcome = "success"      // the selected agent completed the request
+	OutcomeError        Outcome = "error"        // the selected agent reported an error
+	OutcomeTimeout      Outcome = "timeout"      // the selected agent did not answer in time
+	OutcomeUnroutable   Outcome = "unroutable"   // no agent could handle the detected intent
+	OutcomeConversation Outcome = "conversation" // answered as general conversation
+	OutcomeFallback     Outcome = "fallback"     // handled by deterministic handlers without AI
+	OutcomeFailed       Outcome = "failed"       // the orchestrator returned an error
+)
+
+// IntentRecord is one intent classification and what happened to it
+type IntentRecord struct {
+	Timestamp      time.Time `json:"timestamp"`
+	CorrelationID  string    `json:"correlation_id,omitempty"`
+	ConversationID string    `json:"conversation_id,omitempty"`
+	Tenant         string    `json:"tenant,omitempty"`
+	Message        string    `json:"message"`
+	Intent         string    `json:"intent"`
+	Agent          string    `json:"agent,omitempty"`
+	Confidence     float64   `json:"confidence,omitempty"` // 0 when neither the orchestrator nor the agent reported one
+	Outcome        Outcome   `json:"outcome"`
+	LatencyMS      int64     `json:"latency_ms"`
+	Feedback       string    `json:"feedback,omitempty"` // latest user rating of the response: up or down
+}
+
+// IntentQuery filters recorded classifications. Zero values match everything.
+type IntentQuery struct {
+	Intent        string
+	Agent         string
+	Outcome       Outcome
+	Tenant        string
+	Feedback      string
+	MaxConfidence float64 // records with a higher confidence are excluded; 0 disables the filter
+	Since         time.Time
+	Until         time.Time
+	Limit         int // most recent records to return; 0 means all matches
+}
+
+// Matches reports whether a record satisfies the query filters (Limit is applied by the store)
+func (q IntentQuery) Matches(r IntentRecord) bool {
+	if q.Intent != "" && r.Intent != q.Intent {
+		return false
+	}
+	if q.Agent != "" && r.Agent != q.Agent {
+		return false
+	}
+	if q.Outcome != "" && r.Outcome != q.Outcome {
+		return false
+	}
+	if q.Tenant != "" && r.Tenant != q.Tenant {
+		return false
+	}
+	if q.Feedback != "" && r.Feedback != q.Feedback {
+		return false
+	}
+	if q.MaxConfidence > 0 && r.Confidence > q.MaxConfidence {
+		return false
+	}
+	if !q.Since.IsZero() && r.Timestamp.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && r.Timestamp.After(q.Until) {
+		return false
+	}
+	return true
+}
+
+// ErrRecordNotFound is returned when no retained record has the given correlation ID
+var ErrRecordNotFound = errors.New("intent record not found")
+
+// Store retains intent records so they can be queried and aggregated
+type Store interface {
+	Record(r IntentRecord) error
+	// Query returns matching records in chronological order
+	Query(ctx context.Context, q IntentQuery) ([]IntentRecord, error)
+	// SetFeedback attaches a user rating to the record with the given correlation ID
+	SetFeedback(correlationID, rating string) error
+}
+
+// MemoryStore retains the most recent intent records in memory
+type MemoryStore struct {
+	mu      sync.RWMutex
+	records []IntentRecord
+	next    int
+	full    bool
+}
+
+// NewMemoryStore creates an in-memory store holding at most capacity records
+func NewMemoryStore(capacity int) *MemoryStore {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &MemoryStore{records: make([]IntentRecord, capacity)}
+}
+
+// Record retains a record, overwriting the oldest one once the store is full
+func (s *MemoryStore) Record(r IntentRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[s.next] = r
+	s.next = (s.next + 1) % len(s.records)
+	if s.next == 0 {
+		s.full = true
+	}
+	return nil
+}
+
+// Query returns matching records in chronological order
+func (s *MemoryStore) Query(ctx context.Context, q IntentQuery) ([]IntentRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	start, count := 0, s.next
+	if s.full {
+		start, count = s.next, len(s.records)
+	}
+
+	var matches []IntentRecord
+	for i := 0; i < count; i++ {
+		r := s.records[(start+i)%len(s.records)]
+		if q.Matches(r) {
+			matches = append(matches, r)
+		}
+	}
+	if q.Limit > 0 && len(matches) > q.Limit {
+		matches = matches[len(matches)-q.Limit:]
+	}
+	return matches, nil
+}
+
+// SetFeedback attaches a user rating to the most recent record with the correlation ID
+func (s *MemoryStore) SetFeedback(correlationID, rating string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := 1; i <= len(s.records); i++ {
+		idx := (s.next - i + len(s.records)) % len(s.records)
+		if !s.full && idx >= s.next {
+			break
+		}
+		if correlationID != "" && s.records[idx].CorrelationID == correlationID {
+			s.records[idx].Feedback = rating
+			return nil
+		}
+	}
+	return ErrRecordNotFound
+}
+
+// Collector records intent classifications into a store. Messages are redacted first so
+// analytics never retain secrets users pasted into chat.
+type Collector struct {
+	store  Store
+	logger *logging.Logger
+	now    func() time.Time
+}
+
+// NewCollector creates a collector writing to store
+func NewCollector(store Store) *Collector {
+	return &Collector{
+		store:  store,
+		logger: logging.GetLogger().ForComponent("analytics"),
+		now:    time.Now,
+	}
+}
+
+// Store returns the store the collector writes to
+func (c *Collector) Store() Store {
+	return c.store
+}
+
+// Record stores a classification. Failures are logged rather than returned because
+// analytics must never fail a chat request.
+func (c *Collector) Record(ctx context.Context, r IntentRecord) {
+	if r.Timestamp.IsZero() {
+		r.Timestamp = c.now().UTC()
+	}
+	if r.CorrelationID == "" {
+		r.CorrelationID = logging.CorrelationIDFromContext(ctx)
+	}
+	r.Message = redaction.Default().String(r.Message)
+
+	if err := c.store.Record(r); err != nil {
+		c.logger.Warn("⚠️ Failed to record intent analytics: %v", err)
+	}
+}
+
+// RecordFeedback attaches a user's rating of a response to the classification that produced
+// it, so poorly rated intents show up in aggregates and the misrouted list
+func (c *Collector) RecordFeedback(correlationID, rating string) {
+	if err := c.store.SetFeedback(correlationID, rating); err != nil {
+		c.logger.Debug("Feedback for %s not attached to intent analytics: %v", correlationID, err)
+	}
+}
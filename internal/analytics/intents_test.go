@@ -0,0 +1,95 @@
+package analytics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_KeepsMostRecentAndFilters(t *testing.T) {
+	store := NewMemoryStore(3)
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, intent := range []string{"create application", "deploy application", "create application", "list services"} {
+		require.NoError(t, store.Record(IntentRecord{Timestamp: base.Add(time.Duration(i) * time.Minute), Intent: intent, Outcome: OutcomeSuccess}))
+	}
+
+	all, err := store.Query(context.Background(), IntentQuery{})
+	require.NoError(t, err)
+	require.Len(t, all, 3, "the oldest record is overwritten")
+	assert.Equal(t, "deploy application", all[0].Intent)
+
+	created, err := store.Query(context.Background(), IntentQuery{Intent: "create application"})
+	require.NoError(t, err)
+	assert.Len(t, created, 1)
+
+	recent, err := store.Query(context.Background(), IntentQuery{Since: base.Add(2 * time.Minute), Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, recent, 1)
+	assert.Equal(t, "list services", recent[0].Intent)
+}
+
+func TestAggregate_ByIntentAndAgent(t *testing.T) {
+	records := []IntentRecord{
+		{Intent: "deploy application", Agent: "deployment-agent", Outcome: OutcomeSuccess, Confidence: 0.9, LatencyMS: 100},
+		{Intent: "deploy application", Agent: "deployment-agent", Outcome: OutcomeTimeout, LatencyMS: 300},
+		{Intent: "create application", Agent: "application-agent", Outcome: OutcomeSuccess, Confidence: 0.5, LatencyMS: 50},
+	}
+
+	byIntent, err := Aggregate(records, GroupByIntent)
+	require.NoError(t, err)
+	require.Len(t, byIntent, 2)
+	deploy := byIntent[0]
+	assert.Equal(t, "deploy application", deploy.Key)
+	assert.Equal(t, 2, deploy.Count)
+	assert.Equal(t, 0.5, deploy.SuccessRate)
+	assert.Equal(t, 0.9, deploy.AvgConfidence, "records without a confidence are not averaged in")
+	assert.Equal(t, int64(200), deploy.AvgLatencyMS)
+	assert.Equal(t, int64(300), deploy.P95LatencyMS)
+	assert.Equal(t, map[string]int{"deployment-agent": 2}, deploy.Agents)
+
+	byAgent, err := Aggregate(records, GroupByAgent)
+	require.NoError(t, err)
+	assert.Nil(t, byAgent[0].Agents)
+
+	_, err = Aggregate(records, "color")
+	assert.Error(t, err)
+
+	suspects := Misrouted(records, 0.7)
+	require.Len(t, suspects, 2)
+	assert.Equal(t, OutcomeTimeout, suspects[0].Outcome)
+	assert.Equal(t, "create application", suspects[1].Intent)
+}
+
+func TestCollector_RedactsMessages(t *testing.T) {
+	store := NewMemoryStore(1)
+	NewCollector(store).Record(context.Background(), IntentRecord{Message: "deploy with token=supersecret123", Intent: "deploy application"})
+
+	records, err := store.Query(context.Background(), IntentQuery{})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.NotContains(t, records[0].Message, "supersecret123")
+	assert.False(t, records[0].Timestamp.IsZero())
+}
+
+func TestCollector_RecordFeedback(t *testing.T) {
+	store := NewMemoryStore(5)
+	collector := NewCollector(store)
+	collector.Record(context.Background(), IntentRecord{CorrelationID: "corr-1", Intent: "deploy application", Outcome: OutcomeSuccess})
+	collector.Record(context.Background(), IntentRecord{CorrelationID: "corr-2", Intent: "create application", Outcome: OutcomeSuccess})
+
+	collector.RecordFeedback("corr-1", "down")
+	assert.ErrorIs(t, store.SetFeedback("corr-9", "up"), ErrRecordNotFound)
+
+	rated, err := store.Query(context.Background(), IntentQuery{Feedback: "down"})
+	require.NoError(t, err)
+	require.Len(t, rated, 1)
+	assert.Equal(t, "deploy application", rated[0].Intent)
+
+	stats, err := Aggregate(rated, GroupByIntent)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats[0].ThumbsDown)
+	assert.Len(t, Misrouted(rated, 0.7), 1, "responses rated down are flagged")
+}
@@ -31,7 +31,9 @@ func (s *Service) CreateApplication(app contracts.ApplicationContract) error {
 	}
 
 	node, _ := graph.ResolveContract(app)
-	s.Graph.AddNode(node)
+	if err := s.Graph.AddNode(node); err != nil {
+		return err
+	}
 
 	// Save the graph
 	if err := s.Graph.Save(); err != nil {
@@ -60,7 +62,9 @@ func (s *Service) CreateApplicationFromContract(ctx context.Context, app *contra
 	}
 
 	node, _ := graph.ResolveContract(*app)
-	s.Graph.AddNode(node)
+	if err := s.Graph.AddNode(node); err != nil {
+		return nil, err
+	}
 
 	// Save the graph
 	if err := s.Graph.Save(); err != nil {
@@ -150,7 +154,9 @@ func (s *Service) UpdateApplication(appName string, app contracts.ApplicationCon
 	}
 
 	node, _ := graph.ResolveContract(app)
-	s.Graph.AddNode(node)
+	if err := s.Graph.AddNode(node); err != nil {
+		return err
+	}
 
 	// Save the graph
 	if err := s.Graph.Save(); err != nil {
@@ -173,19 +179,8 @@ func (s *Service) DeleteApplication(appName string) error {
 	// Mark node as deleted by updating its metadata
 	node.Metadata["deleted"] = true
 
-	// Get current graph and update the node
-	currentGraph, err := s.Graph.Graph()
-	if err != nil {
-		return err
-	}
-
-	err = currentGraph.UpdateNode(node)
-	if err != nil {
-		return err
-	}
-
-	// Save the graph
-	if err := s.Graph.Save(); err != nil {
+	// Persist the update through the graph API so it works on every backend
+	if err := s.Graph.UpdateNode(node); err != nil {
 		return err
 	}
 
@@ -6,13 +6,15 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/krzachariassen/ZTDP/internal/agentFramework"
 	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
 	"github.com/krzachariassen/ZTDP/internal/ai"
 	"github.com/krzachariassen/ZTDP/internal/contracts"
 	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/features"
 	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/guardrails"
 	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
 )
 
 // AIResponse represents the structure of AI responses for parameter extraction
@@ -65,13 +67,14 @@ func NewApplicationAgent(
 	}
 
 	// Create dependencies for the framework
-	deps := agentFramework.AgentDependencies{
+	deps := agentframework.AgentDependencies{
 		Registry: registry,
 		EventBus: eventBus,
+		Flags:    features.NewService(graph),
 	}
 
 	// Build the agent using the framework
-	agent, err := agentFramework.NewAgent("application-agent").
+	agent, err := agentframework.NewAgent("application-agent").
 		WithType("application").
 		WithCapabilities(getApplicationCapabilities()).
 		WithEventHandler(wrapper.handleEvent).
@@ -156,12 +159,12 @@ func (a *ApplicationAgent) handleApplicationEvent(ctx context.Context, event *ev
 		aiResponse.Action, aiResponse.ApplicationName, aiResponse.Confidence)
 
 	// Check confidence level - request clarification if too low
-	if aiResponse.Confidence < 0.7 {
+	if agentframework.NeedsClarification(ctx, aiResponse.Confidence) {
 		clarificationMsg := aiResponse.Clarification
 		if clarificationMsg == "" {
 			clarificationMsg = fmt.Sprintf("I'm not completely sure what you want to do (confidence: %.0f%%). Could you please clarify your request?", aiResponse.Confidence*100)
 		}
-		return a.createClarificationResponse(event, clarificationMsg), nil
+		return agentframework.ClarificationResponse(ctx, event, clarificationMsg, aiResponse.Confidence), nil
 	}
 
 	// Route to appropriate handler based on AI-extracted action
@@ -175,7 +178,7 @@ func (a *ApplicationAgent) handleApplicationEvent(ctx context.Context, event *ev
 	case "delete", "remove":
 		return a.handleApplicationDelete(ctx, event, aiResponse)
 	default:
-		return a.createClarificationResponse(event, fmt.Sprintf("I'm not sure how to '%s' applications. I can list, create, update, or delete applications.", aiResponse.Action)), nil
+		return agentframework.ClarificationResponse(ctx, event, fmt.Sprintf("I'm not sure how to '%s' applications. I can list, create, update, or delete applications.", aiResponse.Action), aiResponse.Confidence), nil
 	}
 }
 
@@ -202,11 +205,14 @@ Set confidence < 0.8 if:
 Examples:
 - "list all applications" -> {"action": "list", "confidence": 0.9}
 - "create app called myapp" -> {"action": "create", "application_name": "myapp", "confidence": 0.9}
-- "do something" -> {"action": "unknown", "confidence": 0.2, "clarification": "What would you like to do with applications?"}`
+- "do something" -> {"action": "unknown", "confidence": 0.2, "clarification": "What would you like to do with applications?"}
+
+The application contract you are collecting parameters for:
+` + contracts.PromptSummary(contracts.ApplicationContract{}.Kind())
 
 	userPrompt := fmt.Sprintf("Parse this application request: %s", userMessage)
 
-	aiResponseText, err := a.aiProvider.CallAI(ctx, systemPrompt, userPrompt)
+	aiResponseText, err := a.aiProvider.CallAI(ai.WithTask(ctx, ai.TaskExtraction), systemPrompt, userPrompt)
 	if err != nil {
 		return nil, fmt.Errorf("AI call failed: %w", err)
 	}
@@ -255,7 +261,16 @@ func (a *ApplicationAgent) handleApplicationCreate(ctx context.Context, event *e
 
 	// Validate required parameters
 	if aiResponse.ApplicationName == "" {
-		return a.createClarificationResponse(event, "What would you like to name the new application?"), nil
+		return agentframework.ClarificationResponse(ctx, event, "What would you like to name the new application?", aiResponse.Confidence), nil
+	}
+
+	if blocked := a.checkGuardrails(ctx, event, guardrails.Action{
+		Operation:   guardrails.OperationCreate,
+		Kind:        graph.KindApplication,
+		Targets:     []string{aiResponse.ApplicationName},
+		Application: aiResponse.ApplicationName,
+	}); blocked != nil {
+		return blocked, nil
 	}
 
 	// Create application contract
@@ -292,7 +307,7 @@ func (a *ApplicationAgent) handleApplicationUpdate(ctx context.Context, event *e
 
 	// Validate required parameters
 	if aiResponse.ApplicationName == "" {
-		return a.createClarificationResponse(event, "Which application would you like to update?"), nil
+		return agentframework.ClarificationResponse(ctx, event, "Which application would you like to update?", aiResponse.Confidence), nil
 	}
 
 	// For now, return a placeholder since update logic depends on what fields to update
@@ -313,7 +328,17 @@ func (a *ApplicationAgent) handleApplicationDelete(ctx context.Context, event *e
 
 	// Validate required parameters
 	if aiResponse.ApplicationName == "" {
-		return a.createClarificationResponse(event, "Which application would you like to delete?"), nil
+		return agentframework.ClarificationResponse(ctx, event, "Which application would you like to delete?", aiResponse.Confidence), nil
+	}
+
+	// The application's services and other owned nodes go with it
+	if blocked := a.checkGuardrails(ctx, event, guardrails.Action{
+		Operation:   guardrails.OperationDelete,
+		Kind:        graph.KindApplication,
+		Targets:     guardrails.Footprint(a.service.Graph, aiResponse.ApplicationName),
+		Application: aiResponse.ApplicationName,
+	}); blocked != nil {
+		return blocked, nil
 	}
 
 	// Use service to delete application
@@ -333,6 +358,20 @@ func (a *ApplicationAgent) handleApplicationDelete(ctx context.Context, event *e
 	return a.createSuccessResponse(event, payload), nil
 }
 
+// checkGuardrails returns the response to send instead of executing an AI-proposed action,
+// or nil when the guardrails allow it
+func (a *ApplicationAgent) checkGuardrails(ctx context.Context, event *events.Event, action guardrails.Action) *events.Event {
+	action.Source = "application-agent"
+	decision := guardrails.Default().Check(ctx, action)
+	switch decision.Outcome {
+	case guardrails.Reject:
+		return a.createErrorResponse(event, decision.Message())
+	case guardrails.RequireApproval, guardrails.Propose:
+		return a.createClarificationResponse(event, decision.Message())
+	}
+	return nil
+}
+
 // Response helper methods
 
 // getCorrelationID extracts the correlation ID from the orchestrator's event payload
@@ -5,11 +5,11 @@ import (
 	"os"
 	"testing"
 
-	"github.com/krzachariassen/ZTDP/internal/agentFramework"
 	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
 	"github.com/krzachariassen/ZTDP/internal/ai"
 	"github.com/krzachariassen/ZTDP/internal/events"
 	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -116,7 +116,7 @@ func TestApplicationAgentEventHandling(t *testing.T) {
 	}
 
 	// Cast to framework agent to access ProcessEvent
-	agent, ok := baseAgent.(*agentFramework.BaseAgent)
+	agent, ok := baseAgent.(*agentframework.BaseAgent)
 	if !ok {
 		t.Fatalf("Expected BaseAgent, got %T", baseAgent)
 	}
@@ -216,7 +216,7 @@ func TestApplicationAgentAIIntegration(t *testing.T) {
 				t.Fatalf("Failed to create agent: %v", err)
 			}
 
-			agent, ok := baseAgent.(*agentFramework.BaseAgent)
+			agent, ok := baseAgent.(*agentframework.BaseAgent)
 			if !ok {
 				t.Fatalf("Expected BaseAgent, got %T", baseAgent)
 			}
@@ -0,0 +1,279 @@
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+)
+
+// applicationCollection names application batches in the archive
+const applicationCollection = "applications"
+
+var (
+	// ErrApplicationNotFound is returned when archiving an application that is not in the graph
+	ErrApplicationNotFound = errors.New("application not found")
+	// ErrRestoreConflict is returned when restoring would overwrite nodes created since archiving
+	ErrRestoreConflict = errors.New("restore conflicts with the current graph")
+	// ErrDeploymentActive is returned when archiving an application with a deployment in progress
+	ErrDeploymentActive = errors.New("application has a deployment in progress")
+)
+
+// activeDeploymentStatuses are deployment edge statuses of rollouts that have not ended
+var activeDeploymentStatuses = map[string]bool{"pending": true, "in-progress": true, "in_progress": true}
+
+// ApplicationSnapshot is an archived application: its subgraph and deployment history as they
+// were when it was archived
+type ApplicationSnapshot struct {
+	Application string                  `json:"application"`
+	Nodes       map[string]*graph.Node  `json:"nodes"` // the application and everything belonging to it
+	Edges       map[string][]graph.Edge `json:"edges"` // by source: edges from, to and deploying those nodes
+	Reason      string                  `json:"reason,omitempty"`
+	ArchivedBy  string                  `json:"archived_by,omitempty"`
+	ArchivedAt  time.Time               `json:"archived_at"`
+}
+
+// ArchivedApplication is an application whose latest snapshot is in the archive and that is not
+// in the graph
+type ArchivedApplication struct {
+	Application string    `json:"application"`
+	ArchivedAt  time.Time `json:"archived_at"`
+	Batch       string    `json:"batch"`
+}
+
+// RestoreResult reports what restoring an application brought back
+type RestoreResult struct {
+	Application string   `json:"application"`
+	Nodes       int      `json:"nodes"`
+	Edges       int      `json:"edges"`
+	Skipped     []string `json:"skipped,omitempty"` // edges from or to nodes deleted since archiving
+}
+
+// ArchiveApplication exports a retired application to the archive and removes it from the
+// graph, and with it from the AI's context. Besides the application, the snapshot holds the
+// nodes it owns (directly or through owned nodes), their versions, the nodes recording their
+// history (e.g. migrations and policy decisions naming the application), every edge from or to
+// those nodes, and the deployment edges of its releases.
+func (a *Archive) ArchiveApplication(ctx context.Context, app, archivedBy, reason string) (*ApplicationSnapshot, error) {
+	current, err := a.graph.Graph()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph: %w", err)
+	}
+	snapshot, err := applicationSnapshot(current, app)
+	if err != nil {
+		return nil, err
+	}
+	for _, edges := range snapshot.Edges {
+		for _, edge := range edges {
+			status, _ := edge.Metadata["status"].(string)
+			if isDeployment(edge) && activeDeploymentStatuses[status] {
+				return nil, fmt.Errorf("%w: %s is %s in %s", ErrDeploymentActive, app, status, edge.To)
+			}
+		}
+	}
+	snapshot.Reason = reason
+	snapshot.ArchivedBy = archivedBy
+	snapshot.ArchivedAt = a.now().UTC()
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode application %s: %w", app, err)
+	}
+	labels := []string{"application:" + app}
+	if archivedBy != "" {
+		labels = append(labels, "archived_by:"+archivedBy)
+	}
+	record := Record{Ref: Ref{ID: app, Time: snapshot.ArchivedAt, Labels: labels}, Data: data}
+	if _, err := a.Write(ctx, applicationCollection, []Record{record}); err != nil {
+		return nil, err
+	}
+
+	// The snapshot is stored; only now does the application leave the graph
+	err = a.graph.Update(func(current *graph.Graph) error {
+		for id := range snapshot.Nodes {
+			if _, ok := current.Nodes[id]; ok {
+				current.DeleteNode(id)
+			}
+		}
+		for from, edges := range snapshot.Edges {
+			if _, archived := snapshot.Nodes[from]; archived {
+				continue
+			}
+			current.Edges[from] = withoutEdges(current.Edges[from], edges)
+			if len(current.Edges[from]) == 0 {
+				delete(current.Edges, from)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("archived %s but failed to remove it from the graph: %w", app, err)
+	}
+	a.logger.Info("🗄️ Archived application %s: %d nodes and their edges left the graph", app, len(snapshot.Nodes))
+	return snapshot, nil
+}
+
+// RestoreApplication puts the latest archived snapshot of an application back into the graph.
+// Nodes created with the same IDs since it was archived make the restore fail; edges from or
+// to nodes deleted since are skipped. Deployment edges start at releases, which are not nodes.
+func (a *Archive) RestoreApplication(ctx context.Context, app string) (*RestoreResult, error) {
+	snapshot, err := a.ApplicationSnapshot(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+	result := &RestoreResult{Application: app}
+	err = a.graph.Update(func(current *graph.Graph) error {
+		var conflicts []string
+		for id := range snapshot.Nodes {
+			if _, exists := current.Nodes[id]; exists {
+				conflicts = append(conflicts, id)
+			}
+		}
+		if len(conflicts) > 0 {
+			sort.Strings(conflicts)
+			return fmt.Errorf("%w: %s already exist", ErrRestoreConflict, strings.Join(conflicts, ", "))
+		}
+
+		for id, node := range snapshot.Nodes {
+			current.Nodes[id] = node
+			result.Nodes++
+		}
+		for from, edges := range snapshot.Edges {
+			for _, edge := range edges {
+				_, fromExists := current.Nodes[from]
+				if _, toExists := current.Nodes[edge.To]; !toExists || (!fromExists && !isDeployment(edge)) {
+					result.Skipped = append(result.Skipped, fmt.Sprintf("%s -[%s]-> %s", from, edge.Type, edge.To))
+					continue
+				}
+				if hasEdge(current.Edges[from], edge) {
+					continue
+				}
+				current.Edges[from] = append(current.Edges[from], edge)
+				result.Edges++
+			}
+		}
+		sort.Strings(result.Skipped)
+		return nil
+	})
+	if errors.Is(err, ErrRestoreConflict) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore %s: %w", app, err)
+	}
+	a.logger.Info("♻️ Restored application %s: %d nodes, %d edges", app, result.Nodes, result.Edges)
+	return result, nil
+}
+
+// ApplicationSnapshot returns the latest archived snapshot of an application
+func (a *Archive) ApplicationSnapshot(ctx context.Context, app string) (*ApplicationSnapshot, error) {
+	data, err := a.Get(ctx, applicationCollection, app)
+	if err != nil {
+		return nil, err
+	}
+	var snapshot ApplicationSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to decode archived application %s: %w", app, err)
+	}
+	return &snapshot, nil
+}
+
+// ArchivedApplications lists the applications in the archive that are not in the graph, by name
+func (a *Archive) ArchivedApplications() ([]ArchivedApplication, error) {
+	manifests, err := a.Manifests(applicationCollection)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := a.graph.Nodes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph: %w", err)
+	}
+	latest := map[string]ArchivedApplication{}
+	for _, manifest := range manifests {
+		for _, ref := range manifest.Records {
+			latest[ref.ID] = ArchivedApplication{Application: ref.ID, ArchivedAt: ref.Time, Batch: manifest.Name}
+		}
+	}
+	list := []ArchivedApplication{}
+	for app, archived := range latest {
+		if _, restored := nodes[app]; !restored {
+			list = append(list, archived)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Application < list[j].Application })
+	return list, nil
+}
+
+// applicationSnapshot collects what belongs to the application in g
+func applicationSnapshot(g *graph.Graph, app string) (*ApplicationSnapshot, error) {
+	root, ok := g.Nodes[app]
+	if !ok || root.Kind != graph.KindApplication {
+		return nil, fmt.Errorf("%w: %s", ErrApplicationNotFound, app)
+	}
+
+	snapshot := &ApplicationSnapshot{Application: app, Nodes: map[string]*graph.Node{}, Edges: map[string][]graph.Edge{}}
+	queue := []string{app}
+	for id, node := range g.Nodes {
+		if id != app && node.Kind != graph.KindApplication && namesApplication(node, app) {
+			queue = append(queue, id)
+		}
+	}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if _, seen := snapshot.Nodes[id]; seen {
+			continue
+		}
+		snapshot.Nodes[id] = g.Nodes[id]
+		for _, edge := range g.Edges[id] {
+			if _, ok := g.Nodes[edge.To]; ok && (edge.Type == graph.EdgeTypeOwns || edge.Type == graph.EdgeTypeHasVersion) {
+				queue = append(queue, edge.To)
+			}
+		}
+	}
+
+	for from, edges := range g.Edges {
+		_, fromArchived := snapshot.Nodes[from]
+		for _, edge := range edges {
+			_, toArchived := snapshot.Nodes[edge.To]
+			if fromArchived || toArchived || (isDeployment(edge) && edge.Metadata["application"] == app) {
+				snapshot.Edges[from] = append(snapshot.Edges[from], edge)
+			}
+		}
+	}
+	return snapshot, nil
+}
+
+// namesApplication reports whether a node records the application as the one it belongs to
+func namesApplication(node *graph.Node, app string) bool {
+	return node.Spec["application"] == app || node.Metadata["application"] == app
+}
+
+func isDeployment(edge graph.Edge) bool {
+	return edge.Type == graph.EdgeTypeDeploy || edge.Type == "deployment"
+}
+
+// withoutEdges returns edges minus those in removed, matched by target, type and metadata
+func withoutEdges(edges, removed []graph.Edge) []graph.Edge {
+	kept := make([]graph.Edge, 0, len(edges))
+	for _, edge := range edges {
+		if !hasEdge(removed, edge) {
+			kept = append(kept, edge)
+		}
+	}
+	return kept
+}
+
+func hasEdge(edges []graph.Edge, edge graph.Edge) bool {
+	for _, other := range edges {
+		if other.To == edge.To && other.Type == edge.Type && fmt.Sprint(other.Metadata) == fmt.Sprint(edge.Metadata) {
+			return true
+		}
+	}
+	return false
+}
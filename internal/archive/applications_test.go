@@ -0,0 +1,127 @@
+package archive
+
+import (
+	"context"
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seedApplications stores checkout with a service, a version, a migration and a production
+// deployment, and billing, whose service depends on checkout's
+func seedApplications(t *testing.T, g *graph.GlobalGraph) {
+	t.Helper()
+	current := graph.NewGraph()
+	node := func(id, kind string, spec map[string]interface{}) {
+		if spec == nil {
+			spec = map[string]interface{}{}
+		}
+		current.Nodes[id] = &graph.Node{ID: id, Kind: kind, Metadata: map[string]interface{}{"name": id}, Spec: spec}
+	}
+	edge := func(from, to, edgeType string, metadata map[string]interface{}) {
+		current.Edges[from] = append(current.Edges[from], graph.Edge{To: to, Type: edgeType, Metadata: metadata})
+	}
+	node("checkout", graph.KindApplication, nil)
+	node("checkout-api", graph.KindService, nil)
+	node("checkout-api:1.0.0", graph.KindServiceVersion, nil)
+	node("migration-1", graph.KindMigration, map[string]interface{}{"application": "checkout"})
+	node("billing", graph.KindApplication, nil)
+	node("billing-api", graph.KindService, nil)
+	node("production", graph.KindEnvironment, nil)
+	edge("checkout", "checkout-api", graph.EdgeTypeOwns, nil)
+	edge("checkout", "production", "allowed_in", nil)
+	edge("checkout-api", "checkout-api:1.0.0", graph.EdgeTypeHasVersion, nil)
+	edge("billing", "billing-api", graph.EdgeTypeOwns, nil)
+	edge("billing-api", "checkout-api", "depends_on", nil)
+	edge("release-checkout-1", "production", "deployment", map[string]interface{}{"application": "checkout", "status": "succeeded"})
+	require.NoError(t, g.Backend.SaveGlobal(current))
+}
+
+func TestArchiveAndRestoreApplication(t *testing.T) {
+	a, g := newTestArchive(t)
+	ctx := context.Background()
+	seedApplications(t, g)
+
+	snapshot, err := a.ArchiveApplication(ctx, "checkout", "alice", "retired in favour of payments")
+	require.NoError(t, err)
+	ids := []string{}
+	for id := range snapshot.Nodes {
+		ids = append(ids, id)
+	}
+	assert.ElementsMatch(t, []string{"checkout", "checkout-api", "checkout-api:1.0.0", "migration-1"}, ids)
+	assert.Len(t, snapshot.Edges["billing-api"], 1, "edges into the application are kept")
+	assert.Len(t, snapshot.Edges["release-checkout-1"], 1, "deployment history is kept")
+
+	current, err := g.Graph()
+	require.NoError(t, err)
+	for _, id := range []string{"checkout", "checkout-api", "checkout-api:1.0.0", "migration-1"} {
+		assert.NotContains(t, current.Nodes, id)
+	}
+	assert.Contains(t, current.Nodes, "billing-api")
+	assert.Empty(t, current.Edges["billing-api"])
+	assert.NotContains(t, current.Edges, "release-checkout-1")
+
+	archived, err := a.ArchivedApplications()
+	require.NoError(t, err)
+	require.Len(t, archived, 1)
+	assert.Equal(t, "checkout", archived[0].Application)
+
+	result, err := a.RestoreApplication(ctx, "checkout")
+	require.NoError(t, err)
+	assert.Equal(t, &RestoreResult{Application: "checkout", Nodes: 4, Edges: 5}, result)
+	current, err = g.Graph()
+	require.NoError(t, err)
+	assert.Contains(t, current.Nodes, "checkout-api:1.0.0")
+	assert.Len(t, current.Edges["billing-api"], 1)
+	assert.Equal(t, "succeeded", current.Edges["release-checkout-1"][0].Metadata["status"])
+
+	archived, err = a.ArchivedApplications()
+	require.NoError(t, err)
+	assert.Empty(t, archived, "restored applications are no longer listed")
+
+	_, err = a.RestoreApplication(ctx, "checkout")
+	assert.ErrorIs(t, err, ErrRestoreConflict)
+}
+
+func TestRestoreSkipsEdgesOfDeletedNodes(t *testing.T) {
+	a, g := newTestArchive(t)
+	ctx := context.Background()
+	seedApplications(t, g)
+
+	_, err := a.ArchiveApplication(ctx, "checkout", "", "")
+	require.NoError(t, err)
+	require.NoError(t, g.DeleteNode("billing-api"))
+	require.NoError(t, g.DeleteNode("production"))
+
+	result, err := a.RestoreApplication(ctx, "checkout")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"billing-api -[depends_on]-> checkout-api",
+		"checkout -[allowed_in]-> production",
+		"release-checkout-1 -[deployment]-> production",
+	}, result.Skipped)
+	assert.Equal(t, 2, result.Edges)
+}
+
+func TestArchiveApplicationRefusals(t *testing.T) {
+	a, g := newTestArchive(t)
+	ctx := context.Background()
+	seedApplications(t, g)
+
+	_, err := a.ArchiveApplication(ctx, "unknown", "", "")
+	assert.ErrorIs(t, err, ErrApplicationNotFound)
+	_, err = a.ArchiveApplication(ctx, "billing-api", "", "")
+	assert.ErrorIs(t, err, ErrApplicationNotFound, "only applications are archived")
+	_, err = a.RestoreApplication(ctx, "billing")
+	assert.ErrorIs(t, err, ErrNotArchived)
+
+	current, err := g.Graph()
+	require.NoError(t, err)
+	current.Edges["release-checkout-2"] = []graph.Edge{{To: "production", Type: "deployment", Metadata: map[string]interface{}{"application": "checkout", "status": "in-progress"}}}
+	require.NoError(t, g.Save())
+	_, err = a.ArchiveApplication(ctx, "checkout", "", "")
+	assert.ErrorIs(t, err, ErrDeploymentActive)
+	assert.Contains(t, current.Nodes, "checkout", "nothing is removed")
+}
@@ -0,0 +1,279 @@
+// Package archive moves old records out of the primary graph backend into object storage.
+// Records are written in gzip-compressed batches; a manifest node per batch stays in the graph
+// with the IDs, times and labels of its records, so a record or the records matching a filter
+// can be fetched back without listing or downloading every batch.
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/backup"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// ErrNotArchived is returned when no batch holds the requested record
+var ErrNotArchived = errors.New("record not archived")
+
+const (
+	// nodeIDPrefix namespaces manifest nodes so they cannot collide with platform entities
+	nodeIDPrefix = "archive:"
+	nameSuffix   = ".json.gz"
+	nameLayout   = "20060102T150405.000000000Z"
+)
+
+// IsBatchName reports whether name is an archive batch object name
+func IsBatchName(name string) bool {
+	collection, _, ok := strings.Cut(strings.TrimSuffix(name, nameSuffix), "-")
+	return strings.HasSuffix(name, nameSuffix) && ok && collection != "" && !strings.ContainsAny(name, "/\\")
+}
+
+// NewDirStore keeps batches as files in a directory, which may be a mounted bucket
+func NewDirStore(dir string) (*backup.DirStore, error) {
+	store, err := backup.NewDirStore(dir)
+	if err != nil {
+		return nil, err
+	}
+	store.Names = IsBatchName
+	return store, nil
+}
+
+// NewHTTPStore keeps batches in the object store bucket or prefix at url
+func NewHTTPStore(url string) *backup.HTTPStore {
+	store := backup.NewHTTPStore(url)
+	store.Names = IsBatchName
+	return store
+}
+
+// Ref identifies an archived record. Labels are the terms callers filter on, e.g.
+// "tenant:acme" or "entity:checkout".
+type Ref struct {
+	ID     string    `json:"id"`
+	Time   time.Time `json:"time"`
+	Labels []string  `json:"labels,omitempty"`
+}
+
+// Record is an archived record and its data
+type Record struct {
+	Ref
+	Data json.RawMessage `json:"data"`
+}
+
+// Manifest describes a stored batch
+type Manifest struct {
+	Name       string    `json:"name"`
+	Collection string    `json:"collection"`
+	CreatedAt  time.Time `json:"created_at"`
+	From       time.Time `json:"from"` // oldest record
+	To         time.Time `json:"to"`   // newest record
+	Size       int       `json:"size"` // compressed bytes
+	Records    []Ref     `json:"records"`
+}
+
+// Archive writes batches to a store and reads records back through their manifests
+type Archive struct {
+	graph  *graph.GlobalGraph
+	store  backup.Store
+	logger *logging.Logger
+	now    func() time.Time
+}
+
+// New creates an archive keeping batches in store and manifests in the global graph
+func New(globalGraph *graph.GlobalGraph, store backup.Store) *Archive {
+	return &Archive{
+		graph:  globalGraph,
+		store:  store,
+		logger: logging.GetLogger().ForComponent("archive"),
+		now:    time.Now,
+	}
+}
+
+// Write stores records of a collection as one compressed batch and records its manifest.
+// Callers remove the records from the graph once Write succeeds.
+func (a *Archive) Write(ctx context.Context, collection string, records []Record) (*Manifest, error) {
+	if len(records) == 0 {
+		return nil, fmt.Errorf("nothing to archive")
+	}
+	now := a.now().UTC()
+	// Batch names sort by creation; batches written within the same instant still get their own
+	for a.exists(batchName(collection, now)) {
+		now = now.Add(time.Nanosecond)
+	}
+	manifest := &Manifest{
+		Name:       batchName(collection, now),
+		Collection: collection,
+		CreatedAt:  now,
+		From:       records[0].Time,
+		To:         records[0].Time,
+	}
+	for _, record := range records {
+		manifest.Records = append(manifest.Records, record.Ref)
+		if record.Time.Before(manifest.From) {
+			manifest.From = record.Time
+		}
+		if record.Time.After(manifest.To) {
+			manifest.To = record.Time
+		}
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(zw).Encode(records); err != nil {
+		return nil, fmt.Errorf("failed to encode batch: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress batch: %w", err)
+	}
+	manifest.Size = buf.Len()
+	if err := a.store.Put(ctx, manifest.Name, buf.Bytes()); err != nil {
+		return nil, fmt.Errorf("failed to store batch %s: %w", manifest.Name, err)
+	}
+
+	node, err := manifestToNode(manifest)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.graph.AddNode(node); err != nil {
+		return nil, fmt.Errorf("failed to record batch %s: %w", manifest.Name, err)
+	}
+	a.logger.Info("🗄️ Archived %d %s records to %s (%d bytes)", len(records), collection, manifest.Name, manifest.Size)
+	return manifest, nil
+}
+
+// Get returns the data of an archived record
+func (a *Archive) Get(ctx context.Context, collection, id string) (json.RawMessage, error) {
+	records, err := a.Find(ctx, collection, func(ref Ref) bool { return ref.ID == id })
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%w: %s %s", ErrNotArchived, collection, id)
+	}
+	return records[len(records)-1].Data, nil
+}
+
+// Find returns the archived records of a collection that match, oldest batch first. Only
+// batches whose manifest lists a matching record are downloaded.
+func (a *Archive) Find(ctx context.Context, collection string, match func(Ref) bool) ([]Record, error) {
+	manifests, err := a.Manifests(collection)
+	if err != nil {
+		return nil, err
+	}
+	var found []Record
+	for _, manifest := range manifests {
+		wanted := map[string]bool{}
+		for _, ref := range manifest.Records {
+			if match(ref) {
+				wanted[ref.ID] = true
+			}
+		}
+		if len(wanted) == 0 {
+			continue
+		}
+		records, err := a.read(ctx, manifest.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			if wanted[record.ID] {
+				found = append(found, record)
+			}
+		}
+	}
+	return found, nil
+}
+
+// Manifests returns the batches of a collection, oldest first
+func (a *Archive) Manifests(collection string) ([]*Manifest, error) {
+	nodes, err := a.graph.Nodes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph: %w", err)
+	}
+	manifests := []*Manifest{}
+	for _, node := range nodes {
+		if node.Kind != graph.KindArchiveBatch || node.Metadata["collection"] != collection {
+			continue
+		}
+		manifest, err := nodeToManifest(node)
+		if err != nil {
+			a.logger.Warn("⚠️ Skipping unreadable archive manifest %s: %v", node.ID, err)
+			continue
+		}
+		manifests = append(manifests, manifest)
+	}
+	sort.Slice(manifests, func(i, j int) bool { return manifests[i].Name < manifests[j].Name })
+	return manifests, nil
+}
+
+func (a *Archive) read(ctx context.Context, name string) ([]Record, error) {
+	data, err := a.store.Get(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch batch %s: %w", name, err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("batch %s is corrupt: %w", name, err)
+	}
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("batch %s is corrupt: %w", name, err)
+	}
+	var records []Record
+	if err := json.Unmarshal(raw, &records); err != nil {
+		return nil, fmt.Errorf("batch %s is corrupt: %w", name, err)
+	}
+	return records, nil
+}
+
+func (a *Archive) exists(name string) bool {
+	node, _ := a.graph.GetNode(nodeIDPrefix + name)
+	return node != nil
+}
+
+func batchName(collection string, at time.Time) string {
+	return collection + "-" + at.Format(nameLayout) + nameSuffix
+}
+
+func manifestToNode(manifest *Manifest) (*graph.Node, error) {
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	var spec map[string]interface{}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	return &graph.Node{
+		ID:   nodeIDPrefix + manifest.Name,
+		Kind: graph.KindArchiveBatch,
+		Metadata: map[string]interface{}{
+			"name":       manifest.Name,
+			"collection": manifest.Collection,
+			"records":    len(manifest.Records),
+			"from":       manifest.From.Format(time.RFC3339),
+			"to":         manifest.To.Format(time.RFC3339),
+		},
+		Spec: spec,
+	}, nil
+}
+
+func nodeToManifest(node *graph.Node) (*Manifest, error) {
+	data, err := json.Marshal(node.Spec)
+	if err != nil {
+		return nil, err
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
@@ -0,0 +1,81 @@
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestArchive(t *testing.T) (*Archive, *graph.GlobalGraph) {
+	t.Helper()
+	store, err := NewDirStore(t.TempDir())
+	require.NoError(t, err)
+	g := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	a := New(g, store)
+	a.now = func() time.Time { return time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC) }
+	return a, g
+}
+
+func record(id string, at time.Time, labels ...string) Record {
+	data, _ := json.Marshal(map[string]string{"id": id})
+	return Record{Ref: Ref{ID: id, Time: at, Labels: labels}, Data: data}
+}
+
+func TestWriteAndGet(t *testing.T) {
+	a, g := newTestArchive(t)
+	ctx := context.Background()
+	day := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	manifest, err := a.Write(ctx, "conversations", []Record{record("conv-2", day.Add(time.Hour)), record("conv-1", day)})
+	require.NoError(t, err)
+	assert.Equal(t, "conversations-20260302T090000.000000000Z.json.gz", manifest.Name)
+	assert.Equal(t, day, manifest.From)
+	assert.Equal(t, day.Add(time.Hour), manifest.To)
+	assert.Positive(t, manifest.Size)
+
+	node, err := g.GetNode(nodeIDPrefix + manifest.Name)
+	require.NoError(t, err)
+	assert.Equal(t, graph.KindArchiveBatch, node.Kind)
+
+	data, err := a.Get(ctx, "conversations", "conv-1")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"conv-1"}`, string(data))
+
+	_, err = a.Get(ctx, "conversations", "conv-3")
+	assert.ErrorIs(t, err, ErrNotArchived)
+	_, err = a.Get(ctx, "decisions", "conv-1")
+	assert.ErrorIs(t, err, ErrNotArchived, "collections are separate")
+}
+
+func TestFind_OnlyFetchesBatchesWithMatches(t *testing.T) {
+	a, _ := newTestArchive(t)
+	ctx := context.Background()
+	day := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	first, err := a.Write(ctx, "conversations", []Record{record("conv-1", day, "tenant:acme")})
+	require.NoError(t, err)
+	second, err := a.Write(ctx, "conversations", []Record{record("conv-2", day, "tenant:globex"), record("conv-1", day.Add(time.Hour), "tenant:acme")})
+	require.NoError(t, err)
+	assert.NotEqual(t, first.Name, second.Name, "batches written at the same instant get distinct names")
+
+	// A batch that cannot be read is only a problem when it is needed
+	require.NoError(t, a.store.Put(ctx, first.Name, []byte("not gzip")))
+	found, err := a.Find(ctx, "conversations", func(ref Ref) bool { return ref.ID == "conv-2" })
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, "conv-2", found[0].ID)
+
+	_, err = a.Find(ctx, "conversations", func(ref Ref) bool { return ref.ID == "conv-1" })
+	assert.ErrorContains(t, err, "is corrupt")
+}
+
+func TestIsBatchName(t *testing.T) {
+	assert.True(t, IsBatchName("conversations-20260302T090000.000000000Z.json.gz"))
+	assert.False(t, IsBatchName("ztdp-20260302T090000Z.json"), "backups are not batches")
+	assert.False(t, IsBatchName("../conversations-1.json.gz"))
+}
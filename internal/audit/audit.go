@@ -0,0 +1,227 @@
+// Package audit keeps a log of every API call: who made it, the route, the status, the latency
+// and a hash of the request body. Graph mutations are covered by provenance; this log also covers
+// reads, rejected calls and chat requests, and exports to SIEM formats.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// filePrefix names the daily JSON lines files written to the audit directory
+const filePrefix = "audit-"
+
+// Record is one API call
+type Record struct {
+	Sequence      int64     `json:"sequence"`
+	Time          time.Time `json:"time"`
+	Caller        string    `json:"caller"`
+	RemoteAddr    string    `json:"remote_addr"`
+	Method        string    `json:"method"`
+	Route         string    `json:"route"` // route pattern, e.g. /v1/applications/{app_name}/runbook; the path when no route matched
+	Path          string    `json:"path"`
+	Status        int       `json:"status"`
+	LatencyMS     float64   `json:"latency_ms"`
+	BodySHA256    string    `json:"body_sha256,omitempty"` // hex sha256 of the request body; empty without a body
+	BodyBytes     int64     `json:"body_bytes"`
+	CorrelationID string    `json:"correlation_id,omitempty"`
+}
+
+// Filter narrows a query; empty fields match every record
+type Filter struct {
+	From      time.Time
+	To        time.Time
+	Caller    string
+	Route     string
+	MinStatus int
+}
+
+func (f Filter) matches(record Record) bool {
+	return (f.From.IsZero() || !record.Time.Before(f.From)) &&
+		(f.To.IsZero() || record.Time.Before(f.To)) &&
+		(f.Caller == "" || record.Caller == f.Caller) &&
+		(f.Route == "" || record.Route == f.Route) &&
+		record.Status >= f.MinStatus
+}
+
+// Log keeps the most recent records in memory and, with a directory, appends every record to a
+// JSON lines file per day for SIEM collectors to ship. Records and files older than the
+// retention are pruned.
+type Log struct {
+	capacity  int
+	retention time.Duration
+	dir       string
+	logger    *logging.Logger
+	now       func() time.Time
+
+	mu       sync.Mutex
+	records  []Record // oldest first
+	sequence int64
+	file     *os.File
+	fileDay  string
+}
+
+// NewLog creates an audit log keeping capacity records in memory. An empty dir keeps records only
+// in memory; a zero retention keeps them until capacity pushes them out.
+func NewLog(capacity int, retention time.Duration, dir string) (*Log, error) {
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return nil, fmt.Errorf("failed to create audit directory: %w", err)
+		}
+	}
+	return &Log{
+		capacity:  capacity,
+		retention: retention,
+		dir:       dir,
+		logger:    logging.GetLogger().ForComponent("audit"),
+		now:       time.Now,
+	}, nil
+}
+
+// Append numbers a record and stores it
+func (l *Log) Append(record Record) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sequence++
+	record.Sequence = l.sequence
+	l.records = append(l.records, record)
+	if over := len(l.records) - l.capacity; over > 0 {
+		l.records = append([]Record(nil), l.records[over:]...)
+	}
+	if l.dir != "" {
+		if err := l.write(record); err != nil {
+			l.logger.Error("❌ Failed to write audit record %d: %v", record.Sequence, err)
+		}
+	}
+}
+
+// write appends the record to the file of its day, opening a new file when the day changes
+func (l *Log) write(record Record) error {
+	day := record.Time.UTC().Format("2006-01-02")
+	if l.file == nil || l.fileDay != day {
+		if l.file != nil {
+			l.file.Close()
+		}
+		file, err := os.OpenFile(filepath.Join(l.dir, filePrefix+day+".jsonl"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o640)
+		if err != nil {
+			l.file = nil
+			return err
+		}
+		l.file, l.fileDay = file, day
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	_, err = l.file.Write(append(data, '\n'))
+	return err
+}
+
+// Query returns the records in memory matching the filter, oldest first
+func (l *Log) Query(filter Filter) []Record {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	matched := []Record{}
+	for _, record := range l.records {
+		if filter.matches(record) {
+			matched = append(matched, record)
+		}
+	}
+	return matched
+}
+
+// Prune drops the records and daily files older than the retention
+func (l *Log) Prune() {
+	if l.retention <= 0 {
+		return
+	}
+	cutoff := l.now().Add(-l.retention)
+
+	l.mu.Lock()
+	keep := sort.Search(len(l.records), func(i int) bool { return !l.records[i].Time.Before(cutoff) })
+	if keep > 0 {
+		l.records = append([]Record(nil), l.records[keep:]...)
+	}
+	current := l.fileDay
+	l.mu.Unlock()
+
+	if l.dir == "" {
+		return
+	}
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		l.logger.Warn("⚠️ Could not list audit files: %v", err)
+		return
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, filePrefix) || !strings.HasSuffix(name, ".jsonl") {
+			continue
+		}
+		day, err := time.Parse("2006-01-02", strings.TrimSuffix(strings.TrimPrefix(name, filePrefix), ".jsonl"))
+		// A file holds a whole day, so it goes once the day after it is past the cutoff
+		if err != nil || name == filePrefix+current+".jsonl" || !day.AddDate(0, 0, 1).Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(l.dir, name)); err != nil {
+			l.logger.Warn("⚠️ Could not remove audit file %s: %v", name, err)
+			continue
+		}
+		l.logger.Info("🧹 Removed audit file %s past retention", name)
+	}
+}
+
+// Run prunes the log every interval until ctx is cancelled, then closes the current file
+func (l *Log) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			l.Close()
+			return
+		case <-ticker.C:
+			l.Prune()
+		}
+	}
+}
+
+// Close closes the current daily file
+func (l *Log) Close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file != nil {
+		l.file.Close()
+		l.file = nil
+	}
+}
+
+// callerOf identifies who made a request: the X-User header, the basic auth user, or the client
+// address when the request names no one
+func callerOf(r *http.Request) string {
+	if user := r.Header.Get("X-User"); user != "" {
+		return user
+	}
+	if user, _, ok := r.BasicAuth(); ok && user != "" {
+		return user
+	}
+	return clientAddr(r)
+}
+
+func clientAddr(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
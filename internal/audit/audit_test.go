@@ -0,0 +1,107 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware_RecordsCalls(t *testing.T) {
+	dir := t.TempDir()
+	log, err := NewLog(2, 0, dir)
+	require.NoError(t, err)
+	defer log.Close()
+
+	router := chi.NewRouter()
+	router.Route("/v1", func(v1 chi.Router) {
+		v1.Post("/applications/{app_name}/runbook", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+		})
+		v1.Get("/health", func(w http.ResponseWriter, r *http.Request) {})
+	})
+	handler := log.Middleware(router)
+
+	body := `{"environment":"prod"}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/applications/checkout/runbook", strings.NewReader(body))
+	req.Header.Set("X-User", "alice")
+	req = req.WithContext(logging.WithCorrelationID(req.Context(), "corr-1"))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	records := log.Query(Filter{})
+	require.Len(t, records, 1)
+	record := records[0]
+	assert.Equal(t, int64(1), record.Sequence)
+	assert.Equal(t, "alice", record.Caller)
+	assert.Equal(t, "/v1/applications/{app_name}/runbook", record.Route)
+	assert.Equal(t, "/v1/applications/checkout/runbook", record.Path)
+	assert.Equal(t, http.StatusCreated, record.Status)
+	assert.Equal(t, "corr-1", record.CorrelationID)
+	sum := sha256.Sum256([]byte(body))
+	assert.Equal(t, hex.EncodeToString(sum[:]), record.BodySHA256, "the body is hashed even when the handler does not read it")
+	assert.Equal(t, int64(len(body)), record.BodyBytes)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/health", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/missing", nil))
+	records = log.Query(Filter{})
+	require.Len(t, records, 2, "memory keeps the most recent calls")
+	assert.Equal(t, "192.0.2.1", records[0].Caller, "anonymous calls are attributed to the client address")
+	assert.Equal(t, "/v1/missing", records[1].Route, "unmatched calls keep their path")
+	assert.Len(t, log.Query(Filter{MinStatus: 400}), 1)
+
+	data, err := os.ReadFile(filepath.Join(dir, filePrefix+record.Time.Format("2006-01-02")+".jsonl"))
+	require.NoError(t, err)
+	assert.Len(t, strings.Split(strings.TrimSpace(string(data)), "\n"), 3, "every call is written to the day's file")
+}
+
+func TestPrune_DropsRecordsAndFilesPastRetention(t *testing.T) {
+	dir := t.TempDir()
+	log, err := NewLog(10, 48*time.Hour, dir)
+	require.NoError(t, err)
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	log.now = func() time.Time { return now }
+
+	log.Append(Record{Time: now.Add(-72 * time.Hour), Method: "GET", Route: "/v1/health", Status: 200})
+	log.Append(Record{Time: now.Add(-time.Hour), Method: "GET", Route: "/v1/health", Status: 200})
+	log.Close()
+	log.Prune()
+
+	records := log.Query(Filter{})
+	require.Len(t, records, 1)
+	assert.Equal(t, int64(2), records[0].Sequence)
+	_, err = os.Stat(filepath.Join(dir, filePrefix+"2026-03-07.jsonl"))
+	assert.True(t, os.IsNotExist(err), "files past retention are removed")
+	_, err = os.Stat(filepath.Join(dir, filePrefix+"2026-03-10.jsonl"))
+	assert.NoError(t, err)
+}
+
+func TestExport_CEF(t *testing.T) {
+	record := Record{
+		Sequence:   7,
+		Time:       time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC),
+		Caller:     "alice=admin",
+		RemoteAddr: "10.0.0.1",
+		Method:     "DELETE",
+		Route:      "/v1/quotas/{scope}",
+		Path:       "/v1/quotas/team",
+		Status:     403,
+		LatencyMS:  1.5,
+	}
+	assert.Equal(t, `CEF:0|ZTDP|ztdp-api|1|api-call|DELETE /v1/quotas/{scope}|5|rt=1773144000000 suser=alice\=admin src=10.0.0.1 requestMethod=DELETE request=/v1/quotas/team outcome=403 cn1Label=latencyMs cn1=1.500 cn2Label=sequence cn2=7 cs1Label=route cs1=/v1/quotas/{scope}`, CEF(record))
+
+	var out bytes.Buffer
+	require.NoError(t, Export(&out, []Record{record, record}, FormatJSONLines))
+	assert.Equal(t, 2, strings.Count(out.String(), "\n"))
+	assert.ErrorContains(t, Export(&out, nil, "xml"), "unknown export format")
+}
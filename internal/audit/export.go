@@ -0,0 +1,99 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Export formats
+const (
+	FormatJSON      = "json"  // a JSON array
+	FormatJSONLines = "jsonl" // one JSON object per line
+	FormatCEF       = "cef"   // ArcSight Common Event Format, one event per line
+)
+
+// Formats lists the export formats
+var Formats = []string{FormatJSON, FormatJSONLines, FormatCEF}
+
+// ContentType returns the media type of an export format
+func ContentType(format string) string {
+	switch format {
+	case FormatJSONLines:
+		return "application/x-ndjson"
+	case FormatCEF:
+		return "text/plain; charset=utf-8"
+	default:
+		return "application/json"
+	}
+}
+
+// Export writes the records in the format
+func Export(w io.Writer, records []Record, format string) error {
+	switch format {
+	case FormatJSON:
+		return json.NewEncoder(w).Encode(records)
+	case FormatJSONLines:
+		encoder := json.NewEncoder(w)
+		for _, record := range records {
+			if err := encoder.Encode(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	case FormatCEF:
+		for _, record := range records {
+			if _, err := io.WriteString(w, CEF(record)+"\n"); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown export format %q (use %s)", format, strings.Join(Formats, ", "))
+	}
+}
+
+// CEF renders a record as a Common Event Format event. Server errors are severity 7, rejected
+// calls 5 and everything else 3.
+func CEF(record Record) string {
+	severity := 3
+	switch {
+	case record.Status >= 500:
+		severity = 7
+	case record.Status >= 400:
+		severity = 5
+	}
+	extensions := []string{
+		"rt=" + fmt.Sprint(record.Time.UnixMilli()),
+		"suser=" + cefValue(record.Caller),
+		"src=" + cefValue(record.RemoteAddr),
+		"requestMethod=" + cefValue(record.Method),
+		"request=" + cefValue(record.Path),
+		"outcome=" + fmt.Sprint(record.Status),
+		"cn1Label=latencyMs",
+		"cn1=" + fmt.Sprintf("%.3f", record.LatencyMS),
+		"cn2Label=sequence",
+		"cn2=" + fmt.Sprint(record.Sequence),
+		"cs1Label=route",
+		"cs1=" + cefValue(record.Route),
+	}
+	if record.BodySHA256 != "" {
+		extensions = append(extensions, "cs2Label=bodySha256", "cs2="+record.BodySHA256)
+	}
+	if record.CorrelationID != "" {
+		extensions = append(extensions, "cs3Label=correlationId", "cs3="+cefValue(record.CorrelationID))
+	}
+	return fmt.Sprintf("CEF:0|ZTDP|ztdp-api|1|api-call|%s|%d|%s",
+		cefHeader(record.Method+" "+record.Route), severity, strings.Join(extensions, " "))
+}
+
+// cefHeader escapes a CEF header field
+func cefHeader(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\n", " ", "\r", " ").Replace(value)
+}
+
+// cefValue escapes a CEF extension value
+func cefValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\n", `\n`, "\r", `\r`).Replace(value)
+}
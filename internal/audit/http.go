@@ -0,0 +1,117 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// Middleware records every API call once it has been answered. It sets up the chi route context
+// itself so the matched route pattern can be read after the router ran. The body is hashed as the
+// handler reads it, and whatever it left unread is hashed afterwards.
+func (l *Log) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := l.now()
+		routeCtx := chi.NewRouteContext()
+		r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, routeCtx))
+
+		body := &hashingBody{hash: sha256.New()}
+		if r.Body != nil && r.Body != http.NoBody {
+			body.ReadCloser = r.Body
+			r.Body = body
+		}
+		capture := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(capture, r)
+		body.drain()
+
+		// Unmatched calls have no pattern, or only the wildcard of the subrouter they fell through
+		route := routeCtx.RoutePattern()
+		if route == "" || (capture.status == http.StatusNotFound && strings.HasSuffix(route, "/*")) {
+			route = r.URL.Path
+		}
+		record := Record{
+			Time:          start.UTC(),
+			Caller:        callerOf(r),
+			RemoteAddr:    clientAddr(r),
+			Method:        r.Method,
+			Route:         route,
+			Path:          r.URL.Path,
+			Status:        capture.status,
+			LatencyMS:     float64(l.now().Sub(start).Microseconds()) / 1000,
+			BodyBytes:     body.size,
+			CorrelationID: logging.CorrelationIDFromContext(r.Context()),
+		}
+		if body.size > 0 {
+			record.BodySHA256 = hex.EncodeToString(body.hash.Sum(nil))
+		}
+		l.Append(record)
+	})
+}
+
+// hashingBody hashes a request body as it is read
+type hashingBody struct {
+	io.ReadCloser
+	hash hash.Hash
+	size int64
+}
+
+func (b *hashingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.hash.Write(p[:n])
+	b.size += int64(n)
+	return n, err
+}
+
+// drain hashes what the handler did not read
+func (b *hashingBody) drain() {
+	if b.ReadCloser == nil {
+		return
+	}
+	n, _ := io.Copy(b.hash, b.ReadCloser)
+	b.size += n
+}
+
+// statusWriter keeps the status of the response
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (s *statusWriter) WriteHeader(status int) {
+	if !s.wroteHeader {
+		s.status, s.wroteHeader = status, true
+	}
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusWriter) Write(data []byte) (int, error) {
+	s.wroteHeader = true
+	return s.ResponseWriter.Write(data)
+}
+
+// Flush passes through so streamed responses keep streaming
+func (s *statusWriter) Flush() {
+	if flusher, ok := s.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack passes through for handlers that take over the connection
+func (s *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := s.ResponseWriter.(http.Hijacker); ok {
+		s.status, s.wroteHeader = http.StatusSwitchingProtocols, true
+		return hijacker.Hijack()
+	}
+	return nil, nil, fmt.Errorf("response writer does not implement http.Hijacker")
+}
@@ -0,0 +1,305 @@
+// Package backup exports the global graph to checksummed snapshot files, restores them after
+// validation, and takes scheduled backups under a retention policy.
+package backup
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/integrity"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// FormatVersion is the snapshot file format this build writes and restores
+const FormatVersion = 1
+
+const (
+	namePrefix = "graph-"
+	nameSuffix = ".json"
+	nameLayout = "20060102T150405.000Z"
+)
+
+var (
+	// ErrBackupNotFound is returned for unknown backup names
+	ErrBackupNotFound = errors.New("backup not found")
+	// ErrInvalidName is returned for names that are not backup file names
+	ErrInvalidName = errors.New("invalid backup name")
+	// ErrCorrupt is returned when a snapshot fails its checksum or cannot be decoded
+	ErrCorrupt = errors.New("backup is corrupt")
+)
+
+// Snapshot is the content of a backup file. The checksum covers the compacted graph JSON,
+// so a file can be verified without trusting anything else in it.
+type Snapshot struct {
+	Format    int             `json:"format"`
+	CreatedAt time.Time       `json:"created_at"`
+	Backend   string          `json:"backend,omitempty"`
+	Nodes     int             `json:"nodes"`
+	Edges     int             `json:"edges"`
+	Checksum  string          `json:"checksum"` // sha256:<hex>
+	Graph     json.RawMessage `json:"graph"`
+}
+
+// Info describes a stored backup
+type Info struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	Size      int       `json:"size,omitempty"`
+	Nodes     int       `json:"nodes,omitempty"`
+	Edges     int       `json:"edges,omitempty"`
+	Checksum  string    `json:"checksum,omitempty"`
+}
+
+// RestoreResult reports a restore, or what a dry run would restore
+type RestoreResult struct {
+	Backup   Info              `json:"backup"`
+	Issues   []integrity.Issue `json:"issues"` // integrity issues present in the snapshot
+	Restored bool              `json:"restored"`
+}
+
+// Retention decides which backups are pruned; the newest backup is always kept
+type Retention struct {
+	Keep   int           // newest backups kept; 0 keeps any number
+	MaxAge time.Duration // backups older than this are deleted; 0 keeps them
+}
+
+// Name returns the file name of a backup taken at t
+func Name(t time.Time) string {
+	return namePrefix + t.UTC().Format(nameLayout) + nameSuffix
+}
+
+// IsBackupName reports whether name is a backup file name
+func IsBackupName(name string) bool {
+	_, err := parseName(name)
+	return err == nil
+}
+
+func parseName(name string) (time.Time, error) {
+	if !strings.HasPrefix(name, namePrefix) || !strings.HasSuffix(name, nameSuffix) {
+		return time.Time{}, fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+	t, err := time.Parse(nameLayout, strings.TrimSuffix(strings.TrimPrefix(name, namePrefix), nameSuffix))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+	return t, nil
+}
+
+func checksum(graphJSON []byte) (string, error) {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, graphJSON); err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(compact.Bytes())
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// Encode serializes a graph into a snapshot file
+func Encode(g *graph.Graph, backend string, createdAt time.Time) ([]byte, *Snapshot, error) {
+	graphJSON, err := json.Marshal(g)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode graph: %w", err)
+	}
+	sum, err := checksum(graphJSON)
+	if err != nil {
+		return nil, nil, err
+	}
+	snapshot := &Snapshot{
+		Format:    FormatVersion,
+		CreatedAt: createdAt.UTC(),
+		Backend:   backend,
+		Nodes:     len(g.Nodes),
+		Checksum:  sum,
+		Graph:     graphJSON,
+	}
+	for _, edges := range g.Edges {
+		snapshot.Edges += len(edges)
+	}
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, snapshot, nil
+}
+
+// Decode verifies a snapshot file and returns its graph
+func Decode(data []byte) (*Snapshot, *graph.Graph, error) {
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	if snapshot.Format != FormatVersion {
+		return nil, nil, fmt.Errorf("%w: unsupported format %d (want %d)", ErrCorrupt, snapshot.Format, FormatVersion)
+	}
+	sum, err := checksum(snapshot.Graph)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	if sum != snapshot.Checksum {
+		return nil, nil, fmt.Errorf("%w: checksum %s does not match recorded %s", ErrCorrupt, sum, snapshot.Checksum)
+	}
+
+	g := graph.NewGraph()
+	if err := json.Unmarshal(snapshot.Graph, g); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	if g.Nodes == nil {
+		g.Nodes = map[string]*graph.Node{}
+	}
+	if g.Edges == nil {
+		g.Edges = map[string][]graph.Edge{}
+	}
+	if len(g.Nodes) != snapshot.Nodes {
+		return nil, nil, fmt.Errorf("%w: %d nodes, header records %d", ErrCorrupt, len(g.Nodes), snapshot.Nodes)
+	}
+	return &snapshot, g, nil
+}
+
+// Service backs up and restores the global graph
+type Service struct {
+	graph   *graph.GlobalGraph
+	store   Store
+	backend string
+	logger  *logging.Logger
+	now     func() time.Time
+}
+
+// NewService creates a service storing backups of globalGraph in store. backend names the
+// graph backend in snapshot headers.
+func NewService(globalGraph *graph.GlobalGraph, store Store, backend string) *Service {
+	return &Service{
+		graph:   globalGraph,
+		store:   store,
+		backend: backend,
+		logger:  logging.GetLogger().ForComponent("backup"),
+		now:     time.Now,
+	}
+}
+
+// Create takes a backup. The graph is read from the backend in one load, so the snapshot is
+// a consistent point-in-time copy.
+func (s *Service) Create(ctx context.Context) (*Info, error) {
+	g, err := s.graph.Graph()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph: %w", err)
+	}
+	now := s.now()
+	data, snapshot, err := Encode(g, s.backend, now)
+	if err != nil {
+		return nil, err
+	}
+	name := Name(now)
+	if err := s.store.Put(ctx, name, data); err != nil {
+		return nil, fmt.Errorf("failed to store backup: %w", err)
+	}
+	s.logger.Info("💾 Backed up graph to %s (%d nodes, %d edges)", name, snapshot.Nodes, snapshot.Edges)
+	return &Info{Name: name, CreatedAt: snapshot.CreatedAt, Size: len(data), Nodes: snapshot.Nodes, Edges: snapshot.Edges, Checksum: snapshot.Checksum}, nil
+}
+
+// List returns the stored backups, newest first
+func (s *Service) List(ctx context.Context) ([]Info, error) {
+	names, err := s.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	backups := []Info{}
+	for _, name := range names {
+		if createdAt, err := parseName(name); err == nil {
+			backups = append(backups, Info{Name: name, CreatedAt: createdAt})
+		}
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].CreatedAt.After(backups[j].CreatedAt) })
+	return backups, nil
+}
+
+// Restore verifies a backup and replaces the graph with it. A dry run only verifies.
+// Integrity issues the snapshot already had are reported but do not block the restore.
+func (s *Service) Restore(ctx context.Context, name string, dryRun bool) (*RestoreResult, error) {
+	data, err := s.store.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, g, err := Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	result := &RestoreResult{
+		Backup: Info{Name: name, CreatedAt: snapshot.CreatedAt, Size: len(data), Nodes: snapshot.Nodes, Edges: snapshot.Edges, Checksum: snapshot.Checksum},
+		Issues: integrity.Scan(g),
+	}
+	if dryRun {
+		return result, nil
+	}
+	// Swap the contents under the write lock so no concurrent update is saved over the restore
+	err = s.graph.Update(func(current *graph.Graph) error {
+		*current = *g
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore graph: %w", err)
+	}
+	result.Restored = true
+	s.logger.Info("♻️ Restored graph from %s (%d nodes, %d integrity issues)", name, snapshot.Nodes, len(result.Issues))
+	return result, nil
+}
+
+// Prune deletes backups outside the retention policy and returns their names
+func (s *Service) Prune(ctx context.Context, retention Retention) ([]string, error) {
+	backups, err := s.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cutoff := time.Time{}
+	if retention.MaxAge > 0 {
+		cutoff = s.now().Add(-retention.MaxAge)
+	}
+
+	var deleted []string
+	for i, backup := range backups {
+		if i == 0 {
+			continue // never delete the newest backup
+		}
+		tooMany := retention.Keep > 0 && i >= retention.Keep
+		tooOld := !cutoff.IsZero() && backup.CreatedAt.Before(cutoff)
+		if !tooMany && !tooOld {
+			continue
+		}
+		if err := s.store.Delete(ctx, backup.Name); err != nil {
+			return deleted, fmt.Errorf("failed to delete %s: %w", backup.Name, err)
+		}
+		deleted = append(deleted, backup.Name)
+	}
+	if len(deleted) > 0 {
+		s.logger.Info("🧹 Pruned %d backups", len(deleted))
+	}
+	return deleted, nil
+}
+
+// Run takes a backup and prunes every interval until ctx is cancelled
+func (s *Service) Run(ctx context.Context, interval time.Duration, retention Retention) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if _, err := s.Create(ctx); err != nil {
+			s.logger.Warn("⚠️ Scheduled backup failed: %v", err)
+			continue
+		}
+		if _, err := s.Prune(ctx, retention); err != nil {
+			s.logger.Warn("⚠️ Backup pruning failed: %v", err)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+)
+
+func sampleGraph(t *testing.T) *graph.GlobalGraph {
+	t.Helper()
+	g := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	g.AddNode(&graph.Node{ID: "checkout", Kind: graph.KindApplication, Metadata: map[string]interface{}{"name": "checkout", "owner": "team-a"}, Spec: map[string]interface{}{}})
+	g.AddNode(&graph.Node{ID: "checkout-api", Kind: graph.KindService, Metadata: map[string]interface{}{"name": "checkout-api"}, Spec: map[string]interface{}{"application": "checkout"}})
+	if err := g.AddEdge("checkout", "checkout-api", graph.EdgeTypeOwns); err != nil {
+		t.Fatalf("AddEdge: %v", err)
+	}
+	return g
+}
+
+func newTestService(t *testing.T, g *graph.GlobalGraph, store Store) (*Service, *time.Time) {
+	t.Helper()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := NewService(g, store, "memory")
+	s.now = func() time.Time { return now }
+	return s, &now
+}
+
+func TestCreateAndRestore(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewDirStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDirStore: %v", err)
+	}
+	g := sampleGraph(t)
+	s, _ := newTestService(t, g, store)
+
+	info, err := s.Create(ctx)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if info.Name != "graph-20260301T120000.000Z.json" || info.Nodes != 2 || info.Edges != 1 || !strings.HasPrefix(info.Checksum, "sha256:") {
+		t.Fatalf("unexpected backup info: %+v", info)
+	}
+
+	if err := g.DeleteNode("checkout-api"); err != nil {
+		t.Fatalf("DeleteNode: %v", err)
+	}
+
+	dry, err := s.Restore(ctx, info.Name, true)
+	if err != nil {
+		t.Fatalf("Restore (dry run): %v", err)
+	}
+	if dry.Restored || len(dry.Issues) != 0 {
+		t.Fatalf("unexpected dry run result: %+v", dry)
+	}
+	if node, _ := g.GetNode("checkout-api"); node != nil {
+		t.Fatal("a dry run must not change the graph")
+	}
+
+	result, err := s.Restore(ctx, info.Name, false)
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if !result.Restored {
+		t.Fatal("expected the backup to be restored")
+	}
+	if ok, _ := g.HasEdge("checkout", "checkout-api", graph.EdgeTypeOwns); !ok {
+		t.Fatal("expected the restored graph to contain the owns edge")
+	}
+
+	if _, err := s.Restore(ctx, "graph-20200101T000000.000Z.json", true); !errors.Is(err, ErrBackupNotFound) {
+		t.Fatalf("expected ErrBackupNotFound, got %v", err)
+	}
+	if _, err := s.Restore(ctx, "../etc/passwd", true); !errors.Is(err, ErrInvalidName) {
+		t.Fatalf("expected ErrInvalidName, got %v", err)
+	}
+}
+
+func TestDecodeRejectsTamperedSnapshot(t *testing.T) {
+	current, _ := sampleGraph(t).Graph()
+	data, _, err := Encode(current, "memory", time.Now())
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if _, _, err := Decode(data); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+
+	tampered := bytes.Replace(data, []byte("team-a"), []byte("team-b"), 1)
+	if _, _, err := Decode(tampered); !errors.Is(err, ErrCorrupt) {
+		t.Fatalf("expected ErrCorrupt for a modified graph, got %v", err)
+	}
+	if _, _, err := Decode(data[:len(data)/2]); !errors.Is(err, ErrCorrupt) {
+		t.Fatalf("expected ErrCorrupt for a truncated file, got %v", err)
+	}
+}
+
+func TestPruneRetention(t *testing.T) {
+	ctx := context.Background()
+	store, _ := NewDirStore(t.TempDir())
+	s, now := newTestService(t, sampleGraph(t), store)
+
+	for i := 0; i < 5; i++ {
+		if _, err := s.Create(ctx); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		*now = now.Add(24 * time.Hour)
+	}
+
+	deleted, err := s.Prune(ctx, Retention{Keep: 3})
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if len(deleted) != 2 || deleted[1] != "graph-20260301T120000.000Z.json" {
+		t.Fatalf("expected the 2 oldest backups deleted, got %v", deleted)
+	}
+
+	// Age-based pruning never deletes the newest backup
+	*now = now.Add(365 * 24 * time.Hour)
+	if _, err := s.Prune(ctx, Retention{MaxAge: time.Hour}); err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	backups, _ := s.List(ctx)
+	if len(backups) != 1 || backups[0].Name != "graph-20260305T120000.000Z.json" {
+		t.Fatalf("expected only the newest backup kept, got %+v", backups)
+	}
+}
+
+// objectStore is an in-memory bucket accepting PUT, GET and DELETE
+func objectStore() *httptest.Server {
+	var mu sync.Mutex
+	objects := map[string][]byte{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodPut:
+			objects[r.URL.Path], _ = io.ReadAll(r.Body)
+		case http.MethodGet:
+			data, ok := objects[r.URL.Path]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write(data)
+		case http.MethodDelete:
+			delete(objects, r.URL.Path)
+		}
+	}))
+}
+
+func TestHTTPStore(t *testing.T) {
+	ctx := context.Background()
+	server := objectStore()
+	defer server.Close()
+
+	g := sampleGraph(t)
+	s, now := newTestService(t, g, NewHTTPStore(server.URL+"/ztdp/"))
+	first, err := s.Create(ctx)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	*now = now.Add(time.Hour)
+	if _, err := s.Create(ctx); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	backups, err := s.List(ctx)
+	if err != nil || len(backups) != 2 {
+		t.Fatalf("expected 2 backups, got %+v (%v)", backups, err)
+	}
+	if _, err := s.Restore(ctx, first.Name, true); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if _, err := s.Prune(ctx, Retention{Keep: 1}); err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if _, err := s.Restore(ctx, first.Name, true); !errors.Is(err, ErrBackupNotFound) {
+		t.Fatalf("expected the pruned backup to be gone, got %v", err)
+	}
+}
@@ -0,0 +1,225 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Store keeps backup files by name
+type Store interface {
+	Put(ctx context.Context, name string, data []byte) error
+	Get(ctx context.Context, name string) ([]byte, error)
+	Delete(ctx context.Context, name string) error
+	List(ctx context.Context) ([]string, error)
+}
+
+// DirStore keeps backups as files in a directory, which may be a mounted bucket
+type DirStore struct {
+	Dir   string
+	Names func(name string) bool // file names the store keeps; nil keeps backup names
+}
+
+// NewDirStore creates the directory if needed
+func NewDirStore(dir string) (*DirStore, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("backup directory %s: %w", dir, err)
+	}
+	return &DirStore{Dir: dir}, nil
+}
+
+func (s *DirStore) path(name string) (string, error) {
+	if name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+	return filepath.Join(s.Dir, name), nil
+}
+
+// Put writes the file atomically so an interrupted backup never leaves a partial file behind
+func (s *DirStore) Put(_ context.Context, name string, data []byte) error {
+	path, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o640); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Get reads a backup file
+func (s *DirStore) Get(_ context.Context, name string) ([]byte, error) {
+	path, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrBackupNotFound, name)
+	}
+	return data, err
+}
+
+// Delete removes a backup file
+func (s *DirStore) Delete(_ context.Context, name string) error {
+	path, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// List returns the backup files in the directory
+func (s *DirStore) List(_ context.Context) ([]string, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && accepts(s.Names, entry.Name()) {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+// accepts reports whether a store keeps objects called name
+func accepts(names func(string) bool, name string) bool {
+	if names == nil {
+		return IsBackupName(name)
+	}
+	return names(name)
+}
+
+// HTTPStore keeps backups in an object store that accepts PUT, GET and DELETE on
+// <URL>/<name>. Object stores rarely share a listing API, so the store maintains an
+// index.json object next to the backups.
+type HTTPStore struct {
+	URL    string
+	Client *http.Client
+	Names  func(name string) bool // object names the store keeps; nil keeps backup names
+
+	mu sync.Mutex // serializes index updates from this process
+}
+
+const indexObject = "index.json"
+
+// NewHTTPStore creates a store for the bucket or prefix at url
+func NewHTTPStore(url string) *HTTPStore {
+	return &HTTPStore{URL: strings.TrimSuffix(url, "/"), Client: &http.Client{Timeout: 5 * time.Minute}}
+}
+
+func (s *HTTPStore) do(ctx context.Context, method, name string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.URL+"/"+name, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("object store request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s", ErrBackupNotFound, name)
+	case resp.StatusCode >= 300:
+		return nil, fmt.Errorf("object store returned %s for %s %s", resp.Status, method, name)
+	}
+	return data, nil
+}
+
+func (s *HTTPStore) index(ctx context.Context) ([]string, error) {
+	data, err := s.do(ctx, http.MethodGet, indexObject, nil)
+	if errors.Is(err, ErrBackupNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return nil, fmt.Errorf("invalid backup index: %w", err)
+	}
+	return names, nil
+}
+
+func (s *HTTPStore) updateIndex(ctx context.Context, update func(map[string]bool)) error {
+	names, err := s.index(ctx)
+	if err != nil {
+		return err
+	}
+	set := map[string]bool{}
+	for _, name := range names {
+		set[name] = true
+	}
+	update(set)
+	names = names[:0]
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	data, _ := json.Marshal(names)
+	_, err = s.do(ctx, http.MethodPut, indexObject, data)
+	return err
+}
+
+// Put uploads a backup and adds it to the index
+func (s *HTTPStore) Put(ctx context.Context, name string, data []byte) error {
+	if !accepts(s.Names, name) {
+		return fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.do(ctx, http.MethodPut, name, data); err != nil {
+		return err
+	}
+	return s.updateIndex(ctx, func(set map[string]bool) { set[name] = true })
+}
+
+// Get downloads a backup
+func (s *HTTPStore) Get(ctx context.Context, name string) ([]byte, error) {
+	if !accepts(s.Names, name) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+	return s.do(ctx, http.MethodGet, name, nil)
+}
+
+// Delete removes a backup and drops it from the index
+func (s *HTTPStore) Delete(ctx context.Context, name string) error {
+	if !accepts(s.Names, name) {
+		return fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.do(ctx, http.MethodDelete, name, nil); err != nil && !errors.Is(err, ErrBackupNotFound) {
+		return err
+	}
+	return s.updateIndex(ctx, func(set map[string]bool) { delete(set, name) })
+}
+
+// List returns the indexed backups
+func (s *HTTPStore) List(ctx context.Context) ([]string, error) {
+	return s.index(ctx)
+}
@@ -0,0 +1,331 @@
+// Package bootstrap reconciles declarative platform definitions (system policies,
+// environments, resource types and default checks) from YAML files into the graph
+// at startup, so a fresh platform does not depend on scripts to seed them.
+package bootstrap
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/krzachariassen/ZTDP/internal/contracts"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"gopkg.in/yaml.v3"
+)
+
+// ManagedByKey marks nodes created by the bootstrap loader
+const ManagedByKey = "managed_by"
+
+const managedByBootstrap = "bootstrap"
+
+// Definition is one YAML document in the bootstrap directory
+type Definition struct {
+	Kind     string                 `yaml:"kind"` // policy | environment | resource_type | check
+	Metadata map[string]interface{} `yaml:"metadata"`
+	Spec     map[string]interface{} `yaml:"spec"`
+
+	// Satisfies lists policy IDs a check satisfies (checks only)
+	Satisfies []string `yaml:"satisfies,omitempty"`
+
+	// Transitions lists the transitions a policy guards (policies only)
+	Transitions []Transition `yaml:"transitions,omitempty"`
+
+	source string
+}
+
+// Transition identifies an edge that requires a policy before it can be added
+type Transition struct {
+	From     string `yaml:"from"`
+	To       string `yaml:"to"`
+	EdgeType string `yaml:"edge_type"`
+}
+
+// Result summarizes a reconciliation run
+type Result struct {
+	Created   []string `json:"created"`
+	Updated   []string `json:"updated"`
+	Unchanged []string `json:"unchanged"`
+}
+
+// Loader reconciles bootstrap definitions into the global graph
+type Loader struct {
+	graph  *graph.GlobalGraph
+	logger *logging.Logger
+}
+
+// NewLoader creates a loader that writes to globalGraph
+func NewLoader(globalGraph *graph.GlobalGraph) *Loader {
+	return &Loader{
+		graph:  globalGraph,
+		logger: logging.GetLogger().ForComponent("bootstrap"),
+	}
+}
+
+// LoadDir parses every .yaml/.yml file in dir (in name order) and reconciles the definitions.
+// All files are parsed and validated before anything is written, so a bad file leaves the graph untouched.
+func (l *Loader) LoadDir(dir string) (*Result, error) {
+	defs, err := ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	return l.Apply(defs)
+}
+
+// ReadDir parses the definitions in dir without touching the graph
+func ReadDir(dir string) ([]Definition, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("bootstrap dir %s: %w", dir, err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".yaml", ".yml":
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(files)
+
+	var defs []Definition
+	for _, file := range files {
+		fileDefs, err := readFile(file)
+		if err != nil {
+			return nil, err
+		}
+		defs = append(defs, fileDefs...)
+	}
+	return defs, nil
+}
+
+// readFile decodes every document in a (possibly multi-document) YAML file
+func readFile(path string) ([]Definition, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("bootstrap file %s: %w", path, err)
+	}
+
+	var defs []Definition
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	for i := 1; ; i++ {
+		var def Definition
+		if err := decoder.Decode(&def); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("bootstrap file %s (document %d): %w", path, i, err)
+		}
+		if def.Kind == "" && def.Metadata == nil && def.Spec == nil {
+			continue // empty document, e.g. a trailing "---"
+		}
+		def.source = fmt.Sprintf("%s (document %d)", path, i)
+		defs = append(defs, def)
+	}
+	return defs, nil
+}
+
+// Apply reconciles the definitions into the graph. Nodes that already match are left
+// alone, so running it on every startup is safe; nodes not mentioned are never removed.
+func (l *Loader) Apply(defs []Definition) (*Result, error) {
+	nodes := make([]*graph.Node, len(defs))
+	seen := map[string]string{}
+	for i, def := range defs {
+		node, err := def.toNode()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", def.source, err)
+		}
+		if previous, dup := seen[node.ID]; dup {
+			return nil, fmt.Errorf("%s: %s %q is already defined in %s", def.source, node.Kind, node.ID, previous)
+		}
+		seen[node.ID] = def.source
+		nodes[i] = node
+	}
+
+	result := &Result{Created: []string{}, Updated: []string{}, Unchanged: []string{}}
+	for i, node := range nodes {
+		existing, _ := l.graph.GetNode(node.ID)
+		if existing != nil {
+			keepRuntimeMetadata(defs[i], existing, node)
+		}
+		switch {
+		case existing == nil:
+			l.graph.AddNode(node)
+			result.Created = append(result.Created, node.ID)
+		case existing.Kind != node.Kind:
+			return result, fmt.Errorf("node %s already exists as %s, cannot bootstrap it as %s", node.ID, existing.Kind, node.Kind)
+		case sameContent(existing, node):
+			result.Unchanged = append(result.Unchanged, node.ID)
+		default:
+			if err := l.graph.UpdateNode(node); err != nil {
+				return result, fmt.Errorf("failed to update %s: %w", node.ID, err)
+			}
+			result.Updated = append(result.Updated, node.ID)
+		}
+	}
+
+	// Edges are reconciled once every node exists, so files can reference each other in any order
+	for _, def := range defs {
+		id := def.id()
+		for _, policyID := range def.Satisfies {
+			if err := l.ensureEdge(id, policyID, graph.EdgeTypeSatisfies); err != nil {
+				return result, fmt.Errorf("%s: %w", def.source, err)
+			}
+		}
+		for _, t := range def.Transitions {
+			if err := l.graph.AttachPolicyToTransition(t.From, t.To, t.EdgeType, id); err != nil {
+				return result, fmt.Errorf("%s: attach to %s-%s-%s: %w", def.source, t.From, t.EdgeType, t.To, err)
+			}
+		}
+	}
+
+	l.logger.Info("📦 Bootstrap reconciled %d definitions (created: %d, updated: %d, unchanged: %d)",
+		len(defs), len(result.Created), len(result.Updated), len(result.Unchanged))
+	return result, nil
+}
+
+// ensureEdge adds an edge unless it is already present
+func (l *Loader) ensureEdge(fromID, toID, edgeType string) error {
+	if exists, _ := l.graph.HasEdge(fromID, toID, edgeType); exists {
+		return nil
+	}
+	if err := l.graph.AddEdge(fromID, toID, edgeType); err != nil {
+		return fmt.Errorf("%s edge %s -> %s: %w", edgeType, fromID, toID, err)
+	}
+	return nil
+}
+
+// id returns the node ID a definition maps to
+func (d Definition) id() string {
+	name, _ := d.Metadata["name"].(string)
+	return name
+}
+
+// toNode validates the definition and converts it to a graph node
+func (d Definition) toNode() (*graph.Node, error) {
+	if d.id() == "" {
+		return nil, fmt.Errorf("metadata.name is required")
+	}
+	if len(d.Satisfies) > 0 && d.Kind != graph.KindCheck {
+		return nil, fmt.Errorf("satisfies is only supported for checks")
+	}
+	if len(d.Transitions) > 0 && d.Kind != graph.KindPolicy {
+		return nil, fmt.Errorf("transitions are only supported for policies")
+	}
+	for _, t := range d.Transitions {
+		if t.From == "" || t.To == "" || t.EdgeType == "" {
+			return nil, fmt.Errorf("transitions require from, to and edge_type")
+		}
+	}
+
+	var node *graph.Node
+	var err error
+	switch d.Kind {
+	case graph.KindEnvironment:
+		var env contracts.EnvironmentContract
+		if err = d.decodeContract(&env); err == nil {
+			node, err = graph.ResolveContract(env)
+		}
+	case graph.KindResourceType:
+		var rt contracts.ResourceTypeContract
+		if err = d.decodeContract(&rt); err == nil {
+			node, err = graph.ResolveContract(rt)
+		}
+	case graph.KindPolicy, graph.KindCheck:
+		node = d.rawNode()
+	default:
+		return nil, fmt.Errorf("unsupported kind %q (expected policy, environment, resource_type or check)", d.Kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	node.Metadata[ManagedByKey] = managedByBootstrap
+	return normalize(node)
+}
+
+// decodeContract decodes the definition into its contract (which uses JSON field names)
+// so the same validation applies as for contracts submitted through the API
+func (d Definition) decodeContract(contract interface{}) error {
+	data, err := json.Marshal(map[string]interface{}{"metadata": d.Metadata, "spec": d.Spec})
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, contract); err != nil {
+		return fmt.Errorf("invalid %s definition: %w", d.Kind, err)
+	}
+	return nil
+}
+
+// metadataDefaults are filled in when a definition leaves them out. They are runtime
+// state (a check's status changes as it runs), so reconciliation never resets them.
+func metadataDefaults(kind string) map[string]interface{} {
+	switch kind {
+	case graph.KindPolicy:
+		return map[string]interface{}{"type": "system", "status": "active"}
+	case graph.KindCheck:
+		return map[string]interface{}{"status": graph.CheckStatusPending}
+	}
+	return nil
+}
+
+// keepRuntimeMetadata carries defaulted metadata over from the existing node
+func keepRuntimeMetadata(def Definition, existing, desired *graph.Node) {
+	for key := range metadataDefaults(desired.Kind) {
+		if _, explicit := def.Metadata[key]; explicit {
+			continue
+		}
+		if value, ok := existing.Metadata[key]; ok {
+			desired.Metadata[key] = value
+		}
+	}
+}
+
+// rawNode builds a node for kinds without a contract, filling in metadata defaults
+func (d Definition) rawNode() *graph.Node {
+	metadata := map[string]interface{}{}
+	for k, v := range metadataDefaults(d.Kind) {
+		metadata[k] = v
+	}
+	for k, v := range d.Metadata {
+		metadata[k] = v
+	}
+	spec := d.Spec
+	if spec == nil {
+		spec = map[string]interface{}{}
+	}
+	return &graph.Node{ID: d.id(), Kind: d.Kind, Metadata: metadata, Spec: spec}
+}
+
+// normalize round-trips the node through JSON so it compares equal to what backends load
+func normalize(node *graph.Node) (*graph.Node, error) {
+	data, err := json.Marshal(node)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s definition: %w", node.Kind, err)
+	}
+	var out graph.Node
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// sameContent reports whether an existing node already matches the definition
+func sameContent(existing, desired *graph.Node) bool {
+	current, err := normalize(existing)
+	if err != nil {
+		return false
+	}
+	return reflect.DeepEqual(current.Metadata, desired.Metadata) && reflect.DeepEqual(current.Spec, desired.Spec)
+}
@@ -0,0 +1,170 @@
+package bootstrap
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPolicies = `
+kind: policy
+metadata:
+  name: policy-dev-before-prod
+  description: Requires deployment to dev before prod
+spec:
+  enforcement: block
+transitions:
+  - from: checkout
+    to: prod
+    edge_type: deploy
+`
+
+const testChecks = `
+kind: check
+metadata:
+  name: check-dev-deployment
+spec:
+  required_env: dev
+satisfies: [policy-dev-before-prod]
+`
+
+const testEnvironments = `
+kind: environment
+metadata:
+  name: dev
+  owner: platform-team
+spec:
+  description: Development
+---
+kind: resource_type
+metadata:
+  name: postgres
+spec:
+  version: "15"
+  tier_options: [standard, premium]
+`
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	return dir
+}
+
+func TestLoadDir_CreatesNodesAndEdges(t *testing.T) {
+	gg := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	// checks.yaml sorts before policies.yaml: edges must still resolve
+	dir := writeFiles(t, map[string]string{
+		"checks.yaml":      testChecks,
+		"policies.yaml":    testPolicies,
+		"environments.yml": testEnvironments,
+		"README.md":        "ignored",
+	})
+
+	result, err := NewLoader(gg).LoadDir(dir)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"check-dev-deployment", "dev", "postgres", "policy-dev-before-prod"}, result.Created)
+
+	policy, _ := gg.GetNode("policy-dev-before-prod")
+	require.NotNil(t, policy)
+	assert.Equal(t, graph.KindPolicy, policy.Kind)
+	assert.Equal(t, "active", policy.Metadata["status"])
+	assert.Equal(t, "bootstrap", policy.Metadata[ManagedByKey])
+
+	env, _ := gg.GetNode("dev")
+	require.NotNil(t, env)
+	assert.Equal(t, "Development", env.Spec["description"])
+
+	satisfied, err := gg.HasEdge("check-dev-deployment", "policy-dev-before-prod", graph.EdgeTypeSatisfies)
+	require.NoError(t, err)
+	assert.True(t, satisfied)
+
+	required, err := gg.HasEdge("checkout-deploy-prod", "policy-dev-before-prod", graph.EdgeTypeRequires)
+	require.NoError(t, err)
+	assert.True(t, required)
+}
+
+func TestLoadDir_IsIdempotent(t *testing.T) {
+	gg := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	dir := writeFiles(t, map[string]string{"policies.yaml": testPolicies, "checks.yaml": testChecks})
+	loader := NewLoader(gg)
+
+	_, err := loader.LoadDir(dir)
+	require.NoError(t, err)
+
+	// A check that has run keeps its status across restarts
+	check, _ := gg.GetNode("check-dev-deployment")
+	check.Metadata["status"] = graph.CheckStatusSucceeded
+	require.NoError(t, gg.UpdateNode(check))
+
+	result, err := loader.LoadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, result.Created)
+	assert.Empty(t, result.Updated)
+	assert.Len(t, result.Unchanged, 2)
+
+	check, _ = gg.GetNode("check-dev-deployment")
+	assert.Equal(t, graph.CheckStatusSucceeded, check.Metadata["status"])
+
+	edges, err := gg.Edges()
+	require.NoError(t, err)
+	assert.Len(t, edges["check-dev-deployment"], 1)
+}
+
+func TestLoadDir_UpdatesChangedDefinitions(t *testing.T) {
+	gg := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	loader := NewLoader(gg)
+	_, err := loader.LoadDir(writeFiles(t, map[string]string{"policies.yaml": testPolicies}))
+	require.NoError(t, err)
+
+	changed := `
+kind: policy
+metadata:
+  name: policy-dev-before-prod
+spec:
+  enforcement: warn
+`
+	result, err := loader.LoadDir(writeFiles(t, map[string]string{"policies.yaml": changed}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"policy-dev-before-prod"}, result.Updated)
+
+	policy, _ := gg.GetNode("policy-dev-before-prod")
+	assert.Equal(t, "warn", policy.Spec["enforcement"])
+}
+
+func TestLoadDir_InvalidDefinitionsLeaveGraphUntouched(t *testing.T) {
+	tests := map[string]string{
+		"unknown kind":    "kind: widget\nmetadata:\n  name: w\n",
+		"missing name":    "kind: policy\nspec: {}\n",
+		"contract error":  "kind: resource_type\nmetadata:\n  name: mysql\n",
+		"unknown field":   "kind: policy\nmetadata:\n  name: p\nspecs: {}\n",
+		"misplaced edges": "kind: policy\nmetadata:\n  name: p\nsatisfies: [x]\n",
+		"duplicate":       testPolicies + "---\n" + testPolicies,
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			gg := graph.NewGlobalGraph(graph.NewMemoryGraph())
+			dir := writeFiles(t, map[string]string{"a.yaml": testEnvironments, "b.yaml": content})
+
+			_, err := NewLoader(gg).LoadDir(dir)
+			assert.Error(t, err)
+
+			nodes, _ := gg.Nodes()
+			assert.Empty(t, nodes)
+		})
+	}
+}
+
+func TestLoadDir_ShippedDefinitionsAreValid(t *testing.T) {
+	gg := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	result, err := NewLoader(gg).LoadDir(filepath.Join("..", "..", "config", "bootstrap"))
+	require.NoError(t, err)
+	assert.Contains(t, result.Created, "policy-dev-before-prod")
+}
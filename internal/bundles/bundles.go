@@ -0,0 +1,529 @@
+// Package bundles creates immutable release bundles: the service versions and configuration of
+// an application resolved once and promoted unchanged from environment to environment, so what
+// reaches production is exactly what ran in the environments before it instead of whatever
+// each environment resolves as "latest" when it is deployed.
+package bundles
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/deployments"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/governance"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/graphwatch"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// PromotedSubject is the notify event emitted when a bundle is promoted to an environment
+const PromotedSubject = "release_bundle.promoted"
+
+var (
+	ErrApplicationNotFound = errors.New("application not found")
+	ErrBundleNotFound      = errors.New("release bundle not found")
+	// ErrOutOfOrder is returned when a bundle is promoted before reaching the environment the
+	// pipeline puts before the target
+	ErrOutOfOrder = errors.New("release bundle has not been promoted to the previous environment")
+	// ErrDrift is returned when the bundle or a version it pins changed since it was created
+	ErrDrift = errors.New("release bundle no longer matches what it pinned")
+	// ErrBlocked is returned when the deployment gates refused the promotion
+	ErrBlocked = errors.New("promotion blocked")
+)
+
+// Bundle is an application's service versions and configuration, resolved once. The digest
+// covers everything but the promotions, which are read from the bundle's deployments.
+type Bundle struct {
+	ID          string                            `json:"id"`
+	Application string                            `json:"application"`
+	Digest      string                            `json:"digest"` // hex sha256 of the application, services and config
+	Source      string                            `json:"source,omitempty"`
+	Services    []PinnedVersion                   `json:"services"`
+	Config      map[string]map[string]interface{} `json:"config"` // each service's spec, environment overrides included
+	CreatedBy   string                            `json:"created_by,omitempty"`
+	CreatedAt   time.Time                         `json:"created_at"`
+	Promotions  []Promotion                       `json:"promotions"`
+}
+
+// PinnedVersion is a service version a bundle deploys
+type PinnedVersion struct {
+	Service string `json:"service"`
+	Version string `json:"version"`
+	Digest  string `json:"digest"` // hex sha256 of the version node as it was when pinned
+}
+
+// Promotion is a bundle's deployment to an environment, with the gate decision it got there
+type Promotion struct {
+	Environment  string               `json:"environment"`
+	DeploymentID string               `json:"deployment_id"`
+	Status       string               `json:"status"`
+	Message      string               `json:"message,omitempty"`
+	PromotedBy   string               `json:"promoted_by,omitempty"`
+	PromotedAt   time.Time            `json:"promoted_at"`
+	Policy       *governance.Decision `json:"policy,omitempty"`
+}
+
+// CreateRequest chooses the versions a bundle pins. Services without an explicit version take
+// the one deployed in From, or their latest version when From is empty.
+type CreateRequest struct {
+	From     string            `json:"from,omitempty"`
+	Versions map[string]string `json:"versions,omitempty"` // by service
+}
+
+// Service creates and promotes release bundles
+type Service struct {
+	graph    *graph.GlobalGraph
+	eventBus *events.EventBus
+	order    map[string]string // environment -> environment a bundle must reach first
+	deployer *deployments.Deployer
+	logger   *logging.Logger
+	now      func() time.Time
+}
+
+// NewService creates a bundle service. order maps an environment to the one a bundle must have
+// been promoted to successfully before it, e.g. production: staging. Promotions roll out
+// through deployer, which shares its environment locks with every other deployment; nil
+// creates one with locks of its own.
+func NewService(globalGraph *graph.GlobalGraph, eventBus *events.EventBus, order map[string]string, deployer *deployments.Deployer) *Service {
+	if deployer == nil {
+		deployer = deployments.NewDeployer(globalGraph, eventBus, nil)
+	}
+	return &Service{
+		graph:    globalGraph,
+		eventBus: eventBus,
+		order:    order,
+		deployer: deployer,
+		logger:   logging.GetLogger().ForComponent("bundles"),
+		now:      time.Now,
+	}
+}
+
+// Create resolves the application's service versions and configuration into a bundle. Bundles
+// are identified by their content: creating one identical to an existing bundle returns that
+// bundle with created false.
+func (s *Service) Create(ctx context.Context, appName string, req CreateRequest) (*Bundle, bool, error) {
+	g, err := s.graph.Graph()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read graph: %w", err)
+	}
+	if node, ok := g.Nodes[appName]; !ok || node.Kind != graph.KindApplication {
+		return nil, false, fmt.Errorf("%w: %s", ErrApplicationNotFound, appName)
+	}
+	deployed := map[string]string{}
+	if req.From != "" {
+		if node, ok := g.Nodes[req.From]; !ok || node.Kind != graph.KindEnvironment {
+			return nil, false, fmt.Errorf("environment %s not found", req.From)
+		}
+		if deployed, err = deployments.NewDeploymentService(s.graph, nil).DeployedVersions(appName, req.From); err != nil {
+			return nil, false, err
+		}
+	}
+
+	services := ownedServices(g, appName)
+	if len(services) == 0 {
+		return nil, false, fmt.Errorf("application %s has no services to bundle", appName)
+	}
+	for service := range req.Versions {
+		if !contains(services, service) {
+			return nil, false, fmt.Errorf("service %s is not part of application %s", service, appName)
+		}
+	}
+
+	bundle := &Bundle{
+		Application: appName,
+		Source:      req.From,
+		Services:    make([]PinnedVersion, 0, len(services)),
+		Config:      map[string]map[string]interface{}{},
+		CreatedBy:   actor(ctx),
+		CreatedAt:   s.now().UTC(),
+		Promotions:  []Promotion{},
+	}
+	for _, service := range services {
+		version := req.Versions[service]
+		if version == "" {
+			version = deployed[service]
+		}
+		if version == "" {
+			version = latestVersion(g, service)
+		}
+		if version == "" {
+			return nil, false, fmt.Errorf("service %s has no version to pin", service)
+		}
+		node, ok := g.Nodes[service+":"+version]
+		if !ok || node.Kind != graph.KindServiceVersion {
+			return nil, false, fmt.Errorf("service version %s:%s not found", service, version)
+		}
+		bundle.Services = append(bundle.Services, PinnedVersion{Service: service, Version: version, Digest: versionDigest(node)})
+
+		config, err := toMap(g.Nodes[service].Spec)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid service %s: %w", service, err)
+		}
+		bundle.Config[service] = config
+	}
+	bundle.Digest = contentDigest(bundle)
+	bundle.ID = fmt.Sprintf("bundle-%s-%s", appName, bundle.Digest[:12])
+
+	if existing, ok := g.Nodes[bundle.ID]; ok && existing.Kind == graph.KindReleaseBundle {
+		stored, err := s.Get(bundle.ID)
+		return stored, false, err
+	}
+
+	node, err := bundleToNode(bundle)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := s.graph.AddNode(node); err != nil {
+		return nil, false, fmt.Errorf("failed to store release bundle: %w", err)
+	}
+	s.logger.Info("📦 Created release bundle %s pinning %d services", bundle.ID, len(bundle.Services))
+	return bundle, true, nil
+}
+
+// Get returns a bundle with its promotions
+func (s *Service) Get(bundleID string) (*Bundle, error) {
+	g, err := s.graph.Graph()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph: %w", err)
+	}
+	return loadBundle(g, bundleID)
+}
+
+// List returns the application's bundles, oldest first
+func (s *Service) List(appName string) ([]*Bundle, error) {
+	g, err := s.graph.Graph()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph: %w", err)
+	}
+	if node, ok := g.Nodes[appName]; !ok || node.Kind != graph.KindApplication {
+		return nil, fmt.Errorf("%w: %s", ErrApplicationNotFound, appName)
+	}
+	bundles := []*Bundle{}
+	for id, node := range g.Nodes {
+		if node.Kind != graph.KindReleaseBundle || node.Spec["application"] != appName {
+			continue
+		}
+		bundle, err := loadBundle(g, id)
+		if err != nil {
+			s.logger.Warn("⚠️ Skipping unreadable release bundle %s: %v", id, err)
+			continue
+		}
+		bundles = append(bundles, bundle)
+	}
+	sort.Slice(bundles, func(i, j int) bool {
+		if !bundles[i].CreatedAt.Equal(bundles[j].CreatedAt) {
+			return bundles[i].CreatedAt.Before(bundles[j].CreatedAt)
+		}
+		return bundles[i].ID < bundles[j].ID
+	})
+	return bundles, nil
+}
+
+// Promote deploys the bundle, unchanged, to an environment. The bundle must still match what
+// it pinned and must have reached the environment the pipeline puts before this one. It rolls
+// out through the deployment pipeline with the bundle as the release, so the environment lock,
+// the deployment gates and pending migrations apply and the gates' decision is recorded for
+// the bundle. Refused promotions are recorded as blocked and returned with an error.
+func (s *Service) Promote(ctx context.Context, bundleID, environment string) (*Promotion, error) {
+	g, err := s.graph.Graph()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph: %w", err)
+	}
+	bundle, err := loadBundle(g, bundleID)
+	if err != nil {
+		return nil, err
+	}
+	if node, ok := g.Nodes[environment]; !ok || node.Kind != graph.KindEnvironment {
+		return nil, fmt.Errorf("environment %s not found", environment)
+	}
+	if err := verify(g, bundle); err != nil {
+		return nil, err
+	}
+	if previous := s.order[environment]; previous != "" && !promotedTo(bundle, previous) {
+		return nil, fmt.Errorf("%w: %s must reach %s before %s", ErrOutOfOrder, bundle.ID, previous, environment)
+	}
+
+	s.logger.Info("🚀 Promoting %s to %s", bundle.ID, environment)
+	result, err := s.deployer.Deploy(ctx, deployments.Request{
+		Application: bundle.Application,
+		Environment: environment,
+		ReleaseID:   bundle.ID,
+		Metadata:    map[string]interface{}{"bundle_digest": bundle.Digest},
+	})
+	if errors.Is(err, deployments.ErrDeploymentBlocked) {
+		return s.latestPromotion(bundle.ID, environment), fmt.Errorf("%w: %v", ErrBlocked, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.recordVersions(bundle, environment, result.DeploymentID); err != nil {
+		return nil, err
+	}
+
+	if s.eventBus != nil {
+		if err := s.eventBus.Emit(events.EventTypeNotify, "bundles", PromotedSubject, map[string]interface{}{
+			"deployment_id": result.DeploymentID,
+			"bundle_id":     bundle.ID,
+			"digest":        bundle.Digest,
+			"application":   bundle.Application,
+			"environment":   environment,
+		}); err != nil {
+			s.logger.Warn("⚠️ Failed to emit %s for %s: %v", PromotedSubject, bundle.ID, err)
+		}
+	}
+	s.logger.Info("✅ %s promoted to %s", bundle.ID, environment)
+	return s.promotion(bundle.ID, result.DeploymentID), nil
+}
+
+// recordVersions records the pinned versions as deployed to the environment once the pipeline
+// rolled the bundle out, replacing the other versions of those services deployed there
+func (s *Service) recordVersions(bundle *Bundle, environment, deploymentID string) error {
+	now := s.now().UTC()
+	err := s.graph.Update(func(g *graph.Graph) error {
+		for _, pinned := range bundle.Services {
+			versionID := pinned.Service + ":" + pinned.Version
+			for _, edge := range g.Edges[pinned.Service] {
+				if edge.Type == graph.EdgeTypeHasVersion && edge.To != versionID {
+					removeEdge(g, edge.To, environment, graph.EdgeTypeDeploy)
+				}
+			}
+			removeEdge(g, versionID, environment, graph.EdgeTypeDeploy)
+			g.Edges[versionID] = append(g.Edges[versionID], graph.Edge{
+				To:   environment,
+				Type: graph.EdgeTypeDeploy,
+				Metadata: map[string]interface{}{
+					"deployment_id": deploymentID,
+					"release_id":    bundle.ID,
+					"status":        string(deployments.StatusSucceeded),
+					"updated_at":    now.Format(time.RFC3339),
+				},
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record promotion: %w", err)
+	}
+	return nil
+}
+
+// latestPromotion returns the bundle's most recent promotion to the environment
+func (s *Service) latestPromotion(bundleID, environment string) *Promotion {
+	bundle, err := s.Get(bundleID)
+	if err != nil {
+		return nil
+	}
+	var latest *Promotion
+	for i := range bundle.Promotions {
+		if bundle.Promotions[i].Environment == environment {
+			latest = &bundle.Promotions[i]
+		}
+	}
+	return latest
+}
+
+// promotion reads the promotion back from the graph, falling back to its ID when the graph
+// cannot be read
+func (s *Service) promotion(bundleID, deploymentID string) *Promotion {
+	if bundle, err := s.Get(bundleID); err == nil {
+		for i := range bundle.Promotions {
+			if bundle.Promotions[i].DeploymentID == deploymentID {
+				return &bundle.Promotions[i]
+			}
+		}
+	}
+	return &Promotion{DeploymentID: deploymentID}
+}
+
+// ImmutablePolicy is the mutation policy denying changes to stored bundles. Promotions are
+// recorded on the bundle's deployment edges, so the bundle node itself never changes.
+func ImmutablePolicy() governance.Policy {
+	return governance.Policy{
+		Name:        "immutable-release-bundles",
+		Description: "Release bundles cannot be changed once created; create a new bundle instead",
+		Check: func(m governance.Mutation) string {
+			if m.Node == nil || m.Op != graphwatch.OpUpdated || m.Node.Kind != graph.KindReleaseBundle {
+				return ""
+			}
+			return "release bundles are immutable"
+		},
+	}
+}
+
+// verify checks that the stored bundle still hashes to its digest and that every version it
+// pins is unchanged
+func verify(g *graph.Graph, bundle *Bundle) error {
+	if contentDigest(bundle) != bundle.Digest {
+		return fmt.Errorf("%w: %s was modified after it was created", ErrDrift, bundle.ID)
+	}
+	for _, pinned := range bundle.Services {
+		node, ok := g.Nodes[pinned.Service+":"+pinned.Version]
+		if !ok || node.Kind != graph.KindServiceVersion {
+			return fmt.Errorf("%w: service version %s:%s no longer exists", ErrDrift, pinned.Service, pinned.Version)
+		}
+		if versionDigest(node) != pinned.Digest {
+			return fmt.Errorf("%w: service version %s:%s changed since it was pinned", ErrDrift, pinned.Service, pinned.Version)
+		}
+	}
+	return nil
+}
+
+// promotedTo reports whether the bundle was promoted successfully to the environment
+func promotedTo(bundle *Bundle, environment string) bool {
+	for _, promotion := range bundle.Promotions {
+		if promotion.Environment == environment && promotion.Status == string(deployments.StatusSucceeded) {
+			return true
+		}
+	}
+	return false
+}
+
+func loadBundle(g *graph.Graph, bundleID string) (*Bundle, error) {
+	node, ok := g.Nodes[bundleID]
+	if !ok || node.Kind != graph.KindReleaseBundle {
+		return nil, fmt.Errorf("%w: %s", ErrBundleNotFound, bundleID)
+	}
+	data, err := json.Marshal(node.Spec)
+	if err != nil {
+		return nil, err
+	}
+	var bundle Bundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return nil, fmt.Errorf("invalid release bundle %s: %w", bundleID, err)
+	}
+	bundle.Promotions = promotions(g, bundleID)
+	return &bundle, nil
+}
+
+func bundleToNode(bundle *Bundle) (*graph.Node, error) {
+	spec, err := toMap(bundle)
+	if err != nil {
+		return nil, err
+	}
+	delete(spec, "promotions")
+	return &graph.Node{
+		ID:       bundle.ID,
+		Kind:     graph.KindReleaseBundle,
+		Metadata: map[string]interface{}{"name": bundle.ID, "application": bundle.Application},
+		Spec:     spec,
+	}, nil
+}
+
+// promotions reads the bundle's deployments, oldest first
+func promotions(g *graph.Graph, bundleID string) []Promotion {
+	result := []Promotion{}
+	for _, edge := range g.Edges[bundleID] {
+		if edge.Type != "deployment" {
+			continue
+		}
+		promotion := Promotion{Environment: edge.To}
+		promotion.DeploymentID, _ = edge.Metadata["deployment_id"].(string)
+		promotion.Status, _ = edge.Metadata["status"].(string)
+		promotion.Message, _ = edge.Metadata["message"].(string)
+		if created, ok := edge.Metadata["created_at"].(string); ok {
+			promotion.PromotedAt, _ = time.Parse(time.RFC3339, created)
+		}
+		if history := deployments.StatusHistory(edge.Metadata); len(history) > 0 {
+			promotion.PromotedBy = history[0].Actor
+		}
+		promotion.Policy = governance.LookupDecision(g, edge.To, bundleID)
+		result = append(result, promotion)
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].PromotedAt.Before(result[j].PromotedAt) })
+	return result
+}
+
+func removeEdge(g *graph.Graph, from, to, edgeType string) {
+	kept := g.Edges[from][:0]
+	for _, edge := range g.Edges[from] {
+		if edge.To != to || edge.Type != edgeType {
+			kept = append(kept, edge)
+		}
+	}
+	g.Edges[from] = kept
+}
+
+// ownedServices returns the application's services, sorted
+func ownedServices(g *graph.Graph, appName string) []string {
+	var services []string
+	for _, edge := range g.Edges[appName] {
+		if node, ok := g.Nodes[edge.To]; ok && edge.Type == graph.EdgeTypeOwns && node.Kind == graph.KindService {
+			services = append(services, edge.To)
+		}
+	}
+	sort.Strings(services)
+	return services
+}
+
+// latestVersion returns the service's latest version. Version nodes carry no creation time,
+// so like the deployed version lookups the highest "<service>:<version>" ID wins.
+func latestVersion(g *graph.Graph, service string) string {
+	var latest string
+	for _, edge := range g.Edges[service] {
+		if node, ok := g.Nodes[edge.To]; ok && edge.Type == graph.EdgeTypeHasVersion && node.Kind == graph.KindServiceVersion && edge.To > latest {
+			latest = edge.To
+		}
+	}
+	if latest == "" {
+		return ""
+	}
+	return latest[len(service)+1:]
+}
+
+// versionDigest hashes a service version as an artifact. Its metadata is left out: lifecycle
+// states and scan results change without changing what is deployed.
+func versionDigest(node *graph.Node) string {
+	data, _ := json.Marshal(struct {
+		ID   string                 `json:"id"`
+		Kind string                 `json:"kind"`
+		Spec map[string]interface{} `json:"spec"`
+	}{node.ID, node.Kind, node.Spec})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// contentDigest hashes what the bundle deploys
+func contentDigest(bundle *Bundle) string {
+	data, _ := json.Marshal(struct {
+		Application string                            `json:"application"`
+		Services    []PinnedVersion                   `json:"services"`
+		Config      map[string]map[string]interface{} `json:"config"`
+	}{bundle.Application, bundle.Services, bundle.Config})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// toMap converts a value to the JSON-shaped map the graph stores, so stored and in-memory
+// values compare and hash alike
+func toMap(value interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]interface{}{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// actor is who a bundle or promotion is attributed to: the user the request came from
+func actor(ctx context.Context) string {
+	return logging.UserIDFromContext(ctx)
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
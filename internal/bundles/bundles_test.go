@@ -0,0 +1,176 @@
+package bundles
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/calendar"
+	"github.com/krzachariassen/ZTDP/internal/deployments"
+	"github.com/krzachariassen/ZTDP/internal/governance"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/graphwatch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newBundleTestService(t *testing.T) (*Service, *graph.GlobalGraph) {
+	t.Helper()
+	g := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	add := func(id, kind string, spec map[string]interface{}) {
+		require.NoError(t, g.AddNode(&graph.Node{ID: id, Kind: kind, Metadata: map[string]interface{}{"name": id}, Spec: spec}))
+	}
+	add("shop", graph.KindApplication, map[string]interface{}{})
+	add("staging", graph.KindEnvironment, map[string]interface{}{})
+	add("production", graph.KindEnvironment, map[string]interface{}{})
+	add("checkout", graph.KindService, map[string]interface{}{"port": 8080})
+	add("cart", graph.KindService, map[string]interface{}{"port": 9090})
+	require.NoError(t, g.AddEdge("shop", "checkout", graph.EdgeTypeOwns))
+	require.NoError(t, g.AddEdge("shop", "cart", graph.EdgeTypeOwns))
+	for _, version := range []string{"checkout:1.0.0", "checkout:1.1.0", "cart:2.0.0"} {
+		add(version, graph.KindServiceVersion, map[string]interface{}{"image": "registry/" + version})
+	}
+	require.NoError(t, g.AddEdge("checkout", "checkout:1.0.0", graph.EdgeTypeHasVersion))
+	require.NoError(t, g.AddEdge("checkout", "checkout:1.1.0", graph.EdgeTypeHasVersion))
+	require.NoError(t, g.AddEdge("cart", "cart:2.0.0", graph.EdgeTypeHasVersion))
+
+	service := NewService(g, nil, map[string]string{"production": "staging"}, nil)
+	clock := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time {
+		clock = clock.Add(time.Minute)
+		return clock
+	}
+	return service, g
+}
+
+func TestCreatePinsVersionsOnce(t *testing.T) {
+	service, g := newBundleTestService(t)
+
+	bundle, created, err := service.Create(context.Background(), "shop", CreateRequest{})
+	require.NoError(t, err)
+	assert.True(t, created)
+	require.Len(t, bundle.Services, 2)
+	assert.Equal(t, "cart", bundle.Services[0].Service)
+	assert.Equal(t, "2.0.0", bundle.Services[0].Version)
+	assert.Equal(t, "1.1.0", bundle.Services[1].Version, "services without a version pin their latest")
+	assert.EqualValues(t, 8080, bundle.Config["checkout"]["port"])
+	assert.Equal(t, "bundle-shop-"+bundle.Digest[:12], bundle.ID)
+
+	again, created, err := service.Create(context.Background(), "shop", CreateRequest{})
+	require.NoError(t, err)
+	assert.False(t, created, "identical content returns the existing bundle")
+	assert.Equal(t, bundle.ID, again.ID)
+
+	older, created, err := service.Create(context.Background(), "shop", CreateRequest{Versions: map[string]string{"checkout": "1.0.0"}})
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.NotEqual(t, bundle.Digest, older.Digest)
+
+	list, err := service.List("shop")
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, bundle.ID, list[0].ID)
+
+	nodes, err := g.Nodes()
+	require.NoError(t, err)
+	node := nodes[bundle.ID]
+	require.NotNil(t, node)
+	assert.NotEmpty(t, ImmutablePolicy().Check(governance.Mutation{Change: graphwatch.Change{Op: graphwatch.OpUpdated}, Node: node}))
+
+	_, _, err = service.Create(context.Background(), "missing", CreateRequest{})
+	assert.ErrorIs(t, err, ErrApplicationNotFound)
+	_, _, err = service.Create(context.Background(), "shop", CreateRequest{Versions: map[string]string{"checkout": "9.9.9"}})
+	assert.Error(t, err)
+}
+
+func TestPromoteFollowsThePipeline(t *testing.T) {
+	service, g := newBundleTestService(t)
+	ctx := context.Background()
+	bundle, _, err := service.Create(ctx, "shop", CreateRequest{})
+	require.NoError(t, err)
+
+	_, err = service.Promote(ctx, bundle.ID, "production")
+	assert.ErrorIs(t, err, ErrOutOfOrder, "production needs the bundle in staging first")
+
+	promotion, err := service.Promote(ctx, bundle.ID, "staging")
+	require.NoError(t, err)
+	assert.Equal(t, "staging", promotion.Environment)
+	assert.Equal(t, string(deployments.StatusSucceeded), promotion.Status)
+
+	deployed, err := deployments.NewDeploymentService(g, nil).DeployedVersions("shop", "staging")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"checkout": "1.1.0", "cart": "2.0.0"}, deployed)
+
+	// A newer version appearing does not change what the bundle promotes
+	require.NoError(t, g.AddNode(&graph.Node{ID: "checkout:1.2.0", Kind: graph.KindServiceVersion, Metadata: map[string]interface{}{"name": "checkout:1.2.0"}}))
+	require.NoError(t, g.AddEdge("checkout", "checkout:1.2.0", graph.EdgeTypeHasVersion))
+	_, err = service.Promote(ctx, bundle.ID, "production")
+	require.NoError(t, err)
+	deployed, err = deployments.NewDeploymentService(g, nil).DeployedVersions("shop", "production")
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.0", deployed["checkout"])
+
+	stored, err := service.Get(bundle.ID)
+	require.NoError(t, err)
+	require.Len(t, stored.Promotions, 2)
+	assert.Equal(t, []string{"staging", "production"}, []string{stored.Promotions[0].Environment, stored.Promotions[1].Environment})
+
+	fromStaging, created, err := service.Create(ctx, "shop", CreateRequest{From: "staging"})
+	require.NoError(t, err)
+	assert.False(t, created, "a bundle from staging pins what the bundle deployed there")
+	assert.Equal(t, bundle.ID, fromStaging.ID)
+}
+
+func TestPromoteRejectsDrift(t *testing.T) {
+	service, g := newBundleTestService(t)
+	bundle, _, err := service.Create(context.Background(), "shop", CreateRequest{})
+	require.NoError(t, err)
+
+	current, err := g.Graph()
+	require.NoError(t, err)
+	current.Nodes["checkout:1.1.0"].Spec["image"] = "registry/checkout:rebuilt"
+	require.NoError(t, g.Backend.SaveGlobal(current))
+
+	_, err = service.Promote(context.Background(), bundle.ID, "staging")
+	assert.ErrorIs(t, err, ErrDrift)
+}
+
+func TestPromoteRecordsBlockedPromotions(t *testing.T) {
+	service, g := newBundleTestService(t)
+	now := time.Now()
+	_, err := calendar.NewService(g).Schedule(calendar.Entry{Type: calendar.TypeFreeze, Environment: "staging", Start: now.Add(-time.Hour), End: now.Add(time.Hour), Reason: "change freeze"}, false)
+	require.NoError(t, err)
+	bundle, _, err := service.Create(context.Background(), "shop", CreateRequest{})
+	require.NoError(t, err)
+
+	promotion, err := service.Promote(context.Background(), bundle.ID, "staging")
+	assert.ErrorIs(t, err, ErrBlocked)
+	require.NotNil(t, promotion)
+	assert.Equal(t, governance.DecisionBlocked, promotion.Status)
+	require.NotNil(t, promotion.Policy, "the gates' decision is recorded for the bundle")
+	assert.Equal(t, governance.DecisionBlocked, promotion.Policy.Decision)
+
+	deployed, err := deployments.NewDeploymentService(g, nil).DeployedVersions("shop", "staging")
+	require.NoError(t, err)
+	assert.Empty(t, deployed)
+}
+
+func TestPromoteSharesEnvironmentLocks(t *testing.T) {
+	_, g := newBundleTestService(t)
+	locks := deployments.NewEnvironmentLocks(deployments.DeploymentLockTTL)
+	service := NewService(g, nil, nil, deployments.NewDeployer(g, nil, locks))
+	bundle, _, err := service.Create(context.Background(), "shop", CreateRequest{})
+	require.NoError(t, err)
+
+	release, err := locks.Acquire("shop", "staging", "agent-deployment")
+	require.NoError(t, err)
+	_, err = service.Promote(context.Background(), bundle.ID, "staging")
+	var inProgress *deployments.InProgressError
+	require.True(t, errors.As(err, &inProgress), "a deployment by the agent holds the environment")
+
+	release()
+	promotion, err := service.Promote(context.Background(), bundle.ID, "staging")
+	require.NoError(t, err)
+	assert.Equal(t, string(deployments.StatusSucceeded), promotion.Status)
+}
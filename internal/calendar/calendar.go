@@ -0,0 +1,332 @@
+// Package calendar keeps the change calendar: scheduled deployments, resource maintenance
+// windows and change freezes, stored in the global graph. Proposed changes are checked against
+// it so a deployment into a freeze is refused and overlapping changes to the same resources are
+// reported before anything runs.
+package calendar
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// Entry types
+const (
+	TypeDeployment  = "deployment"
+	TypeMaintenance = "maintenance"
+	TypeFreeze      = "freeze"
+)
+
+// DeploymentDuration is how long a deployment is assumed to take when no end is given
+const DeploymentDuration = time.Hour
+
+// nodeIDPrefix namespaces calendar nodes so they cannot collide with platform entities
+const nodeIDPrefix = "calendar:"
+
+// ErrEntryNotFound is returned when a calendar entry does not exist
+var ErrEntryNotFound = errors.New("calendar entry not found")
+
+// Entry is a change or freeze period on the calendar. Application and Environment scope it;
+// left empty on a freeze or maintenance window they mean every application or environment.
+type Entry struct {
+	ID          string    `json:"id"`
+	Type        string    `json:"type"` // deployment | maintenance | freeze
+	Title       string    `json:"title,omitempty"`
+	Application string    `json:"application,omitempty"`
+	Environment string    `json:"environment,omitempty"`
+	Resources   []string  `json:"resources,omitempty"` // maintenance: resources worked on; deployment: resources the application uses
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	Reason      string    `json:"reason,omitempty"`
+	CreatedBy   string    `json:"created_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Validate checks the entry's type, scope and window
+func (e Entry) Validate() error {
+	switch e.Type {
+	case TypeDeployment:
+		if e.Application == "" || e.Environment == "" {
+			return fmt.Errorf("scheduled deployments need an application and an environment")
+		}
+	case TypeMaintenance:
+		if len(e.Resources) == 0 {
+			return fmt.Errorf("maintenance windows need at least one resource")
+		}
+	case TypeFreeze:
+	default:
+		return fmt.Errorf("unknown calendar entry type %q (use deployment, maintenance or freeze)", e.Type)
+	}
+	if e.Start.IsZero() {
+		return fmt.Errorf("start is required")
+	}
+	if !e.End.After(e.Start) {
+		return fmt.Errorf("end must be after start")
+	}
+	return nil
+}
+
+// Overlaps reports whether the entry's window intersects [start, end)
+func (e Entry) Overlaps(start, end time.Time) bool {
+	return e.Start.Before(end) && start.Before(e.End)
+}
+
+// Service stores calendar entries in the global graph and checks changes against them
+type Service struct {
+	graph    *graph.GlobalGraph
+	eventBus *events.EventBus // maintenance impact notices; nil uses the global bus
+	logger   *logging.Logger
+	now      func() time.Time
+}
+
+// NewService creates a calendar service backed by the global graph
+func NewService(globalGraph *graph.GlobalGraph) *Service {
+	return &Service{
+		graph:  globalGraph,
+		logger: logging.GetLogger().ForComponent("calendar"),
+		now:    time.Now,
+	}
+}
+
+// ScheduleResult is a stored entry and the non-blocking conflicts found when it was scheduled.
+// For a maintenance window it also holds the impact on each owner depending on its resources.
+type ScheduleResult struct {
+	Entry     *Entry     `json:"entry"`
+	Conflicts []Conflict `json:"conflicts,omitempty"`
+	Impact    []Notice   `json:"impact,omitempty"`
+}
+
+// Schedule checks an entry against the calendar and stores it. A deployment without an end is
+// given DeploymentDuration; its resources are read from the graph. Changes that fall into a
+// freeze are refused with a *ConflictError; with dryRun nothing is stored. Once a maintenance
+// window is stored, every owner of an application or service depending on its resources is sent
+// a MaintenanceImpactSubject notice with the impact window.
+func (s *Service) Schedule(entry Entry, dryRun bool) (*ScheduleResult, error) {
+	if entry.Type == TypeDeployment && entry.End.IsZero() && !entry.Start.IsZero() {
+		entry.End = entry.Start.Add(DeploymentDuration)
+	}
+	if err := entry.Validate(); err != nil {
+		return nil, err
+	}
+	if entry.Type == TypeDeployment {
+		if app, _ := s.graph.GetNode(entry.Application); app == nil || app.Kind != graph.KindApplication {
+			return nil, fmt.Errorf("application %s not found", entry.Application)
+		}
+		resources, err := s.deploymentResources(entry.Application)
+		if err != nil {
+			return nil, err
+		}
+		entry.Resources = resources
+	}
+
+	conflicts, err := s.Check(entry)
+	if err != nil {
+		return nil, err
+	}
+	if err := Blocking(conflicts); err != nil {
+		return nil, err
+	}
+	result := &ScheduleResult{Entry: &entry, Conflicts: conflicts}
+	if entry.Type == TypeMaintenance {
+		if result.Impact, err = s.MaintenanceImpact(entry); err != nil {
+			return nil, err
+		}
+	}
+	if dryRun {
+		return result, nil
+	}
+
+	now := s.now().UTC()
+	entry.ID = fmt.Sprintf("%s-%d", entry.Type, now.UnixNano())
+	entry.CreatedAt = now
+	node, err := entryToNode(&entry)
+	if err != nil {
+		return nil, err
+	}
+	s.graph.AddNode(node)
+	if err := s.graph.Save(); err != nil {
+		return nil, fmt.Errorf("failed to save calendar entry: %w", err)
+	}
+	s.logger.Info("📅 Scheduled %s %s from %s to %s", entry.Type, entry.ID, entry.Start.Format(time.RFC3339), entry.End.Format(time.RFC3339))
+	for i := range result.Impact {
+		result.Impact[i].Entry = entry.ID
+	}
+	s.broadcastImpact(result.Impact)
+	return result, nil
+}
+
+// Get returns a calendar entry by ID
+func (s *Service) Get(id string) (*Entry, error) {
+	node, _ := s.graph.GetNode(nodeIDPrefix + id)
+	if node == nil || node.Kind != graph.KindCalendarEntry {
+		return nil, ErrEntryNotFound
+	}
+	return nodeToEntry(node)
+}
+
+// Delete removes a calendar entry
+func (s *Service) Delete(id string) error {
+	if _, err := s.Get(id); err != nil {
+		return err
+	}
+	if err := s.graph.DeleteNode(nodeIDPrefix + id); err != nil {
+		return fmt.Errorf("failed to delete calendar entry: %w", err)
+	}
+	s.logger.Info("📅 Calendar entry %s deleted", id)
+	return s.graph.Save()
+}
+
+// Filter narrows the calendar view; empty fields match everything
+type Filter struct {
+	From        time.Time
+	To          time.Time
+	Application string
+	Environment string
+}
+
+// View is the calendar between two times with the conflicts between its entries
+type View struct {
+	From      time.Time  `json:"from"`
+	To        time.Time  `json:"to"`
+	Entries   []*Entry   `json:"entries"`
+	Conflicts []Conflict `json:"conflicts,omitempty"`
+}
+
+// View returns the entries overlapping the filter's window, ordered by start. Freezes and
+// maintenance windows without an application or environment match every filter.
+func (s *Service) View(filter Filter) (*View, error) {
+	entries, err := s.entries()
+	if err != nil {
+		return nil, err
+	}
+	view := &View{From: filter.From, To: filter.To, Entries: []*Entry{}}
+	for _, entry := range entries {
+		if !entry.Overlaps(filter.From, filter.To) ||
+			!matches(entry.Application, filter.Application) || !matches(entry.Environment, filter.Environment) {
+			continue
+		}
+		view.Entries = append(view.Entries, entry)
+	}
+	// Two changes to the same resources conflict with each other; report the pair once
+	reported := map[[2]string]bool{}
+	for _, entry := range view.Entries {
+		if entry.Type == TypeFreeze {
+			continue
+		}
+		for _, conflict := range conflicts(*entry, entries) {
+			if reported[[2]string{conflict.With, conflict.Change}] {
+				continue
+			}
+			reported[[2]string{conflict.Change, conflict.With}] = true
+			view.Conflicts = append(view.Conflicts, conflict)
+		}
+	}
+	return view, nil
+}
+
+// entries returns every stored entry ordered by start
+func (s *Service) entries() ([]*Entry, error) {
+	nodes, err := s.graph.Nodes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph: %w", err)
+	}
+	entries := []*Entry{}
+	for _, node := range nodes {
+		if node.Kind != graph.KindCalendarEntry {
+			continue
+		}
+		entry, err := nodeToEntry(node)
+		if err != nil {
+			s.logger.Warn("⚠️ Skipping malformed calendar entry %s: %v", node.ID, err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].Start.Equal(entries[j].Start) {
+			return entries[i].Start.Before(entries[j].Start)
+		}
+		return entries[i].ID < entries[j].ID
+	})
+	return entries, nil
+}
+
+// deploymentResources lists the resources a deployment of the application may change: those
+// the application owns or was granted, and those its services use or were granted
+func (s *Service) deploymentResources(appName string) ([]string, error) {
+	nodes, err := s.graph.Nodes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph: %w", err)
+	}
+	edges, err := s.graph.Edges()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph: %w", err)
+	}
+	seen := map[string]bool{}
+	var resources []string
+	add := func(from string) {
+		for _, edge := range edges[from] {
+			node, ok := nodes[edge.To]
+			if !ok || node.Kind != graph.KindResource || seen[edge.To] {
+				continue
+			}
+			switch edge.Type {
+			case graph.EdgeTypeOwns, graph.EdgeTypeUses, graph.EdgeTypeAccesses:
+				seen[edge.To] = true
+				resources = append(resources, edge.To)
+			}
+		}
+	}
+	add(appName)
+	for _, edge := range edges[appName] {
+		if node, ok := nodes[edge.To]; ok && edge.Type == graph.EdgeTypeOwns && node.Kind == graph.KindService {
+			add(edge.To)
+		}
+	}
+	sort.Strings(resources)
+	return resources, nil
+}
+
+func matches(scope, filter string) bool {
+	return scope == "" || filter == "" || scope == filter
+}
+
+func entryToNode(entry *Entry) (*graph.Node, error) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode calendar entry: %w", err)
+	}
+	var spec map[string]interface{}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to encode calendar entry: %w", err)
+	}
+	return &graph.Node{
+		ID:   nodeIDPrefix + entry.ID,
+		Kind: graph.KindCalendarEntry,
+		Metadata: map[string]interface{}{
+			"name":        entry.ID,
+			"type":        entry.Type,
+			"application": entry.Application,
+			"environment": entry.Environment,
+		},
+		Spec: spec,
+	}, nil
+}
+
+func nodeToEntry(node *graph.Node) (*Entry, error) {
+	data, err := json.Marshal(node.Spec)
+	if err != nil {
+		return nil, err
+	}
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
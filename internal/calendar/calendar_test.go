@@ -0,0 +1,105 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var monday = time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+func newCalendarTestService(t *testing.T) *Service {
+	t.Helper()
+	g := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	for _, app := range []string{"checkout", "search"} {
+		g.AddNode(&graph.Node{ID: app, Kind: graph.KindApplication, Metadata: map[string]interface{}{"name": app}, Spec: map[string]interface{}{}})
+		g.AddNode(&graph.Node{ID: app + "-api", Kind: graph.KindService, Metadata: map[string]interface{}{"name": app + "-api"}, Spec: map[string]interface{}{}})
+		require.NoError(t, g.AddEdge(app, app+"-api", graph.EdgeTypeOwns))
+	}
+	g.AddNode(&graph.Node{ID: "checkout-db", Kind: graph.KindResource, Metadata: map[string]interface{}{"name": "checkout-db", "application": "checkout", "catalog_ref": "postgres"}, Spec: map[string]interface{}{}})
+	g.AddNode(&graph.Node{ID: "shared-kafka", Kind: graph.KindResource, Metadata: map[string]interface{}{"name": "shared-kafka", "shared": true, "catalog_ref": "kafka"}, Spec: map[string]interface{}{}})
+	require.NoError(t, g.AddEdge("checkout", "checkout-db", graph.EdgeTypeOwns))
+	require.NoError(t, g.AddEdge("checkout-api", "shared-kafka", graph.EdgeTypeAccesses))
+	require.NoError(t, g.AddEdge("search-api", "shared-kafka", graph.EdgeTypeAccesses))
+
+	service := NewService(g)
+	service.now = func() time.Time { return monday }
+	return service
+}
+
+func TestSchedule_RefusesChangesInsideAFreeze(t *testing.T) {
+	service := newCalendarTestService(t)
+
+	freeze, err := service.Schedule(Entry{Type: TypeFreeze, Environment: "prod", Start: monday, End: monday.Add(48 * time.Hour), Reason: "quarter close"}, false)
+	require.NoError(t, err)
+
+	_, err = service.Schedule(Entry{Type: TypeDeployment, Application: "checkout", Environment: "prod", Start: monday.Add(time.Hour)}, false)
+	var conflict *ConflictError
+	require.ErrorAs(t, err, &conflict)
+	require.Len(t, conflict.Conflicts, 1)
+	assert.Equal(t, freeze.Entry.ID, conflict.Conflicts[0].With)
+	assert.Contains(t, conflict.Error(), "prod is frozen from 2026-03-02T09:00:00Z to 2026-03-04T09:00:00Z (quarter close)")
+
+	result, err := service.Schedule(Entry{Type: TypeDeployment, Application: "checkout", Environment: "staging", Start: monday.Add(time.Hour)}, false)
+	require.NoError(t, err, "the freeze only covers prod")
+	assert.Equal(t, monday.Add(2*time.Hour), result.Entry.End, "deployments default to an hour")
+	assert.Equal(t, []string{"checkout-db", "shared-kafka"}, result.Entry.Resources)
+
+	conflicts, err := service.CheckDeployment("checkout", "prod", monday.Add(72*time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, conflicts, "the freeze is over by then")
+}
+
+func TestSchedule_ReportsChangesToSharedResources(t *testing.T) {
+	service := newCalendarTestService(t)
+
+	maintenance, err := service.Schedule(Entry{Type: TypeMaintenance, Resources: []string{"shared-kafka"}, Start: monday, End: monday.Add(4 * time.Hour)}, false)
+	require.NoError(t, err)
+
+	result, err := service.Schedule(Entry{Type: TypeDeployment, Application: "search", Environment: "prod", Start: monday.Add(time.Hour)}, true)
+	require.NoError(t, err)
+	assert.Empty(t, result.Entry.ID, "a dry run stores nothing")
+	require.Len(t, result.Conflicts, 1)
+	assert.False(t, result.Conflicts[0].Blocking)
+	assert.Equal(t, maintenance.Entry.ID, result.Conflicts[0].With)
+	assert.Contains(t, result.Conflicts[0].Reason, "also changes shared-kafka")
+
+	service.now = func() time.Time { return monday.Add(time.Minute) }
+	_, err = service.Schedule(Entry{Type: TypeDeployment, Application: "checkout", Environment: "prod", Start: monday.Add(2 * time.Hour)}, false)
+	require.NoError(t, err)
+
+	view, err := service.View(Filter{From: monday, To: monday.Add(24 * time.Hour), Environment: "prod"})
+	require.NoError(t, err)
+	require.Len(t, view.Entries, 2, "the maintenance window has no environment and shows for prod")
+	assert.Equal(t, TypeMaintenance, view.Entries[0].Type)
+	assert.Len(t, view.Conflicts, 1, "each overlapping pair is reported once")
+
+	// Another deployment of the same application and environment is the same change
+	conflicts, err := service.CheckDeployment("checkout", "prod", monday.Add(2*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, TypeMaintenance, conflicts[0].Type)
+
+	require.NoError(t, service.Delete(maintenance.Entry.ID))
+	assert.ErrorIs(t, service.Delete(maintenance.Entry.ID), ErrEntryNotFound)
+}
+
+func TestSchedule_FreezeListsChangesAlreadyScheduled(t *testing.T) {
+	service := newCalendarTestService(t)
+
+	_, err := service.Schedule(Entry{Type: TypeDeployment, Application: "checkout", Environment: "prod", Start: monday}, false)
+	require.NoError(t, err)
+
+	result, err := service.Schedule(Entry{Type: TypeFreeze, Application: "checkout", Start: monday.Add(-time.Hour), End: monday.Add(time.Hour)}, false)
+	require.NoError(t, err, "declaring a freeze is never blocked")
+	require.Len(t, result.Conflicts, 1)
+	assert.Contains(t, result.Conflicts[0].Reason, "deployment of checkout to prod is scheduled during the freeze")
+
+	_, err = service.Schedule(Entry{Type: TypeMaintenance, Start: monday, End: monday.Add(time.Hour)}, false)
+	assert.ErrorContains(t, err, "at least one resource")
+	_, err = service.Schedule(Entry{Type: TypeFreeze, Start: monday, End: monday}, false)
+	assert.ErrorContains(t, err, "end must be after start")
+}
@@ -0,0 +1,162 @@
+package calendar
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Conflict is a calendar entry that overlaps a change. Freezes block the change; other
+// changes to the same resources are reported so they can be coordinated.
+type Conflict struct {
+	Change   string `json:"change,omitempty"` // ID of the checked change; empty for one not yet scheduled
+	With     string `json:"with"`             // ID of the overlapping entry
+	Type     string `json:"type"`             // type of the overlapping entry
+	Reason   string `json:"reason"`
+	Blocking bool   `json:"blocking"`
+}
+
+func (c Conflict) String() string {
+	if c.Blocking {
+		return "blocked: " + c.Reason
+	}
+	return c.Reason
+}
+
+// ConflictError refuses a change that falls into a freeze
+type ConflictError struct {
+	Conflicts []Conflict `json:"conflicts"`
+}
+
+func (e *ConflictError) Error() string {
+	reasons := make([]string, 0, len(e.Conflicts))
+	for _, conflict := range e.Conflicts {
+		if conflict.Blocking {
+			reasons = append(reasons, conflict.Reason)
+		}
+	}
+	return "change conflicts with the calendar: " + strings.Join(reasons, "; ")
+}
+
+// Blocking returns a *ConflictError listing the conflicts if any of them blocks the change
+func Blocking(conflicts []Conflict) error {
+	for _, conflict := range conflicts {
+		if conflict.Blocking {
+			return &ConflictError{Conflicts: conflicts}
+		}
+	}
+	return nil
+}
+
+// Check returns the stored entries that conflict with a change
+func (s *Service) Check(change Entry) ([]Conflict, error) {
+	entries, err := s.entries()
+	if err != nil {
+		return nil, err
+	}
+	return conflicts(change, entries), nil
+}
+
+// CheckDeployment returns the conflicts of deploying the application to the environment at the
+// given time, for deployments that run now and plan previews
+func (s *Service) CheckDeployment(appName, environment string, at time.Time) ([]Conflict, error) {
+	resources, err := s.deploymentResources(appName)
+	if err != nil {
+		return nil, err
+	}
+	return s.Check(Entry{
+		Type:        TypeDeployment,
+		Application: appName,
+		Environment: environment,
+		Resources:   resources,
+		Start:       at,
+		End:         at.Add(DeploymentDuration),
+	})
+}
+
+// conflicts compares a change with the entries overlapping its window. A freeze being declared
+// reports the changes already scheduled inside it; a scheduled deployment of the same
+// application and environment is the change itself and never conflicts.
+func conflicts(change Entry, entries []*Entry) []Conflict {
+	var found []Conflict
+	for _, entry := range entries {
+		if entry.ID == change.ID || !entry.Overlaps(change.Start, change.End) || !sameEnvironment(entry.Environment, change.Environment) {
+			continue
+		}
+		conflict := Conflict{Change: change.ID, With: entry.ID, Type: entry.Type}
+		switch {
+		case change.Type == TypeFreeze:
+			if entry.Type == TypeFreeze || !matches(change.Application, entry.Application) {
+				continue
+			}
+			conflict.Reason = fmt.Sprintf("%s is scheduled during the freeze", describe(entry))
+		case entry.Type == TypeFreeze:
+			if entry.Application != "" && entry.Application != change.Application {
+				continue
+			}
+			conflict.Reason = fmt.Sprintf("%s is frozen %s", scope(entry), window(entry))
+			if entry.Reason != "" {
+				conflict.Reason += " (" + entry.Reason + ")"
+			}
+			conflict.Blocking = true
+		case entry.Type == TypeDeployment && change.Type == TypeDeployment &&
+			entry.Application == change.Application && entry.Environment == change.Environment:
+			continue
+		default:
+			shared := intersect(change.Resources, entry.Resources)
+			if len(shared) == 0 {
+				continue
+			}
+			conflict.Reason = fmt.Sprintf("%s also changes %s %s", describe(entry), strings.Join(shared, ", "), window(entry))
+		}
+		found = append(found, conflict)
+	}
+	return found
+}
+
+// sameEnvironment reports whether two scopes can refer to the same environment; empty means all
+func sameEnvironment(a, b string) bool {
+	return a == "" || b == "" || a == b
+}
+
+func describe(entry *Entry) string {
+	switch entry.Type {
+	case TypeDeployment:
+		return fmt.Sprintf("deployment of %s to %s", entry.Application, entry.Environment)
+	case TypeMaintenance:
+		return "maintenance " + entry.ID
+	default:
+		return "freeze " + entry.ID
+	}
+}
+
+func scope(freeze *Entry) string {
+	switch {
+	case freeze.Application != "" && freeze.Environment != "":
+		return fmt.Sprintf("%s in %s", freeze.Application, freeze.Environment)
+	case freeze.Application != "":
+		return freeze.Application
+	case freeze.Environment != "":
+		return freeze.Environment
+	default:
+		return "every environment"
+	}
+}
+
+func window(entry *Entry) string {
+	return fmt.Sprintf("from %s to %s", entry.Start.UTC().Format(time.RFC3339), entry.End.UTC().Format(time.RFC3339))
+}
+
+func intersect(a, b []string) []string {
+	in := make(map[string]bool, len(b))
+	for _, value := range b {
+		in[value] = true
+	}
+	var shared []string
+	for _, value := range a {
+		if in[value] {
+			shared = append(shared, value)
+		}
+	}
+	return shared
+}
@@ -0,0 +1,216 @@
+package calendar
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+)
+
+// MaintenanceImpactSubject is the notify event sent to each owner affected by a maintenance window
+const MaintenanceImpactSubject = "maintenance.impact"
+
+// ImpactedService is a service that cannot rely on a resource while it is in maintenance
+type ImpactedService struct {
+	Service     string `json:"service"`
+	Application string `json:"application,omitempty"`
+	// Via is the resource in maintenance the service uses, or for an indirect impact the
+	// affected service it consumes
+	Via      string `json:"via"`
+	Indirect bool   `json:"indirect,omitempty"`
+}
+
+// Notice is the impact of a maintenance window on the entities one owner is responsible for.
+// Applications and services without an owner are collected in a notice without one.
+type Notice struct {
+	Owner        string            `json:"owner"`
+	Entry        string            `json:"entry,omitempty"`
+	Start        time.Time         `json:"start"`
+	End          time.Time         `json:"end"`
+	Reason       string            `json:"reason,omitempty"`
+	Resources    []string          `json:"resources"`    // the resources in maintenance this owner depends on, directly or not
+	Applications []string          `json:"applications"` // applications owning or using them, directly or through services
+	Services     []ImpactedService `json:"services"`
+	Message      string            `json:"message"`
+}
+
+// MaintenanceImpact walks the graph from the resources a maintenance window works on to
+// everything depending on them: services using or granted a resource, applications owning or
+// granted one, and transitively the services consuming an affected service. The impact is
+// returned as one notice per owner, ordered by owner.
+func (s *Service) MaintenanceImpact(entry Entry) ([]Notice, error) {
+	nodes, err := s.graph.Nodes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph: %w", err)
+	}
+	edges, err := s.graph.Edges()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph: %w", err)
+	}
+
+	applicationOf := map[string]string{}
+	incoming := map[string][]graph.Edge{} // edges by target, with To holding the source
+	for from, out := range edges {
+		for _, edge := range out {
+			incoming[edge.To] = append(incoming[edge.To], graph.Edge{To: from, Type: edge.Type})
+			if edge.Type == graph.EdgeTypeOwns && nodes[from] != nil && nodes[from].Kind == graph.KindApplication {
+				applicationOf[edge.To] = from
+			}
+		}
+	}
+
+	notices := map[string]*Notice{}
+	notice := func(owner string) *Notice {
+		if n, ok := notices[owner]; ok {
+			return n
+		}
+		n := &Notice{Owner: owner, Entry: entry.ID, Start: entry.Start, End: entry.End, Reason: entry.Reason}
+		notices[owner] = n
+		return n
+	}
+	addApplication := func(n *Notice, app string) {
+		if app != "" && !contains(n.Applications, app) {
+			n.Applications = append(n.Applications, app)
+		}
+	}
+	addResource := func(n *Notice, resource string) {
+		if !contains(n.Resources, resource) {
+			n.Resources = append(n.Resources, resource)
+		}
+	}
+
+	// pending is an affected service and the resource in maintenance its impact traces back to
+	type pending struct {
+		service  ImpactedService
+		resource string
+	}
+	affected := map[string]bool{}
+	var queue []pending
+	for _, resource := range entry.Resources {
+		for _, edge := range incoming[resource] {
+			source := nodes[edge.To]
+			if source == nil {
+				continue
+			}
+			switch {
+			case source.Kind == graph.KindApplication && (edge.Type == graph.EdgeTypeOwns || edge.Type == graph.EdgeTypeAccesses):
+				n := notice(ownerOf(nodes, source.ID, ""))
+				addApplication(n, source.ID)
+				addResource(n, resource)
+			case source.Kind == graph.KindService && (edge.Type == graph.EdgeTypeUses || edge.Type == graph.EdgeTypeAccesses):
+				if !affected[source.ID] {
+					affected[source.ID] = true
+					queue = append(queue, pending{ImpactedService{Service: source.ID, Application: applicationOf[source.ID], Via: resource}, resource})
+				}
+			}
+		}
+	}
+
+	// Consumers of an affected service are affected too, through the service they consume
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		impacted := next.service
+		n := notice(ownerOf(nodes, impacted.Service, impacted.Application))
+		n.Services = append(n.Services, impacted)
+		addApplication(n, impacted.Application)
+		addResource(n, next.resource)
+		for _, edge := range incoming[impacted.Service] {
+			consumer := nodes[edge.To]
+			if edge.Type != graph.EdgeTypeConsumes || consumer == nil || consumer.Kind != graph.KindService || affected[consumer.ID] {
+				continue
+			}
+			affected[consumer.ID] = true
+			queue = append(queue, pending{ImpactedService{Service: consumer.ID, Application: applicationOf[consumer.ID], Via: impacted.Service, Indirect: true}, next.resource})
+		}
+	}
+
+	result := make([]Notice, 0, len(notices))
+	for _, n := range notices {
+		sort.Strings(n.Applications)
+		sort.Strings(n.Resources)
+		sort.Slice(n.Services, func(i, j int) bool { return n.Services[i].Service < n.Services[j].Service })
+		if n.Applications == nil {
+			n.Applications = []string{}
+		}
+		if n.Resources == nil {
+			n.Resources = []string{}
+		}
+		if n.Services == nil {
+			n.Services = []ImpactedService{}
+		}
+		n.Message = describeImpact(n)
+		result = append(result, *n)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Owner < result[j].Owner })
+	return result, nil
+}
+
+// broadcastImpact emits a notify event per owner affected by a scheduled maintenance window
+func (s *Service) broadcastImpact(notices []Notice) {
+	bus := s.eventBus
+	if bus == nil {
+		bus = events.GlobalEventBus
+	}
+	if bus == nil {
+		return
+	}
+	for _, n := range notices {
+		err := bus.Emit(events.EventTypeNotify, "calendar", MaintenanceImpactSubject, map[string]interface{}{
+			"owner":        n.Owner,
+			"entry":        n.Entry,
+			"start":        n.Start.UTC().Format(time.RFC3339),
+			"end":          n.End.UTC().Format(time.RFC3339),
+			"reason":       n.Reason,
+			"resources":    n.Resources,
+			"applications": n.Applications,
+			"services":     n.Services,
+			"message":      n.Message,
+		})
+		if err != nil {
+			s.logger.Warn("⚠️ Could not notify %s of maintenance %s: %v", n.Owner, n.Entry, err)
+		}
+	}
+}
+
+// ownerOf returns the owner of a node, falling back to the owner of its application
+func ownerOf(nodes map[string]*graph.Node, id, application string) string {
+	if node := nodes[id]; node != nil {
+		if owner, _ := node.Metadata["owner"].(string); owner != "" {
+			return owner
+		}
+	}
+	if node := nodes[application]; node != nil {
+		owner, _ := node.Metadata["owner"].(string)
+		return owner
+	}
+	return ""
+}
+
+func describeImpact(n *Notice) string {
+	var affected []string
+	for _, service := range n.Services {
+		if service.Indirect {
+			affected = append(affected, fmt.Sprintf("%s (through %s)", service.Service, service.Via))
+		} else {
+			affected = append(affected, service.Service)
+		}
+	}
+	if len(affected) == 0 {
+		affected = n.Applications
+	}
+	return fmt.Sprintf("Maintenance on %s %s affects %s", strings.Join(n.Resources, ", "),
+		window(&Entry{Start: n.Start, End: n.End}), strings.Join(affected, ", "))
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package calendar
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedule_NotifiesOwnersOfMaintenanceImpact(t *testing.T) {
+	service := newCalendarTestService(t)
+	g := service.graph
+	for app, owner := range map[string]string{"checkout": "team-checkout", "search": "team-search"} {
+		node, err := g.GetNode(app)
+		require.NoError(t, err)
+		node.Metadata["owner"] = owner
+		require.NoError(t, g.UpdateNode(node))
+	}
+	g.AddNode(&graph.Node{ID: "storefront-web", Kind: graph.KindService, Metadata: map[string]interface{}{"name": "storefront-web", "owner": "team-web"}, Spec: map[string]interface{}{}})
+	require.NoError(t, g.AddEdge("storefront-web", "checkout-api", graph.EdgeTypeConsumes))
+
+	bus := events.NewEventBus(nil, false)
+	service.eventBus = bus
+	var notified []events.Event
+	bus.Subscribe(events.EventTypeNotify, func(event events.Event) error {
+		notified = append(notified, event)
+		return nil
+	})
+
+	maintenance := Entry{Type: TypeMaintenance, Resources: []string{"shared-kafka"}, Start: monday, End: monday.Add(4 * time.Hour), Reason: "broker upgrade"}
+	preview, err := service.Schedule(maintenance, true)
+	require.NoError(t, err)
+	require.Len(t, preview.Impact, 3)
+	assert.Empty(t, notified, "a dry run notifies no one")
+
+	result, err := service.Schedule(maintenance, false)
+	require.NoError(t, err)
+	require.Len(t, result.Impact, 3)
+	checkout := result.Impact[0]
+	assert.Equal(t, "team-checkout", checkout.Owner)
+	assert.Equal(t, result.Entry.ID, checkout.Entry)
+	assert.Equal(t, []string{"checkout"}, checkout.Applications)
+	assert.Equal(t, []ImpactedService{{Service: "checkout-api", Application: "checkout", Via: "shared-kafka"}}, checkout.Services)
+	assert.Equal(t, "team-search", result.Impact[1].Owner)
+	web := result.Impact[2]
+	assert.Equal(t, "team-web", web.Owner)
+	assert.Equal(t, []string{"shared-kafka"}, web.Resources)
+	assert.Equal(t, []ImpactedService{{Service: "storefront-web", Via: "checkout-api", Indirect: true}}, web.Services, "consumers of an affected service are affected too")
+	assert.Equal(t, "Maintenance on shared-kafka from 2026-03-02T09:00:00Z to 2026-03-02T13:00:00Z affects storefront-web (through checkout-api)", web.Message)
+
+	require.Len(t, notified, 3)
+	assert.Equal(t, MaintenanceImpactSubject, notified[0].Subject)
+	assert.Equal(t, "team-checkout", notified[0].Payload["owner"])
+	assert.Equal(t, "broker upgrade", notified[0].Payload["reason"])
+
+	// Applications owning the resource are affected without going through a service
+	notices, err := service.MaintenanceImpact(Entry{Resources: []string{"checkout-db"}, Start: monday, End: monday.Add(time.Hour)})
+	require.NoError(t, err)
+	require.Len(t, notices, 1)
+	assert.Equal(t, []string{"checkout"}, notices[0].Applications)
+	assert.Empty(t, notices[0].Services)
+}
+
+func TestNotifier_DeliversToWebhooksAndSlack(t *testing.T) {
+	var webhook map[string]interface{}
+	var slack map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/hook":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&webhook))
+		case "/slack":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&slack))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	bus := events.NewEventBus(nil, false)
+	NewNotifier([]string{server.URL + "/hook", server.URL + "/missing"}, server.URL+"/slack", time.Second).Subscribe(bus)
+	require.NoError(t, bus.Emit(events.EventTypeNotify, "calendar", "deployment.completed", map[string]interface{}{"owner": "team-a"}))
+	assert.Nil(t, webhook, "only maintenance notices are delivered")
+
+	require.NoError(t, bus.Emit(events.EventTypeNotify, "calendar", MaintenanceImpactSubject, map[string]interface{}{
+		"owner":   "team-checkout",
+		"message": "Maintenance on shared-kafka affects checkout-api",
+	}))
+	assert.Equal(t, "team-checkout", webhook["owner"])
+	assert.Equal(t, "*team-checkout*: Maintenance on shared-kafka affects checkout-api", slack["text"])
+}
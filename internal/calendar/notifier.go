@@ -0,0 +1,78 @@
+package calendar
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// Notifier delivers maintenance impact notices outside the platform: the notice's event payload
+// is POSTed to every webhook, and its message to a Slack incoming webhook
+type Notifier struct {
+	webhooks []string
+	slackURL string
+	client   *http.Client
+	logger   *logging.Logger
+}
+
+// NewNotifier creates a notifier posting to the webhooks and Slack URL; each delivery gives up
+// after timeout
+func NewNotifier(webhooks []string, slackURL string, timeout time.Duration) *Notifier {
+	return &Notifier{
+		webhooks: webhooks,
+		slackURL: slackURL,
+		client:   &http.Client{Timeout: timeout},
+		logger:   logging.GetLogger().ForComponent("maintenance-notifier"),
+	}
+}
+
+// Subscribe delivers every maintenance impact notice emitted on bus
+func (n *Notifier) Subscribe(bus *events.EventBus) {
+	bus.Subscribe(events.EventTypeNotify, func(event events.Event) error {
+		if event.Subject != MaintenanceImpactSubject {
+			return nil
+		}
+		n.Deliver(event.Payload)
+		return nil
+	})
+}
+
+// Deliver posts a notice to the webhooks and Slack. Failed deliveries are logged and not retried.
+func (n *Notifier) Deliver(notice map[string]interface{}) {
+	for _, url := range n.webhooks {
+		if err := n.post(url, notice); err != nil {
+			n.logger.Warn("⚠️ Maintenance notice for %v not delivered to %s: %v", notice["owner"], url, err)
+		}
+	}
+	if n.slackURL == "" {
+		return
+	}
+	text, _ := notice["message"].(string)
+	if owner, _ := notice["owner"].(string); owner != "" {
+		text = fmt.Sprintf("*%s*: %s", owner, text)
+	}
+	if err := n.post(n.slackURL, map[string]interface{}{"text": text}); err != nil {
+		n.logger.Warn("⚠️ Maintenance notice for %v not delivered to Slack: %v", notice["owner"], err)
+	}
+}
+
+func (n *Notifier) post(url string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	resp, err := n.client.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package chaos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+	"github.com/krzachariassen/ZTDP/internal/ai"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/features"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
+)
+
+// ChaosRequest is the structure the AI extracts from a chaos request
+type ChaosRequest struct {
+	Action        string  `json:"action"` // inject | clear | list
+	Fault         Fault   `json:"fault"`
+	FaultID       string  `json:"fault_id,omitempty"` // clear only; empty clears every fault
+	Confidence    float64 `json:"confidence"`
+	Clarification string  `json:"clarification,omitempty"`
+}
+
+// ChaosAgent turns natural language chaos requests into injected faults
+type ChaosAgent struct {
+	injector   *Injector
+	aiProvider ai.AIProvider
+	logger     *logging.Logger
+}
+
+// NewChaosAgent creates the chaos agent. aiProvider should be the unwrapped provider so the
+// agent can still clear faults while AI failures are being injected.
+func NewChaosAgent(
+	globalGraph *graph.GlobalGraph,
+	injector *Injector,
+	aiProvider ai.AIProvider,
+	eventBus *events.EventBus,
+	registry agentRegistry.AgentRegistry,
+) (agentRegistry.AgentInterface, error) {
+	if injector == nil {
+		return nil, fmt.Errorf("injector is required")
+	}
+	if aiProvider == nil {
+		return nil, fmt.Errorf("aiProvider is required for AI-native agent")
+	}
+	if eventBus == nil {
+		return nil, fmt.Errorf("eventBus is required")
+	}
+	if registry == nil {
+		return nil, fmt.Errorf("registry is required")
+	}
+
+	wrapper := &ChaosAgent{
+		injector:   injector,
+		aiProvider: aiProvider,
+		logger:     logging.GetLogger().ForComponent("chaos-agent"),
+	}
+
+	agent, err := agentframework.NewAgent("chaos-agent").
+		WithType("chaos").
+		WithCapabilities(getChaosCapabilities()).
+		WithEventHandler(wrapper.handleEvent).
+		Build(agentframework.AgentDependencies{
+			Registry: registry,
+			EventBus: eventBus,
+			Flags:    features.NewService(globalGraph),
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build chaos agent: %w", err)
+	}
+
+	wrapper.logger.Info("✅ ChaosAgent created successfully")
+	return agent, nil
+}
+
+// getChaosCapabilities returns the capabilities for the chaos agent
+func getChaosCapabilities() []agentRegistry.AgentCapability {
+	return []agentRegistry.AgentCapability{
+		{
+			Name:        "chaos_testing",
+			Description: "Injects controlled failures (delayed events, failing AI calls, unhealthy resources) and clears them",
+			Intents: []string{
+				"inject failure", "chaos test", "simulate failure", "clear failures", "list failures",
+			},
+			InputTypes:  []string{"user_message"},
+			OutputTypes: []string{"chaos_fault", "chaos_fault_list"},
+			RoutingKeys: []string{"chaos.inject", "chaos.clear", "chaos.request"},
+			Version:     "1.0.0",
+		},
+	}
+}
+
+// handleEvent extracts the chaos request with AI and applies it
+func (a *ChaosAgent) handleEvent(ctx context.Context, event *events.Event) (*events.Event, error) {
+	userMessage, ok := event.Payload["user_message"].(string)
+	if !ok || userMessage == "" {
+		return a.createErrorResponse(event, "user_message field is required in event payload"), nil
+	}
+
+	request, err := a.extractRequest(ctx, userMessage)
+	if err != nil {
+		return a.createErrorResponse(event, fmt.Sprintf("I couldn't understand the chaos request: %v", err)), nil
+	}
+	if agentframework.NeedsClarification(ctx, request.Confidence) {
+		clarification := request.Clarification
+		if clarification == "" {
+			clarification = "Which failure should I inject: delayed events, failing AI calls or an unhealthy resource?"
+		}
+		return agentframework.ClarificationResponse(ctx, event, clarification, request.Confidence), nil
+	}
+
+	switch request.Action {
+	case "inject":
+		fault, err := a.injector.Inject(request.Fault)
+		if err != nil {
+			return a.createErrorResponse(event, fmt.Sprintf("Failed to inject fault: %v", err)), nil
+		}
+		return a.createSuccessResponse(event, fmt.Sprintf("💥 Injected %s fault %s", fault.Type, fault.ID), map[string]interface{}{"fault": fault}), nil
+	case "clear":
+		if request.FaultID == "" {
+			cleared := a.injector.ClearAll()
+			return a.createSuccessResponse(event, fmt.Sprintf("🧹 Cleared %d faults", cleared), nil), nil
+		}
+		if err := a.injector.Clear(request.FaultID); err != nil {
+			return a.createErrorResponse(event, fmt.Sprintf("Failed to clear %s: %v", request.FaultID, err)), nil
+		}
+		return a.createSuccessResponse(event, fmt.Sprintf("🧹 Cleared fault %s", request.FaultID), nil), nil
+	case "list":
+		faults := a.injector.Faults()
+		return a.createSuccessResponse(event, describeFaults(faults), map[string]interface{}{"faults": faults}), nil
+	default:
+		return a.createErrorResponse(event, fmt.Sprintf("I can inject, clear or list failures, not %q", request.Action)), nil
+	}
+}
+
+// extractRequest asks the AI to turn the user message into a ChaosRequest
+func (a *ChaosAgent) extractRequest(ctx context.Context, userMessage string) (*ChaosRequest, error) {
+	systemPrompt := `You control fault injection for a platform's chaos tests. Extract the request as JSON:
+{"action": "inject|clear|list", "fault": {"type": "event_delay|ai_failure|unhealthy_resource", "target": "", "delay_ms": 0, "percent": 0, "ttl": ""}, "fault_id": "", "confidence": 0.0, "clarification": ""}
+
+Rules:
+- event_delay holds events for delay_ms; target is an event subject prefix such as "deployment." (empty delays every agent)
+- ai_failure fails percent (1-100) of AI calls
+- unhealthy_resource marks the resource whose ID is target unhealthy
+- ttl is an optional Go duration like "10m" after which the fault clears itself
+- For clear, set fault_id when the user names one; leave it empty to clear everything
+- Set confidence below 0.7 and explain in clarification when required values are missing
+
+Respond with JSON only.`
+
+	response, err := a.aiProvider.CallAI(ai.WithTask(ctx, ai.TaskExtraction), systemPrompt, userMessage)
+	if err != nil {
+		return nil, err
+	}
+
+	cleaned := strings.TrimSpace(response)
+	cleaned = strings.TrimPrefix(cleaned, "```json")
+	cleaned = strings.TrimPrefix(cleaned, "```")
+	cleaned = strings.TrimSuffix(cleaned, "```")
+
+	var request ChaosRequest
+	if err := json.Unmarshal([]byte(strings.TrimSpace(cleaned)), &request); err != nil {
+		return nil, fmt.Errorf("failed to parse AI response: %w", err)
+	}
+	a.logger.Info("🤖 AI extracted chaos action: %s (%s), confidence: %.2f", request.Action, request.Fault.Type, request.Confidence)
+	return &request, nil
+}
+
+func describeFaults(faults []Fault) string {
+	if len(faults) == 0 {
+		return "No chaos faults are active"
+	}
+	lines := []string{fmt.Sprintf("%d chaos faults active:", len(faults))}
+	for _, f := range faults {
+		lines = append(lines, fmt.Sprintf("- %s: %s target=%q triggered=%d", f.ID, f.Type, f.Target, f.Triggered))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (a *ChaosAgent) createSuccessResponse(originalEvent *events.Event, message string, data map[string]interface{}) *events.Event {
+	payload := map[string]interface{}{
+		"status":         "success",
+		"message":        message,
+		"correlation_id": originalEvent.Payload["correlation_id"],
+	}
+	for k, v := range data {
+		payload[k] = v
+	}
+	return &events.Event{
+		ID:        fmt.Sprintf("chaos-response-%d", time.Now().UnixNano()),
+		Type:      events.EventTypeResponse,
+		Subject:   "chaos.response",
+		Source:    "chaos-agent",
+		Timestamp: time.Now().Unix(),
+		Payload:   payload,
+	}
+}
+
+func (a *ChaosAgent) createErrorResponse(originalEvent *events.Event, errorMessage string) *events.Event {
+	return &events.Event{
+		ID:        fmt.Sprintf("chaos-error-%d", time.Now().UnixNano()),
+		Type:      events.EventTypeResponse,
+		Subject:   "chaos.error",
+		Source:    "chaos-agent",
+		Timestamp: time.Now().Unix(),
+		Payload: map[string]interface{}{
+			"status":         "error",
+			"error":          errorMessage,
+			"correlation_id": originalEvent.Payload["correlation_id"],
+		},
+	}
+}
@@ -0,0 +1,301 @@
+// Package chaos injects controlled failures into a running platform - delayed event delivery,
+// failing AI calls and unhealthy resources - so teams can verify how the orchestrator and
+// agents handle timeouts, retries and compensation.
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/ai"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// FaultType identifies the kind of failure a fault injects
+type FaultType string
+
+const (
+	FaultEventDelay        FaultType = "event_delay"        // hold events before agents receive them
+	FaultAIFailure         FaultType = "ai_failure"         // fail a percentage of AI calls
+	FaultUnhealthyResource FaultType = "unhealthy_resource" // mark a resource node unhealthy
+)
+
+// ErrInjectedFailure is returned by AI calls failed on purpose
+var ErrInjectedFailure = errors.New("chaos: injected AI failure")
+
+// ErrFaultNotFound is returned when clearing a fault that is not active
+var ErrFaultNotFound = errors.New("fault not found")
+
+// Fault is one active failure injection
+type Fault struct {
+	ID        string    `json:"id"`
+	Type      FaultType `json:"type"`
+	Target    string    `json:"target,omitempty"`   // event subject prefix (event_delay) or resource node ID (unhealthy_resource)
+	DelayMS   int64     `json:"delay_ms,omitempty"` // event_delay only
+	Percent   int       `json:"percent,omitempty"`  // ai_failure only: share of calls to fail (1-100)
+	TTL       string    `json:"ttl,omitempty"`      // Go duration after which the fault clears itself; empty lasts until cleared
+	Triggered int64     `json:"triggered"`          // times the fault has affected an event, call or resource
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+
+	previousHealth interface{} // unhealthy_resource: health metadata restored when the fault clears
+}
+
+// Validate checks the fault definition
+func (f *Fault) Validate() error {
+	switch f.Type {
+	case FaultEventDelay:
+		if f.DelayMS <= 0 {
+			return fmt.Errorf("event_delay requires a positive delay_ms")
+		}
+	case FaultAIFailure:
+		if f.Percent < 1 || f.Percent > 100 {
+			return fmt.Errorf("ai_failure requires percent between 1 and 100, got %d", f.Percent)
+		}
+	case FaultUnhealthyResource:
+		if f.Target == "" {
+			return fmt.Errorf("unhealthy_resource requires the resource ID as target")
+		}
+	default:
+		return fmt.Errorf("unknown fault type %q (expected event_delay, ai_failure or unhealthy_resource)", f.Type)
+	}
+	if f.TTL != "" {
+		if ttl, err := time.ParseDuration(f.TTL); err != nil || ttl <= 0 {
+			return fmt.Errorf("ttl must be a positive duration such as 5m, got %q", f.TTL)
+		}
+	}
+	return nil
+}
+
+// Injector holds the active faults and applies them to the event bus, AI provider and graph
+type Injector struct {
+	graph  *graph.GlobalGraph
+	logger *logging.Logger
+
+	mu     sync.Mutex
+	faults map[string]*Fault
+	nextID int
+	rng    *rand.Rand
+	now    func() time.Time
+}
+
+// NewInjector creates an injector that marks resources unhealthy in globalGraph
+func NewInjector(globalGraph *graph.GlobalGraph) *Injector {
+	return &Injector{
+		graph:  globalGraph,
+		logger: logging.GetLogger().ForComponent("chaos"),
+		faults: map[string]*Fault{},
+		rng:    rand.New(rand.NewSource(time.Now().UnixNano())),
+		now:    time.Now,
+	}
+}
+
+// Attach makes the injector delay events on bus; agents only see the delay while an event_delay fault is active
+func (i *Injector) Attach(bus *events.EventBus) {
+	bus.SetDeliveryDelay(i.EventDelay)
+}
+
+// Inject activates a fault and returns it with its ID and timestamps filled in
+func (i *Injector) Inject(fault Fault) (*Fault, error) {
+	if err := fault.Validate(); err != nil {
+		return nil, err
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.expireLocked()
+
+	now := i.now().UTC()
+	i.nextID++
+	fault.ID = fmt.Sprintf("fault-%d", i.nextID)
+	fault.CreatedAt = now
+	fault.Triggered = 0
+	if fault.TTL != "" {
+		ttl, _ := time.ParseDuration(fault.TTL)
+		fault.ExpiresAt = now.Add(ttl)
+	}
+
+	if fault.Type == FaultUnhealthyResource {
+		for _, active := range i.faults {
+			if active.Type == FaultUnhealthyResource && active.Target == fault.Target {
+				return nil, fmt.Errorf("resource %s is already unhealthy from fault %s", fault.Target, active.ID)
+			}
+		}
+		previous, err := i.markUnhealthy(fault.Target, fault.ID)
+		if err != nil {
+			return nil, err
+		}
+		fault.previousHealth = previous
+		fault.Triggered = 1
+	}
+
+	i.faults[fault.ID] = &fault
+	i.logger.Warn("💥 Injected %s fault %s (target: %q)", fault.Type, fault.ID, fault.Target)
+	copied := fault
+	return &copied, nil
+}
+
+// Clear removes an active fault, restoring any resource it marked unhealthy
+func (i *Injector) Clear(id string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.expireLocked()
+
+	fault, ok := i.faults[id]
+	if !ok {
+		return ErrFaultNotFound
+	}
+	i.removeLocked(fault)
+	return nil
+}
+
+// ClearAll removes every active fault and returns how many were cleared
+func (i *Injector) ClearAll() int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	cleared := len(i.faults)
+	for _, fault := range i.faults {
+		i.removeLocked(fault)
+	}
+	return cleared
+}
+
+// Faults returns the active faults, oldest first
+func (i *Injector) Faults() []Fault {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.expireLocked()
+
+	faults := make([]Fault, 0, len(i.faults))
+	for _, fault := range i.faults {
+		faults = append(faults, *fault)
+	}
+	sort.Slice(faults, func(a, b int) bool {
+		return faults[a].CreatedAt.Before(faults[b].CreatedAt) ||
+			(faults[a].CreatedAt.Equal(faults[b].CreatedAt) && faults[a].ID < faults[b].ID)
+	})
+	return faults
+}
+
+// EventDelay returns how long to hold event before agents receive it. Chaos requests are never
+// delayed so faults can always be cleared.
+func (i *Injector) EventDelay(event events.Event) time.Duration {
+	if strings.HasPrefix(event.Subject, "chaos.") {
+		return 0
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.expireLocked()
+
+	var delay time.Duration
+	for _, fault := range i.faults {
+		if fault.Type != FaultEventDelay || !strings.HasPrefix(event.Subject, fault.Target) {
+			continue
+		}
+		fault.Triggered++
+		if d := time.Duration(fault.DelayMS) * time.Millisecond; d > delay {
+			delay = d
+		}
+	}
+	return delay
+}
+
+// shouldFailAI decides whether the next AI call fails
+func (i *Injector) shouldFailAI() bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.expireLocked()
+
+	for _, fault := range i.faults {
+		if fault.Type == FaultAIFailure && i.rng.Intn(100) < fault.Percent {
+			fault.Triggered++
+			return true
+		}
+	}
+	return false
+}
+
+// expireLocked clears faults whose TTL has passed
+func (i *Injector) expireLocked() {
+	now := i.now()
+	for _, fault := range i.faults {
+		if !fault.ExpiresAt.IsZero() && now.After(fault.ExpiresAt) {
+			i.logger.Info("⌛ Chaos fault %s expired", fault.ID)
+			i.removeLocked(fault)
+		}
+	}
+}
+
+func (i *Injector) removeLocked(fault *Fault) {
+	delete(i.faults, fault.ID)
+	if fault.Type == FaultUnhealthyResource {
+		if err := i.restoreHealth(fault.Target, fault.ID, fault.previousHealth); err != nil {
+			i.logger.Warn("⚠️ Could not restore health of %s: %v", fault.Target, err)
+		}
+	}
+	i.logger.Info("🧹 Cleared %s fault %s", fault.Type, fault.ID)
+}
+
+// markUnhealthy flags a resource node and returns its previous health value
+func (i *Injector) markUnhealthy(resourceID, faultID string) (interface{}, error) {
+	node, _ := i.graph.GetNode(resourceID)
+	if node == nil || node.Kind != graph.KindResource {
+		return nil, fmt.Errorf("resource %s not found", resourceID)
+	}
+	if node.Metadata == nil {
+		node.Metadata = map[string]interface{}{}
+	}
+	previous := node.Metadata["health"]
+	node.Metadata["health"] = "unhealthy"
+	node.Metadata["chaos_fault"] = faultID
+	if err := i.graph.UpdateNode(node); err != nil {
+		return nil, fmt.Errorf("failed to mark %s unhealthy: %w", resourceID, err)
+	}
+	return previous, nil
+}
+
+// restoreHealth undoes markUnhealthy unless the node no longer carries this fault
+func (i *Injector) restoreHealth(resourceID, faultID string, previous interface{}) error {
+	node, _ := i.graph.GetNode(resourceID)
+	if node == nil || node.Metadata["chaos_fault"] != faultID {
+		return nil
+	}
+	delete(node.Metadata, "chaos_fault")
+	if previous == nil {
+		delete(node.Metadata, "health")
+	} else {
+		node.Metadata["health"] = previous
+	}
+	return i.graph.UpdateNode(node)
+}
+
+// WrapProvider returns an AI provider that fails calls while an ai_failure fault is active
+func (i *Injector) WrapProvider(provider ai.AIProvider) ai.AIProvider {
+	return &faultyProvider{AIProvider: provider, injector: i}
+}
+
+type faultyProvider struct {
+	ai.AIProvider
+	injector *Injector
+}
+
+func (p *faultyProvider) CallAI(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	if p.injector.shouldFailAI() {
+		return "", ErrInjectedFailure
+	}
+	return p.AIProvider.CallAI(ctx, systemPrompt, userPrompt)
+}
+
+func (p *faultyProvider) PromptBudget(ctx context.Context) int {
+	return ai.PromptBudget(ctx, p.AIProvider)
+}
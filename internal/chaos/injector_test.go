@@ -0,0 +1,134 @@
+package chaos
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+	"github.com/krzachariassen/ZTDP/internal/ai"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubProvider struct {
+	response string
+}
+
+func (p *stubProvider) CallAI(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	return p.response, nil
+}
+
+func (p *stubProvider) GetProviderInfo() *ai.ProviderInfo { return &ai.ProviderInfo{Name: "stub"} }
+
+func (p *stubProvider) Close() error { return nil }
+
+func newTestGraph(t *testing.T) *graph.GlobalGraph {
+	t.Helper()
+	gg := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	gg.AddNode(&graph.Node{
+		ID:       "orders-db",
+		Kind:     graph.KindResource,
+		Metadata: map[string]interface{}{"name": "orders-db", "health": "healthy"},
+		Spec:     map[string]interface{}{},
+	})
+	return gg
+}
+
+func TestInjector_DelaysMatchingEvents(t *testing.T) {
+	injector := NewInjector(newTestGraph(t))
+	bus := events.NewEventBus(nil, false)
+	injector.Attach(bus)
+
+	delivered := 0
+	bus.SubscribeToRoutingKey("deployment.request", func(events.Event) error { delivered++; return nil })
+
+	fault, err := injector.Inject(Fault{Type: FaultEventDelay, Target: "deployment.", DelayMS: 50})
+	require.NoError(t, err)
+
+	start := time.Now()
+	require.NoError(t, bus.Emit(events.EventTypeRequest, "test", "deployment.request", nil))
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	assert.Equal(t, 1, delivered)
+	assert.Zero(t, injector.EventDelay(events.Event{Subject: "chaos.clear"}), "chaos requests are never delayed")
+	assert.Zero(t, injector.EventDelay(events.Event{Subject: "application.request"}))
+
+	require.NoError(t, injector.Clear(fault.ID))
+	start = time.Now()
+	require.NoError(t, bus.Emit(events.EventTypeRequest, "test", "deployment.request", nil))
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+}
+
+func TestInjector_FailsAICalls(t *testing.T) {
+	injector := NewInjector(newTestGraph(t))
+	provider := injector.WrapProvider(&stubProvider{response: "ok"})
+
+	_, err := injector.Inject(Fault{Type: FaultAIFailure, Percent: 100})
+	require.NoError(t, err)
+	_, err = provider.CallAI(context.Background(), "system", "user")
+	assert.ErrorIs(t, err, ErrInjectedFailure)
+	assert.Equal(t, int64(1), injector.Faults()[0].Triggered)
+
+	assert.Equal(t, 1, injector.ClearAll())
+	response, err := provider.CallAI(context.Background(), "system", "user")
+	require.NoError(t, err)
+	assert.Equal(t, "ok", response)
+}
+
+func TestInjector_MarksResourcesUnhealthyUntilExpired(t *testing.T) {
+	gg := newTestGraph(t)
+	injector := NewInjector(gg)
+	now := time.Now()
+	injector.now = func() time.Time { return now }
+
+	_, err := injector.Inject(Fault{Type: FaultUnhealthyResource, Target: "orders-db", TTL: "1m"})
+	require.NoError(t, err)
+	node, _ := gg.GetNode("orders-db")
+	assert.Equal(t, "unhealthy", node.Metadata["health"])
+
+	_, err = injector.Inject(Fault{Type: FaultUnhealthyResource, Target: "orders-db"})
+	assert.Error(t, err, "a resource can only carry one unhealthy fault")
+	_, err = injector.Inject(Fault{Type: FaultUnhealthyResource, Target: "missing"})
+	assert.Error(t, err)
+
+	now = now.Add(2 * time.Minute)
+	assert.Empty(t, injector.Faults())
+	node, _ = gg.GetNode("orders-db")
+	assert.Equal(t, "healthy", node.Metadata["health"])
+	assert.NotContains(t, node.Metadata, "chaos_fault")
+}
+
+func TestFault_Validate(t *testing.T) {
+	for _, fault := range []Fault{
+		{Type: "explode"},
+		{Type: FaultEventDelay},
+		{Type: FaultAIFailure, Percent: 101},
+		{Type: FaultUnhealthyResource},
+		{Type: FaultAIFailure, Percent: 10, TTL: "soon"},
+	} {
+		assert.Error(t, fault.Validate(), "%+v", fault)
+	}
+}
+
+func TestChaosAgent_InjectsFaultFromMessage(t *testing.T) {
+	injector := NewInjector(newTestGraph(t))
+	provider := &stubProvider{response: `{"action": "inject", "fault": {"type": "ai_failure", "percent": 30}, "confidence": 0.95}`}
+	agent, err := NewChaosAgent(newTestGraph(t), injector, provider, events.NewEventBus(nil, false), agentRegistry.NewInMemoryAgentRegistry())
+	require.NoError(t, err)
+
+	response, err := agent.(*agentframework.BaseAgent).ProcessEvent(context.Background(), &events.Event{
+		Subject: "chaos.inject",
+		Payload: map[string]interface{}{"user_message": "fail 30% of AI calls", "correlation_id": "corr-1"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "success", response.Payload["status"])
+	assert.Equal(t, "corr-1", response.Payload["correlation_id"])
+
+	faults := injector.Faults()
+	require.Len(t, faults, 1)
+	assert.Equal(t, FaultAIFailure, faults[0].Type)
+	assert.Equal(t, 30, faults[0].Percent)
+}
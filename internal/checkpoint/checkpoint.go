@@ -0,0 +1,213 @@
+// Package checkpoint hands in-flight work from one orchestrator instance to the next during
+// blue/green upgrades. The stopping instance writes a checkpoint of every registered
+// participant's state to the graph; the instance taking over restores it on startup, or as
+// soon as it appears when both instances run side by side.
+package checkpoint
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// nodeID is the graph node the pending checkpoint is stored in
+const nodeID = "checkpoint:handoff"
+
+// Participant is a component with in-flight work to hand over
+type Participant interface {
+	// Checkpoint returns the work still in flight; nil means nothing to hand over
+	Checkpoint() (interface{}, error)
+	// Restore takes over work checkpointed by another instance
+	Restore(data json.RawMessage) error
+}
+
+// State is a checkpoint written by a stopping instance
+type State struct {
+	Instance     string                     `json:"instance"`
+	Process      string                     `json:"process"` // distinguishes restarts of the same instance
+	TakenAt      time.Time                  `json:"taken_at"`
+	Participants map[string]json.RawMessage `json:"participants"`
+}
+
+// Manager checkpoints and restores the registered participants
+type Manager struct {
+	graph    *graph.GlobalGraph
+	instance string
+	process  string
+	logger   *logging.Logger
+	now      func() time.Time
+
+	mu           sync.Mutex
+	names        []string // restore order
+	participants map[string]Participant
+}
+
+// NewManager creates a manager for this instance storing checkpoints in globalGraph
+func NewManager(globalGraph *graph.GlobalGraph, instance string) *Manager {
+	return &Manager{
+		graph:        globalGraph,
+		instance:     instance,
+		process:      fmt.Sprintf("%s-%d", instance, time.Now().UnixNano()),
+		logger:       logging.GetLogger().ForComponent("checkpoint"),
+		now:          time.Now,
+		participants: map[string]Participant{},
+	}
+}
+
+// Register adds a participant. Participants are restored in registration order, so register
+// state that others depend on first.
+func (m *Manager) Register(name string, participant Participant) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, exists := m.participants[name]; !exists {
+		m.names = append(m.names, name)
+	}
+	m.participants[name] = participant
+}
+
+// Save writes a checkpoint of the participants' in-flight work. Nothing is written when no
+// participant has work in flight.
+func (m *Manager) Save() (*State, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state := &State{Instance: m.instance, Process: m.process, TakenAt: m.now().UTC(), Participants: map[string]json.RawMessage{}}
+	for _, name := range m.names {
+		work, err := m.participants[name].Checkpoint()
+		if err != nil {
+			return nil, fmt.Errorf("checkpoint of %s failed: %w", name, err)
+		}
+		if work == nil {
+			continue
+		}
+		data, err := json.Marshal(work)
+		if err != nil {
+			return nil, fmt.Errorf("checkpoint of %s failed: %w", name, err)
+		}
+		state.Participants[name] = data
+	}
+	if len(state.Participants) == 0 {
+		return nil, nil
+	}
+
+	existing, _ := m.graph.GetNode(nodeID)
+	if existing != nil {
+		// An unclaimed checkpoint from an earlier instance is still pending; keep its work too
+		if err := m.merge(state, existing); err != nil {
+			m.logger.Warn("⚠️ Dropping unreadable pending checkpoint: %v", err)
+		}
+	}
+
+	var spec map[string]interface{}
+	data, _ := json.Marshal(state)
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, err
+	}
+	node := &graph.Node{
+		ID:       nodeID,
+		Kind:     graph.KindCheckpoint,
+		Metadata: map[string]interface{}{"name": nodeID, "instance": m.instance},
+		Spec:     spec,
+	}
+	if existing != nil {
+		if err := m.graph.UpdateNode(node); err != nil {
+			return nil, err
+		}
+	} else {
+		m.graph.AddNode(node)
+	}
+	m.logger.Info("📌 Checkpointed in-flight work of %d components for handoff", len(state.Participants))
+	return state, nil
+}
+
+// merge adds the pending checkpoint's work for participants this instance had nothing for
+func (m *Manager) merge(state *State, pending *graph.Node) error {
+	previous, err := decode(pending)
+	if err != nil {
+		return err
+	}
+	for name, data := range previous.Participants {
+		if _, ok := state.Participants[name]; !ok {
+			state.Participants[name] = data
+		}
+	}
+	return nil
+}
+
+func decode(node *graph.Node) (*State, error) {
+	data, err := json.Marshal(node.Spec)
+	if err != nil {
+		return nil, err
+	}
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint: %w", err)
+	}
+	return &state, nil
+}
+
+// Pending returns the checkpoint waiting to be restored, if any
+func (m *Manager) Pending() (*State, error) {
+	node, _ := m.graph.GetNode(nodeID)
+	if node == nil {
+		return nil, nil
+	}
+	return decode(node)
+}
+
+// Restore claims a checkpoint left by another process and hands each participant its work.
+// It returns nil when there is nothing to restore. Checkpoints this process wrote itself are
+// left for the one taking over.
+func (m *Manager) Restore() (*State, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, err := m.Pending()
+	if err != nil || state == nil || state.Process == m.process {
+		return nil, err
+	}
+	// Claim the checkpoint before restoring so no other instance restores it too
+	if err := m.graph.DeleteNode(nodeID); err != nil {
+		return nil, fmt.Errorf("failed to claim checkpoint: %w", err)
+	}
+
+	for _, name := range m.names {
+		data, ok := state.Participants[name]
+		if !ok {
+			continue
+		}
+		if err := m.participants[name].Restore(data); err != nil {
+			m.logger.Error("❌ Failed to restore %s from %s: %v", name, state.Instance, err)
+			continue
+		}
+	}
+	for name := range state.Participants {
+		if _, ok := m.participants[name]; !ok {
+			m.logger.Warn("⚠️ No participant for checkpointed %s work; it is dropped", name)
+		}
+	}
+	m.logger.Info("📌 Took over in-flight work from %s (checkpointed %s)", state.Instance, state.TakenAt.Format(time.RFC3339))
+	return state, nil
+}
+
+// Watch restores checkpoints other instances write until ctx is cancelled. During blue/green
+// upgrades the new instance is already running when the old one checkpoints and stops.
+func (m *Manager) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if _, err := m.Restore(); err != nil {
+			m.logger.Warn("⚠️ Checkpoint restore failed: %v", err)
+		}
+	}
+}
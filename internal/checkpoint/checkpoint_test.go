@@ -0,0 +1,98 @@
+package checkpoint
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+)
+
+// queue is a participant whose in-flight work is a list of job names
+type queue struct {
+	jobs     []string
+	restored []string
+}
+
+func (q *queue) Checkpoint() (interface{}, error) {
+	if len(q.jobs) == 0 {
+		return nil, nil
+	}
+	return q.jobs, nil
+}
+
+func (q *queue) Restore(data json.RawMessage) error {
+	return json.Unmarshal(data, &q.restored)
+}
+
+func TestHandoffBetweenInstances(t *testing.T) {
+	g := graph.NewGlobalGraph(graph.NewMemoryGraph())
+
+	blue := NewManager(g, "ztdp-blue")
+	blueQueue := &queue{jobs: []string{"deploy checkout", "deploy billing"}}
+	blue.Register("queue", blueQueue)
+	blue.Register("idle", &queue{})
+
+	green := NewManager(g, "ztdp-green")
+	greenQueue := &queue{}
+	green.Register("queue", greenQueue)
+
+	state, err := blue.Save()
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if len(state.Participants) != 1 {
+		t.Fatalf("expected only participants with work in the checkpoint, got %v", state.Participants)
+	}
+
+	// The stopping instance never restores its own checkpoint
+	if restored, err := blue.Restore(); err != nil || restored != nil {
+		t.Fatalf("expected blue to leave its checkpoint, got %+v (%v)", restored, err)
+	}
+
+	restored, err := green.Restore()
+	if err != nil || restored == nil || restored.Instance != "ztdp-blue" {
+		t.Fatalf("expected green to take over blue's work, got %+v (%v)", restored, err)
+	}
+	if len(greenQueue.restored) != 2 || greenQueue.restored[0] != "deploy checkout" {
+		t.Fatalf("unexpected restored work: %v", greenQueue.restored)
+	}
+
+	// The checkpoint is claimed, so it is restored only once
+	if pending, _ := green.Pending(); pending != nil {
+		t.Fatalf("expected the checkpoint to be claimed, got %+v", pending)
+	}
+	if again, _ := NewManager(g, "ztdp-other").Restore(); again != nil {
+		t.Fatal("expected a claimed checkpoint not to be restored again")
+	}
+}
+
+func TestRestartOfSameInstanceRestores(t *testing.T) {
+	g := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	before := NewManager(g, "ztdp-1")
+	before.Register("queue", &queue{jobs: []string{"deploy checkout"}})
+	if _, err := before.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	after := NewManager(g, "ztdp-1")
+	q := &queue{}
+	after.Register("queue", q)
+	if _, err := after.Restore(); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if len(q.restored) != 1 {
+		t.Fatalf("expected a restarted instance to restore its predecessor's work, got %v", q.restored)
+	}
+}
+
+func TestSaveWithoutWorkWritesNothing(t *testing.T) {
+	g := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	m := NewManager(g, "ztdp-1")
+	m.Register("queue", &queue{})
+	if state, err := m.Save(); err != nil || state != nil {
+		t.Fatalf("expected no checkpoint, got %+v (%v)", state, err)
+	}
+	if node, _ := g.GetNode(nodeID); node != nil {
+		t.Fatal("expected no checkpoint node")
+	}
+}
@@ -0,0 +1,189 @@
+package cluster
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// Status describes the cluster as one instance sees it
+type Status struct {
+	Instance    string     `json:"instance"`
+	Leader      string     `json:"leader"` // empty while no instance holds the lease
+	IsLeader    bool       `json:"is_leader"`
+	LeaderSince *time.Time `json:"leader_since,omitempty"`
+	Duties      []string   `json:"duties"`
+}
+
+type duty struct {
+	name string
+	run  func(ctx context.Context)
+}
+
+// Elector keeps one instance of the cluster in charge of singleton duties such as scheduled
+// backups and reconcilers. Every instance keeps serving requests and running agents; only the
+// duties move with the lease. Duties get a context that is cancelled as soon as the instance
+// can no longer confirm its lease, so at most one instance runs them once the old lease expires.
+type Elector struct {
+	lock     Lock
+	instance string
+	ttl      time.Duration
+	logger   *logging.Logger
+
+	mu          sync.Mutex
+	duties      []duty
+	leading     bool
+	leaderSince time.Time
+	term        context.Context // cancelled when the instance stops leading
+	cancel      context.CancelFunc
+	running     *sync.WaitGroup // the duties started in the current term
+}
+
+// NewElector creates an elector for instance competing for lock with leases of ttl
+func NewElector(lock Lock, instance string, ttl time.Duration) *Elector {
+	return &Elector{
+		lock:     lock,
+		instance: instance,
+		ttl:      ttl,
+		logger:   logging.GetLogger().ForComponent("cluster"),
+	}
+}
+
+// Singleton registers a duty that only runs on the leader. run is started each time the
+// instance becomes leader and must return once its context is cancelled. Duties registered
+// while the instance leads start immediately.
+func (e *Elector) Singleton(name string, run func(ctx context.Context)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	d := duty{name: name, run: run}
+	e.duties = append(e.duties, d)
+	if e.leading {
+		e.start(d)
+	}
+}
+
+// Run competes for the lease until ctx is cancelled, then stops the duties and releases the
+// lease so another instance can take over without waiting for it to expire
+func (e *Elector) Run(ctx context.Context) {
+	interval := e.ttl / 3
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		e.tick(ctx)
+		select {
+		case <-ctx.Done():
+			e.Resign()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// tick acquires or renews the lease and starts or stops the duties to match
+func (e *Elector) tick(ctx context.Context) {
+	// A check slower than the renewal interval could outlive the lease
+	checkCtx, cancel := context.WithTimeout(ctx, e.ttl/3)
+	defer cancel()
+	acquired, err := e.lock.TryAcquire(checkCtx, e.instance, e.ttl)
+	if err != nil {
+		if ctx.Err() == nil {
+			e.logger.Warn("⚠️ Cluster lease check failed: %v", err)
+		}
+		acquired = false
+	}
+
+	e.mu.Lock()
+	switch {
+	case acquired && !e.leading:
+		e.promote()
+		e.mu.Unlock()
+	case !acquired && e.leading:
+		e.mu.Unlock()
+		e.demote()
+	default:
+		e.mu.Unlock()
+	}
+}
+
+// promote starts the duties; callers hold mu
+func (e *Elector) promote() {
+	e.leading = true
+	e.leaderSince = time.Now()
+	e.term, e.cancel = context.WithCancel(context.Background())
+	e.running = &sync.WaitGroup{}
+	e.logger.Info("👑 %s is now the cluster leader, starting %d singleton duties", e.instance, len(e.duties))
+	for _, d := range e.duties {
+		e.start(d)
+	}
+}
+
+// start runs a duty for the current term; callers hold mu and lead. Each term counts its duties
+// in its own WaitGroup, so a duty started in a new term never races the wait for the old one.
+func (e *Elector) start(d duty) {
+	ctx, running := e.term, e.running
+	running.Add(1)
+	go func() {
+		defer running.Done()
+		e.logger.Info("▶️ Running singleton duty %s", d.name)
+		d.run(ctx)
+	}()
+}
+
+// demote stops the duties and waits for them to return. Once leading is cleared under mu no
+// duty can join the term, so waiting outside the lock is safe.
+func (e *Elector) demote() {
+	e.mu.Lock()
+	if !e.leading {
+		e.mu.Unlock()
+		return
+	}
+	e.leading = false
+	cancel, running := e.cancel, e.running
+	e.term, e.cancel, e.running = nil, nil, nil
+	e.mu.Unlock()
+
+	cancel()
+	running.Wait()
+	e.logger.Warn("👋 %s is no longer the cluster leader, singleton duties stopped", e.instance)
+}
+
+// Resign stops the duties and releases the lease if this instance holds it
+func (e *Elector) Resign() {
+	e.demote()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := e.lock.Release(ctx, e.instance); err != nil {
+		e.logger.Warn("⚠️ Failed to release cluster lease: %v", err)
+	}
+}
+
+// IsLeader reports whether this instance currently runs the singleton duties
+func (e *Elector) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.leading
+}
+
+// Status reports this instance, the current lease holder and the registered duties
+func (e *Elector) Status(ctx context.Context) (Status, error) {
+	leader, err := e.lock.Holder(ctx)
+	if err != nil {
+		return Status{}, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	status := Status{Instance: e.instance, Leader: leader, IsLeader: e.leading, Duties: []string{}}
+	if e.leading {
+		since := e.leaderSince
+		status.LeaderSince = &since
+	}
+	for _, d := range e.duties {
+		status.Duties = append(status.Duties, d.name)
+	}
+	sort.Strings(status.Duties)
+	return status, nil
+}
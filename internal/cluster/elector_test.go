@@ -0,0 +1,208 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTTL = 90 * time.Millisecond
+
+// partitionedLock fails every call while cut off, like an instance that lost its Redis connection
+type partitionedLock struct {
+	Lock
+	cut atomic.Bool
+}
+
+func (l *partitionedLock) TryAcquire(ctx context.Context, holder string, ttl time.Duration) (bool, error) {
+	if l.cut.Load() {
+		return false, errors.New("connection refused")
+	}
+	return l.Lock.TryAcquire(ctx, holder, ttl)
+}
+
+// instance runs an elector with a duty that counts how many instances run it at once
+type instance struct {
+	elector *Elector
+	stop    context.CancelFunc
+	done    chan struct{}
+}
+
+func startInstance(t *testing.T, lock Lock, name string, active *atomic.Int32, maxActive *atomic.Int32) *instance {
+	t.Helper()
+	elector := NewElector(lock, name, testTTL)
+	elector.Singleton("reconciler", func(ctx context.Context) {
+		n := active.Add(1)
+		for {
+			if current := maxActive.Load(); n <= current || maxActive.CompareAndSwap(current, n) {
+				break
+			}
+		}
+		<-ctx.Done()
+		active.Add(-1)
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		elector.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return &instance{elector: elector, stop: cancel, done: done}
+}
+
+func leaders(instances ...*instance) []string {
+	var names []string
+	for _, i := range instances {
+		if i.elector.IsLeader() {
+			names = append(names, i.elector.instance)
+		}
+	}
+	return names
+}
+
+func TestElector_OneLeaderRunsSingletonDuties(t *testing.T) {
+	lock := NewMemoryLock()
+	var active, maxActive atomic.Int32
+	a := startInstance(t, lock, "api-a", &active, &maxActive)
+	b := startInstance(t, lock, "api-b", &active, &maxActive)
+	c := startInstance(t, lock, "api-c", &active, &maxActive)
+
+	require.Eventually(t, func() bool { return len(leaders(a, b, c)) == 1 }, time.Second, 5*time.Millisecond)
+	time.Sleep(3 * testTTL)
+
+	assert.Len(t, leaders(a, b, c), 1, "leadership must stay put while the leader renews its lease")
+	assert.Equal(t, int32(1), active.Load())
+	assert.Equal(t, int32(1), maxActive.Load(), "the duty never ran on two instances at once")
+
+	status, err := a.elector.Status(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, leaders(a, b, c)[0], status.Leader)
+	assert.Equal(t, []string{"reconciler"}, status.Duties)
+}
+
+func TestElector_FailoverWhenLeaderShutsDown(t *testing.T) {
+	lock := NewMemoryLock()
+	var active, maxActive atomic.Int32
+	a := startInstance(t, lock, "api-a", &active, &maxActive)
+	require.Eventually(t, a.elector.IsLeader, time.Second, 5*time.Millisecond)
+	b := startInstance(t, lock, "api-b", &active, &maxActive)
+
+	a.stop()
+	<-a.done
+	assert.False(t, a.elector.IsLeader())
+
+	require.Eventually(t, b.elector.IsLeader, time.Second, 5*time.Millisecond, "a released lease is taken over on the next check")
+	require.Eventually(t, func() bool { return active.Load() == 1 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, int32(1), maxActive.Load())
+}
+
+func TestElector_FailoverWhenLeaderIsPartitioned(t *testing.T) {
+	shared := NewMemoryLock()
+	cutOff := &partitionedLock{Lock: shared}
+	var active, maxActive atomic.Int32
+	a := startInstance(t, cutOff, "api-a", &active, &maxActive)
+	require.Eventually(t, a.elector.IsLeader, time.Second, 5*time.Millisecond)
+	b := startInstance(t, shared, "api-b", &active, &maxActive)
+
+	cutOff.cut.Store(true)
+	require.Eventually(t, func() bool { return !a.elector.IsLeader() }, time.Second, 5*time.Millisecond,
+		"an instance that cannot renew its lease stops its duties")
+	require.Eventually(t, b.elector.IsLeader, time.Second, 5*time.Millisecond, "the lease is taken over once it expires")
+	assert.Equal(t, int32(1), maxActive.Load())
+
+	// Reconnecting does not take leadership back while the new leader renews
+	cutOff.cut.Store(false)
+	time.Sleep(2 * testTTL)
+	assert.Equal(t, []string{"api-b"}, leaders(a, b))
+}
+
+func TestElector_SingletonRegisteredWhileLeading(t *testing.T) {
+	var active, maxActive atomic.Int32
+	a := startInstance(t, NewMemoryLock(), "api-a", &active, &maxActive)
+	require.Eventually(t, a.elector.IsLeader, time.Second, 5*time.Millisecond)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	a.elector.Singleton("late", func(ctx context.Context) {
+		wg.Done()
+		<-ctx.Done()
+	})
+	wg.Wait()
+}
+
+func TestElector_ResignWaitsOnlyForItsOwnTerm(t *testing.T) {
+	elector := NewElector(NewMemoryLock(), "api-a", testTTL)
+	stopping, release := make(chan struct{}), make(chan struct{})
+	var terms atomic.Int32
+	elector.Singleton("reconciler", func(ctx context.Context) {
+		term := terms.Add(1)
+		<-ctx.Done()
+		if term == 1 {
+			close(stopping)
+			<-release
+		}
+	})
+	elector.tick(context.Background())
+	require.True(t, elector.IsLeader())
+
+	resigned := make(chan struct{})
+	go func() {
+		elector.Resign()
+		close(resigned)
+	}()
+	<-stopping
+
+	// The lease is still held while the first term's duty stops, so a tick starts a new term
+	elector.tick(context.Background())
+	require.True(t, elector.IsLeader())
+	require.Eventually(t, func() bool { return terms.Load() == 2 }, time.Second, time.Millisecond)
+	close(release)
+
+	select {
+	case <-resigned:
+	case <-time.After(time.Second):
+		t.Fatal("Resign waited for the duties of the next term")
+	}
+	elector.Resign()
+}
+
+func TestRedisLock_Lease(t *testing.T) {
+	addr := os.Getenv("REDIS_HOST")
+	if addr == "" {
+		t.Skip("REDIS_HOST not set, skipping Redis lock test")
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr, Password: os.Getenv("REDIS_PASSWORD")})
+	lock := NewRedisLock(client, "ztdp:test:cluster:leader")
+	ctx := context.Background()
+	require.NoError(t, client.Del(ctx, "ztdp:test:cluster:leader").Err())
+
+	acquired, err := lock.TryAcquire(ctx, "api-a", time.Second)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+	acquired, err = lock.TryAcquire(ctx, "api-b", time.Second)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+
+	require.NoError(t, lock.Release(ctx, "api-b"), "releasing someone else's lease is a no-op")
+	holder, err := lock.Holder(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "api-a", holder)
+
+	require.NoError(t, lock.Release(ctx, "api-a"))
+	acquired, err = lock.TryAcquire(ctx, "api-b", time.Second)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+	require.NoError(t, lock.Release(ctx, "api-b"))
+}
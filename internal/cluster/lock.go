@@ -0,0 +1,129 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Lock is a lease shared by the instances of a cluster. Whoever holds it is the leader
+// until the lease expires or is released.
+type Lock interface {
+	// TryAcquire takes the lease for holder, or extends it when holder already has it.
+	// It returns false while another holder's lease is still valid.
+	TryAcquire(ctx context.Context, holder string, ttl time.Duration) (bool, error)
+	// Release gives up the lease if holder has it
+	Release(ctx context.Context, holder string) error
+	// Holder returns the current lease holder, or "" when nobody holds it
+	Holder(ctx context.Context) (string, error)
+}
+
+// MemoryLock is a Lock within one process, for single-instance deployments and tests
+type MemoryLock struct {
+	mu      sync.Mutex
+	holder  string
+	expires time.Time
+}
+
+// NewMemoryLock creates an unheld in-process lock
+func NewMemoryLock() *MemoryLock {
+	return &MemoryLock{}
+}
+
+// TryAcquire implements Lock
+func (l *MemoryLock) TryAcquire(ctx context.Context, holder string, ttl time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	if l.holder != "" && l.holder != holder && now.Before(l.expires) {
+		return false, nil
+	}
+	l.holder, l.expires = holder, now.Add(ttl)
+	return true, nil
+}
+
+// Release implements Lock
+func (l *MemoryLock) Release(ctx context.Context, holder string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.holder == holder {
+		l.holder = ""
+	}
+	return nil
+}
+
+// Holder implements Lock
+func (l *MemoryLock) Holder(ctx context.Context) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if time.Now().After(l.expires) {
+		return "", nil
+	}
+	return l.holder, nil
+}
+
+// DefaultRedisLockKey is the key instances compete for
+const DefaultRedisLockKey = "ztdp:cluster:leader"
+
+// The scripts compare the holder before changing the key, so an instance whose lease
+// expired cannot extend or delete the lease another instance has since taken
+var (
+	acquireScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+if current == ARGV[1] then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	return 1
+end
+if current then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+return 1
+`)
+	releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+)
+
+// RedisLock is a Lock stored in Redis, shared by every instance using the same key
+type RedisLock struct {
+	client *redis.Client
+	key    string
+}
+
+// NewRedisLock creates a Redis lease on key (DefaultRedisLockKey when empty)
+func NewRedisLock(client *redis.Client, key string) *RedisLock {
+	if key == "" {
+		key = DefaultRedisLockKey
+	}
+	return &RedisLock{client: client, key: key}
+}
+
+// TryAcquire implements Lock
+func (l *RedisLock) TryAcquire(ctx context.Context, holder string, ttl time.Duration) (bool, error) {
+	acquired, err := acquireScript.Run(ctx, l.client, []string{l.key}, holder, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return acquired == 1, nil
+}
+
+// Release implements Lock
+func (l *RedisLock) Release(ctx context.Context, holder string) error {
+	return releaseScript.Run(ctx, l.client, []string{l.key}, holder).Err()
+}
+
+// Holder implements Lock
+func (l *RedisLock) Holder(ctx context.Context) (string, error) {
+	holder, err := l.client.Get(ctx, l.key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return holder, err
+}
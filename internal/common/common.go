@@ -12,6 +12,31 @@ const (
 	KindPolicy           = "policy"
 	KindCheck            = "check"
 	KindProcess          = "process"
+	KindFeatureFlag      = "feature_flag"
+	KindConversation     = "conversation"
+	KindPlan             = "plan"
+	KindQuota            = "quota"
+	KindSavedSearch      = "saved_search"
+	KindCheckpoint       = "checkpoint"
+	KindAIDecision       = "ai_decision"
+	KindCalendarEntry    = "calendar_entry"
+	KindArchiveBatch     = "archive_batch"
+	KindNodeKind         = "node_kind"
+	KindMLModel          = "ml_model"
+	KindModelVersion     = "model_version"
+	KindModelEndpoint    = "model_endpoint"
+	KindMigration        = "migration"
+	KindTopic            = "topic"
+	KindRunbook          = "runbook"
+	KindDRDrill          = "dr_drill"
+	KindOrganization     = "organization"
+	KindTeam             = "team"
+	KindProject          = "project"
+	KindAutonomy         = "ai_autonomy"
+	KindRoutingOverride  = "routing_override"
+	KindWorkflow         = "workflow"
+	KindPolicyDecision   = "policy_decision"
+	KindReleaseBundle    = "release_bundle"
 )
 
 // Constants for graph edge types
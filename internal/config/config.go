@@ -0,0 +1,1206 @@
+package config
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the typed platform configuration loaded from a file and environment overrides
+type Config struct {
+	Server          ServerConfig          `yaml:"server" json:"server"`
+	Graph           GraphConfig           `yaml:"graph" json:"graph"`
+	AI              AIConfig              `yaml:"ai" json:"ai"`
+	Events          EventConfig           `yaml:"events" json:"events"`
+	Logs            LogsConfig            `yaml:"logs" json:"logs"`
+	Bootstrap       BootstrapConfig       `yaml:"bootstrap" json:"bootstrap"`
+	Templates       TemplatesConfig       `yaml:"templates" json:"templates"`
+	Resources       ResourcesConfig       `yaml:"resources" json:"resources"`
+	Conversations   ConversationsConfig   `yaml:"conversations" json:"conversations"`
+	Redaction       RedactionConfig       `yaml:"redaction" json:"redaction"`
+	Guardrails      GuardrailsConfig      `yaml:"guardrails" json:"guardrails"`
+	Chaos           ChaosConfig           `yaml:"chaos" json:"chaos"`
+	Analytics       AnalyticsConfig       `yaml:"analytics" json:"analytics"`
+	Vulnerabilities VulnerabilitiesConfig `yaml:"vulnerabilities" json:"vulnerabilities"`
+	Promotion       PromotionConfig       `yaml:"promotion" json:"promotion"`
+	Migrations      MigrationsConfig      `yaml:"migrations" json:"migrations"`
+	Provenance      ProvenanceConfig      `yaml:"provenance" json:"provenance"`
+	Backup          BackupConfig          `yaml:"backup" json:"backup"`
+	Handoff         HandoffConfig         `yaml:"handoff" json:"handoff"`
+	Cluster         ClusterConfig         `yaml:"cluster" json:"cluster"`
+	Clarification   ClarificationConfig   `yaml:"clarification" json:"clarification"`
+	Arbitration     ArbitrationConfig     `yaml:"arbitration" json:"arbitration"`
+	Recording       RecordingConfig       `yaml:"recording" json:"recording"`
+	GraphStats      GraphStatsConfig      `yaml:"graph_stats" json:"graph_stats"`
+	PolicyCache     PolicyCacheConfig     `yaml:"policy_cache" json:"policy_cache"`
+	Maintenance     MaintenanceConfig     `yaml:"maintenance" json:"maintenance"`
+	Audit           AuditConfig           `yaml:"audit" json:"audit"`
+	DecisionLogs    DecisionLogsConfig    `yaml:"decision_logs" json:"decision_logs"`
+	Workflows       WorkflowsConfig       `yaml:"workflows" json:"workflows"`
+	Governance      GovernanceConfig      `yaml:"governance" json:"governance"`
+	AgentSLA        AgentSLAConfig        `yaml:"agent_sla" json:"agent_sla"`
+	Degradation     DegradationConfig     `yaml:"degradation" json:"degradation"`
+}
+
+// ServerConfig configures the HTTP API server
+type ServerConfig struct {
+	Port            string        `yaml:"port" json:"port"`
+	LogLevel        string        `yaml:"log_level" json:"log_level"`               // hot-reloadable
+	LogFormat       string        `yaml:"log_format" json:"log_format"`             // json | text
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" json:"shutdown_timeout"` // max time to drain on SIGINT/SIGTERM
+}
+
+// GraphConfig configures the graph storage backend
+type GraphConfig struct {
+	Backend string      `yaml:"backend" json:"backend"` // memory | redis
+	Redis   RedisConfig `yaml:"redis" json:"redis"`
+}
+
+// RedisConfig configures the Redis graph backend
+type RedisConfig struct {
+	Addr     string `yaml:"addr" json:"addr"`
+	Password string `yaml:"password" json:"-"`
+}
+
+// AIConfig configures the AI provider
+type AIConfig struct {
+	Provider string        `yaml:"provider" json:"provider"` // openai
+	APIKey   string        `yaml:"api_key" json:"-"`
+	Model    string        `yaml:"model" json:"model"` // hot-reloadable
+	BaseURL  string        `yaml:"base_url" json:"base_url"`
+	Timeout  time.Duration `yaml:"timeout" json:"timeout"`
+	// Models overrides Model per task (classification, extraction, planning, conversation); hot-reloadable
+	Models map[string]string `yaml:"models" json:"models"`
+
+	Embeddings    EmbeddingsConfig    `yaml:"embeddings" json:"embeddings"`
+	PromptLogging PromptLoggingConfig `yaml:"prompt_logging" json:"prompt_logging"`
+	Tokenizer     TokenizerConfig     `yaml:"tokenizer" json:"tokenizer"`
+}
+
+// TokenizerConfig configures how prompt tokens are counted for budgeting, truncation and chunking
+type TokenizerConfig struct {
+	Type      string `yaml:"type" json:"type"`             // bpe | estimate
+	Encoding  string `yaml:"encoding" json:"encoding"`     // bpe encoding, e.g. cl100k_base
+	RanksFile string `yaml:"ranks_file" json:"ranks_file"` // local .tiktoken vocabulary; empty downloads and caches it
+}
+
+// EmbeddingsConfig configures the embedding provider used for semantic matching. Embeddings
+// are disabled when no provider is set.
+type EmbeddingsConfig struct {
+	Provider  string `yaml:"provider" json:"provider"`     // openai | local; empty disables embeddings
+	Model     string `yaml:"model" json:"model"`           // openai model name
+	URL       string `yaml:"url" json:"url"`               // embed endpoint for local; defaults to ai.base_url for openai
+	BatchSize int    `yaml:"batch_size" json:"batch_size"` // maximum texts per request
+	CacheSize int    `yaml:"cache_size" json:"cache_size"` // embeddings kept in memory; 0 disables the cache
+}
+
+// PromptLoggingConfig configures logging of a sample of full AI prompts and responses, redacted
+// and kept apart from the application logs, for /v1/ai/prompt-logs
+type PromptLoggingConfig struct {
+	Enabled    bool          `yaml:"enabled" json:"enabled"`
+	SampleRate float64       `yaml:"sample_rate" json:"sample_rate"` // fraction of AI calls logged, 0 to 1; hot-reloadable
+	Capacity   int           `yaml:"capacity" json:"capacity"`       // most recent calls kept in memory
+	Retention  time.Duration `yaml:"retention" json:"retention"`     // calls older than this are dropped; 0 keeps them until overwritten
+}
+
+// EventConfig configures the event transport
+type EventConfig struct {
+	Transport  string           `yaml:"transport" json:"transport"` // memory | nats
+	NATSURL    string           `yaml:"nats_url" json:"nats_url"`
+	DedupStore string           `yaml:"dedup_store" json:"dedup_store"` // memory | redis (uses graph.redis connection settings); empty disables deduplication
+	DedupTTL   time.Duration    `yaml:"dedup_ttl" json:"dedup_ttl"`     // how long processed event IDs are remembered
+	Encryption EncryptionConfig `yaml:"encryption" json:"encryption"`
+}
+
+// EncryptionConfig configures AES-GCM encryption of event payloads on sensitive subjects
+type EncryptionConfig struct {
+	Subjects       []string      `yaml:"subjects" json:"subjects"`               // subjects to encrypt; a trailing * matches any suffix; empty disables encryption
+	KeyFile        string        `yaml:"key_file" json:"key_file"`               // "id:base64-key" per line, newest last, as mounted by the secrets provider
+	ReloadInterval time.Duration `yaml:"reload_interval" json:"reload_interval"` // how often the key file is re-read to pick up rotated keys
+}
+
+// LogsConfig configures log retention for the log query API
+type LogsConfig struct {
+	Store    string `yaml:"store" json:"store"`       // memory | redis (uses graph.redis connection settings)
+	Capacity int    `yaml:"capacity" json:"capacity"` // number of entries retained
+}
+
+// TemplatesConfig configures the golden-path templates offered through chat and /v1/templates
+type TemplatesConfig struct {
+	Dir string `yaml:"dir" json:"dir"` // directory of YAML template definitions; empty disables templates
+}
+
+// BootstrapConfig configures the declarative definitions reconciled into the graph at startup
+type BootstrapConfig struct {
+	Dir string `yaml:"dir" json:"dir"` // directory of YAML definitions; empty disables bootstrap
+}
+
+// ResourcesConfig configures resource type plugins and how resource instances are named
+type ResourcesConfig struct {
+	PluginDir string               `yaml:"plugin_dir" json:"plugin_dir"` // directory of Go plugin (.so) files; empty loads built-ins only
+	Naming    ResourceNamingConfig `yaml:"naming" json:"naming"`
+}
+
+// ResourceNamingConfig configures the names generated for resource instances, with overrides
+// per resource type for providers that restrict names
+type ResourceNamingConfig struct {
+	ResourceNamingRule `yaml:",inline"`
+	Providers          map[string]ResourceNamingRule `yaml:"providers" json:"providers"` // by resource type, e.g. s3; empty fields use the defaults above
+}
+
+// ResourceNamingRule is one naming rule for resource instances
+type ResourceNamingRule struct {
+	Template  string `yaml:"template" json:"template"`     // Go template over .App, .Resource, .Type, .Team and .Env
+	MaxLength int    `yaml:"max_length" json:"max_length"` // longer names are truncated with a hash suffix; 0 allows any length
+	Charset   string `yaml:"charset" json:"charset"`       // allowed characters as a regular expression class, e.g. a-z0-9-; others become dashes
+}
+
+// ConversationsConfig configures chat transcript storage
+type ConversationsConfig struct {
+	Enabled     bool          `yaml:"enabled" json:"enabled"`
+	Retention   time.Duration `yaml:"retention" json:"retention"`       // idle transcripts older than this are pruned; 0 keeps them
+	RedactPII   bool          `yaml:"redact_pii" json:"redact_pii"`     // mask emails, tokens and credentials before storing
+	Store       string        `yaml:"store" json:"store"`               // graph | redis (uses graph.redis connection settings) | postgres
+	PostgresURL string        `yaml:"postgres_url" json:"postgres_url"` // connection string of the postgres store
+	Archive     ArchiveConfig `yaml:"archive" json:"archive"`
+}
+
+// ArchiveConfig configures tiered storage of AI conversation and decision history. Transcripts
+// idle longer than After, and decisions beyond the kept capacity, move from the graph to Dir or
+// the object store at URL in compressed batches; archiving is off when neither is set. Archived
+// applications are kept in the same store.
+type ArchiveConfig struct {
+	Dir       string        `yaml:"dir" json:"dir"`
+	URL       string        `yaml:"url" json:"url"`               // object store prefix accepting PUT/GET/DELETE
+	After     time.Duration `yaml:"after" json:"after"`           // must be shorter than the retention
+	BatchSize int           `yaml:"batch_size" json:"batch_size"` // records per compressed batch
+}
+
+// RedactionConfig configures masking of secrets and personal data in AI prompts, event logs
+// and stored conversations. Built-in rules and field names always apply when enabled.
+type RedactionConfig struct {
+	Enabled    bool              `yaml:"enabled" json:"enabled"`
+	DenyFields []string          `yaml:"deny_fields" json:"deny_fields"` // extra field names whose values are always masked
+	Patterns   map[string]string `yaml:"patterns" json:"patterns"`       // extra named regular expressions to mask
+}
+
+// GuardrailsConfig configures the checks applied to AI-proposed actions before agents execute them.
+// Roles are merged over the built-in viewer, developer, operator and admin roles.
+type GuardrailsConfig struct {
+	Enabled               bool                `yaml:"enabled" json:"enabled"`
+	DefaultRole           string              `yaml:"default_role" json:"default_role"`                     // role assumed when a chat request names none
+	Roles                 map[string][]string `yaml:"roles" json:"roles"`                                   // operations (create, update, delete, deploy or *) per role
+	ApproverRoles         []string            `yaml:"approver_roles" json:"approver_roles"`                 // roles that may run actions requiring approval
+	Naming                map[string]string   `yaml:"naming" json:"naming"`                                 // regular expression per node kind for new names
+	ProtectedEnvironments []string            `yaml:"protected_environments" json:"protected_environments"` // changes require approval
+	RoleEnvironments      map[string][]string `yaml:"role_environments" json:"role_environments"`           // environments a role may change
+	MaxDeletes            int                 `yaml:"max_deletes" json:"max_deletes"`                       // deletions per plan above this are rejected
+	MaxTargets            int                 `yaml:"max_targets" json:"max_targets"`                       // plans touching more nodes require approval
+	DefaultAutonomy       string              `yaml:"default_autonomy" json:"default_autonomy"`             // AI autonomy of tenants and applications without their own level
+}
+
+// ChaosConfig configures failure injection for resilience testing. Keep it disabled in production.
+type ChaosConfig struct {
+	Enabled bool `yaml:"enabled" json:"enabled"` // registers the chaos agent and /v1/chaos endpoints
+}
+
+// AnalyticsConfig configures recording of intent classifications for /v1/analytics/intents
+type AnalyticsConfig struct {
+	Enabled  bool `yaml:"enabled" json:"enabled"`
+	Capacity int  `yaml:"capacity" json:"capacity"` // most recent classifications kept in memory
+}
+
+// VulnerabilitiesConfig configures SBOM and CVE scanning of service versions and the
+// Policy Agent's gate on deploying them
+type VulnerabilitiesConfig struct {
+	ScannerURL  string `yaml:"scanner_url" json:"scanner_url"`   // scanning service called for new versions without a report; empty disables triggered scans
+	MaxCritical int    `yaml:"max_critical" json:"max_critical"` // versions with more critical CVEs cannot be deployed
+	RequireScan bool   `yaml:"require_scan" json:"require_scan"` // block versions that were never scanned
+}
+
+// PromotionConfig configures how long an application must have run healthy in a lower
+// environment before the Policy Agent lets it be deployed to the next one
+type PromotionConfig struct {
+	Soak map[string]SoakConfig `yaml:"soak" json:"soak"` // by the environment being deployed to, e.g. prod: {after: staging, duration: 24h}
+}
+
+// SoakConfig is the soak time required before deploying to one environment
+type SoakConfig struct {
+	After    string        `yaml:"after" json:"after"`       // the lower environment the application must have run in
+	Duration time.Duration `yaml:"duration" json:"duration"` // for at least this long since its last successful deployment there
+}
+
+// MigrationsConfig configures the Policy Agent's gate on the schema migrations a deployment runs
+type MigrationsConfig struct {
+	RequireReversible []string `yaml:"require_reversible" json:"require_reversible"` // environments that only accept migrations with a down artifact
+}
+
+// ProvenanceConfig configures signing of execution plans and graph mutations with a key per
+// orchestrator instance
+type ProvenanceConfig struct {
+	Enabled     bool              `yaml:"enabled" json:"enabled"`
+	Instance    string            `yaml:"instance" json:"instance"`         // instance name in records; defaults to the hostname
+	KeyFile     string            `yaml:"key_file" json:"key_file"`         // base64 ed25519 seed, created on first start; empty uses a key that lives until restart
+	Store       string            `yaml:"store" json:"store"`               // memory | redis (uses graph.redis connection settings)
+	Capacity    int               `yaml:"capacity" json:"capacity"`         // most recent records kept
+	TrustedKeys map[string]string `yaml:"trusted_keys" json:"trusted_keys"` // base64 public keys of other instances, by instance name
+}
+
+// BackupConfig configures graph backups. Backups are stored in Dir, or in the object store at
+// URL, and the admin API is available when either is set.
+type BackupConfig struct {
+	Dir      string        `yaml:"dir" json:"dir"`
+	URL      string        `yaml:"url" json:"url"`           // object store prefix accepting PUT/GET/DELETE
+	Interval time.Duration `yaml:"interval" json:"interval"` // scheduled backups; 0 only backs up on request
+	Keep     int           `yaml:"keep" json:"keep"`         // newest backups kept; 0 keeps any number
+	MaxAge   time.Duration `yaml:"max_age" json:"max_age"`   // backups older than this are pruned; 0 keeps them
+}
+
+// HandoffConfig configures handing in-flight work to the next instance during upgrades
+type HandoffConfig struct {
+	Enabled       bool          `yaml:"enabled" json:"enabled"`
+	WatchInterval time.Duration `yaml:"watch_interval" json:"watch_interval"` // how often a running instance looks for work handed to it
+}
+
+// ClusterConfig configures running several API instances side by side. Every instance serves
+// requests and runs agents; singleton duties such as scheduled backups run on the instance
+// holding the leader lease in Redis.
+type ClusterConfig struct {
+	Enabled  bool          `yaml:"enabled" json:"enabled"`
+	Instance string        `yaml:"instance" json:"instance"`   // defaults to the hostname
+	LeaseTTL time.Duration `yaml:"lease_ttl" json:"lease_ttl"` // a crashed leader is replaced after at most this long
+	AgentTTL time.Duration `yaml:"agent_ttl" json:"agent_ttl"` // other instances stop seeing a crashed instance's agents after this long
+}
+
+// ClarificationConfig configures how confident agents must be about a request before acting
+// on it; below the threshold they ask the user to clarify
+type ClarificationConfig struct {
+	Threshold    float64            `yaml:"threshold" json:"threshold"`       // 0.0-1.0, for every capability without an override
+	Capabilities map[string]float64 `yaml:"capabilities" json:"capabilities"` // thresholds by capability name, e.g. deployment_orchestration: 0.8
+}
+
+// ArbitrationConfig configures how the orchestrator chooses between agents competing for an
+// intent: each is asked for a bid and the policy ranks the bids
+type ArbitrationConfig struct {
+	Enabled       bool          `yaml:"enabled" json:"enabled"`               // off tries agents in registration order
+	Policy        string        `yaml:"policy" json:"policy"`                 // confidence | cost | eta
+	BidTimeout    time.Duration `yaml:"bid_timeout" json:"bid_timeout"`       // agents that have not bid by then are tried last
+	MinConfidence float64       `yaml:"min_confidence" json:"min_confidence"` // 0.0-1.0; cost and eta try less confident bids last
+}
+
+// RecordingConfig configures capturing API requests, responses and graph mutations for a time
+// window into a bundle that `go run ./cmd/replay` reapplies to reproduce bugs
+type RecordingConfig struct {
+	Enabled   bool          `yaml:"enabled" json:"enabled"`       // registers /v1/admin/recordings; nothing is recorded until started there
+	MaxWindow time.Duration `yaml:"max_window" json:"max_window"` // longest recording that can be started
+	Dir       string        `yaml:"dir" json:"dir"`               // where bundles are written; empty keeps the last one in memory
+}
+
+// GraphStatsConfig configures the graph statistics served on /metrics and /v1/graph/stats
+type GraphStatsConfig struct {
+	Interval    time.Duration `yaml:"interval" json:"interval"`         // how often the statistics are computed
+	Window      time.Duration `yaml:"window" json:"window"`             // growth is reported over this window
+	GrowthAlert int           `yaml:"growth_alert" json:"growth_alert"` // a kind gaining more nodes within the window is an anomaly; 0 disables
+}
+
+// PolicyCacheConfig configures caching of AI policy decisions. A cached decision is dropped as
+// soon as a node or edge its evaluation read changes, and after TTL at the latest.
+type PolicyCacheConfig struct {
+	Enabled bool          `yaml:"enabled" json:"enabled"`
+	TTL     time.Duration `yaml:"ttl" json:"ttl"` // bounds staleness from changes made by other instances
+}
+
+// MaintenanceConfig configures where the impact notices of scheduled maintenance windows are
+// delivered besides the event bus
+type MaintenanceConfig struct {
+	Webhooks []string      `yaml:"webhooks" json:"webhooks"` // each notice's payload is POSTed to every URL
+	SlackURL string        `yaml:"slack_url" json:"-"`       // Slack incoming webhook; the URL is a secret
+	Timeout  time.Duration `yaml:"timeout" json:"timeout"`   // per delivery
+}
+
+// AuditConfig configures the log of every API call served by /v1/admin/audit
+type AuditConfig struct {
+	Enabled   bool          `yaml:"enabled" json:"enabled"`
+	Capacity  int           `yaml:"capacity" json:"capacity"`   // most recent calls kept in memory for export
+	Dir       string        `yaml:"dir" json:"dir"`             // daily JSON lines files for SIEM collectors; empty keeps calls only in memory
+	Retention time.Duration `yaml:"retention" json:"retention"` // calls and files older than this are pruned; 0 keeps them
+}
+
+// DecisionLogsConfig configures the OPA-style log of every policy decision served by
+// /v1/policies/decisions and shipped to a file or an OPA decision log endpoint
+type DecisionLogsConfig struct {
+	Enabled       bool          `yaml:"enabled" json:"enabled"`
+	Capacity      int           `yaml:"capacity" json:"capacity"`             // most recent decisions kept in memory
+	File          string        `yaml:"file" json:"file"`                     // JSON lines file, one decision per line
+	URL           string        `yaml:"url" json:"url"`                       // endpoint receiving gzipped JSON arrays like OPA's decision_logs plugin sends
+	BatchSize     int           `yaml:"batch_size" json:"batch_size"`         // decisions shipped together
+	FlushInterval time.Duration `yaml:"flush_interval" json:"flush_interval"` // a partial batch is shipped after this
+	Timeout       time.Duration `yaml:"timeout" json:"timeout"`               // per upload to url
+}
+
+// WorkflowsConfig configures the durable workflow engine behind /v1/workflows
+type WorkflowsConfig struct {
+	Enabled      bool          `yaml:"enabled" json:"enabled"`
+	TickInterval time.Duration `yaml:"tick_interval" json:"tick_interval"` // how often the leader wakes workflows whose timers have ended
+}
+
+// GovernanceConfig configures the mutation policies every graph save is checked against
+type GovernanceConfig struct {
+	Enabled               bool          `yaml:"enabled" json:"enabled"`
+	ProtectedEnvironments []string      `yaml:"protected_environments" json:"protected_environments"` // deployments need an allowed policy decision before rolling out here
+	DecisionTTL           time.Duration `yaml:"decision_ttl" json:"decision_ttl"`                     // how long an allowed decision lets a release roll out
+	DecisionKeyFile       string        `yaml:"decision_key_file" json:"decision_key_file"`           // base64 key decisions are signed with, created on first start; empty uses a key that lives until restart
+}
+
+// AgentSLAConfig configures how agents breaching the response-time SLAs their capabilities
+// declare are flagged
+type AgentSLAConfig struct {
+	BreachThreshold int `yaml:"breach_threshold" json:"breach_threshold"` // consecutive slow responses that flag an agent
+}
+
+// DegradationConfig configures the reduced modes the platform enters while one of its own
+// dependencies is down, instead of failing
+type DegradationConfig struct {
+	Enabled  bool          `yaml:"enabled" json:"enabled"`
+	Interval time.Duration `yaml:"interval" json:"interval"` // how often dependencies are probed
+}
+
+const (
+	GraphBackendMemory = "memory"
+	GraphBackendRedis  = "redis"
+
+	EventTransportMemory = "memory"
+	EventTransportNATS   = "nats"
+
+	AIProviderOpenAI = "openai"
+
+	EmbeddingProviderOpenAI = "openai"
+	EmbeddingProviderLocal  = "local"
+
+	TokenizerBPE      = "bpe"
+	TokenizerEstimate = "estimate"
+
+	LogStoreMemory = "memory"
+	LogStoreRedis  = "redis"
+
+	DedupStoreMemory = "memory"
+	DedupStoreRedis  = "redis"
+
+	ConversationStoreGraph    = "graph"
+	ConversationStoreRedis    = "redis"
+	ConversationStorePostgres = "postgres"
+
+	ProvenanceStoreMemory = "memory"
+	ProvenanceStoreRedis  = "redis"
+)
+
+// AITasks are the task hints ai.models can route to a specific model
+var AITasks = []string{"classification", "extraction", "planning", "conversation"}
+
+func isAITask(task string) bool {
+	for _, known := range AITasks {
+		if task == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Default returns the configuration used when no file or environment overrides are present
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Port:            "8080",
+			LogLevel:        "info",
+			LogFormat:       string(logging.FormatJSON),
+			ShutdownTimeout: 30 * time.Second,
+		},
+		Graph: GraphConfig{
+			Backend: GraphBackendMemory,
+		},
+		AI: AIConfig{
+			Provider: AIProviderOpenAI,
+			Model:    "gpt-4o-mini",
+			BaseURL:  "https://api.openai.com/v1",
+			Timeout:  90 * time.Second,
+			Embeddings: EmbeddingsConfig{
+				Model:     "text-embedding-3-small",
+				BatchSize: 64,
+				CacheSize: 10000,
+			},
+			PromptLogging: PromptLoggingConfig{
+				SampleRate: 0.01,
+				Capacity:   1000,
+				Retention:  24 * time.Hour,
+			},
+			Tokenizer: TokenizerConfig{
+				Type:     TokenizerBPE,
+				Encoding: "cl100k_base",
+			},
+		},
+		Events: EventConfig{
+			Transport:  EventTransportMemory,
+			DedupStore: DedupStoreMemory,
+			DedupTTL:   24 * time.Hour,
+			Encryption: EncryptionConfig{
+				ReloadInterval: time.Minute,
+			},
+		},
+		Logs: LogsConfig{
+			Store:    LogStoreMemory,
+			Capacity: 10000,
+		},
+		Resources: ResourcesConfig{
+			Naming: ResourceNamingConfig{
+				ResourceNamingRule: ResourceNamingRule{Template: "{{.App}}-{{.Resource}}"},
+			},
+		},
+		Conversations: ConversationsConfig{
+			Enabled:   true,
+			Retention: 30 * 24 * time.Hour,
+			RedactPII: true,
+			Store:     ConversationStoreGraph,
+			Archive: ArchiveConfig{
+				After:     7 * 24 * time.Hour,
+				BatchSize: 100,
+			},
+		},
+		Redaction: RedactionConfig{
+			Enabled: true,
+		},
+		Guardrails: GuardrailsConfig{
+			Enabled:         true,
+			DefaultRole:     "operator",
+			ApproverRoles:   []string{"admin"},
+			MaxDeletes:      3,
+			MaxTargets:      20,
+			DefaultAutonomy: "full-auto",
+		},
+		Analytics: AnalyticsConfig{
+			Enabled:  true,
+			Capacity: 10000,
+		},
+		Provenance: ProvenanceConfig{
+			Enabled:  true,
+			Store:    ProvenanceStoreMemory,
+			Capacity: 10000,
+		},
+		Backup: BackupConfig{
+			Keep: 14,
+		},
+		Handoff: HandoffConfig{
+			Enabled:       true,
+			WatchInterval: 10 * time.Second,
+		},
+		Cluster: ClusterConfig{
+			LeaseTTL: 15 * time.Second,
+			AgentTTL: 15 * time.Second,
+		},
+		Clarification: ClarificationConfig{
+			Threshold: 0.7,
+		},
+		Arbitration: ArbitrationConfig{
+			Enabled:    true,
+			Policy:     "confidence",
+			BidTimeout: 2 * time.Second,
+		},
+		Recording: RecordingConfig{
+			MaxWindow: time.Hour,
+		},
+		GraphStats: GraphStatsConfig{
+			Interval: time.Minute,
+			Window:   time.Hour,
+		},
+		PolicyCache: PolicyCacheConfig{
+			Enabled: true,
+			TTL:     10 * time.Minute,
+		},
+		Maintenance: MaintenanceConfig{
+			Timeout: 10 * time.Second,
+		},
+		Audit: AuditConfig{
+			Enabled:   true,
+			Capacity:  10000,
+			Retention: 90 * 24 * time.Hour,
+		},
+		DecisionLogs: DecisionLogsConfig{
+			Enabled:       true,
+			Capacity:      10000,
+			BatchSize:     100,
+			FlushInterval: 10 * time.Second,
+			Timeout:       10 * time.Second,
+		},
+		Workflows: WorkflowsConfig{
+			Enabled:      true,
+			TickInterval: 5 * time.Second,
+		},
+		Governance: GovernanceConfig{
+			Enabled:               true,
+			ProtectedEnvironments: []string{"prod"}, // the production environment created by config/bootstrap
+			DecisionTTL:           15 * time.Minute,
+		},
+		AgentSLA: AgentSLAConfig{
+			BreachThreshold: 3,
+		},
+		Degradation: DegradationConfig{
+			Enabled:  true,
+			Interval: 10 * time.Second,
+		},
+	}
+}
+
+// Load builds the configuration from defaults, the optional config file at path and environment overrides.
+// An empty path skips the file. The result is validated before it is returned.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadFile overlays values from a YAML, JSON or TOML config file
+func (c *Config) loadFile(path string) error {
+	ext := strings.ToLower(filepath.Ext(path))
+	switch ext {
+	case ".yaml", ".yml", ".json", ".toml":
+	default:
+		return fmt.Errorf("config file %s: unsupported format (expected .yaml, .yml, .json or .toml)", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	if ext == ".toml" {
+		if data, err = tomlToYAML(data); err != nil {
+			return fmt.Errorf("config file %s: %w", path, err)
+		}
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	// An empty file decodes to io.EOF and simply keeps the defaults
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// tomlToYAML re-encodes a TOML document as YAML, so TOML files use the same keys, duration
+// strings and unknown-field checks as YAML ones
+func tomlToYAML(data []byte) ([]byte, error) {
+	var values map[string]interface{}
+	if _, err := toml.Decode(string(data), &values); err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, nil
+	}
+	return yaml.Marshal(values)
+}
+
+// applyEnv overlays the environment variables the platform has always honoured
+func (c *Config) applyEnv() error {
+	if v := os.Getenv("PORT"); v != "" {
+		c.Server.Port = v
+	}
+	if v := os.Getenv("ZTDP_LOG_LEVEL"); v != "" {
+		c.Server.LogLevel = v
+	}
+	if v := os.Getenv("ZTDP_LOG_FORMAT"); v != "" {
+		c.Server.LogFormat = v
+	}
+	if v := os.Getenv("ZTDP_GRAPH_BACKEND"); v != "" {
+		c.Graph.Backend = v
+	}
+	if v := os.Getenv("REDIS_HOST"); v != "" {
+		c.Graph.Redis.Addr = v
+	}
+	if v := os.Getenv("REDIS_PASSWORD"); v != "" {
+		c.Graph.Redis.Password = v
+	}
+	if v := os.Getenv("OPENAI_API_KEY"); v != "" {
+		c.AI.APIKey = v
+	}
+	if v := os.Getenv("OPENAI_MODEL"); v != "" {
+		c.AI.Model = v
+	}
+	if v := os.Getenv("OPENAI_BASE_URL"); v != "" {
+		c.AI.BaseURL = v
+	}
+	if v := os.Getenv("ZTDP_OPENAI_TIMEOUT"); v != "" {
+		timeout, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("ZTDP_OPENAI_TIMEOUT: invalid duration %q", v)
+		}
+		c.AI.Timeout = timeout
+	}
+	if v := os.Getenv("ZTDP_EMBEDDINGS_PROVIDER"); v != "" {
+		c.AI.Embeddings.Provider = v
+	}
+	if v := os.Getenv("ZTDP_EMBEDDINGS_MODEL"); v != "" {
+		c.AI.Embeddings.Model = v
+	}
+	if v := os.Getenv("ZTDP_EMBEDDINGS_URL"); v != "" {
+		c.AI.Embeddings.URL = v
+	}
+	if v := os.Getenv("ZTDP_TOKENIZER_RANKS_FILE"); v != "" {
+		c.AI.Tokenizer.RanksFile = v
+	}
+	if v := os.Getenv("ZTDP_EVENT_DEDUP_STORE"); v != "" {
+		c.Events.DedupStore = v
+	}
+	if v := os.Getenv("ZTDP_LOG_STORE"); v != "" {
+		c.Logs.Store = v
+	}
+	if v := os.Getenv("ZTDP_BOOTSTRAP_DIR"); v != "" {
+		c.Bootstrap.Dir = v
+	}
+	if v := os.Getenv("ZTDP_TEMPLATES_DIR"); v != "" {
+		c.Templates.Dir = v
+	}
+	if v := os.Getenv("ZTDP_RESOURCE_PLUGIN_DIR"); v != "" {
+		c.Resources.PluginDir = v
+	}
+	if v := os.Getenv("ZTDP_CONVERSATIONS_ENABLED"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("ZTDP_CONVERSATIONS_ENABLED: invalid boolean %q", v)
+		}
+		c.Conversations.Enabled = enabled
+	}
+	if v := os.Getenv("ZTDP_CONVERSATION_RETENTION"); v != "" {
+		retention, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("ZTDP_CONVERSATION_RETENTION: invalid duration %q", v)
+		}
+		c.Conversations.Retention = retention
+	}
+	if v := os.Getenv("ZTDP_CONVERSATION_STORE"); v != "" {
+		c.Conversations.Store = v
+	}
+	if v := os.Getenv("ZTDP_CONVERSATION_POSTGRES_URL"); v != "" {
+		c.Conversations.PostgresURL = v
+	}
+	if v := os.Getenv("ZTDP_REDACTION_DENY_FIELDS"); v != "" {
+		for _, field := range strings.Split(v, ",") {
+			if field = strings.TrimSpace(field); field != "" {
+				c.Redaction.DenyFields = append(c.Redaction.DenyFields, field)
+			}
+		}
+	}
+	if v := os.Getenv("ZTDP_GUARDRAILS_ENABLED"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("ZTDP_GUARDRAILS_ENABLED: invalid boolean %q", v)
+		}
+		c.Guardrails.Enabled = enabled
+	}
+	if v := os.Getenv("ZTDP_GUARDRAILS_DEFAULT_ROLE"); v != "" {
+		c.Guardrails.DefaultRole = v
+	}
+	if v := os.Getenv("ZTDP_CHAOS_ENABLED"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("ZTDP_CHAOS_ENABLED: invalid boolean %q", v)
+		}
+		c.Chaos.Enabled = enabled
+	}
+	if v := os.Getenv("ZTDP_ANALYTICS_ENABLED"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("ZTDP_ANALYTICS_ENABLED: invalid boolean %q", v)
+		}
+		c.Analytics.Enabled = enabled
+	}
+	if v := os.Getenv("ZTDP_SCANNER_URL"); v != "" {
+		c.Vulnerabilities.ScannerURL = v
+	}
+	if v := os.Getenv("ZTDP_MAX_CRITICAL_CVES"); v != "" {
+		maxCritical, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("ZTDP_MAX_CRITICAL_CVES: invalid integer %q", v)
+		}
+		c.Vulnerabilities.MaxCritical = maxCritical
+	}
+	if v := os.Getenv("ZTDP_BACKUP_DIR"); v != "" {
+		c.Backup.Dir = v
+	}
+	if v := os.Getenv("ZTDP_BACKUP_URL"); v != "" {
+		c.Backup.URL = v
+	}
+	if v := os.Getenv("ZTDP_BACKUP_INTERVAL"); v != "" {
+		interval, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("ZTDP_BACKUP_INTERVAL: invalid duration %q", v)
+		}
+		c.Backup.Interval = interval
+	}
+	if v := os.Getenv("ZTDP_PROVENANCE_KEY_FILE"); v != "" {
+		c.Provenance.KeyFile = v
+	}
+	if v := os.Getenv("ZTDP_PROVENANCE_STORE"); v != "" {
+		c.Provenance.Store = v
+	}
+	if v := os.Getenv("ZTDP_GOVERNANCE_DECISION_KEY_FILE"); v != "" {
+		c.Governance.DecisionKeyFile = v
+	}
+	if v := os.Getenv("ZTDP_EVENTS_KEY_FILE"); v != "" {
+		c.Events.Encryption.KeyFile = v
+	}
+	if v := os.Getenv("ZTDP_CLUSTER_ENABLED"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("ZTDP_CLUSTER_ENABLED: invalid boolean %q", v)
+		}
+		c.Cluster.Enabled = enabled
+	}
+	if v := os.Getenv("ZTDP_INSTANCE"); v != "" {
+		c.Cluster.Instance = v
+	}
+	if v := os.Getenv("ZTDP_CONFIDENCE_THRESHOLD"); v != "" {
+		threshold, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("ZTDP_CONFIDENCE_THRESHOLD: invalid number %q", v)
+		}
+		c.Clarification.Threshold = threshold
+	}
+	if v := os.Getenv("ZTDP_RECORDING_ENABLED"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("ZTDP_RECORDING_ENABLED: invalid boolean %q", v)
+		}
+		c.Recording.Enabled = enabled
+	}
+	if v := os.Getenv("ZTDP_MAINTENANCE_SLACK_URL"); v != "" {
+		c.Maintenance.SlackURL = v
+	}
+	if v := os.Getenv("ZTDP_AUDIT_DIR"); v != "" {
+		c.Audit.Dir = v
+	}
+	if v := os.Getenv("ZTDP_DECISION_LOGS_URL"); v != "" {
+		c.DecisionLogs.URL = v
+	}
+	if v := os.Getenv("ZTDP_NATS_URL"); v != "" {
+		// Setting a NATS URL has always implied the NATS transport
+		c.Events.NATSURL = v
+		c.Events.Transport = EventTransportNATS
+	}
+	return nil
+}
+
+// Validate checks the configuration and reports every problem found
+func (c *Config) Validate() error {
+	var problems []string
+
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || port > 65535 {
+		problems = append(problems, fmt.Sprintf("server.port: %q is not a valid port", c.Server.Port))
+	}
+	if _, err := logging.ParseLevel(c.Server.LogLevel); err != nil {
+		problems = append(problems, fmt.Sprintf("server.log_level: %v", err))
+	}
+	if _, err := logging.ParseFormat(c.Server.LogFormat); err != nil {
+		problems = append(problems, fmt.Sprintf("server.log_format: %v", err))
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		problems = append(problems, "server.shutdown_timeout: must be positive")
+	}
+
+	switch c.Graph.Backend {
+	case GraphBackendMemory:
+	case GraphBackendRedis:
+		if c.Graph.Redis.Addr == "" {
+			problems = append(problems, "graph.redis.addr: required when graph.backend is redis (or set REDIS_HOST)")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("graph.backend: %q is not supported (expected memory or redis)", c.Graph.Backend))
+	}
+
+	if c.AI.Provider != AIProviderOpenAI {
+		problems = append(problems, fmt.Sprintf("ai.provider: %q is not supported (expected openai)", c.AI.Provider))
+	}
+	if c.AI.Model == "" {
+		problems = append(problems, "ai.model: must not be empty")
+	}
+	if c.AI.Timeout <= 0 {
+		problems = append(problems, "ai.timeout: must be positive")
+	}
+	for task := range c.AI.Models {
+		if !isAITask(task) {
+			problems = append(problems, fmt.Sprintf("ai.models.%s: unknown task (expected %s)", task, strings.Join(AITasks, ", ")))
+		}
+	}
+	switch c.AI.Embeddings.Provider {
+	case "", EmbeddingProviderOpenAI:
+	case EmbeddingProviderLocal:
+		if c.AI.Embeddings.URL == "" {
+			problems = append(problems, "ai.embeddings.url: required when ai.embeddings.provider is local (or set ZTDP_EMBEDDINGS_URL)")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("ai.embeddings.provider: %q is not supported (expected openai or local)", c.AI.Embeddings.Provider))
+	}
+	switch c.AI.Tokenizer.Type {
+	case TokenizerBPE:
+		if c.AI.Tokenizer.Encoding == "" {
+			problems = append(problems, "ai.tokenizer.encoding: required when ai.tokenizer.type is bpe")
+		}
+	case TokenizerEstimate:
+	default:
+		problems = append(problems, fmt.Sprintf("ai.tokenizer.type: %q is not supported (expected bpe or estimate)", c.AI.Tokenizer.Type))
+	}
+	if c.AI.Embeddings.BatchSize <= 0 {
+		problems = append(problems, "ai.embeddings.batch_size: must be positive")
+	}
+	if c.AI.Embeddings.CacheSize < 0 {
+		problems = append(problems, "ai.embeddings.cache_size: must not be negative")
+	}
+	if c.AI.PromptLogging.SampleRate < 0 || c.AI.PromptLogging.SampleRate > 1 {
+		problems = append(problems, "ai.prompt_logging.sample_rate: must be between 0 and 1")
+	}
+	if c.AI.PromptLogging.Enabled && c.AI.PromptLogging.Capacity <= 0 {
+		problems = append(problems, "ai.prompt_logging.capacity: must be positive")
+	}
+	if c.AI.PromptLogging.Retention < 0 {
+		problems = append(problems, "ai.prompt_logging.retention: must not be negative")
+	}
+
+	switch c.Events.Transport {
+	case EventTransportMemory:
+	case EventTransportNATS:
+		if c.Events.NATSURL == "" {
+			problems = append(problems, "events.nats_url: required when events.transport is nats (or set ZTDP_NATS_URL)")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("events.transport: %q is not supported (expected memory or nats)", c.Events.Transport))
+	}
+	switch c.Events.DedupStore {
+	case "", DedupStoreMemory:
+	case DedupStoreRedis:
+		if c.Graph.Redis.Addr == "" {
+			problems = append(problems, "events.dedup_store: redis requires graph.redis.addr (or set REDIS_HOST)")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("events.dedup_store: %q is not supported (expected memory or redis)", c.Events.DedupStore))
+	}
+	if len(c.Events.Encryption.Subjects) > 0 && c.Events.Encryption.KeyFile == "" {
+		problems = append(problems, "events.encryption.key_file: required when events.encryption.subjects is set (or set ZTDP_EVENTS_KEY_FILE)")
+	}
+	if c.Events.Encryption.ReloadInterval <= 0 {
+		problems = append(problems, "events.encryption.reload_interval: must be positive")
+	}
+	if c.Events.DedupTTL < 0 {
+		problems = append(problems, "events.dedup_ttl: must not be negative")
+	}
+
+	switch c.Logs.Store {
+	case LogStoreMemory:
+	case LogStoreRedis:
+		if c.Graph.Redis.Addr == "" {
+			problems = append(problems, "logs.store: redis requires graph.redis.addr (or set REDIS_HOST)")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("logs.store: %q is not supported (expected memory or redis)", c.Logs.Store))
+	}
+	if c.Logs.Capacity <= 0 {
+		problems = append(problems, "logs.capacity: must be positive")
+	}
+
+	if c.Bootstrap.Dir != "" {
+		if info, err := os.Stat(c.Bootstrap.Dir); err != nil || !info.IsDir() {
+			problems = append(problems, fmt.Sprintf("bootstrap.dir: %q is not a readable directory", c.Bootstrap.Dir))
+		}
+	}
+	if c.Templates.Dir != "" {
+		if info, err := os.Stat(c.Templates.Dir); err != nil || !info.IsDir() {
+			problems = append(problems, fmt.Sprintf("templates.dir: %q is not a readable directory", c.Templates.Dir))
+		}
+	}
+
+	if c.Resources.PluginDir != "" {
+		if info, err := os.Stat(c.Resources.PluginDir); err != nil || !info.IsDir() {
+			problems = append(problems, fmt.Sprintf("resources.plugin_dir: %q is not a readable directory", c.Resources.PluginDir))
+		}
+	}
+
+	for name, rule := range c.resourceNamingRules() {
+		if _, err := template.New(name).Parse(rule.Template); err != nil {
+			problems = append(problems, fmt.Sprintf("%s.template: %v", name, err))
+		}
+		if rule.MaxLength < 0 {
+			problems = append(problems, fmt.Sprintf("%s.max_length: must not be negative", name))
+		}
+		if rule.Charset != "" {
+			if _, err := regexp.Compile("[" + rule.Charset + "]"); err != nil {
+				problems = append(problems, fmt.Sprintf("%s.charset: %v", name, err))
+			}
+		}
+	}
+
+	if c.Conversations.Retention < 0 {
+		problems = append(problems, "conversations.retention: must not be negative")
+	}
+	switch c.Conversations.Store {
+	case ConversationStoreGraph:
+	case ConversationStoreRedis:
+		if c.Graph.Redis.Addr == "" {
+			problems = append(problems, "conversations.store: redis requires graph.redis.addr (or set REDIS_HOST)")
+		}
+	case ConversationStorePostgres:
+		if c.Conversations.PostgresURL == "" {
+			problems = append(problems, "conversations.store: postgres requires conversations.postgres_url (or set ZTDP_CONVERSATION_POSTGRES_URL)")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("conversations.store: %q is not supported (expected graph, redis or postgres)", c.Conversations.Store))
+	}
+	if archive := c.Conversations.Archive; archive.Dir != "" || archive.URL != "" {
+		if archive.Dir != "" && archive.URL != "" {
+			problems = append(problems, "conversations.archive: set dir or url, not both")
+		}
+		if archive.After <= 0 {
+			problems = append(problems, "conversations.archive.after: must be positive")
+		} else if c.Conversations.Retention > 0 && archive.After >= c.Conversations.Retention {
+			problems = append(problems, "conversations.archive.after: must be shorter than conversations.retention or nothing is archived")
+		}
+		if archive.BatchSize < 1 {
+			problems = append(problems, "conversations.archive.batch_size: must be positive")
+		}
+	}
+
+	for name, pattern := range c.Redaction.Patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			problems = append(problems, fmt.Sprintf("redaction.patterns.%s: %v", name, err))
+		}
+	}
+
+	for kind, pattern := range c.Guardrails.Naming {
+		if _, err := regexp.Compile(pattern); err != nil {
+			problems = append(problems, fmt.Sprintf("guardrails.naming.%s: %v", kind, err))
+		}
+	}
+	if c.Guardrails.MaxDeletes < 0 {
+		problems = append(problems, "guardrails.max_deletes: must not be negative")
+	}
+	if c.Guardrails.MaxTargets < 0 {
+		problems = append(problems, "guardrails.max_targets: must not be negative")
+	}
+	switch c.Guardrails.DefaultAutonomy {
+	case "observe", "suggest", "execute-with-approval", "full-auto":
+	default:
+		problems = append(problems, fmt.Sprintf("guardrails.default_autonomy: unknown level %q (use observe, suggest, execute-with-approval or full-auto)", c.Guardrails.DefaultAutonomy))
+	}
+
+	if c.Analytics.Enabled && c.Analytics.Capacity <= 0 {
+		problems = append(problems, "analytics.capacity: must be positive")
+	}
+
+	if c.Vulnerabilities.ScannerURL != "" {
+		if u, err := url.Parse(c.Vulnerabilities.ScannerURL); err != nil || u.Scheme == "" || u.Host == "" {
+			problems = append(problems, fmt.Sprintf("vulnerabilities.scanner_url: %q is not a valid URL", c.Vulnerabilities.ScannerURL))
+		}
+	}
+	if c.Vulnerabilities.MaxCritical < 0 {
+		problems = append(problems, "vulnerabilities.max_critical: must not be negative")
+	}
+	for _, environment := range c.Migrations.RequireReversible {
+		if strings.TrimSpace(environment) == "" {
+			problems = append(problems, "migrations.require_reversible: environment names must not be empty")
+			break
+		}
+	}
+
+	if c.Backup.Dir != "" && c.Backup.URL != "" {
+		problems = append(problems, "backup: set dir or url, not both")
+	}
+	if c.Backup.Interval < 0 || c.Backup.Keep < 0 || c.Backup.MaxAge < 0 {
+		problems = append(problems, "backup: interval, keep and max_age must not be negative")
+	}
+	if c.Backup.Interval > 0 && c.Backup.Dir == "" && c.Backup.URL == "" {
+		problems = append(problems, "backup.interval: scheduled backups need a dir or url")
+	}
+	if c.Handoff.Enabled && c.Handoff.WatchInterval <= 0 {
+		problems = append(problems, "handoff.watch_interval: must be positive")
+	}
+	if c.Cluster.Enabled && c.Graph.Redis.Addr == "" {
+		problems = append(problems, "cluster.enabled: leader election requires graph.redis.addr (or set REDIS_HOST)")
+	}
+	if c.Cluster.LeaseTTL < time.Second {
+		problems = append(problems, "cluster.lease_ttl: must be at least 1s")
+	}
+	if c.Cluster.AgentTTL < time.Second {
+		problems = append(problems, "cluster.agent_ttl: must be at least 1s")
+	}
+	if c.Clarification.Threshold < 0 || c.Clarification.Threshold > 1 {
+		problems = append(problems, "clarification.threshold: must be between 0 and 1")
+	}
+	for capability, threshold := range c.Clarification.Capabilities {
+		if threshold < 0 || threshold > 1 {
+			problems = append(problems, fmt.Sprintf("clarification.capabilities.%s: must be between 0 and 1", capability))
+		}
+	}
+	switch c.Arbitration.Policy {
+	case "confidence", "cost", "eta":
+	default:
+		problems = append(problems, fmt.Sprintf("arbitration.policy: unknown policy %q (use confidence, cost or eta)", c.Arbitration.Policy))
+	}
+	if c.Arbitration.BidTimeout <= 0 {
+		problems = append(problems, "arbitration.bid_timeout: must be positive")
+	}
+	if c.Arbitration.MinConfidence < 0 || c.Arbitration.MinConfidence > 1 {
+		problems = append(problems, "arbitration.min_confidence: must be between 0 and 1")
+	}
+	for environment, soak := range c.Promotion.Soak {
+		if soak.After == "" || soak.After == environment {
+			problems = append(problems, fmt.Sprintf("promotion.soak.%s.after: must name another environment", environment))
+		}
+		if soak.Duration <= 0 {
+			problems = append(problems, fmt.Sprintf("promotion.soak.%s.duration: must be positive", environment))
+		}
+	}
+	if c.Recording.Enabled && c.Recording.MaxWindow <= 0 {
+		problems = append(problems, "recording.max_window: must be positive")
+	}
+	if c.GraphStats.Interval <= 0 {
+		problems = append(problems, "graph_stats.interval: must be positive")
+	}
+	if c.GraphStats.Window < c.GraphStats.Interval {
+		problems = append(problems, "graph_stats.window: must be at least the interval")
+	}
+	if c.GraphStats.GrowthAlert < 0 {
+		problems = append(problems, "graph_stats.growth_alert: must not be negative")
+	}
+	if c.PolicyCache.Enabled && c.PolicyCache.TTL <= 0 {
+		problems = append(problems, "policy_cache.ttl: must be positive")
+	}
+	for _, webhook := range c.Maintenance.Webhooks {
+		if u, err := url.Parse(webhook); err != nil || u.Scheme == "" || u.Host == "" {
+			problems = append(problems, fmt.Sprintf("maintenance.webhooks: %q is not a valid URL", webhook))
+		}
+	}
+	if c.Maintenance.SlackURL != "" {
+		if u, err := url.Parse(c.Maintenance.SlackURL); err != nil || u.Scheme == "" || u.Host == "" {
+			problems = append(problems, "maintenance.slack_url: not a valid URL")
+		}
+	}
+	if (len(c.Maintenance.Webhooks) > 0 || c.Maintenance.SlackURL != "") && c.Maintenance.Timeout <= 0 {
+		problems = append(problems, "maintenance.timeout: must be positive")
+	}
+	if c.Audit.Enabled && c.Audit.Capacity <= 0 {
+		problems = append(problems, "audit.capacity: must be positive")
+	}
+	if c.Audit.Retention < 0 {
+		problems = append(problems, "audit.retention: must not be negative")
+	}
+	if c.DecisionLogs.Enabled {
+		if c.DecisionLogs.Capacity <= 0 {
+			problems = append(problems, "decision_logs.capacity: must be positive")
+		}
+		if c.DecisionLogs.BatchSize <= 0 {
+			problems = append(problems, "decision_logs.batch_size: must be positive")
+		}
+		if c.DecisionLogs.FlushInterval <= 0 {
+			problems = append(problems, "decision_logs.flush_interval: must be positive")
+		}
+		if c.DecisionLogs.URL != "" {
+			if u, err := url.Parse(c.DecisionLogs.URL); err != nil || u.Scheme == "" || u.Host == "" {
+				problems = append(problems, "decision_logs.url: not a valid URL")
+			}
+			if c.DecisionLogs.Timeout <= 0 {
+				problems = append(problems, "decision_logs.timeout: must be positive")
+			}
+		}
+	}
+	if c.Workflows.Enabled && c.Workflows.TickInterval <= 0 {
+		problems = append(problems, "workflows.tick_interval: must be positive")
+	}
+	if c.Governance.Enabled {
+		if len(c.Governance.ProtectedEnvironments) == 0 {
+			problems = append(problems, "governance.protected_environments: at least one environment is required")
+		}
+		for _, environment := range c.Governance.ProtectedEnvironments {
+			if strings.TrimSpace(environment) == "" {
+				problems = append(problems, "governance.protected_environments: environment names must not be empty")
+				break
+			}
+		}
+		if c.Governance.DecisionTTL < 0 {
+			problems = append(problems, "governance.decision_ttl: must not be negative")
+		}
+	}
+	if c.AgentSLA.BreachThreshold < 1 {
+		problems = append(problems, "agent_sla.breach_threshold: must be at least 1")
+	}
+	if c.Degradation.Enabled && c.Degradation.Interval <= 0 {
+		problems = append(problems, "degradation.interval: must be positive")
+	}
+	if c.Provenance.Enabled && c.Provenance.Capacity <= 0 {
+		problems = append(problems, "provenance.capacity: must be positive")
+	}
+	if c.Provenance.Enabled {
+		switch c.Provenance.Store {
+		case ProvenanceStoreMemory:
+		case ProvenanceStoreRedis:
+			if c.Graph.Redis.Addr == "" {
+				problems = append(problems, "provenance.store: redis requires graph.redis.addr (or set REDIS_HOST)")
+			}
+		default:
+			problems = append(problems, fmt.Sprintf("provenance.store: %q is not supported (expected memory or redis)", c.Provenance.Store))
+		}
+	}
+	for instance, key := range c.Provenance.TrustedKeys {
+		if raw, err := base64.StdEncoding.DecodeString(key); err != nil || len(raw) != 32 {
+			problems = append(problems, fmt.Sprintf("provenance.trusted_keys.%s: expected a base64 ed25519 public key", instance))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+// resourceNamingRules returns the resource naming rules by their config path
+func (c *Config) resourceNamingRules() map[string]ResourceNamingRule {
+	rules := map[string]ResourceNamingRule{"resources.naming": c.Resources.Naming.ResourceNamingRule}
+	for provider, rule := range c.Resources.Naming.Providers {
+		rules["resources.naming.providers."+provider] = rule
+	}
+	return rules
+}
+
+// Format returns the parsed server log format
+func (c *Config) Format() logging.LogFormat {
+	format, _ := logging.ParseFormat(c.Server.LogFormat)
+	return format
+}
+
+// Level returns the parsed server log level
+func (c *Config) Level() logging.LogLevel {
+	level, _ := logging.ParseLevel(c.Server.LogLevel)
+	return level
+}
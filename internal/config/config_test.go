@@ -0,0 +1,208 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ztdp.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad_DefaultsWithoutFile(t *testing.T) {
+	cfg, err := Load("")
+	require.NoError(t, err)
+
+	assert.Equal(t, "8080", cfg.Server.Port)
+	assert.Equal(t, GraphBackendMemory, cfg.Graph.Backend)
+	assert.Equal(t, EventTransportMemory, cfg.Events.Transport)
+	assert.Equal(t, 90*time.Second, cfg.AI.Timeout)
+	assert.Equal(t, TokenizerBPE, cfg.AI.Tokenizer.Type)
+}
+
+func TestLoad_FileWithEnvOverrides(t *testing.T) {
+	path := writeConfigFile(t, `
+server:
+  port: "9090"
+  log_level: warn
+graph:
+  backend: redis
+  redis:
+    addr: redis:6379
+ai:
+  model: gpt-4o
+  timeout: 30s
+`)
+	t.Setenv("OPENAI_MODEL", "gpt-4.1")
+	t.Setenv("ZTDP_NATS_URL", "nats://localhost:4222")
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, "9090", cfg.Server.Port)
+	assert.Equal(t, "warn", cfg.Server.LogLevel)
+	assert.Equal(t, "redis:6379", cfg.Graph.Redis.Addr)
+	assert.Equal(t, 30*time.Second, cfg.AI.Timeout)
+	assert.Equal(t, "gpt-4.1", cfg.AI.Model, "environment should override the file")
+	assert.Equal(t, EventTransportNATS, cfg.Events.Transport)
+}
+
+func TestLoad_ValidationReportsAllProblems(t *testing.T) {
+	path := writeConfigFile(t, `
+server:
+  port: "not-a-port"
+  log_level: loud
+graph:
+  backend: redis
+ai:
+  models:
+    summarizing: gpt-4o
+  embeddings:
+    provider: local
+  prompt_logging:
+    sample_rate: 2
+  tokenizer:
+    type: sentencepiece
+events:
+  transport: kafka
+  dedup_store: etcd
+  encryption:
+    subjects: ["resource.*"]
+conversations:
+  retention: -1h
+  store: postgres
+  archive:
+    dir: /var/lib/ztdp/archive
+    url: https://storage.example.com/ztdp-archive
+redaction:
+  patterns:
+    broken: "("
+guardrails:
+  max_deletes: -1
+  default_autonomy: reckless
+vulnerabilities:
+  max_critical: -1
+promotion:
+  soak:
+    prod:
+      after: staging
+migrations:
+  require_reversible: [""]
+provenance:
+  store: postgres
+  trusted_keys:
+    other: not-a-key
+backup:
+  interval: 1h
+cluster:
+  enabled: true
+  agent_ttl: 0s
+clarification:
+  threshold: 1.5
+  capabilities:
+    deployment_orchestration: -0.1
+arbitration:
+  policy: random
+  bid_timeout: 0s
+  min_confidence: 2
+recording:
+  enabled: true
+  max_window: 0s
+graph_stats:
+  growth_alert: -1
+policy_cache:
+  ttl: -1m
+maintenance:
+  webhooks: ["not-a-url"]
+audit:
+  retention: -24h
+decision_logs:
+  batch_size: 0
+workflows:
+  tick_interval: 0s
+governance:
+  protected_environments: [""]
+  decision_ttl: -1m
+agent_sla:
+  breach_threshold: 0
+degradation:
+  interval: 0s
+resources:
+  naming:
+    providers:
+      s3:
+        charset: "z-a"
+`)
+
+	_, err := Load(path)
+	require.Error(t, err)
+	for _, field := range []string{"server.port", "server.log_level", "graph.redis.addr", "ai.models.summarizing", "ai.embeddings.url", "ai.prompt_logging.sample_rate", "ai.tokenizer.type", "events.transport", "events.dedup_store", "events.encryption.key_file", "conversations.retention", "conversations.store", "conversations.archive", "redaction.patterns.broken", "guardrails.max_deletes", "vulnerabilities.max_critical", "promotion.soak.prod.duration", "migrations.require_reversible", "provenance.store", "provenance.trusted_keys.other", "backup.interval", "cluster.enabled", "cluster.agent_ttl", "clarification.threshold", "clarification.capabilities.deployment_orchestration", "arbitration.policy", "arbitration.bid_timeout", "arbitration.min_confidence", "recording.max_window", "resources.naming.providers.s3.charset", "graph_stats.growth_alert", "policy_cache.ttl", "maintenance.webhooks", "audit.retention", "decision_logs.batch_size", "workflows.tick_interval", "governance.protected_environments", "governance.decision_ttl", "agent_sla.breach_threshold", "degradation.interval", "guardrails.default_autonomy"} {
+		assert.Contains(t, err.Error(), field)
+	}
+	assert.Contains(t, err.Error(), "postgres requires conversations.postgres_url")
+}
+
+func TestLoad_TOMLFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ztdp.toml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+[server]
+port = "9090"
+log_level = "debug"
+
+[ai]
+timeout = "45s"
+`), 0o600))
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "9090", cfg.Server.Port)
+	assert.Equal(t, "debug", cfg.Server.LogLevel)
+	assert.Equal(t, 45*time.Second, cfg.AI.Timeout)
+	assert.Equal(t, Default().Graph.Backend, cfg.Graph.Backend, "omitted values keep their defaults")
+
+	require.NoError(t, os.WriteFile(path, []byte("[server]\nprot = \"8080\"\n"), 0o600))
+	_, err = Load(path)
+	assert.ErrorContains(t, err, "prot", "unknown fields are rejected as in YAML files")
+	require.NoError(t, os.WriteFile(path, []byte("[server\n"), 0o600))
+	_, err = Load(path)
+	assert.Error(t, err)
+}
+
+func TestLoad_RejectsUnknownFieldsAndFormats(t *testing.T) {
+	_, err := Load(writeConfigFile(t, "server:\n  prot: \"8080\"\n"))
+	assert.Error(t, err)
+
+	_, err = Load(filepath.Join(t.TempDir(), "ztdp.ini"))
+	require.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "unsupported format"))
+}
+
+func TestWatcher_ReloadsHotFieldsOnly(t *testing.T) {
+	path := writeConfigFile(t, "server:\n  log_level: info\n  port: \"8080\"\nai:\n  model: gpt-4o-mini\n")
+	cfg, err := Load(path)
+	require.NoError(t, err)
+
+	watcher := NewWatcher(path, cfg, time.Hour)
+	var reloaded *Config
+	watcher.OnReload(func(old, updated *Config) { reloaded = updated })
+
+	require.NoError(t, os.WriteFile(path, []byte("server:\n  log_level: debug\n  port: \"9999\"\nai:\n  model: gpt-4o\n  prompt_logging:\n    sample_rate: 0.5\n"), 0o600))
+	require.NoError(t, os.Chtimes(path, time.Now().Add(time.Minute), time.Now().Add(time.Minute)))
+	watcher.check()
+
+	require.NotNil(t, reloaded)
+	assert.Equal(t, "debug", reloaded.Server.LogLevel)
+	assert.Equal(t, "gpt-4o", reloaded.AI.Model)
+	assert.Equal(t, 0.5, reloaded.AI.PromptLogging.SampleRate)
+	assert.Equal(t, "8080", reloaded.Server.Port, "port requires a restart")
+	assert.Equal(t, reloaded, watcher.Current())
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// ReloadFunc is called after the config file changed and the new configuration validated.
+// Only hot-reloadable fields (server.log_level, ai.model, ai.models, ai.prompt_logging.sample_rate)
+// differ between old and updated;
+// every other field keeps its startup value until the process restarts.
+type ReloadFunc func(old, updated *Config)
+
+// Watcher polls a config file and applies hot-reloadable changes
+type Watcher struct {
+	path     string
+	interval time.Duration
+	logger   *logging.Logger
+
+	mu       sync.RWMutex
+	current  *Config
+	modTime  time.Time
+	handlers []ReloadFunc
+}
+
+// NewWatcher creates a watcher for the config file at path, starting from the already loaded configuration
+func NewWatcher(path string, current *Config, interval time.Duration) *Watcher {
+	w := &Watcher{
+		path:     path,
+		interval: interval,
+		logger:   logging.GetLogger().ForComponent("config"),
+		current:  current,
+	}
+	if info, err := os.Stat(path); err == nil {
+		w.modTime = info.ModTime()
+	}
+	return w
+}
+
+// OnReload registers a handler invoked after each successful reload
+func (w *Watcher) OnReload(fn ReloadFunc) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.handlers = append(w.handlers, fn)
+}
+
+// Current returns the configuration currently in effect
+func (w *Watcher) Current() *Config {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.current
+}
+
+// Start polls the config file until the context is cancelled
+func (w *Watcher) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				w.check()
+			}
+		}
+	}()
+}
+
+// check reloads the file when its modification time changed
+func (w *Watcher) check() {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return
+	}
+
+	w.mu.RLock()
+	unchanged := info.ModTime().Equal(w.modTime)
+	w.mu.RUnlock()
+	if unchanged {
+		return
+	}
+
+	loaded, err := Load(w.path)
+
+	w.mu.Lock()
+	w.modTime = info.ModTime()
+	if err != nil {
+		w.mu.Unlock()
+		w.logger.Warn("⚠️ Ignoring config reload, keeping previous configuration: %v", err)
+		return
+	}
+
+	old := w.current
+	updated := *old
+	updated.Server.LogLevel = loaded.Server.LogLevel
+	updated.AI.Model = loaded.AI.Model
+	updated.AI.Models = loaded.AI.Models
+	updated.AI.PromptLogging.SampleRate = loaded.AI.PromptLogging.SampleRate
+
+	loaded.Server.LogLevel = old.Server.LogLevel
+	loaded.AI.Model = old.AI.Model
+	loaded.AI.Models = old.AI.Models
+	loaded.AI.PromptLogging.SampleRate = old.AI.PromptLogging.SampleRate
+	if !reflect.DeepEqual(*loaded, *old) {
+		w.logger.Warn("⚠️ Config file changed fields that require a restart; only log level, AI models and the prompt sample rate are reloaded")
+	}
+
+	w.current = &updated
+	handlers := append([]ReloadFunc(nil), w.handlers...)
+	w.mu.Unlock()
+
+	if reflect.DeepEqual(updated, *old) {
+		return
+	}
+
+	w.logger.Info("🔄 Configuration reloaded (log level: %s, AI model: %s)", updated.Server.LogLevel, updated.AI.Model)
+	for _, handler := range handlers {
+		handler(old, &updated)
+	}
+}
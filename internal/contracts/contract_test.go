@@ -1,6 +1,7 @@
 package contracts
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -54,6 +55,21 @@ func TestEnvironmentContract_Validate(t *testing.T) {
 	if err := env.Validate(); err != nil {
 		t.Errorf("expected valid environment contract, got error: %v", err)
 	}
+
+	env.Spec.Regions = []EnvironmentRegion{{Name: "eu-west", Clusters: []string{"eu-west-a"}}, {Name: "us-east"}}
+	if err := env.Validate(); err != nil {
+		t.Errorf("expected regions to be accepted, got error: %v", err)
+	}
+	if region, ok := env.Spec.Region("eu-west"); !ok || region.HasCluster("eu-west-b") {
+		t.Errorf("expected eu-west to only have cluster eu-west-a, got %+v", region)
+	}
+	if region, _ := env.Spec.Region("us-east"); !region.HasCluster("us-east-a") {
+		t.Errorf("expected a region without clusters to accept any cluster")
+	}
+	env.Spec.Regions = append(env.Spec.Regions, EnvironmentRegion{Name: "us-east"})
+	if err := env.Validate(); err == nil {
+		t.Errorf("expected a duplicate region to be rejected")
+	}
 }
 
 func TestMetadataFields(t *testing.T) {
@@ -154,3 +170,135 @@ func TestServiceVersionContract(t *testing.T) {
 		t.Error("expected error for missing version, got nil")
 	}
 }
+
+func TestServiceSpec_ForEnvironment(t *testing.T) {
+	replicas := 5
+	spec := ServiceSpec{
+		Port:     8080,
+		Replicas: 1,
+		Tier:     "small",
+		Env:      map[string]string{"LOG_LEVEL": "debug", "REGION": "eu"},
+		Overrides: map[string]ServiceOverride{
+			"prod": {Replicas: &replicas, Tier: "large", Env: map[string]string{"LOG_LEVEL": "info"}},
+		},
+	}
+
+	prod := spec.ForEnvironment("prod")
+	if prod.Replicas != 5 || prod.Tier != "large" || prod.Port != 8080 {
+		t.Errorf("unexpected prod config: %+v", prod)
+	}
+	if prod.Env["LOG_LEVEL"] != "info" || prod.Env["REGION"] != "eu" {
+		t.Errorf("expected env vars merged with override winning, got %v", prod.Env)
+	}
+	if prod.Overrides != nil {
+		t.Errorf("merged spec should not carry overrides")
+	}
+	if spec.Env["LOG_LEVEL"] != "debug" {
+		t.Errorf("ForEnvironment must not modify the base spec")
+	}
+
+	dev := spec.ForEnvironment("dev")
+	if dev.Replicas != 1 || dev.Tier != "small" {
+		t.Errorf("expected base config without an override, got %+v", dev)
+	}
+
+	negative := -1
+	if err := (ServiceOverride{Replicas: &negative}).Validate(); err == nil {
+		t.Errorf("expected negative replicas to be rejected")
+	}
+	if err := (ServiceOverride{Env: map[string]string{"1BAD": "x"}}).Validate(); err == nil {
+		t.Errorf("expected invalid env var name to be rejected")
+	}
+}
+
+func TestEnvironmentConstraints_Check(t *testing.T) {
+	constraints := EnvironmentConstraints{MinReplicas: 2, MaxReplicas: 10, AllowedTiers: []string{"medium", "large"}}
+
+	if err := constraints.Check(ServiceSpec{Replicas: 3, Tier: "large"}); err != nil {
+		t.Errorf("expected config within constraints to pass, got %v", err)
+	}
+	err := constraints.Check(ServiceSpec{Replicas: 1, Tier: "small"})
+	if err == nil {
+		t.Fatal("expected constraint violations")
+	}
+	if !strings.Contains(err.Error(), "replicas 1 below minimum 2") || !strings.Contains(err.Error(), "small") {
+		t.Errorf("expected every violation to be reported, got %v", err)
+	}
+
+	env := EnvironmentContract{
+		Metadata: Metadata{Name: "prod", Owner: "platform-team"},
+		Spec:     EnvironmentSpec{Constraints: EnvironmentConstraints{MinReplicas: 5, MaxReplicas: 2}},
+	}
+	if err := env.Validate(); err == nil {
+		t.Errorf("expected min above max to be rejected")
+	}
+}
+
+func TestAutoscalingSpec(t *testing.T) {
+	scaling := &AutoscalingSpec{MinReplicas: 2, MaxReplicas: 10, TargetCPU: 70, Metrics: []CustomMetric{{Name: "requests_per_second", Target: 100}}}
+	if err := scaling.Validate(); err != nil {
+		t.Errorf("expected valid autoscaling, got %v", err)
+	}
+	if got := scaling.String(); got != "2-10 replicas at 70% cpu, 100 requests_per_second" {
+		t.Errorf("unexpected description %q", got)
+	}
+	for _, invalid := range []AutoscalingSpec{
+		{MinReplicas: 0, MaxReplicas: 3, TargetCPU: 50},
+		{MinReplicas: 4, MaxReplicas: 3, TargetCPU: 50},
+		{MinReplicas: 1, MaxReplicas: 3, TargetCPU: 150},
+		{MinReplicas: 1, MaxReplicas: 3},
+	} {
+		if err := invalid.Validate(); err == nil {
+			t.Errorf("expected %+v to be rejected", invalid)
+		}
+	}
+
+	spec := ServiceSpec{Replicas: 1, Overrides: map[string]ServiceOverride{"prod": {Autoscaling: scaling}}}
+	if spec.ForEnvironment("prod").Autoscaling != scaling || spec.ForEnvironment("dev").Autoscaling != nil {
+		t.Errorf("expected the prod override to add autoscaling")
+	}
+
+	constraints := EnvironmentConstraints{MinReplicas: 3, MaxReplicas: 8, MaxTargetCPU: 60, RequireAutoscaling: true}
+	err := constraints.Check(spec.ForEnvironment("prod"))
+	if err == nil || !strings.Contains(err.Error(), "min_replicas 2 below minimum 3") || !strings.Contains(err.Error(), "max_replicas 10 above maximum 8") || !strings.Contains(err.Error(), "target_cpu 70% above maximum 60%") {
+		t.Errorf("expected autoscaling bounds checked against the constraints, got %v", err)
+	}
+	if err := constraints.Check(ServiceSpec{Replicas: 4}); err == nil || !strings.Contains(err.Error(), "autoscaling is required") {
+		t.Errorf("expected fixed replicas to be rejected, got %v", err)
+	}
+}
+
+func TestServiceSpec_ConfigKeys(t *testing.T) {
+	spec := ServiceSpec{
+		Config: []ConfigKey{
+			{Name: "DATABASE_URL", From: "checkout-pg-db.connection_string", Required: true},
+			{Name: "LOG_LEVEL", Default: "info"},
+		},
+		Overrides: map[string]ServiceOverride{
+			"prod": {Config: []ConfigKey{{Name: "DATABASE_URL", From: "checkout-pg-replica.connection_string"}}},
+		},
+	}
+
+	prod := spec.ForEnvironment("prod")
+	if len(prod.Config) != 2 || prod.Config[0].From != "checkout-pg-replica.connection_string" || prod.Config[1].Name != "LOG_LEVEL" {
+		t.Errorf("expected the override to replace the base key of the same name, got %+v", prod.Config)
+	}
+	if spec.Config[0].From != "checkout-pg-db.connection_string" {
+		t.Errorf("ForEnvironment must not modify the base keys")
+	}
+
+	resource, field, ok := ConfigKey{From: "pg.v2.connection_string"}.Binding()
+	if !ok || resource != "pg.v2" || field != "connection_string" {
+		t.Errorf("unexpected binding %q %q %v", resource, field, ok)
+	}
+
+	for _, invalid := range [][]ConfigKey{
+		{{Name: "1BAD"}},
+		{{Name: "DATABASE_URL", From: "checkout-pg-db"}},
+		{{Name: "DATABASE_URL"}, {Name: "DATABASE_URL"}},
+	} {
+		if err := validateConfigKeys(invalid); err == nil {
+			t.Errorf("expected %+v to be rejected", invalid)
+		}
+	}
+}
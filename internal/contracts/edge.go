@@ -2,6 +2,7 @@ package contracts
 
 import (
 	"fmt"
+	"sync"
 )
 
 // EdgeContract represents an edge in the graph with validation rules
@@ -32,7 +33,7 @@ var EdgeValidationRules = []EdgeValidationRule{
 	{
 		FromKind:     "application",
 		ToKind:       "resource",
-		AllowedTypes: []string{"owns"},
+		AllowedTypes: []string{"owns", "accesses"}, // accesses grants use of a shared instance
 		SpecialRules: validateApplicationToResource,
 	},
 	{
@@ -43,9 +44,14 @@ var EdgeValidationRules = []EdgeValidationRule{
 	{
 		FromKind:     "service",
 		ToKind:       "resource",
-		AllowedTypes: []string{"uses"},
+		AllowedTypes: []string{"uses", "accesses"},
 		SpecialRules: validateServiceToResource,
 	},
+	{
+		FromKind:     "service",
+		ToKind:       "service",
+		AllowedTypes: []string{"consumes"}, // Declared API dependencies between services
+	},
 	{
 		FromKind:     "resource",
 		ToKind:       "resource_type",
@@ -62,6 +68,16 @@ var EdgeValidationRules = []EdgeValidationRule{
 		ToKind:       "resource_type",
 		AllowedTypes: []string{"owns"},
 	},
+	{
+		FromKind:     "resource",
+		ToKind:       "topic",
+		AllowedTypes: []string{"owns"}, // topics and queues of messaging brokers
+	},
+	{
+		FromKind:     "service",
+		ToKind:       "topic",
+		AllowedTypes: []string{"produces", "consumes"}, // ACL bindings
+	},
 	{
 		FromKind:     "service",
 		ToKind:       "service_version",
@@ -72,6 +88,11 @@ var EdgeValidationRules = []EdgeValidationRule{
 		ToKind:       "environment",
 		AllowedTypes: []string{"deploy"},
 	},
+	{
+		FromKind:     "service_version",
+		ToKind:       "migration",
+		AllowedTypes: []string{"has_migration"}, // schema migrations the version ships
+	},
 	// Policy-related edge rules
 	{
 		FromKind:     "check",
@@ -104,6 +125,73 @@ var EdgeValidationRules = []EdgeValidationRule{
 		ToKind:       "resource_type",
 		AllowedTypes: []string{}, // Services should NOT directly use resource types
 	},
+	// Conversation transcripts reference the entities a chat touched
+	{
+		FromKind:     "conversation",
+		ToKind:       "application",
+		AllowedTypes: []string{"references"},
+	},
+	{
+		FromKind:     "conversation",
+		ToKind:       "service",
+		AllowedTypes: []string{"references"},
+	},
+	{
+		FromKind:     "conversation",
+		ToKind:       "environment",
+		AllowedTypes: []string{"references"},
+	},
+	{
+		FromKind:     "conversation",
+		ToKind:       "resource",
+		AllowedTypes: []string{"references"},
+	},
+	{
+		FromKind:     "conversation",
+		ToKind:       "policy",
+		AllowedTypes: []string{"references"},
+	},
+	// ML models: applications own models, models have versions and serving endpoints
+	{
+		FromKind:     "application",
+		ToKind:       "ml_model",
+		AllowedTypes: []string{"owns"},
+	},
+	{
+		FromKind:     "ml_model",
+		ToKind:       "model_version",
+		AllowedTypes: []string{"has_version"},
+	},
+	{
+		FromKind:     "ml_model",
+		ToKind:       "model_endpoint",
+		AllowedTypes: []string{"owns"},
+	},
+	{
+		FromKind:     "ml_model",
+		ToKind:       "resource",
+		AllowedTypes: []string{"uses", "accesses"}, // training data, feature stores
+	},
+	{
+		FromKind:     "model_endpoint",
+		ToKind:       "model_version",
+		AllowedTypes: []string{"serves"}, // versions deployed to at least one environment
+	},
+	{
+		FromKind:     "model_endpoint",
+		ToKind:       "resource",
+		AllowedTypes: []string{"uses", "accesses"},
+	},
+	{
+		FromKind:     "model_endpoint",
+		ToKind:       "environment",
+		AllowedTypes: []string{"deploy"},
+	},
+	{
+		FromKind:     "service",
+		ToKind:       "model_endpoint",
+		AllowedTypes: []string{"consumes"}, // services calling the inference endpoint
+	},
 	// Test node rules (for testing purposes)
 	{
 		FromKind:     "test",
@@ -113,17 +201,51 @@ var EdgeValidationRules = []EdgeValidationRule{
 	// Add more rules as needed
 }
 
-// Validate validates the edge according to platform policies
-func (e EdgeContract) Validate() error {
-	// Find applicable rule
-	var applicableRule *EdgeValidationRule
+var (
+	customRulesMu sync.RWMutex
+	customRules   = map[string][]EdgeValidationRule{} // by owner
+)
+
+// SetCustomEdgeRules replaces the edge rules registered by owner, such as a user-defined node
+// kind; nil removes them. Built-in rules take precedence over custom ones for the same kinds.
+func SetCustomEdgeRules(owner string, rules []EdgeValidationRule) {
+	customRulesMu.Lock()
+	defer customRulesMu.Unlock()
+	if len(rules) == 0 {
+		delete(customRules, owner)
+		return
+	}
+	customRules[owner] = rules
+}
+
+// findRule returns the rule for edges between two kinds, or nil when none allows any edge.
+// Custom rules of several owners for the same kinds allow the union of their types.
+func findRule(fromKind, toKind string) *EdgeValidationRule {
 	for _, rule := range EdgeValidationRules {
-		if rule.FromKind == e.FromKind && rule.ToKind == e.ToKind {
-			applicableRule = &rule
-			break
+		if rule.FromKind == fromKind && rule.ToKind == toKind {
+			return &rule
+		}
+	}
+	customRulesMu.RLock()
+	defer customRulesMu.RUnlock()
+	var merged *EdgeValidationRule
+	for _, rules := range customRules {
+		for _, rule := range rules {
+			if rule.FromKind != fromKind || rule.ToKind != toKind {
+				continue
+			}
+			if merged == nil {
+				merged = &EdgeValidationRule{FromKind: fromKind, ToKind: toKind}
+			}
+			merged.AllowedTypes = append(merged.AllowedTypes, rule.AllowedTypes...)
 		}
 	}
+	return merged
+}
 
+// Validate validates the edge according to platform policies
+func (e EdgeContract) Validate() error {
+	applicableRule := findRule(e.FromKind, e.ToKind)
 	if applicableRule == nil {
 		return fmt.Errorf("edge type '%s' not allowed from %s (%s) to %s (%s)",
 			e.Type, e.FromID, e.FromKind, e.ToID, e.ToKind)
@@ -168,7 +290,9 @@ func validateApplicationToResource(from, to interface{}) error {
 		return fmt.Errorf("resource node missing metadata")
 	}
 
-	if app, hasApp := metadata["application"]; !hasApp || app == nil {
+	// Shared instances belong to a team and are used by applications through access grants
+	shared, _ := metadata["shared"].(bool)
+	if app, hasApp := metadata["application"]; !shared && (!hasApp || app == nil) {
 		return fmt.Errorf("applications can only own resource instances, not catalog resources")
 	}
 
@@ -197,8 +321,9 @@ func validateResourceInstanceToResourceType(from, to interface{}) error {
 		return fmt.Errorf("from-resource node missing metadata")
 	}
 
-	// From node should be a resource instance (has application metadata)
-	if app, hasApp := fromMetadata["application"]; !hasApp || app == nil {
+	// From node should be a resource instance (has application metadata, or is shared)
+	shared, _ := fromMetadata["shared"].(bool)
+	if app, hasApp := fromMetadata["application"]; !shared && (!hasApp || app == nil) {
 		return fmt.Errorf("instance_of edges can only originate from resource instances")
 	}
 
@@ -1,6 +1,9 @@
 package contracts
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 type EnvironmentContract struct {
 	Metadata Metadata        `json:"metadata"`
@@ -8,7 +11,88 @@ type EnvironmentContract struct {
 }
 
 type EnvironmentSpec struct {
-	Description string `json:"description"`
+	Description string                 `json:"description"`
+	Constraints EnvironmentConstraints `json:"constraints,omitempty"`
+	// Regions the environment spans; multi-region deployments fan out to each of them
+	Regions []EnvironmentRegion `json:"regions,omitempty"`
+}
+
+// EnvironmentRegion is a region an environment runs in and the clusters it has there
+type EnvironmentRegion struct {
+	Name     string   `json:"name"`
+	Clusters []string `json:"clusters,omitempty"`
+}
+
+// Region returns the environment's region with the given name
+func (s EnvironmentSpec) Region(name string) (EnvironmentRegion, bool) {
+	for _, region := range s.Regions {
+		if region.Name == name {
+			return region, true
+		}
+	}
+	return EnvironmentRegion{}, false
+}
+
+// HasCluster reports whether the region has the cluster; regions that list no clusters accept any
+func (r EnvironmentRegion) HasCluster(cluster string) bool {
+	if len(r.Clusters) == 0 {
+		return true
+	}
+	for _, c := range r.Clusters {
+		if c == cluster {
+			return true
+		}
+	}
+	return false
+}
+
+// EnvironmentConstraints limit the configuration services may run with in an environment.
+// Zero values leave the corresponding setting unconstrained.
+type EnvironmentConstraints struct {
+	MinReplicas        int      `json:"min_replicas,omitempty"` // autoscaled services must not scale below it
+	MaxReplicas        int      `json:"max_replicas,omitempty"` // nor above it
+	AllowedTiers       []string `json:"allowed_tiers,omitempty"`
+	RequireAutoscaling bool     `json:"require_autoscaling,omitempty"`
+	MaxTargetCPU       int      `json:"max_target_cpu,omitempty"` // percent; lower targets keep headroom for spikes
+}
+
+// Check reports every way a service's effective spec violates the constraints
+func (c EnvironmentConstraints) Check(spec ServiceSpec) error {
+	var problems []string
+	if scaling := spec.Autoscaling; scaling != nil {
+		if c.MinReplicas > 0 && scaling.MinReplicas < c.MinReplicas {
+			problems = append(problems, fmt.Sprintf("autoscaling min_replicas %d below minimum %d", scaling.MinReplicas, c.MinReplicas))
+		}
+		if c.MaxReplicas > 0 && scaling.MaxReplicas > c.MaxReplicas {
+			problems = append(problems, fmt.Sprintf("autoscaling max_replicas %d above maximum %d", scaling.MaxReplicas, c.MaxReplicas))
+		}
+		if c.MaxTargetCPU > 0 && scaling.TargetCPU > c.MaxTargetCPU {
+			problems = append(problems, fmt.Sprintf("autoscaling target_cpu %d%% above maximum %d%%", scaling.TargetCPU, c.MaxTargetCPU))
+		}
+	} else {
+		if c.RequireAutoscaling {
+			problems = append(problems, "autoscaling is required")
+		}
+		if c.MinReplicas > 0 && spec.Replicas < c.MinReplicas {
+			problems = append(problems, fmt.Sprintf("replicas %d below minimum %d", spec.Replicas, c.MinReplicas))
+		}
+		if c.MaxReplicas > 0 && spec.Replicas > c.MaxReplicas {
+			problems = append(problems, fmt.Sprintf("replicas %d above maximum %d", spec.Replicas, c.MaxReplicas))
+		}
+	}
+	if len(c.AllowedTiers) > 0 && spec.Tier != "" {
+		allowed := false
+		for _, tier := range c.AllowedTiers {
+			allowed = allowed || tier == spec.Tier
+		}
+		if !allowed {
+			problems = append(problems, fmt.Sprintf("tier %q not one of %s", spec.Tier, strings.Join(c.AllowedTiers, ", ")))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return nil
 }
 
 func (e EnvironmentContract) ID() string            { return e.Metadata.Name }
@@ -19,5 +103,30 @@ func (e EnvironmentContract) Validate() error {
 	if e.Metadata.Name == "" {
 		return fmt.Errorf("environment name is required")
 	}
+	c := e.Spec.Constraints
+	if c.MinReplicas < 0 || c.MaxReplicas < 0 {
+		return fmt.Errorf("replica constraints must not be negative")
+	}
+	if c.MaxTargetCPU < 0 || c.MaxTargetCPU > 100 {
+		return fmt.Errorf("max_target_cpu must be a percentage")
+	}
+	if c.MaxReplicas > 0 && c.MinReplicas > c.MaxReplicas {
+		return fmt.Errorf("min_replicas %d exceeds max_replicas %d", c.MinReplicas, c.MaxReplicas)
+	}
+	regions := map[string]bool{}
+	for _, region := range e.Spec.Regions {
+		if region.Name == "" {
+			return fmt.Errorf("region name is required")
+		}
+		if regions[region.Name] {
+			return fmt.Errorf("region %s is listed twice", region.Name)
+		}
+		regions[region.Name] = true
+		for _, cluster := range region.Clusters {
+			if cluster == "" {
+				return fmt.Errorf("region %s: cluster name is required", region.Name)
+			}
+		}
+	}
 	return nil
 }
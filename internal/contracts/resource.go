@@ -43,6 +43,10 @@ type ResourceSpec struct {
 	Tier     string `json:"tier"`
 	Capacity string `json:"capacity,omitempty"`
 	Plan     string `json:"plan,omitempty"`
+	// Region and Cluster pin the resource to where it runs; unset means it is reachable from
+	// every region
+	Region  string `json:"region,omitempty"`
+	Cluster string `json:"cluster,omitempty"`
 	// Additional fields can be added by specific resource providers
 	ProviderConfig map[string]interface{} `json:"provider_config,omitempty"`
 }
@@ -64,5 +68,8 @@ func (r ResourceContract) Validate() error {
 	if r.Spec.Type == "" {
 		return fmt.Errorf("resource type reference is required")
 	}
+	if r.Spec.Cluster != "" && r.Spec.Region == "" {
+		return fmt.Errorf("resource %s names cluster %s without a region", r.Metadata.Name, r.Spec.Cluster)
+	}
 	return nil
 }
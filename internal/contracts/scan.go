@@ -0,0 +1,114 @@
+package contracts
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Vulnerability severities, as reported by scanners (CVSS v3 qualitative ratings)
+const (
+	SeverityCritical = "critical"
+	SeverityHigh     = "high"
+	SeverityMedium   = "medium"
+	SeverityLow      = "low"
+	SeverityUnknown  = "unknown"
+)
+
+// ScanMetadataKey is the service version node metadata field holding its ScanReport
+const ScanMetadataKey = "scan"
+
+// SBOMComponent is a package included in a service version's build
+type SBOMComponent struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	PURL    string `json:"purl,omitempty"`
+	License string `json:"license,omitempty"`
+}
+
+// Vulnerability is a known CVE found in one of the components
+type Vulnerability struct {
+	ID       string  `json:"id"` // e.g. CVE-2024-3094
+	Severity string  `json:"severity"`
+	Package  string  `json:"package,omitempty"`
+	Version  string  `json:"version,omitempty"`
+	FixedIn  string  `json:"fixed_in,omitempty"`
+	CVSS     float64 `json:"cvss,omitempty"`
+}
+
+// ScanReport is the SBOM and vulnerability scan of a service version
+type ScanReport struct {
+	Scanner         string          `json:"scanner"`               // tool that produced the report, e.g. trivy
+	SBOMFormat      string          `json:"sbom_format,omitempty"` // e.g. cyclonedx, spdx
+	Components      []SBOMComponent `json:"components,omitempty"`
+	Vulnerabilities []Vulnerability `json:"vulnerabilities"`
+	ScannedAt       time.Time       `json:"scanned_at"`
+}
+
+// Validate checks the report is attributable and uses known severities
+func (r *ScanReport) Validate() error {
+	if r.Scanner == "" {
+		return fmt.Errorf("scan report: scanner is required")
+	}
+	for i := range r.Vulnerabilities {
+		v := &r.Vulnerabilities[i]
+		if v.ID == "" {
+			return fmt.Errorf("scan report: vulnerability %d has no id", i)
+		}
+		v.Severity = strings.ToLower(v.Severity)
+		switch v.Severity {
+		case SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow, SeverityUnknown:
+		case "":
+			v.Severity = SeverityUnknown
+		default:
+			return fmt.Errorf("scan report: vulnerability %s has unknown severity %q", v.ID, v.Severity)
+		}
+	}
+	return nil
+}
+
+// Count returns the number of vulnerabilities of the given severity
+func (r *ScanReport) Count(severity string) int {
+	count := 0
+	for _, v := range r.Vulnerabilities {
+		if v.Severity == severity {
+			count++
+		}
+	}
+	return count
+}
+
+// Summary counts vulnerabilities per severity
+func (r *ScanReport) Summary() map[string]int {
+	summary := map[string]int{}
+	for _, v := range r.Vulnerabilities {
+		summary[v.Severity]++
+	}
+	return summary
+}
+
+// ToMetadata encodes the report for storage on a graph node
+func (r *ScanReport) ToMetadata() map[string]interface{} {
+	data, _ := json.Marshal(r)
+	var result map[string]interface{}
+	_ = json.Unmarshal(data, &result)
+	return result
+}
+
+// ScanReportFromMetadata decodes the report stored on a service version node, if any
+func ScanReportFromMetadata(metadata map[string]interface{}) (*ScanReport, bool) {
+	stored, ok := metadata[ScanMetadataKey]
+	if !ok || stored == nil {
+		return nil, false
+	}
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return nil, false
+	}
+	var report ScanReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, false
+	}
+	return &report, true
+}
@@ -0,0 +1,235 @@
+package contracts
+
+//go:generate go run ../../cmd/contract-schemas -out schemas
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrUnknownKind is returned when no schema exists for a contract kind
+var ErrUnknownKind = errors.New("unknown contract kind")
+
+//go:embed schemas/*.json
+var schemaFiles embed.FS
+
+// schemaDefinition describes how the JSON schema for one contract kind is generated.
+// Required lists dotted field paths that the contract's Validate method enforces.
+type schemaDefinition struct {
+	Contract    Contract
+	Description string
+	Required    []string
+}
+
+var schemaDefinitions = []schemaDefinition{
+	{ApplicationContract{}, "An application groups services, resources and policies owned by one team", []string{"metadata", "metadata.name", "metadata.owner"}},
+	{ServiceContract{}, "A service is a deployable unit that belongs to an application", []string{"metadata", "metadata.name", "spec", "spec.application"}},
+	{ServiceVersionContract{}, "A service version is an immutable, versioned service artifact", []string{"name", "version"}},
+	{EnvironmentContract{}, "An environment is a deployment target such as development or production", []string{"metadata", "metadata.name"}},
+	{ResourceTypeContract{}, "A resource type is a catalog template resources are created from", []string{"metadata", "metadata.name", "spec", "spec.version"}},
+	{ResourceContract{}, "A resource is an instance of a resource type owned by an application", []string{"metadata", "metadata.name", "spec", "spec.type"}},
+	{ReleaseContract{}, "A release bundles service versions of an application for deployment", []string{"metadata", "metadata.name", "spec", "spec.application", "spec.version", "spec.service_versions"}},
+}
+
+// GenerateSchemas builds the JSON schema of every contract kind from the Go contract types.
+// The output is what `go generate` writes to schemas/; the embedded copies are served at runtime.
+func GenerateSchemas() (map[string][]byte, error) {
+	out := make(map[string][]byte, len(schemaDefinitions))
+	for _, def := range schemaDefinitions {
+		kind := def.Contract.Kind()
+		required := map[string]bool{}
+		for _, path := range def.Required {
+			required[path] = true
+		}
+
+		schema := structSchema(reflect.TypeOf(def.Contract), "", required)
+		schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+		schema["$id"] = "urn:ztdp:contract:" + kind
+		schema["title"] = kind
+		schema["description"] = def.Description
+
+		data, err := json.MarshalIndent(schema, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s schema: %w", kind, err)
+		}
+		out[kind] = append(data, '\n')
+	}
+	return out, nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func typeSchema(t reflect.Type, path string, required map[string]bool) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem(), path, required)}
+	case reflect.Map:
+		schema := map[string]interface{}{"type": "object"}
+		if t.Elem().Kind() != reflect.Interface {
+			schema["additionalProperties"] = typeSchema(t.Elem(), path, required)
+		}
+		return schema
+	case reflect.Struct:
+		return structSchema(t, path, required)
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+func structSchema(t reflect.Type, path string, required map[string]bool) map[string]interface{} {
+	properties := map[string]interface{}{}
+	var requiredFields []string
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		fieldPath := name
+		if path != "" {
+			fieldPath = path + "." + name
+		}
+		properties[name] = typeSchema(field.Type, fieldPath, required)
+		if required[fieldPath] {
+			requiredFields = append(requiredFields, name)
+		}
+	}
+
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(requiredFields) > 0 {
+		schema["required"] = requiredFields
+	}
+	return schema
+}
+
+// Kinds returns the contract kinds with an embedded schema, sorted
+func Kinds() []string {
+	kinds := make([]string, 0, len(schemaDefinitions))
+	for _, def := range schemaDefinitions {
+		kinds = append(kinds, def.Contract.Kind())
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// Schema returns the embedded JSON schema for a contract kind
+func Schema(kind string) (json.RawMessage, error) {
+	data, err := schemaFiles.ReadFile("schemas/" + kind + ".json")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKind, kind)
+	}
+	return json.RawMessage(data), nil
+}
+
+// Schemas returns every embedded schema keyed by contract kind
+func Schemas() map[string]json.RawMessage {
+	schemas := map[string]json.RawMessage{}
+	for _, kind := range Kinds() {
+		if schema, err := Schema(kind); err == nil {
+			schemas[kind] = schema
+		}
+	}
+	return schemas
+}
+
+// PromptSummary renders the schemas of the given kinds (all kinds when none are given) as
+// compact field listings for AI prompts, e.g. "metadata.name: string (required)".
+func PromptSummary(kinds ...string) string {
+	if len(kinds) == 0 {
+		kinds = Kinds()
+	}
+
+	var sb strings.Builder
+	for _, kind := range kinds {
+		raw, err := Schema(kind)
+		if err != nil {
+			continue
+		}
+		var schema map[string]interface{}
+		if err := json.Unmarshal(raw, &schema); err != nil {
+			continue
+		}
+		fmt.Fprintf(&sb, "%s contract - %v:\n", kind, schema["description"])
+		for _, line := range describeFields(schema, "") {
+			fmt.Fprintf(&sb, "  %s\n", line)
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func describeFields(schema map[string]interface{}, prefix string) []string {
+	properties, _ := schema["properties"].(map[string]interface{})
+	required := map[string]bool{}
+	if list, ok := schema["required"].([]interface{}); ok {
+		for _, name := range list {
+			required[fmt.Sprint(name)] = true
+		}
+	}
+
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var lines []string
+	for _, name := range names {
+		field, _ := properties[name].(map[string]interface{})
+		path := prefix + name
+		if _, nested := field["properties"]; nested {
+			lines = append(lines, describeFields(field, path+".")...)
+			continue
+		}
+		line := path + ": " + fieldType(field)
+		if required[name] {
+			line += " (required)"
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func fieldType(field map[string]interface{}) string {
+	typ := fmt.Sprint(field["type"])
+	switch typ {
+	case "array":
+		if items, ok := field["items"].(map[string]interface{}); ok {
+			return "array of " + fieldType(items)
+		}
+	case "string":
+		if format, ok := field["format"].(string); ok {
+			return "string (" + format + ")"
+		}
+	}
+	return typ
+}
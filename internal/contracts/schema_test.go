@@ -0,0 +1,61 @@
+package contracts
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEmbeddedSchemasMatchContracts(t *testing.T) {
+	generated, err := GenerateSchemas()
+	if err != nil {
+		t.Fatalf("GenerateSchemas: %v", err)
+	}
+	for kind, want := range generated {
+		got, err := Schema(kind)
+		if err != nil {
+			t.Fatalf("no embedded schema for %s: %v", kind, err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("embedded %s schema is stale; run go generate ./internal/contracts", kind)
+		}
+	}
+	if len(Schemas()) != len(generated) {
+		t.Errorf("expected %d embedded schemas, got %d", len(generated), len(Schemas()))
+	}
+}
+
+func TestSchema_RequiredFieldsFollowValidate(t *testing.T) {
+	raw, err := Schema("service")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema struct {
+		Properties map[string]struct {
+			Required []string `json:"required"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		t.Fatal(err)
+	}
+	if got := schema.Properties["spec"].Required; len(got) != 1 || got[0] != "application" {
+		t.Errorf("expected spec.application to be the only required spec field, got %v", got)
+	}
+
+	if _, err := Schema("widget"); !errors.Is(err, ErrUnknownKind) {
+		t.Errorf("expected ErrUnknownKind, got %v", err)
+	}
+}
+
+func TestPromptSummary(t *testing.T) {
+	summary := PromptSummary("application")
+	for _, want := range []string{"application contract", "metadata.owner: string (required)", "spec.tags: array of string"} {
+		if !strings.Contains(summary, want) {
+			t.Errorf("summary missing %q:\n%s", want, summary)
+		}
+	}
+	if strings.Contains(summary, "service contract") {
+		t.Errorf("summary should only describe the requested kinds:\n%s", summary)
+	}
+}
@@ -2,13 +2,348 @@ package contracts
 
 import (
 	"fmt"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
 )
 
 type ServiceSpec struct {
-	Application string `json:"application"`
-	Port        int    `json:"port"`
-	Public      bool   `json:"public"`
+	Application string            `json:"application"`
+	Port        int               `json:"port"`
+	Public      bool              `json:"public"`
+	Replicas    int               `json:"replicas,omitempty"`
+	Tier        string            `json:"tier,omitempty"` // resource tier, e.g. small or large
+	Env         map[string]string `json:"env,omitempty"`
+	// Overrides holds per-environment overlays keyed by environment name
+	Overrides map[string]ServiceOverride `json:"overrides,omitempty"`
+	// Dependencies are the APIs this service consumes from other services
+	Dependencies []ServiceDependency `json:"dependencies,omitempty"`
+	// Routes expose a public service through the environment's ingress
+	Routes []ServiceRoute `json:"routes,omitempty"`
+	// Autoscaling lets the replica count vary between bounds; Replicas is then the initial count
+	Autoscaling *AutoscalingSpec `json:"autoscaling,omitempty"`
+	// Config declares the configuration keys the service reads and where their values come from
+	Config []ConfigKey `json:"config,omitempty"`
+}
+
+// ConfigKey declares a configuration value the service reads from an env var. The value is
+// the env var set for the environment, otherwise the bound resource field, otherwise Default.
+type ConfigKey struct {
+	Name     string `json:"name"`               // env var name, e.g. DATABASE_URL
+	From     string `json:"from,omitempty"`     // resource binding "<resource>.<field>", e.g. checkout-pg-db.connection_string
+	Default  string `json:"default,omitempty"`  // used when nothing else provides a value
+	Required bool   `json:"required,omitempty"` // deployments are refused while the key has no value
+	Secret   bool   `json:"secret,omitempty"`   // the value is masked wherever it is shown
+}
+
+// Binding splits From into the resource and the spec field read from it. A catalog resource
+// stands for the application's instance of it.
+func (k ConfigKey) Binding() (resource, field string, ok bool) {
+	i := strings.LastIndex(k.From, ".")
+	if i <= 0 || i == len(k.From)-1 {
+		return "", "", false
+	}
+	return k.From[:i], k.From[i+1:], true
+}
+
+// Validate checks a config key independent of the resources in the graph
+func (k ConfigKey) Validate() error {
+	if !envVarName.MatchString(k.Name) {
+		return fmt.Errorf("invalid config key name %q", k.Name)
+	}
+	if k.From != "" {
+		if _, _, ok := k.Binding(); !ok {
+			return fmt.Errorf("config key %s: binding %q must be <resource>.<field>", k.Name, k.From)
+		}
+	}
+	return nil
+}
+
+func validateConfigKeys(keys []ConfigKey) error {
+	seen := map[string]bool{}
+	for _, key := range keys {
+		if err := key.Validate(); err != nil {
+			return err
+		}
+		if seen[key.Name] {
+			return fmt.Errorf("config key %s is declared twice", key.Name)
+		}
+		seen[key.Name] = true
+	}
+	return nil
+}
+
+// AutoscalingSpec scales a service between MinReplicas and MaxReplicas to keep its CPU
+// utilization and custom metrics at their targets
+type AutoscalingSpec struct {
+	MinReplicas int            `json:"min_replicas"`
+	MaxReplicas int            `json:"max_replicas"`
+	TargetCPU   int            `json:"target_cpu,omitempty"` // average utilization in percent
+	Metrics     []CustomMetric `json:"metrics,omitempty"`
+}
+
+// CustomMetric is a per-replica metric target, e.g. requests per second
+type CustomMetric struct {
+	Name   string  `json:"name"`
+	Target float64 `json:"target"`
+}
+
+// String describes the autoscaling, e.g. "2-10 replicas at 70% cpu"
+func (a *AutoscalingSpec) String() string {
+	if a == nil {
+		return ""
+	}
+	var targets []string
+	if a.TargetCPU > 0 {
+		targets = append(targets, fmt.Sprintf("%d%% cpu", a.TargetCPU))
+	}
+	for _, metric := range a.Metrics {
+		targets = append(targets, fmt.Sprintf("%g %s", metric.Target, metric.Name))
+	}
+	return fmt.Sprintf("%d-%d replicas at %s", a.MinReplicas, a.MaxReplicas, strings.Join(targets, ", "))
+}
+
+// Validate checks the autoscaling bounds and targets
+func (a AutoscalingSpec) Validate() error {
+	if a.MinReplicas < 1 {
+		return fmt.Errorf("autoscaling min_replicas must be at least 1")
+	}
+	if a.MaxReplicas < a.MinReplicas {
+		return fmt.Errorf("autoscaling max_replicas %d below min_replicas %d", a.MaxReplicas, a.MinReplicas)
+	}
+	if a.TargetCPU < 0 || a.TargetCPU > 100 {
+		return fmt.Errorf("autoscaling target_cpu must be a percentage")
+	}
+	if a.TargetCPU == 0 && len(a.Metrics) == 0 {
+		return fmt.Errorf("autoscaling needs a target_cpu or a custom metric")
+	}
+	for _, metric := range a.Metrics {
+		if metric.Name == "" || metric.Target <= 0 {
+			return fmt.Errorf("autoscaling metrics need a name and a positive target")
+		}
+	}
+	return nil
+}
+
+// ServiceRoute routes requests for a hostname and path prefix to the service's port
+type ServiceRoute struct {
+	Host         string    `json:"host"`
+	Path         string    `json:"path,omitempty"` // prefix, "/" when unset
+	TLS          *RouteTLS `json:"tls,omitempty"`
+	Environments []string  `json:"environments,omitempty"` // the environments the route applies in; every environment when empty
+}
+
+// RouteTLS terminates TLS for a route with an existing certificate secret or one issued for it
+type RouteTLS struct {
+	SecretName string `json:"secret_name,omitempty"`
+	Issuer     string `json:"issuer,omitempty"` // e.g. a cert-manager cluster issuer
+}
+
+var routeHost = regexp.MustCompile(`^(\*\.)?([a-z0-9]([a-z0-9-]*[a-z0-9])?\.)*[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+// EffectivePath returns the route's path prefix, "/" when none is set
+func (r ServiceRoute) EffectivePath() string {
+	if r.Path == "" {
+		return "/"
+	}
+	return r.Path
+}
+
+// AppliesIn reports whether the route is served in the environment
+func (r ServiceRoute) AppliesIn(environment string) bool {
+	if len(r.Environments) == 0 {
+		return true
+	}
+	for _, env := range r.Environments {
+		if env == environment {
+			return true
+		}
+	}
+	return false
+}
+
+// Overlaps reports whether two routes claim the same host and path in a common environment
+func (r ServiceRoute) Overlaps(other ServiceRoute) bool {
+	if r.Host != other.Host || r.EffectivePath() != other.EffectivePath() {
+		return false
+	}
+	if len(r.Environments) == 0 || len(other.Environments) == 0 {
+		return true
+	}
+	for _, env := range r.Environments {
+		if other.AppliesIn(env) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks a route independent of other services' routes
+func (r ServiceRoute) Validate() error {
+	if !routeHost.MatchString(r.Host) {
+		return fmt.Errorf("invalid route host %q", r.Host)
+	}
+	if r.Path != "" && r.Path[0] != '/' {
+		return fmt.Errorf("route %s: path %q must start with /", r.Host, r.Path)
+	}
+	if r.TLS != nil && r.TLS.SecretName == "" && r.TLS.Issuer == "" {
+		return fmt.Errorf("route %s: tls needs a secret_name or an issuer", r.Host)
+	}
+	return nil
+}
+
+// Protocols a service dependency can declare
+const (
+	ProtocolHTTP = "http"
+	ProtocolGRPC = "grpc"
+	ProtocolTCP  = "tcp"
+)
+
+// ServiceDependency declares an API a service consumes from another service. In the graph it
+// is a consumes edge from the consumer to the consumed service carrying the protocol metadata.
+type ServiceDependency struct {
+	Service  string `json:"service"`
+	Protocol string `json:"protocol,omitempty"` // http (default), grpc or tcp
+	Port     int    `json:"port,omitempty"`     // the consumed service's port; unset means the one it declares
+	API      string `json:"api,omitempty"`      // e.g. a path prefix or gRPC service name
+	Version  string `json:"version,omitempty"`  // pins a version of the consumed service
+}
+
+// Validate checks a dependency independent of the services in the graph
+func (d ServiceDependency) Validate() error {
+	if d.Service == "" {
+		return fmt.Errorf("dependency service is required")
+	}
+	switch d.Protocol {
+	case "", ProtocolHTTP, ProtocolGRPC, ProtocolTCP:
+	default:
+		return fmt.Errorf("dependency on %s: unsupported protocol %q", d.Service, d.Protocol)
+	}
+	if d.Port < 0 || d.Port > 65535 {
+		return fmt.Errorf("dependency on %s: invalid port %d", d.Service, d.Port)
+	}
+	return nil
+}
+
+// EffectiveProtocol returns the dependency's protocol, http when none is declared
+func (d ServiceDependency) EffectiveProtocol() string {
+	if d.Protocol == "" {
+		return ProtocolHTTP
+	}
+	return d.Protocol
+}
+
+// EdgeMetadata is the protocol metadata recorded on the dependency's consumes edge
+func (d ServiceDependency) EdgeMetadata() map[string]interface{} {
+	metadata := map[string]interface{}{"protocol": d.EffectiveProtocol()}
+	if d.Port != 0 {
+		metadata["port"] = d.Port
+	}
+	if d.API != "" {
+		metadata["api"] = d.API
+	}
+	if d.Version != "" {
+		metadata["version"] = d.Version
+	}
+	return metadata
+}
+
+// ServiceOverride is an environment-scoped overlay on a service spec. Unset fields keep the
+// base value; env vars and config keys are merged with the base ones, the override winning.
+type ServiceOverride struct {
+	Replicas    *int              `json:"replicas,omitempty"`
+	Tier        string            `json:"tier,omitempty"`
+	Env         map[string]string `json:"env,omitempty"`
+	Autoscaling *AutoscalingSpec  `json:"autoscaling,omitempty"` // replaces the base autoscaling
+	Config      []ConfigKey       `json:"config,omitempty"`      // replace base keys of the same name, e.g. to bind another resource
+}
+
+var envVarName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ForEnvironment returns the spec with the environment's override merged in
+func (s ServiceSpec) ForEnvironment(environment string) ServiceSpec {
+	merged := s
+	merged.Overrides = nil
+	merged.Env = make(map[string]string, len(s.Env))
+	for k, v := range s.Env {
+		merged.Env[k] = v
+	}
+
+	if override, ok := s.Overrides[environment]; ok {
+		if override.Replicas != nil {
+			merged.Replicas = *override.Replicas
+		}
+		if override.Tier != "" {
+			merged.Tier = override.Tier
+		}
+		if override.Autoscaling != nil {
+			merged.Autoscaling = override.Autoscaling
+		}
+		for k, v := range override.Env {
+			merged.Env[k] = v
+		}
+		merged.Config = mergeConfigKeys(s.Config, override.Config)
+	}
+	if len(merged.Env) == 0 {
+		merged.Env = nil
+	}
+	return merged
+}
+
+// mergeConfigKeys returns the base keys with overrides replacing keys of the same name
+func mergeConfigKeys(base, overrides []ConfigKey) []ConfigKey {
+	if len(overrides) == 0 {
+		return base
+	}
+	byName := make(map[string]ConfigKey, len(overrides))
+	for _, key := range overrides {
+		byName[key.Name] = key
+	}
+	merged := make([]ConfigKey, 0, len(base)+len(overrides))
+	for _, key := range base {
+		if override, ok := byName[key.Name]; ok {
+			key = override
+			delete(byName, key.Name)
+		}
+		merged = append(merged, key)
+	}
+	for _, key := range overrides {
+		if _, ok := byName[key.Name]; ok {
+			merged = append(merged, key)
+		}
+	}
+	return merged
+}
+
+// Validate checks an override's values independent of any environment's constraints
+func (o ServiceOverride) Validate() error {
+	if o.Replicas != nil && *o.Replicas < 0 {
+		return fmt.Errorf("replicas must not be negative")
+	}
+	if o.Autoscaling != nil {
+		if err := o.Autoscaling.Validate(); err != nil {
+			return err
+		}
+	}
+	if err := validateConfigKeys(o.Config); err != nil {
+		return err
+	}
+	return validateEnvVars(o.Env)
+}
+
+func validateEnvVars(env map[string]string) error {
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !envVarName.MatchString(name) {
+			return fmt.Errorf("invalid env var name %q", name)
+		}
+	}
+	return nil
 }
 
 type ServiceContract struct {
@@ -27,6 +362,51 @@ func (s ServiceContract) Validate() error {
 	if s.Spec.Application == "" {
 		return fmt.Errorf("linked application is required")
 	}
+	if s.Spec.Replicas < 0 {
+		return fmt.Errorf("replicas must not be negative")
+	}
+	if err := validateEnvVars(s.Spec.Env); err != nil {
+		return err
+	}
+	if err := validateConfigKeys(s.Spec.Config); err != nil {
+		return err
+	}
+	if s.Spec.Autoscaling != nil {
+		if err := s.Spec.Autoscaling.Validate(); err != nil {
+			return err
+		}
+	}
+	for environment, override := range s.Spec.Overrides {
+		if err := override.Validate(); err != nil {
+			return fmt.Errorf("override for %s: %w", environment, err)
+		}
+	}
+	seen := map[string]bool{}
+	for _, dependency := range s.Spec.Dependencies {
+		if err := dependency.Validate(); err != nil {
+			return err
+		}
+		if dependency.Service == s.Metadata.Name {
+			return fmt.Errorf("service %s cannot depend on itself", s.Metadata.Name)
+		}
+		if seen[dependency.Service] {
+			return fmt.Errorf("dependency on %s is declared twice", dependency.Service)
+		}
+		seen[dependency.Service] = true
+	}
+	if len(s.Spec.Routes) > 0 && (!s.Spec.Public || s.Spec.Port == 0) {
+		return fmt.Errorf("routes require a public service with a port")
+	}
+	for i, route := range s.Spec.Routes {
+		if err := route.Validate(); err != nil {
+			return err
+		}
+		for _, earlier := range s.Spec.Routes[:i] {
+			if route.Overlaps(earlier) {
+				return fmt.Errorf("route %s%s is declared twice", route.Host, route.EffectivePath())
+			}
+		}
+	}
 	return nil
 }
 
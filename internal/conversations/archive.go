@@ -0,0 +1,152 @@
+package conversations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/archive"
+)
+
+// archiveCollection names transcript batches in the archive
+const archiveCollection = "conversations"
+
+// DefaultBatchSize is how many transcripts go into one archive batch when unset
+const DefaultBatchSize = 100
+
+// Archive moves transcripts idle longer than ArchiveAfter from the store to the archive, oldest
+// first, and returns how many were moved. Each batch is removed from the store only once archived.
+func (s *Service) Archive(ctx context.Context, now time.Time) (int, error) {
+	if s.opts.Archive == nil || s.opts.ArchiveAfter <= 0 {
+		return 0, nil
+	}
+	transcripts, err := s.List(ListFilter{})
+	if err != nil {
+		return 0, err
+	}
+	cutoff := now.Add(-s.opts.ArchiveAfter)
+	idle := []*Transcript{}
+	for _, transcript := range transcripts {
+		if transcript.UpdatedAt.Before(cutoff) {
+			idle = append(idle, transcript)
+		}
+	}
+	sort.Slice(idle, func(i, j int) bool { return idle[i].UpdatedAt.Before(idle[j].UpdatedAt) })
+
+	batchSize := s.opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	archived := 0
+	for start := 0; start < len(idle); start += batchSize {
+		batch := idle[start:min(start+batchSize, len(idle))]
+		records := make([]archive.Record, 0, len(batch))
+		for _, transcript := range batch {
+			data, err := json.Marshal(transcript)
+			if err != nil {
+				return archived, fmt.Errorf("failed to encode conversation %s: %w", transcript.ID, err)
+			}
+			records = append(records, archive.Record{Ref: archiveRef(transcript), Data: data})
+		}
+		if _, err := s.opts.Archive.Write(ctx, archiveCollection, records); err != nil {
+			return archived, err
+		}
+		for _, transcript := range batch {
+			if err := s.store.Delete(transcript.ID); err != nil {
+				return archived, fmt.Errorf("failed to remove archived conversation %s: %w", transcript.ID, err)
+			}
+			archived++
+		}
+	}
+	if archived > 0 {
+		s.logger.Info("🗄️ Archived %d conversations idle since before %s", archived, cutoff.Format(time.RFC3339))
+	}
+	return archived, nil
+}
+
+// open returns a transcript for writing, restoring it to the store when it was archived
+func (s *Service) open(conversationID string) (*Transcript, error) {
+	transcript, err := s.load(conversationID)
+	if !errors.Is(err, ErrConversationNotFound) || s.opts.Archive == nil {
+		return transcript, err
+	}
+	transcript, err = s.getArchived(context.Background(), conversationID)
+	if err != nil {
+		return nil, err
+	}
+	transcript.Archived = false
+	if err := s.store.Save(transcript); err != nil {
+		return nil, fmt.Errorf("failed to restore conversation %s: %w", conversationID, err)
+	}
+	s.logger.Info("♻️ Restored archived conversation %s", conversationID)
+	return transcript, nil
+}
+
+func (s *Service) getArchived(ctx context.Context, conversationID string) (*Transcript, error) {
+	data, err := s.opts.Archive.Get(ctx, archiveCollection, conversationID)
+	if errors.Is(err, archive.ErrNotArchived) {
+		return nil, ErrConversationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeArchived(data)
+}
+
+// listArchived returns the archived transcripts matching the filter that are not among the
+// transcripts already in the store
+func (s *Service) listArchived(ctx context.Context, filter ListFilter, stored []*Transcript) ([]*Transcript, error) {
+	inStore := map[string]bool{}
+	for _, transcript := range stored {
+		inStore[transcript.ID] = true
+	}
+	records, err := s.opts.Archive.Find(ctx, archiveCollection, func(ref archive.Ref) bool {
+		return !inStore[ref.ID] &&
+			(filter.Tenant == "" || contains(ref.Labels, "tenant:"+filter.Tenant)) &&
+			(filter.Entity == "" || contains(ref.Labels, "entity:"+filter.Entity)) &&
+			(filter.Since.IsZero() || !ref.Time.Before(filter.Since))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// A conversation resumed after archival and archived again is in two batches; the newer wins
+	latest := map[string]json.RawMessage{}
+	for _, record := range records {
+		latest[record.ID] = record.Data
+	}
+	transcripts := make([]*Transcript, 0, len(latest))
+	for id, data := range latest {
+		transcript, err := decodeArchived(data)
+		if err != nil {
+			s.logger.Warn("⚠️ Skipping unreadable archived conversation %s: %v", id, err)
+			continue
+		}
+		transcripts = append(transcripts, transcript)
+	}
+	return transcripts, nil
+}
+
+// archiveRef indexes a transcript by its last update, tenant and referenced entities
+func archiveRef(transcript *Transcript) archive.Ref {
+	ref := archive.Ref{ID: transcript.ID, Time: transcript.UpdatedAt}
+	if transcript.Tenant != "" {
+		ref.Labels = append(ref.Labels, "tenant:"+transcript.Tenant)
+	}
+	for _, entity := range transcript.Entities {
+		ref.Labels = append(ref.Labels, "entity:"+entity)
+	}
+	return ref
+}
+
+func decodeArchived(data json.RawMessage) (*Transcript, error) {
+	var transcript Transcript
+	if err := json.Unmarshal(data, &transcript); err != nil {
+		return nil, fmt.Errorf("failed to decode archived conversation: %w", err)
+	}
+	transcript.Archived = true
+	return &transcript, nil
+}
@@ -0,0 +1,87 @@
+package conversations
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/archive"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newArchivingTestService(t *testing.T) (*Service, *graph.GlobalGraph) {
+	t.Helper()
+	svc, gg := newTestService(t, Options{})
+	store, err := archive.NewDirStore(t.TempDir())
+	require.NoError(t, err)
+	svc.opts = Options{Archive: archive.New(gg, store), ArchiveAfter: 24 * time.Hour, BatchSize: 2}
+	return svc, gg
+}
+
+func TestArchive_MovesIdleConversationsInBatches(t *testing.T) {
+	svc, gg := newArchivingTestService(t)
+	ctx := context.Background()
+	now := time.Now().UTC()
+	for _, id := range []string{"old-1", "old-2", "old-3"} {
+		_, err := svc.RecordTurn(id, "team-a", Turn{Timestamp: now.Add(-72 * time.Hour), UserMessage: "status of checkout"})
+		require.NoError(t, err)
+	}
+	_, err := svc.RecordTurn("recent", "team-b", Turn{Timestamp: now.Add(-time.Hour), UserMessage: "deploy checkout to prod"})
+	require.NoError(t, err)
+
+	archived, err := svc.Archive(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, 3, archived)
+	manifests, err := svc.opts.Archive.Manifests(archiveCollection)
+	require.NoError(t, err)
+	assert.Len(t, manifests, 2, "three conversations in batches of two")
+
+	node, _ := gg.GetNode(nodeIDPrefix + "old-1")
+	assert.Nil(t, node, "archived conversations leave the graph")
+	stored, err := svc.List(ListFilter{})
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.Equal(t, "recent", stored[0].ID)
+
+	// Reads fall back to the archive
+	transcript, err := svc.Get("old-2")
+	require.NoError(t, err)
+	assert.True(t, transcript.Archived)
+	assert.Equal(t, []string{"checkout"}, transcript.Entities)
+
+	byEntity, err := svc.List(ListFilter{Entity: "checkout", IncludeArchived: true})
+	require.NoError(t, err)
+	assert.Len(t, byEntity, 4)
+	byTenant, err := svc.List(ListFilter{Tenant: "team-a", IncludeArchived: true, Limit: 2})
+	require.NoError(t, err)
+	assert.Len(t, byTenant, 2)
+	byEntity, err = svc.List(ListFilter{Entity: "prod", IncludeArchived: true})
+	require.NoError(t, err)
+	require.Len(t, byEntity, 1)
+	assert.False(t, byEntity[0].Archived)
+}
+
+func TestRecordTurn_RestoresArchivedConversation(t *testing.T) {
+	svc, gg := newArchivingTestService(t)
+	now := time.Now().UTC()
+	_, err := svc.RecordTurn("conv-1", "team-a", Turn{Timestamp: now.Add(-72 * time.Hour), UserMessage: "status of checkout"})
+	require.NoError(t, err)
+	_, err = svc.Archive(context.Background(), now)
+	require.NoError(t, err)
+
+	transcript, err := svc.RecordTurn("conv-1", "team-a", Turn{Timestamp: now, UserMessage: "and prod?"})
+	require.NoError(t, err)
+	assert.Len(t, transcript.Turns, 2)
+	assert.False(t, transcript.Archived)
+	linked, err := gg.HasEdge(nodeIDPrefix+"conv-1", "checkout", graph.EdgeTypeReferences)
+	require.NoError(t, err)
+	assert.True(t, linked, "entity links are restored")
+
+	// The live copy wins over the archived one
+	listed, err := svc.List(ListFilter{IncludeArchived: true})
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	assert.Len(t, listed[0].Turns, 2)
+}
@@ -0,0 +1,85 @@
+package conversations
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/redaction"
+)
+
+// Rating is a user's verdict on an AI response
+type Rating string
+
+const (
+	RatingUp   Rating = "up"
+	RatingDown Rating = "down"
+)
+
+// ErrInvalidFeedback is returned for feedback with an unknown rating or turn
+var ErrInvalidFeedback = errors.New("invalid feedback")
+
+// Feedback is a thumbs up or down on one turn of a conversation, optionally with a correction
+type Feedback struct {
+	Timestamp     time.Time `json:"timestamp"`
+	Turn          int       `json:"turn"` // index into the transcript's turns
+	Rating        Rating    `json:"rating"`
+	Comment       string    `json:"comment,omitempty"`
+	Intent        string    `json:"intent,omitempty"`         // intent of the rated turn
+	CorrelationID string    `json:"correlation_id,omitempty"` // correlation ID of the rated turn
+}
+
+// FeedbackHandler is told about feedback after it has been stored, e.g. to attach it to
+// intent analytics so poorly rated routes can be found and tuned
+type FeedbackHandler func(transcript *Transcript, feedback Feedback)
+
+// OnFeedback registers a handler called for every stored feedback
+func (s *Service) OnFeedback(handler FeedbackHandler) {
+	s.feedbackHandlers = append(s.feedbackHandlers, handler)
+}
+
+// AddFeedback stores feedback on a turn of a conversation. A nil turn rates the latest turn.
+func (s *Service) AddFeedback(conversationID string, turn *int, rating Rating, comment string) (*Feedback, error) {
+	if rating != RatingUp && rating != RatingDown {
+		return nil, fmt.Errorf("%w: rating must be %q or %q", ErrInvalidFeedback, RatingUp, RatingDown)
+	}
+
+	transcript, err := s.open(conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if len(transcript.Turns) == 0 {
+		return nil, fmt.Errorf("%w: conversation %s has no turns", ErrInvalidFeedback, conversationID)
+	}
+
+	index := len(transcript.Turns) - 1
+	if turn != nil {
+		index = *turn
+	}
+	if index < 0 || index >= len(transcript.Turns) {
+		return nil, fmt.Errorf("%w: turn %d does not exist (conversation has %d turns)", ErrInvalidFeedback, index, len(transcript.Turns))
+	}
+
+	if s.opts.RedactPII {
+		comment = redaction.Default().String(comment)
+	}
+	feedback := Feedback{
+		Timestamp:     time.Now().UTC(),
+		Turn:          index,
+		Rating:        rating,
+		Comment:       comment,
+		Intent:        transcript.Turns[index].Intent,
+		CorrelationID: transcript.Turns[index].CorrelationID,
+	}
+	transcript.Feedback = append(transcript.Feedback, feedback)
+
+	if err := s.store.Save(transcript); err != nil {
+		return nil, fmt.Errorf("failed to store feedback on conversation %s: %w", conversationID, err)
+	}
+	s.logger.Info("📝 Feedback %s on turn %d of conversation %s", rating, index, conversationID)
+
+	for _, handler := range s.feedbackHandlers {
+		handler(transcript, feedback)
+	}
+	return &feedback, nil
+}
@@ -0,0 +1,346 @@
+// Package conversations stores chat transcripts, by default as graph nodes linked to the entities they touched
+package conversations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/archive"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/krzachariassen/ZTDP/internal/redaction"
+)
+
+// ErrConversationNotFound is returned when a transcript does not exist
+var ErrConversationNotFound = errors.New("conversation not found")
+
+// nodeIDPrefix namespaces transcript nodes so they cannot collide with platform entities
+const nodeIDPrefix = "conversation:"
+
+// referenceableKinds are the node kinds a transcript links to when a chat mentions them
+var referenceableKinds = map[string]bool{
+	graph.KindApplication: true,
+	graph.KindService:     true,
+	graph.KindEnvironment: true,
+	graph.KindResource:    true,
+	graph.KindPolicy:      true,
+}
+
+// Turn is one user message and everything the platform did in response
+type Turn struct {
+	Timestamp      time.Time `json:"timestamp"`
+	CorrelationID  string    `json:"correlation_id,omitempty"`
+	UserMessage    string    `json:"user_message"`
+	Intent         string    `json:"intent,omitempty"`
+	SelectedAgent  string    `json:"selected_agent,omitempty"`
+	Response       string    `json:"response"`
+	AgentResponses []string  `json:"agent_responses,omitempty"` // raw messages returned by agents
+	Actions        []Action  `json:"actions,omitempty"`
+}
+
+// Action summarizes an action taken during a turn
+type Action struct {
+	Type   string `json:"type"`
+	Status string `json:"status,omitempty"`
+}
+
+// Transcript is a full conversation
+type Transcript struct {
+	ID        string     `json:"id"`
+	Tenant    string     `json:"tenant,omitempty"`
+	StartedAt time.Time  `json:"started_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	Entities  []string   `json:"entities,omitempty"` // IDs of graph nodes the conversation referenced
+	Turns     []Turn     `json:"turns"`
+	Feedback  []Feedback `json:"feedback,omitempty"` // user ratings of individual turns
+	Archived  bool       `json:"archived,omitempty"` // served from the archive rather than the graph
+}
+
+// Options control what is stored and for how long
+type Options struct {
+	Retention time.Duration // transcripts idle for longer are pruned; zero keeps them forever
+	RedactPII bool          // apply the platform redactor before storing
+
+	// Archive receives transcripts idle longer than ArchiveAfter in batches of up to BatchSize;
+	// nil keeps every transcript in the graph
+	Archive      *archive.Archive
+	ArchiveAfter time.Duration
+	BatchSize    int
+
+	// Store keeps the transcripts; nil keeps them in the global graph
+	Store Store
+}
+
+// Service records and queries transcripts. Entities mentioned in chats are looked up in the
+// global graph wherever the transcripts are stored.
+type Service struct {
+	graph            *graph.GlobalGraph
+	store            Store
+	opts             Options
+	logger           *logging.Logger
+	feedbackHandlers []FeedbackHandler
+}
+
+// NewService creates a transcript service storing transcripts in opts.Store, or in the global
+// graph when none is given
+func NewService(globalGraph *graph.GlobalGraph, opts Options) *Service {
+	store := opts.Store
+	if store == nil {
+		store = NewGraphStore(globalGraph)
+	}
+	return &Service{
+		graph:  globalGraph,
+		store:  store,
+		opts:   opts,
+		logger: logging.GetLogger().ForComponent("conversations"),
+	}
+}
+
+// RecordTurn appends a turn to a conversation, creating it on first use, and links the
+// conversation to every platform entity the turn mentions
+func (s *Service) RecordTurn(conversationID, tenant string, turn Turn) (*Transcript, error) {
+	if conversationID == "" {
+		return nil, fmt.Errorf("conversation ID is required")
+	}
+	if turn.Timestamp.IsZero() {
+		turn.Timestamp = time.Now().UTC()
+	}
+	if s.opts.RedactPII {
+		turn = redactTurn(turn)
+	}
+
+	transcript, err := s.open(conversationID)
+	isNew := errors.Is(err, ErrConversationNotFound)
+	if err != nil && !isNew {
+		return nil, err
+	}
+	if isNew {
+		transcript = &Transcript{ID: conversationID, Tenant: tenant, StartedAt: turn.Timestamp}
+	}
+	transcript.Turns = append(transcript.Turns, turn)
+	transcript.UpdatedAt = turn.Timestamp
+
+	referenced, err := s.findReferences(turn)
+	if err != nil {
+		return nil, err
+	}
+	transcript.Entities = mergeSorted(transcript.Entities, referenced)
+
+	if err := s.store.Save(transcript); err != nil {
+		return nil, err
+	}
+	return transcript, nil
+}
+
+// Get returns a transcript by conversation ID, fetching it from the archive when it is no
+// longer in the store
+func (s *Service) Get(conversationID string) (*Transcript, error) {
+	transcript, err := s.load(conversationID)
+	if errors.Is(err, ErrConversationNotFound) && s.opts.Archive != nil {
+		return s.getArchived(context.Background(), conversationID)
+	}
+	return transcript, err
+}
+
+// load returns a transcript from the store
+func (s *Service) load(conversationID string) (*Transcript, error) {
+	return s.store.Get(conversationID)
+}
+
+// ListFilter narrows List results
+type ListFilter struct {
+	Entity          string // only conversations that referenced this node ID
+	Tenant          string
+	Since           time.Time
+	Limit           int
+	IncludeArchived bool // also search archived transcripts; only batches with matches are fetched
+}
+
+// List returns transcripts, most recently updated first
+func (s *Service) List(filter ListFilter) ([]*Transcript, error) {
+	stored, err := s.store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	transcripts := []*Transcript{}
+	for _, transcript := range stored {
+		if filter.Tenant != "" && transcript.Tenant != filter.Tenant {
+			continue
+		}
+		if !filter.Since.IsZero() && transcript.UpdatedAt.Before(filter.Since) {
+			continue
+		}
+		if filter.Entity != "" && !contains(transcript.Entities, filter.Entity) {
+			continue
+		}
+		transcripts = append(transcripts, transcript)
+	}
+	if filter.IncludeArchived && s.opts.Archive != nil {
+		archived, err := s.listArchived(context.Background(), filter, transcripts)
+		if err != nil {
+			return nil, err
+		}
+		transcripts = append(transcripts, archived...)
+	}
+
+	sort.Slice(transcripts, func(i, j int) bool {
+		return transcripts[i].UpdatedAt.After(transcripts[j].UpdatedAt)
+	})
+	if filter.Limit > 0 && len(transcripts) > filter.Limit {
+		transcripts = transcripts[:filter.Limit]
+	}
+	return transcripts, nil
+}
+
+// Delete removes a transcript and its entity links. Archived copies are kept.
+func (s *Service) Delete(conversationID string) error {
+	if _, err := s.load(conversationID); err != nil {
+		return err
+	}
+	return s.store.Delete(conversationID)
+}
+
+// Prune deletes transcripts that have been idle longer than the retention period and
+// returns how many were removed
+func (s *Service) Prune(now time.Time) (int, error) {
+	if s.opts.Retention <= 0 {
+		return 0, nil
+	}
+
+	expired, err := s.List(ListFilter{})
+	if err != nil {
+		return 0, err
+	}
+	cutoff := now.Add(-s.opts.Retention)
+
+	pruned := 0
+	for _, transcript := range expired {
+		if !transcript.UpdatedAt.Before(cutoff) {
+			continue
+		}
+		if err := s.store.Delete(transcript.ID); err != nil {
+			return pruned, fmt.Errorf("failed to prune conversation %s: %w", transcript.ID, err)
+		}
+		pruned++
+	}
+	if pruned > 0 {
+		s.logger.Info("🧹 Pruned %d conversations idle since before %s", pruned, cutoff.Format(time.RFC3339))
+	}
+	return pruned, nil
+}
+
+var wordPattern = regexp.MustCompile(`[A-Za-z0-9][A-Za-z0-9_.:-]*`)
+
+// findReferences returns IDs of platform entities named in the turn's message or agent responses
+func (s *Service) findReferences(turn Turn) ([]string, error) {
+	entities, err := FindEntities(s.graph, turn.UserMessage+" "+strings.Join(turn.AgentResponses, " "))
+	if err != nil {
+		return nil, err
+	}
+	var referenced []string
+	for _, ids := range entities {
+		referenced = append(referenced, ids...)
+	}
+	sort.Strings(referenced)
+	return referenced, nil
+}
+
+// FindEntities returns the IDs of platform entities named in text, by node kind
+func FindEntities(globalGraph *graph.GlobalGraph, text string) (map[string][]string, error) {
+	nodes, err := globalGraph.Nodes()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	entities := map[string][]string{}
+	for _, word := range wordPattern.FindAllString(text, -1) {
+		word = strings.TrimRight(word, ".:-")
+		if seen[word] {
+			continue
+		}
+		seen[word] = true
+		if node, ok := nodes[word]; ok && referenceableKinds[node.Kind] {
+			entities[node.Kind] = append(entities[node.Kind], word)
+		}
+	}
+	return entities, nil
+}
+
+func redactTurn(turn Turn) Turn {
+	redactor := redaction.Default()
+	turn.UserMessage = redactor.String(turn.UserMessage)
+	turn.Response = redactor.String(turn.Response)
+	if turn.AgentResponses != nil {
+		turn.AgentResponses = redactor.Value(turn.AgentResponses).([]string)
+	}
+	return turn
+}
+
+func transcriptToNode(transcript *Transcript) (*graph.Node, error) {
+	data, err := json.Marshal(transcript)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode conversation: %w", err)
+	}
+	var spec map[string]interface{}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to encode conversation: %w", err)
+	}
+
+	return &graph.Node{
+		ID:   nodeIDPrefix + transcript.ID,
+		Kind: graph.KindConversation,
+		Metadata: map[string]interface{}{
+			"name":       transcript.ID,
+			"tenant":     transcript.Tenant,
+			"turns":      len(transcript.Turns),
+			"updated_at": transcript.UpdatedAt.Format(time.RFC3339),
+		},
+		Spec: spec,
+	}, nil
+}
+
+// nodeToTranscript decodes the transcript from the node spec
+func nodeToTranscript(node *graph.Node) (*Transcript, error) {
+	data, err := json.Marshal(node.Spec)
+	if err != nil {
+		return nil, err
+	}
+	var transcript Transcript
+	if err := json.Unmarshal(data, &transcript); err != nil {
+		return nil, err
+	}
+	return &transcript, nil
+}
+
+func mergeSorted(existing, added []string) []string {
+	set := map[string]bool{}
+	for _, id := range existing {
+		set[id] = true
+	}
+	for _, id := range added {
+		set[id] = true
+	}
+	merged := make([]string, 0, len(set))
+	for id := range set {
+		merged = append(merged, id)
+	}
+	sort.Strings(merged)
+	return merged
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
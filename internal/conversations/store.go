@@ -0,0 +1,88 @@
+package conversations
+
+import (
+	"fmt"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// Store persists transcripts. Get returns ErrConversationNotFound for unknown conversations.
+type Store interface {
+	Get(conversationID string) (*Transcript, error)
+	List() ([]*Transcript, error)
+	Save(transcript *Transcript) error // creates or replaces the transcript
+	Delete(conversationID string) error
+}
+
+// GraphStore keeps transcripts as graph nodes linked to the entities they reference, so graph
+// queries and the explain API can follow them
+type GraphStore struct {
+	graph  *graph.GlobalGraph
+	logger *logging.Logger
+}
+
+// NewGraphStore creates a store keeping transcripts in the global graph
+func NewGraphStore(globalGraph *graph.GlobalGraph) *GraphStore {
+	return &GraphStore{graph: globalGraph, logger: logging.GetLogger().ForComponent("conversations")}
+}
+
+// Get returns a transcript stored in the graph
+func (s *GraphStore) Get(conversationID string) (*Transcript, error) {
+	node, _ := s.graph.GetNode(nodeIDPrefix + conversationID)
+	if node == nil || node.Kind != graph.KindConversation {
+		return nil, ErrConversationNotFound
+	}
+	return nodeToTranscript(node)
+}
+
+// List returns every transcript stored in the graph, skipping unreadable ones
+func (s *GraphStore) List() ([]*Transcript, error) {
+	nodes, err := s.graph.Nodes()
+	if err != nil {
+		return nil, err
+	}
+	transcripts := []*Transcript{}
+	for _, node := range nodes {
+		if node.Kind != graph.KindConversation {
+			continue
+		}
+		transcript, err := nodeToTranscript(node)
+		if err != nil {
+			s.logger.Warn("⚠️ Skipping unreadable conversation %s: %v", node.ID, err)
+			continue
+		}
+		transcripts = append(transcripts, transcript)
+	}
+	return transcripts, nil
+}
+
+// Save stores the transcript node and links it to every entity it references
+func (s *GraphStore) Save(transcript *Transcript) error {
+	node, err := transcriptToNode(transcript)
+	if err != nil {
+		return err
+	}
+	if existing, _ := s.graph.GetNode(node.ID); existing == nil {
+		if err := s.graph.AddNode(node); err != nil {
+			return fmt.Errorf("failed to store conversation %s: %w", transcript.ID, err)
+		}
+	} else if err := s.graph.UpdateNode(node); err != nil {
+		return fmt.Errorf("failed to update conversation %s: %w", transcript.ID, err)
+	}
+
+	for _, entity := range transcript.Entities {
+		if exists, _ := s.graph.HasEdge(node.ID, entity, graph.EdgeTypeReferences); exists {
+			continue
+		}
+		if err := s.graph.AddEdge(node.ID, entity, graph.EdgeTypeReferences); err != nil {
+			s.logger.Warn("⚠️ Could not link conversation %s to %s: %v", transcript.ID, entity, err)
+		}
+	}
+	return nil
+}
+
+// Delete removes the transcript node and its entity links
+func (s *GraphStore) Delete(conversationID string) error {
+	return s.graph.DeleteNode(nodeIDPrefix + conversationID)
+}
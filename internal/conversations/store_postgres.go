@@ -0,0 +1,124 @@
+package conversations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// postgresTimeout bounds each query so a slow database cannot stall chat requests
+const postgresTimeout = 5 * time.Second
+
+// postgresSchema creates the table on first use; transcripts are stored whole as JSON, with
+// the tenant and update time alongside for operators querying the table directly
+const postgresSchema = `CREATE TABLE IF NOT EXISTS ztdp_conversations (
+	id         TEXT PRIMARY KEY,
+	tenant     TEXT NOT NULL DEFAULT '',
+	updated_at TIMESTAMPTZ NOT NULL,
+	transcript JSONB NOT NULL
+)`
+
+// PostgresStore keeps transcripts in a Postgres table, for installations that already run
+// Postgres and do not want chat history in the graph. Like RedisStore, transcripts record the
+// entities they reference but are not linked to them in the graph.
+type PostgresStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresStore connects to the database at url and creates the transcript table if needed
+func NewPostgresStore(ctx context.Context, url string) (*PostgresStore, error) {
+	ctx, cancel := context.WithTimeout(ctx, postgresTimeout)
+	defer cancel()
+	pool, err := pgxpool.New(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to postgres: %w", err)
+	}
+	if _, err := pool.Exec(ctx, postgresSchema); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("failed to create the conversations table: %w", err)
+	}
+	return &PostgresStore{pool: pool}, nil
+}
+
+// Get returns a transcript stored in Postgres
+func (s *PostgresStore) Get(conversationID string) (*Transcript, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), postgresTimeout)
+	defer cancel()
+	var data []byte
+	err := s.pool.QueryRow(ctx, `SELECT transcript FROM ztdp_conversations WHERE id = $1`, conversationID).Scan(&data)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrConversationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read conversation %s: %w", conversationID, err)
+	}
+	var transcript Transcript
+	if err := json.Unmarshal(data, &transcript); err != nil {
+		return nil, fmt.Errorf("failed to decode conversation %s: %w", conversationID, err)
+	}
+	return &transcript, nil
+}
+
+// List returns every transcript stored in Postgres, skipping unreadable ones
+func (s *PostgresStore) List() ([]*Transcript, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), postgresTimeout)
+	defer cancel()
+	rows, err := s.pool.Query(ctx, `SELECT transcript FROM ztdp_conversations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conversations: %w", err)
+	}
+	defer rows.Close()
+	transcripts := []*Transcript{}
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to read conversations: %w", err)
+		}
+		var transcript Transcript
+		if err := json.Unmarshal(data, &transcript); err != nil {
+			continue
+		}
+		transcripts = append(transcripts, &transcript)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read conversations: %w", err)
+	}
+	return transcripts, nil
+}
+
+// Save inserts or replaces the transcript
+func (s *PostgresStore) Save(transcript *Transcript) error {
+	data, err := json.Marshal(transcript)
+	if err != nil {
+		return fmt.Errorf("failed to encode conversation: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), postgresTimeout)
+	defer cancel()
+	_, err = s.pool.Exec(ctx, `INSERT INTO ztdp_conversations (id, tenant, updated_at, transcript) VALUES ($1, $2, $3, $4)
+ON CONFLICT (id) DO UPDATE SET tenant = EXCLUDED.tenant, updated_at = EXCLUDED.updated_at, transcript = EXCLUDED.transcript`,
+		transcript.ID, transcript.Tenant, transcript.UpdatedAt, data)
+	if err != nil {
+		return fmt.Errorf("failed to store conversation %s: %w", transcript.ID, err)
+	}
+	return nil
+}
+
+// Delete removes the transcript
+func (s *PostgresStore) Delete(conversationID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), postgresTimeout)
+	defer cancel()
+	if _, err := s.pool.Exec(ctx, `DELETE FROM ztdp_conversations WHERE id = $1`, conversationID); err != nil {
+		return fmt.Errorf("failed to delete conversation %s: %w", conversationID, err)
+	}
+	return nil
+}
+
+// Close releases the connection pool
+func (s *PostgresStore) Close() {
+	s.pool.Close()
+}
@@ -0,0 +1,113 @@
+package conversations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis keys: one JSON value per transcript and a set of every stored conversation ID
+const (
+	redisTranscriptPrefix = "ztdp:conversation:"
+	redisTranscriptIndex  = "ztdp:conversations"
+)
+
+// redisTimeout bounds each Redis round trip so a slow Redis cannot stall chat requests
+const redisTimeout = 2 * time.Second
+
+// RedisStore keeps transcripts in Redis for installations that do not want them in the graph.
+// Transcripts still record the entities they reference, but are not linked to them in the graph.
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore creates a store keeping transcripts in Redis
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+// Get returns a transcript stored in Redis
+func (s *RedisStore) Get(conversationID string) (*Transcript, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	data, err := s.client.Get(ctx, redisTranscriptPrefix+conversationID).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrConversationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read conversation %s: %w", conversationID, err)
+	}
+	var transcript Transcript
+	if err := json.Unmarshal(data, &transcript); err != nil {
+		return nil, fmt.Errorf("failed to decode conversation %s: %w", conversationID, err)
+	}
+	return &transcript, nil
+}
+
+// List returns every transcript stored in Redis, skipping IDs whose transcript is gone
+func (s *RedisStore) List() ([]*Transcript, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	ids, err := s.client.SMembers(ctx, redisTranscriptIndex).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conversations: %w", err)
+	}
+	transcripts := []*Transcript{}
+	if len(ids) == 0 {
+		return transcripts, nil
+	}
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = redisTranscriptPrefix + id
+	}
+	values, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read conversations: %w", err)
+	}
+	for _, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var transcript Transcript
+		if err := json.Unmarshal([]byte(data), &transcript); err != nil {
+			continue
+		}
+		transcripts = append(transcripts, &transcript)
+	}
+	return transcripts, nil
+}
+
+// Save writes the transcript and adds it to the index
+func (s *RedisStore) Save(transcript *Transcript) error {
+	data, err := json.Marshal(transcript)
+	if err != nil {
+		return fmt.Errorf("failed to encode conversation: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	pipe := s.client.TxPipeline()
+	pipe.Set(ctx, redisTranscriptPrefix+transcript.ID, data, 0)
+	pipe.SAdd(ctx, redisTranscriptIndex, transcript.ID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to store conversation %s: %w", transcript.ID, err)
+	}
+	return nil
+}
+
+// Delete removes the transcript and its index entry
+func (s *RedisStore) Delete(conversationID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	pipe := s.client.TxPipeline()
+	pipe.Del(ctx, redisTranscriptPrefix+conversationID)
+	pipe.SRem(ctx, redisTranscriptIndex, conversationID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to delete conversation %s: %w", conversationID, err)
+	}
+	return nil
+}
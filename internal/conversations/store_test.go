@@ -0,0 +1,123 @@
+package conversations
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mapStore keeps transcripts in a map, standing in for a store outside the graph
+type mapStore map[string]Transcript
+
+func (s mapStore) Get(conversationID string) (*Transcript, error) {
+	transcript, ok := s[conversationID]
+	if !ok {
+		return nil, ErrConversationNotFound
+	}
+	return &transcript, nil
+}
+
+func (s mapStore) List() ([]*Transcript, error) {
+	transcripts := []*Transcript{}
+	for id := range s {
+		transcript := s[id]
+		transcripts = append(transcripts, &transcript)
+	}
+	return transcripts, nil
+}
+
+func (s mapStore) Save(transcript *Transcript) error {
+	s[transcript.ID] = *transcript
+	return nil
+}
+
+func (s mapStore) Delete(conversationID string) error {
+	delete(s, conversationID)
+	return nil
+}
+
+func TestService_UsesConfiguredStore(t *testing.T) {
+	store := mapStore{}
+	svc, gg := newTestService(t, Options{Store: store})
+
+	_, err := svc.RecordTurn("conv-1", "team-a", Turn{UserMessage: "Deploy checkout to prod"})
+	require.NoError(t, err)
+	require.Contains(t, store, "conv-1")
+	assert.Equal(t, []string{"checkout", "prod"}, store["conv-1"].Entities, "entities are still found in the graph")
+	node, _ := gg.GetNode(nodeIDPrefix + "conv-1")
+	assert.Nil(t, node, "the transcript is not stored in the graph")
+
+	byEntity, err := svc.List(ListFilter{Entity: "prod"})
+	require.NoError(t, err)
+	require.Len(t, byEntity, 1)
+
+	_, err = svc.AddFeedback("conv-1", nil, RatingUp, "")
+	require.NoError(t, err)
+	assert.Len(t, store["conv-1"].Feedback, 1)
+
+	require.NoError(t, svc.Delete("conv-1"))
+	assert.Empty(t, store)
+	assert.ErrorIs(t, svc.Delete("conv-1"), ErrConversationNotFound)
+}
+
+func TestRedisStore(t *testing.T) {
+	addr := os.Getenv("REDIS_HOST")
+	if addr == "" {
+		t.Skip("REDIS_HOST not set, skipping Redis conversation store test")
+	}
+	store := NewRedisStore(redis.NewClient(&redis.Options{Addr: addr, Password: os.Getenv("REDIS_PASSWORD")}))
+	gg := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	svc := NewService(gg, Options{Store: store})
+	t.Cleanup(func() { store.Delete("redis-conv-1") })
+
+	_, err := svc.RecordTurn("redis-conv-1", "team-a", Turn{UserMessage: "hello"})
+	require.NoError(t, err)
+	transcript, err := svc.Get("redis-conv-1")
+	require.NoError(t, err)
+	assert.Equal(t, "team-a", transcript.Tenant)
+
+	listed, err := svc.List(ListFilter{Tenant: "team-a"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, listed)
+
+	require.NoError(t, svc.Delete("redis-conv-1"))
+	_, err = store.Get("redis-conv-1")
+	assert.ErrorIs(t, err, ErrConversationNotFound)
+}
+
+func TestPostgresStore(t *testing.T) {
+	url := os.Getenv("POSTGRES_URL")
+	if url == "" {
+		t.Skip("POSTGRES_URL not set, skipping Postgres conversation store test")
+	}
+	store, err := NewPostgresStore(context.Background(), url)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		store.Delete("postgres-conv-1")
+		store.Close()
+	})
+	gg := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	svc := NewService(gg, Options{Store: store})
+
+	_, err = svc.RecordTurn("postgres-conv-1", "team-a", Turn{UserMessage: "hello"})
+	require.NoError(t, err)
+	_, err = svc.RecordTurn("postgres-conv-1", "team-a", Turn{UserMessage: "deploy checkout"})
+	require.NoError(t, err)
+	transcript, err := svc.Get("postgres-conv-1")
+	require.NoError(t, err)
+	assert.Equal(t, "team-a", transcript.Tenant)
+	assert.Len(t, transcript.Turns, 2)
+
+	listed, err := svc.List(ListFilter{Tenant: "team-a"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, listed)
+
+	require.NoError(t, svc.Delete("postgres-conv-1"))
+	_, err = store.Get("postgres-conv-1")
+	assert.ErrorIs(t, err, ErrConversationNotFound)
+}
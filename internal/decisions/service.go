@@ -0,0 +1,407 @@
+// Package decisions keeps an audit trail of how the orchestrator routed chat requests: what it
+// was asked, which agents could have handled it, which one it chose and why.
+package decisions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/krzachariassen/ZTDP/internal/archive"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/krzachariassen/ZTDP/internal/redaction"
+)
+
+// ErrDecisionNotFound is returned when a decision does not exist
+var ErrDecisionNotFound = errors.New("decision not found")
+
+// DefaultCapacity is how many decisions are kept; older ones are pruned as new ones are recorded
+const DefaultCapacity = 1000
+
+// DefaultBatchSize is how many decisions go into one archive batch when unset
+const DefaultBatchSize = 100
+
+// archiveCollection names decision batches in the archive
+const archiveCollection = "decisions"
+
+// nodeIDPrefix namespaces decision nodes so they cannot collide with platform entities
+const nodeIDPrefix = "decision:"
+
+// maxInputLength bounds the stored summary of the request
+const maxInputLength = 500
+
+// Decision is one routing decision the orchestrator made for a chat request
+type Decision struct {
+	ID             string       `json:"id"`
+	Timestamp      time.Time    `json:"timestamp"`
+	CorrelationID  string       `json:"correlation_id,omitempty"`
+	ConversationID string       `json:"conversation_id,omitempty"`
+	Tenant         string       `json:"tenant,omitempty"`
+	Input          string       `json:"input"`      // the request, redacted and truncated
+	Intent         string       `json:"intent"`     // what the AI classified the request as
+	Candidates     []string     `json:"candidates"` // agents offering the intent, in the order they were tried
+	SelectedAgent  string       `json:"selected_agent,omitempty"`
+	RoutingKey     string       `json:"routing_key,omitempty"`
+	Outcome        string       `json:"outcome"` // completed | error | timeout | cancelled | needs_clarification | unroutable
+	Reasoning      string       `json:"reasoning"`
+	Confidence     float64      `json:"confidence,omitempty"`  // 0 when neither the AI nor the agent reported one
+	Arbitration    *Arbitration `json:"arbitration,omitempty"` // set when several agents bid for the request
+}
+
+// Arbitration records how the orchestrator chose between several agents offering the intent
+type Arbitration struct {
+	Policy string `json:"policy"`
+	Bids   []Bid  `json:"bids"` // in the order the agents were tried; declined and missing bids last
+	Winner string `json:"winner,omitempty"`
+}
+
+// Bid is one agent's answer to a bid request
+type Bid struct {
+	Agent      string  `json:"agent"`
+	Answered   bool    `json:"answered"` // false when the agent did not bid in time
+	Declined   bool    `json:"declined,omitempty"`
+	Confidence float64 `json:"confidence,omitempty"`
+	Cost       float64 `json:"cost,omitempty"`
+	ETASeconds float64 `json:"eta_seconds,omitempty"`
+	Reason     string  `json:"reason,omitempty"`
+}
+
+// Filter narrows List results. Zero values match everything.
+type Filter struct {
+	Agent           string
+	Intent          string
+	Outcome         string
+	ConversationID  string
+	Since           time.Time
+	Limit           int
+	IncludeArchived bool // also search archived decisions; only batches with matches are fetched
+}
+
+// Service records and queries decisions stored in the global graph
+type Service struct {
+	graph     *graph.GlobalGraph
+	capacity  int
+	logger    *logging.Logger
+	archive   *archive.Archive // nil deletes decisions beyond capacity
+	batchSize int
+}
+
+// NewService creates a decision service that keeps the newest capacity decisions
+func NewService(globalGraph *graph.GlobalGraph, capacity int) *Service {
+	if capacity <= 0 {
+		capacity = DefaultCapacity
+	}
+	return &Service{
+		graph:    globalGraph,
+		capacity: capacity,
+		logger:   logging.GetLogger().ForComponent("decisions"),
+	}
+}
+
+// ArchiveTo moves decisions beyond capacity to the archive instead of deleting them. They are
+// moved once batchSize of them have accumulated, so each batch holds at least that many.
+func (s *Service) ArchiveTo(a *archive.Archive, batchSize int) {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	s.archive = a
+	s.batchSize = batchSize
+}
+
+// Record stores a decision, assigning its ID and timestamp when unset
+func (s *Service) Record(decision Decision) (*Decision, error) {
+	if decision.ID == "" {
+		decision.ID = uuid.New().String()
+	}
+	if decision.Timestamp.IsZero() {
+		decision.Timestamp = time.Now().UTC()
+	}
+	input := []rune(redaction.Default().String(decision.Input))
+	if len(input) > maxInputLength {
+		input = append(input[:maxInputLength], '…')
+	}
+	decision.Input = string(input)
+	decision.Reasoning = redaction.Default().String(decision.Reasoning)
+	if decision.Candidates == nil {
+		decision.Candidates = []string{}
+	}
+
+	node, err := decisionToNode(&decision)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.graph.AddNode(node); err != nil {
+		return nil, fmt.Errorf("failed to record decision: %w", err)
+	}
+	s.prune()
+	return &decision, nil
+}
+
+// Get returns a decision by ID, fetching it from the archive when it is no longer in the graph
+func (s *Service) Get(id string) (*Decision, error) {
+	node, _ := s.graph.GetNode(nodeIDPrefix + id)
+	if node != nil && node.Kind == graph.KindAIDecision {
+		return nodeToDecision(node)
+	}
+	if s.archive == nil {
+		return nil, ErrDecisionNotFound
+	}
+	data, err := s.archive.Get(context.Background(), archiveCollection, id)
+	if errors.Is(err, archive.ErrNotArchived) {
+		return nil, ErrDecisionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var decision Decision
+	if err := json.Unmarshal(data, &decision); err != nil {
+		return nil, fmt.Errorf("failed to decode archived decision %s: %w", id, err)
+	}
+	return &decision, nil
+}
+
+// List returns decisions matching the filter, newest first
+func (s *Service) List(filter Filter) ([]*Decision, error) {
+	all, err := s.all()
+	if err != nil {
+		return nil, err
+	}
+	if filter.IncludeArchived && s.archive != nil {
+		archived, err := s.listArchived(filter)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, archived...)
+		sort.Slice(all, func(i, j int) bool {
+			return all[i].Timestamp.After(all[j].Timestamp)
+		})
+	}
+	matched := []*Decision{}
+	for _, decision := range all {
+		if filter.Agent != "" && decision.SelectedAgent != filter.Agent {
+			continue
+		}
+		if filter.Intent != "" && decision.Intent != filter.Intent {
+			continue
+		}
+		if filter.Outcome != "" && decision.Outcome != filter.Outcome {
+			continue
+		}
+		if filter.ConversationID != "" && decision.ConversationID != filter.ConversationID {
+			continue
+		}
+		if !filter.Since.IsZero() && decision.Timestamp.Before(filter.Since) {
+			continue
+		}
+		matched = append(matched, decision)
+	}
+	if filter.Limit > 0 && len(matched) > filter.Limit {
+		matched = matched[:filter.Limit]
+	}
+	return matched, nil
+}
+
+// ArbitrationStats summarizes how one agent fared when several agents bid for requests
+type ArbitrationStats struct {
+	Agent         string  `json:"agent"`
+	Arbitrations  int     `json:"arbitrations"` // arbitrations the agent was asked to bid in
+	Wins          int     `json:"wins"`
+	Declined      int     `json:"declined"`
+	Missed        int     `json:"missed"`         // bid requests not answered in time
+	AvgConfidence float64 `json:"avg_confidence"` // over the bids the agent made
+	WinRate       float64 `json:"win_rate"`
+}
+
+// ArbitrationStats summarizes the arbitrations of the decisions matching the filter by agent,
+// most wins first
+func (s *Service) ArbitrationStats(filter Filter) ([]ArbitrationStats, error) {
+	decisions, err := s.List(filter)
+	if err != nil {
+		return nil, err
+	}
+	byAgent := map[string]*ArbitrationStats{}
+	confidence := map[string]float64{}
+	bids := map[string]int{}
+	for _, decision := range decisions {
+		if decision.Arbitration == nil {
+			continue
+		}
+		for _, bid := range decision.Arbitration.Bids {
+			stats, ok := byAgent[bid.Agent]
+			if !ok {
+				stats = &ArbitrationStats{Agent: bid.Agent}
+				byAgent[bid.Agent] = stats
+			}
+			stats.Arbitrations++
+			switch {
+			case !bid.Answered:
+				stats.Missed++
+			case bid.Declined:
+				stats.Declined++
+			default:
+				confidence[bid.Agent] += bid.Confidence
+				bids[bid.Agent]++
+			}
+			if bid.Agent == decision.Arbitration.Winner {
+				stats.Wins++
+			}
+		}
+	}
+
+	summary := make([]ArbitrationStats, 0, len(byAgent))
+	for agent, stats := range byAgent {
+		if bids[agent] > 0 {
+			stats.AvgConfidence = confidence[agent] / float64(bids[agent])
+		}
+		stats.WinRate = float64(stats.Wins) / float64(stats.Arbitrations)
+		summary = append(summary, *stats)
+	}
+	sort.Slice(summary, func(i, j int) bool {
+		if summary[i].Wins != summary[j].Wins {
+			return summary[i].Wins > summary[j].Wins
+		}
+		return summary[i].Agent < summary[j].Agent
+	})
+	return summary, nil
+}
+
+// all returns every stored decision, newest first
+func (s *Service) all() ([]*Decision, error) {
+	nodes, err := s.graph.Nodes()
+	if err != nil {
+		return nil, err
+	}
+	decisions := []*Decision{}
+	for _, node := range nodes {
+		if node.Kind != graph.KindAIDecision {
+			continue
+		}
+		decision, err := nodeToDecision(node)
+		if err != nil {
+			s.logger.Warn("⚠️ Skipping unreadable decision %s: %v", node.ID, err)
+			continue
+		}
+		decisions = append(decisions, decision)
+	}
+	sort.Slice(decisions, func(i, j int) bool {
+		return decisions[i].Timestamp.After(decisions[j].Timestamp)
+	})
+	return decisions, nil
+}
+
+// listArchived returns the archived decisions that may match the filter
+func (s *Service) listArchived(filter Filter) ([]*Decision, error) {
+	records, err := s.archive.Find(context.Background(), archiveCollection, func(ref archive.Ref) bool {
+		return (filter.Agent == "" || containsLabel(ref.Labels, "agent:"+filter.Agent)) &&
+			(filter.Intent == "" || containsLabel(ref.Labels, "intent:"+filter.Intent)) &&
+			(filter.Outcome == "" || containsLabel(ref.Labels, "outcome:"+filter.Outcome)) &&
+			(filter.ConversationID == "" || containsLabel(ref.Labels, "conversation:"+filter.ConversationID)) &&
+			(filter.Since.IsZero() || !ref.Time.Before(filter.Since))
+	})
+	if err != nil {
+		return nil, err
+	}
+	decisions := make([]*Decision, 0, len(records))
+	for _, record := range records {
+		var decision Decision
+		if err := json.Unmarshal(record.Data, &decision); err != nil {
+			s.logger.Warn("⚠️ Skipping unreadable archived decision %s: %v", record.ID, err)
+			continue
+		}
+		decisions = append(decisions, &decision)
+	}
+	return decisions, nil
+}
+
+// prune deletes the oldest decisions beyond capacity, or archives them once a batch has accumulated
+func (s *Service) prune() {
+	decisions, err := s.all()
+	if err != nil || len(decisions) <= s.capacity {
+		return
+	}
+	if s.archive != nil {
+		if len(decisions)-s.capacity < s.batchSize {
+			return
+		}
+		if err := s.archiveDecisions(decisions[s.capacity:]); err != nil {
+			s.logger.Warn("⚠️ Could not archive decisions: %v", err)
+			return
+		}
+	}
+	for _, decision := range decisions[s.capacity:] {
+		if err := s.graph.DeleteNode(nodeIDPrefix + decision.ID); err != nil {
+			s.logger.Warn("⚠️ Could not prune decision %s: %v", decision.ID, err)
+		}
+	}
+}
+
+func (s *Service) archiveDecisions(decisions []*Decision) error {
+	records := make([]archive.Record, 0, len(decisions))
+	for _, decision := range decisions {
+		data, err := json.Marshal(decision)
+		if err != nil {
+			return fmt.Errorf("failed to encode decision %s: %w", decision.ID, err)
+		}
+		ref := archive.Ref{ID: decision.ID, Time: decision.Timestamp, Labels: []string{"intent:" + decision.Intent, "outcome:" + decision.Outcome}}
+		if decision.SelectedAgent != "" {
+			ref.Labels = append(ref.Labels, "agent:"+decision.SelectedAgent)
+		}
+		if decision.ConversationID != "" {
+			ref.Labels = append(ref.Labels, "conversation:"+decision.ConversationID)
+		}
+		records = append(records, archive.Record{Ref: ref, Data: data})
+	}
+	_, err := s.archive.Write(context.Background(), archiveCollection, records)
+	return err
+}
+
+func containsLabel(labels []string, label string) bool {
+	for _, l := range labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+func decisionToNode(decision *Decision) (*graph.Node, error) {
+	data, err := json.Marshal(decision)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode decision: %w", err)
+	}
+	var spec map[string]interface{}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to encode decision: %w", err)
+	}
+
+	return &graph.Node{
+		ID:   nodeIDPrefix + decision.ID,
+		Kind: graph.KindAIDecision,
+		Metadata: map[string]interface{}{
+			"name":           decision.ID,
+			"intent":         decision.Intent,
+			"selected_agent": decision.SelectedAgent,
+			"outcome":        decision.Outcome,
+			"timestamp":      decision.Timestamp.Format(time.RFC3339),
+		},
+		Spec: spec,
+	}, nil
+}
+
+// nodeToDecision decodes the decision from the node spec
+func nodeToDecision(node *graph.Node) (*Decision, error) {
+	data, err := json.Marshal(node.Spec)
+	if err != nil {
+		return nil, err
+	}
+	var decision Decision
+	if err := json.Unmarshal(data, &decision); err != nil {
+		return nil, err
+	}
+	return &decision, nil
+}
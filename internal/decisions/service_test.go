@@ -0,0 +1,101 @@
+package decisions
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/archive"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordAndGet(t *testing.T) {
+	svc := NewService(graph.NewGlobalGraph(graph.NewMemoryGraph()), 10)
+
+	recorded, err := svc.Record(Decision{
+		Input:         strings.Repeat("deploy checkout ", 100),
+		Intent:        "deploy application",
+		Candidates:    []string{"deployment-agent"},
+		SelectedAgent: "deployment-agent",
+		Outcome:       "completed",
+		Reasoning:     "Only deployment-agent offers it.",
+		Confidence:    0.9,
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, recorded.ID)
+	assert.False(t, recorded.Timestamp.IsZero())
+	assert.LessOrEqual(t, len([]rune(recorded.Input)), maxInputLength+1, "input is truncated")
+
+	got, err := svc.Get(recorded.ID)
+	require.NoError(t, err)
+	assert.Equal(t, recorded, got)
+
+	_, err = svc.Get("missing")
+	assert.ErrorIs(t, err, ErrDecisionNotFound)
+}
+
+func TestListFiltersAndPrunes(t *testing.T) {
+	svc := NewService(graph.NewGlobalGraph(graph.NewMemoryGraph()), 3)
+	start := time.Now().UTC()
+	for i, agent := range []string{"a", "b", "a", "b"} {
+		_, err := svc.Record(Decision{
+			Timestamp:     start.Add(time.Duration(i) * time.Minute),
+			Intent:        "deploy application",
+			SelectedAgent: agent,
+			Outcome:       "completed",
+		})
+		require.NoError(t, err)
+	}
+
+	all, err := svc.List(Filter{})
+	require.NoError(t, err)
+	require.Len(t, all, 3, "the oldest decision is pruned")
+	assert.True(t, all[0].Timestamp.After(all[1].Timestamp), "newest first")
+
+	byAgent, err := svc.List(Filter{Agent: "a"})
+	require.NoError(t, err)
+	assert.Len(t, byAgent, 1)
+
+	limited, err := svc.List(Filter{Since: start.Add(90 * time.Second), Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, limited, 1)
+	assert.Equal(t, "b", limited[0].SelectedAgent)
+}
+
+func TestArchiveTo_MovesDecisionsBeyondCapacityInBatches(t *testing.T) {
+	g := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	store, err := archive.NewDirStore(t.TempDir())
+	require.NoError(t, err)
+	svc := NewService(g, 2)
+	svc.ArchiveTo(archive.New(g, store), 2)
+	start := time.Now().UTC()
+	var recorded []*Decision
+	for i, agent := range []string{"a", "b", "a", "b", "a"} {
+		decision, err := svc.Record(Decision{
+			Timestamp:     start.Add(time.Duration(i) * time.Minute),
+			Intent:        "deploy application",
+			SelectedAgent: agent,
+			Outcome:       "completed",
+		})
+		require.NoError(t, err)
+		recorded = append(recorded, decision)
+	}
+
+	stored, err := svc.List(Filter{})
+	require.NoError(t, err)
+	assert.Len(t, stored, 3, "one decision beyond capacity waits for a full batch")
+
+	archived, err := svc.Get(recorded[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, recorded[0], archived)
+
+	all, err := svc.List(Filter{IncludeArchived: true})
+	require.NoError(t, err)
+	require.Len(t, all, 5)
+	assert.Equal(t, recorded[4].ID, all[0].ID, "newest first")
+	byAgent, err := svc.List(Filter{Agent: "a", IncludeArchived: true})
+	require.NoError(t, err)
+	assert.Len(t, byAgent, 3)
+}
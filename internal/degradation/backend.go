@@ -0,0 +1,100 @@
+package degradation
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+)
+
+// ErrReadOnly is returned for graph writes while the graph backend is unavailable
+var ErrReadOnly = errors.New("graph is read-only while the graph backend is unavailable")
+
+// Backend wraps a graph backend so the platform keeps serving reads when it is down. Every
+// successful load is kept; while read-only, failed loads return a copy of the last one and
+// saves are rejected with ErrReadOnly instead of being attempted.
+type Backend struct {
+	graph.GraphBackend
+
+	mu       sync.RWMutex
+	readOnly bool
+	lastGood *graph.Graph
+}
+
+// NewBackend wraps inner
+func NewBackend(inner graph.GraphBackend) *Backend {
+	return &Backend{GraphBackend: inner}
+}
+
+// SetReadOnly enters or leaves read-only mode
+func (b *Backend) SetReadOnly(readOnly bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.readOnly = readOnly
+}
+
+// ReadOnly reports whether writes are currently rejected
+func (b *Backend) ReadOnly() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.readOnly
+}
+
+// LoadGlobal loads the graph, falling back to the last loaded one while read-only
+func (b *Backend) LoadGlobal() (*graph.Graph, error) {
+	g, err := b.GraphBackend.LoadGlobal()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err == nil {
+		if g != nil {
+			b.lastGood = g.Clone()
+		}
+		return g, nil
+	}
+	if b.readOnly && b.lastGood != nil {
+		return b.lastGood.Clone(), nil
+	}
+	return nil, err
+}
+
+// SaveGlobal saves the graph unless the backend is read-only
+func (b *Backend) SaveGlobal(g *graph.Graph) error {
+	return b.SaveGlobalContext(context.Background(), g)
+}
+
+// SaveGlobalContext is SaveGlobal passing the save's context on
+func (b *Backend) SaveGlobalContext(ctx context.Context, g *graph.Graph) error {
+	if b.ReadOnly() {
+		return ErrReadOnly
+	}
+	if err := graph.SaveWithContext(ctx, b.GraphBackend, g); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	b.lastGood = g.Clone()
+	b.mu.Unlock()
+	return nil
+}
+
+// Clear empties the graph unless the backend is read-only
+func (b *Backend) Clear() error {
+	if b.ReadOnly() {
+		return ErrReadOnly
+	}
+	if err := b.GraphBackend.Clear(); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	b.lastGood = graph.NewGraph()
+	b.mu.Unlock()
+	return nil
+}
+
+// Ping passes through to backends that can verify connectivity, so recovery is noticed while read-only
+func (b *Backend) Ping(ctx context.Context) error {
+	if pinger, ok := b.GraphBackend.(graph.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
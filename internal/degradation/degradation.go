@@ -0,0 +1,251 @@
+// Package degradation keeps ZTDP serving when one of its own dependencies is down by putting
+// the platform in a reduced mode for that dependency instead of failing: a graph backend outage
+// makes the graph read-only, an AI outage routes requests deterministically and an event
+// transport outage handles events locally and synchronously. Modes are entered and left as the
+// health checker's probes fail and recover.
+package degradation
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/health"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// Modes the platform enters while a dependency is down
+const (
+	ModeReadOnly      = "read_only"     // graph backend down: reads come from the last loaded graph, writes are rejected
+	ModeDeterministic = "deterministic" // AI provider down: requests are routed by the deterministic handlers
+	ModeLocalEvents   = "local_events"  // event transport down: events are handled by local subscribers, synchronously
+)
+
+// TierFull is the service tier while every watched dependency is up. Otherwise the tier is the
+// most severe active mode.
+const TierFull = "full"
+
+// severity orders the modes from least to most severe
+var severity = map[string]int{
+	ModeDeterministic: 1,
+	ModeLocalEvents:   2,
+	ModeReadOnly:      3,
+}
+
+// ChangedSubject is the notify event emitted each time a dependency goes down or recovers
+const ChangedSubject = "platform_degradation_changed"
+
+// transitionHistory is how many transitions Status keeps
+const transitionHistory = 20
+
+// DependencyStatus is the state of one watched dependency
+type DependencyStatus struct {
+	Name      string    `json:"name"`
+	Mode      string    `json:"mode"` // entered while the dependency is down
+	Available bool      `json:"available"`
+	Error     string    `json:"error,omitempty"`
+	Since     time.Time `json:"since"` // when availability last changed
+}
+
+// Transition is a dependency going down or recovering
+type Transition struct {
+	Dependency string    `json:"dependency"`
+	Mode       string    `json:"mode"`
+	Degraded   bool      `json:"degraded"` // true when the mode was entered, false when it was left
+	Error      string    `json:"error,omitempty"`
+	Tier       string    `json:"tier"` // the tier after the transition
+	At         time.Time `json:"at"`
+}
+
+// Status is the platform's current service tier
+type Status struct {
+	Tier         string             `json:"tier"`
+	Modes        []string           `json:"modes"` // active modes, most severe first
+	Dependencies []DependencyStatus `json:"dependencies"`
+	Transitions  []Transition       `json:"transitions"` // most recent last
+	CheckedAt    time.Time          `json:"checked_at"`
+}
+
+type dependency struct {
+	status DependencyStatus
+	apply  func(degraded bool)
+}
+
+// Monitor watches health checks and moves the platform between modes as they fail and recover
+type Monitor struct {
+	checker *health.Checker
+	bus     *events.EventBus // nil disables transition events
+	logger  *logging.Logger
+
+	mu           sync.RWMutex
+	dependencies []*dependency
+	transitions  []Transition
+	checkedAt    time.Time
+	now          func() time.Time
+}
+
+// NewMonitor creates a monitor driven by the checker's probes, announcing transitions on bus
+func NewMonitor(checker *health.Checker, bus *events.EventBus) *Monitor {
+	return &Monitor{
+		checker: checker,
+		bus:     bus,
+		logger:  logging.GetLogger().ForComponent("degradation"),
+		now:     time.Now,
+	}
+}
+
+// Register puts the platform in mode while the health check called name fails. apply is called
+// with true when the mode is entered and false when it is left; it may be nil when callers only
+// consult Active.
+func (m *Monitor) Register(name, mode string, apply func(degraded bool)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dependencies = append(m.dependencies, &dependency{
+		status: DependencyStatus{Name: name, Mode: mode, Available: true, Since: m.now().UTC()},
+		apply:  apply,
+	})
+}
+
+// Check runs the health checks once and enters or leaves modes for dependencies whose
+// availability changed
+func (m *Monitor) Check(ctx context.Context) Status {
+	results := map[string]health.CheckResult{}
+	for _, result := range m.checker.Check(ctx).Checks {
+		results[result.Name] = result
+	}
+
+	type change struct {
+		dep        *dependency
+		transition Transition
+	}
+	var changes []change
+
+	m.mu.Lock()
+	now := m.now().UTC()
+	m.checkedAt = now
+	for _, dep := range m.dependencies {
+		result, ok := results[dep.status.Name]
+		if !ok {
+			continue
+		}
+		available := result.Status == health.StatusOK
+		dep.status.Error = result.Error
+		if available == dep.status.Available {
+			continue
+		}
+		dep.status.Available = available
+		dep.status.Since = now
+		changes = append(changes, change{dep: dep, transition: Transition{
+			Dependency: dep.status.Name,
+			Mode:       dep.status.Mode,
+			Degraded:   !available,
+			Error:      result.Error,
+			At:         now,
+		}})
+	}
+	for i := range changes {
+		changes[i].transition.Tier = m.tierLocked()
+		m.transitions = append(m.transitions, changes[i].transition)
+	}
+	if len(m.transitions) > transitionHistory {
+		m.transitions = m.transitions[len(m.transitions)-transitionHistory:]
+	}
+	m.mu.Unlock()
+
+	// Modes are applied before the event is emitted, so an event transport outage is
+	// announced through the local handlers it switched to
+	for _, c := range changes {
+		if c.dep.apply != nil {
+			c.dep.apply(c.transition.Degraded)
+		}
+		m.announce(c.transition)
+	}
+	return m.Status()
+}
+
+// Run checks every interval until ctx is done
+func (m *Monitor) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		m.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Active reports whether the platform is currently in mode
+func (m *Monitor) Active(mode string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, dep := range m.dependencies {
+		if dep.status.Mode == mode && !dep.status.Available {
+			return true
+		}
+	}
+	return false
+}
+
+// Status returns the current tier, the state of every dependency and the recent transitions
+func (m *Monitor) Status() Status {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	status := Status{
+		Tier:         m.tierLocked(),
+		Modes:        m.modesLocked(),
+		Dependencies: make([]DependencyStatus, 0, len(m.dependencies)),
+		Transitions:  append([]Transition{}, m.transitions...),
+		CheckedAt:    m.checkedAt,
+	}
+	for _, dep := range m.dependencies {
+		status.Dependencies = append(status.Dependencies, dep.status)
+	}
+	return status
+}
+
+// modesLocked lists the active modes, most severe first
+func (m *Monitor) modesLocked() []string {
+	modes := []string{}
+	seen := map[string]bool{}
+	for _, dep := range m.dependencies {
+		if !dep.status.Available && !seen[dep.status.Mode] {
+			seen[dep.status.Mode] = true
+			modes = append(modes, dep.status.Mode)
+		}
+	}
+	sort.SliceStable(modes, func(i, j int) bool { return severity[modes[i]] > severity[modes[j]] })
+	return modes
+}
+
+func (m *Monitor) tierLocked() string {
+	if modes := m.modesLocked(); len(modes) > 0 {
+		return modes[0]
+	}
+	return TierFull
+}
+
+// announce logs the transition and emits it as a notify event
+func (m *Monitor) announce(t Transition) {
+	if t.Degraded {
+		m.logger.Warn("⚠️ %s unavailable, entering %s mode (tier: %s): %s", t.Dependency, t.Mode, t.Tier, t.Error)
+	} else {
+		m.logger.Info("✅ %s recovered, leaving %s mode (tier: %s)", t.Dependency, t.Mode, t.Tier)
+	}
+	if m.bus == nil {
+		return
+	}
+	if err := m.bus.Emit(events.EventTypeNotify, "degradation", ChangedSubject, map[string]interface{}{
+		"dependency": t.Dependency,
+		"mode":       t.Mode,
+		"degraded":   t.Degraded,
+		"error":      t.Error,
+		"tier":       t.Tier,
+	}); err != nil {
+		m.logger.Warn("⚠️ Failed to emit degradation change: %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package degradation
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/health"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// switchableProbe fails while err is set
+type switchableProbe struct {
+	mu  sync.Mutex
+	err error
+}
+
+func (p *switchableProbe) set(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.err = err
+}
+
+func (p *switchableProbe) probe(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
+
+func TestMonitorEntersAndLeavesModes(t *testing.T) {
+	graphProbe, aiProbe := &switchableProbe{}, &switchableProbe{}
+	checker := health.NewChecker(0)
+	checker.Register("graph_backend", false, graphProbe.probe)
+	checker.Register("ai_provider", false, aiProbe.probe)
+
+	bus := events.NewEventBus(nil, false)
+	var announced []events.Event
+	bus.Subscribe(events.EventTypeNotify, func(event events.Event) error {
+		if event.Subject == ChangedSubject {
+			announced = append(announced, event)
+		}
+		return nil
+	})
+
+	monitor := NewMonitor(checker, bus)
+	readOnly := false
+	monitor.Register("graph_backend", ModeReadOnly, func(degraded bool) { readOnly = degraded })
+	monitor.Register("ai_provider", ModeDeterministic, nil)
+
+	status := monitor.Check(context.Background())
+	assert.Equal(t, TierFull, status.Tier)
+	assert.Empty(t, status.Modes)
+	assert.Empty(t, announced, "nothing changed")
+
+	aiProbe.set(errors.New("timeout"))
+	graphProbe.set(errors.New("connection refused"))
+	status = monitor.Check(context.Background())
+	assert.Equal(t, ModeReadOnly, status.Tier, "the most severe mode is the tier")
+	assert.Equal(t, []string{ModeReadOnly, ModeDeterministic}, status.Modes)
+	assert.True(t, readOnly)
+	assert.True(t, monitor.Active(ModeDeterministic))
+	require.Len(t, announced, 2)
+	assert.Equal(t, "graph_backend", announced[0].Payload["dependency"])
+	assert.Equal(t, true, announced[0].Payload["degraded"])
+	assert.Equal(t, "connection refused", announced[0].Payload["error"])
+
+	graphProbe.set(nil)
+	status = monitor.Check(context.Background())
+	assert.Equal(t, ModeDeterministic, status.Tier)
+	assert.False(t, readOnly)
+	require.Len(t, announced, 3)
+	assert.Equal(t, false, announced[2].Payload["degraded"])
+	assert.Equal(t, ModeDeterministic, announced[2].Payload["tier"])
+
+	require.Len(t, status.Transitions, 3)
+	assert.True(t, status.Transitions[0].Degraded)
+	assert.False(t, status.Transitions[2].Degraded)
+	for _, dep := range status.Dependencies {
+		assert.Equal(t, dep.Name == "graph_backend", dep.Available, dep.Name)
+	}
+}
+
+// failingBackend fails every call while down
+type failingBackend struct {
+	graph.GraphBackend
+	down bool
+}
+
+func (b *failingBackend) LoadGlobal() (*graph.Graph, error) {
+	if b.down {
+		return nil, errors.New("connection refused")
+	}
+	return b.GraphBackend.LoadGlobal()
+}
+
+func (b *failingBackend) SaveGlobal(g *graph.Graph) error {
+	if b.down {
+		return errors.New("connection refused")
+	}
+	return b.GraphBackend.SaveGlobal(g)
+}
+
+func TestBackendServesLastGraphWhileReadOnly(t *testing.T) {
+	inner := &failingBackend{GraphBackend: graph.NewMemoryGraph()}
+	backend := NewBackend(inner)
+	gg := graph.NewGlobalGraph(backend)
+	require.NoError(t, gg.AddNode(&graph.Node{ID: "checkout", Kind: "application"}))
+
+	inner.down = true
+	nodes, _ := gg.Nodes()
+	assert.Empty(t, nodes, "loads fail until read-only mode is entered")
+
+	backend.SetReadOnly(true)
+	nodes, err := gg.Nodes()
+	require.NoError(t, err)
+	assert.Contains(t, nodes, "checkout")
+	err = gg.AddNode(&graph.Node{ID: "payments", Kind: "application"})
+	assert.ErrorIs(t, err, ErrReadOnly)
+
+	inner.down = false
+	backend.SetReadOnly(false)
+	require.NoError(t, gg.AddNode(&graph.Node{ID: "payments", Kind: "application"}))
+	nodes, err = gg.Nodes()
+	require.NoError(t, err)
+	assert.Len(t, nodes, 2)
+}
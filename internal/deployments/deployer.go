@@ -0,0 +1,106 @@
+package deployments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// ErrDeploymentBlocked is returned when the deployment gates refused a deployment
+var ErrDeploymentBlocked = errors.New("deployment blocked by policy")
+
+// Request is a deployment of an application to an environment
+type Request struct {
+	Application string
+	Environment string
+	// Regions fans the rollout out to these regions of the environment; none deploys it once
+	Regions []string
+	// ReleaseID deploys an existing release, such as a release bundle, instead of creating one
+	ReleaseID string
+	// CorrelationID identifies the request holding the environment lock while it deploys
+	CorrelationID string
+	// Metadata is recorded on the deployment edges next to the pipeline's own keys
+	Metadata map[string]interface{}
+}
+
+// Deployer runs deployments through the deployment agent's pipeline for components deploying on
+// an application's behalf, such as release bundles and workflow steps. Under the environment
+// lock it validates the services' configuration and dependencies, records the deployment edge,
+// runs the deployment gates and pending schema migrations, and executes the rollout with
+// retries. Nothing else writes deployment outcomes to the graph.
+type Deployer struct {
+	agent *FrameworkDeploymentAgent
+}
+
+// NewDeployer creates a deployer. locks are the environment locks shared with everything else
+// that deploys; nil gives the deployer its own. eventBus may be nil.
+func NewDeployer(globalGraph *graph.GlobalGraph, eventBus *events.EventBus, locks *EnvironmentLocks) *Deployer {
+	if eventBus == nil {
+		// Progress and completion events need a bus even when nobody listens
+		eventBus = events.NewEventBus(nil, false)
+	}
+	if locks == nil {
+		locks = NewEnvironmentLocks(DeploymentLockTTL)
+	}
+	return &Deployer{agent: &FrameworkDeploymentAgent{
+		service:  NewDeploymentService(globalGraph, nil),
+		logger:   logging.GetLogger().ForComponent("deployer"),
+		eventBus: eventBus,
+		locks:    locks,
+	}}
+}
+
+// Deploy runs the deployment and returns its result. The request's correlation ID defaults to
+// the context's, so a request already holding the environment lock deploys within it. It
+// returns an *InProgressError while another request deploys the application to the
+// environment, and an error wrapping ErrDeploymentBlocked when the gates refused it.
+func (d *Deployer) Deploy(ctx context.Context, req Request) (*DeploymentResult, error) {
+	g, err := d.agent.service.globalGraph.Graph()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph: %w", err)
+	}
+	if node, ok := g.Nodes[req.Application]; !ok || node.Kind != graph.KindApplication {
+		return nil, fmt.Errorf("application %s not found", req.Application)
+	}
+	if node, ok := g.Nodes[req.Environment]; !ok || node.Kind != graph.KindEnvironment {
+		return nil, fmt.Errorf("environment %s not found", req.Environment)
+	}
+	if req.CorrelationID == "" {
+		req.CorrelationID = logging.CorrelationIDFromContext(ctx)
+	}
+	if req.CorrelationID == "" {
+		req.CorrelationID = fmt.Sprintf("deploy-%s-%s-%d", req.Application, req.Environment, time.Now().UnixNano())
+	}
+	return d.agent.deploy(ctx, req)
+}
+
+// DeployApplication deploys the application to the environment in a new release
+func (d *Deployer) DeployApplication(ctx context.Context, appName, environment string) (*DeploymentResult, error) {
+	return d.Deploy(ctx, Request{Application: appName, Environment: environment})
+}
+
+// GetDeploymentStatus reports the application deployed to the environment once one of its
+// deployments there succeeded, naming the latest successful release
+func (d *Deployer) GetDeploymentStatus(appName, environment string) (map[string]interface{}, error) {
+	attempts, err := d.agent.service.DeploymentHistory(appName, environment)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(attempts) - 1; i >= 0; i-- {
+		if attempts[i].Status == string(StatusSucceeded) {
+			return map[string]interface{}{
+				"status":        "deployed",
+				"application":   appName,
+				"environment":   environment,
+				"release_id":    attempts[i].ReleaseID,
+				"deployment_id": attempts[i].DeploymentID,
+			}, nil
+		}
+	}
+	return d.agent.service.GetDeploymentStatus(appName, environment)
+}
@@ -0,0 +1,92 @@
+package deployments
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/calendar"
+	"github.com/krzachariassen/ZTDP/internal/governance"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDeployerTestGraph(t *testing.T) *graph.GlobalGraph {
+	t.Helper()
+	g := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	require.NoError(t, g.AddNode(&graph.Node{ID: "checkout", Kind: graph.KindApplication, Metadata: map[string]interface{}{"name": "checkout"}, Spec: map[string]interface{}{}}))
+	require.NoError(t, g.AddNode(&graph.Node{ID: "prod", Kind: graph.KindEnvironment, Metadata: map[string]interface{}{"name": "prod"}, Spec: map[string]interface{}{}}))
+	return g
+}
+
+func TestDeployer_DeploysThroughThePipeline(t *testing.T) {
+	g := newDeployerTestGraph(t)
+	deployer := NewDeployer(g, nil, nil)
+
+	result, err := deployer.Deploy(context.Background(), Request{
+		Application: "checkout",
+		Environment: "prod",
+		ReleaseID:   "bundle-checkout-1",
+		Metadata:    map[string]interface{}{"bundle_digest": "abc123", "status": "forged"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "completed", result.Status)
+	assert.Equal(t, "bundle-checkout-1", result.ReleaseID)
+
+	current, err := g.Graph()
+	require.NoError(t, err)
+	require.Len(t, current.Edges["bundle-checkout-1"], 1)
+	edge := current.Edges["bundle-checkout-1"][0]
+	assert.Equal(t, "abc123", edge.Metadata["bundle_digest"])
+	assert.Equal(t, string(StatusSucceeded), edge.Metadata["status"], "metadata cannot override the pipeline's keys")
+	decision := governance.LookupDecision(current, "prod", "bundle-checkout-1")
+	require.NotNil(t, decision, "the gates ran for the release")
+	assert.Equal(t, governance.DecisionAllowed, decision.Decision)
+
+	status, err := deployer.GetDeploymentStatus("checkout", "prod")
+	require.NoError(t, err)
+	assert.Equal(t, "deployed", status["status"])
+	assert.Equal(t, "bundle-checkout-1", status["release_id"])
+
+	_, err = deployer.Deploy(context.Background(), Request{Application: "checkout", Environment: "staging"})
+	assert.ErrorContains(t, err, "environment staging not found")
+}
+
+func TestDeployer_RecordsBlockedDeployments(t *testing.T) {
+	g := newDeployerTestGraph(t)
+	now := time.Now()
+	_, err := calendar.NewService(g).Schedule(calendar.Entry{Type: calendar.TypeFreeze, Environment: "prod", Start: now.Add(-time.Hour), End: now.Add(time.Hour), Reason: "quarter close"}, false)
+	require.NoError(t, err)
+
+	_, err = NewDeployer(g, nil, nil).DeployApplication(context.Background(), "checkout", "prod")
+	assert.ErrorIs(t, err, ErrDeploymentBlocked)
+
+	attempts, err := NewDeploymentService(g, nil).DeploymentHistory("checkout", "prod")
+	require.NoError(t, err)
+	require.Len(t, attempts, 1)
+	assert.Equal(t, "blocked", attempts[0].Status)
+}
+
+func TestDeployer_SharesEnvironmentLocks(t *testing.T) {
+	g := newDeployerTestGraph(t)
+	locks := NewEnvironmentLocks(DeploymentLockTTL)
+	deployer := NewDeployer(g, nil, locks)
+
+	release, err := locks.Acquire("checkout", "prod", "promotion-1")
+	require.NoError(t, err)
+
+	_, err = deployer.DeployApplication(context.Background(), "checkout", "prod")
+	var inProgress *InProgressError
+	require.True(t, errors.As(err, &inProgress), "another request holds the environment")
+	assert.Equal(t, "promotion-1", inProgress.CorrelationID)
+
+	_, err = deployer.DeployApplication(logging.WithCorrelationID(context.Background(), "promotion-1"), "checkout", "prod")
+	require.NoError(t, err, "the holding request deploys within its lock")
+
+	release()
+	_, err = deployer.DeployApplication(context.Background(), "checkout", "prod")
+	assert.NoError(t, err)
+}
@@ -2,17 +2,34 @@ package deployments
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
-	"github.com/krzachariassen/ZTDP/internal/agentFramework"
 	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
 	"github.com/krzachariassen/ZTDP/internal/ai"
+	"github.com/krzachariassen/ZTDP/internal/calendar"
+	"github.com/krzachariassen/ZTDP/internal/contracts"
 	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/features"
 	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/guardrails"
 	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/krzachariassen/ZTDP/internal/migrations"
+	"github.com/krzachariassen/ZTDP/internal/plans"
+	"github.com/krzachariassen/ZTDP/internal/resources"
+	servicecore "github.com/krzachariassen/ZTDP/internal/service"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
 )
 
+// policyQueryTimeout bounds how long a deployment waits for the Policy Agent's decision
+const policyQueryTimeout = 30 * time.Second
+
+// executeAttempts is how often a deployment is executed before it is reported failed
+const executeAttempts = 3
+
 // FrameworkDeploymentAgent wraps the deployment business logic in the new agent framework
 type FrameworkDeploymentAgent struct {
 	service      *Service
@@ -20,15 +37,25 @@ type FrameworkDeploymentAgent struct {
 	logger       *logging.Logger
 	eventBus     *events.EventBus // Store EventBus for emitting events
 	currentEvent *events.Event    // Store current event context for correlation
+	locks        *EnvironmentLocks
+	// execute rolls a release out to an environment, or one region of it; executeDeployment
+	// when nil
+	execute func(ctx context.Context, appName, environment, region, releaseID, deploymentID string) (*DeploymentResult, error)
 }
 
-// NewDeploymentAgent creates a DeploymentAgent using the agent framework
+// NewDeploymentAgent creates a DeploymentAgent using the agent framework. locks are the
+// environment locks shared with everything else that deploys; nil gives the agent its own.
 func NewDeploymentAgent(
 	graph *graph.GlobalGraph,
 	aiProvider ai.AIProvider,
 	eventBus *events.EventBus,
 	registry agentRegistry.AgentRegistry,
+	locks *EnvironmentLocks,
 ) (agentRegistry.AgentInterface, error) {
+	if locks == nil {
+		locks = NewEnvironmentLocks(DeploymentLockTTL)
+	}
+
 	// Create the deployment service for business logic
 	service := NewDeploymentService(graph, aiProvider)
 
@@ -38,16 +65,18 @@ func NewDeploymentAgent(
 		env:      "", // Agents are environment-agnostic
 		logger:   logging.GetLogger().ForComponent("deployment-agent"),
 		eventBus: eventBus,
+		locks:    locks,
 	}
 
 	// Create dependencies for the framework
-	deps := agentFramework.AgentDependencies{
+	deps := agentframework.AgentDependencies{
 		Registry: registry,
 		EventBus: eventBus,
+		Flags:    features.NewService(graph),
 	}
 
 	// Build the agent using the framework
-	agent, err := agentFramework.NewAgent("deployment-agent").
+	agent, err := agentframework.NewAgent("deployment-agent").
 		WithType("deployment").
 		WithCapabilities(getDeploymentCapabilities()).
 		WithEventHandler(wrapper.handleEvent).
@@ -104,6 +133,20 @@ func getDeploymentCapabilities() []agentRegistry.AgentCapability {
 	}
 }
 
+// deploymentParamsFromEnvelope returns the parameters the request's context envelope already
+// settles, or nil when the envelope does not name both the application and the environment
+func deploymentParamsFromEnvelope(event *events.Event) *DeploymentDomainParams {
+	envelope, ok := agentframework.ContextEnvelopeFrom(event)
+	if !ok {
+		return nil
+	}
+	app, env := envelope.Entity(graph.KindApplication), envelope.Entity(graph.KindEnvironment)
+	if app == "" || env == "" {
+		return nil
+	}
+	return &DeploymentDomainParams{Action: "deploy", AppName: app, Environment: env, Confidence: 1}
+}
+
 // handleEvent is the main event handler for AI-native deployment processing
 func (a *FrameworkDeploymentAgent) handleEvent(ctx context.Context, event *events.Event) (*events.Event, error) {
 	// Store current event for correlation context
@@ -119,8 +162,22 @@ func (a *FrameworkDeploymentAgent) handleEvent(ctx context.Context, event *event
 
 	a.logger.Info("🤖 AI-native deployment execution: %s", userMessage)
 
-	// Use deployment service's AI extraction method (proper AI-native approach)
-	params, err := a.service.ExtractDeploymentParamsFromUserMessage(ctx, userMessage)
+	// The orchestrator may already have found the application and environment in the graph;
+	// only ask the AI when it has not
+	params := deploymentParamsFromEnvelope(event)
+	var err error
+	if params == nil {
+		params, err = a.service.ExtractDeploymentParamsFromUserMessage(ctx, userMessage)
+	}
+	// Request clarification when the AI is not sure about the details
+	var lowConfidence *ai.LowConfidenceError
+	if errors.As(err, &lowConfidence) {
+		clarificationMsg := lowConfidence.Clarification
+		if clarificationMsg == "" {
+			clarificationMsg = "I'm not sure about the deployment details. Please specify the application name and target environment clearly."
+		}
+		return agentframework.ClarificationResponse(ctx, event, clarificationMsg, lowConfidence.Confidence), nil
+	}
 	if err != nil {
 		a.logger.Error("AI parameter extraction failed: %v", err)
 		return a.createErrorResponse(event, fmt.Sprintf("failed to parse deployment request: %v", err)), nil
@@ -129,15 +186,6 @@ func (a *FrameworkDeploymentAgent) handleEvent(ctx context.Context, event *event
 	a.logger.Info("🤖 AI extracted - action: %s, app: %s, env: %s, confidence: %.2f",
 		params.Action, params.AppName, params.Environment, params.Confidence)
 
-	// Check confidence level - request clarification if too low
-	if params.Confidence < 0.7 {
-		clarificationMsg := params.Clarification
-		if clarificationMsg == "" {
-			clarificationMsg = "I'm not sure about the deployment details. Please specify the application name and target environment clearly."
-		}
-		return a.createErrorResponse(event, clarificationMsg), nil
-	}
-
 	// Validate required parameters
 	if params.AppName == "" {
 		return a.createErrorResponse(event, "Application name is required for deployment"), nil
@@ -152,8 +200,53 @@ func (a *FrameworkDeploymentAgent) handleEvent(ctx context.Context, event *event
 
 	a.logger.Info("🎯 AI validated parameters - app: %s, env: %s", appName, environment)
 
-	// ✅ ORCHESTRATION WORKFLOW - Coordinate with other agents
-	result, err := a.orchestrateDeployment(ctx, appName, environment, userMessage)
+	// Multi-region deployments fan out to the requested regions of the environment, all of them
+	// unless the user named some
+	multiRegion, names := requestedRegions(event, params)
+	regions, err := targetRegions(a.service.globalGraph, environment, multiRegion, names)
+	if err != nil {
+		return a.createErrorResponse(event, err.Error()), nil
+	}
+
+	// Planning requests only propose a plan; the user revises and approves it in the conversation
+	if event.Subject == "deployment.plan" || event.Subject == "deployment.planning" {
+		return a.proposeDeploymentPlan(ctx, event, appName, environment, userMessage, regions), nil
+	}
+
+	// Deploying an application rolls out every service it owns
+	decision := guardrails.Default().Check(ctx, guardrails.Action{
+		Operation:   guardrails.OperationDeploy,
+		Kind:        graph.KindApplication,
+		Targets:     guardrails.Footprint(a.service.globalGraph, appName),
+		Environment: environment,
+		Application: appName,
+		Source:      "deployment-agent",
+	})
+	// Where the AI may only suggest, the deployment becomes a plan for the user to carry out
+	if decision.Outcome == guardrails.Propose {
+		return a.proposeDeploymentPlan(ctx, event, appName, environment, userMessage, regions), nil
+	}
+	if !decision.Allowed() {
+		return a.createErrorResponse(event, decision.Message()), nil
+	}
+
+	// One deployment of the application to the environment at a time
+	correlationID, _ := event.Payload["correlation_id"].(string)
+	if correlationID == "" {
+		correlationID = event.ID
+	}
+	result, err := a.deploy(ctx, Request{
+		Application:   appName,
+		Environment:   environment,
+		Regions:       regions,
+		CorrelationID: correlationID,
+	})
+	var inProgress *InProgressError
+	if errors.As(err, &inProgress) {
+		response := a.createErrorResponse(event, err.Error())
+		response.Payload["in_progress_correlation_id"] = inProgress.CorrelationID
+		return response, nil
+	}
 	if err != nil {
 		return a.createErrorResponse(event, fmt.Sprintf("deployment orchestration failed: %v", err)), nil
 	}
@@ -164,6 +257,7 @@ func (a *FrameworkDeploymentAgent) handleEvent(ctx context.Context, event *event
 		"release_id":        result.ReleaseID,
 		"application":       result.Application,
 		"environment":       result.Environment,
+		"regions":           result.Regions,
 		"deployment_result": result,
 		"parsed_from":       userMessage,
 		"ai_extracted_params": map[string]interface{}{
@@ -177,55 +271,296 @@ func (a *FrameworkDeploymentAgent) handleEvent(ctx context.Context, event *event
 	return a.createSuccessResponse(event, payload), nil
 }
 
-// orchestrateDeployment implements the full multi-agent deployment workflow
-func (a *FrameworkDeploymentAgent) orchestrateDeployment(ctx context.Context, appName, environment, userMessage string) (*DeploymentResult, error) {
+// proposeDeploymentPlan emits the deployment workflow as a draft plan bound to the conversation,
+// so the user can revise it step by step instead of asking for a new plan
+func (a *FrameworkDeploymentAgent) proposeDeploymentPlan(ctx context.Context, event *events.Event, appName, environment, userMessage string, regions []string) *events.Event {
+	steps, err := a.deploymentPlanSteps(appName, environment, regions)
+	if err != nil {
+		return a.createErrorResponse(event, fmt.Sprintf("deployment planning failed: %v", err))
+	}
+
+	evalCtx := features.EvaluationContextFrom(ctx)
+	plan := plans.Plan{
+		ID:             plans.NewID(time.Now()),
+		ConversationID: evalCtx.ConversationID,
+		Tenant:         evalCtx.Tenant,
+		Goal:           userMessage,
+		Application:    appName,
+		Environment:    environment,
+		ProposedBy:     "deployment-agent",
+		Status:         plans.StatusDraft,
+		Revision:       1,
+		Steps:          steps,
+	}
+	// Freezes and overlapping changes to the same resources are surfaced before approval
+	conflicts, err := calendar.NewService(a.service.globalGraph).CheckDeployment(appName, environment, time.Now())
+	if err != nil {
+		a.logger.Warn("⚠️ Could not check the change calendar for %s → %s: %v", appName, environment, err)
+	}
+	for _, conflict := range conflicts {
+		plan.Warnings = append(plan.Warnings, conflict.String())
+	}
+
+	if err := a.eventBus.Emit(events.EventTypeRequest, "deployment-agent", plans.ProposedSubject, map[string]interface{}{
+		"plan":           plan,
+		"correlation_id": event.Payload["correlation_id"],
+	}); err != nil {
+		a.logger.Warn("⚠️ Failed to emit proposed plan %s: %v", plan.ID, err)
+	}
+	a.logger.Info("📋 Proposed %d-step deployment plan %s for %s → %s", len(steps), plan.ID, appName, environment)
+
+	return a.createSuccessResponse(event, map[string]interface{}{
+		"message":     plan.Describe() + "\n\nTell me what to change (for example \"remove step 2\"), or approve the plan.",
+		"plan":        plan,
+		"plan_id":     plan.ID,
+		"application": appName,
+		"environment": environment,
+		"conflicts":   conflicts,
+	})
+}
+
+// deploymentPlanSteps lays out the steps orchestrateDeployment runs, with a step per pending
+// schema migration ahead of one deploy step per owned service. Multi-region deployments get a
+// branch of deploy steps per region, each depending only on the shared steps before it.
+func (a *FrameworkDeploymentAgent) deploymentPlanSteps(appName, environment string, regions []string) ([]plans.Step, error) {
+	configs, err := a.serviceConfigs(appName, environment)
+	if err != nil {
+		return nil, err
+	}
+	services := make([]string, 0, len(configs))
+	for service := range configs {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	if len(services) == 0 {
+		services = []string{appName}
+	}
+
+	steps := []plans.Step{
+		{Action: "validate", Target: appName, Description: fmt.Sprintf("Check %s and %s exist", appName, environment)},
+		{Action: "create-release", Target: appName, Description: fmt.Sprintf("Snapshot %s into a release", appName)},
+		{Action: "evaluate-policies", Target: environment, Description: fmt.Sprintf("Check the release may be deployed to %s", environment)},
+	}
+	// Schemas change before the code that depends on them rolls out
+	pending, err := migrations.NewService(a.service.globalGraph).Pending(appName, environment)
+	if err != nil {
+		return nil, err
+	}
+	for _, migration := range pending {
+		description := fmt.Sprintf("Run migration %s in %s", migration.Name, environment)
+		if !migration.Reversible {
+			description += " (irreversible)"
+		}
+		steps = append(steps, plans.Step{Action: "migrate", Target: migration.ID, Description: description})
+	}
+	for i := range steps {
+		steps[i].ID = fmt.Sprintf("step-%d", i+1)
+		if i > 0 {
+			steps[i].DependsOn = []string{steps[i-1].ID}
+		}
+	}
+
+	branches := regions
+	if len(branches) == 0 {
+		branches = []string{""}
+	}
+	shared := steps[len(steps)-1].ID
+	for _, region := range branches {
+		previous := shared
+		for _, service := range services {
+			description := fmt.Sprintf("Deploy %s to %s", service, environment)
+			if region != "" {
+				description += " in " + region
+			}
+			if config, ok := configs[service]; ok {
+				description += describeServiceConfig(config)
+			}
+			step := plans.Step{ID: fmt.Sprintf("step-%d", len(steps)+1), Action: "deploy", Target: service, Description: description, DependsOn: []string{previous}}
+			steps = append(steps, step)
+			previous = step.ID
+		}
+	}
+	return steps, nil
+}
+
+// serviceConfigs resolves the effective configuration of every service the application owns,
+// merging environment overrides and checking them against the environment's constraints and
+// the services' config contracts
+func (a *FrameworkDeploymentAgent) serviceConfigs(appName, environment string) (map[string]contracts.ServiceSpec, error) {
+	edges, err := a.service.globalGraph.Edges()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph: %w", err)
+	}
+	configs := make(map[string]contracts.ServiceSpec)
+	for _, edge := range edges[appName] {
+		if edge.Type != graph.EdgeTypeOwns {
+			continue
+		}
+		if node, _ := a.service.globalGraph.GetNode(edge.To); node == nil || node.Kind != graph.KindService {
+			continue
+		}
+		config, err := servicecore.ResolveForEnvironment(a.service.globalGraph, edge.To, environment, nil)
+		if err != nil {
+			return nil, err
+		}
+		configs[edge.To] = config
+	}
+	// Every declared config key must resolve before anything is planned or rolled out
+	if err := servicecore.CheckConfig(a.service.globalGraph, appName, environment); err != nil {
+		return nil, err
+	}
+	return configs, nil
+}
+
+// describeServiceConfig summarizes the settings environment overrides can change
+func describeServiceConfig(config contracts.ServiceSpec) string {
+	var parts []string
+	if config.Autoscaling != nil {
+		parts = append(parts, "autoscaling "+config.Autoscaling.String())
+	} else if config.Replicas > 0 {
+		parts = append(parts, fmt.Sprintf("%d replicas", config.Replicas))
+	}
+	if config.Tier != "" {
+		parts = append(parts, "tier "+config.Tier)
+	}
+	if len(config.Env) > 0 {
+		parts = append(parts, fmt.Sprintf("%d env vars", len(config.Env)))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
+
+// deploy runs a deployment while holding the application's environment lock
+func (a *FrameworkDeploymentAgent) deploy(ctx context.Context, req Request) (*DeploymentResult, error) {
+	release, err := a.locks.Acquire(req.Application, req.Environment, req.CorrelationID)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return a.orchestrateDeployment(ctx, req)
+}
+
+// orchestrateDeployment implements the full multi-agent deployment workflow. Given regions, the
+// release is rolled out to each of them in turn with its own deployment edge, and a region that
+// fails is rolled back to its previous release without affecting the others.
+func (a *FrameworkDeploymentAgent) orchestrateDeployment(ctx context.Context, req Request) (*DeploymentResult, error) {
+	appName, environment, regions := req.Application, req.Environment, req.Regions
 	a.logger.Info("🎭 Orchestrating deployment: %s → %s", appName, environment)
 
 	// Step 1: Create deployment plan (simple for TDD)
-	plan := []string{"validate", "create-release", "evaluate-policies", "execute"}
+	plan := []string{"validate", "create-release", "evaluate-policies", "migrate"}
+	if len(regions) == 0 {
+		plan = append(plan, "execute")
+	}
+	for _, region := range regions {
+		plan = append(plan, executeStep(region))
+	}
 	a.logger.Info("📋 Created simple deployment plan for %s", appName)
+	progress := NewProgressTracker(ctx, a.eventBus, appName, environment, plan)
 
-	// Step 2: Request Release Agent to create a release
-	releaseID, err := a.requestReleaseCreation(ctx, appName, plan)
+	// Parameters and guardrails were checked before orchestration started; what is left to
+	// validate is each service's configuration for the target environment
+	progress.Start("validate")
+	configs, err := a.serviceConfigs(appName, environment)
 	if err != nil {
-		return nil, fmt.Errorf("release creation failed: %w", err)
+		progress.Fail("validate", err)
+		return nil, fmt.Errorf("configuration validation failed: %w", err)
+	}
+	// Services the application consumes must already run in the environment
+	if err := servicecore.CheckDependencies(a.service.globalGraph, appName, environment); err != nil {
+		progress.Fail("validate", err)
+		return nil, fmt.Errorf("dependency validation failed: %w", err)
+	}
+	// Services talking to a message broker must declare the topics they produce and consume
+	if err := resources.CheckTopicDeclarations(a.service.globalGraph, appName); err != nil {
+		progress.Fail("validate", err)
+		return nil, fmt.Errorf("messaging validation failed: %w", err)
+	}
+	// Regional resources must be in the regions the release is deployed to
+	if err := resources.CheckRegions(a.service.globalGraph, appName, environment, regions); err != nil {
+		progress.Fail("validate", err)
+		return nil, fmt.Errorf("region validation failed: %w", err)
+	}
+	progress.Complete("validate")
+
+	// The user may cancel or pause the conversation; nothing has been created yet
+	if err := agentframework.Checkpoint(ctx); err != nil {
+		progress.Fail("create-release", err)
+		return nil, fmt.Errorf("deployment cancelled before a release was created: %w", err)
+	}
+
+	// Step 2: Request Release Agent to create a release, unless an existing one is deployed
+	progress.Start("create-release")
+	releaseID := req.ReleaseID
+	if releaseID == "" {
+		releaseID, err = a.requestReleaseCreation(ctx, appName, plan)
+		if err != nil {
+			progress.Fail("create-release", err)
+			return nil, fmt.Errorf("release creation failed: %w", err)
+		}
 	}
 
-	// Step 3: Create deployment edge from Release to Environment
-	deploymentID, err := a.createDeploymentEdge(ctx, releaseID, environment, "pending")
+	// Step 3: Create deployment edges from Release to Environment, one per region
+	if len(regions) > 0 {
+		return a.orchestrateRegions(ctx, progress, req, releaseID, configs)
+	}
+	deploymentID, err := a.createDeploymentEdge(ctx, appName, releaseID, environment, "", "pending", req.Metadata)
 	if err != nil {
+		progress.Fail("create-release", err)
 		return nil, fmt.Errorf("deployment edge creation failed: %w", err)
 	}
+	progress.SetDeploymentID(deploymentID)
+	progress.Complete("create-release")
 
 	// Step 4: Request Policy Agent validation
-	policyDecision, err := a.requestPolicyValidation(ctx, appName, environment, releaseID)
-	if err != nil {
-		// Update deployment status to failed
-		a.updateDeploymentStatus(ctx, deploymentID, "failed", fmt.Sprintf("Policy validation failed: %v", err))
-		return nil, fmt.Errorf("policy validation failed: %w", err)
+	if err := a.evaluatePolicies(ctx, progress, appName, environment, releaseID, []string{deploymentID}); err != nil {
+		return nil, err
 	}
 
-	if policyDecision != "allowed" {
-		// Update deployment status to blocked
-		a.updateDeploymentStatus(ctx, deploymentID, "blocked", "Deployment blocked by policy")
-		return nil, fmt.Errorf("deployment blocked by policy: %s", policyDecision)
+	// Last point to stop before anything is rolled out
+	if err := agentframework.Checkpoint(ctx); err != nil {
+		progress.Fail("execute", err)
+		a.updateDeploymentStatus(ctx, deploymentID, "cancelled", "Deployment cancelled before execution")
+		return nil, fmt.Errorf("deployment cancelled before execution: %w", err)
 	}
 
 	// Step 5: Update status to in-progress and execute deployment
 	a.updateDeploymentStatus(ctx, deploymentID, "in-progress", "Executing deployment")
 
-	// Step 6: Execute actual deployment (currently mocked)
-	result, err := a.executeDeployment(ctx, appName, environment, releaseID, deploymentID)
+	// Step 6: Run pending schema migrations before any service is rolled out
+	progress.Start("migrate")
+	if err := a.runMigrations(ctx, appName, environment); err != nil {
+		progress.Fail("migrate", err)
+		a.updateDeploymentStatus(ctx, deploymentID, "failed", fmt.Sprintf("Migrations failed: %v", err))
+		return nil, fmt.Errorf("migrations failed: %w", err)
+	}
+	progress.Complete("migrate")
+
+	// Step 7: Execute actual deployment (currently mocked), retrying transient failures
+	progress.Start("execute")
+	var result *DeploymentResult
+	for attempt := 1; ; attempt++ {
+		result, err = a.executor()(ctx, appName, environment, "", releaseID, deploymentID)
+		if err == nil || attempt == executeAttempts {
+			break
+		}
+		progress.Retry("execute", attempt+1, err)
+	}
 	if err != nil {
 		// Update deployment status to failed
+		progress.Fail("execute", err)
 		a.updateDeploymentStatus(ctx, deploymentID, "failed", fmt.Sprintf("Deployment execution failed: %v", err))
 		return nil, fmt.Errorf("deployment execution failed: %w", err)
 	}
+	progress.Complete("execute")
+	result.Configs = configs
 
-	// Step 7: Update final status to succeeded
+	// Step 8: Update final status to succeeded
 	a.updateDeploymentStatus(ctx, deploymentID, "succeeded", "Deployment completed successfully")
 
-	// Step 8: Emit deployment.completed event
+	// Step 9: Emit deployment.completed event
 	completionEvent := events.Event{
 		Subject: "deployment.completed",
 		Source:  "deployment-agent",
@@ -282,16 +617,14 @@ func (a *FrameworkDeploymentAgent) requestReleaseCreation(ctx context.Context, a
 	return releaseID, nil
 }
 
-// createDeploymentEdge creates a deployment edge from Release to Environment in the graph
-func (a *FrameworkDeploymentAgent) createDeploymentEdge(ctx context.Context, releaseID, environment, status string) (string, error) {
+// createDeploymentEdge creates a deployment edge from Release to Environment in the graph,
+// recording the region for multi-region deployments
+func (a *FrameworkDeploymentAgent) createDeploymentEdge(ctx context.Context, appName, releaseID, environment, region, status string, metadata map[string]interface{}) (string, error) {
 	a.logger.Info("🔗 Creating deployment edge: %s → %s", releaseID, environment)
 
-	deploymentID := fmt.Sprintf("deployment-%s-%s-%d", releaseID, environment, time.Now().Unix())
-
-	// Get current graph
-	currentGraph, err := a.service.globalGraph.Graph()
-	if err != nil {
-		return "", fmt.Errorf("failed to get graph: %w", err)
+	deploymentID := fmt.Sprintf("deployment-%s-%s-%d", releaseID, environment, time.Now().UnixNano())
+	if region != "" {
+		deploymentID = fmt.Sprintf("deployment-%s-%s-%s-%d", releaseID, environment, region, time.Now().UnixNano())
 	}
 
 	// Create deployment edge with metadata
@@ -300,20 +633,31 @@ func (a *FrameworkDeploymentAgent) createDeploymentEdge(ctx context.Context, rel
 		Type: "deployment",
 		Metadata: map[string]interface{}{
 			"deployment_id": deploymentID,
+			"application":   appName,
 			"status":        status,
 			"created_at":    time.Now().Format(time.RFC3339),
 			"updated_at":    time.Now().Format(time.RFC3339),
 		},
 	}
-
-	// Add edge to graph
-	if currentGraph.Edges == nil {
-		currentGraph.Edges = make(map[string][]graph.Edge)
+	if region != "" {
+		edge.Metadata["region"] = region
 	}
-	currentGraph.Edges[releaseID] = append(currentGraph.Edges[releaseID], edge)
+	for key, value := range metadata {
+		if _, reserved := edge.Metadata[key]; !reserved {
+			edge.Metadata[key] = value
+		}
+	}
+	AppendStatusChange(edge.Metadata, StatusChange{Status: status, Message: "Deployment created", Actor: deploymentActor(ctx), Timestamp: time.Now()})
 
-	// Save graph
-	if err := a.service.globalGraph.Save(); err != nil {
+	// Add edge to graph
+	err := a.service.globalGraph.WithContext(ctx).Update(func(currentGraph *graph.Graph) error {
+		if currentGraph.Edges == nil {
+			currentGraph.Edges = make(map[string][]graph.Edge)
+		}
+		currentGraph.Edges[releaseID] = append(currentGraph.Edges[releaseID], edge)
+		return nil
+	})
+	if err != nil {
 		return "", fmt.Errorf("failed to save graph: %w", err)
 	}
 
@@ -324,77 +668,101 @@ func (a *FrameworkDeploymentAgent) createDeploymentEdge(ctx context.Context, rel
 // requestPolicyValidation coordinates with Policy Agent for deployment validation
 func (a *FrameworkDeploymentAgent) requestPolicyValidation(ctx context.Context, appName, environment, releaseID string) (string, error) {
 	a.logger.Info("🛡️ Requesting policy validation for %s → %s", appName, environment)
+	return EvaluateGates(ctx, a.service.globalGraph.WithContext(ctx), appName, environment, releaseID)
+}
 
-	// Emit policy.evaluate event to Policy Agent
-	policyEvent := events.Event{
-		Type:    events.EventTypeRequest,
-		Source:  "deployment-agent",
-		Subject: "policy.evaluate",
-		Payload: map[string]interface{}{
-			"application": appName,
-			"environment": environment,
-			"release_id":  releaseID,
-			"timestamp":   time.Now().Unix(),
-		},
-	}
-
-	if err := a.eventBus.EmitEvent(policyEvent); err != nil {
-		return "error", fmt.Errorf("failed to emit policy evaluation event: %w", err)
-	}
-
-	a.logger.Info("📤 Emitted policy.evaluate event for %s → %s", appName, environment)
-
-	// TODO: In full implementation, wait for "policy.decision" response
-	// For now, simulate policy validation for TDD purposes
-
-	// Simple validation for demo
-	if environment == "production" && appName == "critical-app" {
-		return "blocked", fmt.Errorf("critical application requires manual approval for production")
-	}
-
-	a.logger.Info("🛡️ Policy validation passed")
-	return "allowed", nil
+// evaluatePolicies runs the deployment gates for the release and records a refusal on its
+// deployment edges: blocked when the gates decided against it, failed when they could not decide
+func (a *FrameworkDeploymentAgent) evaluatePolicies(ctx context.Context, progress *ProgressTracker, appName, environment, releaseID string, deploymentIDs []string) error {
+	progress.Start("evaluate-policies")
+	policyDecision, err := a.requestPolicyValidation(ctx, appName, environment, releaseID)
+	switch {
+	case policyDecision == "blocked":
+		cause := fmt.Errorf("%w: %s", ErrDeploymentBlocked, policyDecision)
+		message := "Deployment blocked by policy"
+		if err != nil {
+			cause = fmt.Errorf("%w: %v", ErrDeploymentBlocked, err)
+			message += ": " + err.Error()
+		}
+		progress.Fail("evaluate-policies", cause)
+		a.updateDeploymentStatuses(ctx, deploymentIDs, "blocked", message)
+		return cause
+	case err != nil:
+		progress.Fail("evaluate-policies", err)
+		a.updateDeploymentStatuses(ctx, deploymentIDs, "failed", fmt.Sprintf("Policy validation failed: %v", err))
+		return fmt.Errorf("policy validation failed: %w", err)
+	case policyDecision != "allowed":
+		cause := fmt.Errorf("%w: %s", ErrDeploymentBlocked, policyDecision)
+		progress.Fail("evaluate-policies", cause)
+		a.updateDeploymentStatuses(ctx, deploymentIDs, "blocked", "Deployment blocked by policy")
+		return cause
+	}
+	progress.Complete("evaluate-policies")
+	return nil
 }
 
 // updateDeploymentStatus updates the deployment edge status in the graph
 func (a *FrameworkDeploymentAgent) updateDeploymentStatus(ctx context.Context, deploymentID, status, message string) error {
 	a.logger.Info("📊 Updating deployment status: %s → %s", deploymentID, status)
 
-	// Get current graph
-	currentGraph, err := a.service.globalGraph.Graph()
-	if err != nil {
-		return fmt.Errorf("failed to get graph: %w", err)
-	}
-
-	// Find and update the deployment edge
-	for from, edges := range currentGraph.Edges {
-		for i, edge := range edges {
-			if edge.Type == "deployment" {
+	// Find and update the deployment edge under the write lock, so concurrent deployments do
+	// not overwrite each other's status
+	err := a.service.globalGraph.WithContext(ctx).Update(func(currentGraph *graph.Graph) error {
+		for from, edges := range currentGraph.Edges {
+			for i, edge := range edges {
+				if edge.Type != "deployment" {
+					continue
+				}
 				if deploymentIDVal, ok := edge.Metadata["deployment_id"].(string); ok && deploymentIDVal == deploymentID {
-					// Update status and timestamp
+					// Update status and timestamp; earlier statuses stay in the history
 					edge.Metadata["status"] = status
 					edge.Metadata["updated_at"] = time.Now().Format(time.RFC3339)
 					edge.Metadata["message"] = message
+					AppendStatusChange(edge.Metadata, StatusChange{Status: status, Message: message, Actor: deploymentActor(ctx), Timestamp: time.Now()})
 					currentGraph.Edges[from][i] = edge
-
-					// Save graph
-					if err := a.service.globalGraph.Save(); err != nil {
-						return fmt.Errorf("failed to save graph: %w", err)
-					}
-
-					a.logger.Info("📊 Deployment status updated: %s", status)
 					return nil
 				}
 			}
 		}
+		return fmt.Errorf("deployment edge not found: %s", deploymentID)
+	})
+	if err != nil {
+		return err
+	}
+
+	a.logger.Info("📊 Deployment status updated: %s", status)
+	return nil
+}
+
+// runMigrations runs the application's pending migrations in order with the runners
+// registered for their engines, recording each one's status on its node. The first migration
+// that fails, or has no runner, stops the deployment before any service rolls out.
+func (a *FrameworkDeploymentAgent) runMigrations(ctx context.Context, appName, environment string) error {
+	service := migrations.NewService(a.service.globalGraph.WithContext(ctx))
+	pending, err := service.Pending(appName, environment)
+	if err != nil {
+		return err
+	}
+	for _, migration := range pending {
+		a.logger.Info("🗄️ Running migration %s in %s", migration.ID, environment)
+		if err := service.Apply(ctx, migration, environment); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	return fmt.Errorf("deployment edge not found: %s", deploymentID)
+// executor returns the function that rolls releases out
+func (a *FrameworkDeploymentAgent) executor() func(ctx context.Context, appName, environment, region, releaseID, deploymentID string) (*DeploymentResult, error) {
+	if a.execute != nil {
+		return a.execute
+	}
+	return a.executeDeployment
 }
 
 // executeDeployment performs the actual deployment (currently mocked)
-func (a *FrameworkDeploymentAgent) executeDeployment(ctx context.Context, appName, environment, releaseID, deploymentID string) (*DeploymentResult, error) {
-	a.logger.Info("🚀 Executing deployment: %s → %s", appName, environment)
+func (a *FrameworkDeploymentAgent) executeDeployment(ctx context.Context, appName, environment, region, releaseID, deploymentID string) (*DeploymentResult, error) {
+	a.logger.Info("🚀 Executing deployment: %s → %s %s", appName, environment, region)
 
 	// TODO: Implement actual deployment logic
 	// For now, simulate deployment execution
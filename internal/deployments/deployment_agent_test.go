@@ -9,11 +9,13 @@ import (
 	"testing"
 	"time"
 
-	"github.com/krzachariassen/ZTDP/internal/agentFramework"
 	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
 	"github.com/krzachariassen/ZTDP/internal/ai"
 	"github.com/krzachariassen/ZTDP/internal/events"
 	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/krzachariassen/ZTDP/internal/migrations"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -51,7 +53,7 @@ func TestDeploymentAgentCreation(t *testing.T) {
 	defer realAIProvider.Close()
 
 	// Act - Create DeploymentAgent using framework
-	agent, err := NewDeploymentAgent(mockGraph, realAIProvider, eventBus, registry)
+	agent, err := NewDeploymentAgent(mockGraph, realAIProvider, eventBus, registry, nil)
 
 	// Assert
 	if err != nil {
@@ -115,13 +117,13 @@ func TestDeploymentAgentEventHandling(t *testing.T) {
 	defer realAIProvider.Close()
 
 	// Create agent using framework
-	baseAgent, err := NewDeploymentAgent(mockGraph, realAIProvider, eventBus, registry)
+	baseAgent, err := NewDeploymentAgent(mockGraph, realAIProvider, eventBus, registry, nil)
 	if err != nil {
 		t.Fatalf("Failed to create agent: %v", err)
 	}
 
 	// Cast to framework agent to access ProcessEvent
-	agent, ok := baseAgent.(*agentFramework.BaseAgent)
+	agent, ok := baseAgent.(*agentframework.BaseAgent)
 	if !ok {
 		t.Fatalf("Expected BaseAgent, got %T", baseAgent)
 	}
@@ -216,12 +218,12 @@ func TestDeploymentAgentAIIntegration(t *testing.T) {
 			defer realAIProvider.Close()
 
 			// Create agent using framework
-			baseAgent, err := NewDeploymentAgent(mockGraph, realAIProvider, eventBus, registry)
+			baseAgent, err := NewDeploymentAgent(mockGraph, realAIProvider, eventBus, registry, nil)
 			if err != nil {
 				t.Fatalf("Failed to create agent: %v", err)
 			}
 
-			agent, ok := baseAgent.(*agentFramework.BaseAgent)
+			agent, ok := baseAgent.(*agentframework.BaseAgent)
 			if !ok {
 				t.Fatalf("Expected BaseAgent, got %T", baseAgent)
 			}
@@ -315,12 +317,12 @@ func TestDeploymentAgentBusinessLogicIntegration(t *testing.T) {
 	defer realAIProvider.Close()
 
 	// Create agent using framework
-	baseAgent, err := NewDeploymentAgent(mockGraph, realAIProvider, eventBus, registry)
+	baseAgent, err := NewDeploymentAgent(mockGraph, realAIProvider, eventBus, registry, nil)
 	if err != nil {
 		t.Fatalf("Failed to create agent: %v", err)
 	}
 
-	agent, ok := baseAgent.(*agentFramework.BaseAgent)
+	agent, ok := baseAgent.(*agentframework.BaseAgent)
 	if !ok {
 		t.Fatalf("Expected BaseAgent, got %T", baseAgent)
 	}
@@ -422,7 +424,7 @@ func TestDeploymentOrchestrationWorkflow(t *testing.T) {
 		})
 
 		// Create deployment agent
-		deploymentAgent, err := NewDeploymentAgent(mockGraph, realAIProvider, eventBus, registry)
+		deploymentAgent, err := NewDeploymentAgent(mockGraph, realAIProvider, eventBus, registry, nil)
 		if err != nil {
 			t.Fatalf("Failed to create deployment agent: %v", err)
 		}
@@ -440,7 +442,7 @@ func TestDeploymentOrchestrationWorkflow(t *testing.T) {
 		}
 
 		// Act - Start the orchestration workflow
-		response, err := deploymentAgent.(*agentFramework.BaseAgent).ProcessEvent(context.Background(), deploymentEvent)
+		response, err := deploymentAgent.(*agentframework.BaseAgent).ProcessEvent(context.Background(), deploymentEvent)
 		if err != nil {
 			t.Fatalf("Deployment orchestration failed: %v", err)
 		}
@@ -748,7 +750,7 @@ func TestDeploymentOrchestrationWorkflow_UnitTest(t *testing.T) {
 		})
 
 		// Create deployment agent with mocked dependencies
-		deploymentAgent, err := NewDeploymentAgent(mockGraph, realAIProvider, eventBus, registry)
+		deploymentAgent, err := NewDeploymentAgent(mockGraph, realAIProvider, eventBus, registry, nil)
 		if err != nil {
 			t.Fatalf("Failed to create deployment agent: %v", err)
 		}
@@ -899,3 +901,65 @@ func TestDeploymentOrchestrationWorkflow_UnitTest(t *testing.T) {
 		// t.Logf("📨 Response payload keys: %v", getPayloadKeys(payload)) // Removed since we use events now
 	})
 }
+
+func TestDeploymentParamsFromEnvelope(t *testing.T) {
+	envelope := agentframework.ContextEnvelope{Entities: map[string]string{graph.KindApplication: "checkout", graph.KindEnvironment: "dev"}}
+	event := &events.Event{Payload: map[string]interface{}{agentframework.ContextEnvelopeKey: envelope.Payload()}}
+
+	params := deploymentParamsFromEnvelope(event)
+	if assert.NotNil(t, params, "an envelope naming the app and environment needs no AI extraction") {
+		assert.Equal(t, "checkout", params.AppName)
+		assert.Equal(t, "dev", params.Environment)
+	}
+
+	envelope.Entities = map[string]string{graph.KindApplication: "checkout"}
+	event.Payload[agentframework.ContextEnvelopeKey] = envelope.Payload()
+	assert.Nil(t, deploymentParamsFromEnvelope(event), "without an environment the message is extracted with AI")
+	assert.Nil(t, deploymentParamsFromEnvelope(&events.Event{Payload: map[string]interface{}{}}))
+}
+
+// planTestRunner applies every migration of the plan-test engine
+type planTestRunner struct{}
+
+func (planTestRunner) Engine() string { return "plan-test" }
+
+func (planTestRunner) Apply(ctx context.Context, migration *migrations.Migration, environment string) error {
+	return nil
+}
+
+func TestDeploymentPlanRunsMigrationsBeforeRollout(t *testing.T) {
+	g := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	for id, kind := range map[string]string{
+		"checkout":           graph.KindApplication,
+		"checkout-api":       graph.KindService,
+		"checkout-api:1.1.0": graph.KindServiceVersion,
+		"prod":               graph.KindEnvironment,
+	} {
+		g.AddNode(&graph.Node{ID: id, Kind: kind, Metadata: map[string]interface{}{"name": id}, Spec: map[string]interface{}{}})
+	}
+	g.AddEdge("checkout", "checkout-api", graph.EdgeTypeOwns)
+	g.AddEdge("checkout-api", "checkout-api:1.1.0", graph.EdgeTypeHasVersion)
+	_, err := migrations.NewService(g).Declare(migrations.Migration{Service: "checkout-api", Version: "1.1.0", Name: "add_column", Engine: "plan-test", UpURI: "s3://sql/up.sql"})
+	assert.NoError(t, err)
+
+	agent := &FrameworkDeploymentAgent{service: &Service{globalGraph: g}, logger: logging.GetLogger().ForComponent("deployment-agent")}
+	steps, err := agent.deploymentPlanSteps("checkout", "prod", nil)
+	assert.NoError(t, err)
+	actions := []string{}
+	for _, step := range steps {
+		actions = append(actions, step.Action+" "+step.Target)
+	}
+	assert.Equal(t, []string{"validate checkout", "create-release checkout", "evaluate-policies prod", "migrate checkout-api:1.1.0:add_column", "deploy checkout-api"}, actions)
+	assert.Contains(t, steps[3].Description, "irreversible")
+
+	assert.ErrorIs(t, agent.runMigrations(context.Background(), "checkout", "prod"), migrations.ErrNoRunner)
+	steps, err = agent.deploymentPlanSteps("checkout", "prod", nil)
+	assert.NoError(t, err)
+	assert.Len(t, steps, 5, "migrations without a runner are still planned")
+
+	migrations.RegisterRunner(&planTestRunner{})
+	assert.NoError(t, agent.runMigrations(context.Background(), "checkout", "prod"))
+	steps, err = agent.deploymentPlanSteps("checkout", "prod", nil)
+	assert.NoError(t, err)
+	assert.Len(t, steps, 4, "migrations that succeeded in the environment are not planned again")
+}
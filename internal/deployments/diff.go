@@ -0,0 +1,423 @@
+package deployments
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/krzachariassen/ZTDP/internal/ai"
+	"github.com/krzachariassen/ZTDP/internal/contracts"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/redaction"
+	servicecore "github.com/krzachariassen/ZTDP/internal/service"
+)
+
+// EnvironmentDiff describes how an application differs between two environments
+type EnvironmentDiff struct {
+	Application string           `json:"application"`
+	From        string           `json:"from"`
+	To          string           `json:"to"`
+	Identical   bool             `json:"identical"`
+	Release     ReleaseDiff      `json:"release"`
+	Services    []ServiceDiff    `json:"services"`
+	Resources   []ResourceChange `json:"resources"`
+	Summary     string           `json:"summary"`
+	// SummarySource is "ai" when the summary was written by the AI provider, "generated" otherwise
+	SummarySource string `json:"summary_source"`
+}
+
+// DeployedRelease is the latest release deployment recorded for an environment
+type DeployedRelease struct {
+	ReleaseID    string `json:"release_id"`
+	DeploymentID string `json:"deployment_id,omitempty"`
+	Status       string `json:"status,omitempty"`
+	DeployedAt   string `json:"deployed_at,omitempty"`
+}
+
+// ReleaseDiff pairs the releases deployed to each environment; nil means nothing is deployed
+type ReleaseDiff struct {
+	From    *DeployedRelease `json:"from"`
+	To      *DeployedRelease `json:"to"`
+	Changed bool             `json:"changed"`
+}
+
+// ServiceDiff lists the differences of one service. Services identical in both
+// environments are left out of the diff.
+type ServiceDiff struct {
+	Service     string         `json:"service"`
+	FromVersion string         `json:"from_version,omitempty"`
+	ToVersion   string         `json:"to_version,omitempty"`
+	Config      []ConfigChange `json:"config,omitempty"`
+}
+
+// ConfigChange is a configuration value that differs after environment overrides are applied.
+// Env vars use the field name "env.<NAME>" and config contract keys "config.<NAME>", showing
+// where the value came from with secrets masked; an empty side means the value is unset there.
+type ConfigChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// ResourceChange is a resource instance bound in only one of the environments
+type ResourceChange struct {
+	Resource string   `json:"resource"`
+	In       string   `json:"in"` // environment the resource is deployed to
+	UsedBy   []string `json:"used_by,omitempty"`
+}
+
+// DiffEnvironments compares what is deployed for an application in two environments: release
+// versions, service versions, resource bindings and environment configuration overrides. The
+// summary is written by the AI provider when one is configured.
+func (s *Service) DiffEnvironments(ctx context.Context, appName, from, to string) (*EnvironmentDiff, error) {
+	if from == "" || to == "" {
+		return nil, fmt.Errorf("both from and to environments are required")
+	}
+	nodes, err := s.globalGraph.Nodes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph: %w", err)
+	}
+	if node, ok := nodes[appName]; !ok || node.Kind != graph.KindApplication {
+		return nil, fmt.Errorf("application %s not found", appName)
+	}
+	for _, env := range []string{from, to} {
+		if node, ok := nodes[env]; !ok || node.Kind != graph.KindEnvironment {
+			return nil, fmt.Errorf("environment %s not found", env)
+		}
+	}
+	edges, err := s.globalGraph.Edges()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph: %w", err)
+	}
+
+	diff := &EnvironmentDiff{
+		Application: appName,
+		From:        from,
+		To:          to,
+		Services:    []ServiceDiff{},
+		Resources:   []ResourceChange{},
+	}
+
+	diff.Release.From = latestRelease(nodes, edges, appName, from)
+	diff.Release.To = latestRelease(nodes, edges, appName, to)
+	diff.Release.Changed = releaseID(diff.Release.From) != releaseID(diff.Release.To)
+
+	var services, resources []string
+	for _, edge := range edges[appName] {
+		if edge.Type != graph.EdgeTypeOwns {
+			continue
+		}
+		if node, ok := nodes[edge.To]; ok {
+			switch node.Kind {
+			case graph.KindService:
+				services = append(services, edge.To)
+			case graph.KindResource:
+				resources = append(resources, edge.To)
+			}
+		}
+	}
+	sort.Strings(services)
+	sort.Strings(resources)
+
+	for _, service := range services {
+		serviceDiff, err := diffService(nodes, edges, service, from, to)
+		if err != nil {
+			return nil, err
+		}
+		if serviceDiff.FromVersion != serviceDiff.ToVersion || len(serviceDiff.Config) > 0 {
+			diff.Services = append(diff.Services, serviceDiff)
+		}
+	}
+
+	for _, resource := range resources {
+		inFrom := hasEdge(edges, resource, from, graph.EdgeTypeDeploy)
+		inTo := hasEdge(edges, resource, to, graph.EdgeTypeDeploy)
+		if inFrom == inTo {
+			continue
+		}
+		change := ResourceChange{Resource: resource, In: from, UsedBy: resourceUsers(edges, services, resource)}
+		if inTo {
+			change.In = to
+		}
+		diff.Resources = append(diff.Resources, change)
+	}
+
+	diff.Identical = !diff.Release.Changed && len(diff.Services) == 0 && len(diff.Resources) == 0
+	diff.Summary, diff.SummarySource = s.summarizeDiff(ctx, diff)
+	return diff, nil
+}
+
+// LatestRelease returns the most recent release deployment of the application to environment,
+// or nil when nothing was deployed there
+func (s *Service) LatestRelease(appName, environment string) (*DeployedRelease, error) {
+	nodes, err := s.globalGraph.Nodes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph: %w", err)
+	}
+	edges, err := s.globalGraph.Edges()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph: %w", err)
+	}
+	return latestRelease(nodes, edges, appName, environment), nil
+}
+
+// DeployedVersions returns the version of each service of the application deployed to
+// environment; services with no version deployed there are left out
+func (s *Service) DeployedVersions(appName, environment string) (map[string]string, error) {
+	nodes, err := s.globalGraph.Nodes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph: %w", err)
+	}
+	edges, err := s.globalGraph.Edges()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph: %w", err)
+	}
+	versions := map[string]string{}
+	for _, edge := range edges[appName] {
+		if node, ok := nodes[edge.To]; !ok || edge.Type != graph.EdgeTypeOwns || node.Kind != graph.KindService {
+			continue
+		}
+		if version := deployedVersion(nodes, edges, edge.To, environment); version != "" {
+			versions[edge.To] = version
+		}
+	}
+	return versions, nil
+}
+
+// latestRelease finds the most recent release deployment of the application to environment
+func latestRelease(nodes map[string]*graph.Node, edges map[string][]graph.Edge, appName, environment string) *DeployedRelease {
+	var latest *DeployedRelease
+	for from, list := range edges {
+		if !isReleaseOf(nodes, from, appName) {
+			continue
+		}
+		for _, edge := range list {
+			if edge.To != environment || (edge.Type != "deployment" && edge.Type != graph.EdgeTypeDeploy) {
+				continue
+			}
+			candidate := &DeployedRelease{
+				ReleaseID:    from,
+				DeploymentID: metadataString(edge.Metadata, "deployment_id"),
+				Status:       metadataString(edge.Metadata, "status"),
+				DeployedAt:   metadataString(edge.Metadata, "created_at"),
+			}
+			// RFC3339 timestamps order lexically; the release ID breaks ties deterministically
+			if latest == nil || candidate.DeployedAt > latest.DeployedAt ||
+				(candidate.DeployedAt == latest.DeployedAt && candidate.ReleaseID > latest.ReleaseID) {
+				latest = candidate
+			}
+		}
+	}
+	return latest
+}
+
+// isReleaseOf reports whether id is a release or release bundle of the application. The
+// deployment agent records deployments against release IDs before the release node exists,
+// so the ID prefix is accepted as well.
+func isReleaseOf(nodes map[string]*graph.Node, id, appName string) bool {
+	if node, ok := nodes[id]; ok {
+		return (node.Kind == "release" || node.Kind == graph.KindReleaseBundle) && fmt.Sprint(node.Spec["application"]) == appName
+	}
+	return strings.HasPrefix(id, "release-"+appName+"-")
+}
+
+func diffService(nodes map[string]*graph.Node, edges map[string][]graph.Edge, service, from, to string) (ServiceDiff, error) {
+	diff := ServiceDiff{
+		Service:     service,
+		FromVersion: deployedVersion(nodes, edges, service, from),
+		ToVersion:   deployedVersion(nodes, edges, service, to),
+	}
+
+	var spec contracts.ServiceSpec
+	data, err := json.Marshal(nodes[service].Spec)
+	if err == nil {
+		err = json.Unmarshal(data, &spec)
+	}
+	if err != nil {
+		return diff, fmt.Errorf("invalid service %s: %w", service, err)
+	}
+	fromConfig, toConfig := spec.ForEnvironment(from), spec.ForEnvironment(to)
+
+	if fromConfig.Replicas != toConfig.Replicas {
+		diff.Config = append(diff.Config, ConfigChange{Field: "replicas", From: strconv.Itoa(fromConfig.Replicas), To: strconv.Itoa(toConfig.Replicas)})
+	}
+	if fromConfig.Tier != toConfig.Tier {
+		diff.Config = append(diff.Config, ConfigChange{Field: "tier", From: fromConfig.Tier, To: toConfig.Tier})
+	}
+	if fromConfig.Autoscaling.String() != toConfig.Autoscaling.String() {
+		diff.Config = append(diff.Config, ConfigChange{Field: "autoscaling", From: fromConfig.Autoscaling.String(), To: toConfig.Autoscaling.String()})
+	}
+	names := make(map[string]struct{})
+	for name := range fromConfig.Env {
+		names[name] = struct{}{}
+	}
+	for name := range toConfig.Env {
+		names[name] = struct{}{}
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	for _, name := range sorted {
+		if fromConfig.Env[name] != toConfig.Env[name] {
+			diff.Config = append(diff.Config, ConfigChange{Field: "env." + name, From: fromConfig.Env[name], To: toConfig.Env[name]})
+		}
+	}
+	diff.Config = append(diff.Config, diffConfigContract(
+		servicecore.ResolveConfigValues(nodes, edges, service, fromConfig),
+		servicecore.ResolveConfigValues(nodes, edges, service, toConfig))...)
+	return diff, nil
+}
+
+// diffConfigContract lists the config keys whose value or origin differs, as "config.<NAME>".
+// Keys set by env vars in both environments are already covered by the env changes.
+func diffConfigContract(from, to *servicecore.ResolvedConfig) []ConfigChange {
+	values := map[string][2]servicecore.ConfigValue{}
+	for _, value := range from.Values {
+		pair := values[value.Name]
+		pair[0] = value
+		values[value.Name] = pair
+	}
+	for _, value := range to.Values {
+		pair := values[value.Name]
+		pair[1] = value
+		values[value.Name] = pair
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var changes []ConfigChange
+	for _, name := range names {
+		pair := values[name]
+		if pair[0] == pair[1] || (pair[0].Source == servicecore.ConfigSourceEnv && pair[1].Source == servicecore.ConfigSourceEnv) {
+			continue
+		}
+		changes = append(changes, ConfigChange{Field: "config." + name, From: pair[0].Display(), To: pair[1].Display()})
+	}
+	return changes
+}
+
+// deployedVersion returns the version of service deployed to environment, or "". Version
+// nodes carry no spec, so the version is taken from their "<service>:<version>" ID; when
+// several are deployed the highest ID wins.
+func deployedVersion(nodes map[string]*graph.Node, edges map[string][]graph.Edge, service, environment string) string {
+	var latest string
+	for _, edge := range edges[service] {
+		node, ok := nodes[edge.To]
+		if !ok || node.Kind != graph.KindServiceVersion || !hasEdge(edges, edge.To, environment, graph.EdgeTypeDeploy) {
+			continue
+		}
+		if edge.To > latest {
+			latest = edge.To
+		}
+	}
+	return strings.TrimPrefix(latest, service+":")
+}
+
+func resourceUsers(edges map[string][]graph.Edge, services []string, resource string) []string {
+	var users []string
+	for _, service := range services {
+		if hasEdge(edges, service, resource, graph.EdgeTypeUses) {
+			users = append(users, service)
+		}
+	}
+	return users
+}
+
+func hasEdge(edges map[string][]graph.Edge, from, to, edgeType string) bool {
+	for _, edge := range edges[from] {
+		if edge.To == to && edge.Type == edgeType {
+			return true
+		}
+	}
+	return false
+}
+
+func metadataString(metadata map[string]interface{}, key string) string {
+	if value, ok := metadata[key].(string); ok {
+		return value
+	}
+	return ""
+}
+
+func releaseID(release *DeployedRelease) string {
+	if release == nil {
+		return ""
+	}
+	return release.ReleaseID
+}
+
+// summarizeDiff asks the AI provider to explain the diff and falls back to a generated summary
+func (s *Service) summarizeDiff(ctx context.Context, diff *EnvironmentDiff) (string, string) {
+	generated := generatedDiffSummary(diff)
+	if s.aiProvider == nil || diff.Identical {
+		return generated, "generated"
+	}
+
+	raw, err := json.Marshal(diff)
+	if err != nil {
+		return generated, "generated"
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return generated, "generated"
+	}
+	// Env var values in config changes can be credentials
+	redacted, err := json.Marshal(redaction.Default().Map(payload))
+	if err != nil {
+		return generated, "generated"
+	}
+
+	systemPrompt := `You are a platform engineer explaining how an application differs between two environments.
+Write 2-4 plain sentences for an engineer asking "what's different?". Lead with the differences most likely
+to change runtime behavior (versions, missing resources, replica counts), and do not list unchanged things.
+Return only the summary text.`
+	userPrompt := fmt.Sprintf("Differences for application %s between %s and %s:\n%s", diff.Application, diff.From, diff.To, redacted)
+
+	response, err := s.aiProvider.CallAI(ai.WithTask(ctx, ai.TaskConversation), systemPrompt, userPrompt)
+	if err != nil || strings.TrimSpace(response) == "" {
+		s.logger.Warn("⚠️ AI diff summary unavailable, using generated summary: %v", err)
+		return generated, "generated"
+	}
+	return strings.TrimSpace(response), "ai"
+}
+
+func generatedDiffSummary(diff *EnvironmentDiff) string {
+	if diff.Identical {
+		return fmt.Sprintf("%s is identical in %s and %s.", diff.Application, diff.From, diff.To)
+	}
+
+	var parts []string
+	if diff.Release.Changed {
+		parts = append(parts, fmt.Sprintf("release %s in %s vs %s in %s",
+			orNone(releaseID(diff.Release.From)), diff.From, orNone(releaseID(diff.Release.To)), diff.To))
+	}
+	for _, service := range diff.Services {
+		var changes []string
+		if service.FromVersion != service.ToVersion {
+			changes = append(changes, fmt.Sprintf("version %s → %s", orNone(service.FromVersion), orNone(service.ToVersion)))
+		}
+		if len(service.Config) > 0 {
+			changes = append(changes, fmt.Sprintf("%d config differences", len(service.Config)))
+		}
+		parts = append(parts, fmt.Sprintf("%s: %s", service.Service, strings.Join(changes, ", ")))
+	}
+	for _, resource := range diff.Resources {
+		parts = append(parts, fmt.Sprintf("resource %s only in %s", resource.Resource, resource.In))
+	}
+	return fmt.Sprintf("%s differs between %s and %s: %s.", diff.Application, diff.From, diff.To, strings.Join(parts, "; "))
+}
+
+func orNone(value string) string {
+	if value == "" {
+		return "none"
+	}
+	return value
+}
@@ -0,0 +1,175 @@
+package deployments
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/ai"
+	"github.com/krzachariassen/ZTDP/internal/contracts"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type summaryProvider struct {
+	response string
+	err      error
+	prompt   string
+}
+
+func (p *summaryProvider) CallAI(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	p.prompt = userPrompt
+	return p.response, p.err
+}
+
+func (p *summaryProvider) GetProviderInfo() *ai.ProviderInfo {
+	return &ai.ProviderInfo{Name: "summary"}
+}
+
+func (p *summaryProvider) Close() error { return nil }
+
+func newDiffTestGraph(t *testing.T) *graph.GlobalGraph {
+	t.Helper()
+	g := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	prodReplicas := 4
+	add := func(contract contracts.Contract) {
+		node, err := graph.ResolveContract(contract)
+		require.NoError(t, err)
+		g.AddNode(node)
+	}
+	add(contracts.ApplicationContract{Metadata: contracts.Metadata{Name: "checkout", Owner: "team-a"}})
+	add(contracts.EnvironmentContract{Metadata: contracts.Metadata{Name: "staging", Owner: "platform"}})
+	add(contracts.EnvironmentContract{Metadata: contracts.Metadata{Name: "prod", Owner: "platform"}})
+	add(contracts.ServiceContract{
+		Metadata: contracts.Metadata{Name: "checkout-api", Owner: "team-a"},
+		Spec: contracts.ServiceSpec{
+			Application: "checkout",
+			Port:        8080,
+			Replicas:    1,
+			Env:         map[string]string{"LOG_LEVEL": "debug"},
+			Overrides: map[string]contracts.ServiceOverride{
+				"prod": {Replicas: &prodReplicas, Env: map[string]string{"LOG_LEVEL": "info"}},
+			},
+		},
+	})
+	add(contracts.ServiceContract{
+		Metadata: contracts.Metadata{Name: "checkout-worker", Owner: "team-a"},
+		Spec:     contracts.ServiceSpec{Application: "checkout", Port: 9090},
+	})
+	for _, version := range []string{"1.0.0", "1.1.0"} {
+		add(contracts.ServiceVersionContract{IDValue: "checkout-api:" + version, Name: "checkout-api", Version: version})
+	}
+	g.AddNode(&graph.Node{ID: "checkout-db", Kind: graph.KindResource, Metadata: map[string]interface{}{"name": "checkout-db", "application": "checkout", "catalog_ref": "postgres"}, Spec: map[string]interface{}{}})
+
+	for _, edge := range [][3]string{
+		{"checkout", "checkout-api", graph.EdgeTypeOwns},
+		{"checkout", "checkout-worker", graph.EdgeTypeOwns},
+		{"checkout", "checkout-db", graph.EdgeTypeOwns},
+		{"checkout-api", "checkout-api:1.0.0", "has_version"},
+		{"checkout-api", "checkout-api:1.1.0", "has_version"},
+		{"checkout-api:1.0.0", "prod", graph.EdgeTypeDeploy},
+		{"checkout-api:1.1.0", "staging", graph.EdgeTypeDeploy},
+		{"checkout-api", "checkout-db", graph.EdgeTypeUses},
+		{"checkout-db", "staging", graph.EdgeTypeDeploy},
+	} {
+		require.NoError(t, g.AddEdge(edge[0], edge[1], edge[2]))
+	}
+
+	// Deployments as the deployment agent records them, against release IDs
+	current, err := g.Graph()
+	require.NoError(t, err)
+	current.Edges["release-checkout-100"] = []graph.Edge{
+		{To: "prod", Type: "deployment", Metadata: map[string]interface{}{"deployment_id": "d1", "status": "succeeded", "created_at": "2026-01-01T10:00:00Z"}},
+		{To: "staging", Type: "deployment", Metadata: map[string]interface{}{"deployment_id": "d2", "status": "succeeded", "created_at": "2026-01-01T09:00:00Z"}},
+	}
+	current.Edges["release-checkout-200"] = []graph.Edge{
+		{To: "staging", Type: "deployment", Metadata: map[string]interface{}{"deployment_id": "d3", "status": "succeeded", "created_at": "2026-01-02T09:00:00Z"}},
+	}
+	require.NoError(t, g.Save())
+	return g
+}
+
+func TestDiffEnvironments(t *testing.T) {
+	g := newDiffTestGraph(t)
+	service := NewDeploymentService(g, nil)
+
+	diff, err := service.DiffEnvironments(context.Background(), "checkout", "staging", "prod")
+	require.NoError(t, err)
+	assert.False(t, diff.Identical)
+
+	require.NotNil(t, diff.Release.From)
+	require.NotNil(t, diff.Release.To)
+	assert.Equal(t, "release-checkout-200", diff.Release.From.ReleaseID)
+	assert.Equal(t, "release-checkout-100", diff.Release.To.ReleaseID)
+	assert.True(t, diff.Release.Changed)
+
+	require.Len(t, diff.Services, 1, "checkout-worker is the same in both environments")
+	api := diff.Services[0]
+	assert.Equal(t, "1.1.0", api.FromVersion)
+	assert.Equal(t, "1.0.0", api.ToVersion)
+	assert.Equal(t, []ConfigChange{
+		{Field: "replicas", From: "1", To: "4"},
+		{Field: "env.LOG_LEVEL", From: "debug", To: "info"},
+	}, api.Config)
+
+	require.Len(t, diff.Resources, 1)
+	assert.Equal(t, ResourceChange{Resource: "checkout-db", In: "staging", UsedBy: []string{"checkout-api"}}, diff.Resources[0])
+
+	assert.Equal(t, "generated", diff.SummarySource)
+	assert.Contains(t, diff.Summary, "resource checkout-db only in staging")
+
+	same, err := service.DiffEnvironments(context.Background(), "checkout", "prod", "prod")
+	require.NoError(t, err)
+	assert.True(t, same.Identical)
+
+	_, err = service.DiffEnvironments(context.Background(), "checkout", "staging", "qa")
+	assert.ErrorContains(t, err, "environment qa not found")
+}
+
+func TestDiffEnvironmentsAISummary(t *testing.T) {
+	g := newDiffTestGraph(t)
+
+	provider := &summaryProvider{response: "Prod runs an older checkout-api and has no checkout-db.\n"}
+	diff, err := NewDeploymentService(g, provider).DiffEnvironments(context.Background(), "checkout", "staging", "prod")
+	require.NoError(t, err)
+	assert.Equal(t, "ai", diff.SummarySource)
+	assert.Equal(t, "Prod runs an older checkout-api and has no checkout-db.", diff.Summary)
+	assert.Contains(t, provider.prompt, "checkout-db")
+
+	failing := &summaryProvider{err: errors.New("rate limited")}
+	diff, err = NewDeploymentService(g, failing).DiffEnvironments(context.Background(), "checkout", "staging", "prod")
+	require.NoError(t, err)
+	assert.Equal(t, "generated", diff.SummarySource)
+}
+
+func TestDiffEnvironmentsConfigContract(t *testing.T) {
+	g := newDiffTestGraph(t)
+	g.AddNode(&graph.Node{ID: "checkout-db-replica", Kind: graph.KindResource, Metadata: map[string]interface{}{"name": "checkout-db-replica", "application": "checkout", "catalog_ref": "postgres"}, Spec: map[string]interface{}{"connection_string": "postgres://replica"}})
+	require.NoError(t, g.AddEdge("checkout", "checkout-db-replica", graph.EdgeTypeOwns))
+	workerNode, err := g.GetNode("checkout-worker")
+	require.NoError(t, err)
+	workerNode.Spec["config"] = []interface{}{
+		map[string]interface{}{"name": "DATABASE_URL", "from": "checkout-db.connection_string", "secret": true},
+		map[string]interface{}{"name": "BATCH_SIZE", "default": "100"},
+	}
+	workerNode.Spec["overrides"] = map[string]interface{}{
+		"prod": map[string]interface{}{"config": []interface{}{map[string]interface{}{"name": "DATABASE_URL", "from": "checkout-db-replica.connection_string", "secret": true}}},
+	}
+	db, err := g.GetNode("checkout-db")
+	require.NoError(t, err)
+	db.Spec["connection_string"] = "postgres://primary"
+
+	diff, err := NewDeploymentService(g, nil).DiffEnvironments(context.Background(), "checkout", "staging", "prod")
+	require.NoError(t, err)
+	var worker *ServiceDiff
+	for i := range diff.Services {
+		if diff.Services[i].Service == "checkout-worker" {
+			worker = &diff.Services[i]
+		}
+	}
+	require.NotNil(t, worker)
+	assert.Equal(t, []ConfigChange{
+		{Field: "config.DATABASE_URL", From: "******** (checkout-db.connection_string)", To: "******** (checkout-db-replica.connection_string)"},
+	}, worker.Config, "secret values are masked and unchanged keys are left out")
+}
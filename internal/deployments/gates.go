@@ -0,0 +1,179 @@
+package deployments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/calendar"
+	"github.com/krzachariassen/ZTDP/internal/governance"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/krzachariassen/ZTDP/internal/resources"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
+)
+
+var gateLogger = logging.GetLogger().ForComponent("deployment-gates")
+
+// EvaluateGates runs the checks every rollout into an environment passes before it executes:
+// the Policy Agent, vulnerability, promotion, autoscaling and migration gates when those
+// agents are reachable, then resource lifecycles and the change calendar. It returns
+// "allowed", or "blocked" with the reason. Other domains that deploy on behalf of an
+// application, such as ML model endpoints, go through the same gates.
+//
+// The outcome is recorded as the release's policy decision for the environment, which the
+// graph requires before a deployment into a protected environment rolls out.
+func EvaluateGates(ctx context.Context, globalGraph *graph.GlobalGraph, appName, environment, releaseID string) (string, error) {
+	decision, err := evaluateGates(ctx, globalGraph, appName, environment, releaseID)
+	if releaseID == "" || (decision != governance.DecisionAllowed && decision != governance.DecisionBlocked) {
+		return decision, err
+	}
+	record := governance.Decision{Application: appName, Environment: environment, ReleaseID: releaseID, Decision: decision}
+	if err != nil {
+		record.Reason = err.Error()
+	}
+	if recordErr := governance.RecordDecision(globalGraph, record); recordErr != nil {
+		gateLogger.Error("❌ Failed to record policy decision for %s → %s: %v", releaseID, environment, recordErr)
+		if err == nil {
+			return "", fmt.Errorf("failed to record policy decision: %w", recordErr)
+		}
+	}
+	return decision, err
+}
+
+func evaluateGates(ctx context.Context, globalGraph *graph.GlobalGraph, appName, environment, releaseID string) (string, error) {
+
+	// Ask the Policy Agent directly and wait for its decision
+	result, err := agentframework.QueryAgent(ctx, "policy_evaluation", map[string]interface{}{
+		"intent":      "evaluate deployment",
+		"application": appName,
+		"environment": environment,
+		"release_id":  releaseID,
+		"edge": map[string]interface{}{
+			"to":   environment,
+			"type": "deploy",
+			"metadata": map[string]interface{}{
+				"application": appName,
+				"release_id":  releaseID,
+			},
+		},
+	}, policyQueryTimeout)
+	switch {
+	case err == nil:
+		decision, _ := result.Payload["decision"].(string)
+		reasoning, _ := result.Payload["reasoning"].(string)
+		gateLogger.Info("📥 Policy Agent decided %q for %s → %s", decision, appName, environment)
+		if decision == "blocked" {
+			return "blocked", fmt.Errorf("blocked by policy agent: %s", reasoning)
+		}
+	case errors.Is(err, agentframework.ErrNoAgentForCapability), errors.Is(err, agentframework.ErrNoQueryingAgent):
+		gateLogger.Info("ℹ️ No Policy Agent reachable, applying local deployment checks only")
+	default:
+		// An evaluation the Policy Agent could not complete is not a decision; the local checks below still apply
+		gateLogger.Warn("⚠️ Policy Agent could not evaluate %s → %s: %v", appName, environment, err)
+	}
+
+	// Versions with critical CVEs above the configured threshold must not ship
+	result, err = agentframework.QueryAgent(ctx, "vulnerability_gate", map[string]interface{}{
+		"intent":      "check vulnerabilities",
+		"application": appName,
+		"environment": environment,
+		"release_id":  releaseID,
+	}, policyQueryTimeout)
+	switch {
+	case err == nil:
+		if decision, _ := result.Payload["decision"].(string); decision == "blocked" {
+			reasoning, _ := result.Payload["reasoning"].(string)
+			return "blocked", fmt.Errorf("blocked by vulnerability gate: %s", reasoning)
+		}
+	case errors.Is(err, agentframework.ErrNoAgentForCapability), errors.Is(err, agentframework.ErrNoQueryingAgent):
+		gateLogger.Info("ℹ️ No vulnerability gate reachable for %s", appName)
+	default:
+		gateLogger.Warn("⚠️ Vulnerability gate could not evaluate %s: %v", appName, err)
+	}
+
+	// Promotions wait until the application has soaked in the lower environment
+	result, err = agentframework.QueryAgent(ctx, "promotion_gate", map[string]interface{}{
+		"intent":      "check soak time",
+		"application": appName,
+		"environment": environment,
+		"release_id":  releaseID,
+	}, policyQueryTimeout)
+	switch {
+	case err == nil:
+		if decision, _ := result.Payload["decision"].(string); decision == "blocked" {
+			reasoning, _ := result.Payload["reasoning"].(string)
+			return "blocked", fmt.Errorf("blocked by promotion gate: %s", reasoning)
+		}
+	case errors.Is(err, agentframework.ErrNoAgentForCapability), errors.Is(err, agentframework.ErrNoQueryingAgent):
+		gateLogger.Info("ℹ️ No promotion gate reachable for %s", appName)
+	default:
+		gateLogger.Warn("⚠️ Promotion gate could not evaluate %s → %s: %v", appName, environment, err)
+	}
+
+	// Replicas and autoscaling bounds must fit the environment's constraints
+	result, err = agentframework.QueryAgent(ctx, "autoscaling_policy", map[string]interface{}{
+		"intent":      "check autoscaling",
+		"application": appName,
+		"environment": environment,
+	}, policyQueryTimeout)
+	switch {
+	case err == nil:
+		if decision, _ := result.Payload["decision"].(string); decision == "blocked" {
+			reasoning, _ := result.Payload["reasoning"].(string)
+			return "blocked", fmt.Errorf("blocked by autoscaling policy: %s", reasoning)
+		}
+	case errors.Is(err, agentframework.ErrNoAgentForCapability), errors.Is(err, agentframework.ErrNoQueryingAgent):
+		gateLogger.Info("ℹ️ No autoscaling policy reachable for %s", appName)
+	default:
+		gateLogger.Warn("⚠️ Autoscaling policy could not evaluate %s → %s: %v", appName, environment, err)
+	}
+
+	// Pending schema migrations must be reversible where the environment requires it
+	result, err = agentframework.QueryAgent(ctx, "migration_gate", map[string]interface{}{
+		"intent":      "check migrations",
+		"application": appName,
+		"environment": environment,
+	}, policyQueryTimeout)
+	switch {
+	case err == nil:
+		if decision, _ := result.Payload["decision"].(string); decision == "blocked" {
+			reasoning, _ := result.Payload["reasoning"].(string)
+			return "blocked", fmt.Errorf("blocked by migration gate: %s", reasoning)
+		}
+	case errors.Is(err, agentframework.ErrNoAgentForCapability), errors.Is(err, agentframework.ErrNoQueryingAgent):
+		gateLogger.Info("ℹ️ No migration gate reachable for %s", appName)
+	default:
+		gateLogger.Warn("⚠️ Migration gate could not evaluate %s → %s: %v", appName, environment, err)
+	}
+
+	// Simple validation for demo
+	if environment == "production" && appName == "critical-app" {
+		return "blocked", fmt.Errorf("critical application requires manual approval for production")
+	}
+
+	// Resources in maintenance or decommissioned block the deployment
+	warnings, err := resources.CheckDeployable(globalGraph, appName)
+	if err != nil {
+		return "blocked", err
+	}
+	for _, warning := range warnings {
+		gateLogger.Warn("⚠️ Deploying %s with %s", appName, warning)
+	}
+
+	// Nothing ships into a change freeze
+	conflicts, err := calendar.NewService(globalGraph).CheckDeployment(appName, environment, time.Now())
+	if err != nil {
+		return "", err
+	}
+	if err := calendar.Blocking(conflicts); err != nil {
+		return "blocked", err
+	}
+	for _, conflict := range conflicts {
+		gateLogger.Warn("⚠️ Deploying %s to %s while %s", appName, environment, conflict.Reason)
+	}
+
+	gateLogger.Info("🛡️ Policy validation passed")
+	return "allowed", nil
+}
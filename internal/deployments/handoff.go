@@ -0,0 +1,145 @@
+package deployments
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// InFlight follows deployment progress events to know which deployments have not finished,
+// so an orchestrator upgrade can hand them over instead of leaving them stuck mid-plan
+type InFlight struct {
+	graph  *graph.GlobalGraph
+	bus    *events.EventBus
+	logger *logging.Logger
+	now    func() time.Time
+
+	mu      sync.Mutex
+	running map[string]ProgressUpdate // latest update per deployment run
+}
+
+// NewInFlight starts following the progress events on bus
+func NewInFlight(globalGraph *graph.GlobalGraph, bus *events.EventBus) *InFlight {
+	f := &InFlight{
+		graph:   globalGraph,
+		bus:     bus,
+		logger:  logging.GetLogger().ForComponent("deployment-handoff"),
+		now:     time.Now,
+		running: map[string]ProgressUpdate{},
+	}
+	bus.Subscribe(events.EventTypeNotify, func(event events.Event) error {
+		if event.Subject != ProgressSubject {
+			return nil
+		}
+		data, err := json.Marshal(event.Payload)
+		if err != nil {
+			return err
+		}
+		var update ProgressUpdate
+		if err := json.Unmarshal(data, &update); err != nil {
+			return fmt.Errorf("invalid progress event: %w", err)
+		}
+		f.observe(update)
+		return nil
+	})
+	return f
+}
+
+func runKey(update ProgressUpdate) string {
+	if update.CorrelationID != "" {
+		return update.CorrelationID
+	}
+	return update.Application + "/" + update.Environment
+}
+
+func (f *InFlight) observe(update ProgressUpdate) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	finished := update.State == StepFailed || (update.State == StepCompleted && update.StepIndex == update.TotalSteps)
+	if finished {
+		delete(f.running, runKey(update))
+		return
+	}
+	f.running[runKey(update)] = update
+}
+
+// Running returns the latest progress of every unfinished deployment, oldest first
+func (f *InFlight) Running() []ProgressUpdate {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	running := make([]ProgressUpdate, 0, len(f.running))
+	for _, update := range f.running {
+		running = append(running, update)
+	}
+	sort.Slice(running, func(i, j int) bool { return running[i].Timestamp.Before(running[j].Timestamp) })
+	return running
+}
+
+// Checkpoint returns the unfinished deployments (implements checkpoint.Participant)
+func (f *InFlight) Checkpoint() (interface{}, error) {
+	running := f.Running()
+	if len(running) == 0 {
+		return nil, nil
+	}
+	return running, nil
+}
+
+// Restore closes out deployments another instance stopped in the middle of: their deployment
+// records are marked interrupted and a final progress event ends the run, so nothing waits on
+// them. The orchestrator re-sends the requests behind them, which start new runs.
+func (f *InFlight) Restore(data json.RawMessage) error {
+	var interrupted []ProgressUpdate
+	if err := json.Unmarshal(data, &interrupted); err != nil {
+		return fmt.Errorf("invalid deployment progress: %w", err)
+	}
+	for _, update := range interrupted {
+		message := fmt.Sprintf("Orchestrator upgraded during step %d/%d (%s); the deployment is restarted by the new instance",
+			update.StepIndex, update.TotalSteps, update.Step)
+		if update.DeploymentID != "" {
+			if err := f.markInterrupted(update.DeploymentID, message); err != nil {
+				f.logger.Warn("⚠️ Failed to mark deployment %s interrupted: %v", update.DeploymentID, err)
+			}
+		}
+
+		final := update
+		final.State = StepFailed
+		final.ETASeconds = 0
+		final.Attempt = 0
+		final.Message = message
+		final.Timestamp = f.now().UTC()
+		if err := f.bus.Emit(events.EventTypeNotify, "deployment-agent", ProgressSubject, final.Payload()); err != nil {
+			f.logger.Warn("⚠️ Failed to emit progress for interrupted deployment of %s: %v", update.Application, err)
+		}
+		f.logger.Info("🔁 Closed out %s → %s deployment interrupted at %s", update.Application, update.Environment, update.Step)
+	}
+	return nil
+}
+
+// markInterrupted sets the status of the deployment edge with the given ID
+func (f *InFlight) markInterrupted(deploymentID, message string) error {
+	return f.graph.Update(func(current *graph.Graph) error {
+		for from, edges := range current.Edges {
+			for i, edge := range edges {
+				if id, _ := edge.Metadata["deployment_id"].(string); id != deploymentID {
+					continue
+				}
+				status, _ := edge.Metadata["status"].(string)
+				if status != "pending" && status != "in-progress" {
+					return graph.ErrSkipSave // the deployment finished after all
+				}
+				edge.Metadata["status"] = "interrupted"
+				edge.Metadata["updated_at"] = f.now().Format(time.RFC3339)
+				edge.Metadata["message"] = message
+				current.Edges[from][i] = edge
+				return nil
+			}
+		}
+		return fmt.Errorf("deployment edge not found: %s", deploymentID)
+	})
+}
@@ -0,0 +1,74 @@
+package deployments
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInFlightHandsOverUnfinishedDeployments(t *testing.T) {
+	g := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	g.AddNode(&graph.Node{ID: "checkout", Kind: graph.KindApplication, Metadata: map[string]interface{}{"name": "checkout"}, Spec: map[string]interface{}{}})
+	g.AddNode(&graph.Node{ID: "dev", Kind: graph.KindEnvironment, Metadata: map[string]interface{}{"name": "dev"}, Spec: map[string]interface{}{}})
+	current, err := g.Graph()
+	require.NoError(t, err)
+	current.Edges["checkout"] = append(current.Edges["checkout"], graph.Edge{
+		To:       "dev",
+		Type:     graph.EdgeTypeDeploy,
+		Metadata: map[string]interface{}{"deployment_id": "deployment-1", "status": "in-progress"},
+	})
+	require.NoError(t, g.Save())
+
+	// The stopping instance sees one deployment finish and one still executing
+	oldBus := events.NewEventBus(nil, false)
+	stopping := NewInFlight(g, oldBus)
+	steps := []string{"create-release", "execute"}
+	done := NewProgressTracker(logging.WithCorrelationID(context.Background(), "corr-done"), oldBus, "billing", "dev", steps)
+	done.Start("create-release")
+	done.Complete("create-release")
+	done.Start("execute")
+	done.Complete("execute")
+	running := NewProgressTracker(logging.WithCorrelationID(context.Background(), "corr-running"), oldBus, "checkout", "dev", steps)
+	running.SetDeploymentID("deployment-1")
+	running.Start("create-release")
+	running.Complete("create-release")
+	running.Start("execute")
+
+	work, err := stopping.Checkpoint()
+	require.NoError(t, err)
+	unfinished, ok := work.([]ProgressUpdate)
+	require.True(t, ok)
+	require.Len(t, unfinished, 1)
+	assert.Equal(t, "checkout", unfinished[0].Application)
+	assert.Equal(t, "execute", unfinished[0].Step)
+	data, err := json.Marshal(work)
+	require.NoError(t, err)
+
+	// The instance taking over closes the run out
+	newBus := events.NewEventBus(nil, false)
+	var final map[string]interface{}
+	newBus.Subscribe(events.EventTypeNotify, func(event events.Event) error {
+		if event.Subject == ProgressSubject {
+			final = event.Payload
+		}
+		return nil
+	})
+	takingOver := NewInFlight(g, newBus)
+	require.NoError(t, takingOver.Restore(data))
+
+	require.NotNil(t, final)
+	assert.Equal(t, "failed", final["state"])
+	assert.Equal(t, "corr-running", final["correlation_id"])
+	assert.Empty(t, takingOver.Running(), "the closing event ends the run")
+
+	edges, err := g.Edges()
+	require.NoError(t, err)
+	require.Len(t, edges["checkout"], 1)
+	assert.Equal(t, "interrupted", edges["checkout"][0].Metadata["status"])
+}
@@ -0,0 +1,92 @@
+package deployments
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// DeploymentAttempt is one deployment of an application to an environment
+type DeploymentAttempt struct {
+	DeploymentID string         `json:"deployment_id"`
+	ReleaseID    string         `json:"release_id"`
+	Region       string         `json:"region,omitempty"` // set for each region of a multi-region deployment
+	Status       string         `json:"status"`           // the latest status
+	CreatedAt    time.Time      `json:"created_at"`
+	History      []StatusChange `json:"history"`
+}
+
+// DeploymentHistory returns every deployment of an application to an environment, oldest
+// first, each with the status changes it went through
+func (s *Service) DeploymentHistory(appName, environment string) ([]DeploymentAttempt, error) {
+	currentGraph, err := s.globalGraph.Graph()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get graph: %w", err)
+	}
+	if node, ok := currentGraph.Nodes[appName]; !ok || node.Kind != graph.KindApplication {
+		return nil, fmt.Errorf("application %s not found", appName)
+	}
+	if node, ok := currentGraph.Nodes[environment]; !ok || node.Kind != graph.KindEnvironment {
+		return nil, fmt.Errorf("environment %s not found", environment)
+	}
+
+	attempts := []DeploymentAttempt{}
+	for from, edges := range currentGraph.Edges {
+		for _, edge := range edges {
+			if edge.Type != "deployment" || edge.To != environment || !deploys(edge, from, appName) {
+				continue
+			}
+			attempts = append(attempts, deploymentAttempt(from, edge))
+		}
+	}
+	sort.Slice(attempts, func(i, j int) bool {
+		if !attempts[i].CreatedAt.Equal(attempts[j].CreatedAt) {
+			return attempts[i].CreatedAt.Before(attempts[j].CreatedAt)
+		}
+		return attempts[i].DeploymentID < attempts[j].DeploymentID
+	})
+	return attempts, nil
+}
+
+// deploys reports whether a deployment edge deploys the application. Edges created before the
+// application was recorded on them are matched by their release ID.
+func deploys(edge graph.Edge, releaseID, appName string) bool {
+	if application, ok := edge.Metadata["application"].(string); ok {
+		return application == appName
+	}
+	return strings.HasPrefix(releaseID, "release-"+appName+"-")
+}
+
+func deploymentAttempt(releaseID string, edge graph.Edge) DeploymentAttempt {
+	attempt := DeploymentAttempt{ReleaseID: releaseID, History: StatusHistory(edge.Metadata)}
+	attempt.DeploymentID, _ = edge.Metadata["deployment_id"].(string)
+	attempt.Status, _ = edge.Metadata["status"].(string)
+	attempt.Region, _ = edge.Metadata["region"].(string)
+	if created, ok := edge.Metadata["created_at"].(string); ok {
+		attempt.CreatedAt, _ = time.Parse(time.RFC3339, created)
+	}
+	// Edges from before the history was kept only know their latest status
+	if len(attempt.History) == 0 && attempt.Status != "" {
+		change := StatusChange{Status: attempt.Status}
+		change.Message, _ = edge.Metadata["message"].(string)
+		if updated, ok := edge.Metadata["updated_at"].(string); ok {
+			change.Timestamp, _ = time.Parse(time.RFC3339, updated)
+		}
+		attempt.History = []StatusChange{change}
+	}
+	return attempt
+}
+
+// deploymentActor is who a status change is attributed to: the user the request came from,
+// or the deployment agent when it acts without one
+func deploymentActor(ctx context.Context) string {
+	if userID := logging.UserIDFromContext(ctx); userID != "" {
+		return userID
+	}
+	return "deployment-agent"
+}
@@ -0,0 +1,50 @@
+package deployments
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusHistory(t *testing.T) {
+	metadata := map[string]interface{}{}
+	started := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	AppendStatusChange(metadata, StatusChange{Status: "pending", Message: "Deployment created", Actor: "alice", Timestamp: started})
+	AppendStatusChange(metadata, StatusChange{Status: "failed", Actor: "deployment-agent", Timestamp: started.Add(time.Minute)})
+
+	assert.Equal(t, []StatusChange{
+		{Status: "pending", Message: "Deployment created", Actor: "alice", Timestamp: started},
+		{Status: "failed", Actor: "deployment-agent", Timestamp: started.Add(time.Minute)},
+	}, StatusHistory(metadata))
+	assert.Empty(t, StatusHistory(map[string]interface{}{}))
+}
+
+func TestDeploymentHistory(t *testing.T) {
+	g := newDiffTestGraph(t)
+	current, err := g.Graph()
+	require.NoError(t, err)
+	edge := &current.Edges["release-checkout-200"][0]
+	edge.Metadata["application"] = "checkout"
+	AppendStatusChange(edge.Metadata, StatusChange{Status: "pending", Timestamp: time.Date(2026, 1, 2, 9, 0, 0, 0, time.UTC)})
+	AppendStatusChange(edge.Metadata, StatusChange{Status: "succeeded", Timestamp: time.Date(2026, 1, 2, 9, 5, 0, 0, time.UTC)})
+	require.NoError(t, g.Save())
+	service := NewDeploymentService(g, nil)
+
+	history, err := service.DeploymentHistory("checkout", "staging")
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, "d2", history[0].DeploymentID, "oldest first")
+	assert.Equal(t, "release-checkout-100", history[0].ReleaseID)
+	require.Len(t, history[0].History, 1, "edges without a history report their latest status")
+	assert.Equal(t, "succeeded", history[0].History[0].Status)
+	assert.Equal(t, "d3", history[1].DeploymentID)
+	require.Len(t, history[1].History, 2)
+	assert.Equal(t, "pending", history[1].History[0].Status)
+
+	_, err = service.DeploymentHistory("checkout", "qa")
+	assert.ErrorContains(t, err, "not found")
+	_, err = service.DeploymentHistory("payments", "staging")
+	assert.ErrorContains(t, err, "not found")
+}
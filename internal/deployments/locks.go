@@ -0,0 +1,86 @@
+package deployments
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DeploymentLockTTL is how long a deployment may hold its environment before another one can
+// take over, so a deployment that never finishes does not block the environment for good
+const DeploymentLockTTL = 30 * time.Minute
+
+// InProgressError rejects a deployment while another deployment of the same application to
+// the same environment is still running
+type InProgressError struct {
+	Application   string
+	Environment   string
+	CorrelationID string // the request the running deployment belongs to
+	Since         time.Time
+}
+
+func (e *InProgressError) Error() string {
+	return fmt.Sprintf("a deployment of %s to %s is already in progress (correlation ID %s, started %s)",
+		e.Application, e.Environment, e.CorrelationID, e.Since.Format(time.RFC3339))
+}
+
+type environmentLock struct {
+	correlationID string
+	acquired      time.Time
+	token         uint64 // identifies this acquisition among the ones of the key
+	holders       int    // acquisitions by the request not released yet
+}
+
+// EnvironmentLocks allows one deployment of an application to an environment at a time. Every
+// component that deploys must share one instance, or they do not exclude each other.
+type EnvironmentLocks struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu     sync.Mutex
+	held   map[string]*environmentLock // by application/environment
+	tokens uint64
+}
+
+// NewEnvironmentLocks creates locks that expire ttl after they were acquired
+func NewEnvironmentLocks(ttl time.Duration) *EnvironmentLocks {
+	return &EnvironmentLocks{ttl: ttl, now: time.Now, held: map[string]*environmentLock{}}
+}
+
+// Acquire locks the application's environment for the request with correlationID. It returns
+// an *InProgressError while another request holds an unexpired lock; otherwise the caller must
+// call the returned release once the deployment completes or fails. The request holding the
+// lock may acquire it again, e.g. a workflow step deploying through the deployment agent; the
+// lock is held until every acquisition is released.
+func (l *EnvironmentLocks) Acquire(appName, environment, correlationID string) (func(), error) {
+	key := appName + "/" + environment
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	held, ok := l.held[key]
+	switch {
+	case ok && now.Sub(held.acquired) < l.ttl && held.correlationID != correlationID:
+		return nil, &InProgressError{Application: appName, Environment: environment, CorrelationID: held.correlationID, Since: held.acquired}
+	case ok && now.Sub(held.acquired) < l.ttl:
+		held.holders++
+	default:
+		l.tokens++
+		held = &environmentLock{correlationID: correlationID, acquired: now, token: l.tokens, holders: 1}
+		l.held[key] = held
+	}
+
+	token := held.token
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			// A lock that expired may have been taken over; only its holders release it
+			if current, ok := l.held[key]; ok && current.token == token {
+				if current.holders--; current.holders == 0 {
+					delete(l.held, key)
+				}
+			}
+		})
+	}, nil
+}
@@ -0,0 +1,56 @@
+package deployments
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvironmentLocks(t *testing.T) {
+	locks := NewEnvironmentLocks(time.Minute)
+	now := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	locks.now = func() time.Time { return now }
+
+	release, err := locks.Acquire("checkout", "prod", "corr-1")
+	require.NoError(t, err)
+
+	_, err = locks.Acquire("checkout", "prod", "corr-2")
+	var inProgress *InProgressError
+	require.True(t, errors.As(err, &inProgress))
+	assert.Equal(t, "corr-1", inProgress.CorrelationID)
+
+	_, err = locks.Acquire("checkout", "staging", "corr-2")
+	assert.NoError(t, err, "other environments are not locked")
+
+	release()
+	releaseNext, err := locks.Acquire("checkout", "prod", "corr-2")
+	require.NoError(t, err, "released locks can be taken")
+
+	now = now.Add(2 * time.Minute)
+	_, err = locks.Acquire("checkout", "prod", "corr-3")
+	require.NoError(t, err, "expired locks can be taken over")
+	releaseNext()
+	_, err = locks.Acquire("checkout", "prod", "corr-4")
+	assert.Error(t, err, "the expired holder must not release the new holder's lock")
+}
+
+func TestEnvironmentLocks_ReentryHoldsUntilEveryRelease(t *testing.T) {
+	locks := NewEnvironmentLocks(time.Minute)
+
+	outer, err := locks.Acquire("checkout", "prod", "corr-1")
+	require.NoError(t, err)
+	inner, err := locks.Acquire("checkout", "prod", "corr-1")
+	require.NoError(t, err, "the holding request may acquire the lock again")
+
+	inner()
+	inner()
+	_, err = locks.Acquire("checkout", "prod", "corr-2")
+	assert.Error(t, err, "the outer acquisition still holds the lock, however often the inner one is released")
+
+	outer()
+	_, err = locks.Acquire("checkout", "prod", "corr-2")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,195 @@
+package deployments
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// ProgressSubject is the subject deployment progress events are emitted on
+const ProgressSubject = "deployment.progress"
+
+// StepState is the state a progress event reports for a deployment step
+type StepState string
+
+const (
+	StepStarted   StepState = "started"
+	StepRetrying  StepState = "retrying"
+	StepCompleted StepState = "completed"
+	StepFailed    StepState = "failed"
+)
+
+// ProgressUpdate is the payload of a deployment.progress event
+type ProgressUpdate struct {
+	DeploymentID  string    `json:"deployment_id,omitempty"` // empty until the deployment edge exists
+	CorrelationID string    `json:"correlation_id,omitempty"`
+	Application   string    `json:"application"`
+	Environment   string    `json:"environment"`
+	Step          string    `json:"step"`
+	StepIndex     int       `json:"step_index"` // 1-based position in the plan
+	TotalSteps    int       `json:"total_steps"`
+	State         StepState `json:"state"`
+	Attempt       int       `json:"attempt,omitempty"` // retries only
+	Percent       float64   `json:"percent"`           // share of steps completed, 0-100
+	ETASeconds    float64   `json:"eta_seconds"`       // estimated time left; 0 when finished or unknown
+	Message       string    `json:"message,omitempty"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// Payload converts the update to an event payload
+func (u ProgressUpdate) Payload() map[string]interface{} {
+	payload := map[string]interface{}{
+		"deployment_id":  u.DeploymentID,
+		"correlation_id": u.CorrelationID,
+		"application":    u.Application,
+		"environment":    u.Environment,
+		"step":           u.Step,
+		"step_index":     u.StepIndex,
+		"total_steps":    u.TotalSteps,
+		"state":          string(u.State),
+		"percent":        u.Percent,
+		"eta_seconds":    u.ETASeconds,
+		"timestamp":      u.Timestamp.Format(time.RFC3339Nano),
+	}
+	if u.Attempt > 0 {
+		payload["attempt"] = u.Attempt
+	}
+	if u.Message != "" {
+		payload["message"] = u.Message
+	}
+	return payload
+}
+
+// ProgressTracker emits a progress event whenever a step of a deployment plan changes state.
+// The ETA assumes remaining steps take as long as completed ones did on average.
+type ProgressTracker struct {
+	mu            sync.Mutex
+	bus           *events.EventBus
+	logger        *logging.Logger
+	now           func() time.Time
+	steps         []string
+	application   string
+	environment   string
+	correlationID string
+	deploymentID  string
+	startedAt     map[string]time.Time
+	completed     int
+	elapsed       time.Duration // total duration of completed steps
+}
+
+// NewProgressTracker creates a tracker for a deployment running the given plan steps
+func NewProgressTracker(ctx context.Context, bus *events.EventBus, application, environment string, steps []string) *ProgressTracker {
+	return &ProgressTracker{
+		bus:           bus,
+		logger:        logging.GetLogger().ForComponent("deployment-progress"),
+		now:           time.Now,
+		steps:         steps,
+		application:   application,
+		environment:   environment,
+		correlationID: logging.CorrelationIDFromContext(ctx),
+		startedAt:     make(map[string]time.Time),
+	}
+}
+
+// SetDeploymentID attaches the deployment ID to subsequent events once the deployment edge exists
+func (t *ProgressTracker) SetDeploymentID(deploymentID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.deploymentID = deploymentID
+}
+
+// Start reports that a step began
+func (t *ProgressTracker) Start(step string) ProgressUpdate {
+	t.mu.Lock()
+	t.startedAt[step] = t.now()
+	update := t.update(step, StepStarted, 0, "")
+	t.mu.Unlock()
+	return t.emit(update)
+}
+
+// Retry reports that a step failed and is being attempted again
+func (t *ProgressTracker) Retry(step string, attempt int, cause error) ProgressUpdate {
+	t.mu.Lock()
+	update := t.update(step, StepRetrying, attempt, errorMessage(cause))
+	t.mu.Unlock()
+	return t.emit(update)
+}
+
+// Complete reports that a step finished successfully
+func (t *ProgressTracker) Complete(step string) ProgressUpdate {
+	t.mu.Lock()
+	if started, ok := t.startedAt[step]; ok {
+		t.elapsed += t.now().Sub(started)
+		delete(t.startedAt, step)
+	}
+	t.completed++
+	update := t.update(step, StepCompleted, 0, "")
+	t.mu.Unlock()
+	return t.emit(update)
+}
+
+// Fail reports that a step failed for good, ending the deployment
+func (t *ProgressTracker) Fail(step string, cause error) ProgressUpdate {
+	t.mu.Lock()
+	delete(t.startedAt, step)
+	update := t.update(step, StepFailed, 0, errorMessage(cause))
+	update.ETASeconds = 0
+	t.mu.Unlock()
+	return t.emit(update)
+}
+
+// update builds the event for a step; callers hold t.mu
+func (t *ProgressTracker) update(step string, state StepState, attempt int, message string) ProgressUpdate {
+	total := len(t.steps)
+	update := ProgressUpdate{
+		DeploymentID:  t.deploymentID,
+		CorrelationID: t.correlationID,
+		Application:   t.application,
+		Environment:   t.environment,
+		Step:          step,
+		StepIndex:     t.indexOf(step),
+		TotalSteps:    total,
+		State:         state,
+		Attempt:       attempt,
+		Message:       message,
+		Timestamp:     t.now().UTC(),
+	}
+	if total > 0 {
+		update.Percent = float64(min(t.completed, total)) * 100 / float64(total)
+	}
+	if t.completed > 0 && t.completed < total {
+		average := t.elapsed / time.Duration(t.completed)
+		update.ETASeconds = (average * time.Duration(total-t.completed)).Seconds()
+	}
+	return update
+}
+
+func (t *ProgressTracker) indexOf(step string) int {
+	for i, s := range t.steps {
+		if s == step {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func (t *ProgressTracker) emit(update ProgressUpdate) ProgressUpdate {
+	if t.bus == nil {
+		return update
+	}
+	if err := t.bus.Emit(events.EventTypeNotify, "deployment-agent", ProgressSubject, update.Payload()); err != nil {
+		// Progress is informational; a deployment never fails because it could not be reported
+		t.logger.Warn("⚠️ Failed to emit progress for %s step %s: %v", t.application, update.Step, err)
+	}
+	return update
+}
+
+func errorMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,65 @@
+package deployments
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgressTracker(t *testing.T) {
+	bus := events.NewEventBus(nil, false)
+	var received []map[string]interface{}
+	bus.Subscribe(events.EventTypeNotify, func(event events.Event) error {
+		if event.Subject == ProgressSubject {
+			received = append(received, event.Payload)
+		}
+		return nil
+	})
+
+	clock := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	ctx := logging.WithCorrelationID(context.Background(), "corr-1")
+	tracker := NewProgressTracker(ctx, bus, "checkout", "dev", []string{"create-release", "evaluate-policies", "execute", "verify"})
+	tracker.now = func() time.Time { return clock }
+
+	started := tracker.Start("create-release")
+	assert.Equal(t, StepStarted, started.State)
+	assert.Equal(t, 1, started.StepIndex)
+	assert.Zero(t, started.Percent)
+	assert.Zero(t, started.ETASeconds, "no ETA before any step completed")
+
+	clock = clock.Add(10 * time.Second)
+	tracker.SetDeploymentID("deployment-1")
+	completed := tracker.Complete("create-release")
+	assert.Equal(t, 25.0, completed.Percent)
+	assert.Equal(t, 30.0, completed.ETASeconds, "three steps left at 10s each")
+
+	tracker.Start("evaluate-policies")
+	clock = clock.Add(20 * time.Second)
+	completed = tracker.Complete("evaluate-policies")
+	assert.Equal(t, 50.0, completed.Percent)
+	assert.Equal(t, 30.0, completed.ETASeconds, "two steps left at 15s average")
+
+	tracker.Start("execute")
+	retry := tracker.Retry("execute", 2, errors.New("connection reset"))
+	assert.Equal(t, StepRetrying, retry.State)
+	assert.Equal(t, 2, retry.Attempt)
+	assert.Equal(t, "connection reset", retry.Message)
+
+	failed := tracker.Fail("execute", errors.New("connection reset"))
+	assert.Equal(t, StepFailed, failed.State)
+	assert.Zero(t, failed.ETASeconds)
+
+	require.Len(t, received, 7)
+	assert.Equal(t, "", received[0]["deployment_id"], "deployment ID unknown until the edge exists")
+	assert.Equal(t, "deployment-1", received[1]["deployment_id"])
+	assert.Equal(t, "corr-1", received[1]["correlation_id"])
+	assert.Equal(t, "retrying", received[5]["state"])
+	assert.Equal(t, 3, received[6]["step_index"])
+	assert.Equal(t, 4, received[6]["total_steps"])
+}
@@ -0,0 +1,265 @@
+package deployments
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/contracts"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/resources"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
+)
+
+// ModeMultiRegion fans a deployment out to the regions of its environment
+const ModeMultiRegion = "multi-region"
+
+// statusRolledBack is the status of a region's deployment edge after its rollout failed and
+// the region was rolled back to its previous release
+const statusRolledBack = "rolled_back"
+
+// requestedRegions returns whether a multi-region deployment was asked for and the regions
+// named for it. The event's "mode" and "regions" fields, set by API callers, win over what was
+// extracted from the message; naming regions implies a multi-region deployment.
+func requestedRegions(event *events.Event, params *DeploymentDomainParams) (bool, []string) {
+	mode, regions := params.Mode, params.Regions
+	if value, ok := event.Payload["mode"].(string); ok && value != "" {
+		mode = value
+	}
+	if value, ok := event.Payload["regions"].(string); ok && value != "" {
+		regions = value
+	}
+	var names []string
+	for _, name := range strings.Split(regions, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return mode == ModeMultiRegion || len(names) > 0, names
+}
+
+// targetRegions resolves the regions a multi-region deployment fans out to: the named ones, or
+// every region the environment spans. Single-region deployments have none.
+func targetRegions(g *graph.GlobalGraph, environment string, multiRegion bool, names []string) ([]string, error) {
+	if !multiRegion {
+		return nil, nil
+	}
+	spans, err := resources.EnvironmentRegions(g, environment)
+	if err != nil {
+		return nil, err
+	}
+	if len(spans) == 0 {
+		return nil, fmt.Errorf("environment %s spans no regions; add regions to it for a multi-region deployment", environment)
+	}
+	if len(names) == 0 {
+		for _, region := range spans {
+			names = append(names, region.Name)
+		}
+		return names, nil
+	}
+
+	var regions []string
+	seen := map[string]bool{}
+	for _, name := range names {
+		if !spansRegion(spans, name) {
+			return nil, fmt.Errorf("environment %s does not span region %s", environment, name)
+		}
+		if !seen[name] {
+			seen[name] = true
+			regions = append(regions, name)
+		}
+	}
+	return regions, nil
+}
+
+func spansRegion(spans []contracts.EnvironmentRegion, name string) bool {
+	for _, region := range spans {
+		if region.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// executeStep is the progress step rolling a release out to a region
+func executeStep(region string) string {
+	return "execute-" + region
+}
+
+// orchestrateRegions continues orchestrateDeployment once the release exists: it records a
+// deployment edge per region, evaluates policies and runs migrations once for all of them, then
+// rolls the release out region by region. A region whose rollout fails is rolled back to its
+// previous release and the remaining regions still deploy; the deployment fails only when no region succeeded.
+func (a *FrameworkDeploymentAgent) orchestrateRegions(ctx context.Context, progress *ProgressTracker, req Request, releaseID string, configs map[string]contracts.ServiceSpec) (*DeploymentResult, error) {
+	appName, environment, regions := req.Application, req.Environment, req.Regions
+	deploymentIDs := make([]string, 0, len(regions))
+	for _, region := range regions {
+		deploymentID, err := a.createDeploymentEdge(ctx, appName, releaseID, environment, region, "pending", req.Metadata)
+		if err != nil {
+			progress.Fail("create-release", err)
+			a.updateDeploymentStatuses(ctx, deploymentIDs, "failed", "Deployment edge creation failed")
+			return nil, fmt.Errorf("deployment edge creation failed for %s: %w", region, err)
+		}
+		deploymentIDs = append(deploymentIDs, deploymentID)
+	}
+	progress.Complete("create-release")
+
+	if err := a.evaluatePolicies(ctx, progress, appName, environment, releaseID, deploymentIDs); err != nil {
+		return nil, err
+	}
+
+	if err := agentframework.Checkpoint(ctx); err != nil {
+		progress.Fail("migrate", err)
+		a.updateDeploymentStatuses(ctx, deploymentIDs, string(StatusCancelled), "Deployment cancelled before execution")
+		return nil, fmt.Errorf("deployment cancelled before execution: %w", err)
+	}
+	a.updateDeploymentStatuses(ctx, deploymentIDs, "in-progress", "Executing deployment")
+
+	// Schemas are shared by every region, so migrations run once before the first rollout
+	progress.Start("migrate")
+	if err := a.runMigrations(ctx, appName, environment); err != nil {
+		progress.Fail("migrate", err)
+		a.updateDeploymentStatuses(ctx, deploymentIDs, "failed", fmt.Sprintf("Migrations failed: %v", err))
+		return nil, fmt.Errorf("migrations failed: %w", err)
+	}
+	progress.Complete("migrate")
+
+	result := &DeploymentResult{
+		Application: appName,
+		Environment: environment,
+		ReleaseID:   releaseID,
+		Configs:     configs,
+	}
+	var failed, rolledBack []string
+	for i, region := range regions {
+		outcome := a.deployRegion(ctx, progress, appName, environment, region, releaseID, deploymentIDs[i])
+		result.Regions = append(result.Regions, outcome)
+		switch {
+		case outcome.Status == statusRolledBack:
+			rolledBack = append(rolledBack, region)
+			failed = append(failed, region)
+		case outcome.Status != "succeeded":
+			failed = append(failed, region)
+		case result.DeploymentID == "":
+			result.DeploymentID = outcome.DeploymentID
+		}
+	}
+
+	status := "succeeded"
+	switch {
+	case len(failed) == len(regions):
+		return nil, fmt.Errorf("deployment failed in every region: %s", strings.Join(failed, ", "))
+	case len(failed) > 0:
+		status = "partially_succeeded"
+		result.Status = "partially_completed"
+		result.Message = fmt.Sprintf("Deployment failed in %s", strings.Join(failed, ", "))
+		if len(rolledBack) > 0 {
+			result.Message += fmt.Sprintf("; rolled back in %s", strings.Join(rolledBack, ", "))
+		}
+	default:
+		result.Status = "completed"
+		result.Message = fmt.Sprintf("Deployment completed in %d regions", len(regions))
+	}
+
+	completionEvent := events.Event{
+		Subject: "deployment.completed",
+		Source:  "deployment-agent",
+		Type:    events.EventTypeNotify,
+		Payload: map[string]interface{}{
+			"deployment_id": result.DeploymentID,
+			"application":   appName,
+			"environment":   environment,
+			"release_id":    releaseID,
+			"status":        status,
+			"regions":       result.Regions,
+			"timestamp":     time.Now().Unix(),
+		},
+	}
+	if err := a.eventBus.EmitEvent(completionEvent); err != nil {
+		a.logger.Error("Failed to emit deployment.completed event: %v", err)
+	}
+
+	a.logger.Info("✅ Multi-region deployment of %s to %s %s: %s", appName, environment, status, result.Message)
+	return result, nil
+}
+
+// deployRegion rolls the release out to one region, retrying transient failures, and rolls the
+// region back to its previous release when the rollout fails. A region that cannot be rolled
+// back is marked failed.
+func (a *FrameworkDeploymentAgent) deployRegion(ctx context.Context, progress *ProgressTracker, appName, environment, region, releaseID, deploymentID string) RegionResult {
+	step := executeStep(region)
+	outcome := RegionResult{Region: region, DeploymentID: deploymentID}
+
+	// The user may stop the fan-out between regions; regions already deployed stay deployed
+	if err := agentframework.Checkpoint(ctx); err != nil {
+		progress.Fail(step, err)
+		outcome.Status, outcome.Message = string(StatusCancelled), fmt.Sprintf("Cancelled before %s: %v", region, err)
+		a.updateDeploymentStatus(ctx, deploymentID, outcome.Status, outcome.Message)
+		return outcome
+	}
+
+	progress.Start(step)
+	var err error
+	for attempt := 1; ; attempt++ {
+		_, err = a.executor()(ctx, appName, environment, region, releaseID, deploymentID)
+		if err == nil || attempt == executeAttempts {
+			break
+		}
+		progress.Retry(step, attempt+1, err)
+	}
+	if err != nil {
+		progress.Fail(step, err)
+		if previous, rollbackErr := a.rollbackRegion(ctx, appName, environment, region, releaseID, deploymentID); rollbackErr != nil {
+			outcome.Status, outcome.Message = "failed", fmt.Sprintf("Failed in %s: %v; rollback failed: %v", region, err, rollbackErr)
+		} else {
+			outcome.Status, outcome.Message = statusRolledBack, fmt.Sprintf("Rolled back %s to %s: %v", region, previous, err)
+		}
+		a.updateDeploymentStatus(ctx, deploymentID, outcome.Status, outcome.Message)
+		return outcome
+	}
+	progress.Complete(step)
+	outcome.Status, outcome.Message = "succeeded", fmt.Sprintf("Deployed to %s", region)
+	a.updateDeploymentStatus(ctx, deploymentID, outcome.Status, outcome.Message)
+	return outcome
+}
+
+// rollbackRegion restores the region to the release it ran before: the latest other release
+// that deployed successfully to it, or to the whole environment. It returns that release.
+func (a *FrameworkDeploymentAgent) rollbackRegion(ctx context.Context, appName, environment, region, releaseID, deploymentID string) (string, error) {
+	previous, err := a.previousRegionRelease(appName, environment, region, releaseID)
+	if err != nil {
+		return "", err
+	}
+	a.logger.Warn("↩️ Rolling back %s in %s %s to %s", appName, environment, region, previous)
+	if _, err := a.executor()(ctx, appName, environment, region, previous, deploymentID); err != nil {
+		return "", fmt.Errorf("redeploying %s: %w", previous, err)
+	}
+	return previous, nil
+}
+
+// previousRegionRelease returns the latest release other than releaseID that deployed
+// successfully to the region. Single-region deployments count for every region.
+func (a *FrameworkDeploymentAgent) previousRegionRelease(appName, environment, region, releaseID string) (string, error) {
+	attempts, err := a.service.DeploymentHistory(appName, environment)
+	if err != nil {
+		return "", err
+	}
+	for i := len(attempts) - 1; i >= 0; i-- {
+		attempt := attempts[i]
+		if attempt.ReleaseID != releaseID && attempt.Status == "succeeded" && (attempt.Region == region || attempt.Region == "") {
+			return attempt.ReleaseID, nil
+		}
+	}
+	return "", fmt.Errorf("no previous release of %s succeeded in %s %s", appName, environment, region)
+}
+
+// updateDeploymentStatuses updates the status of several deployment edges
+func (a *FrameworkDeploymentAgent) updateDeploymentStatuses(ctx context.Context, deploymentIDs []string, status, message string) {
+	for _, deploymentID := range deploymentIDs {
+		if err := a.updateDeploymentStatus(ctx, deploymentID, status, message); err != nil {
+			a.logger.Warn("⚠️ Could not update %s: %v", deploymentID, err)
+		}
+	}
+}
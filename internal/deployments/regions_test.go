@@ -0,0 +1,108 @@
+package deployments
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRegionTestAgent(t *testing.T) (*FrameworkDeploymentAgent, *graph.GlobalGraph) {
+	t.Helper()
+	g := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	require.NoError(t, g.AddNode(&graph.Node{ID: "checkout", Kind: graph.KindApplication, Metadata: map[string]interface{}{"name": "checkout"}, Spec: map[string]interface{}{}}))
+	require.NoError(t, g.AddNode(&graph.Node{ID: "prod", Kind: graph.KindEnvironment, Metadata: map[string]interface{}{"name": "prod"}, Spec: map[string]interface{}{
+		"regions": []interface{}{
+			map[string]interface{}{"name": "eu-west", "clusters": []interface{}{"eu-west-a"}},
+			map[string]interface{}{"name": "us-east"},
+		},
+	}}))
+	require.NoError(t, g.AddNode(&graph.Node{ID: "checkout-db", Kind: graph.KindResource, Metadata: map[string]interface{}{"name": "checkout-db", "application": "checkout", "catalog_ref": "postgres"}, Spec: map[string]interface{}{"type": "postgres", "region": "eu-west", "cluster": "eu-west-a"}}))
+	require.NoError(t, g.AddEdge("checkout", "checkout-db", graph.EdgeTypeOwns))
+
+	agent := &FrameworkDeploymentAgent{
+		service:  &Service{globalGraph: g},
+		logger:   logging.GetLogger().ForComponent("deployment-agent"),
+		eventBus: events.NewEventBus(nil, false),
+	}
+	return agent, g
+}
+
+func TestTargetRegions(t *testing.T) {
+	_, g := newRegionTestAgent(t)
+
+	multi, names := requestedRegions(&events.Event{Payload: map[string]interface{}{"regions": "us-east, eu-west"}}, &DeploymentDomainParams{})
+	assert.True(t, multi, "naming regions asks for a multi-region deployment")
+	regions, err := targetRegions(g, "prod", multi, names)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"us-east", "eu-west"}, regions)
+
+	regions, err = targetRegions(g, "prod", true, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"eu-west", "us-east"}, regions, "every region the environment spans by default")
+	regions, err = targetRegions(g, "prod", false, nil)
+	require.NoError(t, err)
+	assert.Nil(t, regions)
+	_, err = targetRegions(g, "prod", true, []string{"ap-south"})
+	assert.ErrorContains(t, err, "does not span region ap-south")
+}
+
+func TestMultiRegionDeploymentRollsBackFailedRegions(t *testing.T) {
+	agent, g := newRegionTestAgent(t)
+
+	// The database only runs in eu-west, so a deployment to us-east alone is incompatible
+	_, err := agent.orchestrateDeployment(context.Background(), Request{Application: "checkout", Environment: "prod", Regions: []string{"us-east"}})
+	assert.ErrorContains(t, err, "checkout-db is in eu-west, which the deployment does not target")
+
+	type call struct{ region, releaseID string }
+	var calls []call
+	agent.execute = func(ctx context.Context, appName, environment, region, releaseID, deploymentID string) (*DeploymentResult, error) {
+		calls = append(calls, call{region, releaseID})
+		if region == "us-east" && releaseID != "release-checkout-1" {
+			return nil, errors.New("health checks failed")
+		}
+		return &DeploymentResult{DeploymentID: deploymentID}, nil
+	}
+	result, err := agent.orchestrateDeployment(context.Background(), Request{Application: "checkout", Environment: "prod", Regions: []string{"eu-west", "us-east"}})
+	require.NoError(t, err)
+	assert.Equal(t, "partially_completed", result.Status)
+	require.Len(t, result.Regions, 2)
+	assert.Equal(t, "succeeded", result.Regions[0].Status)
+	assert.Equal(t, "failed", result.Regions[1].Status, "without a previous release there is nothing to roll back to")
+	assert.Contains(t, result.Regions[1].Message, "rollback failed")
+
+	// A release that deployed to us-east earlier is what the region rolls back to
+	require.NoError(t, g.AddNode(&graph.Node{ID: "release-checkout-1", Kind: "release", Metadata: map[string]interface{}{"name": "release-checkout-1"}, Spec: map[string]interface{}{"application": "checkout"}}))
+	current, err := g.Graph()
+	require.NoError(t, err)
+	current.Edges["release-checkout-1"] = append(current.Edges["release-checkout-1"], graph.Edge{To: "prod", Type: "deployment", Metadata: map[string]interface{}{
+		"deployment_id": "deployment-release-checkout-1-prod-us-east", "application": "checkout", "region": "us-east",
+		"status": "succeeded", "created_at": "2026-01-01T00:00:00Z",
+	}})
+	require.NoError(t, g.Save())
+
+	calls = nil
+	result, err = agent.orchestrateDeployment(context.Background(), Request{Application: "checkout", Environment: "prod", Regions: []string{"eu-west", "us-east"}})
+	require.NoError(t, err)
+	assert.Equal(t, "partially_completed", result.Status)
+	require.Len(t, result.Regions, 2)
+	assert.Equal(t, "succeeded", result.Regions[0].Status)
+	assert.Equal(t, statusRolledBack, result.Regions[1].Status)
+	assert.Equal(t, "Deployment failed in us-east; rolled back in us-east", result.Message)
+	assert.Equal(t, call{"us-east", "release-checkout-1"}, calls[len(calls)-1], "the region's previous release is redeployed")
+
+	current, err = g.Graph()
+	require.NoError(t, err)
+	statuses := map[string]interface{}{}
+	for _, edge := range current.Edges[result.ReleaseID] {
+		if edge.Type == "deployment" {
+			statuses[edge.Metadata["region"].(string)] = edge.Metadata["status"]
+		}
+	}
+	assert.Equal(t, map[string]interface{}{"eu-west": "succeeded", "us-east": statusRolledBack}, statuses, "each region has its own deployment edge")
+}
@@ -7,22 +7,31 @@ import (
 	"strings"
 
 	"github.com/krzachariassen/ZTDP/internal/ai"
+	"github.com/krzachariassen/ZTDP/internal/features"
 	"github.com/krzachariassen/ZTDP/internal/graph"
 	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/krzachariassen/ZTDP/internal/redaction"
 )
 
+// FlagAutoExecutePlans controls whether AI-generated deployment plans are executed automatically
+const FlagAutoExecutePlans = "deployments.auto-execute-ai-plans"
+
 // Service provides ALL deployment domain logic (Clean Architecture - business logic only here)
 type Service struct {
 	globalGraph *graph.GlobalGraph
 	aiProvider  ai.AIProvider
+	extractor   *ai.Extractor
 	logger      *logging.Logger
 }
 
 // NewDeploymentService creates a new deployment service with AI capabilities
 func NewDeploymentService(globalGraph *graph.GlobalGraph, aiProvider ai.AIProvider) *Service {
+	extractor := ai.NewExtractor(aiProvider)
+	extractor.Register(deploymentExtraction)
 	return &Service{
 		globalGraph: globalGraph,
 		aiProvider:  aiProvider,
+		extractor:   extractor,
 		logger:      logging.GetLogger().ForComponent("deployment-service"),
 	}
 }
@@ -51,7 +60,26 @@ func (s *Service) DeployApplication(ctx context.Context, appName, environment st
 		return nil, fmt.Errorf("deployment planning failed: %w", err)
 	}
 
-	// 4. Execute deployment plan
+	// 4. Execute deployment plan, unless auto-execution of AI plans is switched off for this conversation
+	if !features.NewService(s.globalGraph).IsEnabled(ctx, FlagAutoExecutePlans, true) {
+		s.logger.Info("🚩 Auto-execution of AI plans disabled, returning plan for %s without deploying", appName)
+		return &DeploymentResult{
+			Application:  appName,
+			Environment:  environment,
+			DeploymentID: fmt.Sprintf("deploy-%s-%s", appName, environment),
+			Deployments:  []string{},
+			Skipped:      plan,
+			Failed:       []map[string]interface{}{},
+			Status:       "planned",
+			Message:      "Deployment plan generated; auto-execution is disabled by feature flag",
+			Summary: DeploymentSummary{
+				TotalServices: len(plan),
+				Skipped:       len(plan),
+				Message:       "Awaiting manual execution",
+			},
+		}, nil
+	}
+
 	result, err := s.executeDeploymentPlan(ctx, appName, environment, plan)
 	if err != nil {
 		return nil, fmt.Errorf("deployment execution failed: %w", err)
@@ -139,15 +167,27 @@ func (s *Service) generateDeploymentPlan(ctx context.Context, appName, environme
 Return ONLY a JSON array of strings representing the deployment order.
 Example: ["database", "api", "frontend"]`
 
-	// Build user prompt with context
+	// Build user prompt with context; node data is redacted because it can hold credentials
+	graphContext := make(map[string]interface{}, len(currentGraph.Nodes))
+	for id, node := range currentGraph.Nodes {
+		graphContext[id] = map[string]interface{}{
+			"kind":     node.Kind,
+			"metadata": node.Metadata,
+			"spec":     node.Spec,
+		}
+	}
+	graphJSON, err := json.Marshal(redaction.Default().Map(graphContext))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode graph context: %w", err)
+	}
 	userPrompt := fmt.Sprintf(`Plan deployment for application: %s
-Graph context: %v
+Graph context: %s
 Environment: %s
 
-Return deployment order as JSON array.`, appName, currentGraph.Nodes, environment)
+Return deployment order as JSON array.`, appName, graphJSON, environment)
 
 	// Call AI
-	response, err := s.aiProvider.CallAI(ctx, systemPrompt, userPrompt)
+	response, err := s.aiProvider.CallAI(ai.WithTask(ctx, ai.TaskPlanning), systemPrompt, userPrompt)
 	if err != nil {
 		return nil, fmt.Errorf("AI deployment planning failed: %w", err)
 	}
@@ -219,6 +259,28 @@ func (s *Service) ValidateNodeExists(kind, name string) error {
 	return nil
 }
 
+// deploymentExtraction is the deployment domain's schema for the shared extractor. Deploying
+// the wrong application is costly, so it asks for clarification sooner than other domains.
+var deploymentExtraction = ai.ExtractionSchema{
+	Domain: "deployment",
+	Fields: map[string]ai.ExtractionField{
+		"action":      {Type: ai.ExtractionString, Required: true, Enum: []string{"deploy", "plan", "status", "execute"}},
+		"app_name":    {Type: ai.ExtractionString, Required: true, Description: "the application to deploy"},
+		"environment": {Type: ai.ExtractionString, Required: true, Description: "the target environment"},
+		"version":     {Type: ai.ExtractionString, Description: "version if specified"},
+		"force":       {Type: ai.ExtractionBoolean, Description: "whether the user asked to force the deployment"},
+		"mode":        {Type: ai.ExtractionString, Enum: []string{"single", ModeMultiRegion}, Description: "multi-region when the user asks to deploy to several or all regions"},
+		"regions":     {Type: ai.ExtractionString, Description: "comma-separated regions if the user named them"},
+	},
+	Instructions: `Rules:
+- Extract application name from deployment requests
+- Extract environment (production, staging, development, test, etc.)
+- Common environment aliases: prod=production, dev=development, stage=staging
+- Action should be: deploy, plan, status, or execute
+- Mode is multi-region when the user asks to deploy to several or all regions; put the regions they name in regions`,
+	MinConfidence: 0.8,
+}
+
 // ExtractDeploymentParamsFromUserMessage uses AI to parse user messages and extract deployment parameters
 func (s *Service) ExtractDeploymentParamsFromUserMessage(ctx context.Context, userMessage string) (*DeploymentDomainParams, error) {
 	s.logger.Info("🤖 Extracting deployment parameters from user message using AI")
@@ -227,58 +289,10 @@ func (s *Service) ExtractDeploymentParamsFromUserMessage(ctx context.Context, us
 		return nil, fmt.Errorf("AI provider required for parameter extraction")
 	}
 
-	systemPrompt := `You are a deployment parameter extraction assistant. Extract deployment information from user messages.
-
-IMPORTANT: Response must be valid JSON only, no explanations or additional text.
-
-Response format:
-{
-  "action": "deploy|plan|status|execute",
-  "app_name": "extracted-app-name",
-  "environment": "extracted-environment-name", 
-  "version": "version-if-specified",
-  "force": false,
-  "confidence": 0.85,
-  "clarification": "explanation-if-low-confidence"
-}
-
-Rules:
-- Extract application name from deployment requests
-- Extract environment (production, staging, development, test, etc.) 
-- Set confidence 0.0-1.0 based on clarity
-- If confidence < 0.8, provide clarification request
-- Common environment aliases: prod=production, dev=development, stage=staging
-- Action should be: deploy, plan, status, or execute`
-
-	userPrompt := fmt.Sprintf("Extract deployment parameters from: %s", userMessage)
-
-	response, err := s.aiProvider.CallAI(ctx, systemPrompt, userPrompt)
-	if err != nil {
-		s.logger.Error("AI parameter extraction failed: %v", err)
-		return nil, fmt.Errorf("failed to extract parameters using AI: %w", err)
-	}
-
-	s.logger.Info("🤖 AI response for parameter extraction: %s", response)
-
-	// Parse AI response
 	var params DeploymentDomainParams
-	if err := json.Unmarshal([]byte(response), &params); err != nil {
-		s.logger.Error("Failed to parse AI response as JSON: %v", err)
-		return nil, fmt.Errorf("failed to parse AI response: %w", err)
-	}
-
-	// Validate extraction confidence
-	if params.Confidence < 0.8 {
-		s.logger.Warn("Low confidence extraction (%.2f): %s", params.Confidence, params.Clarification)
-		return &params, fmt.Errorf("extraction confidence too low (%.2f): %s", params.Confidence, params.Clarification)
-	}
-
-	// Validate required fields
-	if params.AppName == "" {
-		return &params, fmt.Errorf("could not extract application name from message")
-	}
-	if params.Environment == "" {
-		return &params, fmt.Errorf("could not extract environment from message")
+	if err := s.extractor.Extract(ctx, deploymentExtraction.Domain, userMessage, &params); err != nil {
+		s.logger.Warn("Deployment parameter extraction failed: %v", err)
+		return &params, err
 	}
 
 	s.logger.Info("✅ AI extracted deployment params - app: %s, env: %s, action: %s (confidence: %.2f)",
@@ -294,6 +308,8 @@ type DeploymentDomainParams struct {
 	Environment   string  `json:"environment"`
 	Version       string  `json:"version"`
 	Force         bool    `json:"force"`
+	Mode          string  `json:"mode"`    // "multi-region" fans the deployment out per region
+	Regions       string  `json:"regions"` // comma-separated regions of a multi-region deployment
 	Confidence    float64 `json:"confidence"`
 	Clarification string  `json:"clarification"`
 }
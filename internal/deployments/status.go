@@ -226,3 +226,53 @@ func ValidateStatusTransition(metadata map[string]interface{}, newStatus Deploym
 	}
 	return nil
 }
+
+// StatusHistoryKey is the edge metadata key holding a deployment's status changes, oldest first.
+// Entries are only ever appended, so the edge's "status" is the latest entry and the history
+// shows how it got there.
+const StatusHistoryKey = "history"
+
+// StatusChange is one entry in a deployment's status history
+type StatusChange struct {
+	Status    string    `json:"status"`
+	Message   string    `json:"message,omitempty"`
+	Actor     string    `json:"actor,omitempty"` // the user who asked for the deployment, or the agent acting on its own
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// AppendStatusChange records a status change in edge metadata
+func AppendStatusChange(metadata map[string]interface{}, change StatusChange) {
+	history, _ := metadata[StatusHistoryKey].([]interface{})
+	entry := map[string]interface{}{
+		"status":    change.Status,
+		"timestamp": change.Timestamp.Format(time.RFC3339Nano),
+	}
+	if change.Message != "" {
+		entry["message"] = change.Message
+	}
+	if change.Actor != "" {
+		entry["actor"] = change.Actor
+	}
+	metadata[StatusHistoryKey] = append(history, entry)
+}
+
+// StatusHistory returns the status changes recorded in edge metadata, oldest first
+func StatusHistory(metadata map[string]interface{}) []StatusChange {
+	history, _ := metadata[StatusHistoryKey].([]interface{})
+	changes := make([]StatusChange, 0, len(history))
+	for _, item := range history {
+		entry, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		change := StatusChange{}
+		change.Status, _ = entry["status"].(string)
+		change.Message, _ = entry["message"].(string)
+		change.Actor, _ = entry["actor"].(string)
+		if timestamp, ok := entry["timestamp"].(string); ok {
+			change.Timestamp, _ = time.Parse(time.RFC3339Nano, timestamp)
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
@@ -1,17 +1,31 @@
 package deployments
 
+import "github.com/krzachariassen/ZTDP/internal/contracts"
+
 // DeploymentResult represents the result of a deployment operation
 type DeploymentResult struct {
 	Application  string                   `json:"application"`
 	Environment  string                   `json:"environment"`
 	DeploymentID string                   `json:"deployment_id"`
-	ReleaseID    string                   `json:"release_id"`    // Added for release tracking
+	ReleaseID    string                   `json:"release_id"` // Added for release tracking
 	Deployments  []string                 `json:"deployments"`
 	Skipped      []string                 `json:"skipped"`
 	Failed       []map[string]interface{} `json:"failed"`
 	Summary      DeploymentSummary        `json:"summary"`
-	Status       string                   `json:"status"` // "initiated", "in_progress", "completed", "failed"
+	Status       string                   `json:"status"`  // "initiated", "in_progress", "completed", "failed"
 	Message      string                   `json:"message"` // Added for status messages
+	// Configs is the effective configuration of each service, environment overrides merged in
+	Configs map[string]contracts.ServiceSpec `json:"configs,omitempty"`
+	// Regions holds the outcome per region of a multi-region deployment
+	Regions []RegionResult `json:"regions,omitempty"`
+}
+
+// RegionResult is the outcome of a multi-region deployment in one region
+type RegionResult struct {
+	Region       string `json:"region"`
+	DeploymentID string `json:"deployment_id"`
+	Status       string `json:"status"` // "succeeded", "rolled_back" or "cancelled"
+	Message      string `json:"message,omitempty"`
 }
 
 // DeploymentSummary provides a high-level summary of the deployment
@@ -3,6 +3,7 @@ package environment
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -11,13 +12,17 @@ import (
 	"github.com/krzachariassen/ZTDP/internal/contracts"
 	"github.com/krzachariassen/ZTDP/internal/events"
 	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/guardrails"
 	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/krzachariassen/ZTDP/internal/quotas"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
 )
 
 // EnvironmentService - ALL domain logic for environments (business logic, AI extraction, persistence)
 type EnvironmentService struct {
 	Graph      *graph.GlobalGraph
 	aiProvider ai.AIProvider
+	extractor  *ai.Extractor
 	eventBus   *events.EventBus
 	logger     *logging.Logger
 	config     *EnvironmentConfig
@@ -46,12 +51,16 @@ func NewEnvironmentService(g *graph.GlobalGraph) *EnvironmentService {
 
 // NewAIEnvironmentService creates AI-native environment service with all dependencies
 func NewAIEnvironmentService(g *graph.GlobalGraph, aiProvider ai.AIProvider, eventBus *events.EventBus) *EnvironmentService {
+	config := DefaultEnvironmentConfig()
+	extractor := ai.NewExtractor(aiProvider)
+	extractor.Register(environmentExtraction(config))
 	return &EnvironmentService{
 		Graph:      g,
 		aiProvider: aiProvider,
+		extractor:  extractor,
 		eventBus:   eventBus,
 		logger:     logging.GetLogger().ForComponent("environment-domain"),
-		config:     DefaultEnvironmentConfig(),
+		config:     config,
 	}
 }
 
@@ -61,6 +70,10 @@ func (s *EnvironmentService) HandleEnvironmentEvent(ctx context.Context, event *
 
 	// Extract intent and parameters using AI (domain owns this)
 	params, err := s.ExtractEnvironmentParameters(ctx, userMessage)
+	var lowConfidence *ai.LowConfidenceError
+	if errors.As(err, &lowConfidence) {
+		return agentframework.ClarificationResponse(ctx, event, lowConfidence.Clarification, lowConfidence.Confidence), nil
+	}
 	if err != nil {
 		return s.createErrorResponse(event, fmt.Sprintf("Failed to extract parameters: %v", err)), nil
 	}
@@ -68,11 +81,6 @@ func (s *EnvironmentService) HandleEnvironmentEvent(ctx context.Context, event *
 	s.logger.Info("🤖 AI extracted - action: %s, env: %s, owner: %s, confidence: %.2f",
 		params.Action, params.EnvironmentName, params.Owner, params.Confidence)
 
-	// Check confidence level
-	if params.Confidence < 0.7 {
-		return s.createClarificationResponse(event, params.Clarification), nil
-	}
-
 	// Route to appropriate handler based on AI-extracted action
 	switch params.Action {
 	case "create":
@@ -86,54 +94,52 @@ func (s *EnvironmentService) HandleEnvironmentEvent(ctx context.Context, event *
 	}
 }
 
-// ExtractEnvironmentParameters - Environment domain owns AI extraction
-func (s *EnvironmentService) ExtractEnvironmentParameters(ctx context.Context, userMessage string) (*EnvironmentDomainParams, error) {
-	if s.aiProvider == nil {
-		return nil, fmt.Errorf("AI provider not available")
-	}
-
-	systemPrompt := fmt.Sprintf(`You are an environment management assistant. Parse the user's request and extract the action and parameters.
-
-Available actions: list, create, update, delete, show, get
-
-IMPORTANT: Environment Name Inference Rules:
+// environmentExtraction is the environment domain's schema for the shared extractor. The
+// inference rules come from the configured environment names.
+func environmentExtraction(config *EnvironmentConfig) ai.ExtractionSchema {
+	return ai.ExtractionSchema{
+		Domain: "environment",
+		Fields: map[string]ai.ExtractionField{
+			"action":           {Type: ai.ExtractionString, Required: true, Enum: []string{"list", "create", "update", "delete", "show", "get"}},
+			"environment_name": {Type: ai.ExtractionString, Description: "canonical environment name (infer from context using approved names)"},
+			"owner":            {Type: ai.ExtractionString, Description: "owner if specified"},
+			"description":      {Type: ai.ExtractionString, Description: "description if specified"},
+			"env_type":         {Type: ai.ExtractionString, Description: "development, staging, production or test if specified"},
+		},
+		Instructions: fmt.Sprintf(`IMPORTANT: Environment Name Inference Rules:
 %s
 
 Approved environment names: %s
 
+The environment contract you are collecting parameters for:
+%s
+
 ALWAYS try to infer the canonical environment name from context. Look for patterns like:
 - "staging environment" -> "staging"
-- "production env" -> "production"  
+- "production env" -> "production"
 - "dev environment" -> "development"
 - Use the approved environment names list above as your reference
 
-Response format must be valid JSON:
-{
-  "action": "list|create|update|delete|show|get",
-  "environment_name": "canonical environment name (infer from context using approved names)",
-  "owner": "owner if specified or null",
-  "description": "description if specified or null", 
-  "env_type": "development|staging|production|test if specified or null",
-  "confidence": 0.0-1.0,
-  "clarification": "what to ask if confidence < 0.7"
-}
-
 Examples:
 - "list environments" -> {"action": "list", "confidence": 0.9}
 - "create environment dev" -> {"action": "create", "environment_name": "development", "confidence": 0.9}
 - "Create a development environment called dev owned by platform-team for development work" -> {"action": "create", "environment_name": "development", "owner": "platform-team", "description": "for development work", "env_type": "development", "confidence": 0.95}
 - "Create a staging environment for testing" -> {"action": "create", "environment_name": "staging", "description": "for testing", "env_type": "staging", "confidence": 0.9}
 - "Create a production environment with strict policies" -> {"action": "create", "environment_name": "production", "description": "with strict policies", "env_type": "production", "confidence": 0.9}`,
-		s.config.GetEnvironmentExamples(), s.config.GetApprovedEnvironmentsList())
+			config.GetEnvironmentExamples(), config.GetApprovedEnvironmentsList(), contracts.PromptSummary(contracts.EnvironmentContract{}.Kind())),
+	}
+}
 
-	response, err := s.aiProvider.CallAI(ctx, systemPrompt, userMessage)
-	if err != nil {
-		return nil, fmt.Errorf("AI extraction failed: %w", err)
+// ExtractEnvironmentParameters - Environment domain owns AI extraction. A *ai.LowConfidenceError
+// comes back with the parameters extracted so far.
+func (s *EnvironmentService) ExtractEnvironmentParameters(ctx context.Context, userMessage string) (*EnvironmentDomainParams, error) {
+	if s.aiProvider == nil {
+		return nil, fmt.Errorf("AI provider not available")
 	}
 
 	var params EnvironmentDomainParams
-	if err := json.Unmarshal([]byte(response), &params); err != nil {
-		return nil, fmt.Errorf("failed to parse AI response: %w", err)
+	if err := s.extractor.Extract(ctx, "environment", userMessage, &params); err != nil {
+		return &params, err
 	}
 
 	// Post-process: resolve environment name using our configuration
@@ -160,6 +166,20 @@ func (s *EnvironmentService) handleCreateEnvironment(ctx context.Context, event
 		return s.createErrorResponse(event, "environment name is required"), nil
 	}
 
+	decision := guardrails.Default().Check(ctx, guardrails.Action{
+		Operation:   guardrails.OperationCreate,
+		Kind:        graph.KindEnvironment,
+		Targets:     []string{params.EnvironmentName},
+		Environment: params.EnvironmentName,
+		Source:      "environment-agent",
+	})
+	switch decision.Outcome {
+	case guardrails.Reject:
+		return s.createErrorResponse(event, decision.Message()), nil
+	case guardrails.RequireApproval, guardrails.Propose:
+		return s.createClarificationResponse(event, decision.Message()), nil
+	}
+
 	// Create environment using domain logic
 	envContract := contracts.EnvironmentContract{
 		Metadata: contracts.Metadata{
@@ -285,11 +305,18 @@ func (s *EnvironmentService) createClarificationResponse(originalEvent *events.E
 
 // CreateEnvironment validates and creates an environment node in the graph
 func (s *EnvironmentService) CreateEnvironment(env contracts.EnvironmentContract) error {
+	if existing, _ := s.Graph.GetNode(env.Metadata.Name); existing == nil {
+		if err := quotas.NewService(s.Graph).CheckEnvironmentCreation(env.Metadata.Owner); err != nil {
+			return err
+		}
+	}
 	node, err := graph.ResolveContract(env)
 	if err != nil {
 		return err
 	}
-	s.Graph.AddNode(node)
+	if err := s.Graph.AddNode(node); err != nil {
+		return err
+	}
 	return s.Graph.Save()
 }
 
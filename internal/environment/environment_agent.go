@@ -4,12 +4,13 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/krzachariassen/ZTDP/internal/agentFramework"
 	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
 	"github.com/krzachariassen/ZTDP/internal/ai"
 	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/features"
 	"github.com/krzachariassen/ZTDP/internal/graph"
 	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
 )
 
 // EnvironmentAgent - thin delegation layer, ALL logic in domain service
@@ -49,13 +50,14 @@ func NewEnvironmentAgent(
 	}
 
 	// Create dependencies for the framework
-	deps := agentFramework.AgentDependencies{
+	deps := agentframework.AgentDependencies{
 		Registry: registry,
 		EventBus: eventBus,
+		Flags:    features.NewService(graph),
 	}
 
 	// Build the agent using the framework
-	agent, err := agentFramework.NewAgent("environment-agent").
+	agent, err := agentframework.NewAgent("environment-agent").
 		WithType("environment").
 		WithCapabilities(getEnvironmentCapabilities()).
 		WithEventHandler(wrapper.handleEvent).
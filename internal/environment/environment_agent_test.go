@@ -5,11 +5,11 @@ import (
 	"os"
 	"testing"
 
-	"github.com/krzachariassen/ZTDP/internal/agentFramework"
 	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
 	"github.com/krzachariassen/ZTDP/internal/ai"
 	"github.com/krzachariassen/ZTDP/internal/events"
 	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -97,7 +97,7 @@ func TestEnvironmentAgentEventHandlingIntegration(t *testing.T) {
 	assert.NoError(t, err, "Failed to create agent")
 
 	// Cast to framework agent to access ProcessEvent
-	agent, ok := baseAgent.(*agentFramework.BaseAgent)
+	agent, ok := baseAgent.(*agentframework.BaseAgent)
 	assert.True(t, ok, "Expected BaseAgent, got %T", baseAgent)
 
 	// Test cases for different environment operations
@@ -180,7 +180,7 @@ func TestEnvironmentAgentBusinessLogicIntegration(t *testing.T) {
 	baseAgent, err := NewEnvironmentAgent(mockGraph, realAIProvider, eventBus, registry)
 	assert.NoError(t, err, "Failed to create environment agent")
 
-	agent, ok := baseAgent.(*agentFramework.BaseAgent)
+	agent, ok := baseAgent.(*agentframework.BaseAgent)
 	assert.True(t, ok, "Expected BaseAgent")
 
 	// Test environment creation with full business logic
@@ -272,7 +272,7 @@ func TestEnvironmentAgentParameterExtraction(t *testing.T) {
 	baseAgent, err := NewEnvironmentAgent(mockGraph, realAIProvider, eventBus, registry)
 	assert.NoError(t, err, "Failed to create environment agent")
 
-	agent, ok := baseAgent.(*agentFramework.BaseAgent)
+	agent, ok := baseAgent.(*agentframework.BaseAgent)
 	assert.True(t, ok, "Expected BaseAgent")
 
 	// Test parameter extraction for different environment operations
@@ -342,7 +342,7 @@ func TestEnvironmentAgentEndToEndScenario(t *testing.T) {
 	baseAgent, err := NewEnvironmentAgent(mockGraph, realAIProvider, eventBus, registry)
 	assert.NoError(t, err, "Failed to create environment agent")
 
-	agent, ok := baseAgent.(*agentFramework.BaseAgent)
+	agent, ok := baseAgent.(*agentframework.BaseAgent)
 	assert.True(t, ok, "Expected BaseAgent")
 
 	// Test cases that match the exact integration test scenarios
@@ -0,0 +1,116 @@
+package events
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TransportMessage is an encoded event as handed to a transport
+type TransportMessage struct {
+	Topic string
+	Data  []byte
+}
+
+// BatchPublisher is implemented by transports that publish several messages as one
+// transaction: either every message is published or none is
+type BatchPublisher interface {
+	PublishBatch(messages []TransportMessage) error
+}
+
+// EmitResult is the outcome of one event of a batch
+type EmitResult struct {
+	ID      string
+	Subject string
+	Err     error // nil when the event was published and delivered to local handlers
+}
+
+// EmitBatch publishes several events in one call, e.g. to notify every agent of a kind without
+// a round trip per agent. Each event gets its own result: events that fail validation are not
+// sent, and the rest go to the transport as one transaction when it implements BatchPublisher,
+// otherwise one by one. Events without an ID or timestamp get one. The error is only set when
+// the bus is closed.
+func (b *EventBus) EmitBatch(batch []Event) ([]EmitResult, error) {
+	if b.isClosed() {
+		return nil, ErrEventBusClosed
+	}
+
+	results := make([]EmitResult, len(batch))
+	accepted := make([]int, 0, len(batch))
+	for i := range batch {
+		event := &batch[i]
+		if event.ID == "" {
+			event.ID = uuid.New().String()
+		}
+		if event.Timestamp == 0 {
+			event.Timestamp = time.Now().UnixNano()
+		}
+		results[i] = EmitResult{ID: event.ID, Subject: event.Subject}
+		if err := b.validate(*event); err != nil {
+			results[i].Err = err
+			continue
+		}
+		accepted = append(accepted, i)
+	}
+
+	localOnly := b.LocalOnly()
+	if b.transport != nil && !localOnly {
+		accepted = b.publishBatch(batch, accepted, results)
+	}
+
+	for _, i := range accepted {
+		b.mu.RLock()
+		handlers := b.handlers[batch[i].Type]
+		b.mu.RUnlock()
+		if len(handlers) == 0 {
+			continue
+		}
+		if b.defaultAsync && !localOnly {
+			b.processHandlersAsync(batch[i], handlers)
+		} else {
+			b.processHandlers(batch[i], handlers)
+		}
+	}
+	return results, nil
+}
+
+// publishBatch sends the accepted events to the transport, recording failures in results, and
+// returns the events that were published
+func (b *EventBus) publishBatch(batch []Event, accepted []int, results []EmitResult) []int {
+	publisher, transactional := b.transport.(BatchPublisher)
+	if !transactional {
+		published := accepted[:0]
+		for _, i := range accepted {
+			if err := b.publish(batch[i]); err != nil {
+				results[i].Err = err
+				continue
+			}
+			published = append(published, i)
+		}
+		return published
+	}
+
+	encoded := accepted[:0]
+	messages := make([]TransportMessage, 0, len(accepted))
+	for _, i := range accepted {
+		message, err := b.encode(batch[i])
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		encoded = append(encoded, i)
+		messages = append(messages, message)
+	}
+	if len(messages) == 0 {
+		return encoded
+	}
+	if err := publisher.PublishBatch(messages); err != nil {
+		err = fmt.Errorf("failed to publish event batch: %w", err)
+		for _, i := range encoded {
+			results[i].Err = err
+		}
+		return nil
+	}
+	return encoded
+}
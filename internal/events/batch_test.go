@@ -0,0 +1,103 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// transactionalTransport publishes batches all-or-nothing and can be made to fail
+type transactionalTransport struct {
+	recordingTransport
+	batches int
+	fail    error
+}
+
+func (t *transactionalTransport) PublishBatch(messages []TransportMessage) error {
+	if t.fail != nil {
+		return t.fail
+	}
+	t.batches++
+	for _, message := range messages {
+		t.Publish(message.Topic, message.Data)
+	}
+	return nil
+}
+
+func complianceBatch() []Event {
+	return []Event{
+		{Type: EventTypeRequest, Source: "orchestrator", Subject: "deployment.request", Payload: map[string]interface{}{"intent": "audit"}},
+		{Type: EventTypeRequest, Source: "orchestrator", Subject: "deployment.request", Payload: map[string]interface{}{"environment": "prod"}},
+		{Type: EventTypeNotify, Source: "orchestrator", Subject: "compliance.audit", Payload: map[string]interface{}{"scope": "all"}},
+	}
+}
+
+func newBatchTestBus(t *testing.T, transport EventTransport) (*EventBus, *[]Event) {
+	t.Helper()
+	schemas := NewSchemaRegistry()
+	if err := schemas.Register(deployRequestV1()); err != nil {
+		t.Fatal(err)
+	}
+	bus := NewEventBus(transport, false)
+	bus.SetSchemaRegistry(schemas)
+
+	var delivered []Event
+	record := func(event Event) error {
+		delivered = append(delivered, event)
+		return nil
+	}
+	bus.Subscribe(EventTypeRequest, record)
+	bus.Subscribe(EventTypeNotify, record)
+	return bus, &delivered
+}
+
+func TestEmitBatchTransactional(t *testing.T) {
+	transport := &transactionalTransport{}
+	bus, delivered := newBatchTestBus(t, transport)
+
+	results, err := bus.EmitBatch(complianceBatch())
+	if err != nil {
+		t.Fatalf("EmitBatch failed: %v", err)
+	}
+	if len(results) != 3 || results[0].Err != nil || results[2].Err != nil {
+		t.Fatalf("expected the valid events to be published, got %+v", results)
+	}
+	if !errors.Is(results[1].Err, ErrSchemaViolation) {
+		t.Errorf("expected the event missing its intent to be rejected, got %v", results[1].Err)
+	}
+	if results[0].ID == "" || results[0].ID == results[2].ID {
+		t.Errorf("expected each event to get its own ID, got %+v", results)
+	}
+	if transport.batches != 1 || len(transport.published) != 2 || len(*delivered) != 2 {
+		t.Errorf("expected one transaction of 2 events delivered locally, got %d batches, %d published, %d delivered",
+			transport.batches, len(transport.published), len(*delivered))
+	}
+
+	transport.fail = errors.New("channel closed")
+	*delivered = nil
+	results, _ = bus.EmitBatch(complianceBatch())
+	if results[0].Err == nil || results[2].Err == nil || len(*delivered) != 0 {
+		t.Errorf("expected a failed transaction to publish and deliver nothing, got %+v and %d delivered", results, len(*delivered))
+	}
+}
+
+func TestEmitBatchWithoutTransactions(t *testing.T) {
+	transport := &recordingTransport{}
+	bus, delivered := newBatchTestBus(t, transport)
+
+	results, err := bus.EmitBatch(complianceBatch())
+	if err != nil {
+		t.Fatalf("EmitBatch failed: %v", err)
+	}
+	if results[0].Err != nil || results[1].Err == nil || results[2].Err != nil {
+		t.Errorf("unexpected results: %+v", results)
+	}
+	if len(transport.published) != 2 || len(*delivered) != 2 {
+		t.Errorf("expected the 2 valid events to be published one by one, got %d published, %d delivered", len(transport.published), len(*delivered))
+	}
+
+	bus.Shutdown(context.Background())
+	if _, err := bus.EmitBatch(complianceBatch()); !errors.Is(err, ErrEventBusClosed) {
+		t.Errorf("expected ErrEventBusClosed after shutdown, got %v", err)
+	}
+}
@@ -0,0 +1,251 @@
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// encryptedPayloadKey holds the envelope replacing the payload of an encrypted event
+const encryptedPayloadKey = "encrypted_payload"
+
+// ErrUnknownKey is returned when an event was encrypted with a key the keyring does not hold
+var ErrUnknownKey = errors.New("unknown encryption key")
+
+// Keyring holds the AES keys for payload encryption. The newest key encrypts; every key
+// decrypts, so payloads in flight during a rotation can still be read.
+type Keyring struct {
+	mu      sync.RWMutex
+	keys    map[string][]byte
+	current string
+}
+
+// ParseKeys reads one "id:base64-key" pair per line, oldest first; blank lines and lines
+// starting with # are ignored. Keys are 16, 24 or 32 bytes (AES-128, -192 or -256).
+func ParseKeys(data []byte) (*Keyring, error) {
+	k := &Keyring{keys: map[string][]byte{}}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		id, encoded, ok := strings.Cut(text, ":")
+		if !ok || strings.TrimSpace(id) == "" {
+			return nil, fmt.Errorf("line %d: expected id:base64-key", line)
+		}
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid base64 key: %w", line, err)
+		}
+		if err := k.Add(strings.TrimSpace(id), key); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if k.current == "" {
+		return nil, errors.New("no encryption keys")
+	}
+	return k, nil
+}
+
+// LoadKeyFile reads a keyring from a file, such as one mounted by the secrets provider
+func LoadKeyFile(path string) (*Keyring, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseKeys(data)
+}
+
+// Add adds a key and makes it the one new payloads are encrypted with
+func (k *Keyring) Add(id string, key []byte) error {
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return fmt.Errorf("key %s is %d bytes; expected 16, 24 or 32", id, len(key))
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys[id] = key
+	k.current = id
+	return nil
+}
+
+// Current returns the ID of the key new payloads are encrypted with
+func (k *Keyring) Current() string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.current
+}
+
+// Reload replaces the keys with those in the file at path
+func (k *Keyring) Reload(path string) error {
+	loaded, err := LoadKeyFile(path)
+	if err != nil {
+		return err
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if loaded.current != k.current {
+		log.Printf("🔑 Event payload encryption key rotated: %s → %s", k.current, loaded.current)
+	}
+	k.keys, k.current = loaded.keys, loaded.current
+	return nil
+}
+
+// WatchKeyFile reloads the keys from path every interval until ctx is cancelled, so a key
+// rotated by the secrets provider takes effect without a restart
+func (k *Keyring) WatchKeyFile(ctx context.Context, path string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := k.Reload(path); err != nil {
+				log.Printf("⚠️ Failed to reload event encryption keys, keeping the current ones: %v", err)
+			}
+		}
+	}
+}
+
+func (k *Keyring) aead(id string) (cipher.AEAD, error) {
+	k.mu.RLock()
+	key, ok := k.keys[id]
+	k.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, id)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Encryption encrypts the payloads of events on sensitive subjects before they are published
+// to the transport
+type Encryption struct {
+	keys     *Keyring
+	subjects []string
+}
+
+// NewEncryption encrypts payloads of events whose subject matches one of subjects. A
+// trailing * matches any suffix, e.g. "resource.*".
+func NewEncryption(keys *Keyring, subjects []string) *Encryption {
+	return &Encryption{keys: keys, subjects: subjects}
+}
+
+// Sensitive reports whether payloads on subject are encrypted
+func (e *Encryption) Sensitive(subject string) bool {
+	for _, pattern := range e.subjects {
+		if prefix, wildcard := strings.CutSuffix(pattern, "*"); wildcard {
+			if strings.HasPrefix(subject, prefix) {
+				return true
+			}
+		} else if subject == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// encryptedPayload is the envelope an encrypted payload is replaced with
+type encryptedPayload struct {
+	KeyID      string `json:"key_id"`
+	Nonce      string `json:"nonce"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+// additionalData binds a ciphertext to its event so it cannot be replayed under another one
+func additionalData(event Event) []byte {
+	return []byte(event.ID + "|" + event.Subject)
+}
+
+// Encrypt returns the event with its payload replaced by an encrypted envelope
+func (e *Encryption) Encrypt(event Event) (Event, error) {
+	plaintext, err := json.Marshal(event.Payload)
+	if err != nil {
+		return event, err
+	}
+	keyID := e.keys.Current()
+	aead, err := e.keys.aead(keyID)
+	if err != nil {
+		return event, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return event, err
+	}
+	ciphertext := aead.Seal(nil, nonce, plaintext, additionalData(event))
+
+	event.Payload = map[string]interface{}{
+		encryptedPayloadKey: map[string]interface{}{
+			"key_id":     keyID,
+			"nonce":      base64.StdEncoding.EncodeToString(nonce),
+			"ciphertext": base64.StdEncoding.EncodeToString(ciphertext),
+		},
+	}
+	return event, nil
+}
+
+// Decrypt returns the event with its encrypted envelope replaced by the original payload
+func (e *Encryption) Decrypt(event Event) (Event, error) {
+	raw, err := json.Marshal(event.Payload[encryptedPayloadKey])
+	if err != nil {
+		return event, err
+	}
+	var envelope encryptedPayload
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return event, fmt.Errorf("invalid encrypted payload: %w", err)
+	}
+	nonce, err := base64.StdEncoding.DecodeString(envelope.Nonce)
+	if err != nil {
+		return event, fmt.Errorf("invalid encrypted payload nonce: %w", err)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(envelope.Ciphertext)
+	if err != nil {
+		return event, fmt.Errorf("invalid encrypted payload: %w", err)
+	}
+	aead, err := e.keys.aead(envelope.KeyID)
+	if err != nil {
+		return event, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return event, errors.New("invalid encrypted payload nonce")
+	}
+	plaintext, err := aead.Open(nil, nonce, ciphertext, additionalData(event))
+	if err != nil {
+		return event, fmt.Errorf("failed to decrypt payload of %s: %w", event.Subject, err)
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(plaintext, &payload); err != nil {
+		return event, err
+	}
+	event.Payload = payload
+	return event, nil
+}
+
+// IsEncrypted reports whether payload is an encrypted envelope
+func IsEncrypted(payload map[string]interface{}) bool {
+	_, ok := payload[encryptedPayloadKey]
+	return ok && len(payload) == 1
+}
@@ -0,0 +1,119 @@
+package events
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordingTransport keeps everything published to it
+type recordingTransport struct {
+	mu        sync.Mutex
+	published [][]byte
+}
+
+func (r *recordingTransport) Publish(topic string, data []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.published = append(r.published, data)
+	return nil
+}
+
+func (r *recordingTransport) Subscribe(topic string, handler func([]byte)) error { return nil }
+func (r *recordingTransport) Close() error                                       { return nil }
+
+func keyLine(id string, fill byte) string {
+	return id + ":" + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{fill}, 32))
+}
+
+func TestPayloadEncryptionWithRotation(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "event-keys")
+	if err := os.WriteFile(keyFile, []byte("# event keys\n"+keyLine("k1", 1)+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := LoadKeyFile(keyFile)
+	if err != nil {
+		t.Fatalf("LoadKeyFile: %v", err)
+	}
+
+	transport := &recordingTransport{}
+	bus := NewEventBus(transport, false)
+	bus.SetEncryption(NewEncryption(keys, []string{"resource.*"}))
+
+	secret := map[string]interface{}{"connection_string": "postgres://admin:hunter2@db"}
+	if err := bus.Emit(EventTypeRequest, "orchestrator", "resource.create", secret); err != nil {
+		t.Fatalf("Emit: %v", err)
+	}
+	if err := bus.Emit(EventTypeRequest, "orchestrator", "application.list", map[string]interface{}{"intent": "list"}); err != nil {
+		t.Fatalf("Emit: %v", err)
+	}
+	if strings.Contains(string(transport.published[0]), "hunter2") {
+		t.Fatal("sensitive payload published in plaintext")
+	}
+	if !strings.Contains(string(transport.published[1]), `"intent":"list"`) {
+		t.Fatalf("expected other subjects to stay plaintext, got %s", transport.published[1])
+	}
+
+	var received Event
+	if err := json.Unmarshal(transport.published[0], &received); err != nil {
+		t.Fatal(err)
+	}
+
+	// Rotate: the new key encrypts, the old one still decrypts events already in flight
+	if err := os.WriteFile(keyFile, []byte(keyLine("k1", 1)+"\n"+keyLine("k2", 2)+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := keys.Reload(keyFile); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if keys.Current() != "k2" {
+		t.Fatalf("expected k2 to be current after rotation, got %s", keys.Current())
+	}
+
+	decrypted := received
+	if err := bus.Decrypt(&decrypted); err != nil {
+		t.Fatalf("Decrypt: %v", err)
+	}
+	if decrypted.Payload["connection_string"] != "postgres://admin:hunter2@db" {
+		t.Fatalf("unexpected decrypted payload: %v", decrypted.Payload)
+	}
+
+	// The ciphertext is bound to its event
+	replayed := received
+	replayed.Subject = "resource.delete"
+	if err := bus.Decrypt(&replayed); err == nil {
+		t.Fatal("expected decryption under another subject to fail")
+	}
+
+	// Once the old key is retired its payloads can no longer be read
+	if err := os.WriteFile(keyFile, []byte(keyLine("k2", 2)+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := keys.Reload(keyFile); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	retired := received
+	if err := bus.Decrypt(&retired); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("expected ErrUnknownKey, got %v", err)
+	}
+}
+
+func TestParseKeysRejectsBadKeys(t *testing.T) {
+	for name, data := range map[string]string{
+		"empty":        "# nothing here\n",
+		"no id":        ":" + base64.StdEncoding.EncodeToString(make([]byte, 32)),
+		"short key":    "k1:" + base64.StdEncoding.EncodeToString(make([]byte, 8)),
+		"not base64":   "k1:???",
+		"no separator": "k1",
+	} {
+		if _, err := ParseKeys([]byte(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
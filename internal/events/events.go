@@ -3,7 +3,9 @@
 package events
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
@@ -30,6 +32,8 @@ type Event struct {
 	Payload   map[string]interface{} `json:"payload,omitempty"`
 	Timestamp int64                  `json:"timestamp"`
 	ID        string                 `json:"id"`
+	// ExpiresAt (unix nanoseconds) is when a request stops being worth handling; zero never expires
+	ExpiresAt int64 `json:"expires_at,omitempty"`
 }
 
 // EventHandler is a function that processes events
@@ -41,8 +45,34 @@ type EventBus struct {
 	mu           sync.RWMutex
 	transport    EventTransport
 	defaultAsync bool
+
+	// Shutdown tracking for asynchronously processed handlers
+	inflight sync.WaitGroup
+	closed   bool
+	done     chan struct{}
+
+	// ready gates delivery to routing-key subscribers (agents) until MarkReady; nil means open
+	ready chan struct{}
+
+	// deliveryDelay, when set, holds events before they reach routing-key subscribers
+	deliveryDelay func(Event) time.Duration
+
+	// schemas, when set, validates payloads on emit and before routing-key subscribers receive them
+	schemas *SchemaRegistry
+
+	// encryption, when set, encrypts payloads on sensitive subjects before they reach the transport
+	encryption *Encryption
+
+	// deadLetters keeps the most recent events that were dropped instead of delivered
+	deadLetters []DeadLetter
+
+	// localOnly skips the transport and runs local handlers synchronously while it is down
+	localOnly bool
 }
 
+// ErrEventBusClosed is returned when emitting on a bus that is shutting down
+var ErrEventBusClosed = errors.New("event bus is closed")
+
 // EventTransport defines the interface for event transport (memory, kafka, etc.)
 type EventTransport interface {
 	Publish(topic string, data []byte) error
@@ -56,7 +86,193 @@ func NewEventBus(transport EventTransport, defaultAsync bool) *EventBus {
 		handlers:     make(map[EventType][]EventHandler),
 		transport:    transport,
 		defaultAsync: defaultAsync,
+		done:         make(chan struct{}),
+	}
+}
+
+// DeferDelivery holds events for routing-key subscribers until MarkReady is called.
+// Agents subscribe while they are constructed, so this keeps them from consuming events
+// before the platform's startup dependency checks have passed. Held handlers block, so
+// this is intended for buses that process handlers asynchronously.
+func (b *EventBus) DeferDelivery() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.ready == nil {
+		b.ready = make(chan struct{})
+	}
+}
+
+// MarkReady releases held events and lets routing-key subscribers consume normally
+func (b *EventBus) MarkReady() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.ready != nil {
+		select {
+		case <-b.ready:
+		default:
+			close(b.ready)
+		}
+	}
+}
+
+// waitReady blocks until delivery is allowed, returning false if the bus shuts down first
+func (b *EventBus) waitReady() bool {
+	b.mu.RLock()
+	ready := b.ready
+	b.mu.RUnlock()
+	if ready == nil {
+		return true
+	}
+
+	select {
+	case <-ready:
+		return true
+	case <-b.done:
+		return false
+	}
+}
+
+// SetDeliveryDelay installs a function deciding how long each event is held before it reaches
+// routing-key subscribers, e.g. to simulate a slow transport. Pass nil to deliver immediately.
+func (b *EventBus) SetDeliveryDelay(delay func(Event) time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.deliveryDelay = delay
+}
+
+// waitDelay holds an event for the configured delivery delay, returning false if the bus shuts down first
+func (b *EventBus) waitDelay(event Event) bool {
+	b.mu.RLock()
+	delay := b.deliveryDelay
+	b.mu.RUnlock()
+	if delay == nil {
+		return true
+	}
+	d := delay(event)
+	if d <= 0 {
+		return true
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-b.done:
+		return false
+	}
+}
+
+// Ping verifies the bus accepts events and, when the transport supports it, that the transport is connected
+func (b *EventBus) Ping(ctx context.Context) error {
+	if b.isClosed() {
+		return ErrEventBusClosed
+	}
+	if pinger, ok := b.transport.(interface{ Ping(context.Context) error }); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+// SetLocalOnly stops publishing to the transport and runs local handlers synchronously, so
+// events keep flowing inside this instance while the transport is down. Ping still checks the
+// transport, so its recovery can be noticed.
+func (b *EventBus) SetLocalOnly(localOnly bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.localOnly = localOnly
+}
+
+// LocalOnly reports whether events bypass the transport
+func (b *EventBus) LocalOnly() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.localOnly
+}
+
+// SetSchemaRegistry enables payload validation against registered subject schemas. Pass nil to disable.
+func (b *EventBus) SetSchemaRegistry(schemas *SchemaRegistry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.schemas = schemas
+}
+
+// Schemas returns the bus's schema registry, or nil when validation is disabled
+func (b *EventBus) Schemas() *SchemaRegistry {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.schemas
+}
+
+// SetEncryption enables payload encryption for sensitive subjects. Pass nil to disable.
+func (b *EventBus) SetEncryption(encryption *Encryption) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.encryption = encryption
+}
+
+// Decrypt replaces an encrypted payload received from the transport with the original one.
+// Events that are not encrypted are left as they are.
+func (b *EventBus) Decrypt(event *Event) error {
+	if !IsEncrypted(event.Payload) {
+		return nil
+	}
+	b.mu.RLock()
+	encryption := b.encryption
+	b.mu.RUnlock()
+	if encryption == nil {
+		return fmt.Errorf("event %s on %s is encrypted but payload encryption is not configured", event.ID, event.Subject)
+	}
+	decrypted, err := encryption.Decrypt(*event)
+	if err != nil {
+		return err
+	}
+	*event = decrypted
+	return nil
+}
+
+// publish sends an event to the transport, encrypting its payload if the subject is sensitive
+func (b *EventBus) publish(event Event) error {
+	message, err := b.encode(event)
+	if err != nil {
+		return err
+	}
+	if err := b.transport.Publish(message.Topic, message.Data); err != nil {
+		return fmt.Errorf("failed to publish event: %w", err)
 	}
+	return nil
+}
+
+// encode turns an event into what the transport carries, encrypting its payload if the subject is sensitive
+func (b *EventBus) encode(event Event) (TransportMessage, error) {
+	b.mu.RLock()
+	encryption := b.encryption
+	b.mu.RUnlock()
+	if encryption != nil && encryption.Sensitive(event.Subject) {
+		encrypted, err := encryption.Encrypt(event)
+		if err != nil {
+			return TransportMessage{}, fmt.Errorf("failed to encrypt event payload: %w", err)
+		}
+		event = encrypted
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return TransportMessage{}, fmt.Errorf("failed to marshal event: %w", err)
+	}
+	return TransportMessage{Topic: string(event.Type), Data: data}, nil
+}
+
+// validate checks an event against the schema registry, if one is set. Encrypted payloads
+// were checked by the sender before encryption.
+func (b *EventBus) validate(event Event) error {
+	if IsEncrypted(event.Payload) {
+		return nil
+	}
+	if schemas := b.Schemas(); schemas != nil {
+		return schemas.Validate(event)
+	}
+	return nil
 }
 
 // Subscribe registers a handler for a specific event type
@@ -75,6 +291,18 @@ func (b *EventBus) SubscribeToRoutingKey(routingKey string, handler EventHandler
 	// Create a wrapper handler that filters by routing key
 	routingHandler := func(event Event) error {
 		if event.Subject == routingKey {
+			if !b.waitReady() || !b.waitDelay(event) {
+				return ErrEventBusClosed
+			}
+			// A request held back by an outage or a slow transport may have outlived its caller
+			if event.Expired(time.Now()) {
+				b.deadLetter(event, "expired")
+				return nil
+			}
+			// Events from a transport never passed through Emit, so check them before the subscriber sees them
+			if err := b.validate(event); err != nil {
+				return fmt.Errorf("rejected event from %s: %w", event.Source, err)
+			}
 			return handler(event)
 		}
 		return nil
@@ -95,15 +323,18 @@ func (b *EventBus) Emit(eventType EventType, source, subject string, payload map
 		ID:        uuid.New().String(),
 	}
 
-	// Send to transport if available
-	if b.transport != nil {
-		data, err := json.Marshal(event)
-		if err != nil {
-			return fmt.Errorf("failed to marshal event: %w", err)
-		}
+	if b.isClosed() {
+		return ErrEventBusClosed
+	}
+	if err := b.validate(event); err != nil {
+		return err
+	}
 
-		if err := b.transport.Publish(string(eventType), data); err != nil {
-			return fmt.Errorf("failed to publish event: %w", err)
+	// Send to transport if available
+	localOnly := b.LocalOnly()
+	if b.transport != nil && !localOnly {
+		if err := b.publish(event); err != nil {
+			return err
 		}
 	}
 
@@ -116,8 +347,8 @@ func (b *EventBus) Emit(eventType EventType, source, subject string, payload map
 		return nil
 	}
 
-	if b.defaultAsync {
-		go b.processHandlers(event, handlers)
+	if b.defaultAsync && !localOnly {
+		b.processHandlersAsync(event, handlers)
 		return nil
 	}
 
@@ -126,15 +357,18 @@ func (b *EventBus) Emit(eventType EventType, source, subject string, payload map
 
 // EmitEvent publishes a complete event to the bus (preserves all event fields)
 func (b *EventBus) EmitEvent(event Event) error {
-	// Send to transport if available
-	if b.transport != nil {
-		data, err := json.Marshal(event)
-		if err != nil {
-			return fmt.Errorf("failed to marshal event: %w", err)
-		}
+	if b.isClosed() {
+		return ErrEventBusClosed
+	}
+	if err := b.validate(event); err != nil {
+		return err
+	}
 
-		if err := b.transport.Publish(string(event.Type), data); err != nil {
-			return fmt.Errorf("failed to publish event: %w", err)
+	// Send to transport if available
+	localOnly := b.LocalOnly()
+	if b.transport != nil && !localOnly {
+		if err := b.publish(event); err != nil {
+			return err
 		}
 	}
 
@@ -147,8 +381,8 @@ func (b *EventBus) EmitEvent(event Event) error {
 		return nil
 	}
 
-	if b.defaultAsync {
-		go b.processHandlers(event, handlers)
+	if b.defaultAsync && !localOnly {
+		b.processHandlersAsync(event, handlers)
 	} else {
 		b.processHandlers(event, handlers)
 	}
@@ -156,6 +390,53 @@ func (b *EventBus) EmitEvent(event Event) error {
 	return nil
 }
 
+// processHandlersAsync runs handlers in the background while tracking them for Shutdown
+func (b *EventBus) processHandlersAsync(event Event, handlers []EventHandler) {
+	b.inflight.Add(1)
+	go func() {
+		defer b.inflight.Done()
+		b.processHandlers(event, handlers)
+	}()
+}
+
+// isClosed reports whether Shutdown has been called
+func (b *EventBus) isClosed() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.closed
+}
+
+// Shutdown stops accepting new events, waits for in-flight handlers to finish
+// (or the context to expire) and closes the transport
+func (b *EventBus) Shutdown(ctx context.Context) error {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.done)
+	}
+	b.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		b.inflight.Wait()
+		close(done)
+	}()
+
+	var drainErr error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		drainErr = fmt.Errorf("timed out waiting for event handlers: %w", ctx.Err())
+	}
+
+	if b.transport != nil {
+		if err := b.transport.Close(); err != nil {
+			return fmt.Errorf("failed to close event transport: %w", err)
+		}
+	}
+	return drainErr
+}
+
 // processHandlers runs all handlers for an event
 func (b *EventBus) processHandlers(event Event, handlers []EventHandler) error {
 	for _, handler := range handlers {
@@ -198,6 +479,17 @@ func (m *MemoryTransport) Publish(topic string, data []byte) error {
 	return nil
 }
 
+// PublishBatch sends several messages at once. Publishing to memory cannot fail, so the batch
+// is trivially all-or-nothing.
+func (m *MemoryTransport) PublishBatch(messages []TransportMessage) error {
+	for _, message := range messages {
+		if err := m.Publish(message.Topic, message.Data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Subscribe registers a handler for a topic
 func (m *MemoryTransport) Subscribe(topic string, handler func([]byte)) error {
 	m.mu.Lock()
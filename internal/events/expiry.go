@@ -0,0 +1,110 @@
+package events
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DeadLetterTopic is the transport topic expired events are routed to
+const DeadLetterTopic = "dead_letter"
+
+// maxDeadLetters bounds how many dropped events a bus keeps for inspection
+const maxDeadLetters = 100
+
+// DeadLetter is an event that was dropped instead of delivered
+type DeadLetter struct {
+	Event    Event     `json:"event"`
+	Reason   string    `json:"reason"`
+	Received time.Time `json:"received"`
+}
+
+// Expired reports whether the event's TTL has run out at now
+func (e Event) Expired(now time.Time) bool {
+	return e.ExpiresAt != 0 && now.UnixNano() > e.ExpiresAt
+}
+
+// EmitWithTTL publishes an event that subscribers drop, rather than handle, once ttl has passed.
+// Use it for requests nobody will wait for indefinitely, e.g. a deployment requested from chat.
+func (b *EventBus) EmitWithTTL(eventType EventType, source, subject string, payload map[string]interface{}, ttl time.Duration) error {
+	now := time.Now()
+	event := Event{
+		Type:      eventType,
+		Source:    source,
+		Subject:   subject,
+		Payload:   payload,
+		Timestamp: now.UnixNano(),
+		ID:        uuid.New().String(),
+	}
+	if ttl > 0 {
+		event.ExpiresAt = now.Add(ttl).UnixNano()
+	}
+	return b.EmitEvent(event)
+}
+
+// deadLetter records a dropped event once, however many subscribers dropped it, and routes it
+// to the dead-letter topic of the transport
+func (b *EventBus) deadLetter(event Event, reason string) {
+	b.mu.Lock()
+	for _, letter := range b.deadLetters {
+		if letter.Event.ID == event.ID {
+			b.mu.Unlock()
+			return
+		}
+	}
+	b.deadLetters = append(b.deadLetters, DeadLetter{Event: event, Reason: reason, Received: time.Now()})
+	if len(b.deadLetters) > maxDeadLetters {
+		b.deadLetters = b.deadLetters[len(b.deadLetters)-maxDeadLetters:]
+	}
+	b.mu.Unlock()
+
+	recordExpired(event.Subject)
+	if b.transport == nil || b.LocalOnly() {
+		return
+	}
+	message, err := b.encode(event)
+	if err == nil {
+		err = b.transport.Publish(DeadLetterTopic, message.Data)
+	}
+	if err != nil {
+		log.Printf("Failed to route %s event %s to the dead-letter topic: %v", reason, event.ID, err)
+	}
+}
+
+// DeadLetters returns the most recent dropped events, oldest first
+func (b *EventBus) DeadLetters() []DeadLetter {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return append([]DeadLetter(nil), b.deadLetters...)
+}
+
+var (
+	expiryMetricsMu sync.Mutex
+	expiredEvents   = map[string]int64{}
+)
+
+func recordExpired(subject string) {
+	expiryMetricsMu.Lock()
+	defer expiryMetricsMu.Unlock()
+	expiredEvents[subject]++
+}
+
+// GetExpiryMetrics returns how many events expired before delivery, per subject
+func GetExpiryMetrics() map[string]int64 {
+	expiryMetricsMu.Lock()
+	defer expiryMetricsMu.Unlock()
+	snapshot := make(map[string]int64, len(expiredEvents))
+	for subject, count := range expiredEvents {
+		snapshot[subject] = count
+	}
+	return snapshot
+}
+
+// ResetExpiryMetrics clears the expiry counters
+func ResetExpiryMetrics() {
+	expiryMetricsMu.Lock()
+	defer expiryMetricsMu.Unlock()
+	expiredEvents = map[string]int64{}
+}
@@ -0,0 +1,46 @@
+package events
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExpiredRequestsAreDeadLettered(t *testing.T) {
+	ResetExpiryMetrics()
+	transport := &recordingTransport{}
+	bus := NewEventBus(transport, false)
+	var handled []string
+	for i := 0; i < 2; i++ {
+		bus.SubscribeToRoutingKey("deployment.request", func(event Event) error {
+			handled = append(handled, event.ID)
+			return nil
+		})
+	}
+	// Held back like a request queued during an outage
+	bus.SetDeliveryDelay(func(Event) time.Duration { return 20 * time.Millisecond })
+
+	if err := bus.EmitWithTTL(EventTypeRequest, "orchestrator", "deployment.request", map[string]interface{}{"intent": "deploy"}, 5*time.Millisecond); err != nil {
+		t.Fatalf("emit failed: %v", err)
+	}
+	if len(handled) != 0 {
+		t.Fatalf("expected the expired request not to be handled, handled %v", handled)
+	}
+	letters := bus.DeadLetters()
+	if len(letters) != 1 || letters[0].Reason != "expired" || letters[0].Event.Subject != "deployment.request" {
+		t.Fatalf("expected one expired dead letter however many subscribers dropped it, got %+v", letters)
+	}
+	if got := GetExpiryMetrics()["deployment.request"]; got != 1 {
+		t.Errorf("expected 1 expired deployment request, got %d", got)
+	}
+	// Published once as the request, once to the dead-letter topic
+	if len(transport.published) != 2 {
+		t.Errorf("expected the expired request to be routed to the dead-letter topic, got %d publishes", len(transport.published))
+	}
+
+	if err := bus.EmitWithTTL(EventTypeRequest, "orchestrator", "deployment.request", map[string]interface{}{"intent": "deploy"}, time.Minute); err != nil {
+		t.Fatalf("emit failed: %v", err)
+	}
+	if len(handled) != 2 || len(bus.DeadLetters()) != 1 {
+		t.Errorf("expected a live request to reach both subscribers, handled %d", len(handled))
+	}
+}
@@ -0,0 +1,44 @@
+package events
+
+import (
+	"errors"
+	"testing"
+)
+
+// downTransport fails every publish, like a transport whose broker is unreachable
+type downTransport struct{ recordingTransport }
+
+func (d *downTransport) Publish(topic string, data []byte) error {
+	return errors.New("connection refused")
+}
+
+func TestLocalOnlyHandlesEventsWithoutTransport(t *testing.T) {
+	bus := NewEventBus(&downTransport{}, true)
+	handled := 0
+	bus.Subscribe(EventTypeNotify, func(event Event) error {
+		handled++
+		return nil
+	})
+
+	if err := bus.Emit(EventTypeNotify, "test", "deployment.completed", nil); err == nil {
+		t.Fatal("expected the emit to fail while the transport is down")
+	}
+	if handled != 0 {
+		t.Fatalf("expected no local handling when publishing fails, handled %d", handled)
+	}
+
+	bus.SetLocalOnly(true)
+	if err := bus.Emit(EventTypeNotify, "test", "deployment.completed", nil); err != nil {
+		t.Fatalf("expected local-only emit to succeed, got %v", err)
+	}
+	// Handled synchronously even though the bus is asynchronous
+	if handled != 1 {
+		t.Errorf("expected the event to be handled before Emit returned, handled %d", handled)
+	}
+	if err := bus.EmitEvent(Event{Type: EventTypeNotify, Source: "test", Subject: "deployment.completed"}); err != nil {
+		t.Fatalf("expected local-only EmitEvent to succeed, got %v", err)
+	}
+	if handled != 2 {
+		t.Errorf("expected 2 handled events, got %d", handled)
+	}
+}
@@ -1,8 +1,10 @@
 package events
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"math/rand"
 	"time"
 
 	"github.com/nats-io/nats.go"
@@ -19,33 +21,62 @@ type NATSTransport struct {
 
 // NATSConfig represents configuration options for NATS transport
 type NATSConfig struct {
-	URL            string
-	ConnectTimeout time.Duration
-	MaxReconnects  int
-	ReconnectWait  time.Duration
+	URL                 string
+	ConnectTimeout      time.Duration
+	MaxReconnects       int           // reconnect attempts before the connection is given up; -1 retries forever
+	ReconnectWait       time.Duration // delay before the first reconnect attempt, doubled after each failed one
+	MaxReconnectWait    time.Duration // longest delay between reconnect attempts
+	PingInterval        time.Duration // keepalive pings detect a dead connection without traffic
+	MaxPingsOutstanding int           // unanswered pings after which the connection is treated as lost
+	ReconnectBufferSize int           // bytes of publishes buffered while reconnecting; further publishes fail
 }
 
 // DefaultNATSConfig provides sensible defaults for NATS
 func DefaultNATSConfig() NATSConfig {
 	return NATSConfig{
-		URL:            nats.DefaultURL,
-		ConnectTimeout: 5 * time.Second,
-		MaxReconnects:  10,
-		ReconnectWait:  1 * time.Second,
+		URL:                 nats.DefaultURL,
+		ConnectTimeout:      5 * time.Second,
+		MaxReconnects:       -1,
+		ReconnectWait:       500 * time.Millisecond,
+		MaxReconnectWait:    30 * time.Second,
+		PingInterval:        20 * time.Second,
+		MaxPingsOutstanding: 3,
+		ReconnectBufferSize: 8 * 1024 * 1024,
 	}
 }
 
-// NewNATSTransport creates a new NATS transport
+// reconnectDelay backs off exponentially from ReconnectWait to MaxReconnectWait, adding up to
+// 10% jitter so instances that lost the same server do not reconnect in lockstep
+func (c NATSConfig) reconnectDelay(attempts int) time.Duration {
+	delay := c.ReconnectWait
+	for i := 1; i < attempts && delay < c.MaxReconnectWait; i++ {
+		delay *= 2
+	}
+	if c.MaxReconnectWait > 0 && delay > c.MaxReconnectWait {
+		delay = c.MaxReconnectWait
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay + time.Duration(rand.Int63n(int64(delay)/10+1))
+}
+
+// NewNATSTransport creates a new NATS transport. While the connection is down the client
+// keeps reconnecting with backoff, buffers publishes up to ReconnectBufferSize and, once
+// reconnected, restores every subscription before flushing the buffered publishes.
 func NewNATSTransport(config NATSConfig) (*NATSTransport, error) {
 	options := []nats.Option{
 		nats.Timeout(config.ConnectTimeout),
 		nats.MaxReconnects(config.MaxReconnects),
-		nats.ReconnectWait(config.ReconnectWait),
+		nats.CustomReconnectDelay(config.reconnectDelay),
+		nats.PingInterval(config.PingInterval),
+		nats.MaxPingsOutstanding(config.MaxPingsOutstanding),
+		nats.ReconnectBufSize(config.ReconnectBufferSize),
 		nats.DisconnectErrHandler(func(nc *nats.Conn, err error) {
 			log.Printf("NATS disconnected: %v", err)
 		}),
 		nats.ReconnectHandler(func(nc *nats.Conn) {
-			log.Printf("NATS reconnected to %s", nc.ConnectedUrl())
+			log.Printf("NATS reconnected to %s, restored %d subscriptions", nc.ConnectedUrl(), nc.NumSubscriptions())
 		}),
 		nats.ClosedHandler(func(nc *nats.Conn) {
 			log.Printf("NATS connection closed")
@@ -66,12 +97,16 @@ func NewNATSTransport(config NATSConfig) (*NATSTransport, error) {
 	}, nil
 }
 
-// Publish sends data to NATS for a specific topic
+// Publish sends data to NATS for a specific topic. Publishes made while reconnecting are
+// buffered; they fail once the buffer is full or the connection was given up.
 func (n *NATSTransport) Publish(topic string, data []byte) error {
 	if !n.connected {
 		return fmt.Errorf("not connected to NATS")
 	}
-	return n.conn.Publish(topic, data)
+	if err := n.conn.Publish(topic, data); err != nil {
+		return fmt.Errorf("failed to publish to NATS topic %s: %w", topic, err)
+	}
+	return nil
 }
 
 // Subscribe registers a handler for a NATS topic
@@ -92,6 +127,14 @@ func (n *NATSTransport) Subscribe(topic string, handler func([]byte)) error {
 	return nil
 }
 
+// Ping verifies the NATS connection with a server round trip
+func (n *NATSTransport) Ping(ctx context.Context) error {
+	if !n.connected || !n.conn.IsConnected() {
+		return fmt.Errorf("not connected to NATS (%s)", n.url)
+	}
+	return n.conn.FlushWithContext(ctx)
+}
+
 // Close cleans up NATS resources
 func (n *NATSTransport) Close() error {
 	if !n.connected {
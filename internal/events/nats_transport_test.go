@@ -0,0 +1,244 @@
+package events
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeNATSServer speaks enough of the NATS client protocol for the transport: it answers
+// pings, counts the client's keepalive pings and routes PUB to matching SUB on any connection
+type fakeNATSServer struct {
+	t    *testing.T
+	addr string
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]map[string]string // subscription IDs by subject, per connection
+	pings    int
+}
+
+func newFakeNATSServer(t *testing.T) *fakeNATSServer {
+	t.Helper()
+	s := &fakeNATSServer{t: t}
+	s.start("127.0.0.1:0")
+	t.Cleanup(s.stop)
+	return s
+}
+
+func (s *fakeNATSServer) url() string {
+	return "nats://" + s.addr
+}
+
+func (s *fakeNATSServer) start(addr string) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		s.t.Fatalf("listen: %v", err)
+	}
+	s.mu.Lock()
+	s.listener = listener
+	s.addr = listener.Addr().String()
+	s.conns = map[net.Conn]map[string]string{}
+	s.mu.Unlock()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+}
+
+// stop drops the listener and every client connection, like a crashed server
+func (s *fakeNATSServer) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listener != nil {
+		s.listener.Close()
+		s.listener = nil
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = map[net.Conn]map[string]string{}
+}
+
+func (s *fakeNATSServer) clientPings() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pings
+}
+
+func (s *fakeNATSServer) serve(conn net.Conn) {
+	s.mu.Lock()
+	s.conns[conn] = map[string]string{}
+	s.mu.Unlock()
+	defer conn.Close()
+
+	host, port, _ := net.SplitHostPort(s.addr)
+	fmt.Fprintf(conn, "INFO {\"server_id\":\"fake\",\"version\":\"2.10.0\",\"proto\":1,\"host\":%q,\"port\":%s,\"max_payload\":1048576}\r\n", host, port)
+	reader := bufio.NewReader(conn)
+	handshake := true
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch strings.ToUpper(fields[0]) {
+		case "PING":
+			// The first PING completes the handshake; later ones are keepalives
+			if !handshake {
+				s.mu.Lock()
+				s.pings++
+				s.mu.Unlock()
+			}
+			handshake = false
+			fmt.Fprint(conn, "PONG\r\n")
+		case "SUB":
+			s.mu.Lock()
+			if subs, ok := s.conns[conn]; ok {
+				subs[fields[1]] = fields[len(fields)-1]
+			}
+			s.mu.Unlock()
+		case "PUB":
+			size, _ := strconv.Atoi(fields[len(fields)-1])
+			payload := make([]byte, size+2)
+			if _, err := io.ReadFull(reader, payload); err != nil {
+				return
+			}
+			s.deliver(fields[1], payload[:size])
+		}
+	}
+}
+
+func (s *fakeNATSServer) deliver(subject string, payload []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn, subs := range s.conns {
+		if sid, ok := subs[subject]; ok {
+			fmt.Fprintf(conn, "MSG %s %s %d\r\n%s\r\n", subject, sid, len(payload), payload)
+		}
+	}
+}
+
+func testNATSConfig(url string) NATSConfig {
+	config := DefaultNATSConfig()
+	config.URL = url
+	config.ReconnectWait = 10 * time.Millisecond
+	config.MaxReconnectWait = 50 * time.Millisecond
+	return config
+}
+
+func waitFor(t *testing.T, what string, done func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !done() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestNATSReconnectDelayBacksOff(t *testing.T) {
+	config := NATSConfig{ReconnectWait: 100 * time.Millisecond, MaxReconnectWait: time.Second}
+	for attempts, want := range map[int]time.Duration{
+		1:  100 * time.Millisecond,
+		2:  200 * time.Millisecond,
+		3:  400 * time.Millisecond,
+		4:  800 * time.Millisecond,
+		5:  time.Second,
+		50: time.Second,
+	} {
+		got := config.reconnectDelay(attempts)
+		if got < want || got > want+want/10 {
+			t.Errorf("attempt %d: expected %v plus at most 10%% jitter, got %v", attempts, want, got)
+		}
+	}
+}
+
+func TestNATSTransportSendsKeepalivePings(t *testing.T) {
+	server := newFakeNATSServer(t)
+	config := testNATSConfig(server.url())
+	config.PingInterval = 20 * time.Millisecond
+	transport, err := NewNATSTransport(config)
+	if err != nil {
+		t.Fatalf("NewNATSTransport: %v", err)
+	}
+	defer transport.Close()
+
+	waitFor(t, "keepalive pings", func() bool { return server.clientPings() >= 3 })
+}
+
+func TestNATSTransportResubscribesAndFlushesBufferAfterReconnect(t *testing.T) {
+	server := newFakeNATSServer(t)
+	transport, err := NewNATSTransport(testNATSConfig(server.url()))
+	if err != nil {
+		t.Fatalf("NewNATSTransport: %v", err)
+	}
+	defer transport.Close()
+
+	received := make(chan string, 10)
+	if err := transport.Subscribe("deployment.completed", func(data []byte) { received <- string(data) }); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if err := transport.Publish("deployment.completed", []byte("before")); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if got := <-received; got != "before" {
+		t.Fatalf("expected the message published before the outage, got %q", got)
+	}
+
+	addr := server.addr
+	server.stop()
+	waitFor(t, "the disconnect", func() bool { return !transport.conn.IsConnected() })
+
+	// Published while the server is down: buffered, not failed
+	if err := transport.Publish("deployment.completed", []byte("during")); err != nil {
+		t.Fatalf("expected the publish to be buffered while reconnecting, got %v", err)
+	}
+
+	server.start(addr)
+	select {
+	case got := <-received:
+		if got != "during" {
+			t.Fatalf("expected the buffered message, got %q", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the subscription to be restored and the buffered message delivered")
+	}
+}
+
+func TestNATSTransportFailsPublishesBeyondTheBuffer(t *testing.T) {
+	server := newFakeNATSServer(t)
+	config := testNATSConfig(server.url())
+	config.ReconnectBufferSize = 64
+	transport, err := NewNATSTransport(config)
+	if err != nil {
+		t.Fatalf("NewNATSTransport: %v", err)
+	}
+	defer transport.Close()
+
+	server.stop()
+	waitFor(t, "the disconnect", func() bool { return !transport.conn.IsConnected() })
+
+	// The publish that fills the buffer is kept; the ones after it fail until reconnected
+	if err := transport.Publish("deployment.completed", make([]byte, 128)); err != nil {
+		t.Fatalf("expected the first publish to be buffered, got %v", err)
+	}
+	if err := transport.Publish("deployment.completed", make([]byte, 128)); err == nil {
+		t.Fatal("expected a publish beyond the reconnect buffer to fail")
+	}
+}
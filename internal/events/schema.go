@@ -0,0 +1,262 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FieldType is the JSON type a payload field must have
+type FieldType string
+
+const (
+	FieldString FieldType = "string"
+	FieldNumber FieldType = "number"
+	FieldBool   FieldType = "bool"
+	FieldObject FieldType = "object"
+	FieldArray  FieldType = "array"
+	FieldAny    FieldType = "any"
+)
+
+// SchemaVersionKey is the payload field naming the schema version an event was produced
+// against. Events without it are validated against the latest version.
+const SchemaVersionKey = "schema_version"
+
+var (
+	// ErrSchemaViolation is returned for payloads that do not match their subject's schema
+	ErrSchemaViolation = errors.New("event payload violates schema")
+	// ErrIncompatibleSchema is returned when registering a version that would break existing producers or consumers
+	ErrIncompatibleSchema = errors.New("incompatible schema change")
+)
+
+// FieldSchema describes one payload field
+type FieldSchema struct {
+	Type        FieldType `json:"type"`
+	Required    bool      `json:"required,omitempty"`
+	Description string    `json:"description,omitempty"`
+}
+
+// PayloadSchema is a versioned contract for the payload of events with a subject.
+// Fields not listed are allowed unless Strict is set.
+type PayloadSchema struct {
+	Subject string                 `json:"subject"`
+	Version int                    `json:"version"`
+	Fields  map[string]FieldSchema `json:"fields"`
+	Strict  bool                   `json:"strict,omitempty"`
+}
+
+// Validate checks a payload against the schema, reporting every problem at once
+func (s PayloadSchema) Validate(payload map[string]interface{}) error {
+	var problems []string
+	names := make([]string, 0, len(s.Fields))
+	for name := range s.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		field := s.Fields[name]
+		value, present := payload[name]
+		if !present || value == nil {
+			if field.Required {
+				problems = append(problems, fmt.Sprintf("missing required field %q", name))
+			}
+			continue
+		}
+		if !matchesFieldType(value, field.Type) {
+			problems = append(problems, fmt.Sprintf("field %q must be %s, got %T", name, field.Type, value))
+		}
+	}
+	if s.Strict {
+		var unknown []string
+		for name := range payload {
+			if _, known := s.Fields[name]; !known && name != SchemaVersionKey {
+				unknown = append(unknown, fmt.Sprintf("unknown field %q", name))
+			}
+		}
+		sort.Strings(unknown)
+		problems = append(problems, unknown...)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s v%d: %s", ErrSchemaViolation, s.Subject, s.Version, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func matchesFieldType(value interface{}, fieldType FieldType) bool {
+	switch fieldType {
+	case FieldString:
+		_, ok := value.(string)
+		return ok
+	case FieldNumber:
+		switch value.(type) {
+		case int, int32, int64, float32, float64:
+			return true
+		}
+		return false
+	case FieldBool:
+		_, ok := value.(bool)
+		return ok
+	case FieldObject:
+		_, ok := value.(map[string]interface{})
+		return ok
+	case FieldArray:
+		switch value.(type) {
+		case []interface{}, []string, []map[string]interface{}:
+			return true
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+// CheckCompatibility lists the changes in next that would break producers or consumers of
+// prev: new required fields (old producers do not send them), removed or relaxed required
+// fields (consumers rely on them) and changed field types
+func CheckCompatibility(prev, next PayloadSchema) []string {
+	var problems []string
+	for name, field := range next.Fields {
+		old, existed := prev.Fields[name]
+		switch {
+		case !existed && field.Required:
+			problems = append(problems, fmt.Sprintf("new field %q cannot be required", name))
+		case existed && old.Type != field.Type && old.Type != FieldAny && field.Type != FieldAny:
+			problems = append(problems, fmt.Sprintf("field %q changed type from %s to %s", name, old.Type, field.Type))
+		case existed && !old.Required && field.Required:
+			problems = append(problems, fmt.Sprintf("optional field %q cannot become required", name))
+		}
+	}
+	for name, field := range prev.Fields {
+		if next.Fields[name].Required || !field.Required {
+			continue
+		}
+		if _, kept := next.Fields[name]; !kept {
+			problems = append(problems, fmt.Sprintf("required field %q cannot be removed", name))
+		} else {
+			problems = append(problems, fmt.Sprintf("required field %q cannot become optional", name))
+		}
+	}
+	if next.Strict && !prev.Strict {
+		problems = append(problems, "schema cannot become strict")
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// SchemaRegistry holds the payload schemas for event subjects, every version of each
+type SchemaRegistry struct {
+	mu       sync.RWMutex
+	versions map[string][]PayloadSchema // ascending by version
+}
+
+// NewSchemaRegistry creates an empty schema registry
+func NewSchemaRegistry() *SchemaRegistry {
+	return &SchemaRegistry{versions: make(map[string][]PayloadSchema)}
+}
+
+// Register adds a schema version. Versions must increase and stay compatible with the
+// latest registered version of the subject.
+func (r *SchemaRegistry) Register(schema PayloadSchema) error {
+	if schema.Subject == "" {
+		return fmt.Errorf("schema subject is required")
+	}
+	if schema.Version < 1 {
+		return fmt.Errorf("schema %s: version must be at least 1", schema.Subject)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	existing := r.versions[schema.Subject]
+	if n := len(existing); n > 0 {
+		latest := existing[n-1]
+		if schema.Version <= latest.Version {
+			return fmt.Errorf("schema %s: version %d is not newer than registered version %d", schema.Subject, schema.Version, latest.Version)
+		}
+		if problems := CheckCompatibility(latest, schema); len(problems) > 0 {
+			return fmt.Errorf("%w: %s v%d → v%d: %s", ErrIncompatibleSchema, schema.Subject, latest.Version, schema.Version, strings.Join(problems, "; "))
+		}
+	}
+	r.versions[schema.Subject] = append(existing, schema)
+	return nil
+}
+
+// Latest returns the newest schema for a subject
+func (r *SchemaRegistry) Latest(subject string) (PayloadSchema, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	versions := r.versions[subject]
+	if len(versions) == 0 {
+		return PayloadSchema{}, false
+	}
+	return versions[len(versions)-1], true
+}
+
+// Get returns a specific schema version for a subject
+func (r *SchemaRegistry) Get(subject string, version int) (PayloadSchema, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, schema := range r.versions[subject] {
+		if schema.Version == version {
+			return schema, true
+		}
+	}
+	return PayloadSchema{}, false
+}
+
+// Subjects lists the subjects that have schemas
+func (r *SchemaRegistry) Subjects() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	subjects := make([]string, 0, len(r.versions))
+	for subject := range r.versions {
+		subjects = append(subjects, subject)
+	}
+	sort.Strings(subjects)
+	return subjects
+}
+
+// Validate checks an event against the schema version it declares, or the latest one.
+// Events whose subject has no schema pass.
+func (r *SchemaRegistry) Validate(event Event) error {
+	version, declared, err := payloadSchemaVersion(event.Payload)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrSchemaViolation, event.Subject, err)
+	}
+
+	var schema PayloadSchema
+	var ok bool
+	if declared {
+		schema, ok = r.Get(event.Subject, version)
+		if !ok {
+			if _, known := r.Latest(event.Subject); known {
+				return fmt.Errorf("%w: %s has no schema version %d", ErrSchemaViolation, event.Subject, version)
+			}
+			return nil
+		}
+	} else if schema, ok = r.Latest(event.Subject); !ok {
+		return nil
+	}
+	return schema.Validate(event.Payload)
+}
+
+// payloadSchemaVersion reads the declared schema version, which is a float64 after a JSON round trip
+func payloadSchemaVersion(payload map[string]interface{}) (int, bool, error) {
+	raw, ok := payload[SchemaVersionKey]
+	if !ok {
+		return 0, false, nil
+	}
+	switch v := raw.(type) {
+	case int:
+		return v, true, nil
+	case int64:
+		return int(v), true, nil
+	case float64:
+		if v == float64(int(v)) {
+			return int(v), true, nil
+		}
+	}
+	return 0, false, fmt.Errorf("%s must be a whole number, got %v", SchemaVersionKey, raw)
+}
@@ -0,0 +1,138 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func deployRequestV1() PayloadSchema {
+	return PayloadSchema{
+		Subject: "deployment.request",
+		Version: 1,
+		Fields: map[string]FieldSchema{
+			"intent":      {Type: FieldString, Required: true},
+			"environment": {Type: FieldString},
+			"context":     {Type: FieldObject},
+		},
+	}
+}
+
+func TestPayloadSchemaValidate(t *testing.T) {
+	schema := deployRequestV1()
+	if err := schema.Validate(map[string]interface{}{"intent": "deploy", "extra": 1}); err != nil {
+		t.Errorf("expected unknown fields to be allowed, got %v", err)
+	}
+
+	err := schema.Validate(map[string]interface{}{"environment": 3, "context": "orchestrator"})
+	if !errors.Is(err, ErrSchemaViolation) {
+		t.Fatalf("expected ErrSchemaViolation, got %v", err)
+	}
+	for _, problem := range []string{`missing required field "intent"`, `field "environment" must be string`, `field "context" must be object`} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("expected %q in %q", problem, err)
+		}
+	}
+
+	schema.Strict = true
+	if err := schema.Validate(map[string]interface{}{"intent": "deploy", "action": "deploy"}); err == nil || !strings.Contains(err.Error(), `unknown field "action"`) {
+		t.Errorf("expected strict schemas to reject unknown fields, got %v", err)
+	}
+}
+
+func TestCheckCompatibility(t *testing.T) {
+	v1 := deployRequestV1()
+
+	compatible := deployRequestV1()
+	compatible.Version = 2
+	compatible.Fields["user_message"] = FieldSchema{Type: FieldString}
+	if problems := CheckCompatibility(v1, compatible); len(problems) != 0 {
+		t.Errorf("adding an optional field should be compatible, got %v", problems)
+	}
+
+	breaking := PayloadSchema{
+		Subject: "deployment.request",
+		Version: 2,
+		Fields: map[string]FieldSchema{
+			"action":      {Type: FieldString, Required: true},
+			"environment": {Type: FieldObject},
+		},
+	}
+	problems := CheckCompatibility(v1, breaking)
+	expected := []string{
+		`field "environment" changed type from string to object`,
+		`new field "action" cannot be required`,
+		`required field "intent" cannot be removed`,
+	}
+	if strings.Join(problems, "|") != strings.Join(expected, "|") {
+		t.Errorf("expected %v, got %v", expected, problems)
+	}
+}
+
+func TestSchemaRegistryVersions(t *testing.T) {
+	registry := NewSchemaRegistry()
+	if err := registry.Register(deployRequestV1()); err != nil {
+		t.Fatalf("register v1: %v", err)
+	}
+	if err := registry.Register(deployRequestV1()); err == nil {
+		t.Error("expected re-registering v1 to fail")
+	}
+
+	breaking := deployRequestV1()
+	breaking.Version = 2
+	breaking.Fields["environment"] = FieldSchema{Type: FieldString, Required: true}
+	if err := registry.Register(breaking); !errors.Is(err, ErrIncompatibleSchema) {
+		t.Fatalf("expected ErrIncompatibleSchema, got %v", err)
+	}
+
+	v2 := deployRequestV1()
+	v2.Version = 2
+	v2.Fields["user_message"] = FieldSchema{Type: FieldString}
+	if err := registry.Register(v2); err != nil {
+		t.Fatalf("register v2: %v", err)
+	}
+
+	// Latest is used unless the payload names its version; JSON turns the version into a float64
+	event := Event{Subject: "deployment.request", Payload: map[string]interface{}{"intent": "deploy", "user_message": 7}}
+	if err := registry.Validate(event); !errors.Is(err, ErrSchemaViolation) {
+		t.Errorf("expected v2 to check user_message, got %v", err)
+	}
+	var decoded map[string]interface{}
+	json.Unmarshal([]byte(`{"intent": "deploy", "user_message": 7, "schema_version": 1}`), &decoded)
+	if err := registry.Validate(Event{Subject: "deployment.request", Payload: decoded}); err != nil {
+		t.Errorf("expected v1 to accept the payload, got %v", err)
+	}
+	if err := registry.Validate(Event{Subject: "deployment.request", Payload: map[string]interface{}{"intent": "deploy", SchemaVersionKey: 9}}); err == nil {
+		t.Error("expected an unknown version to be rejected")
+	}
+	if err := registry.Validate(Event{Subject: "unregistered", Payload: map[string]interface{}{"anything": true}}); err != nil {
+		t.Errorf("expected subjects without schemas to pass, got %v", err)
+	}
+}
+
+func TestEventBusValidatesPayloads(t *testing.T) {
+	bus := NewEventBus(nil, false)
+	registry := NewSchemaRegistry()
+	registry.Register(deployRequestV1())
+	bus.SetSchemaRegistry(registry)
+
+	delivered := 0
+	bus.SubscribeToRoutingKey("deployment.request", func(event Event) error {
+		delivered++
+		return nil
+	})
+
+	if err := bus.Emit(EventTypeRequest, "test", "deployment.request", map[string]interface{}{"environment": "dev"}); !errors.Is(err, ErrSchemaViolation) {
+		t.Errorf("expected Emit to reject the payload, got %v", err)
+	}
+	if err := bus.EmitEvent(Event{Type: EventTypeRequest, Subject: "deployment.request", Payload: map[string]interface{}{"intent": 1}}); !errors.Is(err, ErrSchemaViolation) {
+		t.Errorf("expected EmitEvent to reject the payload, got %v", err)
+	}
+	if err := bus.Emit(EventTypeRequest, "test", "deployment.request", map[string]interface{}{"intent": "deploy"}); err != nil {
+		t.Errorf("expected a valid payload to be emitted, got %v", err)
+	}
+	if delivered != 1 {
+		t.Errorf("expected only the valid event to be delivered, got %d", delivered)
+	}
+}
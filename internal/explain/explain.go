@@ -0,0 +1,366 @@
+// Package explain reconstructs how a graph entity reached its current state from the records
+// the platform keeps about it, and narrates that history with citations to those records
+package explain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/ai"
+	"github.com/krzachariassen/ZTDP/internal/conversations"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/krzachariassen/ZTDP/internal/redaction"
+)
+
+// ErrNodeNotFound is returned when explaining a node that does not exist
+var ErrNodeNotFound = errors.New("node not found")
+
+// Record kinds
+const (
+	RecordNode         = "node"
+	RecordRelationship = "relationship"
+	RecordDeployment   = "deployment"
+	RecordPolicy       = "policy"
+	RecordConversation = "conversation"
+	RecordLog          = "log"
+)
+
+// logsPerTurn bounds the log entries pulled in for each conversation turn that touched the node
+const logsPerTurn = 5
+
+// Record is one piece of evidence about the entity. Narratives cite records by ID, e.g. [R3].
+type Record struct {
+	ID      string     `json:"id"`
+	Kind    string     `json:"kind"`
+	Time    *time.Time `json:"time,omitempty"`
+	Summary string     `json:"summary"`
+	Source  string     `json:"source"` // where the record lives, e.g. edge:checkout->dev:deploy or conversation:abc#2
+}
+
+// Explanation is the history of an entity and the story it tells
+type Explanation struct {
+	NodeID    string `json:"node_id"`
+	Kind      string `json:"kind"`
+	Narrative string `json:"narrative"`
+	// NarrativeSource is "ai" when the narrative was written by the AI provider, "generated" otherwise
+	NarrativeSource string   `json:"narrative_source"`
+	Records         []Record `json:"records"`
+	// Dependencies are the depends_on and uses chains the entity relies on, most critical first,
+	// so troubleshooting starts with the chains most likely to take it down
+	Dependencies []graph.DependencyPath `json:"dependencies,omitempty"`
+}
+
+// explainDependencies bounds the dependency chains an explanation lists
+const explainDependencies = 5
+
+// Service assembles entity histories from the graph, chat transcripts and retained logs
+type Service struct {
+	graph       *graph.GlobalGraph
+	aiProvider  ai.AIProvider          // nil writes generated narratives
+	transcripts *conversations.Service // nil when transcript storage is disabled
+	logs        logging.LogStore       // nil skips log entries
+	logger      *logging.Logger
+}
+
+// NewService creates an explain service; aiProvider, transcripts and logs are optional
+func NewService(globalGraph *graph.GlobalGraph, aiProvider ai.AIProvider, transcripts *conversations.Service, logs logging.LogStore) *Service {
+	return &Service{
+		graph:       globalGraph,
+		aiProvider:  aiProvider,
+		transcripts: transcripts,
+		logs:        logs,
+		logger:      logging.GetLogger().ForComponent("explain"),
+	}
+}
+
+// Explain returns the node's history and a narrative of how it reached its current state
+func (s *Service) Explain(ctx context.Context, nodeID string) (*Explanation, error) {
+	node, records, err := s.History(ctx, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	explanation := &Explanation{NodeID: node.ID, Kind: node.Kind, Records: records}
+	if g, err := s.graph.Graph(); err == nil {
+		explanation.Dependencies, _ = g.DependencyPaths(node.ID, graph.DependencyDirectionOut, 0, explainDependencies)
+	}
+	explanation.Narrative, explanation.NarrativeSource = s.narrate(ctx, node, records, explanation.Dependencies)
+	return explanation, nil
+}
+
+// History collects every record that touched the node, oldest first; undated records come last
+func (s *Service) History(ctx context.Context, nodeID string) (*graph.Node, []Record, error) {
+	g, err := s.graph.Graph()
+	if err != nil {
+		return nil, nil, err
+	}
+	node, ok := g.Nodes[nodeID]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %s", ErrNodeNotFound, nodeID)
+	}
+
+	records := []Record{nodeRecord(node)}
+	records = append(records, edgeRecords(g, node)...)
+	if s.transcripts != nil {
+		conversationRecords, correlationIDs, err := s.conversationRecords(nodeID)
+		if err != nil {
+			s.logger.Warn("⚠️ Could not read conversations for %s: %v", nodeID, err)
+		}
+		records = append(records, conversationRecords...)
+		records = append(records, s.logRecords(ctx, correlationIDs)...)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		a, b := records[i].Time, records[j].Time
+		switch {
+		case a == nil:
+			return false
+		case b == nil:
+			return true
+		default:
+			return a.Before(*b)
+		}
+	})
+	for i := range records {
+		records[i].ID = fmt.Sprintf("R%d", i+1)
+	}
+	return node, records, nil
+}
+
+func nodeRecord(node *graph.Node) Record {
+	summary := fmt.Sprintf("%s %s current state", node.Kind, node.ID)
+	if details := describeFields(node.Metadata, "name", "created_at", "updated_at"); details != "" {
+		summary += ": " + details
+	}
+	return Record{
+		Kind:    RecordNode,
+		Time:    firstTime(node.Metadata, "updated_at", "created_at"),
+		Summary: summary,
+		Source:  "node:" + node.ID,
+	}
+}
+
+// edgeRecords describes the node's relationships, deployments and governing policies in both directions
+func edgeRecords(g *graph.Graph, node *graph.Node) []Record {
+	var records []Record
+	add := func(from string, edge graph.Edge) {
+		other := edge.To
+		if other == node.ID {
+			other = from
+		}
+		otherKind := ""
+		if otherNode, ok := g.Nodes[other]; ok {
+			otherKind = otherNode.Kind
+		}
+
+		record := Record{
+			Kind:    RecordRelationship,
+			Summary: fmt.Sprintf("%s -%s-> %s", from, edge.Type, edge.To),
+			Source:  fmt.Sprintf("edge:%s->%s:%s", from, edge.To, edge.Type),
+			Time:    firstTime(edge.Metadata, "updated_at", "created_at", "timestamp"),
+		}
+		switch {
+		case edge.Type == graph.EdgeTypeDeploy || edge.Type == "deployment":
+			record.Kind = RecordDeployment
+			if status := deploymentState(edge.Metadata); status != "" {
+				record.Summary += " (" + status + ")"
+			}
+		case otherKind == graph.KindPolicy || otherKind == graph.KindCheck:
+			record.Kind = RecordPolicy
+			if otherNode := g.Nodes[other]; otherNode != nil {
+				if details := describeFields(otherNode.Metadata, "name", "status", "type"); details != "" {
+					record.Summary += " [" + details + "]"
+				}
+				if record.Time == nil {
+					record.Time = firstTime(otherNode.Metadata, "updated_at", "created_at")
+				}
+			}
+		}
+		records = append(records, record)
+	}
+
+	for _, edge := range g.Edges[node.ID] {
+		add(node.ID, edge)
+	}
+	froms := make([]string, 0, len(g.Edges))
+	for from := range g.Edges {
+		froms = append(froms, from)
+	}
+	sort.Strings(froms)
+	for _, from := range froms {
+		if from == node.ID {
+			continue
+		}
+		for _, edge := range g.Edges[from] {
+			if edge.To == node.ID {
+				add(from, edge)
+			}
+		}
+	}
+	return records
+}
+
+// deploymentState reads a deployment's status from edge metadata, in either the flat or the nested form
+func deploymentState(metadata map[string]interface{}) string {
+	if nested, ok := metadata["deployment"].(map[string]interface{}); ok {
+		if details := describeFields(nested, "status", "message"); details != "" {
+			return details
+		}
+	}
+	return describeFields(metadata, "status", "message", "deployment_id")
+}
+
+// conversationRecords returns the chat turns that mentioned the node and their correlation IDs
+func (s *Service) conversationRecords(nodeID string) ([]Record, []string, error) {
+	transcripts, err := s.transcripts.List(conversations.ListFilter{Entity: nodeID, IncludeArchived: true})
+	if err != nil {
+		return nil, nil, err
+	}
+	var records []Record
+	var correlationIDs []string
+	for _, transcript := range transcripts {
+		for i, turn := range transcript.Turns {
+			text := turn.UserMessage + " " + strings.Join(turn.AgentResponses, " ")
+			if !strings.Contains(text, nodeID) {
+				continue
+			}
+			timestamp := turn.Timestamp
+			summary := fmt.Sprintf("user asked %q", truncate(turn.UserMessage, 160))
+			if turn.Intent != "" {
+				summary += fmt.Sprintf("; intent %s", turn.Intent)
+			}
+			if turn.SelectedAgent != "" {
+				summary += fmt.Sprintf(" handled by %s", turn.SelectedAgent)
+			}
+			summary += fmt.Sprintf("; platform replied %q", truncate(turn.Response, 200))
+			records = append(records, Record{
+				Kind:    RecordConversation,
+				Time:    &timestamp,
+				Summary: summary,
+				Source:  fmt.Sprintf("conversation:%s#%d", transcript.ID, i+1),
+			})
+			if turn.CorrelationID != "" {
+				correlationIDs = append(correlationIDs, turn.CorrelationID)
+			}
+		}
+	}
+	return records, correlationIDs, nil
+}
+
+// logRecords pulls the retained log entries of the requests that touched the node
+func (s *Service) logRecords(ctx context.Context, correlationIDs []string) []Record {
+	if s.logs == nil {
+		return nil
+	}
+	var records []Record
+	for _, correlationID := range correlationIDs {
+		entries, err := s.logs.Query(ctx, logging.LogQuery{CorrelationID: correlationID, Limit: logsPerTurn})
+		if err != nil {
+			s.logger.Warn("⚠️ Could not query logs for %s: %v", correlationID, err)
+			continue
+		}
+		for _, entry := range entries {
+			timestamp := entry.Timestamp
+			component := entry.Component
+			if entry.AgentID != "" {
+				component = entry.AgentID
+			}
+			records = append(records, Record{
+				Kind:    RecordLog,
+				Time:    &timestamp,
+				Summary: fmt.Sprintf("%s %s: %s", entry.Level, component, truncate(entry.Message, 200)),
+				Source:  "log:" + correlationID,
+			})
+		}
+	}
+	return records
+}
+
+// narrate asks the AI provider for a cited narrative and falls back to a generated timeline
+func (s *Service) narrate(ctx context.Context, node *graph.Node, records []Record, dependencies []graph.DependencyPath) (string, string) {
+	generated := generatedNarrative(node, records)
+	if s.aiProvider == nil {
+		return generated, "generated"
+	}
+
+	redactor := redaction.Default()
+	var evidence strings.Builder
+	for _, record := range records {
+		when := "undated"
+		if record.Time != nil {
+			when = record.Time.UTC().Format(time.RFC3339)
+		}
+		fmt.Fprintf(&evidence, "[%s] %s %s: %s\n", record.ID, when, record.Kind, redactor.String(record.Summary))
+	}
+
+	systemPrompt := `You are a platform historian explaining how an entity in a developer platform reached its current state.
+Write a short chronological narrative (3-6 sentences) for an engineer asking "how did this get like this?".
+Use ONLY the records provided, cite every claim with the record IDs in brackets, e.g. [R2], and say plainly
+when the records do not explain something. When dependencies are listed and the entity is failing, name the
+most critical ones to check first. Return only the narrative text.`
+	userPrompt := fmt.Sprintf("Entity: %s %s\n\nRecords (oldest first):\n%s", node.Kind, node.ID, evidence.String())
+	if len(dependencies) > 0 {
+		// Weighed chains tell the AI which dependencies to suspect first when the entity is failing
+		var chains strings.Builder
+		for _, path := range dependencies {
+			fmt.Fprintf(&chains, "- %s (criticality %.2f)\n", strings.Join(path.Path, " -> "), path.Criticality)
+		}
+		userPrompt += "\nDependencies, most critical first (when the entity is failing, suspect these in this order):\n" + chains.String()
+	}
+
+	response, err := s.aiProvider.CallAI(ai.WithTask(ctx, ai.TaskConversation), systemPrompt, userPrompt)
+	if err != nil || strings.TrimSpace(response) == "" {
+		s.logger.Warn("⚠️ AI narrative unavailable, using generated timeline: %v", err)
+		return generated, "generated"
+	}
+	return strings.TrimSpace(response), "ai"
+}
+
+// generatedNarrative is a plain timeline of the records
+func generatedNarrative(node *graph.Node, records []Record) string {
+	lines := []string{fmt.Sprintf("History of %s %s (%d records):", node.Kind, node.ID, len(records))}
+	for _, record := range records {
+		when := "undated"
+		if record.Time != nil {
+			when = record.Time.UTC().Format(time.RFC3339)
+		}
+		lines = append(lines, fmt.Sprintf("- %s %s [%s]", when, record.Summary, record.ID))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// firstTime returns the first of the named metadata fields holding an RFC3339 timestamp
+func firstTime(metadata map[string]interface{}, keys ...string) *time.Time {
+	for _, key := range keys {
+		value, ok := metadata[key].(string)
+		if !ok {
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
+
+// describeFields renders the named metadata fields that are set as key=value pairs
+func describeFields(metadata map[string]interface{}, keys ...string) string {
+	var parts []string
+	for _, key := range keys {
+		if value, ok := metadata[key]; ok && value != nil && fmt.Sprint(value) != "" {
+			parts = append(parts, fmt.Sprintf("%s=%v", key, value))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func truncate(text string, max int) string {
+	if len(text) <= max {
+		return text
+	}
+	return text[:max] + "…"
+}
@@ -0,0 +1,151 @@
+package explain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/ai"
+	"github.com/krzachariassen/ZTDP/internal/conversations"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+type narrativeProvider struct {
+	response string
+	err      error
+	prompt   string
+}
+
+func (p *narrativeProvider) CallAI(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	p.prompt = userPrompt
+	return p.response, p.err
+}
+
+func (p *narrativeProvider) GetProviderInfo() *ai.ProviderInfo {
+	return &ai.ProviderInfo{Name: "narrative"}
+}
+
+func (p *narrativeProvider) Close() error { return nil }
+
+func newExplainTestGraph(t *testing.T) *graph.GlobalGraph {
+	t.Helper()
+	g := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	g.AddNode(&graph.Node{ID: "checkout", Kind: graph.KindApplication, Metadata: map[string]interface{}{"name": "checkout", "created_at": "2026-01-01T10:00:00Z"}, Spec: map[string]interface{}{}})
+	g.AddNode(&graph.Node{ID: "dev", Kind: graph.KindEnvironment, Metadata: map[string]interface{}{"name": "dev"}, Spec: map[string]interface{}{}})
+	g.AddNode(&graph.Node{ID: "require-tests", Kind: graph.KindPolicy, Metadata: map[string]interface{}{"name": "require-tests", "status": "satisfied", "updated_at": "2026-01-02T09:00:00Z"}, Spec: map[string]interface{}{}})
+
+	// Deployments and policy evaluations as the agents record them
+	current, err := g.Graph()
+	if err != nil {
+		t.Fatalf("Failed to read graph: %v", err)
+	}
+	current.Edges["checkout"] = []graph.Edge{
+		{To: "require-tests", Type: graph.EdgeTypeSatisfies},
+		{To: "dev", Type: "deployment", Metadata: map[string]interface{}{"status": "failed", "message": "image pull error", "updated_at": "2026-01-03T12:00:00Z"}},
+	}
+	if err := g.Save(); err != nil {
+		t.Fatalf("Failed to save graph: %v", err)
+	}
+	return g
+}
+
+func TestHistoryCollectsRecordsInOrder(t *testing.T) {
+	g := newExplainTestGraph(t)
+	transcripts := conversations.NewService(g, conversations.Options{})
+	if _, err := transcripts.RecordTurn("conv-1", "", conversations.Turn{
+		Timestamp:     time.Date(2026, 1, 3, 11, 0, 0, 0, time.UTC),
+		CorrelationID: "corr-1",
+		UserMessage:   "deploy checkout to dev",
+		Intent:        "deploy application",
+		Response:      "Deployment started",
+	}); err != nil {
+		t.Fatalf("Failed to record turn: %v", err)
+	}
+	logs := logging.NewRingBufferStore(10)
+	logs.Write(logging.LogEntry{Timestamp: time.Date(2026, 1, 3, 11, 30, 0, 0, time.UTC), Level: "ERROR", Component: "deployments", Message: "image pull error", CorrelationID: "corr-1"})
+
+	service := NewService(g, nil, transcripts, logs)
+	_, records, err := service.History(context.Background(), "checkout")
+	if err != nil {
+		t.Fatalf("History failed: %v", err)
+	}
+
+	var kinds []string
+	for i, record := range records {
+		if record.ID != fmt.Sprintf("R%d", i+1) {
+			t.Errorf("Expected record %d to be cited as R%d, got %s", i, i+1, record.ID)
+		}
+		kinds = append(kinds, record.Kind)
+	}
+	// node (Jan 1), policy (Jan 2), conversation (Jan 3 11:00), log (11:30), deployment (12:00), then the undated conversation link
+	expected := []string{RecordNode, RecordPolicy, RecordConversation, RecordLog, RecordDeployment, RecordRelationship}
+	if strings.Join(kinds, ",") != strings.Join(expected, ",") {
+		t.Fatalf("Expected records %v, got %v", expected, kinds)
+	}
+	if !strings.Contains(records[4].Summary, "status=failed") {
+		t.Errorf("Expected the deployment record to carry its status, got %q", records[4].Summary)
+	}
+}
+
+func TestExplainNarrative(t *testing.T) {
+	g := newExplainTestGraph(t)
+
+	provider := &narrativeProvider{response: "checkout was created [R1] and its dev deployment failed [R3]."}
+	explanation, err := NewService(g, provider, nil, nil).Explain(context.Background(), "checkout")
+	if err != nil {
+		t.Fatalf("Explain failed: %v", err)
+	}
+	if explanation.NarrativeSource != "ai" || explanation.Narrative != provider.response {
+		t.Errorf("Expected the AI narrative, got %s: %q", explanation.NarrativeSource, explanation.Narrative)
+	}
+	if !strings.Contains(provider.prompt, "[R3]") || !strings.Contains(provider.prompt, "image pull error") {
+		t.Errorf("Expected the prompt to list the cited records, got %q", provider.prompt)
+	}
+
+	provider = &narrativeProvider{err: errors.New("provider down")}
+	explanation, err = NewService(g, provider, nil, nil).Explain(context.Background(), "checkout")
+	if err != nil {
+		t.Fatalf("Explain failed: %v", err)
+	}
+	if explanation.NarrativeSource != "generated" || !strings.Contains(explanation.Narrative, "[R3]") {
+		t.Errorf("Expected a generated timeline with citations, got %q", explanation.Narrative)
+	}
+
+	if _, err := NewService(g, nil, nil, nil).Explain(context.Background(), "missing"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("Expected ErrNodeNotFound, got %v", err)
+	}
+}
+
+func TestExplainRanksDependencies(t *testing.T) {
+	g := newExplainTestGraph(t)
+	current, err := g.Graph()
+	if err != nil {
+		t.Fatalf("Failed to read graph: %v", err)
+	}
+	for _, id := range []string{"orders-db", "cache"} {
+		current.Nodes[id] = &graph.Node{ID: id, Kind: graph.KindResource, Metadata: map[string]interface{}{"name": id}, Spec: map[string]interface{}{}}
+	}
+	current.Edges["checkout"] = append(current.Edges["checkout"],
+		graph.Edge{To: "cache", Type: graph.EdgeTypeUses, Metadata: map[string]interface{}{graph.EdgeCriticalityKey: graph.CriticalityLow}},
+		graph.Edge{To: "orders-db", Type: graph.EdgeTypeUses, Metadata: map[string]interface{}{graph.EdgeCriticalityKey: graph.CriticalityCritical}},
+	)
+	if err := g.Save(); err != nil {
+		t.Fatalf("Failed to save graph: %v", err)
+	}
+
+	provider := &narrativeProvider{response: "checkout relies on orders-db."}
+	explanation, err := NewService(g, provider, nil, nil).Explain(context.Background(), "checkout")
+	if err != nil {
+		t.Fatalf("Explain failed: %v", err)
+	}
+	if len(explanation.Dependencies) != 2 || explanation.Dependencies[0].Target != "orders-db" {
+		t.Errorf("Expected orders-db to be the most critical dependency, got %+v", explanation.Dependencies)
+	}
+	if !strings.Contains(provider.prompt, "- checkout -> orders-db (criticality 1.00)\n- checkout -> cache (criticality 0.25)") {
+		t.Errorf("Expected the prompt to rank the dependencies, got %q", provider.prompt)
+	}
+}
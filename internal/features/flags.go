@@ -0,0 +1,154 @@
+// Package features provides graph-backed feature flags that agents and the orchestrator
+// consult at runtime, so behaviour can be rolled out gradually or switched off without a redeploy.
+package features
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// Override scopes, from most to least specific
+const (
+	ScopeConversation = "conversation"
+	ScopeTenant       = "tenant"
+	ScopeAgent        = "agent"
+)
+
+// flagNamePattern keeps flag names usable in URLs and graph node IDs
+var flagNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]*$`)
+
+// Flag is a feature flag stored as a node in the global graph
+type Flag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+
+	// Enabled is the kill switch: a disabled flag is off for everyone, overrides included
+	Enabled bool `json:"enabled"`
+
+	// RolloutPercent is the share of conversations (0-100) that get the flag when no override matches
+	RolloutPercent int `json:"rollout_percent"`
+
+	// Overrides force the flag on or off for a specific conversation, tenant or agent
+	Overrides []Override `json:"overrides,omitempty"`
+
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Override forces a flag value for one conversation, tenant or agent
+type Override struct {
+	Scope   string `json:"scope"` // conversation | tenant | agent
+	Value   string `json:"value"`
+	Enabled bool   `json:"enabled"`
+}
+
+// Validate checks the flag definition
+func (f *Flag) Validate() error {
+	if !flagNamePattern.MatchString(f.Name) {
+		return fmt.Errorf("invalid flag name %q: use lowercase letters, digits, '.', '_' or '-'", f.Name)
+	}
+	if f.RolloutPercent < 0 || f.RolloutPercent > 100 {
+		return fmt.Errorf("rollout_percent must be between 0 and 100, got %d", f.RolloutPercent)
+	}
+	for _, o := range f.Overrides {
+		switch o.Scope {
+		case ScopeConversation, ScopeTenant, ScopeAgent:
+		default:
+			return fmt.Errorf("invalid override scope %q (expected conversation, tenant or agent)", o.Scope)
+		}
+		if o.Value == "" {
+			return fmt.Errorf("override for scope %s requires a value", o.Scope)
+		}
+	}
+	return nil
+}
+
+// EvaluationContext identifies who a flag is evaluated for
+type EvaluationContext struct {
+	ConversationID string `json:"conversation_id,omitempty"`
+	Tenant         string `json:"tenant,omitempty"`
+	AgentID        string `json:"agent_id,omitempty"`
+}
+
+// Evaluate returns whether the flag is on for the given context.
+// Order: kill switch, then conversation, tenant and agent overrides, then percentage rollout.
+func (f *Flag) Evaluate(ec EvaluationContext) bool {
+	if !f.Enabled {
+		return false
+	}
+
+	for _, scope := range []struct{ name, value string }{
+		{ScopeConversation, ec.ConversationID},
+		{ScopeTenant, ec.Tenant},
+		{ScopeAgent, ec.AgentID},
+	} {
+		if scope.value == "" {
+			continue
+		}
+		for _, o := range f.Overrides {
+			if o.Scope == scope.name && o.Value == scope.value {
+				return o.Enabled
+			}
+		}
+	}
+
+	return inRollout(f.Name, rolloutKey(ec), f.RolloutPercent)
+}
+
+// rolloutKey picks the most specific identity so a conversation keeps a stable result
+func rolloutKey(ec EvaluationContext) string {
+	switch {
+	case ec.ConversationID != "":
+		return ec.ConversationID
+	case ec.Tenant != "":
+		return ec.Tenant
+	default:
+		return ec.AgentID
+	}
+}
+
+// inRollout deterministically buckets key into 0-99 per flag and compares it with percent
+func inRollout(flagName, key string, percent int) bool {
+	if percent >= 100 {
+		return true
+	}
+	if percent <= 0 || key == "" {
+		return false
+	}
+	h := fnv.New32a()
+	h.Write([]byte(flagName + ":" + key))
+	return int(h.Sum32()%100) < percent
+}
+
+type contextKey string
+
+const (
+	tenantKey         contextKey = "tenant"
+	conversationIDKey contextKey = "conversation_id"
+)
+
+// WithTenant returns a context evaluated as belonging to tenant
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey, tenant)
+}
+
+// WithConversationID returns a context evaluated as part of the given conversation
+func WithConversationID(ctx context.Context, conversationID string) context.Context {
+	return context.WithValue(ctx, conversationIDKey, conversationID)
+}
+
+// EvaluationContextFrom builds an evaluation context from ctx. Without an explicit
+// conversation ID the request's correlation ID identifies the conversation.
+func EvaluationContextFrom(ctx context.Context) EvaluationContext {
+	ec := EvaluationContext{AgentID: logging.AgentIDFromContext(ctx)}
+	ec.Tenant, _ = ctx.Value(tenantKey).(string)
+	ec.ConversationID, _ = ctx.Value(conversationIDKey).(string)
+	if ec.ConversationID == "" {
+		ec.ConversationID = logging.CorrelationIDFromContext(ctx)
+	}
+	return ec
+}
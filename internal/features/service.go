@@ -0,0 +1,155 @@
+package features
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// ErrFlagNotFound is returned when a flag is not defined
+var ErrFlagNotFound = errors.New("feature flag not found")
+
+// nodeIDPrefix namespaces flag nodes so they cannot collide with application or service names
+const nodeIDPrefix = "feature-flag:"
+
+// Service manages feature flags stored in the global graph
+type Service struct {
+	graph  *graph.GlobalGraph
+	logger *logging.Logger
+}
+
+// NewService creates a feature flag service backed by the global graph
+func NewService(globalGraph *graph.GlobalGraph) *Service {
+	return &Service{
+		graph:  globalGraph,
+		logger: logging.GetLogger().ForComponent("features"),
+	}
+}
+
+// SetFlag creates or replaces a flag
+func (s *Service) SetFlag(flag *Flag) error {
+	if err := flag.Validate(); err != nil {
+		return err
+	}
+	flag.UpdatedAt = time.Now().UTC()
+
+	node, err := flagToNode(flag)
+	if err != nil {
+		return err
+	}
+
+	if existing, _ := s.graph.GetNode(node.ID); existing != nil {
+		if err := s.graph.UpdateNode(node); err != nil {
+			return fmt.Errorf("failed to update flag %s: %w", flag.Name, err)
+		}
+	} else {
+		s.graph.AddNode(node)
+	}
+
+	s.logger.Info("🚩 Feature flag %s set (enabled: %t, rollout: %d%%, overrides: %d)",
+		flag.Name, flag.Enabled, flag.RolloutPercent, len(flag.Overrides))
+	return nil
+}
+
+// GetFlag returns a flag by name
+func (s *Service) GetFlag(name string) (*Flag, error) {
+	node, _ := s.graph.GetNode(nodeIDPrefix + name)
+	if node == nil || node.Kind != graph.KindFeatureFlag {
+		return nil, ErrFlagNotFound
+	}
+	return nodeToFlag(node)
+}
+
+// ListFlags returns all flags sorted by name
+func (s *Service) ListFlags() ([]*Flag, error) {
+	nodes, err := s.graph.Nodes()
+	if err != nil {
+		return nil, err
+	}
+
+	flags := []*Flag{}
+	for _, node := range nodes {
+		if node.Kind != graph.KindFeatureFlag {
+			continue
+		}
+		flag, err := nodeToFlag(node)
+		if err != nil {
+			s.logger.Warn("⚠️ Skipping malformed feature flag node %s: %v", node.ID, err)
+			continue
+		}
+		flags = append(flags, flag)
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Name < flags[j].Name })
+	return flags, nil
+}
+
+// DeleteFlag removes a flag; code consulting it falls back to its default
+func (s *Service) DeleteFlag(name string) error {
+	if _, err := s.GetFlag(name); err != nil {
+		return err
+	}
+	if err := s.graph.DeleteNode(nodeIDPrefix + name); err != nil {
+		return fmt.Errorf("failed to delete flag %s: %w", name, err)
+	}
+	s.logger.Info("🚩 Feature flag %s deleted", name)
+	return nil
+}
+
+// IsEnabled evaluates a flag for the conversation, tenant and agent found in ctx.
+// Undefined flags return defaultValue, so code can consult a flag before anyone creates it.
+func (s *Service) IsEnabled(ctx context.Context, name string, defaultValue bool) bool {
+	return s.Evaluate(name, EvaluationContextFrom(ctx), defaultValue)
+}
+
+// Evaluate evaluates a flag for an explicit evaluation context
+func (s *Service) Evaluate(name string, ec EvaluationContext, defaultValue bool) bool {
+	if s == nil || s.graph == nil {
+		return defaultValue
+	}
+	flag, err := s.GetFlag(name)
+	if err != nil {
+		return defaultValue
+	}
+	return flag.Evaluate(ec)
+}
+
+// flagToNode stores the flag definition in the node spec
+func flagToNode(flag *Flag) (*graph.Node, error) {
+	data, err := json.Marshal(flag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode flag: %w", err)
+	}
+	var spec map[string]interface{}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to encode flag: %w", err)
+	}
+
+	return &graph.Node{
+		ID:   nodeIDPrefix + flag.Name,
+		Kind: graph.KindFeatureFlag,
+		Metadata: map[string]interface{}{
+			"name":        flag.Name,
+			"description": flag.Description,
+		},
+		Spec: spec,
+	}, nil
+}
+
+// nodeToFlag decodes the flag definition from the node spec
+func nodeToFlag(node *graph.Node) (*Flag, error) {
+	data, err := json.Marshal(node.Spec)
+	if err != nil {
+		return nil, err
+	}
+	var flag Flag
+	if err := json.Unmarshal(data, &flag); err != nil {
+		return nil, err
+	}
+	return &flag, nil
+}
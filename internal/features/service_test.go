@@ -0,0 +1,107 @@
+package features
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestService() *Service {
+	return NewService(graph.NewGlobalGraph(graph.NewMemoryGraph()))
+}
+
+func TestService_SetGetListDelete(t *testing.T) {
+	s := newTestService()
+
+	require.NoError(t, s.SetFlag(&Flag{Name: "semantic-intent", Enabled: true, RolloutPercent: 10}))
+	require.NoError(t, s.SetFlag(&Flag{Name: "auto-execute", Enabled: true, RolloutPercent: 100}))
+
+	flag, err := s.GetFlag("semantic-intent")
+	require.NoError(t, err)
+	assert.Equal(t, 10, flag.RolloutPercent)
+
+	// Updating replaces the stored definition
+	require.NoError(t, s.SetFlag(&Flag{Name: "semantic-intent", Enabled: false}))
+	flag, err = s.GetFlag("semantic-intent")
+	require.NoError(t, err)
+	assert.False(t, flag.Enabled)
+
+	flags, err := s.ListFlags()
+	require.NoError(t, err)
+	require.Len(t, flags, 2)
+	assert.Equal(t, "auto-execute", flags[0].Name)
+
+	require.NoError(t, s.DeleteFlag("auto-execute"))
+	_, err = s.GetFlag("auto-execute")
+	assert.ErrorIs(t, err, ErrFlagNotFound)
+	assert.ErrorIs(t, s.DeleteFlag("auto-execute"), ErrFlagNotFound)
+}
+
+func TestService_RejectsInvalidFlags(t *testing.T) {
+	s := newTestService()
+	assert.Error(t, s.SetFlag(&Flag{Name: "Bad Name"}))
+	assert.Error(t, s.SetFlag(&Flag{Name: "rollout", RolloutPercent: 150}))
+	assert.Error(t, s.SetFlag(&Flag{Name: "scope", Overrides: []Override{{Scope: "region", Value: "eu"}}}))
+}
+
+func TestService_IsEnabledUsesDefaultForUndefinedFlags(t *testing.T) {
+	s := newTestService()
+	assert.True(t, s.IsEnabled(context.Background(), "missing", true))
+	assert.False(t, s.IsEnabled(context.Background(), "missing", false))
+
+	var nilService *Service
+	assert.True(t, nilService.IsEnabled(context.Background(), "missing", true))
+}
+
+func TestFlag_EvaluateOverridesAndKillSwitch(t *testing.T) {
+	flag := &Flag{
+		Name:           "auto-execute",
+		Enabled:        true,
+		RolloutPercent: 100,
+		Overrides: []Override{
+			{Scope: ScopeTenant, Value: "acme", Enabled: false},
+			{Scope: ScopeConversation, Value: "conv-vip", Enabled: true},
+			{Scope: ScopeAgent, Value: "deployment-agent", Enabled: false},
+		},
+	}
+
+	assert.True(t, flag.Evaluate(EvaluationContext{Tenant: "globex"}))
+	assert.False(t, flag.Evaluate(EvaluationContext{Tenant: "acme"}))
+	assert.True(t, flag.Evaluate(EvaluationContext{Tenant: "acme", ConversationID: "conv-vip"}), "conversation overrides win over tenant")
+	assert.False(t, flag.Evaluate(EvaluationContext{AgentID: "deployment-agent"}))
+
+	flag.Enabled = false
+	assert.False(t, flag.Evaluate(EvaluationContext{ConversationID: "conv-vip"}), "kill switch beats overrides")
+}
+
+func TestFlag_EvaluateRolloutIsStableAndProportional(t *testing.T) {
+	flag := &Flag{Name: "semantic-intent", Enabled: true, RolloutPercent: 10}
+
+	enabled := 0
+	for i := 0; i < 1000; i++ {
+		ec := EvaluationContext{ConversationID: fmt.Sprintf("conv-%d", i)}
+		result := flag.Evaluate(ec)
+		assert.Equal(t, result, flag.Evaluate(ec), "a conversation must get a stable result")
+		if result {
+			enabled++
+		}
+	}
+	assert.InDelta(t, 100, enabled, 40, "roughly 10%% of conversations should be enabled")
+}
+
+func TestEvaluationContextFrom(t *testing.T) {
+	ctx := logging.WithCorrelationID(context.Background(), "corr-1")
+	ctx = logging.WithAgentID(ctx, "policy-agent")
+	ctx = WithTenant(ctx, "acme")
+
+	ec := EvaluationContextFrom(ctx)
+	assert.Equal(t, EvaluationContext{ConversationID: "corr-1", Tenant: "acme", AgentID: "policy-agent"}, ec)
+
+	ec = EvaluationContextFrom(WithConversationID(ctx, "conv-7"))
+	assert.Equal(t, "conv-7", ec.ConversationID)
+}
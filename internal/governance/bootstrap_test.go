@@ -0,0 +1,46 @@
+package governance_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/bootstrap"
+	"github.com/krzachariassen/ZTDP/internal/config"
+	"github.com/krzachariassen/ZTDP/internal/governance"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/graphwatch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The default protected environments must exist on a stock install, or governance guards nothing
+func TestDefaultGovernanceProtectsBootstrappedProduction(t *testing.T) {
+	registry := governance.NewRegistry()
+	require.NoError(t, registry.Register(governance.ProtectedDeployments(config.Default().Governance.ProtectedEnvironments, config.Default().Governance.DecisionTTL)))
+	watch := graphwatch.NewBackend(graph.NewMemoryGraph())
+	governance.Watch(watch, registry)
+	gg := graph.NewGlobalGraph(watch)
+	_, err := bootstrap.NewLoader(gg).LoadDir("../../config/bootstrap")
+	require.NoError(t, err)
+
+	nodes, err := gg.Nodes()
+	require.NoError(t, err)
+	for _, environment := range config.Default().Governance.ProtectedEnvironments {
+		require.Contains(t, nodes, environment, "protected environment %s is not bootstrapped", environment)
+	}
+
+	for _, node := range []*graph.Node{{ID: "checkout", Kind: graph.KindApplication}, {ID: "release-1", Kind: "release"}} {
+		node.Metadata = map[string]interface{}{"name": node.ID}
+		node.Spec = map[string]interface{}{}
+		require.NoError(t, gg.AddNode(node))
+	}
+	current, err := gg.Graph()
+	require.NoError(t, err)
+	current.Edges["release-1"] = append(current.Edges["release-1"], graph.Edge{
+		To: "prod", Type: "deployment",
+		Metadata: map[string]interface{}{"application": "checkout", "status": "in-progress"},
+	})
+	var denied *governance.DeniedError
+	require.True(t, errors.As(gg.Save(), &denied), "a prod deployment without an allowed decision is rejected")
+	assert.Equal(t, "protected-deployments", denied.Policy)
+}
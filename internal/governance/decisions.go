@@ -0,0 +1,216 @@
+package governance
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+)
+
+// Policy decisions
+const (
+	DecisionAllowed = "allowed"
+	DecisionBlocked = "blocked"
+)
+
+// decisionPrefix starts the node IDs of policy decisions
+const decisionPrefix = "policy-decision:"
+
+// decisionKeySize is the size of the key decisions are signed with
+const decisionKeySize = 32
+
+// Decision records the outcome of the deployment gates for a release and environment
+type Decision struct {
+	Application string    `json:"application"`
+	Environment string    `json:"environment"`
+	ReleaseID   string    `json:"release_id"`
+	Decision    string    `json:"decision"` // allowed | blocked
+	Reason      string    `json:"reason,omitempty"`
+	DecidedAt   time.Time `json:"decided_at"`
+	Signature   string    `json:"signature,omitempty"` // set by RecordDecision; nodes without a valid one are not decisions
+}
+
+// Decision nodes are signed so a node saved directly into the graph cannot pass for one the
+// deployment gates recorded. Instances sharing a graph share the key through a key file;
+// without one the key lives until restart.
+var (
+	decisionKeyMu sync.RWMutex
+	decisionKey   = newDecisionKey()
+)
+
+func newDecisionKey() []byte {
+	key := make([]byte, decisionKeySize)
+	if _, err := rand.Read(key); err != nil {
+		panic(fmt.Sprintf("governance: generating decision key: %v", err))
+	}
+	return key
+}
+
+// SetDecisionKey replaces the key decisions are signed and verified with
+func SetDecisionKey(key []byte) {
+	decisionKeyMu.Lock()
+	defer decisionKeyMu.Unlock()
+	decisionKey = append([]byte(nil), key...)
+}
+
+// LoadDecisionKey reads the base64 decision key from path, creating it on first start
+func LoadDecisionKey(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		key := newDecisionKey()
+		if err := os.WriteFile(path, []byte(base64.StdEncoding.EncodeToString(key)+"\n"), 0o600); err != nil {
+			return nil, fmt.Errorf("decision key %s: %w", path, err)
+		}
+		return key, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decision key %s: %w", path, err)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(key) < decisionKeySize {
+		return nil, fmt.Errorf("decision key %s: expected at least %d base64 bytes", path, decisionKeySize)
+	}
+	return key, nil
+}
+
+// sign returns the signature of the decision, ignoring any signature it carries
+func (d Decision) sign() string {
+	d.Signature = ""
+	data, _ := json.Marshal(d)
+	decisionKeyMu.RLock()
+	mac := hmac.New(sha256.New, decisionKey)
+	decisionKeyMu.RUnlock()
+	mac.Write(data)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verified reports whether the decision carries the signature RecordDecision gave it
+func (d Decision) verified() bool {
+	return d.Signature != "" && hmac.Equal([]byte(d.Signature), []byte(d.sign()))
+}
+
+// decisionID is the node ID of the decision for a release in an environment
+func decisionID(environment, releaseID string) string {
+	return decisionPrefix + environment + ":" + releaseID
+}
+
+// RecordDecision signs and stores the decision, replacing an earlier one for the same release
+// and environment
+func RecordDecision(globalGraph *graph.GlobalGraph, decision Decision) error {
+	if decision.Environment == "" || decision.ReleaseID == "" {
+		return fmt.Errorf("environment and release are required to record a policy decision")
+	}
+	if decision.DecidedAt.IsZero() {
+		decision.DecidedAt = time.Now().UTC()
+	}
+	decision.DecidedAt = decision.DecidedAt.Round(0)
+	decision.Signature = decision.sign()
+	data, err := json.Marshal(decision)
+	if err != nil {
+		return err
+	}
+	spec := map[string]interface{}{}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return err
+	}
+	id := decisionID(decision.Environment, decision.ReleaseID)
+	node := &graph.Node{
+		ID:       id,
+		Kind:     graph.KindPolicyDecision,
+		Metadata: map[string]interface{}{"name": id},
+		Spec:     spec,
+	}
+	if existing, err := globalGraph.GetNode(id); err == nil && existing != nil {
+		return globalGraph.UpdateNode(node)
+	}
+	return globalGraph.AddNode(node)
+}
+
+// decisionFromNode decodes a decision node, or returns nil when the node is not one
+func decisionFromNode(node *graph.Node) *Decision {
+	if node == nil || node.Kind != graph.KindPolicyDecision {
+		return nil
+	}
+	data, err := json.Marshal(node.Spec)
+	if err != nil {
+		return nil
+	}
+	var decision Decision
+	if err := json.Unmarshal(data, &decision); err != nil {
+		return nil
+	}
+	return &decision
+}
+
+// LookupDecision returns the decision recorded in g for a release in an environment, or nil
+// when there is none or its signature does not verify
+func LookupDecision(g *graph.Graph, environment, releaseID string) *Decision {
+	decision := decisionFromNode(g.Nodes[decisionID(environment, releaseID)])
+	if decision == nil || !decision.verified() {
+		return nil
+	}
+	return decision
+}
+
+// inactiveStatuses are deployment edge statuses that do not roll anything out, so they need no
+// decision: edges are created pending before the gates run
+var inactiveStatuses = map[string]bool{
+	"": true, "pending": true, "blocked": true, "failed": true, "cancelled": true,
+}
+
+// ProtectedDeployments requires an allowed policy decision before a deploy edge into one of
+// the environments leaves the pending state. The release is the edge's release_id metadata,
+// or its source for deployment edges, which start at the release. The decision must already
+// be stored before the save, and no older than ttl (0 never expires). Decision nodes that were
+// not signed by RecordDecision are denied.
+func ProtectedDeployments(environments []string, ttl time.Duration) Policy {
+	protected := map[string]bool{}
+	for _, environment := range environments {
+		protected[environment] = true
+	}
+	return Policy{
+		Name:        "protected-deployments",
+		Description: fmt.Sprintf("Deployments into %s need an allowed policy decision for the release before they roll out", strings.Join(environments, ", ")),
+		Check: func(m Mutation) string {
+			if m.Node != nil && m.Node.Kind == graph.KindPolicyDecision {
+				if decision := decisionFromNode(m.Node); decision == nil || !decision.verified() {
+					return "policy decisions can only be recorded by the deployment gates"
+				}
+				return ""
+			}
+			if m.Edge == nil || !protected[m.Edge.To] {
+				return ""
+			}
+			if m.Edge.Type != graph.EdgeTypeDeploy && m.Edge.Type != "deployment" {
+				return ""
+			}
+			status, _ := m.Edge.Metadata["status"].(string)
+			if inactiveStatuses[status] {
+				return ""
+			}
+			releaseID, _ := m.Edge.Metadata["release_id"].(string)
+			if releaseID == "" {
+				releaseID = m.ID
+			}
+			decision := LookupDecision(m.Previous, m.Edge.To, releaseID)
+			switch {
+			case decision == nil:
+				return fmt.Sprintf("no policy decision recorded for %s in %s", releaseID, m.Edge.To)
+			case decision.Decision != DecisionAllowed:
+				return fmt.Sprintf("%s was %s in %s: %s", releaseID, decision.Decision, m.Edge.To, decision.Reason)
+			case ttl > 0 && time.Since(decision.DecidedAt) > ttl:
+				return fmt.Sprintf("the policy decision for %s in %s expired at %s", releaseID, m.Edge.To, decision.DecidedAt.Add(ttl).Format(time.RFC3339))
+			}
+			return ""
+		},
+	}
+}
@@ -0,0 +1,153 @@
+// Package governance enforces mutation policies on every graph save. Agents, handlers and
+// services change the graph in many ways (AddNode, AddEdge, or editing the loaded graph and
+// saving it), so the policies are evaluated by a check on the graph watch that sees every save
+// rather than by callers remembering to check them.
+package governance
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/graphwatch"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// Mutation is a node or edge a save adds or updates
+type Mutation struct {
+	graphwatch.Change
+	Node  *graph.Node  // node changes: the node as it would be saved
+	Edge  *graph.Edge  // edge changes: the edge as it would be saved
+	Graph *graph.Graph // the graph as it would be saved; policies must not modify it
+
+	// Previous is the graph as stored before the save, for policies that must not trust what
+	// the same save adds
+	Previous *graph.Graph
+}
+
+// Policy is a rule every mutation must pass
+type Policy struct {
+	Name        string                  `json:"name"`
+	Description string                  `json:"description"`
+	Check       func(m Mutation) string `json:"-"` // why the mutation is denied, or "" to allow it
+}
+
+// DeniedError is returned when a policy denies a mutation; nothing of the save is stored
+type DeniedError struct {
+	Policy string
+	Change graphwatch.Change
+	Reason string
+}
+
+func (e *DeniedError) Error() string {
+	target := e.Change.ID
+	if e.Change.Kind == graphwatch.KindEdge {
+		target = fmt.Sprintf("%s -[%s]-> %s", e.Change.ID, e.Change.Type, e.Change.To)
+	}
+	return fmt.Sprintf("policy %s denied %s %s %s: %s", e.Policy, e.Change.Op, e.Change.Kind, target, e.Reason)
+}
+
+// Registry holds the mutation policies by name
+type Registry struct {
+	logger *logging.Logger
+
+	mu       sync.RWMutex
+	policies map[string]Policy
+	denied   map[string]int // by policy: mutations denied since startup
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		logger:   logging.GetLogger().ForComponent("governance"),
+		policies: map[string]Policy{},
+		denied:   map[string]int{},
+	}
+}
+
+// Register adds a policy, replacing one with the same name
+func (r *Registry) Register(policy Policy) error {
+	if policy.Name == "" {
+		return fmt.Errorf("policy name is required")
+	}
+	if policy.Check == nil {
+		return fmt.Errorf("policy %s has no check", policy.Name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.policies[policy.Name] = policy
+	return nil
+}
+
+// PolicyStatus describes a registered policy and how often it denied a mutation
+type PolicyStatus struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Denied      int    `json:"denied"`
+}
+
+// Policies returns the registered policies by name
+func (r *Registry) Policies() []PolicyStatus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]PolicyStatus, 0, len(r.policies))
+	for _, policy := range r.policies {
+		list = append(list, PolicyStatus{Name: policy.Name, Description: policy.Description, Denied: r.denied[policy.Name]})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// evaluate returns the first denial of the mutation, checking policies by name
+func (r *Registry) evaluate(m Mutation) *DeniedError {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	names := make([]string, 0, len(r.policies))
+	for name := range r.policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if reason := r.policies[name].Check(m); reason != "" {
+			r.denied[name]++
+			return &DeniedError{Policy: name, Change: m.Change, Reason: reason}
+		}
+	}
+	return nil
+}
+
+func (r *Registry) empty() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.policies) == 0
+}
+
+// Watch evaluates the registered policies for every node and edge a save on watch adds or
+// updates. A denied save is rejected as a whole, so the stored graph stays as it was.
+func Watch(watch *graphwatch.Backend, registry *Registry) {
+	watch.AddCheck(registry.check)
+}
+
+// check evaluates every added or updated node and edge; removals are not governed
+func (r *Registry) check(save graphwatch.Save) error {
+	if r.empty() {
+		return nil
+	}
+	for _, change := range save.Changes {
+		if change.Op == graphwatch.OpRemoved {
+			continue
+		}
+		m := Mutation{Change: change, Graph: save.Graph, Previous: save.Previous}
+		if change.Kind == graphwatch.KindNode {
+			m.Node = save.Node(change)
+		} else {
+			m.Edge = save.Edge(change)
+		}
+		if denied := r.evaluate(m); denied != nil {
+			r.logger.Warn("⛔ %v", denied)
+			return denied
+		}
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package governance
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/graphwatch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newWatch returns a graph backend whose saves are governed by registry
+func newWatch(registry *Registry) *graphwatch.Backend {
+	watch := graphwatch.NewBackend(graph.NewMemoryGraph())
+	Watch(watch, registry)
+	return watch
+}
+
+func newTestGraph(t *testing.T) (*graph.GlobalGraph, *Registry) {
+	t.Helper()
+	registry := NewRegistry()
+	require.NoError(t, registry.Register(ProtectedDeployments([]string{"production"}, time.Hour)))
+	gg := graph.NewGlobalGraph(newWatch(registry))
+	for _, node := range []*graph.Node{
+		{ID: "checkout", Kind: graph.KindApplication},
+		{ID: "release-1", Kind: "release"},
+		{ID: "staging", Kind: graph.KindEnvironment},
+		{ID: "production", Kind: graph.KindEnvironment},
+	} {
+		node.Metadata = map[string]interface{}{"name": node.ID}
+		node.Spec = map[string]interface{}{}
+		require.NoError(t, gg.AddNode(node))
+	}
+	return gg, registry
+}
+
+// deploy adds a deployment edge by editing the loaded graph and saving it, as the deployment
+// agent does
+func deploy(gg *graph.GlobalGraph, environment, status string) error {
+	current, err := gg.Graph()
+	if err != nil {
+		return err
+	}
+	current.Edges["release-1"] = append(current.Edges["release-1"], graph.Edge{
+		To: environment, Type: "deployment",
+		Metadata: map[string]interface{}{"application": "checkout", "status": status},
+	})
+	return gg.Save()
+}
+
+func TestProtectedDeploymentsNeedAnAllowedDecision(t *testing.T) {
+	gg, registry := newTestGraph(t)
+
+	err := deploy(gg, "production", "in-progress")
+	var denied *DeniedError
+	require.True(t, errors.As(err, &denied), "got %v", err)
+	assert.Equal(t, "protected-deployments", denied.Policy)
+	assert.Contains(t, err.Error(), "no policy decision recorded for release-1 in production")
+	edges, err := gg.Edges()
+	require.NoError(t, err)
+	assert.Empty(t, edges["release-1"], "the denied save is not kept")
+
+	require.NoError(t, deploy(gg, "staging", "in-progress"), "unprotected environments are not governed")
+	require.NoError(t, RecordDecision(gg, Decision{Application: "checkout", Environment: "production", ReleaseID: "release-1", Decision: DecisionBlocked, Reason: "change freeze"}))
+	assert.ErrorContains(t, deploy(gg, "production", "succeeded"), "release-1 was blocked in production: change freeze")
+
+	require.NoError(t, RecordDecision(gg, Decision{Application: "checkout", Environment: "production", ReleaseID: "release-1", Decision: DecisionAllowed}))
+	require.NoError(t, deploy(gg, "production", "in-progress"))
+	assert.Equal(t, []PolicyStatus{{
+		Name:        "protected-deployments",
+		Description: "Deployments into production need an allowed policy decision for the release before they roll out",
+		Denied:      2,
+	}}, registry.Policies())
+}
+
+func TestProtectedDeploymentsAllowPendingEdges(t *testing.T) {
+	gg, _ := newTestGraph(t)
+
+	// Deployments are recorded as pending before the gates run, and may fail without a decision
+	require.NoError(t, deploy(gg, "production", "pending"))
+	current, err := gg.Graph()
+	require.NoError(t, err)
+	current.Edges["release-1"][0].Metadata["status"] = "failed"
+	require.NoError(t, gg.Save())
+
+	current.Edges["release-1"][0].Metadata["status"] = "in-progress"
+	assert.Error(t, gg.Save(), "an edge updated to roll out needs a decision too")
+
+	// Deploy edges name their release in metadata when they do not start at it
+	current, err = gg.Graph()
+	require.NoError(t, err)
+	current.Edges["checkout"] = append(current.Edges["checkout"], graph.Edge{
+		To: "production", Type: graph.EdgeTypeDeploy,
+		Metadata: map[string]interface{}{"status": "succeeded", "release_id": "model:1"},
+	})
+	assert.ErrorContains(t, gg.Save(), "no policy decision recorded for model:1 in production")
+}
+
+func TestProtectedDeploymentsRejectForgedDecisions(t *testing.T) {
+	gg, _ := newTestGraph(t)
+
+	// A decision node saved directly is denied, even in the save that rolls the release out
+	current, err := gg.Graph()
+	require.NoError(t, err)
+	current.Nodes[decisionID("production", "release-1")] = &graph.Node{
+		ID: decisionID("production", "release-1"), Kind: graph.KindPolicyDecision,
+		Metadata: map[string]interface{}{"name": "forged"},
+		Spec:     map[string]interface{}{"environment": "production", "release_id": "release-1", "decision": DecisionAllowed, "decided_at": time.Now().UTC(), "signature": "forged"},
+	}
+	assert.ErrorContains(t, gg.Save(), "policy decisions can only be recorded by the deployment gates")
+
+	// Changing a recorded decision invalidates its signature
+	require.NoError(t, RecordDecision(gg, Decision{Application: "checkout", Environment: "production", ReleaseID: "release-1", Decision: DecisionBlocked, Reason: "change freeze"}))
+	current, err = gg.Graph()
+	require.NoError(t, err)
+	current.Nodes[decisionID("production", "release-1")].Spec["decision"] = DecisionAllowed
+	assert.ErrorContains(t, gg.Save(), "policy decisions can only be recorded by the deployment gates")
+	current, err = gg.Graph()
+	require.NoError(t, err)
+	assert.Equal(t, DecisionBlocked, LookupDecision(current, "production", "release-1").Decision)
+}
+
+func TestProtectedDeploymentsDecisionExpires(t *testing.T) {
+	gg, _ := newTestGraph(t)
+
+	require.NoError(t, RecordDecision(gg, Decision{Application: "checkout", Environment: "production", ReleaseID: "release-1", Decision: DecisionAllowed, DecidedAt: time.Now().Add(-2 * time.Hour)}))
+	assert.ErrorContains(t, deploy(gg, "production", "in-progress"), "the policy decision for release-1 in production expired")
+
+	require.NoError(t, RecordDecision(gg, Decision{Application: "checkout", Environment: "production", ReleaseID: "release-1", Decision: DecisionAllowed}))
+	require.NoError(t, deploy(gg, "production", "in-progress"))
+}
+
+func TestRegistryRegister(t *testing.T) {
+	registry := NewRegistry()
+	assert.Error(t, registry.Register(Policy{Check: func(Mutation) string { return "" }}))
+	assert.Error(t, registry.Register(Policy{Name: "no-check"}))
+
+	// Custom policies see node mutations with the node as it would be saved
+	require.NoError(t, registry.Register(Policy{Name: "owned-applications", Check: func(m Mutation) string {
+		if m.Node != nil && m.Node.Kind == graph.KindApplication && m.Node.Metadata["owner"] == nil {
+			return "applications need an owner"
+		}
+		return ""
+	}}))
+	gg := graph.NewGlobalGraph(newWatch(registry))
+	err := gg.AddNode(&graph.Node{ID: "checkout", Kind: graph.KindApplication, Metadata: map[string]interface{}{"name": "checkout"}})
+	assert.EqualError(t, err, "policy owned-applications denied added node checkout: applications need an owner")
+	assert.NoError(t, gg.AddNode(&graph.Node{ID: "checkout", Kind: graph.KindApplication, Metadata: map[string]interface{}{"name": "checkout", "owner": "payments"}}))
+}
@@ -1,5 +1,7 @@
 package graph
 
+import "context"
+
 type GraphBackend interface {
 	// Global graph operations (the only storage mechanism)
 	SaveGlobal(g *Graph) error
@@ -8,3 +10,22 @@ type GraphBackend interface {
 	// Clear removes all global data (useful for testing)
 	Clear() error
 }
+
+// Pinger is implemented by backends that depend on an external store and can verify connectivity
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// ContextSaver is implemented by backends that pass the context a save was made in on to what
+// watches the graph, e.g. to attribute the change to the request that made it
+type ContextSaver interface {
+	SaveGlobalContext(ctx context.Context, g *Graph) error
+}
+
+// SaveWithContext saves g through backend, passing ctx on when the backend accepts it
+func SaveWithContext(ctx context.Context, backend GraphBackend, g *Graph) error {
+	if saver, ok := backend.(ContextSaver); ok && ctx != nil {
+		return saver.SaveGlobalContext(ctx, g)
+	}
+	return backend.SaveGlobal(g)
+}
@@ -49,6 +49,11 @@ func NewRedisGraph(cfg RedisGraphConfig) GraphBackend {
 	panic(fmt.Errorf("failed to connect to Redis after 3 attempts: %w", err))
 }
 
+// Ping verifies the Redis connection
+func (r *redisGraph) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
 // Global graph persistence - the only storage mechanism
 func (r *redisGraph) SaveGlobal(g *Graph) error {
 	data, err := json.Marshal(g)
@@ -40,11 +40,7 @@ func TestGlobalGraph_Apply_RedisBackend(t *testing.T) {
 			Name:  "checkout-api",
 			Owner: "team-x",
 		},
-		Spec: struct {
-			Application string `json:"application"`
-			Port        int    `json:"port"`
-			Public      bool   `json:"public"`
-		}{
+		Spec: contracts.ServiceSpec{
 			Application: "checkout",
 			Port:        8080,
 			Public:      true,
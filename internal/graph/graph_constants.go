@@ -17,20 +17,50 @@ const (
 	KindPolicy           = common.KindPolicy
 	KindCheck            = common.KindCheck
 	KindProcess          = common.KindProcess
+	KindFeatureFlag      = common.KindFeatureFlag
+	KindConversation     = common.KindConversation
+	KindPlan             = common.KindPlan
+	KindQuota            = common.KindQuota
+	KindSavedSearch      = common.KindSavedSearch
+	KindCheckpoint       = common.KindCheckpoint
+	KindAIDecision       = common.KindAIDecision
+	KindCalendarEntry    = common.KindCalendarEntry
+	KindArchiveBatch     = common.KindArchiveBatch
+	KindNodeKind         = common.KindNodeKind
+	KindMLModel          = common.KindMLModel
+	KindModelVersion     = common.KindModelVersion
+	KindModelEndpoint    = common.KindModelEndpoint
+	KindMigration        = common.KindMigration
+	KindTopic            = common.KindTopic
+	KindRunbook          = common.KindRunbook
+	KindDRDrill          = common.KindDRDrill
+	KindOrganization     = common.KindOrganization
+	KindTeam             = common.KindTeam
+	KindProject          = common.KindProject
+	KindAutonomy         = common.KindAutonomy
+	KindRoutingOverride  = common.KindRoutingOverride
+	KindWorkflow         = common.KindWorkflow
+	KindPolicyDecision   = common.KindPolicyDecision
+	KindReleaseBundle    = common.KindReleaseBundle
 
 	// Edge types
-	EdgeTypeOwns       = common.EdgeTypeOwns
-	EdgeTypeHasVersion = common.EdgeTypeHasVersion
-	EdgeTypeDeploy     = common.EdgeTypeDeploy
-	EdgeTypeCreate     = "create"
-	EdgeTypeUses       = common.EdgeTypeUses
-	EdgeTypeInstanceOf = common.EdgeTypeInstanceOf
-	EdgeTypeRequires   = common.EdgeTypeRequires
-	EdgeTypeSatisfies  = common.EdgeTypeSatisfies
-	EdgeTypeAccesses   = "accesses"
-	EdgeTypeConnectsTo = "connects_to"
-	EdgeTypeDependsOn  = "depends_on"
-	EdgeTypeIncludes   = "includes"
+	EdgeTypeOwns         = common.EdgeTypeOwns
+	EdgeTypeHasVersion   = common.EdgeTypeHasVersion
+	EdgeTypeDeploy       = common.EdgeTypeDeploy
+	EdgeTypeCreate       = "create"
+	EdgeTypeUses         = common.EdgeTypeUses
+	EdgeTypeInstanceOf   = common.EdgeTypeInstanceOf
+	EdgeTypeRequires     = common.EdgeTypeRequires
+	EdgeTypeSatisfies    = common.EdgeTypeSatisfies
+	EdgeTypeAccesses     = "accesses"
+	EdgeTypeConnectsTo   = "connects_to"
+	EdgeTypeDependsOn    = "depends_on"
+	EdgeTypeIncludes     = "includes"
+	EdgeTypeReferences   = "references"
+	EdgeTypeConsumes     = "consumes"
+	EdgeTypeServes       = "serves"
+	EdgeTypeHasMigration = "has_migration"
+	EdgeTypeProduces     = "produces"
 
 	// Policy types
 	PolicyTypeCheck    = common.PolicyTypeCheck
@@ -46,19 +76,24 @@ const (
 
 // Allowed edge types for the platform
 var AllowedEdgeTypes = map[string]struct{}{
-	EdgeTypeOwns:       {},
-	EdgeTypeHasVersion: {},
-	EdgeTypeDeploy:     {},
-	EdgeTypeCreate:     {},
-	EdgeTypeUses:       {},
-	EdgeTypeInstanceOf: {},
-	EdgeTypeRequires:   {},
-	EdgeTypeSatisfies:  {},
-	EdgeTypeAccesses:   {},
-	EdgeTypeConnectsTo: {},
-	EdgeTypeDependsOn:  {},
-	EdgeTypeIncludes:   {},
-	"allowed_in":       {}, // Policy edge type for environment access
+	EdgeTypeOwns:         {},
+	EdgeTypeHasVersion:   {},
+	EdgeTypeDeploy:       {},
+	EdgeTypeCreate:       {},
+	EdgeTypeUses:         {},
+	EdgeTypeInstanceOf:   {},
+	EdgeTypeRequires:     {},
+	EdgeTypeSatisfies:    {},
+	EdgeTypeAccesses:     {},
+	EdgeTypeConnectsTo:   {},
+	EdgeTypeDependsOn:    {},
+	EdgeTypeIncludes:     {},
+	EdgeTypeReferences:   {},
+	EdgeTypeConsumes:     {},
+	EdgeTypeServes:       {},
+	EdgeTypeHasMigration: {},
+	EdgeTypeProduces:     {},
+	"allowed_in":         {}, // Policy edge type for environment access
 	// Add more as needed
 }
 
@@ -0,0 +1,247 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Edge metadata weighing dependency edges
+const (
+	EdgeCriticalityKey = "criticality" // low | medium | high | critical
+	EdgeWeightKey      = "weight"      // 0 < weight <= 1; overrides the criticality's weight
+)
+
+// Dependency criticalities
+const (
+	CriticalityLow      = "low"
+	CriticalityMedium   = "medium"
+	CriticalityHigh     = "high"
+	CriticalityCritical = "critical"
+)
+
+// criticalityWeights are the weights of edges that declare a criticality but no weight;
+// edges declaring neither weigh as medium
+var criticalityWeights = map[string]float64{
+	CriticalityLow:      0.25,
+	CriticalityMedium:   0.5,
+	CriticalityHigh:     0.75,
+	CriticalityCritical: 1,
+}
+
+// Traversal directions
+const (
+	DependencyDirectionOut = "out" // what the node depends on
+	DependencyDirectionIn  = "in"  // what depends on the node
+)
+
+// Traversal bounds used when none are given
+const (
+	DefaultDependencyDepth = 5
+	DefaultDependencyLimit = 20
+)
+
+// ErrDependencyNodeNotFound is returned when traversing from a node that is not in the graph
+var ErrDependencyNodeNotFound = errors.New("node not found")
+
+// IsDependencyEdge reports whether the edge type carries a dependency that can be weighed
+func IsDependencyEdge(edgeType string) bool {
+	return edgeType == EdgeTypeDependsOn || edgeType == EdgeTypeUses
+}
+
+// ValidateEdgeCriticality checks a criticality and weight before they are set on an edge;
+// either may be empty or zero
+func ValidateEdgeCriticality(criticality string, weight float64) error {
+	if _, ok := criticalityWeights[criticality]; criticality != "" && !ok {
+		return fmt.Errorf("criticality must be low, medium, high or critical, got %q", criticality)
+	}
+	if weight < 0 || weight > 1 {
+		return fmt.Errorf("weight must be between 0 and 1, got %v", weight)
+	}
+	return nil
+}
+
+// EdgeWeight returns how strongly the source of a dependency edge relies on its target: the
+// edge's weight, else its criticality's weight, else the medium weight
+func EdgeWeight(edge Edge) float64 {
+	if weight, ok := edge.Metadata[EdgeWeightKey].(float64); ok && weight > 0 && weight <= 1 {
+		return weight
+	}
+	if criticality, ok := edge.Metadata[EdgeCriticalityKey].(string); ok {
+		if weight, known := criticalityWeights[criticality]; known {
+			return weight
+		}
+	}
+	return criticalityWeights[CriticalityMedium]
+}
+
+// DependencyHop is one dependency edge of a path
+type DependencyHop struct {
+	From        string  `json:"from"`
+	To          string  `json:"to"`
+	Type        string  `json:"type"`
+	Criticality string  `json:"criticality,omitempty"`
+	Weight      float64 `json:"weight"`
+}
+
+// DependencyPath is the most critical dependency chain between the start node and Target.
+// Its criticality is the product of its edges' weights, so a chain is weaker than its links
+// and long chains of weak dependencies rank last.
+type DependencyPath struct {
+	Target      string          `json:"target"`
+	Kind        string          `json:"kind"`
+	Path        []string        `json:"path"` // node IDs from the start node, in traversal order
+	Hops        []DependencyHop `json:"hops"` // edges in their stored direction
+	Criticality float64         `json:"criticality"`
+}
+
+// DependencyPaths returns, for every node reachable from start over depends_on and uses edges
+// within depth hops, the most critical path to it, most critical first. Direction out follows
+// what start depends on, e.g. to troubleshoot it; in follows what depends on start, e.g. to
+// predict the impact of changing it. A depth or limit of 0 uses the default.
+func (g *Graph) DependencyPaths(start, direction string, depth, limit int) ([]DependencyPath, error) {
+	if _, ok := g.Nodes[start]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrDependencyNodeNotFound, start)
+	}
+	if direction == "" {
+		direction = DependencyDirectionOut
+	}
+	if direction != DependencyDirectionOut && direction != DependencyDirectionIn {
+		return nil, fmt.Errorf("direction must be %s or %s", DependencyDirectionOut, DependencyDirectionIn)
+	}
+	if depth <= 0 {
+		depth = DefaultDependencyDepth
+	}
+	if limit <= 0 {
+		limit = DefaultDependencyLimit
+	}
+
+	// neighbours lists the hops leaving each node in the traversal direction
+	neighbours := map[string][]DependencyHop{}
+	for from, edges := range g.Edges {
+		for _, edge := range edges {
+			if !IsDependencyEdge(edge.Type) {
+				continue
+			}
+			if _, ok := g.Nodes[edge.To]; !ok {
+				continue
+			}
+			criticality, _ := edge.Metadata[EdgeCriticalityKey].(string)
+			hop := DependencyHop{From: from, To: edge.To, Type: edge.Type, Criticality: criticality, Weight: EdgeWeight(edge)}
+			if direction == DependencyDirectionOut {
+				neighbours[from] = append(neighbours[from], hop)
+			} else {
+				neighbours[edge.To] = append(neighbours[edge.To], hop)
+			}
+		}
+	}
+
+	// Weights are at most 1, so extending a path never makes it more critical: relaxing depth
+	// times finds the most critical path of at most depth hops to every node
+	best := map[string]DependencyPath{start: {Target: start, Path: []string{start}, Criticality: 1}}
+	for round := 0; round < depth; round++ {
+		next := make(map[string]DependencyPath, len(best))
+		for id, path := range best {
+			next[id] = path
+		}
+		changed := false
+		for id, path := range best {
+			for _, hop := range neighbours[id] {
+				target := hop.To
+				if direction == DependencyDirectionIn {
+					target = hop.From
+				}
+				if containsString(path.Path, target) {
+					continue
+				}
+				criticality := path.Criticality * hop.Weight
+				current, seen := next[target]
+				if seen && (current.Criticality > criticality || (current.Criticality == criticality && len(current.Path) <= len(path.Path)+1)) {
+					continue
+				}
+				next[target] = DependencyPath{
+					Target:      target,
+					Path:        append(append([]string(nil), path.Path...), target),
+					Hops:        append(append([]DependencyHop(nil), path.Hops...), hop),
+					Criticality: criticality,
+				}
+				changed = true
+			}
+		}
+		best = next
+		if !changed {
+			break
+		}
+	}
+
+	paths := make([]DependencyPath, 0, len(best))
+	for id, path := range best {
+		if id == start {
+			continue
+		}
+		path.Kind = g.Nodes[id].Kind
+		paths = append(paths, path)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		a, b := paths[i], paths[j]
+		if a.Criticality != b.Criticality {
+			return a.Criticality > b.Criticality
+		}
+		if len(a.Path) != len(b.Path) {
+			return len(a.Path) < len(b.Path)
+		}
+		return a.Target < b.Target
+	})
+	if len(paths) > limit {
+		paths = paths[:limit]
+	}
+	return paths, nil
+}
+
+// SetEdgeCriticality sets the criticality and weight of a dependency edge; an empty
+// criticality or zero weight removes it from the edge
+func (gg *GlobalGraph) SetEdgeCriticality(fromID, toID, edgeType, criticality string, weight float64) error {
+	if !IsDependencyEdge(edgeType) {
+		return fmt.Errorf("only %s and %s edges carry a criticality", EdgeTypeDependsOn, EdgeTypeUses)
+	}
+	if err := ValidateEdgeCriticality(criticality, weight); err != nil {
+		return err
+	}
+
+	gg.mutex().Lock()
+	defer gg.mutex().Unlock()
+
+	currentGraph, err := gg.Backend.LoadGlobal()
+	if err != nil {
+		return err
+	}
+	for i, edge := range currentGraph.Edges[fromID] {
+		if edge.To != toID || edge.Type != edgeType {
+			continue
+		}
+		metadata := make(map[string]interface{}, len(edge.Metadata)+2)
+		for k, v := range edge.Metadata {
+			metadata[k] = v
+		}
+		delete(metadata, EdgeCriticalityKey)
+		delete(metadata, EdgeWeightKey)
+		if criticality != "" {
+			metadata[EdgeCriticalityKey] = criticality
+		}
+		if weight > 0 {
+			metadata[EdgeWeightKey] = weight
+		}
+		currentGraph.Edges[fromID][i].Metadata = metadata
+		return gg.save(currentGraph)
+	}
+	return fmt.Errorf("edge %s -[%s]-> %s not found", fromID, edgeType, toID)
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
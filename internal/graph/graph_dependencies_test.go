@@ -0,0 +1,80 @@
+package graph
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newDependencyGraph stores checkout-api relying critically on payments-api, which uses the
+// payments database, and loosely on a cache; search-api depends on checkout-api
+func newDependencyGraph(t *testing.T) *GlobalGraph {
+	t.Helper()
+	g := NewGraph()
+	for id, kind := range map[string]string{"checkout-api": KindService, "payments-api": KindService, "search-api": KindService, "payments-db": KindResource, "cache": KindResource} {
+		g.Nodes[id] = &Node{ID: id, Kind: kind, Metadata: map[string]interface{}{"name": id}}
+	}
+	g.Edges["checkout-api"] = []Edge{
+		{To: "payments-api", Type: EdgeTypeDependsOn, Metadata: map[string]interface{}{EdgeCriticalityKey: CriticalityCritical}},
+		{To: "cache", Type: EdgeTypeUses, Metadata: map[string]interface{}{EdgeCriticalityKey: CriticalityLow}},
+	}
+	g.Edges["payments-api"] = []Edge{{To: "payments-db", Type: EdgeTypeUses, Metadata: map[string]interface{}{EdgeWeightKey: 0.9}}}
+	g.Edges["search-api"] = []Edge{{To: "checkout-api", Type: EdgeTypeDependsOn}}
+	gg := NewGlobalGraph(NewMemoryGraph())
+	require.NoError(t, gg.Backend.SaveGlobal(g))
+	return gg
+}
+
+func TestDependencyPathsRankByCriticality(t *testing.T) {
+	gg := newDependencyGraph(t)
+	g, err := gg.Graph()
+	require.NoError(t, err)
+
+	paths, err := g.DependencyPaths("checkout-api", DependencyDirectionOut, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, paths, 3)
+	assert.Equal(t, []string{"checkout-api", "payments-api"}, paths[0].Path)
+	assert.Equal(t, 1.0, paths[0].Criticality)
+	assert.Equal(t, []string{"checkout-api", "payments-api", "payments-db"}, paths[1].Path)
+	assert.InDelta(t, 0.9, paths[1].Criticality, 1e-9, "the chain is as critical as the product of its links")
+	assert.Equal(t, "cache", paths[2].Target)
+	assert.Equal(t, KindResource, paths[2].Kind)
+
+	// Impact runs against the edges: search-api depends on checkout-api with the default weight
+	paths, err = g.DependencyPaths("payments-db", DependencyDirectionIn, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, paths, 3)
+	assert.Equal(t, []string{"payments-api", "checkout-api", "search-api"}, []string{paths[0].Target, paths[1].Target, paths[2].Target})
+	assert.InDelta(t, 0.45, paths[2].Criticality, 1e-9)
+	assert.Equal(t, DependencyHop{From: "search-api", To: "checkout-api", Type: EdgeTypeDependsOn, Weight: 0.5}, paths[2].Hops[2])
+
+	paths, err = g.DependencyPaths("payments-db", DependencyDirectionIn, 1, 0)
+	require.NoError(t, err)
+	assert.Len(t, paths, 1, "depth bounds the chains")
+
+	_, err = g.DependencyPaths("unknown", DependencyDirectionOut, 0, 0)
+	assert.True(t, errors.Is(err, ErrDependencyNodeNotFound))
+	_, err = g.DependencyPaths("checkout-api", "sideways", 0, 0)
+	assert.Error(t, err)
+}
+
+func TestSetEdgeCriticality(t *testing.T) {
+	gg := newDependencyGraph(t)
+
+	require.NoError(t, gg.SetEdgeCriticality("search-api", "checkout-api", EdgeTypeDependsOn, CriticalityHigh, 0))
+	edges, err := gg.Edges()
+	require.NoError(t, err)
+	assert.Equal(t, 0.75, EdgeWeight(edges["search-api"][0]))
+
+	require.NoError(t, gg.SetEdgeCriticality("checkout-api", "cache", EdgeTypeUses, "", 0.3))
+	edges, err = gg.Edges()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{EdgeWeightKey: 0.3}, edges["checkout-api"][1].Metadata, "the previous criticality is replaced")
+
+	assert.Error(t, gg.SetEdgeCriticality("search-api", "checkout-api", EdgeTypeDependsOn, "urgent", 0))
+	assert.Error(t, gg.SetEdgeCriticality("search-api", "checkout-api", EdgeTypeDependsOn, "", 2))
+	assert.Error(t, gg.SetEdgeCriticality("search-api", "checkout-api", EdgeTypeOwns, CriticalityHigh, 0))
+	assert.Error(t, gg.SetEdgeCriticality("search-api", "payments-api", EdgeTypeDependsOn, CriticalityHigh, 0))
+}
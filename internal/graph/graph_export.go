@@ -0,0 +1,153 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Export formats
+const (
+	ExportMermaid = "mermaid"
+	ExportDOT     = "dot"
+)
+
+// ApplicationSubgraph returns the application, the nodes it owns (directly or through owned
+// nodes) and every node those point at, such as environments, resource types and services of
+// other applications. Nodes outside the application appear as leaves; their own edges are left out.
+func (g *Graph) ApplicationSubgraph(app string) (*Graph, error) {
+	root, ok := g.Nodes[app]
+	if !ok || root.Kind != KindApplication {
+		return nil, fmt.Errorf("application %s not found", app)
+	}
+
+	sub := NewGraph()
+	sub.Nodes[app] = root
+	owned := map[string]bool{app: true}
+	queue := []string{app}
+	for len(queue) > 0 {
+		from := queue[0]
+		queue = queue[1:]
+		seen := map[[2]string]bool{} // repeated deployments leave several deploy edges to one environment
+		for _, edge := range g.Edges[from] {
+			to, ok := g.Nodes[edge.To]
+			if !ok || seen[[2]string{edge.To, edge.Type}] {
+				continue
+			}
+			seen[[2]string{edge.To, edge.Type}] = true
+			sub.Edges[from] = append(sub.Edges[from], edge)
+			sub.Nodes[to.ID] = to
+			if edge.Type == EdgeTypeOwns && !owned[to.ID] {
+				owned[to.ID] = true
+				queue = append(queue, to.ID)
+			}
+		}
+	}
+	return sub, nil
+}
+
+// Export renders g in the given format
+func (g *Graph) Export(format, title string) (string, error) {
+	switch format {
+	case ExportMermaid:
+		return g.mermaid(), nil
+	case ExportDOT:
+		return g.dot(title), nil
+	default:
+		return "", fmt.Errorf("unsupported export format %q (expected mermaid or dot)", format)
+	}
+}
+
+// sortedIDs returns the node IDs in a stable order so exports diff cleanly between runs
+func (g *Graph) sortedIDs() []string {
+	ids := make([]string, 0, len(g.Nodes))
+	for id := range g.Nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// sortedEdges returns the edges of from ordered by target and type
+func (g *Graph) sortedEdges(from string) []Edge {
+	edges := append([]Edge(nil), g.Edges[from]...)
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].To != edges[j].To {
+			return edges[i].To < edges[j].To
+		}
+		return edges[i].Type < edges[j].Type
+	})
+	return edges
+}
+
+// mermaidShapes wraps a label in the Mermaid shape for a node kind
+var mermaidShapes = map[string][2]string{
+	KindApplication:  {"[[", "]]"},
+	KindEnvironment:  {"([", "])"},
+	KindResource:     {"[(", ")]"},
+	KindResourceType: {"[/", "/]"},
+	KindPolicy:       {"{{", "}}"},
+}
+
+func (g *Graph) mermaid() string {
+	ids := g.sortedIDs()
+	alias := make(map[string]string, len(ids))
+	for i, id := range ids {
+		alias[id] = fmt.Sprintf("n%d", i)
+	}
+	escape := func(s string) string { return strings.ReplaceAll(s, `"`, "#quot;") }
+
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for _, id := range ids {
+		node := g.Nodes[id]
+		shape, ok := mermaidShapes[node.Kind]
+		if !ok {
+			shape = [2]string{"[", "]"}
+		}
+		fmt.Fprintf(&b, "  %s%s\"%s<br/><small>%s</small>\"%s\n", alias[id], shape[0], escape(id), escape(node.Kind), shape[1])
+	}
+	for _, from := range ids {
+		for _, edge := range g.sortedEdges(from) {
+			if to, ok := alias[edge.To]; ok {
+				fmt.Fprintf(&b, "  %s -->|%s| %s\n", alias[from], escape(edge.Type), to)
+			}
+		}
+	}
+	return b.String()
+}
+
+// dotShapes is the Graphviz shape for a node kind
+var dotShapes = map[string]string{
+	KindApplication:  "box3d",
+	KindEnvironment:  "ellipse",
+	KindResource:     "cylinder",
+	KindResourceType: "parallelogram",
+	KindPolicy:       "hexagon",
+}
+
+func (g *Graph) dot(title string) string {
+	escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace
+	quote := func(s string) string { return `"` + escape(s) + `"` }
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n  rankdir=LR;\n  node [fontname=\"Helvetica\"];\n", quote(title))
+	ids := g.sortedIDs()
+	for _, id := range ids {
+		node := g.Nodes[id]
+		shape, ok := dotShapes[node.Kind]
+		if !ok {
+			shape = "box"
+		}
+		fmt.Fprintf(&b, "  %s [label=%s, shape=%s];\n", quote(id), `"`+escape(id)+`\n(`+escape(node.Kind)+`)"`, shape)
+	}
+	for _, from := range ids {
+		for _, edge := range g.sortedEdges(from) {
+			if _, ok := g.Nodes[edge.To]; ok {
+				fmt.Fprintf(&b, "  %s -> %s [label=%s];\n", quote(from), quote(edge.To), quote(edge.Type))
+			}
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
@@ -0,0 +1,63 @@
+package graph
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func exportTestGraph() *Graph {
+	g := NewGraph()
+	for id, kind := range map[string]string{
+		"checkout":     KindApplication,
+		"checkout-api": KindService,
+		"checkout-db":  KindResource,
+		"postgres":     KindResourceType,
+		"prod":         KindEnvironment,
+		"billing":      KindApplication,
+		"billing-api":  KindService,
+	} {
+		g.Nodes[id] = &Node{ID: id, Kind: kind}
+	}
+	g.Edges["checkout"] = []Edge{{To: "checkout-api", Type: EdgeTypeOwns}, {To: "checkout-db", Type: EdgeTypeOwns}, {To: "prod", Type: EdgeTypeDeploy}, {To: "prod", Type: EdgeTypeDeploy}}
+	g.Edges["checkout-api"] = []Edge{{To: "checkout-db", Type: EdgeTypeUses}, {To: "billing-api", Type: EdgeTypeDependsOn}}
+	g.Edges["checkout-db"] = []Edge{{To: "postgres", Type: EdgeTypeInstanceOf}}
+	g.Edges["billing"] = []Edge{{To: "billing-api", Type: EdgeTypeOwns}}
+	g.Edges["billing-api"] = []Edge{{To: "prod", Type: EdgeTypeDeploy}}
+	return g
+}
+
+func TestApplicationSubgraph(t *testing.T) {
+	sub, err := exportTestGraph().ApplicationSubgraph("checkout")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"billing-api", "checkout", "checkout-api", "checkout-db", "postgres", "prod"}, sub.sortedIDs())
+	assert.Len(t, sub.Edges["checkout"], 3, "repeated deploy edges collapse into one")
+	assert.Empty(t, sub.Edges["billing-api"], "nodes of other applications are leaves")
+
+	_, err = exportTestGraph().ApplicationSubgraph("checkout-api")
+	assert.Error(t, err, "only applications can be exported")
+}
+
+func TestExportFormats(t *testing.T) {
+	sub, err := exportTestGraph().ApplicationSubgraph("checkout")
+	require.NoError(t, err)
+
+	mermaid, err := sub.Export(ExportMermaid, "checkout")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(mermaid, "flowchart LR\n"))
+	assert.Contains(t, mermaid, `n1[["checkout<br/><small>application</small>"]]`)
+	assert.Contains(t, mermaid, "n2 -->|depends_on| n0")
+	again, _ := sub.Export(ExportMermaid, "checkout")
+	assert.Equal(t, mermaid, again, "exports are stable")
+
+	dot, err := sub.Export(ExportDOT, "checkout")
+	require.NoError(t, err)
+	assert.Contains(t, dot, `digraph "checkout" {`)
+	assert.Contains(t, dot, `"checkout-db" [label="checkout-db\n(resource)", shape=cylinder];`)
+	assert.Contains(t, dot, `"checkout-db" -> "postgres" [label="instance_of"];`)
+
+	_, err = sub.Export("svg", "checkout")
+	assert.Error(t, err)
+}
@@ -1,12 +1,16 @@
 package graph
 
 import (
+	"context"
+	"errors"
 	"sync"
 )
 
 type GlobalGraph struct {
 	Backend GraphBackend
 	mu      sync.Mutex
+	root    *GlobalGraph    // set on scoped and context views, which share the root's lock
+	ctx     context.Context // set on context views: the context saves are made in
 }
 
 func NewGlobalGraph(backend GraphBackend) *GlobalGraph {
@@ -15,15 +19,48 @@ func NewGlobalGraph(backend GraphBackend) *GlobalGraph {
 	}
 }
 
+// mutex returns the lock serializing writes to the backend
+func (gg *GlobalGraph) mutex() *sync.Mutex {
+	if gg.root != nil {
+		return &gg.root.mu
+	}
+	return &gg.mu
+}
+
+// WithContext returns a view of the graph whose saves carry ctx, so what watches the graph can
+// attribute them to the request or operation that made them. The view shares the backend and
+// write lock with gg.
+func (gg *GlobalGraph) WithContext(ctx context.Context) *GlobalGraph {
+	root := gg
+	if gg.root != nil {
+		root = gg.root
+	}
+	return &GlobalGraph{Backend: gg.Backend, root: root, ctx: ctx}
+}
+
+// save stores the graph, passing on the view's context
+func (gg *GlobalGraph) save(g *Graph) error {
+	return SaveWithContext(gg.ctx, gg.Backend, g)
+}
+
+// Ping verifies the backend is reachable; in-process backends are always reachable
+func (gg *GlobalGraph) Ping(ctx context.Context) error {
+	if pinger, ok := gg.Backend.(Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
 // Graph returns always-fresh graph data from backend
 // This enables both GlobalGraph.Graph().Nodes and currentGraph := GlobalGraph.Graph() patterns
 func (gg *GlobalGraph) Graph() (*Graph, error) {
 	return gg.Backend.LoadGlobal()
 }
 
-func (gg *GlobalGraph) AddNode(node *Node) {
-	gg.mu.Lock()
-	defer gg.mu.Unlock()
+// AddNode adds or replaces a node and persists the change
+func (gg *GlobalGraph) AddNode(node *Node) error {
+	gg.mutex().Lock()
+	defer gg.mutex().Unlock()
 
 	// Get current global graph or create new one
 	currentGraph, err := gg.Backend.LoadGlobal()
@@ -35,12 +72,42 @@ func (gg *GlobalGraph) AddNode(node *Node) {
 	currentGraph.AddNode(node)
 
 	// Save back to backend
-	gg.Backend.SaveGlobal(currentGraph)
+	return gg.save(currentGraph)
+}
+
+// UpdateNode replaces an existing node and persists the change
+func (gg *GlobalGraph) UpdateNode(node *Node) error {
+	gg.mutex().Lock()
+	defer gg.mutex().Unlock()
+
+	currentGraph, err := gg.Backend.LoadGlobal()
+	if err != nil {
+		return err
+	}
+	if err := currentGraph.UpdateNode(node); err != nil {
+		return err
+	}
+	return gg.save(currentGraph)
+}
+
+// DeleteNode removes a node and its edges and persists the change
+func (gg *GlobalGraph) DeleteNode(id string) error {
+	gg.mutex().Lock()
+	defer gg.mutex().Unlock()
+
+	currentGraph, err := gg.Backend.LoadGlobal()
+	if err != nil {
+		return err
+	}
+	if err := currentGraph.DeleteNode(id); err != nil {
+		return err
+	}
+	return gg.save(currentGraph)
 }
 
 func (gg *GlobalGraph) AddEdge(fromID, toID, relType string) error {
-	gg.mu.Lock()
-	defer gg.mu.Unlock()
+	gg.mutex().Lock()
+	defer gg.mutex().Unlock()
 
 	// Get current graph state for policy checking
 	currentGraph, err := gg.Backend.LoadGlobal()
@@ -60,7 +127,30 @@ func (gg *GlobalGraph) AddEdge(fromID, toID, relType string) error {
 	}
 
 	// Save back to backend
-	return gg.Backend.SaveGlobal(currentGraph)
+	return gg.save(currentGraph)
+}
+
+// ErrSkipSave is returned by an Update function to leave the graph unsaved
+var ErrSkipSave = errors.New("skip save")
+
+// Update runs fn on the current graph under the write lock and saves the graph when fn returns
+// nil, so read-modify-write cycles spanning many nodes and edges do not lose concurrent writes.
+// When fn returns an error nothing is saved; ErrSkipSave makes Update return nil.
+func (gg *GlobalGraph) Update(fn func(*Graph) error) error {
+	gg.mutex().Lock()
+	defer gg.mutex().Unlock()
+
+	currentGraph, err := gg.Backend.LoadGlobal()
+	if err != nil {
+		return err
+	}
+	if err := fn(currentGraph); err != nil {
+		if errors.Is(err, ErrSkipSave) {
+			return nil
+		}
+		return err
+	}
+	return gg.save(currentGraph)
 }
 
 func (gg *GlobalGraph) Apply(env string) (*Graph, error) {
@@ -74,7 +164,7 @@ func (gg *GlobalGraph) Save() error {
 	if err != nil {
 		return err
 	}
-	return gg.Backend.SaveGlobal(currentGraph)
+	return gg.save(currentGraph)
 }
 
 func (gg *GlobalGraph) Load() error {
@@ -146,8 +236,8 @@ func (gg *GlobalGraph) GetNode(id string) (*Node, error) {
 
 // AttachPolicyToTransition attaches a policy to a specific transition
 func (gg *GlobalGraph) AttachPolicyToTransition(fromID, toID, edgeType, policyID string) error {
-	gg.mu.Lock()
-	defer gg.mu.Unlock()
+	gg.mutex().Lock()
+	defer gg.mutex().Unlock()
 
 	// Get current graph state
 	currentGraph, err := gg.Backend.LoadGlobal()
@@ -162,13 +252,13 @@ func (gg *GlobalGraph) AttachPolicyToTransition(fromID, toID, edgeType, policyID
 	}
 
 	// Save back to backend
-	return gg.Backend.SaveGlobal(currentGraph)
+	return gg.save(currentGraph)
 }
 
 // GetEdge retrieves an edge from the global graph
 func (gg *GlobalGraph) GetEdge(edgeID string) (*Edge, bool) {
-	gg.mu.Lock()
-	defer gg.mu.Unlock()
+	gg.mutex().Lock()
+	defer gg.mutex().Unlock()
 
 	// Get current graph state
 	currentGraph, err := gg.Backend.LoadGlobal()
@@ -181,8 +271,8 @@ func (gg *GlobalGraph) GetEdge(edgeID string) (*Edge, bool) {
 
 // UpdateEdge updates an edge in the global graph
 func (gg *GlobalGraph) UpdateEdge(edge *Edge) error {
-	gg.mu.Lock()
-	defer gg.mu.Unlock()
+	gg.mutex().Lock()
+	defer gg.mutex().Unlock()
 
 	// Get current graph state
 	currentGraph, err := gg.Backend.LoadGlobal()
@@ -196,13 +286,13 @@ func (gg *GlobalGraph) UpdateEdge(edge *Edge) error {
 	}
 
 	// Save back to backend
-	return gg.Backend.SaveGlobal(currentGraph)
+	return gg.save(currentGraph)
 }
 
 // GetEdgeByFromToType retrieves an edge by explicit from, to, and type parameters
 func (gg *GlobalGraph) GetEdgeByFromToType(fromID, toID, edgeType string) (*Edge, bool) {
-	gg.mu.Lock()
-	defer gg.mu.Unlock()
+	gg.mutex().Lock()
+	defer gg.mutex().Unlock()
 
 	// Get current graph state
 	currentGraph, err := gg.Backend.LoadGlobal()
@@ -0,0 +1,66 @@
+package graph
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestGlobalGraph_Update_SerializesReadModifyWrite(t *testing.T) {
+	gg := NewGlobalGraph(NewMemoryGraph())
+	gg.AddNode(&Node{ID: "counter", Kind: "counter", Metadata: map[string]interface{}{"value": 0}})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := gg.Update(func(g *Graph) error {
+				node := g.Nodes["counter"]
+				node.Metadata["value"] = node.Metadata["value"].(int) + 1
+				return nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	node, _ := gg.GetNode("counter")
+	if got := node.Metadata["value"]; got != 50 {
+		t.Errorf("expected 50 increments, got %v", got)
+	}
+}
+
+func TestGlobalGraph_Update_ErrorSkipsSave(t *testing.T) {
+	backend := &countingBackend{GraphBackend: NewMemoryGraph()}
+	gg := NewGlobalGraph(backend)
+
+	failure := errors.New("invalid change")
+	if err := gg.Update(func(g *Graph) error { return failure }); !errors.Is(err, failure) {
+		t.Errorf("expected the function's error, got %v", err)
+	}
+	if err := gg.Update(func(g *Graph) error { return ErrSkipSave }); err != nil {
+		t.Errorf("expected ErrSkipSave to return nil, got %v", err)
+	}
+	if backend.saves != 0 {
+		t.Errorf("expected no saves, got %d", backend.saves)
+	}
+	if err := gg.Update(func(g *Graph) error { g.AddNode(&Node{ID: "a", Kind: "k"}); return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if backend.saves != 1 {
+		t.Errorf("expected one save, got %d", backend.saves)
+	}
+}
+
+type countingBackend struct {
+	GraphBackend
+	saves int
+}
+
+func (b *countingBackend) SaveGlobal(g *Graph) error {
+	b.saves++
+	return b.GraphBackend.SaveGlobal(g)
+}
@@ -0,0 +1,119 @@
+package graph
+
+import (
+	"sort"
+	"strings"
+)
+
+// Index is an inverted index over a snapshot of graph nodes by kind, owner and tag, so
+// lookups intersect small ID sets instead of scanning and decoding every node
+type Index struct {
+	nodes   map[string]*Node
+	byKind  map[string]map[string]struct{}
+	byOwner map[string]map[string]struct{}
+	byTag   map[string]map[string]struct{}
+}
+
+// NewIndex indexes the given nodes. Owners and tags are matched case-insensitively.
+func NewIndex(nodes map[string]*Node) *Index {
+	idx := &Index{
+		nodes:   nodes,
+		byKind:  make(map[string]map[string]struct{}),
+		byOwner: make(map[string]map[string]struct{}),
+		byTag:   make(map[string]map[string]struct{}),
+	}
+	for id, node := range nodes {
+		indexAdd(idx.byKind, node.Kind, id)
+		if owner, ok := node.Metadata["owner"].(string); ok && owner != "" {
+			indexAdd(idx.byOwner, strings.ToLower(owner), id)
+		}
+		for _, tag := range NodeTags(node) {
+			indexAdd(idx.byTag, strings.ToLower(tag), id)
+		}
+	}
+	return idx
+}
+
+func indexAdd(index map[string]map[string]struct{}, key, id string) {
+	if index[key] == nil {
+		index[key] = make(map[string]struct{})
+	}
+	index[key][id] = struct{}{}
+}
+
+// Lookup returns the nodes of any of kinds, owned by owner and carrying every tag, sorted
+// by ID. Empty filters match everything.
+func (idx *Index) Lookup(kinds []string, owner string, tags []string) []*Node {
+	var sets []map[string]struct{}
+	if len(kinds) > 0 {
+		union := make(map[string]struct{})
+		for _, kind := range kinds {
+			for id := range idx.byKind[kind] {
+				union[id] = struct{}{}
+			}
+		}
+		sets = append(sets, union)
+	}
+	if owner != "" {
+		sets = append(sets, idx.byOwner[strings.ToLower(owner)])
+	}
+	for _, tag := range tags {
+		sets = append(sets, idx.byTag[strings.ToLower(tag)])
+	}
+
+	var ids []string
+	if len(sets) == 0 {
+		for id := range idx.nodes {
+			ids = append(ids, id)
+		}
+	} else {
+		// Intersect starting from the smallest set
+		sort.Slice(sets, func(i, j int) bool { return len(sets[i]) < len(sets[j]) })
+		for id := range sets[0] {
+			inAll := true
+			for _, set := range sets[1:] {
+				if _, ok := set[id]; !ok {
+					inAll = false
+					break
+				}
+			}
+			if inAll {
+				ids = append(ids, id)
+			}
+		}
+	}
+	sort.Strings(ids)
+
+	result := make([]*Node, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, idx.nodes[id])
+	}
+	return result
+}
+
+// Tags returns every indexed tag with the number of nodes carrying it
+func (idx *Index) Tags() map[string]int {
+	counts := make(map[string]int, len(idx.byTag))
+	for tag, ids := range idx.byTag {
+		counts[tag] = len(ids)
+	}
+	return counts
+}
+
+// NodeTags returns the tags recorded in a node's spec or metadata
+func NodeTags(node *Node) []string {
+	var tags []string
+	for _, source := range []map[string]interface{}{node.Spec, node.Metadata} {
+		switch values := source["tags"].(type) {
+		case []string:
+			tags = append(tags, values...)
+		case []interface{}:
+			for _, value := range values {
+				if tag, ok := value.(string); ok && tag != "" {
+					tags = append(tags, tag)
+				}
+			}
+		}
+	}
+	return tags
+}
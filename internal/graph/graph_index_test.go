@@ -0,0 +1,30 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIndexLookup(t *testing.T) {
+	nodes := map[string]*Node{
+		"checkout": {ID: "checkout", Kind: KindApplication, Metadata: map[string]interface{}{"owner": "Alice", "tags": []interface{}{"payments", "tier-1"}}},
+		"billing":  {ID: "billing", Kind: KindApplication, Metadata: map[string]interface{}{"owner": "bob"}, Spec: map[string]interface{}{"tags": []string{"payments"}}},
+		"dev":      {ID: "dev", Kind: KindEnvironment, Metadata: map[string]interface{}{"owner": "alice"}},
+	}
+	idx := NewIndex(nodes)
+
+	ids := func(found []*Node) []string {
+		out := []string{}
+		for _, node := range found {
+			out = append(out, node.ID)
+		}
+		return out
+	}
+	assert.Equal(t, []string{"billing", "checkout", "dev"}, ids(idx.Lookup(nil, "", nil)))
+	assert.Equal(t, []string{"billing", "checkout"}, ids(idx.Lookup(nil, "", []string{"PAYMENTS"})))
+	assert.Equal(t, []string{"checkout", "dev"}, ids(idx.Lookup(nil, "alice", nil)))
+	assert.Equal(t, []string{"checkout"}, ids(idx.Lookup([]string{KindApplication}, "alice", []string{"payments", "tier-1"})))
+	assert.Empty(t, idx.Lookup(nil, "", []string{"unknown"}))
+	assert.Equal(t, map[string]int{"payments": 2, "tier-1": 1}, idx.Tags())
+}
@@ -88,6 +88,26 @@ func (g *Graph) UpdateNode(node *Node) error {
 	return nil
 }
 
+// DeleteNode removes a node and every edge from or to it.
+// If the node doesn't exist, an error is returned.
+func (g *Graph) DeleteNode(id string) error {
+	if _, exists := g.Nodes[id]; !exists {
+		return fmt.Errorf("node with ID %s not found", id)
+	}
+	delete(g.Nodes, id)
+	delete(g.Edges, id)
+	for from, edges := range g.Edges {
+		kept := edges[:0]
+		for _, edge := range edges {
+			if edge.To != id {
+				kept = append(kept, edge)
+			}
+		}
+		g.Edges[from] = kept
+	}
+	return nil
+}
+
 // validateEdgeContract validates an edge using the contract system
 func (g *Graph) validateEdgeContract(fromID, toID, relType string) error {
 	fromNode := g.Nodes[fromID]
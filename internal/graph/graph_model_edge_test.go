@@ -31,3 +31,25 @@ func TestEdge_MetadataState(t *testing.T) {
 		t.Errorf("expected state 'deploying', got %v", edge.Metadata["state"])
 	}
 }
+
+func TestDeleteNode_RemovesEdges(t *testing.T) {
+	g := NewGraph()
+	g.AddNode(&Node{ID: "a", Kind: KindApplication, Metadata: map[string]interface{}{}, Spec: map[string]interface{}{}})
+	g.AddNode(&Node{ID: "b", Kind: KindService, Metadata: map[string]interface{}{}, Spec: map[string]interface{}{}})
+	if err := g.AddEdge("a", "b", EdgeTypeOwns); err != nil {
+		t.Fatalf("unexpected error adding edge: %v", err)
+	}
+
+	if err := g.DeleteNode("b"); err != nil {
+		t.Fatalf("expected node to be deleted, got error: %v", err)
+	}
+	if _, err := g.GetNode("b"); err == nil {
+		t.Error("expected deleted node to be gone")
+	}
+	if len(g.Edges["a"]) != 0 {
+		t.Errorf("expected edges to deleted node to be removed, got: %v", g.Edges["a"])
+	}
+	if err := g.DeleteNode("b"); err == nil {
+		t.Error("expected error deleting a missing node")
+	}
+}
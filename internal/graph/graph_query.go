@@ -0,0 +1,148 @@
+package graph
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Query return modes
+const (
+	QueryReturnNodes = "nodes"
+	QueryReturnCount = "count"
+)
+
+// Query is a structured graph query: select nodes of a kind, optionally filtered, then walk
+// edges step by step. It is small enough for an AI to generate and for a person to verify.
+type Query struct {
+	Kind     string            `json:"kind"`
+	Where    map[string]string `json:"where,omitempty"` // id, or a metadata/spec field; values match case-insensitively
+	Traverse []QueryStep       `json:"traverse,omitempty"`
+	Return   string            `json:"return,omitempty"` // nodes (default) or count
+}
+
+// QueryStep follows edges of one type from the current node set
+type QueryStep struct {
+	Edge      string            `json:"edge"`
+	Direction string            `json:"direction,omitempty"` // out (default) or in
+	Kind      string            `json:"kind,omitempty"`      // keep only nodes of this kind
+	Where     map[string]string `json:"where,omitempty"`
+}
+
+// QueryNode is a node in a query result
+type QueryNode struct {
+	ID   string `json:"id"`
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+// QueryResult holds the matching nodes, sorted by ID. Nodes is empty for count queries.
+type QueryResult struct {
+	Query Query       `json:"query"`
+	Count int         `json:"count"`
+	Nodes []QueryNode `json:"nodes,omitempty"`
+}
+
+// Validate checks that the query can be executed
+func (q Query) Validate() error {
+	if q.Kind == "" {
+		return fmt.Errorf("kind is required")
+	}
+	switch q.Return {
+	case "", QueryReturnNodes, QueryReturnCount:
+	default:
+		return fmt.Errorf("return must be %s or %s", QueryReturnNodes, QueryReturnCount)
+	}
+	for i, step := range q.Traverse {
+		if !IsValidEdgeType(step.Edge) {
+			return fmt.Errorf("traverse step %d: unknown edge type %q", i+1, step.Edge)
+		}
+		if step.Direction != "" && step.Direction != "out" && step.Direction != "in" {
+			return fmt.Errorf("traverse step %d: direction must be out or in", i+1)
+		}
+	}
+	return nil
+}
+
+// Query executes a structured query against the current graph
+func (gg *GlobalGraph) Query(q Query) (*QueryResult, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+	nodes, err := gg.Nodes()
+	if err != nil {
+		return nil, err
+	}
+	edges, err := gg.Edges()
+	if err != nil {
+		return nil, err
+	}
+
+	current := map[string]bool{}
+	for id, node := range nodes {
+		if node.Kind == q.Kind && matchesWhere(node, q.Where) {
+			current[id] = true
+		}
+	}
+
+	for _, step := range q.Traverse {
+		next := map[string]bool{}
+		for from, list := range edges {
+			for _, edge := range list {
+				if edge.Type != step.Edge {
+					continue
+				}
+				source, target := from, edge.To
+				if step.Direction == "in" {
+					source, target = edge.To, from
+				}
+				if !current[source] {
+					continue
+				}
+				node, ok := nodes[target]
+				if !ok || (step.Kind != "" && node.Kind != step.Kind) || !matchesWhere(node, step.Where) {
+					continue
+				}
+				next[target] = true
+			}
+		}
+		current = next
+	}
+
+	result := &QueryResult{Query: q, Count: len(current)}
+	if q.Return == QueryReturnCount {
+		return result, nil
+	}
+	ids := make([]string, 0, len(current))
+	for id := range current {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	result.Nodes = make([]QueryNode, 0, len(ids))
+	for _, id := range ids {
+		node := nodes[id]
+		name := id
+		if n, ok := node.Metadata["name"].(string); ok && n != "" {
+			name = n
+		}
+		result.Nodes = append(result.Nodes, QueryNode{ID: id, Kind: node.Kind, Name: name})
+	}
+	return result, nil
+}
+
+func matchesWhere(node *Node, where map[string]string) bool {
+	for field, want := range where {
+		var value interface{}
+		if field == "id" {
+			value = node.ID
+		} else if v, ok := node.Metadata[field]; ok {
+			value = v
+		} else {
+			value = node.Spec[field]
+		}
+		if value == nil || !strings.EqualFold(fmt.Sprint(value), want) {
+			return false
+		}
+	}
+	return true
+}
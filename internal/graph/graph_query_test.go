@@ -0,0 +1,47 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGlobalGraphQuery(t *testing.T) {
+	g := NewGlobalGraph(NewMemoryGraph())
+	g.AddNode(&Node{ID: "checkout", Kind: KindApplication, Metadata: map[string]interface{}{"name": "checkout", "owner": "team-a"}})
+	g.AddNode(&Node{ID: "billing", Kind: KindApplication, Metadata: map[string]interface{}{"name": "billing", "owner": "team-b"}})
+	for _, svc := range []string{"checkout-api", "checkout-worker"} {
+		g.AddNode(&Node{ID: svc, Kind: KindService, Metadata: map[string]interface{}{"name": svc}})
+		require.NoError(t, g.AddEdge("checkout", svc, EdgeTypeOwns))
+	}
+	g.AddNode(&Node{ID: "billing-api", Kind: KindService, Metadata: map[string]interface{}{"name": "billing-api"}})
+	require.NoError(t, g.AddEdge("billing", "billing-api", EdgeTypeOwns))
+
+	result, err := g.Query(Query{
+		Kind:     KindApplication,
+		Where:    map[string]string{"name": "Checkout"},
+		Traverse: []QueryStep{{Edge: EdgeTypeOwns, Kind: KindService}},
+		Return:   QueryReturnCount,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Count)
+	assert.Empty(t, result.Nodes)
+
+	result, err = g.Query(Query{
+		Kind:     KindService,
+		Where:    map[string]string{"id": "billing-api"},
+		Traverse: []QueryStep{{Edge: EdgeTypeOwns, Direction: "in"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []QueryNode{{ID: "billing", Kind: KindApplication, Name: "billing"}}, result.Nodes)
+
+	result, err = g.Query(Query{Kind: KindApplication, Where: map[string]string{"owner": "team-a"}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Count)
+
+	_, err = g.Query(Query{Kind: KindApplication, Traverse: []QueryStep{{Edge: "knows"}}})
+	assert.Error(t, err)
+	_, err = g.Query(Query{})
+	assert.Error(t, err)
+}
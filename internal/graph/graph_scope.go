@@ -0,0 +1,238 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// ErrScopeDenied is returned when a scoped graph is asked to change something outside its scope
+var ErrScopeDenied = errors.New("graph change outside scope")
+
+// Scope limits what a holder of a scoped graph may change. Reads are never limited.
+type Scope struct {
+	Name     string   // who the scope is granted to, for errors and logs
+	ReadOnly bool     // no changes at all
+	Kinds    []string // node kinds that may be added, changed or removed
+}
+
+// ReadOnlyScope grants reads only
+func ReadOnlyScope(name string) Scope {
+	return Scope{Name: name, ReadOnly: true}
+}
+
+// WriteScope grants changes to nodes of the given kinds and to edges touching them
+func WriteScope(name string, kinds ...string) Scope {
+	return Scope{Name: name, Kinds: kinds}
+}
+
+// Allows reports whether nodes of kind may be changed
+func (s Scope) Allows(kind string) bool {
+	if s.ReadOnly {
+		return false
+	}
+	for _, k := range s.Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// ScopeError describes a denied change
+type ScopeError struct {
+	Scope  string
+	Change string // e.g. "node checkout (application)" or "edge checkout->dev:deploy"
+}
+
+func (e *ScopeError) Error() string {
+	return fmt.Sprintf("%s may not change %s: %v", e.Scope, e.Change, ErrScopeDenied)
+}
+
+func (e *ScopeError) Unwrap() error { return ErrScopeDenied }
+
+// Scoped returns a view of the graph that only makes changes the scope allows. The view
+// shares the backend and write lock with gg. Every load through it returns a private copy,
+// so a denied save leaves the shared graph untouched even on the in-memory backend.
+func (gg *GlobalGraph) Scoped(scope Scope) *GlobalGraph {
+	root := gg
+	if gg.root != nil {
+		root = gg.root
+	}
+	return &GlobalGraph{
+		Backend: &scopedBackend{inner: gg.Backend, scope: scope},
+		root:    root,
+		ctx:     gg.ctx,
+	}
+}
+
+// scopedBackend checks every save against its scope before passing it on
+type scopedBackend struct {
+	inner GraphBackend
+	scope Scope
+}
+
+func (b *scopedBackend) LoadGlobal() (*Graph, error) {
+	g, err := b.inner.LoadGlobal()
+	if err != nil {
+		return nil, err
+	}
+	return cloneGraph(g), nil
+}
+
+func (b *scopedBackend) SaveGlobal(g *Graph) error {
+	return b.SaveGlobalContext(context.Background(), g)
+}
+
+func (b *scopedBackend) SaveGlobalContext(ctx context.Context, g *Graph) error {
+	current, err := b.inner.LoadGlobal()
+	if err != nil {
+		current = NewGraph()
+	}
+	if err := b.authorize(current, g); err != nil {
+		logging.GetLogger().ForComponent("graph-scope").Warn("🚫 %v", err)
+		return err
+	}
+	return SaveWithContext(ctx, b.inner, g)
+}
+
+func (b *scopedBackend) Clear() error {
+	return &ScopeError{Scope: b.scope.Name, Change: "the whole graph"}
+}
+
+// authorize returns the first change from current to next the scope does not allow
+func (b *scopedBackend) authorize(current, next *Graph) error {
+	for _, id := range changedNodes(current, next) {
+		for _, node := range []*Node{current.Nodes[id], next.Nodes[id]} {
+			if node != nil && !b.scope.Allows(node.Kind) {
+				return &ScopeError{Scope: b.scope.Name, Change: fmt.Sprintf("node %s (%s)", id, node.Kind)}
+			}
+		}
+	}
+
+	kindOf := func(id string) string {
+		if node := next.Nodes[id]; node != nil {
+			return node.Kind
+		}
+		if node := current.Nodes[id]; node != nil {
+			return node.Kind
+		}
+		return ""
+	}
+	for _, change := range changedEdges(current, next) {
+		if !b.scope.Allows(kindOf(change.from)) && !b.scope.Allows(kindOf(change.to)) {
+			return &ScopeError{Scope: b.scope.Name, Change: fmt.Sprintf("edge %s->%s:%s", change.from, change.to, change.edgeType)}
+		}
+	}
+	return nil
+}
+
+func changedNodes(current, next *Graph) []string {
+	var changed []string
+	for id, node := range next.Nodes {
+		if !reflect.DeepEqual(current.Nodes[id], node) {
+			changed = append(changed, id)
+		}
+	}
+	for id := range current.Nodes {
+		if _, ok := next.Nodes[id]; !ok {
+			changed = append(changed, id)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+type edgeChange struct {
+	from, to, edgeType string
+}
+
+func changedEdges(current, next *Graph) []edgeChange {
+	index := func(g *Graph) map[edgeChange]Edge {
+		edges := map[edgeChange]Edge{}
+		for from, list := range g.Edges {
+			for _, edge := range list {
+				edges[edgeChange{from, edge.To, edge.Type}] = edge
+			}
+		}
+		return edges
+	}
+	before, after := index(current), index(next)
+
+	var changed []edgeChange
+	for key, edge := range after {
+		if previous, ok := before[key]; !ok || !reflect.DeepEqual(previous.Metadata, edge.Metadata) {
+			changed = append(changed, key)
+		}
+	}
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			changed = append(changed, key)
+		}
+	}
+	sort.Slice(changed, func(i, j int) bool {
+		return changed[i].from+changed[i].to+changed[i].edgeType < changed[j].from+changed[j].to+changed[j].edgeType
+	})
+	return changed
+}
+
+// cloneGraph deep-copies the maps and slices of g; other values are shared
+func cloneGraph(g *Graph) *Graph {
+	clone := &Graph{Nodes: make(map[string]*Node, len(g.Nodes)), Edges: make(map[string][]Edge, len(g.Edges))}
+	for id, node := range g.Nodes {
+		if node == nil {
+			continue
+		}
+		clone.Nodes[id] = &Node{
+			ID:       node.ID,
+			Kind:     node.Kind,
+			Metadata: cloneMap(node.Metadata),
+			Spec:     cloneMap(node.Spec),
+		}
+	}
+	for from, edges := range g.Edges {
+		list := make([]Edge, len(edges))
+		for i, edge := range edges {
+			list[i] = Edge{To: edge.To, Type: edge.Type, Metadata: cloneMap(edge.Metadata)}
+		}
+		clone.Edges[from] = list
+	}
+	return clone
+}
+
+func cloneMap(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+	clone := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		clone[k] = cloneValue(v)
+	}
+	return clone
+}
+
+func cloneValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		return cloneMap(value)
+	case []interface{}:
+		list := make([]interface{}, len(value))
+		for i, item := range value {
+			list[i] = cloneValue(item)
+		}
+		return list
+	case []string:
+		return append([]string(nil), value...)
+	default:
+		return v
+	}
+}
+
+// Clone returns a copy of the graph whose maps and slices can be changed without affecting g
+func (g *Graph) Clone() *Graph {
+	return cloneGraph(g)
+}
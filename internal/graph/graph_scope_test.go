@@ -0,0 +1,66 @@
+package graph
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func scopeTestGraph(t *testing.T) *GlobalGraph {
+	t.Helper()
+	gg := NewGlobalGraph(NewMemoryGraph())
+	gg.AddNode(&Node{ID: "checkout", Kind: KindApplication, Metadata: map[string]interface{}{"name": "checkout", "owner": "team-a"}, Spec: map[string]interface{}{}})
+	gg.AddNode(&Node{ID: "dev", Kind: KindEnvironment, Metadata: map[string]interface{}{"name": "dev", "owner": "team-a"}, Spec: map[string]interface{}{}})
+	return gg
+}
+
+func TestReadOnlyScope(t *testing.T) {
+	gg := scopeTestGraph(t)
+	view := gg.Scoped(ReadOnlyScope("policy-agent"))
+
+	node, err := view.GetNode("checkout")
+	require.NoError(t, err)
+	assert.Equal(t, "team-a", node.Metadata["owner"])
+
+	// Changing what a read returned and saving it is denied, and the shared graph is untouched
+	node.Metadata["owner"] = "team-b"
+	err = view.UpdateNode(node)
+	assert.True(t, errors.Is(err, ErrScopeDenied), "got %v", err)
+	current, _ := gg.GetNode("checkout")
+	assert.Equal(t, "team-a", current.Metadata["owner"])
+
+	assert.ErrorIs(t, view.AddEdge("checkout", "dev", "allowed_in"), ErrScopeDenied)
+	assert.ErrorIs(t, view.Backend.Clear(), ErrScopeDenied)
+	nodes, _ := gg.Nodes()
+	assert.Len(t, nodes, 2)
+}
+
+func TestWriteScopeLimitsKinds(t *testing.T) {
+	gg := scopeTestGraph(t)
+	view := gg.Scoped(WriteScope("environment-agent", KindEnvironment))
+
+	view.AddNode(&Node{ID: "prod", Kind: KindEnvironment, Metadata: map[string]interface{}{"name": "prod"}, Spec: map[string]interface{}{}})
+	created, _ := gg.GetNode("prod")
+	assert.NotNil(t, created, "environments are in scope")
+
+	view.AddNode(&Node{ID: "billing", Kind: KindApplication, Metadata: map[string]interface{}{"name": "billing"}, Spec: map[string]interface{}{}})
+	denied, _ := gg.GetNode("billing")
+	assert.Nil(t, denied, "applications are out of scope")
+
+	// Edges touching an in-scope node may change
+	require.NoError(t, view.AddEdge("checkout", "dev", "allowed_in"))
+	ok, _ := gg.HasEdge("checkout", "dev", "allowed_in")
+	assert.True(t, ok)
+
+	err := view.DeleteNode("checkout")
+	var scopeErr *ScopeError
+	require.ErrorAs(t, err, &scopeErr)
+	assert.Equal(t, "environment-agent", scopeErr.Scope)
+	assert.Contains(t, scopeErr.Change, "checkout")
+
+	require.NoError(t, view.DeleteNode("prod"))
+	gone, _ := gg.GetNode("prod")
+	assert.Nil(t, gone)
+}
@@ -0,0 +1,214 @@
+// Package graphgen builds realistic synthetic platform graphs for load and performance testing
+package graphgen
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+)
+
+// Spec describes the shape of the generated graph
+type Spec struct {
+	Applications        int      // number of applications
+	ServicesPerApp      int      // services owned by each application
+	ResourcesPerApp     int      // resource instances owned by each application
+	VersionsPerService  int      // service versions per service
+	Policies            int      // policies attached to service_version → environment deploy transitions
+	Environments        []string // environment names, deployments target the first one
+	Seed                int64    // random seed so runs are reproducible
+	ResourceTypes       []string // resource type catalog
+	ServiceResourceLink float64  // probability that a service uses each of its application's resources
+}
+
+// DefaultSpec returns a small but representative graph
+func DefaultSpec() Spec {
+	return Spec{
+		Applications:        10,
+		ServicesPerApp:      5,
+		ResourcesPerApp:     2,
+		VersionsPerService:  2,
+		Policies:            5,
+		Environments:        []string{"dev", "staging", "prod"},
+		Seed:                1,
+		ResourceTypes:       []string{"postgres", "redis", "kafka", "s3"},
+		ServiceResourceLink: 0.5,
+	}
+}
+
+// Stats summarizes a generated graph
+type Stats struct {
+	Nodes       int            `json:"nodes"`
+	Edges       int            `json:"edges"`
+	NodesByKind map[string]int `json:"nodes_by_kind"`
+}
+
+// Validate checks the spec before generating
+func (s Spec) Validate() error {
+	if s.Applications < 0 || s.ServicesPerApp < 0 || s.ResourcesPerApp < 0 || s.VersionsPerService < 0 || s.Policies < 0 {
+		return fmt.Errorf("counts must not be negative")
+	}
+	if len(s.Environments) == 0 {
+		return fmt.Errorf("at least one environment is required")
+	}
+	if s.ResourcesPerApp > 0 && len(s.ResourceTypes) == 0 {
+		return fmt.Errorf("resources require at least one resource type")
+	}
+	if s.ServiceResourceLink < 0 || s.ServiceResourceLink > 1 {
+		return fmt.Errorf("service resource link probability must be between 0 and 1")
+	}
+	return nil
+}
+
+// Generate builds the graph in memory. Edges go through the normal edge contracts so the
+// result is a graph the platform could have produced itself.
+func Generate(spec Spec) (*graph.Graph, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+
+	rng := rand.New(rand.NewSource(spec.Seed))
+	g := graph.NewGraph()
+
+	for _, env := range spec.Environments {
+		addNode(g, env, graph.KindEnvironment, nil)
+	}
+
+	const register = "resource-catalog"
+	if len(spec.ResourceTypes) > 0 {
+		addNode(g, register, graph.KindResourceRegister, nil)
+	}
+	for _, resourceType := range spec.ResourceTypes {
+		addNode(g, resourceType, graph.KindResourceType, map[string]interface{}{"version": "1"})
+		if err := g.AddEdge(register, resourceType, graph.EdgeTypeOwns); err != nil {
+			return nil, err
+		}
+	}
+
+	var versions []string
+	for a := 0; a < spec.Applications; a++ {
+		app := fmt.Sprintf("app-%04d", a)
+		addNode(g, app, graph.KindApplication, map[string]interface{}{"tags": []interface{}{"synthetic"}})
+		for _, env := range spec.Environments {
+			if err := g.AddEdge(app, env, "allowed_in"); err != nil {
+				return nil, err
+			}
+		}
+
+		var appResources []string
+		for r := 0; r < spec.ResourcesPerApp; r++ {
+			resourceType := spec.ResourceTypes[rng.Intn(len(spec.ResourceTypes))]
+			resource := fmt.Sprintf("%s-%s-%d", app, resourceType, r)
+			node := addNode(g, resource, graph.KindResource, map[string]interface{}{"type": resourceType})
+			node.Metadata["application"] = app
+			node.Metadata["catalog_ref"] = resourceType
+			if err := addEdges(g,
+				edge{app, resource, graph.EdgeTypeOwns},
+				edge{resource, resourceType, graph.EdgeTypeInstanceOf},
+				edge{resource, spec.Environments[0], graph.EdgeTypeDeploy},
+			); err != nil {
+				return nil, err
+			}
+			appResources = append(appResources, resource)
+		}
+
+		for s := 0; s < spec.ServicesPerApp; s++ {
+			service := fmt.Sprintf("%s-svc-%02d", app, s)
+			addNode(g, service, graph.KindService, map[string]interface{}{"application": app, "port": 8000 + s, "public": rng.Intn(4) == 0})
+			if err := g.AddEdge(app, service, graph.EdgeTypeOwns); err != nil {
+				return nil, err
+			}
+			for _, resource := range appResources {
+				if rng.Float64() < spec.ServiceResourceLink {
+					if err := g.AddEdge(service, resource, graph.EdgeTypeUses); err != nil {
+						return nil, err
+					}
+				}
+			}
+			for v := 0; v < spec.VersionsPerService; v++ {
+				version := fmt.Sprintf("%s:1.%d.0", service, v)
+				addNode(g, version, graph.KindServiceVersion, map[string]interface{}{"version": fmt.Sprintf("1.%d.0", v)})
+				if err := g.AddEdge(service, version, graph.EdgeTypeHasVersion); err != nil {
+					return nil, err
+				}
+				versions = append(versions, version)
+			}
+			// The newest version of every service runs in the first environment
+			if spec.VersionsPerService > 0 {
+				if err := g.AddEdge(versions[len(versions)-1], spec.Environments[0], graph.EdgeTypeDeploy); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	// Policies are attached last so they do not block the deploy edges created above
+	target := spec.Environments[len(spec.Environments)-1]
+	for p := 0; p < spec.Policies; p++ {
+		policy := fmt.Sprintf("policy-%04d", p)
+		addNode(g, policy, graph.KindPolicy, map[string]interface{}{"type": graph.PolicyTypeCheck})
+		check := fmt.Sprintf("check-%04d", p)
+		addNode(g, check, graph.KindCheck, map[string]interface{}{"status": graph.CheckStatusPending})
+		if err := g.AddEdge(check, policy, graph.EdgeTypeSatisfies); err != nil {
+			return nil, err
+		}
+		if len(versions) > 0 {
+			version := versions[rng.Intn(len(versions))]
+			if err := g.AttachPolicyToTransition(version, target, graph.EdgeTypeDeploy, policy); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return g, nil
+}
+
+// Write replaces the contents of backend with a generated graph
+func Write(backend graph.GraphBackend, g *graph.Graph) error {
+	if err := backend.Clear(); err != nil {
+		return fmt.Errorf("clear backend: %w", err)
+	}
+	return backend.SaveGlobal(g)
+}
+
+// Summarize counts the nodes and edges of g
+func Summarize(g *graph.Graph) Stats {
+	stats := Stats{NodesByKind: map[string]int{}}
+	for _, node := range g.Nodes {
+		stats.Nodes++
+		stats.NodesByKind[node.Kind]++
+	}
+	for _, edges := range g.Edges {
+		stats.Edges += len(edges)
+	}
+	return stats
+}
+
+type edge struct{ from, to, relType string }
+
+func addEdges(g *graph.Graph, edges ...edge) error {
+	for _, e := range edges {
+		if err := g.AddEdge(e.from, e.to, e.relType); err != nil {
+			return fmt.Errorf("%s -%s-> %s: %w", e.from, e.relType, e.to, err)
+		}
+	}
+	return nil
+}
+
+func addNode(g *graph.Graph, id, kind string, spec map[string]interface{}) *graph.Node {
+	if spec == nil {
+		spec = map[string]interface{}{}
+	}
+	node := &graph.Node{
+		ID:   id,
+		Kind: kind,
+		Metadata: map[string]interface{}{
+			"name":      id,
+			"owner":     "graphgen",
+			"synthetic": true,
+		},
+		Spec: spec,
+	}
+	g.AddNode(node)
+	return node
+}
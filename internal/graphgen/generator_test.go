@@ -0,0 +1,95 @@
+package graphgen
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate_ShapeMatchesSpec(t *testing.T) {
+	spec := DefaultSpec()
+	spec.Applications = 3
+	spec.ServicesPerApp = 4
+	spec.ResourcesPerApp = 2
+	spec.VersionsPerService = 2
+	spec.Policies = 2
+
+	g, err := Generate(spec)
+	require.NoError(t, err)
+
+	stats := Summarize(g)
+	assert.Equal(t, 3, stats.NodesByKind[graph.KindApplication])
+	assert.Equal(t, 12, stats.NodesByKind[graph.KindService])
+	assert.Equal(t, 24, stats.NodesByKind[graph.KindServiceVersion])
+	assert.Equal(t, 6, stats.NodesByKind[graph.KindResource])
+	assert.Equal(t, 2, stats.NodesByKind[graph.KindPolicy])
+	assert.Equal(t, 3, stats.NodesByKind[graph.KindEnvironment])
+	assert.Greater(t, stats.Edges, 0)
+}
+
+func TestGenerate_IsDeterministicPerSeed(t *testing.T) {
+	a, err := Generate(DefaultSpec())
+	require.NoError(t, err)
+	b, err := Generate(DefaultSpec())
+	require.NoError(t, err)
+	assert.Equal(t, Summarize(a), Summarize(b))
+	assert.Equal(t, a.Edges, b.Edges)
+}
+
+func TestGenerate_RejectsInvalidSpec(t *testing.T) {
+	spec := DefaultSpec()
+	spec.Environments = nil
+	_, err := Generate(spec)
+	assert.Error(t, err)
+}
+
+func TestWriteAndMeasureBackend(t *testing.T) {
+	backend := graph.NewMemoryGraph()
+	g, err := Generate(DefaultSpec())
+	require.NoError(t, err)
+	require.NoError(t, Write(backend, g))
+
+	report, err := MeasureBackend(backend, 3)
+	require.NoError(t, err)
+	for _, op := range []string{"load", "save", "add_node", "delete_node"} {
+		assert.Equal(t, 3, report.Operations[op].Count, op)
+		assert.Zero(t, report.Operations[op].Errors, op)
+	}
+
+	loaded, err := backend.LoadGlobal()
+	require.NoError(t, err)
+	assert.Equal(t, len(g.Nodes), len(loaded.Nodes), "probe nodes must be removed")
+}
+
+func TestRunAPILoad_CountsRequestsAndErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	report, err := RunAPILoad(context.Background(), LoadTestConfig{
+		BaseURL:     server.URL,
+		Concurrency: 2,
+		Duration:    100 * time.Millisecond,
+		Requests: []Request{
+			{Name: "ok", Method: http.MethodGet, Path: "/ok"},
+			{Name: "broken", Method: http.MethodGet, Path: "/broken"},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Greater(t, report.Operations["ok"].Count, 0)
+	assert.Zero(t, report.Operations["ok"].Errors)
+	assert.Equal(t, report.Operations["broken"].Count, report.Operations["broken"].Errors)
+	assert.Greater(t, report.Operations["ok"].Throughput, 0.0)
+}
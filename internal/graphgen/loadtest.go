@@ -0,0 +1,222 @@
+package graphgen
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+)
+
+// Request is one kind of call issued by the API load test
+type Request struct {
+	Name   string
+	Method string
+	Path   string
+	Body   string
+}
+
+// LoadTestConfig controls an API load test
+type LoadTestConfig struct {
+	BaseURL     string
+	Concurrency int
+	Duration    time.Duration
+	Requests    []Request // issued round-robin by every worker
+	Client      *http.Client
+}
+
+// Latency summarizes the durations of one operation
+type Latency struct {
+	Count      int           `json:"count"`
+	Errors     int           `json:"errors"`
+	Throughput float64       `json:"throughput_per_sec"`
+	P50        time.Duration `json:"p50"`
+	P95        time.Duration `json:"p95"`
+	P99        time.Duration `json:"p99"`
+	Max        time.Duration `json:"max"`
+}
+
+// Report is the result of a load test, keyed by operation name
+type Report struct {
+	Elapsed    time.Duration      `json:"elapsed"`
+	Operations map[string]Latency `json:"operations"`
+}
+
+// DefaultRequests exercises orchestration (chat) and graph reads against a generated graph
+func DefaultRequests(spec Spec) []Request {
+	requests := []Request{
+		{Name: "graph", Method: http.MethodGet, Path: "/v1/graph"},
+		{Name: "chat", Method: http.MethodPost, Path: "/v3/ai/chat", Body: `{"message":"List all applications"}`},
+	}
+	if spec.Applications > 0 {
+		requests = append(requests, Request{Name: "services", Method: http.MethodGet, Path: "/v1/applications/app-0000/services"})
+	}
+	return requests
+}
+
+// RunAPILoad issues requests against a running API from Concurrency workers until
+// Duration elapses or ctx is cancelled. Non-2xx responses count as errors.
+func RunAPILoad(ctx context.Context, cfg LoadTestConfig) (*Report, error) {
+	if cfg.BaseURL == "" {
+		return nil, fmt.Errorf("base URL is required")
+	}
+	if len(cfg.Requests) == 0 {
+		return nil, fmt.Errorf("at least one request is required")
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 1
+	}
+	client := cfg.Client
+	if client == nil {
+		client = &http.Client{Timeout: 60 * time.Second}
+	}
+	baseURL := strings.TrimRight(cfg.BaseURL, "/")
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Duration)
+	defer cancel()
+
+	rec := newRecorder()
+	start := time.Now()
+	var wg sync.WaitGroup
+	for w := 0; w < cfg.Concurrency; w++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for i := worker; ctx.Err() == nil; i++ {
+				req := cfg.Requests[i%len(cfg.Requests)]
+				began := time.Now()
+				err := doRequest(ctx, client, baseURL, req)
+				if ctx.Err() != nil {
+					return // the test ended mid-request, don't count it
+				}
+				rec.observe(req.Name, time.Since(began), err)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	return rec.report(time.Since(start)), nil
+}
+
+func doRequest(ctx context.Context, client *http.Client, baseURL string, r Request) error {
+	var body io.Reader
+	if r.Body != "" {
+		body = bytes.NewBufferString(r.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, r.Method, baseURL+r.Path, body)
+	if err != nil {
+		return err
+	}
+	if r.Body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s: status %d", r.Method, r.Path, resp.StatusCode)
+	}
+	return nil
+}
+
+// MeasureBackend times full graph loads and saves against a backend, plus the
+// load-modify-save cycle GlobalGraph.AddNode performs on every write
+func MeasureBackend(backend graph.GraphBackend, iterations int) (*Report, error) {
+	if iterations <= 0 {
+		iterations = 1
+	}
+	gg := graph.NewGlobalGraph(backend)
+	rec := newRecorder()
+	start := time.Now()
+
+	for i := 0; i < iterations; i++ {
+		began := time.Now()
+		g, err := backend.LoadGlobal()
+		rec.observe("load", time.Since(began), err)
+		if err != nil {
+			return nil, fmt.Errorf("load graph: %w", err)
+		}
+
+		began = time.Now()
+		err = backend.SaveGlobal(g)
+		rec.observe("save", time.Since(began), err)
+		if err != nil {
+			return nil, fmt.Errorf("save graph: %w", err)
+		}
+
+		id := fmt.Sprintf("loadtest-probe-%d", i)
+		began = time.Now()
+		gg.AddNode(&graph.Node{ID: id, Kind: graph.KindEnvironment, Metadata: map[string]interface{}{"name": id}, Spec: map[string]interface{}{}})
+		rec.observe("add_node", time.Since(began), nil)
+
+		began = time.Now()
+		err = gg.DeleteNode(id)
+		rec.observe("delete_node", time.Since(began), err)
+	}
+
+	return rec.report(time.Since(start)), nil
+}
+
+// recorder collects per-operation durations from concurrent workers
+type recorder struct {
+	mu        sync.Mutex
+	durations map[string][]time.Duration
+	errors    map[string]int
+}
+
+func newRecorder() *recorder {
+	return &recorder{durations: map[string][]time.Duration{}, errors: map[string]int{}}
+}
+
+func (r *recorder) observe(name string, d time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.durations[name] = append(r.durations[name], d)
+	if err != nil {
+		r.errors[name]++
+	}
+}
+
+func (r *recorder) report(elapsed time.Duration) *Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	report := &Report{Elapsed: elapsed, Operations: map[string]Latency{}}
+	for name, durations := range r.durations {
+		sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+		latency := Latency{
+			Count:  len(durations),
+			Errors: r.errors[name],
+			P50:    percentile(durations, 0.50),
+			P95:    percentile(durations, 0.95),
+			P99:    percentile(durations, 0.99),
+			Max:    durations[len(durations)-1],
+		}
+		if elapsed > 0 {
+			latency.Throughput = float64(len(durations)) / elapsed.Seconds()
+		}
+		report.Operations[name] = latency
+	}
+	return report
+}
+
+// percentile expects sorted, non-empty durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	idx := int(float64(len(sorted))*p+0.5) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
@@ -0,0 +1,45 @@
+package graphstats
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WritePrometheus writes the statistics in the Prometheus text exposition format
+func (s *Stats) WritePrometheus(w io.Writer) error {
+	var sb strings.Builder
+	gauge := func(name, help string) {
+		fmt.Fprintf(&sb, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+	}
+
+	gauge("ztdp_graph_nodes", "Nodes in the graph by kind.")
+	for _, kind := range sortedKinds(s.NodesByKind) {
+		fmt.Fprintf(&sb, "ztdp_graph_nodes{kind=%q} %d\n", kind, s.NodesByKind[kind])
+	}
+	gauge("ztdp_graph_edges", "Edges in the graph by type.")
+	for _, edgeType := range sortedKinds(s.EdgesByType) {
+		fmt.Fprintf(&sb, "ztdp_graph_edges{type=%q} %d\n", edgeType, s.EdgesByType[edgeType])
+	}
+	gauge("ztdp_graph_orphaned_nodes", "Services, versions and resources no node points at.")
+	fmt.Fprintf(&sb, "ztdp_graph_orphaned_nodes %d\n", len(s.OrphanedNodes))
+	gauge("ztdp_graph_orphan_ratio", "Orphaned share of services, versions and resources.")
+	fmt.Fprintf(&sb, "ztdp_graph_orphan_ratio %g\n", s.OrphanRate)
+	gauge("ztdp_graph_application_nodes", "Nodes owned by the largest applications.")
+	for _, app := range s.LargestApplications {
+		fmt.Fprintf(&sb, "ztdp_graph_application_nodes{application=%q} %d\n", app.Name, app.Nodes)
+	}
+	if s.Growth != nil {
+		gauge("ztdp_graph_node_growth", "Nodes added by kind over the growth window; negative when removed.")
+		for _, kind := range sortedKinds(s.Growth.NodesByKind) {
+			fmt.Fprintf(&sb, "ztdp_graph_node_growth{kind=%q} %d\n", kind, s.Growth.NodesByKind[kind])
+		}
+		gauge("ztdp_graph_edge_growth", "Edges added over the growth window; negative when removed.")
+		fmt.Fprintf(&sb, "ztdp_graph_edge_growth %d\n", s.Growth.Edges)
+	}
+	gauge("ztdp_graph_stats_computed_timestamp_seconds", "When the graph statistics were computed.")
+	fmt.Fprintf(&sb, "ztdp_graph_stats_computed_timestamp_seconds %d\n", s.ComputedAt.Unix())
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
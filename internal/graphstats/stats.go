@@ -0,0 +1,242 @@
+// Package graphstats periodically computes statistics about the global graph (nodes per kind,
+// edges per type, orphaned nodes, the largest applications and growth over a window) so
+// operators can watch the model grow and spot anomalies such as agents creating nodes in a loop.
+package graphstats
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// largestApplications is how many applications Stats ranks by size
+const largestApplications = 5
+
+// dependentKinds are the kinds that only make sense when another node points at them: services
+// and resources belong to an application or the catalog, versions to a service
+var dependentKinds = map[string]bool{
+	graph.KindService:        true,
+	graph.KindServiceVersion: true,
+	graph.KindResource:       true,
+	graph.KindResourceType:   true,
+}
+
+// ApplicationSize is the number of nodes an application owns, directly or through its services
+type ApplicationSize struct {
+	Name  string `json:"name"`
+	Nodes int    `json:"nodes"`
+}
+
+// Growth is how much the graph changed over the collector's window
+type Growth struct {
+	Since       time.Time      `json:"since"`
+	Nodes       int            `json:"nodes"`
+	Edges       int            `json:"edges"`
+	NodesByKind map[string]int `json:"nodes_by_kind,omitempty"` // kinds whose count changed
+}
+
+// Stats is one computation of the graph statistics
+type Stats struct {
+	ComputedAt          time.Time         `json:"computed_at"`
+	Nodes               int               `json:"nodes"`
+	Edges               int               `json:"edges"`
+	NodesByKind         map[string]int    `json:"nodes_by_kind"`
+	EdgesByType         map[string]int    `json:"edges_by_type"`
+	OrphanedNodes       []string          `json:"orphaned_nodes"` // services, versions and resources nothing points at
+	OrphanRate          float64           `json:"orphan_rate"`    // orphaned share of those kinds
+	LargestApplications []ApplicationSize `json:"largest_applications"`
+	Growth              *Growth           `json:"growth,omitempty"`
+	Anomalies           []string          `json:"anomalies,omitempty"` // kinds that grew faster than the alert threshold
+}
+
+// Compute calculates the statistics of a graph snapshot
+func Compute(nodes map[string]*graph.Node, edges map[string][]graph.Edge, now time.Time) *Stats {
+	stats := &Stats{
+		ComputedAt:          now,
+		Nodes:               len(nodes),
+		NodesByKind:         map[string]int{},
+		EdgesByType:         map[string]int{},
+		OrphanedNodes:       []string{},
+		LargestApplications: []ApplicationSize{},
+	}
+	incoming := map[string]bool{}
+	for _, list := range edges {
+		for _, edge := range list {
+			stats.Edges++
+			stats.EdgesByType[edge.Type]++
+			incoming[edge.To] = true
+		}
+	}
+
+	dependent := 0
+	for id, node := range nodes {
+		stats.NodesByKind[node.Kind]++
+		if !dependentKinds[node.Kind] {
+			continue
+		}
+		dependent++
+		if !incoming[id] {
+			stats.OrphanedNodes = append(stats.OrphanedNodes, id)
+		}
+	}
+	sort.Strings(stats.OrphanedNodes)
+	if dependent > 0 {
+		stats.OrphanRate = float64(len(stats.OrphanedNodes)) / float64(dependent)
+	}
+
+	for id, node := range nodes {
+		if node.Kind == graph.KindApplication {
+			stats.LargestApplications = append(stats.LargestApplications, ApplicationSize{Name: id, Nodes: ownedNodes(edges, id)})
+		}
+	}
+	sort.Slice(stats.LargestApplications, func(i, j int) bool {
+		a, b := stats.LargestApplications[i], stats.LargestApplications[j]
+		if a.Nodes != b.Nodes {
+			return a.Nodes > b.Nodes
+		}
+		return a.Name < b.Name
+	})
+	if len(stats.LargestApplications) > largestApplications {
+		stats.LargestApplications = stats.LargestApplications[:largestApplications]
+	}
+	return stats
+}
+
+// ownedNodes counts the nodes reachable from root through owns and has_version edges
+func ownedNodes(edges map[string][]graph.Edge, root string) int {
+	seen := map[string]bool{root: true}
+	queue := []string{root}
+	for len(queue) > 0 {
+		from := queue[0]
+		queue = queue[1:]
+		for _, edge := range edges[from] {
+			if (edge.Type == graph.EdgeTypeOwns || edge.Type == graph.EdgeTypeHasVersion) && !seen[edge.To] {
+				seen[edge.To] = true
+				queue = append(queue, edge.To)
+			}
+		}
+	}
+	return len(seen) - 1
+}
+
+// sample is the size of the graph at one collection, kept for growth over the window
+type sample struct {
+	at          time.Time
+	nodes       int
+	edges       int
+	nodesByKind map[string]int
+}
+
+// Collector computes the statistics on an interval and keeps the latest result
+type Collector struct {
+	graph       *graph.GlobalGraph
+	window      time.Duration
+	growthAlert int // a kind gaining more nodes than this within the window is an anomaly; 0 disables
+	logger      *logging.Logger
+	now         func() time.Time
+
+	mu      sync.RWMutex
+	samples []sample
+	latest  *Stats
+}
+
+// NewCollector creates a collector reporting growth over window
+func NewCollector(globalGraph *graph.GlobalGraph, window time.Duration, growthAlert int) *Collector {
+	return &Collector{
+		graph:       globalGraph,
+		window:      window,
+		growthAlert: growthAlert,
+		logger:      logging.GetLogger().ForComponent("graph-stats"),
+		now:         time.Now,
+	}
+}
+
+// Collect computes the statistics now, records the sample for growth and returns the result
+func (c *Collector) Collect() (*Stats, error) {
+	nodes, err := c.graph.Nodes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph: %w", err)
+	}
+	edges, err := c.graph.Edges()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph: %w", err)
+	}
+	now := c.now().UTC()
+	stats := Compute(nodes, edges, now)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Drop samples older than the window, keeping the newest of them as the baseline
+	for len(c.samples) > 1 && !c.samples[1].at.After(now.Add(-c.window)) {
+		c.samples = c.samples[1:]
+	}
+	if len(c.samples) > 0 {
+		stats.Growth = growth(c.samples[0], stats)
+		for _, kind := range sortedKinds(stats.Growth.NodesByKind) {
+			if added := stats.Growth.NodesByKind[kind]; c.growthAlert > 0 && added > c.growthAlert {
+				anomaly := fmt.Sprintf("%d %s nodes added since %s", added, kind, stats.Growth.Since.Format(time.RFC3339))
+				stats.Anomalies = append(stats.Anomalies, anomaly)
+				c.logger.Warn("⚠️ Graph growth anomaly: %s", anomaly)
+			}
+		}
+	}
+	c.samples = append(c.samples, sample{at: now, nodes: stats.Nodes, edges: stats.Edges, nodesByKind: stats.NodesByKind})
+	c.latest = stats
+	return stats, nil
+}
+
+// Latest returns the most recent statistics, computing them if none were collected yet
+func (c *Collector) Latest() (*Stats, error) {
+	c.mu.RLock()
+	latest := c.latest
+	c.mu.RUnlock()
+	if latest != nil {
+		return latest, nil
+	}
+	return c.Collect()
+}
+
+// Run collects the statistics every interval until ctx is cancelled
+func (c *Collector) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := c.Collect(); err != nil {
+			c.logger.Warn("⚠️ Graph statistics failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func growth(baseline sample, stats *Stats) *Growth {
+	g := &Growth{Since: baseline.at, Nodes: stats.Nodes - baseline.nodes, Edges: stats.Edges - baseline.edges, NodesByKind: map[string]int{}}
+	for kind, count := range stats.NodesByKind {
+		if delta := count - baseline.nodesByKind[kind]; delta != 0 {
+			g.NodesByKind[kind] = delta
+		}
+	}
+	for kind, count := range baseline.nodesByKind {
+		if _, ok := stats.NodesByKind[kind]; !ok {
+			g.NodesByKind[kind] = -count
+		}
+	}
+	return g
+}
+
+func sortedKinds(counts map[string]int) []string {
+	kinds := make([]string, 0, len(counts))
+	for kind := range counts {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
@@ -0,0 +1,101 @@
+package graphstats
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newStatsTestGraph(t *testing.T) *graph.GlobalGraph {
+	t.Helper()
+	g := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	add := func(id, kind string) {
+		g.AddNode(&graph.Node{ID: id, Kind: kind, Metadata: map[string]interface{}{"name": id}, Spec: map[string]interface{}{}})
+	}
+	add("checkout", graph.KindApplication)
+	add("search", graph.KindApplication)
+	add("checkout-api", graph.KindService)
+	add("checkout-worker", graph.KindService)
+	add("checkout-api:1.0.0", graph.KindServiceVersion)
+	add("search-api", graph.KindService)
+	add("stray-api", graph.KindService)
+	add("prod", graph.KindEnvironment)
+	for _, edge := range [][3]string{
+		{"checkout", "checkout-api", graph.EdgeTypeOwns},
+		{"checkout", "checkout-worker", graph.EdgeTypeOwns},
+		{"checkout-api", "checkout-api:1.0.0", graph.EdgeTypeHasVersion},
+		{"search", "search-api", graph.EdgeTypeOwns},
+	} {
+		require.NoError(t, g.AddEdge(edge[0], edge[1], edge[2]))
+	}
+	return g
+}
+
+func TestCollect_ComputesCountsOrphansAndLargestApplications(t *testing.T) {
+	collector := NewCollector(newStatsTestGraph(t), time.Hour, 0)
+
+	stats, err := collector.Collect()
+	require.NoError(t, err)
+	assert.Equal(t, 8, stats.Nodes)
+	assert.Equal(t, 4, stats.Edges)
+	assert.Equal(t, 4, stats.NodesByKind[graph.KindService])
+	assert.Equal(t, 3, stats.EdgesByType[graph.EdgeTypeOwns])
+	assert.Equal(t, []string{"stray-api"}, stats.OrphanedNodes, "environments and applications are roots, not orphans")
+	assert.InDelta(t, 0.2, stats.OrphanRate, 0.001)
+	assert.Equal(t, []ApplicationSize{{Name: "checkout", Nodes: 3}, {Name: "search", Nodes: 1}}, stats.LargestApplications)
+	assert.Nil(t, stats.Growth, "growth needs an earlier sample")
+}
+
+func TestCollect_ReportsGrowthAndAnomalies(t *testing.T) {
+	g := newStatsTestGraph(t)
+	collector := NewCollector(g, time.Hour, 2)
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	collector.now = func() time.Time { return now }
+	_, err := collector.Collect()
+	require.NoError(t, err)
+
+	for _, id := range []string{"plan:1", "plan:2", "plan:3"} {
+		g.AddNode(&graph.Node{ID: id, Kind: graph.KindPlan, Metadata: map[string]interface{}{"name": id}, Spec: map[string]interface{}{}})
+	}
+	now = now.Add(10 * time.Minute)
+	stats, err := collector.Collect()
+	require.NoError(t, err)
+	require.NotNil(t, stats.Growth)
+	assert.Equal(t, 3, stats.Growth.Nodes)
+	assert.Equal(t, map[string]int{graph.KindPlan: 3}, stats.Growth.NodesByKind)
+	assert.Equal(t, []string{"3 plan nodes added since 2026-03-02T09:00:00Z"}, stats.Anomalies)
+
+	// Samples outside the window stop counting towards growth
+	now = now.Add(2 * time.Hour)
+	stats, err = collector.Collect()
+	require.NoError(t, err)
+	assert.Equal(t, 0, stats.Growth.Nodes)
+	assert.Empty(t, stats.Anomalies)
+
+	latest, err := collector.Latest()
+	require.NoError(t, err)
+	assert.Same(t, stats, latest)
+}
+
+func TestWritePrometheus(t *testing.T) {
+	stats, err := NewCollector(newStatsTestGraph(t), time.Hour, 0).Collect()
+	require.NoError(t, err)
+
+	var out strings.Builder
+	require.NoError(t, stats.WritePrometheus(&out))
+	for _, line := range []string{
+		"# TYPE ztdp_graph_nodes gauge",
+		`ztdp_graph_nodes{kind="service"} 4`,
+		`ztdp_graph_edges{type="owns"} 3`,
+		"ztdp_graph_orphaned_nodes 1",
+		"ztdp_graph_orphan_ratio 0.2",
+		`ztdp_graph_application_nodes{application="checkout"} 3`,
+	} {
+		assert.Contains(t, out.String(), line+"\n")
+	}
+	assert.NotContains(t, out.String(), "ztdp_graph_node_growth", "growth is only exported once known")
+}
@@ -0,0 +1,243 @@
+// Package graphwatch tells checks and subscribers which nodes and edges each graph save changes,
+// so policies can veto a save and state derived from the graph (such as cached policy
+// decisions) can drop exactly what a change affects.
+package graphwatch
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+)
+
+// Change operations and kinds
+const (
+	OpAdded   = "added"
+	OpUpdated = "updated"
+	OpRemoved = "removed"
+
+	KindNode = "node"
+	KindEdge = "edge"
+)
+
+// Change is one node or edge a save added, updated or removed
+type Change struct {
+	Op   string `json:"op"`
+	Kind string `json:"kind"`
+	ID   string `json:"id"`             // node ID, or the edge's source node
+	To   string `json:"to,omitempty"`   // edge target
+	Type string `json:"type,omitempty"` // edge type
+}
+
+// Save is what one save changed. Previous and Graph are shared with every check and subscriber,
+// so they must not be modified.
+type Save struct {
+	Context  context.Context // the context the save was made in, see graph.GlobalGraph.WithContext
+	Changes  []Change
+	Previous *graph.Graph // the graph as stored before the save
+	Graph    *graph.Graph // the graph as it is saved
+	Cleared  bool         // the graph was emptied by Clear
+}
+
+// Node returns the node a change is about: as saved, or as it was for removals
+func (s Save) Node(c Change) *graph.Node {
+	if c.Op == OpRemoved {
+		return s.Previous.Nodes[c.ID]
+	}
+	return s.Graph.Nodes[c.ID]
+}
+
+// PreviousNode returns the node an update or removal changed as it was before, or nil
+func (s Save) PreviousNode(c Change) *graph.Node {
+	if c.Op == OpAdded {
+		return nil
+	}
+	return s.Previous.Nodes[c.ID]
+}
+
+// Edge returns the edge a change is about: as saved, or as it was for removals
+func (s Save) Edge(c Change) *graph.Edge {
+	g := s.Graph
+	if c.Op == OpRemoved {
+		g = s.Previous
+	}
+	for i, edge := range g.Edges[c.ID] {
+		if edge.To == c.To && edge.Type == c.Type {
+			return &g.Edges[c.ID][i]
+		}
+	}
+	return nil
+}
+
+// Check is called with what a save changes before it is stored; an error rejects the save
+type Check func(save Save) error
+
+// Subscriber receives what each stored save changed. It is called synchronously after the
+// save, in save order, so it must not block or write to the graph.
+type Subscriber func(save Save)
+
+// Backend wraps a graph backend, finds what each save changes once and passes it to the checks
+// and subscribers, so everything watching the graph shares one comparison. Callers modify the
+// graph in place before saving, so changes are found by comparing with a copy of the graph
+// taken at the previous save. Only saves made through this instance are seen.
+type Backend struct {
+	graph.GraphBackend
+
+	mu          sync.Mutex
+	previous    *graph.Graph
+	checks      []Check
+	subscribers []Subscriber
+}
+
+// NewBackend wraps inner; the graph already stored in it is the baseline for the first save
+func NewBackend(inner graph.GraphBackend) *Backend {
+	b := &Backend{GraphBackend: inner, previous: graph.NewGraph()}
+	if current, err := inner.LoadGlobal(); err == nil && current != nil {
+		b.previous = current.Clone()
+	}
+	return b
+}
+
+// AddCheck registers fn for every later save; checks run in the order they were added
+func (b *Backend) AddCheck(fn Check) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.checks = append(b.checks, fn)
+}
+
+// Subscribe registers fn for the changes of every later save
+func (b *Backend) Subscribe(fn Subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, fn)
+}
+
+// SaveGlobal runs the checks on what changed since the previous save, saves the graph unless
+// one of them rejected it and then notifies the subscribers. A rejected save leaves the stored
+// graph as it was.
+func (b *Backend) SaveGlobal(g *graph.Graph) error {
+	return b.SaveGlobalContext(context.Background(), g)
+}
+
+// SaveGlobalContext is SaveGlobal for a save made in ctx
+func (b *Backend) SaveGlobalContext(ctx context.Context, g *graph.Graph) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	save := Save{Context: ctx, Changes: Diff(b.previous, g), Previous: b.previous, Graph: g}
+	if len(save.Changes) > 0 {
+		for _, check := range b.checks {
+			if err := check(save); err != nil {
+				// The graph was changed in place; put it back so the rejected change is not kept
+				*g = *b.previous.Clone()
+				return err
+			}
+		}
+	}
+	if err := b.GraphBackend.SaveGlobal(g); err != nil {
+		return err
+	}
+	b.previous = g.Clone()
+	save.Graph = b.previous
+	b.notify(save)
+	return nil
+}
+
+// Clear empties the graph and reports everything in it as removed
+func (b *Backend) Clear() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.GraphBackend.Clear(); err != nil {
+		return err
+	}
+	empty := graph.NewGraph()
+	save := Save{Context: context.Background(), Changes: Diff(b.previous, empty), Previous: b.previous, Graph: empty, Cleared: true}
+	b.previous = empty
+	b.notify(save)
+	return nil
+}
+
+// Ping passes through to backends that can verify connectivity
+func (b *Backend) Ping(ctx context.Context) error {
+	if pinger, ok := b.GraphBackend.(graph.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (b *Backend) notify(save Save) {
+	if len(save.Changes) == 0 {
+		return
+	}
+	for _, fn := range b.subscribers {
+		fn(save)
+	}
+}
+
+// Diff lists the changes from before to after, nodes first, in a stable order
+func Diff(before, after *graph.Graph) []Change {
+	var changes []Change
+	for id, node := range after.Nodes {
+		previous, existed := before.Nodes[id]
+		switch {
+		case !existed:
+			changes = append(changes, Change{Op: OpAdded, Kind: KindNode, ID: id})
+		case !reflect.DeepEqual(previous, node):
+			changes = append(changes, Change{Op: OpUpdated, Kind: KindNode, ID: id})
+		}
+	}
+	for id := range before.Nodes {
+		if _, exists := after.Nodes[id]; !exists {
+			changes = append(changes, Change{Op: OpRemoved, Kind: KindNode, ID: id})
+		}
+	}
+
+	beforeEdges, afterEdges := edgesByKey(before), edgesByKey(after)
+	for key, edge := range afterEdges {
+		previous, existed := beforeEdges[key]
+		switch {
+		case !existed:
+			changes = append(changes, edgeChange(OpAdded, key, edge))
+		case !reflect.DeepEqual(previous, edge):
+			changes = append(changes, edgeChange(OpUpdated, key, edge))
+		}
+	}
+	for key, edge := range beforeEdges {
+		if _, exists := afterEdges[key]; !exists {
+			changes = append(changes, edgeChange(OpRemoved, key, edge))
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+		if a.Kind != b.Kind {
+			return a.Kind == KindNode
+		}
+		if a.ID != b.ID {
+			return a.ID < b.ID
+		}
+		if a.To != b.To {
+			return a.To < b.To
+		}
+		return a.Type < b.Type
+	})
+	return changes
+}
+
+// edgeKey identifies an edge by its source, target and type
+type edgeKey struct{ from, to, edgeType string }
+
+func edgesByKey(g *graph.Graph) map[edgeKey]graph.Edge {
+	edges := map[edgeKey]graph.Edge{}
+	for from, list := range g.Edges {
+		for _, edge := range list {
+			edges[edgeKey{from, edge.To, edge.Type}] = edge
+		}
+	}
+	return edges
+}
+
+func edgeChange(op string, key edgeKey, edge graph.Edge) Change {
+	return Change{Op: op, Kind: KindEdge, ID: key.from, To: edge.To, Type: edge.Type}
+}
@@ -0,0 +1,66 @@
+package graphwatch
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackend_NotifiesChangedNodesAndEdges(t *testing.T) {
+	backend := NewBackend(graph.NewMemoryGraph())
+	var saves [][]Change
+	backend.Subscribe(func(save Save) { saves = append(saves, save.Changes) })
+	gg := graph.NewGlobalGraph(backend)
+
+	node := func(id, kind string) *graph.Node {
+		return &graph.Node{ID: id, Kind: kind, Metadata: map[string]interface{}{"name": id}, Spec: map[string]interface{}{}}
+	}
+	require.NoError(t, gg.AddNode(node("checkout", graph.KindApplication)))
+	require.NoError(t, gg.AddNode(node("checkout-api", graph.KindService)))
+	require.NoError(t, gg.AddEdge("checkout", "checkout-api", graph.EdgeTypeOwns))
+	require.NoError(t, gg.Save(), "saving an unchanged graph notifies nobody")
+	require.NoError(t, gg.DeleteNode("checkout-api"))
+
+	require.Len(t, saves, 4)
+	assert.Equal(t, []Change{{Op: OpAdded, Kind: KindNode, ID: "checkout"}}, saves[0])
+	assert.Equal(t, []Change{{Op: OpAdded, Kind: KindEdge, ID: "checkout", To: "checkout-api", Type: graph.EdgeTypeOwns}}, saves[2])
+	assert.Equal(t, []Change{
+		{Op: OpRemoved, Kind: KindNode, ID: "checkout-api"},
+		{Op: OpRemoved, Kind: KindEdge, ID: "checkout", To: "checkout-api", Type: graph.EdgeTypeOwns},
+	}, saves[3])
+
+	require.NoError(t, backend.Clear())
+	require.Len(t, saves, 5)
+	assert.Equal(t, []Change{{Op: OpRemoved, Kind: KindNode, ID: "checkout"}}, saves[4])
+}
+
+func TestBackend_ChecksRejectSaves(t *testing.T) {
+	backend := NewBackend(graph.NewMemoryGraph())
+	var notified []Save
+	backend.Subscribe(func(save Save) { notified = append(notified, save) })
+	backend.AddCheck(func(save Save) error {
+		for _, change := range save.Changes {
+			if node := save.Node(change); change.Op == OpUpdated && node.Metadata["owner"] == nil {
+				return errors.New("updated nodes need an owner")
+			}
+		}
+		return nil
+	})
+	gg := graph.NewGlobalGraph(backend)
+
+	require.NoError(t, gg.AddNode(&graph.Node{ID: "checkout", Kind: graph.KindApplication, Metadata: map[string]interface{}{"name": "checkout"}}))
+	err := gg.UpdateNode(&graph.Node{ID: "checkout", Kind: graph.KindApplication, Metadata: map[string]interface{}{"name": "renamed"}})
+	assert.EqualError(t, err, "updated nodes need an owner")
+	node, err := gg.GetNode("checkout")
+	require.NoError(t, err)
+	assert.Equal(t, "checkout", node.Metadata["name"], "the rejected save is not kept")
+
+	require.NoError(t, gg.UpdateNode(&graph.Node{ID: "checkout", Kind: graph.KindApplication, Metadata: map[string]interface{}{"name": "renamed", "owner": "team-a"}}))
+	require.Len(t, notified, 2, "subscribers only see stored saves")
+	change := notified[1].Changes[0]
+	assert.Equal(t, "renamed", notified[1].Node(change).Metadata["name"])
+	assert.Equal(t, "checkout", notified[1].PreviousNode(change).Metadata["name"])
+}
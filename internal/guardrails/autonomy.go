@@ -0,0 +1,249 @@
+package guardrails
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/features"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// Autonomy is how far the AI may act on its own for an application or tenant
+type Autonomy string
+
+const (
+	// AutonomyObserve lets the AI answer questions and report, never change anything
+	AutonomyObserve Autonomy = "observe"
+	// AutonomySuggest lets the AI propose actions; people carry them out
+	AutonomySuggest Autonomy = "suggest"
+	// AutonomyApproval runs AI actions once someone with an approver role asks for them
+	AutonomyApproval Autonomy = "execute-with-approval"
+	// AutonomyFullAuto runs AI actions the other guardrails allow
+	AutonomyFullAuto Autonomy = "full-auto"
+)
+
+// AutonomyLevels lists the levels, most restrictive first
+var AutonomyLevels = []Autonomy{AutonomyObserve, AutonomySuggest, AutonomyApproval, AutonomyFullAuto}
+
+// Valid reports whether a is a known level
+func (a Autonomy) Valid() bool {
+	for _, level := range AutonomyLevels {
+		if a == level {
+			return true
+		}
+	}
+	return false
+}
+
+// Autonomy scopes; the application's setting wins over its tenant's, which wins over the
+// configured default
+const (
+	AutonomyScopeTenant      = "tenant"
+	AutonomyScopeApplication = "application"
+)
+
+// ErrAutonomyNotFound is returned when no autonomy level is set for a scope and name
+var ErrAutonomyNotFound = errors.New("autonomy setting not found")
+
+// autonomyNodePrefix namespaces autonomy nodes so they cannot collide with application names
+const autonomyNodePrefix = "autonomy:"
+
+// AutonomySetting sets the autonomy level of a tenant or application
+type AutonomySetting struct {
+	Scope     string    `json:"scope"`
+	Name      string    `json:"name"`
+	Level     Autonomy  `json:"level"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Validate checks the scope, name and level
+func (s AutonomySetting) Validate() error {
+	if s.Scope != AutonomyScopeTenant && s.Scope != AutonomyScopeApplication {
+		return fmt.Errorf("unknown autonomy scope %q (use tenant or application)", s.Scope)
+	}
+	if s.Name == "" {
+		return fmt.Errorf("%s autonomy settings need a name", s.Scope)
+	}
+	if !s.Level.Valid() {
+		return fmt.Errorf("unknown autonomy level %q (use observe, suggest, execute-with-approval or full-auto)", s.Level)
+	}
+	return nil
+}
+
+func autonomyNodeID(scope, name string) string {
+	return autonomyNodePrefix + scope + ":" + name
+}
+
+// AutonomyStore keeps autonomy settings in the global graph
+type AutonomyStore struct {
+	graph  *graph.GlobalGraph
+	logger *logging.Logger
+}
+
+// NewAutonomyStore creates a store backed by the global graph
+func NewAutonomyStore(globalGraph *graph.GlobalGraph) *AutonomyStore {
+	return &AutonomyStore{graph: globalGraph, logger: logging.GetLogger().ForComponent("guardrails")}
+}
+
+// Set creates or replaces a setting
+func (s *AutonomyStore) Set(setting *AutonomySetting) error {
+	if err := setting.Validate(); err != nil {
+		return err
+	}
+	if setting.Scope == AutonomyScopeApplication {
+		if node, _ := s.graph.GetNode(setting.Name); node == nil || node.Kind != graph.KindApplication {
+			return fmt.Errorf("application %q not found", setting.Name)
+		}
+	}
+	setting.UpdatedAt = time.Now().UTC()
+
+	data, err := json.Marshal(setting)
+	if err != nil {
+		return fmt.Errorf("failed to encode autonomy setting: %w", err)
+	}
+	var spec map[string]interface{}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return fmt.Errorf("failed to encode autonomy setting: %w", err)
+	}
+	node := &graph.Node{
+		ID:       autonomyNodeID(setting.Scope, setting.Name),
+		Kind:     graph.KindAutonomy,
+		Metadata: map[string]interface{}{"name": autonomyNodeID(setting.Scope, setting.Name), "scope": setting.Scope},
+		Spec:     spec,
+	}
+	if existing, _ := s.graph.GetNode(node.ID); existing != nil {
+		if err := s.graph.UpdateNode(node); err != nil {
+			return fmt.Errorf("failed to update autonomy setting: %w", err)
+		}
+	} else if err := s.graph.AddNode(node); err != nil {
+		return fmt.Errorf("failed to add autonomy setting: %w", err)
+	}
+	s.logger.Info("🎚️ AI autonomy for %s %s set to %s", setting.Scope, setting.Name, setting.Level)
+	return s.graph.Save()
+}
+
+// Get returns the setting for a scope and name
+func (s *AutonomyStore) Get(scope, name string) (*AutonomySetting, error) {
+	node, _ := s.graph.GetNode(autonomyNodeID(scope, name))
+	if node == nil || node.Kind != graph.KindAutonomy {
+		return nil, ErrAutonomyNotFound
+	}
+	return nodeToAutonomy(node)
+}
+
+// List returns every setting, tenants first, then by name
+func (s *AutonomyStore) List() ([]*AutonomySetting, error) {
+	nodes, err := s.graph.Nodes()
+	if err != nil {
+		return nil, err
+	}
+	settings := []*AutonomySetting{}
+	for _, node := range nodes {
+		if node.Kind != graph.KindAutonomy {
+			continue
+		}
+		setting, err := nodeToAutonomy(node)
+		if err != nil {
+			s.logger.Warn("⚠️ Skipping malformed autonomy node %s: %v", node.ID, err)
+			continue
+		}
+		settings = append(settings, setting)
+	}
+	sort.Slice(settings, func(i, j int) bool {
+		if settings[i].Scope != settings[j].Scope {
+			return settings[i].Scope == AutonomyScopeTenant
+		}
+		return settings[i].Name < settings[j].Name
+	})
+	return settings, nil
+}
+
+// Delete removes a setting; the tenant's level or the default applies again
+func (s *AutonomyStore) Delete(scope, name string) error {
+	if _, err := s.Get(scope, name); err != nil {
+		return err
+	}
+	if err := s.graph.DeleteNode(autonomyNodeID(scope, name)); err != nil {
+		return fmt.Errorf("failed to delete autonomy setting: %w", err)
+	}
+	s.logger.Info("🎚️ AI autonomy setting for %s %s removed", scope, name)
+	return s.graph.Save()
+}
+
+// Resolve returns the level set for the application, else for the tenant, and the scope that
+// set it; "" when neither has a setting
+func (s *AutonomyStore) Resolve(application, tenant string) (Autonomy, string) {
+	if application != "" {
+		if setting, err := s.Get(AutonomyScopeApplication, application); err == nil {
+			return setting.Level, AutonomyScopeApplication
+		}
+	}
+	if tenant != "" {
+		if setting, err := s.Get(AutonomyScopeTenant, tenant); err == nil {
+			return setting.Level, AutonomyScopeTenant
+		}
+	}
+	return "", ""
+}
+
+func nodeToAutonomy(node *graph.Node) (*AutonomySetting, error) {
+	data, err := json.Marshal(node.Spec)
+	if err != nil {
+		return nil, err
+	}
+	var setting AutonomySetting
+	if err := json.Unmarshal(data, &setting); err != nil {
+		return nil, err
+	}
+	return &setting, nil
+}
+
+var (
+	autonomyMu    sync.RWMutex
+	autonomyStore *AutonomyStore
+)
+
+// SetAutonomyStore sets where per-tenant and per-application levels are read from (called from
+// main.go); without a store every AI action gets the configured default level
+func SetAutonomyStore(store *AutonomyStore) {
+	autonomyMu.Lock()
+	defer autonomyMu.Unlock()
+	autonomyStore = store
+}
+
+// GetAutonomyStore returns the store levels are read from, or nil
+func GetAutonomyStore() *AutonomyStore {
+	autonomyMu.RLock()
+	defer autonomyMu.RUnlock()
+	return autonomyStore
+}
+
+// DefaultAutonomy returns the level of tenants and applications without their own
+func (e *Engine) DefaultAutonomy() Autonomy {
+	return e.cfg.DefaultAutonomy
+}
+
+// autonomyFor returns the level that applies to an action and what set it, e.g. "application
+// checkout". Only actions an agent takes on behalf of the AI have one; direct API calls are made
+// by people.
+func (e *Engine) autonomyFor(ctx context.Context, action Action) (Autonomy, string) {
+	if logging.AgentIDFromContext(ctx) == "" {
+		return "", ""
+	}
+	tenant := features.EvaluationContextFrom(ctx).Tenant
+	if store := GetAutonomyStore(); store != nil {
+		switch level, scope := store.Resolve(action.Application, tenant); scope {
+		case AutonomyScopeApplication:
+			return level, "application " + action.Application
+		case AutonomyScopeTenant:
+			return level, "tenant " + tenant
+		}
+	}
+	return e.cfg.DefaultAutonomy, "platform default"
+}
@@ -0,0 +1,84 @@
+package guardrails
+
+import (
+	"context"
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/features"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAutonomyTestStore(t *testing.T) *AutonomyStore {
+	t.Helper()
+	g := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	for _, app := range []string{"checkout", "search"} {
+		require.NoError(t, g.AddNode(&graph.Node{ID: app, Kind: graph.KindApplication, Metadata: map[string]interface{}{"name": app}}))
+	}
+	store := NewAutonomyStore(g)
+	SetAutonomyStore(store)
+	t.Cleanup(func() { SetAutonomyStore(nil) })
+	return store
+}
+
+func TestAutonomyStore_SetAndResolve(t *testing.T) {
+	store := newAutonomyTestStore(t)
+	assert.ErrorContains(t, store.Set(&AutonomySetting{Scope: AutonomyScopeApplication, Name: "checkout", Level: "reckless"}), "unknown autonomy level")
+	assert.ErrorContains(t, store.Set(&AutonomySetting{Scope: AutonomyScopeApplication, Name: "missing", Level: AutonomyObserve}), "not found")
+	assert.ErrorContains(t, store.Set(&AutonomySetting{Scope: "cluster", Name: "x", Level: AutonomyObserve}), "unknown autonomy scope")
+
+	require.NoError(t, store.Set(&AutonomySetting{Scope: AutonomyScopeTenant, Name: "acme", Level: AutonomySuggest}))
+	require.NoError(t, store.Set(&AutonomySetting{Scope: AutonomyScopeApplication, Name: "checkout", Level: AutonomyObserve}))
+	require.NoError(t, store.Set(&AutonomySetting{Scope: AutonomyScopeApplication, Name: "checkout", Level: AutonomyApproval}))
+
+	settings, err := store.List()
+	require.NoError(t, err)
+	require.Len(t, settings, 2)
+	assert.Equal(t, AutonomyScopeTenant, settings[0].Scope)
+	assert.Equal(t, AutonomyApproval, settings[1].Level)
+
+	level, scope := store.Resolve("checkout", "acme")
+	assert.Equal(t, AutonomyApproval, level)
+	assert.Equal(t, AutonomyScopeApplication, scope)
+	level, scope = store.Resolve("search", "acme")
+	assert.Equal(t, AutonomySuggest, level)
+	assert.Equal(t, AutonomyScopeTenant, scope)
+	_, scope = store.Resolve("search", "other")
+	assert.Empty(t, scope)
+
+	require.NoError(t, store.Delete(AutonomyScopeApplication, "checkout"))
+	assert.Equal(t, ErrAutonomyNotFound, store.Delete(AutonomyScopeApplication, "checkout"))
+}
+
+func TestCheck_AutonomyLevels(t *testing.T) {
+	store := newAutonomyTestStore(t)
+	e, err := New(Config{DefaultAutonomy: AutonomySuggest})
+	require.NoError(t, err)
+
+	ai := logging.WithAgentID(features.WithTenant(context.Background(), "acme"), "application-agent")
+	create := Action{Operation: OperationCreate, Kind: "service", Targets: []string{"checkout-api"}, Application: "checkout"}
+
+	decision := e.Check(ai, create)
+	assert.Equal(t, Propose, decision.Outcome)
+	assert.Contains(t, decision.Reasons[0], "platform default")
+	assert.True(t, e.Check(context.Background(), create).Allowed(), "direct API calls have no autonomy level")
+
+	require.NoError(t, store.Set(&AutonomySetting{Scope: AutonomyScopeTenant, Name: "acme", Level: AutonomyObserve}))
+	decision = e.Check(ai, create)
+	assert.Equal(t, Reject, decision.Outcome)
+	assert.Contains(t, decision.Reasons[0], "tenant acme is observe")
+
+	require.NoError(t, store.Set(&AutonomySetting{Scope: AutonomyScopeApplication, Name: "checkout", Level: AutonomyApproval}))
+	decision = e.Check(ai, create)
+	assert.Equal(t, RequireApproval, decision.Outcome)
+	assert.Equal(t, AutonomyApproval, decision.Autonomy)
+	assert.True(t, e.Check(WithRole(ai, "admin"), create).Allowed(), "approver roles skip approval")
+
+	require.NoError(t, store.Set(&AutonomySetting{Scope: AutonomyScopeApplication, Name: "checkout", Level: AutonomyFullAuto}))
+	assert.True(t, e.Check(ai, create).Allowed())
+
+	_, err = New(Config{DefaultAutonomy: "reckless"})
+	assert.Error(t, err)
+}
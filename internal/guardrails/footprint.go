@@ -0,0 +1,37 @@
+package guardrails
+
+import (
+	"sort"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+)
+
+// Footprint returns rootID plus every node it transitively owns, i.e. the nodes an action on
+// rootID affects. Use it to fill Action.Targets so blast radius limits see the whole subtree.
+func Footprint(g *graph.GlobalGraph, rootID string) []string {
+	edges, err := g.Edges()
+	if err != nil {
+		return []string{rootID}
+	}
+
+	seen := map[string]bool{rootID: true}
+	queue := []string{rootID}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, edge := range edges[id] {
+			if edge.Type != graph.EdgeTypeOwns || seen[edge.To] {
+				continue
+			}
+			seen[edge.To] = true
+			queue = append(queue, edge.To)
+		}
+	}
+
+	nodes := make([]string, 0, len(seen))
+	for id := range seen {
+		nodes = append(nodes, id)
+	}
+	sort.Strings(nodes)
+	return nodes
+}
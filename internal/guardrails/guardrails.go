@@ -0,0 +1,296 @@
+// Package guardrails checks actions proposed by AI agents before they are executed: which
+// operations the caller's role may perform, naming conventions, environment restrictions and
+// how many nodes a single plan may touch (its blast radius). Autonomy levels set per tenant or
+// application decide whether what passes these checks runs, waits for approval or is only proposed.
+package guardrails
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// Operations that change the graph; reads are never checked
+const (
+	OperationCreate = "create"
+	OperationUpdate = "update"
+	OperationDelete = "delete"
+	OperationDeploy = "deploy"
+)
+
+// AllOperations grants every operation when listed for a role
+const AllOperations = "*"
+
+// Outcome is the guardrail verdict for a proposed action
+type Outcome string
+
+const (
+	Allow           Outcome = "allow"
+	RequireApproval Outcome = "require_approval"
+	Reject          Outcome = "reject"
+	// Propose means the action may only be proposed to the user, not executed
+	Propose Outcome = "propose"
+)
+
+// Action is an operation an agent is about to execute because the AI proposed it
+type Action struct {
+	Operation   string
+	Kind        string   // kind of the nodes being changed
+	Targets     []string // every node the action creates, changes or removes
+	Environment string   // target environment, if any
+	Application string   // application the action belongs to, if any; selects its autonomy level
+	Source      string   // agent proposing the action
+}
+
+// Decision is the result of checking an action
+type Decision struct {
+	Outcome  Outcome  `json:"outcome"`
+	Role     string   `json:"role"`
+	Autonomy Autonomy `json:"autonomy,omitempty"` // level the action was checked at; empty for direct API calls
+	Reasons  []string `json:"reasons,omitempty"`
+}
+
+// Allowed reports whether the action may run
+func (d Decision) Allowed() bool {
+	return d.Outcome == Allow
+}
+
+// Message explains a rejection or approval requirement to the user
+func (d Decision) Message() string {
+	switch d.Outcome {
+	case Reject:
+		return "Blocked by platform guardrails: " + strings.Join(d.Reasons, "; ")
+	case RequireApproval:
+		return fmt.Sprintf("This action requires approval (%s). Ask someone with an approver role to run it.", strings.Join(d.Reasons, "; "))
+	case Propose:
+		return fmt.Sprintf("Proposed, not executed (%s). Run it yourself, or ask a platform admin to raise the AI autonomy level.", strings.Join(d.Reasons, "; "))
+	default:
+		return ""
+	}
+}
+
+// Config customizes an Engine. Zero values fall back to the built-in defaults.
+type Config struct {
+	Disabled              bool
+	DefaultRole           string              // role assumed when the caller does not state one
+	Roles                 map[string][]string // operations per role, merged over the built-in roles
+	ApproverRoles         []string            // roles whose requests skip approval (never rejection)
+	Naming                map[string]string   // regular expression new node names of a kind must match
+	ProtectedEnvironments []string            // changes targeting these environments require approval
+	RoleEnvironments      map[string][]string // environments a role may change; unlisted roles may change any
+	MaxDeletes            int                 // deletions per plan above this are rejected; 0 means no limit
+	MaxTargets            int                 // plans touching more nodes require approval; 0 means no limit
+	DefaultAutonomy       Autonomy            // level for AI actions of tenants and applications without one; full-auto when empty
+}
+
+// DefaultRoles are the built-in caller roles
+func DefaultRoles() map[string][]string {
+	return map[string][]string{
+		"viewer":    {},
+		"developer": {OperationCreate, OperationUpdate, OperationDeploy},
+		"operator":  {OperationCreate, OperationUpdate, OperationDelete, OperationDeploy},
+		"admin":     {AllOperations},
+	}
+}
+
+// Engine evaluates proposed actions against the configured rules
+type Engine struct {
+	cfg    Config
+	roles  map[string]map[string]bool
+	naming map[string]*regexp.Regexp
+}
+
+// New builds an engine from the built-in roles plus cfg
+func New(cfg Config) (*Engine, error) {
+	if cfg.DefaultRole == "" {
+		cfg.DefaultRole = "operator"
+	}
+	if len(cfg.ApproverRoles) == 0 {
+		cfg.ApproverRoles = []string{"admin"}
+	}
+	if cfg.MaxDeletes < 0 || cfg.MaxTargets < 0 {
+		return nil, fmt.Errorf("guardrail limits must not be negative")
+	}
+	if cfg.DefaultAutonomy == "" {
+		cfg.DefaultAutonomy = AutonomyFullAuto
+	}
+	if !cfg.DefaultAutonomy.Valid() {
+		return nil, fmt.Errorf("unknown default autonomy level %q", cfg.DefaultAutonomy)
+	}
+
+	e := &Engine{
+		cfg:    cfg,
+		roles:  map[string]map[string]bool{},
+		naming: map[string]*regexp.Regexp{},
+	}
+
+	roles := DefaultRoles()
+	for role, ops := range cfg.Roles {
+		roles[role] = ops
+	}
+	for role, ops := range roles {
+		e.roles[role] = map[string]bool{}
+		for _, op := range ops {
+			e.roles[role][strings.ToLower(op)] = true
+		}
+	}
+	if _, ok := e.roles[cfg.DefaultRole]; !ok {
+		return nil, fmt.Errorf("guardrail default role %q is not defined", cfg.DefaultRole)
+	}
+
+	for kind, pattern := range cfg.Naming {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("guardrail naming rule for %s: %w", kind, err)
+		}
+		e.naming[kind] = re
+	}
+	return e, nil
+}
+
+var (
+	defaultMu     sync.RWMutex
+	defaultEngine = mustNew(Config{MaxDeletes: 3, MaxTargets: 20})
+)
+
+func mustNew(cfg Config) *Engine {
+	e, err := New(cfg)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+// Default returns the process-wide engine
+func Default() *Engine {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultEngine
+}
+
+// SetDefault replaces the process-wide engine (called from main.go with configured rules)
+func SetDefault(e *Engine) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultEngine = e
+}
+
+// Check decides whether the action may run for the caller role found in ctx.
+// Violations of role, naming, environment or delete limits reject the action; protected
+// environments and large plans require approval unless the caller holds an approver role.
+// For actions taken by agents, the autonomy level then rejects everything under observe,
+// only proposes under suggest and requires approval under execute-with-approval.
+func (e *Engine) Check(ctx context.Context, action Action) Decision {
+	role := RoleFromContext(ctx)
+	if role == "" {
+		role = e.cfg.DefaultRole
+	}
+	if e.cfg.Disabled {
+		return Decision{Outcome: Allow, Role: role}
+	}
+
+	var rejections []string
+	operation := strings.ToLower(action.Operation)
+
+	if ops, ok := e.roles[role]; !ok {
+		rejections = append(rejections, fmt.Sprintf("unknown role %q", role))
+	} else if !ops[operation] && !ops[AllOperations] {
+		rejections = append(rejections, fmt.Sprintf("role %s may not %s %s", role, operation, kindLabel(action.Kind)))
+	}
+
+	if pattern, ok := e.naming[action.Kind]; ok && operation == OperationCreate {
+		for _, target := range action.Targets {
+			if !pattern.MatchString(target) {
+				rejections = append(rejections, fmt.Sprintf("name %q does not match the %s naming convention %s", target, action.Kind, pattern))
+			}
+		}
+	}
+
+	if allowed, restricted := e.cfg.RoleEnvironments[role]; restricted && action.Environment != "" && !contains(allowed, action.Environment) {
+		rejections = append(rejections, fmt.Sprintf("role %s may not change environment %s", role, action.Environment))
+	}
+
+	if operation == OperationDelete && e.cfg.MaxDeletes > 0 && len(action.Targets) > e.cfg.MaxDeletes {
+		rejections = append(rejections, fmt.Sprintf("plan deletes %d nodes, more than the limit of %d", len(action.Targets), e.cfg.MaxDeletes))
+	}
+
+	autonomy, setBy := e.autonomyFor(ctx, action)
+	if autonomy == AutonomyObserve {
+		rejections = append(rejections, fmt.Sprintf("AI autonomy for %s is observe, so the AI only reports", setBy))
+	}
+
+	if len(rejections) > 0 {
+		return e.decide(action, Decision{Outcome: Reject, Role: role, Autonomy: autonomy, Reasons: rejections})
+	}
+	if autonomy == AutonomySuggest {
+		return e.decide(action, Decision{Outcome: Propose, Role: role, Autonomy: autonomy,
+			Reasons: []string{fmt.Sprintf("AI autonomy for %s is suggest", setBy)}})
+	}
+
+	var approvals []string
+	if action.Environment != "" && contains(e.cfg.ProtectedEnvironments, action.Environment) {
+		approvals = append(approvals, fmt.Sprintf("environment %s is protected", action.Environment))
+	}
+	if e.cfg.MaxTargets > 0 && len(action.Targets) > e.cfg.MaxTargets {
+		approvals = append(approvals, fmt.Sprintf("plan touches %d nodes, more than %d", len(action.Targets), e.cfg.MaxTargets))
+	}
+	if autonomy == AutonomyApproval {
+		approvals = append(approvals, fmt.Sprintf("AI autonomy for %s is execute-with-approval", setBy))
+	}
+	if len(approvals) > 0 && !contains(e.cfg.ApproverRoles, role) {
+		return e.decide(action, Decision{Outcome: RequireApproval, Role: role, Autonomy: autonomy, Reasons: approvals})
+	}
+
+	return e.decide(action, Decision{Outcome: Allow, Role: role, Autonomy: autonomy, Reasons: approvals})
+}
+
+func (e *Engine) decide(action Action, decision Decision) Decision {
+	logger := logging.GetLogger().ForComponent("guardrails")
+	switch decision.Outcome {
+	case Reject:
+		logger.Warn("🛑 Guardrails rejected %s %s from %s (role %s): %s", action.Operation, kindLabel(action.Kind), action.Source, decision.Role, strings.Join(decision.Reasons, "; "))
+	case Propose:
+		logger.Info("💡 Guardrails only allow proposing %s %s from %s (role %s): %s", action.Operation, kindLabel(action.Kind), action.Source, decision.Role, strings.Join(decision.Reasons, "; "))
+	case RequireApproval:
+		logger.Warn("✋ Guardrails require approval for %s %s from %s (role %s): %s", action.Operation, kindLabel(action.Kind), action.Source, decision.Role, strings.Join(decision.Reasons, "; "))
+	default:
+		logger.Debug("✅ Guardrails allowed %s %s from %s (role %s)", action.Operation, kindLabel(action.Kind), action.Source, decision.Role)
+	}
+	return decision
+}
+
+func kindLabel(kind string) string {
+	if kind == "" {
+		return "nodes"
+	}
+	return kind + "s"
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// contextKey is unexported so only this package can set the caller role on a context
+type contextKey string
+
+const roleKey contextKey = "caller_role"
+
+// WithRole returns a context carrying the caller's role
+func WithRole(ctx context.Context, role string) context.Context {
+	return context.WithValue(ctx, roleKey, role)
+}
+
+// RoleFromContext returns the caller's role, or "" if none was set
+func RoleFromContext(ctx context.Context) string {
+	role, _ := ctx.Value(roleKey).(string)
+	return role
+}
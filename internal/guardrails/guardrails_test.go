@@ -0,0 +1,102 @@
+package guardrails
+
+import (
+	"context"
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheck_RoleAllowlist(t *testing.T) {
+	e, err := New(Config{})
+	require.NoError(t, err)
+
+	del := Action{Operation: OperationDelete, Kind: "application", Targets: []string{"checkout"}}
+
+	assert.True(t, e.Check(context.Background(), del).Allowed(), "default role operator may delete")
+
+	decision := e.Check(WithRole(context.Background(), "developer"), del)
+	assert.Equal(t, Reject, decision.Outcome)
+	assert.Contains(t, decision.Message(), "role developer may not delete applications")
+
+	decision = e.Check(WithRole(context.Background(), "intern"), del)
+	assert.Equal(t, Reject, decision.Outcome)
+	assert.Contains(t, decision.Reasons[0], "unknown role")
+
+	custom, err := New(Config{Roles: map[string][]string{"developer": {"create", "update", "deploy", "delete"}}})
+	require.NoError(t, err)
+	assert.True(t, custom.Check(WithRole(context.Background(), "developer"), del).Allowed())
+}
+
+func TestCheck_NamingAndEnvironmentRestrictions(t *testing.T) {
+	e, err := New(Config{
+		Naming:           map[string]string{"application": `^[a-z][a-z0-9-]*$`},
+		RoleEnvironments: map[string][]string{"developer": {"dev", "staging"}},
+	})
+	require.NoError(t, err)
+	dev := WithRole(context.Background(), "developer")
+
+	decision := e.Check(dev, Action{Operation: OperationCreate, Kind: "application", Targets: []string{"Checkout_API"}})
+	assert.Equal(t, Reject, decision.Outcome)
+	assert.Contains(t, decision.Reasons[0], "naming convention")
+
+	// Existing nodes that predate a naming rule can still be changed
+	assert.True(t, e.Check(dev, Action{Operation: OperationUpdate, Kind: "application", Targets: []string{"Checkout_API"}}).Allowed())
+
+	assert.True(t, e.Check(dev, Action{Operation: OperationDeploy, Kind: "application", Targets: []string{"checkout"}, Environment: "dev"}).Allowed())
+	decision = e.Check(dev, Action{Operation: OperationDeploy, Kind: "application", Targets: []string{"checkout"}, Environment: "production"})
+	assert.Equal(t, Reject, decision.Outcome)
+	assert.Contains(t, decision.Reasons[0], "may not change environment production")
+}
+
+func TestCheck_BlastRadiusAndApprovals(t *testing.T) {
+	e, err := New(Config{MaxDeletes: 3, MaxTargets: 5, ProtectedEnvironments: []string{"production"}})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	decision := e.Check(ctx, Action{Operation: OperationDelete, Kind: "service", Targets: []string{"a", "b", "c", "d"}})
+	assert.Equal(t, Reject, decision.Outcome)
+	assert.Contains(t, decision.Reasons[0], "deletes 4 nodes")
+	assert.True(t, e.Check(ctx, Action{Operation: OperationDelete, Kind: "service", Targets: []string{"a", "b", "c"}}).Allowed())
+
+	wide := Action{Operation: OperationDeploy, Kind: "service", Targets: []string{"a", "b", "c", "d", "e", "f"}, Environment: "dev"}
+	decision = e.Check(ctx, wide)
+	assert.Equal(t, RequireApproval, decision.Outcome)
+	assert.Contains(t, decision.Message(), "requires approval")
+
+	prod := Action{Operation: OperationDeploy, Kind: "application", Targets: []string{"checkout"}, Environment: "production"}
+	assert.Equal(t, RequireApproval, e.Check(ctx, prod).Outcome)
+
+	admin := WithRole(ctx, "admin")
+	assert.True(t, e.Check(admin, prod).Allowed(), "approver roles skip approval")
+	assert.True(t, e.Check(admin, wide).Allowed())
+	assert.Equal(t, Reject, e.Check(admin, Action{Operation: OperationDelete, Targets: []string{"a", "b", "c", "d"}}).Outcome, "approvers cannot bypass rejections")
+}
+
+func TestNew_InvalidConfigAndDisabled(t *testing.T) {
+	_, err := New(Config{Naming: map[string]string{"service": "("}})
+	assert.Error(t, err)
+	_, err = New(Config{DefaultRole: "nobody"})
+	assert.Error(t, err)
+	_, err = New(Config{MaxDeletes: -1})
+	assert.Error(t, err)
+
+	off, err := New(Config{Disabled: true, MaxDeletes: 1})
+	require.NoError(t, err)
+	assert.True(t, off.Check(WithRole(context.Background(), "viewer"), Action{Operation: OperationDelete, Targets: []string{"a", "b"}}).Allowed())
+}
+
+func TestFootprint_FollowsOwnership(t *testing.T) {
+	gg := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	for _, n := range []struct{ id, kind string }{{"checkout", graph.KindApplication}, {"api", graph.KindService}, {"worker", graph.KindService}, {"dev", graph.KindEnvironment}} {
+		gg.AddNode(&graph.Node{ID: n.id, Kind: n.kind, Metadata: map[string]interface{}{"name": n.id, "application": "checkout"}, Spec: map[string]interface{}{}})
+	}
+	require.NoError(t, gg.AddEdge("checkout", "api", graph.EdgeTypeOwns))
+	require.NoError(t, gg.AddEdge("checkout", "worker", graph.EdgeTypeOwns))
+	require.NoError(t, gg.AddEdge("checkout", "dev", "allowed_in"))
+
+	assert.Equal(t, []string{"api", "checkout", "worker"}, Footprint(gg, "checkout"))
+	assert.Equal(t, []string{"api"}, Footprint(gg, "api"))
+}
@@ -0,0 +1,154 @@
+// Package health provides startup dependency checks and the readiness report served by the API.
+// Liveness (is the process up) and readiness (can this instance serve traffic) are deliberately
+// separate: an instance can be alive while its graph backend or event transport is unreachable.
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ProbeFunc checks a single dependency and returns an error when it is unavailable
+type ProbeFunc func(ctx context.Context) error
+
+// Check statuses
+const (
+	StatusOK     = "ok"
+	StatusFailed = "failed"
+)
+
+// Readiness statuses
+const (
+	ReadinessReady    = "ready"
+	ReadinessDegraded = "degraded"  // all critical checks pass, an optional dependency is down
+	ReadinessStarting = "starting"  // startup has not completed yet
+	ReadinessNotReady = "not_ready" // a critical dependency is down
+)
+
+// CheckResult is the outcome of a single dependency probe
+type CheckResult struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	Critical  bool   `json:"critical"`
+	Error     string `json:"error,omitempty"`
+	LatencyMs int64  `json:"latency_ms"`
+}
+
+// Report summarises all dependency probes
+type Report struct {
+	Status    string        `json:"status"`
+	Ready     bool          `json:"ready"`
+	Checks    []CheckResult `json:"checks"`
+	CheckedAt time.Time     `json:"checked_at"`
+}
+
+type check struct {
+	name     string
+	critical bool
+	probe    ProbeFunc
+}
+
+// Checker runs registered dependency probes
+type Checker struct {
+	mu      sync.RWMutex
+	checks  []check
+	timeout time.Duration
+	started bool
+}
+
+// NewChecker creates a checker that gives each probe at most timeout to respond
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Register adds a dependency probe. A failing critical probe makes the instance not ready;
+// a failing optional probe only marks it degraded.
+func (c *Checker) Register(name string, critical bool, probe ProbeFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, check{name: name, critical: critical, probe: probe})
+}
+
+// MarkStarted records that startup completed; the instance is never ready before this
+func (c *Checker) MarkStarted() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.started = true
+}
+
+// Check runs all probes concurrently and builds a readiness report
+func (c *Checker) Check(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := append([]check(nil), c.checks...)
+	started := c.started
+	c.mu.RUnlock()
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, chk := range checks {
+		wg.Add(1)
+		go func(i int, chk check) {
+			defer wg.Done()
+			results[i] = c.run(ctx, chk)
+		}(i, chk)
+	}
+	wg.Wait()
+
+	report := Report{Status: ReadinessReady, Ready: true, Checks: results, CheckedAt: time.Now()}
+	for _, result := range results {
+		if result.Status == StatusOK {
+			continue
+		}
+		if result.Critical {
+			report.Status = ReadinessNotReady
+			report.Ready = false
+		} else if report.Ready {
+			report.Status = ReadinessDegraded
+		}
+	}
+
+	if !started && report.Ready {
+		report.Status = ReadinessStarting
+		report.Ready = false
+	}
+	return report
+}
+
+// WaitForDependencies retries the probes every interval until all critical dependencies are up
+// or the context is cancelled. It returns the last report either way.
+func (c *Checker) WaitForDependencies(ctx context.Context, interval time.Duration) (Report, error) {
+	for {
+		report := c.Check(ctx)
+		if report.Status != ReadinessNotReady {
+			return report, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return report, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// run executes one probe with the checker's timeout
+func (c *Checker) run(ctx context.Context, chk check) CheckResult {
+	probeCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := chk.probe(probeCtx)
+
+	result := CheckResult{
+		Name:      chk.name,
+		Status:    StatusOK,
+		Critical:  chk.critical,
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		result.Status = StatusFailed
+		result.Error = err.Error()
+	}
+	return result
+}
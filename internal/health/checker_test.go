@@ -0,0 +1,79 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecker_NotReadyUntilStarted(t *testing.T) {
+	checker := NewChecker(time.Second)
+	checker.Register("graph", true, func(ctx context.Context) error { return nil })
+
+	report := checker.Check(context.Background())
+	assert.False(t, report.Ready)
+	assert.Equal(t, ReadinessStarting, report.Status)
+
+	checker.MarkStarted()
+	report = checker.Check(context.Background())
+	assert.True(t, report.Ready)
+	assert.Equal(t, ReadinessReady, report.Status)
+}
+
+func TestChecker_CriticalAndOptionalFailures(t *testing.T) {
+	checker := NewChecker(time.Second)
+	checker.MarkStarted()
+	checker.Register("graph", true, func(ctx context.Context) error { return nil })
+	checker.Register("ai", false, func(ctx context.Context) error { return errors.New("unauthorized") })
+
+	report := checker.Check(context.Background())
+	assert.True(t, report.Ready, "optional dependencies must not fail readiness")
+	assert.Equal(t, ReadinessDegraded, report.Status)
+	require.Len(t, report.Checks, 2)
+	assert.Equal(t, "unauthorized", report.Checks[1].Error)
+
+	checker.Register("events", true, func(ctx context.Context) error { return errors.New("not connected") })
+	report = checker.Check(context.Background())
+	assert.False(t, report.Ready)
+	assert.Equal(t, ReadinessNotReady, report.Status)
+}
+
+func TestChecker_ProbeTimeout(t *testing.T) {
+	checker := NewChecker(10 * time.Millisecond)
+	checker.MarkStarted()
+	checker.Register("slow", true, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	report := checker.Check(context.Background())
+	assert.False(t, report.Ready)
+	assert.Equal(t, StatusFailed, report.Checks[0].Status)
+}
+
+func TestChecker_WaitForDependencies(t *testing.T) {
+	checker := NewChecker(time.Second)
+	attempts := 0
+	checker.Register("graph", true, func(ctx context.Context) error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+
+	_, err := checker.WaitForDependencies(context.Background(), time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	failing := NewChecker(time.Second)
+	failing.Register("graph", true, func(ctx context.Context) error { return errors.New("down") })
+	_, err = failing.WaitForDependencies(ctx, time.Millisecond)
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,186 @@
+// Package hooks lets external systems take part in graph changes: synchronous pre hooks can
+// veto creating, updating or deleting nodes of a kind (e.g. a CMDB check before a resource is
+// created), and asynchronous post hooks are notified after the change is saved.
+package hooks
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Operations on nodes a hook can subscribe to
+const (
+	OperationCreate = "create"
+	OperationUpdate = "update"
+	OperationDelete = "delete"
+)
+
+// Phases of a change
+const (
+	PhasePre  = "pre"  // called before the change is saved and may veto it
+	PhasePost = "post" // notified after the change is saved
+)
+
+// Failure policies for pre hooks that deny a change, time out or fail
+const (
+	FailureBlock = "block"
+	FailureWarn  = "warn"
+)
+
+// DefaultTimeout bounds a hook call when the hook sets no timeout
+const DefaultTimeout = 5 * time.Second
+
+// ErrHookNotFound is returned for operations on a hook that is not registered
+var ErrHookNotFound = errors.New("hook not found")
+
+// Hook is an external endpoint called for changes to nodes of one kind
+type Hook struct {
+	Name          string    `json:"name"`
+	URL           string    `json:"url"`  // receives a POST with the Request as JSON
+	Kind          string    `json:"kind"` // node kind, or * for every kind
+	Operations    []string  `json:"operations"`
+	Phase         string    `json:"phase"`
+	Timeout       string    `json:"timeout,omitempty"`        // Go duration, DefaultTimeout when empty
+	FailurePolicy string    `json:"failure_policy,omitempty"` // pre hooks: block (default) or warn
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// Validate checks the hook definition
+func (h *Hook) Validate() error {
+	if h.Name == "" {
+		return fmt.Errorf("hook name is required")
+	}
+	if u, err := url.Parse(h.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("hook url must be an http(s) URL, got %q", h.URL)
+	}
+	if h.Kind == "" {
+		return fmt.Errorf("hook kind is required (use * for every kind)")
+	}
+	if len(h.Operations) == 0 {
+		return fmt.Errorf("hook needs at least one operation")
+	}
+	for _, op := range h.Operations {
+		if op != OperationCreate && op != OperationUpdate && op != OperationDelete {
+			return fmt.Errorf("unknown operation %q (expected create, update or delete)", op)
+		}
+	}
+	if h.Phase != PhasePre && h.Phase != PhasePost {
+		return fmt.Errorf("unknown phase %q (expected pre or post)", h.Phase)
+	}
+	if h.Timeout != "" {
+		if timeout, err := time.ParseDuration(h.Timeout); err != nil || timeout <= 0 {
+			return fmt.Errorf("timeout must be a positive duration such as 2s, got %q", h.Timeout)
+		}
+	}
+	switch h.FailurePolicy {
+	case "", FailureBlock, FailureWarn:
+	default:
+		return fmt.Errorf("unknown failure_policy %q (expected block or warn)", h.FailurePolicy)
+	}
+	return nil
+}
+
+func (h Hook) timeout() time.Duration {
+	if timeout, err := time.ParseDuration(h.Timeout); err == nil && timeout > 0 {
+		return timeout
+	}
+	return DefaultTimeout
+}
+
+func (h Hook) blocks() bool {
+	return h.FailurePolicy != FailureWarn
+}
+
+func (h Hook) matches(kind, operation, phase string) bool {
+	if h.Phase != phase || (h.Kind != "*" && h.Kind != kind) {
+		return false
+	}
+	for _, op := range h.Operations {
+		if op == operation {
+			return true
+		}
+	}
+	return false
+}
+
+// Registry holds the registered hooks
+type Registry struct {
+	mu    sync.RWMutex
+	hooks map[string]Hook
+	now   func() time.Time
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{hooks: map[string]Hook{}, now: time.Now}
+}
+
+// Register validates the hook and adds it, replacing a hook with the same name
+func (r *Registry) Register(hook Hook) (Hook, error) {
+	if err := hook.Validate(); err != nil {
+		return Hook{}, err
+	}
+	if hook.FailurePolicy == "" && hook.Phase == PhasePre {
+		hook.FailurePolicy = FailureBlock
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	hook.CreatedAt = r.now()
+	r.hooks[hook.Name] = hook
+	return hook, nil
+}
+
+// Get returns a registered hook
+func (r *Registry) Get(name string) (Hook, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	hook, ok := r.hooks[name]
+	if !ok {
+		return Hook{}, ErrHookNotFound
+	}
+	return hook, nil
+}
+
+// List returns the registered hooks sorted by name
+func (r *Registry) List() []Hook {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	hooks := make([]Hook, 0, len(r.hooks))
+	for _, hook := range r.hooks {
+		hooks = append(hooks, hook)
+	}
+	sort.Slice(hooks, func(i, j int) bool { return hooks[i].Name < hooks[j].Name })
+	return hooks
+}
+
+// Delete removes a hook
+func (r *Registry) Delete(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.hooks[name]; !ok {
+		return ErrHookNotFound
+	}
+	delete(r.hooks, name)
+	return nil
+}
+
+// matching returns the hooks for a change, sorted by name
+func (r *Registry) matching(kind, operation, phase string) []Hook {
+	var matched []Hook
+	for _, hook := range r.List() {
+		if hook.matches(kind, operation, phase) {
+			matched = append(matched, hook)
+		}
+	}
+	return matched
+}
+
+func (r *Registry) empty() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.hooks) == 0
+}
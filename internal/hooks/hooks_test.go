@@ -0,0 +1,187 @@
+package hooks
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/graphwatch"
+)
+
+// recorder is a hook endpoint that answers with a fixed status and body and records requests
+type recorder struct {
+	mu       sync.Mutex
+	requests []Request
+	status   int
+	body     string
+	delay    time.Duration
+}
+
+func (rec *recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req Request
+	json.NewDecoder(r.Body).Decode(&req)
+	rec.mu.Lock()
+	rec.requests = append(rec.requests, req)
+	rec.mu.Unlock()
+	time.Sleep(rec.delay)
+	if rec.status != 0 {
+		w.WriteHeader(rec.status)
+	}
+	w.Write([]byte(rec.body))
+}
+
+func (rec *recorder) calls() []Request {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return append([]Request(nil), rec.requests...)
+}
+
+func newHookServer(t *testing.T, rec *recorder) string {
+	t.Helper()
+	server := httptest.NewServer(rec)
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func newTestGraph(registry *Registry) (*graph.GlobalGraph, *Watcher) {
+	watch := graphwatch.NewBackend(graph.NewMemoryGraph())
+	return graph.NewGlobalGraph(watch), Watch(watch, registry)
+}
+
+func TestHookValidate(t *testing.T) {
+	valid := Hook{Name: "cmdb", URL: "https://cmdb.example.com/check", Kind: "resource", Operations: []string{OperationCreate}, Phase: PhasePre}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("valid hook rejected: %v", err)
+	}
+	for name, mutate := range map[string]func(*Hook){
+		"missing name":     func(h *Hook) { h.Name = "" },
+		"relative url":     func(h *Hook) { h.URL = "/check" },
+		"missing kind":     func(h *Hook) { h.Kind = "" },
+		"no operations":    func(h *Hook) { h.Operations = nil },
+		"unknown op":       func(h *Hook) { h.Operations = []string{"rename"} },
+		"unknown phase":    func(h *Hook) { h.Phase = "during" },
+		"bad timeout":      func(h *Hook) { h.Timeout = "soon" },
+		"negative timeout": func(h *Hook) { h.Timeout = "-1s" },
+		"unknown policy":   func(h *Hook) { h.FailurePolicy = "retry" },
+	} {
+		hook := valid
+		mutate(&hook)
+		if err := hook.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+	registered, err := registry.Register(Hook{Name: "b", URL: "http://x", Kind: "*", Operations: []string{OperationDelete}, Phase: PhasePre})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if registered.FailurePolicy != FailureBlock || registered.CreatedAt.IsZero() {
+		t.Errorf("expected defaults to be applied, got %+v", registered)
+	}
+	registry.Register(Hook{Name: "a", URL: "http://x", Kind: "service", Operations: []string{OperationCreate}, Phase: PhasePost})
+
+	if list := registry.List(); len(list) != 2 || list[0].Name != "a" {
+		t.Errorf("expected hooks sorted by name, got %+v", list)
+	}
+	if matched := registry.matching("application", OperationDelete, PhasePre); len(matched) != 1 || matched[0].Name != "b" {
+		t.Errorf("expected the wildcard hook to match, got %+v", matched)
+	}
+	if matched := registry.matching("application", OperationCreate, PhasePost); len(matched) != 0 {
+		t.Errorf("expected no hook for another kind, got %+v", matched)
+	}
+	if err := registry.Delete("a"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := registry.Get("a"); !errors.Is(err, ErrHookNotFound) {
+		t.Errorf("expected ErrHookNotFound, got %v", err)
+	}
+}
+
+func TestPreHookBlocksCreate(t *testing.T) {
+	rec := &recorder{body: `{"allowed": false, "reason": "not in CMDB"}`}
+	registry := NewRegistry()
+	registry.Register(Hook{Name: "cmdb", URL: newHookServer(t, rec), Kind: graph.KindResource, Operations: []string{OperationCreate}, Phase: PhasePre})
+	gg, _ := newTestGraph(registry)
+
+	err := gg.AddNode(&graph.Node{ID: "db", Kind: graph.KindResource})
+	var rejected *RejectedError
+	if !errors.As(err, &rejected) || rejected.Hook != "cmdb" || rejected.Reason != "not in CMDB" {
+		t.Fatalf("expected the hook to reject the create, got %v", err)
+	}
+	if _, err := gg.GetNode("db"); err == nil {
+		t.Error("rejected node was kept in the graph")
+	}
+
+	// Other kinds are not checked
+	if err := gg.AddNode(&graph.Node{ID: "checkout", Kind: graph.KindApplication}); err != nil {
+		t.Fatalf("AddNode: %v", err)
+	}
+	calls := rec.calls()
+	if len(calls) != 1 || calls[0].Operation != OperationCreate || calls[0].Node.ID != "db" {
+		t.Errorf("unexpected hook calls: %+v", calls)
+	}
+}
+
+func TestPreHookFailurePolicies(t *testing.T) {
+	slow := &recorder{delay: 200 * time.Millisecond}
+	failing := &recorder{status: http.StatusInternalServerError, body: "down"}
+
+	registry := NewRegistry()
+	registry.Register(Hook{Name: "slow", URL: newHookServer(t, slow), Kind: "*", Operations: []string{OperationUpdate}, Phase: PhasePre, Timeout: "20ms"})
+	registry.Register(Hook{Name: "flaky", URL: newHookServer(t, failing), Kind: "*", Operations: []string{OperationCreate}, Phase: PhasePre, FailurePolicy: FailureWarn})
+	gg, _ := newTestGraph(registry)
+
+	// A failing warn-only hook lets the change through
+	if err := gg.AddNode(&graph.Node{ID: "api", Kind: graph.KindService, Metadata: map[string]interface{}{"owner": "a"}}); err != nil {
+		t.Fatalf("warn-only hook blocked the create: %v", err)
+	}
+
+	// A blocking hook that times out rejects the change and the node keeps its old state
+	err := gg.UpdateNode(&graph.Node{ID: "api", Kind: graph.KindService, Metadata: map[string]interface{}{"owner": "b"}})
+	var rejected *RejectedError
+	if !errors.As(err, &rejected) || rejected.Hook != "slow" {
+		t.Fatalf("expected the timeout to reject the update, got %v", err)
+	}
+	node, err := gg.GetNode("api")
+	if err != nil || node.Metadata["owner"] != "a" {
+		t.Errorf("expected the update to be rolled back, got %+v (%v)", node, err)
+	}
+}
+
+func TestPostHookNotifiedAfterSave(t *testing.T) {
+	rec := &recorder{status: http.StatusAccepted}
+	registry := NewRegistry()
+	registry.Register(Hook{Name: "audit", URL: newHookServer(t, rec), Kind: graph.KindService, Operations: []string{OperationCreate, OperationDelete}, Phase: PhasePost})
+	gg, watcher := newTestGraph(registry)
+
+	if err := gg.AddNode(&graph.Node{ID: "api", Kind: graph.KindService}); err != nil {
+		t.Fatalf("AddNode: %v", err)
+	}
+	if err := gg.DeleteNode("api"); err != nil {
+		t.Fatalf("DeleteNode: %v", err)
+	}
+	watcher.Wait()
+
+	calls := rec.calls()
+	if len(calls) != 2 {
+		t.Fatalf("expected 2 notifications, got %+v", calls)
+	}
+	seen := map[string]bool{}
+	for _, call := range calls {
+		if call.Phase != PhasePost || call.Node.ID != "api" {
+			t.Errorf("unexpected notification: %+v", call)
+		}
+		seen[call.Operation] = true
+	}
+	if !seen[OperationCreate] || !seen[OperationDelete] {
+		t.Errorf("expected create and delete notifications, got %+v", calls)
+	}
+}
@@ -0,0 +1,197 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/graphwatch"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// Request is the JSON body POSTed to a hook
+type Request struct {
+	Hook      string      `json:"hook"`
+	Phase     string      `json:"phase"`
+	Operation string      `json:"operation"`
+	Kind      string      `json:"kind"`
+	Node      *graph.Node `json:"node"`               // the node after the change; before it for deletes
+	Previous  *graph.Node `json:"previous,omitempty"` // the node before an update
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+// Response is what a pre hook may answer. A 2xx response without a body allows the change.
+type Response struct {
+	Allowed *bool  `json:"allowed,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// RejectedError is returned when a blocking pre hook denies a change or cannot be reached
+type RejectedError struct {
+	Hook      string
+	Operation string
+	Node      string
+	Reason    string
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("hook %s rejected %s of %s: %s", e.Hook, e.Operation, e.Node, e.Reason)
+}
+
+// change is a node created, updated or deleted by a save
+type change struct {
+	operation string
+	node      *graph.Node
+	previous  *graph.Node
+}
+
+// Watcher calls the registered hooks for every node a save creates, updates or deletes: pre
+// hooks before the save is stored, where a blocking one rejects the whole save, and post hooks
+// once it was stored.
+type Watcher struct {
+	registry *Registry
+	client   *http.Client
+	logger   *logging.Logger
+	pending  sync.WaitGroup // post hooks still being delivered
+}
+
+// Watch calls the registered hooks for the saves on watch
+func Watch(watch *graphwatch.Backend, registry *Registry) *Watcher {
+	w := &Watcher{
+		registry: registry,
+		client:   &http.Client{},
+		logger:   logging.GetLogger().ForComponent("hooks"),
+	}
+	watch.AddCheck(w.check)
+	watch.Subscribe(w.notify)
+	return w
+}
+
+// Wait blocks until the post hooks notified so far have been delivered
+func (w *Watcher) Wait() {
+	w.pending.Wait()
+}
+
+// check calls the pre hooks for each node the save changes
+func (w *Watcher) check(save graphwatch.Save) error {
+	if w.registry.empty() {
+		return nil
+	}
+	for _, c := range nodeChanges(save) {
+		if err := w.runPreHooks(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// notify calls the post hooks for each node a stored save changed; emptying the graph is not
+// reported as deletes
+func (w *Watcher) notify(save graphwatch.Save) {
+	if save.Cleared || w.registry.empty() {
+		return
+	}
+	for _, c := range nodeChanges(save) {
+		w.runPostHooks(c)
+	}
+}
+
+func (w *Watcher) runPreHooks(c change) error {
+	for _, hook := range w.registry.matching(c.node.Kind, c.operation, PhasePre) {
+		reason := w.call(hook, c)
+		if reason == "" {
+			continue
+		}
+		if hook.blocks() {
+			w.logger.Warn("⛔ Hook %s rejected %s of %s: %s", hook.Name, c.operation, c.node.ID, reason)
+			return &RejectedError{Hook: hook.Name, Operation: c.operation, Node: c.node.ID, Reason: reason}
+		}
+		w.logger.Warn("⚠️ Hook %s objected to %s of %s (warn only): %s", hook.Name, c.operation, c.node.ID, reason)
+	}
+	return nil
+}
+
+func (w *Watcher) runPostHooks(c change) {
+	for _, hook := range w.registry.matching(c.node.Kind, c.operation, PhasePost) {
+		w.pending.Add(1)
+		go func(hook Hook) {
+			defer w.pending.Done()
+			if reason := w.call(hook, c); reason != "" {
+				w.logger.Warn("⚠️ Hook %s was not notified of %s of %s: %s", hook.Name, c.operation, c.node.ID, reason)
+			}
+		}(hook)
+	}
+}
+
+// call POSTs the change to the hook and returns why it was denied or failed, or "" if allowed
+func (w *Watcher) call(hook Hook, c change) string {
+	body, err := json.Marshal(Request{
+		Hook: hook.Name, Phase: hook.Phase, Operation: c.operation, Kind: c.node.Kind,
+		Node: c.node, Previous: c.previous, Timestamp: time.Now(),
+	})
+	if err != nil {
+		return fmt.Sprintf("failed to encode request: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), hook.timeout())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Sprintf("invalid request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := w.client.Do(req)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Sprintf("no response within %s", hook.timeout())
+		}
+		return fmt.Sprintf("call failed: %v", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Sprintf("returned %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	if hook.Phase == PhasePost || len(bytes.TrimSpace(data)) == 0 {
+		return ""
+	}
+	var answer Response
+	if err := json.Unmarshal(data, &answer); err != nil {
+		return fmt.Sprintf("invalid response: %v", err)
+	}
+	if answer.Allowed != nil && !*answer.Allowed {
+		if answer.Reason == "" {
+			return "denied"
+		}
+		return answer.Reason
+	}
+	return ""
+}
+
+// nodeChanges lists the nodes the save creates, updates and deletes, sorted by ID
+func nodeChanges(save graphwatch.Save) []change {
+	operations := map[string]string{
+		graphwatch.OpAdded:   OperationCreate,
+		graphwatch.OpUpdated: OperationUpdate,
+		graphwatch.OpRemoved: OperationDelete,
+	}
+	var changes []change
+	for _, c := range save.Changes {
+		node := save.Node(c)
+		if c.Kind != graphwatch.KindNode || node == nil {
+			continue
+		}
+		nodeChange := change{operation: operations[c.Op], node: node}
+		if c.Op == graphwatch.OpUpdated {
+			nodeChange.previous = save.PreviousNode(c)
+		}
+		changes = append(changes, nodeChange)
+	}
+	return changes
+}
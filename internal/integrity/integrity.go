@@ -0,0 +1,346 @@
+// Package integrity scans the global graph for inconsistencies that direct and AI-driven
+// mutations leave behind, and proposes a repair for each one. Repairs that cannot lose
+// information are marked safe and can be applied automatically; the rest need a person.
+package integrity
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/contracts"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// Checks
+const (
+	CheckDanglingEdge    = "dangling_edge"
+	CheckOrphanResource  = "orphan_resource"
+	CheckInvalidContract = "invalid_contract"
+	CheckCycle           = "cycle"
+)
+
+// Repair actions
+const (
+	ActionRemoveEdge = "remove_edge"
+	ActionAddEdge    = "add_edge"
+	ActionDeleteNode = "delete_node"
+	ActionReview     = "review"
+)
+
+// acyclicEdgeTypes are the relationships that must never form a cycle
+var acyclicEdgeTypes = []string{graph.EdgeTypeOwns, graph.EdgeTypeDependsOn}
+
+// contractKinds decode nodes of a kind back into their contract for validation
+var contractKinds = map[string]func() contracts.Contract{
+	graph.KindApplication:  func() contracts.Contract { return &contracts.ApplicationContract{} },
+	graph.KindService:      func() contracts.Contract { return &contracts.ServiceContract{} },
+	graph.KindEnvironment:  func() contracts.Contract { return &contracts.EnvironmentContract{} },
+	graph.KindResourceType: func() contracts.Contract { return &contracts.ResourceTypeContract{} },
+	graph.KindResource:     func() contracts.Contract { return &contracts.ResourceContract{} },
+}
+
+// Repair is the proposed fix for an issue
+type Repair struct {
+	Action      string `json:"action"`
+	Node        string `json:"node,omitempty"`
+	From        string `json:"from,omitempty"`
+	To          string `json:"to,omitempty"`
+	Type        string `json:"type,omitempty"`
+	Safe        bool   `json:"safe"` // applied when repairing; unsafe repairs are only proposed
+	Description string `json:"description"`
+}
+
+// Issue is one inconsistency found in the graph
+type Issue struct {
+	ID       string `json:"id"`
+	Check    string `json:"check"`
+	Subject  string `json:"subject"` // node ID, or from->to:type for edges
+	Message  string `json:"message"`
+	Repair   Repair `json:"repair"`
+	Repaired bool   `json:"repaired"`
+}
+
+// Report is the result of a validation run
+type Report struct {
+	CheckedAt time.Time      `json:"checked_at"`
+	Nodes     int            `json:"nodes"`
+	Edges     int            `json:"edges"`
+	Valid     bool           `json:"valid"` // no issues remain after any repairs
+	Summary   map[string]int `json:"summary"`
+	Issues    []Issue        `json:"issues"`
+	Repaired  int            `json:"repaired"`
+}
+
+// Service validates the global graph
+type Service struct {
+	graph  *graph.GlobalGraph
+	logger *logging.Logger
+	now    func() time.Time
+}
+
+// NewService creates a validator for globalGraph
+func NewService(globalGraph *graph.GlobalGraph) *Service {
+	return &Service{
+		graph:  globalGraph,
+		logger: logging.GetLogger().ForComponent("integrity"),
+		now:    time.Now,
+	}
+}
+
+// Validate scans the graph and, when repair is set, applies the safe repairs and saves the graph
+// under the write lock, so repairs are computed on the graph they are saved to
+func (s *Service) Validate(repair bool) (*Report, error) {
+	var report *Report
+	if !repair {
+		g, err := s.graph.Graph()
+		if err != nil {
+			return nil, err
+		}
+		report = s.scan(g)
+	} else {
+		err := s.graph.Update(func(g *graph.Graph) error {
+			report = s.scan(g)
+			for i := range report.Issues {
+				if report.Issues[i].Repair.Safe && apply(g, report.Issues[i].Repair) {
+					report.Issues[i].Repaired = true
+					report.Repaired++
+				}
+			}
+			if report.Repaired == 0 {
+				return graph.ErrSkipSave
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to save repaired graph: %w", err)
+		}
+		if report.Repaired > 0 {
+			s.logger.Info("🔧 Applied %d safe graph repairs", report.Repaired)
+		}
+	}
+
+	for _, issue := range report.Issues {
+		if !issue.Repaired {
+			report.Summary[issue.Check]++
+		}
+	}
+	report.Valid = len(report.Summary) == 0
+	return report, nil
+}
+
+func (s *Service) scan(g *graph.Graph) *Report {
+	report := &Report{CheckedAt: s.now().UTC(), Nodes: len(g.Nodes), Summary: map[string]int{}, Issues: Scan(g)}
+	for _, edges := range g.Edges {
+		report.Edges += len(edges)
+	}
+	return report
+}
+
+// Scan returns the graph's issues in a stable order, numbered I1, I2, ...
+func Scan(g *graph.Graph) []Issue {
+	var issues []Issue
+	issues = append(issues, danglingEdges(g)...)
+	issues = append(issues, orphanResources(g)...)
+	issues = append(issues, invalidContracts(g)...)
+	for _, edgeType := range acyclicEdgeTypes {
+		issues = append(issues, cycles(g, edgeType)...)
+	}
+	for i := range issues {
+		issues[i].ID = fmt.Sprintf("I%d", i+1)
+	}
+	if issues == nil {
+		issues = []Issue{}
+	}
+	return issues
+}
+
+func edgeSubject(from string, edge graph.Edge) string {
+	return from + "->" + edge.To + ":" + edge.Type
+}
+
+func sortedNodeIDs(g *graph.Graph) []string {
+	ids := make([]string, 0, len(g.Nodes))
+	for id := range g.Nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func sortedSources(g *graph.Graph) []string {
+	sources := make([]string, 0, len(g.Edges))
+	for from := range g.Edges {
+		sources = append(sources, from)
+	}
+	sort.Strings(sources)
+	return sources
+}
+
+// danglingEdges finds edges whose source or target node no longer exists
+func danglingEdges(g *graph.Graph) []Issue {
+	var issues []Issue
+	for _, from := range sortedSources(g) {
+		_, fromExists := g.Nodes[from]
+		for _, edge := range g.Edges[from] {
+			_, toExists := g.Nodes[edge.To]
+			if fromExists && toExists {
+				continue
+			}
+			missing := edge.To
+			if !fromExists {
+				missing = from
+			}
+			issues = append(issues, Issue{
+				Check:   CheckDanglingEdge,
+				Subject: edgeSubject(from, edge),
+				Message: fmt.Sprintf("%s edge %s -> %s points at missing node %s", edge.Type, from, edge.To, missing),
+				Repair: Repair{Action: ActionRemoveEdge, From: from, To: edge.To, Type: edge.Type, Safe: true,
+					Description: "remove the edge; it references nothing"},
+			})
+		}
+	}
+	return issues
+}
+
+// orphanResources finds resource instances no node owns. Instances record their application,
+// so they are relinked when it still exists.
+func orphanResources(g *graph.Graph) []Issue {
+	owned := map[string]bool{}
+	for _, edges := range g.Edges {
+		for _, edge := range edges {
+			if edge.Type == graph.EdgeTypeOwns {
+				owned[edge.To] = true
+			}
+		}
+	}
+
+	var issues []Issue
+	for _, id := range sortedNodeIDs(g) {
+		node := g.Nodes[id]
+		app, _ := node.Metadata["application"].(string)
+		if node.Kind != graph.KindResource || app == "" || node.Metadata["catalog_ref"] == nil || owned[id] {
+			continue
+		}
+		issue := Issue{Check: CheckOrphanResource, Subject: id}
+		if appNode, ok := g.Nodes[app]; ok && appNode.Kind == graph.KindApplication {
+			issue.Message = fmt.Sprintf("resource instance %s is not owned by its application %s", id, app)
+			issue.Repair = Repair{Action: ActionAddEdge, From: app, To: id, Type: graph.EdgeTypeOwns, Safe: true,
+				Description: fmt.Sprintf("link %s back to %s", id, app)}
+		} else {
+			issue.Message = fmt.Sprintf("resource instance %s belongs to application %s, which does not exist", id, app)
+			issue.Repair = Repair{Action: ActionDeleteNode, Node: id,
+				Description: "delete the instance once it is confirmed deprovisioned"}
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// invalidContracts finds nodes that no longer satisfy their kind's contract
+func invalidContracts(g *graph.Graph) []Issue {
+	var issues []Issue
+	for _, id := range sortedNodeIDs(g) {
+		node := g.Nodes[id]
+		newContract, ok := contractKinds[node.Kind]
+		if !ok {
+			continue
+		}
+		if err := validateNode(node, newContract()); err != nil {
+			issues = append(issues, Issue{
+				Check:   CheckInvalidContract,
+				Subject: id,
+				Message: fmt.Sprintf("%s %s violates its contract: %v", node.Kind, id, err),
+				Repair:  Repair{Action: ActionReview, Node: id, Description: "correct the node through its API"},
+			})
+		}
+	}
+	return issues
+}
+
+func validateNode(node *graph.Node, contract contracts.Contract) error {
+	data, err := json.Marshal(map[string]interface{}{"metadata": node.Metadata, "spec": node.Spec})
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, contract); err != nil {
+		return fmt.Errorf("malformed fields: %w", err)
+	}
+	return contract.Validate()
+}
+
+// cycles finds edges of edgeType that close a cycle, each reported with the cycle's path
+func cycles(g *graph.Graph, edgeType string) []Issue {
+	const (
+		unvisited = iota
+		inProgress
+		done
+	)
+	state := map[string]int{}
+	var path []string
+	var issues []Issue
+
+	var visit func(id string)
+	visit = func(id string) {
+		state[id] = inProgress
+		path = append(path, id)
+		for _, edge := range g.Edges[id] {
+			if edge.Type != edgeType {
+				continue
+			}
+			switch state[edge.To] {
+			case unvisited:
+				visit(edge.To)
+			case inProgress:
+				start := 0
+				for i, step := range path {
+					if step == edge.To {
+						start = i
+					}
+				}
+				loop := append(append([]string{}, path[start:]...), edge.To)
+				issues = append(issues, Issue{
+					Check:   CheckCycle,
+					Subject: edgeSubject(id, edge),
+					Message: fmt.Sprintf("%s edges form a cycle: %s", edgeType, strings.Join(loop, " -> ")),
+					Repair: Repair{Action: ActionRemoveEdge, From: id, To: edge.To, Type: edgeType,
+						Description: "remove the edge that closes the cycle, or another edge in it"},
+				})
+			}
+		}
+		path = path[:len(path)-1]
+		state[id] = done
+	}
+
+	for _, from := range sortedSources(g) {
+		if state[from] == unvisited {
+			visit(from)
+		}
+	}
+	return issues
+}
+
+// apply performs a repair on g, reporting whether it changed anything
+func apply(g *graph.Graph, repair Repair) bool {
+	switch repair.Action {
+	case ActionRemoveEdge:
+		edges := g.Edges[repair.From]
+		for i, edge := range edges {
+			if edge.To == repair.To && edge.Type == repair.Type {
+				g.Edges[repair.From] = append(edges[:i:i], edges[i+1:]...)
+				if len(g.Edges[repair.From]) == 0 {
+					delete(g.Edges, repair.From)
+				}
+				return true
+			}
+		}
+	case ActionAddEdge:
+		return g.AddEdge(repair.From, repair.To, repair.Type) == nil
+	case ActionDeleteNode:
+		return g.DeleteNode(repair.Node) == nil
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package integrity
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+)
+
+func node(id, kind string, metadata, spec map[string]interface{}) *graph.Node {
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	if spec == nil {
+		spec = map[string]interface{}{}
+	}
+	return &graph.Node{ID: id, Kind: kind, Metadata: metadata, Spec: spec}
+}
+
+// brokenGraph has one issue of every check
+func brokenGraph(t *testing.T) *graph.GlobalGraph {
+	t.Helper()
+	g := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	g.AddNode(node("checkout", graph.KindApplication, map[string]interface{}{"name": "checkout", "owner": "team-a"}, nil))
+	g.AddNode(node("checkout-api", graph.KindService, map[string]interface{}{"name": "checkout-api"}, map[string]interface{}{"application": "checkout"}))
+	g.AddNode(node("checkout-worker", graph.KindService, map[string]interface{}{"name": "checkout-worker"}, map[string]interface{}{"application": "checkout"}))
+	g.AddNode(node("billing", graph.KindApplication, map[string]interface{}{"name": "billing"}, nil)) // no owner
+	g.AddNode(node("checkout-db", graph.KindResource,
+		map[string]interface{}{"name": "checkout-db", "application": "checkout", "catalog_ref": "postgres"},
+		map[string]interface{}{"type": "postgres"}))
+	g.AddNode(node("legacy-cache", graph.KindResource,
+		map[string]interface{}{"name": "legacy-cache", "application": "retired", "catalog_ref": "redis"},
+		map[string]interface{}{"type": "redis"}))
+
+	current, err := g.Graph()
+	if err != nil {
+		t.Fatalf("Graph: %v", err)
+	}
+	current.Edges["checkout"] = []graph.Edge{
+		{To: "checkout-api", Type: graph.EdgeTypeOwns},
+		{To: "checkout-worker", Type: graph.EdgeTypeOwns},
+		{To: "checkout-cache", Type: graph.EdgeTypeOwns}, // deleted node
+	}
+	current.Edges["checkout-api"] = []graph.Edge{{To: "checkout-worker", Type: graph.EdgeTypeDependsOn}}
+	current.Edges["checkout-worker"] = []graph.Edge{{To: "checkout-api", Type: graph.EdgeTypeDependsOn}}
+	if err := g.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	return g
+}
+
+func issuesByCheck(issues []Issue) map[string][]Issue {
+	result := map[string][]Issue{}
+	for _, issue := range issues {
+		result[issue.Check] = append(result[issue.Check], issue)
+	}
+	return result
+}
+
+func TestValidateFindsIssues(t *testing.T) {
+	g := brokenGraph(t)
+	report, err := NewService(g).Validate(false)
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if report.Valid {
+		t.Fatal("expected the graph to be invalid")
+	}
+
+	byCheck := issuesByCheck(report.Issues)
+	if got := byCheck[CheckDanglingEdge]; len(got) != 1 || got[0].Subject != "checkout->checkout-cache:owns" || !got[0].Repair.Safe {
+		t.Fatalf("unexpected dangling edge issues: %+v", got)
+	}
+	orphans := byCheck[CheckOrphanResource]
+	if len(orphans) != 2 {
+		t.Fatalf("expected 2 orphan resources, got %+v", orphans)
+	}
+	if orphans[0].Subject != "checkout-db" || orphans[0].Repair.Action != ActionAddEdge || !orphans[0].Repair.Safe {
+		t.Fatalf("expected checkout-db to be relinked safely, got %+v", orphans[0])
+	}
+	if orphans[1].Subject != "legacy-cache" || orphans[1].Repair.Action != ActionDeleteNode || orphans[1].Repair.Safe {
+		t.Fatalf("expected legacy-cache deletion to need review, got %+v", orphans[1])
+	}
+	if got := byCheck[CheckInvalidContract]; len(got) != 1 || got[0].Subject != "billing" {
+		t.Fatalf("unexpected contract issues: %+v", got)
+	}
+	if got := byCheck[CheckCycle]; len(got) != 1 || got[0].Repair.Safe {
+		t.Fatalf("expected one unsafe cycle repair, got %+v", got)
+	}
+	if report.Summary[CheckOrphanResource] != 2 || report.Repaired != 0 {
+		t.Fatalf("unexpected summary: %+v", report.Summary)
+	}
+	for i, issue := range report.Issues {
+		if issue.Repaired {
+			t.Fatalf("issue %s repaired without repair requested", issue.ID)
+		}
+		if want := fmt.Sprintf("I%d", i+1); issue.ID != want {
+			t.Fatalf("expected issue %d to be %s, got %s", i, want, issue.ID)
+		}
+	}
+}
+
+func TestValidateAppliesSafeRepairs(t *testing.T) {
+	g := brokenGraph(t)
+	report, err := NewService(g).Validate(true)
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if report.Repaired != 2 {
+		t.Fatalf("expected 2 safe repairs, got %d", report.Repaired)
+	}
+	if ok, _ := g.HasEdge("checkout", "checkout-db", graph.EdgeTypeOwns); !ok {
+		t.Fatal("expected checkout-db to be owned by checkout again")
+	}
+	if ok, _ := g.HasEdge("checkout", "checkout-cache", graph.EdgeTypeOwns); ok {
+		t.Fatal("expected the dangling edge to be removed")
+	}
+	if node, _ := g.GetNode("legacy-cache"); node == nil {
+		t.Fatal("unsafe repairs must not be applied")
+	}
+
+	again, err := NewService(g).Validate(false)
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	byCheck := issuesByCheck(again.Issues)
+	if len(byCheck[CheckDanglingEdge]) != 0 || len(byCheck[CheckOrphanResource]) != 1 || len(byCheck[CheckCycle]) != 1 {
+		t.Fatalf("unexpected issues after repair: %+v", again.Issues)
+	}
+}
+
+func TestValidateCleanGraph(t *testing.T) {
+	g := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	g.AddNode(node("checkout", graph.KindApplication, map[string]interface{}{"name": "checkout", "owner": "team-a"}, nil))
+	report, err := NewService(g).Validate(true)
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if !report.Valid || len(report.Issues) != 0 || report.Nodes != 1 {
+		t.Fatalf("expected a clean report, got %+v", report)
+	}
+}
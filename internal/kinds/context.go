@@ -0,0 +1,68 @@
+package kinds
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+)
+
+// AIVisibleKinds returns the custom kinds stored in g whose nodes may be shown to the AI
+func AIVisibleKinds(g *graph.Graph) []string {
+	var names []string
+	for _, def := range definitions(g) {
+		if !def.AIContext.Exclude {
+			names = append(names, def.Name)
+		}
+	}
+	return names
+}
+
+// AIExcludedKinds returns the custom kinds stored in g whose nodes must not reach the AI
+func AIExcludedKinds(g *graph.Graph) map[string]bool {
+	excluded := map[string]bool{}
+	for _, def := range definitions(g) {
+		if def.AIContext.Exclude {
+			excluded[def.Name] = true
+		}
+	}
+	return excluded
+}
+
+// PlatformContext describes the nodes of the custom kinds in g that are not excluded from AI
+// context, with the spec fields each kind exposes. It is empty when there are none.
+func PlatformContext(g *graph.Graph) string {
+	var b strings.Builder
+	for _, def := range definitions(g) {
+		if def.AIContext.Exclude {
+			continue
+		}
+		var nodes []*graph.Node
+		for _, node := range g.Nodes {
+			if node.Kind == def.Name {
+				nodes = append(nodes, node)
+			}
+		}
+		sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+
+		title := strings.ToUpper(def.Name)
+		if def.Description != "" {
+			title += " - " + def.Description
+		}
+		fmt.Fprintf(&b, "\n\n%s (%d):", title, len(nodes))
+		for _, node := range nodes {
+			fmt.Fprintf(&b, "\n  - %s", node.ID)
+			var fields []string
+			for _, field := range def.AIContext.Fields {
+				if value, ok := node.Spec[field]; ok {
+					fields = append(fields, fmt.Sprintf("%s=%v", field, value))
+				}
+			}
+			if len(fields) > 0 {
+				b.WriteString(" (" + strings.Join(fields, ", ") + ")")
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,356 @@
+// Package kinds lets teams register their own node kinds (datasets, ML models, ...) and model
+// them in the platform graph next to the built-in entities. A kind definition carries the JSON
+// schema its nodes' specs must match, the edges its nodes may take part in, optional lifecycle
+// hooks, and whether its nodes are shown to the AI as platform context.
+package kinds
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/contracts"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/hooks"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+var (
+	// ErrKindNotFound is returned for a kind that is not registered
+	ErrKindNotFound = errors.New("kind not found")
+	// ErrKindInUse is returned when deleting a kind that still has nodes
+	ErrKindInUse = errors.New("kind still has nodes")
+	// ErrNodeNotFound is returned for a node that does not exist or is of another kind
+	ErrNodeNotFound = errors.New("node not found")
+	// ErrInvalidNode is returned for nodes whose spec does not match the kind's schema
+	ErrInvalidNode = errors.New("node does not match its kind")
+)
+
+// nodeIDPrefix namespaces definition nodes so they cannot collide with platform entities
+const nodeIDPrefix = "kind:"
+
+// Relationship directions
+const (
+	DirectionOut = "out" // this kind -> the other kind
+	DirectionIn  = "in"  // the other kind -> this kind
+)
+
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,62}$`)
+
+// Relationship allows edges of the given types between nodes of this kind and another kind
+type Relationship struct {
+	Kind      string   `json:"kind"`
+	Direction string   `json:"direction,omitempty"` // out (default) or in
+	Types     []string `json:"types"`
+}
+
+// AIContext controls what the AI sees of the kind's nodes in platform context
+type AIContext struct {
+	Exclude bool     `json:"exclude,omitempty"` // keep the nodes out of AI prompts entirely
+	Fields  []string `json:"fields,omitempty"`  // spec fields shown alongside each node; none when empty
+}
+
+// Definition is a user-defined node kind
+type Definition struct {
+	Name          string          `json:"name"`
+	Description   string          `json:"description,omitempty"`
+	Schema        json.RawMessage `json:"schema,omitempty"` // JSON schema node specs must match
+	Relationships []Relationship  `json:"relationships,omitempty"`
+	Hooks         []hooks.Hook    `json:"hooks,omitempty"` // registered for this kind as <kind>/<hook name>
+	AIContext     AIContext       `json:"ai_context"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+// Validate checks the definition and returns its parsed schema, nil when it has none
+func (d *Definition) Validate() (*Schema, error) {
+	if !namePattern.MatchString(d.Name) {
+		return nil, fmt.Errorf("kind name must be 2-63 lowercase letters, digits or underscores starting with a letter, got %q", d.Name)
+	}
+	if reserved()[d.Name] {
+		return nil, fmt.Errorf("%s is a built-in kind", d.Name)
+	}
+	for _, relationship := range d.Relationships {
+		if relationship.Kind == "" {
+			return nil, fmt.Errorf("relationship kind is required")
+		}
+		if relationship.Direction != "" && relationship.Direction != DirectionOut && relationship.Direction != DirectionIn {
+			return nil, fmt.Errorf("unknown relationship direction %q (expected out or in)", relationship.Direction)
+		}
+		if len(relationship.Types) == 0 {
+			return nil, fmt.Errorf("relationship with %s needs at least one edge type", relationship.Kind)
+		}
+		for _, edgeType := range relationship.Types {
+			if !graph.IsValidEdgeType(edgeType) {
+				return nil, fmt.Errorf("unknown edge type %q", edgeType)
+			}
+		}
+	}
+	for _, hook := range d.Hooks {
+		hook.Kind = d.Name
+		if err := hook.Validate(); err != nil {
+			return nil, fmt.Errorf("hook %s: %w", hook.Name, err)
+		}
+	}
+	if len(d.Schema) == 0 {
+		return nil, nil
+	}
+	return ParseSchema(d.Schema)
+}
+
+// edgeRules turns the relationships into edge contract rules
+func (d *Definition) edgeRules() []contracts.EdgeValidationRule {
+	rules := make([]contracts.EdgeValidationRule, 0, len(d.Relationships))
+	for _, relationship := range d.Relationships {
+		rule := contracts.EdgeValidationRule{FromKind: d.Name, ToKind: relationship.Kind, AllowedTypes: relationship.Types}
+		if relationship.Direction == DirectionIn {
+			rule.FromKind, rule.ToKind = relationship.Kind, d.Name
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// reserved returns the kinds custom kinds may not take: every built-in kind and every kind
+// the edge contracts know
+func reserved() map[string]bool {
+	names := map[string]bool{}
+	for _, kind := range []string{
+		graph.KindApplication, graph.KindService, graph.KindServiceVersion, graph.KindEnvironment,
+		graph.KindResourceRegister, graph.KindResourceType, graph.KindResource, graph.KindPolicy,
+		graph.KindCheck, graph.KindProcess, graph.KindFeatureFlag, graph.KindConversation,
+		graph.KindPlan, graph.KindQuota, graph.KindSavedSearch, graph.KindCheckpoint,
+		graph.KindAIDecision, graph.KindCalendarEntry, graph.KindArchiveBatch, graph.KindNodeKind,
+		graph.KindMLModel, graph.KindModelVersion, graph.KindModelEndpoint, graph.KindMigration,
+		graph.KindTopic, graph.KindRunbook, graph.KindDRDrill,
+		graph.KindOrganization, graph.KindTeam, graph.KindProject, graph.KindAutonomy,
+		graph.KindRoutingOverride, graph.KindWorkflow, graph.KindPolicyDecision, graph.KindReleaseBundle,
+	} {
+		names[kind] = true
+	}
+	for _, rule := range contracts.EdgeValidationRules {
+		names[rule.FromKind] = true
+		names[rule.ToKind] = true
+	}
+	return names
+}
+
+// Service stores kind definitions in the graph and manages the nodes of custom kinds.
+// Definitions live in the graph so every instance sees the same kinds; the edge rules and
+// hooks they imply are registered in-process by Register and Sync.
+type Service struct {
+	graph  *graph.GlobalGraph
+	hooks  *hooks.Registry // nil when lifecycle hooks are not available
+	logger *logging.Logger
+	now    func() time.Time
+}
+
+// NewService creates the kind service; hookRegistry may be nil
+func NewService(globalGraph *graph.GlobalGraph, hookRegistry *hooks.Registry) *Service {
+	return &Service{
+		graph:  globalGraph,
+		hooks:  hookRegistry,
+		logger: logging.GetLogger().ForComponent("kinds"),
+		now:    time.Now,
+	}
+}
+
+// Register creates or replaces a kind. A changed schema must still accept every existing node.
+func (s *Service) Register(def Definition) (*Definition, error) {
+	schema, err := def.Validate()
+	if err != nil {
+		return nil, err
+	}
+	if len(def.Hooks) > 0 && s.hooks == nil {
+		return nil, fmt.Errorf("lifecycle hooks are not available")
+	}
+
+	now := s.now().UTC()
+	def.CreatedAt, def.UpdatedAt = now, now
+	existing, err := s.Get(def.Name)
+	switch {
+	case err == nil:
+		def.CreatedAt = existing.CreatedAt
+		if schema != nil {
+			if err := s.checkExisting(def.Name, schema); err != nil {
+				return nil, err
+			}
+		}
+	case !errors.Is(err, ErrKindNotFound):
+		return nil, err
+	}
+
+	node, err := definitionToNode(&def)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		err = s.graph.UpdateNode(node)
+	} else {
+		err = s.graph.AddNode(node)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to store kind %s: %w", def.Name, err)
+	}
+	s.activate(&def, existing)
+	s.logger.Info("🧩 Registered node kind %s", def.Name)
+	return &def, nil
+}
+
+// checkExisting fails when a node of the kind does not match schema
+func (s *Service) checkExisting(kind string, schema *Schema) error {
+	nodes, err := s.Nodes(kind)
+	if err != nil {
+		return err
+	}
+	var invalid []string
+	for _, node := range nodes {
+		if violations := schema.Validate(normalize(node.Spec)); len(violations) > 0 {
+			invalid = append(invalid, fmt.Sprintf("%s (%s)", node.ID, violations[0]))
+		}
+	}
+	if len(invalid) > 0 {
+		return fmt.Errorf("%w: the new schema rejects existing nodes: %s", ErrInvalidNode, strings.Join(invalid, ", "))
+	}
+	return nil
+}
+
+// Get returns a registered kind
+func (s *Service) Get(name string) (*Definition, error) {
+	node, err := s.graph.GetNode(nodeIDPrefix + name)
+	if err != nil || node == nil || node.Kind != graph.KindNodeKind {
+		return nil, fmt.Errorf("%w: %s", ErrKindNotFound, name)
+	}
+	return nodeToDefinition(node)
+}
+
+// List returns the registered kinds sorted by name
+func (s *Service) List() ([]*Definition, error) {
+	g, err := s.graph.Graph()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph: %w", err)
+	}
+	return definitions(g), nil
+}
+
+// Delete removes a kind that has no nodes left, with its edge rules and hooks
+func (s *Service) Delete(name string) error {
+	def, err := s.Get(name)
+	if err != nil {
+		return err
+	}
+	nodes, err := s.Nodes(name)
+	if err != nil {
+		return err
+	}
+	if len(nodes) > 0 {
+		return fmt.Errorf("%w: %s has %d nodes", ErrKindInUse, name, len(nodes))
+	}
+	if err := s.graph.DeleteNode(nodeIDPrefix + name); err != nil {
+		return fmt.Errorf("failed to delete kind %s: %w", name, err)
+	}
+	s.deactivate(def)
+	s.logger.Info("🧩 Removed node kind %s", name)
+	return nil
+}
+
+// Sync registers the edge rules and hooks of every stored kind, e.g. at startup or before
+// changes that rely on kinds another instance registered
+func (s *Service) Sync() error {
+	defs, err := s.List()
+	if err != nil {
+		return err
+	}
+	for _, def := range defs {
+		s.activate(def, nil)
+	}
+	return nil
+}
+
+// activate registers the edge rules and hooks of def, removing the hooks previous had
+func (s *Service) activate(def, previous *Definition) {
+	contracts.SetCustomEdgeRules(def.Name, def.edgeRules())
+	if s.hooks == nil {
+		return
+	}
+	if previous != nil {
+		s.removeHooks(previous)
+	}
+	for _, hook := range def.Hooks {
+		hook.Name = hookName(def.Name, hook.Name)
+		hook.Kind = def.Name
+		if _, err := s.hooks.Register(hook); err != nil {
+			s.logger.Warn("⚠️ Could not register hook %s: %v", hook.Name, err)
+		}
+	}
+}
+
+func (s *Service) deactivate(def *Definition) {
+	contracts.SetCustomEdgeRules(def.Name, nil)
+	if s.hooks != nil {
+		s.removeHooks(def)
+	}
+}
+
+func (s *Service) removeHooks(def *Definition) {
+	for _, hook := range def.Hooks {
+		if err := s.hooks.Delete(hookName(def.Name, hook.Name)); err != nil && !errors.Is(err, hooks.ErrHookNotFound) {
+			s.logger.Warn("⚠️ Could not remove hook %s: %v", hook.Name, err)
+		}
+	}
+}
+
+func hookName(kind, name string) string {
+	return kind + "/" + name
+}
+
+// definitions returns the kinds stored in g sorted by name, skipping unreadable ones
+func definitions(g *graph.Graph) []*Definition {
+	defs := []*Definition{}
+	for _, node := range g.Nodes {
+		if node.Kind != graph.KindNodeKind {
+			continue
+		}
+		if def, err := nodeToDefinition(node); err == nil {
+			defs = append(defs, def)
+		}
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Name < defs[j].Name })
+	return defs
+}
+
+func definitionToNode(def *Definition) (*graph.Node, error) {
+	data, err := json.Marshal(def)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode kind: %w", err)
+	}
+	var spec map[string]interface{}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to encode kind: %w", err)
+	}
+	return &graph.Node{
+		ID:   nodeIDPrefix + def.Name,
+		Kind: graph.KindNodeKind,
+		Metadata: map[string]interface{}{
+			"name":        def.Name,
+			"description": def.Description,
+		},
+		Spec: spec,
+	}, nil
+}
+
+func nodeToDefinition(node *graph.Node) (*Definition, error) {
+	data, err := json.Marshal(node.Spec)
+	if err != nil {
+		return nil, err
+	}
+	var def Definition
+	if err := json.Unmarshal(data, &def); err != nil {
+		return nil, err
+	}
+	return &def, nil
+}
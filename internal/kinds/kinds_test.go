@@ -0,0 +1,200 @@
+package kinds
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/contracts"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/hooks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const datasetSchema = `{
+	"type": "object",
+	"required": ["owner", "classification"],
+	"additionalProperties": false,
+	"properties": {
+		"owner": {"type": "string", "minLength": 2},
+		"classification": {"type": "string", "enum": ["public", "internal", "restricted"]},
+		"retention_days": {"type": "integer", "minimum": 1},
+		"tags": {"type": "array", "items": {"type": "string"}}
+	}
+}`
+
+func newKindsTestService(t *testing.T) (*Service, *graph.GlobalGraph, *hooks.Registry) {
+	t.Helper()
+	g := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	require.NoError(t, g.AddNode(&graph.Node{ID: "checkout", Kind: graph.KindApplication, Metadata: map[string]interface{}{"name": "checkout"}, Spec: map[string]interface{}{}}))
+	registry := hooks.NewRegistry()
+	service := NewService(g, registry)
+	t.Cleanup(func() {
+		defs, _ := service.List()
+		for _, def := range defs {
+			contracts.SetCustomEdgeRules(def.Name, nil)
+		}
+	})
+	return service, g, registry
+}
+
+func dataset() Definition {
+	return Definition{
+		Name:          "dataset",
+		Description:   "Analytics datasets",
+		Schema:        json.RawMessage(datasetSchema),
+		Relationships: []Relationship{{Kind: graph.KindApplication, Direction: DirectionIn, Types: []string{graph.EdgeTypeUses}}},
+		AIContext:     AIContext{Fields: []string{"owner", "classification"}},
+	}
+}
+
+func datasetNode(id string, spec map[string]interface{}) graph.Node {
+	return graph.Node{ID: id, Spec: spec}
+}
+
+func TestRegister_ValidatesDefinitions(t *testing.T) {
+	service, _, _ := newKindsTestService(t)
+
+	for name, def := range map[string]Definition{
+		"built-in kind":  {Name: graph.KindApplication},
+		"bad name":       {Name: "Data Set"},
+		"bad schema":     {Name: "dataset", Schema: json.RawMessage(`{"type": "string"}`)},
+		"bad pattern":    {Name: "dataset", Schema: json.RawMessage(`{"type": "object", "properties": {"x": {"type": "string", "pattern": "("}}}`)},
+		"bad edge type":  {Name: "dataset", Relationships: []Relationship{{Kind: graph.KindApplication, Types: []string{"likes"}}}},
+		"bad direction":  {Name: "dataset", Relationships: []Relationship{{Kind: graph.KindApplication, Direction: "sideways", Types: []string{graph.EdgeTypeUses}}}},
+		"bad hook":       {Name: "dataset", Hooks: []hooks.Hook{{Name: "cmdb", URL: "not a url", Operations: []string{hooks.OperationCreate}, Phase: hooks.PhasePre}}},
+		"no edge types":  {Name: "dataset", Relationships: []Relationship{{Kind: graph.KindApplication}}},
+		"no target kind": {Name: "dataset", Relationships: []Relationship{{Types: []string{graph.EdgeTypeUses}}}},
+	} {
+		_, err := service.Register(def)
+		assert.Error(t, err, name)
+	}
+
+	registered, err := service.Register(dataset())
+	require.NoError(t, err)
+	assert.False(t, registered.CreatedAt.IsZero())
+
+	defs, err := service.List()
+	require.NoError(t, err)
+	require.Len(t, defs, 1)
+	assert.Equal(t, "dataset", defs[0].Name)
+	assert.JSONEq(t, datasetSchema, string(defs[0].Schema))
+}
+
+func TestCreateNode_EnforcesSchema(t *testing.T) {
+	service, g, _ := newKindsTestService(t)
+	_, err := service.Register(dataset())
+	require.NoError(t, err)
+
+	_, err = service.CreateNode("dataset", datasetNode("orders", map[string]interface{}{"owner": "data-team"}))
+	require.ErrorIs(t, err, ErrInvalidNode)
+	assert.Contains(t, err.Error(), "classification is required")
+
+	_, err = service.CreateNode("dataset", datasetNode("orders", map[string]interface{}{"owner": "data-team", "classification": "secret", "retention_days": 1.5, "extra": true}))
+	require.ErrorIs(t, err, ErrInvalidNode)
+	for _, violation := range []string{"spec.classification: must be one of", "spec.retention_days: must be integer", "extra is not allowed"} {
+		assert.Contains(t, err.Error(), violation)
+	}
+
+	node, err := service.CreateNode("dataset", datasetNode("orders", map[string]interface{}{"owner": "data-team", "classification": "internal", "retention_days": 30, "tags": []string{"pii"}}))
+	require.NoError(t, err)
+	assert.Equal(t, "dataset", node.Kind)
+	assert.Equal(t, "orders", node.Metadata["name"])
+
+	stored, err := g.GetNode("orders")
+	require.NoError(t, err)
+	assert.Equal(t, "dataset", stored.Kind)
+
+	_, err = service.CreateNode("dataset", datasetNode("orders", map[string]interface{}{"owner": "data-team", "classification": "internal"}))
+	assert.ErrorIs(t, err, ErrInvalidNode, "duplicate IDs are refused")
+
+	_, err = service.UpdateNode("dataset", datasetNode("orders", map[string]interface{}{"owner": "x", "classification": "internal"}))
+	assert.ErrorIs(t, err, ErrInvalidNode)
+	_, err = service.UpdateNode("dataset", datasetNode("orders", map[string]interface{}{"owner": "data-team", "classification": "restricted"}))
+	require.NoError(t, err)
+
+	_, err = service.CreateNode("model", datasetNode("churn", nil))
+	assert.ErrorIs(t, err, ErrKindNotFound)
+	_, err = service.GetNode("dataset", "checkout")
+	assert.ErrorIs(t, err, ErrNodeNotFound, "nodes of other kinds are not served")
+}
+
+func TestRegister_RefusesSchemaThatRejectsExistingNodes(t *testing.T) {
+	service, _, _ := newKindsTestService(t)
+	_, err := service.Register(dataset())
+	require.NoError(t, err)
+	_, err = service.CreateNode("dataset", datasetNode("orders", map[string]interface{}{"owner": "data-team", "classification": "internal"}))
+	require.NoError(t, err)
+
+	stricter := dataset()
+	stricter.Schema = json.RawMessage(`{"type": "object", "required": ["owner", "classification", "retention_days"]}`)
+	_, err = service.Register(stricter)
+	require.ErrorIs(t, err, ErrInvalidNode)
+	assert.Contains(t, err.Error(), "orders")
+
+	def, err := service.Get("dataset")
+	require.NoError(t, err)
+	assert.JSONEq(t, datasetSchema, string(def.Schema), "the old schema stays in place")
+}
+
+func TestLink_FollowsRelationships(t *testing.T) {
+	service, g, _ := newKindsTestService(t)
+	_, err := service.Register(dataset())
+	require.NoError(t, err)
+	_, err = service.CreateNode("dataset", datasetNode("orders", map[string]interface{}{"owner": "data-team", "classification": "internal"}))
+	require.NoError(t, err)
+
+	assert.Error(t, service.Link("dataset", "orders", "checkout", graph.EdgeTypeUses, DirectionOut), "only application -> dataset is allowed")
+	assert.Error(t, service.Link("dataset", "orders", "checkout", graph.EdgeTypeOwns, DirectionIn))
+	require.NoError(t, service.Link("dataset", "orders", "checkout", graph.EdgeTypeUses, DirectionIn))
+
+	has, err := g.HasEdge("checkout", "orders", graph.EdgeTypeUses)
+	require.NoError(t, err)
+	assert.True(t, has)
+}
+
+func TestDelete_RefusesKindsWithNodes(t *testing.T) {
+	service, _, registry := newKindsTestService(t)
+	def := dataset()
+	def.Hooks = []hooks.Hook{{Name: "catalog", URL: "https://catalog.example.com/hook", Operations: []string{hooks.OperationCreate}, Phase: hooks.PhasePost}}
+	_, err := service.Register(def)
+	require.NoError(t, err)
+
+	hook, err := registry.Get("dataset/catalog")
+	require.NoError(t, err)
+	assert.Equal(t, "dataset", hook.Kind)
+
+	_, err = service.CreateNode("dataset", datasetNode("orders", map[string]interface{}{"owner": "data-team", "classification": "internal"}))
+	require.NoError(t, err)
+	assert.ErrorIs(t, service.Delete("dataset"), ErrKindInUse)
+
+	require.NoError(t, service.DeleteNode("dataset", "orders"))
+	require.NoError(t, service.Delete("dataset"))
+	_, err = registry.Get("dataset/catalog")
+	assert.ErrorIs(t, err, hooks.ErrHookNotFound)
+	assert.ErrorIs(t, service.Delete("dataset"), ErrKindNotFound)
+}
+
+func TestPlatformContext_HonoursAIContext(t *testing.T) {
+	service, g, _ := newKindsTestService(t)
+	_, err := service.Register(dataset())
+	require.NoError(t, err)
+	_, err = service.Register(Definition{Name: "secret_store", AIContext: AIContext{Exclude: true}})
+	require.NoError(t, err)
+	_, err = service.CreateNode("dataset", datasetNode("orders", map[string]interface{}{"owner": "data-team", "classification": "internal", "retention_days": 30}))
+	require.NoError(t, err)
+	_, err = service.CreateNode("secret_store", datasetNode("vault", nil))
+	require.NoError(t, err)
+
+	snapshot, err := g.Graph()
+	require.NoError(t, err)
+	context := PlatformContext(snapshot)
+	assert.Contains(t, context, "DATASET - Analytics datasets (1):")
+	assert.Contains(t, context, "orders (owner=data-team, classification=internal)")
+	assert.NotContains(t, context, "retention_days", "only the listed fields are shown")
+	assert.NotContains(t, strings.ToLower(context), "vault")
+
+	assert.Equal(t, []string{"dataset"}, AIVisibleKinds(snapshot))
+	assert.Equal(t, map[string]bool{"secret_store": true}, AIExcludedKinds(snapshot))
+}
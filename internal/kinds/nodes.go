@@ -0,0 +1,138 @@
+package kinds
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/krzachariassen/ZTDP/internal/contracts"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+)
+
+// CreateNode adds a node of a custom kind after checking its spec against the kind's schema
+func (s *Service) CreateNode(kind string, node graph.Node) (*graph.Node, error) {
+	if err := s.prepare(kind, &node); err != nil {
+		return nil, err
+	}
+	if existing, _ := s.graph.GetNode(node.ID); existing != nil {
+		return nil, fmt.Errorf("%w: node %s already exists", ErrInvalidNode, node.ID)
+	}
+	if err := s.graph.AddNode(&node); err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
+
+// UpdateNode replaces a node of a custom kind after checking its spec against the kind's schema
+func (s *Service) UpdateNode(kind string, node graph.Node) (*graph.Node, error) {
+	if _, err := s.GetNode(kind, node.ID); err != nil {
+		return nil, err
+	}
+	if err := s.prepare(kind, &node); err != nil {
+		return nil, err
+	}
+	if err := s.graph.UpdateNode(&node); err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
+
+// GetNode returns a node of a custom kind
+func (s *Service) GetNode(kind, id string) (*graph.Node, error) {
+	node, err := s.graph.GetNode(id)
+	if err != nil || node == nil || node.Kind != kind {
+		return nil, fmt.Errorf("%w: %s %s", ErrNodeNotFound, kind, id)
+	}
+	return node, nil
+}
+
+// Nodes returns the nodes of a kind sorted by ID
+func (s *Service) Nodes(kind string) ([]*graph.Node, error) {
+	all, err := s.graph.Nodes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph: %w", err)
+	}
+	nodes := []*graph.Node{}
+	for _, node := range all {
+		if node.Kind == kind {
+			nodes = append(nodes, node)
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	return nodes, nil
+}
+
+// DeleteNode removes a node of a custom kind and its edges
+func (s *Service) DeleteNode(kind, id string) error {
+	if _, err := s.GetNode(kind, id); err != nil {
+		return err
+	}
+	return s.graph.DeleteNode(id)
+}
+
+// Link adds an edge between a node of a custom kind and another node, from the custom node for
+// DirectionOut and to it for DirectionIn. The edge must be allowed by a relationship of either
+// kind.
+func (s *Service) Link(kind, id, other, edgeType, direction string) error {
+	if _, err := s.GetNode(kind, id); err != nil {
+		return err
+	}
+	// Kinds registered on other instances reach this one's edge rules here
+	defs, err := s.List()
+	if err != nil {
+		return err
+	}
+	for _, def := range defs {
+		contracts.SetCustomEdgeRules(def.Name, def.edgeRules())
+	}
+	if direction == DirectionIn {
+		return s.graph.AddEdge(other, id, edgeType)
+	}
+	return s.graph.AddEdge(id, other, edgeType)
+}
+
+// prepare sets the node's kind and name and validates its spec
+func (s *Service) prepare(kind string, node *graph.Node) error {
+	def, err := s.Get(kind)
+	if err != nil {
+		return err
+	}
+	if node.ID == "" {
+		return fmt.Errorf("%w: id is required", ErrInvalidNode)
+	}
+	node.Kind = kind
+	if node.Metadata == nil {
+		node.Metadata = map[string]interface{}{}
+	}
+	if _, ok := node.Metadata["name"]; !ok {
+		node.Metadata["name"] = node.ID
+	}
+	if node.Spec == nil {
+		node.Spec = map[string]interface{}{}
+	}
+	if len(def.Schema) == 0 {
+		return nil
+	}
+	schema, err := ParseSchema(def.Schema)
+	if err != nil {
+		return err
+	}
+	if violations := schema.Validate(normalize(node.Spec)); len(violations) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidNode, strings.Join(violations, "; "))
+	}
+	return nil
+}
+
+// normalize round-trips a spec through JSON so values built in Go validate like decoded ones
+func normalize(spec map[string]interface{}) interface{} {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return spec
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return spec
+	}
+	return value
+}
@@ -0,0 +1,201 @@
+package kinds
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Schema is the subset of JSON schema custom kinds validate node specs with: type, properties,
+// required, additionalProperties, items, enum, minimum, maximum, minLength, maxLength and
+// pattern. Other keywords such as description or title are accepted and ignored.
+type Schema struct {
+	Type                 interface{}        `json:"type,omitempty"` // a type name or a list of them
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+
+	types   []string
+	pattern *regexp.Regexp
+}
+
+var schemaTypes = map[string]bool{
+	"object": true, "array": true, "string": true, "number": true, "integer": true, "boolean": true, "null": true,
+}
+
+// ParseSchema decodes and checks a schema; the root must describe an object
+func ParseSchema(data json.RawMessage) (*Schema, error) {
+	var schema Schema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	if err := schema.compile("spec"); err != nil {
+		return nil, err
+	}
+	if len(schema.types) != 1 || schema.types[0] != "object" {
+		return nil, fmt.Errorf("invalid schema: the root type must be object")
+	}
+	return &schema, nil
+}
+
+func (s *Schema) compile(path string) error {
+	switch t := s.Type.(type) {
+	case nil:
+	case string:
+		s.types = []string{t}
+	case []interface{}:
+		for _, item := range t {
+			name, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("invalid schema: %s: type must be a string or a list of strings", path)
+			}
+			s.types = append(s.types, name)
+		}
+	default:
+		return fmt.Errorf("invalid schema: %s: type must be a string or a list of strings", path)
+	}
+	for _, t := range s.types {
+		if !schemaTypes[t] {
+			return fmt.Errorf("invalid schema: %s: unknown type %q", path, t)
+		}
+	}
+	if s.Pattern != "" {
+		pattern, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid schema: %s: pattern: %w", path, err)
+		}
+		s.pattern = pattern
+	}
+	for name, property := range s.Properties {
+		if property == nil {
+			return fmt.Errorf("invalid schema: %s.%s: property schema is empty", path, name)
+		}
+		if err := property.compile(path + "." + name); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		return s.Items.compile(path + "[]")
+	}
+	return nil
+}
+
+// Validate returns every violation of the schema by value, decoded from JSON
+func (s *Schema) Validate(value interface{}) []string {
+	var violations []string
+	s.validate("spec", value, &violations)
+	return violations
+}
+
+func (s *Schema) validate(path string, value interface{}, violations *[]string) {
+	fail := func(format string, args ...interface{}) {
+		*violations = append(*violations, path+": "+fmt.Sprintf(format, args...))
+	}
+	if len(s.types) > 0 && !s.hasType(value) {
+		fail("must be %s, got %s", strings.Join(s.types, " or "), typeOf(value))
+		return
+	}
+	if len(s.Enum) > 0 && !inEnum(s.Enum, value) {
+		fail("must be one of %v", s.Enum)
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				fail("%s is required", name)
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			property, ok := s.Properties[name]
+			switch {
+			case ok:
+				property.validate(path+"."+name, v[name], violations)
+			case s.AdditionalProperties != nil && !*s.AdditionalProperties:
+				fail("%s is not allowed", name)
+			}
+		}
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, violations)
+			}
+		}
+	case string:
+		length := len([]rune(v))
+		if s.MinLength != nil && length < *s.MinLength {
+			fail("must be at least %d characters", *s.MinLength)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			fail("must be at most %d characters", *s.MaxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			fail("must match %s", s.Pattern)
+		}
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			fail("must be at least %v", *s.Minimum)
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			fail("must be at most %v", *s.Maximum)
+		}
+	}
+}
+
+func (s *Schema) hasType(value interface{}) bool {
+	actual := typeOf(value)
+	for _, t := range s.types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// typeOf names the JSON type of a decoded value; whole numbers are integers
+func typeOf(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func inEnum(enum []interface{}, value interface{}) bool {
+	data, _ := json.Marshal(value)
+	for _, allowed := range enum {
+		if candidate, _ := json.Marshal(allowed); string(candidate) == string(data) {
+			return true
+		}
+	}
+	return false
+}
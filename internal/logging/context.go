@@ -0,0 +1,93 @@
+package logging
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// contextKey is unexported so only this package can set correlation fields on a context
+type contextKey string
+
+const (
+	correlationIDKey contextKey = "correlation_id"
+	agentIDKey       contextKey = "agent_id"
+	eventSubjectKey  contextKey = "event_subject"
+	userIDKey        contextKey = "user_id"
+)
+
+// WithCorrelationID returns a context carrying the correlation ID shared by all logs of a request
+func WithCorrelationID(ctx context.Context, correlationID string) context.Context {
+	return context.WithValue(ctx, correlationIDKey, correlationID)
+}
+
+// CorrelationIDFromContext returns the correlation ID stored in ctx, or "" if none is set
+func CorrelationIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDKey).(string)
+	return id
+}
+
+// EnsureCorrelationID returns ctx with a correlation ID, generating one if the context has none
+func EnsureCorrelationID(ctx context.Context) (context.Context, string) {
+	if id := CorrelationIDFromContext(ctx); id != "" {
+		return ctx, id
+	}
+	id := uuid.New().String()
+	return WithCorrelationID(ctx, id), id
+}
+
+// WithAgentID returns a context identifying the agent handling the current work
+func WithAgentID(ctx context.Context, agentID string) context.Context {
+	return context.WithValue(ctx, agentIDKey, agentID)
+}
+
+// AgentIDFromContext returns the agent ID stored in ctx, or "" if none is set
+func AgentIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(agentIDKey).(string)
+	return id
+}
+
+// WithEventSubject returns a context identifying the event being processed
+func WithEventSubject(ctx context.Context, subject string) context.Context {
+	return context.WithValue(ctx, eventSubjectKey, subject)
+}
+
+// EventSubjectFromContext returns the event subject stored in ctx, or "" if none is set
+func EventSubjectFromContext(ctx context.Context) string {
+	subject, _ := ctx.Value(eventSubjectKey).(string)
+	return subject
+}
+
+// WithUserID returns a context identifying the user a request is made for
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+// UserIDFromContext returns the user ID stored in ctx, or "" if none is set
+func UserIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(userIDKey).(string)
+	return id
+}
+
+// ForContext returns a logger that stamps every entry with the correlation fields found in ctx
+func (l *Logger) ForContext(ctx context.Context) *Logger {
+	logger := l
+	if id := CorrelationIDFromContext(ctx); id != "" {
+		logger = logger.WithCorrelationID(id)
+	}
+	if id := AgentIDFromContext(ctx); id != "" {
+		logger = logger.WithAgentID(id)
+	}
+	if subject := EventSubjectFromContext(ctx); subject != "" {
+		logger = logger.WithEventSubject(subject)
+	}
+	if id := UserIDFromContext(ctx); id != "" {
+		logger = logger.WithUserID(id)
+	}
+	return logger
+}
+
+// FromContext returns the global logger stamped with the correlation fields found in ctx
+func FromContext(ctx context.Context) *Logger {
+	return GetLogger().ForContext(ctx)
+}
@@ -0,0 +1,66 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func newTestLogger(buf *bytes.Buffer) *Logger {
+	logger := GetLogger().ForComponent("test")
+	logger.sinks = []LogSink{NewConsoleSinkWithWriter(buf, true)}
+	return logger
+}
+
+func TestForContext_StampsCorrelationFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newTestLogger(&buf)
+
+	ctx := WithCorrelationID(context.Background(), "corr-123")
+	ctx = WithAgentID(ctx, "application-agent")
+	ctx = WithEventSubject(ctx, "application.create")
+
+	logger.ForContext(ctx).Info("🚀 Creating application %s", "checkout")
+
+	var entry LogEntry
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Expected JSON output, got %q: %v", buf.String(), err)
+	}
+	if entry.CorrelationID != "corr-123" {
+		t.Errorf("Expected correlation_id 'corr-123', got: %s", entry.CorrelationID)
+	}
+	if entry.AgentID != "application-agent" {
+		t.Errorf("Expected agent_id 'application-agent', got: %s", entry.AgentID)
+	}
+	if entry.EventSubject != "application.create" {
+		t.Errorf("Expected event_subject 'application.create', got: %s", entry.EventSubject)
+	}
+	if entry.Component != "test" || entry.Level != "INFO" || entry.Timestamp.IsZero() {
+		t.Errorf("Expected standard fields to be set, got: %+v", entry)
+	}
+	if entry.Message != "Creating application checkout" {
+		t.Errorf("Expected emoji decoration to be stripped, got: %q", entry.Message)
+	}
+}
+
+func TestEnsureCorrelationID(t *testing.T) {
+	ctx, id := EnsureCorrelationID(context.Background())
+	if id == "" || CorrelationIDFromContext(ctx) != id {
+		t.Fatalf("Expected a generated correlation ID to be stored in the context, got %q", id)
+	}
+
+	_, again := EnsureCorrelationID(ctx)
+	if again != id {
+		t.Errorf("Expected existing correlation ID %q to be kept, got %q", id, again)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if format, err := ParseFormat("TEXT"); err != nil || format != FormatText {
+		t.Errorf("Expected text format, got %q (%v)", format, err)
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"runtime"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -41,21 +42,64 @@ func (l LogLevel) String() string {
 	}
 }
 
+// ParseLevel converts a level name such as "debug" or "WARN" into a LogLevel
+func ParseLevel(name string) (LogLevel, error) {
+	switch strings.ToUpper(strings.TrimSpace(name)) {
+	case "TRACE":
+		return LevelTrace, nil
+	case "DEBUG":
+		return LevelDebug, nil
+	case "INFO", "":
+		return LevelInfo, nil
+	case "WARN", "WARNING":
+		return LevelWarn, nil
+	case "ERROR":
+		return LevelError, nil
+	case "FATAL":
+		return LevelFatal, nil
+	default:
+		return LevelInfo, fmt.Errorf("unknown log level %q", name)
+	}
+}
+
 // LogEntry represents a structured log entry
 type LogEntry struct {
-	Timestamp  time.Time              `json:"timestamp"`
-	Level      string                 `json:"level"`
-	Message    string                 `json:"message"`
-	Source     string                 `json:"source"`
-	Component  string                 `json:"component,omitempty"`
-	Operation  string                 `json:"operation,omitempty"`
-	RequestID  string                 `json:"request_id,omitempty"`
-	UserID     string                 `json:"user_id,omitempty"`
-	Duration   *time.Duration         `json:"duration,omitempty"`
-	Error      string                 `json:"error,omitempty"`
-	StackTrace string                 `json:"stack_trace,omitempty"`
-	Properties map[string]interface{} `json:"properties,omitempty"`
-	Tags       []string               `json:"tags,omitempty"`
+	Timestamp     time.Time              `json:"timestamp"`
+	Level         string                 `json:"level"`
+	Message       string                 `json:"message"`
+	Source        string                 `json:"source"`
+	Component     string                 `json:"component,omitempty"`
+	Operation     string                 `json:"operation,omitempty"`
+	RequestID     string                 `json:"request_id,omitempty"`
+	CorrelationID string                 `json:"correlation_id,omitempty"`
+	AgentID       string                 `json:"agent_id,omitempty"`
+	EventSubject  string                 `json:"event_subject,omitempty"`
+	UserID        string                 `json:"user_id,omitempty"`
+	Duration      *time.Duration         `json:"duration,omitempty"`
+	Error         string                 `json:"error,omitempty"`
+	StackTrace    string                 `json:"stack_trace,omitempty"`
+	Properties    map[string]interface{} `json:"properties,omitempty"`
+	Tags          []string               `json:"tags,omitempty"`
+}
+
+// LogFormat selects how console output is rendered
+type LogFormat string
+
+const (
+	FormatJSON LogFormat = "json" // one JSON object per line, for log aggregators
+	FormatText LogFormat = "text" // human-readable lines, for local development
+)
+
+// ParseFormat converts a format name into a LogFormat
+func ParseFormat(name string) (LogFormat, error) {
+	switch LogFormat(strings.ToLower(strings.TrimSpace(name))) {
+	case FormatJSON, "":
+		return FormatJSON, nil
+	case FormatText:
+		return FormatText, nil
+	default:
+		return FormatJSON, fmt.Errorf("unknown log format %q (expected json or text)", name)
+	}
 }
 
 // LogSink defines the interface for log outputs
@@ -68,7 +112,7 @@ type LogSink interface {
 type Logger struct {
 	component string
 	sinks     []LogSink
-	level     LogLevel
+	level     *atomic.Int32 // shared with derived loggers so level changes apply everywhere
 	context   map[string]interface{}
 }
 
@@ -77,15 +121,23 @@ var globalLogger *Logger
 
 // InitializeLogger initializes the global logger with default configuration
 func InitializeLogger(component string, level LogLevel) {
+	InitializeLoggerWithFormat(component, level, FormatJSON)
+}
+
+// InitializeLoggerWithFormat initializes the global logger with the given console output format
+func InitializeLoggerWithFormat(component string, level LogLevel, format LogFormat) {
+	sharedLevel := &atomic.Int32{}
+	sharedLevel.Store(int32(level))
+
 	logger := &Logger{
 		component: component,
-		level:     level,
+		level:     sharedLevel,
 		sinks:     make([]LogSink, 0),
 		context:   make(map[string]interface{}),
 	}
 
 	// Add default console sink
-	consoleSink := NewConsoleSink(true) // structured output
+	consoleSink := NewConsoleSink(format == FormatJSON)
 	logger.AddSink(consoleSink)
 
 	globalLogger = logger
@@ -126,6 +178,21 @@ func (l *Logger) WithRequestID(requestID string) *Logger {
 	return l.WithContext("request_id", requestID)
 }
 
+// WithCorrelationID adds the correlation ID shared by all logs of a request
+func (l *Logger) WithCorrelationID(correlationID string) *Logger {
+	return l.WithContext("correlation_id", correlationID)
+}
+
+// WithAgentID adds the ID of the agent producing the logs
+func (l *Logger) WithAgentID(agentID string) *Logger {
+	return l.WithContext("agent_id", agentID)
+}
+
+// WithEventSubject adds the subject of the event being processed
+func (l *Logger) WithEventSubject(subject string) *Logger {
+	return l.WithContext("event_subject", subject)
+}
+
 // WithUserID adds a user ID to the logger context
 func (l *Logger) WithUserID(userID string) *Logger {
 	return l.WithContext("user_id", userID)
@@ -141,14 +208,19 @@ func (l *Logger) AddSink(sink LogSink) {
 	l.sinks = append(l.sinks, sink)
 }
 
-// SetLevel sets the minimum log level
+// SetLevel sets the minimum log level for this logger and all loggers derived from it
 func (l *Logger) SetLevel(level LogLevel) {
-	l.level = level
+	l.level.Store(int32(level))
+}
+
+// GetLevel returns the current minimum log level
+func (l *Logger) GetLevel() LogLevel {
+	return LogLevel(l.level.Load())
 }
 
 // log is the internal method that handles actual logging
 func (l *Logger) log(level LogLevel, msg string, args ...interface{}) {
-	if level < l.level {
+	if level < l.GetLevel() {
 		return
 	}
 
@@ -178,16 +250,7 @@ func (l *Logger) log(level LogLevel, msg string, args ...interface{}) {
 		Properties: copyMap(l.context),
 	}
 
-	// Extract specific context values
-	if requestID, ok := l.context["request_id"].(string); ok {
-		entry.RequestID = requestID
-	}
-	if userID, ok := l.context["user_id"].(string); ok {
-		entry.UserID = userID
-	}
-	if operation, ok := l.context["operation"].(string); ok {
-		entry.Operation = operation
-	}
+	l.applyContextFields(&entry)
 
 	// Write to all sinks
 	for _, sink := range l.sinks {
@@ -340,18 +403,31 @@ func (l *Logger) createEntry(level LogLevel, msg string, args ...interface{}) Lo
 		Properties: copyMap(l.context),
 	}
 
-	// Extract specific context values
+	l.applyContextFields(&entry)
+
+	return entry
+}
+
+// applyContextFields promotes well-known context values to top-level entry fields
+func (l *Logger) applyContextFields(entry *LogEntry) {
 	if requestID, ok := l.context["request_id"].(string); ok {
 		entry.RequestID = requestID
 	}
+	if correlationID, ok := l.context["correlation_id"].(string); ok {
+		entry.CorrelationID = correlationID
+	}
+	if agentID, ok := l.context["agent_id"].(string); ok {
+		entry.AgentID = agentID
+	}
+	if subject, ok := l.context["event_subject"].(string); ok {
+		entry.EventSubject = subject
+	}
 	if userID, ok := l.context["user_id"].(string); ok {
 		entry.UserID = userID
 	}
 	if operation, ok := l.context["operation"].(string); ok {
 		entry.Operation = operation
 	}
-
-	return entry
 }
 
 func (l *Logger) writeEntry(entry LogEntry) {
@@ -79,8 +79,18 @@ func (m *HTTPMiddleware) LogRequest(next http.Handler) http.Handler {
 			requestID = uuid.New().String()
 		}
 
-		// Add request ID to response headers
+		// Correlate with the caller's trace when provided, otherwise the request ID is the correlation ID
+		correlationID := r.Header.Get("X-Correlation-ID")
+		if correlationID == "" {
+			correlationID = requestID
+		}
+
+		// Add request and correlation IDs to response headers
 		w.Header().Set("X-Request-ID", requestID)
+		w.Header().Set("X-Correlation-ID", correlationID)
+
+		// Downstream handlers, the orchestrator and agents log with the same correlation ID
+		r = r.WithContext(WithCorrelationID(r.Context(), correlationID))
 
 		// Create wrapped response writer to capture status code
 		wrapped := &responseWriter{
@@ -91,6 +101,7 @@ func (m *HTTPMiddleware) LogRequest(next http.Handler) http.Handler {
 		// Create request-scoped logger
 		reqLogger := m.logger.
 			WithRequestID(requestID).
+			WithCorrelationID(correlationID).
 			WithOperation("http_request")
 
 		// Log request start
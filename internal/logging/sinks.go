@@ -7,6 +7,7 @@ import (
 	"os"
 	"strings"
 	"time"
+	"unicode"
 )
 
 // ConsoleSink writes logs to the console
@@ -48,6 +49,7 @@ func (c *ConsoleSink) Close() error {
 }
 
 func (c *ConsoleSink) writeStructured(entry LogEntry) error {
+	entry.Message = stripDecorations(entry.Message)
 	data, err := json.Marshal(entry)
 	if err != nil {
 		return err
@@ -94,6 +96,14 @@ func (c *ConsoleSink) writeFormatted(entry LogEntry) error {
 		parts = append(parts, fmt.Sprintf("[req:%s]", entry.RequestID))
 	}
 
+	if entry.CorrelationID != "" {
+		parts = append(parts, fmt.Sprintf("[corr:%s]", entry.CorrelationID))
+	}
+
+	if entry.AgentID != "" && entry.AgentID != entry.Component {
+		parts = append(parts, fmt.Sprintf("[agent:%s]", entry.AgentID))
+	}
+
 	if entry.Operation != "" {
 		parts = append(parts, fmt.Sprintf("[%s]", entry.Operation))
 	}
@@ -117,7 +127,8 @@ func (c *ConsoleSink) writeFormatted(entry LogEntry) error {
 		var props []string
 		for k, v := range entry.Properties {
 			// Skip already displayed properties
-			if k == "request_id" || k == "operation" {
+			switch k {
+			case "request_id", "operation", "correlation_id", "agent_id":
 				continue
 			}
 			props = append(props, fmt.Sprintf("%s=%v", k, v))
@@ -131,6 +142,18 @@ func (c *ConsoleSink) writeFormatted(entry LogEntry) error {
 	return err
 }
 
+// stripDecorations removes leading emoji and symbols so JSON messages are plain, searchable text
+func stripDecorations(message string) string {
+	trimmed := strings.TrimLeftFunc(message, func(r rune) bool {
+		return unicode.Is(unicode.So, r) || unicode.Is(unicode.Sk, r) ||
+			r == '\uFE0F' || r == '\u200D' || unicode.IsSpace(r)
+	})
+	if trimmed == "" {
+		return message
+	}
+	return trimmed
+}
+
 // FileSink writes logs to a file
 type FileSink struct {
 	file       *os.File
@@ -0,0 +1,232 @@
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// LogQuery filters retained log entries. Zero values match everything.
+type LogQuery struct {
+	Component     string
+	MinLevel      *LogLevel // entries below this level are excluded
+	CorrelationID string
+	Since         time.Time
+	Until         time.Time
+	Limit         int // most recent entries to return; 0 means all matches
+}
+
+// Matches reports whether an entry satisfies the query filters (Limit is applied by the store)
+func (q LogQuery) Matches(entry LogEntry) bool {
+	if q.Component != "" && entry.Component != q.Component {
+		return false
+	}
+	if q.CorrelationID != "" && entry.CorrelationID != q.CorrelationID {
+		return false
+	}
+	if q.MinLevel != nil {
+		if level, err := ParseLevel(entry.Level); err != nil || level < *q.MinLevel {
+			return false
+		}
+	}
+	if !q.Since.IsZero() && entry.Timestamp.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && entry.Timestamp.After(q.Until) {
+		return false
+	}
+	return true
+}
+
+// LogStore is a sink that retains entries so they can be queried after the fact
+type LogStore interface {
+	LogSink
+	// Query returns matching entries in chronological order
+	Query(ctx context.Context, q LogQuery) ([]LogEntry, error)
+}
+
+// applyLimit keeps the most recent limit entries of a chronologically ordered slice
+func applyLimit(entries []LogEntry, limit int) []LogEntry {
+	if limit > 0 && len(entries) > limit {
+		return entries[len(entries)-limit:]
+	}
+	return entries
+}
+
+// RingBufferStore retains the most recent log entries in memory
+type RingBufferStore struct {
+	mu      sync.RWMutex
+	entries []LogEntry
+	next    int
+	full    bool
+}
+
+// NewRingBufferStore creates an in-memory store holding at most capacity entries
+func NewRingBufferStore(capacity int) *RingBufferStore {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &RingBufferStore{entries: make([]LogEntry, capacity)}
+}
+
+// Write retains an entry, overwriting the oldest one once the buffer is full
+func (s *RingBufferStore) Write(entry LogEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[s.next] = entry
+	s.next = (s.next + 1) % len(s.entries)
+	if s.next == 0 {
+		s.full = true
+	}
+	return nil
+}
+
+// Query returns matching entries in chronological order
+func (s *RingBufferStore) Query(ctx context.Context, q LogQuery) ([]LogEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	start, count := 0, s.next
+	if s.full {
+		start, count = s.next, len(s.entries)
+	}
+
+	var matches []LogEntry
+	for i := 0; i < count; i++ {
+		entry := s.entries[(start+i)%len(s.entries)]
+		if q.Matches(entry) {
+			matches = append(matches, entry)
+		}
+	}
+	return applyLimit(matches, q.Limit), nil
+}
+
+// Close is a no-op for the in-memory store
+func (s *RingBufferStore) Close() error {
+	return nil
+}
+
+// ErrLogStoreClosed is returned for entries written after the store was closed
+var ErrLogStoreClosed = errors.New("log store closed")
+
+// redisLogKey is the Redis list holding retained entries, newest first
+const redisLogKey = "ztdp:logs"
+
+// RedisLogStore retains log entries in a capped Redis list so history survives restarts
+// and is shared between API replicas. Writes are queued and flushed in the background so
+// logging never blocks on Redis; entries are dropped if the queue is full.
+type RedisLogStore struct {
+	client   *redis.Client
+	capacity int64
+	queue    chan []byte
+	done     chan struct{}
+
+	mu     sync.RWMutex // held for reading while queueing, so Close never closes the queue under a writer
+	closed bool
+}
+
+// NewRedisLogStore creates a Redis-backed store holding at most capacity entries
+func NewRedisLogStore(client *redis.Client, capacity int) *RedisLogStore {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	s := &RedisLogStore{
+		client:   client,
+		capacity: int64(capacity),
+		queue:    make(chan []byte, 1024),
+		done:     make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// Write queues an entry for the background writer. Entries written after Close are refused
+// with ErrLogStoreClosed.
+func (s *RedisLogStore) Write(entry LogEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("marshal log entry: %w", err)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return ErrLogStoreClosed
+	}
+	select {
+	case s.queue <- data:
+	default:
+		// Redis is slow or down: drop the entry rather than stall the caller
+	}
+	return nil
+}
+
+// run pushes queued entries in batches and trims the list to capacity
+func (s *RedisLogStore) run() {
+	defer close(s.done)
+
+	for data := range s.queue {
+		batch := []interface{}{data}
+	drain:
+		for len(batch) < 100 {
+			select {
+			case more, ok := <-s.queue:
+				if !ok {
+					break drain
+				}
+				batch = append(batch, more)
+			default:
+				break drain
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		pipe := s.client.Pipeline()
+		pipe.LPush(ctx, redisLogKey, batch...)
+		pipe.LTrim(ctx, redisLogKey, 0, s.capacity-1)
+		pipe.Exec(ctx) // best effort: a log store must never fail the caller
+		cancel()
+	}
+}
+
+// Query returns matching entries in chronological order
+func (s *RedisLogStore) Query(ctx context.Context, q LogQuery) ([]LogEntry, error) {
+	items, err := s.client.LRange(ctx, redisLogKey, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("read log entries: %w", err)
+	}
+
+	var matches []LogEntry
+	// The list is newest first, so walk it backwards for chronological order
+	for i := len(items) - 1; i >= 0; i-- {
+		var entry LogEntry
+		if err := json.Unmarshal([]byte(items[i]), &entry); err != nil {
+			continue
+		}
+		if q.Matches(entry) {
+			matches = append(matches, entry)
+		}
+	}
+	return applyLimit(matches, q.Limit), nil
+}
+
+// Close flushes queued entries and closes the Redis client; closing again is a no-op
+func (s *RedisLogStore) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	close(s.queue)
+	s.mu.Unlock()
+
+	<-s.done
+	return s.client.Close()
+}
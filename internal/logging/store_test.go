@@ -0,0 +1,85 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRingBufferStore_KeepsMostRecentEntries(t *testing.T) {
+	store := NewRingBufferStore(3)
+	base := time.Now()
+	for i := 0; i < 5; i++ {
+		store.Write(LogEntry{Timestamp: base.Add(time.Duration(i) * time.Second), Level: "INFO", Message: string(rune('a' + i))})
+	}
+
+	entries, err := store.Query(context.Background(), LogQuery{})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 retained entries, got: %d", len(entries))
+	}
+	for i, want := range []string{"c", "d", "e"} {
+		if entries[i].Message != want {
+			t.Errorf("Expected entry %d to be %q, got %q", i, want, entries[i].Message)
+		}
+	}
+}
+
+func TestRingBufferStore_QueryFilters(t *testing.T) {
+	store := NewRingBufferStore(10)
+	base := time.Now()
+	store.Write(LogEntry{Timestamp: base, Level: "DEBUG", Component: "orchestrator", CorrelationID: "req-1"})
+	store.Write(LogEntry{Timestamp: base.Add(time.Second), Level: "WARN", Component: "orchestrator", CorrelationID: "req-1"})
+	store.Write(LogEntry{Timestamp: base.Add(2 * time.Second), Level: "ERROR", Component: "policy-agent", CorrelationID: "req-2"})
+	store.Write(LogEntry{Timestamp: base.Add(3 * time.Second), Level: "INFO", Component: "orchestrator", CorrelationID: "req-2"})
+
+	warn := LevelWarn
+	tests := []struct {
+		name  string
+		query LogQuery
+		want  int
+	}{
+		{"component", LogQuery{Component: "orchestrator"}, 3},
+		{"minimum level", LogQuery{MinLevel: &warn}, 2},
+		{"correlation id", LogQuery{CorrelationID: "req-2"}, 2},
+		{"time range", LogQuery{Since: base.Add(time.Second), Until: base.Add(2 * time.Second)}, 2},
+		{"limit keeps newest", LogQuery{Component: "orchestrator", Limit: 1}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := store.Query(context.Background(), tt.query)
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			if len(entries) != tt.want {
+				t.Errorf("Expected %d entries, got: %d", tt.want, len(entries))
+			}
+		})
+	}
+
+	entries, _ := store.Query(context.Background(), LogQuery{Component: "orchestrator", Limit: 1})
+	if len(entries) == 1 && entries[0].Level != "INFO" {
+		t.Errorf("Expected limit to keep the most recent entry, got level %s", entries[0].Level)
+	}
+}
+
+func TestRedisLogStore_WriteAfterClose(t *testing.T) {
+	// Nothing is queued, so the store never has to reach Redis
+	store := NewRedisLogStore(redis.NewClient(&redis.Options{Addr: "127.0.0.1:0"}), 10)
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if err := store.Write(LogEntry{Timestamp: time.Now(), Level: "INFO", Message: "late"}); !errors.Is(err, ErrLogStoreClosed) {
+		t.Errorf("Expected ErrLogStoreClosed for a write after Close, got %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Errorf("Expected closing again to be a no-op, got %v", err)
+	}
+}
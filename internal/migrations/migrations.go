@@ -0,0 +1,266 @@
+// Package migrations tracks database schema migrations in the platform graph. Service versions
+// declare the migrations they ship; each migration records its execution status per
+// environment on its node, so the deployment planner can run pending migrations before a
+// rollout and policies can refuse irreversible ones.
+package migrations
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+var (
+	// ErrMigrationNotFound is returned for a migration that was never declared
+	ErrMigrationNotFound = errors.New("migration not found")
+	// ErrVersionNotFound is returned when the service version a migration belongs to does not exist
+	ErrVersionNotFound = errors.New("service version not found")
+)
+
+// Execution statuses of a migration in an environment
+const (
+	StatusPending    = "pending"
+	StatusRunning    = "running"
+	StatusSucceeded  = "succeeded"
+	StatusFailed     = "failed"
+	StatusRolledBack = "rolled_back"
+)
+
+var statuses = map[string]bool{
+	StatusPending: true, StatusRunning: true, StatusSucceeded: true, StatusFailed: true, StatusRolledBack: true,
+}
+
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,62}$`)
+
+// Migration is a schema change shipped with a service version
+type Migration struct {
+	ID          string                `json:"id"`
+	Service     string                `json:"service"`
+	Version     string                `json:"version"`
+	Name        string                `json:"name"`
+	Order       int                   `json:"order"`            // position among the version's migrations; lower runs first
+	Engine      string                `json:"engine,omitempty"` // e.g. flyway, liquibase, goose
+	Description string                `json:"description,omitempty"`
+	UpURI       string                `json:"up_uri"`             // artifact that applies the change
+	DownURI     string                `json:"down_uri,omitempty"` // artifact that reverts it
+	Reversible  bool                  `json:"reversible"`         // whether a down artifact was declared
+	Executions  map[string]*Execution `json:"executions,omitempty"`
+	CreatedAt   time.Time             `json:"created_at"`
+}
+
+// Execution is the outcome of running a migration in one environment
+type Execution struct {
+	Status    string    `json:"status"`
+	Message   string    `json:"message,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Status returns the migration's execution status in the environment; migrations that never
+// ran there are pending
+func (m *Migration) Status(environment string) string {
+	if execution, ok := m.Executions[environment]; ok {
+		return execution.Status
+	}
+	return StatusPending
+}
+
+// Service declares migrations and records their execution
+type Service struct {
+	graph  *graph.GlobalGraph
+	logger *logging.Logger
+	now    func() time.Time
+}
+
+// NewService creates the migration service
+func NewService(globalGraph *graph.GlobalGraph) *Service {
+	return &Service{
+		graph:  globalGraph,
+		logger: logging.GetLogger().ForComponent("migrations"),
+		now:    time.Now,
+	}
+}
+
+// Declare adds a migration to a service version. Migrations are immutable once declared; a
+// migration without an order runs after those already declared for the version.
+func (s *Service) Declare(migration Migration) (*Migration, error) {
+	if !namePattern.MatchString(migration.Name) {
+		return nil, fmt.Errorf("migration name must be lowercase letters, digits, dots, dashes or underscores, got %q", migration.Name)
+	}
+	if migration.UpURI == "" {
+		return nil, fmt.Errorf("up_uri is required")
+	}
+	versionID := migration.Service + ":" + migration.Version
+	if node, err := s.graph.GetNode(versionID); err != nil || node == nil || node.Kind != graph.KindServiceVersion {
+		return nil, fmt.Errorf("%w: %s", ErrVersionNotFound, versionID)
+	}
+	migration.ID = versionID + ":" + migration.Name
+	if existing, _ := s.graph.GetNode(migration.ID); existing != nil {
+		return nil, fmt.Errorf("migration %s already exists", migration.ID)
+	}
+	if migration.Order == 0 {
+		declared, err := s.List(migration.Service, migration.Version)
+		if err != nil {
+			return nil, err
+		}
+		for _, other := range declared {
+			if other.Order > migration.Order {
+				migration.Order = other.Order
+			}
+		}
+		migration.Order++
+	}
+
+	migration.Reversible = migration.DownURI != ""
+	migration.Executions = nil
+	migration.CreatedAt = s.now().UTC()
+	node, err := toNode(&migration)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.graph.AddNode(node); err != nil {
+		return nil, fmt.Errorf("failed to store migration %s: %w", migration.ID, err)
+	}
+	if err := s.graph.AddEdge(versionID, migration.ID, graph.EdgeTypeHasMigration); err != nil {
+		s.graph.DeleteNode(migration.ID)
+		return nil, fmt.Errorf("failed to link %s to %s: %w", migration.ID, versionID, err)
+	}
+	s.logger.Info("🗄️ Declared migration %s (reversible: %t)", migration.ID, migration.Reversible)
+	return &migration, nil
+}
+
+// Get returns a declared migration
+func (s *Service) Get(id string) (*Migration, error) {
+	node, err := s.graph.GetNode(id)
+	if err != nil || node == nil || node.Kind != graph.KindMigration {
+		return nil, fmt.Errorf("%w: %s", ErrMigrationNotFound, id)
+	}
+	return fromNode(node)
+}
+
+// List returns the migrations of a service version, or of every version of the service when
+// version is empty, in the order they run
+func (s *Service) List(service, version string) ([]*Migration, error) {
+	g, err := s.graph.Graph()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph: %w", err)
+	}
+	if version != "" {
+		return versionMigrations(g, service+":"+version), nil
+	}
+	return serviceMigrations(g, service), nil
+}
+
+// Pending returns the migrations of the application's services that have not succeeded in the
+// environment, in the order they must run: version by version, then by their order
+func (s *Service) Pending(appName, environment string) ([]*Migration, error) {
+	g, err := s.graph.Graph()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph: %w", err)
+	}
+	var services []string
+	for _, edge := range g.Edges[appName] {
+		if node, ok := g.Nodes[edge.To]; ok && edge.Type == graph.EdgeTypeOwns && node.Kind == graph.KindService {
+			services = append(services, edge.To)
+		}
+	}
+	sort.Strings(services)
+
+	pending := []*Migration{}
+	for _, service := range services {
+		for _, migration := range serviceMigrations(g, service) {
+			if migration.Status(environment) != StatusSucceeded {
+				pending = append(pending, migration)
+			}
+		}
+	}
+	return pending, nil
+}
+
+// RecordExecution stores the outcome of running a migration in an environment on its node
+func (s *Service) RecordExecution(id, environment, status, message string) (*Migration, error) {
+	if !statuses[status] {
+		return nil, fmt.Errorf("unknown migration status %q", status)
+	}
+	if node, err := s.graph.GetNode(environment); err != nil || node == nil || node.Kind != graph.KindEnvironment {
+		return nil, fmt.Errorf("environment %s not found", environment)
+	}
+	migration, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if migration.Executions == nil {
+		migration.Executions = map[string]*Execution{}
+	}
+	migration.Executions[environment] = &Execution{Status: status, Message: message, UpdatedAt: s.now().UTC()}
+
+	node, err := toNode(migration)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.graph.UpdateNode(node); err != nil {
+		return nil, fmt.Errorf("failed to store status of %s: %w", id, err)
+	}
+	s.logger.Info("🗄️ Migration %s: %s in %s", id, status, environment)
+	return migration, nil
+}
+
+// serviceMigrations returns the migrations of every version of the service; versions are
+// linked in creation order
+func serviceMigrations(g *graph.Graph, service string) []*Migration {
+	migrations := []*Migration{}
+	for _, edge := range g.Edges[service] {
+		if edge.Type == graph.EdgeTypeHasVersion {
+			migrations = append(migrations, versionMigrations(g, edge.To)...)
+		}
+	}
+	return migrations
+}
+
+func versionMigrations(g *graph.Graph, versionID string) []*Migration {
+	migrations := []*Migration{}
+	for _, edge := range g.Edges[versionID] {
+		node, ok := g.Nodes[edge.To]
+		if !ok || edge.Type != graph.EdgeTypeHasMigration || node.Kind != graph.KindMigration {
+			continue
+		}
+		if migration, err := fromNode(node); err == nil {
+			migrations = append(migrations, migration)
+		}
+	}
+	sort.SliceStable(migrations, func(i, j int) bool { return migrations[i].Order < migrations[j].Order })
+	return migrations
+}
+
+func toNode(migration *Migration) (*graph.Node, error) {
+	data, err := json.Marshal(migration)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", migration.ID, err)
+	}
+	var spec map[string]interface{}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", migration.ID, err)
+	}
+	metadata := map[string]interface{}{"name": migration.ID, "reversible": migration.Reversible}
+	if migration.Description != "" {
+		metadata["description"] = migration.Description
+	}
+	return &graph.Node{ID: migration.ID, Kind: graph.KindMigration, Metadata: metadata, Spec: spec}, nil
+}
+
+func fromNode(node *graph.Node) (*Migration, error) {
+	data, err := json.Marshal(node.Spec)
+	if err != nil {
+		return nil, err
+	}
+	var migration Migration
+	if err := json.Unmarshal(data, &migration); err != nil {
+		return nil, err
+	}
+	return &migration, nil
+}
@@ -0,0 +1,108 @@
+package migrations
+
+import (
+	"testing"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMigrationTestService(t *testing.T) (*Service, *graph.GlobalGraph) {
+	t.Helper()
+	g := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	add := func(id, kind string) {
+		require.NoError(t, g.AddNode(&graph.Node{ID: id, Kind: kind, Metadata: map[string]interface{}{"name": id}, Spec: map[string]interface{}{}}))
+	}
+	add("checkout", graph.KindApplication)
+	add("checkout-api", graph.KindService)
+	add("checkout-api:1.0.0", graph.KindServiceVersion)
+	add("checkout-api:1.1.0", graph.KindServiceVersion)
+	add("prod", graph.KindEnvironment)
+	add("staging", graph.KindEnvironment)
+	require.NoError(t, g.AddEdge("checkout", "checkout-api", graph.EdgeTypeOwns))
+	require.NoError(t, g.AddEdge("checkout-api", "checkout-api:1.0.0", graph.EdgeTypeHasVersion))
+	require.NoError(t, g.AddEdge("checkout-api", "checkout-api:1.1.0", graph.EdgeTypeHasVersion))
+
+	service := NewService(g)
+	clock := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time {
+		clock = clock.Add(time.Minute)
+		return clock
+	}
+	return service, g
+}
+
+func TestDeclare_LinksMigrationsToVersions(t *testing.T) {
+	service, g := newMigrationTestService(t)
+
+	first, err := service.Declare(Migration{Service: "checkout-api", Version: "1.1.0", Name: "add_orders", UpURI: "s3://sql/add_orders.up.sql", DownURI: "s3://sql/add_orders.down.sql"})
+	require.NoError(t, err)
+	assert.Equal(t, "checkout-api:1.1.0:add_orders", first.ID)
+	assert.Equal(t, 1, first.Order)
+	assert.True(t, first.Reversible)
+	second, err := service.Declare(Migration{Service: "checkout-api", Version: "1.1.0", Name: "drop_carts", UpURI: "s3://sql/drop_carts.up.sql"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, second.Order, "migrations without an order run last")
+	assert.False(t, second.Reversible)
+
+	has, err := g.HasEdge("checkout-api:1.1.0", second.ID, graph.EdgeTypeHasMigration)
+	require.NoError(t, err)
+	assert.True(t, has)
+	node, err := g.GetNode(second.ID)
+	require.NoError(t, err)
+	assert.Equal(t, false, node.Metadata["reversible"])
+
+	_, err = service.Declare(Migration{Service: "checkout-api", Version: "1.1.0", Name: "add_orders", UpURI: "s3://other"})
+	assert.Error(t, err, "migrations are immutable")
+	_, err = service.Declare(Migration{Service: "checkout-api", Version: "9.9.9", Name: "add_orders", UpURI: "s3://sql"})
+	assert.ErrorIs(t, err, ErrVersionNotFound)
+	_, err = service.Declare(Migration{Service: "checkout-api", Version: "1.1.0", Name: "no_artifact"})
+	assert.Error(t, err)
+	_, err = service.Get("checkout-api:1.1.0:missing")
+	assert.ErrorIs(t, err, ErrMigrationNotFound)
+}
+
+func TestPending_OrdersAndTracksStatusPerEnvironment(t *testing.T) {
+	service, _ := newMigrationTestService(t)
+	for _, m := range []Migration{
+		{Service: "checkout-api", Version: "1.1.0", Name: "backfill", Order: 2, UpURI: "s3://sql/backfill.sql"},
+		{Service: "checkout-api", Version: "1.1.0", Name: "add_column", Order: 1, UpURI: "s3://sql/add_column.sql"},
+		{Service: "checkout-api", Version: "1.0.0", Name: "create_orders", UpURI: "s3://sql/create_orders.sql"},
+	} {
+		_, err := service.Declare(m)
+		require.NoError(t, err)
+	}
+
+	ids := func(migrations []*Migration) []string {
+		result := []string{}
+		for _, m := range migrations {
+			result = append(result, m.Name)
+		}
+		return result
+	}
+	pending, err := service.Pending("checkout", "prod")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"create_orders", "add_column", "backfill"}, ids(pending), "version by version, then by order")
+
+	migration, err := service.RecordExecution("checkout-api:1.0.0:create_orders", "prod", StatusSucceeded, "")
+	require.NoError(t, err)
+	assert.Equal(t, StatusSucceeded, migration.Status("prod"))
+	assert.Equal(t, StatusPending, migration.Status("staging"))
+	_, err = service.RecordExecution("checkout-api:1.1.0:add_column", "prod", StatusFailed, "lock timeout")
+	require.NoError(t, err)
+
+	pending, err = service.Pending("checkout", "prod")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"add_column", "backfill"}, ids(pending), "failed migrations run again")
+	assert.Equal(t, "lock timeout", pending[0].Executions["prod"].Message)
+	pending, err = service.Pending("checkout", "staging")
+	require.NoError(t, err)
+	assert.Len(t, pending, 3)
+
+	_, err = service.RecordExecution("checkout-api:1.1.0:backfill", "prod", "done", "")
+	assert.Error(t, err)
+	_, err = service.RecordExecution("checkout-api:1.1.0:backfill", "qa", StatusSucceeded, "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,67 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrNoRunner is returned when no runner is registered for a migration's engine
+var ErrNoRunner = errors.New("no migration runner registered for engine")
+
+// Runner applies migrations of one engine (flyway, liquibase, goose, ...) to an environment's
+// database by running their up artifact
+type Runner interface {
+	// Engine is the migration engine the runner handles, e.g. "flyway"
+	Engine() string
+
+	// Apply runs the migration's up artifact in the environment
+	Apply(ctx context.Context, migration *Migration, environment string) error
+}
+
+var (
+	runners   = make(map[string]Runner)
+	runnersMu sync.RWMutex
+)
+
+// RegisterRunner registers a runner for its engine, replacing any previous one
+func RegisterRunner(r Runner) {
+	runnersMu.Lock()
+	defer runnersMu.Unlock()
+	runners[r.Engine()] = r
+}
+
+// GetRunner returns the runner registered for an engine
+func GetRunner(engine string) (Runner, bool) {
+	runnersMu.RLock()
+	defer runnersMu.RUnlock()
+	r, ok := runners[engine]
+	return r, ok
+}
+
+// Apply runs a migration in an environment with the runner registered for its engine and
+// records the outcome on its node. Without a runner the migration stays pending, with the
+// reason recorded, and ErrNoRunner is returned: nothing is reported as applied that was not.
+func (s *Service) Apply(ctx context.Context, migration *Migration, environment string) error {
+	runner, ok := GetRunner(migration.Engine)
+	if !ok {
+		cause := fmt.Errorf("%w %q", ErrNoRunner, migration.Engine)
+		if _, err := s.RecordExecution(migration.ID, environment, StatusPending, "Not applied: "+cause.Error()); err != nil {
+			return err
+		}
+		return fmt.Errorf("migration %s: %w", migration.ID, cause)
+	}
+
+	if _, err := s.RecordExecution(migration.ID, environment, StatusRunning, "Running before rollout"); err != nil {
+		return err
+	}
+	if err := runner.Apply(ctx, migration, environment); err != nil {
+		if _, recordErr := s.RecordExecution(migration.ID, environment, StatusFailed, err.Error()); recordErr != nil {
+			return recordErr
+		}
+		return fmt.Errorf("migration %s failed: %w", migration.ID, err)
+	}
+	_, err := s.RecordExecution(migration.ID, environment, StatusSucceeded, "Applied before rollout")
+	return err
+}
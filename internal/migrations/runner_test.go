@@ -0,0 +1,65 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedRunner applies migrations by recording them, failing those listed in fail
+type scriptedRunner struct {
+	engine  string
+	fail    map[string]error
+	applied []string
+}
+
+func (r *scriptedRunner) Engine() string { return r.engine }
+
+func (r *scriptedRunner) Apply(ctx context.Context, migration *Migration, environment string) error {
+	if err := r.fail[migration.Name]; err != nil {
+		return err
+	}
+	r.applied = append(r.applied, migration.ID+"@"+environment)
+	return nil
+}
+
+func TestApply_RecordsTheRunnersOutcome(t *testing.T) {
+	service, _ := newMigrationTestService(t)
+	runner := &scriptedRunner{engine: "apply-test", fail: map[string]error{"drop_carts": errors.New("lock timeout")}}
+	RegisterRunner(runner)
+
+	added, err := service.Declare(Migration{Service: "checkout-api", Version: "1.1.0", Name: "add_orders", Engine: "apply-test", UpURI: "s3://sql/add_orders.up.sql"})
+	require.NoError(t, err)
+	require.NoError(t, service.Apply(context.Background(), added, "prod"))
+	assert.Equal(t, []string{"checkout-api:1.1.0:add_orders@prod"}, runner.applied)
+	added, err = service.Get(added.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusSucceeded, added.Status("prod"))
+
+	dropped, err := service.Declare(Migration{Service: "checkout-api", Version: "1.1.0", Name: "drop_carts", Engine: "apply-test", UpURI: "s3://sql/drop_carts.up.sql"})
+	require.NoError(t, err)
+	assert.ErrorContains(t, service.Apply(context.Background(), dropped, "prod"), "lock timeout")
+	dropped, err = service.Get(dropped.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusFailed, dropped.Status("prod"))
+	assert.Equal(t, "lock timeout", dropped.Executions["prod"].Message)
+}
+
+func TestApply_WithoutRunnerLeavesMigrationPending(t *testing.T) {
+	service, _ := newMigrationTestService(t)
+	migration, err := service.Declare(Migration{Service: "checkout-api", Version: "1.1.0", Name: "add_orders", Engine: "unregistered", UpURI: "s3://sql/add_orders.up.sql"})
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, service.Apply(context.Background(), migration, "prod"), ErrNoRunner)
+	migration, err = service.Get(migration.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusPending, migration.Status("prod"), "nothing is reported as applied")
+	assert.Contains(t, migration.Executions["prod"].Message, `"unregistered"`)
+
+	pending, err := service.Pending("checkout", "prod")
+	require.NoError(t, err)
+	assert.Len(t, pending, 1)
+}
@@ -0,0 +1,338 @@
+package mlmodels
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/deployments"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/guardrails"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/krzachariassen/ZTDP/internal/resources"
+)
+
+// DeployedSubject is the notify event emitted when an endpoint serves a new version
+const DeployedSubject = "mlmodel.deployed"
+
+// StatusBlocked marks a deployment a guardrail or policy gate refused
+const StatusBlocked = "blocked"
+
+// Deploy edge metadata keys
+const (
+	deploymentIDKey   = "deployment_id"
+	versionKey        = "version"         // the version of the latest deployment
+	servingVersionKey = "serving_version" // the version running since the last successful one
+	statusKey         = "status"
+	messageKey        = "message"
+	updatedAtKey      = "updated_at"
+	releaseIDKey      = "release_id" // the model version the gates decided on
+)
+
+// deploySteps are the steps of an endpoint deployment, reported as deployment progress
+var deploySteps = []string{"validate", "evaluate-policies", "execute"}
+
+// Deployment is the state of an endpoint in an environment
+type Deployment struct {
+	ID             string                     `json:"deployment_id"`
+	Endpoint       string                     `json:"endpoint"`
+	Model          string                     `json:"model,omitempty"`
+	Application    string                     `json:"application,omitempty"`
+	Environment    string                     `json:"environment"`
+	Version        string                     `json:"version"`                   // the version of the latest deployment
+	ServingVersion string                     `json:"serving_version,omitempty"` // the version running
+	Status         string                     `json:"status"`
+	Message        string                     `json:"message,omitempty"`
+	Warnings       []string                   `json:"warnings,omitempty"`
+	UpdatedAt      string                     `json:"updated_at,omitempty"`
+	History        []deployments.StatusChange `json:"history,omitempty"`
+}
+
+// DeployEndpoint rolls a version of the endpoint's model out to an environment. It goes through
+// the guardrails, the transition policies on the deploy edge, the deployment gates the
+// application's deployments pass (Policy Agent, vulnerability, promotion and autoscaling gates,
+// resource lifecycles, change calendar) and the lifecycle of the resources the endpoint uses.
+// Refused deployments are recorded as blocked and returned with an error.
+func (s *Service) DeployEndpoint(ctx context.Context, endpointName, version, environment string) (*Deployment, error) {
+	endpoint, err := s.GetEndpoint(endpointName)
+	if err != nil {
+		return nil, err
+	}
+	model, err := s.GetModel(endpoint.Model)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.GetVersion(model.Name, version); err != nil {
+		return nil, err
+	}
+	if node, err := s.graph.GetNode(environment); err != nil || node == nil || node.Kind != graph.KindEnvironment {
+		return nil, fmt.Errorf("environment %s not found", environment)
+	}
+
+	decision := guardrails.Default().Check(ctx, guardrails.Action{
+		Operation:   guardrails.OperationDeploy,
+		Kind:        graph.KindModelEndpoint,
+		Targets:     guardrails.Footprint(s.graph, endpoint.Name),
+		Environment: environment,
+		Application: model.Application,
+		Source:      "mlmodel-agent",
+	})
+	if !decision.Allowed() {
+		return nil, fmt.Errorf("%s", decision.Message())
+	}
+
+	deploymentID := fmt.Sprintf("deployment-%s-%s-%d", endpoint.Name, environment, s.now().UnixNano())
+	correlationID := logging.CorrelationIDFromContext(ctx)
+	if correlationID == "" {
+		correlationID = deploymentID
+	}
+	release, err := s.locks.Acquire(endpoint.Name, environment, correlationID)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	s.logger.Info("🚀 Deploying %s version %s to %s", endpoint.Name, version, environment)
+	deployment := &Deployment{
+		ID:          deploymentID,
+		Endpoint:    endpoint.Name,
+		Model:       model.Name,
+		Application: model.Application,
+		Environment: environment,
+		Version:     version,
+	}
+	progress := deployments.NewProgressTracker(ctx, s.eventBus, model.Application, environment, deploySteps)
+	progress.SetDeploymentID(deploymentID)
+	fail := func(step, status string, cause error) (*Deployment, error) {
+		progress.Fail(step, cause)
+		deployment.Status, deployment.Message = status, cause.Error()
+		if err := s.recordStatus(ctx, deployment); err != nil {
+			s.logger.Warn("⚠️ Could not record %s deployment of %s: %v", status, endpoint.Name, err)
+		}
+		return deployment, cause
+	}
+
+	// The deploy edge is created like any other, so the contract and the transition policies
+	// attached to it apply; redeployments check the policies again
+	progress.Start("validate")
+	if err := s.openDeployEdge(endpoint.Name, environment); err != nil {
+		progress.Fail("validate", err)
+		return nil, err
+	}
+	deployment.Status, deployment.Message = string(deployments.StatusPending), fmt.Sprintf("Deploying version %s", version)
+	if err := s.recordStatus(ctx, deployment); err != nil {
+		progress.Fail("validate", err)
+		return nil, err
+	}
+	warnings, err := s.checkEndpointResources(endpoint)
+	if err != nil {
+		return fail("validate", StatusBlocked, err)
+	}
+	deployment.Warnings = warnings
+	progress.Complete("validate")
+
+	progress.Start("evaluate-policies")
+	result, err := deployments.EvaluateGates(ctx, s.graph, model.Application, environment, versionID(model.Name, version))
+	if err != nil {
+		return fail("evaluate-policies", StatusBlocked, fmt.Errorf("deployment blocked: %w", err))
+	}
+	if result != "allowed" {
+		return fail("evaluate-policies", StatusBlocked, fmt.Errorf("deployment blocked by policy: %s", result))
+	}
+	progress.Complete("evaluate-policies")
+
+	progress.Start("execute")
+	deployment.Status, deployment.Message = string(deployments.StatusSucceeded), fmt.Sprintf("Serving version %s", version)
+	deployment.ServingVersion = version
+	if err := s.recordStatus(ctx, deployment); err != nil {
+		return fail("execute", string(deployments.StatusFailed), err)
+	}
+	progress.Complete("execute")
+
+	if s.eventBus != nil {
+		if err := s.eventBus.Emit(events.EventTypeNotify, "mlmodel-agent", DeployedSubject, map[string]interface{}{
+			"deployment_id": deploymentID,
+			"endpoint":      endpoint.Name,
+			"model":         model.Name,
+			"version":       version,
+			"application":   model.Application,
+			"environment":   environment,
+		}); err != nil {
+			s.logger.Warn("⚠️ Failed to emit %s for %s: %v", DeployedSubject, endpoint.Name, err)
+		}
+	}
+	s.logger.Info("✅ %s serves version %s in %s", endpoint.Name, version, environment)
+	return deployment, nil
+}
+
+// Deployments returns where an endpoint is deployed, sorted by environment
+func (s *Service) Deployments(endpointName string) ([]*Deployment, error) {
+	if _, err := s.GetEndpoint(endpointName); err != nil {
+		return nil, err
+	}
+	g, err := s.graph.Graph()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph: %w", err)
+	}
+	return endpointDeployments(g, endpointName), nil
+}
+
+// openDeployEdge adds the endpoint's deploy edge to the environment, or checks the transition
+// policies again when it already exists
+func (s *Service) openDeployEdge(endpoint, environment string) error {
+	exists, err := s.graph.HasEdge(endpoint, environment, graph.EdgeTypeDeploy)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return s.graph.AddEdge(endpoint, environment, graph.EdgeTypeDeploy)
+	}
+	g, err := s.graph.Graph()
+	if err != nil {
+		return err
+	}
+	return g.IsTransitionAllowed(endpoint, environment, graph.EdgeTypeDeploy)
+}
+
+// recordStatus writes the deployment's status to the deploy edge and, once a version serves,
+// points the endpoint's serves edges at the versions running in any environment
+func (s *Service) recordStatus(ctx context.Context, deployment *Deployment) error {
+	return s.graph.Update(func(g *graph.Graph) error {
+		edges := g.Edges[deployment.Endpoint]
+		for i := range edges {
+			if edges[i].To != deployment.Environment || edges[i].Type != graph.EdgeTypeDeploy {
+				continue
+			}
+			if edges[i].Metadata == nil {
+				edges[i].Metadata = map[string]interface{}{}
+			}
+			metadata := edges[i].Metadata
+			now := s.now().UTC()
+			metadata[deploymentIDKey] = deployment.ID
+			metadata[versionKey] = deployment.Version
+			metadata[releaseIDKey] = versionID(deployment.Model, deployment.Version)
+			metadata[statusKey] = deployment.Status
+			metadata[messageKey] = deployment.Message
+			metadata[updatedAtKey] = now.Format(time.RFC3339)
+			if deployment.ServingVersion != "" {
+				metadata[servingVersionKey] = deployment.ServingVersion
+			}
+			deployments.AppendStatusChange(metadata, deployments.StatusChange{
+				Status:    deployment.Status,
+				Message:   deployment.Message,
+				Actor:     deploymentActor(ctx),
+				Timestamp: now,
+			})
+			deployment.UpdatedAt = now.Format(time.RFC3339)
+			if deployment.Status == string(deployments.StatusSucceeded) {
+				return syncServes(g, deployment.Endpoint)
+			}
+			return nil
+		}
+		return fmt.Errorf("deploy edge %s -> %s not found", deployment.Endpoint, deployment.Environment)
+	})
+}
+
+// syncServes keeps one serves edge per version the endpoint runs in some environment
+func syncServes(g *graph.Graph, endpoint string) error {
+	model := ""
+	if node, ok := g.Nodes[endpoint]; ok {
+		model, _ = node.Spec["model"].(string)
+	}
+	serving := map[string]bool{}
+	for _, deployment := range endpointDeployments(g, endpoint) {
+		if deployment.ServingVersion != "" {
+			serving[versionID(model, deployment.ServingVersion)] = true
+		}
+	}
+
+	kept := g.Edges[endpoint][:0]
+	for _, edge := range g.Edges[endpoint] {
+		if edge.Type == graph.EdgeTypeServes && !serving[edge.To] {
+			continue
+		}
+		if edge.Type == graph.EdgeTypeServes {
+			delete(serving, edge.To)
+		}
+		kept = append(kept, edge)
+	}
+	g.Edges[endpoint] = kept
+
+	missing := make([]string, 0, len(serving))
+	for id := range serving {
+		missing = append(missing, id)
+	}
+	sort.Strings(missing)
+	for _, id := range missing {
+		if err := g.AddEdge(endpoint, id, graph.EdgeTypeServes); err != nil {
+			return fmt.Errorf("failed to link %s to %s: %w", endpoint, id, err)
+		}
+	}
+	return nil
+}
+
+// checkEndpointResources blocks deployments while a resource the endpoint uses is in
+// maintenance or decommissioned; deprecated resources are returned as warnings
+func (s *Service) checkEndpointResources(endpoint *Endpoint) ([]string, error) {
+	edges, err := s.graph.Edges()
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := s.graph.Nodes()
+	if err != nil {
+		return nil, err
+	}
+	var warnings, blocked []string
+	for _, edge := range edges[endpoint.Name] {
+		node, ok := nodes[edge.To]
+		if !ok || node.Kind != graph.KindResource || (edge.Type != graph.EdgeTypeUses && edge.Type != graph.EdgeTypeAccesses) {
+			continue
+		}
+		switch state := resources.StateOf(node); state {
+		case resources.StateMaintenance, resources.StateDecommissioned:
+			blocked = append(blocked, fmt.Sprintf("%s is in %s", node.ID, state))
+		case resources.StateDeprecated:
+			warnings = append(warnings, fmt.Sprintf("%s is deprecated", node.ID))
+		}
+	}
+	sort.Strings(blocked)
+	sort.Strings(warnings)
+	if len(blocked) > 0 {
+		return warnings, fmt.Errorf("resources unavailable: %s", strings.Join(blocked, ", "))
+	}
+	return warnings, nil
+}
+
+// endpointDeployments reads the deploy edges of an endpoint, sorted by environment
+func endpointDeployments(g *graph.Graph, endpoint string) []*Deployment {
+	result := []*Deployment{}
+	for _, edge := range g.Edges[endpoint] {
+		if edge.Type != graph.EdgeTypeDeploy {
+			continue
+		}
+		deployment := &Deployment{Endpoint: endpoint, Environment: edge.To}
+		if edge.Metadata != nil {
+			deployment.ID, _ = edge.Metadata[deploymentIDKey].(string)
+			deployment.Version, _ = edge.Metadata[versionKey].(string)
+			deployment.ServingVersion, _ = edge.Metadata[servingVersionKey].(string)
+			deployment.Status, _ = edge.Metadata[statusKey].(string)
+			deployment.Message, _ = edge.Metadata[messageKey].(string)
+			deployment.UpdatedAt, _ = edge.Metadata[updatedAtKey].(string)
+			deployment.History = deployments.StatusHistory(edge.Metadata)
+		}
+		result = append(result, deployment)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Environment < result[j].Environment })
+	return result
+}
+
+// deploymentActor is who a status change is attributed to: the requesting user, or the agent
+func deploymentActor(ctx context.Context) string {
+	if userID := logging.UserIDFromContext(ctx); userID != "" {
+		return userID
+	}
+	return "mlmodel-agent"
+}
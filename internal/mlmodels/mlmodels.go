@@ -0,0 +1,372 @@
+// Package mlmodels models ML workloads in the platform graph: models owned by applications,
+// their trained versions, and the serving endpoints that put a version behind an API in an
+// environment. Endpoints are deployed through the same guardrails, policy gates, resource
+// checks and change calendar as application deployments.
+package mlmodels
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/deployments"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+var (
+	// ErrModelNotFound is returned for a model that is not registered
+	ErrModelNotFound = errors.New("model not found")
+	// ErrVersionNotFound is returned for a version the model does not have
+	ErrVersionNotFound = errors.New("model version not found")
+	// ErrEndpointNotFound is returned for a serving endpoint that does not exist
+	ErrEndpointNotFound = errors.New("model endpoint not found")
+)
+
+// Accelerators an endpoint can be served on
+const (
+	AcceleratorCPU = "cpu"
+	AcceleratorGPU = "gpu"
+)
+
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// Model is a registered ML model owned by an application
+type Model struct {
+	Name        string    `json:"name"`
+	Application string    `json:"application"`
+	Framework   string    `json:"framework,omitempty"` // e.g. pytorch, tensorflow, sklearn
+	Task        string    `json:"task,omitempty"`      // e.g. classification, forecasting
+	Description string    `json:"description,omitempty"`
+	Resources   []string  `json:"resources,omitempty"` // resources the model is trained from, e.g. a feature store
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Version is a trained, immutable version of a model
+type Version struct {
+	Model       string             `json:"model"`
+	Version     string             `json:"version"`
+	ArtifactURI string             `json:"artifact_uri"`      // where the weights are stored
+	Metrics     map[string]float64 `json:"metrics,omitempty"` // evaluation results, e.g. accuracy
+	CreatedAt   time.Time          `json:"created_at"`
+}
+
+// Endpoint serves a version of a model; which version runs where is recorded on its deploy edges
+type Endpoint struct {
+	Name        string    `json:"name"`
+	Model       string    `json:"model"`
+	Replicas    int       `json:"replicas,omitempty"`
+	Accelerator string    `json:"accelerator,omitempty"` // cpu (default) or gpu
+	Resources   []string  `json:"resources,omitempty"`   // resources the endpoint uses at inference time
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ModelDetails is a model with its versions, endpoints and what each endpoint serves
+type ModelDetails struct {
+	Model
+	Versions  []*Version        `json:"versions"`
+	Endpoints []*EndpointStatus `json:"endpoints"`
+}
+
+// EndpointStatus is an endpoint with the version deployed to each environment
+type EndpointStatus struct {
+	Endpoint
+	Deployments []*Deployment `json:"deployments"`
+}
+
+// Service registers models, versions and endpoints and deploys endpoints
+type Service struct {
+	graph    *graph.GlobalGraph
+	eventBus *events.EventBus
+	locks    *deployments.EnvironmentLocks
+	logger   *logging.Logger
+	now      func() time.Time
+}
+
+// NewService creates the ML model service; eventBus may be nil. locks are the environment
+// locks shared with everything else that deploys; nil gives the service its own.
+func NewService(globalGraph *graph.GlobalGraph, eventBus *events.EventBus, locks *deployments.EnvironmentLocks) *Service {
+	if locks == nil {
+		locks = deployments.NewEnvironmentLocks(deployments.DeploymentLockTTL)
+	}
+	return &Service{
+		graph:    globalGraph,
+		eventBus: eventBus,
+		locks:    locks,
+		logger:   logging.GetLogger().ForComponent("mlmodels"),
+		now:      time.Now,
+	}
+}
+
+// RegisterModel adds a model to its application and links the resources it uses
+func (s *Service) RegisterModel(model Model) (*Model, error) {
+	if !namePattern.MatchString(model.Name) {
+		return nil, fmt.Errorf("model name must be lowercase letters, digits or dashes, got %q", model.Name)
+	}
+	if model.Application == "" {
+		return nil, fmt.Errorf("application is required")
+	}
+	if node, err := s.graph.GetNode(model.Application); err != nil || node == nil || node.Kind != graph.KindApplication {
+		return nil, fmt.Errorf("application %s not found", model.Application)
+	}
+	if existing, _ := s.graph.GetNode(model.Name); existing != nil {
+		return nil, fmt.Errorf("%s already exists", model.Name)
+	}
+	if err := s.checkResources(model.Resources); err != nil {
+		return nil, err
+	}
+
+	model.CreatedAt = s.now().UTC()
+	node, err := toNode(model.Name, graph.KindMLModel, model.Description, model)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.graph.AddNode(node); err != nil {
+		return nil, fmt.Errorf("failed to store model %s: %w", model.Name, err)
+	}
+	if err := s.graph.AddEdge(model.Application, model.Name, graph.EdgeTypeOwns); err != nil {
+		s.graph.DeleteNode(model.Name)
+		return nil, fmt.Errorf("failed to link %s to %s: %w", model.Name, model.Application, err)
+	}
+	if err := s.linkResources(model.Name, model.Resources); err != nil {
+		return nil, err
+	}
+	s.logger.Info("🧠 Registered model %s for %s", model.Name, model.Application)
+	return &model, nil
+}
+
+// RegisterVersion adds a trained version to a model
+func (s *Service) RegisterVersion(version Version) (*Version, error) {
+	if _, err := s.GetModel(version.Model); err != nil {
+		return nil, err
+	}
+	if version.Version == "" {
+		return nil, fmt.Errorf("version is required")
+	}
+	if version.ArtifactURI == "" {
+		return nil, fmt.Errorf("artifact_uri is required")
+	}
+	id := versionID(version.Model, version.Version)
+	if existing, _ := s.graph.GetNode(id); existing != nil {
+		return nil, fmt.Errorf("version %s of %s already exists; versions are immutable", version.Version, version.Model)
+	}
+
+	version.CreatedAt = s.now().UTC()
+	node, err := toNode(id, graph.KindModelVersion, "", version)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.graph.AddNode(node); err != nil {
+		return nil, fmt.Errorf("failed to store version %s: %w", id, err)
+	}
+	if err := s.graph.AddEdge(version.Model, id, graph.EdgeTypeHasVersion); err != nil {
+		s.graph.DeleteNode(id)
+		return nil, fmt.Errorf("failed to link %s to %s: %w", id, version.Model, err)
+	}
+	s.logger.Info("🧠 Registered version %s of model %s", version.Version, version.Model)
+	return &version, nil
+}
+
+// CreateEndpoint adds a serving endpoint for a model; nothing is served until it is deployed
+func (s *Service) CreateEndpoint(endpoint Endpoint) (*Endpoint, error) {
+	if !namePattern.MatchString(endpoint.Name) {
+		return nil, fmt.Errorf("endpoint name must be lowercase letters, digits or dashes, got %q", endpoint.Name)
+	}
+	if _, err := s.GetModel(endpoint.Model); err != nil {
+		return nil, err
+	}
+	if existing, _ := s.graph.GetNode(endpoint.Name); existing != nil {
+		return nil, fmt.Errorf("%s already exists", endpoint.Name)
+	}
+	switch endpoint.Accelerator {
+	case "":
+		endpoint.Accelerator = AcceleratorCPU
+	case AcceleratorCPU, AcceleratorGPU:
+	default:
+		return nil, fmt.Errorf("unknown accelerator %q (expected cpu or gpu)", endpoint.Accelerator)
+	}
+	if endpoint.Replicas < 0 {
+		return nil, fmt.Errorf("replicas must not be negative")
+	}
+	if endpoint.Replicas == 0 {
+		endpoint.Replicas = 1
+	}
+	if err := s.checkResources(endpoint.Resources); err != nil {
+		return nil, err
+	}
+
+	endpoint.CreatedAt = s.now().UTC()
+	node, err := toNode(endpoint.Name, graph.KindModelEndpoint, "", endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.graph.AddNode(node); err != nil {
+		return nil, fmt.Errorf("failed to store endpoint %s: %w", endpoint.Name, err)
+	}
+	if err := s.graph.AddEdge(endpoint.Model, endpoint.Name, graph.EdgeTypeOwns); err != nil {
+		s.graph.DeleteNode(endpoint.Name)
+		return nil, fmt.Errorf("failed to link %s to %s: %w", endpoint.Name, endpoint.Model, err)
+	}
+	if err := s.linkResources(endpoint.Name, endpoint.Resources); err != nil {
+		return nil, err
+	}
+	s.logger.Info("🧠 Created endpoint %s for model %s", endpoint.Name, endpoint.Model)
+	return &endpoint, nil
+}
+
+// GetModel returns a registered model
+func (s *Service) GetModel(name string) (*Model, error) {
+	node, err := s.graph.GetNode(name)
+	if err != nil || node == nil || node.Kind != graph.KindMLModel {
+		return nil, fmt.Errorf("%w: %s", ErrModelNotFound, name)
+	}
+	var model Model
+	if err := fromNode(node, &model); err != nil {
+		return nil, err
+	}
+	return &model, nil
+}
+
+// GetVersion returns a version of a model
+func (s *Service) GetVersion(model, version string) (*Version, error) {
+	node, err := s.graph.GetNode(versionID(model, version))
+	if err != nil || node == nil || node.Kind != graph.KindModelVersion {
+		return nil, fmt.Errorf("%w: %s of %s", ErrVersionNotFound, version, model)
+	}
+	var v Version
+	if err := fromNode(node, &v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// GetEndpoint returns a serving endpoint
+func (s *Service) GetEndpoint(name string) (*Endpoint, error) {
+	node, err := s.graph.GetNode(name)
+	if err != nil || node == nil || node.Kind != graph.KindModelEndpoint {
+		return nil, fmt.Errorf("%w: %s", ErrEndpointNotFound, name)
+	}
+	var endpoint Endpoint
+	if err := fromNode(node, &endpoint); err != nil {
+		return nil, err
+	}
+	return &endpoint, nil
+}
+
+// ListModels returns the models of an application, or every model when application is empty,
+// with their versions and endpoints
+func (s *Service) ListModels(application string) ([]*ModelDetails, error) {
+	g, err := s.graph.Graph()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph: %w", err)
+	}
+	models := []*ModelDetails{}
+	for _, node := range g.Nodes {
+		if node.Kind != graph.KindMLModel {
+			continue
+		}
+		details, err := modelDetails(g, node)
+		if err != nil {
+			s.logger.Warn("⚠️ Skipping unreadable model %s: %v", node.ID, err)
+			continue
+		}
+		if application == "" || details.Application == application {
+			models = append(models, details)
+		}
+	}
+	sort.Slice(models, func(i, j int) bool { return models[i].Name < models[j].Name })
+	return models, nil
+}
+
+// DescribeModel returns a model with its versions and endpoints
+func (s *Service) DescribeModel(name string) (*ModelDetails, error) {
+	g, err := s.graph.Graph()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph: %w", err)
+	}
+	node, ok := g.Nodes[name]
+	if !ok || node.Kind != graph.KindMLModel {
+		return nil, fmt.Errorf("%w: %s", ErrModelNotFound, name)
+	}
+	return modelDetails(g, node)
+}
+
+func modelDetails(g *graph.Graph, node *graph.Node) (*ModelDetails, error) {
+	details := &ModelDetails{Versions: []*Version{}, Endpoints: []*EndpointStatus{}}
+	if err := fromNode(node, &details.Model); err != nil {
+		return nil, err
+	}
+	for _, edge := range g.Edges[node.ID] {
+		target, ok := g.Nodes[edge.To]
+		if !ok {
+			continue
+		}
+		switch {
+		case edge.Type == graph.EdgeTypeHasVersion && target.Kind == graph.KindModelVersion:
+			var version Version
+			if err := fromNode(target, &version); err == nil {
+				details.Versions = append(details.Versions, &version)
+			}
+		case edge.Type == graph.EdgeTypeOwns && target.Kind == graph.KindModelEndpoint:
+			status := &EndpointStatus{}
+			if err := fromNode(target, &status.Endpoint); err == nil {
+				status.Deployments = endpointDeployments(g, target.ID)
+				details.Endpoints = append(details.Endpoints, status)
+			}
+		}
+	}
+	sort.Slice(details.Versions, func(i, j int) bool { return details.Versions[i].CreatedAt.Before(details.Versions[j].CreatedAt) })
+	sort.Slice(details.Endpoints, func(i, j int) bool { return details.Endpoints[i].Name < details.Endpoints[j].Name })
+	return details, nil
+}
+
+// checkResources fails when a named resource does not exist
+func (s *Service) checkResources(names []string) error {
+	for _, name := range names {
+		if node, err := s.graph.GetNode(name); err != nil || node == nil || node.Kind != graph.KindResource {
+			return fmt.Errorf("resource %s not found", name)
+		}
+	}
+	return nil
+}
+
+func (s *Service) linkResources(from string, names []string) error {
+	for _, name := range names {
+		if err := s.graph.AddEdge(from, name, graph.EdgeTypeUses); err != nil {
+			return fmt.Errorf("failed to link %s to resource %s: %w", from, name, err)
+		}
+	}
+	return nil
+}
+
+func versionID(model, version string) string {
+	return model + ":" + version
+}
+
+func toNode(id, kind, description string, value interface{}) (*graph.Node, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", id, err)
+	}
+	var spec map[string]interface{}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", id, err)
+	}
+	metadata := map[string]interface{}{"name": id}
+	if description != "" {
+		metadata["description"] = description
+	}
+	return &graph.Node{ID: id, Kind: kind, Metadata: metadata, Spec: spec}, nil
+}
+
+func fromNode(node *graph.Node, target interface{}) error {
+	data, err := json.Marshal(node.Spec)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
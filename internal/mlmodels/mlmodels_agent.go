@@ -0,0 +1,303 @@
+package mlmodels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+	"github.com/krzachariassen/ZTDP/internal/ai"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/features"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
+)
+
+// MLModelParams are the parameters the AI extracts from an ML model request
+type MLModelParams struct {
+	Action      string  `json:"action"`
+	Model       string  `json:"model"`
+	Application string  `json:"application"`
+	Version     string  `json:"version"`
+	ArtifactURI string  `json:"artifact_uri"`
+	Framework   string  `json:"framework"`
+	Task        string  `json:"task"`
+	Endpoint    string  `json:"endpoint"`
+	Environment string  `json:"environment"`
+	Accelerator string  `json:"accelerator"`
+	Replicas    int     `json:"replicas"`
+	Resources   string  `json:"resources"` // comma separated
+	Confidence  float64 `json:"confidence"`
+}
+
+// mlModelExtraction is the ML model domain's schema for the shared extractor
+var mlModelExtraction = ai.ExtractionSchema{
+	Domain: "ml_model",
+	Fields: map[string]ai.ExtractionField{
+		"action":       {Type: ai.ExtractionString, Required: true, Enum: []string{"register_model", "register_version", "create_endpoint", "deploy", "list", "show"}},
+		"model":        {Type: ai.ExtractionString, Description: "model name"},
+		"application":  {Type: ai.ExtractionString, Description: "application owning the model"},
+		"version":      {Type: ai.ExtractionString, Description: "model version, e.g. v3 or 1.2.0"},
+		"artifact_uri": {Type: ai.ExtractionString, Description: "where the trained weights are stored, e.g. s3://models/churn/v3"},
+		"framework":    {Type: ai.ExtractionString, Description: "ML framework such as pytorch, tensorflow or sklearn"},
+		"task":         {Type: ai.ExtractionString, Description: "what the model does, e.g. classification"},
+		"endpoint":     {Type: ai.ExtractionString, Description: "serving endpoint name"},
+		"environment":  {Type: ai.ExtractionString, Description: "target environment for deployments"},
+		"accelerator":  {Type: ai.ExtractionString, Description: "cpu or gpu, empty if not specified"},
+		"replicas":     {Type: ai.ExtractionNumber, Description: "number of serving replicas, 0 if not specified"},
+		"resources":    {Type: ai.ExtractionString, Description: "comma separated resources the model or endpoint uses"},
+	},
+	Instructions: `Examples:
+- "register model churn-predictor for the crm application, it's a pytorch classifier" -> {"action": "register_model", "model": "churn-predictor", "application": "crm", "framework": "pytorch", "task": "classification", "replicas": 0, "confidence": 0.95}
+- "add version v3 of churn-predictor from s3://models/churn/v3" -> {"action": "register_version", "model": "churn-predictor", "version": "v3", "artifact_uri": "s3://models/churn/v3", "replicas": 0, "confidence": 0.95}
+- "create a gpu endpoint churn-api for churn-predictor with 2 replicas" -> {"action": "create_endpoint", "model": "churn-predictor", "endpoint": "churn-api", "accelerator": "gpu", "replicas": 2, "confidence": 0.9}
+- "deploy v3 of churn-api to prod" -> {"action": "deploy", "endpoint": "churn-api", "version": "v3", "environment": "prod", "replicas": 0, "confidence": 0.9}
+- "list ml models for crm" -> {"action": "list", "application": "crm", "replicas": 0, "confidence": 0.9}
+- "show the churn-predictor model" -> {"action": "show", "model": "churn-predictor", "replicas": 0, "confidence": 0.9}`,
+}
+
+// MLModelAgent registers models, versions and serving endpoints and deploys endpoints
+type MLModelAgent struct {
+	service   *Service
+	extractor *ai.Extractor
+	logger    *logging.Logger
+}
+
+// NewMLModelAgent creates the ML model agent
+func NewMLModelAgent(
+	globalGraph *graph.GlobalGraph,
+	service *Service,
+	aiProvider ai.AIProvider,
+	eventBus *events.EventBus,
+	registry agentRegistry.AgentRegistry,
+) (agentRegistry.AgentInterface, error) {
+	if service == nil {
+		return nil, fmt.Errorf("ML model service is required")
+	}
+	if aiProvider == nil {
+		return nil, fmt.Errorf("aiProvider is required for AI-native agent")
+	}
+	if eventBus == nil {
+		return nil, fmt.Errorf("eventBus is required")
+	}
+	if registry == nil {
+		return nil, fmt.Errorf("registry is required")
+	}
+
+	extractor := ai.NewExtractor(aiProvider)
+	if err := extractor.Register(mlModelExtraction); err != nil {
+		return nil, err
+	}
+	wrapper := &MLModelAgent{
+		service:   service,
+		extractor: extractor,
+		logger:    logging.GetLogger().ForComponent("mlmodel-agent"),
+	}
+
+	agent, err := agentframework.NewAgent("mlmodel-agent").
+		WithType("mlmodel").
+		WithCapabilities(getMLModelCapabilities()).
+		WithEventHandler(wrapper.handleEvent).
+		Build(agentframework.AgentDependencies{
+			Registry: registry,
+			EventBus: eventBus,
+			Flags:    features.NewService(globalGraph),
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build ML model agent: %w", err)
+	}
+
+	wrapper.logger.Info("✅ MLModelAgent created successfully")
+	return agent, nil
+}
+
+// getMLModelCapabilities returns the capabilities for the ML model agent
+func getMLModelCapabilities() []agentRegistry.AgentCapability {
+	return []agentRegistry.AgentCapability{
+		{
+			Name:        "ml_model_management",
+			Description: "Registers ML models, model versions and serving endpoints, links them to applications and resources, and deploys endpoints through the deployment policy gates",
+			Intents: []string{
+				"register model", "register model version", "create model endpoint", "deploy model",
+				"list models", "show model", "ml model", "machine learning model", "model serving", "inference endpoint",
+			},
+			InputTypes:  []string{"user_message"},
+			OutputTypes: []string{"ml_model", "model_version", "model_endpoint", "model_deployment", "clarification"},
+			RoutingKeys: []string{"mlmodel.request", "mlmodel.register", "mlmodel.deploy"},
+			Version:     "1.0.0",
+		},
+	}
+}
+
+// handleEvent extracts the request and runs it against the ML model service
+func (a *MLModelAgent) handleEvent(ctx context.Context, event *events.Event) (*events.Event, error) {
+	userMessage, ok := event.Payload["user_message"].(string)
+	if !ok || userMessage == "" {
+		return a.createErrorResponse(event, "user_message field is required in event payload"), nil
+	}
+
+	var params MLModelParams
+	err := a.extractor.Extract(ctx, mlModelExtraction.Domain, userMessage, &params)
+	var lowConfidence *ai.LowConfidenceError
+	if errors.As(err, &lowConfidence) {
+		return agentframework.ClarificationResponse(ctx, event, lowConfidence.Clarification, lowConfidence.Confidence), nil
+	}
+	if err != nil {
+		return a.createErrorResponse(event, fmt.Sprintf("Failed to extract parameters: %v", err)), nil
+	}
+	a.logger.Info("🤖 AI extracted - action: %s, model: %s, endpoint: %s, confidence: %.2f",
+		params.Action, params.Model, params.Endpoint, params.Confidence)
+
+	message, result, err := a.execute(ctx, &params)
+	if err != nil {
+		return a.createErrorResponse(event, err.Error()), nil
+	}
+	return a.createSuccessResponse(event, message, result), nil
+}
+
+// execute runs the extracted action and describes the outcome
+func (a *MLModelAgent) execute(ctx context.Context, params *MLModelParams) (string, interface{}, error) {
+	switch params.Action {
+	case "register_model":
+		model, err := a.service.RegisterModel(Model{
+			Name:        params.Model,
+			Application: params.Application,
+			Framework:   params.Framework,
+			Task:        params.Task,
+			Resources:   splitList(params.Resources),
+		})
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to register model: %w", err)
+		}
+		return fmt.Sprintf("🧠 Registered model %s for %s", model.Name, model.Application), model, nil
+	case "register_version":
+		version, err := a.service.RegisterVersion(Version{Model: params.Model, Version: params.Version, ArtifactURI: params.ArtifactURI})
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to register version: %w", err)
+		}
+		return fmt.Sprintf("🧠 Registered version %s of %s from %s", version.Version, version.Model, version.ArtifactURI), version, nil
+	case "create_endpoint":
+		endpoint, err := a.service.CreateEndpoint(Endpoint{
+			Name:        params.Endpoint,
+			Model:       params.Model,
+			Replicas:    params.Replicas,
+			Accelerator: params.Accelerator,
+			Resources:   splitList(params.Resources),
+		})
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to create endpoint: %w", err)
+		}
+		return fmt.Sprintf("🧠 Created %s endpoint %s for %s with %d replicas; deploy a version to serve it", endpoint.Accelerator, endpoint.Name, endpoint.Model, endpoint.Replicas), endpoint, nil
+	case "deploy":
+		if params.Endpoint == "" || params.Version == "" || params.Environment == "" {
+			return "", nil, fmt.Errorf("deploying a model needs the endpoint, the version and the environment")
+		}
+		deployment, err := a.service.DeployEndpoint(ctx, params.Endpoint, params.Version, params.Environment)
+		if err != nil {
+			return "", nil, fmt.Errorf("model deployment failed: %w", err)
+		}
+		message := fmt.Sprintf("🚀 %s now serves version %s of %s in %s", deployment.Endpoint, deployment.Version, deployment.Model, deployment.Environment)
+		for _, warning := range deployment.Warnings {
+			message += "\n⚠️ " + warning
+		}
+		return message, deployment, nil
+	case "list":
+		models, err := a.service.ListModels(params.Application)
+		if err != nil {
+			return "", nil, err
+		}
+		return describeModels(models), models, nil
+	case "show":
+		model, err := a.service.DescribeModel(params.Model)
+		if err != nil {
+			return "", nil, err
+		}
+		return describeModels([]*ModelDetails{model}), model, nil
+	default:
+		return "", nil, fmt.Errorf("unknown action: %s", params.Action)
+	}
+}
+
+// describeModels summarises models with their versions and where their endpoints serve
+func describeModels(models []*ModelDetails) string {
+	if len(models) == 0 {
+		return "No ML models are registered."
+	}
+	var b strings.Builder
+	for i, model := range models {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "🧠 %s (application %s", model.Name, model.Application)
+		if model.Framework != "" {
+			b.WriteString(", " + model.Framework)
+		}
+		b.WriteString(")")
+		versions := make([]string, 0, len(model.Versions))
+		for _, version := range model.Versions {
+			versions = append(versions, version.Version)
+		}
+		if len(versions) > 0 {
+			fmt.Fprintf(&b, "\n  versions: %s", strings.Join(versions, ", "))
+		}
+		for _, endpoint := range model.Endpoints {
+			fmt.Fprintf(&b, "\n  endpoint %s", endpoint.Name)
+			var serving []string
+			for _, deployment := range endpoint.Deployments {
+				if deployment.ServingVersion != "" {
+					serving = append(serving, fmt.Sprintf("%s in %s", deployment.ServingVersion, deployment.Environment))
+				}
+			}
+			if len(serving) > 0 {
+				b.WriteString(": serves " + strings.Join(serving, ", "))
+			} else {
+				b.WriteString(": not deployed")
+			}
+		}
+	}
+	return b.String()
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func (a *MLModelAgent) createSuccessResponse(originalEvent *events.Event, message string, result interface{}) *events.Event {
+	return &events.Event{
+		ID:        fmt.Sprintf("mlmodel-response-%d", time.Now().UnixNano()),
+		Type:      events.EventTypeResponse,
+		Subject:   "mlmodel.response",
+		Source:    "mlmodel-agent",
+		Timestamp: time.Now().Unix(),
+		Payload: map[string]interface{}{
+			"status":         "success",
+			"message":        message,
+			"result":         result,
+			"correlation_id": originalEvent.Payload["correlation_id"],
+		},
+	}
+}
+
+func (a *MLModelAgent) createErrorResponse(originalEvent *events.Event, errorMessage string) *events.Event {
+	return &events.Event{
+		ID:        fmt.Sprintf("mlmodel-error-%d", time.Now().UnixNano()),
+		Type:      events.EventTypeResponse,
+		Subject:   "mlmodel.error",
+		Source:    "mlmodel-agent",
+		Timestamp: time.Now().Unix(),
+		Payload: map[string]interface{}{
+			"status":         "error",
+			"error":          errorMessage,
+			"correlation_id": originalEvent.Payload["correlation_id"],
+		},
+	}
+}
@@ -0,0 +1,192 @@
+package mlmodels
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+	"github.com/krzachariassen/ZTDP/internal/ai/aitest"
+	"github.com/krzachariassen/ZTDP/internal/deployments"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMLTestService(t *testing.T) (*Service, *graph.GlobalGraph) {
+	t.Helper()
+	g := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	add := func(id, kind string) {
+		require.NoError(t, g.AddNode(&graph.Node{ID: id, Kind: kind, Metadata: map[string]interface{}{"name": id}, Spec: map[string]interface{}{}}))
+	}
+	add("crm", graph.KindApplication)
+	add("crm-api", graph.KindService)
+	add("prod", graph.KindEnvironment)
+	add("staging", graph.KindEnvironment)
+	add("feature-store", graph.KindResource)
+	add("gpu-pool", graph.KindResource)
+	require.NoError(t, g.AddEdge("crm", "crm-api", graph.EdgeTypeOwns))
+
+	service := NewService(g, nil, nil)
+	clock := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time {
+		clock = clock.Add(time.Minute)
+		return clock
+	}
+	return service, g
+}
+
+// registerChurn registers the churn model with two versions and a GPU endpoint
+func registerChurn(t *testing.T, service *Service) {
+	t.Helper()
+	_, err := service.RegisterModel(Model{Name: "churn", Application: "crm", Framework: "pytorch", Resources: []string{"feature-store"}})
+	require.NoError(t, err)
+	for _, version := range []string{"v1", "v2"} {
+		_, err := service.RegisterVersion(Version{Model: "churn", Version: version, ArtifactURI: "s3://models/churn/" + version})
+		require.NoError(t, err)
+	}
+	_, err = service.CreateEndpoint(Endpoint{Name: "churn-api", Model: "churn", Accelerator: AcceleratorGPU, Resources: []string{"gpu-pool"}})
+	require.NoError(t, err)
+}
+
+func TestRegister_LinksModelsIntoTheGraph(t *testing.T) {
+	service, g := newMLTestService(t)
+	registerChurn(t, service)
+
+	for _, edge := range [][3]string{
+		{"crm", "churn", graph.EdgeTypeOwns},
+		{"churn", "churn:v1", graph.EdgeTypeHasVersion},
+		{"churn", "churn-api", graph.EdgeTypeOwns},
+		{"churn", "feature-store", graph.EdgeTypeUses},
+		{"churn-api", "gpu-pool", graph.EdgeTypeUses},
+	} {
+		has, err := g.HasEdge(edge[0], edge[1], edge[2])
+		require.NoError(t, err)
+		assert.True(t, has, "%s -%s-> %s", edge[0], edge[2], edge[1])
+	}
+	assert.NoError(t, g.AddEdge("crm-api", "churn-api", graph.EdgeTypeConsumes), "services can consume endpoints")
+
+	_, err := service.RegisterModel(Model{Name: "fraud", Application: "missing"})
+	assert.Error(t, err)
+	_, err = service.RegisterModel(Model{Name: "fraud", Application: "crm", Resources: []string{"missing"}})
+	assert.Error(t, err)
+	_, err = service.RegisterVersion(Version{Model: "churn", Version: "v1", ArtifactURI: "s3://other"})
+	assert.Error(t, err, "versions are immutable")
+	_, err = service.RegisterVersion(Version{Model: "fraud", Version: "v1", ArtifactURI: "s3://fraud"})
+	assert.ErrorIs(t, err, ErrModelNotFound)
+	_, err = service.CreateEndpoint(Endpoint{Name: "churn-tpu", Model: "churn", Accelerator: "tpu"})
+	assert.Error(t, err)
+
+	endpoint, err := service.GetEndpoint("churn-api")
+	require.NoError(t, err)
+	assert.Equal(t, 1, endpoint.Replicas)
+}
+
+func TestDeployEndpoint_ServesVersionsPerEnvironment(t *testing.T) {
+	service, g := newMLTestService(t)
+	registerChurn(t, service)
+	ctx := context.Background()
+
+	deployment, err := service.DeployEndpoint(ctx, "churn-api", "v1", "staging")
+	require.NoError(t, err)
+	assert.Equal(t, string(deployments.StatusSucceeded), deployment.Status)
+	assert.Equal(t, "crm", deployment.Application)
+	_, err = service.DeployEndpoint(ctx, "churn-api", "v1", "prod")
+	require.NoError(t, err)
+	_, err = service.DeployEndpoint(ctx, "churn-api", "v2", "staging")
+	require.NoError(t, err)
+
+	deployed, err := service.Deployments("churn-api")
+	require.NoError(t, err)
+	require.Len(t, deployed, 2)
+	assert.Equal(t, "prod", deployed[0].Environment)
+	assert.Equal(t, "v1", deployed[0].ServingVersion)
+	assert.Equal(t, "v2", deployed[1].ServingVersion)
+	statuses := []string{}
+	for _, change := range deployed[1].History {
+		statuses = append(statuses, change.Status)
+	}
+	assert.Equal(t, []string{"pending", "succeeded", "pending", "succeeded"}, statuses)
+
+	for version, serves := range map[string]bool{"churn:v1": true, "churn:v2": true} {
+		has, err := g.HasEdge("churn-api", version, graph.EdgeTypeServes)
+		require.NoError(t, err)
+		assert.Equal(t, serves, has, version)
+	}
+	_, err = service.DeployEndpoint(ctx, "churn-api", "v2", "prod")
+	require.NoError(t, err)
+	has, err := g.HasEdge("churn-api", "churn:v1", graph.EdgeTypeServes)
+	require.NoError(t, err)
+	assert.False(t, has, "v1 no longer runs anywhere")
+
+	details, err := service.DescribeModel("churn")
+	require.NoError(t, err)
+	assert.Len(t, details.Versions, 2)
+	require.Len(t, details.Endpoints, 1)
+	assert.Contains(t, describeModels([]*ModelDetails{details}), "serves v2 in prod, v2 in staging")
+
+	_, err = service.DeployEndpoint(ctx, "churn-api", "v9", "prod")
+	assert.ErrorIs(t, err, ErrVersionNotFound)
+}
+
+func TestDeployEndpoint_BlockedByResourceLifecycle(t *testing.T) {
+	service, g := newMLTestService(t)
+	registerChurn(t, service)
+	_, err := service.DeployEndpoint(context.Background(), "churn-api", "v1", "prod")
+	require.NoError(t, err)
+
+	pool, err := g.GetNode("gpu-pool")
+	require.NoError(t, err)
+	pool.Metadata["lifecycle_state"] = "maintenance"
+	require.NoError(t, g.UpdateNode(pool))
+
+	deployment, err := service.DeployEndpoint(context.Background(), "churn-api", "v2", "prod")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "gpu-pool is in maintenance")
+	assert.Equal(t, StatusBlocked, deployment.Status)
+
+	deployed, err := service.Deployments("churn-api")
+	require.NoError(t, err)
+	assert.Equal(t, StatusBlocked, deployed[0].Status)
+	assert.Equal(t, "v1", deployed[0].ServingVersion, "the blocked version never served")
+}
+
+func TestDeployEndpoint_BlockedByTransitionPolicy(t *testing.T) {
+	service, g := newMLTestService(t)
+	registerChurn(t, service)
+	require.NoError(t, g.AddNode(&graph.Node{ID: "model-review", Kind: graph.KindPolicy, Metadata: map[string]interface{}{"name": "Model review", "type": graph.PolicyTypeApproval}, Spec: map[string]interface{}{}}))
+	require.NoError(t, g.AttachPolicyToTransition("churn-api", "prod", graph.EdgeTypeDeploy, "model-review"))
+
+	_, err := service.DeployEndpoint(context.Background(), "churn-api", "v1", "prod")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Model review")
+	has, err := g.HasEdge("churn-api", "prod", graph.EdgeTypeDeploy)
+	require.NoError(t, err)
+	assert.False(t, has)
+}
+
+func TestMLModelAgentDeploysEndpoints(t *testing.T) {
+	service, g := newMLTestService(t)
+	registerChurn(t, service)
+	provider := aitest.Respond(`{"action": "deploy", "endpoint": "churn-api", "version": "v2", "environment": "prod", "replicas": 0, "confidence": 0.95}`)
+	agent, err := NewMLModelAgent(g, service, provider, events.NewEventBus(nil, false), agentRegistry.NewInMemoryAgentRegistry())
+	require.NoError(t, err)
+
+	response, err := agent.(*agentframework.BaseAgent).ProcessEvent(context.Background(), &events.Event{
+		Subject: "mlmodel.deploy",
+		Payload: map[string]interface{}{
+			"user_message":   "deploy v2 of churn-api to prod",
+			"correlation_id": "corr-1",
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "success", response.Payload["status"], response.Payload["error"])
+	assert.Contains(t, response.Payload["message"], "churn-api now serves version v2 of churn in prod")
+
+	deployed, err := service.Deployments("churn-api")
+	require.NoError(t, err)
+	require.Len(t, deployed, 1)
+	assert.Equal(t, "v2", deployed[0].ServingVersion)
+}
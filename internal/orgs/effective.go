@@ -0,0 +1,171 @@
+package orgs
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/quotas"
+)
+
+// ErrApplicationNotFound is returned when resolving settings for an application that does not exist
+var ErrApplicationNotFound = errors.New("application not found")
+
+// EffectivePolicy is a policy that applies to an application and the unit it was attached to
+type EffectivePolicy struct {
+	Policy   string `json:"policy"`
+	Enforced bool   `json:"enforced,omitempty"`
+	Source   string `json:"source"` // node ID of the unit, e.g. team:payments
+}
+
+// ExcludedPolicy is an inherited policy a unit opted out of
+type ExcludedPolicy struct {
+	Policy     string `json:"policy"`
+	Source     string `json:"source"`
+	ExcludedBy string `json:"excluded_by"`
+}
+
+// EffectiveDefault is a default and the unit it came from
+type EffectiveDefault struct {
+	Value  interface{} `json:"value"`
+	Source string      `json:"source"`
+	Locked bool        `json:"locked,omitempty"`
+}
+
+// EffectiveLimit is an inherited quota limit; zero means no unit sets one
+type EffectiveLimit struct {
+	Metric string `json:"metric"`
+	Limit  int    `json:"limit"`
+	Source string `json:"source,omitempty"`
+}
+
+// Effective is what an application inherits from the units above it
+type Effective struct {
+	Application string                      `json:"application"`
+	Chain       []string                    `json:"chain"` // unit node IDs from the application's unit up to its organization
+	Policies    []EffectivePolicy           `json:"policies"`
+	Excluded    []ExcludedPolicy            `json:"excluded,omitempty"`
+	Quota       EffectiveLimit              `json:"quota"`
+	Defaults    map[string]EffectiveDefault `json:"defaults"`
+}
+
+// Effective resolves the policies, quota and defaults an application inherits. Units are applied
+// from the organization down, so the unit closest to the application overrides defaults unless a
+// unit above locked them, excludes inherited policies unless a unit above enforced them, and can
+// only tighten the quota.
+func (s *Service) Effective(appName string) (*Effective, error) {
+	app, _ := s.graph.GetNode(appName)
+	if app == nil || app.Kind != graph.KindApplication {
+		return nil, ErrApplicationNotFound
+	}
+	units, err := s.ListUnits()
+	if err != nil {
+		return nil, err
+	}
+	chain := chainOf(app, units)
+
+	effective := &Effective{
+		Application: appName,
+		Chain:       []string{},
+		Policies:    []EffectivePolicy{},
+		Quota:       EffectiveLimit{Metric: quotas.MetricServicesPerApplication},
+		Defaults:    map[string]EffectiveDefault{},
+	}
+	for _, unit := range chain {
+		effective.Chain = append(effective.Chain, NodeID(unit.Kind, unit.Name))
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		effective.apply(chain[i])
+	}
+	sort.Slice(effective.Policies, func(i, j int) bool { return effective.Policies[i].Policy < effective.Policies[j].Policy })
+	return effective, nil
+}
+
+// apply layers a unit's settings over those of the units above it
+func (e *Effective) apply(unit *Unit) {
+	source := NodeID(unit.Kind, unit.Name)
+	for _, attachment := range unit.Policies {
+		if i := e.policyIndex(attachment.Policy); i >= 0 {
+			e.Policies[i].Enforced = e.Policies[i].Enforced || attachment.Enforced
+			continue
+		}
+		e.Policies = append(e.Policies, EffectivePolicy{Policy: attachment.Policy, Enforced: attachment.Enforced, Source: source})
+	}
+	for _, policy := range unit.ExcludedPolicies {
+		i := e.policyIndex(policy)
+		if i < 0 || e.Policies[i].Enforced || e.Policies[i].Source == source {
+			continue
+		}
+		e.Excluded = append(e.Excluded, ExcludedPolicy{Policy: policy, Source: e.Policies[i].Source, ExcludedBy: source})
+		e.Policies = append(e.Policies[:i], e.Policies[i+1:]...)
+	}
+
+	for key, value := range unit.Defaults {
+		if current, ok := e.Defaults[key]; ok && current.Locked {
+			continue
+		}
+		e.Defaults[key] = EffectiveDefault{Value: value, Source: source, Locked: contains(unit.LockedDefaults, key)}
+	}
+	// Locking a default the unit does not set locks the inherited value
+	for _, key := range unit.LockedDefaults {
+		if current, ok := e.Defaults[key]; ok {
+			current.Locked = true
+			e.Defaults[key] = current
+		}
+	}
+
+	if limit := unit.Quota.MaxServicesPerApplication; limit > 0 && (e.Quota.Limit == 0 || limit < e.Quota.Limit) {
+		e.Quota.Limit, e.Quota.Source = limit, source
+	}
+}
+
+func (e *Effective) policyIndex(policy string) int {
+	for i, p := range e.Policies {
+		if p.Policy == policy {
+			return i
+		}
+	}
+	return -1
+}
+
+// chainOf returns the units above an application, closest first: the unit it is assigned to, or
+// the team named by its owner, then each parent up to the organization
+func chainOf(app *graph.Node, units []*Unit) []*Unit {
+	byID := map[string]*Unit{}
+	var start *Unit
+	for _, unit := range units {
+		byID[NodeID(unit.Kind, unit.Name)] = unit
+		if contains(unit.Applications, app.ID) {
+			start = unit
+		}
+	}
+	if start == nil {
+		if owner, ok := app.Metadata["owner"].(string); ok && owner != "" {
+			start = byID[NodeID(KindTeam, owner)]
+		}
+	}
+	var chain []*Unit
+	for unit := start; unit != nil && len(chain) < len(parentKind)+1; {
+		chain = append(chain, unit)
+		if unit.Parent == "" {
+			break
+		}
+		unit = byID[NodeID(parentKind[unit.Kind], unit.Parent)]
+	}
+	return chain
+}
+
+// InheritedLimit returns the limit an application inherits for a quota metric from the units
+// above it, and the kind of unit that set it. Register it with quotas.SetInheritedLimit.
+func InheritedLimit(globalGraph *graph.GlobalGraph, appName, metric string) (int, string) {
+	if metric != quotas.MetricServicesPerApplication {
+		return 0, ""
+	}
+	effective, err := NewService(globalGraph).Effective(appName)
+	if err != nil || effective.Quota.Limit == 0 {
+		return 0, ""
+	}
+	kind, _, _ := strings.Cut(effective.Quota.Source, ":")
+	return effective.Quota.Limit, kind
+}
@@ -0,0 +1,296 @@
+// Package orgs keeps the organization hierarchy: organizations contain teams, teams contain
+// projects, and applications belong to a team or a project. Policies, quotas and defaults
+// attached to a unit are inherited by every application below it, subject to the override rules
+// resolved by Effective.
+package orgs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/krzachariassen/ZTDP/internal/quotas"
+)
+
+// Unit kinds, outermost first
+const (
+	KindOrganization = graph.KindOrganization
+	KindTeam         = graph.KindTeam
+	KindProject      = graph.KindProject
+)
+
+// parentKind is the kind of unit each kind sits under; organizations are the roots
+var parentKind = map[string]string{
+	KindTeam:    KindOrganization,
+	KindProject: KindTeam,
+}
+
+var (
+	// ErrUnitNotFound is returned when an organization, team or project does not exist
+	ErrUnitNotFound = errors.New("organization unit not found")
+	// ErrUnitInUse is returned when deleting a unit that still has units or applications below it
+	ErrUnitInUse = errors.New("organization unit still has children")
+)
+
+// PolicyAttachment attaches a policy node to a unit. Applications below the unit inherit it;
+// an enforced policy cannot be excluded further down.
+type PolicyAttachment struct {
+	Policy   string `json:"policy"`
+	Enforced bool   `json:"enforced,omitempty"`
+}
+
+// Unit is an organization, team or project
+type Unit struct {
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Parent string `json:"parent,omitempty"` // organization of a team, team of a project
+
+	Policies []PolicyAttachment `json:"policies,omitempty"`
+	// ExcludedPolicies opts this unit and everything below it out of inherited policies that
+	// are not enforced
+	ExcludedPolicies []string `json:"excluded_policies,omitempty"`
+	// Quota limits every application below the unit; a unit further down can tighten a limit
+	// but never loosen it
+	Quota quotas.Limits `json:"quota"`
+	// Defaults are settings applications below the unit start from, e.g. {"replicas": 2}. A unit
+	// further down overrides a default unless it is listed in LockedDefaults.
+	Defaults       map[string]interface{} `json:"defaults,omitempty"`
+	LockedDefaults []string               `json:"locked_defaults,omitempty"`
+
+	// Applications assigned to the unit. Applications not assigned anywhere belong to the team
+	// named by their owner, when that team exists.
+	Applications []string  `json:"applications,omitempty"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// Validate checks the kind, parent and quota
+func (u Unit) Validate() error {
+	switch u.Kind {
+	case KindOrganization:
+		if u.Parent != "" {
+			return fmt.Errorf("organizations have no parent")
+		}
+	case KindTeam, KindProject:
+		if u.Parent == "" {
+			return fmt.Errorf("a %s needs a parent %s", u.Kind, parentKind[u.Kind])
+		}
+	default:
+		return fmt.Errorf("unknown unit kind %q (use organization, team or project)", u.Kind)
+	}
+	if u.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if u.Quota.MaxServicesPerApplication < 0 {
+		return fmt.Errorf("quota limits must not be negative")
+	}
+	if u.Quota.MaxResourcesPerTeam > 0 || u.Quota.MaxEnvironments > 0 {
+		return fmt.Errorf("unit quotas apply to each application below the unit, so they can only limit services")
+	}
+	return nil
+}
+
+// NodeID returns the graph node ID of a unit
+func NodeID(kind, name string) string {
+	return kind + ":" + name
+}
+
+// Service stores the hierarchy in the global graph
+type Service struct {
+	graph  *graph.GlobalGraph
+	logger *logging.Logger
+	now    func() time.Time
+}
+
+// NewService creates a hierarchy service backed by the global graph
+func NewService(globalGraph *graph.GlobalGraph) *Service {
+	return &Service{
+		graph:  globalGraph,
+		logger: logging.GetLogger().ForComponent("orgs"),
+		now:    time.Now,
+	}
+}
+
+// SetUnit creates or replaces a unit. Its parent must exist, attached policies must be policy
+// nodes, and assigned applications move here from any unit they belonged to before.
+func (s *Service) SetUnit(unit *Unit) error {
+	if err := unit.Validate(); err != nil {
+		return err
+	}
+	if unit.Parent != "" {
+		if _, err := s.GetUnit(parentKind[unit.Kind], unit.Parent); err != nil {
+			return fmt.Errorf("parent %s %q not found", parentKind[unit.Kind], unit.Parent)
+		}
+	}
+	for _, attachment := range unit.Policies {
+		if node, _ := s.graph.GetNode(attachment.Policy); node == nil || node.Kind != graph.KindPolicy {
+			return fmt.Errorf("policy %q not found", attachment.Policy)
+		}
+	}
+	for _, app := range unit.Applications {
+		if node, _ := s.graph.GetNode(app); node == nil || node.Kind != graph.KindApplication {
+			return fmt.Errorf("application %q not found", app)
+		}
+	}
+	if existing, err := s.GetUnit(unit.Kind, unit.Name); err == nil && existing.Parent != unit.Parent {
+		if children, _ := s.children(existing); len(children) > 0 {
+			return fmt.Errorf("%w: move or delete the units below %s %q before changing its parent", ErrUnitInUse, unit.Kind, unit.Name)
+		}
+	}
+
+	// An application belongs to one unit, so assigning it here takes it from its previous one
+	units, err := s.ListUnits()
+	if err != nil {
+		return err
+	}
+	for _, other := range units {
+		if other.Kind == unit.Kind && other.Name == unit.Name {
+			continue
+		}
+		kept := withoutAll(other.Applications, unit.Applications)
+		if len(kept) != len(other.Applications) {
+			other.Applications = kept
+			if err := s.save(other); err != nil {
+				return err
+			}
+		}
+	}
+	unit.UpdatedAt = s.now().UTC()
+	if err := s.save(unit); err != nil {
+		return err
+	}
+	s.logger.Info("🏢 %s %s set", unit.Kind, unit.Name)
+	return nil
+}
+
+// GetUnit returns a unit
+func (s *Service) GetUnit(kind, name string) (*Unit, error) {
+	node, _ := s.graph.GetNode(NodeID(kind, name))
+	if node == nil || node.Kind != kind {
+		return nil, ErrUnitNotFound
+	}
+	return nodeToUnit(node)
+}
+
+// ListUnits returns every unit, organizations first, then teams and projects, each by name
+func (s *Service) ListUnits() ([]*Unit, error) {
+	nodes, err := s.graph.Nodes()
+	if err != nil {
+		return nil, err
+	}
+	rank := map[string]int{KindOrganization: 0, KindTeam: 1, KindProject: 2}
+	units := []*Unit{}
+	for _, node := range nodes {
+		if _, ok := rank[node.Kind]; !ok {
+			continue
+		}
+		unit, err := nodeToUnit(node)
+		if err != nil {
+			s.logger.Warn("⚠️ Skipping malformed unit node %s: %v", node.ID, err)
+			continue
+		}
+		units = append(units, unit)
+	}
+	sort.Slice(units, func(i, j int) bool {
+		if units[i].Kind != units[j].Kind {
+			return rank[units[i].Kind] < rank[units[j].Kind]
+		}
+		return units[i].Name < units[j].Name
+	})
+	return units, nil
+}
+
+// DeleteUnit removes a unit that has no units or applications below it
+func (s *Service) DeleteUnit(kind, name string) error {
+	unit, err := s.GetUnit(kind, name)
+	if err != nil {
+		return err
+	}
+	children, err := s.children(unit)
+	if err != nil {
+		return err
+	}
+	if len(children) > 0 || len(unit.Applications) > 0 {
+		return fmt.Errorf("%w: %s %q", ErrUnitInUse, kind, name)
+	}
+	if err := s.graph.DeleteNode(NodeID(kind, name)); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", kind, err)
+	}
+	s.logger.Info("🏢 %s %s deleted", kind, name)
+	return s.graph.Save()
+}
+
+// children returns the units directly below a unit
+func (s *Service) children(unit *Unit) ([]*Unit, error) {
+	units, err := s.ListUnits()
+	if err != nil {
+		return nil, err
+	}
+	var children []*Unit
+	for _, other := range units {
+		if parentKind[other.Kind] == unit.Kind && other.Parent == unit.Name {
+			children = append(children, other)
+		}
+	}
+	return children, nil
+}
+
+func (s *Service) save(unit *Unit) error {
+	data, err := json.Marshal(unit)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", unit.Kind, err)
+	}
+	var spec map[string]interface{}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", unit.Kind, err)
+	}
+	node := &graph.Node{
+		ID:       NodeID(unit.Kind, unit.Name),
+		Kind:     unit.Kind,
+		Metadata: map[string]interface{}{"name": unit.Name, "parent": unit.Parent},
+		Spec:     spec,
+	}
+	if existing, _ := s.graph.GetNode(node.ID); existing != nil {
+		if err := s.graph.UpdateNode(node); err != nil {
+			return fmt.Errorf("failed to update %s: %w", unit.Kind, err)
+		}
+	} else if err := s.graph.AddNode(node); err != nil {
+		return fmt.Errorf("failed to add %s: %w", unit.Kind, err)
+	}
+	return s.graph.Save()
+}
+
+func nodeToUnit(node *graph.Node) (*Unit, error) {
+	data, err := json.Marshal(node.Spec)
+	if err != nil {
+		return nil, err
+	}
+	var unit Unit
+	if err := json.Unmarshal(data, &unit); err != nil {
+		return nil, err
+	}
+	return &unit, nil
+}
+
+// withoutAll returns the values not in remove
+func withoutAll(values, remove []string) []string {
+	kept := []string{}
+	for _, value := range values {
+		if !contains(remove, value) {
+			kept = append(kept, value)
+		}
+	}
+	return kept
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
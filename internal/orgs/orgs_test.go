@@ -0,0 +1,133 @@
+package orgs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/quotas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newOrgsTestGraph(t *testing.T) *graph.GlobalGraph {
+	t.Helper()
+	g := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	node := func(id, kind, owner string) {
+		g.AddNode(&graph.Node{ID: id, Kind: kind, Metadata: map[string]interface{}{"name": id, "owner": owner}})
+	}
+	node("checkout", graph.KindApplication, "payments")
+	node("billing", graph.KindApplication, "payments")
+	node("search", graph.KindApplication, "discovery")
+	node("checkout-api", graph.KindService, "payments")
+	require.NoError(t, g.AddEdge("checkout", "checkout-api", graph.EdgeTypeOwns))
+	for _, policy := range []string{"require-tls", "no-friday-deploys", "pci-scan"} {
+		node(policy, graph.KindPolicy, "platform")
+	}
+	return g
+}
+
+func newHierarchy(t *testing.T, service *Service) {
+	t.Helper()
+	require.NoError(t, service.SetUnit(&Unit{
+		Kind:           KindOrganization,
+		Name:           "acme",
+		Policies:       []PolicyAttachment{{Policy: "require-tls", Enforced: true}, {Policy: "no-friday-deploys"}},
+		Quota:          quotas.Limits{MaxServicesPerApplication: 5},
+		Defaults:       map[string]interface{}{"replicas": 2, "region": "eu-west-1"},
+		LockedDefaults: []string{"region"},
+	}))
+	require.NoError(t, service.SetUnit(&Unit{
+		Kind:             KindTeam,
+		Name:             "payments",
+		Parent:           "acme",
+		Policies:         []PolicyAttachment{{Policy: "pci-scan"}},
+		ExcludedPolicies: []string{"require-tls", "no-friday-deploys"},
+		Quota:            quotas.Limits{MaxServicesPerApplication: 10},
+		Defaults:         map[string]interface{}{"replicas": 3, "region": "us-east-1"},
+	}))
+	require.NoError(t, service.SetUnit(&Unit{
+		Kind:         KindProject,
+		Name:         "storefront",
+		Parent:       "payments",
+		Quota:        quotas.Limits{MaxServicesPerApplication: 1},
+		Applications: []string{"checkout"},
+	}))
+}
+
+func TestSetUnit_ValidatesHierarchy(t *testing.T) {
+	service := NewService(newOrgsTestGraph(t))
+	assert.ErrorContains(t, service.SetUnit(&Unit{Kind: KindTeam, Name: "payments"}), "needs a parent organization")
+	assert.ErrorContains(t, service.SetUnit(&Unit{Kind: KindTeam, Name: "payments", Parent: "acme"}), "not found")
+	assert.ErrorContains(t, service.SetUnit(&Unit{Kind: "division", Name: "x"}), "unknown unit kind")
+	assert.ErrorContains(t, service.SetUnit(&Unit{Kind: KindOrganization, Name: "acme", Policies: []PolicyAttachment{{Policy: "checkout"}}}), `policy "checkout" not found`)
+	assert.ErrorContains(t, service.SetUnit(&Unit{Kind: KindOrganization, Name: "acme", Quota: quotas.Limits{MaxEnvironments: 1}}), "can only limit services")
+
+	newHierarchy(t, service)
+	units, err := service.ListUnits()
+	require.NoError(t, err)
+	require.Len(t, units, 3)
+	assert.Equal(t, []string{KindOrganization, KindTeam, KindProject}, []string{units[0].Kind, units[1].Kind, units[2].Kind})
+
+	// Assigning an application elsewhere takes it from its previous unit
+	require.NoError(t, service.SetUnit(&Unit{Kind: KindTeam, Name: "payments", Parent: "acme", Applications: []string{"checkout"}}))
+	project, err := service.GetUnit(KindProject, "storefront")
+	require.NoError(t, err)
+	assert.Empty(t, project.Applications)
+
+	assert.True(t, errors.Is(service.DeleteUnit(KindTeam, "payments"), ErrUnitInUse))
+	require.NoError(t, service.DeleteUnit(KindProject, "storefront"))
+	_, err = service.GetUnit(KindProject, "storefront")
+	assert.Equal(t, ErrUnitNotFound, err)
+}
+
+func TestEffective_AppliesOverrideRules(t *testing.T) {
+	service := NewService(newOrgsTestGraph(t))
+	newHierarchy(t, service)
+
+	effective, err := service.Effective("checkout")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"project:storefront", "team:payments", "organization:acme"}, effective.Chain)
+	assert.Equal(t, []EffectivePolicy{
+		{Policy: "pci-scan", Source: "team:payments"},
+		{Policy: "require-tls", Enforced: true, Source: "organization:acme"},
+	}, effective.Policies, "enforced policies cannot be excluded")
+	assert.Equal(t, []ExcludedPolicy{{Policy: "no-friday-deploys", Source: "organization:acme", ExcludedBy: "team:payments"}}, effective.Excluded)
+	assert.Equal(t, EffectiveDefault{Value: float64(3), Source: "team:payments"}, effective.Defaults["replicas"])
+	assert.Equal(t, EffectiveDefault{Value: "eu-west-1", Source: "organization:acme", Locked: true}, effective.Defaults["region"], "locked defaults cannot be overridden")
+	assert.Equal(t, EffectiveLimit{Metric: quotas.MetricServicesPerApplication, Limit: 1, Source: "project:storefront"}, effective.Quota)
+
+	// Unassigned applications belong to the team named by their owner; a team can only tighten
+	// the organization's quota
+	effective, err = service.Effective("billing")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"team:payments", "organization:acme"}, effective.Chain)
+	assert.Equal(t, 5, effective.Quota.Limit)
+	assert.Equal(t, "organization:acme", effective.Quota.Source)
+
+	effective, err = service.Effective("search")
+	require.NoError(t, err)
+	assert.Empty(t, effective.Chain)
+	assert.Empty(t, effective.Policies)
+
+	_, err = service.Effective("missing")
+	assert.Equal(t, ErrApplicationNotFound, err)
+}
+
+func TestInheritedLimit_IsEnforcedByQuotas(t *testing.T) {
+	g := newOrgsTestGraph(t)
+	newHierarchy(t, NewService(g))
+	quotas.SetInheritedLimit(InheritedLimit)
+	t.Cleanup(func() { quotas.SetInheritedLimit(nil) })
+
+	err := quotas.NewService(g).CheckServiceCreation("checkout")
+	var exceeded *quotas.ExceededError
+	require.True(t, errors.As(err, &exceeded))
+	assert.Equal(t, KindProject, exceeded.Scope)
+	assert.Equal(t, 1, exceeded.Limit)
+	assert.NoError(t, quotas.NewService(g).CheckServiceCreation("billing"))
+
+	// An application quota still overrides what the application inherits
+	require.NoError(t, quotas.NewService(g).SetQuota(&quotas.Quota{Scope: quotas.ScopeApplication, Name: "checkout", Limits: quotas.Limits{MaxServicesPerApplication: 2}}))
+	assert.NoError(t, quotas.NewService(g).CheckServiceCreation("checkout"))
+}
@@ -0,0 +1,270 @@
+// Package plans stores execution plans proposed by AI agents so users can review and revise
+// them step by step ("remove step 3", "rename the database") instead of regenerating them.
+package plans
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Plan statuses; only drafts can be revised
+const (
+	StatusDraft     = "draft"
+	StatusApproved  = "approved"
+	StatusDiscarded = "discarded"
+)
+
+// Revision authors
+const (
+	AuthorAI   = "ai"
+	AuthorUser = "user"
+)
+
+var (
+	// ErrPlanNotFound is returned when a plan does not exist
+	ErrPlanNotFound = errors.New("plan not found")
+	// ErrPlanNotEditable is returned when revising a plan that is no longer a draft
+	ErrPlanNotEditable = errors.New("plan is not a draft")
+	// ErrInvalidOperation is returned for edit operations that cannot be applied
+	ErrInvalidOperation = errors.New("invalid plan operation")
+	// ErrAIUnavailable is returned for natural language revisions when no AI provider is configured
+	ErrAIUnavailable = errors.New("AI provider not available - submit operations instead")
+)
+
+// Step is one action of a plan
+type Step struct {
+	ID          string   `json:"id"` // stable across revisions, unlike the step's position
+	Action      string   `json:"action"`
+	Target      string   `json:"target,omitempty"`
+	Description string   `json:"description,omitempty"`
+	DependsOn   []string `json:"depends_on,omitempty"` // IDs of steps that must finish first
+}
+
+// Plan is an ordered list of steps proposed for a goal, bound to the conversation it came from
+type Plan struct {
+	ID             string     `json:"id"`
+	ConversationID string     `json:"conversation_id,omitempty"`
+	Tenant         string     `json:"tenant,omitempty"`
+	Goal           string     `json:"goal"`
+	Application    string     `json:"application,omitempty"`
+	Environment    string     `json:"environment,omitempty"`
+	ProposedBy     string     `json:"proposed_by,omitempty"` // agent that proposed the plan
+	Status         string     `json:"status"`
+	Revision       int        `json:"revision"`
+	Steps          []Step     `json:"steps"`
+	Warnings       []string   `json:"warnings,omitempty"` // conflicts found when the plan was proposed, e.g. a change freeze
+	Revisions      []Revision `json:"revisions,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// Revision records one edit of a plan and the steps it produced
+type Revision struct {
+	Number      int         `json:"number"`
+	Timestamp   time.Time   `json:"timestamp"`
+	Author      string      `json:"author"`                // ai or user
+	Instruction string      `json:"instruction,omitempty"` // what the user asked for
+	Summary     string      `json:"summary,omitempty"`
+	Operations  []Operation `json:"operations"`
+	Steps       []Step      `json:"steps"` // plan steps after the revision
+}
+
+// Operation is a single edit of a plan's steps. Steps are referenced by ID or by 1-based position.
+type Operation struct {
+	Op          string `json:"op"`              // remove | rename | update | add | move
+	Step        string `json:"step,omitempty"`  // remove, update, move
+	After       string `json:"after,omitempty"` // add, move: step to insert after; "0" inserts first, empty appends
+	From        string `json:"from,omitempty"`  // rename: target to replace everywhere in the plan
+	To          string `json:"to,omitempty"`    // rename: new target name
+	Action      string `json:"action,omitempty"`
+	Target      string `json:"target,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Apply returns the plan's steps with the operations applied, leaving the plan untouched
+func (p *Plan) Apply(ops []Operation) ([]Step, error) {
+	steps := make([]Step, len(p.Steps))
+	for i, step := range p.Steps {
+		steps[i] = step
+		steps[i].DependsOn = append([]string(nil), step.DependsOn...)
+	}
+	next := p.nextStepNumber()
+
+	for i, op := range ops {
+		var err error
+		switch op.Op {
+		case "remove":
+			steps, err = removeStep(steps, op)
+		case "rename":
+			err = renameTarget(steps, op)
+		case "update":
+			err = updateStep(steps, op)
+		case "add":
+			steps, err = addStep(steps, op, fmt.Sprintf("step-%d", next))
+			next++
+		case "move":
+			steps, err = moveStep(steps, op)
+		default:
+			err = fmt.Errorf("unknown op %q (expected remove, rename, update, add or move)", op.Op)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: operation %d: %v", ErrInvalidOperation, i+1, err)
+		}
+	}
+	return steps, nil
+}
+
+// nextStepNumber returns the number for the next step ID, never reusing removed IDs
+func (p *Plan) nextStepNumber() int {
+	next := 1
+	for _, revision := range append([]Revision{{Steps: p.Steps}}, p.Revisions...) {
+		for _, step := range revision.Steps {
+			if n, err := strconv.Atoi(strings.TrimPrefix(step.ID, "step-")); err == nil && n >= next {
+				next = n + 1
+			}
+		}
+	}
+	return next
+}
+
+// indexOf resolves a step reference (ID or 1-based position) to its index
+func indexOf(steps []Step, ref string) (int, error) {
+	ref = strings.TrimSpace(ref)
+	for i, step := range steps {
+		if step.ID == ref {
+			return i, nil
+		}
+	}
+	if pos, err := strconv.Atoi(ref); err == nil && pos >= 1 && pos <= len(steps) {
+		return pos - 1, nil
+	}
+	return -1, fmt.Errorf("step %q does not exist (plan has %d steps)", ref, len(steps))
+}
+
+// insertIndex resolves an "after" reference to the index a step is inserted at
+func insertIndex(steps []Step, after string) (int, error) {
+	switch strings.TrimSpace(after) {
+	case "":
+		return len(steps), nil
+	case "0":
+		return 0, nil
+	}
+	i, err := indexOf(steps, after)
+	return i + 1, err
+}
+
+func removeStep(steps []Step, op Operation) ([]Step, error) {
+	i, err := indexOf(steps, op.Step)
+	if err != nil {
+		return nil, err
+	}
+	removed := steps[i].ID
+	steps = append(steps[:i], steps[i+1:]...)
+	for j := range steps {
+		steps[j].DependsOn = without(steps[j].DependsOn, removed)
+	}
+	return steps, nil
+}
+
+func renameTarget(steps []Step, op Operation) error {
+	if op.From == "" || op.To == "" {
+		return fmt.Errorf("rename requires from and to")
+	}
+	renamed := false
+	for i := range steps {
+		if steps[i].Target == op.From {
+			steps[i].Target = op.To
+			renamed = true
+		}
+		if strings.Contains(steps[i].Description, op.From) {
+			steps[i].Description = strings.ReplaceAll(steps[i].Description, op.From, op.To)
+			renamed = true
+		}
+	}
+	if !renamed {
+		return fmt.Errorf("no step refers to %q", op.From)
+	}
+	return nil
+}
+
+func updateStep(steps []Step, op Operation) error {
+	i, err := indexOf(steps, op.Step)
+	if err != nil {
+		return err
+	}
+	if op.Action == "" && op.Target == "" && op.Description == "" {
+		return fmt.Errorf("update requires action, target or description")
+	}
+	if op.Action != "" {
+		steps[i].Action = op.Action
+	}
+	if op.Target != "" {
+		steps[i].Target = op.Target
+	}
+	if op.Description != "" {
+		steps[i].Description = op.Description
+	}
+	return nil
+}
+
+func addStep(steps []Step, op Operation, id string) ([]Step, error) {
+	if op.Action == "" {
+		return nil, fmt.Errorf("add requires an action")
+	}
+	at, err := insertIndex(steps, op.After)
+	if err != nil {
+		return nil, err
+	}
+	step := Step{ID: id, Action: op.Action, Target: op.Target, Description: op.Description}
+	steps = append(steps, Step{})
+	copy(steps[at+1:], steps[at:])
+	steps[at] = step
+	return steps, nil
+}
+
+func moveStep(steps []Step, op Operation) ([]Step, error) {
+	i, err := indexOf(steps, op.Step)
+	if err != nil {
+		return nil, err
+	}
+	step := steps[i]
+	rest := append(append([]Step(nil), steps[:i]...), steps[i+1:]...)
+	at, err := insertIndex(rest, op.After)
+	if err != nil {
+		return nil, err
+	}
+	moved := append(append(append([]Step(nil), rest[:at]...), step), rest[at:]...)
+	return moved, nil
+}
+
+func without(list []string, value string) []string {
+	var out []string
+	for _, item := range list {
+		if item != value {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// Describe renders the plan's steps as a numbered list for chat responses and AI prompts
+func (p *Plan) Describe() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Plan %s (revision %d, %s): %s", p.ID, p.Revision, p.Status, p.Goal)
+	for i, step := range p.Steps {
+		fmt.Fprintf(&sb, "\n%d. [%s] %s", i+1, step.ID, step.Action)
+		if step.Target != "" {
+			fmt.Fprintf(&sb, " %s", step.Target)
+		}
+		if step.Description != "" {
+			fmt.Fprintf(&sb, " - %s", step.Description)
+		}
+	}
+	for _, warning := range p.Warnings {
+		fmt.Fprintf(&sb, "\n⚠️ %s", warning)
+	}
+	return sb.String()
+}
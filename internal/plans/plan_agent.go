@@ -0,0 +1,223 @@
+package plans
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+	"github.com/krzachariassen/ZTDP/internal/ai"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/features"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
+)
+
+// PlanRequest is what the AI extracts from a message about the conversation's plan
+type PlanRequest struct {
+	Action        string  `json:"action"`            // edit | show | approve | discard
+	PlanID        string  `json:"plan_id,omitempty"` // only when the user names a plan
+	Confidence    float64 `json:"confidence"`
+	Clarification string  `json:"clarification,omitempty"`
+}
+
+// PlanAgent revises the plan proposed earlier in a conversation from chat instructions
+type PlanAgent struct {
+	service    *Service
+	aiProvider ai.AIProvider
+	logger     *logging.Logger
+}
+
+// NewPlanAgent creates the plan agent
+func NewPlanAgent(
+	globalGraph *graph.GlobalGraph,
+	service *Service,
+	aiProvider ai.AIProvider,
+	eventBus *events.EventBus,
+	registry agentRegistry.AgentRegistry,
+) (agentRegistry.AgentInterface, error) {
+	if service == nil {
+		return nil, fmt.Errorf("plan service is required")
+	}
+	if aiProvider == nil {
+		return nil, fmt.Errorf("aiProvider is required for AI-native agent")
+	}
+	if eventBus == nil {
+		return nil, fmt.Errorf("eventBus is required")
+	}
+	if registry == nil {
+		return nil, fmt.Errorf("registry is required")
+	}
+
+	wrapper := &PlanAgent{
+		service:    service,
+		aiProvider: aiProvider,
+		logger:     logging.GetLogger().ForComponent("plan-agent"),
+	}
+
+	agent, err := agentframework.NewAgent("plan-agent").
+		WithType("plan").
+		WithCapabilities(getPlanCapabilities()).
+		WithEventHandler(wrapper.handleEvent).
+		Build(agentframework.AgentDependencies{
+			Registry: registry,
+			EventBus: eventBus,
+			Flags:    features.NewService(globalGraph),
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build plan agent: %w", err)
+	}
+
+	wrapper.logger.Info("✅ PlanAgent created successfully")
+	return agent, nil
+}
+
+// getPlanCapabilities returns the capabilities for the plan agent
+func getPlanCapabilities() []agentRegistry.AgentCapability {
+	return []agentRegistry.AgentCapability{
+		{
+			Name:        "plan_editing",
+			Description: "Revises, shows, approves or discards the execution plan proposed earlier in the conversation",
+			Intents: []string{
+				"edit plan", "revise plan", "remove plan step", "rename in plan", "reorder plan steps",
+				"show plan", "approve plan", "discard plan",
+			},
+			InputTypes:  []string{"user_message"},
+			OutputTypes: []string{"plan"},
+			RoutingKeys: []string{"plan.edit", "plan.request"},
+			Version:     "1.0.0",
+		},
+	}
+}
+
+// handleEvent resolves the conversation's plan and applies the requested change
+func (a *PlanAgent) handleEvent(ctx context.Context, event *events.Event) (*events.Event, error) {
+	userMessage, ok := event.Payload["user_message"].(string)
+	if !ok || userMessage == "" {
+		return a.createErrorResponse(event, "user_message field is required in event payload"), nil
+	}
+
+	request, err := a.extractRequest(ctx, userMessage)
+	if err != nil {
+		return a.createErrorResponse(event, fmt.Sprintf("I couldn't understand the plan request: %v", err)), nil
+	}
+	if agentframework.NeedsClarification(ctx, request.Confidence) {
+		clarification := request.Clarification
+		if clarification == "" {
+			clarification = "Do you want to change, show, approve or discard the plan?"
+		}
+		return agentframework.ClarificationResponse(ctx, event, clarification, request.Confidence), nil
+	}
+
+	plan, err := a.resolvePlan(ctx, request.PlanID)
+	if err != nil {
+		return a.createErrorResponse(event, err.Error()), nil
+	}
+
+	switch request.Action {
+	case "edit":
+		revised, err := a.service.ReviseWithAI(ctx, plan.ID, userMessage)
+		if err != nil {
+			return a.createErrorResponse(event, fmt.Sprintf("I couldn't revise plan %s: %v", plan.ID, err)), nil
+		}
+		summary := revised.Revisions[len(revised.Revisions)-1].Summary
+		if summary == "" {
+			summary = fmt.Sprintf("Revised plan %s", revised.ID)
+		}
+		return a.createSuccessResponse(event, fmt.Sprintf("✏️ %s\n\n%s", summary, revised.Describe()), revised), nil
+	case "show":
+		return a.createSuccessResponse(event, plan.Describe(), plan), nil
+	case "approve", "discard":
+		status := StatusApproved
+		if request.Action == "discard" {
+			status = StatusDiscarded
+		}
+		updated, err := a.service.SetStatus(plan.ID, status)
+		if err != nil {
+			return a.createErrorResponse(event, err.Error()), nil
+		}
+		return a.createSuccessResponse(event, fmt.Sprintf("📋 Plan %s %s at revision %d", updated.ID, status, updated.Revision), updated), nil
+	default:
+		return a.createErrorResponse(event, fmt.Sprintf("I can edit, show, approve or discard plans, not %q", request.Action)), nil
+	}
+}
+
+// resolvePlan returns the named plan, or the latest draft of the current conversation
+func (a *PlanAgent) resolvePlan(ctx context.Context, planID string) (*Plan, error) {
+	if planID != "" {
+		return a.service.Get(planID)
+	}
+	conversationID := features.EvaluationContextFrom(ctx).ConversationID
+	plan, err := a.service.Latest(conversationID)
+	if errors.Is(err, ErrPlanNotFound) {
+		return nil, fmt.Errorf("there is no draft plan in this conversation to change")
+	}
+	return plan, err
+}
+
+// extractRequest asks the AI what the user wants to do with the plan
+func (a *PlanAgent) extractRequest(ctx context.Context, userMessage string) (*PlanRequest, error) {
+	systemPrompt := `An execution plan was proposed earlier in this conversation. Decide what the user wants to do with it and respond with JSON:
+{"action": "edit|show|approve|discard", "plan_id": "", "confidence": 0.0, "clarification": ""}
+
+Rules:
+- edit: remove, rename, reorder, add or change steps ("remove step 3", "rename the database to orders-db")
+- show: display the current plan
+- approve: the user accepts the plan as it is; discard: the user abandons it
+- Set plan_id only when the user names a plan ID such as plan-123
+- Set confidence below 0.7 and explain in clarification when the request is ambiguous
+
+Respond with JSON only.`
+
+	response, err := a.aiProvider.CallAI(ai.WithTask(ctx, ai.TaskExtraction), systemPrompt, userMessage)
+	if err != nil {
+		return nil, err
+	}
+
+	cleaned := strings.TrimSpace(response)
+	cleaned = strings.TrimPrefix(cleaned, "```json")
+	cleaned = strings.TrimPrefix(cleaned, "```")
+	cleaned = strings.TrimSuffix(cleaned, "```")
+
+	var request PlanRequest
+	if err := json.Unmarshal([]byte(strings.TrimSpace(cleaned)), &request); err != nil {
+		return nil, fmt.Errorf("failed to parse AI response: %w", err)
+	}
+	a.logger.Info("🤖 AI extracted plan action: %s, confidence: %.2f", request.Action, request.Confidence)
+	return &request, nil
+}
+
+func (a *PlanAgent) createSuccessResponse(originalEvent *events.Event, message string, plan *Plan) *events.Event {
+	return &events.Event{
+		ID:        fmt.Sprintf("plan-response-%d", time.Now().UnixNano()),
+		Type:      events.EventTypeResponse,
+		Subject:   "plan.response",
+		Source:    "plan-agent",
+		Timestamp: time.Now().Unix(),
+		Payload: map[string]interface{}{
+			"status":         "success",
+			"message":        message,
+			"plan":           plan,
+			"correlation_id": originalEvent.Payload["correlation_id"],
+		},
+	}
+}
+
+func (a *PlanAgent) createErrorResponse(originalEvent *events.Event, errorMessage string) *events.Event {
+	return &events.Event{
+		ID:        fmt.Sprintf("plan-error-%d", time.Now().UnixNano()),
+		Type:      events.EventTypeResponse,
+		Subject:   "plan.error",
+		Source:    "plan-agent",
+		Timestamp: time.Now().Unix(),
+		Payload: map[string]interface{}{
+			"status":         "error",
+			"error":          errorMessage,
+			"correlation_id": originalEvent.Payload["correlation_id"],
+		},
+	}
+}
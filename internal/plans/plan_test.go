@@ -0,0 +1,161 @@
+package plans
+
+import (
+	"context"
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+	"github.com/krzachariassen/ZTDP/internal/ai/aitest"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func samplePlan() Plan {
+	return Plan{
+		ConversationID: "conv-1",
+		Goal:           "deploy checkout to staging",
+		Application:    "checkout",
+		Steps: []Step{
+			{Action: "create", Target: "checkout-db", Description: "Provision checkout-db"},
+			{Action: "deploy", Target: "checkout-api", DependsOn: []string{"step-1"}},
+			{Action: "deploy", Target: "checkout-worker", DependsOn: []string{"step-1", "step-2"}},
+			{Action: "verify", Target: "checkout"},
+		},
+	}
+}
+
+func TestPlanApply(t *testing.T) {
+	plan := samplePlan()
+	for i := range plan.Steps {
+		plan.Steps[i].ID = []string{"step-1", "step-2", "step-3", "step-4"}[i]
+	}
+
+	t.Run("remove by position strips dependencies", func(t *testing.T) {
+		steps, err := plan.Apply([]Operation{{Op: "remove", Step: "2"}})
+		require.NoError(t, err)
+		require.Len(t, steps, 3)
+		assert.Equal(t, "step-3", steps[1].ID)
+		assert.Equal(t, []string{"step-1"}, steps[1].DependsOn)
+		assert.Len(t, plan.Steps, 4, "Apply must not modify the plan")
+		assert.Len(t, plan.Steps[2].DependsOn, 2)
+	})
+
+	t.Run("rename replaces targets and descriptions", func(t *testing.T) {
+		steps, err := plan.Apply([]Operation{{Op: "rename", From: "checkout-db", To: "orders-db"}})
+		require.NoError(t, err)
+		assert.Equal(t, "orders-db", steps[0].Target)
+		assert.Equal(t, "Provision orders-db", steps[0].Description)
+	})
+
+	t.Run("add and move", func(t *testing.T) {
+		steps, err := plan.Apply([]Operation{
+			{Op: "add", After: "0", Action: "backup", Target: "checkout-db"},
+			{Op: "move", Step: "step-4", After: "step-1"},
+		})
+		require.NoError(t, err)
+		ids := []string{}
+		for _, step := range steps {
+			ids = append(ids, step.ID)
+		}
+		assert.Equal(t, []string{"step-5", "step-1", "step-4", "step-2", "step-3"}, ids)
+	})
+
+	t.Run("invalid operations", func(t *testing.T) {
+		_, err := plan.Apply([]Operation{{Op: "remove", Step: "9"}})
+		assert.ErrorIs(t, err, ErrInvalidOperation)
+		_, err = plan.Apply([]Operation{{Op: "explode"}})
+		assert.ErrorIs(t, err, ErrInvalidOperation)
+		_, err = plan.Apply([]Operation{{Op: "rename", From: "payments-db", To: "x"}})
+		assert.ErrorIs(t, err, ErrInvalidOperation)
+	})
+}
+
+func TestServiceRevisionsAndStatus(t *testing.T) {
+	service := NewService(graph.NewGlobalGraph(graph.NewMemoryGraph()), nil)
+
+	plan, err := service.Propose(samplePlan())
+	require.NoError(t, err)
+	assert.Equal(t, StatusDraft, plan.Status)
+	assert.Equal(t, 1, plan.Revision)
+	assert.Equal(t, "step-4", plan.Steps[3].ID)
+
+	revised, err := service.Revise(plan.ID, []Operation{{Op: "remove", Step: "3"}}, AuthorUser, "remove step 3", "")
+	require.NoError(t, err)
+	assert.Equal(t, 2, revised.Revision)
+	require.Len(t, revised.Revisions, 1)
+	assert.Equal(t, "remove step 3", revised.Revisions[0].Instruction)
+
+	stored, err := service.Latest("conv-1")
+	require.NoError(t, err)
+	assert.Len(t, stored.Steps, 3)
+
+	// Step IDs are never reused, even for steps removed in earlier revisions
+	revised, err = service.Revise(plan.ID, []Operation{{Op: "add", Action: "notify"}}, AuthorUser, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "step-5", revised.Steps[3].ID)
+
+	_, err = service.ReviseWithAI(context.Background(), plan.ID, "rename the database")
+	assert.ErrorIs(t, err, ErrAIUnavailable)
+
+	_, err = service.SetStatus(plan.ID, StatusApproved)
+	require.NoError(t, err)
+	_, err = service.Revise(plan.ID, []Operation{{Op: "remove", Step: "1"}}, AuthorUser, "", "")
+	assert.ErrorIs(t, err, ErrPlanNotEditable)
+	_, err = service.Latest("conv-1")
+	assert.ErrorIs(t, err, ErrPlanNotFound)
+
+	require.NoError(t, service.Delete(plan.ID))
+	_, err = service.Get(plan.ID)
+	assert.ErrorIs(t, err, ErrPlanNotFound)
+}
+
+func TestServiceStoresProposedPlans(t *testing.T) {
+	service := NewService(graph.NewGlobalGraph(graph.NewMemoryGraph()), nil)
+	bus := events.NewEventBus(nil, false)
+	service.Attach(bus)
+
+	plan := samplePlan()
+	plan.ID = "plan-42"
+	require.NoError(t, bus.Emit(events.EventTypeRequest, "deployment-agent", ProposedSubject, map[string]interface{}{"plan": plan}))
+
+	stored, err := service.Get("plan-42")
+	require.NoError(t, err)
+	assert.Equal(t, "deployment-agent", stored.ProposedBy)
+	assert.Equal(t, "conv-1", stored.ConversationID)
+	assert.Len(t, stored.Steps, 4)
+}
+
+func TestPlanAgentRevisesConversationPlan(t *testing.T) {
+	globalGraph := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	provider := aitest.Sequence(
+		`{"action": "edit", "confidence": 0.95}`,
+		"```json\n"+`{"operations": [{"op": "rename", "from": "checkout-db", "to": "orders-db"}], "summary": "Renamed the database to orders-db", "confidence": 0.9}`+"\n```",
+	)
+	service := NewService(globalGraph, provider)
+	plan, err := service.Propose(samplePlan())
+	require.NoError(t, err)
+
+	agent, err := NewPlanAgent(globalGraph, service, provider, events.NewEventBus(nil, false), agentRegistry.NewInMemoryAgentRegistry())
+	require.NoError(t, err)
+
+	response, err := agent.(*agentframework.BaseAgent).ProcessEvent(context.Background(), &events.Event{
+		Subject: "plan.edit",
+		Payload: map[string]interface{}{
+			"user_message":    "rename the database to orders-db",
+			"conversation_id": "conv-1",
+			"correlation_id":  "corr-1",
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "success", response.Payload["status"], response.Payload["error"])
+	assert.Contains(t, response.Payload["message"], "Renamed the database to orders-db")
+
+	revised, err := service.Get(plan.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, revised.Revision)
+	assert.Equal(t, "orders-db", revised.Steps[0].Target)
+	assert.Equal(t, AuthorAI, revised.Revisions[0].Author)
+}
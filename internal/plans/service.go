@@ -0,0 +1,378 @@
+package plans
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/ai"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
+)
+
+// nodeIDPrefix namespaces plan nodes so they cannot collide with platform entities
+const nodeIDPrefix = "plan:"
+
+// ProposedSubject is the routing key agents emit proposed plans on; the payload's "plan"
+// field holds the Plan
+const ProposedSubject = "plan.proposed"
+
+// Plan changes reported to ChangeHandlers
+const (
+	ChangeProposed  = "proposed"
+	ChangeRevised   = "revised"
+	ChangeApproved  = "approved"
+	ChangeDiscarded = "discarded"
+)
+
+// ChangeHandler is told about every stored plan change, e.g. to sign it for provenance
+type ChangeHandler func(plan *Plan, change string)
+
+// Service stores plans in the global graph and revises them
+type Service struct {
+	graph          *graph.GlobalGraph
+	aiProvider     ai.AIProvider // nil disables AI-assisted revisions
+	logger         *logging.Logger
+	now            func() time.Time
+	changeHandlers []ChangeHandler
+}
+
+// NewService creates a plan service backed by the global graph
+func NewService(globalGraph *graph.GlobalGraph, aiProvider ai.AIProvider) *Service {
+	return &Service{
+		graph:      globalGraph,
+		aiProvider: aiProvider,
+		logger:     logging.GetLogger().ForComponent("plans"),
+		now:        time.Now,
+	}
+}
+
+// OnChange registers a handler called after a plan is proposed, revised, approved or discarded
+func (s *Service) OnChange(handler ChangeHandler) {
+	s.changeHandlers = append(s.changeHandlers, handler)
+}
+
+func (s *Service) notify(plan *Plan, change string) {
+	for _, handler := range s.changeHandlers {
+		handler(plan, change)
+	}
+}
+
+// Attach stores every plan agents propose on the event bus
+func (s *Service) Attach(bus *events.EventBus) {
+	bus.SubscribeToRoutingKey(ProposedSubject, func(event events.Event) error {
+		data, err := json.Marshal(event.Payload["plan"])
+		if err != nil {
+			return err
+		}
+		var plan Plan
+		if err := json.Unmarshal(data, &plan); err != nil {
+			return fmt.Errorf("invalid proposed plan from %s: %w", event.Source, err)
+		}
+		if plan.ProposedBy == "" {
+			plan.ProposedBy = event.Source
+		}
+		if _, err := s.Propose(plan); err != nil {
+			s.logger.Warn("⚠️ Failed to store plan proposed by %s: %v", event.Source, err)
+			return err
+		}
+		return nil
+	})
+}
+
+// Propose stores a new draft plan. Missing IDs are generated.
+func (s *Service) Propose(plan Plan) (*Plan, error) {
+	if len(plan.Steps) == 0 {
+		return nil, fmt.Errorf("plan has no steps")
+	}
+	now := s.now().UTC()
+	if plan.ID == "" {
+		plan.ID = NewID(now)
+	}
+	if existing, _ := s.graph.GetNode(nodeIDPrefix + plan.ID); existing != nil {
+		return nil, fmt.Errorf("plan %s already exists", plan.ID)
+	}
+	for i := range plan.Steps {
+		if plan.Steps[i].ID == "" {
+			plan.Steps[i].ID = fmt.Sprintf("step-%d", i+1)
+		}
+	}
+	plan.Status = StatusDraft
+	plan.Revision = 1
+	plan.Revisions = nil
+	plan.CreatedAt = now
+	plan.UpdatedAt = now
+
+	node, err := planToNode(&plan)
+	if err != nil {
+		return nil, err
+	}
+	s.graph.AddNode(node)
+	if plan.Application != "" {
+		if app, _ := s.graph.GetNode(plan.Application); app != nil {
+			if err := s.graph.AddEdge(node.ID, app.ID, graph.EdgeTypeReferences); err != nil {
+				s.logger.Warn("⚠️ Could not link plan %s to %s: %v", plan.ID, app.ID, err)
+			}
+		}
+	}
+
+	s.logger.Info("📋 Stored %d-step plan %s for conversation %s", len(plan.Steps), plan.ID, plan.ConversationID)
+	s.notify(&plan, ChangeProposed)
+	return &plan, nil
+}
+
+// NewID returns an ID for a plan proposed at t
+func NewID(t time.Time) string {
+	return fmt.Sprintf("plan-%d", t.UnixNano())
+}
+
+// Get returns a plan by ID
+func (s *Service) Get(id string) (*Plan, error) {
+	node, _ := s.graph.GetNode(nodeIDPrefix + id)
+	if node == nil || node.Kind != graph.KindPlan {
+		return nil, ErrPlanNotFound
+	}
+	return nodeToPlan(node)
+}
+
+// ListFilter narrows List results
+type ListFilter struct {
+	ConversationID string
+	Status         string
+}
+
+// List returns plans, most recently updated first
+func (s *Service) List(filter ListFilter) ([]*Plan, error) {
+	nodes, err := s.graph.Nodes()
+	if err != nil {
+		return nil, err
+	}
+
+	plans := []*Plan{}
+	for _, node := range nodes {
+		if node.Kind != graph.KindPlan {
+			continue
+		}
+		plan, err := nodeToPlan(node)
+		if err != nil {
+			s.logger.Warn("⚠️ Skipping unreadable plan %s: %v", node.ID, err)
+			continue
+		}
+		if filter.ConversationID != "" && plan.ConversationID != filter.ConversationID {
+			continue
+		}
+		if filter.Status != "" && plan.Status != filter.Status {
+			continue
+		}
+		plans = append(plans, plan)
+	}
+
+	sort.Slice(plans, func(i, j int) bool {
+		return plans[i].UpdatedAt.After(plans[j].UpdatedAt)
+	})
+	return plans, nil
+}
+
+// Latest returns the most recently updated draft plan of a conversation
+func (s *Service) Latest(conversationID string) (*Plan, error) {
+	if conversationID == "" {
+		return nil, ErrPlanNotFound
+	}
+	drafts, err := s.List(ListFilter{ConversationID: conversationID, Status: StatusDraft})
+	if err != nil {
+		return nil, err
+	}
+	if len(drafts) == 0 {
+		return nil, ErrPlanNotFound
+	}
+	return drafts[0], nil
+}
+
+// Revise applies edit operations to a draft plan and records the revision
+func (s *Service) Revise(id string, ops []Operation, author, instruction, summary string) (*Plan, error) {
+	if len(ops) == 0 {
+		return nil, fmt.Errorf("%w: no operations given", ErrInvalidOperation)
+	}
+	plan, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if plan.Status != StatusDraft {
+		return nil, fmt.Errorf("%w: plan %s is %s", ErrPlanNotEditable, id, plan.Status)
+	}
+
+	steps, err := plan.Apply(ops)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now().UTC()
+	plan.Steps = steps
+	plan.Revision++
+	plan.UpdatedAt = now
+	plan.Revisions = append(plan.Revisions, Revision{
+		Number:      plan.Revision,
+		Timestamp:   now,
+		Author:      author,
+		Instruction: instruction,
+		Summary:     summary,
+		Operations:  ops,
+		Steps:       steps,
+	})
+
+	if err := s.save(plan); err != nil {
+		return nil, err
+	}
+	s.logger.Info("✏️ Revised plan %s to revision %d (%d operations by %s)", id, plan.Revision, len(ops), author)
+	s.notify(plan, ChangeRevised)
+	return plan, nil
+}
+
+// SetStatus approves or discards a draft plan
+func (s *Service) SetStatus(id, status string) (*Plan, error) {
+	if status != StatusApproved && status != StatusDiscarded {
+		return nil, fmt.Errorf("status must be %s or %s", StatusApproved, StatusDiscarded)
+	}
+	plan, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if plan.Status != StatusDraft {
+		return nil, fmt.Errorf("%w: plan %s is already %s", ErrPlanNotEditable, id, plan.Status)
+	}
+	plan.Status = status
+	plan.UpdatedAt = s.now().UTC()
+	if err := s.save(plan); err != nil {
+		return nil, err
+	}
+	if status == StatusApproved {
+		s.notify(plan, ChangeApproved)
+	} else {
+		s.notify(plan, ChangeDiscarded)
+	}
+	return plan, nil
+}
+
+// Delete removes a plan
+func (s *Service) Delete(id string) error {
+	if _, err := s.Get(id); err != nil {
+		return err
+	}
+	return s.graph.DeleteNode(nodeIDPrefix + id)
+}
+
+// aiRevision is what the AI returns for a revision instruction
+type aiRevision struct {
+	Operations    []Operation `json:"operations"`
+	Summary       string      `json:"summary"`
+	Confidence    float64     `json:"confidence"`
+	Clarification string      `json:"clarification,omitempty"`
+}
+
+// ReviseWithAI asks the AI to turn a natural language instruction ("remove step 3",
+// "rename the database to orders-db") into edit operations and applies them
+func (s *Service) ReviseWithAI(ctx context.Context, id, instruction string) (*Plan, error) {
+	if s.aiProvider == nil {
+		return nil, ErrAIUnavailable
+	}
+	plan, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if plan.Status != StatusDraft {
+		return nil, fmt.Errorf("%w: plan %s is %s", ErrPlanNotEditable, id, plan.Status)
+	}
+
+	systemPrompt := `You edit execution plans. Turn the user's instruction into edit operations on the existing plan; never rewrite the plan from scratch.
+
+Operations (JSON objects):
+- {"op": "remove", "step": "<step id>"}
+- {"op": "rename", "from": "<old target name>", "to": "<new target name>"}
+- {"op": "update", "step": "<step id>", "action": "", "target": "", "description": ""} (only the fields that change)
+- {"op": "add", "after": "<step id, or 0 for first, or empty for last>", "action": "", "target": "", "description": ""}
+- {"op": "move", "step": "<step id>", "after": "<step id, or 0 for first>"}
+
+Refer to steps by their id in square brackets, not their number. When the user says "step 3" they mean the third line.
+
+Respond with JSON only:
+{"operations": [], "summary": "one sentence describing the change", "confidence": 0.0-1.0, "clarification": "question to ask if confidence < 0.7"}`
+
+	userPrompt := fmt.Sprintf("Current plan:\n%s\n\nInstruction: %s", plan.Describe(), instruction)
+
+	response, err := s.aiProvider.CallAI(ai.WithTask(ctx, ai.TaskPlanning), systemPrompt, userPrompt)
+	if err != nil {
+		return nil, fmt.Errorf("AI plan revision failed: %w", err)
+	}
+
+	cleaned := strings.TrimSpace(response)
+	cleaned = strings.TrimPrefix(cleaned, "```json")
+	cleaned = strings.TrimPrefix(cleaned, "```")
+	cleaned = strings.TrimSuffix(cleaned, "```")
+
+	var revision aiRevision
+	if err := json.Unmarshal([]byte(strings.TrimSpace(cleaned)), &revision); err != nil {
+		return nil, fmt.Errorf("failed to parse AI plan revision: %w", err)
+	}
+	if agentframework.NeedsClarification(ctx, revision.Confidence) || len(revision.Operations) == 0 {
+		clarification := revision.Clarification
+		if clarification == "" {
+			clarification = "Which step should I change, and how?"
+		}
+		return nil, fmt.Errorf("%w: %s", ErrInvalidOperation, clarification)
+	}
+
+	return s.Revise(id, revision.Operations, AuthorAI, instruction, revision.Summary)
+}
+
+func (s *Service) save(plan *Plan) error {
+	node, err := planToNode(plan)
+	if err != nil {
+		return err
+	}
+	if err := s.graph.UpdateNode(node); err != nil {
+		return fmt.Errorf("failed to update plan %s: %w", plan.ID, err)
+	}
+	return nil
+}
+
+func planToNode(plan *Plan) (*graph.Node, error) {
+	data, err := json.Marshal(plan)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode plan: %w", err)
+	}
+	var spec map[string]interface{}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to encode plan: %w", err)
+	}
+
+	return &graph.Node{
+		ID:   nodeIDPrefix + plan.ID,
+		Kind: graph.KindPlan,
+		Metadata: map[string]interface{}{
+			"name":            plan.ID,
+			"conversation_id": plan.ConversationID,
+			"status":          plan.Status,
+			"revision":        plan.Revision,
+			"updated_at":      plan.UpdatedAt.Format(time.RFC3339),
+		},
+		Spec: spec,
+	}, nil
+}
+
+// nodeToPlan decodes the plan from the node spec
+func nodeToPlan(node *graph.Node) (*Plan, error) {
+	data, err := json.Marshal(node.Spec)
+	if err != nil {
+		return nil, err
+	}
+	var plan Plan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
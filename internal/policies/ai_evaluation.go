@@ -7,13 +7,14 @@ import (
 	"strings"
 	"time"
 
+	"github.com/krzachariassen/ZTDP/internal/ai"
 	"github.com/krzachariassen/ZTDP/internal/graph"
 )
 
 // AI-specific evaluation logic - Infrastructure layer for AI operations
 
 // evaluateNodePolicyWithAI uses AI to evaluate node policies
-func (s *Service) evaluateNodePolicyWithAI(ctx context.Context, node *graph.Node, policies []*Policy) (*PolicyResult, error) {
+func (s *Service) evaluateNodePolicyWithAI(ctx context.Context, env string, node *graph.Node, policies []*Policy) (*PolicyResult, error) {
 	if s.aiProvider == nil {
 		return nil, fmt.Errorf("AI provider not available - ZTDP is AI-native only")
 	}
@@ -21,7 +22,7 @@ func (s *Service) evaluateNodePolicyWithAI(ctx context.Context, node *graph.Node
 	result := &PolicyResult{
 		NodeID:      node.ID,
 		NodeKind:    node.Kind,
-		Environment: s.env,
+		Environment: env,
 		Evaluations: make(map[string]*PolicyEvaluation),
 		EvaluatedAt: time.Now(),
 		EvaluatedBy: "ai-system",
@@ -34,7 +35,7 @@ func (s *Service) evaluateNodePolicyWithAI(ctx context.Context, node *graph.Node
 			continue // Skip policies that can't generate prompts
 		}
 
-		response, err := s.aiProvider.CallAI(ctx, prompt.System, prompt.User)
+		response, err := s.callPolicyAI(ctx, policy, prompt)
 		if err != nil {
 			continue // Skip policies with AI failures
 		}
@@ -46,12 +47,9 @@ func (s *Service) evaluateNodePolicyWithAI(ctx context.Context, node *graph.Node
 
 		result.Evaluations[policy.ID] = evaluation
 
-		// Determine overall status priority: blocked > warning > allowed
-		if evaluation.Status == PolicyStatusBlocked {
-			overallStatus = PolicyStatusBlocked
-		} else if evaluation.Status == PolicyStatusWarning && overallStatus != PolicyStatusBlocked {
-			overallStatus = PolicyStatusWarning
-		}
+		// Determine overall status priority: blocked > pending approval > warning > allowed,
+		// after the policy's enforcement in the environment softened its verdict
+		overallStatus = applyEnforcement(evaluation, policy, env, overallStatus)
 
 		// For single policy evaluations, populate direct result fields for test compatibility
 		if len(policies) == 1 {
@@ -67,7 +65,7 @@ func (s *Service) evaluateNodePolicyWithAI(ctx context.Context, node *graph.Node
 }
 
 // evaluateEdgePolicyWithAI uses AI to evaluate edge policies
-func (s *Service) evaluateEdgePolicyWithAI(ctx context.Context, edge *graph.Edge, policies []*Policy) (*PolicyResult, error) {
+func (s *Service) evaluateEdgePolicyWithAI(ctx context.Context, env string, edge *graph.Edge, policies []*Policy) (*PolicyResult, error) {
 	if s.aiProvider == nil {
 		return nil, fmt.Errorf("AI provider not available - ZTDP is AI-native only")
 	}
@@ -75,7 +73,7 @@ func (s *Service) evaluateEdgePolicyWithAI(ctx context.Context, edge *graph.Edge
 	result := &PolicyResult{
 		EdgeTo:       edge.To,
 		Relationship: edge.Type,
-		Environment:  s.env,
+		Environment:  env,
 		Evaluations:  make(map[string]*PolicyEvaluation),
 		EvaluatedAt:  time.Now(),
 		EvaluatedBy:  "ai-system",
@@ -88,7 +86,7 @@ func (s *Service) evaluateEdgePolicyWithAI(ctx context.Context, edge *graph.Edge
 			continue // Skip policies that can't generate prompts
 		}
 
-		response, err := s.aiProvider.CallAI(ctx, prompt.System, prompt.User)
+		response, err := s.callPolicyAI(ctx, policy, prompt)
 		if err != nil {
 			continue // Skip policies with AI failures
 		}
@@ -99,11 +97,7 @@ func (s *Service) evaluateEdgePolicyWithAI(ctx context.Context, edge *graph.Edge
 		}
 
 		result.Evaluations[policy.ID] = evaluation
-		if evaluation.Status == PolicyStatusBlocked {
-			overallStatus = PolicyStatusBlocked
-		} else if evaluation.Status == PolicyStatusWarning && overallStatus != PolicyStatusBlocked {
-			overallStatus = PolicyStatusWarning
-		}
+		overallStatus = applyEnforcement(evaluation, policy, env, overallStatus)
 
 		// For single policy evaluations, populate direct result fields for test compatibility
 		if len(policies) == 1 {
@@ -119,14 +113,14 @@ func (s *Service) evaluateEdgePolicyWithAI(ctx context.Context, edge *graph.Edge
 }
 
 // evaluateGraphPolicyWithAI uses AI to evaluate graph-level policies
-func (s *Service) evaluateGraphPolicyWithAI(ctx context.Context, g *graph.Graph, policies []*Policy) (*PolicyResult, error) {
+func (s *Service) evaluateGraphPolicyWithAI(ctx context.Context, env string, g *graph.Graph, policies []*Policy) (*PolicyResult, error) {
 	if s.aiProvider == nil {
 		return nil, fmt.Errorf("AI provider not available - ZTDP is AI-native only")
 	}
 
 	result := &PolicyResult{
 		GraphScope:  true,
-		Environment: s.env,
+		Environment: env,
 		Evaluations: make(map[string]*PolicyEvaluation),
 		EvaluatedAt: time.Now(),
 		EvaluatedBy: "ai-system",
@@ -139,7 +133,7 @@ func (s *Service) evaluateGraphPolicyWithAI(ctx context.Context, g *graph.Graph,
 			continue // Skip policies that can't generate prompts
 		}
 
-		response, err := s.aiProvider.CallAI(ctx, prompt.System, prompt.User)
+		response, err := s.callPolicyAI(ctx, policy, prompt)
 		if err != nil {
 			continue // Skip policies with AI failures
 		}
@@ -150,11 +144,7 @@ func (s *Service) evaluateGraphPolicyWithAI(ctx context.Context, g *graph.Graph,
 		}
 
 		result.Evaluations[policy.ID] = evaluation
-		if evaluation.Status == PolicyStatusBlocked {
-			overallStatus = PolicyStatusBlocked
-		} else if evaluation.Status == PolicyStatusWarning && overallStatus != PolicyStatusBlocked {
-			overallStatus = PolicyStatusWarning
-		}
+		overallStatus = applyEnforcement(evaluation, policy, env, overallStatus)
 
 		// For single policy evaluations, populate direct result fields for test compatibility
 		if len(policies) == 1 {
@@ -169,6 +159,47 @@ func (s *Service) evaluateGraphPolicyWithAI(ctx context.Context, g *graph.Graph,
 	return result, nil
 }
 
+// partialContextInstructions is added to the system prompt when the context is evaluated in parts
+const partialContextInstructions = `
+
+The context is too large for one request and is given in parts; you see one part. Evaluate the
+policy against this part only. Return "blocked" only for a violation you can see in this part, and
+"not_applicable" if this part holds nothing the policy is about.`
+
+// combineEvaluationsPrompt asks for one verdict from the verdicts on each part of the context
+const combineEvaluationsPrompt = `You combine policy evaluations that were each made on one part of a context too large to evaluate at once. They are separated by "---".
+
+Rules:
+- If any evaluation is "blocked", the result is "blocked", with the reasons of the blocking evaluations.
+- Otherwise, if any evaluation is "allowed", the result is "allowed".
+- The result is "not_applicable" only if every evaluation is.
+- Use the lowest confidence among the evaluations that decided the result, and merge their recommendations.
+- Respond ONLY in valid JSON with the fields policy_id, status, reason, confidence and recommendations.`
+
+// callPolicyAI sends an evaluation prompt. A prompt too large for one call, e.g. for a node with
+// a large spec, has its context split into parts that are evaluated with the policy separately
+// and their verdicts combined, instead of failing with ai.ErrPromptTooLarge.
+func (s *Service) callPolicyAI(ctx context.Context, policy *Policy, prompt *AIPrompt) (string, error) {
+	tokenizer := ai.DefaultTokenizer
+	budget := ai.PromptBudget(ctx, s.aiProvider)
+	if tokenizer.Count(prompt.System)+tokenizer.Count(prompt.User) <= budget {
+		return s.aiProvider.CallAI(ctx, prompt.System, prompt.User)
+	}
+
+	header := policyPromptHeader(policy)
+	mapPrompt := prompt.System + partialContextInstructions
+	partLabel := "\n\nCONTEXT PART %d OF %d:\n"
+	room := budget - tokenizer.Count(mapPrompt) - tokenizer.Count(header) - tokenizer.Count(fmt.Sprintf(partLabel, 999, 999))
+	if room <= 0 {
+		return "", fmt.Errorf("%w: policy %s leaves no room for its context", ai.ErrPromptTooLarge, policy.ID)
+	}
+	parts := ai.Chunk(tokenizer, strings.TrimPrefix(prompt.User, header), room)
+	for i, part := range parts {
+		parts[i] = header + fmt.Sprintf(partLabel, i+1, len(parts)) + part
+	}
+	return ai.MapReduce(ctx, s.aiProvider, tokenizer, mapPrompt, combineEvaluationsPrompt, parts, budget-tokenizer.Count(combineEvaluationsPrompt))
+}
+
 // ParseAIResponse parses AI response into PolicyEvaluation
 func (s *Service) ParseAIResponse(response string) (*PolicyEvaluation, error) {
 	if response == "" {
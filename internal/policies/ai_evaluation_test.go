@@ -0,0 +1,65 @@
+package policies
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/ai"
+	"github.com/krzachariassen/ZTDP/internal/ai/aitest"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// budgetedProvider is a scripted provider that reports a small prompt budget
+type budgetedProvider struct {
+	*aitest.Provider
+	budget int
+}
+
+func (p *budgetedProvider) PromptBudget(ctx context.Context) int {
+	return p.budget
+}
+
+func TestEvaluationChunksContextTooLargeForOneCall(t *testing.T) {
+	provider := &budgetedProvider{
+		Provider: aitest.ByPrompt(map[string]string{
+			"you see one part":               `{"status": "allowed", "reason": "nothing wrong in this part", "confidence": 0.9}`,
+			"You combine policy evaluations": `{"status": "blocked", "reason": "replicas exceed the limit", "confidence": 0.8}`,
+		}),
+		budget: 1200,
+	}
+	svc := NewServiceWithAIProvider(nil, nil, provider, NewMockPolicyStore(), "", nil)
+	policy := createApplicationServiceLimitPolicy()
+	spec := map[string]interface{}{}
+	for i := 0; i < 300; i++ {
+		spec[fmt.Sprintf("setting_%03d", i)] = "a value long enough to take several tokens"
+	}
+	node := &graph.Node{ID: "checkout", Kind: "application", Metadata: map[string]interface{}{"name": "checkout"}, Spec: spec}
+
+	result, err := svc.evaluateNodePolicyWithAI(context.Background(), "prod", node, []*Policy{policy})
+	require.NoError(t, err)
+	require.NotNil(t, result.Evaluations[policy.ID], "the combined verdict is used")
+	assert.Equal(t, PolicyStatusBlocked, result.OverallStatus)
+	assert.Equal(t, "replicas exceed the limit", result.Reason)
+
+	calls := provider.Calls()
+	require.Greater(t, len(calls), 3, "expected several parts and a combining call")
+	for _, call := range calls[:len(calls)-1] {
+		assert.Contains(t, call, "- ID: "+policy.ID, "every part carries the policy")
+		assert.LessOrEqual(t, ai.DefaultTokenizer.Count(call), provider.budget)
+	}
+	assert.True(t, strings.Contains(calls[0], "CONTEXT PART 1 OF"))
+}
+
+func TestEvaluationSendsPromptsThatFitInOneCall(t *testing.T) {
+	provider := &budgetedProvider{Provider: aitest.Respond(`{"status": "allowed", "reason": "ok", "confidence": 0.9}`), budget: 8000}
+	svc := NewServiceWithAIProvider(nil, nil, provider, NewMockPolicyStore(), "", nil)
+
+	result, err := svc.evaluateNodePolicyWithAI(context.Background(), "prod", createTestApplicationNode(), []*Policy{createApplicationServiceLimitPolicy()})
+	require.NoError(t, err)
+	assert.Equal(t, PolicyStatusAllowed, result.OverallStatus)
+	assert.Len(t, provider.Calls(), 1)
+}
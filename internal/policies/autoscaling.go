@@ -0,0 +1,71 @@
+package policies
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/krzachariassen/ZTDP/internal/contracts"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	servicecore "github.com/krzachariassen/ZTDP/internal/service"
+)
+
+// AutoscalingFinding is the autoscaling policy's view of one service in the environment
+type AutoscalingFinding struct {
+	Service     string                     `json:"service"`
+	Replicas    int                        `json:"replicas"`
+	Autoscaling *contracts.AutoscalingSpec `json:"autoscaling,omitempty"`
+	Violation   string                     `json:"violation,omitempty"`
+}
+
+// AutoscalingDecision is the result of checking an application's scaling against an environment
+type AutoscalingDecision struct {
+	Decision string               `json:"decision"` // allowed | blocked
+	Reasons  []string             `json:"reasons,omitempty"`
+	Services []AutoscalingFinding `json:"services"`
+}
+
+// EvaluateAutoscaling checks the replicas and autoscaling each of the application's services
+// would run with in the environment against the environment's constraints
+func EvaluateAutoscaling(globalGraph *graph.GlobalGraph, appName, environment string) (*AutoscalingDecision, error) {
+	edges, err := globalGraph.Edges()
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := globalGraph.Nodes()
+	if err != nil {
+		return nil, err
+	}
+
+	decision := &AutoscalingDecision{Decision: "allowed", Services: []AutoscalingFinding{}}
+	for _, owned := range edges[appName] {
+		if node, ok := nodes[owned.To]; owned.Type != graph.EdgeTypeOwns || !ok || node.Kind != graph.KindService {
+			continue
+		}
+		finding := AutoscalingFinding{Service: owned.To}
+		spec, err := servicecore.ResolveForEnvironment(globalGraph, owned.To, environment, nil)
+		if err != nil {
+			finding.Violation = err.Error()
+			decision.Reasons = append(decision.Reasons, err.Error())
+		} else {
+			finding.Replicas, finding.Autoscaling = spec.Replicas, spec.Autoscaling
+		}
+		decision.Services = append(decision.Services, finding)
+	}
+	sort.Slice(decision.Services, func(i, j int) bool { return decision.Services[i].Service < decision.Services[j].Service })
+	sort.Strings(decision.Reasons)
+	if len(decision.Reasons) > 0 {
+		decision.Decision = "blocked"
+	}
+	return decision, nil
+}
+
+// autoscalingReasoning summarizes an allowed decision for the response
+func autoscalingReasoning(decision *AutoscalingDecision, environment string) string {
+	autoscaled := 0
+	for _, finding := range decision.Services {
+		if finding.Autoscaling != nil {
+			autoscaled++
+		}
+	}
+	return fmt.Sprintf("%d services within %s scaling constraints, %d autoscaled", len(decision.Services), environment, autoscaled)
+}
@@ -0,0 +1,61 @@
+package policies
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/contracts"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+func TestPolicyAgentAutoscalingPolicy(t *testing.T) {
+	g := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	for _, contract := range []contracts.Contract{
+		contracts.ApplicationContract{Metadata: contracts.Metadata{Name: "checkout", Owner: "team-a"}},
+		contracts.EnvironmentContract{Metadata: contracts.Metadata{Name: "dev", Owner: "platform"}},
+		contracts.EnvironmentContract{
+			Metadata: contracts.Metadata{Name: "prod", Owner: "platform"},
+			Spec:     contracts.EnvironmentSpec{Constraints: contracts.EnvironmentConstraints{MaxReplicas: 6, RequireAutoscaling: true}},
+		},
+		contracts.ServiceContract{
+			Metadata: contracts.Metadata{Name: "checkout-api", Owner: "team-a"},
+			Spec: contracts.ServiceSpec{Application: "checkout", Port: 8080, Replicas: 1,
+				Autoscaling: &contracts.AutoscalingSpec{MinReplicas: 2, MaxReplicas: 10, TargetCPU: 70}},
+		},
+	} {
+		node, err := graph.ResolveContract(contract)
+		if err != nil {
+			t.Fatalf("Failed to resolve %s: %v", contract.ID(), err)
+		}
+		g.AddNode(node)
+	}
+	if err := g.AddEdge("checkout", "checkout-api", graph.EdgeTypeOwns); err != nil {
+		t.Fatalf("Failed to add edge: %v", err)
+	}
+	agent := &FrameworkPolicyAgent{
+		service: NewService(nil, g, "", nil),
+		logger:  logging.GetLogger().ForComponent("policy-agent"),
+	}
+
+	request := &events.Event{ID: "evt-1", Subject: "policy.autoscaling", Payload: map[string]interface{}{
+		"intent":      "check autoscaling",
+		"application": "checkout",
+		"environment": "prod",
+	}}
+	response, err := agent.handleEvent(context.Background(), request)
+	if err != nil {
+		t.Fatalf("handleEvent failed: %v", err)
+	}
+	if reasoning, _ := response.Payload["reasoning"].(string); response.Payload["decision"] != "blocked" || !strings.Contains(reasoning, "max_replicas 10 above maximum 6") {
+		t.Errorf("Expected scaling beyond prod's maximum to be blocked, got %v", response.Payload)
+	}
+
+	request.Payload["environment"] = "dev"
+	response, _ = agent.handleEvent(context.Background(), request)
+	if response.Payload["decision"] != "allowed" {
+		t.Errorf("Expected dev to be unconstrained, got %v", response.Payload)
+	}
+}
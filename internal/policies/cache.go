@@ -0,0 +1,282 @@
+package policies
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/graphwatch"
+)
+
+// DefaultCacheTTL bounds how long a cached decision is served. Invalidation only sees changes
+// saved through this instance, so the TTL also limits staleness from other instances.
+const DefaultCacheTTL = 10 * time.Minute
+
+// reads records the graph nodes and edges an evaluation looked at
+type reads struct {
+	mu    sync.Mutex
+	nodes map[string]bool
+	edges map[string]bool // target:type, as evaluated edges carry no source
+	graph bool            // the evaluation read the whole graph
+}
+
+type readsKey struct{}
+
+// trackReads returns a context whose evaluation records what it reads into the returned set
+func trackReads(ctx context.Context) (context.Context, *reads) {
+	r := &reads{nodes: map[string]bool{}, edges: map[string]bool{}}
+	return context.WithValue(ctx, readsKey{}, r), r
+}
+
+// readNode records that the evaluation in ctx depends on a node
+func readNode(ctx context.Context, id string) {
+	if r, ok := ctx.Value(readsKey{}).(*reads); ok && id != "" {
+		r.mu.Lock()
+		r.nodes[id] = true
+		r.mu.Unlock()
+	}
+}
+
+// readEdge records that the evaluation in ctx depends on edges of a type into a node
+func readEdge(ctx context.Context, to, edgeType string) {
+	if r, ok := ctx.Value(readsKey{}).(*reads); ok {
+		r.mu.Lock()
+		r.edges[to+":"+edgeType] = true
+		r.mu.Unlock()
+	}
+}
+
+// readGraph records that the evaluation in ctx depends on the whole graph
+func readGraph(ctx context.Context) {
+	if r, ok := ctx.Value(readsKey{}).(*reads); ok {
+		r.mu.Lock()
+		r.graph = true
+		r.mu.Unlock()
+	}
+}
+
+// CacheStats counts cache activity since the cache was created
+type CacheStats struct {
+	Entries     int `json:"entries"`
+	Hits        int `json:"hits"`
+	Misses      int `json:"misses"`
+	Invalidated int `json:"invalidated"` // entries dropped because something they read changed
+	Expired     int `json:"expired"`
+}
+
+type cacheEntry struct {
+	result  *PolicyResult
+	reads   *reads
+	expires time.Time
+}
+
+// DecisionCache keeps policy results until a node or edge their evaluation read changes. Feed
+// it graph changes with Invalidate, e.g. by subscribing it to a graphwatch.Backend.
+type DecisionCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+	byNode  map[string]map[string]bool // node ID -> keys of entries that read it
+	byEdge  map[string]map[string]bool // target:type -> keys
+	byGraph map[string]bool            // keys of entries that read the whole graph
+	stats   CacheStats
+
+	// generation is bumped by every invalidation; a result evaluated across one may have read
+	// the graph before the change and is not cached
+	generation uint64
+}
+
+// NewDecisionCache creates a cache serving decisions for at most ttl (DefaultCacheTTL when 0)
+func NewDecisionCache(ttl time.Duration) *DecisionCache {
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	return &DecisionCache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: map[string]*cacheEntry{},
+		byNode:  map[string]map[string]bool{},
+		byEdge:  map[string]map[string]bool{},
+		byGraph: map[string]bool{},
+	}
+}
+
+var (
+	decisionCacheMu sync.RWMutex
+	decisionCache   *DecisionCache
+)
+
+// SetDecisionCache sets the cache policy evaluations use (called from main.go); nil disables caching
+func SetDecisionCache(cache *DecisionCache) {
+	decisionCacheMu.Lock()
+	defer decisionCacheMu.Unlock()
+	decisionCache = cache
+}
+
+// GetDecisionCache returns the cache policy evaluations use, or nil when caching is disabled
+func GetDecisionCache() *DecisionCache {
+	decisionCacheMu.RLock()
+	defer decisionCacheMu.RUnlock()
+	return decisionCache
+}
+
+// Invalidate drops the entries that read a changed node or edge, or the whole graph, and
+// returns how many were dropped
+func (c *DecisionCache) Invalidate(changes []graphwatch.Change) int {
+	if len(changes) == 0 {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	stale := map[string]bool{}
+	for key := range c.byGraph {
+		stale[key] = true
+	}
+	for _, change := range changes {
+		switch change.Kind {
+		case graphwatch.KindNode:
+			for key := range c.byNode[change.ID] {
+				stale[key] = true
+			}
+		case graphwatch.KindEdge:
+			for key := range c.byEdge[change.To+":"+change.Type] {
+				stale[key] = true
+			}
+		}
+	}
+	for key := range stale {
+		c.remove(key)
+	}
+	c.stats.Invalidated += len(stale)
+	return len(stale)
+}
+
+// Stats returns the cache counters
+func (c *DecisionCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Entries = len(c.entries)
+	return stats
+}
+
+func (c *DecisionCache) get(key string) (*PolicyResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if ok && c.now().After(entry.expires) {
+		c.remove(key)
+		c.stats.Expired++
+		ok = false
+	}
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+	c.stats.Hits++
+	return entry.result, true
+}
+
+// currentGeneration returns the generation to pass to put for an evaluation starting now
+func (c *DecisionCache) currentGeneration() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+// put caches a result unless the graph changed since its evaluation started at generation
+func (c *DecisionCache) put(key string, result *PolicyResult, r *reads, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation != generation {
+		return
+	}
+	c.remove(key)
+	c.entries[key] = &cacheEntry{result: result, reads: r, expires: c.now().Add(c.ttl)}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id := range r.nodes {
+		index(c.byNode, id, key)
+	}
+	for edge := range r.edges {
+		index(c.byEdge, edge, key)
+	}
+	if r.graph {
+		c.byGraph[key] = true
+	}
+}
+
+// remove drops an entry and its dependency index entries; c.mu must be held
+func (c *DecisionCache) remove(key string) {
+	entry, ok := c.entries[key]
+	if !ok {
+		return
+	}
+	delete(c.entries, key)
+	for id := range entry.reads.nodes {
+		unindex(c.byNode, id, key)
+	}
+	for edge := range entry.reads.edges {
+		unindex(c.byEdge, edge, key)
+	}
+	delete(c.byGraph, key)
+}
+
+func index(deps map[string]map[string]bool, dep, key string) {
+	if deps[dep] == nil {
+		deps[dep] = map[string]bool{}
+	}
+	deps[dep][key] = true
+}
+
+func unindex(deps map[string]map[string]bool, dep, key string) {
+	delete(deps[dep], key)
+	if len(deps[dep]) == 0 {
+		delete(deps, dep)
+	}
+}
+
+// cacheKey identifies an evaluation by scope, environment and the content of its subject and
+// policies, so a changed subject or policy definition never hits an older result
+func cacheKey(scope PolicyScope, env string, subject interface{}, policies []*Policy) string {
+	data, _ := json.Marshal(struct {
+		Subject  interface{} `json:"subject"`
+		Policies []*Policy   `json:"policies"`
+	}{subject, policies})
+	sum := sha256.Sum256(data)
+	return string(scope) + "|" + env + "|" + hex.EncodeToString(sum[:])
+}
+
+// cached serves an evaluation from the decision cache, or runs it while tracking what it reads
+// and caches the result when every policy was evaluated. Either way each policy's decision is
+// written to the decision log.
+func (s *Service) cached(ctx context.Context, scope PolicyScope, env string, subject interface{}, policies []*Policy, evaluate func(context.Context) (*PolicyResult, error)) (*PolicyResult, error) {
+	start := time.Now()
+	env = s.environment(env)
+	cache := GetDecisionCache()
+	if cache == nil {
+		result, err := evaluate(ctx)
+		logDecisions(ctx, scope, env, subject, policies, result, err, false, time.Since(start))
+		return result, err
+	}
+	key := cacheKey(scope, env, subject, policies)
+	if result, ok := cache.get(key); ok {
+		logDecisions(ctx, scope, env, subject, policies, result, nil, true, time.Since(start))
+		return result, nil
+	}
+	generation := cache.currentGeneration()
+	tracked, r := trackReads(ctx)
+	result, err := evaluate(tracked)
+	// Policies the AI failed to evaluate are skipped; such partial results are not kept
+	if err == nil && result != nil && len(result.Evaluations) == len(policies) {
+		cache.put(key, result, r, generation)
+	}
+	logDecisions(ctx, scope, env, subject, policies, result, err, false, time.Since(start))
+	return result, err
+}
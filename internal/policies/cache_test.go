@@ -0,0 +1,155 @@
+package policies
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/ai"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/graphwatch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingProvider struct {
+	calls  int
+	during func() // runs inside each call, while the evaluation is in flight
+}
+
+func (p *countingProvider) CallAI(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	p.calls++
+	if p.during != nil {
+		p.during()
+	}
+	return `{"status": "allowed", "reason": "compliant", "confidence": 0.9}`, nil
+}
+
+func (p *countingProvider) GetProviderInfo() *ai.ProviderInfo {
+	return &ai.ProviderInfo{Name: "counting"}
+}
+
+func (p *countingProvider) Close() error { return nil }
+
+func newCachingTestService(t *testing.T) (*Service, *countingProvider, *DecisionCache) {
+	t.Helper()
+	provider := &countingProvider{}
+	cache := NewDecisionCache(time.Minute)
+	SetDecisionCache(cache)
+	t.Cleanup(func() { SetDecisionCache(nil) })
+	return NewServiceWithAIProvider(nil, nil, provider, NewMockPolicyStore(), "test-env", nil), provider, cache
+}
+
+func TestDecisionCache_InvalidatesOnlyEntriesThatReadTheChange(t *testing.T) {
+	svc, provider, cache := newCachingTestService(t)
+	ctx := context.Background()
+	app, db := createTestApplicationNode(), createTestDatabaseNode()
+	appPolicy, dbPolicy := createApplicationServiceLimitPolicy(), createDatabaseBackupPolicy()
+
+	_, err := svc.EvaluateNodePolicy(ctx, "prod", app, appPolicy)
+	require.NoError(t, err)
+	_, err = svc.EvaluateNodePolicy(ctx, "prod", db, dbPolicy)
+	require.NoError(t, err)
+	result, err := svc.EvaluateNodePolicy(ctx, "prod", app, appPolicy)
+	require.NoError(t, err)
+	assert.Equal(t, PolicyStatusAllowed, result.Status)
+	assert.Equal(t, 2, provider.calls, "the repeated evaluation is served from the cache")
+
+	assert.Equal(t, 1, cache.Invalidate([]graphwatch.Change{{Op: graphwatch.OpUpdated, Kind: graphwatch.KindNode, ID: app.ID}}))
+	_, err = svc.EvaluateNodePolicy(ctx, "prod", app, appPolicy)
+	require.NoError(t, err)
+	_, err = svc.EvaluateNodePolicy(ctx, "prod", db, dbPolicy)
+	require.NoError(t, err)
+	assert.Equal(t, 3, provider.calls, "only the application's decision was re-evaluated")
+
+	// Changing the policy definition invalidates every decision that applied it
+	assert.Equal(t, 1, cache.Invalidate([]graphwatch.Change{{Op: graphwatch.OpUpdated, Kind: graphwatch.KindNode, ID: dbPolicy.ID}}))
+
+	// A different subject or environment is a different decision
+	_, err = svc.EvaluateNodePolicy(ctx, "staging", app, appPolicy)
+	require.NoError(t, err)
+	assert.Equal(t, 4, provider.calls)
+
+	stats := cache.Stats()
+	assert.Equal(t, 2, stats.Entries)
+	assert.Equal(t, 2, stats.Hits)
+	assert.Equal(t, 2, stats.Invalidated)
+}
+
+func TestDecisionCache_EdgeAndGraphDependencies(t *testing.T) {
+	svc, provider, cache := newCachingTestService(t)
+	ctx := context.Background()
+	edge := &graph.Edge{To: "prod", Type: graph.EdgeTypeDeploy}
+	policy := createNoDirectProdDeploymentPolicy()
+	g := graph.NewGraph()
+	graphPolicy := createMaxAppsPerCustomerPolicy()
+
+	_, err := svc.EvaluateEdgePolicy(ctx, "prod", edge, policy)
+	require.NoError(t, err)
+	_, err = svc.EvaluateGraphPolicy(ctx, "prod", g, graphPolicy)
+	require.NoError(t, err)
+	assert.Equal(t, 2, provider.calls)
+
+	// Any change can affect a graph-wide decision; the deployment edge decision only depends on deploys into prod
+	assert.Equal(t, 1, cache.Invalidate([]graphwatch.Change{{Op: graphwatch.OpAdded, Kind: graphwatch.KindEdge, ID: "checkout", To: "dev", Type: graph.EdgeTypeDeploy}}))
+	assert.Equal(t, 1, cache.Invalidate([]graphwatch.Change{{Op: graphwatch.OpAdded, Kind: graphwatch.KindEdge, ID: "checkout", To: "prod", Type: graph.EdgeTypeDeploy}}))
+	assert.Equal(t, 0, cache.Stats().Entries)
+}
+
+func TestDecisionCache_SubscribedToGraphChanges(t *testing.T) {
+	svc, provider, cache := newCachingTestService(t)
+	ctx := context.Background()
+	watch := graphwatch.NewBackend(graph.NewMemoryGraph())
+	watch.Subscribe(func(save graphwatch.Save) { cache.Invalidate(save.Changes) })
+	gg := graph.NewGlobalGraph(watch)
+	app := createTestApplicationNode()
+	require.NoError(t, gg.AddNode(app))
+	require.NoError(t, gg.AddNode(createTestDatabaseNode()))
+	policy := createApplicationServiceLimitPolicy()
+
+	_, err := svc.EvaluateNodePolicy(ctx, "prod", app, policy)
+	require.NoError(t, err)
+	require.NoError(t, gg.UpdateNode(&graph.Node{ID: "test-db", Kind: graph.KindResource, Metadata: map[string]interface{}{"name": "renamed"}}))
+	_, err = svc.EvaluateNodePolicy(ctx, "prod", app, policy)
+	require.NoError(t, err)
+	assert.Equal(t, 1, provider.calls, "unrelated changes keep the decision")
+
+	require.NoError(t, gg.DeleteNode(app.ID))
+	assert.Equal(t, 0, cache.Stats().Entries)
+}
+
+func TestDecisionCache_Expires(t *testing.T) {
+	svc, provider, cache := newCachingTestService(t)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	app, policy := createTestApplicationNode(), createApplicationServiceLimitPolicy()
+
+	_, err := svc.EvaluateNodePolicy(context.Background(), "prod", app, policy)
+	require.NoError(t, err)
+	now = now.Add(2 * time.Minute)
+	_, err = svc.EvaluateNodePolicy(context.Background(), "prod", app, policy)
+	require.NoError(t, err)
+	assert.Equal(t, 2, provider.calls)
+	assert.Equal(t, 1, cache.Stats().Expired)
+}
+
+func TestDecisionCache_SkipsResultsEvaluatedAcrossAChange(t *testing.T) {
+	svc, provider, cache := newCachingTestService(t)
+	ctx := context.Background()
+	app, policy := createTestApplicationNode(), createApplicationServiceLimitPolicy()
+
+	// The application changes while its decision is evaluated; the result may predate the change
+	provider.during = func() {
+		cache.Invalidate([]graphwatch.Change{{Op: graphwatch.OpUpdated, Kind: graphwatch.KindNode, ID: app.ID}})
+	}
+	_, err := svc.EvaluateNodePolicy(ctx, "prod", app, policy)
+	require.NoError(t, err)
+	assert.Equal(t, 0, cache.Stats().Entries)
+
+	provider.during = nil
+	_, err = svc.EvaluateNodePolicy(ctx, "prod", app, policy)
+	require.NoError(t, err)
+	_, err = svc.EvaluateNodePolicy(ctx, "prod", app, policy)
+	require.NoError(t, err)
+	assert.Equal(t, 2, provider.calls, "results evaluated without a change in between are cached")
+}
@@ -0,0 +1,355 @@
+package policies
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// Evaluators of a policy decision. Every policy is evaluated by the AI today; decisions
+// forwarded to an OPA evaluator would be logged as EvaluatorOPA.
+const (
+	EvaluatorAI  = "ai"
+	EvaluatorOPA = "opa"
+)
+
+// DecisionLog is one policy decision in the OPA decision log format, so collectors and tools
+// reading OPA decision logs can ingest it. The input is never logged: it is erased and replaced
+// by its digest, which compliance teams can recompute from the graph to verify a decision.
+type DecisionLog struct {
+	DecisionID  string                `json:"decision_id"`
+	Path        string                `json:"path"` // ztdp/<scope>/<policy id>
+	Labels      map[string]string     `json:"labels"`
+	Bundles     map[string]BundleInfo `json:"bundles"`
+	InputDigest string                `json:"input_digest"` // hex sha256 of the environment and evaluated node, edge or graph
+	Erased      []string              `json:"erased"`
+	Result      *DecisionResult       `json:"result,omitempty"`
+	Error       *DecisionError        `json:"error,omitempty"`
+	TraceID     string                `json:"trace_id,omitempty"` // correlation ID of the request that asked for the decision
+	Timestamp   time.Time             `json:"timestamp"`
+	Metrics     map[string]int64      `json:"metrics"`
+}
+
+// BundleInfo carries the revision of the policy a decision was made by
+type BundleInfo struct {
+	Revision string `json:"revision"`
+}
+
+// DecisionResult is what the evaluator decided
+type DecisionResult struct {
+	Decision   PolicyStatus `json:"decision"`
+	Confidence float64      `json:"confidence"`
+	Reason     string       `json:"reason"`
+}
+
+// DecisionError explains why a policy was not decided
+type DecisionError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Evaluator returns who made the decision
+func (d DecisionLog) Evaluator() string {
+	return d.Labels["evaluator"]
+}
+
+// DecisionFilter narrows a query; empty fields match every decision
+type DecisionFilter struct {
+	Since     time.Time
+	Evaluator string
+	Decision  PolicyStatus
+	PolicyID  string
+}
+
+func (f DecisionFilter) matches(d DecisionLog) bool {
+	return (f.Since.IsZero() || !d.Timestamp.Before(f.Since)) &&
+		(f.Evaluator == "" || d.Evaluator() == f.Evaluator) &&
+		(f.Decision == "" || (d.Result != nil && d.Result.Decision == f.Decision)) &&
+		(f.PolicyID == "" || d.Labels["policy_id"] == f.PolicyID)
+}
+
+// DecisionSink receives batches of decisions
+type DecisionSink interface {
+	Send(ctx context.Context, batch []DecisionLog) error
+}
+
+// DecisionLogger keeps the most recent decisions in memory and ships every decision to its
+// sinks in batches
+type DecisionLogger struct {
+	instance  string
+	capacity  int
+	batchSize int
+	sinks     []DecisionSink
+	logger    *logging.Logger
+	now       func() time.Time
+
+	mu      sync.Mutex
+	recent  []DecisionLog // oldest first
+	pending []DecisionLog // not yet shipped
+	flushMu sync.Mutex
+}
+
+// NewDecisionLogger creates a logger labelling decisions with the instance that made them. It
+// keeps capacity decisions in memory and ships a batch once batchSize decisions are pending.
+func NewDecisionLogger(instance string, capacity, batchSize int, sinks ...DecisionSink) *DecisionLogger {
+	if batchSize <= 0 {
+		batchSize = 1
+	}
+	return &DecisionLogger{
+		instance:  instance,
+		capacity:  capacity,
+		batchSize: batchSize,
+		sinks:     sinks,
+		logger:    logging.GetLogger().ForComponent("decision-log"),
+		now:       time.Now,
+	}
+}
+
+var (
+	decisionLoggerMu sync.RWMutex
+	decisionLogger   *DecisionLogger
+)
+
+// SetDecisionLogger sets the logger policy decisions are written to (called from main.go); nil
+// disables decision logging
+func SetDecisionLogger(logger *DecisionLogger) {
+	decisionLoggerMu.Lock()
+	defer decisionLoggerMu.Unlock()
+	decisionLogger = logger
+}
+
+// GetDecisionLogger returns the logger policy decisions are written to, or nil when disabled
+func GetDecisionLogger() *DecisionLogger {
+	decisionLoggerMu.RLock()
+	defer decisionLoggerMu.RUnlock()
+	return decisionLogger
+}
+
+// Log stores decisions and ships them once a batch is full
+func (l *DecisionLogger) Log(decisions ...DecisionLog) {
+	l.mu.Lock()
+	l.recent = append(l.recent, decisions...)
+	if over := len(l.recent) - l.capacity; over > 0 {
+		l.recent = append([]DecisionLog(nil), l.recent[over:]...)
+	}
+	full := false
+	if len(l.sinks) > 0 {
+		l.pending = append(l.pending, decisions...)
+		full = len(l.pending) >= l.batchSize
+	}
+	l.mu.Unlock()
+	if full {
+		go l.Flush(context.Background())
+	}
+}
+
+// Query returns the decisions in memory matching the filter, oldest first
+func (l *DecisionLogger) Query(filter DecisionFilter) []DecisionLog {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	matched := []DecisionLog{}
+	for _, decision := range l.recent {
+		if filter.matches(decision) {
+			matched = append(matched, decision)
+		}
+	}
+	return matched
+}
+
+// Flush ships the pending decisions to every sink. A batch any sink rejected is sent to every sink
+// again at the next flush, as long as it fits in the logger's capacity; collectors drop the
+// duplicates by decision ID.
+func (l *DecisionLogger) Flush(ctx context.Context) {
+	l.flushMu.Lock()
+	defer l.flushMu.Unlock()
+	l.mu.Lock()
+	batch := l.pending
+	l.pending = nil
+	l.mu.Unlock()
+	if len(batch) == 0 {
+		return
+	}
+
+	failed := false
+	for _, sink := range l.sinks {
+		if err := sink.Send(ctx, batch); err != nil {
+			l.logger.Error("❌ Failed to ship %d policy decisions: %v", len(batch), err)
+			failed = true
+		}
+	}
+	if !failed {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pending = append(batch, l.pending...)
+	if over := len(l.pending) - l.capacity; over > 0 {
+		l.logger.Warn("⚠️ Dropped %d policy decisions that could not be shipped", over)
+		l.pending = append([]DecisionLog(nil), l.pending[over:]...)
+	}
+}
+
+// Run flushes pending decisions every interval until ctx is cancelled, then flushes once more
+func (l *DecisionLogger) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			l.Flush(shutdown)
+			cancel()
+			return
+		case <-ticker.C:
+			l.Flush(ctx)
+		}
+	}
+}
+
+// logDecisions writes one decision per policy of an evaluation to the decision log. Policies
+// missing from the result, or all of them when the evaluation failed, are logged with an error.
+func logDecisions(ctx context.Context, scope PolicyScope, env string, subject interface{}, policies []*Policy, result *PolicyResult, evalErr error, cached bool, elapsed time.Duration) {
+	logger := GetDecisionLogger()
+	if logger == nil || len(policies) == 0 {
+		return
+	}
+	digest := inputDigest(env, subject)
+	now := logger.now().UTC()
+	decisions := make([]DecisionLog, 0, len(policies))
+	for _, policy := range policies {
+		decision := DecisionLog{
+			DecisionID: uuid.New().String(),
+			Path:       fmt.Sprintf("ztdp/%s/%s", scope, policy.ID),
+			Labels: map[string]string{
+				"id":          logger.instance,
+				"evaluator":   EvaluatorAI,
+				"environment": env,
+				"policy_id":   policy.ID,
+				"cached":      fmt.Sprint(cached),
+			},
+			Bundles:     map[string]BundleInfo{"ztdp": {Revision: policyRevision(policy)}},
+			InputDigest: digest,
+			Erased:      []string{"/input"},
+			TraceID:     logging.CorrelationIDFromContext(ctx),
+			Timestamp:   now,
+			Metrics:     map[string]int64{"timer_policy_eval_ns": elapsed.Nanoseconds()},
+		}
+		var evaluation *PolicyEvaluation
+		if result != nil {
+			evaluation = result.Evaluations[policy.ID]
+		}
+		switch {
+		case evaluation != nil:
+			decision.Result = &DecisionResult{Decision: evaluation.Status, Confidence: evaluation.Confidence, Reason: evaluation.Reason}
+		case evalErr != nil:
+			decision.Error = &DecisionError{Code: "evaluation_error", Message: evalErr.Error()}
+		default:
+			decision.Error = &DecisionError{Code: "evaluation_error", Message: "the evaluator returned no decision for the policy"}
+		}
+		decisions = append(decisions, decision)
+	}
+	logger.Log(decisions...)
+}
+
+// inputDigest hashes what an evaluation was asked to decide on
+func inputDigest(env string, subject interface{}) string {
+	data, _ := json.Marshal(struct {
+		Environment string      `json:"environment"`
+		Subject     interface{} `json:"subject"`
+	}{env, subject})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// policyRevision is the version of a policy: a digest of its definition, so any edit yields a
+// new revision
+func policyRevision(policy *Policy) string {
+	data, _ := json.Marshal(policy)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// FileSink appends decisions to a JSON lines file, one decision per line
+type FileSink struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileSink creates a sink appending to path, creating its directory when missing
+func NewFileSink(path string) (*FileSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create decision log directory: %w", err)
+	}
+	return &FileSink{path: path}, nil
+}
+
+// Send appends the batch to the file
+func (s *FileSink) Send(ctx context.Context, batch []DecisionLog) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, decision := range batch {
+		if err := encoder.Encode(decision); err != nil {
+			return err
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o640)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(buf.Bytes())
+	return err
+}
+
+// HTTPSink POSTs batches as a gzipped JSON array, the way OPA's decision_logs plugin does, so an
+// endpoint receiving OPA decision logs receives these too
+type HTTPSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPSink creates a sink posting to url, e.g. https://collector.example.com/logs
+func NewHTTPSink(url string, timeout time.Duration) *HTTPSink {
+	return &HTTPSink{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+// Send posts the batch
+func (s *HTTPSink) Send(ctx context.Context, batch []DecisionLog) error {
+	var body bytes.Buffer
+	writer := gzip.NewWriter(&body)
+	if err := json.NewEncoder(writer).Encode(batch); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "gzip")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("decision log endpoint answered %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package policies
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecisionLog_LogsEveryPolicyDecision(t *testing.T) {
+	svc, provider, _ := newCachingTestService(t)
+	sink, err := NewFileSink(filepath.Join(t.TempDir(), "decisions", "decisions.jsonl"))
+	require.NoError(t, err)
+	decisionLogger := NewDecisionLogger("api-1", 10, 100, sink)
+	SetDecisionLogger(decisionLogger)
+	t.Cleanup(func() { SetDecisionLogger(nil) })
+
+	ctx := logging.WithCorrelationID(context.Background(), "corr-1")
+	app, policy := createTestApplicationNode(), createApplicationServiceLimitPolicy()
+	_, err = svc.EvaluateNodePolicy(ctx, "prod", app, policy)
+	require.NoError(t, err)
+	_, err = svc.EvaluateNodePolicy(ctx, "prod", app, policy)
+	require.NoError(t, err)
+	require.Equal(t, 1, provider.calls)
+
+	decisions := decisionLogger.Query(DecisionFilter{})
+	require.Len(t, decisions, 2, "decisions served from the cache are logged too")
+	first, second := decisions[0], decisions[1]
+	assert.Equal(t, "ztdp/node/"+policy.ID, first.Path)
+	assert.Equal(t, EvaluatorAI, first.Evaluator())
+	assert.Equal(t, "api-1", first.Labels["id"])
+	assert.Equal(t, "false", first.Labels["cached"])
+	assert.Equal(t, "true", second.Labels["cached"])
+	assert.Equal(t, []string{"/input"}, first.Erased)
+	assert.Equal(t, inputDigest("prod", app), first.InputDigest)
+	assert.Equal(t, first.InputDigest, second.InputDigest)
+	assert.NotEqual(t, first.DecisionID, second.DecisionID)
+	assert.Equal(t, "corr-1", first.TraceID)
+	require.NotNil(t, first.Result)
+	assert.Equal(t, PolicyStatusAllowed, first.Result.Decision)
+	assert.Equal(t, 0.9, first.Result.Confidence)
+
+	revision := first.Bundles["ztdp"].Revision
+	policy.NaturalLanguageRule += " Exceptions need approval."
+	_, err = svc.EvaluateNodePolicy(ctx, "prod", app, policy)
+	require.NoError(t, err)
+	decisions = decisionLogger.Query(DecisionFilter{PolicyID: policy.ID})
+	require.Len(t, decisions, 3)
+	assert.NotEqual(t, revision, decisions[2].Bundles["ztdp"].Revision, "an edited policy has a new revision")
+	assert.Empty(t, decisionLogger.Query(DecisionFilter{Decision: PolicyStatusBlocked}))
+
+	decisionLogger.Flush(ctx)
+	data, err := os.ReadFile(sink.path)
+	require.NoError(t, err)
+	assert.Len(t, strings.Split(strings.TrimSpace(string(data)), "\n"), 3)
+}
+
+func TestHTTPSink_ShipsGzippedBatchesAndRetries(t *testing.T) {
+	var received [][]DecisionLog
+	fail := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		assert.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+		reader, err := gzip.NewReader(r.Body)
+		require.NoError(t, err)
+		var batch []DecisionLog
+		require.NoError(t, json.NewDecoder(reader).Decode(&batch))
+		received = append(received, batch)
+	}))
+	defer server.Close()
+
+	decisionLogger := NewDecisionLogger("api-1", 10, 100, NewHTTPSink(server.URL+"/logs", 0))
+	decisionLogger.Log(DecisionLog{DecisionID: "d1"}, DecisionLog{DecisionID: "d2"})
+	decisionLogger.Flush(context.Background())
+	assert.Empty(t, received)
+
+	fail = false
+	decisionLogger.Flush(context.Background())
+	require.Len(t, received, 1, "the rejected batch is shipped at the next flush")
+	assert.Len(t, received[0], 2)
+	decisionLogger.Flush(context.Background())
+	assert.Len(t, received, 1, "shipped decisions are not sent again")
+}
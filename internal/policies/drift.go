@@ -0,0 +1,338 @@
+package policies
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/krzachariassen/ZTDP/internal/ai"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// Coverage is how a policy applies to transitions into one environment, weakest first
+type Coverage string
+
+const (
+	CoverageMissing  Coverage = "missing"  // not attached to any transition into the environment
+	CoverageDisabled Coverage = "disabled" // attached but spec.enabled is false
+	CoverageAdvisory Coverage = "advisory" // attached with warn, audit or monitor enforcement
+	CoverageEnforced Coverage = "enforced" // attached with block or approve enforcement
+)
+
+var coverageRank = map[Coverage]int{
+	CoverageMissing:  0,
+	CoverageDisabled: 1,
+	CoverageAdvisory: 2,
+	CoverageEnforced: 3,
+}
+
+// DriftReport compares the policies attached to each environment
+type DriftReport struct {
+	Environments []string         `json:"environments"`
+	Policies     []PolicyCoverage `json:"policies"`
+	Findings     []DriftFinding   `json:"findings"`
+	Summary      string           `json:"summary"`
+	// RemediationSource is "ai" when the AI provider wrote the remediations, "generated" otherwise
+	RemediationSource string `json:"remediation_source"`
+}
+
+// PolicyCoverage is the coverage of one policy in every compared environment
+type PolicyCoverage struct {
+	Policy       string              `json:"policy"`
+	Name         string              `json:"name,omitempty"`
+	Environments map[string]Coverage `json:"environments"`
+}
+
+// DriftFinding is a policy applied more strictly in some environments than in others
+type DriftFinding struct {
+	Policy   string `json:"policy"`
+	Name     string `json:"name,omitempty"`
+	Severity string `json:"severity"` // high | medium | low
+	// StrongestIn are the environments with the strictest coverage; WeakerIn maps the others to theirs
+	StrongestIn []string            `json:"strongest_in"`
+	WeakerIn    map[string]Coverage `json:"weaker_in"`
+	Remediation string              `json:"remediation"`
+}
+
+// DriftAnalyzer finds policy asymmetries between environments, such as a security policy
+// enforced in prod but not attached in staging
+type DriftAnalyzer struct {
+	globalGraph *graph.GlobalGraph
+	aiProvider  ai.AIProvider
+	logger      *logging.Logger
+}
+
+// NewDriftAnalyzer creates a drift analyzer. aiProvider may be nil, in which case
+// remediations are generated from the findings.
+func NewDriftAnalyzer(globalGraph *graph.GlobalGraph, aiProvider ai.AIProvider) *DriftAnalyzer {
+	return &DriftAnalyzer{
+		globalGraph: globalGraph,
+		aiProvider:  aiProvider,
+		logger:      logging.GetLogger().ForComponent("policy-drift"),
+	}
+}
+
+// Analyze compares policy coverage across the given environments, or across every environment
+// when none are given. Policies with the same coverage everywhere produce no finding.
+func (d *DriftAnalyzer) Analyze(ctx context.Context, environments []string) (*DriftReport, error) {
+	g, err := d.globalGraph.Graph()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load graph: %w", err)
+	}
+
+	if len(environments) == 0 {
+		for id, node := range g.Nodes {
+			if node.Kind == graph.KindEnvironment {
+				environments = append(environments, id)
+			}
+		}
+	} else {
+		for _, env := range environments {
+			if node, ok := g.Nodes[env]; !ok || node.Kind != graph.KindEnvironment {
+				return nil, fmt.Errorf("environment %s not found", env)
+			}
+		}
+	}
+	sort.Strings(environments)
+
+	coverage := policyCoverage(g, environments)
+	report := &DriftReport{Environments: environments, Policies: coverage, Findings: []DriftFinding{}}
+	if len(environments) > 1 {
+		for _, policy := range coverage {
+			if finding, ok := findDrift(policy, environments); ok {
+				report.Findings = append(report.Findings, finding)
+			}
+		}
+	}
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		return severityRank[report.Findings[i].Severity] > severityRank[report.Findings[j].Severity]
+	})
+
+	report.RemediationSource = d.remediate(ctx, report)
+	report.Summary = driftSummary(report)
+	return report, nil
+}
+
+// policyCoverage collects, per policy, the strongest coverage on transitions into each environment
+func policyCoverage(g *graph.Graph, environments []string) []PolicyCoverage {
+	compared := map[string]bool{}
+	for _, env := range environments {
+		compared[env] = true
+	}
+
+	byPolicy := map[string]*PolicyCoverage{}
+	for id, node := range g.Nodes {
+		if node.Kind != graph.KindPolicy {
+			continue
+		}
+		entry := &PolicyCoverage{Policy: id, Name: displayName(node), Environments: map[string]Coverage{}}
+		for _, env := range environments {
+			entry.Environments[env] = CoverageMissing
+		}
+		byPolicy[id] = entry
+	}
+
+	for id, node := range g.Nodes {
+		if node.Kind != graph.KindProcess {
+			continue
+		}
+		env, _ := node.Metadata["toID"].(string)
+		if !compared[env] {
+			continue
+		}
+		for _, edge := range g.Edges[id] {
+			entry, ok := byPolicy[edge.To]
+			if edge.Type != graph.EdgeTypeRequires || !ok {
+				continue
+			}
+			if level := nodeCoverage(g.Nodes[edge.To], env); coverageRank[level] > coverageRank[entry.Environments[env]] {
+				entry.Environments[env] = level
+			}
+		}
+	}
+
+	list := make([]PolicyCoverage, 0, len(byPolicy))
+	for _, entry := range byPolicy {
+		list = append(list, *entry)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Policy < list[j].Policy })
+	return list
+}
+
+// nodeCoverage is the coverage an attached policy node provides in an environment, where its
+// environment_enforcement may override its enforcement
+func nodeCoverage(node *graph.Node, env string) Coverage {
+	if enabled, ok := node.Spec["enabled"].(bool); ok && !enabled {
+		return CoverageDisabled
+	}
+	enforcement, _ := node.Spec["enforcement"].(string)
+	if levels, ok := node.Spec["environment_enforcement"].(map[string]interface{}); ok {
+		if level, ok := levels[env].(string); ok && level != "" {
+			enforcement = level
+		}
+	}
+	switch PolicyEnforcement(enforcement) {
+	case EnforcementWarn, EnforcementAudit, EnforcementMonitor:
+		return CoverageAdvisory
+	default:
+		// Policies without an explicit enforcement block transitions, see IsTransitionAllowed
+		return CoverageEnforced
+	}
+}
+
+func displayName(node *graph.Node) string {
+	if name, ok := node.Metadata["display_name"].(string); ok && name != "" {
+		return name
+	}
+	name, _ := node.Metadata["name"].(string)
+	if name == node.ID {
+		return ""
+	}
+	return name
+}
+
+var severityRank = map[string]int{"low": 0, "medium": 1, "high": 2}
+
+// findDrift reports a policy whose coverage differs between environments
+func findDrift(policy PolicyCoverage, environments []string) (DriftFinding, bool) {
+	strongest, weakest := CoverageMissing, CoverageEnforced
+	for _, env := range environments {
+		level := policy.Environments[env]
+		if coverageRank[level] > coverageRank[strongest] {
+			strongest = level
+		}
+		if coverageRank[level] < coverageRank[weakest] {
+			weakest = level
+		}
+	}
+	if strongest == weakest {
+		return DriftFinding{}, false
+	}
+
+	finding := DriftFinding{Policy: policy.Policy, Name: policy.Name, WeakerIn: map[string]Coverage{}}
+	for _, env := range environments {
+		if level := policy.Environments[env]; level == strongest {
+			finding.StrongestIn = append(finding.StrongestIn, env)
+		} else {
+			finding.WeakerIn[env] = level
+		}
+	}
+	switch {
+	case strongest == CoverageEnforced && coverageRank[weakest] <= coverageRank[CoverageDisabled]:
+		finding.Severity = "high"
+	case strongest == CoverageEnforced:
+		finding.Severity = "medium"
+	default:
+		finding.Severity = "low"
+	}
+	finding.Remediation = generatedRemediation(finding, strongest)
+	return finding, true
+}
+
+func generatedRemediation(finding DriftFinding, strongest Coverage) string {
+	var steps []string
+	for _, env := range sortedKeys(finding.WeakerIn) {
+		switch finding.WeakerIn[env] {
+		case CoverageMissing:
+			steps = append(steps, fmt.Sprintf("attach %s to deploy transitions into %s", finding.Policy, env))
+		case CoverageDisabled:
+			steps = append(steps, fmt.Sprintf("re-enable %s where it is attached for %s", finding.Policy, env))
+		case CoverageAdvisory:
+			steps = append(steps, fmt.Sprintf("raise enforcement of %s in %s to match %s", finding.Policy, env, strings.Join(finding.StrongestIn, ", ")))
+		}
+	}
+	remediation := strings.Join(steps, "; ")
+	return fmt.Sprintf("%s is %s in %s: %s, or record why the difference is intended.",
+		finding.Policy, strongest, strings.Join(finding.StrongestIn, ", "), remediation)
+}
+
+func sortedKeys(m map[string]Coverage) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// remediate asks the AI provider for remediation suggestions and keeps the generated ones
+// for any finding it does not answer. Findings that do not fit one prompt are sent in batches.
+func (d *DriftAnalyzer) remediate(ctx context.Context, report *DriftReport) string {
+	if d.aiProvider == nil || len(report.Findings) == 0 {
+		return "generated"
+	}
+
+	findings := make([]string, 0, len(report.Findings))
+	for _, finding := range report.Findings {
+		data, err := json.Marshal(finding)
+		if err != nil {
+			return "generated"
+		}
+		findings = append(findings, string(data))
+	}
+	systemPrompt := `You are a platform security engineer reviewing policy drift between environments.
+For each finding, suggest one concrete remediation (1-2 sentences). Consider whether the weaker environment
+should be tightened or whether the difference looks intentional, e.g. an approval gate that only makes sense in production.
+Respond with a JSON object mapping each policy ID to its remediation, and nothing else.`
+	ctx = ai.WithTask(ctx, ai.TaskConversation)
+	header := fmt.Sprintf("Environments compared: %s\nFindings:\n", strings.Join(report.Environments, ", "))
+	tokenizer := ai.DefaultTokenizer
+	room := ai.PromptBudget(ctx, d.aiProvider) - tokenizer.Count(systemPrompt) - tokenizer.Count(header) - 2
+
+	suggestions := map[string]string{}
+	for _, batch := range ai.ChunkItems(tokenizer, findings, room, ",\n") {
+		response, err := d.aiProvider.CallAI(ctx, systemPrompt, header+"["+batch+"]")
+		if err != nil {
+			d.logger.Warn("⚠️ AI drift remediation unavailable, using generated remediations: %v", err)
+			continue
+		}
+		cleaned := strings.TrimSpace(response)
+		cleaned = strings.TrimPrefix(cleaned, "```json")
+		cleaned = strings.TrimPrefix(cleaned, "```")
+		cleaned = strings.TrimSuffix(cleaned, "```")
+
+		var answered map[string]string
+		if err := json.Unmarshal([]byte(strings.TrimSpace(cleaned)), &answered); err != nil {
+			d.logger.Warn("⚠️ Could not parse AI drift remediations, using generated remediations: %v", err)
+			continue
+		}
+		for policy, suggestion := range answered {
+			suggestions[policy] = suggestion
+		}
+	}
+	answered := 0
+	for i := range report.Findings {
+		if suggestion := strings.TrimSpace(suggestions[report.Findings[i].Policy]); suggestion != "" {
+			report.Findings[i].Remediation = suggestion
+			answered++
+		}
+	}
+	if answered == 0 {
+		return "generated"
+	}
+	return "ai"
+}
+
+func driftSummary(report *DriftReport) string {
+	if len(report.Environments) < 2 {
+		return "At least two environments are needed to compare policy coverage."
+	}
+	if len(report.Findings) == 0 {
+		return fmt.Sprintf("%d policies are applied the same way in %s.", len(report.Policies), strings.Join(report.Environments, ", "))
+	}
+	counts := map[string]int{}
+	for _, finding := range report.Findings {
+		counts[finding.Severity]++
+	}
+	var parts []string
+	for _, severity := range []string{"high", "medium", "low"} {
+		if counts[severity] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[severity], severity))
+		}
+	}
+	return fmt.Sprintf("%d of %d policies drift between %s (%s).",
+		len(report.Findings), len(report.Policies), strings.Join(report.Environments, ", "), strings.Join(parts, ", "))
+}
@@ -0,0 +1,168 @@
+package policies
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/ai"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type remediationProvider struct {
+	response string
+	err      error
+	budget   int
+	calls    []string
+}
+
+func (p *remediationProvider) CallAI(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	p.calls = append(p.calls, userPrompt)
+	return p.response, p.err
+}
+
+func (p *remediationProvider) PromptBudget(ctx context.Context) int {
+	if p.budget == 0 {
+		return ai.DefaultContextWindow
+	}
+	return p.budget
+}
+
+func (p *remediationProvider) GetProviderInfo() *ai.ProviderInfo {
+	return &ai.ProviderInfo{Name: "remediation"}
+}
+
+func (p *remediationProvider) Close() error { return nil }
+
+func newDriftTestGraph(t *testing.T) *graph.GlobalGraph {
+	t.Helper()
+	gg := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	g, err := gg.Graph()
+	require.NoError(t, err)
+
+	for _, env := range []string{"dev", "staging", "prod"} {
+		require.NoError(t, g.AddNode(&graph.Node{ID: env, Kind: graph.KindEnvironment, Metadata: map[string]interface{}{"name": env}, Spec: map[string]interface{}{}}))
+	}
+	require.NoError(t, g.AddNode(&graph.Node{ID: "api-1.0", Kind: graph.KindServiceVersion, Metadata: map[string]interface{}{"name": "api-1.0"}, Spec: map[string]interface{}{}}))
+	policies := map[string]map[string]interface{}{
+		"policy-image-signing": {"enforcement": "block", "enabled": true},
+		"policy-cost-review":   {"enforcement": "warn", "enabled": true},
+		"policy-change-ticket": {"enforcement": "approve", "enabled": true},
+		"policy-tls":           {"enforcement": "block", "enabled": true},
+	}
+	for id, spec := range policies {
+		require.NoError(t, g.AddNode(&graph.Node{ID: id, Kind: graph.KindPolicy, Metadata: map[string]interface{}{"name": id, "display_name": id + " rule"}, Spec: spec}))
+	}
+	attach := func(env, policy string) {
+		require.NoError(t, g.AttachPolicyToTransition("api-1.0", env, graph.EdgeTypeDeploy, policy))
+	}
+	// image signing and change tickets apply to prod only; cost review warns in staging
+	// and prod; TLS is enforced everywhere
+	attach("prod", "policy-image-signing")
+	attach("staging", "policy-cost-review")
+	attach("prod", "policy-cost-review")
+	attach("prod", "policy-change-ticket")
+	for _, env := range []string{"dev", "staging", "prod"} {
+		attach(env, "policy-tls")
+	}
+	return gg
+}
+
+func TestDriftAnalyzer_FlagsAsymmetricPolicies(t *testing.T) {
+	analyzer := NewDriftAnalyzer(newDriftTestGraph(t), nil)
+
+	report, err := analyzer.Analyze(context.Background(), []string{"staging", "prod"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"prod", "staging"}, report.Environments)
+	assert.Equal(t, "generated", report.RemediationSource)
+	require.Len(t, report.Findings, 2, "policies applied the same way in both environments are not drift")
+
+	assert.Equal(t, "policy-change-ticket", report.Findings[0].Policy)
+	assert.Equal(t, "high", report.Findings[0].Severity)
+	assert.Equal(t, "policy-image-signing", report.Findings[1].Policy)
+	assert.Equal(t, []string{"prod"}, report.Findings[1].StrongestIn)
+	assert.Equal(t, map[string]Coverage{"staging": CoverageMissing}, report.Findings[1].WeakerIn)
+	assert.Contains(t, report.Findings[1].Remediation, "attach policy-image-signing to deploy transitions into staging")
+
+	for _, policy := range report.Policies {
+		if policy.Policy == "policy-cost-review" {
+			assert.Equal(t, map[string]Coverage{"staging": CoverageAdvisory, "prod": CoverageAdvisory}, policy.Environments)
+			assert.Equal(t, "policy-cost-review rule", policy.Name)
+		}
+	}
+	assert.Contains(t, report.Summary, "2 of 4 policies drift")
+}
+
+func TestDriftAnalyzer_ComparesEveryEnvironmentByDefault(t *testing.T) {
+	gg := newDriftTestGraph(t)
+	g, err := gg.Graph()
+	require.NoError(t, err)
+	g.Nodes["policy-tls"].Spec["enabled"] = false
+
+	report, err := NewDriftAnalyzer(gg, nil).Analyze(context.Background(), nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"dev", "prod", "staging"}, report.Environments)
+	for _, finding := range report.Findings {
+		assert.NotEqual(t, "policy-tls", finding.Policy, "a policy disabled everywhere is consistent")
+		if finding.Policy == "policy-cost-review" {
+			assert.Equal(t, "low", finding.Severity)
+			assert.Equal(t, map[string]Coverage{"dev": CoverageMissing}, finding.WeakerIn)
+		}
+	}
+}
+
+func TestDriftAnalyzer_UnknownEnvironment(t *testing.T) {
+	_, err := NewDriftAnalyzer(newDriftTestGraph(t), nil).Analyze(context.Background(), []string{"prod", "qa"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "environment qa not found")
+}
+
+func TestDriftAnalyzer_AIRemediation(t *testing.T) {
+	provider := &remediationProvider{response: "```json\n{\"policy-image-signing\": \"Require signed images in staging too so unsigned builds fail before prod.\"}\n```"}
+
+	report, err := NewDriftAnalyzer(newDriftTestGraph(t), provider).Analyze(context.Background(), []string{"staging", "prod"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "ai", report.RemediationSource)
+	for _, finding := range report.Findings {
+		switch finding.Policy {
+		case "policy-image-signing":
+			assert.Equal(t, "Require signed images in staging too so unsigned builds fail before prod.", finding.Remediation)
+		default:
+			assert.Contains(t, finding.Remediation, "attach", "findings the AI skipped keep the generated remediation")
+		}
+	}
+
+	provider.err = errors.New("provider down")
+	report, err = NewDriftAnalyzer(newDriftTestGraph(t), provider).Analyze(context.Background(), []string{"staging", "prod"})
+	require.NoError(t, err)
+	assert.Equal(t, "generated", report.RemediationSource)
+}
+
+func TestDriftAnalyzer_AIRemediationInBatches(t *testing.T) {
+	provider := &remediationProvider{
+		response: `{"policy-image-signing": "Sign staging images too.", "policy-change-ticket": "Decide whether staging needs tickets."}`,
+		budget:   300,
+	}
+
+	report, err := NewDriftAnalyzer(newDriftTestGraph(t), provider).Analyze(context.Background(), []string{"staging", "prod"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "ai", report.RemediationSource)
+	assert.Greater(t, len(provider.calls), 1, "findings beyond the prompt budget go in further calls")
+	for _, call := range provider.calls {
+		assert.Contains(t, call, "Environments compared: prod, staging")
+	}
+	for _, finding := range report.Findings {
+		switch finding.Policy {
+		case "policy-image-signing":
+			assert.Equal(t, "Sign staging images too.", finding.Remediation)
+		case "policy-change-ticket":
+			assert.Equal(t, "Decide whether staging needs tickets.", finding.Remediation)
+		}
+	}
+}
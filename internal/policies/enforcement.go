@@ -0,0 +1,56 @@
+package policies
+
+// EnforcementFor returns how the policy is enforced in an environment: its override for the
+// environment, else its enforcement, else block
+func (p *Policy) EnforcementFor(env string) PolicyEnforcement {
+	if enforcement, ok := p.EnvironmentEnforcement[env]; ok && enforcement != "" {
+		return enforcement
+	}
+	if p.Enforcement != "" {
+		return p.Enforcement
+	}
+	return EnforcementBlock
+}
+
+// Enforce turns the AI's verdict on a policy into what it means at an enforcement level. Only
+// violations are softened: warn reports them as warnings, approve holds them for approval,
+// and audit and monitor record them without stopping anything.
+func Enforce(verdict PolicyStatus, enforcement PolicyEnforcement) PolicyStatus {
+	if verdict != PolicyStatusBlocked {
+		return verdict
+	}
+	switch enforcement {
+	case EnforcementWarn:
+		return PolicyStatusWarning
+	case EnforcementApprove:
+		return PolicyStatusPendingApproval
+	case EnforcementAudit, EnforcementMonitor:
+		return PolicyStatusAllowed
+	default:
+		return PolicyStatusBlocked
+	}
+}
+
+// statusRank orders the statuses an overall result can take, most restrictive last
+var statusRank = map[PolicyStatus]int{
+	PolicyStatusAllowed:         0,
+	PolicyStatusWarning:         1,
+	PolicyStatusPendingApproval: 2,
+	PolicyStatusBlocked:         3,
+}
+
+// applyEnforcement enforces the evaluation of policy at env's level and folds it into overall,
+// which is the most restrictive status seen so far
+func applyEnforcement(evaluation *PolicyEvaluation, policy *Policy, env string, overall PolicyStatus) PolicyStatus {
+	evaluation.PolicyID = policy.ID
+	evaluation.Verdict = evaluation.Status
+	evaluation.Enforcement = policy.EnforcementFor(env)
+	evaluation.Status = Enforce(evaluation.Verdict, evaluation.Enforcement)
+	if evaluation.Status == PolicyStatusPendingApproval {
+		evaluation.RequiresApproval = true
+	}
+	if statusRank[evaluation.Status] > statusRank[overall] {
+		return evaluation.Status
+	}
+	return overall
+}
@@ -0,0 +1,99 @@
+package policies
+
+import (
+	"context"
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/ai"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// violationProvider finds every policy violated
+type violationProvider struct{}
+
+func (p *violationProvider) CallAI(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	return `{"status": "blocked", "reason": "too many services", "confidence": 0.95}`, nil
+}
+
+func (p *violationProvider) GetProviderInfo() *ai.ProviderInfo {
+	return &ai.ProviderInfo{Name: "violation"}
+}
+
+func (p *violationProvider) Close() error { return nil }
+
+func TestPolicyEnforcementFor(t *testing.T) {
+	policy := &Policy{
+		Enforcement:            EnforcementBlock,
+		EnvironmentEnforcement: map[string]PolicyEnforcement{"dev": EnforcementWarn, "staging": EnforcementApprove},
+	}
+	assert.Equal(t, EnforcementWarn, policy.EnforcementFor("dev"))
+	assert.Equal(t, EnforcementApprove, policy.EnforcementFor("staging"))
+	assert.Equal(t, EnforcementBlock, policy.EnforcementFor("prod"))
+	assert.Equal(t, EnforcementBlock, (&Policy{}).EnforcementFor("prod"), "block is the default")
+
+	assert.Equal(t, PolicyStatusWarning, Enforce(PolicyStatusBlocked, EnforcementWarn))
+	assert.Equal(t, PolicyStatusPendingApproval, Enforce(PolicyStatusBlocked, EnforcementApprove))
+	assert.Equal(t, PolicyStatusAllowed, Enforce(PolicyStatusBlocked, EnforcementAudit))
+	assert.Equal(t, PolicyStatusBlocked, Enforce(PolicyStatusBlocked, EnforcementBlock))
+	assert.Equal(t, PolicyStatusAllowed, Enforce(PolicyStatusAllowed, EnforcementBlock), "only violations are enforced")
+}
+
+func TestEvaluationEnforcesTheTargetEnvironmentsLevel(t *testing.T) {
+	store := NewMockPolicyStore()
+	policy := createApplicationServiceLimitPolicy()
+	policy.EnvironmentEnforcement = map[string]PolicyEnforcement{"dev": EnforcementWarn, "staging": EnforcementApprove}
+	require.NoError(t, store.Store(policy))
+	svc := NewServiceWithAIProvider(nil, nil, &violationProvider{}, store, "", nil)
+	app := createTestApplicationNode()
+
+	for env, want := range map[string]PolicyStatus{
+		"dev":     PolicyStatusWarning,
+		"staging": PolicyStatusPendingApproval,
+		"prod":    PolicyStatusBlocked,
+	} {
+		result, err := svc.EvaluateNode(context.Background(), env, app)
+		require.NoError(t, err)
+		assert.Equal(t, env, result.Environment)
+		assert.Equal(t, want, result.OverallStatus, env)
+		evaluation := result.Evaluations[policy.ID]
+		require.NotNil(t, evaluation)
+		assert.Equal(t, PolicyStatusBlocked, evaluation.Verdict, "the AI's verdict is kept")
+		assert.Equal(t, want == PolicyStatusPendingApproval, evaluation.RequiresApproval, env)
+	}
+}
+
+func TestPolicyAgentEvaluatesForThePayloadEnvironment(t *testing.T) {
+	store := NewMockPolicyStore()
+	policy := createApplicationServiceLimitPolicy()
+	policy.EnvironmentEnforcement = map[string]PolicyEnforcement{"staging": EnforcementApprove}
+	require.NoError(t, store.Store(policy))
+	agent := &FrameworkPolicyAgent{
+		service: NewServiceWithAIProvider(nil, nil, &violationProvider{}, store, "", nil),
+		logger:  logging.GetLogger().ForComponent("policy-agent"),
+	}
+
+	app := createTestApplicationNode()
+	node := map[string]interface{}{"id": app.ID, "kind": app.Kind, "metadata": app.Metadata, "spec": app.Spec}
+	for env, want := range map[string]string{"staging": "conditional", "prod": "blocked"} {
+		response, err := agent.handlePolicyEvaluation(context.Background(), &events.Event{
+			ID:      "evaluate-" + env,
+			Payload: map[string]interface{}{"node": node, "environment": env},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, want, response.Payload["decision"], env)
+		assert.Equal(t, env, response.Payload["environment"])
+	}
+}
+
+func TestNodeCoverageUsesEnvironmentEnforcement(t *testing.T) {
+	node := &graph.Node{ID: "policy-tls", Kind: graph.KindPolicy, Spec: map[string]interface{}{
+		"enforcement":             "block",
+		"environment_enforcement": map[string]interface{}{"dev": "warn"},
+	}}
+	assert.Equal(t, CoverageAdvisory, nodeCoverage(node, "dev"))
+	assert.Equal(t, CoverageEnforced, nodeCoverage(node, "prod"))
+}
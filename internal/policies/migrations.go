@@ -0,0 +1,69 @@
+package policies
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/migrations"
+)
+
+// MigrationGate decides whether the schema migrations a deployment would run may run
+type MigrationGate struct {
+	ReversibleEnvironments []string // environments where every pending migration must be reversible
+}
+
+var (
+	migrationGateMu sync.RWMutex
+	migrationGate   MigrationGate
+)
+
+// SetMigrationGate sets the gate the Policy Agent applies to deployments (called from main.go)
+func SetMigrationGate(g MigrationGate) {
+	migrationGateMu.Lock()
+	defer migrationGateMu.Unlock()
+	migrationGate = g
+}
+
+// GetMigrationGate returns the gate the Policy Agent applies to deployments
+func GetMigrationGate() MigrationGate {
+	migrationGateMu.RLock()
+	defer migrationGateMu.RUnlock()
+	return migrationGate
+}
+
+// MigrationDecision is the result of applying the gate to a deployment
+type MigrationDecision struct {
+	Decision   string                  `json:"decision"` // allowed | blocked
+	Reasons    []string                `json:"reasons,omitempty"`
+	Migrations []*migrations.Migration `json:"migrations"` // pending migrations the deployment runs
+}
+
+// Evaluate applies the gate to the migrations deploying the application to the environment would run
+func (g MigrationGate) Evaluate(globalGraph *graph.GlobalGraph, appName, environment string) (*MigrationDecision, error) {
+	pending, err := migrations.NewService(globalGraph).Pending(appName, environment)
+	if err != nil {
+		return nil, err
+	}
+	decision := &MigrationDecision{Decision: "allowed", Migrations: pending}
+	if g.requiresReversible(environment) {
+		for _, migration := range pending {
+			if !migration.Reversible {
+				decision.Reasons = append(decision.Reasons, fmt.Sprintf("migration %s is not reversible, which %s requires", migration.ID, environment))
+			}
+		}
+	}
+	if len(decision.Reasons) > 0 {
+		decision.Decision = "blocked"
+	}
+	return decision, nil
+}
+
+func (g MigrationGate) requiresReversible(environment string) bool {
+	for _, env := range g.ReversibleEnvironments {
+		if env == environment {
+			return true
+		}
+	}
+	return false
+}
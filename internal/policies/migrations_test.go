@@ -0,0 +1,89 @@
+package policies
+
+import (
+	"context"
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/krzachariassen/ZTDP/internal/migrations"
+)
+
+func newMigrationTestGraph(t *testing.T) *graph.GlobalGraph {
+	t.Helper()
+	g := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	for id, kind := range map[string]string{
+		"checkout":           graph.KindApplication,
+		"checkout-api":       graph.KindService,
+		"checkout-api:1.1.0": graph.KindServiceVersion,
+		"staging":            graph.KindEnvironment,
+		"prod":               graph.KindEnvironment,
+	} {
+		g.AddNode(&graph.Node{ID: id, Kind: kind, Metadata: map[string]interface{}{"name": id}, Spec: map[string]interface{}{}})
+	}
+	g.AddEdge("checkout", "checkout-api", graph.EdgeTypeOwns)
+	g.AddEdge("checkout-api", "checkout-api:1.1.0", graph.EdgeTypeHasVersion)
+
+	service := migrations.NewService(g)
+	if _, err := service.Declare(migrations.Migration{Service: "checkout-api", Version: "1.1.0", Name: "add_column", UpURI: "s3://sql/up.sql", DownURI: "s3://sql/down.sql"}); err != nil {
+		t.Fatalf("Failed to declare migration: %v", err)
+	}
+	if _, err := service.Declare(migrations.Migration{Service: "checkout-api", Version: "1.1.0", Name: "drop_table", UpURI: "s3://sql/drop.sql"}); err != nil {
+		t.Fatalf("Failed to declare migration: %v", err)
+	}
+	return g
+}
+
+func TestMigrationGate(t *testing.T) {
+	g := newMigrationTestGraph(t)
+	gate := MigrationGate{ReversibleEnvironments: []string{"prod"}}
+
+	decision, err := gate.Evaluate(g, "checkout", "prod")
+	if err != nil {
+		t.Fatalf("Evaluate failed: %v", err)
+	}
+	if decision.Decision != "blocked" || len(decision.Reasons) != 1 || len(decision.Migrations) != 2 {
+		t.Errorf("Expected the irreversible migration to block prod, got %+v", decision)
+	}
+	if decision, _ := gate.Evaluate(g, "checkout", "staging"); decision.Decision != "allowed" {
+		t.Errorf("Expected staging to accept irreversible migrations, got %+v", decision)
+	}
+
+	// Once the migration has run in prod the gate no longer applies to it
+	if _, err := migrations.NewService(g).RecordExecution("checkout-api:1.1.0:drop_table", "prod", migrations.StatusSucceeded, ""); err != nil {
+		t.Fatalf("RecordExecution failed: %v", err)
+	}
+	if decision, _ := gate.Evaluate(g, "checkout", "prod"); decision.Decision != "allowed" || len(decision.Migrations) != 1 {
+		t.Errorf("Expected only the reversible migration to be pending, got %+v", decision)
+	}
+}
+
+func TestPolicyAgentMigrationGate(t *testing.T) {
+	g := newMigrationTestGraph(t)
+	SetMigrationGate(MigrationGate{ReversibleEnvironments: []string{"prod"}})
+	defer SetMigrationGate(MigrationGate{})
+	agent := &FrameworkPolicyAgent{
+		service: NewService(nil, g, "", nil),
+		logger:  logging.GetLogger().ForComponent("policy-agent"),
+	}
+
+	request := &events.Event{ID: "evt-1", Subject: "policy.migrations", Payload: map[string]interface{}{
+		"intent":      "check migrations",
+		"application": "checkout",
+		"environment": "prod",
+	}}
+	response, err := agent.handleEvent(context.Background(), request)
+	if err != nil {
+		t.Fatalf("handleEvent failed: %v", err)
+	}
+	if response.Payload["decision"] != "blocked" {
+		t.Errorf("Expected the irreversible migration to be blocked, got %v", response.Payload)
+	}
+
+	request.Payload["environment"] = "staging"
+	response, _ = agent.handleEvent(context.Background(), request)
+	if response.Payload["decision"] != "allowed" {
+		t.Errorf("Expected staging to be allowed, got %v", response.Payload)
+	}
+}
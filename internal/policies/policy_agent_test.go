@@ -5,11 +5,11 @@ import (
 	"os"
 	"testing"
 
-	"github.com/krzachariassen/ZTDP/internal/agentFramework"
 	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
 	"github.com/krzachariassen/ZTDP/internal/ai"
 	"github.com/krzachariassen/ZTDP/internal/events"
 	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -148,9 +148,9 @@ func TestFrameworkPolicyAgent_EventBasedEvaluation(t *testing.T) {
 			baseAgent := createTestFrameworkPolicyAgent(t)
 
 			// Cast to framework agent to access ProcessEvent
-			agent, ok := baseAgent.(*agentFramework.BaseAgent)
+			agent, ok := baseAgent.(*agentframework.BaseAgent)
 			if !ok {
-				t.Fatalf("Expected *agentFramework.BaseAgent, got %T", baseAgent)
+				t.Fatalf("Expected *agentframework.BaseAgent, got %T", baseAgent)
 			}
 
 			// Process the event
@@ -199,9 +199,9 @@ func TestFrameworkPolicyAgent_BusinessLogicIntegration(t *testing.T) {
 		baseAgent := createTestFrameworkPolicyAgent(t)
 
 		// Cast to framework agent
-		agent, ok := baseAgent.(*agentFramework.BaseAgent)
+		agent, ok := baseAgent.(*agentframework.BaseAgent)
 		if !ok {
-			t.Fatalf("Expected *agentFramework.BaseAgent, got %T", baseAgent)
+			t.Fatalf("Expected *agentframework.BaseAgent, got %T", baseAgent)
 		}
 
 		// Create event with real node data
@@ -264,7 +264,7 @@ func TestPolicyAgentEndToEndScenario(t *testing.T) {
 	baseAgent, err := NewPolicyAgent(graphStore, globalGraph, mockPolicyStore, eventBus, registry)
 	assert.NoError(t, err, "Failed to create policy agent")
 
-	agent, ok := baseAgent.(*agentFramework.BaseAgent)
+	agent, ok := baseAgent.(*agentframework.BaseAgent)
 	assert.True(t, ok, "Expected BaseAgent")
 
 	// Test cases that require real AI processing
@@ -3,9 +3,11 @@ package policies
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/redaction"
 )
 
 // Prompt building for AI policy evaluation - Infrastructure layer
@@ -15,6 +17,8 @@ func (s *Service) BuildNodePolicyPrompt(ctx context.Context, node *graph.Node, p
 	if policy == nil || node == nil {
 		return nil, fmt.Errorf("policy and node must not be nil")
 	}
+	readNode(ctx, node.ID)
+	readNode(ctx, policy.ID)
 
 	// Fully generic system prompt for AI policy agent
 	systemPrompt := `You are an expert policy evaluator with full access to the infrastructure graph. You will be given a policy rule and a context (node, edge, or graph). Your job is to determine if the context is compliant with the policy.
@@ -40,15 +44,7 @@ Instructions:
 	// Build full graph context for this node (generic approach)
 	graphContext := s.buildFullGraphContext(ctx, node, nil)
 
-	userPrompt := fmt.Sprintf(`POLICY EVALUATION REQUEST
-
-POLICY:
-- ID: %s
-- Name: %s
-- Description: %s
-- Rule: %s
-- Enforcement: %s
-- Required Confidence: %.2f
+	userPrompt := fmt.Sprintf(`%s
 
 NODE CONTEXT:
 - ID: %s
@@ -60,12 +56,7 @@ GRAPH CONTEXT:
 %s
 
 Analyze the node against the policy using both the direct context and any relevant graph information. Use your AI reasoning to determine compliance.`,
-		policy.ID,
-		policy.Name,
-		policy.Description,
-		policy.NaturalLanguageRule,
-		string(policy.Enforcement),
-		policy.RequiredConfidence,
+		policyPromptHeader(policy),
 		node.ID,
 		node.Kind,
 		formatMapForPrompt(node.Metadata),
@@ -80,6 +71,9 @@ func (s *Service) BuildEdgePolicyPrompt(ctx context.Context, edge *graph.Edge, p
 	if policy == nil || edge == nil {
 		return nil, fmt.Errorf("policy and edge must not be nil")
 	}
+	readNode(ctx, edge.To)
+	readEdge(ctx, edge.To, edge.Type)
+	readNode(ctx, policy.ID)
 
 	systemPrompt := `You are an expert policy evaluator with full access to the infrastructure graph. You will be given a policy rule and a context (node, edge, or graph). Your job is to determine if the context is compliant with the policy.
 
@@ -104,15 +98,7 @@ Instructions:
 	// Build full graph context for this edge (generic approach)
 	graphContext := s.buildFullGraphContext(ctx, nil, edge)
 
-	userPrompt := fmt.Sprintf(`POLICY EVALUATION REQUEST
-
-POLICY:
-- ID: %s
-- Name: %s
-- Description: %s
-- Rule: %s
-- Enforcement: %s
-- Required Confidence: %.2f
+	userPrompt := fmt.Sprintf(`%s
 
 EDGE CONTEXT:
 - Target: %s
@@ -123,12 +109,7 @@ GRAPH CONTEXT:
 %s
 
 Analyze the edge/relationship against the policy using both the direct context and any relevant graph information. Use your AI reasoning to determine compliance.`,
-		policy.ID,
-		policy.Name,
-		policy.Description,
-		policy.NaturalLanguageRule,
-		string(policy.Enforcement),
-		policy.RequiredConfidence,
+		policyPromptHeader(policy),
 		edge.To,
 		edge.Type,
 		formatMapForPrompt(edge.Metadata),
@@ -142,6 +123,7 @@ func (s *Service) BuildGraphPolicyPrompt(ctx context.Context, g *graph.Graph, po
 	if policy == nil || g == nil {
 		return nil, fmt.Errorf("policy and graph must not be nil")
 	}
+	readGraph(ctx)
 
 	nodeCount := len(g.Nodes)
 	edgeCount := 0
@@ -177,15 +159,7 @@ Instructions:
 - If the policy does not apply, return status "not_applicable" with a reason.
 - Be precise, concise, and actionable in your reasoning.`
 
-	userPrompt := fmt.Sprintf(`POLICY EVALUATION REQUEST
-
-POLICY:
-- ID: %s
-- Name: %s
-- Description: %s
-- Rule: %s
-- Enforcement: %s
-- Required Confidence: %.2f
+	userPrompt := fmt.Sprintf(`%s
 
 GRAPH CONTEXT:
 - Total Nodes: %d
@@ -193,12 +167,7 @@ GRAPH CONTEXT:
 - Node Types: %s
 
 Analyze the entire graph against the policy using the system-wide information. Focus on architectural patterns and system-wide compliance.`,
-		policy.ID,
-		policy.Name,
-		policy.Description,
-		policy.NaturalLanguageRule,
-		string(policy.Enforcement),
-		policy.RequiredConfidence,
+		policyPromptHeader(policy),
 		nodeCount,
 		edgeCount,
 		formatNodeKinds(nodeKinds),
@@ -254,16 +223,39 @@ func (s *Service) buildFullGraphContext(ctx context.Context, node *graph.Node, e
 // HELPER FUNCTIONS
 // =============================================================================
 
+// policyPromptHeader describes the policy being evaluated; it opens every evaluation prompt, and
+// every part of one that is evaluated in chunks
+func policyPromptHeader(policy *Policy) string {
+	return fmt.Sprintf(`POLICY EVALUATION REQUEST
+
+POLICY:
+- ID: %s
+- Name: %s
+- Description: %s
+- Rule: %s
+- Enforcement: %s
+- Required Confidence: %.2f`,
+		policy.ID,
+		policy.Name,
+		policy.Description,
+		policy.NaturalLanguageRule,
+		string(policy.Enforcement),
+		policy.RequiredConfidence,
+	)
+}
+
 // formatMapForPrompt converts a map to a readable string for AI prompts
 func formatMapForPrompt(m map[string]interface{}) string {
 	if len(m) == 0 {
 		return "none"
 	}
 
+	// Graph metadata can hold credentials and contact details that must not reach the AI provider
 	var items []string
-	for k, v := range m {
+	for k, v := range redaction.Default().Map(m) {
 		items = append(items, fmt.Sprintf("%s: %v", k, v))
 	}
+	sort.Strings(items)
 	return strings.Join(items, ", ")
 }
 
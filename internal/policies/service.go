@@ -101,7 +101,10 @@ func (s *Service) EvaluateNodePolicy(ctx context.Context, env string, node *grap
 	}
 
 	// Use AI evaluation infrastructure
-	return s.evaluateNodePolicyWithAI(ctx, node, []*Policy{policy})
+	policies := []*Policy{policy}
+	return s.cached(ctx, PolicyScopeNode, env, node, policies, func(ctx context.Context) (*PolicyResult, error) {
+		return s.evaluateNodePolicyWithAI(ctx, s.environment(env), node, policies)
+	})
 }
 
 // EvaluateNode evaluates a node against all applicable policies
@@ -141,7 +144,9 @@ func (s *Service) EvaluateNode(ctx context.Context, env string, node *graph.Node
 	}
 
 	// Use AI evaluation infrastructure
-	return s.evaluateNodePolicyWithAI(ctx, node, applicablePolicies)
+	return s.cached(ctx, PolicyScopeNode, env, node, applicablePolicies, func(ctx context.Context) (*PolicyResult, error) {
+		return s.evaluateNodePolicyWithAI(ctx, s.environment(env), node, applicablePolicies)
+	})
 }
 
 // =============================================================================
@@ -169,7 +174,10 @@ func (s *Service) EvaluateEdgePolicy(ctx context.Context, env string, edge *grap
 	}
 
 	// Use AI evaluation infrastructure
-	return s.evaluateEdgePolicyWithAI(ctx, edge, []*Policy{policy})
+	policies := []*Policy{policy}
+	return s.cached(ctx, PolicyScopeEdge, env, edge, policies, func(ctx context.Context) (*PolicyResult, error) {
+		return s.evaluateEdgePolicyWithAI(ctx, s.environment(env), edge, policies)
+	})
 }
 
 // EvaluateEdge evaluates an edge against all applicable policies
@@ -209,7 +217,9 @@ func (s *Service) EvaluateEdge(ctx context.Context, env string, edge *graph.Edge
 	}
 
 	// Use AI evaluation infrastructure
-	return s.evaluateEdgePolicyWithAI(ctx, edge, applicablePolicies)
+	return s.cached(ctx, PolicyScopeEdge, env, edge, applicablePolicies, func(ctx context.Context) (*PolicyResult, error) {
+		return s.evaluateEdgePolicyWithAI(ctx, s.environment(env), edge, applicablePolicies)
+	})
 }
 
 // =============================================================================
@@ -236,7 +246,10 @@ func (s *Service) EvaluateGraphPolicy(ctx context.Context, env string, g *graph.
 	}
 
 	// Use AI evaluation infrastructure
-	return s.evaluateGraphPolicyWithAI(ctx, g, []*Policy{policy})
+	policies := []*Policy{policy}
+	return s.cached(ctx, PolicyScopeGraph, env, g, policies, func(ctx context.Context) (*PolicyResult, error) {
+		return s.evaluateGraphPolicyWithAI(ctx, s.environment(env), g, policies)
+	})
 }
 
 // EvaluateGraph evaluates a graph against all applicable policies
@@ -275,13 +288,24 @@ func (s *Service) EvaluateGraph(ctx context.Context, env string, g *graph.Graph)
 	}
 
 	// Use AI evaluation infrastructure
-	return s.evaluateGraphPolicyWithAI(ctx, g, applicablePolicies)
+	return s.cached(ctx, PolicyScopeGraph, env, g, applicablePolicies, func(ctx context.Context) (*PolicyResult, error) {
+		return s.evaluateGraphPolicyWithAI(ctx, s.environment(env), g, applicablePolicies)
+	})
 }
 
 // =============================================================================
 // BUSINESS LOGIC HELPERS
 // =============================================================================
 
+// environment is the environment an evaluation enforces policies for: the target given by the
+// caller, else the one the service was created for
+func (s *Service) environment(env string) string {
+	if env != "" {
+		return env
+	}
+	return s.env
+}
+
 // isPolicyApplicableToNode checks if a policy applies to a specific node
 func (s *Service) isPolicyApplicableToNode(policy *Policy, node *graph.Node) bool {
 	// If no specific node types specified, applies to all
@@ -0,0 +1,161 @@
+package policies
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/deployments"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+)
+
+// SoakRule requires an application to have run in a lower environment before it is deployed
+// to Environment
+type SoakRule struct {
+	Environment string        `json:"environment"`
+	After       string        `json:"after"`    // the lower environment
+	Duration    time.Duration `json:"duration"` // minimum time since the last successful deployment there
+}
+
+// SoakGate decides whether an application has soaked long enough to be promoted
+type SoakGate struct {
+	Rules map[string]SoakRule // by Environment
+}
+
+var (
+	soakMu   sync.RWMutex
+	soakGate = SoakGate{}
+)
+
+// SetSoakGate sets the soak gate the Policy Agent applies to deployments (called from main.go)
+func SetSoakGate(g SoakGate) {
+	soakMu.Lock()
+	defer soakMu.Unlock()
+	soakGate = g
+}
+
+// GetSoakGate returns the soak gate the Policy Agent applies to deployments
+func GetSoakGate() SoakGate {
+	soakMu.RLock()
+	defer soakMu.RUnlock()
+	return soakGate
+}
+
+// SoakDecision is the result of applying the soak gate to a deployment
+type SoakDecision struct {
+	Decision     string     `json:"decision"` // allowed | blocked
+	Reason       string     `json:"reason,omitempty"`
+	After        string     `json:"after,omitempty"`
+	Required     string     `json:"required,omitempty"`
+	Soaked       string     `json:"soaked,omitempty"`
+	DeploymentID string     `json:"deployment_id,omitempty"` // the deployment in the lower environment the soak is measured from
+	HealthySince *time.Time `json:"healthy_since,omitempty"`
+}
+
+// Evaluate applies the gate to deploying the application's release to the environment at now.
+// The soak is measured from the last successful deployment of the application to the lower
+// environment, read from the deployment edges' status history, and only counts while the
+// application's resources there are healthy.
+func (g SoakGate) Evaluate(globalGraph *graph.GlobalGraph, appName, releaseID, environment string, now time.Time) (*SoakDecision, error) {
+	rule, ok := g.Rules[environment]
+	if !ok {
+		return &SoakDecision{Decision: "allowed"}, nil
+	}
+	decision := &SoakDecision{Decision: "blocked", After: rule.After, Required: rule.Duration.String()}
+
+	history, err := deployments.NewDeploymentService(globalGraph, nil).DeploymentHistory(appName, rule.After)
+	if err != nil {
+		return nil, err
+	}
+	var soaked *deployments.DeploymentAttempt
+	var since time.Time
+	for i := range history {
+		if at, ok := succeededAt(history[i]); ok {
+			soaked, since = &history[i], at
+		}
+	}
+	if soaked == nil {
+		decision.Reason = fmt.Sprintf("%s has not been deployed successfully to %s", appName, rule.After)
+		return decision, nil
+	}
+	decision.DeploymentID = soaked.DeploymentID
+	decision.HealthySince = &since
+
+	edges, err := globalGraph.Edges()
+	if err != nil {
+		return nil, err
+	}
+	shipped, soakedVersions := includedVersions(edges, releaseID), includedVersions(edges, soaked.ReleaseID)
+	if len(shipped) > 0 && len(soakedVersions) > 0 && fmt.Sprint(shipped) != fmt.Sprint(soakedVersions) {
+		decision.Reason = fmt.Sprintf("%s ships %v but %s ran %v", releaseID, shipped, rule.After, soakedVersions)
+		return decision, nil
+	}
+
+	unhealthy, err := unhealthyResources(globalGraph, edges, appName, rule.After)
+	if err != nil {
+		return nil, err
+	}
+	if len(unhealthy) > 0 {
+		decision.Reason = fmt.Sprintf("%s resources are unhealthy in %s: %v", appName, rule.After, unhealthy)
+		return decision, nil
+	}
+
+	elapsed := now.Sub(since)
+	decision.Soaked = elapsed.Truncate(time.Second).String()
+	if elapsed < rule.Duration {
+		decision.Reason = fmt.Sprintf("%s has run in %s for %s of the required %s", appName, rule.After, decision.Soaked, rule.Duration)
+		return decision, nil
+	}
+	decision.Decision = "allowed"
+	return decision, nil
+}
+
+// succeededAt returns when a deployment last succeeded, if it is still in that state
+func succeededAt(attempt deployments.DeploymentAttempt) (time.Time, bool) {
+	if attempt.Status != string(deployments.StatusSucceeded) {
+		return time.Time{}, false
+	}
+	for i := len(attempt.History) - 1; i >= 0; i-- {
+		if attempt.History[i].Status == string(deployments.StatusSucceeded) && !attempt.History[i].Timestamp.IsZero() {
+			return attempt.History[i].Timestamp, true
+		}
+	}
+	return attempt.CreatedAt, !attempt.CreatedAt.IsZero()
+}
+
+// includedVersions returns the service versions a release in the graph includes
+func includedVersions(edges map[string][]graph.Edge, releaseID string) []string {
+	var versions []string
+	for _, edge := range edges[releaseID] {
+		if edge.Type == "includes" {
+			versions = append(versions, edge.To)
+		}
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// unhealthyResources returns the application's resources in the environment that are marked
+// unhealthy
+func unhealthyResources(globalGraph *graph.GlobalGraph, edges map[string][]graph.Edge, appName, environment string) ([]string, error) {
+	nodes, err := globalGraph.Nodes()
+	if err != nil {
+		return nil, err
+	}
+	var unhealthy []string
+	for _, owned := range edges[appName] {
+		node, ok := nodes[owned.To]
+		if owned.Type != graph.EdgeTypeOwns || !ok || node.Kind != graph.KindResource || node.Metadata["health"] != "unhealthy" {
+			continue
+		}
+		for _, edge := range edges[owned.To] {
+			if edge.Type == graph.EdgeTypeDeploy && edge.To == environment {
+				unhealthy = append(unhealthy, owned.To)
+				break
+			}
+		}
+	}
+	sort.Strings(unhealthy)
+	return unhealthy, nil
+}
@@ -0,0 +1,91 @@
+package policies
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/deployments"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+var soakDeployedAt = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func newSoakTestGraph(t *testing.T) *graph.GlobalGraph {
+	t.Helper()
+	g := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	g.AddNode(&graph.Node{ID: "checkout", Kind: graph.KindApplication, Metadata: map[string]interface{}{"name": "checkout"}, Spec: map[string]interface{}{}})
+	g.AddNode(&graph.Node{ID: "staging", Kind: graph.KindEnvironment, Metadata: map[string]interface{}{"name": "staging"}, Spec: map[string]interface{}{}})
+	g.AddNode(&graph.Node{ID: "prod", Kind: graph.KindEnvironment, Metadata: map[string]interface{}{"name": "prod"}, Spec: map[string]interface{}{}})
+	g.AddNode(&graph.Node{ID: "checkout-db", Kind: graph.KindResource, Metadata: map[string]interface{}{"name": "checkout-db"}, Spec: map[string]interface{}{}})
+
+	current, err := g.Graph()
+	if err != nil {
+		t.Fatalf("Failed to read graph: %v", err)
+	}
+	current.Edges["checkout"] = []graph.Edge{{To: "checkout-db", Type: graph.EdgeTypeOwns}}
+	current.Edges["checkout-db"] = []graph.Edge{{To: "staging", Type: graph.EdgeTypeDeploy}}
+	deployed := map[string]interface{}{"deployment_id": "d1", "application": "checkout", "status": "succeeded", "created_at": soakDeployedAt.Add(-time.Minute).Format(time.RFC3339)}
+	deployments.AppendStatusChange(deployed, deployments.StatusChange{Status: "pending", Timestamp: soakDeployedAt.Add(-time.Minute)})
+	deployments.AppendStatusChange(deployed, deployments.StatusChange{Status: "succeeded", Timestamp: soakDeployedAt})
+	current.Edges["release-checkout-1"] = []graph.Edge{{To: "staging", Type: "deployment", Metadata: deployed}}
+	if err := g.Save(); err != nil {
+		t.Fatalf("Failed to save graph: %v", err)
+	}
+	return g
+}
+
+func TestSoakGate(t *testing.T) {
+	g := newSoakTestGraph(t)
+	gate := SoakGate{Rules: map[string]SoakRule{"prod": {Environment: "prod", After: "staging", Duration: 24 * time.Hour}}}
+
+	decision, err := gate.Evaluate(g, "checkout", "release-checkout-2", "prod", soakDeployedAt.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("Evaluate failed: %v", err)
+	}
+	if decision.Decision != "blocked" || !strings.Contains(decision.Reason, "2h0m0s of the required 24h0m0s") {
+		t.Errorf("Expected a 2h soak to be blocked, got %+v", decision)
+	}
+
+	decision, _ = gate.Evaluate(g, "checkout", "release-checkout-2", "prod", soakDeployedAt.Add(25*time.Hour))
+	if decision.Decision != "allowed" || decision.DeploymentID != "d1" || !decision.HealthySince.Equal(soakDeployedAt) {
+		t.Errorf("Expected a 25h soak to be allowed, got %+v", decision)
+	}
+
+	if decision, _ := gate.Evaluate(g, "checkout", "", "staging", soakDeployedAt); decision.Decision != "allowed" {
+		t.Errorf("Expected environments without a rule to be allowed, got %+v", decision)
+	}
+
+	node, _ := g.GetNode("checkout-db")
+	node.Metadata["health"] = "unhealthy"
+	g.AddNode(node)
+	decision, _ = gate.Evaluate(g, "checkout", "release-checkout-2", "prod", soakDeployedAt.Add(25*time.Hour))
+	if decision.Decision != "blocked" || !strings.Contains(decision.Reason, "checkout-db") {
+		t.Errorf("Expected unhealthy resources in staging to block, got %+v", decision)
+	}
+}
+
+func TestPolicyAgentPromotionGate(t *testing.T) {
+	g := newSoakTestGraph(t)
+	SetSoakGate(SoakGate{Rules: map[string]SoakRule{"prod": {Environment: "prod", After: "staging", Duration: 24 * time.Hour}}})
+	defer SetSoakGate(SoakGate{})
+	agent := &FrameworkPolicyAgent{
+		service: NewService(nil, g, "", nil),
+		logger:  logging.GetLogger().ForComponent("policy-agent"),
+	}
+
+	response, err := agent.handleEvent(context.Background(), &events.Event{ID: "evt-1", Subject: "policy.promotion", Payload: map[string]interface{}{
+		"intent":      "check soak time",
+		"application": "checkout",
+		"environment": "prod",
+	}})
+	if err != nil {
+		t.Fatalf("handleEvent failed: %v", err)
+	}
+	if response.Payload["decision"] != "allowed" {
+		t.Errorf("Expected a deployment that soaked since March to be allowed, got %v", response.Payload)
+	}
+}
@@ -64,9 +64,11 @@ type Policy struct {
 	NaturalLanguageRule string `json:"natural_language_rule"`
 	AIPromptTemplate    string `json:"ai_prompt_template,omitempty"`
 
-	// Enforcement configuration
-	Enforcement PolicyEnforcement `json:"enforcement"`
-	Priority    int               `json:"priority"`
+	// Enforcement configuration. EnvironmentEnforcement overrides Enforcement in the
+	// environments it names, e.g. warn in dev and approve in staging while prod blocks.
+	Enforcement            PolicyEnforcement            `json:"enforcement"`
+	EnvironmentEnforcement map[string]PolicyEnforcement `json:"environment_enforcement,omitempty"`
+	Priority               int                          `json:"priority"`
 
 	// AI configuration
 	RequiredConfidence float64 `json:"required_confidence"`
@@ -116,6 +118,11 @@ type PolicyEvaluation struct {
 	Confidence  float64      `json:"confidence"`
 	AIReasoning string       `json:"ai_reasoning,omitempty"`
 
+	// Status is the verdict after the policy's enforcement in the evaluated environment was
+	// applied; Verdict is the AI's verdict before it
+	Enforcement PolicyEnforcement `json:"enforcement,omitempty"`
+	Verdict     PolicyStatus      `json:"verdict,omitempty"`
+
 	// Actions and recommendations
 	RequiredActions []PolicyAction `json:"required_actions,omitempty"`
 	Recommendations []string       `json:"recommendations,omitempty"`
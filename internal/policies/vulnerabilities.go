@@ -0,0 +1,127 @@
+package policies
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/krzachariassen/ZTDP/internal/contracts"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+)
+
+// VulnerabilityGate decides which scanned service versions may be deployed
+type VulnerabilityGate struct {
+	MaxCritical int  // versions with more critical CVEs are blocked
+	RequireScan bool // block versions that have no scan report
+}
+
+var (
+	gateMu sync.RWMutex
+	gate   = VulnerabilityGate{MaxCritical: 0}
+)
+
+// SetVulnerabilityGate sets the gate the Policy Agent applies to deployments (called from main.go)
+func SetVulnerabilityGate(g VulnerabilityGate) {
+	gateMu.Lock()
+	defer gateMu.Unlock()
+	gate = g
+}
+
+// GetVulnerabilityGate returns the gate the Policy Agent applies to deployments
+func GetVulnerabilityGate() VulnerabilityGate {
+	gateMu.RLock()
+	defer gateMu.RUnlock()
+	return gate
+}
+
+// VersionFinding is the gate's view of one service version
+type VersionFinding struct {
+	Version  string         `json:"version"`
+	Scanned  bool           `json:"scanned"`
+	Critical int            `json:"critical"`
+	Summary  map[string]int `json:"summary,omitempty"`
+	CVEs     []string       `json:"critical_cves,omitempty"`
+}
+
+// VulnerabilityDecision is the result of applying the gate to a deployment
+type VulnerabilityDecision struct {
+	Decision string           `json:"decision"` // allowed | blocked
+	Reasons  []string         `json:"reasons,omitempty"`
+	Versions []VersionFinding `json:"versions"`
+}
+
+// Evaluate applies the gate to the given service version node IDs
+func (g VulnerabilityGate) Evaluate(globalGraph *graph.GlobalGraph, versionIDs []string) (*VulnerabilityDecision, error) {
+	nodes, err := globalGraph.Nodes()
+	if err != nil {
+		return nil, err
+	}
+	decision := &VulnerabilityDecision{Decision: "allowed", Versions: []VersionFinding{}}
+	for _, id := range versionIDs {
+		finding := VersionFinding{Version: id}
+		var report *contracts.ScanReport
+		if node, ok := nodes[id]; ok {
+			report, finding.Scanned = contracts.ScanReportFromMetadata(node.Metadata)
+		}
+
+		switch {
+		case !finding.Scanned:
+			if g.RequireScan {
+				decision.Reasons = append(decision.Reasons, fmt.Sprintf("%s has no vulnerability scan", id))
+			}
+		default:
+			finding.Summary = report.Summary()
+			finding.Critical = report.Count(contracts.SeverityCritical)
+			for _, v := range report.Vulnerabilities {
+				if v.Severity == contracts.SeverityCritical {
+					finding.CVEs = append(finding.CVEs, v.ID)
+				}
+			}
+			if finding.Critical > g.MaxCritical {
+				decision.Reasons = append(decision.Reasons, fmt.Sprintf("%s has %d critical CVEs (maximum %d): %v", id, finding.Critical, g.MaxCritical, finding.CVEs))
+			}
+		}
+		decision.Versions = append(decision.Versions, finding)
+	}
+	if len(decision.Reasons) > 0 {
+		decision.Decision = "blocked"
+	}
+	return decision, nil
+}
+
+// DeploymentVersions returns the service versions a deployment of the application ships: the
+// versions the release includes when it is in the graph, otherwise the latest version of
+// each of the application's services
+func DeploymentVersions(globalGraph *graph.GlobalGraph, appName, releaseID string) ([]string, error) {
+	edges, err := globalGraph.Edges()
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []string
+	for _, edge := range edges[releaseID] {
+		if edge.Type == "includes" {
+			versions = append(versions, edge.To)
+		}
+	}
+	if len(versions) > 0 {
+		return versions, nil
+	}
+
+	for _, owned := range edges[appName] {
+		if owned.Type != graph.EdgeTypeOwns {
+			continue
+		}
+		latest := ""
+		for _, edge := range edges[owned.To] {
+			if edge.Type == "has_version" {
+				latest = edge.To // versions are linked in creation order
+			}
+		}
+		if latest != "" {
+			versions = append(versions, latest)
+		}
+	}
+	sort.Strings(versions)
+	return versions, nil
+}
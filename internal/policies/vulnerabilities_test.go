@@ -0,0 +1,97 @@
+package policies
+
+import (
+	"context"
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/contracts"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+func newVulnerabilityTestGraph(t *testing.T) *graph.GlobalGraph {
+	t.Helper()
+	g := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	g.AddNode(&graph.Node{ID: "checkout", Kind: graph.KindApplication, Metadata: map[string]interface{}{"name": "checkout"}, Spec: map[string]interface{}{}})
+	g.AddNode(&graph.Node{ID: "checkout-api", Kind: graph.KindService, Metadata: map[string]interface{}{"name": "checkout-api"}, Spec: map[string]interface{}{}})
+
+	vulnerable := contracts.ScanReport{Scanner: "trivy", Vulnerabilities: []contracts.Vulnerability{
+		{ID: "CVE-2024-3094", Severity: contracts.SeverityCritical},
+		{ID: "CVE-2023-0001", Severity: contracts.SeverityHigh},
+	}}
+	clean := contracts.ScanReport{Scanner: "trivy"}
+	g.AddNode(&graph.Node{ID: "checkout-api:1.0.0", Kind: graph.KindServiceVersion, Metadata: map[string]interface{}{"name": "checkout-api", "scan": vulnerable.ToMetadata()}})
+	g.AddNode(&graph.Node{ID: "checkout-api:1.1.0", Kind: graph.KindServiceVersion, Metadata: map[string]interface{}{"name": "checkout-api", "scan": clean.ToMetadata()}})
+	g.AddNode(&graph.Node{ID: "checkout-api:1.2.0", Kind: graph.KindServiceVersion, Metadata: map[string]interface{}{"name": "checkout-api"}})
+
+	current, err := g.Graph()
+	if err != nil {
+		t.Fatalf("Failed to read graph: %v", err)
+	}
+	current.Edges["checkout"] = []graph.Edge{{To: "checkout-api", Type: graph.EdgeTypeOwns}}
+	current.Edges["checkout-api"] = []graph.Edge{
+		{To: "checkout-api:1.0.0", Type: "has_version"},
+		{To: "checkout-api:1.1.0", Type: "has_version"},
+	}
+	current.Edges["release-1"] = []graph.Edge{{To: "checkout-api:1.0.0", Type: "includes"}}
+	if err := g.Save(); err != nil {
+		t.Fatalf("Failed to save graph: %v", err)
+	}
+	return g
+}
+
+func TestVulnerabilityGate(t *testing.T) {
+	g := newVulnerabilityTestGraph(t)
+
+	versions, err := DeploymentVersions(g, "checkout", "release-1")
+	if err != nil || len(versions) != 1 || versions[0] != "checkout-api:1.0.0" {
+		t.Fatalf("Expected the release's version, got %v (%v)", versions, err)
+	}
+	versions, _ = DeploymentVersions(g, "checkout", "release-unknown")
+	if len(versions) != 1 || versions[0] != "checkout-api:1.1.0" {
+		t.Fatalf("Expected the latest version of each service, got %v", versions)
+	}
+
+	decision, err := VulnerabilityGate{MaxCritical: 0}.Evaluate(g, []string{"checkout-api:1.0.0", "checkout-api:1.2.0"})
+	if err != nil {
+		t.Fatalf("Evaluate failed: %v", err)
+	}
+	if decision.Decision != "blocked" || len(decision.Reasons) != 1 || decision.Versions[0].Critical != 1 {
+		t.Errorf("Expected the critical CVE to block, got %+v", decision)
+	}
+
+	if decision, _ := (VulnerabilityGate{MaxCritical: 1}).Evaluate(g, []string{"checkout-api:1.0.0"}); decision.Decision != "allowed" {
+		t.Errorf("Expected a higher threshold to allow the version, got %+v", decision)
+	}
+	if decision, _ := (VulnerabilityGate{RequireScan: true}).Evaluate(g, []string{"checkout-api:1.2.0"}); decision.Decision != "blocked" {
+		t.Errorf("Expected an unscanned version to be blocked when scans are required, got %+v", decision)
+	}
+}
+
+func TestPolicyAgentVulnerabilityGate(t *testing.T) {
+	g := newVulnerabilityTestGraph(t)
+	agent := &FrameworkPolicyAgent{
+		service: NewService(nil, g, "", nil),
+		logger:  logging.GetLogger().ForComponent("policy-agent"),
+	}
+
+	request := &events.Event{ID: "evt-1", Subject: "policy.vulnerabilities", Payload: map[string]interface{}{
+		"intent":      "check vulnerabilities",
+		"application": "checkout",
+		"release_id":  "release-1",
+	}}
+	response, err := agent.handleEvent(context.Background(), request)
+	if err != nil {
+		t.Fatalf("handleEvent failed: %v", err)
+	}
+	if response.Payload["decision"] != "blocked" {
+		t.Errorf("Expected the release with a critical CVE to be blocked, got %v", response.Payload)
+	}
+
+	request.Payload["release_id"] = ""
+	response, _ = agent.handleEvent(context.Background(), request)
+	if response.Payload["decision"] != "allowed" {
+		t.Errorf("Expected the latest clean version to be allowed, got %v", response.Payload)
+	}
+}
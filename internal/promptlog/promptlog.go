@@ -0,0 +1,256 @@
+// Package promptlog keeps a sample of full AI prompts and responses, redacted, apart from the
+// application logs so prompt regressions can be debugged without logging every payload
+package promptlog
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/krzachariassen/ZTDP/internal/ai"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/krzachariassen/ZTDP/internal/redaction"
+)
+
+// Entry is one sampled AI call
+type Entry struct {
+	ID            string    `json:"id"`
+	Timestamp     time.Time `json:"timestamp"`
+	Provider      string    `json:"provider,omitempty"`
+	Task          string    `json:"task,omitempty"` // the task hint the call was made with
+	CorrelationID string    `json:"correlation_id,omitempty"`
+	SystemPrompt  string    `json:"system_prompt"`
+	UserPrompt    string    `json:"user_prompt"`
+	Response      string    `json:"response"`
+	Error         string    `json:"error,omitempty"`
+	DurationMS    int64     `json:"duration_ms"`
+	Streamed      bool      `json:"streamed,omitempty"`
+}
+
+// Query narrows the entries returned by a store
+type Query struct {
+	Task          string
+	CorrelationID string
+	Contains      string // case-insensitive text in either prompt or the response
+	Since         time.Time
+	ErrorsOnly    bool
+	Limit         int // most recent entries kept when more match
+}
+
+// Matches reports whether the entry satisfies the query
+func (q Query) Matches(e Entry) bool {
+	if q.Task != "" && e.Task != q.Task {
+		return false
+	}
+	if q.CorrelationID != "" && e.CorrelationID != q.CorrelationID {
+		return false
+	}
+	if !q.Since.IsZero() && e.Timestamp.Before(q.Since) {
+		return false
+	}
+	if q.ErrorsOnly && e.Error == "" {
+		return false
+	}
+	if q.Contains != "" {
+		text := strings.ToLower(q.Contains)
+		if !strings.Contains(strings.ToLower(e.SystemPrompt), text) &&
+			!strings.Contains(strings.ToLower(e.UserPrompt), text) &&
+			!strings.Contains(strings.ToLower(e.Response), text) {
+			return false
+		}
+	}
+	return true
+}
+
+// Store retains the most recent entries in memory, dropping those older than the retention
+type Store struct {
+	mu        sync.RWMutex
+	entries   []Entry
+	next      int
+	full      bool
+	retention time.Duration // zero keeps entries until they are overwritten
+	now       func() time.Time
+}
+
+// NewStore creates a store holding at most capacity entries for at most retention
+func NewStore(capacity int, retention time.Duration) *Store {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &Store{entries: make([]Entry, capacity), retention: retention, now: time.Now}
+}
+
+// Record retains an entry, overwriting the oldest one once the store is full
+func (s *Store) Record(e Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[s.next] = e
+	s.next = (s.next + 1) % len(s.entries)
+	if s.next == 0 {
+		s.full = true
+	}
+}
+
+// Query returns matching entries within the retention, oldest first
+func (s *Store) Query(q Query) []Entry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	start, count := 0, s.next
+	if s.full {
+		start, count = s.next, len(s.entries)
+	}
+	var cutoff time.Time
+	if s.retention > 0 {
+		cutoff = s.now().Add(-s.retention)
+	}
+
+	matches := []Entry{}
+	for i := 0; i < count; i++ {
+		e := s.entries[(start+i)%len(s.entries)]
+		if e.Timestamp.Before(cutoff) || !q.Matches(e) {
+			continue
+		}
+		matches = append(matches, e)
+	}
+	if q.Limit > 0 && len(matches) > q.Limit {
+		matches = matches[len(matches)-q.Limit:]
+	}
+	return matches
+}
+
+// Get returns the entry with the ID when it is still retained
+func (s *Store) Get(id string) (Entry, bool) {
+	for _, e := range s.Query(Query{}) {
+		if e.ID == id {
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Sampler decides which AI calls are logged and records them redacted
+type Sampler struct {
+	store  *Store
+	mu     sync.Mutex
+	rate   float64
+	random func() float64
+}
+
+// NewSampler logs the given fraction of AI calls, between 0 (none) and 1 (all), into store
+func NewSampler(store *Store, rate float64) *Sampler {
+	return &Sampler{store: store, rate: rate, random: rand.Float64}
+}
+
+// SetRate changes the fraction of calls logged
+func (s *Sampler) SetRate(rate float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rate = rate
+}
+
+// sample reports whether the next call is logged
+func (s *Sampler) sample() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rate > 0 && (s.rate >= 1 || s.random() < s.rate)
+}
+
+// record redacts the call and stores it
+func (s *Sampler) record(ctx context.Context, provider string, systemPrompt, userPrompt, response string, err error, duration time.Duration, streamed bool) {
+	redactor := redaction.Default()
+	entry := Entry{
+		ID:            uuid.NewString(),
+		Timestamp:     time.Now().UTC(),
+		Provider:      provider,
+		Task:          string(ai.TaskFromContext(ctx)),
+		CorrelationID: logging.CorrelationIDFromContext(ctx),
+		SystemPrompt:  redactor.String(systemPrompt),
+		UserPrompt:    redactor.String(userPrompt),
+		Response:      redactor.String(response),
+		DurationMS:    duration.Milliseconds(),
+		Streamed:      streamed,
+	}
+	if err != nil {
+		entry.Error = redactor.String(err.Error())
+	}
+	s.store.Record(entry)
+}
+
+// WrapProvider logs a sample of the calls made through provider. Streaming providers stay
+// streaming; their chunks are logged once the stream ends.
+func (s *Sampler) WrapProvider(provider ai.AIProvider) ai.AIProvider {
+	logged := &loggedProvider{AIProvider: provider, sampler: s}
+	if _, ok := provider.(ai.StreamingAIProvider); ok {
+		return &loggedStreamingProvider{loggedProvider: logged}
+	}
+	return logged
+}
+
+type loggedProvider struct {
+	ai.AIProvider
+	sampler *Sampler
+}
+
+func (p *loggedProvider) CallAI(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	if !p.sampler.sample() {
+		return p.AIProvider.CallAI(ctx, systemPrompt, userPrompt)
+	}
+	started := time.Now()
+	response, err := p.AIProvider.CallAI(ctx, systemPrompt, userPrompt)
+	p.sampler.record(ctx, p.providerName(), systemPrompt, userPrompt, response, err, time.Since(started), false)
+	return response, err
+}
+
+func (p *loggedProvider) PromptBudget(ctx context.Context) int {
+	return ai.PromptBudget(ctx, p.AIProvider)
+}
+
+func (p *loggedProvider) providerName() string {
+	if info := p.AIProvider.GetProviderInfo(); info != nil {
+		return info.Name
+	}
+	return ""
+}
+
+type loggedStreamingProvider struct {
+	*loggedProvider
+}
+
+func (p *loggedStreamingProvider) CallAIStream(ctx context.Context, systemPrompt, userPrompt string) (<-chan ai.StreamChunk, error) {
+	streaming := p.AIProvider.(ai.StreamingAIProvider)
+	if !p.sampler.sample() {
+		return streaming.CallAIStream(ctx, systemPrompt, userPrompt)
+	}
+	started := time.Now()
+	chunks, err := streaming.CallAIStream(ctx, systemPrompt, userPrompt)
+	if err != nil {
+		p.sampler.record(ctx, p.providerName(), systemPrompt, userPrompt, "", err, time.Since(started), true)
+		return nil, err
+	}
+
+	// Relay the chunks as they arrive and log the whole response when the stream ends
+	relayed := make(chan ai.StreamChunk)
+	go func() {
+		defer close(relayed)
+		var response strings.Builder
+		var streamErr error
+		for chunk := range chunks {
+			response.WriteString(chunk.Content)
+			if chunk.Err != nil {
+				streamErr = chunk.Err
+			}
+			// A caller that gave up stops reading; keep draining so the upstream can finish
+			select {
+			case relayed <- chunk:
+			case <-ctx.Done():
+			}
+		}
+		p.sampler.record(ctx, p.providerName(), systemPrompt, userPrompt, response.String(), streamErr, time.Since(started), true)
+	}()
+	return relayed, nil
+}
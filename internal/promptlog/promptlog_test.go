@@ -0,0 +1,139 @@
+package promptlog
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/ai"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// echoProvider answers with the user prompt, streaming it word by word when streaming is set
+type echoProvider struct {
+	err   error
+	calls int
+}
+
+func (p *echoProvider) CallAI(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	p.calls++
+	return "echo: " + userPrompt, p.err
+}
+
+func (p *echoProvider) GetProviderInfo() *ai.ProviderInfo { return &ai.ProviderInfo{Name: "echo"} }
+
+func (p *echoProvider) Close() error { return nil }
+
+type streamingEchoProvider struct{ echoProvider }
+
+func (p *streamingEchoProvider) CallAIStream(ctx context.Context, systemPrompt, userPrompt string) (<-chan ai.StreamChunk, error) {
+	chunks := make(chan ai.StreamChunk, 2)
+	chunks <- ai.StreamChunk{Content: "echo: "}
+	chunks <- ai.StreamChunk{Content: userPrompt}
+	close(chunks)
+	return chunks, nil
+}
+
+func TestSamplerLogsRedactedCalls(t *testing.T) {
+	store := NewStore(10, 0)
+	sampler := NewSampler(store, 1)
+	provider := &echoProvider{}
+	wrapped := sampler.WrapProvider(provider)
+
+	ctx := logging.WithCorrelationID(ai.WithTask(context.Background(), ai.TaskPlanning), "corr-1")
+	response, err := wrapped.CallAI(ctx, "You are a planner", "deploy checkout with token=abc123")
+	require.NoError(t, err)
+	assert.Equal(t, "echo: deploy checkout with token=abc123", response, "callers get the unredacted response")
+
+	entries := store.Query(Query{})
+	require.Len(t, entries, 1)
+	entry := entries[0]
+	assert.Equal(t, "echo", entry.Provider)
+	assert.Equal(t, string(ai.TaskPlanning), entry.Task)
+	assert.Equal(t, "corr-1", entry.CorrelationID)
+	assert.Equal(t, "You are a planner", entry.SystemPrompt)
+	assert.NotContains(t, entry.UserPrompt, "abc123")
+	assert.NotContains(t, entry.Response, "abc123")
+	stored, ok := store.Get(entry.ID)
+	assert.True(t, ok)
+	assert.Equal(t, entry, stored)
+
+	provider.err = errors.New("rate limited")
+	_, err = wrapped.CallAI(context.Background(), "system", "again")
+	assert.Error(t, err)
+	failed := store.Query(Query{ErrorsOnly: true})
+	require.Len(t, failed, 1)
+	assert.Equal(t, "rate limited", failed[0].Error)
+}
+
+func TestSamplerSamplesCalls(t *testing.T) {
+	store := NewStore(10, 0)
+	sampler := NewSampler(store, 0.5)
+	draws := []float64{0.2, 0.7, 0.4}
+	sampler.random = func() float64 {
+		draw := draws[0]
+		draws = draws[1:]
+		return draw
+	}
+	provider := &echoProvider{}
+	wrapped := sampler.WrapProvider(provider)
+	for _, prompt := range []string{"one", "two", "three"} {
+		_, err := wrapped.CallAI(context.Background(), "system", prompt)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 3, provider.calls, "every call reaches the provider")
+	entries := store.Query(Query{})
+	require.Len(t, entries, 2)
+	assert.Equal(t, "one", entries[0].UserPrompt)
+	assert.Equal(t, "three", entries[1].UserPrompt)
+
+	sampler.SetRate(0)
+	_, err := wrapped.CallAI(context.Background(), "system", "four")
+	require.NoError(t, err)
+	assert.Len(t, store.Query(Query{}), 2)
+}
+
+func TestSamplerLogsStreams(t *testing.T) {
+	store := NewStore(10, 0)
+	wrapped := NewSampler(store, 1).WrapProvider(&streamingEchoProvider{})
+	_, streaming := wrapped.(ai.StreamingAIProvider)
+	require.True(t, streaming, "wrapping keeps streaming providers streaming")
+
+	chunks, err := ai.CallAIStream(context.Background(), wrapped, "system", "status")
+	require.NoError(t, err)
+	response, err := ai.CollectStream(chunks, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "echo: status", response)
+
+	require.Eventually(t, func() bool { return len(store.Query(Query{})) == 1 }, time.Second, 10*time.Millisecond)
+	entry := store.Query(Query{})[0]
+	assert.True(t, entry.Streamed)
+	assert.Equal(t, "echo: status", entry.Response)
+}
+
+func TestStoreRetentionAndCapacity(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	store := NewStore(3, time.Hour)
+	store.now = func() time.Time { return now }
+
+	store.Record(Entry{ID: "expired", Timestamp: now.Add(-2 * time.Hour)})
+	for _, id := range []string{"a", "b", "c"} {
+		store.Record(Entry{ID: id, Timestamp: now.Add(-time.Minute), Task: "planning", UserPrompt: "Deploy " + id})
+	}
+	ids := []string{}
+	for _, entry := range store.Query(Query{}) {
+		ids = append(ids, entry.ID)
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, ids, "the oldest entry is overwritten")
+
+	store.now = func() time.Time { return now.Add(2 * time.Hour) }
+	assert.Empty(t, store.Query(Query{}), "entries past the retention are dropped")
+
+	store.now = func() time.Time { return now }
+	assert.Len(t, store.Query(Query{Contains: "deploy B"}), 1)
+	assert.Len(t, store.Query(Query{Limit: 2}), 2)
+	assert.Empty(t, store.Query(Query{Task: "classification"}))
+}
@@ -0,0 +1,49 @@
+package provenance
+
+import (
+	"context"
+
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// operation is who started what is being done in a context
+type operation struct {
+	initiator string
+	actor     string
+}
+
+type operationKey struct{}
+
+// WithOperation marks what is done in ctx, including the graph saves made through a graph bound
+// to it with GlobalGraph.WithContext, as started by initiator through actor. The orchestrator
+// marks chat requests as InitiatorAI and the API marks direct calls as InitiatorHuman.
+func WithOperation(ctx context.Context, initiator, actor string) context.Context {
+	return context.WithValue(ctx, operationKey{}, operation{initiator: initiator, actor: actor})
+}
+
+// Attribute returns the initiator, actors and correlation IDs of what is done in ctx. Agents
+// handling a request without a marked operation act for the AI; anything else is the system.
+func Attribute(ctx context.Context) (string, []string, []string) {
+	if ctx == nil {
+		return InitiatorSystem, nil, nil
+	}
+	var actors, correlations []string
+	if correlationID := logging.CorrelationIDFromContext(ctx); correlationID != "" {
+		correlations = []string{correlationID}
+	}
+	initiator := InitiatorSystem
+	op, marked := ctx.Value(operationKey{}).(operation)
+	if marked {
+		initiator = op.initiator
+		if op.actor != "" {
+			actors = append(actors, op.actor)
+		}
+	}
+	if agentID := logging.AgentIDFromContext(ctx); agentID != "" && agentID != op.actor {
+		if !marked {
+			initiator = InitiatorAI
+		}
+		actors = append(actors, agentID)
+	}
+	return initiator, actors, correlations
+}
@@ -0,0 +1,23 @@
+package provenance
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Middleware marks the context of API calls that can change the platform as human-initiated.
+// Chat endpoints are skipped: the orchestrator marks what it does for a chat request itself.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+		if strings.HasPrefix(r.URL.Path, "/v3/ai/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(WithOperation(r.Context(), InitiatorHuman, r.Method+" "+r.URL.Path)))
+	})
+}
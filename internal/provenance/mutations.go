@@ -0,0 +1,60 @@
+package provenance
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/krzachariassen/ZTDP/internal/graphwatch"
+)
+
+// Change is one node or edge a mutation added, updated or removed
+type Change struct {
+	Op     string `json:"op"`   // added | updated | removed
+	Kind   string `json:"kind"` // node | edge
+	ID     string `json:"id"`   // node ID, or from->to:type for edges
+	Digest string `json:"digest,omitempty"`
+}
+
+// Watch signs a mutation record for every save stored through watch. Checks run before the
+// save is stored, so changes they reject are never signed.
+func Watch(watch *graphwatch.Backend, service *Service) {
+	watch.Subscribe(service.signSave)
+}
+
+// signSave signs what a save changed; emptying the graph is not a mutation anyone made
+func (s *Service) signSave(save graphwatch.Save) {
+	if save.Cleared {
+		return
+	}
+	changes := make([]Change, 0, len(save.Changes))
+	var entities []string
+	for _, c := range save.Changes {
+		change := Change{Op: c.Op, Kind: c.Kind, ID: c.ID}
+		var value interface{}
+		if c.Kind == graphwatch.KindNode {
+			entities = append(entities, c.ID)
+			value = save.Node(c)
+		} else {
+			change.ID = c.ID + "->" + c.To + ":" + c.Type
+			value = save.Edge(c)
+		}
+		if c.Op != graphwatch.OpRemoved {
+			change.Digest = digestOf(value)
+		}
+		changes = append(changes, change)
+	}
+	subject := changes[0].ID
+	if len(entities) > 0 {
+		subject = entities[0]
+	}
+	if _, err := s.SignContext(save.Context, Record{Type: RecordMutation, Subject: subject, Entities: entities}, changes); err != nil {
+		s.logger.Warn("⚠️ Failed to sign graph mutation: %v", err)
+	}
+}
+
+func digestOf(value interface{}) string {
+	data, _ := json.Marshal(value)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
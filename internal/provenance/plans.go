@@ -0,0 +1,30 @@
+package provenance
+
+import (
+	"github.com/krzachariassen/ZTDP/internal/plans"
+)
+
+// AttachPlans signs every plan change. Proposals are attributed to whoever proposed them (an
+// agent answering a chat request, or a person through the API), revisions carry their author,
+// and approving or discarding is a person's decision whichever channel carried it.
+func (s *Service) AttachPlans(service *plans.Service) {
+	service.OnChange(func(plan *plans.Plan, change string) {
+		record := Record{Type: RecordPlan, Subject: plan.ID, Initiator: InitiatorHuman, Actors: []string{"user"}}
+		switch change {
+		case plans.ChangeProposed:
+			if plan.ProposedBy != "" && plan.ProposedBy != plans.AuthorUser {
+				record.Initiator, record.Actors = InitiatorAI, []string{plan.ProposedBy}
+			}
+		case plans.ChangeRevised:
+			if last := plan.Revisions[len(plan.Revisions)-1]; last.Author == plans.AuthorAI {
+				record.Initiator, record.Actors = InitiatorAI, []string{"plan-reviser"}
+			}
+		}
+		if plan.ConversationID != "" {
+			record.Entities = []string{"conversation:" + plan.ConversationID}
+		}
+		if _, err := s.Sign(record, map[string]interface{}{"change": change, "plan": plan}); err != nil {
+			s.logger.Warn("⚠️ Failed to sign %s plan %s: %v", change, plan.ID, err)
+		}
+	})
+}
@@ -0,0 +1,318 @@
+// Package provenance signs execution plans and graph mutations with a key held by each
+// orchestrator instance, recording whether the change was initiated by the AI or by a person,
+// so security teams can tell autonomous changes apart from API calls and verify neither was
+// altered after the fact.
+package provenance
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// Initiators
+const (
+	InitiatorAI     = "ai"     // a chat request the orchestrator acted on
+	InitiatorHuman  = "human"  // a direct API call
+	InitiatorSystem = "system" // startup reconciliation and background jobs
+)
+
+// Record types
+const (
+	RecordPlan     = "plan"
+	RecordMutation = "mutation"
+)
+
+var (
+	// ErrRecordNotFound is returned for unknown record IDs
+	ErrRecordNotFound = errors.New("provenance record not found")
+	// ErrUnknownKey is returned when a record was signed by a key this instance does not trust
+	ErrUnknownKey = errors.New("record signed by an unknown key")
+	// ErrInvalidSignature is returned when a record's payload or fields were altered after signing
+	ErrInvalidSignature = errors.New("invalid provenance signature")
+)
+
+// Record is a signed statement of who initiated a change and what it was
+type Record struct {
+	ID             string          `json:"id"`
+	Type           string          `json:"type"`               // plan | mutation
+	Subject        string          `json:"subject"`            // plan ID, or the first changed node for mutations
+	Entities       []string        `json:"entities,omitempty"` // every node a mutation touched
+	Initiator      string          `json:"initiator"`
+	Actors         []string        `json:"actors,omitempty"`          // agents, users or endpoints behind the change
+	CorrelationIDs []string        `json:"correlation_ids,omitempty"` // requests the change belongs to
+	Instance       string          `json:"instance"`                  // orchestrator instance that signed
+	KeyID          string          `json:"key_id"`
+	Payload        json.RawMessage `json:"payload"`
+	Digest         string          `json:"digest"` // sha256 of the compacted payload
+	SignedAt       time.Time       `json:"signed_at"`
+	Signature      string          `json:"signature"` // base64 ed25519 signature over the record without this field
+}
+
+// signingBytes is the canonical encoding the signature covers
+func (r *Record) signingBytes() ([]byte, error) {
+	unsigned := *r
+	unsigned.Signature = ""
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, r.Payload); err != nil {
+		return nil, fmt.Errorf("invalid payload: %w", err)
+	}
+	unsigned.Payload = compact.Bytes()
+	return json.Marshal(unsigned)
+}
+
+func payloadDigest(payload json.RawMessage) (string, error) {
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, payload); err != nil {
+		return "", fmt.Errorf("invalid payload: %w", err)
+	}
+	sum := sha256.Sum256(compact.Bytes())
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Signer holds an orchestrator instance's signing key
+type Signer struct {
+	instance string
+	key      ed25519.PrivateKey
+	keyID    string
+}
+
+// NewSigner creates a signer for instance from an ed25519 private key
+func NewSigner(instance string, key ed25519.PrivateKey) *Signer {
+	return &Signer{instance: instance, key: key, keyID: KeyID(key.Public().(ed25519.PublicKey))}
+}
+
+// GenerateSigner creates a signer with a fresh key; its records can only be verified while
+// the instance runs unless the public key is exported to a verifier
+func GenerateSigner(instance string) (*Signer, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return NewSigner(instance, key), nil
+}
+
+// LoadSigner reads the base64 ed25519 seed at path, creating it with a new key on first use
+func LoadSigner(instance, path string) (*Signer, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		signer, err := GenerateSigner(instance)
+		if err != nil {
+			return nil, err
+		}
+		seed := base64.StdEncoding.EncodeToString(signer.key.Seed())
+		if err := os.WriteFile(path, []byte(seed+"\n"), 0o600); err != nil {
+			return nil, fmt.Errorf("provenance key %s: %w", path, err)
+		}
+		return signer, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("provenance key %s: %w", path, err)
+	}
+	seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("provenance key %s: expected a base64 %d-byte ed25519 seed", path, ed25519.SeedSize)
+	}
+	return NewSigner(instance, ed25519.NewKeyFromSeed(seed)), nil
+}
+
+// KeyID identifies a public key by the first 16 hex characters of its sha256
+func KeyID(publicKey ed25519.PublicKey) string {
+	sum := sha256.Sum256(publicKey)
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// Key is a public key records can be verified against
+type Key struct {
+	KeyID     string `json:"key_id"`
+	Instance  string `json:"instance,omitempty"`
+	PublicKey string `json:"public_key"` // base64
+	Local     bool   `json:"local"`      // this instance's own signing key
+}
+
+// ListFilter selects records; zero values match everything
+type ListFilter struct {
+	Type      string
+	Initiator string
+	Subject   string // matches the subject or any entity a mutation touched
+	Since     time.Time
+	Limit     int
+}
+
+// Service signs, keeps and verifies provenance records
+type Service struct {
+	signer *Signer
+	store  Store
+	logger *logging.Logger
+	now    func() time.Time
+
+	mu      sync.RWMutex
+	trusted map[string]Key
+	seq     int
+}
+
+// NewService creates a service that signs with signer and keeps its records in store
+func NewService(signer *Signer, store Store) *Service {
+	s := &Service{
+		signer:  signer,
+		store:   store,
+		logger:  logging.GetLogger().ForComponent("provenance"),
+		now:     time.Now,
+		trusted: map[string]Key{},
+	}
+	public := signer.key.Public().(ed25519.PublicKey)
+	s.trusted[signer.keyID] = Key{KeyID: signer.keyID, Instance: signer.instance, PublicKey: base64.StdEncoding.EncodeToString(public), Local: true}
+	return s
+}
+
+// Trust accepts records signed by another orchestrator instance's key (base64 ed25519 public key)
+func (s *Service) Trust(instance, publicKey string) (string, error) {
+	raw, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(raw) != ed25519.PublicKeySize {
+		return "", fmt.Errorf("expected a base64 %d-byte ed25519 public key", ed25519.PublicKeySize)
+	}
+	keyID := KeyID(raw)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.trusted[keyID]; !exists {
+		s.trusted[keyID] = Key{KeyID: keyID, Instance: instance, PublicKey: publicKey}
+	}
+	return keyID, nil
+}
+
+// Keys returns the keys records are verified against, this instance's first
+func (s *Service) Keys() []Key {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := []Key{s.trusted[s.signer.keyID]}
+	for id, key := range s.trusted {
+		if id != s.signer.keyID {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Sign completes the record's payload, digest and signature and stores it. The caller sets
+// Type, Subject, Entities and the attribution fields.
+func (s *Service) Sign(record Record, payload interface{}) (*Record, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode provenance payload: %w", err)
+	}
+	digest, err := payloadDigest(data)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.seq++
+	seq := s.seq
+	s.mu.Unlock()
+	now := s.now().UTC()
+	record.ID = fmt.Sprintf("prov-%d-%d", now.UnixNano(), seq)
+	record.Instance = s.signer.instance
+	record.KeyID = s.signer.keyID
+	record.Payload = data
+	record.Digest = digest
+	record.SignedAt = now
+	record.Signature = ""
+	message, err := record.signingBytes()
+	if err != nil {
+		return nil, err
+	}
+	record.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(s.signer.key, message))
+
+	if err := s.store.Add(&record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// SignContext signs a change attributed to the operation ctx belongs to
+func (s *Service) SignContext(ctx context.Context, record Record, payload interface{}) (*Record, error) {
+	record.Initiator, record.Actors, record.CorrelationIDs = Attribute(ctx)
+	return s.Sign(record, payload)
+}
+
+// Verify checks a record's payload digest and signature against the trusted keys
+func (s *Service) Verify(record *Record) error {
+	s.mu.RLock()
+	key, ok := s.trusted[record.KeyID]
+	s.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownKey, record.KeyID)
+	}
+
+	digest, err := payloadDigest(record.Payload)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	if digest != record.Digest {
+		return fmt.Errorf("%w: payload digest mismatch", ErrInvalidSignature)
+	}
+	signature, err := base64.StdEncoding.DecodeString(record.Signature)
+	if err != nil {
+		return fmt.Errorf("%w: signature is not base64", ErrInvalidSignature)
+	}
+	message, err := record.signingBytes()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	public, _ := base64.StdEncoding.DecodeString(key.PublicKey)
+	if !ed25519.Verify(public, message, signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Get returns a record by ID
+func (s *Service) Get(id string) (*Record, error) {
+	return s.store.Get(id)
+}
+
+// List returns matching records, newest first
+func (s *Service) List(filter ListFilter) ([]*Record, error) {
+	return s.store.List(filter)
+}
+
+// matches reports whether record passes the filter, ignoring Limit
+func (f ListFilter) matches(record *Record) bool {
+	if f.Type != "" && record.Type != f.Type {
+		return false
+	}
+	if f.Initiator != "" && record.Initiator != f.Initiator {
+		return false
+	}
+	if f.Subject != "" && record.Subject != f.Subject && !contains(record.Entities, f.Subject) {
+		return false
+	}
+	return f.Since.IsZero() || !record.SignedAt.Before(f.Since)
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// KeyID returns the ID of the signer's public key
+func (s *Signer) KeyID() string {
+	return s.keyID
+}
@@ -0,0 +1,227 @@
+package provenance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/graphwatch"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/krzachariassen/ZTDP/internal/plans"
+)
+
+func newTestService(t *testing.T, instance string) *Service {
+	t.Helper()
+	signer, err := GenerateSigner(instance)
+	if err != nil {
+		t.Fatalf("GenerateSigner: %v", err)
+	}
+	return NewService(signer, NewMemoryStore(100))
+}
+
+func list(t *testing.T, s *Service, filter ListFilter) []*Record {
+	t.Helper()
+	records, err := s.List(filter)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	return records
+}
+
+func TestSignAndVerify(t *testing.T) {
+	s := newTestService(t, "ztdp-1")
+	record, err := s.Sign(Record{Type: RecordPlan, Subject: "plan-1", Initiator: InitiatorAI}, map[string]string{"goal": "deploy"})
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	if record.Instance != "ztdp-1" || record.Signature == "" || record.Digest == "" {
+		t.Fatalf("record not completed: %+v", record)
+	}
+	if err := s.Verify(record); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+
+	// Records survive a JSON round trip, as when verified through the API
+	data, _ := json.Marshal(record)
+	var decoded Record
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if err := s.Verify(&decoded); err != nil {
+		t.Fatalf("Verify after round trip: %v", err)
+	}
+
+	tampered := decoded
+	tampered.Initiator = InitiatorHuman
+	if err := s.Verify(&tampered); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expected ErrInvalidSignature for altered initiator, got %v", err)
+	}
+	tampered = decoded
+	tampered.Payload = json.RawMessage(`{"goal":"delete"}`)
+	if err := s.Verify(&tampered); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expected ErrInvalidSignature for altered payload, got %v", err)
+	}
+}
+
+func TestVerifyAcrossInstances(t *testing.T) {
+	a := newTestService(t, "ztdp-a")
+	b := newTestService(t, "ztdp-b")
+	record, err := a.Sign(Record{Type: RecordMutation, Subject: "checkout"}, []Change{{Op: "added", Kind: "node", ID: "checkout"}})
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	if err := b.Verify(record); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("expected ErrUnknownKey, got %v", err)
+	}
+
+	local := a.Keys()[0]
+	if !local.Local || local.Instance != "ztdp-a" {
+		t.Fatalf("expected the local key first, got %+v", local)
+	}
+	if _, err := b.Trust("ztdp-a", local.PublicKey); err != nil {
+		t.Fatalf("Trust: %v", err)
+	}
+	if err := b.Verify(record); err != nil {
+		t.Fatalf("Verify with trusted key: %v", err)
+	}
+	if _, err := b.Trust("bad", "not-a-key"); err == nil {
+		t.Fatal("expected an invalid public key to be rejected")
+	}
+}
+
+func TestLoadSignerPersistsKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "provenance.key")
+	first, err := LoadSigner("ztdp-1", path)
+	if err != nil {
+		t.Fatalf("LoadSigner (create): %v", err)
+	}
+	second, err := LoadSigner("ztdp-1", path)
+	if err != nil {
+		t.Fatalf("LoadSigner (reload): %v", err)
+	}
+	if first.KeyID() != second.KeyID() {
+		t.Fatalf("expected the same key after reload, got %s and %s", first.KeyID(), second.KeyID())
+	}
+}
+
+func TestAttribution(t *testing.T) {
+	ctx := context.Background()
+	if initiator, actors, _ := Attribute(ctx); initiator != InitiatorSystem || actors != nil {
+		t.Fatalf("expected system without an operation, got %s %v", initiator, actors)
+	}
+
+	ai := WithOperation(logging.WithCorrelationID(ctx, "corr-1"), InitiatorAI, "orchestrator")
+	initiator, actors, correlations := Attribute(ai)
+	if initiator != InitiatorAI || len(actors) != 1 || actors[0] != "orchestrator" || correlations[0] != "corr-1" {
+		t.Fatalf("unexpected AI attribution: %s %v %v", initiator, actors, correlations)
+	}
+
+	// Concurrent operations do not mix: each context carries its own
+	human := WithOperation(logging.WithCorrelationID(ctx, "corr-2"), InitiatorHuman, "POST /v1/applications")
+	if initiator, _, correlations := Attribute(human); initiator != InitiatorHuman || correlations[0] != "corr-2" {
+		t.Fatalf("unexpected human attribution: %s %v", initiator, correlations)
+	}
+
+	// Agents handling a request act for the AI, and join the actors of a marked operation
+	if initiator, actors, _ := Attribute(logging.WithAgentID(ctx, "deployment-agent")); initiator != InitiatorAI || actors[0] != "deployment-agent" {
+		t.Fatalf("expected an agent to act for the AI, got %s %v", initiator, actors)
+	}
+	if _, actors, _ := Attribute(logging.WithAgentID(ai, "deployment-agent")); len(actors) != 2 {
+		t.Fatalf("expected the orchestrator and the agent, got %v", actors)
+	}
+}
+
+func TestWatchSignsMutations(t *testing.T) {
+	s := newTestService(t, "ztdp-1")
+	watch := graphwatch.NewBackend(graph.NewMemoryGraph())
+	Watch(watch, s)
+	g := graph.NewGlobalGraph(watch)
+
+	ctx := WithOperation(logging.WithCorrelationID(context.Background(), "corr-1"), InitiatorAI, "orchestrator")
+	g.WithContext(ctx).AddNode(&graph.Node{ID: "checkout", Kind: graph.KindApplication, Metadata: map[string]interface{}{}, Spec: map[string]interface{}{}})
+
+	records := list(t, s, ListFilter{Type: RecordMutation})
+	if len(records) != 1 {
+		t.Fatalf("expected 1 mutation record, got %d", len(records))
+	}
+	added := records[0]
+	if added.Initiator != InitiatorAI || added.Subject != "checkout" || added.CorrelationIDs[0] != "corr-1" {
+		t.Fatalf("unexpected record: %+v", added)
+	}
+	var changes []Change
+	if err := json.Unmarshal(added.Payload, &changes); err != nil {
+		t.Fatalf("payload: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Op != "added" || changes[0].Kind != "node" {
+		t.Fatalf("unexpected changes: %+v", changes)
+	}
+
+	node, _ := g.GetNode("checkout")
+	node.Metadata["owner"] = "team-a"
+	if err := g.UpdateNode(node); err != nil {
+		t.Fatalf("UpdateNode: %v", err)
+	}
+	if err := g.DeleteNode("checkout"); err != nil {
+		t.Fatalf("DeleteNode: %v", err)
+	}
+
+	records = list(t, s, ListFilter{Subject: "checkout"})
+	if len(records) != 3 {
+		t.Fatalf("expected 3 records for checkout, got %d", len(records))
+	}
+	if records[0].Initiator != InitiatorSystem {
+		t.Fatalf("expected untracked changes to be attributed to the system, got %s", records[0].Initiator)
+	}
+	if err := json.Unmarshal(records[0].Payload, &changes); err != nil || changes[0].Op != "removed" {
+		t.Fatalf("expected the newest record to remove checkout, got %s", records[0].Payload)
+	}
+	if err := json.Unmarshal(records[1].Payload, &changes); err != nil || changes[0].Op != "updated" {
+		t.Fatalf("expected an update record, got %s", records[1].Payload)
+	}
+
+	// Saving an unchanged graph signs nothing
+	if err := g.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if got := len(list(t, s, ListFilter{})); got != 3 {
+		t.Fatalf("expected no record for an unchanged save, got %d records", got)
+	}
+}
+
+func TestAttachPlansSignsChanges(t *testing.T) {
+	s := newTestService(t, "ztdp-1")
+	planService := plans.NewService(graph.NewGlobalGraph(graph.NewMemoryGraph()), nil)
+	s.AttachPlans(planService)
+
+	plan, err := planService.Propose(plans.Plan{
+		ConversationID: "conv-1",
+		ProposedBy:     "deployment-agent",
+		Steps:          []plans.Step{{Action: "deploy", Target: "checkout"}},
+	})
+	if err != nil {
+		t.Fatalf("Propose: %v", err)
+	}
+	if _, err := planService.SetStatus(plan.ID, plans.StatusApproved); err != nil {
+		t.Fatalf("SetStatus: %v", err)
+	}
+
+	records := list(t, s, ListFilter{Type: RecordPlan, Subject: plan.ID})
+	if len(records) != 2 {
+		t.Fatalf("expected 2 plan records, got %d", len(records))
+	}
+	approved, proposed := records[0], records[1]
+	if proposed.Initiator != InitiatorAI || !contains(proposed.Actors, "deployment-agent") || !contains(proposed.Entities, "conversation:conv-1") {
+		t.Fatalf("unexpected proposal record: %+v", proposed)
+	}
+	if approved.Initiator != InitiatorHuman {
+		t.Fatalf("expected approval to be human-initiated, got %s", approved.Initiator)
+	}
+	for _, record := range records {
+		if err := s.Verify(record); err != nil {
+			t.Fatalf("Verify %s: %v", record.ID, err)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package provenance
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Store keeps signed records. Records are only ever added; stores may drop the oldest ones
+// to stay within their capacity.
+type Store interface {
+	Add(record *Record) error
+	Get(id string) (*Record, error)
+	List(filter ListFilter) ([]*Record, error)
+}
+
+// MemoryStore keeps the most recent records in memory; they are lost on restart
+type MemoryStore struct {
+	capacity int
+
+	mu      sync.RWMutex
+	records []*Record // oldest first, at most capacity
+	byID    map[string]*Record
+}
+
+// NewMemoryStore creates a store keeping the most recent capacity records
+func NewMemoryStore(capacity int) *MemoryStore {
+	return &MemoryStore{capacity: capacity, byID: map[string]*Record{}}
+}
+
+// Add keeps the record, dropping the oldest one when the store is full
+func (s *MemoryStore) Add(record *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, record)
+	s.byID[record.ID] = record
+	if s.capacity > 0 && len(s.records) > s.capacity {
+		delete(s.byID, s.records[0].ID)
+		s.records = s.records[1:]
+	}
+	return nil
+}
+
+// Get returns a record by ID
+func (s *MemoryStore) Get(id string) (*Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	record, ok := s.byID[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrRecordNotFound, id)
+	}
+	return record, nil
+}
+
+// List returns matching records, newest first
+func (s *MemoryStore) List(filter ListFilter) ([]*Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var result []*Record
+	for i := len(s.records) - 1; i >= 0; i-- {
+		if !filter.matches(s.records[i]) {
+			continue
+		}
+		result = append(result, s.records[i])
+		if filter.Limit > 0 && len(result) == filter.Limit {
+			break
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,111 @@
+package provenance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis keys: a hash of every kept record by ID and a list of their IDs, newest first
+const (
+	redisRecordsKey = "ztdp:provenance:records"
+	redisRecordIDs  = "ztdp:provenance:ids"
+)
+
+// redisTimeout bounds each Redis round trip so a slow Redis cannot stall graph saves
+const redisTimeout = 2 * time.Second
+
+// RedisStore keeps records in Redis so they survive restarts and are shared by every
+// instance using the same Redis; each instance's records stay verifiable by the instances
+// that trust its key.
+type RedisStore struct {
+	client   *redis.Client
+	capacity int64
+}
+
+// NewRedisStore creates a store keeping the most recent capacity records in Redis
+func NewRedisStore(client *redis.Client, capacity int) *RedisStore {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &RedisStore{client: client, capacity: int64(capacity)}
+}
+
+// Add writes the record and drops the records beyond capacity
+func (s *RedisStore) Add(record *Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode provenance record: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	pipe := s.client.TxPipeline()
+	pipe.HSet(ctx, redisRecordsKey, record.ID, data)
+	pipe.LPush(ctx, redisRecordIDs, record.ID)
+	dropped := pipe.LRange(ctx, redisRecordIDs, s.capacity, -1)
+	pipe.LTrim(ctx, redisRecordIDs, 0, s.capacity-1)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to store provenance record %s: %w", record.ID, err)
+	}
+	if ids := dropped.Val(); len(ids) > 0 {
+		if err := s.client.HDel(ctx, redisRecordsKey, ids...).Err(); err != nil {
+			return fmt.Errorf("failed to drop old provenance records: %w", err)
+		}
+	}
+	return nil
+}
+
+// Get returns a record stored in Redis
+func (s *RedisStore) Get(id string) (*Record, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	data, err := s.client.HGet(ctx, redisRecordsKey, id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("%w: %s", ErrRecordNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read provenance record %s: %w", id, err)
+	}
+	var record Record
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to decode provenance record %s: %w", id, err)
+	}
+	return &record, nil
+}
+
+// List returns matching records, newest first, skipping IDs whose record is gone
+func (s *RedisStore) List(filter ListFilter) ([]*Record, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	ids, err := s.client.LRange(ctx, redisRecordIDs, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list provenance records: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	values, err := s.client.HMGet(ctx, redisRecordsKey, ids...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read provenance records: %w", err)
+	}
+	var result []*Record
+	for _, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var record Record
+		if err := json.Unmarshal([]byte(data), &record); err != nil || !filter.matches(&record) {
+			continue
+		}
+		result = append(result, &record)
+		if filter.Limit > 0 && len(result) == filter.Limit {
+			break
+		}
+	}
+	return result, nil
+}
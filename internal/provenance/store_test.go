@@ -0,0 +1,65 @@
+package provenance
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestMemoryStoreDropsOldestRecords(t *testing.T) {
+	signer, err := GenerateSigner("ztdp-1")
+	if err != nil {
+		t.Fatalf("GenerateSigner: %v", err)
+	}
+	s := NewService(signer, NewMemoryStore(2))
+	first, _ := s.Sign(Record{Type: RecordPlan, Subject: "plan-1"}, "one")
+	s.Sign(Record{Type: RecordPlan, Subject: "plan-2"}, "two")
+	s.Sign(Record{Type: RecordPlan, Subject: "plan-3"}, "three")
+
+	records := list(t, s, ListFilter{})
+	if len(records) != 2 || records[0].Subject != "plan-3" || records[1].Subject != "plan-2" {
+		t.Fatalf("expected the two newest records, got %+v", records)
+	}
+	if _, err := s.Get(first.ID); !errors.Is(err, ErrRecordNotFound) {
+		t.Fatalf("expected the oldest record to be dropped, got %v", err)
+	}
+}
+
+func TestRedisStore(t *testing.T) {
+	addr := os.Getenv("REDIS_HOST")
+	if addr == "" {
+		t.Skip("REDIS_HOST not set, skipping Redis provenance store test")
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr, Password: os.Getenv("REDIS_PASSWORD")})
+	signer, err := GenerateSigner("ztdp-1")
+	if err != nil {
+		t.Fatalf("GenerateSigner: %v", err)
+	}
+	s := NewService(signer, NewRedisStore(client, 10000))
+
+	record, err := s.Sign(Record{Type: RecordMutation, Subject: "redis-checkout", Initiator: InitiatorHuman}, map[string]string{"op": "added"})
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	t.Cleanup(func() {
+		client.HDel(context.Background(), redisRecordsKey, record.ID)
+		client.LRem(context.Background(), redisRecordIDs, 0, record.ID)
+	})
+
+	// A restarted instance with the same key reads and verifies the stored record
+	restarted := NewService(signer, NewRedisStore(client, 10000))
+	stored, err := restarted.Get(record.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if err := restarted.Verify(stored); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	records := list(t, restarted, ListFilter{Subject: "redis-checkout", Limit: 1})
+	if len(records) != 1 || records[0].ID != record.ID {
+		t.Fatalf("expected the stored record, got %+v", records)
+	}
+}
@@ -0,0 +1,234 @@
+package quotas
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+)
+
+// Usage is the consumption of one metric for an application, team or the platform
+type Usage struct {
+	Metric string `json:"metric"`
+	Scope  string `json:"scope"`          // application, team or default (platform-wide)
+	Name   string `json:"name,omitempty"` // application or team name
+	Used   int    `json:"used"`
+	Limit  int    `json:"limit"` // 0 means unlimited
+	// LimitScope is the scope of the quota that set Limit
+	LimitScope string `json:"limit_scope,omitempty"`
+}
+
+// Report lists the defined quotas and current consumption
+type Report struct {
+	Quotas []*Quota `json:"quotas"`
+	Usage  []Usage  `json:"usage"`
+}
+
+// CheckServiceCreation returns an *ExceededError if appName may not get another service
+func (s *Service) CheckServiceCreation(appName string) error {
+	snapshot, err := s.snapshot()
+	if err != nil {
+		return err
+	}
+	usage := snapshot.servicesUsage(s, appName)
+	return exceeded(usage, 1)
+}
+
+// CheckResourceCreation returns an *ExceededError if the team owning appName may not get
+// another resource instance
+func (s *Service) CheckResourceCreation(appName string) error {
+	snapshot, err := s.snapshot()
+	if err != nil {
+		return err
+	}
+	team := snapshot.owner(appName)
+	if team == "" {
+		return nil
+	}
+	return exceeded(snapshot.resourcesUsage(s, team), 1)
+}
+
+// CheckEnvironmentCreation returns an *ExceededError if another environment owned by owner
+// would exceed the owner's quota or the platform-wide limit
+func (s *Service) CheckEnvironmentCreation(owner string) error {
+	snapshot, err := s.snapshot()
+	if err != nil {
+		return err
+	}
+	if owner != "" {
+		if err := exceeded(snapshot.teamEnvironmentsUsage(s, owner), 1); err != nil {
+			return err
+		}
+	}
+	return exceeded(snapshot.platformEnvironmentsUsage(s), 1)
+}
+
+// Report returns the defined quotas and the consumption of every application and team
+func (s *Service) Report() (*Report, error) {
+	quotas, err := s.ListQuotas()
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := s.snapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Quotas: quotas, Usage: []Usage{snapshot.platformEnvironmentsUsage(s)}}
+	teams := map[string]bool{}
+	for _, app := range snapshot.ids(graph.KindApplication) {
+		report.Usage = append(report.Usage, snapshot.servicesUsage(s, app))
+		if team := snapshot.owner(app); team != "" {
+			teams[team] = true
+		}
+	}
+	for _, env := range snapshot.ids(graph.KindEnvironment) {
+		if team := snapshot.owner(env); team != "" {
+			teams[team] = true
+		}
+	}
+	for _, team := range sortedKeys(teams) {
+		report.Usage = append(report.Usage, snapshot.resourcesUsage(s, team), snapshot.teamEnvironmentsUsage(s, team))
+	}
+	return report, nil
+}
+
+// exceeded turns usage into an error when adding more would go over the limit
+func exceeded(usage Usage, more int) error {
+	if usage.Limit > 0 && usage.Used+more > usage.Limit {
+		return &ExceededError{Metric: usage.Metric, Scope: usage.LimitScope, Name: usage.Name, Limit: usage.Limit, Current: usage.Used}
+	}
+	return nil
+}
+
+// limit returns the first non-zero limit among the quotas, most specific first
+func (s *Service) limit(metric string, candidates ...Quota) (int, string) {
+	for _, candidate := range candidates {
+		quota, err := s.GetQuota(candidate.Scope, candidate.Name)
+		if err != nil {
+			continue
+		}
+		var value int
+		switch metric {
+		case MetricServicesPerApplication:
+			value = quota.Limits.MaxServicesPerApplication
+		case MetricResourcesPerTeam:
+			value = quota.Limits.MaxResourcesPerTeam
+		case MetricEnvironments:
+			value = quota.Limits.MaxEnvironments
+		}
+		if value > 0 {
+			return value, quota.Scope
+		}
+	}
+	return 0, ""
+}
+
+// inheritedLimit returns the limit an application inherits from the organization hierarchy
+func (s *Service) inheritedLimit(metric, appName string) (int, string) {
+	inheritedMu.RLock()
+	inherited := inheritedLimit
+	inheritedMu.RUnlock()
+	if inherited == nil {
+		return 0, ""
+	}
+	return inherited(s.graph, appName, metric)
+}
+
+// graphSnapshot is the part of the graph quota usage is counted from
+type graphSnapshot struct {
+	nodes map[string]*graph.Node
+	edges map[string][]graph.Edge
+}
+
+func (s *Service) snapshot() (*graphSnapshot, error) {
+	nodes, err := s.graph.Nodes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph: %w", err)
+	}
+	edges, err := s.graph.Edges()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph: %w", err)
+	}
+	return &graphSnapshot{nodes: nodes, edges: edges}, nil
+}
+
+func (g *graphSnapshot) owner(id string) string {
+	if node, ok := g.nodes[id]; ok {
+		if owner, ok := node.Metadata["owner"].(string); ok {
+			return owner
+		}
+	}
+	return ""
+}
+
+func (g *graphSnapshot) ids(kind string) []string {
+	var ids []string
+	for id, node := range g.nodes {
+		if node.Kind == kind {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (g *graphSnapshot) servicesUsage(s *Service, appName string) Usage {
+	used := 0
+	for _, edge := range g.edges[appName] {
+		if node, ok := g.nodes[edge.To]; ok && edge.Type == graph.EdgeTypeOwns && node.Kind == graph.KindService {
+			used++
+		}
+	}
+	limit, scope := s.limit(MetricServicesPerApplication, Quota{Scope: ScopeApplication, Name: appName})
+	if limit == 0 {
+		limit, scope = s.inheritedLimit(MetricServicesPerApplication, appName)
+	}
+	if limit == 0 {
+		limit, scope = s.limit(MetricServicesPerApplication, Quota{Scope: ScopeTeam, Name: g.owner(appName)}, Quota{Scope: ScopeDefault})
+	}
+	return Usage{Metric: MetricServicesPerApplication, Scope: ScopeApplication, Name: appName, Used: used, Limit: limit, LimitScope: scope}
+}
+
+// resourcesUsage counts the resource instances owned by the team's applications
+func (g *graphSnapshot) resourcesUsage(s *Service, team string) Usage {
+	used := 0
+	for _, app := range g.ids(graph.KindApplication) {
+		if g.owner(app) != team {
+			continue
+		}
+		for _, edge := range g.edges[app] {
+			if node, ok := g.nodes[edge.To]; ok && edge.Type == graph.EdgeTypeOwns && node.Kind == graph.KindResource {
+				used++
+			}
+		}
+	}
+	limit, scope := s.limit(MetricResourcesPerTeam, Quota{Scope: ScopeTeam, Name: team}, Quota{Scope: ScopeDefault})
+	return Usage{Metric: MetricResourcesPerTeam, Scope: ScopeTeam, Name: team, Used: used, Limit: limit, LimitScope: scope}
+}
+
+func (g *graphSnapshot) teamEnvironmentsUsage(s *Service, team string) Usage {
+	used := 0
+	for _, env := range g.ids(graph.KindEnvironment) {
+		if g.owner(env) == team {
+			used++
+		}
+	}
+	// The default quota caps the platform, not each team, so only a team quota applies here
+	limit, scope := s.limit(MetricEnvironments, Quota{Scope: ScopeTeam, Name: team})
+	return Usage{Metric: MetricEnvironments, Scope: ScopeTeam, Name: team, Used: used, Limit: limit, LimitScope: scope}
+}
+
+func (g *graphSnapshot) platformEnvironmentsUsage(s *Service) Usage {
+	limit, scope := s.limit(MetricEnvironments, Quota{Scope: ScopeDefault})
+	return Usage{Metric: MetricEnvironments, Scope: ScopeDefault, Used: len(g.ids(graph.KindEnvironment)), Limit: limit, LimitScope: scope}
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
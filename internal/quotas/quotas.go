@@ -0,0 +1,225 @@
+// Package quotas enforces limits platform admins set on how much teams and applications may
+// create. Quotas are stored in the global graph at three scopes: a platform-wide default, per
+// team (the owner recorded on nodes) and per application. The most specific quota that sets a
+// limit wins; a limit of zero means unlimited. Applications also inherit limits from the
+// organization hierarchy above them, see SetInheritedLimit.
+package quotas
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// Quota scopes
+const (
+	ScopeDefault     = "default"
+	ScopeTeam        = "team"
+	ScopeApplication = "application"
+)
+
+// Metrics a quota limits
+const (
+	MetricServicesPerApplication = "services_per_application"
+	MetricResourcesPerTeam       = "resources_per_team"
+	MetricEnvironments           = "environments"
+)
+
+// ErrQuotaNotFound is returned when no quota is defined for a scope and name
+var ErrQuotaNotFound = errors.New("quota not found")
+
+// nodeIDPrefix namespaces quota nodes so they cannot collide with application or team names
+const nodeIDPrefix = "quota:"
+
+// Limits are the values a quota sets; zero leaves the metric to a less specific quota
+type Limits struct {
+	MaxServicesPerApplication int `json:"max_services_per_application,omitempty"`
+	MaxResourcesPerTeam       int `json:"max_resources_per_team,omitempty"`
+	// MaxEnvironments caps every environment on the platform for the default quota, and the
+	// environments a team owns for a team quota
+	MaxEnvironments int `json:"max_environments,omitempty"`
+}
+
+// Quota is a set of limits for a scope
+type Quota struct {
+	Scope     string    `json:"scope"`
+	Name      string    `json:"name,omitempty"` // team or application name; empty for the default
+	Limits    Limits    `json:"limits"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Validate checks the scope and limits
+func (q Quota) Validate() error {
+	switch q.Scope {
+	case ScopeDefault:
+		if q.Name != "" {
+			return fmt.Errorf("the default quota has no name")
+		}
+	case ScopeTeam, ScopeApplication:
+		if q.Name == "" {
+			return fmt.Errorf("%s quotas need a name", q.Scope)
+		}
+	default:
+		return fmt.Errorf("unknown quota scope %q (use default, team or application)", q.Scope)
+	}
+	if q.Limits.MaxServicesPerApplication < 0 || q.Limits.MaxResourcesPerTeam < 0 || q.Limits.MaxEnvironments < 0 {
+		return fmt.Errorf("quota limits must not be negative")
+	}
+	if q.Scope == ScopeApplication && (q.Limits.MaxResourcesPerTeam > 0 || q.Limits.MaxEnvironments > 0) {
+		return fmt.Errorf("application quotas can only limit services")
+	}
+	return nil
+}
+
+func (q Quota) nodeID() string {
+	if q.Scope == ScopeDefault {
+		return nodeIDPrefix + ScopeDefault
+	}
+	return nodeIDPrefix + q.Scope + ":" + q.Name
+}
+
+// ExceededError reports a creation refused by a quota, with the usage that triggered it
+type ExceededError struct {
+	Metric  string `json:"metric"`
+	Scope   string `json:"scope"`          // scope of the quota that set the limit
+	Name    string `json:"name,omitempty"` // team or application the usage was counted for
+	Limit   int    `json:"limit"`
+	Current int    `json:"current"`
+}
+
+func (e *ExceededError) Error() string {
+	subject := "the platform"
+	if e.Name != "" {
+		subject = e.Name
+	}
+	return fmt.Sprintf("quota exceeded: %s for %s is at %d of %d allowed", e.Metric, subject, e.Current, e.Limit)
+}
+
+// InheritedLimit returns the limit an application inherits for a metric from the units of the
+// organization hierarchy above it, and the kind of unit that set it; zero when none does
+type InheritedLimit func(globalGraph *graph.GlobalGraph, appName, metric string) (int, string)
+
+var (
+	inheritedMu    sync.RWMutex
+	inheritedLimit InheritedLimit
+)
+
+// SetInheritedLimit sets how inherited limits are resolved (called from main.go). An
+// application quota overrides an inherited limit, which overrides team and default quotas.
+func SetInheritedLimit(resolve InheritedLimit) {
+	inheritedMu.Lock()
+	defer inheritedMu.Unlock()
+	inheritedLimit = resolve
+}
+
+// Service stores quotas in the global graph and checks them
+type Service struct {
+	graph  *graph.GlobalGraph
+	logger *logging.Logger
+}
+
+// NewService creates a quota service backed by the global graph
+func NewService(globalGraph *graph.GlobalGraph) *Service {
+	return &Service{
+		graph:  globalGraph,
+		logger: logging.GetLogger().ForComponent("quotas"),
+	}
+}
+
+// SetQuota creates or replaces a quota
+func (s *Service) SetQuota(quota *Quota) error {
+	if err := quota.Validate(); err != nil {
+		return err
+	}
+	quota.UpdatedAt = time.Now().UTC()
+
+	data, err := json.Marshal(quota)
+	if err != nil {
+		return fmt.Errorf("failed to encode quota: %w", err)
+	}
+	var spec map[string]interface{}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return fmt.Errorf("failed to encode quota: %w", err)
+	}
+	node := &graph.Node{
+		ID:       quota.nodeID(),
+		Kind:     graph.KindQuota,
+		Metadata: map[string]interface{}{"name": quota.nodeID(), "scope": quota.Scope},
+		Spec:     spec,
+	}
+
+	if existing, _ := s.graph.GetNode(node.ID); existing != nil {
+		if err := s.graph.UpdateNode(node); err != nil {
+			return fmt.Errorf("failed to update quota: %w", err)
+		}
+	} else {
+		s.graph.AddNode(node)
+	}
+	s.logger.Info("📏 Quota %s set", node.ID)
+	return s.graph.Save()
+}
+
+// GetQuota returns the quota for a scope; name is ignored for the default scope
+func (s *Service) GetQuota(scope, name string) (*Quota, error) {
+	if scope == ScopeDefault {
+		name = ""
+	}
+	node, _ := s.graph.GetNode(Quota{Scope: scope, Name: name}.nodeID())
+	if node == nil || node.Kind != graph.KindQuota {
+		return nil, ErrQuotaNotFound
+	}
+	return nodeToQuota(node)
+}
+
+// ListQuotas returns every quota, default first, then by scope and name
+func (s *Service) ListQuotas() ([]*Quota, error) {
+	nodes, err := s.graph.Nodes()
+	if err != nil {
+		return nil, err
+	}
+	quotas := []*Quota{}
+	for _, node := range nodes {
+		if node.Kind != graph.KindQuota {
+			continue
+		}
+		quota, err := nodeToQuota(node)
+		if err != nil {
+			s.logger.Warn("⚠️ Skipping malformed quota node %s: %v", node.ID, err)
+			continue
+		}
+		quotas = append(quotas, quota)
+	}
+	sort.Slice(quotas, func(i, j int) bool { return quotas[i].nodeID() < quotas[j].nodeID() })
+	return quotas, nil
+}
+
+// DeleteQuota removes a quota; less specific quotas apply again
+func (s *Service) DeleteQuota(scope, name string) error {
+	quota, err := s.GetQuota(scope, name)
+	if err != nil {
+		return err
+	}
+	if err := s.graph.DeleteNode(quota.nodeID()); err != nil {
+		return fmt.Errorf("failed to delete quota: %w", err)
+	}
+	s.logger.Info("📏 Quota %s deleted", quota.nodeID())
+	return s.graph.Save()
+}
+
+func nodeToQuota(node *graph.Node) (*Quota, error) {
+	data, err := json.Marshal(node.Spec)
+	if err != nil {
+		return nil, err
+	}
+	var quota Quota
+	if err := json.Unmarshal(data, &quota); err != nil {
+		return nil, err
+	}
+	return &quota, nil
+}
@@ -0,0 +1,92 @@
+package quotas
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newQuotaTestGraph(t *testing.T) *graph.GlobalGraph {
+	t.Helper()
+	g := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	node := func(id, kind, owner string) {
+		g.AddNode(&graph.Node{ID: id, Kind: kind, Metadata: map[string]interface{}{"name": id, "owner": owner}})
+	}
+	node("checkout", graph.KindApplication, "team-payments")
+	node("billing", graph.KindApplication, "team-payments")
+	node("search", graph.KindApplication, "team-discovery")
+	node("dev", graph.KindEnvironment, "team-payments")
+	node("prod", graph.KindEnvironment, "platform")
+	for _, svc := range []string{"checkout-api", "checkout-worker"} {
+		node(svc, graph.KindService, "team-payments")
+		require.NoError(t, g.AddEdge("checkout", svc, graph.EdgeTypeOwns))
+	}
+	g.AddNode(&graph.Node{ID: "checkout-db", Kind: graph.KindResource, Metadata: map[string]interface{}{"name": "checkout-db", "application": "checkout", "catalog_ref": "postgres"}})
+	require.NoError(t, g.AddEdge("checkout", "checkout-db", graph.EdgeTypeOwns))
+	return g
+}
+
+func TestQuotaValidation(t *testing.T) {
+	assert.NoError(t, Quota{Scope: ScopeDefault, Limits: Limits{MaxEnvironments: 5}}.Validate())
+	assert.Error(t, Quota{Scope: ScopeTeam}.Validate(), "team quotas need a name")
+	assert.Error(t, Quota{Scope: "org", Name: "x"}.Validate())
+	assert.Error(t, Quota{Scope: ScopeDefault, Limits: Limits{MaxServicesPerApplication: -1}}.Validate())
+	assert.Error(t, Quota{Scope: ScopeApplication, Name: "checkout", Limits: Limits{MaxEnvironments: 1}}.Validate())
+}
+
+func TestQuotaEnforcement(t *testing.T) {
+	service := NewService(newQuotaTestGraph(t))
+
+	// Nothing is limited until a quota is defined
+	require.NoError(t, service.CheckServiceCreation("checkout"))
+
+	require.NoError(t, service.SetQuota(&Quota{Scope: ScopeDefault, Limits: Limits{MaxServicesPerApplication: 2, MaxEnvironments: 2}}))
+	err := service.CheckServiceCreation("checkout")
+	var exceeded *ExceededError
+	require.True(t, errors.As(err, &exceeded))
+	assert.Equal(t, ExceededError{Metric: MetricServicesPerApplication, Scope: ScopeDefault, Name: "checkout", Limit: 2, Current: 2}, *exceeded)
+	assert.EqualError(t, err, "quota exceeded: services_per_application for checkout is at 2 of 2 allowed")
+	assert.NoError(t, service.CheckServiceCreation("billing"))
+
+	// More specific quotas win
+	require.NoError(t, service.SetQuota(&Quota{Scope: ScopeTeam, Name: "team-payments", Limits: Limits{MaxServicesPerApplication: 3, MaxResourcesPerTeam: 1}}))
+	assert.NoError(t, service.CheckServiceCreation("checkout"))
+	require.NoError(t, service.SetQuota(&Quota{Scope: ScopeApplication, Name: "checkout", Limits: Limits{MaxServicesPerApplication: 1}}))
+	assert.Error(t, service.CheckServiceCreation("checkout"))
+
+	err = service.CheckResourceCreation("billing")
+	require.True(t, errors.As(err, &exceeded), "checkout-db counts against the whole team")
+	assert.Equal(t, 1, exceeded.Current)
+	assert.NoError(t, service.CheckResourceCreation("search"))
+
+	err = service.CheckEnvironmentCreation("team-discovery")
+	require.True(t, errors.As(err, &exceeded), "the default quota caps the platform")
+	assert.Equal(t, "", exceeded.Name)
+
+	require.NoError(t, service.DeleteQuota(ScopeApplication, "checkout"))
+	assert.NoError(t, service.CheckServiceCreation("checkout"))
+	assert.ErrorIs(t, service.DeleteQuota(ScopeApplication, "checkout"), ErrQuotaNotFound)
+}
+
+func TestQuotaReport(t *testing.T) {
+	service := NewService(newQuotaTestGraph(t))
+	require.NoError(t, service.SetQuota(&Quota{Scope: ScopeTeam, Name: "team-payments", Limits: Limits{MaxResourcesPerTeam: 4, MaxEnvironments: 3}}))
+
+	report, err := service.Report()
+	require.NoError(t, err)
+	require.Len(t, report.Quotas, 1)
+
+	usage := map[string]Usage{}
+	for _, u := range report.Usage {
+		usage[u.Metric+"/"+u.Name] = u
+	}
+	assert.Equal(t, 2, usage["environments/"].Used)
+	assert.Equal(t, 2, usage["services_per_application/checkout"].Used)
+	assert.Zero(t, usage["services_per_application/checkout"].Limit)
+	assert.Equal(t, Usage{Metric: MetricResourcesPerTeam, Scope: ScopeTeam, Name: "team-payments", Used: 1, Limit: 4, LimitScope: ScopeTeam}, usage["resources_per_team/team-payments"])
+	assert.Equal(t, 1, usage["environments/team-payments"].Used)
+	assert.Equal(t, 0, usage["environments/platform"].Limit)
+}
@@ -0,0 +1,125 @@
+package recording
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/krzachariassen/ZTDP/internal/redaction"
+)
+
+// AdminPath is where recordings are managed; those requests are not recorded themselves
+const AdminPath = "/v1/admin/recordings"
+
+// Middleware records each request and its response while a recording is in progress.
+// WebSocket upgrades are passed through unrecorded. Bodies are redacted like logs are.
+func (r *Recorder) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if strings.HasPrefix(req.URL.Path, AdminPath) || strings.EqualFold(req.Header.Get("Upgrade"), "websocket") {
+			next.ServeHTTP(w, req)
+			return
+		}
+		seq, ok := r.begin()
+		if !ok {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		start := r.now()
+		requestBody, truncated := readBody(req)
+		capture := &capturingWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(capture, req)
+
+		headers := map[string]string{}
+		for name := range req.Header {
+			if keepHeader(name) {
+				headers[name] = redaction.Default().String(req.Header.Get(name))
+			}
+		}
+		r.finish(Exchange{
+			Sequence:      seq,
+			At:            start,
+			Method:        req.Method,
+			Path:          req.URL.Path,
+			Query:         req.URL.RawQuery,
+			Headers:       headers,
+			CorrelationID: logging.CorrelationIDFromContext(req.Context()),
+			RequestBody:   redaction.Default().String(requestBody),
+			Status:        capture.status,
+			ResponseBody:  redaction.Default().String(capture.body.String()),
+			Truncated:     truncated || capture.truncated,
+			Duration:      r.now().Sub(start).Truncate(time.Millisecond).String(),
+		})
+	})
+}
+
+// readBody reads up to maxBodyBytes of the request body and puts the whole body back
+func readBody(req *http.Request) (string, bool) {
+	if req.Body == nil {
+		return "", false
+	}
+	data, err := io.ReadAll(io.LimitReader(req.Body, maxBodyBytes+1))
+	if err != nil {
+		return "", false
+	}
+	req.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(data), req.Body), req.Body}
+	if len(data) > maxBodyBytes {
+		return string(data[:maxBodyBytes]), true
+	}
+	return string(data), false
+}
+
+// capturingWriter keeps the status and up to maxBodyBytes of the response body
+type capturingWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+	truncated   bool
+}
+
+func (c *capturingWriter) WriteHeader(status int) {
+	if !c.wroteHeader {
+		c.status, c.wroteHeader = status, true
+	}
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *capturingWriter) Write(data []byte) (int, error) {
+	c.wroteHeader = true
+	if room := maxBodyBytes - c.body.Len(); room > 0 {
+		if len(data) > room {
+			c.body.Write(data[:room])
+			c.truncated = true
+		} else {
+			c.body.Write(data)
+		}
+	} else if len(data) > 0 {
+		c.truncated = true
+	}
+	return c.ResponseWriter.Write(data)
+}
+
+// Flush passes through so streamed responses keep streaming while recorded
+func (c *capturingWriter) Flush() {
+	if flusher, ok := c.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack passes through for handlers that take over the connection
+func (c *capturingWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := c.ResponseWriter.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	return nil, nil, fmt.Errorf("response writer does not support hijacking")
+}
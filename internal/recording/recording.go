@@ -0,0 +1,311 @@
+// Package recording captures API request/response pairs and the graph mutations they cause
+// for a time window into a bundle, and replays bundles against a fresh instance so reported
+// orchestration bugs can be reproduced exactly.
+package recording
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/graphwatch"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// BundleVersion is the bundle format written by this package
+const BundleVersion = 1
+
+// maxBodyBytes bounds the request and response bodies kept per exchange
+const maxBodyBytes = 1 << 20
+
+// Errors returned when starting or stopping a recording
+var (
+	ErrRecording    = errors.New("a recording is already in progress")
+	ErrNotRecording = errors.New("no recording in progress")
+	ErrNoBundle     = errors.New("no recording has completed yet")
+)
+
+// Exchange is one recorded API request and the response it got
+type Exchange struct {
+	Sequence      int               `json:"sequence"` // order the requests arrived in
+	At            time.Time         `json:"at"`
+	Method        string            `json:"method"`
+	Path          string            `json:"path"`
+	Query         string            `json:"query,omitempty"`
+	Headers       map[string]string `json:"headers,omitempty"`
+	CorrelationID string            `json:"correlation_id,omitempty"`
+	RequestBody   string            `json:"request_body,omitempty"`
+	Status        int               `json:"status"`
+	ResponseBody  string            `json:"response_body,omitempty"`
+	Truncated     bool              `json:"truncated,omitempty"` // a body exceeded the size limit
+	Duration      string            `json:"duration"`
+}
+
+// Change is one node or edge a mutation added, updated or removed
+type Change struct {
+	Op   string      `json:"op"`   // added | updated | removed
+	Kind string      `json:"kind"` // node | edge
+	ID   string      `json:"id"`   // node ID, or from->to:type for edges
+	Node *graph.Node `json:"node,omitempty"`
+	Edge *graph.Edge `json:"edge,omitempty"`
+}
+
+// Mutation is one save of the graph during a recording
+type Mutation struct {
+	Sequence  int       `json:"sequence"`
+	At        time.Time `json:"at"`
+	Exchanges []int     `json:"exchanges,omitempty"` // requests in flight when the graph was saved
+	Changes   []Change  `json:"changes"`
+}
+
+// Bundle is everything recorded in one window
+type Bundle struct {
+	Version   int          `json:"version"`
+	StartedAt time.Time    `json:"started_at"`
+	StoppedAt time.Time    `json:"stopped_at"`
+	Initial   *graph.Graph `json:"initial"` // the graph when the recording started
+	Final     *graph.Graph `json:"final"`   // the graph when it stopped
+	Exchanges []Exchange   `json:"exchanges"`
+	Mutations []Mutation   `json:"mutations"`
+}
+
+// LoadBundle reads a bundle file
+func LoadBundle(path string) (*Bundle, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read bundle %s: %w", path, err)
+	}
+	var bundle Bundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return nil, fmt.Errorf("parse bundle %s: %w", path, err)
+	}
+	if bundle.Version != BundleVersion {
+		return nil, fmt.Errorf("bundle %s has version %d, expected %d", path, bundle.Version, BundleVersion)
+	}
+	return &bundle, nil
+}
+
+// Save writes the bundle as indented JSON
+func (b *Bundle) Save(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Status describes the recording in progress, if any, and the last completed bundle
+type Status struct {
+	Recording bool       `json:"recording"`
+	StartedAt *time.Time `json:"started_at,omitempty"`
+	EndsAt    *time.Time `json:"ends_at,omitempty"`
+	Exchanges int        `json:"exchanges"`
+	Mutations int        `json:"mutations"`
+	LastPath  string     `json:"last_path,omitempty"` // where the last bundle was written
+}
+
+type session struct {
+	bundle   *Bundle
+	endsAt   time.Time
+	timer    *time.Timer
+	previous *graph.Graph
+	nextSeq  int
+	inFlight map[int]bool
+}
+
+// Recorder captures the mutations of the saves on a graph watch and provides the HTTP
+// middleware that captures exchanges. It records nothing until Start is called.
+type Recorder struct {
+	backend   graph.GraphBackend // loads the graph at the start and end of a recording
+	maxWindow time.Duration
+	dir       string // where completed bundles are written; empty keeps only the last in memory
+	now       func() time.Time
+	logger    *logging.Logger
+
+	mu       sync.Mutex
+	session  *session
+	last     *Bundle
+	lastPath string
+}
+
+// NewRecorder records the saves on watch; recordings last at most maxWindow
+func NewRecorder(watch *graphwatch.Backend, maxWindow time.Duration, dir string) *Recorder {
+	r := &Recorder{
+		backend:   watch,
+		maxWindow: maxWindow,
+		dir:       dir,
+		now:       time.Now,
+		logger:    logging.GetLogger().ForComponent("recording"),
+	}
+	watch.Subscribe(r.record)
+	return r
+}
+
+// Start begins recording for the window; it stops by itself when the window ends
+func (r *Recorder) Start(window time.Duration) (*Status, error) {
+	if window <= 0 || window > r.maxWindow {
+		return nil, fmt.Errorf("window must be between 0 and %s, got %s", r.maxWindow, window)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.session != nil {
+		return nil, ErrRecording
+	}
+	initial, err := r.backend.LoadGlobal()
+	if err != nil || initial == nil {
+		initial = graph.NewGraph()
+	}
+	now := r.now()
+	r.session = &session{
+		bundle:   &Bundle{Version: BundleVersion, StartedAt: now, Initial: initial.Clone(), Exchanges: []Exchange{}, Mutations: []Mutation{}},
+		endsAt:   now.Add(window),
+		previous: initial.Clone(),
+		inFlight: map[int]bool{},
+	}
+	session := r.session
+	session.timer = time.AfterFunc(window, func() {
+		if _, err := r.stop(session); err != nil && !errors.Is(err, ErrNotRecording) {
+			r.logger.Warn("⚠️ Failed to finish recording: %v", err)
+		}
+	})
+	r.logger.Info("⏺️ Recording API requests and graph mutations for %s", window)
+	return r.statusLocked(), nil
+}
+
+// Stop ends the recording in progress and returns its bundle
+func (r *Recorder) Stop() (*Bundle, error) {
+	r.mu.Lock()
+	current := r.session
+	r.mu.Unlock()
+	if current == nil {
+		return nil, ErrNotRecording
+	}
+	return r.stop(current)
+}
+
+// stop finishes s unless another recording has replaced it
+func (r *Recorder) stop(s *session) (*Bundle, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.session != s {
+		return nil, ErrNotRecording
+	}
+	s.timer.Stop()
+	r.session = nil
+
+	bundle := s.bundle
+	bundle.StoppedAt = r.now()
+	bundle.Final = s.previous
+	if current, err := r.backend.LoadGlobal(); err == nil && current != nil {
+		bundle.Final = current.Clone()
+	}
+	sort.Slice(bundle.Exchanges, func(i, j int) bool { return bundle.Exchanges[i].Sequence < bundle.Exchanges[j].Sequence })
+	r.last, r.lastPath = bundle, ""
+
+	if r.dir != "" {
+		path := filepath.Join(r.dir, "recording-"+bundle.StartedAt.UTC().Format("20060102T150405.000Z")+".json")
+		if err := bundle.Save(path); err != nil {
+			return bundle, fmt.Errorf("failed to write bundle: %w", err)
+		}
+		r.lastPath = path
+	}
+	r.logger.Info("⏹️ Recorded %d requests and %d graph mutations", len(bundle.Exchanges), len(bundle.Mutations))
+	return bundle, nil
+}
+
+// Status describes the recording in progress and the last completed one
+func (r *Recorder) Status() *Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.statusLocked()
+}
+
+func (r *Recorder) statusLocked() *Status {
+	status := &Status{LastPath: r.lastPath}
+	if s := r.session; s != nil {
+		status.Recording = true
+		status.StartedAt, status.EndsAt = &s.bundle.StartedAt, &s.endsAt
+		status.Exchanges, status.Mutations = len(s.bundle.Exchanges), len(s.bundle.Mutations)
+	}
+	return status
+}
+
+// Last returns the most recently completed bundle
+func (r *Recorder) Last() (*Bundle, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.last == nil {
+		return nil, ErrNoBundle
+	}
+	return r.last, nil
+}
+
+// record adds what a save changed to the recording in progress; emptying the graph is
+// recorded as removals
+func (r *Recorder) record(save graphwatch.Save) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.session
+	if s == nil {
+		return
+	}
+	s.previous = save.Graph
+	changes := make([]Change, 0, len(save.Changes))
+	for _, c := range save.Changes {
+		change := Change{Op: c.Op, Kind: c.Kind, ID: c.ID}
+		if c.Kind == graphwatch.KindNode {
+			change.Node = save.Node(c)
+		} else {
+			change.ID = c.ID + "->" + c.To + ":" + c.Type
+			change.Edge = save.Edge(c)
+		}
+		changes = append(changes, change)
+	}
+	var exchanges []int
+	for seq := range s.inFlight {
+		exchanges = append(exchanges, seq)
+	}
+	sort.Ints(exchanges)
+	s.bundle.Mutations = append(s.bundle.Mutations, Mutation{
+		Sequence: len(s.bundle.Mutations) + 1, At: r.now(), Exchanges: exchanges, Changes: changes,
+	})
+}
+
+// begin registers a request that arrived while recording; ok is false when not recording
+func (r *Recorder) begin() (seq int, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.session == nil {
+		return 0, false
+	}
+	r.session.nextSeq++
+	r.session.inFlight[r.session.nextSeq] = true
+	return r.session.nextSeq, true
+}
+
+// finish adds a completed exchange to the recording it started in, if that is still running
+func (r *Recorder) finish(exchange Exchange) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s := r.session; s != nil && s.inFlight[exchange.Sequence] {
+		delete(s.inFlight, exchange.Sequence)
+		s.bundle.Exchanges = append(s.bundle.Exchanges, exchange)
+	}
+}
+
+// redactedHeaders are never written to a bundle
+var redactedHeaders = map[string]bool{"authorization": true, "cookie": true, "proxy-authorization": true, "x-api-key": true}
+
+func keepHeader(name string) bool {
+	return !redactedHeaders[strings.ToLower(name)]
+}
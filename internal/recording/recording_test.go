@@ -0,0 +1,163 @@
+package recording
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/graphwatch"
+)
+
+// newTestAPI serves POST /v1/nodes, which adds the node in the body to the graph, and
+// GET /v1/graph like the real API does
+func newTestAPI(backend graph.GraphBackend) http.Handler {
+	gg := graph.NewGlobalGraph(backend)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/nodes", func(w http.ResponseWriter, r *http.Request) {
+		var node graph.Node
+		if err := json.NewDecoder(r.Body).Decode(&node); err != nil || node.ID == "" {
+			http.Error(w, "invalid node", http.StatusBadRequest)
+			return
+		}
+		if err := gg.AddNode(&node); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(node)
+	})
+	mux.HandleFunc("/v1/graph", func(w http.ResponseWriter, r *http.Request) {
+		current, _ := gg.Graph()
+		json.NewEncoder(w).Encode(current)
+	})
+	return mux
+}
+
+func post(t *testing.T, url, body string, headers map[string]string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST %s: %v", url, err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+func TestStartValidatesWindow(t *testing.T) {
+	recorder := NewRecorder(graphwatch.NewBackend(graph.NewMemoryGraph()), time.Hour, "")
+	for _, window := range []time.Duration{0, -time.Minute, 2 * time.Hour} {
+		if _, err := recorder.Start(window); err == nil {
+			t.Errorf("window %s: expected an error", window)
+		}
+	}
+	if _, err := recorder.Start(time.Minute); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if _, err := recorder.Start(time.Minute); !errors.Is(err, ErrRecording) {
+		t.Errorf("expected ErrRecording, got %v", err)
+	}
+	if _, err := recorder.Stop(); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if _, err := recorder.Stop(); !errors.Is(err, ErrNotRecording) {
+		t.Errorf("expected ErrNotRecording, got %v", err)
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	dir := t.TempDir()
+	watch := graphwatch.NewBackend(graph.NewMemoryGraph())
+	recorder := NewRecorder(watch, time.Hour, dir)
+	recorded := httptest.NewServer(recorder.Middleware(newTestAPI(watch)))
+	defer recorded.Close()
+
+	// Requests before the recording starts are not captured
+	post(t, recorded.URL+"/v1/nodes", `{"id":"before","kind":"application"}`, nil)
+	if _, err := recorder.Start(time.Minute); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	post(t, recorded.URL+"/v1/nodes", `{"id":"checkout","kind":"application"}`, map[string]string{"Authorization": "Bearer secret"})
+	post(t, recorded.URL+"/v1/nodes", `{"kind":"application"}`, nil)
+	bundle, err := recorder.Stop()
+	if err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+
+	if len(bundle.Exchanges) != 2 {
+		t.Fatalf("expected 2 exchanges, got %+v", bundle.Exchanges)
+	}
+	first := bundle.Exchanges[0]
+	if first.Sequence != 1 || first.Status != http.StatusCreated || !strings.Contains(first.RequestBody, "checkout") {
+		t.Errorf("unexpected first exchange: %+v", first)
+	}
+	if _, ok := first.Headers["Authorization"]; ok {
+		t.Error("Authorization header was recorded")
+	}
+	if bundle.Exchanges[1].Status != http.StatusBadRequest {
+		t.Errorf("expected the invalid request to be recorded with 400, got %+v", bundle.Exchanges[1])
+	}
+	if len(bundle.Mutations) != 1 || bundle.Mutations[0].Changes[0].ID != "checkout" || len(bundle.Mutations[0].Exchanges) != 1 || bundle.Mutations[0].Exchanges[0] != 1 {
+		t.Errorf("expected one mutation attributed to the first request, got %+v", bundle.Mutations)
+	}
+	if _, ok := bundle.Initial.Nodes["before"]; !ok {
+		t.Error("initial graph is missing the node created before recording")
+	}
+
+	// The bundle was written to the directory and loads back
+	loaded, err := LoadBundle(filepath.Join(dir, "recording-"+bundle.StartedAt.UTC().Format("20060102T150405.000Z")+".json"))
+	if err != nil {
+		t.Fatalf("LoadBundle: %v", err)
+	}
+
+	// A fresh instance seeded with the initial graph reproduces the recording
+	fresh := graph.NewMemoryGraph()
+	fresh.SaveGlobal(loaded.Initial)
+	replayed := httptest.NewServer(newTestAPI(fresh))
+	defer replayed.Close()
+	report, err := Replay(context.Background(), loaded, replayed.URL, http.DefaultClient, 0)
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if !report.Reproduced {
+		t.Fatalf("expected the replay to reproduce the recording, got %+v", report)
+	}
+
+	// An instance that was not seeded ends up without the earlier node
+	unseeded := httptest.NewServer(newTestAPI(graph.NewMemoryGraph()))
+	defer unseeded.Close()
+	report, err = Replay(context.Background(), loaded, unseeded.URL, http.DefaultClient, 0)
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if report.Reproduced || len(report.Graph.Missing) != 1 || report.Graph.Missing[0] != "node:before" {
+		t.Errorf("expected the unseeded replay to miss node:before, got %+v", report.Graph)
+	}
+}
+
+func TestRecordingStopsWhenWindowEnds(t *testing.T) {
+	recorder := NewRecorder(graphwatch.NewBackend(graph.NewMemoryGraph()), time.Hour, "")
+	if _, err := recorder.Start(20 * time.Millisecond); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for recorder.Status().Recording && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if recorder.Status().Recording {
+		t.Fatal("recording did not stop when its window ended")
+	}
+	if _, err := recorder.Last(); err != nil {
+		t.Errorf("expected the bundle to be kept, got %v", err)
+	}
+}
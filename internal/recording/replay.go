@@ -0,0 +1,176 @@
+package recording
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+)
+
+// Result is the outcome of replaying one exchange
+type Result struct {
+	Sequence       int    `json:"sequence"`
+	Method         string `json:"method"`
+	Path           string `json:"path"`
+	RecordedStatus int    `json:"recorded_status"`
+	Status         int    `json:"status"`
+	Error          string `json:"error,omitempty"`
+	Matched        bool   `json:"matched"` // the replayed request got the recorded status
+}
+
+// GraphDifference compares the graph after a replay with the graph the recording ended with
+type GraphDifference struct {
+	Missing    []string `json:"missing,omitempty"`    // nodes and edges the recording ended with that the replay lacks
+	Unexpected []string `json:"unexpected,omitempty"` // nodes and edges only the replay has
+	Changed    []string `json:"changed,omitempty"`    // nodes and edges whose content differs
+}
+
+// Empty reports whether the graphs matched
+func (d GraphDifference) Empty() bool {
+	return len(d.Missing) == 0 && len(d.Unexpected) == 0 && len(d.Changed) == 0
+}
+
+// Report is the outcome of replaying a bundle
+type Report struct {
+	Results    []Result        `json:"results"`
+	Mismatches int             `json:"mismatches"`
+	Graph      GraphDifference `json:"graph"`
+	Reproduced bool            `json:"reproduced"` // every status and the final graph matched
+}
+
+// Replay sends the bundle's requests in the order they arrived to the instance at baseURL,
+// waiting the recorded gap between them (up to maxGap), and compares the statuses and the
+// final graph with what was recorded. The instance should start from the bundle's initial graph.
+func Replay(ctx context.Context, bundle *Bundle, baseURL string, client *http.Client, maxGap time.Duration) (*Report, error) {
+	baseURL = strings.TrimRight(baseURL, "/")
+	report := &Report{Results: []Result{}}
+	for i, exchange := range bundle.Exchanges {
+		if i > 0 {
+			gap := exchange.At.Sub(bundle.Exchanges[i-1].At)
+			if gap > maxGap {
+				gap = maxGap
+			}
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(gap):
+			}
+		}
+		result := Result{Sequence: exchange.Sequence, Method: exchange.Method, Path: exchange.Path, RecordedStatus: exchange.Status}
+		status, err := send(ctx, client, baseURL, exchange)
+		if err != nil {
+			result.Error = err.Error()
+		}
+		result.Status = status
+		result.Matched = err == nil && status == exchange.Status
+		if !result.Matched {
+			report.Mismatches++
+		}
+		report.Results = append(report.Results, result)
+	}
+
+	if bundle.Final != nil {
+		replayed, err := fetchGraph(ctx, client, baseURL)
+		if err != nil {
+			return nil, err
+		}
+		report.Graph = compareGraphs(bundle.Final, replayed)
+	}
+	report.Reproduced = report.Mismatches == 0 && report.Graph.Empty()
+	return report, nil
+}
+
+func send(ctx context.Context, client *http.Client, baseURL string, exchange Exchange) (int, error) {
+	target := baseURL + exchange.Path
+	if exchange.Query != "" {
+		target += "?" + exchange.Query
+	}
+	req, err := http.NewRequestWithContext(ctx, exchange.Method, target, strings.NewReader(exchange.RequestBody))
+	if err != nil {
+		return 0, err
+	}
+	for name, value := range exchange.Headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
+
+func fetchGraph(ctx context.Context, client *http.Client, baseURL string) (*graph.Graph, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/v1/graph", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch the replayed graph: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch the replayed graph: %s", resp.Status)
+	}
+	var g graph.Graph
+	if err := json.NewDecoder(resp.Body).Decode(&g); err != nil {
+		return nil, fmt.Errorf("failed to decode the replayed graph: %w", err)
+	}
+	return &g, nil
+}
+
+// compareGraphs compares node and edge content after a JSON round trip, so graphs loaded from
+// different backends compare equal. Timestamps generated during the replay will differ.
+func compareGraphs(recorded, replayed *graph.Graph) GraphDifference {
+	var difference GraphDifference
+	expected, actual := contents(recorded), contents(replayed)
+	for id, content := range expected {
+		other, ok := actual[id]
+		switch {
+		case !ok:
+			difference.Missing = append(difference.Missing, id)
+		case !reflect.DeepEqual(content, other):
+			difference.Changed = append(difference.Changed, id)
+		}
+	}
+	for id := range actual {
+		if _, ok := expected[id]; !ok {
+			difference.Unexpected = append(difference.Unexpected, id)
+		}
+	}
+	sort.Strings(difference.Missing)
+	sort.Strings(difference.Unexpected)
+	sort.Strings(difference.Changed)
+	return difference
+}
+
+// contents decodes every node and edge of the graph to generic JSON values, keyed like Change IDs
+func contents(g *graph.Graph) map[string]interface{} {
+	result := map[string]interface{}{}
+	normalize := func(v interface{}) interface{} {
+		data, _ := json.Marshal(v)
+		var out interface{}
+		json.Unmarshal(data, &out)
+		return out
+	}
+	for id, node := range g.Nodes {
+		if node != nil {
+			result["node:"+id] = normalize(node)
+		}
+	}
+	for from, edges := range g.Edges {
+		for _, edge := range edges {
+			result["edge:"+from+"->"+edge.To+":"+edge.Type] = normalize(edge)
+		}
+	}
+	return result
+}
@@ -0,0 +1,249 @@
+package recovery
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+)
+
+// Drill statuses
+const (
+	DrillScheduled = "scheduled"
+	DrillPassed    = "passed"
+	DrillFailed    = "failed"
+	DrillCancelled = "cancelled"
+)
+
+// DrillCompletedSubject is the notify event emitted when a drill outcome is recorded
+const DrillCompletedSubject = "dr.drill.completed"
+
+// Drill errors
+var (
+	ErrDrillNotFound  = errors.New("drill not found")
+	ErrDrillCompleted = errors.New("drill already completed")
+)
+
+// Drill is a scheduled exercise of an application's runbook in an environment
+type Drill struct {
+	ID             string    `json:"id"`
+	Application    string    `json:"application"`
+	Environment    string    `json:"environment"`
+	RunbookVersion int       `json:"runbook_version"`
+	ScheduledFor   time.Time `json:"scheduled_for"`
+	Status         string    `json:"status"` // scheduled | passed | failed | cancelled
+	// Set once the outcome is recorded
+	ActualRTOMinutes int          `json:"actual_rto_minutes,omitempty"`
+	StepResults      []StepResult `json:"step_results,omitempty"`
+	Findings         []string     `json:"findings,omitempty"`
+	CreatedAt        time.Time    `json:"created_at"`
+	CompletedAt      *time.Time   `json:"completed_at,omitempty"`
+}
+
+// StepResult is how restoring one runbook step went during a drill
+type StepResult struct {
+	Target    string `json:"target"`
+	Succeeded bool   `json:"succeeded"`
+	Minutes   int    `json:"minutes"`
+	Notes     string `json:"notes,omitempty"`
+}
+
+// Outcome is what a drill found
+type Outcome struct {
+	Status           string       `json:"status"` // passed | failed | cancelled
+	ActualRTOMinutes int          `json:"actual_rto_minutes"`
+	StepResults      []StepResult `json:"step_results"`
+	Findings         []string     `json:"findings"`
+}
+
+// ScheduleDrill schedules a drill of the application's current runbook in an environment
+func (s *Service) ScheduleDrill(appName, environment string, scheduledFor time.Time) (*Drill, error) {
+	runbook, err := s.GetRunbook(appName)
+	if err != nil {
+		return nil, err
+	}
+	if env, _ := s.graph.GetNode(environment); env == nil || env.Kind != graph.KindEnvironment {
+		return nil, fmt.Errorf("environment %s not found", environment)
+	}
+	if scheduledFor.IsZero() {
+		return nil, fmt.Errorf("scheduled_for is required")
+	}
+
+	now := s.now().UTC()
+	drill := &Drill{
+		ID:             fmt.Sprintf("drill-%d", now.UnixNano()),
+		Application:    appName,
+		Environment:    environment,
+		RunbookVersion: runbook.Version,
+		ScheduledFor:   scheduledFor.UTC(),
+		Status:         DrillScheduled,
+		CreatedAt:      now,
+	}
+	if err := s.saveDrill(drill); err != nil {
+		return nil, err
+	}
+	s.logger.Info("🧯 Scheduled DR drill %s of %s in %s for %s", drill.ID, appName, environment, drill.ScheduledFor.Format(time.RFC3339))
+	return drill, nil
+}
+
+// GetDrill returns a drill by ID
+func (s *Service) GetDrill(id string) (*Drill, error) {
+	node, _ := s.graph.GetNode(drillIDPrefix + id)
+	if node == nil || node.Kind != graph.KindDRDrill {
+		return nil, ErrDrillNotFound
+	}
+	var drill Drill
+	if err := decodeSpec(node, &drill); err != nil {
+		return nil, fmt.Errorf("invalid drill %s: %w", id, err)
+	}
+	return &drill, nil
+}
+
+// ListDrills returns the application's drills, most recently scheduled first
+func (s *Service) ListDrills(appName string) ([]*Drill, error) {
+	nodes, err := s.graph.Nodes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph: %w", err)
+	}
+	drills := []*Drill{}
+	for _, node := range nodes {
+		if node.Kind != graph.KindDRDrill {
+			continue
+		}
+		var drill Drill
+		if err := decodeSpec(node, &drill); err != nil {
+			s.logger.Warn("⚠️ Skipping malformed drill %s: %v", node.ID, err)
+			continue
+		}
+		if drill.Application == appName {
+			drills = append(drills, &drill)
+		}
+	}
+	sort.Slice(drills, func(i, j int) bool {
+		if !drills[i].ScheduledFor.Equal(drills[j].ScheduledFor) {
+			return drills[i].ScheduledFor.After(drills[j].ScheduledFor)
+		}
+		return drills[i].ID > drills[j].ID
+	})
+	return drills, nil
+}
+
+// RecordOutcome completes a scheduled drill. The runbook records the measured RTO, the next
+// regeneration uses the drill's step times, and a notify event reports the estimated and
+// measured RTO. Without an actual RTO the step times are summed.
+func (s *Service) RecordOutcome(id string, outcome Outcome) (*Drill, error) {
+	switch outcome.Status {
+	case DrillPassed, DrillFailed, DrillCancelled:
+	default:
+		return nil, fmt.Errorf("unknown drill status %q (use passed, failed or cancelled)", outcome.Status)
+	}
+	drill, err := s.GetDrill(id)
+	if err != nil {
+		return nil, err
+	}
+	if drill.Status != DrillScheduled {
+		return nil, fmt.Errorf("%w: %s is %s", ErrDrillCompleted, id, drill.Status)
+	}
+
+	completedAt := s.now().UTC()
+	drill.Status = outcome.Status
+	drill.StepResults = outcome.StepResults
+	drill.Findings = outcome.Findings
+	drill.ActualRTOMinutes = outcome.ActualRTOMinutes
+	if drill.ActualRTOMinutes == 0 {
+		for _, result := range outcome.StepResults {
+			drill.ActualRTOMinutes += result.Minutes
+		}
+	}
+	drill.CompletedAt = &completedAt
+	if err := s.saveDrill(drill); err != nil {
+		return nil, err
+	}
+
+	if drill.Status == DrillCancelled {
+		s.logger.Info("🧯 DR drill %s of %s cancelled", id, drill.Application)
+		return drill, nil
+	}
+	runbook, err := s.GetRunbook(drill.Application)
+	if err != nil {
+		return nil, err
+	}
+	runbook.LastDrillID, runbook.LastDrillStatus = drill.ID, drill.Status
+	runbook.MeasuredRTOMinutes = drill.ActualRTOMinutes
+	if err := s.saveRunbook(runbook); err != nil {
+		return nil, err
+	}
+	s.notifyDrillCompleted(drill, runbook)
+	s.logger.Info("🧯 DR drill %s of %s %s: RTO %d minutes (estimated %d)", id, drill.Application, drill.Status, drill.ActualRTOMinutes, runbook.RTOMinutes)
+	return drill, nil
+}
+
+// lastCompletedDrill returns the application's most recent passed or failed drill, if any
+func (s *Service) lastCompletedDrill(appName string) (*Drill, error) {
+	drills, err := s.ListDrills(appName)
+	if err != nil {
+		return nil, err
+	}
+	var last *Drill
+	for _, drill := range drills {
+		if drill.CompletedAt == nil || drill.Status == DrillCancelled {
+			continue
+		}
+		if last == nil || drill.CompletedAt.After(*last.CompletedAt) {
+			last = drill
+		}
+	}
+	return last, nil
+}
+
+func (s *Service) notifyDrillCompleted(drill *Drill, runbook *Runbook) {
+	bus := s.eventBus
+	if bus == nil {
+		bus = events.GlobalEventBus
+	}
+	if bus == nil {
+		return
+	}
+	var failed []string
+	for _, result := range drill.StepResults {
+		if !result.Succeeded {
+			failed = append(failed, result.Target)
+		}
+	}
+	bus.Emit(events.EventTypeNotify, "recovery", DrillCompletedSubject, map[string]interface{}{
+		"drill_id":          drill.ID,
+		"application":       drill.Application,
+		"environment":       drill.Environment,
+		"status":            drill.Status,
+		"runbook_version":   drill.RunbookVersion,
+		"estimated_rto":     runbook.RTOMinutes,
+		"actual_rto":        drill.ActualRTOMinutes,
+		"failed_steps":      failed,
+		"findings":          drill.Findings,
+		"regenerate_needed": drill.Status == DrillFailed || drill.ActualRTOMinutes > runbook.RTOMinutes,
+	})
+}
+
+func (s *Service) saveDrill(drill *Drill) error {
+	spec, err := encodeSpec(drill)
+	if err != nil {
+		return fmt.Errorf("failed to encode drill: %w", err)
+	}
+	if err := s.putNode(&graph.Node{
+		ID:   drillIDPrefix + drill.ID,
+		Kind: graph.KindDRDrill,
+		Metadata: map[string]interface{}{
+			"name":        drill.ID,
+			"application": drill.Application,
+			"environment": drill.Environment,
+			"status":      drill.Status,
+		},
+		Spec: spec,
+	}); err != nil {
+		return fmt.Errorf("failed to save drill: %w", err)
+	}
+	return nil
+}
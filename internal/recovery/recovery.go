@@ -0,0 +1,442 @@
+// Package recovery generates disaster-recovery runbooks from the global graph and tracks the
+// DR drills that exercise them. A runbook lists what an application depends on, the order its
+// resources and services are restored in and an RTO estimate; drill outcomes are recorded
+// against it so the next runbook uses the measured restore times.
+package recovery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/ai"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/krzachariassen/ZTDP/internal/redaction"
+)
+
+// Step kinds
+const (
+	StepResource = "resource"
+	StepService  = "service"
+)
+
+// serviceRestoreMinutes is the estimated time to redeploy and health-check a service
+const serviceRestoreMinutes = 5
+
+// resourceRestoreMinutes estimates restoring a resource by its type; data stores are restored
+// from backups and take longest
+var resourceRestoreMinutes = map[string]int{
+	"postgres":      30,
+	"mysql":         30,
+	"mongodb":       30,
+	"elasticsearch": 45,
+	"kafka":         20,
+	"rabbitmq":      10,
+	"redis":         10,
+	"s3":            15,
+}
+
+// defaultResourceRestoreMinutes is used for resource types without an estimate
+const defaultResourceRestoreMinutes = 15
+
+// Node ID prefixes namespace recovery nodes so they cannot collide with platform entities
+const (
+	runbookIDPrefix = "runbook:"
+	drillIDPrefix   = "drill:"
+)
+
+// ErrRunbookNotFound is returned when an application has no runbook yet
+var ErrRunbookNotFound = errors.New("runbook not found")
+
+// Runbook is the recovery procedure for an application
+type Runbook struct {
+	Application string `json:"application"`
+	// Version increases each time the runbook is regenerated; drills record the version they ran
+	Version int `json:"version"`
+	// Dependencies are services of other applications that must be up before recovery starts
+	Dependencies []string `json:"dependencies"`
+	// Steps are in restore order; steps with the same order can run in parallel
+	Steps      []Step `json:"steps"`
+	RTOMinutes int    `json:"rto_minutes"`
+	Procedure  string `json:"procedure"`
+	// Source is "ai" when the procedure was written by the AI provider, "generated" otherwise
+	Source      string    `json:"source"`
+	GeneratedAt time.Time `json:"generated_at"`
+	// Set once a drill has exercised the runbook
+	LastDrillID        string `json:"last_drill_id,omitempty"`
+	LastDrillStatus    string `json:"last_drill_status,omitempty"`
+	MeasuredRTOMinutes int    `json:"measured_rto_minutes,omitempty"`
+}
+
+// Step restores one resource or service
+type Step struct {
+	Order            int      `json:"order"`
+	Target           string   `json:"target"`
+	Kind             string   `json:"kind"` // resource | service
+	Action           string   `json:"action"`
+	DependsOn        []string `json:"depends_on,omitempty"`
+	EstimatedMinutes int      `json:"estimated_minutes"`
+	// Measured is set when the estimate comes from the last drill rather than the defaults
+	Measured bool   `json:"measured,omitempty"`
+	Notes    string `json:"notes,omitempty"`
+}
+
+// Service generates runbooks and schedules drills in the global graph
+type Service struct {
+	graph      *graph.GlobalGraph
+	aiProvider ai.AIProvider
+	eventBus   *events.EventBus
+	logger     *logging.Logger
+	now        func() time.Time
+}
+
+// NewService creates a recovery service; without an AI provider runbook procedures are generated
+// from the restore order
+func NewService(globalGraph *graph.GlobalGraph, aiProvider ai.AIProvider) *Service {
+	return &Service{
+		graph:      globalGraph,
+		aiProvider: aiProvider,
+		logger:     logging.GetLogger().ForComponent("recovery"),
+		now:        time.Now,
+	}
+}
+
+// GenerateRunbook builds the application's runbook from the graph and stores it, replacing the
+// previous version. Restore times measured by the application's last completed drill replace the
+// default estimates, and steps that failed in it are called out.
+func (s *Service) GenerateRunbook(ctx context.Context, appName string) (*Runbook, error) {
+	if app, _ := s.graph.GetNode(appName); app == nil || app.Kind != graph.KindApplication {
+		return nil, fmt.Errorf("application %s not found", appName)
+	}
+	nodes, err := s.graph.Nodes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph: %w", err)
+	}
+	edges, err := s.graph.Edges()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph: %w", err)
+	}
+	lastDrill, err := s.lastCompletedDrill(appName)
+	if err != nil {
+		return nil, err
+	}
+
+	runbook := restorePlan(nodes, edges, appName)
+	if lastDrill != nil {
+		applyDrill(runbook, lastDrill)
+	}
+	runbook.RTOMinutes = estimateRTO(runbook.Steps)
+	if previous, err := s.GetRunbook(appName); err == nil {
+		runbook.Version = previous.Version + 1
+		runbook.LastDrillID, runbook.LastDrillStatus = previous.LastDrillID, previous.LastDrillStatus
+		runbook.MeasuredRTOMinutes = previous.MeasuredRTOMinutes
+	} else {
+		runbook.Version = 1
+	}
+	runbook.Procedure, runbook.Source = s.writeProcedure(ctx, runbook, lastDrill)
+	runbook.GeneratedAt = s.now().UTC()
+
+	if err := s.saveRunbook(runbook); err != nil {
+		return nil, err
+	}
+	s.logger.Info("🧯 Generated runbook v%d for %s: %d steps, RTO %d minutes", runbook.Version, appName, len(runbook.Steps), runbook.RTOMinutes)
+	return runbook, nil
+}
+
+// GetRunbook returns the application's current runbook
+func (s *Service) GetRunbook(appName string) (*Runbook, error) {
+	node, _ := s.graph.GetNode(runbookIDPrefix + appName)
+	if node == nil || node.Kind != graph.KindRunbook {
+		return nil, ErrRunbookNotFound
+	}
+	var runbook Runbook
+	if err := decodeSpec(node, &runbook); err != nil {
+		return nil, fmt.Errorf("invalid runbook for %s: %w", appName, err)
+	}
+	return &runbook, nil
+}
+
+// restorePlan orders the application's recovery: the resources it and its services own, use or
+// were granted come first and restore in parallel, then its services in waves, each after the
+// services it consumes. Services of other applications it consumes become dependencies.
+func restorePlan(nodes map[string]*graph.Node, edges map[string][]graph.Edge, appName string) *Runbook {
+	runbook := &Runbook{Application: appName, Dependencies: []string{}, Steps: []Step{}}
+
+	var services []string
+	owned := map[string]bool{}
+	for _, edge := range edges[appName] {
+		if node, ok := nodes[edge.To]; ok && edge.Type == graph.EdgeTypeOwns && node.Kind == graph.KindService {
+			services = append(services, edge.To)
+			owned[edge.To] = true
+		}
+	}
+	sort.Strings(services)
+
+	resources := map[string]bool{}
+	addResource := func(from string) []string {
+		var used []string
+		for _, edge := range edges[from] {
+			node, ok := nodes[edge.To]
+			if !ok || node.Kind != graph.KindResource {
+				continue
+			}
+			switch edge.Type {
+			case graph.EdgeTypeOwns, graph.EdgeTypeUses, graph.EdgeTypeAccesses:
+				resources[edge.To] = true
+				used = append(used, edge.To)
+			}
+		}
+		return used
+	}
+	addResource(appName)
+
+	dependsOn := map[string][]string{}
+	consumes := map[string][]string{}
+	external := map[string]bool{}
+	for _, service := range services {
+		dependsOn[service] = addResource(service)
+		for _, edge := range edges[service] {
+			if edge.Type != graph.EdgeTypeConsumes {
+				continue
+			}
+			if owned[edge.To] {
+				consumes[service] = append(consumes[service], edge.To)
+			} else {
+				external[edge.To] = true
+			}
+		}
+	}
+
+	var resourceNames []string
+	for name := range resources {
+		resourceNames = append(resourceNames, name)
+	}
+	sort.Strings(resourceNames)
+	for _, name := range resourceNames {
+		resourceType, _ := nodes[name].Spec["type"].(string)
+		minutes, ok := resourceRestoreMinutes[resourceType]
+		if !ok {
+			minutes = defaultResourceRestoreMinutes
+		}
+		action := "Restore from the latest backup and verify connectivity"
+		if resourceType != "" {
+			action = fmt.Sprintf("Restore %s from the latest backup and verify connectivity", resourceType)
+		}
+		runbook.Steps = append(runbook.Steps, Step{Order: 1, Target: name, Kind: StepResource, Action: action, EstimatedMinutes: minutes})
+	}
+
+	// Services restore in waves: a service waits for every service it consumes
+	levels := map[string]int{}
+	remaining := append([]string(nil), services...)
+	for wave := 2; len(remaining) > 0; wave++ {
+		var ready, blocked []string
+		for _, service := range remaining {
+			if consumedRestored(consumes[service], levels) {
+				ready = append(ready, service)
+			} else {
+				blocked = append(blocked, service)
+			}
+		}
+		notes := ""
+		if len(ready) == 0 {
+			// The rest consume each other; restore them together and let retries settle it
+			ready, blocked = blocked, nil
+			notes = "Part of a dependency cycle; restore together with the other services in this step"
+		}
+		for _, service := range ready {
+			levels[service] = wave
+			depends := append(append([]string(nil), dependsOn[service]...), consumes[service]...)
+			sort.Strings(depends)
+			runbook.Steps = append(runbook.Steps, Step{
+				Order:            wave,
+				Target:           service,
+				Kind:             StepService,
+				Action:           "Redeploy the current release and wait for health checks",
+				DependsOn:        depends,
+				EstimatedMinutes: serviceRestoreMinutes,
+				Notes:            notes,
+			})
+		}
+		remaining = blocked
+	}
+
+	for name := range external {
+		runbook.Dependencies = append(runbook.Dependencies, name)
+	}
+	sort.Strings(runbook.Dependencies)
+	return runbook
+}
+
+func consumedRestored(consumed []string, levels map[string]int) bool {
+	for _, name := range consumed {
+		if _, ok := levels[name]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// applyDrill replaces estimates with the restore times a drill measured and notes failed steps
+func applyDrill(runbook *Runbook, drill *Drill) {
+	results := map[string]StepResult{}
+	for _, result := range drill.StepResults {
+		results[result.Target] = result
+	}
+	for i := range runbook.Steps {
+		result, ok := results[runbook.Steps[i].Target]
+		if !ok {
+			continue
+		}
+		if result.Minutes > 0 {
+			runbook.Steps[i].EstimatedMinutes = result.Minutes
+			runbook.Steps[i].Measured = true
+		}
+		if !result.Succeeded {
+			note := fmt.Sprintf("Failed in drill %s", drill.ID)
+			if result.Notes != "" {
+				note += ": " + result.Notes
+			}
+			if runbook.Steps[i].Notes != "" {
+				note = runbook.Steps[i].Notes + "; " + note
+			}
+			runbook.Steps[i].Notes = note
+		}
+	}
+}
+
+// estimateRTO is the time to work through the steps with each order's steps run in parallel
+func estimateRTO(steps []Step) int {
+	longest := map[int]int{}
+	for _, step := range steps {
+		if step.EstimatedMinutes > longest[step.Order] {
+			longest[step.Order] = step.EstimatedMinutes
+		}
+	}
+	total := 0
+	for _, minutes := range longest {
+		total += minutes
+	}
+	return total
+}
+
+// writeProcedure asks the AI provider to write the runbook's procedure and falls back to one
+// generated from the steps
+func (s *Service) writeProcedure(ctx context.Context, runbook *Runbook, lastDrill *Drill) (string, string) {
+	generated := generatedProcedure(runbook)
+	if s.aiProvider == nil {
+		return generated, "generated"
+	}
+
+	input := map[string]interface{}{"runbook": runbook}
+	if lastDrill != nil {
+		input["last_drill"] = lastDrill
+	}
+	raw, err := json.Marshal(input)
+	if err != nil {
+		return generated, "generated"
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return generated, "generated"
+	}
+	// Drill findings and step notes are free text and can contain credentials
+	redacted, err := json.Marshal(redaction.Default().Map(payload))
+	if err != nil {
+		return generated, "generated"
+	}
+
+	systemPrompt := `You are a site reliability engineer writing a disaster-recovery runbook for an on-call engineer.
+Using the restore steps given, write a numbered procedure in plain text: confirm the dependencies are up,
+restore the steps in order (steps with the same order can run in parallel), and say how to verify each
+one. Keep the given order and targets, mention the RTO estimate, and call out steps that failed in the
+last drill. Return only the procedure.`
+	userPrompt := fmt.Sprintf("Runbook for application %s:\n%s", runbook.Application, redacted)
+
+	response, err := s.aiProvider.CallAI(ai.WithTask(ctx, ai.TaskConversation), systemPrompt, userPrompt)
+	if err != nil || strings.TrimSpace(response) == "" {
+		s.logger.Warn("⚠️ AI runbook procedure unavailable, using generated procedure: %v", err)
+		return generated, "generated"
+	}
+	return strings.TrimSpace(response), "ai"
+}
+
+func generatedProcedure(runbook *Runbook) string {
+	var lines []string
+	number := 1
+	if len(runbook.Dependencies) > 0 {
+		lines = append(lines, fmt.Sprintf("%d. Confirm these dependencies are up: %s.", number, strings.Join(runbook.Dependencies, ", ")))
+		number++
+	}
+	for _, step := range runbook.Steps {
+		line := fmt.Sprintf("%d. [order %d] %s: %s (~%d min).", number, step.Order, step.Target, step.Action, step.EstimatedMinutes)
+		if step.Notes != "" {
+			line += " Note: " + step.Notes + "."
+		}
+		lines = append(lines, line)
+		number++
+	}
+	if len(runbook.Steps) == 0 {
+		lines = append(lines, fmt.Sprintf("%d. %s has no services or resources to restore.", number, runbook.Application))
+	}
+	lines = append(lines, fmt.Sprintf("Estimated recovery time: %d minutes.", runbook.RTOMinutes))
+	return strings.Join(lines, "\n")
+}
+
+func (s *Service) saveRunbook(runbook *Runbook) error {
+	spec, err := encodeSpec(runbook)
+	if err != nil {
+		return fmt.Errorf("failed to encode runbook: %w", err)
+	}
+	if err := s.putNode(&graph.Node{
+		ID:   runbookIDPrefix + runbook.Application,
+		Kind: graph.KindRunbook,
+		Metadata: map[string]interface{}{
+			"name":        runbookIDPrefix + runbook.Application,
+			"application": runbook.Application,
+			"version":     runbook.Version,
+		},
+		Spec: spec,
+	}); err != nil {
+		return fmt.Errorf("failed to save runbook: %w", err)
+	}
+	return nil
+}
+
+// putNode stores a new node or replaces the existing one, as regenerated runbooks and completed
+// drills keep their IDs
+func (s *Service) putNode(node *graph.Node) error {
+	if existing, _ := s.graph.GetNode(node.ID); existing != nil {
+		if err := s.graph.UpdateNode(node); err != nil {
+			return err
+		}
+	} else if err := s.graph.AddNode(node); err != nil {
+		return err
+	}
+	return s.graph.Save()
+}
+
+func encodeSpec(value interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var spec map[string]interface{}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+func decodeSpec(node *graph.Node, value interface{}) error {
+	data, err := json.Marshal(node.Spec)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, value)
+}
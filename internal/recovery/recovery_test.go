@@ -0,0 +1,116 @@
+package recovery
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var drillDay = time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+func newRecoveryTestService(t *testing.T) *Service {
+	t.Helper()
+	g := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	for _, app := range []string{"checkout", "payments"} {
+		g.AddNode(&graph.Node{ID: app, Kind: graph.KindApplication, Metadata: map[string]interface{}{"name": app}, Spec: map[string]interface{}{}})
+	}
+	for _, service := range []string{"checkout-api", "checkout-web", "payments-api"} {
+		g.AddNode(&graph.Node{ID: service, Kind: graph.KindService, Metadata: map[string]interface{}{"name": service}, Spec: map[string]interface{}{}})
+	}
+	g.AddNode(&graph.Node{ID: "prod", Kind: graph.KindEnvironment, Metadata: map[string]interface{}{"name": "prod"}, Spec: map[string]interface{}{}})
+	g.AddNode(&graph.Node{ID: "checkout-db", Kind: graph.KindResource, Metadata: map[string]interface{}{"name": "checkout-db", "application": "checkout", "catalog_ref": "postgres"}, Spec: map[string]interface{}{"type": "postgres"}})
+	require.NoError(t, g.AddEdge("checkout", "checkout-api", graph.EdgeTypeOwns))
+	require.NoError(t, g.AddEdge("checkout", "checkout-web", graph.EdgeTypeOwns))
+	require.NoError(t, g.AddEdge("payments", "payments-api", graph.EdgeTypeOwns))
+	require.NoError(t, g.AddEdge("checkout", "checkout-db", graph.EdgeTypeOwns))
+	require.NoError(t, g.AddEdge("checkout-api", "checkout-db", graph.EdgeTypeUses))
+	require.NoError(t, g.AddEdge("checkout-web", "checkout-api", graph.EdgeTypeConsumes))
+	require.NoError(t, g.AddEdge("checkout-api", "payments-api", graph.EdgeTypeConsumes))
+
+	service := NewService(g, nil)
+	service.now = func() time.Time { return drillDay }
+	return service
+}
+
+func TestGenerateRunbook_RestoreOrder(t *testing.T) {
+	service := newRecoveryTestService(t)
+
+	runbook, err := service.GenerateRunbook(context.Background(), "checkout")
+	require.NoError(t, err)
+	assert.Equal(t, 1, runbook.Version)
+	assert.Equal(t, []string{"payments-api"}, runbook.Dependencies, "services of other applications must be up first")
+	require.Len(t, runbook.Steps, 3)
+	assert.Equal(t, Step{Order: 1, Target: "checkout-db", Kind: StepResource, Action: "Restore postgres from the latest backup and verify connectivity", EstimatedMinutes: 30}, runbook.Steps[0])
+	assert.Equal(t, 2, runbook.Steps[1].Order)
+	assert.Equal(t, "checkout-api", runbook.Steps[1].Target)
+	assert.Equal(t, []string{"checkout-db"}, runbook.Steps[1].DependsOn)
+	assert.Equal(t, 3, runbook.Steps[2].Order, "checkout-web waits for the service it consumes")
+	assert.Equal(t, []string{"checkout-api"}, runbook.Steps[2].DependsOn)
+	assert.Equal(t, 40, runbook.RTOMinutes)
+	assert.Equal(t, "generated", runbook.Source)
+	assert.Contains(t, runbook.Procedure, "Confirm these dependencies are up: payments-api")
+
+	stored, err := service.GetRunbook("checkout")
+	require.NoError(t, err)
+	assert.Equal(t, runbook.Steps, stored.Steps)
+	_, err = service.GetRunbook("payments")
+	assert.ErrorIs(t, err, ErrRunbookNotFound)
+}
+
+func TestDrillOutcomeFeedsRunbook(t *testing.T) {
+	service := newRecoveryTestService(t)
+	bus := events.NewEventBus(nil, false)
+	service.eventBus = bus
+	var notified []events.Event
+	bus.Subscribe(events.EventTypeNotify, func(event events.Event) error {
+		notified = append(notified, event)
+		return nil
+	})
+
+	_, err := service.ScheduleDrill("checkout", "prod", drillDay.Add(24*time.Hour))
+	assert.ErrorIs(t, err, ErrRunbookNotFound, "drills exercise a runbook")
+	_, err = service.GenerateRunbook(context.Background(), "checkout")
+	require.NoError(t, err)
+	drill, err := service.ScheduleDrill("checkout", "prod", drillDay.Add(24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, DrillScheduled, drill.Status)
+	assert.Equal(t, 1, drill.RunbookVersion)
+
+	drill, err = service.RecordOutcome(drill.ID, Outcome{
+		Status: DrillFailed,
+		StepResults: []StepResult{
+			{Target: "checkout-db", Succeeded: true, Minutes: 50},
+			{Target: "checkout-api", Succeeded: false, Minutes: 10, Notes: "payments credentials missing"},
+		},
+		Findings: []string{"restore credentials are not in the vault"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 60, drill.ActualRTOMinutes, "step times are summed without an actual RTO")
+	_, err = service.RecordOutcome(drill.ID, Outcome{Status: DrillPassed})
+	assert.ErrorIs(t, err, ErrDrillCompleted)
+
+	require.Len(t, notified, 1)
+	assert.Equal(t, DrillCompletedSubject, notified[0].Subject)
+	assert.Equal(t, []string{"checkout-api"}, notified[0].Payload["failed_steps"])
+	assert.Equal(t, true, notified[0].Payload["regenerate_needed"])
+
+	runbook, err := service.GenerateRunbook(context.Background(), "checkout")
+	require.NoError(t, err)
+	assert.Equal(t, 2, runbook.Version)
+	assert.Equal(t, drill.ID, runbook.LastDrillID)
+	assert.Equal(t, 60, runbook.MeasuredRTOMinutes)
+	assert.Equal(t, 50, runbook.Steps[0].EstimatedMinutes)
+	assert.True(t, runbook.Steps[0].Measured)
+	assert.Contains(t, runbook.Steps[1].Notes, "payments credentials missing")
+	assert.Equal(t, 65, runbook.RTOMinutes)
+
+	drills, err := service.ListDrills("checkout")
+	require.NoError(t, err)
+	require.Len(t, drills, 1)
+	assert.Equal(t, DrillFailed, drills[0].Status)
+}
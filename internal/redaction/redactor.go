@@ -0,0 +1,230 @@
+// Package redaction masks personal data and secrets before graph data reaches AI prompts,
+// logs or stored conversations
+package redaction
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Placeholder replaces the value of a denylisted field
+const Placeholder = "[REDACTED]"
+
+// ruleDenylistedField is the audit counter name for values removed by the field denylist
+const ruleDenylistedField = "denylisted_field"
+
+// Rule masks every match of Pattern with Replacement (which may reference groups)
+type Rule struct {
+	Name        string
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+// DefaultRules catch the secrets and personal data most often found in graph metadata and chats
+func DefaultRules() []Rule {
+	return []Rule{
+		{"email", regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`), "[REDACTED_EMAIL]"},
+		{"bearer_token", regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/=-]+`), "Bearer " + Placeholder},
+		{"api_token", regexp.MustCompile(`\b(sk|pk|ghp|xox[abp])[-_][A-Za-z0-9_-]{16,}`), "[REDACTED_TOKEN]"},
+		{"aws_access_key", regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`), "[REDACTED_TOKEN]"},
+		{"credential_assignment", regexp.MustCompile(`(?i)\b(password|passwd|secret|token|api[_-]?key)(\s*[=:]\s*)[^\s,;&]+`), "$1$2" + Placeholder},
+		{"url_credentials", regexp.MustCompile(`([a-z][a-z0-9+.-]*://[^:/\s@]+:)[^@\s]+@`), "$1" + Placeholder + "@"},
+	}
+}
+
+// DefaultDenyFields are map keys whose values are always replaced, whatever they contain
+func DefaultDenyFields() []string {
+	return []string{"password", "passwd", "secret", "token", "api_key", "apikey", "private_key", "credentials", "connection_string"}
+}
+
+// Config customizes a Redactor
+type Config struct {
+	Disabled   bool              // pass everything through unchanged
+	DenyFields []string          // extra field names, matched case-insensitively and as a suffix ("db_password")
+	Patterns   map[string]string // extra named regular expressions replaced with [REDACTED]
+}
+
+// Stats are the redaction audit counters
+type Stats struct {
+	Total  int64            `json:"total"`
+	ByRule map[string]int64 `json:"by_rule"`
+	Since  time.Time        `json:"since"`
+}
+
+// Redactor masks sensitive values and counts what it masked
+type Redactor struct {
+	disabled   bool
+	rules      []Rule
+	denyFields map[string]bool
+
+	mu     sync.Mutex
+	counts map[string]int64
+	since  time.Time
+}
+
+// New builds a redactor from the default rules and denylist plus cfg
+func New(cfg Config) (*Redactor, error) {
+	r := &Redactor{
+		disabled:   cfg.Disabled,
+		rules:      DefaultRules(),
+		denyFields: map[string]bool{},
+		counts:     map[string]int64{},
+		since:      time.Now().UTC(),
+	}
+	for _, field := range append(DefaultDenyFields(), cfg.DenyFields...) {
+		if field = normalizeField(field); field != "" {
+			r.denyFields[field] = true
+		}
+	}
+
+	names := make([]string, 0, len(cfg.Patterns))
+	for name := range cfg.Patterns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		pattern, err := regexp.Compile(cfg.Patterns[name])
+		if err != nil {
+			return nil, fmt.Errorf("redaction pattern %s: %w", name, err)
+		}
+		r.rules = append(r.rules, Rule{Name: name, Pattern: pattern, Replacement: Placeholder})
+	}
+	return r, nil
+}
+
+var (
+	defaultMu       sync.RWMutex
+	defaultRedactor = mustNew(Config{})
+)
+
+func mustNew(cfg Config) *Redactor {
+	r, err := New(cfg)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Default returns the process-wide redactor
+func Default() *Redactor {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultRedactor
+}
+
+// SetDefault replaces the process-wide redactor (called from main.go with configured rules)
+func SetDefault(r *Redactor) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultRedactor = r
+}
+
+// String masks every rule match in s
+func (r *Redactor) String(s string) string {
+	if r.disabled || s == "" {
+		return s
+	}
+	for _, rule := range r.rules {
+		matches := len(rule.Pattern.FindAllStringIndex(s, -1))
+		if matches == 0 {
+			continue
+		}
+		s = rule.Pattern.ReplaceAllString(s, rule.Replacement)
+		r.count(rule.Name, matches)
+	}
+	return s
+}
+
+// Map returns a redacted deep copy of m; the input is never modified
+func (r *Redactor) Map(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+	if r.disabled {
+		return m
+	}
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if r.isDenied(k) && v != nil && v != "" {
+			out[k] = Placeholder
+			r.count(ruleDenylistedField, 1)
+			continue
+		}
+		out[k] = r.Value(v)
+	}
+	return out
+}
+
+// Value redacts strings, maps and slices nested anywhere inside v
+func (r *Redactor) Value(v interface{}) interface{} {
+	if r.disabled {
+		return v
+	}
+	switch val := v.(type) {
+	case string:
+		return r.String(val)
+	case map[string]interface{}:
+		return r.Map(val)
+	case map[string]string:
+		out := make(map[string]interface{}, len(val))
+		for k, s := range val {
+			out[k] = s
+		}
+		return r.Map(out)
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = r.Value(item)
+		}
+		return out
+	case []string:
+		out := make([]string, len(val))
+		for i, item := range val {
+			out[i] = r.String(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// Stats returns a snapshot of the audit counters
+func (r *Redactor) Stats() Stats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := Stats{ByRule: make(map[string]int64, len(r.counts)), Since: r.since}
+	for rule, n := range r.counts {
+		stats.ByRule[rule] = n
+		stats.Total += n
+	}
+	return stats
+}
+
+func (r *Redactor) count(rule string, n int) {
+	r.mu.Lock()
+	r.counts[rule] += int64(n)
+	r.mu.Unlock()
+}
+
+// isDenied matches a field exactly or by suffix, so "db_password" is caught by "password"
+func (r *Redactor) isDenied(field string) bool {
+	field = normalizeField(field)
+	if r.denyFields[field] {
+		return true
+	}
+	for denied := range r.denyFields {
+		if strings.HasSuffix(field, "_"+denied) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeField(field string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(field)), "-", "_")
+}
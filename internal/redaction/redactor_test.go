@@ -0,0 +1,67 @@
+package redaction
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestString_MasksBuiltInPatterns(t *testing.T) {
+	r, err := New(Config{})
+	require.NoError(t, err)
+
+	got := r.String("mail ops@example.com, Authorization: Bearer abc.def.ghi, url postgres://app:s3cret@db:5432/orders, api_key=xyz123")
+	assert.NotContains(t, got, "ops@example.com")
+	assert.NotContains(t, got, "abc.def.ghi")
+	assert.NotContains(t, got, "s3cret")
+	assert.NotContains(t, got, "xyz123")
+	assert.Contains(t, got, "postgres://app:[REDACTED]@db:5432/orders")
+
+	stats := r.Stats()
+	assert.Equal(t, int64(1), stats.ByRule["email"])
+	assert.Equal(t, int64(1), stats.ByRule["url_credentials"])
+	assert.GreaterOrEqual(t, stats.Total, int64(4))
+}
+
+func TestMap_DenylistsFieldsAndRecurses(t *testing.T) {
+	r, err := New(Config{DenyFields: []string{"ssn"}})
+	require.NoError(t, err)
+
+	input := map[string]interface{}{
+		"name":              "orders-db",
+		"db_password":       "hunter2",
+		"Connection-String": "redis://cache:6379",
+		"ssn":               "123-45-6789",
+		"max_tokens":        4096,
+		"owner":             map[string]interface{}{"contact": "jane@example.com"},
+		"replicas":          []interface{}{"a", "token: abc"},
+	}
+	got := r.Map(input)
+
+	assert.Equal(t, "orders-db", got["name"])
+	assert.Equal(t, Placeholder, got["db_password"])
+	assert.Equal(t, Placeholder, got["Connection-String"])
+	assert.Equal(t, Placeholder, got["ssn"])
+	assert.Equal(t, 4096, got["max_tokens"], "fields merely containing a denied word are kept")
+	assert.Equal(t, "[REDACTED_EMAIL]", got["owner"].(map[string]interface{})["contact"])
+	assert.Equal(t, "token: [REDACTED]", got["replicas"].([]interface{})[1])
+
+	assert.Equal(t, "hunter2", input["db_password"], "input must not be modified")
+	assert.Equal(t, int64(3), r.Stats().ByRule[ruleDenylistedField])
+}
+
+func TestNew_CustomPatternsAndDisabled(t *testing.T) {
+	r, err := New(Config{Patterns: map[string]string{"employee_id": `EMP-[0-9]{6}`}})
+	require.NoError(t, err)
+	assert.Equal(t, "owner [REDACTED]", r.String("owner EMP-123456"))
+	assert.Equal(t, int64(1), r.Stats().ByRule["employee_id"])
+
+	_, err = New(Config{Patterns: map[string]string{"broken": `(`}})
+	assert.Error(t, err)
+
+	off, err := New(Config{Disabled: true})
+	require.NoError(t, err)
+	assert.Equal(t, "ops@example.com", off.String("ops@example.com"))
+	assert.Zero(t, off.Stats().Total)
+}
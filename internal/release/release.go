@@ -29,7 +29,9 @@ func (s *Service) CreateRelease(release contracts.ReleaseContract) error {
 	}
 
 	node, _ := graph.ResolveContract(release)
-	s.Graph.AddNode(node)
+	if err := s.Graph.AddNode(node); err != nil {
+		return err
+	}
 
 	// Create edges to link release to application and service versions
 	s.linkReleaseToApplication(release.Spec.Application, release.Metadata.Name)
@@ -0,0 +1,267 @@
+package resources
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+)
+
+// LifecycleState is the lifecycle state of a resource node
+type LifecycleState string
+
+const (
+	StateProvisioning   LifecycleState = "provisioning"
+	StateActive         LifecycleState = "active"
+	StateMaintenance    LifecycleState = "maintenance"
+	StateDeprecated     LifecycleState = "deprecated"
+	StateDecommissioned LifecycleState = "decommissioned"
+)
+
+// Node metadata keys holding the lifecycle; resources without a state are active
+const (
+	lifecycleStateKey     = "lifecycle_state"
+	lifecycleReasonKey    = "lifecycle_reason"
+	lifecycleChangedAtKey = "lifecycle_changed_at"
+)
+
+// LifecycleSubject is the notify event emitted when a resource changes state
+const LifecycleSubject = "resource.lifecycle.changed"
+
+// ErrInvalidTransition is returned for lifecycle transitions the state machine does not allow
+var ErrInvalidTransition = errors.New("invalid lifecycle transition")
+
+// transitions lists the states each state may move to. Decommissioned is final.
+var transitions = map[LifecycleState][]LifecycleState{
+	StateProvisioning: {StateActive, StateDecommissioned},
+	StateActive:       {StateMaintenance, StateDeprecated, StateDecommissioned},
+	StateMaintenance:  {StateActive, StateDeprecated, StateDecommissioned},
+	StateDeprecated:   {StateActive, StateMaintenance, StateDecommissioned},
+}
+
+// Valid reports whether s is a known lifecycle state
+func (s LifecycleState) Valid() bool {
+	_, ok := transitions[s]
+	return ok || s == StateDecommissioned
+}
+
+// CanTransitionTo reports whether a resource in state s may move to target
+func (s LifecycleState) CanTransitionTo(target LifecycleState) bool {
+	for _, allowed := range transitions[s] {
+		if allowed == target {
+			return true
+		}
+	}
+	return false
+}
+
+// Lifecycle is a resource's current lifecycle state and the services that depend on it
+type Lifecycle struct {
+	Resource          string         `json:"resource"`
+	State             LifecycleState `json:"state"`
+	Reason            string         `json:"reason,omitempty"`
+	ChangedAt         string         `json:"changed_at,omitempty"`
+	Previous          LifecycleState `json:"previous,omitempty"` // set on transitions only
+	DependentServices []string       `json:"dependent_services"`
+}
+
+// StateOf returns the lifecycle state recorded on a resource node
+func StateOf(node *graph.Node) LifecycleState {
+	if state, ok := node.Metadata[lifecycleStateKey].(string); ok && state != "" {
+		return LifecycleState(state)
+	}
+	return StateActive
+}
+
+// GetLifecycle returns a resource's lifecycle state
+func (s *Service) GetLifecycle(resourceName string) (*Lifecycle, error) {
+	node, err := s.Graph.GetNode(resourceName)
+	if err != nil || node == nil || node.Kind != graph.KindResource {
+		return nil, errors.New("resource not found")
+	}
+	dependents, err := s.dependentServices(resourceName)
+	if err != nil {
+		return nil, err
+	}
+	lifecycle := &Lifecycle{
+		Resource:          resourceName,
+		State:             StateOf(node),
+		DependentServices: dependents,
+	}
+	lifecycle.Reason, _ = node.Metadata[lifecycleReasonKey].(string)
+	lifecycle.ChangedAt, _ = node.Metadata[lifecycleChangedAtKey].(string)
+	return lifecycle, nil
+}
+
+// TransitionResource moves a resource to a new lifecycle state and notifies the services that
+// use it with a resource.lifecycle.changed event
+func (s *Service) TransitionResource(resourceName string, target LifecycleState, reason string) (*Lifecycle, error) {
+	if !target.Valid() {
+		return nil, fmt.Errorf("%w: unknown state %q", ErrInvalidTransition, target)
+	}
+	node, err := s.Graph.GetNode(resourceName)
+	if err != nil || node == nil || node.Kind != graph.KindResource {
+		return nil, errors.New("resource not found")
+	}
+	current := StateOf(node)
+	if !current.CanTransitionTo(target) {
+		return nil, fmt.Errorf("%w: %s cannot move from %s to %s", ErrInvalidTransition, resourceName, current, target)
+	}
+
+	if node.Metadata == nil {
+		node.Metadata = make(map[string]interface{})
+	}
+	node.Metadata[lifecycleStateKey] = string(target)
+	node.Metadata[lifecycleReasonKey] = reason
+	node.Metadata[lifecycleChangedAtKey] = time.Now().UTC().Format(time.RFC3339)
+	if err := s.Graph.UpdateNode(node); err != nil {
+		return nil, fmt.Errorf("failed to update resource: %w", err)
+	}
+	if err := s.Graph.Save(); err != nil {
+		return nil, errors.New("failed to save resource lifecycle")
+	}
+
+	lifecycle, err := s.GetLifecycle(resourceName)
+	if err != nil {
+		return nil, err
+	}
+	lifecycle.Previous = current
+	s.notifyDependents(lifecycle)
+	return lifecycle, nil
+}
+
+// dependentServices returns the services using the resource, or using an instance of it when it
+// is a catalog resource
+func (s *Service) dependentServices(resourceName string) ([]string, error) {
+	nodes, err := s.Graph.Nodes()
+	if err != nil {
+		return nil, err
+	}
+	edges, err := s.Graph.Edges()
+	if err != nil {
+		return nil, err
+	}
+
+	targets := map[string]bool{resourceName: true}
+	for id, node := range nodes {
+		if node.Kind == graph.KindResource && node.Metadata["catalog_ref"] == resourceName {
+			targets[id] = true
+		}
+	}
+
+	dependents := []string{}
+	for from, list := range edges {
+		if node, ok := nodes[from]; !ok || node.Kind != graph.KindService {
+			continue
+		}
+		for _, edge := range list {
+			if (edge.Type == graph.EdgeTypeUses || edge.Type == graph.EdgeTypeAccesses) && targets[edge.To] {
+				dependents = append(dependents, from)
+				break
+			}
+		}
+	}
+	sort.Strings(dependents)
+	return dependents, nil
+}
+
+func (s *Service) notifyDependents(lifecycle *Lifecycle) {
+	bus := s.EventBus
+	if bus == nil {
+		bus = events.GlobalEventBus
+	}
+	if bus == nil {
+		return
+	}
+	bus.Emit(events.EventTypeNotify, "resource-service", LifecycleSubject, map[string]interface{}{
+		"resource":           lifecycle.Resource,
+		"state":              string(lifecycle.State),
+		"previous_state":     string(lifecycle.Previous),
+		"reason":             lifecycle.Reason,
+		"dependent_services": lifecycle.DependentServices,
+		"message":            describeTransition(lifecycle),
+	})
+}
+
+func describeTransition(lifecycle *Lifecycle) string {
+	message := fmt.Sprintf("Resource %s moved from %s to %s", lifecycle.Resource, lifecycle.Previous, lifecycle.State)
+	if lifecycle.Reason != "" {
+		message += " (" + lifecycle.Reason + ")"
+	}
+	if len(lifecycle.DependentServices) > 0 {
+		message += "; affects " + strings.Join(lifecycle.DependentServices, ", ")
+	}
+	return message
+}
+
+// CheckDeployable is the deployment policy hook for resource lifecycles: an application may not
+// be deployed while a resource it owns, is granted access to or its services use is in
+// maintenance or decommissioned. Deprecated resources are returned as warnings.
+func CheckDeployable(g *graph.GlobalGraph, appName string) (warnings []string, err error) {
+	nodes, err := g.Nodes()
+	if err != nil {
+		return nil, err
+	}
+	edges, err := g.Edges()
+	if err != nil {
+		return nil, err
+	}
+
+	var blocked []string
+	for _, name := range applicationResources(nodes, edges, appName) {
+		node, ok := nodes[name]
+		if !ok || node.Kind != graph.KindResource {
+			continue
+		}
+		switch state := StateOf(node); state {
+		case StateMaintenance, StateDecommissioned:
+			blocked = append(blocked, fmt.Sprintf("%s is in %s", name, state))
+		case StateDeprecated:
+			warnings = append(warnings, fmt.Sprintf("%s is deprecated", name))
+		}
+	}
+	if len(blocked) > 0 {
+		return warnings, fmt.Errorf("resources unavailable: %s", strings.Join(blocked, ", "))
+	}
+	return warnings, nil
+}
+
+// applicationResources returns the resources an application owns, is granted access to or its
+// services use, sorted by name
+func applicationResources(nodes map[string]*graph.Node, edges map[string][]graph.Edge, appName string) []string {
+	resources := map[string]bool{}
+	for _, edge := range edges[appName] {
+		node, ok := nodes[edge.To]
+		if !ok {
+			continue
+		}
+		if edge.Type == graph.EdgeTypeAccesses {
+			resources[edge.To] = true
+			continue
+		}
+		if edge.Type != graph.EdgeTypeOwns {
+			continue
+		}
+		switch node.Kind {
+		case graph.KindResource:
+			resources[edge.To] = true
+		case graph.KindService:
+			for _, used := range edges[edge.To] {
+				if used.Type == graph.EdgeTypeUses || used.Type == graph.EdgeTypeAccesses {
+					resources[used.To] = true
+				}
+			}
+		}
+	}
+
+	names := make([]string, 0, len(resources))
+	for name := range resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
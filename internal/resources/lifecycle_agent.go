@@ -0,0 +1,201 @@
+package resources
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+	"github.com/krzachariassen/ZTDP/internal/ai"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/features"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
+)
+
+// LifecycleRequest is the structure the AI extracts from a resource lifecycle request
+type LifecycleRequest struct {
+	Action        string         `json:"action"` // transition | status
+	Resource      string         `json:"resource"`
+	State         LifecycleState `json:"state,omitempty"`
+	Reason        string         `json:"reason,omitempty"`
+	Confidence    float64        `json:"confidence"`
+	Clarification string         `json:"clarification,omitempty"`
+}
+
+// LifecycleAgent turns natural language requests such as "put orders-db into maintenance"
+// into resource lifecycle transitions
+type LifecycleAgent struct {
+	service    *Service
+	aiProvider ai.AIProvider
+	logger     *logging.Logger
+}
+
+// NewLifecycleAgent creates the resource lifecycle agent
+func NewLifecycleAgent(
+	globalGraph *graph.GlobalGraph,
+	aiProvider ai.AIProvider,
+	eventBus *events.EventBus,
+	registry agentRegistry.AgentRegistry,
+) (agentRegistry.AgentInterface, error) {
+	if aiProvider == nil {
+		return nil, fmt.Errorf("aiProvider is required for AI-native agent")
+	}
+	if eventBus == nil {
+		return nil, fmt.Errorf("eventBus is required")
+	}
+	if registry == nil {
+		return nil, fmt.Errorf("registry is required")
+	}
+
+	service := NewService(globalGraph)
+	service.EventBus = eventBus
+	wrapper := &LifecycleAgent{
+		service:    service,
+		aiProvider: aiProvider,
+		logger:     logging.GetLogger().ForComponent("resource-lifecycle-agent"),
+	}
+
+	agent, err := agentframework.NewAgent("resource-lifecycle-agent").
+		WithType("resource").
+		WithCapabilities(getLifecycleCapabilities()).
+		WithEventHandler(wrapper.handleEvent).
+		Build(agentframework.AgentDependencies{
+			Registry: registry,
+			EventBus: eventBus,
+			Flags:    features.NewService(globalGraph),
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build resource lifecycle agent: %w", err)
+	}
+
+	wrapper.logger.Info("✅ LifecycleAgent created successfully")
+	return agent, nil
+}
+
+// getLifecycleCapabilities returns the capabilities for the resource lifecycle agent
+func getLifecycleCapabilities() []agentRegistry.AgentCapability {
+	return []agentRegistry.AgentCapability{
+		{
+			Name:        "resource_lifecycle",
+			Description: "Moves resources between provisioning, active, maintenance, deprecated and decommissioned, and reports their state",
+			Intents: []string{
+				"resource maintenance", "deprecate resource", "decommission resource", "activate resource", "resource status",
+			},
+			InputTypes:  []string{"user_message"},
+			OutputTypes: []string{"resource_lifecycle"},
+			RoutingKeys: []string{"resource.lifecycle", "resource.maintenance", "resource.request"},
+			Version:     "1.0.0",
+		},
+	}
+}
+
+// handleEvent extracts the lifecycle request with AI and applies it
+func (a *LifecycleAgent) handleEvent(ctx context.Context, event *events.Event) (*events.Event, error) {
+	userMessage, ok := event.Payload["user_message"].(string)
+	if !ok || userMessage == "" {
+		return a.createErrorResponse(event, "user_message field is required in event payload"), nil
+	}
+
+	request, err := a.extractRequest(ctx, userMessage)
+	if err != nil {
+		return a.createErrorResponse(event, fmt.Sprintf("I couldn't understand the resource request: %v", err)), nil
+	}
+	if agentframework.NeedsClarification(ctx, request.Confidence) || request.Resource == "" {
+		clarification := request.Clarification
+		if clarification == "" {
+			clarification = "Which resource do you mean, and which state should it move to (active, maintenance, deprecated or decommissioned)?"
+		}
+		return agentframework.ClarificationResponse(ctx, event, clarification, request.Confidence), nil
+	}
+
+	switch request.Action {
+	case "transition":
+		lifecycle, err := a.service.TransitionResource(request.Resource, request.State, request.Reason)
+		if err != nil {
+			if errors.Is(err, ErrInvalidTransition) {
+				return a.createErrorResponse(event, fmt.Sprintf("Can't do that: %v", err)), nil
+			}
+			return a.createErrorResponse(event, fmt.Sprintf("Failed to update %s: %v", request.Resource, err)), nil
+		}
+		return a.createSuccessResponse(event, "🔧 "+describeTransition(lifecycle), lifecycle), nil
+	case "status":
+		lifecycle, err := a.service.GetLifecycle(request.Resource)
+		if err != nil {
+			return a.createErrorResponse(event, fmt.Sprintf("Failed to read %s: %v", request.Resource, err)), nil
+		}
+		message := fmt.Sprintf("Resource %s is %s", lifecycle.Resource, lifecycle.State)
+		if len(lifecycle.DependentServices) > 0 {
+			message += fmt.Sprintf("; used by %s", strings.Join(lifecycle.DependentServices, ", "))
+		}
+		return a.createSuccessResponse(event, message, lifecycle), nil
+	default:
+		return a.createErrorResponse(event, fmt.Sprintf("I can change or report a resource's lifecycle state, not %q", request.Action)), nil
+	}
+}
+
+// extractRequest asks the AI to turn the user message into a LifecycleRequest
+func (a *LifecycleAgent) extractRequest(ctx context.Context, userMessage string) (*LifecycleRequest, error) {
+	systemPrompt := `You manage the lifecycle of platform resources (databases, queues, caches). Extract the request as JSON:
+{"action": "transition|status", "resource": "", "state": "active|maintenance|deprecated|decommissioned", "reason": "", "confidence": 0.0, "clarification": ""}
+
+Rules:
+- transition changes the resource's state; status only reports it
+- "take out of maintenance" or "bring back" means state active
+- reason is the user's explanation, if any (e.g. "patching", "replaced by orders-db-v2")
+- Set confidence below 0.7 and explain in clarification when the resource or target state is missing
+
+Respond with JSON only.`
+
+	response, err := a.aiProvider.CallAI(ai.WithTask(ctx, ai.TaskExtraction), systemPrompt, userMessage)
+	if err != nil {
+		return nil, err
+	}
+
+	cleaned := strings.TrimSpace(response)
+	cleaned = strings.TrimPrefix(cleaned, "```json")
+	cleaned = strings.TrimPrefix(cleaned, "```")
+	cleaned = strings.TrimSuffix(cleaned, "```")
+
+	var request LifecycleRequest
+	if err := json.Unmarshal([]byte(strings.TrimSpace(cleaned)), &request); err != nil {
+		return nil, fmt.Errorf("failed to parse AI response: %w", err)
+	}
+	a.logger.Info("🤖 AI extracted lifecycle action: %s %s → %s, confidence: %.2f", request.Action, request.Resource, request.State, request.Confidence)
+	return &request, nil
+}
+
+func (a *LifecycleAgent) createSuccessResponse(originalEvent *events.Event, message string, lifecycle *Lifecycle) *events.Event {
+	return &events.Event{
+		ID:        fmt.Sprintf("resource-lifecycle-response-%d", time.Now().UnixNano()),
+		Type:      events.EventTypeResponse,
+		Subject:   "resource.lifecycle.response",
+		Source:    "resource-lifecycle-agent",
+		Timestamp: time.Now().Unix(),
+		Payload: map[string]interface{}{
+			"status":         "success",
+			"message":        message,
+			"lifecycle":      lifecycle,
+			"correlation_id": originalEvent.Payload["correlation_id"],
+		},
+	}
+}
+
+func (a *LifecycleAgent) createErrorResponse(originalEvent *events.Event, errorMessage string) *events.Event {
+	return &events.Event{
+		ID:        fmt.Sprintf("resource-lifecycle-error-%d", time.Now().UnixNano()),
+		Type:      events.EventTypeResponse,
+		Subject:   "resource.lifecycle.error",
+		Source:    "resource-lifecycle-agent",
+		Timestamp: time.Now().Unix(),
+		Payload: map[string]interface{}{
+			"status":         "error",
+			"error":          errorMessage,
+			"correlation_id": originalEvent.Payload["correlation_id"],
+		},
+	}
+}
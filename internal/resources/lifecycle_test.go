@@ -0,0 +1,97 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newLifecycleTestGraph(t *testing.T) *graph.GlobalGraph {
+	t.Helper()
+	g := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	g.AddNode(&graph.Node{ID: "checkout", Kind: graph.KindApplication, Metadata: map[string]interface{}{"name": "checkout"}})
+	for _, service := range []string{"checkout-api", "checkout-worker"} {
+		g.AddNode(&graph.Node{ID: service, Kind: graph.KindService, Metadata: map[string]interface{}{"name": service}})
+		require.NoError(t, g.AddEdge("checkout", service, graph.EdgeTypeOwns))
+	}
+	g.AddNode(&graph.Node{ID: "postgres-standard", Kind: graph.KindResource, Metadata: map[string]interface{}{"name": "postgres-standard"}})
+	g.AddNode(&graph.Node{ID: "checkout-db", Kind: graph.KindResource, Metadata: map[string]interface{}{
+		"name":        "checkout-db",
+		"application": "checkout",
+		"catalog_ref": "postgres-standard",
+	}})
+	require.NoError(t, g.AddEdge("checkout", "checkout-db", graph.EdgeTypeOwns))
+	require.NoError(t, g.AddEdge("checkout-api", "checkout-db", graph.EdgeTypeUses))
+	return g
+}
+
+func TestResourceLifecycleTransitions(t *testing.T) {
+	g := newLifecycleTestGraph(t)
+	bus := events.NewEventBus(nil, false)
+	var notified []map[string]interface{}
+	bus.Subscribe(events.EventTypeNotify, func(event events.Event) error {
+		if event.Subject == LifecycleSubject {
+			notified = append(notified, event.Payload)
+		}
+		return nil
+	})
+	service := NewService(g)
+	service.EventBus = bus
+
+	lifecycle, err := service.GetLifecycle("checkout-db")
+	require.NoError(t, err)
+	assert.Equal(t, StateActive, lifecycle.State, "resources without a recorded state are active")
+	assert.Equal(t, []string{"checkout-api"}, lifecycle.DependentServices)
+
+	lifecycle, err = service.TransitionResource("checkout-db", StateMaintenance, "minor version upgrade")
+	require.NoError(t, err)
+	assert.Equal(t, StateMaintenance, lifecycle.State)
+	assert.Equal(t, StateActive, lifecycle.Previous)
+	assert.Equal(t, "minor version upgrade", lifecycle.Reason)
+
+	require.Len(t, notified, 1)
+	assert.Equal(t, "maintenance", notified[0]["state"])
+	assert.Equal(t, []string{"checkout-api"}, notified[0]["dependent_services"])
+	assert.Contains(t, notified[0]["message"], "affects checkout-api")
+
+	// Catalog resources reach the services using their instances
+	lifecycle, err = service.GetLifecycle("postgres-standard")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"checkout-api"}, lifecycle.DependentServices)
+
+	_, err = service.TransitionResource("checkout-db", StateProvisioning, "")
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+	_, err = service.TransitionResource("checkout-db", "paused", "")
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+	_, err = service.TransitionResource("orders-db", StateActive, "")
+	assert.EqualError(t, err, "resource not found")
+
+	_, err = service.TransitionResource("checkout-db", StateDecommissioned, "")
+	require.NoError(t, err)
+	_, err = service.TransitionResource("checkout-db", StateActive, "")
+	assert.ErrorIs(t, err, ErrInvalidTransition, "decommissioned is final")
+}
+
+func TestCheckDeployable(t *testing.T) {
+	g := newLifecycleTestGraph(t)
+	service := NewService(g)
+	service.EventBus = events.NewEventBus(nil, false)
+
+	warnings, err := CheckDeployable(g, "checkout")
+	require.NoError(t, err)
+	assert.Empty(t, warnings)
+
+	_, err = service.TransitionResource("checkout-db", StateDeprecated, "replaced by orders-db")
+	require.NoError(t, err)
+	warnings, err = CheckDeployable(g, "checkout")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"checkout-db is deprecated"}, warnings)
+
+	_, err = service.TransitionResource("checkout-db", StateMaintenance, "")
+	require.NoError(t, err)
+	_, err = CheckDeployable(g, "checkout")
+	assert.EqualError(t, err, "resources unavailable: checkout-db is in maintenance")
+}
@@ -0,0 +1,428 @@
+package resources
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+)
+
+// Operations a topic binding grants
+const (
+	OperationProduce = "produce"
+	OperationConsume = "consume"
+)
+
+// messagingTypes maps the resource types that carry messages to what their sub-resources are called
+var messagingTypes = map[string]string{
+	"kafka":    "topic",
+	"rabbitmq": "queue",
+}
+
+// aclsKey is the spec key the rendered ACLs of a messaging instance are stored under
+const aclsKey = "acls"
+
+var topicNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]{0,248}$`)
+
+// ErrTopicNotFound is returned for a topic or queue the messaging resource does not have
+var ErrTopicNotFound = errors.New("topic not found")
+
+// ErrNotMessagingResource is returned for topic operations on a resource that is not a message broker
+var ErrNotMessagingResource = errors.New("not a messaging resource")
+
+// Topic is a Kafka topic or RabbitMQ queue on a messaging resource instance
+type Topic struct {
+	ID          string    `json:"id"` // resource:name
+	Name        string    `json:"name"`
+	Resource    string    `json:"resource"`
+	Type        string    `json:"type"`                 // topic | queue, from the resource type
+	Partitions  int       `json:"partitions,omitempty"` // kafka only
+	Retention   string    `json:"retention,omitempty"`  // e.g. 7d
+	Description string    `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TopicBinding is a service's permission to produce to or consume from a topic, stored on
+// its produces or consumes edge and rendered into ACLs by the resource type plugin
+type TopicBinding struct {
+	Resource    string    `json:"resource"`
+	Topic       string    `json:"topic"` // the topic name, not its node ID
+	Service     string    `json:"service"`
+	Application string    `json:"application"`
+	Operation   string    `json:"operation"`       // produce | consume
+	Group       string    `json:"group,omitempty"` // consumer group; defaults to application.service
+	RequestedBy string    `json:"requested_by,omitempty"`
+	GrantedAt   time.Time `json:"granted_at"`
+}
+
+// TopicDetails is a topic with the services bound to it
+type TopicDetails struct {
+	Topic
+	Bindings []TopicBinding `json:"bindings"`
+}
+
+// BindingRequest asks for a service to be allowed to produce to or consume from a topic
+type BindingRequest struct {
+	Service     string `json:"service"`
+	Operation   string `json:"operation"`
+	Group       string `json:"group,omitempty"`
+	RequestedBy string `json:"requested_by,omitempty"`
+}
+
+// CreateTopic adds a topic or queue to a messaging resource instance
+func (s *Service) CreateTopic(resourceName string, topic Topic) (*Topic, error) {
+	resource, topicType, err := s.messagingResource(resourceName)
+	if err != nil {
+		return nil, err
+	}
+	if !topicNamePattern.MatchString(topic.Name) {
+		return nil, fmt.Errorf("invalid %s name %q", topicType, topic.Name)
+	}
+	if topic.Partitions < 0 {
+		return nil, errors.New("partitions must not be negative")
+	}
+	topic.ID = topicID(resource.ID, topic.Name)
+	if existing, _ := s.Graph.GetNode(topic.ID); existing != nil {
+		return nil, fmt.Errorf("%s %s already exists on %s", topicType, topic.Name, resource.ID)
+	}
+	topic.Resource, topic.Type = resource.ID, topicType
+	topic.CreatedAt = time.Now().UTC()
+
+	spec, err := toSpecValue(topic)
+	if err != nil {
+		return nil, err
+	}
+	node := &graph.Node{
+		ID:       topic.ID,
+		Kind:     graph.KindTopic,
+		Metadata: map[string]interface{}{"name": topic.Name, "resource": resource.ID, "type": topicType},
+		Spec:     spec.(map[string]interface{}),
+	}
+	if err := s.Graph.AddNode(node); err != nil {
+		return nil, err
+	}
+	if err := s.Graph.AddEdge(resource.ID, topic.ID, graph.EdgeTypeOwns); err != nil {
+		s.Graph.DeleteNode(topic.ID)
+		return nil, fmt.Errorf("failed to link %s to %s: %w", topic.Name, resource.ID, err)
+	}
+	return &topic, nil
+}
+
+// ListTopics returns the topics of a messaging resource instance with their bindings, sorted by name
+func (s *Service) ListTopics(resourceName string) ([]TopicDetails, error) {
+	resource, _, err := s.messagingResource(resourceName)
+	if err != nil {
+		return nil, err
+	}
+	current, err := s.Graph.Graph()
+	if err != nil {
+		return nil, err
+	}
+	bindings := topicBindings(current)
+	topics := []TopicDetails{}
+	for _, edge := range current.Edges[resource.ID] {
+		node, ok := current.Nodes[edge.To]
+		if !ok || edge.Type != graph.EdgeTypeOwns || node.Kind != graph.KindTopic {
+			continue
+		}
+		details := TopicDetails{Bindings: []TopicBinding{}}
+		fromSpecValue(node.Spec, &details.Topic)
+		for _, binding := range bindings {
+			if binding.Resource == resource.ID && binding.Topic == details.Name {
+				details.Bindings = append(details.Bindings, binding)
+			}
+		}
+		topics = append(topics, details)
+	}
+	sort.Slice(topics, func(i, j int) bool { return topics[i].Name < topics[j].Name })
+	return topics, nil
+}
+
+// BindTopic lets a service produce to or consume from a topic. The service must use the
+// messaging resource, either directly or through an access grant to it or its application.
+// The instance's ACLs are rendered again afterwards.
+func (s *Service) BindTopic(resourceName, topicName string, req BindingRequest) (*TopicBinding, error) {
+	edgeType, err := bindingEdgeType(req.Operation)
+	if err != nil {
+		return nil, err
+	}
+	resource, _, err := s.messagingResource(resourceName)
+	if err != nil {
+		return nil, err
+	}
+	id := topicID(resource.ID, topicName)
+	if node, err := s.Graph.GetNode(id); err != nil || node == nil || node.Kind != graph.KindTopic {
+		return nil, fmt.Errorf("%w: %s on %s", ErrTopicNotFound, topicName, resource.ID)
+	}
+	service, err := s.Graph.GetNode(req.Service)
+	if err != nil || service == nil || service.Kind != graph.KindService {
+		return nil, fmt.Errorf("service %q not found", req.Service)
+	}
+	app, err := s.consumerApplication(service)
+	if err != nil {
+		return nil, err
+	}
+	edges, err := s.Graph.Edges()
+	if err != nil {
+		return nil, err
+	}
+	if !connectsTo(edges, service.ID, app, resource.ID) {
+		return nil, fmt.Errorf("service %s does not use %s; link it to the resource or request access first", service.ID, resource.ID)
+	}
+
+	binding := &TopicBinding{
+		Resource:    resource.ID,
+		Topic:       topicName,
+		Service:     service.ID,
+		Application: app,
+		Operation:   req.Operation,
+		Group:       req.Group,
+		RequestedBy: req.RequestedBy,
+		GrantedAt:   time.Now().UTC(),
+	}
+	if binding.Operation == OperationConsume && binding.Group == "" {
+		binding.Group = app + "." + service.ID
+	}
+	metadata, err := toSpecValue(binding)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Graph.AddEdge(service.ID, id, edgeType); err != nil && err.Error() != "edge already exists" {
+		return nil, fmt.Errorf("failed to bind %s to %s: %w", service.ID, topicName, err)
+	}
+	if err := s.updateBindingEdge(service.ID, id, edgeType, func(edges []graph.Edge, i int) []graph.Edge {
+		edges[i].Metadata = metadata.(map[string]interface{})
+		return edges
+	}); err != nil {
+		return nil, err
+	}
+	if _, err := s.RenderACLs(resource.ID); err != nil {
+		return nil, err
+	}
+	return binding, nil
+}
+
+// UnbindTopic removes a service's produce or consume binding and renders the ACLs again
+func (s *Service) UnbindTopic(resourceName, topicName, serviceName, operation string) error {
+	edgeType, err := bindingEdgeType(operation)
+	if err != nil {
+		return err
+	}
+	resource, _, err := s.messagingResource(resourceName)
+	if err != nil {
+		return err
+	}
+	if err := s.updateBindingEdge(serviceName, topicID(resource.ID, topicName), edgeType, func(edges []graph.Edge, i int) []graph.Edge {
+		return append(edges[:i], edges[i+1:]...)
+	}); err != nil {
+		return err
+	}
+	_, err = s.RenderACLs(resource.ID)
+	return err
+}
+
+// RenderACLs renders the ACLs of a messaging resource instance with its type's plugin and
+// stores them on the instance for the provisioner to apply
+func (s *Service) RenderACLs(resourceName string) ([]string, error) {
+	resource, _, err := s.messagingResource(resourceName)
+	if err != nil {
+		return nil, err
+	}
+	// Render from and store on the same graph under the write lock, so bindings changed
+	// meanwhile are not overwritten with ACLs rendered from stale ones
+	acls := []string{}
+	err = s.Graph.Update(func(current *graph.Graph) error {
+		var bindings []TopicBinding
+		for _, binding := range topicBindings(current) {
+			if binding.Resource == resource.ID {
+				bindings = append(bindings, binding)
+			}
+		}
+
+		resourceType, _ := resource.Spec["type"].(string)
+		if plugin, ok := GetPlugin(resourceType); ok {
+			if renderer, ok := plugin.(ACLRenderer); ok {
+				app, _ := resource.Metadata["application"].(string)
+				rendered, err := renderer.RenderACLs(ResourceInstance{Name: resource.ID, Application: app, Type: resourceType, Spec: resource.Spec}, bindings)
+				if err != nil {
+					return err
+				}
+				acls = append(acls, rendered...)
+			}
+		}
+
+		node, ok := current.Nodes[resource.ID]
+		if !ok {
+			return fmt.Errorf("resource %s not found", resource.ID)
+		}
+		spec := make(map[string]interface{}, len(node.Spec)+1)
+		for k, v := range node.Spec {
+			spec[k] = v
+		}
+		spec[aclsKey] = acls
+		node.Spec = spec
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store ACLs of %s: %w", resource.ID, err)
+	}
+	return acls, nil
+}
+
+// CheckTopicDeclarations fails when one of the application's services uses a messaging
+// resource without declaring a topic it produces to or consumes from, or is bound to a topic
+// on a resource it does not use
+func CheckTopicDeclarations(globalGraph *graph.GlobalGraph, appName string) error {
+	current, err := globalGraph.Graph()
+	if err != nil {
+		return err
+	}
+	declared := map[string]map[string]bool{} // service -> resources it has bindings on
+	for _, binding := range topicBindings(current) {
+		if declared[binding.Service] == nil {
+			declared[binding.Service] = map[string]bool{}
+		}
+		declared[binding.Service][binding.Resource] = true
+	}
+
+	var problems []string
+	for _, owned := range current.Edges[appName] {
+		service, ok := current.Nodes[owned.To]
+		if !ok || owned.Type != graph.EdgeTypeOwns || service.Kind != graph.KindService {
+			continue
+		}
+		for _, edge := range current.Edges[service.ID] {
+			resource, ok := current.Nodes[edge.To]
+			if !ok || resource.Kind != graph.KindResource || (edge.Type != graph.EdgeTypeUses && edge.Type != graph.EdgeTypeAccesses) {
+				continue
+			}
+			resourceType, _ := resource.Spec["type"].(string)
+			if topicType, messaging := messagingTypes[resourceType]; messaging && !declared[service.ID][resource.ID] {
+				problems = append(problems, fmt.Sprintf("%s uses %s without declaring the %ss it produces to or consumes from", service.ID, resource.ID, topicType))
+			}
+		}
+		resources := make([]string, 0, len(declared[service.ID]))
+		for resource := range declared[service.ID] {
+			resources = append(resources, resource)
+		}
+		sort.Strings(resources)
+		for _, resource := range resources {
+			if !connectsTo(current.Edges, service.ID, appName, resource) {
+				problems = append(problems, fmt.Sprintf("%s is bound to topics on %s but does not use it", service.ID, resource))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("messaging declarations: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// messagingResource returns a resource instance of a messaging type and what its topics are called
+func (s *Service) messagingResource(name string) (*graph.Node, string, error) {
+	node, err := s.Graph.GetNode(name)
+	if err != nil || node == nil || node.Kind != graph.KindResource {
+		return nil, "", fmt.Errorf("resource %s not found", name)
+	}
+	resourceType, _ := node.Spec["type"].(string)
+	topicType, ok := messagingTypes[resourceType]
+	if !ok {
+		return nil, "", fmt.Errorf("%w: %s is a %s resource", ErrNotMessagingResource, name, resourceType)
+	}
+	return node, topicType, nil
+}
+
+// updateBindingEdge applies change to the service's binding edge to the topic and saves
+func (s *Service) updateBindingEdge(serviceName, topic, edgeType string, change func(edges []graph.Edge, i int) []graph.Edge) error {
+	found := false
+	err := s.Graph.Update(func(current *graph.Graph) error {
+		for i, edge := range current.Edges[serviceName] {
+			if edge.To == topic && edge.Type == edgeType {
+				current.Edges[serviceName] = change(current.Edges[serviceName], i)
+				found = true
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: %s has no %s binding on %s", ErrTopicNotFound, serviceName, edgeType, topic)
+	})
+	if err != nil && found {
+		return fmt.Errorf("failed to save topic binding: %w", err)
+	}
+	return err
+}
+
+// topicBindings reads every produce and consume binding in the graph, sorted by resource,
+// topic, service and operation
+func topicBindings(g *graph.Graph) []TopicBinding {
+	var bindings []TopicBinding
+	for from, edges := range g.Edges {
+		for _, edge := range edges {
+			if edge.Type != graph.EdgeTypeProduces && edge.Type != graph.EdgeTypeConsumes {
+				continue
+			}
+			topic, ok := g.Nodes[edge.To]
+			if !ok || topic.Kind != graph.KindTopic {
+				continue
+			}
+			var binding TopicBinding
+			fromSpecValue(edge.Metadata, &binding)
+			binding.Service = from
+			binding.Resource, _ = topic.Metadata["resource"].(string)
+			binding.Topic, _ = topic.Metadata["name"].(string)
+			binding.Operation = OperationProduce
+			if edge.Type == graph.EdgeTypeConsumes {
+				binding.Operation = OperationConsume
+			}
+			bindings = append(bindings, binding)
+		}
+	}
+	sort.Slice(bindings, func(i, j int) bool {
+		a, b := bindings[i], bindings[j]
+		if a.Resource != b.Resource {
+			return a.Resource < b.Resource
+		}
+		if a.Topic != b.Topic {
+			return a.Topic < b.Topic
+		}
+		if a.Service != b.Service {
+			return a.Service < b.Service
+		}
+		return a.Operation < b.Operation
+	})
+	return bindings
+}
+
+// connectsTo reports whether a service reaches a resource: it uses it, or it or its
+// application has an access grant on it
+func connectsTo(edges map[string][]graph.Edge, service, app, resource string) bool {
+	for _, from := range []string{service, app} {
+		for _, edge := range edges[from] {
+			if edge.To != resource {
+				continue
+			}
+			if edge.Type == graph.EdgeTypeAccesses || (from == service && edge.Type == graph.EdgeTypeUses) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func bindingEdgeType(operation string) (string, error) {
+	switch operation {
+	case OperationProduce:
+		return graph.EdgeTypeProduces, nil
+	case OperationConsume:
+		return graph.EdgeTypeConsumes, nil
+	}
+	return "", fmt.Errorf("unknown operation %q (use produce or consume)", operation)
+}
+
+func topicID(resource, name string) string {
+	return resource + ":" + name
+}
@@ -0,0 +1,107 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMessagingTestService(t *testing.T) (*Service, *graph.GlobalGraph) {
+	gg := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	svc := NewService(gg)
+	require.NoError(t, gg.AddNode(&graph.Node{ID: "checkout", Kind: "application", Metadata: map[string]interface{}{"name": "checkout", "owner": "payments"}, Spec: map[string]interface{}{}}))
+	for _, service := range []string{"checkout-api", "checkout-worker"} {
+		require.NoError(t, gg.AddNode(&graph.Node{ID: service, Kind: "service", Metadata: map[string]interface{}{"name": service}, Spec: map[string]interface{}{}}))
+		require.NoError(t, gg.AddEdge("checkout", service, graph.EdgeTypeOwns))
+	}
+
+	for name, resourceType := range map[string]string{"events": "kafka", "cache": "redis"} {
+		_, err := svc.CreateResource(ResourceRequest{Kind: "resource_type", Metadata: map[string]interface{}{"name": resourceType, "owner": "platform-team"}})
+		require.NoError(t, err)
+		_, err = svc.CreateResource(ResourceRequest{Kind: "resource", Metadata: map[string]interface{}{"name": name, "owner": "platform-team"}, Spec: map[string]interface{}{"type": resourceType}})
+		require.NoError(t, err)
+		_, err = svc.AddResourceToApplication("checkout", name, "checkout-"+name, "")
+		require.NoError(t, err)
+	}
+	_, err := svc.LinkServiceToResource("checkout", "checkout-api", "events")
+	require.NoError(t, err)
+	return svc, gg
+}
+
+func TestTopics_BindServicesAndRenderACLs(t *testing.T) {
+	svc, gg := newMessagingTestService(t)
+
+	topic, err := svc.CreateTopic("checkout-events", Topic{Name: "orders", Partitions: 6})
+	require.NoError(t, err)
+	assert.Equal(t, "checkout-events:orders", topic.ID)
+	assert.Equal(t, "topic", topic.Type)
+	_, err = svc.CreateTopic("checkout-events", Topic{Name: "orders"})
+	assert.Error(t, err)
+	_, err = svc.CreateTopic("checkout-cache", Topic{Name: "orders"})
+	assert.ErrorIs(t, err, ErrNotMessagingResource)
+
+	_, err = svc.BindTopic("checkout-events", "orders", BindingRequest{Service: "checkout-api", Operation: OperationProduce})
+	require.NoError(t, err)
+	binding, err := svc.BindTopic("checkout-events", "orders", BindingRequest{Service: "checkout-api", Operation: OperationConsume})
+	require.NoError(t, err)
+	assert.Equal(t, "checkout.checkout-api", binding.Group)
+	_, err = svc.BindTopic("checkout-events", "orders", BindingRequest{Service: "checkout-worker", Operation: OperationConsume})
+	assert.ErrorContains(t, err, "does not use checkout-events")
+	_, err = svc.BindTopic("checkout-events", "payments", BindingRequest{Service: "checkout-api", Operation: OperationProduce})
+	assert.ErrorIs(t, err, ErrTopicNotFound)
+	_, err = svc.BindTopic("checkout-events", "orders", BindingRequest{Service: "checkout-api", Operation: "admin"})
+	assert.Error(t, err)
+
+	has, err := gg.HasEdge("checkout-api", "checkout-events:orders", graph.EdgeTypeProduces)
+	require.NoError(t, err)
+	assert.True(t, has)
+
+	topics, err := svc.ListTopics("checkout-events")
+	require.NoError(t, err)
+	require.Len(t, topics, 1)
+	assert.Len(t, topics[0].Bindings, 2)
+
+	instance, err := gg.GetNode("checkout-events")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"kafka-acls --add --allow-principal User:checkout.checkout-api --operation Read --topic orders --group checkout.checkout-api",
+		"kafka-acls --add --allow-principal User:checkout.checkout-api --operation Write --topic orders",
+	}, instance.Spec[aclsKey], "bindings are rendered on the instance as they change")
+
+	require.NoError(t, svc.UnbindTopic("checkout-events", "orders", "checkout-api", OperationConsume))
+	acls, err := svc.RenderACLs("checkout-events")
+	require.NoError(t, err)
+	assert.Len(t, acls, 1)
+	assert.ErrorIs(t, svc.UnbindTopic("checkout-events", "orders", "checkout-api", OperationConsume), ErrTopicNotFound)
+}
+
+func TestCheckTopicDeclarations(t *testing.T) {
+	svc, gg := newMessagingTestService(t)
+	_, err := svc.CreateTopic("checkout-events", Topic{Name: "orders"})
+	require.NoError(t, err)
+
+	err = CheckTopicDeclarations(gg, "checkout")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "checkout-api uses checkout-events without declaring the topics it produces to or consumes from")
+
+	_, err = svc.BindTopic("checkout-events", "orders", BindingRequest{Service: "checkout-api", Operation: OperationProduce})
+	require.NoError(t, err)
+	assert.NoError(t, CheckTopicDeclarations(gg, "checkout"))
+
+	// A binding left behind after the service stopped using the broker is reported too
+	current, err := gg.Graph()
+	require.NoError(t, err)
+	edges := current.Edges["checkout-api"][:0]
+	for _, edge := range current.Edges["checkout-api"] {
+		if edge.Type != graph.EdgeTypeUses {
+			edges = append(edges, edge)
+		}
+	}
+	current.Edges["checkout-api"] = edges
+	require.NoError(t, gg.Backend.SaveGlobal(current))
+	err = CheckTopicDeclarations(gg, "checkout")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "checkout-api is bound to topics on checkout-events but does not use it")
+}
@@ -0,0 +1,208 @@
+package resources
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// DefaultNameTemplate names instances after their application and catalog resource, e.g. checkout-pg-db
+const DefaultNameTemplate = "{{.App}}-{{.Resource}}"
+
+// nameHashLength is how many hex characters of the full name's hash keep truncated names unique
+const nameHashLength = 6
+
+// ErrInstanceNameConflict is returned when an instance name is taken by a resource that
+// belongs to another application or catalog resource
+var ErrInstanceNameConflict = errors.New("resource instance name is already used by another resource")
+
+// NamingRule controls the names of resource instances
+type NamingRule struct {
+	Template  string // Go template over .App, .Resource, .Type, .Team and .Env
+	MaxLength int    // 0 allows any length
+	Charset   string // regular expression character class, e.g. "a-z0-9-"; empty allows any character
+}
+
+// NamingStrategy is the default naming rule with overrides per resource type (provider).
+// Fields left empty in an override fall back to the default rule.
+type NamingStrategy struct {
+	Default   NamingRule
+	Providers map[string]NamingRule
+}
+
+// NameVariables are the values available to naming templates
+type NameVariables struct {
+	App      string // application name
+	Resource string // catalog resource name
+	Type     string // resource type, e.g. postgres
+	Team     string // owner of the application
+	Env      string // environment the instance is for; empty for application-wide instances
+}
+
+type namingRule struct {
+	template  *template.Template
+	maxLength int
+	allowed   *regexp.Regexp // matches one allowed character; nil allows any
+	lower     bool           // the charset has no upper case letters, so names are lower-cased first
+}
+
+type namingStrategy struct {
+	fallback  *namingRule
+	providers map[string]*namingRule
+}
+
+var (
+	naming   = mustCompileNaming(NamingStrategy{})
+	namingMu sync.RWMutex
+)
+
+// SetNamingStrategy replaces the naming strategy for new resource instances
+func SetNamingStrategy(strategy NamingStrategy) error {
+	compiled, err := compileNaming(strategy)
+	if err != nil {
+		return err
+	}
+	namingMu.Lock()
+	defer namingMu.Unlock()
+	naming = compiled
+	return nil
+}
+
+// InstanceName renders the instance name for vars under the current naming strategy
+func InstanceName(vars NameVariables) (string, error) {
+	return namingRuleFor(vars.Type).generate(vars)
+}
+
+// ValidateInstanceName checks a name chosen by the caller against the rule for the resource type
+func ValidateInstanceName(resourceType, name string) error {
+	return namingRuleFor(resourceType).validate(name)
+}
+
+func namingRuleFor(resourceType string) *namingRule {
+	namingMu.RLock()
+	defer namingMu.RUnlock()
+	if rule, ok := naming.providers[resourceType]; ok {
+		return rule
+	}
+	return naming.fallback
+}
+
+func mustCompileNaming(strategy NamingStrategy) *namingStrategy {
+	compiled, err := compileNaming(strategy)
+	if err != nil {
+		panic(err)
+	}
+	return compiled
+}
+
+func compileNaming(strategy NamingStrategy) (*namingStrategy, error) {
+	fallback, err := compileNamingRule(strategy.Default)
+	if err != nil {
+		return nil, err
+	}
+	compiled := &namingStrategy{fallback: fallback, providers: map[string]*namingRule{}}
+	for provider, rule := range strategy.Providers {
+		if rule.Template == "" {
+			rule.Template = strategy.Default.Template
+		}
+		if rule.MaxLength == 0 {
+			rule.MaxLength = strategy.Default.MaxLength
+		}
+		if rule.Charset == "" {
+			rule.Charset = strategy.Default.Charset
+		}
+		if compiled.providers[provider], err = compileNamingRule(rule); err != nil {
+			return nil, fmt.Errorf("naming rule for %s: %w", provider, err)
+		}
+	}
+	return compiled, nil
+}
+
+func compileNamingRule(rule NamingRule) (*namingRule, error) {
+	if rule.Template == "" {
+		rule.Template = DefaultNameTemplate
+	}
+	if rule.MaxLength < 0 {
+		return nil, errors.New("max length must not be negative")
+	}
+	tmpl, err := template.New("instance-name").Option("missingkey=error").Parse(rule.Template)
+	if err != nil {
+		return nil, fmt.Errorf("invalid name template: %w", err)
+	}
+	compiled := &namingRule{template: tmpl, maxLength: rule.MaxLength}
+	if rule.Charset != "" {
+		if compiled.allowed, err = regexp.Compile("^[" + rule.Charset + "]$"); err != nil {
+			return nil, fmt.Errorf("invalid charset: %w", err)
+		}
+		compiled.lower = !compiled.allowed.MatchString("A")
+	}
+	return compiled, nil
+}
+
+// generate renders the template and makes the result fit the rule: disallowed characters
+// become dashes, and names over the limit are truncated with a hash of the full name so
+// that truncated names stay distinct
+func (r *namingRule) generate(vars NameVariables) (string, error) {
+	var buf bytes.Buffer
+	if err := r.template.Execute(&buf, vars); err != nil {
+		return "", fmt.Errorf("failed to render instance name: %w", err)
+	}
+	name := buf.String()
+	if r.lower {
+		name = strings.ToLower(name)
+	}
+
+	if r.allowed != nil {
+		dash := r.allowed.MatchString("-")
+		var b strings.Builder
+		for _, c := range name {
+			switch {
+			case r.allowed.MatchString(string(c)):
+				b.WriteRune(c)
+			case dash:
+				b.WriteByte('-')
+			}
+		}
+		name = b.String()
+	}
+	// Empty variables and replaced characters leave runs of dashes behind
+	for strings.Contains(name, "--") {
+		name = strings.ReplaceAll(name, "--", "-")
+	}
+	name = strings.Trim(name, "-")
+
+	if r.maxLength > 0 && len(name) > r.maxLength {
+		sum := sha256.Sum256([]byte(name))
+		suffix := hex.EncodeToString(sum[:])[:nameHashLength]
+		if r.maxLength <= nameHashLength+1 {
+			name = name[:r.maxLength]
+		} else {
+			name = strings.TrimRight(name[:r.maxLength-nameHashLength-1], "-") + "-" + suffix
+		}
+	}
+
+	if name == "" {
+		return "", errors.New("instance name template rendered an empty name")
+	}
+	return name, nil
+}
+
+func (r *namingRule) validate(name string) error {
+	if r.maxLength > 0 && len(name) > r.maxLength {
+		return fmt.Errorf("instance name %q is longer than %d characters", name, r.maxLength)
+	}
+	if r.allowed != nil {
+		for _, c := range name {
+			if !r.allowed.MatchString(string(c)) {
+				return fmt.Errorf("instance name %q contains %q, which is not allowed", name, c)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package resources
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setNaming(t *testing.T, strategy NamingStrategy) {
+	t.Helper()
+	require.NoError(t, SetNamingStrategy(strategy))
+	t.Cleanup(func() { SetNamingStrategy(NamingStrategy{}) })
+}
+
+func TestInstanceName_DefaultTemplate(t *testing.T) {
+	name, err := InstanceName(NameVariables{App: "checkout", Resource: "pg-db", Type: "postgres"})
+	require.NoError(t, err)
+	assert.Equal(t, "checkout-pg-db", name)
+}
+
+func TestInstanceName_ProviderRules(t *testing.T) {
+	setNaming(t, NamingStrategy{
+		Default: NamingRule{Template: "{{.Env}}-{{.App}}-{{.Resource}}"},
+		Providers: map[string]NamingRule{
+			"s3": {Template: "{{.Team}}_{{.App}}_{{.Resource}}", MaxLength: 20, Charset: "a-z0-9-"},
+		},
+	})
+
+	name, err := InstanceName(NameVariables{App: "checkout", Resource: "pg-db", Type: "postgres"})
+	require.NoError(t, err)
+	assert.Equal(t, "checkout-pg-db", name, "empty variables leave no stray dashes")
+
+	name, err = InstanceName(NameVariables{App: "Checkout", Resource: "assets", Type: "s3", Team: "Payments"})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(name, "payments-chec-"), name)
+	assert.Len(t, name, 20)
+	assert.Regexp(t, `^[a-z0-9-]+$`, name)
+
+	other, err := InstanceName(NameVariables{App: "Checkout", Resource: "assets-archive", Type: "s3", Team: "Payments"})
+	require.NoError(t, err)
+	assert.NotEqual(t, name, other, "truncated names keep a hash of the full name")
+
+	assert.NoError(t, ValidateInstanceName("postgres", "Any_Name"))
+	assert.ErrorContains(t, ValidateInstanceName("s3", "Assets"), "not allowed")
+	assert.ErrorContains(t, ValidateInstanceName("s3", strings.Repeat("a", 21)), "longer than 20")
+}
+
+func TestSetNamingStrategy_RejectsInvalidRules(t *testing.T) {
+	assert.Error(t, SetNamingStrategy(NamingStrategy{Default: NamingRule{Template: "{{.App"}}))
+	assert.Error(t, SetNamingStrategy(NamingStrategy{Providers: map[string]NamingRule{"s3": {Charset: "z-a"}}}))
+
+	name, err := InstanceName(NameVariables{App: "checkout", Resource: "pg-db"})
+	require.NoError(t, err)
+	assert.Equal(t, "checkout-pg-db", name, "a rejected strategy leaves the current one in place")
+}
+
+func TestAddResourceToApplication_DetectsNameCollisions(t *testing.T) {
+	setNaming(t, NamingStrategy{Default: NamingRule{Template: "{{.Resource}}-{{.Env}}"}})
+
+	gg := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	svc := NewService(gg)
+	for _, app := range []string{"checkout", "billing"} {
+		gg.AddNode(&graph.Node{ID: app, Kind: "application", Metadata: map[string]interface{}{"name": app}, Spec: map[string]interface{}{}})
+	}
+	_, err := svc.CreateResource(ResourceRequest{
+		Kind:     "resource_type",
+		Metadata: map[string]interface{}{"name": "redis", "owner": "platform-team"},
+	})
+	require.NoError(t, err)
+	_, err = svc.CreateResource(ResourceRequest{
+		Kind:     "resource",
+		Metadata: map[string]interface{}{"name": "cache", "owner": "platform-team"},
+		Spec:     map[string]interface{}{"type": "redis"},
+	})
+	require.NoError(t, err)
+
+	resp, err := svc.AddResourceToApplication("checkout", "cache", "", "dev")
+	require.NoError(t, err)
+	assert.Equal(t, "cache-dev", resp.InstanceName)
+
+	resp, err = svc.AddResourceToApplication("checkout", "cache", "", "dev")
+	require.NoError(t, err)
+	assert.Equal(t, "exists", resp.Status)
+
+	_, err = svc.AddResourceToApplication("billing", "cache", "", "dev")
+	assert.ErrorIs(t, err, ErrInstanceNameConflict)
+}
@@ -0,0 +1,148 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"plugin"
+	"sort"
+	"sync"
+)
+
+// ResourceTypePlugin teaches the platform about one kind of infrastructure (postgres, kafka, s3, ...).
+// Plugins are registered in-process with RegisterPlugin, loaded from Go plugins with LoadPlugins,
+// or defined declaratively at runtime through the resource plugin API.
+type ResourceTypePlugin interface {
+	// Type is the resource type name the plugin handles, e.g. "postgres"
+	Type() string
+
+	// DefaultConfig returns spec values applied to new resource types when omitted
+	DefaultConfig() map[string]interface{}
+
+	// Validate checks a resource type or catalog resource spec
+	Validate(spec map[string]interface{}) error
+
+	// Provision is called before an application's resource instance is added to the graph.
+	// The returned outputs are stored on the instance; an error aborts the instance creation.
+	Provision(ctx context.Context, instance ResourceInstance) (map[string]interface{}, error)
+
+	// Deprovision is called when an instance is removed
+	Deprovision(ctx context.Context, instance ResourceInstance) error
+
+	// ConnectionString renders how services reach the instance
+	ConnectionString(instance ResourceInstance) (string, error)
+}
+
+// ACLRenderer is implemented by plugins of messaging resource types that can render the access
+// control entries granting services their produce and consume bindings on an instance's topics
+type ACLRenderer interface {
+	RenderACLs(instance ResourceInstance, bindings []TopicBinding) ([]string, error)
+}
+
+// ResourceInstance describes an application's resource instance passed to plugin hooks
+type ResourceInstance struct {
+	Name        string                 `json:"name"`
+	Application string                 `json:"application"`
+	Type        string                 `json:"type"`
+	Spec        map[string]interface{} `json:"spec"`
+}
+
+// PluginSymbol is the exported symbol a Go plugin (.so) must provide: a ResourceTypePlugin
+// value, or a func() []ResourceTypePlugin for plugins that register several types
+const PluginSymbol = "ResourceTypePlugin"
+
+var (
+	plugins   = make(map[string]ResourceTypePlugin)
+	pluginsMu sync.RWMutex
+)
+
+// RegisterPlugin registers a plugin for its resource type, replacing any previous one
+func RegisterPlugin(p ResourceTypePlugin) {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	plugins[p.Type()] = p
+}
+
+// GetPlugin returns the plugin registered for a resource type
+func GetPlugin(resourceType string) (ResourceTypePlugin, bool) {
+	pluginsMu.RLock()
+	defer pluginsMu.RUnlock()
+	p, ok := plugins[resourceType]
+	return p, ok
+}
+
+// Plugins returns all registered plugins sorted by type
+func Plugins() []ResourceTypePlugin {
+	pluginsMu.RLock()
+	defer pluginsMu.RUnlock()
+
+	list := make([]ResourceTypePlugin, 0, len(plugins))
+	for _, p := range plugins {
+		list = append(list, p)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Type() < list[j].Type() })
+	return list
+}
+
+// LoadPlugins opens every .so file in dir and registers the plugins it exports.
+// It returns the registered resource types.
+func LoadPlugins(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.so"))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("plugin dir %s: %w", dir, err)
+	}
+	sort.Strings(files)
+
+	var loaded []string
+	for _, file := range files {
+		found, err := loadPluginFile(file)
+		if err != nil {
+			return loaded, err
+		}
+		for _, p := range found {
+			RegisterPlugin(p)
+			loaded = append(loaded, p.Type())
+		}
+	}
+	return loaded, nil
+}
+
+// loadPluginFile resolves the plugin symbol in a single shared object
+func loadPluginFile(path string) ([]ResourceTypePlugin, error) {
+	so, err := plugin.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open plugin %s: %w", path, err)
+	}
+	sym, err := so.Lookup(PluginSymbol)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", path, err)
+	}
+
+	// Lookup returns a pointer for exported variables
+	switch v := sym.(type) {
+	case ResourceTypePlugin:
+		return []ResourceTypePlugin{v}, nil
+	case *ResourceTypePlugin:
+		return []ResourceTypePlugin{*v}, nil
+	case func() []ResourceTypePlugin:
+		return v(), nil
+	default:
+		return nil, fmt.Errorf("plugin %s: %s has unsupported type %T", path, PluginSymbol, sym)
+	}
+}
+
+// applyDefaults returns spec with the plugin defaults filled in for missing keys
+func applyDefaults(p ResourceTypePlugin, spec map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(spec))
+	for k, v := range p.DefaultConfig() {
+		merged[k] = v
+	}
+	for k, v := range spec {
+		merged[k] = v
+	}
+	return merged
+}
@@ -0,0 +1,124 @@
+package resources
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingPlugin refuses to provision, as a Go plugin would when the cloud API errors
+type failingPlugin struct{ *TemplatePlugin }
+
+func (failingPlugin) Provision(ctx context.Context, instance ResourceInstance) (map[string]interface{}, error) {
+	return nil, errors.New("quota exceeded")
+}
+
+func TestBuiltinPluginsRegistered(t *testing.T) {
+	for _, resourceType := range []string{"postgres", "redis", "kafka", "rabbitmq", "s3"} {
+		_, ok := GetPlugin(resourceType)
+		assert.True(t, ok, "expected built-in plugin for %s", resourceType)
+	}
+}
+
+func TestTemplatePlugin_ValidateAndConnectionString(t *testing.T) {
+	p, err := NewTemplatePlugin(PluginDefinition{
+		Type:               "mongo",
+		Defaults:           map[string]interface{}{"tier_options": []interface{}{"small", "large"}},
+		RequiredFields:     []string{"version"},
+		ConnectionTemplate: "mongodb://{{.Name}}.{{.Application}}:27017",
+	})
+	require.NoError(t, err)
+
+	assert.ErrorContains(t, p.Validate(map[string]interface{}{}), "version")
+	assert.ErrorContains(t, p.Validate(map[string]interface{}{"version": "7", "tier": "huge"}), "tier")
+	assert.NoError(t, p.Validate(map[string]interface{}{"version": "7", "tier": "large"}))
+
+	conn, err := p.ConnectionString(ResourceInstance{Name: "orders-db", Application: "checkout"})
+	require.NoError(t, err)
+	assert.Equal(t, "mongodb://orders-db.checkout:27017", conn)
+
+	_, err = NewTemplatePlugin(PluginDefinition{Type: "Bad Type"})
+	assert.Error(t, err)
+	_, err = NewTemplatePlugin(PluginDefinition{Type: "broken", ConnectionTemplate: "{{.Name"})
+	assert.Error(t, err)
+}
+
+func TestCreateResource_AppliesPluginDefaults(t *testing.T) {
+	svc := NewService(graph.NewGlobalGraph(graph.NewMemoryGraph()))
+
+	resp, err := svc.CreateResource(ResourceRequest{
+		Kind:     "resource_type",
+		Metadata: map[string]interface{}{"name": "postgres", "owner": "platform-team"},
+		Spec:     map[string]interface{}{"default_capacity": "50GB"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "15", resp.Spec["version"])
+	assert.Equal(t, "50GB", resp.Spec["default_capacity"])
+
+	_, err = svc.CreateResource(ResourceRequest{
+		Kind:     "resource",
+		Metadata: map[string]interface{}{"name": "pg-db", "owner": "platform-team"},
+		Spec:     map[string]interface{}{"type": "postgres", "version": "15", "tier": "platinum"},
+	})
+	assert.ErrorContains(t, err, "tier")
+}
+
+func TestAddResourceToApplication_ProvisionsThroughPlugin(t *testing.T) {
+	gg := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	svc := NewService(gg)
+	gg.AddNode(&graph.Node{ID: "checkout", Kind: "application", Metadata: map[string]interface{}{"name": "checkout"}, Spec: map[string]interface{}{}})
+
+	_, err := svc.CreateResource(ResourceRequest{
+		Kind:     "resource_type",
+		Metadata: map[string]interface{}{"name": "postgres", "owner": "platform-team"},
+	})
+	require.NoError(t, err)
+	_, err = svc.CreateResource(ResourceRequest{
+		Kind:     "resource",
+		Metadata: map[string]interface{}{"name": "pg-db", "owner": "platform-team"},
+		Spec:     map[string]interface{}{"type": "postgres", "version": "15", "tier": "standard"},
+	})
+	require.NoError(t, err)
+
+	resp, err := svc.AddResourceToApplication("checkout", "pg-db", "", "")
+	require.NoError(t, err)
+
+	instance, _ := gg.GetNode(resp.InstanceName)
+	require.NotNil(t, instance)
+	assert.Equal(t, "postgres://checkout-pg-db.checkout.svc.cluster.local:5432/checkout-pg-db", instance.Spec["connection_string"])
+
+	catalog, _ := gg.GetNode("pg-db")
+	assert.NotContains(t, catalog.Spec, "connection_string", "catalog spec must not be modified")
+}
+
+func TestAddResourceToApplication_ProvisionFailureCreatesNothing(t *testing.T) {
+	base, err := NewTemplatePlugin(PluginDefinition{Type: "flaky-queue"})
+	require.NoError(t, err)
+	RegisterPlugin(failingPlugin{base})
+
+	gg := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	svc := NewService(gg)
+	gg.AddNode(&graph.Node{ID: "checkout", Kind: "application", Metadata: map[string]interface{}{"name": "checkout"}, Spec: map[string]interface{}{}})
+	_, err = svc.CreateResource(ResourceRequest{
+		Kind:     "resource_type",
+		Metadata: map[string]interface{}{"name": "flaky-queue", "owner": "platform-team"},
+		Spec:     map[string]interface{}{"version": "1"},
+	})
+	require.NoError(t, err)
+	_, err = svc.CreateResource(ResourceRequest{
+		Kind:     "resource",
+		Metadata: map[string]interface{}{"name": "jobs", "owner": "platform-team"},
+		Spec:     map[string]interface{}{"type": "flaky-queue"},
+	})
+	require.NoError(t, err)
+
+	_, err = svc.AddResourceToApplication("checkout", "jobs", "", "")
+	assert.ErrorContains(t, err, "quota exceeded")
+
+	instance, _ := gg.GetNode("checkout-jobs")
+	assert.Nil(t, instance)
+}
@@ -0,0 +1,104 @@
+package resources
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/krzachariassen/ZTDP/internal/contracts"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+)
+
+// Resource spec fields pinning a resource to a region and cluster
+const (
+	regionKey  = "region"
+	clusterKey = "cluster"
+)
+
+// RegionOf returns the region and cluster a resource is pinned to; both are empty for
+// resources reachable from every region
+func RegionOf(node *graph.Node) (region, cluster string) {
+	region, _ = node.Spec[regionKey].(string)
+	cluster, _ = node.Spec[clusterKey].(string)
+	return region, cluster
+}
+
+// EnvironmentRegions returns the regions an environment spans, in declaration order
+func EnvironmentRegions(g *graph.GlobalGraph, environment string) ([]contracts.EnvironmentRegion, error) {
+	node, err := g.GetNode(environment)
+	if err != nil || node == nil || node.Kind != graph.KindEnvironment {
+		return nil, fmt.Errorf("environment %s not found", environment)
+	}
+	data, err := json.Marshal(node.Spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid environment %s: %w", environment, err)
+	}
+	var spec contracts.EnvironmentSpec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("invalid environment %s: %w", environment, err)
+	}
+	return spec.Regions, nil
+}
+
+// CheckRegions verifies an application can be deployed to the regions of an environment: every
+// resource it owns, is granted access to or its services use that is pinned to a region must be
+// in one of the target regions, and on one of that region's clusters when pinned to a cluster.
+// No target regions means every region the environment spans. Environments without regions
+// accept any resource; checking that the environment exists is left to the caller.
+func CheckRegions(g *graph.GlobalGraph, appName, environment string, regions []string) error {
+	if node, _ := g.GetNode(environment); node == nil || node.Kind != graph.KindEnvironment {
+		return nil
+	}
+	spans, err := EnvironmentRegions(g, environment)
+	if err != nil || len(spans) == 0 {
+		return err
+	}
+	targets := map[string]contracts.EnvironmentRegion{}
+	for _, region := range spans {
+		targets[region.Name] = region
+	}
+	if len(regions) > 0 {
+		requested := map[string]contracts.EnvironmentRegion{}
+		for _, name := range regions {
+			region, ok := targets[name]
+			if !ok {
+				return fmt.Errorf("environment %s does not span region %s", environment, name)
+			}
+			requested[name] = region
+		}
+		targets = requested
+	}
+
+	nodes, err := g.Nodes()
+	if err != nil {
+		return err
+	}
+	edges, err := g.Edges()
+	if err != nil {
+		return err
+	}
+	var problems []string
+	for _, name := range applicationResources(nodes, edges, appName) {
+		node, ok := nodes[name]
+		if !ok || node.Kind != graph.KindResource {
+			continue
+		}
+		regionName, cluster := RegionOf(node)
+		if regionName == "" {
+			continue
+		}
+		region, ok := targets[regionName]
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("%s is in %s, which the deployment does not target", name, regionName))
+		case cluster != "" && !region.HasCluster(cluster):
+			problems = append(problems, fmt.Sprintf("%s is on cluster %s, which %s does not have in %s", name, cluster, environment, regionName))
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("incompatible regions: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
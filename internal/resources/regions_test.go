@@ -0,0 +1,33 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckRegions(t *testing.T) {
+	_, gg := newMessagingTestService(t)
+	require.NoError(t, gg.AddNode(&graph.Node{ID: "prod", Kind: graph.KindEnvironment, Metadata: map[string]interface{}{"name": "prod"}, Spec: map[string]interface{}{
+		"regions": []interface{}{
+			map[string]interface{}{"name": "eu-west", "clusters": []interface{}{"eu-west-a"}},
+			map[string]interface{}{"name": "us-east"},
+		},
+	}}))
+	require.NoError(t, CheckRegions(gg, "checkout", "prod", nil), "resources without a region run anywhere")
+
+	instance, err := gg.GetNode("checkout-cache")
+	require.NoError(t, err)
+	instance.Spec[regionKey] = "eu-west"
+	instance.Spec[clusterKey] = "eu-west-b"
+	require.NoError(t, gg.UpdateNode(instance))
+	assert.ErrorContains(t, CheckRegions(gg, "checkout", "prod", nil), "checkout-cache is on cluster eu-west-b, which prod does not have in eu-west")
+
+	instance.Spec[clusterKey] = "eu-west-a"
+	require.NoError(t, gg.UpdateNode(instance))
+	assert.NoError(t, CheckRegions(gg, "checkout", "prod", []string{"eu-west", "us-east"}))
+	assert.ErrorContains(t, CheckRegions(gg, "checkout", "prod", []string{"us-east"}), "checkout-cache is in eu-west, which the deployment does not target")
+	assert.ErrorContains(t, CheckRegions(gg, "checkout", "prod", []string{"ap-south"}), "does not span region ap-south")
+}
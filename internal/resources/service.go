@@ -7,14 +7,17 @@ import (
 	"fmt"
 
 	"github.com/krzachariassen/ZTDP/internal/contracts"
+	"github.com/krzachariassen/ZTDP/internal/events"
 	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/quotas"
 )
 
 const resourceCatalogNodeID = "resource-catalog"
 const resourceCatalogKind = "resource_register"
 
 type Service struct {
-	Graph *graph.GlobalGraph
+	Graph    *graph.GlobalGraph
+	EventBus *events.EventBus // receives lifecycle notifications; defaults to the global bus
 }
 
 func NewService(g *graph.GlobalGraph) *Service {
@@ -114,6 +117,22 @@ func (s *Service) CreateResource(req ResourceRequest) (*ResourceResponse, error)
 		ownerVal = ""
 	}
 
+	// Resource type plugins fill in defaults and validate the spec
+	if req.Kind == "resource_type" {
+		if plugin, ok := GetPlugin(nameVal); ok {
+			req.Spec = applyDefaults(plugin, req.Spec)
+			if err := plugin.Validate(req.Spec); err != nil {
+				return nil, err
+			}
+		}
+	} else if typeName, _ := req.Spec["type"].(string); typeName != "" {
+		if plugin, ok := GetPlugin(typeName); ok {
+			if err := plugin.Validate(req.Spec); err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	// Build the contract
 	var node *graph.Node
 	if req.Kind == "resource_type" {
@@ -141,7 +160,9 @@ func (s *Service) CreateResource(req ResourceRequest) (*ResourceResponse, error)
 	}
 
 	s.ensureResourceCatalogRoot()
-	s.Graph.AddNode(node)
+	if err := s.Graph.AddNode(node); err != nil {
+		return nil, fmt.Errorf("failed to save resource: %w", err)
+	}
 
 	// If this is a resource_type, add an 'owns' edge from the catalog root
 	if node.Kind == "resource_type" {
@@ -162,13 +183,10 @@ func (s *Service) CreateResource(req ResourceRequest) (*ResourceResponse, error)
 	}, nil
 }
 
-// AddResourceToApplication creates a resource instance for an application
-func (s *Service) AddResourceToApplication(appName, resourceName, instanceName string) (*ResourceInstanceResponse, error) {
-	// Use default naming if no custom instance name provided
-	if instanceName == "" {
-		instanceName = appName + "-" + resourceName
-	}
-
+// AddResourceToApplication creates a resource instance for an application. Without a custom
+// instance name the name comes from the naming strategy; environment is only used by naming
+// templates that include it.
+func (s *Service) AddResourceToApplication(appName, resourceName, instanceName, environment string) (*ResourceInstanceResponse, error) {
 	// Check if application exists
 	appNode, err := s.Graph.GetNode(appName)
 	if err != nil || appNode == nil || appNode.Kind != "application" {
@@ -193,9 +211,28 @@ func (s *Service) AddResourceToApplication(appName, resourceName, instanceName s
 		return nil, fmt.Errorf("resource type '%s' not found", resourceTypeName)
 	}
 
+	if instanceName == "" {
+		team, _ := appNode.Metadata["owner"].(string)
+		instanceName, err = InstanceName(NameVariables{
+			App:      appName,
+			Resource: resourceName,
+			Type:     resourceTypeName,
+			Team:     team,
+			Env:      environment,
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else if err := ValidateInstanceName(resourceTypeName, instanceName); err != nil {
+		return nil, err
+	}
+
 	// Check if instance already exists
 	if existingNode, err := s.Graph.GetNode(instanceName); err == nil && existingNode != nil {
 		if existingNode.Kind == "resource" {
+			if existingNode.Metadata["application"] != appName || existingNode.Metadata["catalog_ref"] != resourceName {
+				return nil, fmt.Errorf("%w: %s", ErrInstanceNameConflict, instanceName)
+			}
 			// Resource instance already exists - return success (idempotent)
 			return &ResourceInstanceResponse{
 				Message:      "Resource instance already exists",
@@ -209,6 +246,10 @@ func (s *Service) AddResourceToApplication(appName, resourceName, instanceName s
 		}
 	}
 
+	if err := quotas.NewService(s.Graph).CheckResourceCreation(appName); err != nil {
+		return nil, err
+	}
+
 	// Create the resource instance
 	resourceInstance := &graph.Node{
 		ID:   instanceName,
@@ -218,12 +259,30 @@ func (s *Service) AddResourceToApplication(appName, resourceName, instanceName s
 			"owner":       catalogNode.Metadata["owner"],
 			"application": appName,
 			"catalog_ref": resourceName,
+			// Plugins provision synchronously, so an instance is active once it is recorded
+			lifecycleStateKey: string(StateActive),
 		},
 		Spec: catalogNode.Spec, // Inherit spec from catalog resource
 	}
 
+	// Let the resource type plugin provision the instance before it is recorded
+	if plugin, ok := GetPlugin(resourceTypeName); ok {
+		spec, err := provisionInstance(plugin, ResourceInstance{
+			Name:        instanceName,
+			Application: appName,
+			Type:        resourceTypeName,
+			Spec:        catalogNode.Spec,
+		})
+		if err != nil {
+			return nil, err
+		}
+		resourceInstance.Spec = spec
+	}
+
 	// Add the resource instance to the graph
-	s.Graph.AddNode(resourceInstance)
+	if err := s.Graph.AddNode(resourceInstance); err != nil {
+		return nil, err
+	}
 
 	// Create relationships
 	if err := s.Graph.AddEdge(appName, instanceName, graph.EdgeTypeOwns); err != nil {
@@ -252,6 +311,33 @@ func (s *Service) AddResourceToApplication(appName, resourceName, instanceName s
 	}, nil
 }
 
+// provisionInstance runs the plugin provisioning hook and returns the instance spec
+// (a copy of the catalog spec) extended with the plugin outputs
+func provisionInstance(plugin ResourceTypePlugin, instance ResourceInstance) (map[string]interface{}, error) {
+	outputs, err := plugin.Provision(context.Background(), instance)
+	if err != nil {
+		return nil, fmt.Errorf("failed to provision %s instance %s: %w", instance.Type, instance.Name, err)
+	}
+
+	spec := make(map[string]interface{}, len(instance.Spec)+len(outputs))
+	for k, v := range instance.Spec {
+		spec[k] = v
+	}
+	for k, v := range outputs {
+		spec[k] = v
+	}
+	if _, ok := spec["connection_string"]; !ok {
+		conn, err := plugin.ConnectionString(instance)
+		if err != nil {
+			return nil, err
+		}
+		if conn != "" {
+			spec["connection_string"] = conn
+		}
+	}
+	return spec, nil
+}
+
 // LinkServiceToResource creates a 'uses' edge from service to resource
 func (s *Service) LinkServiceToResource(appName, serviceName, resourceName string) (*ResourceInstanceResponse, error) {
 	// Validate application exists
@@ -298,7 +384,7 @@ func (s *Service) LinkServiceToResource(appName, serviceName, resourceName strin
 
 // findResourceInstanceInApplication finds the resource instance owned by an application that references the catalog resource
 func (s *Service) findResourceInstanceInApplication(appName, catalogResourceName string) (string, error) {
-	// First try the new predictable naming scheme
+	// First try the default naming scheme
 	predictableInstanceName := appName + "-" + catalogResourceName
 	if node, err := s.Graph.GetNode(predictableInstanceName); err == nil && node != nil {
 		if node.Kind == "resource" {
@@ -434,6 +520,9 @@ func (s *Service) isResourceInstance(node *graph.Node) bool {
 	if node.Kind != "resource" {
 		return false
 	}
+	if IsShared(node) {
+		return true
+	}
 	// Resource instances have "application" and "catalog_ref" in metadata
 	if app, hasApp := node.Metadata["application"]; hasApp && app != nil {
 		if catRef, hasCatRef := node.Metadata["catalog_ref"]; hasCatRef && catRef != nil {
@@ -512,7 +601,9 @@ func (s *Service) CreateResourceFromContract(ctx context.Context, resource *cont
 		},
 	}
 
-	s.Graph.AddNode(resourceInstance)
+	if err := s.Graph.AddNode(resourceInstance); err != nil {
+		return nil, err
+	}
 	s.Graph.AddEdge(resource.Metadata.Name, resource.Spec.Type, graph.EdgeTypeInstanceOf)
 
 	if err := s.Graph.Save(); err != nil {
@@ -0,0 +1,388 @@
+package resources
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+)
+
+// Access levels a grant gives its consumer
+const (
+	AccessRead      = "read"
+	AccessReadWrite = "readwrite"
+)
+
+// Spec keys of shared resource instances
+const (
+	sharedKey         = "shared"
+	accessPolicyKey   = "access_policy"
+	sharedCapacityKey = "capacity"
+)
+
+// ErrSharedResourceNotFound is returned when a name does not refer to a shared resource instance
+var ErrSharedResourceNotFound = errors.New("shared resource not found")
+
+// ErrGrantNotFound is returned when a consumer has no grant on a shared resource
+var ErrGrantNotFound = errors.New("access grant not found")
+
+// AccessDeniedError reports an access request refused by the shared resource's policy or capacity
+type AccessDeniedError struct {
+	Resource string `json:"resource"`
+	Consumer string `json:"consumer"`
+	Reason   string `json:"reason"`
+}
+
+func (e *AccessDeniedError) Error() string {
+	return fmt.Sprintf("access to %s denied for %s: %s", e.Resource, e.Consumer, e.Reason)
+}
+
+// AccessPolicy decides which consumers may request access to a shared resource. Applications
+// of the owning team are always allowed.
+type AccessPolicy struct {
+	AllowedTeams        []string `json:"allowed_teams,omitempty"` // "*" allows every team
+	AllowedApplications []string `json:"allowed_applications,omitempty"`
+	MaxConsumers        int      `json:"max_consumers,omitempty"` // 0 allows any number of grants
+}
+
+// SharedResourceRequest creates a resource instance that several applications can use
+type SharedResourceRequest struct {
+	Name       string         `json:"name"`
+	CatalogRef string         `json:"catalog_ref"` // catalog resource the instance is created from
+	Owner      string         `json:"owner"`       // team operating the instance
+	Policy     AccessPolicy   `json:"policy"`
+	Capacity   map[string]int `json:"capacity,omitempty"` // totals the consumers' quotas may add up to, e.g. connections: 500
+}
+
+// AccessRequest asks for a consumer to be granted access to a shared resource
+type AccessRequest struct {
+	Consumer    string         `json:"consumer"` // application or service
+	Access      string         `json:"access"`   // read | readwrite; defaults to read
+	Quota       map[string]int `json:"quota,omitempty"`
+	RequestedBy string         `json:"requested_by,omitempty"`
+}
+
+// AccessGrant is a consumer's access to a shared resource, stored on its accesses edge
+type AccessGrant struct {
+	Resource     string         `json:"resource"`
+	Consumer     string         `json:"consumer"`
+	ConsumerKind string         `json:"consumer_kind"`
+	Team         string         `json:"team,omitempty"` // owner of the consuming application
+	Access       string         `json:"access"`
+	Quota        map[string]int `json:"quota,omitempty"`
+	RequestedBy  string         `json:"requested_by,omitempty"`
+	GrantedAt    time.Time      `json:"granted_at"`
+}
+
+// IsShared reports whether a resource node is a shared instance
+func IsShared(node *graph.Node) bool {
+	shared, _ := node.Metadata[sharedKey].(bool)
+	return node.Kind == graph.KindResource && shared
+}
+
+// CreateSharedResource creates a shared instance of a catalog resource. It is owned by a team
+// rather than an application; applications and services use it through access grants.
+func (s *Service) CreateSharedResource(req SharedResourceRequest) (*ResourceResponse, error) {
+	if req.Name == "" || req.CatalogRef == "" || req.Owner == "" {
+		return nil, errors.New("name, catalog_ref and owner are required")
+	}
+	if req.Policy.MaxConsumers < 0 {
+		return nil, errors.New("max_consumers must not be negative")
+	}
+	for unit, total := range req.Capacity {
+		if total < 0 {
+			return nil, fmt.Errorf("capacity %s must not be negative", unit)
+		}
+	}
+
+	catalogNode, err := s.Graph.GetNode(req.CatalogRef)
+	if err != nil || catalogNode == nil || catalogNode.Kind != graph.KindResource {
+		return nil, errors.New("resource not found in catalog")
+	}
+	resourceTypeName, _ := catalogNode.Spec["type"].(string)
+	if resourceTypeNode, err := s.Graph.GetNode(resourceTypeName); err != nil || resourceTypeNode == nil || resourceTypeNode.Kind != graph.KindResourceType {
+		return nil, fmt.Errorf("resource type '%s' not found", resourceTypeName)
+	}
+	if err := ValidateInstanceName(resourceTypeName, req.Name); err != nil {
+		return nil, err
+	}
+	if existing, err := s.Graph.GetNode(req.Name); err == nil && existing != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInstanceNameConflict, req.Name)
+	}
+
+	spec := catalogNode.Spec
+	if plugin, ok := GetPlugin(resourceTypeName); ok {
+		// Shared instances have no application, so they are placed in their owning team's namespace
+		if spec, err = provisionInstance(plugin, ResourceInstance{Name: req.Name, Application: req.Owner, Type: resourceTypeName, Spec: catalogNode.Spec}); err != nil {
+			return nil, err
+		}
+	} else {
+		spec = make(map[string]interface{}, len(catalogNode.Spec)+2)
+		for k, v := range catalogNode.Spec {
+			spec[k] = v
+		}
+	}
+	if spec[accessPolicyKey], err = toSpecValue(req.Policy); err != nil {
+		return nil, err
+	}
+	if len(req.Capacity) > 0 {
+		if spec[sharedCapacityKey], err = toSpecValue(req.Capacity); err != nil {
+			return nil, err
+		}
+	}
+
+	node := &graph.Node{
+		ID:   req.Name,
+		Kind: graph.KindResource,
+		Metadata: map[string]interface{}{
+			"name":            req.Name,
+			"owner":           req.Owner,
+			"catalog_ref":     req.CatalogRef,
+			sharedKey:         true,
+			lifecycleStateKey: string(StateActive),
+		},
+		Spec: spec,
+	}
+	if err := s.Graph.AddNode(node); err != nil {
+		return nil, err
+	}
+	if err := s.Graph.AddEdge(req.Name, resourceTypeName, graph.EdgeTypeInstanceOf); err != nil {
+		return nil, fmt.Errorf("failed to link instance to resource type: %w", err)
+	}
+	if err := s.Graph.Save(); err != nil {
+		return nil, errors.New("failed to save shared resource")
+	}
+	return &ResourceResponse{ID: node.ID, Kind: node.Kind, Metadata: node.Metadata, Spec: node.Spec}, nil
+}
+
+// RequestAccess grants a consumer access to a shared resource if the resource's access policy
+// allows the consumer's team or application, the consumer limit is not reached and the
+// requested quota fits the capacity left by other consumers. Requesting again replaces the
+// consumer's grant.
+func (s *Service) RequestAccess(resourceName string, req AccessRequest) (*AccessGrant, error) {
+	if req.Access == "" {
+		req.Access = AccessRead
+	}
+	if req.Access != AccessRead && req.Access != AccessReadWrite {
+		return nil, fmt.Errorf("unknown access %q (use read or readwrite)", req.Access)
+	}
+	for unit, amount := range req.Quota {
+		if amount < 0 {
+			return nil, fmt.Errorf("quota %s must not be negative", unit)
+		}
+	}
+
+	resource, err := s.sharedResource(resourceName)
+	if err != nil {
+		return nil, err
+	}
+	consumer, err := s.Graph.GetNode(req.Consumer)
+	if err != nil || consumer == nil || (consumer.Kind != graph.KindApplication && consumer.Kind != graph.KindService) {
+		return nil, fmt.Errorf("consumer %q is not an application or service", req.Consumer)
+	}
+	grants, err := s.ListGrants(resourceName)
+	if err != nil {
+		return nil, err
+	}
+	app, err := s.consumerApplication(consumer)
+	if err != nil {
+		return nil, err
+	}
+	team := ""
+	if appNode, _ := s.Graph.GetNode(app); appNode != nil {
+		team, _ = appNode.Metadata["owner"].(string)
+	}
+
+	denied := func(reason string, args ...interface{}) error {
+		return &AccessDeniedError{Resource: resourceName, Consumer: req.Consumer, Reason: fmt.Sprintf(reason, args...)}
+	}
+	policy, capacity := accessPolicyOf(resource), capacityOf(resource)
+	owner, _ := resource.Metadata["owner"].(string)
+	if !(team != "" && team == owner) && !contains(policy.AllowedTeams, "*") && !(team != "" && contains(policy.AllowedTeams, team)) && !contains(policy.AllowedApplications, app) {
+		return nil, denied("team %q and application %q are not allowed by the access policy", team, app)
+	}
+
+	others := grants[:0]
+	for _, grant := range grants {
+		if grant.Consumer != req.Consumer {
+			others = append(others, grant)
+		}
+	}
+	if policy.MaxConsumers > 0 && len(others) >= policy.MaxConsumers {
+		return nil, denied("the resource already has %d of %d consumers", len(others), policy.MaxConsumers)
+	}
+	for unit, amount := range req.Quota {
+		total, limited := capacity[unit]
+		if !limited {
+			if len(capacity) > 0 {
+				return nil, denied("the resource has no %s capacity", unit)
+			}
+			continue
+		}
+		used := 0
+		for _, grant := range others {
+			used += grant.Quota[unit]
+		}
+		if used+amount > total {
+			return nil, denied("%d %s requested but only %d of %d are left", amount, unit, total-used, total)
+		}
+	}
+
+	grant := &AccessGrant{
+		Resource:     resourceName,
+		Consumer:     req.Consumer,
+		ConsumerKind: consumer.Kind,
+		Team:         team,
+		Access:       req.Access,
+		Quota:        req.Quota,
+		RequestedBy:  req.RequestedBy,
+		GrantedAt:    time.Now().UTC(),
+	}
+	metadata, err := toSpecValue(grant)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Graph.AddEdge(req.Consumer, resourceName, graph.EdgeTypeAccesses); err != nil && err.Error() != "edge already exists" {
+		return nil, fmt.Errorf("failed to grant access: %w", err)
+	}
+	if err := s.updateAccessEdge(req.Consumer, resourceName, func(edges []graph.Edge, i int) []graph.Edge {
+		edges[i].Metadata = metadata.(map[string]interface{})
+		return edges
+	}); err != nil {
+		return nil, err
+	}
+	return grant, nil
+}
+
+// RevokeAccess removes a consumer's grant on a shared resource
+func (s *Service) RevokeAccess(resourceName, consumer string) error {
+	if _, err := s.sharedResource(resourceName); err != nil {
+		return err
+	}
+	return s.updateAccessEdge(consumer, resourceName, func(edges []graph.Edge, i int) []graph.Edge {
+		return append(edges[:i], edges[i+1:]...)
+	})
+}
+
+// ListGrants returns the grants on a shared resource, sorted by consumer
+func (s *Service) ListGrants(resourceName string) ([]AccessGrant, error) {
+	if _, err := s.sharedResource(resourceName); err != nil {
+		return nil, err
+	}
+	edges, err := s.Graph.Edges()
+	if err != nil {
+		return nil, err
+	}
+	grants := []AccessGrant{}
+	for from, list := range edges {
+		for _, edge := range list {
+			if edge.Type != graph.EdgeTypeAccesses || edge.To != resourceName {
+				continue
+			}
+			var grant AccessGrant
+			if data, err := json.Marshal(edge.Metadata); err == nil {
+				json.Unmarshal(data, &grant)
+			}
+			grant.Resource, grant.Consumer = resourceName, from
+			grants = append(grants, grant)
+		}
+	}
+	sort.Slice(grants, func(i, j int) bool { return grants[i].Consumer < grants[j].Consumer })
+	return grants, nil
+}
+
+func (s *Service) sharedResource(name string) (*graph.Node, error) {
+	node, err := s.Graph.GetNode(name)
+	if err != nil || node == nil || !IsShared(node) {
+		return nil, ErrSharedResourceNotFound
+	}
+	return node, nil
+}
+
+// consumerApplication returns the application a consumer belongs to: itself, or the
+// application owning a service
+func (s *Service) consumerApplication(consumer *graph.Node) (string, error) {
+	if consumer.Kind == graph.KindApplication {
+		return consumer.ID, nil
+	}
+	edges, err := s.Graph.Edges()
+	if err != nil {
+		return "", err
+	}
+	for from, list := range edges {
+		for _, edge := range list {
+			if edge.To == consumer.ID && edge.Type == graph.EdgeTypeOwns {
+				return from, nil
+			}
+		}
+	}
+	if app, ok := consumer.Spec["application"].(string); ok {
+		return app, nil
+	}
+	return "", fmt.Errorf("service %s does not belong to an application", consumer.ID)
+}
+
+// updateAccessEdge applies change to the consumer's accesses edge to the resource and saves
+func (s *Service) updateAccessEdge(consumer, resourceName string, change func(edges []graph.Edge, i int) []graph.Edge) error {
+	found := false
+	err := s.Graph.Update(func(current *graph.Graph) error {
+		for i, edge := range current.Edges[consumer] {
+			if edge.To == resourceName && edge.Type == graph.EdgeTypeAccesses {
+				current.Edges[consumer] = change(current.Edges[consumer], i)
+				found = true
+				return nil
+			}
+		}
+		return ErrGrantNotFound
+	})
+	if err != nil && found {
+		return fmt.Errorf("failed to save access grant: %w", err)
+	}
+	return err
+}
+
+func accessPolicyOf(node *graph.Node) AccessPolicy {
+	var policy AccessPolicy
+	fromSpecValue(node.Spec[accessPolicyKey], &policy)
+	return policy
+}
+
+func capacityOf(node *graph.Node) map[string]int {
+	capacity := map[string]int{}
+	fromSpecValue(node.Spec[sharedCapacityKey], &capacity)
+	return capacity
+}
+
+// toSpecValue converts v to the generic JSON form stored in node specs and edge metadata
+func toSpecValue(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func fromSpecValue(value interface{}, v interface{}) {
+	if value == nil {
+		return
+	}
+	if data, err := json.Marshal(value); err == nil {
+		json.Unmarshal(data, v)
+	}
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
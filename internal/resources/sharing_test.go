@@ -0,0 +1,105 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSharingTestService(t *testing.T) (*Service, *graph.GlobalGraph) {
+	gg := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	svc := NewService(gg)
+	for app, owner := range map[string]string{"checkout": "payments", "search": "discovery", "reports": "finance"} {
+		require.NoError(t, gg.AddNode(&graph.Node{ID: app, Kind: "application", Metadata: map[string]interface{}{"name": app, "owner": owner}, Spec: map[string]interface{}{}}))
+	}
+	require.NoError(t, gg.AddNode(&graph.Node{ID: "checkout-api", Kind: "service", Metadata: map[string]interface{}{"name": "checkout-api"}, Spec: map[string]interface{}{}}))
+	require.NoError(t, gg.AddEdge("checkout", "checkout-api", graph.EdgeTypeOwns))
+
+	_, err := svc.CreateResource(ResourceRequest{
+		Kind:     "resource_type",
+		Metadata: map[string]interface{}{"name": "kafka", "owner": "platform-team"},
+	})
+	require.NoError(t, err)
+	_, err = svc.CreateResource(ResourceRequest{
+		Kind:     "resource",
+		Metadata: map[string]interface{}{"name": "events", "owner": "platform-team"},
+		Spec:     map[string]interface{}{"type": "kafka"},
+	})
+	require.NoError(t, err)
+
+	_, err = svc.CreateSharedResource(SharedResourceRequest{
+		Name:       "shared-kafka",
+		CatalogRef: "events",
+		Owner:      "platform-team",
+		Policy:     AccessPolicy{AllowedTeams: []string{"payments"}, AllowedApplications: []string{"search"}, MaxConsumers: 2},
+		Capacity:   map[string]int{"partitions": 10},
+	})
+	require.NoError(t, err)
+	return svc, gg
+}
+
+func TestRequestAccess_AppliesPolicyCapacityAndConsumerLimit(t *testing.T) {
+	svc, gg := newSharingTestService(t)
+
+	grant, err := svc.RequestAccess("shared-kafka", AccessRequest{Consumer: "checkout-api", Access: AccessReadWrite, Quota: map[string]int{"partitions": 6}, RequestedBy: "alice"})
+	require.NoError(t, err)
+	assert.Equal(t, "payments", grant.Team, "a service is checked against its application's team")
+	has, _ := gg.HasEdge("checkout-api", "shared-kafka", graph.EdgeTypeAccesses)
+	assert.True(t, has)
+
+	var denied *AccessDeniedError
+	_, err = svc.RequestAccess("shared-kafka", AccessRequest{Consumer: "reports"})
+	require.ErrorAs(t, err, &denied)
+	assert.Contains(t, denied.Reason, "not allowed by the access policy")
+
+	_, err = svc.RequestAccess("shared-kafka", AccessRequest{Consumer: "search", Quota: map[string]int{"partitions": 5}})
+	require.ErrorAs(t, err, &denied)
+	assert.Contains(t, denied.Reason, "only 4 of 10 are left")
+
+	_, err = svc.RequestAccess("shared-kafka", AccessRequest{Consumer: "search", Quota: map[string]int{"partitions": 4}})
+	require.NoError(t, err)
+
+	// Re-requesting replaces the grant without counting the consumer twice
+	_, err = svc.RequestAccess("shared-kafka", AccessRequest{Consumer: "checkout-api", Quota: map[string]int{"partitions": 2}})
+	require.NoError(t, err)
+
+	_, err = svc.RequestAccess("shared-kafka", AccessRequest{Consumer: "checkout"})
+	require.ErrorAs(t, err, &denied)
+	assert.Contains(t, denied.Reason, "2 of 2 consumers")
+
+	grants, err := svc.ListGrants("shared-kafka")
+	require.NoError(t, err)
+	require.Len(t, grants, 2)
+	assert.Equal(t, "checkout-api", grants[0].Consumer)
+	assert.Equal(t, AccessRead, grants[0].Access)
+	assert.Equal(t, map[string]int{"partitions": 2}, grants[0].Quota)
+	assert.Equal(t, "search", grants[1].Consumer)
+}
+
+func TestRevokeAccess(t *testing.T) {
+	svc, gg := newSharingTestService(t)
+
+	_, err := svc.RequestAccess("shared-kafka", AccessRequest{Consumer: "search"})
+	require.NoError(t, err)
+	require.NoError(t, svc.RevokeAccess("shared-kafka", "search"))
+	has, _ := gg.HasEdge("search", "shared-kafka", graph.EdgeTypeAccesses)
+	assert.False(t, has)
+
+	assert.ErrorIs(t, svc.RevokeAccess("shared-kafka", "search"), ErrGrantNotFound)
+	_, err = svc.ListGrants("events")
+	assert.ErrorIs(t, err, ErrSharedResourceNotFound, "catalog resources are not shared instances")
+}
+
+func TestCheckDeployable_IncludesSharedResources(t *testing.T) {
+	svc, _ := newSharingTestService(t)
+
+	_, err := svc.RequestAccess("shared-kafka", AccessRequest{Consumer: "checkout-api"})
+	require.NoError(t, err)
+	_, err = svc.TransitionResource("shared-kafka", StateMaintenance, "broker upgrade")
+	require.NoError(t, err)
+
+	_, err = CheckDeployable(svc.Graph, "checkout")
+	assert.ErrorContains(t, err, "shared-kafka is in maintenance")
+}
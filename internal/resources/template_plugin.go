@@ -0,0 +1,243 @@
+package resources
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// PluginDefinition declares a resource type plugin without Go code: defaults, required
+// spec fields, a connection string template rendered with the ResourceInstance and, for
+// messaging types, an ACL template rendered once per TopicBinding
+type PluginDefinition struct {
+	Type               string                 `json:"type"`
+	Description        string                 `json:"description,omitempty"`
+	Defaults           map[string]interface{} `json:"defaults,omitempty"`
+	RequiredFields     []string               `json:"required_fields,omitempty"`
+	ConnectionTemplate string                 `json:"connection_template,omitempty"`
+	ACLTemplate        string                 `json:"acl_template,omitempty"`
+}
+
+var pluginTypePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// TemplatePlugin is a ResourceTypePlugin built from a PluginDefinition. Its provisioning
+// hooks only render the connection string; real provisioning belongs in a Go plugin.
+type TemplatePlugin struct {
+	def  PluginDefinition
+	tmpl *template.Template
+	acl  *template.Template
+}
+
+// NewTemplatePlugin validates a definition and compiles its connection template
+func NewTemplatePlugin(def PluginDefinition) (*TemplatePlugin, error) {
+	if !pluginTypePattern.MatchString(def.Type) {
+		return nil, fmt.Errorf("invalid resource type %q: use lowercase letters, digits, '_' or '-'", def.Type)
+	}
+
+	p := &TemplatePlugin{def: def}
+	if def.ConnectionTemplate != "" {
+		tmpl, err := template.New(def.Type).Option("missingkey=error").Parse(def.ConnectionTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid connection template for %s: %w", def.Type, err)
+		}
+		p.tmpl = tmpl
+	}
+	if def.ACLTemplate != "" {
+		acl, err := template.New(def.Type + "-acl").Option("missingkey=error").Parse(def.ACLTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ACL template for %s: %w", def.Type, err)
+		}
+		p.acl = acl
+	}
+	return p, nil
+}
+
+// Definition returns the declarative definition of the plugin
+func (p *TemplatePlugin) Definition() PluginDefinition {
+	return p.def
+}
+
+// Type returns the resource type the plugin handles
+func (p *TemplatePlugin) Type() string { return p.def.Type }
+
+// DefaultConfig returns a copy of the definition defaults
+func (p *TemplatePlugin) DefaultConfig() map[string]interface{} {
+	defaults := make(map[string]interface{}, len(p.def.Defaults))
+	for k, v := range p.def.Defaults {
+		defaults[k] = v
+	}
+	return defaults
+}
+
+// Validate checks required fields and that a requested tier is one the type offers
+func (p *TemplatePlugin) Validate(spec map[string]interface{}) error {
+	var missing []string
+	for _, field := range p.def.RequiredFields {
+		if v, ok := spec[field]; !ok || v == nil || v == "" {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%s: missing required fields: %s", p.def.Type, strings.Join(missing, ", "))
+	}
+
+	tier, _ := spec["tier"].(string)
+	if tier == "" {
+		return nil
+	}
+	options := stringList(spec["tier_options"])
+	if len(options) == 0 {
+		options = stringList(p.def.Defaults["tier_options"])
+	}
+	if len(options) == 0 {
+		return nil
+	}
+	for _, option := range options {
+		if option == tier {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s: tier %q is not one of %s", p.def.Type, tier, strings.Join(options, ", "))
+}
+
+// Provision returns the rendered connection string as the only output
+func (p *TemplatePlugin) Provision(ctx context.Context, instance ResourceInstance) (map[string]interface{}, error) {
+	conn, err := p.ConnectionString(instance)
+	if err != nil || conn == "" {
+		return nil, err
+	}
+	return map[string]interface{}{"connection_string": conn}, nil
+}
+
+// Deprovision has nothing to release for declarative plugins
+func (p *TemplatePlugin) Deprovision(ctx context.Context, instance ResourceInstance) error {
+	return nil
+}
+
+// ConnectionString renders the connection template, or "" when the definition has none
+func (p *TemplatePlugin) ConnectionString(instance ResourceInstance) (string, error) {
+	if p.tmpl == nil {
+		return "", nil
+	}
+	var buf bytes.Buffer
+	if err := p.tmpl.Execute(&buf, instance); err != nil {
+		return "", fmt.Errorf("%s: render connection string: %w", p.def.Type, err)
+	}
+	return buf.String(), nil
+}
+
+// RenderACLs renders the ACL template once per binding, or nothing when the definition has none
+func (p *TemplatePlugin) RenderACLs(instance ResourceInstance, bindings []TopicBinding) ([]string, error) {
+	if p.acl == nil {
+		return nil, nil
+	}
+	acls := make([]string, 0, len(bindings))
+	for _, binding := range bindings {
+		var buf bytes.Buffer
+		if err := p.acl.Execute(&buf, binding); err != nil {
+			return nil, fmt.Errorf("%s: render ACL for %s on %s: %w", p.def.Type, binding.Service, binding.Topic, err)
+		}
+		acls = append(acls, buf.String())
+	}
+	return acls, nil
+}
+
+// stringList converts a decoded JSON/YAML list to strings
+func stringList(v interface{}) []string {
+	switch list := v.(type) {
+	case []string:
+		return list
+	case []interface{}:
+		out := make([]string, 0, len(list))
+		for _, item := range list {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// builtinPlugins are the resource types the platform supports out of the box
+var builtinPlugins = []PluginDefinition{
+	{
+		Type:        "postgres",
+		Description: "PostgreSQL database",
+		Defaults: map[string]interface{}{
+			"version":          "15",
+			"default_tier":     "standard",
+			"tier_options":     []interface{}{"standard", "premium"},
+			"available_plans":  []interface{}{"small", "medium", "large"},
+			"default_capacity": "10GB",
+		},
+		ConnectionTemplate: "postgres://{{.Name}}.{{.Application}}.svc.cluster.local:5432/{{.Name}}",
+	},
+	{
+		Type:        "redis",
+		Description: "Redis cache",
+		Defaults: map[string]interface{}{
+			"version":          "7",
+			"default_tier":     "standard",
+			"tier_options":     []interface{}{"standard", "premium"},
+			"default_capacity": "1GB",
+		},
+		ConnectionTemplate: "redis://{{.Name}}.{{.Application}}.svc.cluster.local:6379",
+	},
+	{
+		Type:        "kafka",
+		Description: "Kafka topic cluster",
+		Defaults: map[string]interface{}{
+			"version":          "3.7",
+			"default_tier":     "standard",
+			"tier_options":     []interface{}{"standard", "high-throughput"},
+			"default_capacity": "3 partitions",
+		},
+		ConnectionTemplate: "{{.Name}}.{{.Application}}.svc.cluster.local:9092",
+		ACLTemplate: `kafka-acls --add --allow-principal User:{{.Application}}.{{.Service}} ` +
+			`{{if eq .Operation "produce"}}--operation Write --topic {{.Topic}}{{else}}--operation Read --topic {{.Topic}} --group {{.Group}}{{end}}`,
+	},
+	{
+		Type:        "rabbitmq",
+		Description: "RabbitMQ message broker",
+		Defaults: map[string]interface{}{
+			"version":      "3.13",
+			"default_tier": "standard",
+			"tier_options": []interface{}{"standard", "quorum"},
+		},
+		ConnectionTemplate: "amqp://{{.Name}}.{{.Application}}.svc.cluster.local:5672",
+		ACLTemplate: `vhost={{.Resource}} user={{.Application}}.{{.Service}} queue={{.Topic}} ` +
+			`{{if eq .Operation "produce"}}permission=write{{else}}permission=read{{end}}`,
+	},
+	{
+		Type:        "s3",
+		Description: "S3-compatible object storage bucket",
+		Defaults: map[string]interface{}{
+			"version":      "v4",
+			"default_tier": "standard",
+			"tier_options": []interface{}{"standard", "infrequent-access", "archive"},
+		},
+		ConnectionTemplate: "s3://{{.Application}}-{{.Name}}",
+	},
+}
+
+func init() {
+	for _, def := range builtinPlugins {
+		p, err := NewTemplatePlugin(def)
+		if err != nil {
+			panic(err) // built-in definitions are static, so this is a programming error
+		}
+		RegisterPlugin(p)
+	}
+}
+
+// DescribePlugin returns the declarative view of a plugin. Go plugins only expose their type and defaults.
+func DescribePlugin(p ResourceTypePlugin) PluginDefinition {
+	if tp, ok := p.(*TemplatePlugin); ok {
+		return tp.Definition()
+	}
+	return PluginDefinition{Type: p.Type(), Defaults: p.DefaultConfig()}
+}
@@ -0,0 +1,242 @@
+// Package routing keeps the routing override table: operator-managed rules that send chat requests
+// matching a pattern straight to a capability or agent, ahead of AI intent detection. Overrides
+// are an escape hatch for requests the AI keeps routing to the wrong agent.
+package routing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// ErrOverrideNotFound is returned when an override does not exist
+var ErrOverrideNotFound = errors.New("routing override not found")
+
+// nodeIDPrefix namespaces override nodes so they cannot collide with platform entities
+const nodeIDPrefix = "routing-override:"
+
+// Override sends chat requests whose message matches Pattern to a capability or agent
+type Override struct {
+	ID         string     `json:"id"`
+	Pattern    string     `json:"pattern"`              // regular expression matched against the message, ignoring case
+	Capability string     `json:"capability,omitempty"` // agents offering it share the request
+	Agent      string     `json:"agent,omitempty"`      // a single agent; with Capability, the capability it handles the request with
+	Intent     string     `json:"intent,omitempty"`     // intent sent to the agent; the capability's first intent when empty
+	Priority   int        `json:"priority,omitempty"`   // higher priorities are tried first, then older overrides
+	Reason     string     `json:"reason,omitempty"`
+	CreatedBy  string     `json:"created_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"` // never expires when unset
+	Hits       int64      `json:"hits"`
+	LastHitAt  *time.Time `json:"last_hit_at,omitempty"`
+}
+
+// Expired reports whether the override no longer applies at now
+func (o *Override) Expired(now time.Time) bool {
+	return o.ExpiresAt != nil && !now.Before(*o.ExpiresAt)
+}
+
+// Target describes where the override sends requests
+func (o *Override) Target() string {
+	switch {
+	case o.Agent != "" && o.Capability != "":
+		return fmt.Sprintf("agent %s (capability %s)", o.Agent, o.Capability)
+	case o.Agent != "":
+		return "agent " + o.Agent
+	default:
+		return "capability " + o.Capability
+	}
+}
+
+// Validate checks the pattern and target
+func (o *Override) Validate() error {
+	if o.Pattern == "" {
+		return fmt.Errorf("pattern is required")
+	}
+	if _, err := compile(o.Pattern); err != nil {
+		return fmt.Errorf("invalid pattern: %w", err)
+	}
+	if o.Capability == "" && o.Agent == "" {
+		return fmt.Errorf("a capability or agent is required")
+	}
+	return nil
+}
+
+// Service stores overrides in the global graph and matches messages against them
+type Service struct {
+	graph  *graph.GlobalGraph
+	logger *logging.Logger
+	mu     sync.Mutex // serializes hit counting
+}
+
+// NewService creates an override service backed by the global graph
+func NewService(globalGraph *graph.GlobalGraph) *Service {
+	return &Service{graph: globalGraph, logger: logging.GetLogger().ForComponent("routing")}
+}
+
+// Create stores a new override, assigning its ID and creation time
+func (s *Service) Create(override *Override) error {
+	if err := override.Validate(); err != nil {
+		return err
+	}
+	if override.ExpiresAt != nil && override.Expired(time.Now()) {
+		return fmt.Errorf("expires_at is in the past")
+	}
+	override.ID = uuid.New().String()
+	override.CreatedAt = time.Now().UTC()
+	override.Hits = 0
+	override.LastHitAt = nil
+
+	node, err := overrideToNode(override)
+	if err != nil {
+		return err
+	}
+	if err := s.graph.AddNode(node); err != nil {
+		return fmt.Errorf("failed to store routing override: %w", err)
+	}
+	s.logger.Info("🔀 Routing override %s added: /%s/ → %s", override.ID, override.Pattern, override.Target())
+	return nil
+}
+
+// Get returns an override by ID
+func (s *Service) Get(id string) (*Override, error) {
+	node, _ := s.graph.GetNode(nodeIDPrefix + id)
+	if node == nil || node.Kind != graph.KindRoutingOverride {
+		return nil, ErrOverrideNotFound
+	}
+	return nodeToOverride(node)
+}
+
+// List returns overrides in the order they are tried; expired ones only with includeExpired
+func (s *Service) List(includeExpired bool) ([]*Override, error) {
+	nodes, err := s.graph.Nodes()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	overrides := []*Override{}
+	for _, node := range nodes {
+		if node.Kind != graph.KindRoutingOverride {
+			continue
+		}
+		override, err := nodeToOverride(node)
+		if err != nil {
+			s.logger.Warn("⚠️ Skipping malformed routing override %s: %v", node.ID, err)
+			continue
+		}
+		if !includeExpired && override.Expired(now) {
+			continue
+		}
+		overrides = append(overrides, override)
+	}
+	sort.Slice(overrides, func(i, j int) bool {
+		if overrides[i].Priority != overrides[j].Priority {
+			return overrides[i].Priority > overrides[j].Priority
+		}
+		return overrides[i].CreatedAt.Before(overrides[j].CreatedAt)
+	})
+	return overrides, nil
+}
+
+// Delete removes an override
+func (s *Service) Delete(id string) error {
+	if _, err := s.Get(id); err != nil {
+		return err
+	}
+	if err := s.graph.DeleteNode(nodeIDPrefix + id); err != nil {
+		return fmt.Errorf("failed to delete routing override: %w", err)
+	}
+	s.logger.Info("🔀 Routing override %s removed", id)
+	return nil
+}
+
+// Match returns the unexpired overrides whose pattern matches message, in the order to try them
+func (s *Service) Match(message string) ([]*Override, error) {
+	overrides, err := s.List(false)
+	if err != nil {
+		return nil, err
+	}
+	var matched []*Override
+	for _, override := range overrides {
+		if re, err := compile(override.Pattern); err == nil && re.MatchString(message) {
+			matched = append(matched, override)
+		}
+	}
+	return matched, nil
+}
+
+// RecordHit counts a request routed by the override
+func (s *Service) RecordHit(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	override, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	override.Hits++
+	override.LastHitAt = &now
+	node, err := overrideToNode(override)
+	if err != nil {
+		return err
+	}
+	return s.graph.UpdateNode(node)
+}
+
+var (
+	patternsMu sync.Mutex
+	patterns   = map[string]*regexp.Regexp{}
+)
+
+// compile compiles a pattern case-insensitively, caching the result
+func compile(pattern string) (*regexp.Regexp, error) {
+	patternsMu.Lock()
+	defer patternsMu.Unlock()
+	if re, ok := patterns[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		return nil, err
+	}
+	patterns[pattern] = re
+	return re, nil
+}
+
+func overrideToNode(override *Override) (*graph.Node, error) {
+	data, err := json.Marshal(override)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode routing override: %w", err)
+	}
+	var spec map[string]interface{}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to encode routing override: %w", err)
+	}
+	return &graph.Node{
+		ID:       nodeIDPrefix + override.ID,
+		Kind:     graph.KindRoutingOverride,
+		Metadata: map[string]interface{}{"name": nodeIDPrefix + override.ID},
+		Spec:     spec,
+	}, nil
+}
+
+func nodeToOverride(node *graph.Node) (*Override, error) {
+	data, err := json.Marshal(node.Spec)
+	if err != nil {
+		return nil, err
+	}
+	var override Override
+	if err := json.Unmarshal(data, &override); err != nil {
+		return nil, err
+	}
+	return &override, nil
+}
@@ -0,0 +1,69 @@
+package routing
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreate_Validates(t *testing.T) {
+	service := NewService(graph.NewGlobalGraph(graph.NewMemoryGraph()))
+	assert.ErrorContains(t, service.Create(&Override{Capability: "deployment"}), "pattern is required")
+	assert.ErrorContains(t, service.Create(&Override{Pattern: "(", Capability: "deployment"}), "invalid pattern")
+	assert.ErrorContains(t, service.Create(&Override{Pattern: "deploy"}), "capability or agent is required")
+	past := time.Now().Add(-time.Hour)
+	assert.ErrorContains(t, service.Create(&Override{Pattern: "deploy", Capability: "deployment", ExpiresAt: &past}), "in the past")
+}
+
+func TestMatch_PriorityExpiryAndHits(t *testing.T) {
+	g := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	service := NewService(g)
+
+	broad := &Override{Pattern: "deploy", Capability: "deployment"}
+	require.NoError(t, service.Create(broad))
+	narrow := &Override{Pattern: `deploy .* to prod`, Agent: "release-agent", Priority: 5}
+	require.NoError(t, service.Create(narrow))
+
+	matched, err := service.Match("Deploy checkout to production")
+	require.NoError(t, err)
+	require.Len(t, matched, 2)
+	assert.Equal(t, narrow.ID, matched[0].ID, "higher priority first")
+	matched, err = service.Match("deploy checkout to dev")
+	require.NoError(t, err)
+	require.Len(t, matched, 1)
+	assert.Equal(t, broad.ID, matched[0].ID)
+	matched, err = service.Match("list applications")
+	require.NoError(t, err)
+	assert.Empty(t, matched)
+
+	// Expired overrides stay listed for their hit counts but no longer match
+	past := time.Now().Add(-time.Minute)
+	narrow.ExpiresAt = &past
+	node, err := overrideToNode(narrow)
+	require.NoError(t, err)
+	require.NoError(t, g.UpdateNode(node))
+	matched, err = service.Match("deploy checkout to prod")
+	require.NoError(t, err)
+	require.Len(t, matched, 1)
+	assert.Equal(t, broad.ID, matched[0].ID)
+	active, err := service.List(false)
+	require.NoError(t, err)
+	assert.Len(t, active, 1)
+	all, err := service.List(true)
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	require.NoError(t, service.RecordHit(broad.ID))
+	require.NoError(t, service.RecordHit(broad.ID))
+	stored, err := service.Get(broad.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), stored.Hits)
+	assert.NotNil(t, stored.LastHitAt)
+
+	require.NoError(t, service.Delete(broad.ID))
+	assert.True(t, errors.Is(service.Delete(broad.ID), ErrOverrideNotFound))
+}
@@ -0,0 +1,558 @@
+// Package sandbox answers "what would happen if" questions. It forks the global graph into an
+// isolated in-memory copy, applies a hypothetical change or plan to the fork, runs the
+// deployment checks and predicts the impact there, and discards the fork, so production state
+// is never touched.
+package sandbox
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/calendar"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/graphwatch"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/krzachariassen/ZTDP/internal/plans"
+	"github.com/krzachariassen/ZTDP/internal/resources"
+)
+
+var (
+	// ErrNothingToSimulate is returned for a request with neither changes nor a plan
+	ErrNothingToSimulate = errors.New("nothing to simulate - submit changes or a plan_id")
+	// ErrInvalidChange is returned for changes that are not well formed
+	ErrInvalidChange = errors.New("invalid change")
+)
+
+// Change operations
+const (
+	OpAddNode    = "add_node"
+	OpUpdateNode = "update_node"
+	OpDeleteNode = "delete_node"
+	OpAddEdge    = "add_edge"
+	OpDeploy     = "deploy"
+)
+
+// Step statuses
+const (
+	StatusApplied = "applied"
+	StatusBlocked = "blocked" // a policy or deployment check refused the change
+	StatusFailed  = "failed"  // the change is invalid against the fork, e.g. an unknown node
+	StatusSkipped = "skipped" // a plan step the sandbox cannot simulate
+)
+
+// Check statuses
+const (
+	CheckPassed  = "passed"
+	CheckWarning = "warning"
+	CheckBlocked = "blocked"
+)
+
+// Change is one hypothetical modification of the graph
+type Change struct {
+	Op          string      `json:"op"`                    // add_node | update_node | delete_node | add_edge | deploy
+	Node        *graph.Node `json:"node,omitempty"`        // add_node, update_node
+	ID          string      `json:"id,omitempty"`          // delete_node; deploy: application or service
+	From        string      `json:"from,omitempty"`        // add_edge
+	To          string      `json:"to,omitempty"`          // add_edge
+	Type        string      `json:"type,omitempty"`        // add_edge
+	Environment string      `json:"environment,omitempty"` // deploy
+	Description string      `json:"description,omitempty"`
+}
+
+// Request describes what to simulate: explicit changes, the steps of a stored plan, or both
+type Request struct {
+	Description string   `json:"description,omitempty"`
+	PlanID      string   `json:"plan_id,omitempty"`
+	Changes     []Change `json:"changes,omitempty"`
+}
+
+// Step is the outcome of applying one change to the fork
+type Step struct {
+	Change Change `json:"change"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Check is the outcome of a deployment check run against the fork
+type Check struct {
+	Name    string `json:"name"`    // transition_policy | resource_lifecycle | change_calendar
+	Subject string `json:"subject"` // application -> environment
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// Impact is what the simulated changes would modify and what depends on it
+type Impact struct {
+	Changes      []graphwatch.Change `json:"changes"`
+	Affected     []string            `json:"affected,omitempty"` // unchanged nodes that depend on a changed node
+	Applications []string            `json:"applications,omitempty"`
+	// CriticalPaths are the most critical depends_on and uses chains from unchanged nodes
+	// into changed ones, most critical first
+	CriticalPaths []graph.DependencyPath `json:"critical_paths,omitempty"`
+}
+
+// criticalPathLimit bounds the dependency chains an impact reports
+const criticalPathLimit = 5
+
+// Result is the outcome of a simulation
+type Result struct {
+	Description string    `json:"description,omitempty"`
+	PlanID      string    `json:"plan_id,omitempty"`
+	Steps       []Step    `json:"steps"`
+	Checks      []Check   `json:"checks,omitempty"`
+	Impact      Impact    `json:"impact"`
+	Safe        bool      `json:"safe"` // every change applied and no check blocked
+	Summary     string    `json:"summary"`
+	SimulatedAt time.Time `json:"simulated_at"`
+}
+
+// Service runs simulations against forks of the global graph
+type Service struct {
+	graph  *graph.GlobalGraph
+	plans  *plans.Service
+	logger *logging.Logger
+	now    func() time.Time
+}
+
+// NewService creates a sandbox forking globalGraph; planService may be nil when plans are not
+// stored, in which case requests by plan_id are rejected
+func NewService(globalGraph *graph.GlobalGraph, planService *plans.Service) *Service {
+	return &Service{
+		graph:  globalGraph,
+		plans:  planService,
+		logger: logging.GetLogger().ForComponent("sandbox"),
+		now:    time.Now,
+	}
+}
+
+// Fork returns an isolated copy of the current graph backed by memory. Writes to the fork go
+// through the same edge contracts and transition policies as writes to the global graph.
+func (s *Service) Fork() (*graph.GlobalGraph, error) {
+	current, err := s.graph.Graph()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph: %w", err)
+	}
+	fork := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	if err := fork.Backend.SaveGlobal(current.Clone()); err != nil {
+		return nil, fmt.Errorf("failed to fork graph: %w", err)
+	}
+	return fork, nil
+}
+
+// Simulate applies the request to a fork of the graph and reports what would happen
+func (s *Service) Simulate(req Request) (*Result, error) {
+	changes, err := s.changes(req)
+	if err != nil {
+		return nil, err
+	}
+	fork, err := s.Fork()
+	if err != nil {
+		return nil, err
+	}
+	before, err := fork.Graph()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fork: %w", err)
+	}
+	before = before.Clone()
+
+	result := &Result{Description: req.Description, PlanID: req.PlanID, Steps: []Step{}, SimulatedAt: s.now()}
+	for _, change := range changes {
+		step, checks := s.apply(fork, change)
+		result.Steps = append(result.Steps, step)
+		result.Checks = append(result.Checks, checks...)
+	}
+
+	after, err := fork.Graph()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fork: %w", err)
+	}
+	result.Impact = impact(before, after)
+	result.Safe = safe(result)
+	result.Summary = summarize(result)
+	s.logger.Info("🧪 Simulated %d changes: %s", len(changes), result.Summary)
+	return result, nil
+}
+
+// changes returns the plan's steps as changes followed by the explicit changes
+func (s *Service) changes(req Request) ([]Change, error) {
+	var changes []Change
+	if req.PlanID != "" {
+		if s.plans == nil {
+			return nil, fmt.Errorf("plan storage is not configured")
+		}
+		plan, err := s.plans.Get(req.PlanID)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, planChanges(plan)...)
+	}
+	for i, change := range req.Changes {
+		if err := validate(change); err != nil {
+			return nil, fmt.Errorf("%w: change %d: %v", ErrInvalidChange, i+1, err)
+		}
+		changes = append(changes, change)
+	}
+	if len(changes) == 0 {
+		return nil, ErrNothingToSimulate
+	}
+	return changes, nil
+}
+
+func validate(change Change) error {
+	switch change.Op {
+	case OpAddNode, OpUpdateNode:
+		if change.Node == nil || change.Node.ID == "" {
+			return fmt.Errorf("%s needs a node with an id", change.Op)
+		}
+	case OpDeleteNode:
+		if change.ID == "" {
+			return fmt.Errorf("delete_node needs an id")
+		}
+	case OpAddEdge:
+		if change.From == "" || change.To == "" || change.Type == "" {
+			return fmt.Errorf("add_edge needs from, to and type")
+		}
+	case OpDeploy:
+		if change.ID == "" || change.Environment == "" {
+			return fmt.Errorf("deploy needs an id and an environment")
+		}
+	default:
+		return fmt.Errorf("unknown op %q", change.Op)
+	}
+	return nil
+}
+
+// planChanges translates plan steps into changes. Steps without a graph effect the sandbox can
+// reproduce, such as validation or release creation, keep their action and are skipped.
+func planChanges(plan *plans.Plan) []Change {
+	changes := make([]Change, 0, len(plan.Steps))
+	for _, step := range plan.Steps {
+		change := Change{Op: step.Action, ID: step.Target, Description: step.Description}
+		switch step.Action {
+		case "deploy":
+			change.Op = OpDeploy
+			change.Environment = plan.Environment
+		case "delete", "remove", "decommission":
+			change.Op = OpDeleteNode
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// apply applies one change to the fork, running the deployment checks for deploys
+func (s *Service) apply(fork *graph.GlobalGraph, change Change) (Step, []Check) {
+	step := Step{Change: change, Status: StatusApplied}
+	var err error
+	switch change.Op {
+	case OpAddNode:
+		err = fork.AddNode(change.Node)
+	case OpUpdateNode:
+		err = fork.UpdateNode(change.Node)
+	case OpDeleteNode:
+		err = fork.DeleteNode(change.ID)
+	case OpAddEdge:
+		err = addEdge(fork, change.From, change.To, change.Type)
+	case OpDeploy:
+		var checks []Check
+		checks, err = s.deploy(fork, change.ID, change.Environment)
+		if err == nil && blocked(checks) {
+			step.Status = StatusBlocked
+			step.Error = "deployment checks failed"
+		}
+		if err != nil {
+			step.Status, step.Error = StatusFailed, err.Error()
+		}
+		return step, checks
+	default:
+		step.Status = StatusSkipped
+		step.Error = "not simulated"
+		return step, nil
+	}
+
+	var notSatisfied *graph.PolicyNotSatisfiedError
+	switch {
+	case errors.As(err, &notSatisfied):
+		step.Status, step.Error = StatusBlocked, err.Error()
+	case err != nil:
+		step.Status, step.Error = StatusFailed, err.Error()
+	}
+	return step, nil
+}
+
+// addEdge checks transition policies before adding an edge, so a refusal keeps its type
+func addEdge(fork *graph.GlobalGraph, from, to, edgeType string) error {
+	current, err := fork.Graph()
+	if err != nil {
+		return err
+	}
+	if err := current.IsTransitionAllowed(from, to, edgeType); err != nil {
+		return err
+	}
+	return fork.AddEdge(from, to, edgeType)
+}
+
+// deploy runs the deployment checks for the application owning target and, when none blocks,
+// records a simulated deployment edge to the environment as the deployment engine would
+func (s *Service) deploy(fork *graph.GlobalGraph, target, environment string) ([]Check, error) {
+	current, err := fork.Graph()
+	if err != nil {
+		return nil, err
+	}
+	appName, err := owningApplication(current, target)
+	if err != nil {
+		return nil, err
+	}
+	if node, ok := current.Nodes[environment]; !ok || node.Kind != graph.KindEnvironment {
+		return nil, fmt.Errorf("environment %s does not exist", environment)
+	}
+	subject := appName + " -> " + environment
+
+	checks := []Check{{Name: "transition_policy", Subject: subject, Status: CheckPassed}}
+	if err := current.IsTransitionAllowed(appName, environment, graph.EdgeTypeDeploy); err != nil {
+		checks[0].Status, checks[0].Message = CheckBlocked, err.Error()
+	}
+
+	lifecycle := Check{Name: "resource_lifecycle", Subject: subject, Status: CheckPassed}
+	warnings, err := resources.CheckDeployable(fork, appName)
+	switch {
+	case err != nil:
+		lifecycle.Status, lifecycle.Message = CheckBlocked, err.Error()
+	case len(warnings) > 0:
+		lifecycle.Status, lifecycle.Message = CheckWarning, strings.Join(warnings, "; ")
+	}
+	checks = append(checks, lifecycle)
+
+	window := Check{Name: "change_calendar", Subject: subject, Status: CheckPassed}
+	conflicts, err := calendar.NewService(fork).CheckDeployment(appName, environment, s.now())
+	switch {
+	case err != nil:
+		window.Status, window.Message = CheckWarning, fmt.Sprintf("calendar unavailable: %v", err)
+	case calendar.Blocking(conflicts) != nil:
+		window.Status, window.Message = CheckBlocked, calendar.Blocking(conflicts).Error()
+	case len(conflicts) > 0:
+		reasons := make([]string, 0, len(conflicts))
+		for _, conflict := range conflicts {
+			reasons = append(reasons, conflict.String())
+		}
+		window.Status, window.Message = CheckWarning, strings.Join(reasons, "; ")
+	}
+	checks = append(checks, window)
+
+	if blocked(checks) {
+		return checks, nil
+	}
+	current.Edges[appName] = append(current.Edges[appName], graph.Edge{
+		To:   environment,
+		Type: "deployment",
+		Metadata: map[string]interface{}{
+			"application": appName,
+			"status":      "simulated",
+		},
+	})
+	return checks, fork.Backend.SaveGlobal(current)
+}
+
+// owningApplication resolves a deploy target, an application or one of its services, to the
+// application
+func owningApplication(g *graph.Graph, target string) (string, error) {
+	node, ok := g.Nodes[target]
+	if !ok {
+		return "", fmt.Errorf("%s does not exist", target)
+	}
+	if node.Kind == graph.KindApplication {
+		return target, nil
+	}
+	for from, edges := range g.Edges {
+		for _, edge := range edges {
+			if edge.To == target && edge.Type == graph.EdgeTypeOwns && g.Nodes[from] != nil && g.Nodes[from].Kind == graph.KindApplication {
+				return from, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("%s is not an application or a service owned by one", target)
+}
+
+func blocked(checks []Check) bool {
+	for _, check := range checks {
+		if check.Status == CheckBlocked {
+			return true
+		}
+	}
+	return false
+}
+
+func safe(result *Result) bool {
+	for _, step := range result.Steps {
+		if step.Status != StatusApplied && step.Status != StatusSkipped {
+			return false
+		}
+	}
+	return !blocked(result.Checks)
+}
+
+// impact diffs the fork against its starting point and walks incoming edges from every changed
+// node to find what depends on it. Conversations referencing a node are not dependents.
+func impact(before, after *graph.Graph) Impact {
+	changes := graphwatch.Diff(before, after)
+	changed := map[string]bool{}
+	for _, change := range changes {
+		changed[change.ID] = true
+	}
+
+	incoming := map[string][]string{}
+	for _, g := range []*graph.Graph{before, after} {
+		for from, edges := range g.Edges {
+			for _, edge := range edges {
+				if edge.Type != graph.EdgeTypeReferences {
+					incoming[edge.To] = append(incoming[edge.To], from)
+				}
+			}
+		}
+	}
+	seen := map[string]bool{}
+	queue := make([]string, 0, len(changed))
+	for id := range changed {
+		seen[id] = true
+		queue = append(queue, id)
+	}
+	affected := []string{}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, from := range incoming[id] {
+			if seen[from] {
+				continue
+			}
+			seen[from] = true
+			queue = append(queue, from)
+			affected = append(affected, from)
+		}
+	}
+	sort.Strings(affected)
+
+	applications := []string{}
+	for id := range seen {
+		node := after.Nodes[id]
+		if node == nil {
+			node = before.Nodes[id]
+		}
+		if node != nil && node.Kind == graph.KindApplication {
+			applications = append(applications, id)
+		}
+	}
+	sort.Strings(applications)
+	return Impact{Changes: changes, Affected: affected, Applications: applications, CriticalPaths: criticalPaths(before, after, changes)}
+}
+
+// criticalPaths ranks the dependency chains reaching the added, updated or removed nodes,
+// keeping the most critical chain to each dependent. Removed nodes are traversed in the graph
+// they left, with the edges removed along with them.
+func criticalPaths(before, after *graph.Graph, changes []graphwatch.Change) []graph.DependencyPath {
+	changed := map[string]bool{}
+	for _, change := range changes {
+		if change.Kind == graphwatch.KindNode {
+			changed[change.ID] = true
+		}
+	}
+	best := map[string]graph.DependencyPath{}
+	for id := range changed {
+		g := after
+		if _, ok := after.Nodes[id]; !ok {
+			g = before
+		}
+		paths, err := g.DependencyPaths(id, graph.DependencyDirectionIn, 0, 0)
+		if err != nil {
+			continue
+		}
+		for _, path := range paths {
+			if changed[path.Target] {
+				continue
+			}
+			if current, ok := best[path.Target]; !ok || path.Criticality > current.Criticality {
+				best[path.Target] = path
+			}
+		}
+	}
+	paths := make([]graph.DependencyPath, 0, len(best))
+	for _, path := range best {
+		paths = append(paths, path)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		if paths[i].Criticality != paths[j].Criticality {
+			return paths[i].Criticality > paths[j].Criticality
+		}
+		return paths[i].Target < paths[j].Target
+	})
+	if len(paths) > criticalPathLimit {
+		paths = paths[:criticalPathLimit]
+	}
+	return paths
+}
+
+func summarize(result *Result) string {
+	counts := map[string]int{}
+	for _, step := range result.Steps {
+		counts[step.Status]++
+	}
+	parts := []string{fmt.Sprintf("%d of %d changes would apply", counts[StatusApplied], len(result.Steps))}
+	for _, status := range []string{StatusBlocked, StatusFailed, StatusSkipped} {
+		if counts[status] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[status], status))
+		}
+	}
+	summary := strings.Join(parts, ", ")
+	summary += fmt.Sprintf("; %d graph changes", len(result.Impact.Changes))
+	if len(result.Impact.Affected) > 0 {
+		summary += fmt.Sprintf(", %d dependent nodes affected", len(result.Impact.Affected))
+	}
+	if len(result.Impact.Applications) > 0 {
+		summary += " in " + strings.Join(result.Impact.Applications, ", ")
+	}
+	return summary
+}
+
+// Describe formats the result for chat
+func (r *Result) Describe() string {
+	var b strings.Builder
+	if r.Safe {
+		b.WriteString("✅ Safe to proceed: " + r.Summary + "\n")
+	} else {
+		b.WriteString("⛔ Not safe as is: " + r.Summary + "\n")
+	}
+	for i, step := range r.Steps {
+		target := step.Change.ID
+		if step.Change.Node != nil {
+			target = step.Change.Node.ID
+		}
+		if step.Change.Op == OpAddEdge {
+			target = fmt.Sprintf("%s -[%s]-> %s", step.Change.From, step.Change.Type, step.Change.To)
+		}
+		if step.Change.Environment != "" {
+			target += " to " + step.Change.Environment
+		}
+		fmt.Fprintf(&b, "%d. %s %s: %s", i+1, step.Change.Op, target, step.Status)
+		if step.Error != "" {
+			b.WriteString(" (" + step.Error + ")")
+		}
+		b.WriteString("\n")
+	}
+	for _, check := range r.Checks {
+		if check.Status != CheckPassed {
+			fmt.Fprintf(&b, "- %s %s for %s: %s\n", check.Name, check.Status, check.Subject, check.Message)
+		}
+	}
+	if len(r.Impact.Affected) > 0 {
+		b.WriteString("Affected: " + strings.Join(r.Impact.Affected, ", ") + "\n")
+	}
+	if len(r.Impact.CriticalPaths) > 0 {
+		b.WriteString("Most critical dependency chains:\n")
+		for _, path := range r.Impact.CriticalPaths {
+			fmt.Fprintf(&b, "- %s (criticality %.2f)\n", strings.Join(path.Path, " <- "), path.Criticality)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,238 @@
+package sandbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+	"github.com/krzachariassen/ZTDP/internal/ai"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/features"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/krzachariassen/ZTDP/internal/plans"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
+)
+
+// SimulationRequest is what the AI extracts from a "what would happen if" message
+type SimulationRequest struct {
+	Request
+	UsePlan       bool    `json:"use_plan"` // simulate the plan proposed earlier in the conversation
+	Confidence    float64 `json:"confidence"`
+	Clarification string  `json:"clarification,omitempty"`
+}
+
+// SandboxAgent answers "what would happen if" questions by simulating them in a sandbox
+type SandboxAgent struct {
+	graph      *graph.GlobalGraph
+	service    *Service
+	plans      *plans.Service
+	aiProvider ai.AIProvider
+	logger     *logging.Logger
+}
+
+// NewSandboxAgent creates the sandbox agent; planService may be nil
+func NewSandboxAgent(
+	globalGraph *graph.GlobalGraph,
+	service *Service,
+	planService *plans.Service,
+	aiProvider ai.AIProvider,
+	eventBus *events.EventBus,
+	registry agentRegistry.AgentRegistry,
+) (agentRegistry.AgentInterface, error) {
+	if service == nil {
+		return nil, fmt.Errorf("sandbox service is required")
+	}
+	if aiProvider == nil {
+		return nil, fmt.Errorf("aiProvider is required for AI-native agent")
+	}
+	if eventBus == nil {
+		return nil, fmt.Errorf("eventBus is required")
+	}
+	if registry == nil {
+		return nil, fmt.Errorf("registry is required")
+	}
+
+	wrapper := &SandboxAgent{
+		graph:      globalGraph,
+		service:    service,
+		plans:      planService,
+		aiProvider: aiProvider,
+		logger:     logging.GetLogger().ForComponent("sandbox-agent"),
+	}
+
+	agent, err := agentframework.NewAgent("sandbox-agent").
+		WithType("sandbox").
+		WithCapabilities(getSandboxCapabilities()).
+		WithEventHandler(wrapper.handleEvent).
+		Build(agentframework.AgentDependencies{
+			Registry: registry,
+			EventBus: eventBus,
+			Flags:    features.NewService(globalGraph),
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build sandbox agent: %w", err)
+	}
+
+	wrapper.logger.Info("✅ SandboxAgent created successfully")
+	return agent, nil
+}
+
+// getSandboxCapabilities returns the capabilities for the sandbox agent
+func getSandboxCapabilities() []agentRegistry.AgentCapability {
+	return []agentRegistry.AgentCapability{
+		{
+			Name:        "change_simulation",
+			Description: "Simulates a hypothetical change or plan on an isolated copy of the graph and reports policy results and impact without changing anything",
+			Intents: []string{
+				"what would happen if", "what if", "simulate change", "simulate plan", "dry run", "predict impact",
+			},
+			InputTypes:  []string{"user_message"},
+			OutputTypes: []string{"simulation"},
+			RoutingKeys: []string{"sandbox.simulate", "sandbox.request"},
+			Version:     "1.0.0",
+		},
+	}
+}
+
+// handleEvent extracts the hypothetical change from the message and simulates it
+func (a *SandboxAgent) handleEvent(ctx context.Context, event *events.Event) (*events.Event, error) {
+	userMessage, ok := event.Payload["user_message"].(string)
+	if !ok || userMessage == "" {
+		return a.createErrorResponse(event, "user_message field is required in event payload"), nil
+	}
+
+	request, err := a.extractRequest(ctx, userMessage)
+	if err != nil {
+		return a.createErrorResponse(event, fmt.Sprintf("I couldn't understand what to simulate: %v", err)), nil
+	}
+	if agentframework.NeedsClarification(ctx, request.Confidence) {
+		clarification := request.Clarification
+		if clarification == "" {
+			clarification = "Which change should I simulate, e.g. deploying an application to an environment or removing a resource?"
+		}
+		return agentframework.ClarificationResponse(ctx, event, clarification, request.Confidence), nil
+	}
+	if request.UsePlan && request.PlanID == "" && a.plans != nil {
+		plan, err := a.plans.Latest(features.EvaluationContextFrom(ctx).ConversationID)
+		if errors.Is(err, plans.ErrPlanNotFound) {
+			return a.createErrorResponse(event, "there is no plan in this conversation to simulate"), nil
+		}
+		if err != nil {
+			return a.createErrorResponse(event, err.Error()), nil
+		}
+		request.PlanID = plan.ID
+	}
+
+	result, err := a.service.Simulate(request.Request)
+	if err != nil {
+		return a.createErrorResponse(event, fmt.Sprintf("I couldn't simulate that: %v", err)), nil
+	}
+	message := "🧪 Simulated on a copy of the graph, nothing was changed.\n\n" + result.Describe()
+	return a.createSuccessResponse(event, message, result), nil
+}
+
+// extractRequest asks the AI to express the hypothetical change as sandbox changes
+func (a *SandboxAgent) extractRequest(ctx context.Context, userMessage string) (*SimulationRequest, error) {
+	systemPrompt := `The user asks what would happen if a change were made to the platform. Express the change as JSON:
+{"description": "", "use_plan": false, "plan_id": "", "changes": [], "confidence": 0.0, "clarification": ""}
+
+Each change is one of:
+- {"op": "deploy", "id": "<application or service>", "environment": "<environment>"}
+- {"op": "delete_node", "id": "<node id>"}
+- {"op": "add_edge", "from": "<node id>", "to": "<node id>", "type": "owns|uses|accesses|depends_on|consumes|allowed_in"}
+- {"op": "add_node", "node": {"id": "", "kind": "", "metadata": {"name": ""}, "spec": {}}}
+- {"op": "update_node", "node": {...the full node with the hypothetical values...}}
+
+Rules:
+- Use node IDs from the list below; do not invent existing nodes
+- Set use_plan when the user asks about "the plan" or "this plan"; set plan_id only when they name one such as plan-123
+- Set confidence below 0.7 and explain in clarification when the change is ambiguous
+
+Respond with JSON only.
+
+Nodes:
+` + a.nodeSummary()
+
+	response, err := a.aiProvider.CallAI(ai.WithTask(ctx, ai.TaskExtraction), systemPrompt, userMessage)
+	if err != nil {
+		return nil, err
+	}
+
+	cleaned := strings.TrimSpace(response)
+	cleaned = strings.TrimPrefix(cleaned, "```json")
+	cleaned = strings.TrimPrefix(cleaned, "```")
+	cleaned = strings.TrimSuffix(cleaned, "```")
+
+	var request SimulationRequest
+	if err := json.Unmarshal([]byte(strings.TrimSpace(cleaned)), &request); err != nil {
+		return nil, fmt.Errorf("failed to parse AI response: %w", err)
+	}
+	if request.Description == "" {
+		request.Description = userMessage
+	}
+	a.logger.Info("🤖 AI extracted %d hypothetical changes, confidence: %.2f", len(request.Changes), request.Confidence)
+	return &request, nil
+}
+
+// nodeSummary lists the platform entities the AI may refer to, grouped by kind
+func (a *SandboxAgent) nodeSummary() string {
+	nodes, err := a.graph.Nodes()
+	if err != nil {
+		return "(unavailable)"
+	}
+	byKind := map[string][]string{}
+	for id, node := range nodes {
+		switch node.Kind {
+		case graph.KindApplication, graph.KindService, graph.KindEnvironment, graph.KindResource:
+			byKind[node.Kind] = append(byKind[node.Kind], id)
+		}
+	}
+	kinds := make([]string, 0, len(byKind))
+	for kind := range byKind {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	var b strings.Builder
+	for _, kind := range kinds {
+		sort.Strings(byKind[kind])
+		fmt.Fprintf(&b, "- %s: %s\n", kind, strings.Join(byKind[kind], ", "))
+	}
+	return b.String()
+}
+
+func (a *SandboxAgent) createSuccessResponse(originalEvent *events.Event, message string, result *Result) *events.Event {
+	return &events.Event{
+		ID:        fmt.Sprintf("sandbox-response-%d", time.Now().UnixNano()),
+		Type:      events.EventTypeResponse,
+		Subject:   "sandbox.response",
+		Source:    "sandbox-agent",
+		Timestamp: time.Now().Unix(),
+		Payload: map[string]interface{}{
+			"status":         "success",
+			"message":        message,
+			"simulation":     result,
+			"correlation_id": originalEvent.Payload["correlation_id"],
+		},
+	}
+}
+
+func (a *SandboxAgent) createErrorResponse(originalEvent *events.Event, errorMessage string) *events.Event {
+	return &events.Event{
+		ID:        fmt.Sprintf("sandbox-error-%d", time.Now().UnixNano()),
+		Type:      events.EventTypeResponse,
+		Subject:   "sandbox.error",
+		Source:    "sandbox-agent",
+		Timestamp: time.Now().Unix(),
+		Payload: map[string]interface{}{
+			"status":         "error",
+			"error":          errorMessage,
+			"correlation_id": originalEvent.Payload["correlation_id"],
+		},
+	}
+}
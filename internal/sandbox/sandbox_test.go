@@ -0,0 +1,183 @@
+package sandbox
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+	"github.com/krzachariassen/ZTDP/internal/ai/aitest"
+	"github.com/krzachariassen/ZTDP/internal/calendar"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/plans"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSandboxTestGraph(t *testing.T) *graph.GlobalGraph {
+	t.Helper()
+	g := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	add := func(id, kind string) {
+		require.NoError(t, g.AddNode(&graph.Node{ID: id, Kind: kind, Metadata: map[string]interface{}{"name": id}, Spec: map[string]interface{}{}}))
+	}
+	add("checkout", graph.KindApplication)
+	add("checkout-api", graph.KindService)
+	add("payments", graph.KindApplication)
+	add("payments-api", graph.KindService)
+	add("prod", graph.KindEnvironment)
+	for _, edge := range [][3]string{
+		{"checkout", "checkout-api", graph.EdgeTypeOwns},
+		{"payments", "payments-api", graph.EdgeTypeOwns},
+		{"payments-api", "checkout-api", graph.EdgeTypeConsumes},
+	} {
+		require.NoError(t, g.AddEdge(edge[0], edge[1], edge[2]))
+	}
+	return g
+}
+
+func TestSimulate_PredictsImpactWithoutTouchingTheGraph(t *testing.T) {
+	g := newSandboxTestGraph(t)
+	before, err := g.Graph()
+	require.NoError(t, err)
+	before = before.Clone()
+
+	result, err := NewService(g, nil).Simulate(Request{Changes: []Change{
+		{Op: OpDeleteNode, ID: "checkout-api"},
+		{Op: OpDeploy, ID: "checkout", Environment: "prod"},
+	}})
+	require.NoError(t, err)
+
+	after, err := g.Graph()
+	require.NoError(t, err)
+	assert.Equal(t, before, after, "the live graph must not change")
+
+	require.Len(t, result.Steps, 2)
+	assert.Equal(t, StatusApplied, result.Steps[0].Status)
+	assert.Equal(t, StatusApplied, result.Steps[1].Status)
+	assert.True(t, result.Safe)
+	assert.Equal(t, []string{"payments"}, result.Impact.Affected, "checkout and payments-api changed; payments depends on payments-api")
+	assert.Equal(t, []string{"checkout", "payments"}, result.Impact.Applications)
+	assert.Contains(t, result.Summary, "2 of 2 changes would apply")
+}
+
+func TestSimulate_RanksCriticalDependencyChains(t *testing.T) {
+	g := newSandboxTestGraph(t)
+	current, err := g.Graph()
+	require.NoError(t, err)
+	for id, criticality := range map[string]string{"orders-api": graph.CriticalityCritical, "search-api": graph.CriticalityLow} {
+		current.Nodes[id] = &graph.Node{ID: id, Kind: graph.KindService, Metadata: map[string]interface{}{"name": id}, Spec: map[string]interface{}{}}
+		current.Edges[id] = []graph.Edge{{To: "checkout-api", Type: graph.EdgeTypeDependsOn, Metadata: map[string]interface{}{graph.EdgeCriticalityKey: criticality}}}
+	}
+	require.NoError(t, g.Save())
+
+	result, err := NewService(g, nil).Simulate(Request{Changes: []Change{{Op: OpDeleteNode, ID: "checkout-api"}}})
+	require.NoError(t, err)
+	require.Len(t, result.Impact.CriticalPaths, 2)
+	assert.Equal(t, []string{"checkout-api", "orders-api"}, result.Impact.CriticalPaths[0].Path)
+	assert.Equal(t, "search-api", result.Impact.CriticalPaths[1].Target)
+	assert.Contains(t, result.Describe(), "- checkout-api <- orders-api (criticality 1.00)")
+}
+
+func TestSimulate_ReportsBlockedDeploymentChecks(t *testing.T) {
+	g := newSandboxTestGraph(t)
+	require.NoError(t, g.AddNode(&graph.Node{ID: "orders-db", Kind: graph.KindResource, Metadata: map[string]interface{}{"name": "orders-db"}, Spec: map[string]interface{}{}}))
+	current, err := g.Graph()
+	require.NoError(t, err)
+	current.Edges["checkout"] = append(current.Edges["checkout"], graph.Edge{To: "orders-db", Type: graph.EdgeTypeOwns})
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	_, err = calendar.NewService(g).Schedule(calendar.Entry{Type: calendar.TypeFreeze, Environment: "prod", Start: now.Add(-time.Hour), End: now.Add(time.Hour), Reason: "quarter close"}, false)
+	require.NoError(t, err)
+
+	service := NewService(g, nil)
+	service.now = func() time.Time { return now }
+	result, err := service.Simulate(Request{Changes: []Change{
+		{Op: OpUpdateNode, Node: &graph.Node{ID: "orders-db", Kind: graph.KindResource, Metadata: map[string]interface{}{"name": "orders-db", "lifecycle_state": "maintenance"}, Spec: map[string]interface{}{}}},
+		{Op: OpAddEdge, From: "checkout", To: "prod", Type: "allowed_in"},
+		{Op: OpDeploy, ID: "checkout-api", Environment: "prod"},
+	}})
+	require.NoError(t, err)
+
+	assert.False(t, result.Safe)
+	assert.Equal(t, StatusApplied, result.Steps[0].Status)
+	assert.Equal(t, StatusApplied, result.Steps[1].Status)
+	assert.Equal(t, StatusBlocked, result.Steps[2].Status)
+	statuses := map[string]string{}
+	for _, check := range result.Checks {
+		assert.Equal(t, "checkout -> prod", check.Subject, "services deploy with their application")
+		statuses[check.Name] = check.Status
+	}
+	assert.Equal(t, map[string]string{
+		"transition_policy":  CheckPassed,
+		"resource_lifecycle": CheckBlocked,
+		"change_calendar":    CheckBlocked,
+	}, statuses)
+	for _, change := range result.Impact.Changes {
+		assert.NotEqual(t, "deployment", change.Type, "blocked deployments are not recorded")
+	}
+}
+
+func TestSimulate_TranslatesPlanSteps(t *testing.T) {
+	g := newSandboxTestGraph(t)
+	planService := plans.NewService(g, nil)
+	plan, err := planService.Propose(plans.Plan{
+		Goal:        "deploy checkout to prod",
+		Application: "checkout",
+		Environment: "prod",
+		Steps: []plans.Step{
+			{Action: "validate", Target: "checkout"},
+			{Action: "deploy", Target: "checkout-api"},
+			{Action: "deploy", Target: "missing-api"},
+		},
+	})
+	require.NoError(t, err)
+
+	result, err := NewService(g, planService).Simulate(Request{PlanID: plan.ID})
+	require.NoError(t, err)
+	require.Len(t, result.Steps, 3)
+	assert.Equal(t, StatusSkipped, result.Steps[0].Status)
+	assert.Equal(t, StatusApplied, result.Steps[1].Status)
+	assert.Equal(t, StatusFailed, result.Steps[2].Status)
+	assert.False(t, result.Safe)
+	assert.Contains(t, result.Describe(), "deploy checkout-api to prod: applied")
+
+	_, err = NewService(g, planService).Simulate(Request{PlanID: "plan-missing"})
+	assert.ErrorIs(t, err, plans.ErrPlanNotFound)
+}
+
+func TestSimulate_RejectsMalformedRequests(t *testing.T) {
+	service := NewService(newSandboxTestGraph(t), nil)
+
+	_, err := service.Simulate(Request{})
+	assert.ErrorIs(t, err, ErrNothingToSimulate)
+	_, err = service.Simulate(Request{Changes: []Change{{Op: OpAddEdge, From: "checkout"}}})
+	assert.ErrorIs(t, err, ErrInvalidChange)
+	_, err = service.Simulate(Request{Changes: []Change{{Op: "rename"}}})
+	assert.ErrorIs(t, err, ErrInvalidChange)
+}
+
+func TestSandboxAgentAnswersWhatIf(t *testing.T) {
+	g := newSandboxTestGraph(t)
+	provider := aitest.Respond("```json\n" + `{"changes": [{"op": "delete_node", "id": "checkout-api"}], "confidence": 0.9}` + "\n```")
+	agent, err := NewSandboxAgent(g, NewService(g, nil), nil, provider, events.NewEventBus(nil, false), agentRegistry.NewInMemoryAgentRegistry())
+	require.NoError(t, err)
+
+	response, err := agent.(*agentframework.BaseAgent).ProcessEvent(context.Background(), &events.Event{
+		Subject: "sandbox.simulate",
+		Payload: map[string]interface{}{
+			"user_message":   "what would happen if we removed checkout-api?",
+			"correlation_id": "corr-1",
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "success", response.Payload["status"], response.Payload["error"])
+	assert.Contains(t, response.Payload["message"], "nothing was changed")
+	assert.Contains(t, response.Payload["message"], "Affected: payments")
+	result := response.Payload["simulation"].(*Result)
+	assert.Equal(t, "what would happen if we removed checkout-api?", result.Description)
+
+	node, err := g.GetNode("checkout-api")
+	require.NoError(t, err)
+	assert.NotNil(t, node, "the simulated delete must not reach the live graph")
+}
@@ -0,0 +1,238 @@
+// Package search finds platform objects by kind, tag, owner and free text, and stores named
+// searches per user so the UI and the chat agent can run them again.
+package search
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// ErrSavedSearchNotFound is returned when a user has no saved search with the given name
+var ErrSavedSearchNotFound = errors.New("saved search not found")
+
+// savedNodePrefix namespaces saved search nodes so they cannot collide with application names
+const savedNodePrefix = "saved-search:"
+
+// DefaultLimit caps results when a query does not set a limit
+const DefaultLimit = 50
+
+// searchableKinds are searched when a query names no kinds; platform bookkeeping such as
+// plans, quotas and feature flags is only returned when asked for explicitly
+var searchableKinds = []string{
+	graph.KindApplication,
+	graph.KindService,
+	graph.KindEnvironment,
+	graph.KindResource,
+	graph.KindResourceType,
+	graph.KindPolicy,
+}
+
+// Query filters platform objects. Every set filter must match; tags must all be present.
+type Query struct {
+	Kinds []string `json:"kinds,omitempty"`
+	Tags  []string `json:"tags,omitempty"`
+	Owner string   `json:"owner,omitempty"`
+	Text  string   `json:"text,omitempty"` // matched against name and description, case-insensitively
+	Limit int      `json:"limit,omitempty"`
+}
+
+// Result is a matching node
+type Result struct {
+	ID          string   `json:"id"`
+	Kind        string   `json:"kind"`
+	Name        string   `json:"name"`
+	Owner       string   `json:"owner,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Description string   `json:"description,omitempty"`
+}
+
+// Results is a page of matches; Total counts every match before the limit
+type Results struct {
+	Query   Query    `json:"query"`
+	Total   int      `json:"total"`
+	Results []Result `json:"results"`
+}
+
+// SavedSearch is a query a user stored under a name
+type SavedSearch struct {
+	User      string    `json:"user"`
+	Name      string    `json:"name"`
+	Query     Query     `json:"query"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Service runs searches against the global graph and stores saved searches in it
+type Service struct {
+	graph  *graph.GlobalGraph
+	logger *logging.Logger
+}
+
+// NewService creates a search service backed by the global graph
+func NewService(globalGraph *graph.GlobalGraph) *Service {
+	return &Service{
+		graph:  globalGraph,
+		logger: logging.GetLogger().ForComponent("search"),
+	}
+}
+
+// Search returns the nodes matching the query, sorted by kind and name
+func (s *Service) Search(query Query) (*Results, error) {
+	nodes, err := s.graph.Nodes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph: %w", err)
+	}
+	kinds := query.Kinds
+	if len(kinds) == 0 {
+		kinds = searchableKinds
+	}
+
+	text := strings.ToLower(strings.TrimSpace(query.Text))
+	results := []Result{}
+	for _, node := range graph.NewIndex(nodes).Lookup(kinds, query.Owner, query.Tags) {
+		result := toResult(node)
+		if text != "" && !strings.Contains(strings.ToLower(result.Name), text) &&
+			!strings.Contains(strings.ToLower(result.Description), text) {
+			continue
+		}
+		results = append(results, result)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Kind != results[j].Kind {
+			return results[i].Kind < results[j].Kind
+		}
+		return results[i].Name < results[j].Name
+	})
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	page := &Results{Query: query, Total: len(results), Results: results}
+	if len(results) > limit {
+		page.Results = results[:limit]
+	}
+	return page, nil
+}
+
+func toResult(node *graph.Node) Result {
+	result := Result{ID: node.ID, Kind: node.Kind, Name: node.ID, Tags: graph.NodeTags(node)}
+	if name, ok := node.Metadata["name"].(string); ok && name != "" {
+		result.Name = name
+	}
+	result.Owner, _ = node.Metadata["owner"].(string)
+	if description, ok := node.Spec["description"].(string); ok {
+		result.Description = description
+	} else {
+		result.Description, _ = node.Metadata["description"].(string)
+	}
+	return result
+}
+
+// Save stores a query under a name for the user, replacing any search with the same name
+func (s *Service) Save(user, name string, query Query) (*SavedSearch, error) {
+	if user == "" || name == "" {
+		return nil, fmt.Errorf("user and name are required")
+	}
+	saved := &SavedSearch{User: user, Name: name, Query: query, UpdatedAt: time.Now().UTC()}
+
+	data, err := json.Marshal(saved)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode saved search: %w", err)
+	}
+	var spec map[string]interface{}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to encode saved search: %w", err)
+	}
+	node := &graph.Node{
+		ID:       savedNodeID(user, name),
+		Kind:     graph.KindSavedSearch,
+		Metadata: map[string]interface{}{"name": name, "user": user},
+		Spec:     spec,
+	}
+	if existing, _ := s.graph.GetNode(node.ID); existing != nil {
+		if err := s.graph.UpdateNode(node); err != nil {
+			return nil, fmt.Errorf("failed to update saved search: %w", err)
+		}
+	} else {
+		s.graph.AddNode(node)
+	}
+	if err := s.graph.Save(); err != nil {
+		return nil, fmt.Errorf("failed to save search: %w", err)
+	}
+	s.logger.Info("🔖 Saved search %q for %s", name, user)
+	return saved, nil
+}
+
+// Saved returns one of the user's saved searches
+func (s *Service) Saved(user, name string) (*SavedSearch, error) {
+	node, _ := s.graph.GetNode(savedNodeID(user, name))
+	if node == nil || node.Kind != graph.KindSavedSearch {
+		return nil, ErrSavedSearchNotFound
+	}
+	return nodeToSaved(node)
+}
+
+// ListSaved returns the user's saved searches sorted by name
+func (s *Service) ListSaved(user string) ([]*SavedSearch, error) {
+	nodes, err := s.graph.Nodes()
+	if err != nil {
+		return nil, err
+	}
+	saved := []*SavedSearch{}
+	for _, node := range nodes {
+		if node.Kind != graph.KindSavedSearch || node.Metadata["user"] != user {
+			continue
+		}
+		search, err := nodeToSaved(node)
+		if err != nil {
+			s.logger.Warn("⚠️ Skipping malformed saved search node %s: %v", node.ID, err)
+			continue
+		}
+		saved = append(saved, search)
+	}
+	sort.Slice(saved, func(i, j int) bool { return saved[i].Name < saved[j].Name })
+	return saved, nil
+}
+
+// RunSaved runs one of the user's saved searches
+func (s *Service) RunSaved(user, name string) (*Results, error) {
+	saved, err := s.Saved(user, name)
+	if err != nil {
+		return nil, err
+	}
+	return s.Search(saved.Query)
+}
+
+// DeleteSaved removes one of the user's saved searches
+func (s *Service) DeleteSaved(user, name string) error {
+	if _, err := s.Saved(user, name); err != nil {
+		return err
+	}
+	if err := s.graph.DeleteNode(savedNodeID(user, name)); err != nil {
+		return fmt.Errorf("failed to delete saved search: %w", err)
+	}
+	return s.graph.Save()
+}
+
+func savedNodeID(user, name string) string {
+	return savedNodePrefix + user + ":" + strings.ToLower(name)
+}
+
+func nodeToSaved(node *graph.Node) (*SavedSearch, error) {
+	data, err := json.Marshal(node.Spec)
+	if err != nil {
+		return nil, err
+	}
+	var saved SavedSearch
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
@@ -0,0 +1,262 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+	"github.com/krzachariassen/ZTDP/internal/ai"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/features"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
+)
+
+// SearchRequest is the structure the AI extracts from a search request
+type SearchRequest struct {
+	Action string `json:"action"` // search | save | run | list
+	Query  Query  `json:"query"`
+	// OwnerIsMe is set for requests like "my apps"; the owner becomes the calling user
+	OwnerIsMe     bool    `json:"owner_is_me,omitempty"`
+	Name          string  `json:"name,omitempty"` // saved search name for save and run
+	Confidence    float64 `json:"confidence"`
+	Clarification string  `json:"clarification,omitempty"`
+}
+
+// SearchAgent answers questions such as "show me my payments-tagged apps" with searches and
+// saved searches
+type SearchAgent struct {
+	service    *Service
+	aiProvider ai.AIProvider
+	logger     *logging.Logger
+}
+
+// NewSearchAgent creates the search agent
+func NewSearchAgent(
+	globalGraph *graph.GlobalGraph,
+	service *Service,
+	aiProvider ai.AIProvider,
+	eventBus *events.EventBus,
+	registry agentRegistry.AgentRegistry,
+) (agentRegistry.AgentInterface, error) {
+	if service == nil {
+		return nil, fmt.Errorf("search service is required")
+	}
+	if aiProvider == nil {
+		return nil, fmt.Errorf("aiProvider is required for AI-native agent")
+	}
+	if eventBus == nil {
+		return nil, fmt.Errorf("eventBus is required")
+	}
+	if registry == nil {
+		return nil, fmt.Errorf("registry is required")
+	}
+
+	wrapper := &SearchAgent{
+		service:    service,
+		aiProvider: aiProvider,
+		logger:     logging.GetLogger().ForComponent("search-agent"),
+	}
+
+	agent, err := agentframework.NewAgent("search-agent").
+		WithType("search").
+		WithCapabilities(getSearchCapabilities()).
+		WithEventHandler(wrapper.handleEvent).
+		Build(agentframework.AgentDependencies{
+			Registry: registry,
+			EventBus: eventBus,
+			Flags:    features.NewService(globalGraph),
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build search agent: %w", err)
+	}
+
+	wrapper.logger.Info("✅ SearchAgent created successfully")
+	return agent, nil
+}
+
+// getSearchCapabilities returns the capabilities for the search agent
+func getSearchCapabilities() []agentRegistry.AgentCapability {
+	return []agentRegistry.AgentCapability{
+		{
+			Name:        "search",
+			Description: "Finds applications, services, environments and resources by tag, owner, kind or text, and saves searches for reuse",
+			Intents: []string{
+				"search", "find by tag", "show tagged", "saved search", "save search",
+			},
+			InputTypes:  []string{"user_message"},
+			OutputTypes: []string{"search_results", "saved_search"},
+			RoutingKeys: []string{"search.query", "search.saved", "search.request"},
+			Version:     "1.0.0",
+		},
+	}
+}
+
+// handleEvent extracts the search request with AI and runs it
+func (a *SearchAgent) handleEvent(ctx context.Context, event *events.Event) (*events.Event, error) {
+	userMessage, ok := event.Payload["user_message"].(string)
+	if !ok || userMessage == "" {
+		return a.createErrorResponse(event, "user_message field is required in event payload"), nil
+	}
+	user := logging.UserIDFromContext(ctx)
+
+	request, err := a.extractRequest(ctx, userMessage, user)
+	if err != nil {
+		return a.createErrorResponse(event, fmt.Sprintf("I couldn't understand the search: %v", err)), nil
+	}
+	if agentframework.NeedsClarification(ctx, request.Confidence) {
+		clarification := request.Clarification
+		if clarification == "" {
+			clarification = "What should I look for? You can search by kind, tag, owner or name."
+		}
+		return agentframework.ClarificationResponse(ctx, event, clarification, request.Confidence), nil
+	}
+	if request.OwnerIsMe {
+		if user == "" {
+			return a.createErrorResponse(event, "I don't know who you are; pass your user with the chat request to search your own objects"), nil
+		}
+		request.Query.Owner = user
+	}
+
+	switch request.Action {
+	case "search":
+		results, err := a.service.Search(request.Query)
+		if err != nil {
+			return a.createErrorResponse(event, fmt.Sprintf("Search failed: %v", err)), nil
+		}
+		return a.createSuccessResponse(event, describeResults(results), map[string]interface{}{"results": results}), nil
+	case "run":
+		results, err := a.service.RunSaved(user, request.Name)
+		if errors.Is(err, ErrSavedSearchNotFound) {
+			return a.createErrorResponse(event, fmt.Sprintf("You have no saved search called %q", request.Name)), nil
+		}
+		if err != nil {
+			return a.createErrorResponse(event, fmt.Sprintf("Search failed: %v", err)), nil
+		}
+		return a.createSuccessResponse(event, describeResults(results), map[string]interface{}{"results": results}), nil
+	case "save":
+		saved, err := a.service.Save(user, request.Name, request.Query)
+		if err != nil {
+			return a.createErrorResponse(event, fmt.Sprintf("Failed to save the search: %v", err)), nil
+		}
+		return a.createSuccessResponse(event, fmt.Sprintf("🔖 Saved search %q", saved.Name), map[string]interface{}{"saved_search": saved}), nil
+	case "list":
+		saved, err := a.service.ListSaved(user)
+		if err != nil {
+			return a.createErrorResponse(event, fmt.Sprintf("Failed to list saved searches: %v", err)), nil
+		}
+		names := make([]string, 0, len(saved))
+		for _, search := range saved {
+			names = append(names, search.Name)
+		}
+		message := "You have no saved searches"
+		if len(names) > 0 {
+			message = "Your saved searches: " + strings.Join(names, ", ")
+		}
+		return a.createSuccessResponse(event, message, map[string]interface{}{"saved_searches": saved}), nil
+	default:
+		return a.createErrorResponse(event, fmt.Sprintf("I can search, save, run or list searches, not %q", request.Action)), nil
+	}
+}
+
+// extractRequest asks the AI to turn the user message into a SearchRequest. The user's saved
+// search names go into the system prompt so the AI can pick one instead of a new search.
+func (a *SearchAgent) extractRequest(ctx context.Context, userMessage, user string) (*SearchRequest, error) {
+	savedNames := "none"
+	if user != "" {
+		if saved, err := a.service.ListSaved(user); err == nil && len(saved) > 0 {
+			names := make([]string, 0, len(saved))
+			for _, search := range saved {
+				names = append(names, search.Name)
+			}
+			savedNames = strings.Join(names, ", ")
+		}
+	}
+
+	systemPrompt := fmt.Sprintf(`You translate search requests for a developer platform into JSON:
+{"action": "search|save|run|list", "query": {"kinds": [], "tags": [], "owner": "", "text": ""}, "owner_is_me": false, "name": "", "confidence": 0.0, "clarification": ""}
+
+Rules:
+- kinds are application, service, environment, resource, resource_type or policy ("apps" means application)
+- "tagged X" or "X-tagged" puts X in tags; every tag must match
+- "my" or "mine" sets owner_is_me instead of owner
+- text is a word to find in names or descriptions
+- run reuses one of the user's saved searches by name; save stores the query under name
+- The user's saved searches: %s
+
+Respond with JSON only.`, savedNames)
+
+	response, err := a.aiProvider.CallAI(ai.WithTask(ctx, ai.TaskExtraction), systemPrompt, userMessage)
+	if err != nil {
+		return nil, err
+	}
+
+	cleaned := strings.TrimSpace(response)
+	cleaned = strings.TrimPrefix(cleaned, "```json")
+	cleaned = strings.TrimPrefix(cleaned, "```")
+	cleaned = strings.TrimSuffix(cleaned, "```")
+
+	var request SearchRequest
+	if err := json.Unmarshal([]byte(strings.TrimSpace(cleaned)), &request); err != nil {
+		return nil, fmt.Errorf("failed to parse AI response: %w", err)
+	}
+	a.logger.Info("🤖 AI extracted search action: %s, confidence: %.2f", request.Action, request.Confidence)
+	return &request, nil
+}
+
+func describeResults(results *Results) string {
+	if results.Total == 0 {
+		return "Nothing matches that search"
+	}
+	lines := []string{fmt.Sprintf("Found %d matches:", results.Total)}
+	for _, result := range results.Results {
+		line := fmt.Sprintf("- %s %s", result.Kind, result.Name)
+		if result.Owner != "" {
+			line += " (owner " + result.Owner + ")"
+		}
+		lines = append(lines, line)
+	}
+	if len(results.Results) < results.Total {
+		lines = append(lines, fmt.Sprintf("...and %d more", results.Total-len(results.Results)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (a *SearchAgent) createSuccessResponse(originalEvent *events.Event, message string, data map[string]interface{}) *events.Event {
+	payload := map[string]interface{}{
+		"status":         "success",
+		"message":        message,
+		"correlation_id": originalEvent.Payload["correlation_id"],
+	}
+	for k, v := range data {
+		payload[k] = v
+	}
+	return &events.Event{
+		ID:        fmt.Sprintf("search-response-%d", time.Now().UnixNano()),
+		Type:      events.EventTypeResponse,
+		Subject:   "search.response",
+		Source:    "search-agent",
+		Timestamp: time.Now().Unix(),
+		Payload:   payload,
+	}
+}
+
+func (a *SearchAgent) createErrorResponse(originalEvent *events.Event, errorMessage string) *events.Event {
+	return &events.Event{
+		ID:        fmt.Sprintf("search-error-%d", time.Now().UnixNano()),
+		Type:      events.EventTypeResponse,
+		Subject:   "search.error",
+		Source:    "search-agent",
+		Timestamp: time.Now().Unix(),
+		Payload: map[string]interface{}{
+			"status":         "error",
+			"error":          errorMessage,
+			"correlation_id": originalEvent.Payload["correlation_id"],
+		},
+	}
+}
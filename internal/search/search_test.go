@@ -0,0 +1,86 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSearchTestGraph() *graph.GlobalGraph {
+	g := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	node := func(id, kind, owner, description string, tags ...interface{}) {
+		g.AddNode(&graph.Node{
+			ID:       id,
+			Kind:     kind,
+			Metadata: map[string]interface{}{"name": id, "owner": owner},
+			Spec:     map[string]interface{}{"description": description, "tags": tags},
+		})
+	}
+	node("checkout", graph.KindApplication, "alice", "Checkout flow", "payments", "tier-1")
+	node("billing", graph.KindApplication, "bob", "Invoices and refunds", "payments")
+	node("catalog", graph.KindApplication, "alice", "Product catalog", "discovery")
+	node("checkout-api", graph.KindService, "alice", "Checkout HTTP API", "payments")
+	g.AddNode(&graph.Node{ID: "quota:default", Kind: graph.KindQuota, Metadata: map[string]interface{}{"owner": "alice"}})
+	return g
+}
+
+func names(results *Results) []string {
+	out := []string{}
+	for _, result := range results.Results {
+		out = append(out, result.Name)
+	}
+	return out
+}
+
+func TestSearchFilters(t *testing.T) {
+	service := NewService(newSearchTestGraph())
+
+	results, err := service.Search(Query{Tags: []string{"Payments"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"billing", "checkout", "checkout-api"}, names(results))
+
+	results, err = service.Search(Query{Kinds: []string{graph.KindApplication}, Tags: []string{"payments"}, Owner: "alice"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"checkout"}, names(results))
+
+	results, err = service.Search(Query{Text: "refund"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"billing"}, names(results))
+
+	results, err = service.Search(Query{Owner: "alice"})
+	require.NoError(t, err)
+	assert.NotContains(t, names(results), "quota:default", "bookkeeping kinds are only searched when named")
+
+	results, err = service.Search(Query{Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 4, results.Total)
+	assert.Len(t, results.Results, 2)
+}
+
+func TestSavedSearches(t *testing.T) {
+	service := NewService(newSearchTestGraph())
+
+	_, err := service.Save("alice", "My Payments", Query{Kinds: []string{graph.KindApplication}, Tags: []string{"payments"}, Owner: "alice"})
+	require.NoError(t, err)
+	_, err = service.Save("bob", "everything", Query{})
+	require.NoError(t, err)
+
+	results, err := service.RunSaved("alice", "my payments")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"checkout"}, names(results))
+
+	saved, err := service.ListSaved("alice")
+	require.NoError(t, err)
+	require.Len(t, saved, 1)
+	assert.Equal(t, "My Payments", saved[0].Name)
+
+	_, err = service.RunSaved("alice", "everything")
+	assert.ErrorIs(t, err, ErrSavedSearchNotFound, "saved searches are per user")
+
+	require.NoError(t, service.DeleteSaved("alice", "My Payments"))
+	assert.ErrorIs(t, service.DeleteSaved("alice", "My Payments"), ErrSavedSearchNotFound)
+	_, err = service.Save("", "x", Query{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/krzachariassen/ZTDP/internal/contracts"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+)
+
+// Where a resolved config value came from
+const (
+	ConfigSourceEnv     = "env"
+	ConfigSourceBinding = "binding"
+	ConfigSourceDefault = "default"
+	ConfigSourceUnset   = "unset"
+)
+
+// maskedValue replaces secret values wherever resolved config is shown
+const maskedValue = "********"
+
+// ConfigValue is a config key resolved for one environment
+type ConfigValue struct {
+	Name   string `json:"name"`
+	Value  string `json:"value,omitempty"`
+	Source string `json:"source"`         // env | binding | default | unset
+	From   string `json:"from,omitempty"` // the resource field a binding resolved to, e.g. checkout-pg-db.connection_string
+	Secret bool   `json:"secret,omitempty"`
+}
+
+// Display describes the value and where it came from, masking secrets
+func (v ConfigValue) Display() string {
+	if v.Source == ConfigSourceUnset {
+		return ""
+	}
+	origin := v.Source
+	if v.From != "" {
+		origin = v.From
+	}
+	value := v.Value
+	if v.Secret {
+		value = maskedValue
+	}
+	return fmt.Sprintf("%s (%s)", value, origin)
+}
+
+// ResolvedConfig is the configuration contract of a service resolved for an environment
+type ResolvedConfig struct {
+	Service     string        `json:"service"`
+	Environment string        `json:"environment"`
+	Values      []ConfigValue `json:"values"`
+	Problems    []string      `json:"problems,omitempty"` // bindings that cannot be resolved and required keys without a value
+}
+
+// Env returns the env vars provisioners inject for the config keys that have a value
+func (c *ResolvedConfig) Env() map[string]string {
+	env := make(map[string]string, len(c.Values))
+	for _, value := range c.Values {
+		if value.Source != ConfigSourceUnset {
+			env[value.Name] = value.Value
+		}
+	}
+	return env
+}
+
+// Masked returns a copy with secret values masked, for showing to users
+func (c *ResolvedConfig) Masked() *ResolvedConfig {
+	masked := *c
+	masked.Values = make([]ConfigValue, len(c.Values))
+	for i, value := range c.Values {
+		if value.Secret && value.Source != ConfigSourceUnset {
+			value.Value = maskedValue
+		}
+		masked.Values[i] = value
+	}
+	return &masked
+}
+
+// Err reports the problems as one error, or nil when the contract is satisfied
+func (c *ResolvedConfig) Err() error {
+	if len(c.Problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("service %s config in %s: %s", c.Service, c.Environment, strings.Join(c.Problems, "; "))
+}
+
+// ResolveConfig resolves the config keys a service declares for an environment: env vars set
+// for the environment win, then resource bindings, then defaults. Provisioners inject the
+// result's Env; deployment planning refuses services whose result has problems.
+func ResolveConfig(g *graph.GlobalGraph, serviceName, environment string) (*ResolvedConfig, error) {
+	spec, err := ResolveForEnvironment(g, serviceName, environment, nil)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := g.Nodes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph: %w", err)
+	}
+	edges, err := g.Edges()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph: %w", err)
+	}
+	resolved := ResolveConfigValues(nodes, edges, serviceName, spec)
+	resolved.Environment = environment
+	return resolved, nil
+}
+
+// ResolveConfigValues resolves the config keys of a spec that already has the environment's
+// override merged in, reading bindings from the given graph snapshot
+func ResolveConfigValues(nodes map[string]*graph.Node, edges map[string][]graph.Edge, serviceName string, spec contracts.ServiceSpec) *ResolvedConfig {
+	resolved := &ResolvedConfig{Service: serviceName, Values: []ConfigValue{}}
+	keys := append([]contracts.ConfigKey(nil), spec.Config...)
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Name < keys[j].Name })
+
+	for _, key := range keys {
+		value := ConfigValue{Name: key.Name, Source: ConfigSourceUnset, Secret: key.Secret}
+		if env, ok := spec.Env[key.Name]; ok {
+			value.Value, value.Source = env, ConfigSourceEnv
+		} else if key.From != "" {
+			from, v, err := resolveBinding(nodes, edges, serviceName, spec.Application, key)
+			if err != nil {
+				resolved.Problems = append(resolved.Problems, fmt.Sprintf("%s: %v", key.Name, err))
+			} else {
+				value.Value, value.Source, value.From = v, ConfigSourceBinding, from
+			}
+		} else if key.Default != "" {
+			value.Value, value.Source = key.Default, ConfigSourceDefault
+		} else if key.Required {
+			resolved.Problems = append(resolved.Problems, fmt.Sprintf("%s: required but has no value", key.Name))
+		}
+		resolved.Values = append(resolved.Values, value)
+	}
+	return resolved
+}
+
+// resolveBinding reads the bound field from the resource instance the service may use: one its
+// application owns, or one the service or application was granted access to. A catalog resource
+// stands for the application's instance of it.
+func resolveBinding(nodes map[string]*graph.Node, edges map[string][]graph.Edge, serviceName, appName string, key contracts.ConfigKey) (string, string, error) {
+	resource, field, _ := key.Binding()
+	node, ok := nodes[resource]
+	if !ok || node.Kind != graph.KindResource {
+		return "", "", fmt.Errorf("resource %s not found", resource)
+	}
+	if node.Metadata["catalog_ref"] == nil {
+		instance := ""
+		for _, edge := range edges[appName] {
+			if target, ok := nodes[edge.To]; ok && edge.Type == graph.EdgeTypeOwns && target.Kind == graph.KindResource && target.Metadata["catalog_ref"] == resource {
+				instance = edge.To
+				break
+			}
+		}
+		if instance == "" {
+			return "", "", fmt.Errorf("%s has no instance of %s", appName, resource)
+		}
+		resource, node = instance, nodes[instance]
+	}
+
+	if !hasEdgeTo(edges[appName], resource, graph.EdgeTypeOwns, graph.EdgeTypeAccesses) &&
+		!hasEdgeTo(edges[serviceName], resource, graph.EdgeTypeUses, graph.EdgeTypeAccesses) {
+		return "", "", fmt.Errorf("%s is not owned by %s or granted to %s", resource, appName, serviceName)
+	}
+
+	raw, ok := node.Spec[field]
+	if !ok {
+		return "", "", fmt.Errorf("%s has no %s", resource, field)
+	}
+	value, ok := raw.(string)
+	if !ok {
+		value = fmt.Sprint(raw)
+	}
+	return resource + "." + field, value, nil
+}
+
+func hasEdgeTo(edges []graph.Edge, to string, types ...string) bool {
+	for _, edge := range edges {
+		if edge.To != to {
+			continue
+		}
+		for _, edgeType := range types {
+			if edge.Type == edgeType {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// EffectiveConfigContract returns the service's config contract resolved for environment, with
+// secret values masked
+func (s *ServiceService) EffectiveConfigContract(appName, serviceName, environment string) (*ResolvedConfig, error) {
+	if _, err := s.getServiceInternal(appName, serviceName); err != nil {
+		return nil, err
+	}
+	resolved, err := ResolveConfig(s.Graph, serviceName, environment)
+	if err != nil {
+		return nil, err
+	}
+	return resolved.Masked(), nil
+}
+
+// CheckConfig resolves the config contract of every service the application owns for the
+// environment and reports all problems at once
+func CheckConfig(g *graph.GlobalGraph, appName, environment string) error {
+	edges, err := g.Edges()
+	if err != nil {
+		return fmt.Errorf("failed to read graph: %w", err)
+	}
+	var problems []string
+	for _, edge := range edges[appName] {
+		if node, _ := g.GetNode(edge.To); edge.Type != graph.EdgeTypeOwns || node == nil || node.Kind != graph.KindService {
+			continue
+		}
+		resolved, err := ResolveConfig(g, edge.To, environment)
+		if err != nil {
+			return err
+		}
+		if err := resolved.Err(); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("config contract not satisfied: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
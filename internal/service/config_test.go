@@ -0,0 +1,79 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/contracts"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newConfigTestGraph(t *testing.T, config []contracts.ConfigKey, overrides map[string]contracts.ServiceOverride) *graph.GlobalGraph {
+	t.Helper()
+	g := newOverrideTestGraph(t)
+	service := contracts.ServiceContract{
+		Metadata: contracts.Metadata{Name: "checkout-api", Owner: "team-a"},
+		Spec:     contracts.ServiceSpec{Application: "checkout", Port: 8080, Replicas: 2, Tier: "medium", Config: config, Overrides: overrides},
+	}
+	node, err := graph.ResolveContract(service)
+	require.NoError(t, err)
+	g.AddNode(node)
+	g.AddNode(&graph.Node{ID: "postgres", Kind: graph.KindResource, Metadata: map[string]interface{}{"name": "postgres"}, Spec: map[string]interface{}{}})
+	g.AddNode(&graph.Node{ID: "checkout-pg-db", Kind: graph.KindResource, Metadata: map[string]interface{}{"name": "checkout-pg-db", "application": "checkout", "catalog_ref": "postgres"}, Spec: map[string]interface{}{"connection_string": "postgres://checkout", "port": 5432}})
+	g.AddNode(&graph.Node{ID: "billing-pg-db", Kind: graph.KindResource, Metadata: map[string]interface{}{"name": "billing-pg-db", "application": "billing", "catalog_ref": "postgres"}, Spec: map[string]interface{}{"connection_string": "postgres://billing"}})
+	require.NoError(t, g.AddEdge("checkout", "checkout-api", graph.EdgeTypeOwns))
+	require.NoError(t, g.AddEdge("checkout", "checkout-pg-db", graph.EdgeTypeOwns))
+	return g
+}
+
+func TestResolveConfig_SourcesInOrder(t *testing.T) {
+	g := newConfigTestGraph(t, []contracts.ConfigKey{
+		{Name: "DATABASE_URL", From: "checkout-pg-db.connection_string", Required: true, Secret: true},
+		{Name: "DB_PORT", From: "postgres.port"},
+		{Name: "LOG_LEVEL", Default: "info"},
+		{Name: "FEATURE_FLAGS"},
+	}, map[string]contracts.ServiceOverride{
+		"prod": {Env: map[string]string{"LOG_LEVEL": "warn"}},
+	})
+
+	resolved, err := ResolveConfig(g, "checkout-api", "prod")
+	require.NoError(t, err)
+	require.NoError(t, resolved.Err())
+	assert.Equal(t, []ConfigValue{
+		{Name: "DATABASE_URL", Value: "postgres://checkout", Source: ConfigSourceBinding, From: "checkout-pg-db.connection_string", Secret: true},
+		{Name: "DB_PORT", Value: "5432", Source: ConfigSourceBinding, From: "checkout-pg-db.port"},
+		{Name: "FEATURE_FLAGS", Source: ConfigSourceUnset},
+		{Name: "LOG_LEVEL", Value: "warn", Source: ConfigSourceEnv},
+	}, resolved.Values, "a catalog binding resolves to the application's instance")
+	assert.Equal(t, map[string]string{"DATABASE_URL": "postgres://checkout", "DB_PORT": "5432", "LOG_LEVEL": "warn"}, resolved.Env())
+
+	masked := resolved.Masked()
+	assert.Equal(t, "********", masked.Values[0].Value)
+	assert.Equal(t, "postgres://checkout", resolved.Values[0].Value, "masking must not modify the resolved config")
+	assert.Equal(t, "******** (checkout-pg-db.connection_string)", resolved.Values[0].Display())
+}
+
+func TestCheckConfig_ReportsUnresolvableBindings(t *testing.T) {
+	g := newConfigTestGraph(t, []contracts.ConfigKey{
+		{Name: "DATABASE_URL", From: "checkout-pg-db.connection_string"},
+		{Name: "API_TOKEN", Required: true},
+	}, map[string]contracts.ServiceOverride{
+		"prod": {Config: []contracts.ConfigKey{{Name: "DATABASE_URL", From: "billing-pg-db.connection_string"}}},
+	})
+
+	err := CheckConfig(g, "checkout", "dev")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "API_TOKEN: required but has no value")
+	assert.NotContains(t, err.Error(), "DATABASE_URL")
+
+	err = CheckConfig(g, "checkout", "prod")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "DATABASE_URL: billing-pg-db is not owned by checkout or granted to checkout-api")
+
+	// Granting the service access to the other application's instance satisfies the binding
+	require.NoError(t, g.AddEdge("checkout-api", "billing-pg-db", graph.EdgeTypeAccesses))
+	resolved, err := ResolveConfig(g, "checkout-api", "prod")
+	require.NoError(t, err)
+	assert.Equal(t, "postgres://billing", resolved.Env()["DATABASE_URL"])
+}
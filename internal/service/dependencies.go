@@ -0,0 +1,183 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/krzachariassen/ZTDP/internal/contracts"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+)
+
+// APIEntry is one service's entry in the API catalog: what it exposes and who consumes it
+type APIEntry struct {
+	Service     string        `json:"service"`
+	Application string        `json:"application"`
+	Port        int           `json:"port,omitempty"`
+	Public      bool          `json:"public"`
+	Consumers   []APIConsumer `json:"consumers"`
+	Consumes    []APIConsumer `json:"consumes"` // this service's own dependencies
+}
+
+// APIConsumer is a declared dependency between two services, seen from either end
+type APIConsumer struct {
+	Service  string `json:"service"`
+	Protocol string `json:"protocol"`
+	Port     int    `json:"port,omitempty"`
+	API      string `json:"api,omitempty"`
+	Version  string `json:"version,omitempty"` // the pinned version of the consumed service
+	Linked   bool   `json:"linked"`            // false while the consumed service is not in the graph
+}
+
+// linkDependencies replaces the consumes edges of a service with those its spec declares and
+// links services created earlier that declared a dependency on it. Dependencies on services
+// that do not exist yet stay in the spec and are linked when the service is created.
+func linkDependencies(current *graph.Graph, svc contracts.ServiceContract) {
+	name := svc.Metadata.Name
+	kept := current.Edges[name][:0]
+	for _, edge := range current.Edges[name] {
+		if edge.Type != graph.EdgeTypeConsumes {
+			kept = append(kept, edge)
+		}
+	}
+	current.Edges[name] = kept
+	for _, dependency := range svc.Spec.Dependencies {
+		if node, ok := current.Nodes[dependency.Service]; ok && node.Kind == graph.KindService {
+			current.Edges[name] = append(current.Edges[name], graph.Edge{To: dependency.Service, Type: graph.EdgeTypeConsumes, Metadata: dependency.EdgeMetadata()})
+		}
+	}
+
+	for id, node := range current.Nodes {
+		if node.Kind != graph.KindService || id == name || hasConsumesEdge(current.Edges[id], name) {
+			continue
+		}
+		for _, dependency := range specDependencies(node) {
+			if dependency.Service == name {
+				current.Edges[id] = append(current.Edges[id], graph.Edge{To: name, Type: graph.EdgeTypeConsumes, Metadata: dependency.EdgeMetadata()})
+			}
+		}
+	}
+}
+
+func hasConsumesEdge(edges []graph.Edge, to string) bool {
+	for _, edge := range edges {
+		if edge.Type == graph.EdgeTypeConsumes && edge.To == to {
+			return true
+		}
+	}
+	return false
+}
+
+func specDependencies(node *graph.Node) []contracts.ServiceDependency {
+	return specOf(node).Dependencies
+}
+
+func specOf(node *graph.Node) contracts.ServiceSpec {
+	var spec contracts.ServiceSpec
+	decodeSpec(node.Spec, &spec)
+	return spec
+}
+
+// APICatalog lists every service with the port it exposes, the services consuming it and the
+// services it consumes, sorted by service name
+func (s *ServiceService) APICatalog() ([]APIEntry, error) {
+	current, err := s.Graph.Graph()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get graph: %w", err)
+	}
+	entries := map[string]*APIEntry{}
+	for id, node := range current.Nodes {
+		if node.Kind != graph.KindService {
+			continue
+		}
+		spec := specOf(node)
+		entries[id] = &APIEntry{Service: id, Application: spec.Application, Port: spec.Port, Public: spec.Public, Consumers: []APIConsumer{}, Consumes: []APIConsumer{}}
+	}
+	for id, entry := range entries {
+		for _, dependency := range specDependencies(current.Nodes[id]) {
+			consumed, linked := entries[dependency.Service]
+			link := APIConsumer{Service: dependency.Service, Protocol: dependency.EffectiveProtocol(), Port: dependency.Port, API: dependency.API, Version: dependency.Version, Linked: linked}
+			entry.Consumes = append(entry.Consumes, link)
+			if linked {
+				link.Service = id
+				consumed.Consumers = append(consumed.Consumers, link)
+			}
+		}
+	}
+
+	catalog := make([]APIEntry, 0, len(entries))
+	for _, entry := range entries {
+		sort.Slice(entry.Consumers, func(i, j int) bool { return entry.Consumers[i].Service < entry.Consumers[j].Service })
+		catalog = append(catalog, *entry)
+	}
+	sort.Slice(catalog, func(i, j int) bool { return catalog[i].Service < catalog[j].Service })
+	return catalog, nil
+}
+
+// CheckDependencies verifies that every service the application's services consume is present
+// in the environment and exposes the port the dependency expects. A consumed service is present
+// when it belongs to the application being deployed, has a version deployed to the environment,
+// or its application has been deployed there successfully. Dependencies pinned to a sunset
+// version are refused too.
+func CheckDependencies(g *graph.GlobalGraph, appName, environment string) error {
+	current, err := g.Graph()
+	if err != nil {
+		return fmt.Errorf("failed to get graph: %w", err)
+	}
+
+	var problems []string
+	for _, owned := range current.Edges[appName] {
+		node, ok := current.Nodes[owned.To]
+		if owned.Type != graph.EdgeTypeOwns || !ok || node.Kind != graph.KindService {
+			continue
+		}
+		for _, dependency := range specDependencies(node) {
+			consumed, ok := current.Nodes[dependency.Service]
+			if !ok || consumed.Kind != graph.KindService {
+				problems = append(problems, fmt.Sprintf("%s consumes %s, which does not exist", owned.To, dependency.Service))
+				continue
+			}
+			spec := specOf(consumed)
+			if spec.Port == 0 {
+				problems = append(problems, fmt.Sprintf("%s consumes %s, which exposes no port", owned.To, dependency.Service))
+			} else if dependency.Port != 0 && dependency.Port != spec.Port {
+				problems = append(problems, fmt.Sprintf("%s consumes %s on port %d, but it exposes %d", owned.To, dependency.Service, dependency.Port, spec.Port))
+			}
+			if version, ok := current.Nodes[dependency.Service+":"+dependency.Version]; ok && dependency.Version != "" && VersionStateOf(version) == VersionSunset {
+				problems = append(problems, fmt.Sprintf("%s consumes %s:%s, which has been sunset", owned.To, dependency.Service, dependency.Version))
+			}
+			if spec.Application != appName && !presentIn(current, dependency.Service, spec.Application, environment) {
+				problems = append(problems, fmt.Sprintf("%s consumes %s, which is not deployed to %s", owned.To, dependency.Service, environment))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("unmet service dependencies: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// presentIn reports whether a service runs in the environment
+func presentIn(current *graph.Graph, serviceName, appName, environment string) bool {
+	for _, version := range current.Edges[serviceName] {
+		if version.Type != graph.EdgeTypeHasVersion {
+			continue
+		}
+		for _, edge := range current.Edges[version.To] {
+			if edge.Type == graph.EdgeTypeDeploy && edge.To == environment {
+				return true
+			}
+		}
+	}
+
+	// Deployments are recorded on edges from the release to the environment
+	for _, edges := range current.Edges {
+		for _, edge := range edges {
+			if edge.Type == "deployment" && edge.To == environment && edge.Metadata["application"] == appName && edge.Metadata["status"] == "succeeded" {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/contracts"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceDependencies(t *testing.T) {
+	g := newOverrideTestGraph(t)
+	payments, err := graph.ResolveContract(contracts.ApplicationContract{Metadata: contracts.Metadata{Name: "payments", Owner: "team-b"}})
+	require.NoError(t, err)
+	g.AddNode(payments)
+	service := NewServiceService(g)
+
+	// checkout-api is declared before the service it consumes exists
+	_, err = service.CreateService("checkout", map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "checkout-api", "owner": "team-a"},
+		"spec": map[string]interface{}{"port": 8080, "dependencies": []interface{}{
+			map[string]interface{}{"service": "payments-api", "protocol": "grpc", "port": 9090, "api": "payments.v1.Payments"},
+		}},
+	})
+	require.NoError(t, err)
+	assert.ErrorContains(t, CheckDependencies(g, "checkout", "prod"), "checkout-api consumes payments-api, which does not exist")
+
+	_, err = service.CreateService("payments", map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "payments-api", "owner": "team-b"},
+		"spec":     map[string]interface{}{"port": 9090},
+	})
+	require.NoError(t, err)
+	edge, ok := g.GetEdgeByFromToType("checkout-api", "payments-api", graph.EdgeTypeConsumes)
+	require.True(t, ok, "the dependency is linked once the consumed service exists")
+	assert.Equal(t, "grpc", edge.Metadata["protocol"])
+
+	assert.ErrorContains(t, CheckDependencies(g, "checkout", "prod"), "payments-api, which is not deployed to prod")
+	current, err := g.Graph()
+	require.NoError(t, err)
+	current.Edges["release-payments-1"] = []graph.Edge{{To: "prod", Type: "deployment", Metadata: map[string]interface{}{"application": "payments", "status": "succeeded"}}}
+	require.NoError(t, g.Save())
+	assert.NoError(t, CheckDependencies(g, "checkout", "prod"))
+
+	catalog, err := service.APICatalog()
+	require.NoError(t, err)
+	require.Len(t, catalog, 2)
+	assert.Equal(t, "payments-api", catalog[1].Service)
+	assert.Equal(t, []APIConsumer{{Service: "checkout-api", Protocol: "grpc", Port: 9090, API: "payments.v1.Payments", Linked: true}}, catalog[1].Consumers)
+
+	_, err = service.CreateService("checkout", map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "checkout-worker", "owner": "team-a"},
+		"spec":     map[string]interface{}{"dependencies": []interface{}{map[string]interface{}{"service": "payments-api", "protocol": "amqp"}}},
+	})
+	assert.ErrorContains(t, err, "unsupported protocol")
+}
@@ -0,0 +1,290 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/contracts"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+)
+
+// VersionState is the lifecycle state of a service version's API
+type VersionState string
+
+const (
+	VersionActive     VersionState = "active"
+	VersionDeprecated VersionState = "deprecated"
+	VersionSunset     VersionState = "sunset"
+)
+
+// Node metadata keys holding a version's lifecycle; versions without a state are active
+const (
+	versionStateKey       = "lifecycle_state"
+	versionReasonKey      = "lifecycle_reason"
+	versionChangedAtKey   = "lifecycle_changed_at"
+	versionSunsetAtKey    = "sunset_at"
+	versionReplacementKey = "replacement"
+)
+
+// VersionLifecycleSubject is the notify event emitted when a service version changes state
+const VersionLifecycleSubject = "service.version.lifecycle.changed"
+
+var (
+	// ErrInvalidVersionTransition is returned for transitions the version state machine does not allow
+	ErrInvalidVersionTransition = errors.New("invalid version lifecycle transition")
+	// ErrDeprecatedDependency is returned when a service declares a new dependency on a
+	// deprecated or sunset version
+	ErrDeprecatedDependency = errors.New("dependency on deprecated version")
+)
+
+// versionTransitions lists the states each state may move to. Sunset is final.
+var versionTransitions = map[VersionState][]VersionState{
+	VersionActive:     {VersionDeprecated},
+	VersionDeprecated: {VersionActive, VersionSunset},
+}
+
+// Valid reports whether s is a known version state
+func (s VersionState) Valid() bool {
+	_, ok := versionTransitions[s]
+	return ok || s == VersionSunset
+}
+
+// CanTransitionTo reports whether a version in state s may move to target
+func (s VersionState) CanTransitionTo(target VersionState) bool {
+	for _, allowed := range versionTransitions[s] {
+		if allowed == target {
+			return true
+		}
+	}
+	return false
+}
+
+// VersionLifecycle is a service version's lifecycle state and the services pinned to it
+type VersionLifecycle struct {
+	Service     string       `json:"service"`
+	Version     string       `json:"version"`
+	State       VersionState `json:"state"`
+	Reason      string       `json:"reason,omitempty"`
+	SunsetAt    string       `json:"sunset_at,omitempty"`   // when a deprecated version is scheduled to be sunset
+	Replacement string       `json:"replacement,omitempty"` // the version consumers should move to
+	ChangedAt   string       `json:"changed_at,omitempty"`
+	Previous    VersionState `json:"previous,omitempty"` // set on transitions only
+	Consumers   []string     `json:"consumers"`
+}
+
+// VersionTransition is a requested change of a version's lifecycle state
+type VersionTransition struct {
+	State       VersionState `json:"state"`
+	Reason      string       `json:"reason,omitempty"`
+	SunsetAt    *time.Time   `json:"sunset_at,omitempty"` // schedules the sunset when deprecating
+	Replacement string       `json:"replacement,omitempty"`
+}
+
+// VersionStateOf returns the lifecycle state recorded on a service version node
+func VersionStateOf(node *graph.Node) VersionState {
+	if state, ok := node.Metadata[versionStateKey].(string); ok && state != "" {
+		return VersionState(state)
+	}
+	return VersionActive
+}
+
+// GetVersionLifecycle returns a service version's lifecycle state
+func (s *ServiceService) GetVersionLifecycle(serviceName, version string) (*VersionLifecycle, error) {
+	current, err := s.Graph.Graph()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get graph: %w", err)
+	}
+	node, ok := current.Nodes[serviceName+":"+version]
+	if !ok || node.Kind != graph.KindServiceVersion {
+		return nil, fmt.Errorf("service version %s:%s not found", serviceName, version)
+	}
+	return versionLifecycle(current, node, serviceName, version), nil
+}
+
+// TransitionVersion moves a service version to a new lifecycle state and notifies the services
+// pinned to it with a service.version.lifecycle.changed event. Moving back to active clears the
+// sunset schedule; a version sunset without one records the time it was sunset.
+func (s *ServiceService) TransitionVersion(serviceName, version string, transition VersionTransition) (*VersionLifecycle, error) {
+	if !transition.State.Valid() {
+		return nil, fmt.Errorf("%w: unknown state %q", ErrInvalidVersionTransition, transition.State)
+	}
+	id := serviceName + ":" + version
+	node, _ := s.Graph.GetNode(id)
+	if node == nil || node.Kind != graph.KindServiceVersion {
+		return nil, fmt.Errorf("service version %s not found", id)
+	}
+	previous := VersionStateOf(node)
+	if !previous.CanTransitionTo(transition.State) {
+		return nil, fmt.Errorf("%w: %s cannot move from %s to %s", ErrInvalidVersionTransition, id, previous, transition.State)
+	}
+	if transition.Replacement != "" {
+		if replacement, _ := s.Graph.GetNode(serviceName + ":" + transition.Replacement); replacement == nil || replacement.Kind != graph.KindServiceVersion {
+			return nil, fmt.Errorf("replacement version %s:%s not found", serviceName, transition.Replacement)
+		}
+	}
+
+	if node.Metadata == nil {
+		node.Metadata = map[string]interface{}{}
+	}
+	node.Metadata[versionStateKey] = string(transition.State)
+	node.Metadata[versionReasonKey] = transition.Reason
+	node.Metadata[versionChangedAtKey] = time.Now().UTC().Format(time.RFC3339)
+	switch {
+	case transition.State == VersionActive:
+		delete(node.Metadata, versionSunsetAtKey)
+		delete(node.Metadata, versionReplacementKey)
+	case transition.SunsetAt != nil:
+		node.Metadata[versionSunsetAtKey] = transition.SunsetAt.UTC().Format(time.RFC3339)
+	case transition.State == VersionSunset && node.Metadata[versionSunsetAtKey] == nil:
+		node.Metadata[versionSunsetAtKey] = node.Metadata[versionChangedAtKey]
+	}
+	if transition.Replacement != "" {
+		node.Metadata[versionReplacementKey] = transition.Replacement
+	}
+	if err := s.Graph.UpdateNode(node); err != nil {
+		return nil, fmt.Errorf("failed to update service version: %w", err)
+	}
+
+	lifecycle, err := s.GetVersionLifecycle(serviceName, version)
+	if err != nil {
+		return nil, err
+	}
+	lifecycle.Previous = previous
+	s.logger.Info("📆 Service version %s moved from %s to %s", id, previous, lifecycle.State)
+	s.notifyConsumers(lifecycle)
+	return lifecycle, nil
+}
+
+// SunsetReport lists the deprecated versions scheduled for sunset and the versions already
+// sunset, with the services still pinned to them, soonest sunset first
+func (s *ServiceService) SunsetReport() ([]VersionLifecycle, error) {
+	current, err := s.Graph.Graph()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get graph: %w", err)
+	}
+	report := []VersionLifecycle{}
+	for serviceName, node := range current.Nodes {
+		if node.Kind != graph.KindService {
+			continue
+		}
+		for _, edge := range current.Edges[serviceName] {
+			versionNode, ok := current.Nodes[edge.To]
+			if edge.Type != graph.EdgeTypeHasVersion || !ok || versionNode.Kind != graph.KindServiceVersion {
+				continue
+			}
+			lifecycle := versionLifecycle(current, versionNode, serviceName, strings.TrimPrefix(edge.To, serviceName+":"))
+			if lifecycle.State == VersionSunset || (lifecycle.State == VersionDeprecated && lifecycle.SunsetAt != "") {
+				report = append(report, *lifecycle)
+			}
+		}
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].SunsetAt != report[j].SunsetAt {
+			return report[i].SunsetAt < report[j].SunsetAt
+		}
+		return report[i].Service+":"+report[i].Version < report[j].Service+":"+report[j].Version
+	})
+	return report, nil
+}
+
+func versionLifecycle(current *graph.Graph, node *graph.Node, serviceName, version string) *VersionLifecycle {
+	lifecycle := &VersionLifecycle{
+		Service:   serviceName,
+		Version:   version,
+		State:     VersionStateOf(node),
+		Consumers: pinnedConsumers(current, serviceName, version),
+	}
+	lifecycle.Reason, _ = node.Metadata[versionReasonKey].(string)
+	lifecycle.SunsetAt, _ = node.Metadata[versionSunsetAtKey].(string)
+	lifecycle.Replacement, _ = node.Metadata[versionReplacementKey].(string)
+	lifecycle.ChangedAt, _ = node.Metadata[versionChangedAtKey].(string)
+	return lifecycle
+}
+
+// pinnedConsumers returns the services declaring a dependency on the given version
+func pinnedConsumers(current *graph.Graph, serviceName, version string) []string {
+	consumers := []string{}
+	for id, node := range current.Nodes {
+		if node.Kind != graph.KindService {
+			continue
+		}
+		for _, dependency := range specDependencies(node) {
+			if dependency.Service == serviceName && dependency.Version == version {
+				consumers = append(consumers, id)
+				break
+			}
+		}
+	}
+	sort.Strings(consumers)
+	return consumers
+}
+
+// checkDeprecatedDependencies refuses dependencies pinned to a deprecated or sunset version
+// unless the service already declared them, so existing consumers can still be updated
+func checkDeprecatedDependencies(current *graph.Graph, svc contracts.ServiceContract) error {
+	declared := map[contracts.ServiceDependency]bool{}
+	if existing, ok := current.Nodes[svc.Metadata.Name]; ok && existing.Kind == graph.KindService {
+		for _, dependency := range specDependencies(existing) {
+			declared[contracts.ServiceDependency{Service: dependency.Service, Version: dependency.Version}] = true
+		}
+	}
+	for _, dependency := range svc.Spec.Dependencies {
+		if dependency.Version == "" || declared[contracts.ServiceDependency{Service: dependency.Service, Version: dependency.Version}] {
+			continue
+		}
+		node, ok := current.Nodes[dependency.Service+":"+dependency.Version]
+		if !ok || node.Kind != graph.KindServiceVersion {
+			continue
+		}
+		if state := VersionStateOf(node); state != VersionActive {
+			hint := ""
+			if replacement, _ := node.Metadata[versionReplacementKey].(string); replacement != "" {
+				hint = ", use " + replacement
+			}
+			return fmt.Errorf("%w: %s:%s is %s%s", ErrDeprecatedDependency, dependency.Service, dependency.Version, state, hint)
+		}
+	}
+	return nil
+}
+
+func (s *ServiceService) notifyConsumers(lifecycle *VersionLifecycle) {
+	bus := s.eventBus
+	if bus == nil {
+		bus = events.GlobalEventBus
+	}
+	if bus == nil {
+		return
+	}
+	bus.Emit(events.EventTypeNotify, "service-service", VersionLifecycleSubject, map[string]interface{}{
+		"service":        lifecycle.Service,
+		"version":        lifecycle.Version,
+		"state":          string(lifecycle.State),
+		"previous_state": string(lifecycle.Previous),
+		"reason":         lifecycle.Reason,
+		"sunset_at":      lifecycle.SunsetAt,
+		"replacement":    lifecycle.Replacement,
+		"consumers":      lifecycle.Consumers,
+		"message":        describeVersionTransition(lifecycle),
+	})
+}
+
+func describeVersionTransition(lifecycle *VersionLifecycle) string {
+	message := fmt.Sprintf("Service version %s:%s moved from %s to %s", lifecycle.Service, lifecycle.Version, lifecycle.Previous, lifecycle.State)
+	if lifecycle.Reason != "" {
+		message += " (" + lifecycle.Reason + ")"
+	}
+	if lifecycle.SunsetAt != "" && lifecycle.State == VersionDeprecated {
+		message += "; sunset scheduled for " + lifecycle.SunsetAt
+	}
+	if lifecycle.Replacement != "" {
+		message += "; use " + lifecycle.Replacement + " instead"
+	}
+	if len(lifecycle.Consumers) > 0 {
+		message += "; affects " + strings.Join(lifecycle.Consumers, ", ")
+	}
+	return message
+}
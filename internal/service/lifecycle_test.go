@@ -0,0 +1,97 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newLifecycleTestService(t *testing.T) *ServiceService {
+	t.Helper()
+	service := NewServiceService(newOverrideTestGraph(t))
+	_, err := service.CreateService("checkout", map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "payments-api", "owner": "team-b"},
+		"spec":     map[string]interface{}{"port": 9090},
+	})
+	require.NoError(t, err)
+	for _, version := range []string{"1.0.0", "2.0.0"} {
+		_, err := service.CreateServiceVersion("payments-api", map[string]interface{}{"version": version, "config_ref": "payments-config"})
+		require.NoError(t, err)
+	}
+	_, err = service.CreateService("checkout", map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "checkout-api", "owner": "team-a"},
+		"spec": map[string]interface{}{"port": 8080, "dependencies": []interface{}{
+			map[string]interface{}{"service": "payments-api", "version": "1.0.0"},
+		}},
+	})
+	require.NoError(t, err)
+	return service
+}
+
+func TestVersionLifecycle_DeprecateAndSunset(t *testing.T) {
+	service := newLifecycleTestService(t)
+	bus := events.NewEventBus(nil, false)
+	service.eventBus = bus
+	var notified []events.Event
+	bus.Subscribe(events.EventTypeNotify, func(event events.Event) error {
+		notified = append(notified, event)
+		return nil
+	})
+
+	lifecycle, err := service.GetVersionLifecycle("payments-api", "1.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, VersionActive, lifecycle.State)
+	assert.Equal(t, []string{"checkout-api"}, lifecycle.Consumers)
+
+	_, err = service.TransitionVersion("payments-api", "1.0.0", VersionTransition{State: VersionSunset})
+	assert.ErrorIs(t, err, ErrInvalidVersionTransition, "versions are deprecated before they are sunset")
+
+	sunsetAt := time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC)
+	lifecycle, err = service.TransitionVersion("payments-api", "1.0.0", VersionTransition{State: VersionDeprecated, Reason: "v2 API", SunsetAt: &sunsetAt, Replacement: "2.0.0"})
+	require.NoError(t, err)
+	assert.Equal(t, VersionActive, lifecycle.Previous)
+	assert.Equal(t, "2026-12-01T00:00:00Z", lifecycle.SunsetAt)
+	require.Len(t, notified, 1)
+	assert.Equal(t, VersionLifecycleSubject, notified[0].Subject)
+	assert.Equal(t, []string{"checkout-api"}, notified[0].Payload["consumers"])
+	assert.Contains(t, notified[0].Payload["message"], "use 2.0.0 instead")
+
+	report, err := service.SunsetReport()
+	require.NoError(t, err)
+	require.Len(t, report, 1)
+	assert.Equal(t, "1.0.0", report[0].Version)
+	assert.Equal(t, []string{"checkout-api"}, report[0].Consumers)
+
+	_, err = service.TransitionVersion("payments-api", "1.0.0", VersionTransition{State: VersionSunset})
+	require.NoError(t, err)
+	_, err = service.TransitionVersion("payments-api", "1.0.0", VersionTransition{State: VersionActive})
+	assert.ErrorIs(t, err, ErrInvalidVersionTransition, "sunset is final")
+	assert.ErrorContains(t, CheckDependencies(service.Graph, "checkout", "prod"), "checkout-api consumes payments-api:1.0.0, which has been sunset")
+}
+
+func TestVersionLifecycle_RefusesNewDependenciesOnDeprecatedVersions(t *testing.T) {
+	service := newLifecycleTestService(t)
+	_, err := service.TransitionVersion("payments-api", "1.0.0", VersionTransition{State: VersionDeprecated, Replacement: "2.0.0"})
+	require.NoError(t, err)
+
+	_, err = service.CreateService("checkout", map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "checkout-worker", "owner": "team-a"},
+		"spec": map[string]interface{}{"dependencies": []interface{}{
+			map[string]interface{}{"service": "payments-api", "version": "1.0.0"},
+		}},
+	})
+	assert.ErrorIs(t, err, ErrDeprecatedDependency)
+	assert.ErrorContains(t, err, "use 2.0.0")
+
+	// An existing consumer may still be updated while it migrates
+	_, err = service.CreateService("checkout", map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "checkout-api", "owner": "team-a"},
+		"spec": map[string]interface{}{"port": 8081, "dependencies": []interface{}{
+			map[string]interface{}{"service": "payments-api", "version": "1.0.0"},
+		}},
+	})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/krzachariassen/ZTDP/internal/contracts"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+)
+
+// SetEnvironmentOverride stores an environment-scoped overlay on a service. The merged
+// configuration must satisfy the environment's constraints.
+func (s *ServiceService) SetEnvironmentOverride(appName, serviceName, environment string, override contracts.ServiceOverride) (map[string]interface{}, error) {
+	svc, err := s.getServiceInternal(appName, serviceName)
+	if err != nil {
+		return nil, err
+	}
+	if err := override.Validate(); err != nil {
+		return nil, err
+	}
+	if envNode, _ := s.Graph.GetNode(environment); envNode == nil || envNode.Kind != graph.KindEnvironment {
+		return nil, fmt.Errorf("environment %s not found", environment)
+	}
+
+	if svc.Spec.Overrides == nil {
+		svc.Spec.Overrides = make(map[string]contracts.ServiceOverride)
+	}
+	svc.Spec.Overrides[environment] = override
+	if _, err := ResolveForEnvironment(s.Graph, serviceName, environment, &svc.Spec); err != nil {
+		return nil, err
+	}
+
+	if err := s.saveService(svc); err != nil {
+		return nil, err
+	}
+	s.logger.Info("🎛️ Set %s override for service %s", environment, serviceName)
+	return contractToMap(svc), nil
+}
+
+// DeleteEnvironmentOverride removes a service's overlay for an environment
+func (s *ServiceService) DeleteEnvironmentOverride(appName, serviceName, environment string) error {
+	svc, err := s.getServiceInternal(appName, serviceName)
+	if err != nil {
+		return err
+	}
+	if _, ok := svc.Spec.Overrides[environment]; !ok {
+		return fmt.Errorf("service %s has no override for %s", serviceName, environment)
+	}
+	delete(svc.Spec.Overrides, environment)
+	return s.saveService(svc)
+}
+
+// EffectiveConfig returns the service spec a deployment to environment runs with
+func (s *ServiceService) EffectiveConfig(appName, serviceName, environment string) (contracts.ServiceSpec, error) {
+	svc, err := s.getServiceInternal(appName, serviceName)
+	if err != nil {
+		return contracts.ServiceSpec{}, err
+	}
+	return ResolveForEnvironment(s.Graph, serviceName, environment, &svc.Spec)
+}
+
+func (s *ServiceService) saveService(svc contracts.ServiceContract) error {
+	node, err := graph.ResolveContract(svc)
+	if err != nil {
+		return err
+	}
+	return s.Graph.UpdateNode(node)
+}
+
+// ResolveForEnvironment merges a service's override for environment into its base spec and
+// checks the result against the environment's constraints. spec may be nil, in which case
+// the service is read from the graph. Environments missing from the graph are unconstrained.
+func ResolveForEnvironment(g *graph.GlobalGraph, serviceName, environment string, spec *contracts.ServiceSpec) (contracts.ServiceSpec, error) {
+	if spec == nil {
+		node, _ := g.GetNode(serviceName)
+		if node == nil || node.Kind != graph.KindService {
+			return contracts.ServiceSpec{}, fmt.Errorf("service %s not found", serviceName)
+		}
+		spec = &contracts.ServiceSpec{}
+		if err := decodeSpec(node.Spec, spec); err != nil {
+			return contracts.ServiceSpec{}, fmt.Errorf("invalid service %s: %w", serviceName, err)
+		}
+	}
+	merged := spec.ForEnvironment(environment)
+
+	envNode, _ := g.GetNode(environment)
+	if envNode == nil || envNode.Kind != graph.KindEnvironment {
+		return merged, nil
+	}
+	var envSpec contracts.EnvironmentSpec
+	if err := decodeSpec(envNode.Spec, &envSpec); err != nil {
+		return contracts.ServiceSpec{}, fmt.Errorf("invalid environment %s: %w", environment, err)
+	}
+	if err := envSpec.Constraints.Check(merged); err != nil {
+		return contracts.ServiceSpec{}, fmt.Errorf("service %s violates %s constraints: %w", serviceName, environment, err)
+	}
+	return merged, nil
+}
+
+func decodeSpec(spec map[string]interface{}, out interface{}) error {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/contracts"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newOverrideTestGraph(t *testing.T) *graph.GlobalGraph {
+	t.Helper()
+	g := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	for _, contract := range []contracts.Contract{
+		contracts.ApplicationContract{Metadata: contracts.Metadata{Name: "checkout", Owner: "team-a"}},
+		contracts.EnvironmentContract{Metadata: contracts.Metadata{Name: "dev", Owner: "platform"}},
+		contracts.EnvironmentContract{
+			Metadata: contracts.Metadata{Name: "prod", Owner: "platform"},
+			Spec: contracts.EnvironmentSpec{Constraints: contracts.EnvironmentConstraints{
+				MinReplicas:  2,
+				AllowedTiers: []string{"medium", "large"},
+			}},
+		},
+	} {
+		node, err := graph.ResolveContract(contract)
+		require.NoError(t, err)
+		g.AddNode(node)
+	}
+	return g
+}
+
+func TestServiceEnvironmentOverrides(t *testing.T) {
+	g := newOverrideTestGraph(t)
+	service := NewServiceService(g)
+	_, err := service.CreateService("checkout", map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "checkout-api", "owner": "team-a"},
+		"spec":     map[string]interface{}{"port": 8080, "replicas": 1, "tier": "small", "env": map[string]interface{}{"LOG_LEVEL": "debug"}},
+	})
+	require.NoError(t, err)
+
+	// The base config is fine for dev but violates prod's constraints
+	_, err = ResolveForEnvironment(g, "checkout-api", "dev", nil)
+	require.NoError(t, err)
+	_, err = service.EffectiveConfig("checkout", "checkout-api", "prod")
+	assert.ErrorContains(t, err, "violates prod constraints")
+
+	tooFew := 1
+	_, err = service.SetEnvironmentOverride("checkout", "checkout-api", "prod", contracts.ServiceOverride{Replicas: &tooFew, Tier: "large"})
+	assert.ErrorContains(t, err, "below minimum")
+
+	_, err = service.SetEnvironmentOverride("checkout", "checkout-api", "staging", contracts.ServiceOverride{Tier: "large"})
+	assert.ErrorContains(t, err, "environment staging not found")
+
+	replicas := 3
+	_, err = service.SetEnvironmentOverride("checkout", "checkout-api", "prod", contracts.ServiceOverride{
+		Replicas: &replicas,
+		Tier:     "large",
+		Env:      map[string]string{"LOG_LEVEL": "info"},
+	})
+	require.NoError(t, err)
+
+	config, err := ResolveForEnvironment(g, "checkout-api", "prod", nil)
+	require.NoError(t, err)
+	assert.Equal(t, 3, config.Replicas)
+	assert.Equal(t, "large", config.Tier)
+	assert.Equal(t, "info", config.Env["LOG_LEVEL"])
+	assert.Equal(t, 8080, config.Port)
+
+	require.NoError(t, service.DeleteEnvironmentOverride("checkout", "checkout-api", "prod"))
+	assert.Error(t, service.DeleteEnvironmentOverride("checkout", "checkout-api", "prod"))
+	_, err = service.EffectiveConfig("checkout", "checkout-api", "prod")
+	assert.Error(t, err)
+}
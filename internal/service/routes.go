@@ -0,0 +1,71 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/krzachariassen/ZTDP/internal/contracts"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+)
+
+// RouteEntry is a route served by an environment's ingress
+type RouteEntry struct {
+	Host        string              `json:"host"`
+	Path        string              `json:"path"`
+	Service     string              `json:"service"`
+	Application string              `json:"application"`
+	Port        int                 `json:"port"`
+	TLS         *contracts.RouteTLS `json:"tls,omitempty"`
+}
+
+// checkRouteCollisions rejects routes that another service already serves on the same host
+// and path in one of the same environments
+func checkRouteCollisions(nodes map[string]*graph.Node, svc contracts.ServiceContract) error {
+	if len(svc.Spec.Routes) == 0 {
+		return nil
+	}
+	for id, node := range nodes {
+		if node.Kind != graph.KindService || id == svc.Metadata.Name {
+			continue
+		}
+		for _, other := range specOf(node).Routes {
+			for _, route := range svc.Spec.Routes {
+				if route.Overlaps(other) {
+					return fmt.Errorf("route %s%s is already served by %s", route.Host, route.EffectivePath(), id)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// EnvironmentRoutes returns the routes the environment's ingress serves, sorted by host and path
+func (s *ServiceService) EnvironmentRoutes(environment string) ([]RouteEntry, error) {
+	nodes, err := s.Graph.Nodes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get services: %w", err)
+	}
+	if node, ok := nodes[environment]; !ok || node.Kind != graph.KindEnvironment {
+		return nil, fmt.Errorf("environment %s not found", environment)
+	}
+
+	routes := []RouteEntry{}
+	for id, node := range nodes {
+		if node.Kind != graph.KindService {
+			continue
+		}
+		spec := specOf(node)
+		for _, route := range spec.Routes {
+			if route.AppliesIn(environment) {
+				routes = append(routes, RouteEntry{Host: route.Host, Path: route.EffectivePath(), Service: id, Application: spec.Application, Port: spec.Port, TLS: route.TLS})
+			}
+		}
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Host != routes[j].Host {
+			return routes[i].Host < routes[j].Host
+		}
+		return routes[i].Path < routes[j].Path
+	})
+	return routes, nil
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/contracts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvironmentRoutes(t *testing.T) {
+	g := newOverrideTestGraph(t)
+	service := NewServiceService(g)
+	create := func(name string, spec map[string]interface{}) error {
+		_, err := service.CreateService("checkout", map[string]interface{}{
+			"metadata": map[string]interface{}{"name": name, "owner": "team-a"},
+			"spec":     spec,
+		})
+		return err
+	}
+
+	require.NoError(t, create("checkout-web", map[string]interface{}{"port": 8080, "public": true, "routes": []interface{}{
+		map[string]interface{}{"host": "shop.example.com", "tls": map[string]interface{}{"issuer": "letsencrypt"}},
+		map[string]interface{}{"host": "shop-dev.example.com", "environments": []interface{}{"dev"}},
+	}}))
+	require.NoError(t, create("checkout-api", map[string]interface{}{"port": 9090, "public": true, "routes": []interface{}{
+		map[string]interface{}{"host": "shop.example.com", "path": "/api"},
+	}}))
+
+	err := create("checkout-admin", map[string]interface{}{"port": 9091, "public": true, "routes": []interface{}{
+		map[string]interface{}{"host": "shop.example.com", "path": "/api", "environments": []interface{}{"prod"}},
+	}})
+	assert.ErrorContains(t, err, "already served by checkout-api")
+	err = create("checkout-admin", map[string]interface{}{"port": 9091, "routes": []interface{}{
+		map[string]interface{}{"host": "admin.example.com"},
+	}})
+	assert.ErrorContains(t, err, "require a public service")
+
+	routes, err := service.EnvironmentRoutes("prod")
+	require.NoError(t, err)
+	assert.Equal(t, []RouteEntry{
+		{Host: "shop.example.com", Path: "/", Service: "checkout-web", Application: "checkout", Port: 8080, TLS: &contracts.RouteTLS{Issuer: "letsencrypt"}},
+		{Host: "shop.example.com", Path: "/api", Service: "checkout-api", Application: "checkout", Port: 9090},
+	}, routes)
+
+	routes, err = service.EnvironmentRoutes("dev")
+	require.NoError(t, err)
+	assert.Len(t, routes, 3)
+
+	_, err = service.EnvironmentRoutes("qa")
+	assert.ErrorContains(t, err, "not found")
+}
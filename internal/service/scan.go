@@ -0,0 +1,143 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/contracts"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+)
+
+// scanTimeout bounds a scan triggered by creating a service version
+const scanTimeout = 2 * time.Minute
+
+// Scanner produces an SBOM and vulnerability report for a service version
+type Scanner interface {
+	Scan(ctx context.Context, serviceName, version string) (*contracts.ScanReport, error)
+}
+
+var (
+	scannerMu sync.RWMutex
+	scanner   Scanner
+)
+
+// SetScanner sets the scanner triggered when a service version is created without a
+// scan report (called from main.go). Nil disables triggered scans; reports can still be
+// attached by CI through the API.
+func SetScanner(s Scanner) {
+	scannerMu.Lock()
+	defer scannerMu.Unlock()
+	scanner = s
+}
+
+func getScanner() Scanner {
+	scannerMu.RLock()
+	defer scannerMu.RUnlock()
+	return scanner
+}
+
+// HTTPScanner asks an external scanning service for a report. It POSTs
+// {"service": ..., "version": ...} and expects a contracts.ScanReport in response.
+type HTTPScanner struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewHTTPScanner creates a scanner calling url
+func NewHTTPScanner(url string) *HTTPScanner {
+	return &HTTPScanner{URL: url, Client: &http.Client{Timeout: scanTimeout}}
+}
+
+// Scan implements Scanner
+func (s *HTTPScanner) Scan(ctx context.Context, serviceName, version string) (*contracts.ScanReport, error) {
+	body, _ := json.Marshal(map[string]string{"service": serviceName, "version": version})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("scanner request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("scanner returned %s", resp.Status)
+	}
+
+	var report contracts.ScanReport
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return nil, fmt.Errorf("invalid scan report: %w", err)
+	}
+	return &report, nil
+}
+
+// AttachScanReport stores a scan report on a service version, replacing any earlier one
+func (s *ServiceService) AttachScanReport(serviceName, version string, report contracts.ScanReport) (*contracts.ScanReport, error) {
+	id := serviceName + ":" + version
+	node, _ := s.Graph.GetNode(id)
+	if node == nil || node.Kind != graph.KindServiceVersion {
+		return nil, fmt.Errorf("service version %s not found", id)
+	}
+	if err := report.Validate(); err != nil {
+		return nil, err
+	}
+	if report.ScannedAt.IsZero() {
+		report.ScannedAt = time.Now().UTC()
+	}
+
+	if node.Metadata == nil {
+		node.Metadata = map[string]interface{}{}
+	}
+	node.Metadata[contracts.ScanMetadataKey] = report.ToMetadata()
+	if err := s.Graph.UpdateNode(node); err != nil {
+		return nil, err
+	}
+	s.logger.Info("🔬 Stored %s scan for %s: %d critical, %d high", report.Scanner, id,
+		report.Count(contracts.SeverityCritical), report.Count(contracts.SeverityHigh))
+	return &report, nil
+}
+
+// GetScanReport returns the scan report stored on a service version
+func (s *ServiceService) GetScanReport(serviceName, version string) (*contracts.ScanReport, error) {
+	id := serviceName + ":" + version
+	node, _ := s.Graph.GetNode(id)
+	if node == nil || node.Kind != graph.KindServiceVersion {
+		return nil, fmt.Errorf("service version %s not found", id)
+	}
+	report, ok := contracts.ScanReportFromMetadata(node.Metadata)
+	if !ok {
+		return nil, fmt.Errorf("service version %s has not been scanned", id)
+	}
+	return report, nil
+}
+
+// scanVersion stores the report supplied with a new version, or triggers the configured
+// scanner. A failed scan leaves the version unscanned rather than failing its creation.
+func (s *ServiceService) scanVersion(serviceName, version string, supplied *contracts.ScanReport) error {
+	if supplied != nil {
+		_, err := s.AttachScanReport(serviceName, version, *supplied)
+		return err
+	}
+	scanner := getScanner()
+	if scanner == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), scanTimeout)
+	defer cancel()
+	report, err := scanner.Scan(ctx, serviceName, version)
+	if err == nil {
+		_, err = s.AttachScanReport(serviceName, version, *report)
+	}
+	if err != nil {
+		s.logger.Warn("⚠️ Scan of %s:%s failed, version left unscanned: %v", serviceName, version, err)
+	}
+	return nil
+}
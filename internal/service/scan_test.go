@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/contracts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubScanner struct {
+	report *contracts.ScanReport
+	err    error
+	calls  int
+}
+
+func (s *stubScanner) Scan(ctx context.Context, serviceName, version string) (*contracts.ScanReport, error) {
+	s.calls++
+	return s.report, s.err
+}
+
+func TestCreateServiceVersionStoresScan(t *testing.T) {
+	g := newOverrideTestGraph(t)
+	service := NewServiceService(g)
+	_, err := service.CreateService("checkout", map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "checkout-api", "owner": "team-a"},
+		"spec":     map[string]interface{}{"port": 8080},
+	})
+	require.NoError(t, err)
+
+	// A report supplied with the version is stored as-is
+	created, err := service.CreateServiceVersion("checkout-api", map[string]interface{}{
+		"version": "1.0.0",
+		"scan": map[string]interface{}{
+			"scanner":         "trivy",
+			"vulnerabilities": []interface{}{map[string]interface{}{"id": "CVE-2024-3094", "severity": "CRITICAL", "package": "xz"}},
+		},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, created, "scan")
+	report, err := service.GetScanReport("checkout-api", "1.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Count(contracts.SeverityCritical))
+
+	// Without a report the configured scanner runs
+	scanner := &stubScanner{report: &contracts.ScanReport{Scanner: "grype"}}
+	SetScanner(scanner)
+	defer SetScanner(nil)
+	_, err = service.CreateServiceVersion("checkout-api", map[string]interface{}{"version": "1.1.0"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, scanner.calls)
+	report, err = service.GetScanReport("checkout-api", "1.1.0")
+	require.NoError(t, err)
+	assert.Equal(t, "grype", report.Scanner)
+
+	// A failing scanner leaves the version unscanned but created
+	scanner.err = errors.New("scanner down")
+	_, err = service.CreateServiceVersion("checkout-api", map[string]interface{}{"version": "1.2.0"})
+	require.NoError(t, err)
+	_, err = service.GetScanReport("checkout-api", "1.2.0")
+	assert.ErrorContains(t, err, "has not been scanned")
+
+	_, err = service.AttachScanReport("checkout-api", "1.2.0", contracts.ScanReport{Scanner: "trivy", Vulnerabilities: []contracts.Vulnerability{{ID: "CVE-1", Severity: "severe"}}})
+	assert.ErrorContains(t, err, "unknown severity")
+	_, err = service.AttachScanReport("checkout-api", "9.9.9", contracts.ScanReport{Scanner: "trivy"})
+	assert.ErrorContains(t, err, "not found")
+}
@@ -12,13 +12,16 @@ import (
 	"github.com/krzachariassen/ZTDP/internal/events"
 	"github.com/krzachariassen/ZTDP/internal/graph"
 	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/krzachariassen/ZTDP/internal/quotas"
 	"github.com/krzachariassen/ZTDP/internal/resources"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
 )
 
 // ServiceService - ALL domain logic for services (business logic, AI extraction, persistence)
 type ServiceService struct {
 	Graph      *graph.GlobalGraph
 	aiProvider ai.AIProvider
+	extractor  *ai.Extractor
 	eventBus   *events.EventBus
 	logger     *logging.Logger
 }
@@ -47,9 +50,12 @@ func NewServiceService(g *graph.GlobalGraph) *ServiceService {
 
 // NewAIServiceService creates AI-native service with all dependencies
 func NewAIServiceService(g *graph.GlobalGraph, aiProvider ai.AIProvider, eventBus *events.EventBus) *ServiceService {
+	extractor := ai.NewExtractor(aiProvider)
+	extractor.Register(serviceExtraction)
 	return &ServiceService{
 		Graph:      g,
 		aiProvider: aiProvider,
+		extractor:  extractor,
 		eventBus:   eventBus,
 		logger:     logging.GetLogger().ForComponent("service-domain"),
 	}
@@ -61,6 +67,10 @@ func (s *ServiceService) HandleServiceEvent(ctx context.Context, event *events.E
 
 	// Extract intent and parameters using AI (domain owns this)
 	params, err := s.ExtractServiceParameters(ctx, userMessage)
+	var lowConfidence *ai.LowConfidenceError
+	if errors.As(err, &lowConfidence) {
+		return agentframework.ClarificationResponse(ctx, event, lowConfidence.Clarification, lowConfidence.Confidence), nil
+	}
 	if err != nil {
 		return s.createErrorResponse(event, fmt.Sprintf("Failed to extract parameters: %v", err)), nil
 	}
@@ -68,11 +78,6 @@ func (s *ServiceService) HandleServiceEvent(ctx context.Context, event *events.E
 	s.logger.Info("🤖 AI extracted - action: %s, service: %s, app: %s, confidence: %.2f",
 		params.Action, params.ServiceName, params.ApplicationName, params.Confidence)
 
-	// Check confidence level
-	if params.Confidence < 0.7 {
-		return s.createClarificationResponse(event, params.Clarification), nil
-	}
-
 	// Route to appropriate handler based on AI-extracted action
 	switch params.Action {
 	case "create":
@@ -88,49 +93,38 @@ func (s *ServiceService) HandleServiceEvent(ctx context.Context, event *events.E
 	}
 }
 
-// ExtractServiceParameters - Service domain owns AI extraction
-func (s *ServiceService) ExtractServiceParameters(ctx context.Context, userMessage string) (*ServiceDomainParams, error) {
-	if s.aiProvider == nil {
-		return nil, fmt.Errorf("AI provider not available")
-	}
-
-	systemPrompt := `You are a service management assistant. Parse the user's request and extract the action and parameters.
-
-Available actions: list, create, update, delete, show, get, version
-
-Response format must be valid JSON:
-{
-  "action": "list|create|update|delete|show|get|version",
-  "service_name": "service name if specified or null",
-  "application_name": "application name if specified or null",
-  "port": 8080,
-  "public": true,
-  "version": "version if specified or null",
-  "details": "any additional context",
-  "confidence": 0.0-1.0,
-  "clarification": "what to ask if confidence < 0.7"
-}
-
-IMPORTANT: port must be a number (integer), not a string. If no port specified, use 0.
-IMPORTANT: public must be a boolean (true/false), not a string.
-
-Examples:
+// serviceExtraction is the service domain's schema for the shared extractor
+var serviceExtraction = ai.ExtractionSchema{
+	Domain: "service",
+	Fields: map[string]ai.ExtractionField{
+		"action":           {Type: ai.ExtractionString, Required: true, Enum: []string{"list", "create", "update", "delete", "show", "get", "version"}},
+		"service_name":     {Type: ai.ExtractionString, Description: "service name if specified"},
+		"application_name": {Type: ai.ExtractionString, Description: "application name if specified"},
+		"port":             {Type: ai.ExtractionNumber, Description: "port number, 0 if not specified"},
+		"public":           {Type: ai.ExtractionBoolean, Description: "whether the service is public facing"},
+		"version":          {Type: ai.ExtractionString, Description: "version if specified"},
+		"details":          {Type: ai.ExtractionString, Description: "any additional context"},
+	},
+	Instructions: `Examples:
 - "list services for myapp" -> {"action": "list", "application_name": "myapp", "port": 0, "public": false, "confidence": 0.9}
 - "create service api in myapp" -> {"action": "create", "application_name": "myapp", "service_name": "api", "port": 0, "public": false, "confidence": 0.9}
 - "create service checkout-api for checkout application on port 8080 that is public facing" -> {"action": "create", "service_name": "checkout-api", "application_name": "checkout", "port": 8080, "public": true, "confidence": 0.95}
-- "show me the payment service details" -> {"action": "show", "service_name": "payment", "port": 0, "public": false, "confidence": 0.9}`
+- "show me the payment service details" -> {"action": "show", "service_name": "payment", "port": 0, "public": false, "confidence": 0.9}
 
-	response, err := s.aiProvider.CallAI(ctx, systemPrompt, userMessage)
-	if err != nil {
-		return nil, fmt.Errorf("AI extraction failed: %w", err)
-	}
+The service contract you are collecting parameters for:
+` + contracts.PromptSummary(contracts.ServiceContract{}.Kind()),
+}
 
-	var params ServiceDomainParams
-	if err := json.Unmarshal([]byte(response), &params); err != nil {
-		return nil, fmt.Errorf("failed to parse AI response: %w", err)
+// ExtractServiceParameters - Service domain owns AI extraction. A *ai.LowConfidenceError
+// comes back with the parameters extracted so far.
+func (s *ServiceService) ExtractServiceParameters(ctx context.Context, userMessage string) (*ServiceDomainParams, error) {
+	if s.aiProvider == nil {
+		return nil, fmt.Errorf("AI provider not available")
 	}
 
-	return &params, nil
+	var params ServiceDomainParams
+	err := s.extractor.Extract(ctx, serviceExtraction.Domain, userMessage, &params)
+	return &params, err
 }
 
 // AI-native action handlers
@@ -273,19 +267,6 @@ func (s *ServiceService) createErrorResponse(originalEvent *events.Event, errorM
 	}
 }
 
-func (s *ServiceService) createClarificationResponse(originalEvent *events.Event, clarification string) *events.Event {
-	return &events.Event{
-		Source:  "service-agent",
-		Subject: "service.clarification",
-		Payload: map[string]interface{}{
-			"status":         "clarification",
-			"message":        clarification,
-			"correlation_id": originalEvent.Payload["correlation_id"],
-			"request_id":     originalEvent.Payload["request_id"],
-		},
-	}
-}
-
 // CreateService creates a new service from raw data
 func (s *ServiceService) CreateService(appName string, serviceData map[string]interface{}) (map[string]interface{}, error) {
 	// Convert raw data to contract internally
@@ -330,20 +311,38 @@ func (s *ServiceService) GetService(appName, serviceName string) (map[string]int
 	return contractToMap(contract), nil
 }
 
-// CreateServiceVersion creates a service version from raw data
+// CreateServiceVersion creates a service version from raw data. A "scan" field carrying a
+// contracts.ScanReport is stored on the version; without one the configured scanner runs.
 func (s *ServiceService) CreateServiceVersion(serviceName string, versionData map[string]interface{}) (map[string]interface{}, error) {
 	// Convert raw data to contract internally
 	var ver contracts.ServiceVersionContract
 	if err := mapToContract(versionData, &ver); err != nil {
 		return nil, err
 	}
+	var report *contracts.ScanReport
+	if scanData, ok := versionData[contracts.ScanMetadataKey].(map[string]interface{}); ok {
+		report = &contracts.ScanReport{}
+		if err := mapToContract(scanData, report); err != nil {
+			return nil, fmt.Errorf("invalid scan report: %w", err)
+		}
+		if err := report.Validate(); err != nil {
+			return nil, err
+		}
+	}
 
 	// Use existing contract-based logic
 	if err := s.createServiceVersionInternal(serviceName, ver); err != nil {
 		return nil, err
 	}
+	if err := s.scanVersion(serviceName, ver.Version, report); err != nil {
+		return nil, err
+	}
 
-	return contractToMap(ver), nil
+	result := contractToMap(ver)
+	if stored, err := s.GetScanReport(serviceName, ver.Version); err == nil {
+		result[contracts.ScanMetadataKey] = stored
+	}
+	return result, nil
 }
 
 // ListServiceVersions returns service versions as basic maps
@@ -374,7 +373,9 @@ func (s *ServiceService) CreateServiceFromContract(ctx context.Context, svc *con
 		return nil, err
 	}
 
-	s.Graph.AddNode(node)
+	if err := s.Graph.AddNode(node); err != nil {
+		return nil, err
+	}
 	s.Graph.AddEdge(svc.Spec.Application, svc.Metadata.Name, "owns")
 
 	if err := s.Graph.Save(); err != nil {
@@ -414,13 +415,39 @@ func (s *ServiceService) createServiceInternal(appName string, svc contracts.Ser
 	if err := svc.Validate(); err != nil {
 		return err
 	}
+	if existing, _ := s.Graph.GetNode(svc.Metadata.Name); existing == nil {
+		if err := quotas.NewService(s.Graph).CheckServiceCreation(appName); err != nil {
+			return err
+		}
+	}
+	nodes, err := s.Graph.Nodes()
+	if err != nil {
+		return err
+	}
+	if err := checkRouteCollisions(nodes, svc); err != nil {
+		return err
+	}
+	existing, err := s.Graph.Graph()
+	if err != nil {
+		return err
+	}
+	if err := checkDeprecatedDependencies(existing, svc); err != nil {
+		return err
+	}
 	node, err := graph.ResolveContract(svc)
 	if err != nil {
 		return err
 	}
-	s.Graph.AddNode(node)
+	if err := s.Graph.AddNode(node); err != nil {
+		return err
+	}
 	s.Graph.AddEdge(appName, svc.Metadata.Name, "owns")
-	return s.Graph.Save()
+
+	// Declared dependencies become consumes edges, whichever of the two services came first
+	return s.Graph.Update(func(current *graph.Graph) error {
+		linkDependencies(current, svc)
+		return nil
+	})
 }
 
 func (s *ServiceService) listServicesInternal(appName string) ([]contracts.ServiceContract, error) {
@@ -494,7 +521,9 @@ func (s *ServiceService) createServiceVersionInternal(serviceName string, ver co
 		return err
 	}
 
-	s.Graph.AddNode(node)
+	if err := s.Graph.AddNode(node); err != nil {
+		return err
+	}
 	s.Graph.AddEdge(serviceName, id, "has_version")
 	return s.Graph.Save()
 }
@@ -7,11 +7,11 @@ import (
 	"strings"
 	"testing"
 
-	"github.com/krzachariassen/ZTDP/internal/agentFramework"
 	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
 	"github.com/krzachariassen/ZTDP/internal/ai"
 	"github.com/krzachariassen/ZTDP/internal/events"
 	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -118,7 +118,7 @@ func TestServiceAgentEventHandling(t *testing.T) {
 	}
 
 	// Cast to framework agent to access ProcessEvent
-	agent, ok := baseAgent.(*agentFramework.BaseAgent)
+	agent, ok := baseAgent.(*agentframework.BaseAgent)
 	if !ok {
 		t.Fatalf("Expected BaseAgent, got %T", baseAgent)
 	}
@@ -216,7 +216,7 @@ func TestServiceAgentAIIntegration(t *testing.T) {
 			baseAgent, err := NewServiceAgent(mockGraph, realAIProvider, eventBus, registry)
 			assert.NoError(t, err)
 
-			agent := baseAgent.(*agentFramework.BaseAgent)
+			agent := baseAgent.(*agentframework.BaseAgent)
 
 			// Create test event
 			serviceEvent := &events.Event{
@@ -294,7 +294,7 @@ func TestServiceAgentBusinessLogicIntegration(t *testing.T) {
 		t.Fatalf("Failed to create agent: %v", err)
 	}
 
-	agent, ok := baseAgent.(*agentFramework.BaseAgent)
+	agent, ok := baseAgent.(*agentframework.BaseAgent)
 	if !ok {
 		t.Fatalf("Expected BaseAgent, got %T", baseAgent)
 	}
@@ -380,7 +380,7 @@ func TestServiceAgentWorkflow(t *testing.T) {
 		}
 
 		// Act - Start the service workflow with real AI
-		response, err := serviceAgent.(*agentFramework.BaseAgent).ProcessEvent(context.Background(), serviceEvent)
+		response, err := serviceAgent.(*agentframework.BaseAgent).ProcessEvent(context.Background(), serviceEvent)
 		if err != nil {
 			t.Fatalf("Service creation failed: %v", err)
 		}
@@ -489,7 +489,7 @@ func TestServiceAgentErrorHandling(t *testing.T) {
 			}
 
 			// Act
-			response, err := agent.(*agentFramework.BaseAgent).ProcessEvent(context.Background(), serviceEvent)
+			response, err := agent.(*agentframework.BaseAgent).ProcessEvent(context.Background(), serviceEvent)
 
 			// Assert
 			if tt.expectError {
@@ -0,0 +1,255 @@
+// Package statusfeed turns deployment progress and orchestration events into a stable stream
+// of status updates for the web UI, so clients do not depend on raw event bus subjects.
+package statusfeed
+
+import (
+	"sync"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/deployments"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
+)
+
+// Kinds of update
+const (
+	KindDeployment    = "deployment"
+	KindOrchestration = "orchestration"
+)
+
+// subscriberBuffer is how many updates a slow client may fall behind before updates are dropped
+const subscriberBuffer = 64
+
+// requestTTL is how long a dispatched request is followed without hearing back from its agent
+const requestTTL = 10 * time.Minute
+
+// Update is one status change sent to clients
+type Update struct {
+	Kind          string    `json:"kind"`
+	Timestamp     time.Time `json:"timestamp"`
+	CorrelationID string    `json:"correlation_id,omitempty"`
+	Application   string    `json:"application,omitempty"`
+	Environment   string    `json:"environment,omitempty"`
+	User          string    `json:"user,omitempty"` // who made the chat request, for orchestration updates
+	Intent        string    `json:"intent,omitempty"`
+	Agent         string    `json:"agent,omitempty"`
+	Step          string    `json:"step,omitempty"`
+	State         string    `json:"state"` // deployments: started | retrying | completed | failed; orchestration: dispatched | acknowledged | rejected | completed | failed
+	Percent       float64   `json:"percent,omitempty"`
+	ETASeconds    float64   `json:"eta_seconds,omitempty"`
+	Message       string    `json:"message,omitempty"`
+}
+
+// Filter selects the updates a client receives
+type Filter struct {
+	User         string   // orchestration of this user's requests
+	Owner        string   // deployments of applications with this owner
+	Applications []string // deployments of these applications
+}
+
+// Empty reports whether the filter selects nothing
+func (f Filter) Empty() bool {
+	return f.User == "" && f.Owner == "" && len(f.Applications) == 0
+}
+
+type subscriber struct {
+	filter  Filter
+	updates chan Update
+}
+
+type request struct {
+	user, intent, agent string
+	at                  time.Time
+}
+
+// Feed follows the event bus and fans updates out to subscribers
+type Feed struct {
+	graph  *graph.GlobalGraph
+	logger *logging.Logger
+	now    func() time.Time
+
+	mu          sync.Mutex
+	subscribers map[*subscriber]bool
+	requests    map[string]request // orchestrator requests awaiting a response, by correlation ID
+	dropped     int
+}
+
+// NewFeed starts following the events on bus; application owners are looked up in the graph
+func NewFeed(globalGraph *graph.GlobalGraph, bus *events.EventBus) *Feed {
+	f := &Feed{
+		graph:       globalGraph,
+		logger:      logging.GetLogger().ForComponent("status-feed"),
+		now:         time.Now,
+		subscribers: map[*subscriber]bool{},
+		requests:    map[string]request{},
+	}
+	bus.Subscribe(events.EventTypeNotify, func(event events.Event) error {
+		f.observeNotify(event)
+		return nil
+	})
+	bus.Subscribe(events.EventTypeRequest, func(event events.Event) error {
+		f.observeRequest(event)
+		return nil
+	})
+	bus.Subscribe(events.EventTypeResponse, func(event events.Event) error {
+		f.observeResponse(event)
+		return nil
+	})
+	return f
+}
+
+// Subscribe returns the updates matching filter until cancel is called
+func (f *Feed) Subscribe(filter Filter) (<-chan Update, func()) {
+	s := &subscriber{filter: filter, updates: make(chan Update, subscriberBuffer)}
+	f.mu.Lock()
+	f.subscribers[s] = true
+	f.mu.Unlock()
+
+	var once sync.Once
+	return s.updates, func() {
+		once.Do(func() {
+			f.mu.Lock()
+			delete(f.subscribers, s)
+			f.mu.Unlock()
+			close(s.updates)
+		})
+	}
+}
+
+func (f *Feed) observeNotify(event events.Event) {
+	switch event.Subject {
+	case deployments.ProgressSubject:
+		application, _ := event.Payload["application"].(string)
+		update := Update{
+			Kind:          KindDeployment,
+			Timestamp:     timestampOf(event),
+			Application:   application,
+			CorrelationID: stringOf(event.Payload["correlation_id"]),
+			Environment:   stringOf(event.Payload["environment"]),
+			Step:          stringOf(event.Payload["step"]),
+			State:         stringOf(event.Payload["state"]),
+			Message:       stringOf(event.Payload["message"]),
+		}
+		update.Percent, _ = event.Payload["percent"].(float64)
+		update.ETASeconds, _ = event.Payload["eta_seconds"].(float64)
+		f.publish(update)
+	case agentframework.TaskAckSubject, agentframework.TaskNackSubject:
+		state, message := "acknowledged", ""
+		if event.Subject == agentframework.TaskNackSubject {
+			state, message = "rejected", stringOf(event.Payload["reason"])
+		}
+		f.publishOrchestration(stringOf(event.Payload["correlation_id"]), event.Source, state, message, timestampOf(event), false)
+	}
+}
+
+// observeRequest follows requests the orchestrator dispatches for chat requests
+func (f *Feed) observeRequest(event events.Event) {
+	if event.Source != "orchestrator" {
+		return
+	}
+	correlationID := stringOf(event.Payload["correlation_id"])
+	if correlationID == "" {
+		return
+	}
+	now := f.now()
+	f.mu.Lock()
+	for id, pending := range f.requests {
+		if now.Sub(pending.at) > requestTTL {
+			delete(f.requests, id)
+		}
+	}
+	f.requests[correlationID] = request{
+		user:   stringOf(event.Payload["user_id"]),
+		intent: stringOf(event.Payload["intent"]),
+		agent:  stringOf(event.Payload[agentframework.TargetAgentKey]),
+		at:     now,
+	}
+	f.mu.Unlock()
+	f.publishOrchestration(correlationID, stringOf(event.Payload[agentframework.TargetAgentKey]), "dispatched", "", timestampOf(event), false)
+}
+
+func (f *Feed) observeResponse(event events.Event) {
+	state, message := "completed", stringOf(event.Payload["message"])
+	if event.Payload["status"] == "error" {
+		state, message = "failed", stringOf(event.Payload["error"])
+	}
+	f.publishOrchestration(stringOf(event.Payload["correlation_id"]), event.Source, state, message, timestampOf(event), true)
+}
+
+// publishOrchestration sends an update about a request the orchestrator dispatched
+func (f *Feed) publishOrchestration(correlationID, agent, state, message string, at time.Time, finished bool) {
+	f.mu.Lock()
+	pending, ok := f.requests[correlationID]
+	if ok && finished {
+		delete(f.requests, correlationID)
+	}
+	f.mu.Unlock()
+	if !ok {
+		return
+	}
+	if agent == "" {
+		agent = pending.agent
+	}
+	f.publish(Update{
+		Kind: KindOrchestration, Timestamp: at, CorrelationID: correlationID,
+		User: pending.user, Intent: pending.intent, Agent: agent, State: state, Message: message,
+	})
+}
+
+func (f *Feed) publish(update Update) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	owner := ""
+	ownerLooked := false
+	for s := range f.subscribers {
+		if !f.matches(s.filter, update, &owner, &ownerLooked) {
+			continue
+		}
+		select {
+		case s.updates <- update:
+		default:
+			f.dropped++
+			f.logger.Warn("⚠️ Status stream client is too slow; dropped a %s update (%d dropped so far)", update.Kind, f.dropped)
+		}
+	}
+}
+
+// matches reports whether a subscriber receives the update; the application's owner is looked
+// up once per update, and only if a subscriber filters by owner
+func (f *Feed) matches(filter Filter, update Update, owner *string, looked *bool) bool {
+	switch update.Kind {
+	case KindOrchestration:
+		return filter.User != "" && update.User == filter.User
+	case KindDeployment:
+		for _, application := range filter.Applications {
+			if application == update.Application {
+				return true
+			}
+		}
+		if filter.Owner == "" {
+			return false
+		}
+		if !*looked {
+			*looked = true
+			if node, err := f.graph.GetNode(update.Application); err == nil && node != nil {
+				*owner, _ = node.Metadata["owner"].(string)
+			}
+		}
+		return *owner == filter.Owner
+	}
+	return false
+}
+
+func timestampOf(event events.Event) time.Time {
+	if event.Timestamp == 0 {
+		return time.Now().UTC()
+	}
+	return time.Unix(0, event.Timestamp).UTC()
+}
+
+func stringOf(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
@@ -0,0 +1,125 @@
+package statusfeed
+
+import (
+	"testing"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/deployments"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestFeed(t *testing.T) (*Feed, *events.EventBus) {
+	gg := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	require.NoError(t, gg.AddNode(&graph.Node{
+		ID:       "checkout",
+		Kind:     graph.KindApplication,
+		Metadata: map[string]interface{}{"owner": "team-payments"},
+		Spec:     map[string]interface{}{},
+	}))
+	bus := events.NewEventBus(nil, false)
+	return NewFeed(gg, bus), bus
+}
+
+func receive(t *testing.T, updates <-chan Update) Update {
+	t.Helper()
+	select {
+	case update := <-updates:
+		return update
+	case <-time.After(time.Second):
+		t.Fatal("no update received")
+		return Update{}
+	}
+}
+
+func assertNothing(t *testing.T, updates <-chan Update) {
+	t.Helper()
+	select {
+	case update := <-updates:
+		t.Fatalf("unexpected update: %+v", update)
+	default:
+	}
+}
+
+func TestDeploymentUpdatesFilterByApplicationAndOwner(t *testing.T) {
+	feed, bus := newTestFeed(t)
+
+	byApp, cancelApp := feed.Subscribe(Filter{Applications: []string{"checkout"}})
+	defer cancelApp()
+	byOwner, cancelOwner := feed.Subscribe(Filter{Owner: "team-payments"})
+	defer cancelOwner()
+	other, cancelOther := feed.Subscribe(Filter{Owner: "team-search"})
+	defer cancelOther()
+
+	require.NoError(t, bus.Emit(events.EventTypeNotify, "deployment-agent", deployments.ProgressSubject, map[string]interface{}{
+		"application": "checkout",
+		"environment": "dev",
+		"step":        "deploy",
+		"state":       "started",
+		"percent":     50.0,
+	}))
+
+	update := receive(t, byApp)
+	assert.Equal(t, KindDeployment, update.Kind)
+	assert.Equal(t, "dev", update.Environment)
+	assert.Equal(t, 50.0, update.Percent)
+	assert.Equal(t, "started", receive(t, byOwner).State)
+	assertNothing(t, other)
+}
+
+func TestOrchestrationUpdatesFollowRequests(t *testing.T) {
+	feed, bus := newTestFeed(t)
+
+	mine, cancelMine := feed.Subscribe(Filter{User: "alice"})
+	defer cancelMine()
+	theirs, cancelTheirs := feed.Subscribe(Filter{User: "bob"})
+	defer cancelTheirs()
+
+	require.NoError(t, bus.Emit(events.EventTypeRequest, "orchestrator", "deploy", map[string]interface{}{
+		"correlation_id":              "c-1",
+		"intent":                      "deploy application",
+		"user_id":                     "alice",
+		agentframework.TargetAgentKey: "deployment-agent",
+	}))
+	require.NoError(t, bus.Emit(events.EventTypeNotify, "deployment-agent", agentframework.TaskAckSubject, map[string]interface{}{
+		"correlation_id": "c-1",
+	}))
+	require.NoError(t, bus.Emit(events.EventTypeResponse, "deployment-agent", "deploy", map[string]interface{}{
+		"correlation_id": "c-1",
+		"status":         "error",
+		"error":          "no capacity",
+	}))
+	// Responses nobody dispatched are ignored
+	require.NoError(t, bus.Emit(events.EventTypeResponse, "deployment-agent", "deploy", map[string]interface{}{
+		"correlation_id": "c-1",
+		"status":         "success",
+	}))
+
+	dispatched := receive(t, mine)
+	assert.Equal(t, "dispatched", dispatched.State)
+	assert.Equal(t, "deployment-agent", dispatched.Agent)
+	assert.Equal(t, "deploy application", dispatched.Intent)
+	assert.Equal(t, "acknowledged", receive(t, mine).State)
+	failed := receive(t, mine)
+	assert.Equal(t, "failed", failed.State)
+	assert.Equal(t, "no capacity", failed.Message)
+	assertNothing(t, mine)
+	assertNothing(t, theirs)
+}
+
+func TestCancelStopsUpdates(t *testing.T) {
+	feed, bus := newTestFeed(t)
+
+	updates, cancel := feed.Subscribe(Filter{Applications: []string{"checkout"}})
+	cancel()
+	cancel()
+	require.NoError(t, bus.Emit(events.EventTypeNotify, "deployment-agent", deployments.ProgressSubject, map[string]interface{}{
+		"application": "checkout",
+		"state":       "completed",
+	}))
+	_, open := <-updates
+	assert.False(t, open)
+}
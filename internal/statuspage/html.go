@@ -0,0 +1,89 @@
+package statuspage
+
+import (
+	"html/template"
+	"io"
+	"sort"
+	"time"
+)
+
+// htmlTemplate renders a page as a self-contained HTML document that dashboards can embed in
+// an iframe; html/template escapes every value taken from the graph
+var htmlTemplate = template.Must(template.New("status-page").Funcs(template.FuncMap{
+	"timestamp": func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.UTC().Format("2006-01-02 15:04 UTC")
+	},
+	"sorted": func(versions map[string]string) []string {
+		names := make([]string, 0, len(versions))
+		for name := range versions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Application}} status</title>
+<style>
+body { font-family: sans-serif; margin: 1.5em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { text-align: left; padding: 0.3em 0.8em; border-bottom: 1px solid #ddd; }
+.health { display: inline-block; padding: 0.1em 0.6em; border-radius: 0.8em; color: #fff; background: #888; }
+.healthy { background: #2e7d32; }
+.deploying { background: #1565c0; }
+.degraded { background: #c62828; }
+</style>
+</head>
+<body>
+<h1>{{.Application}} <span class="health {{.Health}}">{{.Health}}</span></h1>
+<p>Generated {{timestamp .GeneratedAt}}</p>
+{{- if .Incidents}}
+<h2>Open incidents</h2>
+<table>
+<tr><th>Workflow</th><th>Environment</th><th>Severity</th><th>Status</th><th>Opened</th></tr>
+{{- range .Incidents}}
+<tr><td>{{.WorkflowID}}</td><td>{{.Environment}}</td><td>{{.Severity}}</td><td>{{.Status}}{{if .WaitingFor}} ({{.WaitingFor}}){{end}}</td><td>{{timestamp .OpenedAt}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- range .Environments}}
+<h2>{{.Environment}} <span class="health {{.Health}}">{{.Health}}</span></h2>
+{{- if .Release}}
+<p>Release {{.Release.ReleaseID}}{{if .Release.Status}}: {{.Release.Status}}{{end}}{{if .Release.DeployedAt}}, deployed {{.Release.DeployedAt}}{{end}}</p>
+{{- else}}
+<p>Nothing deployed</p>
+{{- end}}
+{{- if .Services}}
+<table>
+<tr><th>Service</th><th>Version</th></tr>
+{{- $versions := .Services}}
+{{- range sorted .Services}}
+<tr><td>{{.}}</td><td>{{index $versions .}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .UnhealthyResources}}
+<p>Unhealthy resources: {{range $i, $resource := .UnhealthyResources}}{{if $i}}, {{end}}{{$resource}}{{end}}</p>
+{{- end}}
+{{- if .RecentChanges}}
+<table>
+<tr><th>When</th><th>Deployment</th><th>Status</th><th>By</th><th>Message</th></tr>
+{{- range .RecentChanges}}
+<tr><td>{{timestamp .Timestamp}}</td><td>{{.DeploymentID}}</td><td>{{.Status}}</td><td>{{.Actor}}</td><td>{{.Message}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- end}}
+</body>
+</html>
+`))
+
+// RenderHTML writes the page as an HTML document
+func RenderHTML(w io.Writer, page *Page) error {
+	return htmlTemplate.Execute(w, page)
+}
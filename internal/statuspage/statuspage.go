@@ -0,0 +1,249 @@
+package statuspage
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/deployments"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/workflows"
+)
+
+// Health of an environment, from best to worst; an application's health is the worst of its
+// environments
+const (
+	HealthNotDeployed = "not_deployed"
+	HealthHealthy     = "healthy"
+	HealthDeploying   = "deploying" // the latest deployment is pending or in progress
+	HealthDegraded    = "degraded"  // the latest deployment failed, a resource is unhealthy or an incident is open
+)
+
+var healthRank = map[string]int{HealthNotDeployed: 0, HealthHealthy: 1, HealthDeploying: 2, HealthDegraded: 3}
+
+// IncidentTemplate is the workflow template whose unfinished workflows are open incidents
+const IncidentTemplate = "incident-response"
+
+// RecentChangeLimit is how many status changes are listed per environment
+const RecentChangeLimit = 5
+
+// ErrApplicationNotFound is returned for applications that are not in the graph
+var ErrApplicationNotFound = errors.New("application not found")
+
+// Page summarizes what is running for an application in each environment, for embedding in
+// team dashboards
+type Page struct {
+	Application  string              `json:"application"`
+	Health       string              `json:"health"`
+	GeneratedAt  time.Time           `json:"generated_at"`
+	Environments []EnvironmentStatus `json:"environments"`
+	Incidents    []Incident          `json:"incidents"` // open incidents in every environment
+}
+
+// EnvironmentStatus is the state of the application in one environment
+type EnvironmentStatus struct {
+	Environment        string                       `json:"environment"`
+	Health             string                       `json:"health"`
+	Release            *deployments.DeployedRelease `json:"release,omitempty"`
+	Services           map[string]string            `json:"services"` // deployed version by service
+	UnhealthyResources []string                     `json:"unhealthy_resources,omitempty"`
+	Incidents          []Incident                   `json:"incidents,omitempty"`
+	RecentChanges      []Change                     `json:"recent_changes"` // newest first
+}
+
+// Incident is an incident response workflow that has not finished
+type Incident struct {
+	WorkflowID  string    `json:"workflow_id"`
+	Environment string    `json:"environment"`
+	Severity    string    `json:"severity,omitempty"`
+	Status      string    `json:"status"`
+	WaitingFor  string    `json:"waiting_for,omitempty"`
+	OpenedAt    time.Time `json:"opened_at"`
+}
+
+// Change is a status change of a deployment
+type Change struct {
+	DeploymentID string    `json:"deployment_id"`
+	ReleaseID    string    `json:"release_id"`
+	Region       string    `json:"region,omitempty"`
+	Status       string    `json:"status"`
+	Message      string    `json:"message,omitempty"`
+	Actor        string    `json:"actor,omitempty"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// Service builds status pages from the graph
+type Service struct {
+	graph       *graph.GlobalGraph
+	deployments *deployments.Service
+	workflows   *workflows.Engine // optional; without it no incidents are reported
+}
+
+// NewService creates a status page service. The workflow engine may be nil when workflows
+// are disabled.
+func NewService(globalGraph *graph.GlobalGraph, deploymentService *deployments.Service, engine *workflows.Engine) *Service {
+	return &Service{graph: globalGraph, deployments: deploymentService, workflows: engine}
+}
+
+// Generate builds the status page of an application. It covers the environments the
+// application is allowed in and those it was deployed to.
+func (s *Service) Generate(appName string) (*Page, error) {
+	nodes, err := s.graph.Nodes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph: %w", err)
+	}
+	if node, ok := nodes[appName]; !ok || node.Kind != graph.KindApplication {
+		return nil, fmt.Errorf("%w: %s", ErrApplicationNotFound, appName)
+	}
+	edges, err := s.graph.Edges()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graph: %w", err)
+	}
+	incidents, err := s.openIncidents(appName)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &Page{
+		Application:  appName,
+		Health:       HealthNotDeployed,
+		GeneratedAt:  time.Now().UTC(),
+		Environments: []EnvironmentStatus{},
+		Incidents:    incidents,
+	}
+	names, allowed := environments(nodes, edges, appName)
+	for _, env := range names {
+		status := EnvironmentStatus{Environment: env, RecentChanges: []Change{}}
+		if status.Release, err = s.deployments.LatestRelease(appName, env); err != nil {
+			return nil, err
+		}
+		if status.Release == nil && !allowed[env] {
+			continue
+		}
+		if status.Services, err = s.deployments.DeployedVersions(appName, env); err != nil {
+			return nil, err
+		}
+		history, err := s.deployments.DeploymentHistory(appName, env)
+		if err != nil {
+			return nil, err
+		}
+		status.RecentChanges = recentChanges(history)
+		status.UnhealthyResources = unhealthyResources(nodes, edges, appName, env)
+		for _, incident := range incidents {
+			if incident.Environment == env {
+				status.Incidents = append(status.Incidents, incident)
+			}
+		}
+		status.Health = environmentHealth(status)
+		if healthRank[status.Health] > healthRank[page.Health] {
+			page.Health = status.Health
+		}
+		page.Environments = append(page.Environments, status)
+	}
+	return page, nil
+}
+
+// environments returns every environment by name, with whether the application is allowed in it
+func environments(nodes map[string]*graph.Node, edges map[string][]graph.Edge, appName string) ([]string, map[string]bool) {
+	var names []string
+	allowed := map[string]bool{}
+	for id, node := range nodes {
+		if node.Kind == graph.KindEnvironment {
+			names = append(names, id)
+		}
+	}
+	for _, edge := range edges[appName] {
+		if edge.Type == "allowed_in" {
+			allowed[edge.To] = true
+		}
+	}
+	sort.Strings(names)
+	return names, allowed
+}
+
+// unhealthyResources lists the application's resources deployed to the environment that are
+// marked unhealthy
+func unhealthyResources(nodes map[string]*graph.Node, edges map[string][]graph.Edge, appName, environment string) []string {
+	var unhealthy []string
+	for _, owned := range edges[appName] {
+		node, ok := nodes[owned.To]
+		if owned.Type != graph.EdgeTypeOwns || !ok || node.Kind != graph.KindResource || node.Metadata["health"] != "unhealthy" {
+			continue
+		}
+		for _, edge := range edges[owned.To] {
+			if edge.Type == graph.EdgeTypeDeploy && edge.To == environment {
+				unhealthy = append(unhealthy, owned.To)
+				break
+			}
+		}
+	}
+	sort.Strings(unhealthy)
+	return unhealthy
+}
+
+// openIncidents returns the application's unfinished incident response workflows, newest first
+func (s *Service) openIncidents(appName string) ([]Incident, error) {
+	incidents := []Incident{}
+	if s.workflows == nil {
+		return incidents, nil
+	}
+	list, err := s.workflows.List("")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workflows: %w", err)
+	}
+	for _, workflow := range list {
+		if workflow.Template != IncidentTemplate || workflow.Finished() || fmt.Sprint(workflow.Input["application"]) != appName {
+			continue
+		}
+		incident := Incident{WorkflowID: workflow.ID, Status: workflow.Status, WaitingFor: workflow.WaitingFor, OpenedAt: workflow.CreatedAt}
+		incident.Environment, _ = workflow.Input["environment"].(string)
+		incident.Severity, _ = workflow.Input["severity"].(string)
+		incidents = append(incidents, incident)
+	}
+	return incidents, nil
+}
+
+// recentChanges returns the latest status changes of the deployments, newest first
+func recentChanges(history []deployments.DeploymentAttempt) []Change {
+	changes := []Change{}
+	for _, attempt := range history {
+		for _, change := range attempt.History {
+			changes = append(changes, Change{
+				DeploymentID: attempt.DeploymentID,
+				ReleaseID:    attempt.ReleaseID,
+				Region:       attempt.Region,
+				Status:       change.Status,
+				Message:      change.Message,
+				Actor:        change.Actor,
+				Timestamp:    change.Timestamp,
+			})
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Timestamp.After(changes[j].Timestamp)
+	})
+	if len(changes) > RecentChangeLimit {
+		changes = changes[:RecentChangeLimit]
+	}
+	return changes
+}
+
+// environmentHealth derives the health of an environment from its latest deployment,
+// resources and incidents
+func environmentHealth(status EnvironmentStatus) string {
+	if len(status.UnhealthyResources) > 0 || len(status.Incidents) > 0 {
+		return HealthDegraded
+	}
+	if status.Release == nil {
+		return HealthNotDeployed
+	}
+	switch status.Release.Status {
+	case "failed":
+		return HealthDegraded
+	case "pending", "in_progress", "in-progress":
+		return HealthDeploying
+	default:
+		return HealthHealthy
+	}
+}
@@ -0,0 +1,140 @@
+package statuspage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/deployments"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/workflows"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newStatusTestGraph stores checkout, allowed in dev, staging and prod: 1.1.0 of checkout-api
+// is deployed to staging by a succeeded release, prod runs 1.0.0 but its latest deployment
+// failed, and nothing is deployed to dev
+func newStatusTestGraph(t *testing.T) *graph.GlobalGraph {
+	t.Helper()
+	g := graph.NewGraph()
+	add := func(id, kind string, metadata map[string]interface{}) {
+		g.Nodes[id] = &graph.Node{ID: id, Kind: kind, Metadata: metadata, Spec: map[string]interface{}{}}
+	}
+	add("checkout", graph.KindApplication, map[string]interface{}{"name": "checkout"})
+	add("payments", graph.KindApplication, map[string]interface{}{"name": "payments"})
+	for _, env := range []string{"dev", "staging", "prod", "qa"} {
+		add(env, graph.KindEnvironment, map[string]interface{}{"name": env})
+	}
+	add("checkout-api", graph.KindService, map[string]interface{}{"name": "checkout-api"})
+	add("checkout-api:1.0.0", graph.KindServiceVersion, map[string]interface{}{})
+	add("checkout-api:1.1.0", graph.KindServiceVersion, map[string]interface{}{})
+	add("checkout-db", graph.KindResource, map[string]interface{}{"name": "checkout-db", "health": "unhealthy"})
+
+	g.Edges["checkout"] = []graph.Edge{
+		{To: "dev", Type: "allowed_in"}, {To: "staging", Type: "allowed_in"}, {To: "prod", Type: "allowed_in"},
+		{To: "checkout-api", Type: graph.EdgeTypeOwns}, {To: "checkout-db", Type: graph.EdgeTypeOwns},
+	}
+	g.Edges["checkout-api"] = []graph.Edge{{To: "checkout-api:1.0.0", Type: "has_version"}, {To: "checkout-api:1.1.0", Type: "has_version"}}
+	g.Edges["checkout-api:1.0.0"] = []graph.Edge{{To: "prod", Type: graph.EdgeTypeDeploy}}
+	g.Edges["checkout-api:1.1.0"] = []graph.Edge{{To: "staging", Type: graph.EdgeTypeDeploy}}
+	g.Edges["checkout-db"] = []graph.Edge{{To: "prod", Type: graph.EdgeTypeDeploy}}
+
+	deployment := func(id, status, created string, changes ...deployments.StatusChange) graph.Edge {
+		metadata := map[string]interface{}{"application": "checkout", "deployment_id": id, "status": status, "created_at": created}
+		for _, change := range changes {
+			deployments.AppendStatusChange(metadata, change)
+		}
+		return graph.Edge{Type: "deployment", Metadata: metadata}
+	}
+	at := func(minute int) time.Time { return time.Date(2026, 3, 1, 10, minute, 0, 0, time.UTC) }
+	staging := deployment("d1", "succeeded", "2026-03-01T10:00:00Z",
+		deployments.StatusChange{Status: "pending", Actor: "alice", Timestamp: at(0)},
+		deployments.StatusChange{Status: "succeeded", Actor: "deployment-agent", Timestamp: at(5)})
+	staging.To = "staging"
+	prod := deployment("d2", "failed", "2026-03-01T11:00:00Z",
+		deployments.StatusChange{Status: "pending", Actor: "alice", Timestamp: at(30)},
+		deployments.StatusChange{Status: "failed", Message: "<script>alert(1)</script>", Actor: "deployment-agent", Timestamp: at(40)})
+	prod.To = "prod"
+	g.Edges["release-checkout-100"] = []graph.Edge{staging, prod}
+
+	gg := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	require.NoError(t, gg.Backend.SaveGlobal(g))
+	return gg
+}
+
+func TestGenerate(t *testing.T) {
+	gg := newStatusTestGraph(t)
+	engine := workflows.NewEngine(gg)
+	incident := &workflows.Workflow{
+		Name:     "incident-response",
+		Template: IncidentTemplate,
+		Input:    map[string]interface{}{"application": "checkout", "environment": "staging", "severity": "critical"},
+		Steps:    []workflows.Step{{Name: "resolve", Type: workflows.StepSignal, Signal: "resolve"}},
+	}
+	require.NoError(t, engine.Start(context.Background(), incident))
+	other := &workflows.Workflow{
+		Name:     "incident-response",
+		Template: IncidentTemplate,
+		Input:    map[string]interface{}{"application": "payments", "environment": "prod"},
+		Steps:    []workflows.Step{{Name: "resolve", Type: workflows.StepSignal, Signal: "resolve"}},
+	}
+	require.NoError(t, engine.Start(context.Background(), other))
+
+	service := NewService(gg, deployments.NewDeploymentService(gg, nil), engine)
+	page, err := service.Generate("checkout")
+	require.NoError(t, err)
+
+	assert.Equal(t, HealthDegraded, page.Health, "the worst environment")
+	require.Len(t, page.Incidents, 1, "only the application's incidents")
+	assert.Equal(t, Incident{WorkflowID: incident.ID, Environment: "staging", Severity: "critical", Status: workflows.StatusWaiting, WaitingFor: "resolve", OpenedAt: incident.CreatedAt}, page.Incidents[0])
+
+	require.Len(t, page.Environments, 3, "qa is neither allowed nor deployed to")
+	dev, prod, staging := page.Environments[0], page.Environments[1], page.Environments[2]
+
+	assert.Equal(t, "dev", dev.Environment)
+	assert.Equal(t, HealthNotDeployed, dev.Health)
+	assert.Nil(t, dev.Release)
+	assert.Empty(t, dev.Services)
+	assert.Empty(t, dev.RecentChanges)
+
+	assert.Equal(t, HealthDegraded, prod.Health)
+	require.NotNil(t, prod.Release)
+	assert.Equal(t, "failed", prod.Release.Status)
+	assert.Equal(t, map[string]string{"checkout-api": "1.0.0"}, prod.Services)
+	assert.Equal(t, []string{"checkout-db"}, prod.UnhealthyResources)
+	require.Len(t, prod.RecentChanges, 2)
+	assert.Equal(t, "failed", prod.RecentChanges[0].Status, "newest first")
+
+	assert.Equal(t, HealthDegraded, staging.Health, "the open incident degrades staging")
+	assert.Equal(t, map[string]string{"checkout-api": "1.1.0"}, staging.Services)
+	assert.Len(t, staging.Incidents, 1)
+
+	// Resolving the incident leaves staging healthy
+	_, err = engine.Signal(context.Background(), incident.ID, "resolve", nil, "alice")
+	require.NoError(t, err)
+	page, err = service.Generate("checkout")
+	require.NoError(t, err)
+	assert.Empty(t, page.Incidents)
+	assert.Equal(t, HealthHealthy, page.Environments[2].Health)
+
+	_, err = service.Generate("unknown")
+	assert.True(t, errors.Is(err, ErrApplicationNotFound))
+}
+
+func TestRenderHTML(t *testing.T) {
+	gg := newStatusTestGraph(t)
+	page, err := NewService(gg, deployments.NewDeploymentService(gg, nil), nil).Generate("checkout")
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, RenderHTML(&out, page))
+	html := out.String()
+	assert.Contains(t, html, "<title>checkout status</title>")
+	assert.Contains(t, html, `<h2>prod <span class="health degraded">degraded</span></h2>`)
+	assert.Contains(t, html, "<tr><td>checkout-api</td><td>1.1.0</td></tr>")
+	assert.Contains(t, html, "Unhealthy resources: checkout-db")
+	assert.NotContains(t, html, "<script>", "values from the graph are escaped")
+}
@@ -0,0 +1,133 @@
+package templates
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Catalog holds the templates platform teams publish
+type Catalog struct {
+	mu        sync.RWMutex
+	templates map[string]*Template
+}
+
+// NewCatalog creates an empty catalog
+func NewCatalog() *Catalog {
+	return &Catalog{templates: map[string]*Template{}}
+}
+
+// Add validates a template and adds it, replacing any template of the same name
+func (c *Catalog) Add(t *Template) error {
+	if problems := t.Validate(); len(problems) > 0 {
+		return fmt.Errorf("%w %s: %s", ErrInvalidTemplate, t.Name, strings.Join(problems, "; "))
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.templates[t.Name] = t
+	return nil
+}
+
+// Get returns the named template
+func (c *Catalog) Get(name string) (*Template, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	t, ok := c.templates[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrTemplateNotFound, name)
+	}
+	return t, nil
+}
+
+// List returns the templates sorted by name
+func (c *Catalog) List() []*Template {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	list := make([]*Template, 0, len(c.templates))
+	for _, t := range c.templates {
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// LoadDir adds every template in the .yaml/.yml files in dir. Every file is read and
+// validated first, so one bad template leaves the catalog unchanged.
+func (c *Catalog) LoadDir(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("templates dir %s: %w", dir, err)
+	}
+	var files []string
+	for _, entry := range entries {
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".yaml", ".yml":
+			if !entry.IsDir() {
+				files = append(files, filepath.Join(dir, entry.Name()))
+			}
+		}
+	}
+	sort.Strings(files)
+
+	var loaded []*Template
+	names := map[string]string{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		templates, err := Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("template file %s: %w", file, err)
+		}
+		for _, t := range templates {
+			if problems := t.Validate(); len(problems) > 0 {
+				return nil, fmt.Errorf("template file %s: %w %s: %s", file, ErrInvalidTemplate, t.Name, strings.Join(problems, "; "))
+			}
+			if previous, ok := names[t.Name]; ok {
+				return nil, fmt.Errorf("template file %s: template %s is already defined in %s", file, t.Name, previous)
+			}
+			names[t.Name] = file
+			t.Source = file
+			loaded = append(loaded, t)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var added []string
+	for _, t := range loaded {
+		c.templates[t.Name] = t
+		added = append(added, t.Name)
+	}
+	return added, nil
+}
+
+// Parse decodes every template in a (possibly multi-document) YAML or JSON definition
+func Parse(data []byte) ([]*Template, error) {
+	var templates []*Template
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	for i := 1; ; i++ {
+		var t Template
+		if err := decoder.Decode(&t); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("document %d: %w", i, err)
+		}
+		if t.Name == "" && t.Title == "" && len(t.Steps) == 0 {
+			continue // empty document, e.g. a trailing "---"
+		}
+		templates = append(templates, &t)
+	}
+	return templates, nil
+}
@@ -0,0 +1,218 @@
+// Package templates holds golden-path blueprints platform teams define (e.g. "Go API +
+// Postgres + Redis") as parameterized execution plans. Instantiating a template produces a
+// draft plan the user reviews and approves like any other.
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/krzachariassen/ZTDP/internal/plans"
+)
+
+var (
+	// ErrTemplateNotFound is returned when no template has the requested name
+	ErrTemplateNotFound = errors.New("template not found")
+	// ErrInvalidTemplate is returned for template definitions that fail validation
+	ErrInvalidTemplate = errors.New("invalid template")
+	// ErrInvalidParameters is returned when instantiating a template with missing or malformed values
+	ErrInvalidParameters = errors.New("invalid template parameters")
+)
+
+var (
+	namePattern        = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+	placeholderPattern = regexp.MustCompile(`\{\{\s*([a-zA-Z_][a-zA-Z0-9_]*)\s*\}\}`)
+)
+
+// Parameter is a value supplied when the template is instantiated
+type Parameter struct {
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	Required    bool   `yaml:"required,omitempty" json:"required,omitempty"`
+	Default     string `yaml:"default,omitempty" json:"default,omitempty"`
+	Pattern     string `yaml:"pattern,omitempty" json:"pattern,omitempty"` // regular expression values must match
+}
+
+// Step is a plan step whose target and description may reference parameters as {{name}}
+type Step struct {
+	ID          string   `yaml:"id" json:"id"`
+	Action      string   `yaml:"action" json:"action"`
+	Target      string   `yaml:"target,omitempty" json:"target,omitempty"`
+	Description string   `yaml:"description,omitempty" json:"description,omitempty"`
+	DependsOn   []string `yaml:"depends_on,omitempty" json:"depends_on,omitempty"`
+}
+
+// Template is a golden-path blueprint
+type Template struct {
+	Name        string      `yaml:"name" json:"name"`
+	Title       string      `yaml:"title" json:"title"`
+	Description string      `yaml:"description,omitempty" json:"description,omitempty"`
+	Tags        []string    `yaml:"tags,omitempty" json:"tags,omitempty"`
+	Goal        string      `yaml:"goal,omitempty" json:"goal,omitempty"`               // plan goal; defaults to "Create <title> {{name}}"
+	Application string      `yaml:"application,omitempty" json:"application,omitempty"` // application the plan is for, usually {{name}}
+	Parameters  []Parameter `yaml:"parameters" json:"parameters"`
+	Steps       []Step      `yaml:"steps" json:"steps"`
+
+	Source string `yaml:"-" json:"source,omitempty"` // file the template was loaded from
+}
+
+// Validate returns every problem with the template; none means it can be instantiated
+func (t *Template) Validate() []string {
+	var problems []string
+	if !namePattern.MatchString(t.Name) {
+		problems = append(problems, fmt.Sprintf("name: %q must be lowercase letters, digits and dashes", t.Name))
+	}
+	if strings.TrimSpace(t.Title) == "" {
+		problems = append(problems, "title: required")
+	}
+
+	params := map[string]bool{}
+	for i, param := range t.Parameters {
+		field := fmt.Sprintf("parameters[%d]", i)
+		if param.Name == "" {
+			problems = append(problems, field+".name: required")
+			continue
+		}
+		if params[param.Name] {
+			problems = append(problems, fmt.Sprintf("%s.name: %q is declared twice", field, param.Name))
+		}
+		params[param.Name] = true
+		if param.Pattern != "" {
+			re, err := regexp.Compile(param.Pattern)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s.pattern: %v", field, err))
+			} else if param.Default != "" && !re.MatchString(param.Default) {
+				problems = append(problems, fmt.Sprintf("%s.default: %q does not match %s", field, param.Default, param.Pattern))
+			}
+		}
+	}
+
+	checkPlaceholders := func(field, text string) {
+		for _, name := range placeholders(text) {
+			if !params[name] {
+				problems = append(problems, fmt.Sprintf("%s: {{%s}} is not a declared parameter", field, name))
+			}
+		}
+	}
+	checkPlaceholders("goal", t.Goal)
+	checkPlaceholders("application", t.Application)
+
+	if len(t.Steps) == 0 {
+		problems = append(problems, "steps: at least one step is required")
+	}
+	seen := map[string]bool{}
+	for i, step := range t.Steps {
+		field := fmt.Sprintf("steps[%d]", i)
+		if step.ID == "" {
+			problems = append(problems, field+".id: required")
+		} else if seen[step.ID] {
+			problems = append(problems, fmt.Sprintf("%s.id: %q is used twice", field, step.ID))
+		}
+		if step.Action == "" {
+			problems = append(problems, field+".action: required")
+		}
+		for _, dep := range step.DependsOn {
+			if !seen[dep] {
+				problems = append(problems, fmt.Sprintf("%s.depends_on: %q is not an earlier step", field, dep))
+			}
+		}
+		seen[step.ID] = true
+		checkPlaceholders(field+".target", step.Target)
+		checkPlaceholders(field+".description", step.Description)
+	}
+	return problems
+}
+
+// placeholders returns the parameter names text references
+func placeholders(text string) []string {
+	var names []string
+	for _, match := range placeholderPattern.FindAllStringSubmatch(text, -1) {
+		names = append(names, match[1])
+	}
+	return names
+}
+
+// Resolve fills in defaults and checks values against the template's parameters
+func (t *Template) Resolve(values map[string]string) (map[string]string, error) {
+	resolved := map[string]string{}
+	var problems []string
+	for _, param := range t.Parameters {
+		value := strings.TrimSpace(values[param.Name])
+		if value == "" {
+			value = param.Default
+		}
+		if value == "" {
+			if param.Required {
+				problems = append(problems, fmt.Sprintf("%s is required", param.Name))
+			}
+			continue
+		}
+		if param.Pattern != "" && !regexp.MustCompile(param.Pattern).MatchString(value) {
+			problems = append(problems, fmt.Sprintf("%s: %q does not match %s", param.Name, value, param.Pattern))
+			continue
+		}
+		resolved[param.Name] = value
+	}
+	var unknown []string
+	for name := range values {
+		if _, declared := t.parameter(name); !declared {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		problems = append(problems, fmt.Sprintf("%s is not a parameter of %s", name, t.Name))
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidParameters, strings.Join(problems, "; "))
+	}
+	return resolved, nil
+}
+
+func (t *Template) parameter(name string) (Parameter, bool) {
+	for _, param := range t.Parameters {
+		if param.Name == name {
+			return param, true
+		}
+	}
+	return Parameter{}, false
+}
+
+// Instantiate returns the draft plan the template produces for values
+func (t *Template) Instantiate(values map[string]string) (plans.Plan, error) {
+	resolved, err := t.Resolve(values)
+	if err != nil {
+		return plans.Plan{}, err
+	}
+	fill := func(text string) string {
+		return placeholderPattern.ReplaceAllStringFunc(text, func(match string) string {
+			return resolved[placeholderPattern.FindStringSubmatch(match)[1]]
+		})
+	}
+
+	goal := t.Goal
+	if goal == "" {
+		goal = fmt.Sprintf("Create %s", t.Title)
+		if _, ok := resolved["name"]; ok {
+			goal += " {{name}}"
+		}
+	}
+	plan := plans.Plan{
+		Goal:        fill(goal),
+		Application: fill(t.Application),
+		ProposedBy:  "template:" + t.Name,
+	}
+	for _, step := range t.Steps {
+		plan.Steps = append(plan.Steps, plans.Step{
+			ID:          step.ID,
+			Action:      step.Action,
+			Target:      fill(step.Target),
+			Description: fill(step.Description),
+			DependsOn:   append([]string(nil), step.DependsOn...),
+		})
+	}
+	return plan, nil
+}
@@ -0,0 +1,250 @@
+package templates
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+	"github.com/krzachariassen/ZTDP/internal/ai"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/features"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/krzachariassen/ZTDP/internal/plans"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
+)
+
+// TemplateRequest is what the AI extracts from a message about golden-path templates
+type TemplateRequest struct {
+	Action        string            `json:"action"` // instantiate | list
+	Template      string            `json:"template,omitempty"`
+	Parameters    map[string]string `json:"parameters,omitempty"`
+	Confidence    float64           `json:"confidence"`
+	Clarification string            `json:"clarification,omitempty"`
+}
+
+// TemplateAgent turns chat requests such as "create a standard API service called payments"
+// into draft plans from the template catalog
+type TemplateAgent struct {
+	catalog    *Catalog
+	plans      *plans.Service
+	aiProvider ai.AIProvider
+	logger     *logging.Logger
+}
+
+// NewTemplateAgent creates the template agent
+func NewTemplateAgent(
+	globalGraph *graph.GlobalGraph,
+	catalog *Catalog,
+	planService *plans.Service,
+	aiProvider ai.AIProvider,
+	eventBus *events.EventBus,
+	registry agentRegistry.AgentRegistry,
+) (agentRegistry.AgentInterface, error) {
+	if catalog == nil {
+		return nil, fmt.Errorf("template catalog is required")
+	}
+	if planService == nil {
+		return nil, fmt.Errorf("plan service is required")
+	}
+	if aiProvider == nil {
+		return nil, fmt.Errorf("aiProvider is required for AI-native agent")
+	}
+	if eventBus == nil {
+		return nil, fmt.Errorf("eventBus is required")
+	}
+	if registry == nil {
+		return nil, fmt.Errorf("registry is required")
+	}
+
+	wrapper := &TemplateAgent{
+		catalog:    catalog,
+		plans:      planService,
+		aiProvider: aiProvider,
+		logger:     logging.GetLogger().ForComponent("template-agent"),
+	}
+
+	agent, err := agentframework.NewAgent("template-agent").
+		WithType("template").
+		WithCapabilities(getTemplateCapabilities()).
+		WithEventHandler(wrapper.handleEvent).
+		Build(agentframework.AgentDependencies{
+			Registry: registry,
+			EventBus: eventBus,
+			Flags:    features.NewService(globalGraph),
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build template agent: %w", err)
+	}
+
+	wrapper.logger.Info("✅ TemplateAgent created successfully")
+	return agent, nil
+}
+
+// getTemplateCapabilities returns the capabilities for the template agent
+func getTemplateCapabilities() []agentRegistry.AgentCapability {
+	return []agentRegistry.AgentCapability{
+		{
+			Name:        "golden_path_templates",
+			Description: "Creates applications from the platform's golden-path templates (e.g. a standard Go API with Postgres and Redis) and lists the available templates",
+			Intents: []string{
+				"create from template", "create standard service", "create standard api", "use golden path",
+				"list templates", "show templates",
+			},
+			InputTypes:  []string{"user_message"},
+			OutputTypes: []string{"plan"},
+			RoutingKeys: []string{"template.instantiate", "template.request"},
+			Version:     "1.0.0",
+		},
+	}
+}
+
+// handleEvent lists the catalog or proposes a plan from the requested template
+func (a *TemplateAgent) handleEvent(ctx context.Context, event *events.Event) (*events.Event, error) {
+	userMessage, ok := event.Payload["user_message"].(string)
+	if !ok || userMessage == "" {
+		return a.createErrorResponse(event, "user_message field is required in event payload"), nil
+	}
+
+	available := a.catalog.List()
+	if len(available) == 0 {
+		return a.createErrorResponse(event, "No golden-path templates are published yet"), nil
+	}
+
+	request, err := a.extractRequest(ctx, userMessage, available)
+	if err != nil {
+		return a.createErrorResponse(event, fmt.Sprintf("I couldn't understand the template request: %v", err)), nil
+	}
+	if agentframework.NeedsClarification(ctx, request.Confidence) {
+		clarification := request.Clarification
+		if clarification == "" {
+			clarification = "Which template do you want to use, and what should the new application be called?\n\n" + describeCatalog(available)
+		}
+		return agentframework.ClarificationResponse(ctx, event, clarification, request.Confidence), nil
+	}
+
+	switch request.Action {
+	case "list":
+		return a.createSuccessResponse(event, "📐 Golden-path templates:\n\n"+describeCatalog(available), nil), nil
+	case "instantiate":
+		template, err := a.catalog.Get(request.Template)
+		if err != nil {
+			return a.createErrorResponse(event, fmt.Sprintf("There is no template called %q.\n\n%s", request.Template, describeCatalog(available))), nil
+		}
+		plan, err := template.Instantiate(request.Parameters)
+		if errors.Is(err, ErrInvalidParameters) {
+			return a.createErrorResponse(event, fmt.Sprintf("I need more details for %s: %v", template.Title, err)), nil
+		} else if err != nil {
+			return a.createErrorResponse(event, err.Error()), nil
+		}
+		evaluation := features.EvaluationContextFrom(ctx)
+		plan.ConversationID = evaluation.ConversationID
+		plan.Tenant = evaluation.Tenant
+		stored, err := a.plans.Propose(plan)
+		if err != nil {
+			return a.createErrorResponse(event, fmt.Sprintf("I couldn't create the plan: %v", err)), nil
+		}
+		a.logger.Info("📐 Proposed plan %s from template %s", stored.ID, template.Name)
+		message := fmt.Sprintf("📐 Planned %s from the %s template. Review it, then approve the plan to go ahead.\n\n%s", stored.Goal, template.Title, stored.Describe())
+		return a.createSuccessResponse(event, message, stored), nil
+	default:
+		return a.createErrorResponse(event, fmt.Sprintf("I can list templates or create from one, not %q", request.Action)), nil
+	}
+}
+
+// describeCatalog lists the templates and their parameters for chat responses and prompts
+func describeCatalog(templates []*Template) string {
+	var b strings.Builder
+	for _, t := range templates {
+		fmt.Fprintf(&b, "- %s: %s", t.Name, t.Title)
+		if t.Description != "" {
+			fmt.Fprintf(&b, " — %s", t.Description)
+		}
+		var params []string
+		for _, param := range t.Parameters {
+			label := param.Name
+			if param.Required {
+				label += " (required)"
+			} else if param.Default != "" {
+				label += fmt.Sprintf(" (default %s)", param.Default)
+			}
+			params = append(params, label)
+		}
+		if len(params) > 0 {
+			fmt.Fprintf(&b, " [parameters: %s]", strings.Join(params, ", "))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// extractRequest asks the AI which template the user wants and with what values
+func (a *TemplateAgent) extractRequest(ctx context.Context, userMessage string, available []*Template) (*TemplateRequest, error) {
+	systemPrompt := `The platform publishes these golden-path templates:
+` + describeCatalog(available) + `
+Decide what the user wants and respond with JSON:
+{"action": "instantiate|list", "template": "", "parameters": {}, "confidence": 0.0, "clarification": ""}
+
+Rules:
+- instantiate: the user wants to create something a template describes ("create a standard API service called payments"); set template to its name and parameters to the values the user gave, using the parameter names above
+- list: the user asks which templates exist
+- Only use parameter names the template declares; leave out values the user did not give
+- Set confidence below 0.7 and explain in clarification when no template fits or the request is ambiguous
+
+Respond with JSON only.`
+
+	response, err := a.aiProvider.CallAI(ai.WithTask(ctx, ai.TaskExtraction), systemPrompt, userMessage)
+	if err != nil {
+		return nil, err
+	}
+
+	cleaned := strings.TrimSpace(response)
+	cleaned = strings.TrimPrefix(cleaned, "```json")
+	cleaned = strings.TrimPrefix(cleaned, "```")
+	cleaned = strings.TrimSuffix(cleaned, "```")
+
+	var request TemplateRequest
+	if err := json.Unmarshal([]byte(strings.TrimSpace(cleaned)), &request); err != nil {
+		return nil, fmt.Errorf("failed to parse AI response: %w", err)
+	}
+	a.logger.Info("🤖 AI extracted template action: %s (%s), confidence: %.2f", request.Action, request.Template, request.Confidence)
+	return &request, nil
+}
+
+func (a *TemplateAgent) createSuccessResponse(originalEvent *events.Event, message string, plan *plans.Plan) *events.Event {
+	payload := map[string]interface{}{
+		"status":         "success",
+		"message":        message,
+		"correlation_id": originalEvent.Payload["correlation_id"],
+	}
+	if plan != nil {
+		payload["plan"] = plan
+	}
+	return &events.Event{
+		ID:        fmt.Sprintf("template-response-%d", time.Now().UnixNano()),
+		Type:      events.EventTypeResponse,
+		Subject:   "template.response",
+		Source:    "template-agent",
+		Timestamp: time.Now().Unix(),
+		Payload:   payload,
+	}
+}
+
+func (a *TemplateAgent) createErrorResponse(originalEvent *events.Event, errorMessage string) *events.Event {
+	return &events.Event{
+		ID:        fmt.Sprintf("template-error-%d", time.Now().UnixNano()),
+		Type:      events.EventTypeResponse,
+		Subject:   "template.error",
+		Source:    "template-agent",
+		Timestamp: time.Now().Unix(),
+		Payload: map[string]interface{}{
+			"status":         "error",
+			"error":          errorMessage,
+			"correlation_id": originalEvent.Payload["correlation_id"],
+		},
+	}
+}
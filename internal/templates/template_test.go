@@ -0,0 +1,107 @@
+package templates
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+	"github.com/krzachariassen/ZTDP/internal/ai/aitest"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/plans"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func shippedCatalog(t *testing.T) *Catalog {
+	t.Helper()
+	catalog := NewCatalog()
+	loaded, err := catalog.LoadDir("../../config/templates")
+	require.NoError(t, err)
+	require.Contains(t, loaded, "go-api")
+	return catalog
+}
+
+func TestInstantiateShippedTemplate(t *testing.T) {
+	template, err := shippedCatalog(t).Get("go-api")
+	require.NoError(t, err)
+
+	plan, err := template.Instantiate(map[string]string{"name": "payments"})
+	require.NoError(t, err)
+	assert.Equal(t, "Create the payments Go API with Postgres and Redis", plan.Goal)
+	assert.Equal(t, "payments", plan.Application)
+	assert.Equal(t, "template:go-api", plan.ProposedBy)
+	require.Len(t, plan.Steps, 5)
+	assert.Equal(t, "payments-db", plan.Steps[2].Target)
+	assert.Equal(t, "Provision Postgres (standard) for payments", plan.Steps[2].Description, "defaults fill unset parameters")
+	assert.Equal(t, []string{"create-service", "create-database", "create-cache"}, plan.Steps[4].DependsOn)
+
+	_, err = template.Instantiate(map[string]string{"owner": "team-a"})
+	assert.True(t, errors.Is(err, ErrInvalidParameters))
+	assert.Contains(t, err.Error(), "name is required")
+
+	_, err = template.Instantiate(map[string]string{"name": "payments", "db_tier": "gold", "region": "eu"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "db_tier")
+	assert.Contains(t, err.Error(), "region is not a parameter")
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	definitions, err := Parse([]byte(`
+name: Bad Name
+parameters:
+  - name: tier
+    pattern: "("
+  - name: tier
+steps:
+  - id: deploy
+    action: deploy
+    target: "{{service}}"
+    depends_on: [build]
+  - id: deploy
+`))
+	require.NoError(t, err)
+	require.Len(t, definitions, 1)
+
+	problems := definitions[0].Validate()
+	for _, want := range []string{"name:", "title:", "parameters[0].pattern", "parameters[1].name", "{{service}}", "depends_on", "steps[1].id", "steps[1].action"} {
+		found := false
+		for _, problem := range problems {
+			if strings.Contains(problem, want) {
+				found = true
+			}
+		}
+		assert.True(t, found, "expected a problem mentioning %q in %v", want, problems)
+	}
+	assert.ErrorIs(t, NewCatalog().Add(definitions[0]), ErrInvalidTemplate)
+}
+
+func TestTemplateAgentProposesPlanFromChat(t *testing.T) {
+	globalGraph := graph.NewGlobalGraph(graph.NewMemoryGraph())
+	planService := plans.NewService(globalGraph, nil)
+	provider := aitest.Sequence(
+		`{"action": "instantiate", "template": "go-api", "parameters": {"name": "payments"}, "confidence": 0.93}`,
+	)
+	agent, err := NewTemplateAgent(globalGraph, shippedCatalog(t), planService, provider, events.NewEventBus(nil, false), agentRegistry.NewInMemoryAgentRegistry())
+	require.NoError(t, err)
+
+	response, err := agent.(*agentframework.BaseAgent).ProcessEvent(context.Background(), &events.Event{
+		Subject: "template.instantiate",
+		Payload: map[string]interface{}{
+			"user_message":    "create a standard API service called payments",
+			"conversation_id": "conv-1",
+			"correlation_id":  "corr-1",
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "success", response.Payload["status"], response.Payload["error"])
+
+	plan, err := planService.Latest("conv-1")
+	require.NoError(t, err)
+	assert.Equal(t, plans.StatusDraft, plan.Status)
+	assert.Equal(t, "payments", plan.Application)
+	assert.Equal(t, "template:go-api", plan.ProposedBy)
+}
@@ -0,0 +1,89 @@
+package workflows
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/krzachariassen/ZTDP/internal/deployments"
+)
+
+// Deployer deploys applications and reports where they are deployed. deployments.Deployer
+// implements it, so workflow steps go through the same locks, gates and migrations as every
+// other deployment.
+type Deployer interface {
+	DeployApplication(ctx context.Context, appName, environment string) (*deployments.DeploymentResult, error)
+	GetDeploymentStatus(appName, environment string) (map[string]interface{}, error)
+}
+
+// RegisterDeploymentActions registers the deployment actions:
+//
+//	deploy   deploys application to environment
+//	verify   fails unless application is deployed to environment
+//	promote  deploys application to "to" once it is deployed to "from"
+func (e *Engine) RegisterDeploymentActions(deployer Deployer) {
+	e.RegisterAction("deploy", func(ctx context.Context, workflow *Workflow, params map[string]interface{}) (map[string]interface{}, error) {
+		app, environment, err := requireParams(params, "application", "environment")
+		if err != nil {
+			return nil, err
+		}
+		return deploy(ctx, deployer, app, environment)
+	})
+	e.RegisterAction("verify", func(ctx context.Context, workflow *Workflow, params map[string]interface{}) (map[string]interface{}, error) {
+		app, environment, err := requireParams(params, "application", "environment")
+		if err != nil {
+			return nil, err
+		}
+		return verify(deployer, app, environment)
+	})
+	e.RegisterAction("promote", func(ctx context.Context, workflow *Workflow, params map[string]interface{}) (map[string]interface{}, error) {
+		app, from, err := requireParams(params, "application", "from")
+		if err != nil {
+			return nil, err
+		}
+		_, to, err := requireParams(params, "application", "to")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := verify(deployer, app, from); err != nil {
+			return nil, fmt.Errorf("cannot promote: %w", err)
+		}
+		return deploy(ctx, deployer, app, to)
+	})
+}
+
+func deploy(ctx context.Context, deployer Deployer, app, environment string) (map[string]interface{}, error) {
+	result, err := deployer.DeployApplication(ctx, app, environment)
+	if err != nil {
+		return nil, err
+	}
+	if result.Status == "failed" {
+		return nil, fmt.Errorf("deployment of %s to %s failed: %s", app, environment, result.Message)
+	}
+	return map[string]interface{}{
+		"application":   app,
+		"environment":   environment,
+		"deployment_id": result.DeploymentID,
+		"status":        result.Status,
+	}, nil
+}
+
+func verify(deployer Deployer, app, environment string) (map[string]interface{}, error) {
+	status, err := deployer.GetDeploymentStatus(app, environment)
+	if err != nil {
+		return nil, err
+	}
+	if status["status"] != "deployed" {
+		return nil, fmt.Errorf("%s is not deployed to %s (%v)", app, environment, status["status"])
+	}
+	return status, nil
+}
+
+// requireParams returns two string params, failing when either is missing
+func requireParams(params map[string]interface{}, first, second string) (string, string, error) {
+	a, _ := params[first].(string)
+	b, _ := params[second].(string)
+	if a == "" || b == "" {
+		return "", "", fmt.Errorf("params %s and %s are required", first, second)
+	}
+	return a, b, nil
+}
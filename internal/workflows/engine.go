@@ -0,0 +1,549 @@
+package workflows
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// nodeIDPrefix namespaces workflow nodes so they cannot collide with platform entities
+const nodeIDPrefix = "workflow:"
+
+// Action performs the work of an action step. params holds the workflow input overlaid with the
+// step's params. The result is kept as the step's output. A step can run again after a crash
+// between the action finishing and the transition being persisted, so actions should be safe to
+// repeat.
+type Action func(ctx context.Context, workflow *Workflow, params map[string]interface{}) (map[string]interface{}, error)
+
+// Dispatcher hands a step assigned to an agent to an agent offering capability and returns its
+// reply. The payload holds the step's params over the workflow input, with the intent and message.
+type Dispatcher func(ctx context.Context, capability string, payload map[string]interface{}, timeout time.Duration) (map[string]interface{}, error)
+
+// Engine starts workflows and advances them through their steps
+type Engine struct {
+	graph      *graph.GlobalGraph
+	logger     *logging.Logger
+	now        func() time.Time
+	mu         sync.Mutex
+	actions    map[string]Action
+	dispatcher Dispatcher
+	templates  map[string]Template
+	locks      map[string]*sync.Mutex // by workflow ID, held while the workflow advances
+}
+
+// NewEngine creates a workflow engine backed by the global graph, with the built-in templates
+func NewEngine(globalGraph *graph.GlobalGraph) *Engine {
+	engine := &Engine{
+		graph:     globalGraph,
+		logger:    logging.GetLogger().ForComponent("workflows"),
+		now:       time.Now,
+		actions:   map[string]Action{},
+		templates: map[string]Template{},
+		locks:     map[string]*sync.Mutex{},
+	}
+	for _, template := range BuiltinTemplates() {
+		engine.templates[template.Name] = template
+	}
+	return engine
+}
+
+// RegisterAction makes an action available to action steps
+func (e *Engine) RegisterAction(name string, action Action) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.actions[name] = action
+}
+
+// Actions returns the names of the registered actions
+func (e *Engine) Actions() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	names := make([]string, 0, len(e.actions))
+	for name := range e.actions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetDispatcher sets how steps assigned to agents reach them; without one those steps fail
+func (e *Engine) SetDispatcher(dispatcher Dispatcher) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.dispatcher = dispatcher
+}
+
+func (e *Engine) action(name string) (Action, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	action, ok := e.actions[name]
+	return action, ok
+}
+
+// Start validates and stores a new workflow, then runs it until its first wait
+func (e *Engine) Start(ctx context.Context, workflow *Workflow) error {
+	if err := workflow.Validate(); err != nil {
+		return err
+	}
+	for _, step := range workflow.Steps {
+		if _, ok := e.action(step.Action); step.Type == StepAction && step.Action != "" && !ok {
+			return fmt.Errorf("step %s: unknown action %q", step.Name, step.Action)
+		}
+	}
+
+	now := e.now().UTC()
+	workflow.ID = uuid.New().String()
+	workflow.Current = 0
+	workflow.Status = StatusRunning
+	workflow.WaitingFor = ""
+	workflow.WakeAt = nil
+	workflow.Attempts = 0
+	workflow.Outputs = map[string]map[string]interface{}{}
+	workflow.Signals = nil
+	workflow.Error = ""
+	workflow.History = []Transition{{At: now, Event: "created", Message: fmt.Sprintf("%d steps", len(workflow.Steps))}}
+	workflow.CreatedAt = now
+	workflow.UpdatedAt = now
+	workflow.CompletedAt = nil
+
+	// Held from the start so a tick cannot advance the stored workflow alongside
+	lock := e.lock(workflow.ID)
+	defer lock.Unlock()
+	node, err := workflowToNode(workflow)
+	if err != nil {
+		return err
+	}
+	if err := e.graph.AddNode(node); err != nil {
+		return fmt.Errorf("failed to store workflow: %w", err)
+	}
+	e.logger.Info("🔁 Workflow %s (%s) started", workflow.ID, workflow.Name)
+	return e.advance(ctx, workflow)
+}
+
+// Get returns a workflow by ID
+func (e *Engine) Get(id string) (*Workflow, error) {
+	node, _ := e.graph.GetNode(nodeIDPrefix + id)
+	if node == nil || node.Kind != graph.KindWorkflow {
+		return nil, ErrWorkflowNotFound
+	}
+	return nodeToWorkflow(node)
+}
+
+// List returns workflows newest first, only those with the status when one is given
+func (e *Engine) List(status string) ([]*Workflow, error) {
+	nodes, err := e.graph.Nodes()
+	if err != nil {
+		return nil, err
+	}
+	workflows := []*Workflow{}
+	for _, node := range nodes {
+		if node.Kind != graph.KindWorkflow {
+			continue
+		}
+		workflow, err := nodeToWorkflow(node)
+		if err != nil {
+			e.logger.Warn("⚠️ Skipping malformed workflow %s: %v", node.ID, err)
+			continue
+		}
+		if status != "" && workflow.Status != status {
+			continue
+		}
+		workflows = append(workflows, workflow)
+	}
+	sort.Slice(workflows, func(i, j int) bool {
+		return workflows[i].CreatedAt.After(workflows[j].CreatedAt)
+	})
+	return workflows, nil
+}
+
+// Signal delivers a signal to a workflow and advances it. A signal the workflow is not waiting
+// for yet is kept until a step waits for it.
+func (e *Engine) Signal(ctx context.Context, id, name string, payload map[string]interface{}, sentBy string) (*Workflow, error) {
+	if name == "" {
+		return nil, fmt.Errorf("signal name is required")
+	}
+	lock := e.lock(id)
+	defer lock.Unlock()
+
+	workflow, err := e.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if workflow.Finished() {
+		return nil, ErrWorkflowFinished
+	}
+	now := e.now().UTC()
+	workflow.Signals = append(workflow.Signals, Signal{Name: name, Payload: payload, SentBy: sentBy, ReceivedAt: now})
+	workflow.record(now, "", "signalled", describeSignal(name, sentBy))
+	if workflow.WaitingFor == name {
+		workflow.Status = StatusRunning
+	}
+	return workflow, e.advance(ctx, workflow)
+}
+
+// Cancel stops a workflow; its current step does not run again
+func (e *Engine) Cancel(id, reason, cancelledBy string) (*Workflow, error) {
+	lock := e.lock(id)
+	defer lock.Unlock()
+
+	workflow, err := e.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if workflow.Finished() {
+		return nil, ErrWorkflowFinished
+	}
+	now := e.now().UTC()
+	message := reason
+	if cancelledBy != "" {
+		message = fmt.Sprintf("by %s: %s", cancelledBy, reason)
+	}
+	workflow.Status = StatusCancelled
+	workflow.WaitingFor = ""
+	workflow.WakeAt = nil
+	workflow.CompletedAt = &now
+	workflow.record(now, workflow.stepName(), "cancelled", message)
+	e.logger.Info("🔁 Workflow %s cancelled %s", id, message)
+	return workflow, e.save(workflow)
+}
+
+// Retry resumes a failed workflow at the step that failed, with its attempts reset
+func (e *Engine) Retry(ctx context.Context, id string) (*Workflow, error) {
+	lock := e.lock(id)
+	defer lock.Unlock()
+
+	workflow, err := e.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if workflow.Status != StatusFailed {
+		return nil, ErrNotRetryable
+	}
+	workflow.Status = StatusRunning
+	workflow.Attempts = 0
+	workflow.WakeAt = nil
+	workflow.Error = ""
+	workflow.CompletedAt = nil
+	workflow.record(e.now().UTC(), workflow.stepName(), "retried", "")
+	return workflow, e.advance(ctx, workflow)
+}
+
+// Tick advances every workflow whose timer, signal timeout or retry backoff has ended, and those
+// left running by a crash. It returns how many it advanced.
+func (e *Engine) Tick(ctx context.Context) int {
+	workflows, err := e.List("")
+	if err != nil {
+		e.logger.Warn("⚠️ Failed to list workflows: %v", err)
+		return 0
+	}
+	advanced := 0
+	now := e.now()
+	for _, candidate := range workflows {
+		if !candidate.due(now) {
+			continue
+		}
+		lock := e.lock(candidate.ID)
+		// Reload: a signal may have advanced the workflow since it was listed
+		if workflow, err := e.Get(candidate.ID); err == nil && workflow.due(now) {
+			if err := e.advance(ctx, workflow); err != nil {
+				e.logger.Warn("⚠️ Failed to advance workflow %s: %v", workflow.ID, err)
+			}
+			advanced++
+		}
+		lock.Unlock()
+	}
+	return advanced
+}
+
+// Run advances due workflows every interval until ctx is done. Only one instance of a cluster
+// should run it.
+func (e *Engine) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		e.Tick(ctx)
+	}
+}
+
+// due reports whether a tick should advance the workflow
+func (w *Workflow) due(now time.Time) bool {
+	switch w.Status {
+	case StatusRunning:
+		return true
+	case StatusWaiting:
+		return w.WakeAt != nil && !now.Before(*w.WakeAt)
+	}
+	return false
+}
+
+// advance runs the workflow's steps until it waits or finishes, persisting every transition.
+// The caller holds the workflow's lock.
+func (e *Engine) advance(ctx context.Context, workflow *Workflow) error {
+	ctx = logging.WithCorrelationID(ctx, "workflow-"+workflow.ID)
+	for !workflow.Finished() {
+		step := workflow.CurrentStep()
+		if step == nil {
+			now := e.now().UTC()
+			workflow.Status = StatusCompleted
+			workflow.CompletedAt = &now
+			workflow.record(now, "", "completed", "")
+			e.logger.Info("✅ Workflow %s (%s) completed", workflow.ID, workflow.Name)
+			return e.save(workflow)
+		}
+
+		var waiting bool
+		switch step.Type {
+		case StepWait:
+			waiting = e.runWait(workflow, step)
+		case StepSignal:
+			waiting = e.runSignal(workflow, step)
+		case StepAction:
+			waiting = e.runAction(ctx, workflow, step)
+		}
+		if err := e.save(workflow); err != nil {
+			return err
+		}
+		if waiting {
+			return nil
+		}
+	}
+	return nil
+}
+
+// runWait starts the step's timer, or moves on once it has ended; it reports whether the
+// workflow waits
+func (e *Engine) runWait(workflow *Workflow, step *Step) bool {
+	now := e.now().UTC()
+	if workflow.WakeAt == nil {
+		wakeAt := now
+		if step.Until != nil {
+			wakeAt = step.Until.UTC()
+		} else {
+			delay, _ := parseDuration(step.Delay)
+			wakeAt = now.Add(delay)
+		}
+		workflow.WakeAt = &wakeAt
+	}
+	if now.Before(*workflow.WakeAt) {
+		if workflow.Status != StatusWaiting {
+			workflow.Status = StatusWaiting
+			workflow.record(now, step.Name, "waiting", "until "+workflow.WakeAt.Format(time.RFC3339))
+		}
+		return true
+	}
+	workflow.next(now, "timer ended")
+	return false
+}
+
+// runSignal consumes the signal the step waits for, or waits for it until the step's timeout
+func (e *Engine) runSignal(workflow *Workflow, step *Step) bool {
+	now := e.now().UTC()
+	for i, signal := range workflow.Signals {
+		if signal.Name != step.Signal {
+			continue
+		}
+		workflow.Signals = append(workflow.Signals[:i], workflow.Signals[i+1:]...)
+		workflow.Outputs[step.Name] = signal.Payload
+		workflow.next(now, describeSignal(signal.Name, signal.SentBy))
+		return false
+	}
+
+	if workflow.WaitingFor != step.Signal {
+		workflow.WaitingFor = step.Signal
+		workflow.WakeAt = nil
+		if timeout, _ := parseDuration(step.Timeout); timeout > 0 {
+			deadline := now.Add(timeout)
+			workflow.WakeAt = &deadline
+		}
+	}
+	if workflow.WakeAt != nil && !now.Before(*workflow.WakeAt) {
+		workflow.fail(now, fmt.Sprintf("no %s signal within %s", step.Signal, step.Timeout))
+		return false
+	}
+	if workflow.Status != StatusWaiting {
+		workflow.Status = StatusWaiting
+		workflow.record(now, step.Name, "waiting", "for signal "+step.Signal)
+	}
+	return true
+}
+
+// runAction runs the step's action, scheduling a retry or failing the workflow when it fails
+func (e *Engine) runAction(ctx context.Context, workflow *Workflow, step *Step) bool {
+	now := e.now().UTC()
+	if workflow.WakeAt != nil && now.Before(*workflow.WakeAt) {
+		workflow.Status = StatusWaiting
+		return true
+	}
+	action, ok := e.action(step.Action)
+	if step.Capability != "" {
+		action, ok = e.agentAction(step)
+	}
+	if !ok {
+		workflow.fail(now, fmt.Sprintf("unknown action %q", step.Action))
+		return false
+	}
+
+	params := make(map[string]interface{}, len(workflow.Input)+len(step.Params))
+	for k, v := range workflow.Input {
+		params[k] = v
+	}
+	for k, v := range step.Params {
+		params[k] = v
+	}
+	e.logger.ForContext(ctx).Info("🔁 Workflow %s running %s (%s)", workflow.ID, step.Name, step.Action+step.Capability)
+	output, err := action(ctx, workflow, params)
+	now = e.now().UTC()
+	if err == nil {
+		if output != nil {
+			workflow.Outputs[step.Name] = output
+		}
+		workflow.next(now, "")
+		return false
+	}
+
+	workflow.Attempts++
+	if workflow.Attempts > step.Retries {
+		workflow.fail(now, fmt.Sprintf("%s failed after %d attempts: %v", step.Action, workflow.Attempts, err))
+		return false
+	}
+	backoff, _ := parseDuration(step.Backoff)
+	if backoff == 0 {
+		backoff = DefaultBackoff
+	}
+	retryAt := now.Add(backoff << (workflow.Attempts - 1))
+	workflow.WakeAt = &retryAt
+	workflow.Status = StatusWaiting
+	workflow.Error = err.Error()
+	workflow.record(now, step.Name, "retrying", fmt.Sprintf("attempt %d failed: %v; retrying at %s", workflow.Attempts, err, retryAt.Format(time.RFC3339)))
+	e.logger.Warn("⚠️ Workflow %s step %s failed (attempt %d): %v", workflow.ID, step.Name, workflow.Attempts, err)
+	return true
+}
+
+// agentAction hands the step to the agent offering its capability through the dispatcher
+func (e *Engine) agentAction(step *Step) (Action, bool) {
+	e.mu.Lock()
+	dispatcher := e.dispatcher
+	e.mu.Unlock()
+	return func(ctx context.Context, workflow *Workflow, params map[string]interface{}) (map[string]interface{}, error) {
+		if dispatcher == nil {
+			return nil, fmt.Errorf("no agent dispatcher to reach capability %s", step.Capability)
+		}
+		timeout, _ := parseDuration(step.Timeout)
+		if timeout == 0 {
+			timeout = DefaultAgentTimeout
+		}
+		params["intent"] = step.Intent
+		params["user_message"] = step.Message
+		params["workflow_id"] = workflow.ID
+		params["workflow_step"] = step.Name
+		return dispatcher(ctx, step.Capability, params, timeout)
+	}, true
+}
+
+// next completes the current step and moves to the following one
+func (w *Workflow) next(now time.Time, message string) {
+	w.record(now, w.stepName(), "step_completed", message)
+	w.Current++
+	w.Status = StatusRunning
+	w.WaitingFor = ""
+	w.WakeAt = nil
+	w.Attempts = 0
+	w.Error = ""
+}
+
+// fail ends the workflow at the current step, which Retry resumes
+func (w *Workflow) fail(now time.Time, message string) {
+	w.Status = StatusFailed
+	w.WaitingFor = ""
+	w.WakeAt = nil
+	w.Error = message
+	w.CompletedAt = &now
+	w.record(now, w.stepName(), "failed", message)
+}
+
+func (w *Workflow) record(now time.Time, step, event, message string) {
+	w.UpdatedAt = now
+	w.History = append(w.History, Transition{At: now, Step: step, Event: event, Message: message})
+}
+
+func (w *Workflow) stepName() string {
+	if step := w.CurrentStep(); step != nil {
+		return step.Name
+	}
+	return ""
+}
+
+func describeSignal(name, sentBy string) string {
+	if sentBy == "" {
+		return name
+	}
+	return name + " from " + sentBy
+}
+
+// lock returns the workflow's lock, held
+func (e *Engine) lock(id string) *sync.Mutex {
+	e.mu.Lock()
+	lock, ok := e.locks[id]
+	if !ok {
+		lock = &sync.Mutex{}
+		e.locks[id] = lock
+	}
+	e.mu.Unlock()
+	lock.Lock()
+	return lock
+}
+
+func (e *Engine) save(workflow *Workflow) error {
+	node, err := workflowToNode(workflow)
+	if err != nil {
+		return err
+	}
+	if err := e.graph.UpdateNode(node); err != nil {
+		return fmt.Errorf("failed to store workflow %s: %w", workflow.ID, err)
+	}
+	return nil
+}
+
+func workflowToNode(workflow *Workflow) (*graph.Node, error) {
+	data, err := json.Marshal(workflow)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode workflow: %w", err)
+	}
+	var spec map[string]interface{}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to encode workflow: %w", err)
+	}
+	return &graph.Node{
+		ID:       nodeIDPrefix + workflow.ID,
+		Kind:     graph.KindWorkflow,
+		Metadata: map[string]interface{}{"name": nodeIDPrefix + workflow.ID},
+		Spec:     spec,
+	}, nil
+}
+
+func nodeToWorkflow(node *graph.Node) (*Workflow, error) {
+	data, err := json.Marshal(node.Spec)
+	if err != nil {
+		return nil, err
+	}
+	var workflow Workflow
+	if err := json.Unmarshal(data, &workflow); err != nil {
+		return nil, err
+	}
+	if workflow.Outputs == nil {
+		workflow.Outputs = map[string]map[string]interface{}{}
+	}
+	return &workflow, nil
+}
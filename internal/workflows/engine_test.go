@@ -0,0 +1,175 @@
+package workflows
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/deployments"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDeployer records deployments and fails the first failures of them
+type fakeDeployer struct {
+	deployed map[string]bool
+	failures int
+}
+
+func (d *fakeDeployer) DeployApplication(ctx context.Context, appName, environment string) (*deployments.DeploymentResult, error) {
+	if d.failures > 0 {
+		d.failures--
+		return nil, errors.New("cluster unreachable")
+	}
+	d.deployed[appName+"/"+environment] = true
+	return &deployments.DeploymentResult{DeploymentID: "deploy-" + appName + "-" + environment, Status: "completed"}, nil
+}
+
+func (d *fakeDeployer) GetDeploymentStatus(appName, environment string) (map[string]interface{}, error) {
+	if d.deployed[appName+"/"+environment] {
+		return map[string]interface{}{"status": "deployed"}, nil
+	}
+	return map[string]interface{}{"status": "not_deployed"}, nil
+}
+
+// newTestEngine returns an engine on a fresh graph whose clock the returned function advances
+func newTestEngine(t *testing.T, deployer *fakeDeployer) (*Engine, func(time.Duration)) {
+	t.Helper()
+	engine := NewEngine(graph.NewGlobalGraph(graph.NewMemoryGraph()))
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	engine.now = func() time.Time { return now }
+	engine.RegisterDeploymentActions(deployer)
+	return engine, func(d time.Duration) { now = now.Add(d) }
+}
+
+func releaseWorkflow() *Workflow {
+	return &Workflow{
+		Name:  "checkout release",
+		Input: map[string]interface{}{"application": "checkout"},
+		Steps: []Step{
+			{Name: "approval", Type: StepSignal, Signal: "approval", Timeout: "72h"},
+			{Name: "deploy-window", Type: StepWait, Delay: "12h"},
+			{Name: "deploy-staging", Type: StepAction, Action: "deploy", Params: map[string]interface{}{"environment": "staging"}, Retries: 2, Backoff: "1m"},
+			{Name: "verify-staging", Type: StepAction, Action: "verify", Params: map[string]interface{}{"environment": "staging"}},
+			{Name: "promote", Type: StepAction, Action: "promote", Params: map[string]interface{}{"from": "staging", "to": "prod"}},
+		},
+	}
+}
+
+func TestStart_Validates(t *testing.T) {
+	engine, _ := newTestEngine(t, &fakeDeployer{deployed: map[string]bool{}})
+	ctx := context.Background()
+
+	assert.ErrorContains(t, engine.Start(ctx, &Workflow{Steps: []Step{{Type: StepWait, Delay: "1h"}}}), "name is required")
+	assert.ErrorContains(t, engine.Start(ctx, &Workflow{Name: "empty"}), "at least one step")
+	assert.ErrorContains(t, engine.Start(ctx, &Workflow{Name: "w", Steps: []Step{{Type: StepWait}}}), "exactly one of delay and until")
+	assert.ErrorContains(t, engine.Start(ctx, &Workflow{Name: "w", Steps: []Step{{Type: StepSignal}}}), "signal is required")
+	assert.ErrorContains(t, engine.Start(ctx, &Workflow{Name: "w", Steps: []Step{{Type: StepAction, Action: "reboot"}}}), `unknown action "reboot"`)
+	assert.ErrorContains(t, engine.Start(ctx, &Workflow{Name: "w", Steps: []Step{{Type: "sleep"}}}), "unknown type")
+	assert.ErrorContains(t, engine.Start(ctx, &Workflow{Name: "w", Steps: []Step{{Name: "a", Type: StepWait, Delay: "1h"}, {Name: "a", Type: StepWait, Delay: "1h"}}}), "duplicate name")
+}
+
+func TestWorkflow_ApprovalScheduledDeployVerificationPromotion(t *testing.T) {
+	deployer := &fakeDeployer{deployed: map[string]bool{}, failures: 1}
+	engine, advance := newTestEngine(t, deployer)
+	ctx := context.Background()
+
+	workflow := releaseWorkflow()
+	require.NoError(t, engine.Start(ctx, workflow))
+	assert.Equal(t, StatusWaiting, workflow.Status)
+	assert.Equal(t, "approval", workflow.WaitingFor)
+
+	// Nothing is due until the approval arrives
+	advance(time.Hour)
+	assert.Equal(t, 0, engine.Tick(ctx))
+
+	approved, err := engine.Signal(ctx, workflow.ID, "approval", map[string]interface{}{"comment": "ship it"}, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, StatusWaiting, approved.Status)
+	assert.Equal(t, 1, approved.Current, "waiting on the deploy window")
+	assert.Equal(t, "ship it", approved.Outputs["approval"]["comment"])
+
+	// The timer ends; the first deploy attempt fails and is retried after the backoff
+	advance(12 * time.Hour)
+	assert.Equal(t, 1, engine.Tick(ctx))
+	retrying, err := engine.Get(workflow.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusWaiting, retrying.Status)
+	assert.Equal(t, 1, retrying.Attempts)
+	assert.Contains(t, retrying.Error, "cluster unreachable")
+
+	advance(time.Minute)
+	assert.Equal(t, 1, engine.Tick(ctx))
+	done, err := engine.Get(workflow.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusCompleted, done.Status, done.Error)
+	assert.True(t, deployer.deployed["checkout/prod"])
+	assert.Equal(t, "deploy-checkout-prod", done.Outputs["promote"]["deployment_id"])
+	assert.NotNil(t, done.CompletedAt)
+
+	_, err = engine.Signal(ctx, workflow.ID, "approval", nil, "bob")
+	assert.ErrorIs(t, err, ErrWorkflowFinished)
+}
+
+func TestWorkflow_FailureRetryAndCancel(t *testing.T) {
+	engine, advance := newTestEngine(t, &fakeDeployer{deployed: map[string]bool{}})
+	ctx := context.Background()
+
+	// Verification of an application that was never deployed fails without retries
+	workflow := &Workflow{
+		Name:  "verify only",
+		Input: map[string]interface{}{"application": "checkout", "environment": "prod"},
+		Steps: []Step{{Name: "verify", Type: StepAction, Action: "verify"}},
+	}
+	require.NoError(t, engine.Start(ctx, workflow))
+	assert.Equal(t, StatusFailed, workflow.Status)
+	assert.Contains(t, workflow.Error, "not deployed")
+	_, err := engine.Cancel(workflow.ID, "giving up", "alice")
+	assert.ErrorIs(t, err, ErrWorkflowFinished)
+
+	retried, err := engine.Retry(ctx, workflow.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusFailed, retried.Status, "still not deployed")
+	_, err = engine.Retry(ctx, "missing")
+	assert.ErrorIs(t, err, ErrWorkflowNotFound)
+
+	// A signal step times out; an early signal is kept for a later step
+	timed := &Workflow{Name: "timed", Steps: []Step{
+		{Name: "hold", Type: StepWait, Delay: "1h"},
+		{Name: "approval", Type: StepSignal, Signal: "approval", Timeout: "1h"},
+	}}
+	require.NoError(t, engine.Start(ctx, timed))
+	advance(time.Hour)
+	engine.Tick(ctx)
+	advance(time.Hour)
+	engine.Tick(ctx)
+	expired, err := engine.Get(timed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusFailed, expired.Status)
+	assert.Contains(t, expired.Error, "no approval signal within 1h")
+
+	early := &Workflow{Name: "early", Steps: []Step{
+		{Name: "hold", Type: StepWait, Delay: "1h"},
+		{Name: "approval", Type: StepSignal, Signal: "approval"},
+	}}
+	require.NoError(t, engine.Start(ctx, early))
+	_, err = engine.Signal(ctx, early.ID, "approval", nil, "alice")
+	require.NoError(t, err)
+	advance(time.Hour)
+	engine.Tick(ctx)
+	finished, err := engine.Get(early.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusCompleted, finished.Status)
+
+	waiting := &Workflow{Name: "cancel me", Steps: []Step{{Type: StepSignal, Signal: "approval"}}}
+	require.NoError(t, engine.Start(ctx, waiting))
+	cancelled, err := engine.Cancel(waiting.ID, "rejected", "bob")
+	require.NoError(t, err)
+	assert.Equal(t, StatusCancelled, cancelled.Status)
+
+	listed, err := engine.List(StatusFailed)
+	require.NoError(t, err)
+	assert.Len(t, listed, 2)
+}
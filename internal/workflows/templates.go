@@ -0,0 +1,239 @@
+package workflows
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ErrTemplateNotFound is returned when a workflow template does not exist
+var ErrTemplateNotFound = errors.New("workflow template not found")
+
+// Parameter is an input of a template. Its value replaces {{name}} in the template's steps and
+// is passed to every action as workflow input.
+type Parameter struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Default     string `json:"default,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// Template is a parameterized workflow for an operational procedure
+type Template struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Parameters  []Parameter `json:"parameters"`
+	Steps       []Step      `json:"steps"`
+}
+
+// Customization adjusts a template when starting a workflow from it
+type Customization struct {
+	Skip        []string          `json:"skip,omitempty"`        // optional steps to leave out
+	Assignments map[string]string `json:"assignments,omitempty"` // by step name: the capability of the agent performing a step assigned to an agent
+}
+
+var placeholder = regexp.MustCompile(`\{\{\s*([a-zA-Z0-9_]+)\s*\}\}`)
+
+// Instantiate builds a workflow from the template, its parameters and customization
+func (t Template) Instantiate(params map[string]string, custom Customization) (*Workflow, error) {
+	values := map[string]string{}
+	for _, parameter := range t.Parameters {
+		value := params[parameter.Name]
+		if value == "" {
+			value = parameter.Default
+		}
+		if value == "" && parameter.Required {
+			return nil, fmt.Errorf("parameter %s is required", parameter.Name)
+		}
+		values[parameter.Name] = value
+	}
+	for name := range params {
+		if _, ok := values[name]; !ok {
+			return nil, fmt.Errorf("unknown parameter %s", name)
+		}
+	}
+
+	skip := map[string]bool{}
+	for _, name := range custom.Skip {
+		skip[name] = true
+	}
+	assigned := map[string]bool{}
+	var steps []Step
+	for _, step := range t.Steps {
+		if skip[step.Name] {
+			if !step.Optional {
+				return nil, fmt.Errorf("step %s cannot be skipped", step.Name)
+			}
+			delete(skip, step.Name)
+			continue
+		}
+		delete(skip, step.Name)
+		if capability, ok := custom.Assignments[step.Name]; ok {
+			if step.Capability == "" {
+				return nil, fmt.Errorf("step %s is not performed by an agent and cannot be reassigned", step.Name)
+			}
+			step.Capability = capability
+			assigned[step.Name] = true
+		}
+		expanded, err := expandStep(step, values)
+		if err != nil {
+			return nil, fmt.Errorf("step %s: %w", step.Name, err)
+		}
+		steps = append(steps, expanded)
+	}
+	for name := range skip {
+		return nil, fmt.Errorf("unknown step %s to skip", name)
+	}
+	for name := range custom.Assignments {
+		if !assigned[name] {
+			return nil, fmt.Errorf("unknown step %s to assign", name)
+		}
+	}
+
+	input := make(map[string]interface{}, len(values))
+	for name, value := range values {
+		if value != "" {
+			input[name] = value
+		}
+	}
+	return &Workflow{Name: t.Name, Template: t.Name, Input: input, Steps: steps}, nil
+}
+
+// expandStep replaces the placeholders in the step's text fields and string params
+func expandStep(step Step, values map[string]string) (Step, error) {
+	var err error
+	expand := func(text string) string {
+		return placeholder.ReplaceAllStringFunc(text, func(match string) string {
+			name := placeholder.FindStringSubmatch(match)[1]
+			value, ok := values[name]
+			if !ok && err == nil {
+				err = fmt.Errorf("unknown parameter %s", name)
+			}
+			return value
+		})
+	}
+	step.Message = expand(step.Message)
+	step.Delay = expand(step.Delay)
+	step.Timeout = expand(step.Timeout)
+	step.Signal = expand(step.Signal)
+	if step.Params != nil {
+		params := make(map[string]interface{}, len(step.Params))
+		for k, v := range step.Params {
+			if text, ok := v.(string); ok {
+				v = expand(text)
+			}
+			params[k] = v
+		}
+		step.Params = params
+	}
+	return step, err
+}
+
+// RegisterTemplate adds a template, replacing one with the same name
+func (e *Engine) RegisterTemplate(template Template) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.templates[template.Name] = template
+}
+
+// Templates returns the templates by name
+func (e *Engine) Templates() []Template {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	templates := make([]Template, 0, len(e.templates))
+	for _, template := range e.templates {
+		templates = append(templates, template)
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	return templates
+}
+
+// Template returns a template by name
+func (e *Engine) Template(name string) (Template, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	template, ok := e.templates[strings.TrimSpace(name)]
+	if !ok {
+		return Template{}, ErrTemplateNotFound
+	}
+	return template, nil
+}
+
+// StartTemplate starts a workflow from a template
+func (e *Engine) StartTemplate(ctx context.Context, name string, params map[string]string, custom Customization, createdBy string) (*Workflow, error) {
+	template, err := e.Template(name)
+	if err != nil {
+		return nil, err
+	}
+	workflow, err := template.Instantiate(params, custom)
+	if err != nil {
+		return nil, err
+	}
+	workflow.CreatedBy = createdBy
+	if err := e.Start(ctx, workflow); err != nil {
+		return nil, err
+	}
+	return workflow, nil
+}
+
+// BuiltinTemplates returns the templates every engine starts with
+func BuiltinTemplates() []Template {
+	return []Template{
+		{
+			Name:        "incident-response",
+			Description: "Assess an incident, wait for an on-call engineer to acknowledge it, roll back, verify the application is deployed again and wait for the incident to be resolved",
+			Parameters: []Parameter{
+				{Name: "application", Description: "Affected application", Required: true},
+				{Name: "environment", Description: "Affected environment", Default: "production"},
+				{Name: "severity", Description: "Incident severity, passed to every agent", Default: "high"},
+				{Name: "ack_timeout", Description: "How long on-call has to acknowledge before the workflow fails", Default: "30m"},
+			},
+			Steps: []Step{
+				{Name: "assess", Type: StepAction, Capability: "deployment_status", Intent: "check deployment status",
+					Message: "Report the deployment status of {{application}} in {{environment}} for a {{severity}} severity incident", Retries: 2, Backoff: "10s"},
+				{Name: "acknowledge", Type: StepSignal, Signal: "acknowledge", Timeout: "{{ack_timeout}}"},
+				{Name: "rollback", Type: StepAction, Capability: "deployment_orchestration", Intent: "rollback deployment",
+					Message: "Roll back {{application}} in {{environment}} to its previous release", Optional: true},
+				{Name: "verify", Type: StepAction, Action: "verify", Retries: 3, Backoff: "1m"},
+				{Name: "resolve", Type: StepSignal, Signal: "resolve"},
+			},
+		},
+		{
+			Name:        "certificate-rotation",
+			Description: "After approval, rotate a certificate, redeploy the application using it and verify the deployment",
+			Parameters: []Parameter{
+				{Name: "application", Description: "Application serving the certificate", Required: true},
+				{Name: "environment", Description: "Environment to rotate it in", Required: true},
+				{Name: "certificate", Description: "Certificate resource to rotate", Required: true},
+				{Name: "approval_timeout", Description: "How long to wait for approval", Default: "72h"},
+			},
+			Steps: []Step{
+				{Name: "approval", Type: StepSignal, Signal: "approval", Timeout: "{{approval_timeout}}", Optional: true},
+				{Name: "rotate", Type: StepAction, Capability: "resource_lifecycle", Intent: "rotate certificate",
+					Message: "Rotate the certificate {{certificate}} used by {{application}} in {{environment}}", Retries: 2, Backoff: "5m"},
+				{Name: "redeploy", Type: StepAction, Action: "deploy", Retries: 2, Backoff: "5m"},
+				{Name: "verify", Type: StepAction, Action: "verify", Retries: 3, Backoff: "1m"},
+			},
+		},
+		{
+			Name:        "environment-decommission",
+			Description: "After approval and a grace period, release an environment's resources and delete it",
+			Parameters: []Parameter{
+				{Name: "environment", Description: "Environment to decommission", Required: true},
+				{Name: "grace_period", Description: "Time between approval and teardown for last objections", Default: "72h"},
+				{Name: "approval_timeout", Description: "How long to wait for approval", Default: "168h"},
+			},
+			Steps: []Step{
+				{Name: "approval", Type: StepSignal, Signal: "approval", Timeout: "{{approval_timeout}}"},
+				{Name: "grace-period", Type: StepWait, Delay: "{{grace_period}}", Optional: true},
+				{Name: "release-resources", Type: StepAction, Capability: "resource_lifecycle", Intent: "decommission resources",
+					Message: "Release the resources provisioned for environment {{environment}}", Retries: 2, Backoff: "5m"},
+				{Name: "remove-environment", Type: StepAction, Capability: "environment_management", Intent: "delete environment",
+					Message: "Delete environment {{environment}}"},
+			},
+		},
+	}
+}
@@ -0,0 +1,141 @@
+package workflows
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+	"github.com/krzachariassen/ZTDP/internal/ai/aitest"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuiltinTemplatesAreValid(t *testing.T) {
+	for _, template := range BuiltinTemplates() {
+		params := map[string]string{}
+		for _, parameter := range template.Parameters {
+			if parameter.Required {
+				params[parameter.Name] = "example"
+			}
+		}
+		workflow, err := template.Instantiate(params, Customization{})
+		require.NoError(t, err, template.Name)
+		assert.NoError(t, workflow.Validate(), template.Name)
+	}
+}
+
+func TestTemplateInstantiate(t *testing.T) {
+	engine, _ := newTestEngine(t, &fakeDeployer{deployed: map[string]bool{}})
+	template, err := engine.Template("incident-response")
+	require.NoError(t, err)
+
+	workflow, err := template.Instantiate(
+		map[string]string{"application": "checkout", "ack_timeout": "15m"},
+		Customization{Skip: []string{"rollback"}, Assignments: map[string]string{"assess": "observability"}},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "incident-response", workflow.Template)
+	assert.Equal(t, map[string]interface{}{"application": "checkout", "environment": "production", "severity": "high", "ack_timeout": "15m"}, workflow.Input)
+	names := []string{}
+	for _, step := range workflow.Steps {
+		names = append(names, step.Name)
+	}
+	assert.Equal(t, []string{"assess", "acknowledge", "verify", "resolve"}, names)
+	assert.Equal(t, "observability", workflow.Steps[0].Capability)
+	assert.Equal(t, "Report the deployment status of checkout in production for a high severity incident", workflow.Steps[0].Message)
+	assert.Equal(t, "15m", workflow.Steps[1].Timeout)
+
+	_, err = template.Instantiate(map[string]string{}, Customization{})
+	assert.ErrorContains(t, err, "parameter application is required")
+	_, err = template.Instantiate(map[string]string{"application": "checkout", "region": "eu"}, Customization{})
+	assert.ErrorContains(t, err, "unknown parameter region")
+	_, err = template.Instantiate(map[string]string{"application": "checkout"}, Customization{Skip: []string{"acknowledge"}})
+	assert.ErrorContains(t, err, "cannot be skipped")
+	_, err = template.Instantiate(map[string]string{"application": "checkout"}, Customization{Assignments: map[string]string{"verify": "other"}})
+	assert.ErrorContains(t, err, "cannot be reassigned")
+	_, err = engine.StartTemplate(context.Background(), "unknown", nil, Customization{}, "")
+	assert.ErrorIs(t, err, ErrTemplateNotFound)
+}
+
+func TestIncidentResponseDispatchesStepsToAgents(t *testing.T) {
+	deployer := &fakeDeployer{deployed: map[string]bool{"checkout/production": true}}
+	engine, _ := newTestEngine(t, deployer)
+	ctx := context.Background()
+
+	// Without a dispatcher, steps assigned to agents fail and are retried
+	retrying, err := engine.StartTemplate(ctx, "incident-response", map[string]string{"application": "checkout"}, Customization{}, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, StatusWaiting, retrying.Status)
+	assert.Contains(t, retrying.Error, "no agent dispatcher to reach capability deployment_status")
+
+	var dispatched []string
+	engine.SetDispatcher(func(ctx context.Context, capability string, payload map[string]interface{}, timeout time.Duration) (map[string]interface{}, error) {
+		dispatched = append(dispatched, capability+": "+payload["intent"].(string))
+		assert.Equal(t, "checkout", payload["application"])
+		assert.Equal(t, DefaultAgentTimeout, timeout)
+		return map[string]interface{}{"status": "success"}, nil
+	})
+
+	workflow, err := engine.StartTemplate(ctx, "incident-response", map[string]string{"application": "checkout"}, Customization{}, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, "acknowledge", workflow.WaitingFor)
+	assert.Equal(t, "alice", workflow.CreatedBy)
+
+	_, err = engine.Signal(ctx, workflow.ID, "acknowledge", nil, "bob")
+	require.NoError(t, err)
+	resolved, err := engine.Signal(ctx, workflow.ID, "resolve", nil, "bob")
+	require.NoError(t, err)
+	assert.Equal(t, StatusCompleted, resolved.Status, resolved.Error)
+	assert.Equal(t, []string{
+		"deployment_status: check deployment status",
+		"deployment_orchestration: rollback deployment",
+	}, dispatched)
+}
+
+func TestWorkflowAgentStartsTemplatesFromChat(t *testing.T) {
+	engine, _ := newTestEngine(t, &fakeDeployer{deployed: map[string]bool{}})
+	registry := agentRegistry.NewInMemoryAgentRegistry()
+	bus := events.NewEventBus(nil, false)
+
+	// A stand-in for the environment and resource agents the decommission template assigns steps to
+	var requests []string
+	for _, capability := range []string{"resource_lifecycle", "environment_management"} {
+		var agent agentRegistry.AgentInterface
+		agent, err := agentframework.NewAgent(capability + "-agent").
+			WithCapabilities([]agentRegistry.AgentCapability{{Name: capability, RoutingKeys: []string{capability + ".request"}}}).
+			WithEventHandler(func(ctx context.Context, event *events.Event) (*events.Event, error) {
+				requests = append(requests, event.Payload["user_message"].(string))
+				return agent.(*agentframework.BaseAgent).CreateResponse("done", nil, event), nil
+			}).
+			Build(agentframework.AgentDependencies{Registry: registry, EventBus: bus})
+		require.NoError(t, err)
+	}
+
+	provider := aitest.Sequence(
+		`{"action": "start", "template": "environment-decommission", "params": {"environment": "qa"}, "skip": ["grace-period"], "confidence": 0.95}`,
+		`{"action": "status", "confidence": 0.9}`,
+	)
+	agent, err := NewWorkflowAgent(nil, engine, provider, bus, registry)
+	require.NoError(t, err)
+	base := agent.(*agentframework.BaseAgent)
+
+	response, err := base.ProcessEvent(context.Background(), &events.Event{Payload: map[string]interface{}{"user_message": "decommission qa now, no grace period"}})
+	require.NoError(t, err)
+	require.Equal(t, "success", response.Payload["status"], response.Payload["error"])
+	workflow := response.Payload["workflow"].(*Workflow)
+	assert.Equal(t, "approval", workflow.WaitingFor)
+	assert.Contains(t, response.Payload["message"], "waiting for approval")
+
+	response, err = base.ProcessEvent(context.Background(), &events.Event{Payload: map[string]interface{}{"user_message": "which workflows are running?"}})
+	require.NoError(t, err)
+	assert.Contains(t, response.Payload["message"], "1 workflows active")
+
+	approved, err := engine.Signal(context.Background(), workflow.ID, "approval", nil, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, StatusCompleted, approved.Status, approved.Error)
+	assert.Equal(t, []string{"Release the resources provisioned for environment qa", "Delete environment qa"}, requests)
+	assert.Equal(t, "resource_lifecycle-agent", approved.Outputs["release-resources"]["agent"])
+}
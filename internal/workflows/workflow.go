@@ -0,0 +1,189 @@
+// Package workflows runs long-lived workflows, such as approval → scheduled deploy → verification
+// → promotion, as durable state machines. Every transition is persisted in the graph before the
+// next step starts, so a workflow waiting days for a timer or an approval survives restarts and
+// is picked up by whichever instance holds the leader lease.
+package workflows
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Step types
+const (
+	StepAction = "action" // runs a registered action or hands the step to an agent, retrying on failure
+	StepWait   = "wait"   // waits for a delay or until a time
+	StepSignal = "signal" // waits for a signal, such as an approval, sent through the API
+)
+
+// Workflow statuses; completed, failed and cancelled workflows no longer advance
+const (
+	StatusRunning   = "running"
+	StatusWaiting   = "waiting" // on a timer, a signal or a retry backoff
+	StatusCompleted = "completed"
+	StatusFailed    = "failed" // can be retried from the failed step
+	StatusCancelled = "cancelled"
+)
+
+// DefaultBackoff is the delay before the first retry of a failed action; it doubles per attempt
+const DefaultBackoff = 30 * time.Second
+
+// DefaultAgentTimeout bounds how long an action step waits for the agent it is assigned to
+const DefaultAgentTimeout = 5 * time.Minute
+
+var (
+	// ErrWorkflowNotFound is returned when a workflow does not exist
+	ErrWorkflowNotFound = errors.New("workflow not found")
+	// ErrWorkflowFinished is returned when signalling or cancelling a workflow that has ended
+	ErrWorkflowFinished = errors.New("workflow has finished")
+	// ErrNotRetryable is returned when retrying a workflow that has not failed
+	ErrNotRetryable = errors.New("only failed workflows can be retried")
+)
+
+// Step is one state of a workflow
+type Step struct {
+	Name       string                 `json:"name"`
+	Type       string                 `json:"type"`
+	Action     string                 `json:"action,omitempty"`     // action steps: the registered action to run
+	Params     map[string]interface{} `json:"params,omitempty"`     // action steps: passed to the action or agent over the workflow input
+	Capability string                 `json:"capability,omitempty"` // action steps: assigns the step to an agent offering the capability instead of an action
+	Intent     string                 `json:"intent,omitempty"`     // agent steps: the intent sent to the agent
+	Message    string                 `json:"message,omitempty"`    // agent steps: the request sent to the agent as its user message
+	Delay      string                 `json:"delay,omitempty"`      // wait steps: e.g. "24h", counted from when the step starts
+	Until      *time.Time             `json:"until,omitempty"`      // wait steps: instead of a delay
+	Signal     string                 `json:"signal,omitempty"`     // signal steps: the signal to wait for, e.g. "approval"
+	Timeout    string                 `json:"timeout,omitempty"`    // signal steps: fail when no signal arrives in time, else wait forever; agent steps: DefaultAgentTimeout when empty
+	Retries    int                    `json:"retries,omitempty"`    // action steps: attempts after the first
+	Backoff    string                 `json:"backoff,omitempty"`    // action steps: delay before the first retry; DefaultBackoff when empty
+	Optional   bool                   `json:"optional,omitempty"`   // templates: the step can be skipped when starting the workflow
+}
+
+// Signal is a message sent to a workflow from outside, such as an approval
+type Signal struct {
+	Name       string                 `json:"name"`
+	Payload    map[string]interface{} `json:"payload,omitempty"`
+	SentBy     string                 `json:"sent_by,omitempty"`
+	ReceivedAt time.Time              `json:"received_at"`
+}
+
+// Transition records a change of a workflow's state
+type Transition struct {
+	At      time.Time `json:"at"`
+	Step    string    `json:"step,omitempty"`
+	Event   string    `json:"event"` // e.g. started, waiting, retrying, completed, failed, signalled
+	Message string    `json:"message,omitempty"`
+}
+
+// Workflow is a running or finished workflow instance
+type Workflow struct {
+	ID          string                            `json:"id"`
+	Name        string                            `json:"name"`
+	Template    string                            `json:"template,omitempty"` // the template the workflow was started from
+	Input       map[string]interface{}            `json:"input,omitempty"`    // e.g. application and environment, shared by every action
+	Steps       []Step                            `json:"steps"`
+	Current     int                               `json:"current"` // index of the step running or waiting
+	Status      string                            `json:"status"`
+	WaitingFor  string                            `json:"waiting_for,omitempty"` // the signal the current step waits for
+	WakeAt      *time.Time                        `json:"wake_at,omitempty"`     // when the timer, signal timeout or retry backoff ends
+	Attempts    int                               `json:"attempts,omitempty"`    // failed attempts of the current step
+	Outputs     map[string]map[string]interface{} `json:"outputs,omitempty"`     // by step name: action results and signal payloads
+	Signals     []Signal                          `json:"signals,omitempty"`     // received before a step waited for them
+	Error       string                            `json:"error,omitempty"`
+	History     []Transition                      `json:"history"`
+	CreatedBy   string                            `json:"created_by,omitempty"`
+	CreatedAt   time.Time                         `json:"created_at"`
+	UpdatedAt   time.Time                         `json:"updated_at"`
+	CompletedAt *time.Time                        `json:"completed_at,omitempty"`
+}
+
+// Finished reports whether the workflow no longer advances
+func (w *Workflow) Finished() bool {
+	return w.Status == StatusCompleted || w.Status == StatusFailed || w.Status == StatusCancelled
+}
+
+// CurrentStep returns the step running or waiting, or nil when the workflow has no more steps
+func (w *Workflow) CurrentStep() *Step {
+	if w.Current < 0 || w.Current >= len(w.Steps) {
+		return nil
+	}
+	return &w.Steps[w.Current]
+}
+
+// Validate checks the workflow definition, naming steps that have no name by their position
+func (w *Workflow) Validate() error {
+	if w.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len(w.Steps) == 0 {
+		return fmt.Errorf("at least one step is required")
+	}
+	names := map[string]bool{}
+	for i := range w.Steps {
+		step := &w.Steps[i]
+		if step.Name == "" {
+			step.Name = fmt.Sprintf("step-%d", i+1)
+		}
+		if names[step.Name] {
+			return fmt.Errorf("step %s: duplicate name", step.Name)
+		}
+		names[step.Name] = true
+		if err := step.validate(); err != nil {
+			return fmt.Errorf("step %s: %w", step.Name, err)
+		}
+	}
+	return nil
+}
+
+func (s *Step) validate() error {
+	switch s.Type {
+	case StepAction:
+		if (s.Action == "") == (s.Capability == "") {
+			return fmt.Errorf("exactly one of action and capability is required")
+		}
+		if s.Capability != "" && s.Intent == "" && s.Message == "" {
+			return fmt.Errorf("an intent or message for the agent is required")
+		}
+		if _, err := parseDuration(s.Timeout); err != nil {
+			return fmt.Errorf("timeout: %w", err)
+		}
+		if s.Retries < 0 {
+			return fmt.Errorf("retries must not be negative")
+		}
+		if _, err := parseDuration(s.Backoff); err != nil {
+			return fmt.Errorf("backoff: %w", err)
+		}
+	case StepWait:
+		if (s.Delay == "") == (s.Until == nil) {
+			return fmt.Errorf("exactly one of delay and until is required")
+		}
+		if _, err := parseDuration(s.Delay); err != nil {
+			return fmt.Errorf("delay: %w", err)
+		}
+	case StepSignal:
+		if s.Signal == "" {
+			return fmt.Errorf("signal is required")
+		}
+		if _, err := parseDuration(s.Timeout); err != nil {
+			return fmt.Errorf("timeout: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown type %q (use action, wait or signal)", s.Type)
+	}
+	return nil
+}
+
+// parseDuration parses an optional positive duration; empty is zero
+func parseDuration(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("must be positive")
+	}
+	return d, nil
+}
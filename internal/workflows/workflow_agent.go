@@ -0,0 +1,288 @@
+package workflows
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+	"github.com/krzachariassen/ZTDP/internal/ai"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/features"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
+)
+
+// WorkflowRequest is what the AI extracts from a workflow request
+type WorkflowRequest struct {
+	Action        string            `json:"action"` // start | templates | status | signal
+	Template      string            `json:"template,omitempty"`
+	Params        map[string]string `json:"params,omitempty"`
+	Skip          []string          `json:"skip,omitempty"`        // optional template steps to leave out
+	WorkflowID    string            `json:"workflow_id,omitempty"` // status and signal
+	Signal        string            `json:"signal,omitempty"`      // e.g. approval, acknowledge, resolve
+	Confidence    float64           `json:"confidence"`
+	Clarification string            `json:"clarification,omitempty"`
+}
+
+// WorkflowAgent starts workflows from templates and signals them from chat, and hands workflow
+// steps assigned to agents to them
+type WorkflowAgent struct {
+	engine     *Engine
+	aiProvider ai.AIProvider
+	logger     *logging.Logger
+}
+
+// NewWorkflowAgent creates the workflow agent and makes it the engine's dispatcher
+func NewWorkflowAgent(
+	globalGraph *graph.GlobalGraph,
+	engine *Engine,
+	aiProvider ai.AIProvider,
+	eventBus *events.EventBus,
+	registry agentRegistry.AgentRegistry,
+) (agentRegistry.AgentInterface, error) {
+	if engine == nil {
+		return nil, fmt.Errorf("workflow engine is required")
+	}
+	if aiProvider == nil {
+		return nil, fmt.Errorf("aiProvider is required for AI-native agent")
+	}
+	if eventBus == nil {
+		return nil, fmt.Errorf("eventBus is required")
+	}
+	if registry == nil {
+		return nil, fmt.Errorf("registry is required")
+	}
+
+	wrapper := &WorkflowAgent{
+		engine:     engine,
+		aiProvider: aiProvider,
+		logger:     logging.GetLogger().ForComponent("workflow-agent"),
+	}
+
+	agent, err := agentframework.NewAgent("workflow-agent").
+		WithType("workflow").
+		WithCapabilities(getWorkflowCapabilities()).
+		WithEventHandler(wrapper.handleEvent).
+		Build(agentframework.AgentDependencies{
+			Registry: registry,
+			EventBus: eventBus,
+			Flags:    features.NewService(globalGraph),
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build workflow agent: %w", err)
+	}
+
+	base := agent.(*agentframework.BaseAgent)
+	engine.SetDispatcher(func(ctx context.Context, capability string, payload map[string]interface{}, timeout time.Duration) (map[string]interface{}, error) {
+		result, err := base.QueryAgent(ctx, capability, payload, timeout)
+		if err != nil {
+			return nil, err
+		}
+		output := map[string]interface{}{"agent": result.AgentID}
+		for _, key := range []string{"status", "message"} {
+			if value, ok := result.Payload[key]; ok {
+				output[key] = value
+			}
+		}
+		return output, nil
+	})
+
+	wrapper.logger.Info("✅ WorkflowAgent created successfully")
+	return agent, nil
+}
+
+// getWorkflowCapabilities returns the capabilities for the workflow agent
+func getWorkflowCapabilities() []agentRegistry.AgentCapability {
+	return []agentRegistry.AgentCapability{
+		{
+			Name:        "workflow_management",
+			Description: "Starts operational procedures (incident response, certificate rotation, environment decommission) as durable workflows, reports their progress and passes approvals to them",
+			Intents: []string{
+				"start workflow", "incident response", "rotate certificate", "decommission environment",
+				"workflow status", "approve workflow", "list workflow templates",
+			},
+			InputTypes:  []string{"user_message"},
+			OutputTypes: []string{"workflow", "workflow_list", "workflow_template_list"},
+			RoutingKeys: []string{"workflow.request", "workflow.start", "workflow.signal"},
+			Version:     "1.0.0",
+		},
+	}
+}
+
+// handleEvent extracts the workflow request with AI and carries it out
+func (a *WorkflowAgent) handleEvent(ctx context.Context, event *events.Event) (*events.Event, error) {
+	userMessage, ok := event.Payload["user_message"].(string)
+	if !ok || userMessage == "" {
+		return a.createErrorResponse(event, "user_message field is required in event payload"), nil
+	}
+
+	request, err := a.extractRequest(ctx, userMessage)
+	if err != nil {
+		return a.createErrorResponse(event, fmt.Sprintf("I couldn't understand the workflow request: %v", err)), nil
+	}
+	if agentframework.NeedsClarification(ctx, request.Confidence) {
+		clarification := request.Clarification
+		if clarification == "" {
+			clarification = "Which procedure should I start, and for which application or environment?"
+		}
+		return agentframework.ClarificationResponse(ctx, event, clarification, request.Confidence), nil
+	}
+
+	userID := logging.UserIDFromContext(ctx)
+	switch request.Action {
+	case "start":
+		workflow, err := a.engine.StartTemplate(ctx, request.Template, request.Params, Customization{Skip: request.Skip}, userID)
+		if err != nil {
+			return a.createErrorResponse(event, fmt.Sprintf("Failed to start %s: %v", request.Template, err)), nil
+		}
+		return a.createSuccessResponse(event, "🔁 Started "+describeWorkflow(workflow), map[string]interface{}{"workflow": workflow}), nil
+	case "templates":
+		templates := a.engine.Templates()
+		return a.createSuccessResponse(event, describeTemplates(templates), map[string]interface{}{"templates": templates}), nil
+	case "status":
+		if request.WorkflowID != "" {
+			workflow, err := a.engine.Get(request.WorkflowID)
+			if err != nil {
+				return a.createErrorResponse(event, fmt.Sprintf("Workflow %s: %v", request.WorkflowID, err)), nil
+			}
+			return a.createSuccessResponse(event, describeWorkflow(workflow), map[string]interface{}{"workflow": workflow}), nil
+		}
+		workflows, err := a.engine.List("")
+		if err != nil {
+			return a.createErrorResponse(event, fmt.Sprintf("Failed to list workflows: %v", err)), nil
+		}
+		var active []*Workflow
+		for _, workflow := range workflows {
+			if !workflow.Finished() {
+				active = append(active, workflow)
+			}
+		}
+		return a.createSuccessResponse(event, describeWorkflows(active), map[string]interface{}{"workflows": active}), nil
+	case "signal":
+		if request.WorkflowID == "" || request.Signal == "" {
+			return a.createErrorResponse(event, "Tell me which workflow to signal and with what, e.g. approval"), nil
+		}
+		workflow, err := a.engine.Signal(ctx, request.WorkflowID, request.Signal, nil, userID)
+		if err != nil {
+			return a.createErrorResponse(event, fmt.Sprintf("Failed to signal %s: %v", request.WorkflowID, err)), nil
+		}
+		return a.createSuccessResponse(event, fmt.Sprintf("📨 Sent %s to %s", request.Signal, describeWorkflow(workflow)), map[string]interface{}{"workflow": workflow}), nil
+	default:
+		return a.createErrorResponse(event, fmt.Sprintf("I can start workflows from templates, list templates, report workflow status or signal workflows, not %q", request.Action)), nil
+	}
+}
+
+// extractRequest asks the AI to turn the user message into a WorkflowRequest
+func (a *WorkflowAgent) extractRequest(ctx context.Context, userMessage string) (*WorkflowRequest, error) {
+	data, err := json.Marshal(a.engine.Templates())
+	if err != nil {
+		return nil, err
+	}
+	systemPrompt := `You run operational procedures as workflows started from templates. Extract the request as JSON:
+{"action": "start|templates|status|signal", "template": "", "params": {}, "skip": [], "workflow_id": "", "signal": "", "confidence": 0.0, "clarification": ""}
+
+Templates:
+` + string(data) + `
+
+Rules:
+- For start, pick the template and fill params with the values the user gave, by parameter name
+- skip lists optional steps the user wants left out, by step name
+- For status, set workflow_id when the user names one; leave it empty to list active workflows
+- For signal, set workflow_id and the signal a step waits for, e.g. approval, acknowledge or resolve
+- Set confidence below 0.7 and explain in clarification when required parameters are missing
+
+Respond with JSON only.`
+
+	response, err := a.aiProvider.CallAI(ai.WithTask(ctx, ai.TaskExtraction), systemPrompt, userMessage)
+	if err != nil {
+		return nil, err
+	}
+
+	cleaned := strings.TrimSpace(response)
+	cleaned = strings.TrimPrefix(cleaned, "```json")
+	cleaned = strings.TrimPrefix(cleaned, "```")
+	cleaned = strings.TrimSuffix(cleaned, "```")
+
+	var request WorkflowRequest
+	if err := json.Unmarshal([]byte(strings.TrimSpace(cleaned)), &request); err != nil {
+		return nil, fmt.Errorf("failed to parse AI response: %w", err)
+	}
+	a.logger.Info("🤖 AI extracted workflow action: %s (%s), confidence: %.2f", request.Action, request.Template, request.Confidence)
+	return &request, nil
+}
+
+func describeWorkflow(workflow *Workflow) string {
+	text := fmt.Sprintf("workflow %s (%s): %s", workflow.ID, workflow.Name, workflow.Status)
+	switch {
+	case workflow.WaitingFor != "":
+		text += fmt.Sprintf(", waiting for %s at step %s", workflow.WaitingFor, workflow.stepName())
+	case workflow.Status == StatusWaiting && workflow.WakeAt != nil:
+		text += fmt.Sprintf(", step %s resumes at %s", workflow.stepName(), workflow.WakeAt.Format(time.RFC3339))
+	case workflow.Status == StatusFailed:
+		text += fmt.Sprintf(" at step %s: %s", workflow.stepName(), workflow.Error)
+	}
+	return text
+}
+
+func describeWorkflows(workflows []*Workflow) string {
+	if len(workflows) == 0 {
+		return "No workflows are active"
+	}
+	lines := []string{fmt.Sprintf("%d workflows active:", len(workflows))}
+	for _, workflow := range workflows {
+		lines = append(lines, "- "+describeWorkflow(workflow))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func describeTemplates(templates []Template) string {
+	lines := []string{"Workflow templates:"}
+	for _, template := range templates {
+		var params []string
+		for _, parameter := range template.Parameters {
+			if parameter.Required {
+				params = append(params, parameter.Name)
+			}
+		}
+		lines = append(lines, fmt.Sprintf("- %s: %s (requires %s)", template.Name, template.Description, strings.Join(params, ", ")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (a *WorkflowAgent) createSuccessResponse(originalEvent *events.Event, message string, data map[string]interface{}) *events.Event {
+	payload := map[string]interface{}{
+		"status":         "success",
+		"message":        message,
+		"correlation_id": originalEvent.Payload["correlation_id"],
+	}
+	for k, v := range data {
+		payload[k] = v
+	}
+	return &events.Event{
+		ID:        fmt.Sprintf("workflow-response-%d", time.Now().UnixNano()),
+		Type:      events.EventTypeResponse,
+		Subject:   "workflow.response",
+		Source:    "workflow-agent",
+		Timestamp: time.Now().Unix(),
+		Payload:   payload,
+	}
+}
+
+func (a *WorkflowAgent) createErrorResponse(originalEvent *events.Event, errorMessage string) *events.Event {
+	return &events.Event{
+		ID:        fmt.Sprintf("workflow-error-%d", time.Now().UnixNano()),
+		Type:      events.EventTypeResponse,
+		Subject:   "workflow.error",
+		Source:    "workflow-agent",
+		Timestamp: time.Now().Unix(),
+		Payload: map[string]interface{}{
+			"status":         "error",
+			"error":          errorMessage,
+			"correlation_id": originalEvent.Payload["correlation_id"],
+		},
+	}
+}
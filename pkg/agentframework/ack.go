@@ -0,0 +1,52 @@
+package agentframework
+
+import (
+	"github.com/krzachariassen/ZTDP/internal/events"
+)
+
+// Task acknowledgments tell the dispatcher whether an agent took a request. An ack means the
+// agent has started handling it; a nack means the agent will not, so the dispatcher can send
+// it to another capable agent straight away instead of waiting for the ack timeout.
+const (
+	TaskAckSubject  = "task.ack"
+	TaskNackSubject = "task.nack"
+
+	// TargetAgentKey addresses a request to one agent when several share its routing key
+	TargetAgentKey = "target_agent"
+)
+
+// addressedTo reports whether a request is for this agent: untargeted requests are for
+// every subscriber of the routing key
+func (a *BaseAgent) addressedTo(event events.Event) bool {
+	target, _ := event.Payload[TargetAgentKey].(string)
+	return target == "" || target == a.id
+}
+
+// acknowledge reports an accepted request to its dispatcher. Only dispatched tasks carry a
+// request ID; other requests are not acknowledged.
+func (a *BaseAgent) acknowledge(event events.Event) {
+	a.emitTaskOutcome(event, TaskAckSubject, "")
+}
+
+// reject reports a request the agent will not handle, with the reason
+func (a *BaseAgent) reject(event events.Event, reason string) {
+	a.emitTaskOutcome(event, TaskNackSubject, reason)
+}
+
+func (a *BaseAgent) emitTaskOutcome(event events.Event, subject, reason string) {
+	requestID, _ := event.Payload["request_id"].(string)
+	if requestID == "" || a.eventBus == nil {
+		return
+	}
+	payload := map[string]interface{}{
+		"request_id":     requestID,
+		"correlation_id": event.Payload["correlation_id"],
+		"agent_id":       a.id,
+	}
+	if reason != "" {
+		payload["reason"] = reason
+	}
+	if err := a.eventBus.Emit(events.EventTypeNotify, a.id, subject, payload); err != nil {
+		a.logger.Warn("⚠️ Failed to send %s for request %s: %v", subject, requestID, err)
+	}
+}
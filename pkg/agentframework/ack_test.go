@@ -0,0 +1,71 @@
+package agentframework
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+	"github.com/krzachariassen/ZTDP/internal/events"
+)
+
+func TestAgentAcknowledgesTasks(t *testing.T) {
+	registry := agentRegistry.NewInMemoryAgentRegistry()
+	bus := events.NewEventBus(nil, false)
+
+	var outcomes []events.Event
+	bus.Subscribe(events.EventTypeNotify, func(event events.Event) error {
+		if event.Subject == TaskAckSubject || event.Subject == TaskNackSubject {
+			outcomes = append(outcomes, event)
+		}
+		return nil
+	})
+
+	handled := 0
+	agent := buildQueryTestAgent(t, registry, bus, "deployer", "deployment", func(ctx context.Context, event *events.Event) (*events.Event, error) {
+		handled++
+		if event.Payload["environment"] == "production" {
+			return nil, errors.New("production is frozen")
+		}
+		return nil, nil
+	})
+
+	emit := func(payload map[string]interface{}) {
+		t.Helper()
+		if err := bus.Emit(events.EventTypeRequest, "orchestrator", "deployer.request", payload); err != nil {
+			t.Fatalf("emit failed: %v", err)
+		}
+	}
+
+	emit(map[string]interface{}{"request_id": "req-1", "correlation_id": "c-1", "environment": "dev"})
+	if len(outcomes) != 1 || outcomes[0].Subject != TaskAckSubject || outcomes[0].Payload["agent_id"] != "deployer" {
+		t.Fatalf("expected one ack from deployer, got %+v", outcomes)
+	}
+
+	// A failing handler still took the task: the failure is its answer
+	emit(map[string]interface{}{"request_id": "req-2", "correlation_id": "c-2", "environment": "production"})
+	if len(outcomes) != 2 || outcomes[1].Subject != TaskAckSubject {
+		t.Fatalf("expected a failed task to be acknowledged, got %+v", outcomes)
+	}
+
+	emit(map[string]interface{}{"request_id": "req-3", "correlation_id": "c-3", TargetAgentKey: "other-deployer"})
+	if handled != 2 || len(outcomes) != 2 {
+		t.Errorf("expected a task addressed to another agent to be ignored, handled %d, outcomes %d", handled, len(outcomes))
+	}
+
+	emit(map[string]interface{}{"correlation_id": "c-4", "environment": "dev"})
+	if handled != 3 || len(outcomes) != 2 {
+		t.Errorf("expected requests without a request ID to be handled without acks, handled %d, outcomes %d", handled, len(outcomes))
+	}
+
+	if err := agent.Stop(context.Background()); err != nil {
+		t.Fatalf("stop failed: %v", err)
+	}
+	emit(map[string]interface{}{"request_id": "req-5", "correlation_id": "c-5", "environment": "dev"})
+	if handled != 3 || len(outcomes) != 3 || outcomes[2].Subject != TaskNackSubject {
+		t.Fatalf("expected a stopping agent to reject the task, got %+v", outcomes)
+	}
+	if outcomes[2].Payload["reason"] != "agent is stopping" || outcomes[2].Payload["correlation_id"] != "c-5" {
+		t.Errorf("expected the nack to carry the reason, got %+v", outcomes[2].Payload)
+	}
+}
@@ -0,0 +1,104 @@
+package agentframework
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/krzachariassen/ZTDP/internal/events"
+)
+
+// When several agents offer an intent, the orchestrator asks each for a bid before choosing
+// one. A bid request arrives on the agent's routing key like any request but carries
+// BidRequestKey; the agent answers with a response holding its bid under BidKey instead of
+// handling the request, and does not acknowledge it as a task.
+const (
+	BidRequestKey = "bid_request"
+	BidKey        = "bid"
+)
+
+// DefaultBidConfidence is the confidence bid by agents without a bidder
+const DefaultBidConfidence = 0.5
+
+// Bid is how an agent rates its fit for a request
+type Bid struct {
+	Confidence float64 `json:"confidence"`         // 0.0-1.0: how likely the agent handles the request well
+	Cost       float64 `json:"cost"`               // relative cost estimate, such as AI tokens or cloud spend; lower is cheaper
+	ETASeconds float64 `json:"eta_seconds"`        // expected time to complete the request
+	Declined   bool    `json:"declined,omitempty"` // the agent will not take the request
+	Reason     string  `json:"reason,omitempty"`
+}
+
+// Bidder rates an agent's fit for a bid request. The event is the request the agent would get
+// if it won, with the intent, user message and context envelope.
+type Bidder func(ctx context.Context, event *events.Event) (Bid, error)
+
+// WithBidder sets how the agent bids for requests; agents without one bid DefaultBidConfidence
+func (b *AgentBuilder) WithBidder(bidder Bidder) *AgentBuilder {
+	b.bidder = bidder
+	return b
+}
+
+// IsBidRequest reports whether a request asks for a bid rather than for the work itself
+func IsBidRequest(event events.Event) bool {
+	bid, _ := event.Payload[BidRequestKey].(bool)
+	return bid
+}
+
+// BidFrom returns the bid carried by a response to a bid request
+func BidFrom(event *events.Event) (Bid, bool) {
+	raw, ok := event.Payload[BidKey]
+	if !ok {
+		return Bid{}, false
+	}
+	if bid, ok := raw.(Bid); ok {
+		return bid, true
+	}
+	// Transports deliver the bid decoded from JSON as a map
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return Bid{}, false
+	}
+	var bid Bid
+	if err := json.Unmarshal(data, &bid); err != nil {
+		return Bid{}, false
+	}
+	return bid, true
+}
+
+// answerBid replies to a bid request with the agent's bid. Stopping agents, and agents whose
+// capability for the routing key is switched off, decline.
+func (a *BaseAgent) answerBid(event events.Event) {
+	ctx := a.eventContext(context.Background(), &event)
+	a.mu.RLock()
+	stopping := a.stopping
+	a.mu.RUnlock()
+
+	var bid Bid
+	if stopping {
+		bid = Bid{Declined: true, Reason: "agent is stopping"}
+	} else if capability, disabled := a.disabledCapability(ctx, event.Subject); disabled {
+		bid = Bid{Declined: true, Reason: "capability " + capability + " is disabled"}
+	} else if a.bidder == nil {
+		bid = Bid{Confidence: DefaultBidConfidence, Reason: "default bid"}
+	} else {
+		var err error
+		if bid, err = a.bidder(ctx, &event); err != nil {
+			bid = Bid{Declined: true, Reason: err.Error()}
+		}
+	}
+
+	response := &events.Event{
+		Type:    events.EventTypeResponse,
+		Source:  a.id,
+		Subject: "Bid from " + a.id,
+		Payload: map[string]interface{}{
+			"status":         "bid",
+			"agent_id":       a.id,
+			"correlation_id": event.Payload["correlation_id"],
+			BidKey:           bid,
+		},
+	}
+	if err := a.eventBus.EmitEvent(*response); err != nil {
+		a.logger.Warn("⚠️ Failed to send bid for %v: %v", event.Payload["correlation_id"], err)
+	}
+}
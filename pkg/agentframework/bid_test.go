@@ -0,0 +1,66 @@
+package agentframework
+
+import (
+	"context"
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+	"github.com/krzachariassen/ZTDP/internal/events"
+)
+
+func TestAgentAnswersBidRequests(t *testing.T) {
+	registry := agentRegistry.NewInMemoryAgentRegistry()
+	bus := events.NewEventBus(nil, false)
+
+	var bids []Bid
+	var acks int
+	bus.Subscribe(events.EventTypeResponse, func(event events.Event) error {
+		if bid, ok := BidFrom(&event); ok {
+			bids = append(bids, bid)
+		}
+		return nil
+	})
+	bus.Subscribe(events.EventTypeNotify, func(event events.Event) error {
+		if event.Subject == TaskAckSubject {
+			acks++
+		}
+		return nil
+	})
+
+	handled := 0
+	agent := buildQueryTestAgent(t, registry, bus, "deployer", "deployment", func(ctx context.Context, event *events.Event) (*events.Event, error) {
+		handled++
+		return nil, nil
+	})
+	bidRequest := func(id string) {
+		t.Helper()
+		payload := map[string]interface{}{"request_id": id, "correlation_id": "bid-" + id, BidRequestKey: true}
+		if err := bus.Emit(events.EventTypeRequest, "orchestrator", "deployer.request", payload); err != nil {
+			t.Fatalf("emit failed: %v", err)
+		}
+	}
+
+	bidRequest("1")
+	if len(bids) != 1 || bids[0].Confidence != DefaultBidConfidence || bids[0].Declined {
+		t.Fatalf("expected the default bid, got %+v", bids)
+	}
+	if handled != 0 || acks != 0 {
+		t.Errorf("expected a bid request not to be handled or acknowledged, handled %d, acks %d", handled, acks)
+	}
+
+	agent.bidder = func(ctx context.Context, event *events.Event) (Bid, error) {
+		return Bid{Confidence: 0.9, Cost: 2, ETASeconds: 30}, nil
+	}
+	bidRequest("2")
+	if len(bids) != 2 || bids[1].Confidence != 0.9 || bids[1].ETASeconds != 30 {
+		t.Fatalf("expected the bidder's bid, got %+v", bids)
+	}
+
+	if err := agent.Stop(context.Background()); err != nil {
+		t.Fatalf("stop failed: %v", err)
+	}
+	bidRequest("3")
+	if len(bids) != 3 || !bids[2].Declined || bids[2].Reason != "agent is stopping" {
+		t.Errorf("expected a stopping agent to decline, got %+v", bids)
+	}
+}
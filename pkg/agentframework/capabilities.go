@@ -0,0 +1,124 @@
+package agentframework
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+)
+
+// UpdateCapabilities replaces the agent's capabilities without restarting it and returns the
+// new capability version. Routing keys the new capabilities add are subscribed before the
+// registry advertises them, and keys they drop stop being served only after the registry has
+// stopped advertising them, so requests routed during the switch still reach the agent.
+// Events already being handled are not interrupted.
+func (a *BaseAgent) UpdateCapabilities(ctx context.Context, capabilities []agentRegistry.AgentCapability) (int, error) {
+	if err := validateCapabilities(capabilities); err != nil {
+		return 0, err
+	}
+
+	a.updateMu.Lock()
+	defer a.updateMu.Unlock()
+
+	if err := a.registerRequestSchemas(capabilities); err != nil {
+		return 0, err
+	}
+
+	a.capMu.Lock()
+	previous := make(map[string]bool, len(a.routes))
+	for key := range a.routes {
+		previous[key] = true
+	}
+	a.bindRoutingKeys(capabilities)
+	version := a.capabilitiesVersion + 1
+	a.capMu.Unlock()
+
+	if updater, ok := a.registry.(agentRegistry.CapabilityUpdater); ok {
+		registered, err := updater.UpdateCapabilities(ctx, a.id, capabilities)
+		if err != nil {
+			a.capMu.Lock()
+			a.routes = previous
+			a.capMu.Unlock()
+			return 0, fmt.Errorf("failed to update capabilities of %s: %w", a.id, err)
+		}
+		version = registered
+	}
+
+	a.capMu.Lock()
+	a.capabilities = capabilities
+	a.capabilitiesVersion = version
+	a.routes = routingKeys(capabilities)
+	a.capMu.Unlock()
+
+	a.logger.Info("🔄 Agent %s now serves capabilities version %d: %v", a.id, version, capabilityNames(capabilities))
+	return version, nil
+}
+
+// CapabilitiesVersion returns the version of the agent's current capabilities, starting at 1
+func (a *BaseAgent) CapabilitiesVersion() int {
+	a.capMu.RLock()
+	defer a.capMu.RUnlock()
+	return a.capabilitiesVersion
+}
+
+// bindRoutingKeys starts serving the routing keys of capabilities, subscribing to the ones
+// the agent has never subscribed to. Callers hold capMu.
+func (a *BaseAgent) bindRoutingKeys(capabilities []agentRegistry.AgentCapability) {
+	if a.routes == nil {
+		a.routes = map[string]bool{}
+	}
+	if a.subscribed == nil {
+		a.subscribed = map[string]bool{}
+	}
+	for key := range routingKeys(capabilities) {
+		a.routes[key] = true
+		if a.eventBus != nil && !a.subscribed[key] {
+			a.subscribe(key)
+			a.subscribed[key] = true
+		}
+	}
+}
+
+// serves reports whether the agent currently handles events for routingKey
+func (a *BaseAgent) serves(routingKey string) bool {
+	a.capMu.RLock()
+	defer a.capMu.RUnlock()
+	return a.routes[routingKey]
+}
+
+func validateCapabilities(capabilities []agentRegistry.AgentCapability) error {
+	names := map[string]bool{}
+	for i, capability := range capabilities {
+		if capability.Name == "" {
+			return fmt.Errorf("capability %d has no name", i)
+		}
+		if names[capability.Name] {
+			return fmt.Errorf("capability %s is defined more than once", capability.Name)
+		}
+		names[capability.Name] = true
+		for _, key := range capability.RoutingKeys {
+			if key == "" {
+				return fmt.Errorf("capability %s has an empty routing key", capability.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func routingKeys(capabilities []agentRegistry.AgentCapability) map[string]bool {
+	keys := map[string]bool{}
+	for _, capability := range capabilities {
+		for _, key := range capability.RoutingKeys {
+			keys[key] = true
+		}
+	}
+	return keys
+}
+
+func capabilityNames(capabilities []agentRegistry.AgentCapability) []string {
+	names := make([]string, 0, len(capabilities))
+	for _, capability := range capabilities {
+		names = append(names, capability.Name)
+	}
+	return names
+}
@@ -0,0 +1,102 @@
+package agentframework
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+	"github.com/krzachariassen/ZTDP/internal/events"
+)
+
+func TestUpdateCapabilitiesRebindsRoutingKeys(t *testing.T) {
+	registry := agentRegistry.NewInMemoryAgentRegistry()
+	bus := events.NewEventBus(nil, false)
+
+	var mu sync.Mutex
+	var handled []string
+	agent := buildQueryTestAgent(t, registry, bus, "reloadable", "old_capability", func(ctx context.Context, event *events.Event) (*events.Event, error) {
+		mu.Lock()
+		handled = append(handled, event.Subject)
+		mu.Unlock()
+		return nil, nil
+	})
+
+	version, err := agent.UpdateCapabilities(context.Background(), []agentRegistry.AgentCapability{
+		{Name: "new_capability", Intents: []string{"do new things"}, RoutingKeys: []string{"reloadable.v2"}},
+	})
+	if err != nil {
+		t.Fatalf("UpdateCapabilities failed: %v", err)
+	}
+	if version != 2 || agent.CapabilitiesVersion() != 2 {
+		t.Errorf("Expected capabilities version 2, got %d (agent reports %d)", version, agent.CapabilitiesVersion())
+	}
+
+	bus.Emit(events.EventTypeRequest, "test", "reloadable.request", map[string]interface{}{})
+	bus.Emit(events.EventTypeRequest, "test", "reloadable.v2", map[string]interface{}{})
+	mu.Lock()
+	if len(handled) != 1 || handled[0] != "reloadable.v2" {
+		t.Errorf("Expected only the new routing key to be served, handled %v", handled)
+	}
+	mu.Unlock()
+
+	if agents, _ := registry.FindAgentsByCapability(context.Background(), "new_capability"); len(agents) != 1 {
+		t.Errorf("Expected the registry to advertise the new capability, found %d agents", len(agents))
+	}
+	if agents, _ := registry.FindAgentsByCapability(context.Background(), "old_capability"); len(agents) != 0 {
+		t.Errorf("Expected the old capability to be withdrawn, found %d agents", len(agents))
+	}
+	history, err := registry.(agentRegistry.CapabilityUpdater).CapabilityHistory(context.Background(), "reloadable")
+	if err != nil || len(history) != 2 || history[0].Capabilities[0].Name != "old_capability" {
+		t.Errorf("Expected both capability revisions in the history, got %+v (%v)", history, err)
+	}
+}
+
+func TestUpdateCapabilitiesKeepsInFlightEvents(t *testing.T) {
+	registry := agentRegistry.NewInMemoryAgentRegistry()
+	bus := events.NewEventBus(nil, true)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	finished := make(chan struct{})
+	agent := buildQueryTestAgent(t, registry, bus, "busy", "busy_work", func(ctx context.Context, event *events.Event) (*events.Event, error) {
+		close(started)
+		<-release
+		close(finished)
+		return nil, nil
+	})
+
+	bus.Emit(events.EventTypeRequest, "test", "busy.request", map[string]interface{}{})
+	<-started
+	if _, err := agent.UpdateCapabilities(context.Background(), []agentRegistry.AgentCapability{{Name: "other_work", RoutingKeys: []string{"busy.other"}}}); err != nil {
+		t.Fatalf("UpdateCapabilities failed: %v", err)
+	}
+	close(release)
+
+	select {
+	case <-finished:
+	case <-time.After(2 * time.Second):
+		t.Fatal("In-flight event was dropped by the capability update")
+	}
+}
+
+func TestUpdateCapabilitiesRejectsInvalidCapabilities(t *testing.T) {
+	registry := agentRegistry.NewInMemoryAgentRegistry()
+	agent := buildQueryTestAgent(t, registry, events.NewEventBus(nil, false), "strict", "strict_work", func(ctx context.Context, event *events.Event) (*events.Event, error) {
+		return nil, nil
+	})
+
+	for name, capabilities := range map[string][]agentRegistry.AgentCapability{
+		"unnamed":       {{RoutingKeys: []string{"strict.x"}}},
+		"duplicate":     {{Name: "a"}, {Name: "a"}},
+		"empty routing": {{Name: "a", RoutingKeys: []string{""}}},
+	} {
+		if _, err := agent.UpdateCapabilities(context.Background(), capabilities); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if agent.CapabilitiesVersion() != 1 || agent.GetCapabilities()[0].Name != "strict_work" {
+		t.Errorf("Expected rejected updates to leave the capabilities unchanged, got %+v", agent.GetCapabilities())
+	}
+}
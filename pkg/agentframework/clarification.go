@@ -0,0 +1,172 @@
+package agentframework
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/ai"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// DefaultConfidenceThreshold is the confidence below which agents ask the user to clarify a
+// request instead of acting on their best guess
+const DefaultConfidenceThreshold = ai.DefaultMinConfidence
+
+// An agent that is not confident enough about a request answers with a clarification
+// response instead of an error. The orchestrator asks the user the question and sends the
+// answer back to the same intent, with the original message, as the conversation's next request.
+const (
+	ClarificationSubject = "clarification"
+	ClarificationStatus  = "clarification"
+	// ClarificationKey is the response payload field carrying the Clarification
+	ClarificationKey = "clarification"
+
+	// Requests continuing a clarification carry the message that needed it and the user's answer;
+	// their user_message combines the two
+	OriginalMessageKey     = "original_message"
+	ClarificationAnswerKey = "clarification_answer"
+)
+
+// Clarification is the question an agent needs answered before it can act on a request
+type Clarification struct {
+	Question   string  `json:"question"`
+	Confidence float64 `json:"confidence"`
+	Threshold  float64 `json:"threshold"`
+	Capability string  `json:"capability,omitempty"`
+	AgentID    string  `json:"agent_id,omitempty"`
+}
+
+var confidenceThresholds = struct {
+	mu           sync.RWMutex
+	fallback     float64
+	byCapability map[string]float64
+}{fallback: DefaultConfidenceThreshold}
+
+// SetConfidenceThresholds sets the confidence every capability needs before acting, with
+// overrides by capability name. A fallback of zero or less keeps DefaultConfidenceThreshold.
+func SetConfidenceThresholds(fallback float64, byCapability map[string]float64) {
+	if fallback <= 0 {
+		fallback = DefaultConfidenceThreshold
+	}
+	overrides := make(map[string]float64, len(byCapability))
+	for capability, threshold := range byCapability {
+		overrides[capability] = threshold
+	}
+
+	confidenceThresholds.mu.Lock()
+	defer confidenceThresholds.mu.Unlock()
+	confidenceThresholds.fallback = fallback
+	confidenceThresholds.byCapability = overrides
+}
+
+// ConfidenceThreshold returns the confidence a capability needs before acting
+func ConfidenceThreshold(capability string) float64 {
+	confidenceThresholds.mu.RLock()
+	defer confidenceThresholds.mu.RUnlock()
+	if threshold, ok := confidenceThresholds.byCapability[capability]; ok {
+		return threshold
+	}
+	return confidenceThresholds.fallback
+}
+
+type capabilityKey struct{}
+
+// CapabilityFromContext returns the capability handling the request, set for event handlers
+// from the routing key the request arrived on
+func CapabilityFromContext(ctx context.Context) string {
+	capability, _ := ctx.Value(capabilityKey{}).(string)
+	return capability
+}
+
+// NeedsClarification reports whether confidence is below the threshold of the capability
+// handling the request
+func NeedsClarification(ctx context.Context, confidence float64) bool {
+	return confidence < ConfidenceThreshold(CapabilityFromContext(ctx))
+}
+
+// ClarificationResponse is the response asking the user to clarify a request. Handlers return
+// it when NeedsClarification reports their extraction is too uncertain.
+func ClarificationResponse(ctx context.Context, request *events.Event, question string, confidence float64) *events.Event {
+	capability := CapabilityFromContext(ctx)
+	agentID := logging.AgentIDFromContext(ctx)
+	clarification := Clarification{
+		Question:   question,
+		Confidence: confidence,
+		Threshold:  ConfidenceThreshold(capability),
+		Capability: capability,
+		AgentID:    agentID,
+	}
+
+	payload := map[string]interface{}{
+		"status":           ClarificationStatus,
+		"message":          question,
+		"response_content": question,
+		"agent_id":         agentID,
+		ClarificationKey:   clarification.payload(),
+	}
+	if request != nil {
+		payload["correlation_id"] = request.Payload["correlation_id"]
+	}
+
+	return &events.Event{
+		ID:        fmt.Sprintf("clarification-%d", time.Now().UnixNano()),
+		Type:      events.EventTypeResponse,
+		Source:    agentID,
+		Subject:   ClarificationSubject,
+		Timestamp: time.Now().Unix(),
+		Payload:   payload,
+	}
+}
+
+// ClarificationFrom returns the clarification a response asks for, if it asks for one
+func ClarificationFrom(response *events.Event) (Clarification, bool) {
+	if response == nil || response.Payload["status"] != ClarificationStatus {
+		return Clarification{}, false
+	}
+	var clarification Clarification
+	switch value := response.Payload[ClarificationKey].(type) {
+	case Clarification:
+		clarification = value
+	case nil:
+	default:
+		data, err := json.Marshal(value)
+		if err != nil || json.Unmarshal(data, &clarification) != nil {
+			return Clarification{}, false
+		}
+	}
+	if clarification.Question == "" {
+		clarification.Question, _ = response.Payload["message"].(string)
+	}
+	return clarification, true
+}
+
+// payload encodes the clarification as a plain object so it survives JSON transports unchanged
+func (c Clarification) payload() map[string]interface{} {
+	return map[string]interface{}{
+		"question":   c.Question,
+		"confidence": c.Confidence,
+		"threshold":  c.Threshold,
+		"capability": c.Capability,
+		"agent_id":   c.AgentID,
+	}
+}
+
+// ClarificationSchema is the payload contract for clarification responses
+func ClarificationSchema() events.PayloadSchema {
+	return events.PayloadSchema{
+		Subject: ClarificationSubject,
+		Version: 1,
+		Fields: map[string]events.FieldSchema{
+			"status":           {Type: events.FieldString, Required: true, Description: "Always \"clarification\""},
+			"message":          {Type: events.FieldString, Required: true, Description: "The question for the user"},
+			"response_content": {Type: events.FieldString},
+			"correlation_id":   {Type: events.FieldString},
+			"agent_id":         {Type: events.FieldString},
+			ClarificationKey:   {Type: events.FieldObject, Required: true, Description: "Question, confidence, threshold and capability"},
+		},
+	}
+}
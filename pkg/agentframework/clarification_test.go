@@ -0,0 +1,78 @@
+package agentframework
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+	"github.com/krzachariassen/ZTDP/internal/events"
+)
+
+func TestConfidenceThresholds(t *testing.T) {
+	defer SetConfidenceThresholds(0, nil)
+
+	if got := ConfidenceThreshold("deployment"); got != DefaultConfidenceThreshold {
+		t.Errorf("expected the default threshold, got %v", got)
+	}
+	SetConfidenceThresholds(0.6, map[string]float64{"deployment": 0.9})
+	if got := ConfidenceThreshold("deployment"); got != 0.9 {
+		t.Errorf("expected the capability's threshold, got %v", got)
+	}
+	if got := ConfidenceThreshold("search"); got != 0.6 {
+		t.Errorf("expected the configured fallback, got %v", got)
+	}
+}
+
+func TestClarificationResponseUsesCapabilityThreshold(t *testing.T) {
+	SetConfidenceThresholds(0, map[string]float64{"deployment": 0.9})
+	defer SetConfidenceThresholds(0, nil)
+
+	registry := agentRegistry.NewInMemoryAgentRegistry()
+	bus := events.NewEventBus(nil, false)
+	agent := buildQueryTestAgent(t, registry, bus, "deployer", "deployment", func(ctx context.Context, event *events.Event) (*events.Event, error) {
+		if NeedsClarification(ctx, 0.8) {
+			return ClarificationResponse(ctx, event, "Which environment?", 0.8), nil
+		}
+		return nil, nil
+	})
+
+	response, err := agent.ProcessEvent(context.Background(), &events.Event{
+		Subject: "deployer.request",
+		Payload: map[string]interface{}{"correlation_id": "c-1", "user_message": "deploy checkout"},
+	})
+	if err != nil {
+		t.Fatalf("process failed: %v", err)
+	}
+	if response == nil || response.Subject != ClarificationSubject || response.Source != "deployer" || response.Payload["correlation_id"] != "c-1" {
+		t.Fatalf("expected a clarification response from deployer, got %+v", response)
+	}
+	if err := ClarificationSchema().Validate(response.Payload); err != nil {
+		t.Errorf("expected the response to match the clarification schema: %v", err)
+	}
+
+	// The clarification survives a JSON transport
+	data, _ := json.Marshal(response)
+	var decoded events.Event
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	clarification, ok := ClarificationFrom(&decoded)
+	if !ok {
+		t.Fatal("expected the decoded response to ask for clarification")
+	}
+	want := Clarification{Question: "Which environment?", Confidence: 0.8, Threshold: 0.9, Capability: "deployment", AgentID: "deployer"}
+	if clarification != want {
+		t.Errorf("expected %+v, got %+v", want, clarification)
+	}
+}
+
+func TestClarificationFromIgnoresOtherResponses(t *testing.T) {
+	agent := &BaseAgent{id: "deployer"}
+	if _, ok := ClarificationFrom(agent.CreateErrorResponse(nil, "boom")); ok {
+		t.Error("expected an error response not to ask for clarification")
+	}
+	if _, ok := ClarificationFrom(nil); ok {
+		t.Error("expected no clarification from a nil response")
+	}
+}
@@ -0,0 +1,216 @@
+package agentframework
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultDedupTTL is how long a processed event ID is remembered when no TTL is configured
+const DefaultDedupTTL = 24 * time.Hour
+
+// DedupStore remembers which events an agent has processed so redelivered events are skipped
+type DedupStore interface {
+	// Claim records key as processed, returning false if it was already recorded
+	Claim(ctx context.Context, key string) (bool, error)
+	// Release forgets key so a redelivery of a failed event is processed again
+	Release(ctx context.Context, key string) error
+}
+
+// MemoryDedupStore keeps processed event IDs in memory; duplicates are only caught within one process
+type MemoryDedupStore struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	seen      map[string]time.Time // key -> expiry
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryDedupStore creates an in-memory store remembering event IDs for ttl
+func NewMemoryDedupStore(ttl time.Duration) *MemoryDedupStore {
+	if ttl <= 0 {
+		ttl = DefaultDedupTTL
+	}
+	return &MemoryDedupStore{ttl: ttl, seen: make(map[string]time.Time), now: time.Now}
+}
+
+// Claim implements DedupStore
+func (s *MemoryDedupStore) Claim(ctx context.Context, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.sweep(now)
+	if expiry, ok := s.seen[key]; ok && now.Before(expiry) {
+		return false, nil
+	}
+	s.seen[key] = now.Add(s.ttl)
+	return true, nil
+}
+
+// Release implements DedupStore
+func (s *MemoryDedupStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.seen, key)
+	return nil
+}
+
+// Len returns the number of remembered event IDs
+func (s *MemoryDedupStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.seen)
+}
+
+// sweep drops expired IDs, at most once per TTL so claims stay cheap
+func (s *MemoryDedupStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < s.ttl {
+		return
+	}
+	for key, expiry := range s.seen {
+		if !now.Before(expiry) {
+			delete(s.seen, key)
+		}
+	}
+	s.lastSweep = now
+}
+
+// redisDedupPrefix namespaces processed event IDs in Redis
+const redisDedupPrefix = "ztdp:dedup:"
+
+// RedisDedupStore keeps processed event IDs in Redis so duplicates are caught across restarts and replicas
+type RedisDedupStore struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// NewRedisDedupStore creates a Redis-backed store remembering event IDs for ttl
+func NewRedisDedupStore(client *redis.Client, ttl time.Duration) *RedisDedupStore {
+	if ttl <= 0 {
+		ttl = DefaultDedupTTL
+	}
+	return &RedisDedupStore{client: client, ttl: ttl}
+}
+
+// Claim implements DedupStore
+func (s *RedisDedupStore) Claim(ctx context.Context, key string) (bool, error) {
+	return s.client.SetNX(ctx, redisDedupPrefix+key, time.Now().Unix(), s.ttl).Result()
+}
+
+// Release implements DedupStore
+func (s *RedisDedupStore) Release(ctx context.Context, key string) error {
+	return s.client.Del(ctx, redisDedupPrefix+key).Err()
+}
+
+var (
+	defaultDedupMu    sync.RWMutex
+	defaultDedupStore DedupStore
+)
+
+// SetDefaultDedupStore sets the store used by agents built without AgentDependencies.Dedup.
+// Pass nil to build agents without deduplication.
+func SetDefaultDedupStore(store DedupStore) {
+	defaultDedupMu.Lock()
+	defer defaultDedupMu.Unlock()
+	defaultDedupStore = store
+}
+
+func getDefaultDedupStore() DedupStore {
+	defaultDedupMu.RLock()
+	defer defaultDedupMu.RUnlock()
+	return defaultDedupStore
+}
+
+// claimEvent reports whether the agent should process the event. Events without an ID and
+// agents without a store are always processed; so are events when the store fails, since
+// dropping a request is worse than handling it twice.
+func (a *BaseAgent) claimEvent(ctx context.Context, eventID string) (string, bool) {
+	if a.dedup == nil || eventID == "" {
+		return "", true
+	}
+	key := a.id + ":" + eventID
+	first, err := a.dedup.Claim(ctx, key)
+	if err != nil {
+		a.logger.Warn("⚠️ Dedup store unavailable, processing event %s without deduplication: %v", eventID, err)
+		recordDedup(a.id, dedupStoreError)
+		return "", true
+	}
+	if !first {
+		recordDedup(a.id, dedupDuplicate)
+		return "", false
+	}
+	recordDedup(a.id, dedupProcessed)
+	return key, true
+}
+
+// releaseEvent lets a redelivery of an event that failed be processed again
+func (a *BaseAgent) releaseEvent(ctx context.Context, key string) {
+	if key == "" {
+		return
+	}
+	if err := a.dedup.Release(ctx, key); err != nil {
+		a.logger.Warn("⚠️ Failed to release dedup key %s: %v", key, err)
+	}
+}
+
+// ==================================================================================
+// DEDUP METRICS
+// ==================================================================================
+
+// DedupStats counts how an agent's deliveries were deduplicated
+type DedupStats struct {
+	Processed   int64 `json:"processed"`
+	Duplicates  int64 `json:"duplicates_skipped"`
+	StoreErrors int64 `json:"store_errors"`
+}
+
+type dedupOutcome int
+
+const (
+	dedupProcessed dedupOutcome = iota
+	dedupDuplicate
+	dedupStoreError
+)
+
+var (
+	dedupMetricsMu sync.Mutex
+	dedupMetrics   = make(map[string]*DedupStats)
+)
+
+func recordDedup(agentID string, outcome dedupOutcome) {
+	dedupMetricsMu.Lock()
+	defer dedupMetricsMu.Unlock()
+	stats, ok := dedupMetrics[agentID]
+	if !ok {
+		stats = &DedupStats{}
+		dedupMetrics[agentID] = stats
+	}
+	switch outcome {
+	case dedupProcessed:
+		stats.Processed++
+	case dedupDuplicate:
+		stats.Duplicates++
+	case dedupStoreError:
+		stats.StoreErrors++
+	}
+}
+
+// GetDedupMetrics returns a snapshot of deduplication counts per agent
+func GetDedupMetrics() map[string]DedupStats {
+	dedupMetricsMu.Lock()
+	defer dedupMetricsMu.Unlock()
+	snapshot := make(map[string]DedupStats, len(dedupMetrics))
+	for agentID, stats := range dedupMetrics {
+		snapshot[agentID] = *stats
+	}
+	return snapshot
+}
+
+// ResetDedupMetrics clears the deduplication counters
+func ResetDedupMetrics() {
+	dedupMetricsMu.Lock()
+	defer dedupMetricsMu.Unlock()
+	dedupMetrics = make(map[string]*DedupStats)
+}
@@ -0,0 +1,68 @@
+package agentframework
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+	"github.com/krzachariassen/ZTDP/internal/events"
+)
+
+func TestMemoryDedupStoreExpires(t *testing.T) {
+	store := NewMemoryDedupStore(time.Minute)
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if first, _ := store.Claim(ctx, "agent:evt-1"); !first {
+		t.Fatal("expected the first claim to succeed")
+	}
+	if first, _ := store.Claim(ctx, "agent:evt-1"); first {
+		t.Fatal("expected a second claim to be reported as a duplicate")
+	}
+
+	store.Release(ctx, "agent:evt-1")
+	if first, _ := store.Claim(ctx, "agent:evt-1"); !first {
+		t.Fatal("expected a released key to be claimable again")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if first, _ := store.Claim(ctx, "agent:evt-1"); !first {
+		t.Fatal("expected the key to expire after the TTL")
+	}
+	if store.Len() != 1 {
+		t.Errorf("expected expired keys to be swept, got %d", store.Len())
+	}
+}
+
+func TestAgentSkipsRedeliveredEvents(t *testing.T) {
+	ResetDedupMetrics()
+	registry := agentRegistry.NewInMemoryAgentRegistry()
+	eventBus := events.NewEventBus(nil, false)
+
+	processed := 0
+	_, err := NewAgent("dedup-agent").
+		WithCapabilities([]agentRegistry.AgentCapability{{Name: "dedup_test", RoutingKeys: []string{"dedup.test"}}}).
+		WithEventHandler(func(ctx context.Context, event *events.Event) (*events.Event, error) {
+			processed++
+			return nil, nil
+		}).
+		Build(AgentDependencies{Registry: registry, EventBus: eventBus, Dedup: NewMemoryDedupStore(time.Hour)})
+	if err != nil {
+		t.Fatalf("Expected no error creating agent, got: %v", err)
+	}
+
+	event := events.Event{Type: events.EventTypeRequest, Subject: "dedup.test", ID: "evt-1", Payload: map[string]interface{}{}}
+	eventBus.EmitEvent(event)
+	eventBus.EmitEvent(event) // redelivery
+	eventBus.EmitEvent(events.Event{Type: events.EventTypeRequest, Subject: "dedup.test", ID: "evt-2", Payload: map[string]interface{}{}})
+
+	if processed != 2 {
+		t.Errorf("Expected 2 distinct events processed, got %d", processed)
+	}
+	stats := GetDedupMetrics()["dedup-agent"]
+	if stats.Processed != 2 || stats.Duplicates != 1 {
+		t.Errorf("Unexpected dedup metrics: %+v", stats)
+	}
+}
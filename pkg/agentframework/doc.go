@@ -0,0 +1,10 @@
+// Package agentframework builds ZTDP agents: an agent declares its capabilities, handles the
+// events routed to them and is registered and subscribed by Build.
+//
+// It is ZTDP's supported surface for in-process agents written outside the platform's own
+// packages. Everything such an agent needs is exported here: the builder (NewAgent,
+// AgentBuilder, AgentDependencies), the capability and event types, the clarification
+// protocol (NeedsClarification, ClarificationResponse, ClarificationFrom), dedup stores and
+// retries (WithRetry, Permanent). Exported names are kept backward compatible; the internal
+// packages behind the type aliases are not part of the API.
+package agentframework
@@ -0,0 +1,66 @@
+package agentframework
+
+import (
+	"encoding/json"
+
+	"github.com/krzachariassen/ZTDP/internal/events"
+)
+
+// ContextEnvelopeKey is the request payload field carrying what the orchestrator already
+// worked out about a request
+const ContextEnvelopeKey = "context_envelope"
+
+// ContextEnvelope is what the orchestrator knows about a request before an agent sees it.
+// Agents can use it instead of extracting the same facts from the user's message with
+// another AI call; anything missing from it still has to come from the message.
+type ContextEnvelope struct {
+	Intent string `json:"intent,omitempty"`
+	// Entities are platform entities named in the message, by node kind ("application",
+	// "environment", ...). Kinds the message names more than once are left out.
+	Entities map[string]string `json:"entities,omitempty"`
+	// ConversationEntities are the entities referenced earlier in the conversation
+	ConversationEntities []string        `json:"conversation_entities,omitempty"`
+	Summary              string          `json:"summary,omitempty"` // recent turns of the conversation
+	PriorDecisions       []PriorDecision `json:"prior_decisions,omitempty"`
+}
+
+// PriorDecision is how an earlier request in the conversation was handled
+type PriorDecision struct {
+	Intent  string `json:"intent"`
+	Agent   string `json:"agent,omitempty"`
+	Status  string `json:"status,omitempty"`
+	Outcome string `json:"outcome,omitempty"`
+}
+
+// Entity returns the entity of a kind named in the message, or "" when there is none
+func (e ContextEnvelope) Entity(kind string) string {
+	return e.Entities[kind]
+}
+
+// Payload encodes the envelope as a request payload value. Payloads cross transports as
+// JSON, so the envelope travels as a plain object rather than a Go struct.
+func (e ContextEnvelope) Payload() map[string]interface{} {
+	data, _ := json.Marshal(e)
+	var payload map[string]interface{}
+	json.Unmarshal(data, &payload)
+	return payload
+}
+
+// ContextEnvelopeFrom returns the envelope the orchestrator attached to a request, if any
+func ContextEnvelopeFrom(event *events.Event) (ContextEnvelope, bool) {
+	raw, ok := event.Payload[ContextEnvelopeKey]
+	if !ok || raw == nil {
+		return ContextEnvelope{}, false
+	}
+	var envelope ContextEnvelope
+	switch value := raw.(type) {
+	case ContextEnvelope:
+		envelope = value
+	default:
+		data, err := json.Marshal(value)
+		if err != nil || json.Unmarshal(data, &envelope) != nil {
+			return ContextEnvelope{}, false
+		}
+	}
+	return envelope, true
+}
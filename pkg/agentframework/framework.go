@@ -1,15 +1,18 @@
-package agentFramework
+package agentframework
 
 import (
 	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
 	"github.com/krzachariassen/ZTDP/internal/ai"
 	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/features"
+	"github.com/krzachariassen/ZTDP/internal/guardrails"
 	"github.com/krzachariassen/ZTDP/internal/logging"
 )
 
@@ -17,20 +20,44 @@ import (
 type AgentDependencies struct {
 	Registry agentRegistry.AgentRegistry
 	EventBus *events.EventBus
+	Flags    *features.Service // optional; enables per-capability kill switches and FeatureEnabled
+	Dedup    DedupStore        // optional; defaults to the store set with SetDefaultDedupStore
 }
 
 // BaseAgent represents the framework agent that implements common patterns
 type BaseAgent struct {
 	id           string
 	agentType    string
-	capabilities []agentRegistry.AgentCapability
 	eventHandler func(ctx context.Context, event *events.Event) (*events.Event, error)
+	bidder       Bidder // nil bids DefaultBidConfidence
+	retry        RetryPolicy
+
+	// Capabilities can be replaced at runtime, see UpdateCapabilities
+	updateMu            sync.Mutex
+	capMu               sync.RWMutex
+	capabilities        []agentRegistry.AgentCapability
+	capabilitiesVersion int
+	routes              map[string]bool // routing keys the agent currently serves
+	subscribed          map[string]bool // routing keys with a bus subscription, served or not
 
 	// Dependencies
 	registry  agentRegistry.AgentRegistry
 	eventBus  *events.EventBus
+	flags     *features.Service
+	dedup     DedupStore
 	logger    *logging.Logger
 	startTime time.Time
+
+	// Shutdown tracking - in-flight handlers are drained on Stop
+	inflight sync.WaitGroup
+	mu       sync.RWMutex
+	stopping bool
+
+	// Direct queries to other agents waiting for their replies
+	queries pendingQueries
+
+	// Events being handled, so cancel and pause broadcasts can reach them
+	running runningEvents
 }
 
 // AgentBuilder provides a fluent interface for building agents
@@ -39,6 +66,8 @@ type AgentBuilder struct {
 	agentType    string
 	capabilities []agentRegistry.AgentCapability
 	eventHandler func(ctx context.Context, event *events.Event) (*events.Event, error)
+	bidder       Bidder
+	retry        RetryPolicy
 }
 
 // NewAgent creates a new agent builder
@@ -70,14 +99,22 @@ func (b *AgentBuilder) WithType(agentType string) *AgentBuilder {
 // Build creates the agent with the specified dependencies
 func (b *AgentBuilder) Build(deps AgentDependencies) (agentRegistry.AgentInterface, error) {
 	agent := &BaseAgent{
-		id:           b.id,
-		agentType:    b.agentType,
-		capabilities: b.capabilities,
-		eventHandler: b.eventHandler,
-		registry:     deps.Registry,
-		eventBus:     deps.EventBus,
-		logger:       logging.GetLogger().ForComponent(b.id),
-		startTime:    time.Now(),
+		id:                  b.id,
+		agentType:           b.agentType,
+		capabilities:        b.capabilities,
+		capabilitiesVersion: 1,
+		eventHandler:        b.eventHandler,
+		bidder:              b.bidder,
+		retry:               b.retry,
+		registry:            deps.Registry,
+		eventBus:            deps.EventBus,
+		flags:               deps.Flags,
+		dedup:               deps.Dedup,
+		logger:              logging.GetLogger().ForComponent(b.id).WithAgentID(b.id),
+		startTime:           time.Now(),
+	}
+	if agent.dedup == nil {
+		agent.dedup = getDefaultDedupStore()
 	}
 
 	// Auto-register the agent
@@ -86,11 +123,16 @@ func (b *AgentBuilder) Build(deps AgentDependencies) (agentRegistry.AgentInterfa
 		return nil, err
 	}
 
-	// Auto-subscribe to routing keys based on capabilities
-	if err := agent.subscribeToCapabilities(); err != nil {
+	// Requests to the agent's routing keys follow the common envelope
+	if err := agent.registerRequestSchemas(agent.capabilities); err != nil {
 		return nil, err
 	}
 
+	// Auto-subscribe to routing keys based on capabilities
+	agent.capMu.Lock()
+	agent.bindRoutingKeys(agent.capabilities)
+	agent.capMu.Unlock()
+
 	return agent, nil
 }
 
@@ -101,8 +143,10 @@ func (a *BaseAgent) GetID() string {
 	return a.id
 }
 
-// GetCapabilities returns the agent's capabilities
+// GetCapabilities returns the agent's current capabilities
 func (a *BaseAgent) GetCapabilities() []agentRegistry.AgentCapability {
+	a.capMu.RLock()
+	defer a.capMu.RUnlock()
 	return a.capabilities
 }
 
@@ -116,8 +160,9 @@ func (a *BaseAgent) GetStatus() agentRegistry.AgentStatus {
 		LoadFactor:   0.1,
 		Version:      "1.0.0",
 		Metadata: map[string]interface{}{
-			"uptime":         time.Since(a.startTime).String(),
-			"framework_type": "base_agent",
+			"uptime":               time.Since(a.startTime).String(),
+			"framework_type":       "base_agent",
+			"capabilities_version": a.CapabilitiesVersion(),
 		},
 	}
 }
@@ -128,10 +173,38 @@ func (a *BaseAgent) Start(ctx context.Context) error {
 	return nil
 }
 
-// Stop shuts down the agent
+// Stop shuts down the agent, refusing new events and waiting for in-flight handlers to finish
 func (a *BaseAgent) Stop(ctx context.Context) error {
 	a.logger.Info("🛑 Stopping agent: %s", a.id)
-	return nil
+
+	a.mu.Lock()
+	a.stopping = true
+	a.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		a.inflight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		a.logger.Info("✅ Agent %s drained", a.id)
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("agent %s: timed out waiting for in-flight events: %w", a.id, ctx.Err())
+	}
+}
+
+// beginEvent registers an in-flight event, returning false once the agent is stopping
+func (a *BaseAgent) beginEvent() bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.stopping {
+		return false
+	}
+	a.inflight.Add(1)
+	return true
 }
 
 // Health returns the agent's health status
@@ -145,22 +218,98 @@ func (a *BaseAgent) Health() agentRegistry.HealthStatus {
 
 // ProcessEvent handles incoming events using the configured handler
 func (a *BaseAgent) ProcessEvent(ctx context.Context, event *events.Event) (*events.Event, error) {
-	a.logger.Info("🎯 Processing event: %s", event.Subject)
+	ctx = a.eventContext(ctx, event)
+	logger := a.logger.ForContext(ctx)
+	logger.Info("🎯 Processing event: %s", event.Subject)
 
 	if a.eventHandler == nil {
 		return a.CreateErrorResponse(event, "No event handler configured"), nil
 	}
 
-	response, err := a.eventHandler(ctx, event)
+	if capability, disabled := a.disabledCapability(ctx, event.Subject); disabled {
+		logger.Warn("🚩 Capability %s is disabled by feature flag, rejecting event", capability)
+		return a.CreateErrorResponse(event, fmt.Sprintf("capability %s is currently disabled", capability)), nil
+	}
+
+	response, err := a.handle(ctx, event)
 	if err != nil {
-		a.logger.Error("❌ Event processing failed: %v", err)
+		logger.Error("❌ Event processing failed: %v", err)
 		return a.CreateErrorResponse(event, err.Error()), nil
 	}
 
-	a.logger.Info("✅ Event processed successfully")
+	logger.Info("✅ Event processed successfully")
 	return response, nil
 }
 
+// eventContext carries the event's correlation fields so handlers can log with logging.FromContext
+func (a *BaseAgent) eventContext(ctx context.Context, event *events.Event) context.Context {
+	if logging.CorrelationIDFromContext(ctx) == "" {
+		if correlationID, ok := event.Payload["correlation_id"].(string); ok && correlationID != "" {
+			ctx = logging.WithCorrelationID(ctx, correlationID)
+		}
+	}
+	if role, ok := event.Payload["caller_role"].(string); ok && role != "" && guardrails.RoleFromContext(ctx) == "" {
+		ctx = guardrails.WithRole(ctx, role)
+	}
+	// The conversation the request belongs to, so handlers can bind state such as proposed plans to it
+	if conversationID, ok := event.Payload["conversation_id"].(string); ok && conversationID != "" {
+		ctx = features.WithConversationID(ctx, conversationID)
+	}
+	if tenant, ok := event.Payload["tenant"].(string); ok && tenant != "" {
+		ctx = features.WithTenant(ctx, tenant)
+	}
+	if userID, ok := event.Payload["user_id"].(string); ok && userID != "" {
+		ctx = logging.WithUserID(ctx, userID)
+	}
+	// Handlers may query other agents through this agent; the chain guards against cycles
+	ctx = context.WithValue(withQueryChain(ctx, event), queryingAgentKey{}, a)
+	ctx = logging.WithAgentID(ctx, a.id)
+	// The capability handling the request decides how confident the handler must be
+	capability := a.capabilityFor(event.Subject)
+	if capability != "" {
+		ctx = context.WithValue(ctx, capabilityKey{}, capability)
+	}
+	ctx = ai.WithMinConfidence(ctx, ConfidenceThreshold(capability))
+	return logging.WithEventSubject(ctx, event.Subject)
+}
+
+// CapabilityFlagName returns the feature flag that switches a capability on or off
+func CapabilityFlagName(capability string) string {
+	return "capability." + capability
+}
+
+// capabilityFor returns the name of the capability serving routingKey, or "" when none does
+func (a *BaseAgent) capabilityFor(routingKey string) string {
+	for _, capability := range a.GetCapabilities() {
+		for _, key := range capability.RoutingKeys {
+			if key == routingKey {
+				return capability.Name
+			}
+		}
+	}
+	return ""
+}
+
+// disabledCapability reports whether the capability serving routingKey is switched off by its feature flag
+func (a *BaseAgent) disabledCapability(ctx context.Context, routingKey string) (string, bool) {
+	if a.flags == nil {
+		return "", false
+	}
+	if capability := a.capabilityFor(routingKey); capability != "" {
+		return capability, !a.flags.IsEnabled(ctx, CapabilityFlagName(capability), true)
+	}
+	return "", false
+}
+
+// FeatureEnabled evaluates a feature flag for the current conversation and agent,
+// returning defaultValue when the flag is undefined or no flag service is configured
+func (a *BaseAgent) FeatureEnabled(ctx context.Context, name string, defaultValue bool) bool {
+	if a.flags == nil {
+		return defaultValue
+	}
+	return a.flags.IsEnabled(logging.WithAgentID(ctx, a.id), name, defaultValue)
+}
+
 // ==================================================================================
 // FRAMEWORK HELPER METHODS FOR COMMON AGENT PATTERNS
 // ==================================================================================
@@ -258,7 +407,7 @@ func (a *BaseAgent) ExtractStructuredDataWithAI(
 
 	userPrompt := fmt.Sprintf("Extract structured data from this message: %s", userMessage)
 
-	response, err := aiProvider.CallAI(ctx, systemPrompt, userPrompt)
+	response, err := aiProvider.CallAI(ai.WithTask(ctx, ai.TaskExtraction), systemPrompt, userPrompt)
 	if err != nil {
 		return fmt.Errorf("AI call failed: %w", err)
 	}
@@ -339,27 +488,53 @@ func (a *BaseAgent) GetLogger() *logging.Logger {
 	return a.logger
 }
 
-// subscribeToCapabilities automatically subscribes to routing keys based on capabilities
-func (a *BaseAgent) subscribeToCapabilities() error {
-	if a.eventBus == nil {
-		return nil // No event bus available
-	}
-
-	for _, capability := range a.capabilities {
-		for _, routingKey := range capability.RoutingKeys {
-			a.eventBus.SubscribeToRoutingKey(routingKey, func(event events.Event) error {
-				response, err := a.ProcessEvent(context.Background(), &event)
-				if err != nil {
-					a.logger.Error("⚠️ Failed to process event: %v", err)
-				} else if response != nil {
-					// Emit the response back to the event bus
-					a.eventBus.EmitEvent(*response)
-				}
-				return err
-			})
-			a.logger.Info("✅ Subscribed to routing key: %s", routingKey)
+// subscribe registers the bus handler for a routing key. Handlers are never removed from the
+// bus; once the agent stops serving the key, events for it are left to other subscribers.
+func (a *BaseAgent) subscribe(routingKey string) {
+	a.eventBus.SubscribeToRoutingKey(routingKey, func(event events.Event) error {
+		if !a.serves(routingKey) || !a.addressedTo(event) {
+			return nil
 		}
-	}
+		// Bids are answered right away and are not tasks: no ack, dedup or drain tracking
+		if IsBidRequest(event) {
+			a.answerBid(event)
+			return nil
+		}
+		if !a.beginEvent() {
+			a.logger.Warn("⚠️ Agent %s is stopping, dropping event: %s", a.id, event.Subject)
+			a.reject(event, "agent is stopping")
+			return nil
+		}
+		defer a.inflight.Done()
 
-	return nil
+		// Payloads on sensitive subjects arrive encrypted from the transport
+		if err := a.eventBus.Decrypt(&event); err != nil {
+			a.logger.Error("🔒 Dropping event %s: %v", event.ID, err)
+			a.reject(event, "cannot decrypt payload")
+			return err
+		}
+
+		// Transports may redeliver an event; each agent handles a given event ID once
+		dedupKey, first := a.claimEvent(context.Background(), event.ID)
+		if !first {
+			a.logger.Info("♻️ Skipping duplicate delivery of event %s: %s", event.ID, event.Subject)
+			return nil
+		}
+
+		a.acknowledge(event)
+		ctx, untrack := a.trackEvent(context.Background(), &event)
+		defer untrack()
+
+		response, err := a.ProcessEvent(ctx, &event)
+		if err != nil {
+			a.releaseEvent(context.Background(), dedupKey)
+			a.logger.Error("⚠️ Failed to process event: %v", err)
+			a.reject(event, err.Error())
+		} else if response != nil {
+			// Emit the response back to the event bus
+			a.eventBus.EmitEvent(*response)
+		}
+		return err
+	})
+	a.logger.Info("✅ Subscribed to routing key: %s", routingKey)
 }
@@ -1,4 +1,4 @@
-package agentFramework
+package agentframework
 
 import (
 	"context"
@@ -7,6 +7,9 @@ import (
 
 	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
 	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/features"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/guardrails"
 )
 
 // TestAgentCreationWithAutoRegistration tests that agents can be created and auto-register
@@ -306,3 +309,173 @@ func TestLoggingConsistency(t *testing.T) {
 	// Verify logger component name matches agent ID
 	// This ensures consistent logging across all agents
 }
+
+// TestAgentStopDrainsInFlightEvents tests that Stop waits for running handlers and refuses new events
+func TestAgentStopDrainsInFlightEvents(t *testing.T) {
+	// Arrange
+	registry := agentRegistry.NewInMemoryAgentRegistry()
+	eventBus := events.NewEventBus(nil, true)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handled := 0
+
+	_, err := NewAgent("draining-agent").
+		WithCapabilities([]agentRegistry.AgentCapability{{Name: "drain", RoutingKeys: []string{"drain.test"}}}).
+		WithEventHandler(func(ctx context.Context, event *events.Event) (*events.Event, error) {
+			handled++
+			close(started)
+			<-release
+			return nil, nil
+		}).
+		Build(AgentDependencies{Registry: registry, EventBus: eventBus})
+	if err != nil {
+		t.Fatalf("Expected no error creating agent, got: %v", err)
+	}
+	agent, _ := registry.FindAgentByID(context.Background(), "draining-agent")
+
+	// Act - start a slow event, then stop with a short timeout
+	eventBus.Emit(events.EventTypeRequest, "test", "drain.test", nil)
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := agent.Stop(ctx); err == nil {
+		t.Error("Expected Stop to time out while an event is in flight")
+	}
+
+	// Assert - once released, Stop succeeds and new events are dropped
+	close(release)
+	if err := agent.Stop(context.Background()); err != nil {
+		t.Errorf("Expected Stop to succeed after draining, got: %v", err)
+	}
+
+	eventBus.EmitEvent(events.Event{Type: events.EventTypeRequest, Subject: "drain.test"})
+	if err := eventBus.Shutdown(context.Background()); err != nil {
+		t.Errorf("Expected event bus shutdown to succeed, got: %v", err)
+	}
+	if handled != 1 {
+		t.Errorf("Expected exactly 1 handled event, got: %d", handled)
+	}
+}
+
+// TestAgentCapabilityFeatureFlag tests that a disabled capability flag rejects events without calling the handler
+func TestAgentCapabilityFeatureFlag(t *testing.T) {
+	// Arrange
+	registry := agentRegistry.NewInMemoryAgentRegistry()
+	flags := features.NewService(graph.NewGlobalGraph(graph.NewMemoryGraph()))
+	handled := false
+
+	agent, err := NewAgent("flagged-agent").
+		WithCapabilities([]agentRegistry.AgentCapability{{Name: "experimental", RoutingKeys: []string{"experimental.run"}}}).
+		WithEventHandler(func(ctx context.Context, event *events.Event) (*events.Event, error) {
+			handled = true
+			return nil, nil
+		}).
+		Build(AgentDependencies{Registry: registry, Flags: flags})
+	if err != nil {
+		t.Fatalf("Expected no error creating agent, got: %v", err)
+	}
+	baseAgent := agent.(*BaseAgent)
+	event := &events.Event{Subject: "experimental.run", Payload: map[string]interface{}{}}
+
+	// Act - switch the capability off
+	if err := flags.SetFlag(&features.Flag{Name: CapabilityFlagName("experimental"), Enabled: false}); err != nil {
+		t.Fatalf("Expected no error setting flag, got: %v", err)
+	}
+	response, _ := baseAgent.ProcessEvent(context.Background(), event)
+
+	// Assert
+	if handled {
+		t.Error("Expected handler not to run for a disabled capability")
+	}
+	if response == nil || response.Payload["status"] != "error" {
+		t.Errorf("Expected error response, got: %+v", response)
+	}
+
+	// Re-enabling the capability takes effect without rebuilding the agent
+	flags.SetFlag(&features.Flag{Name: CapabilityFlagName("experimental"), Enabled: true, RolloutPercent: 100})
+	baseAgent.ProcessEvent(context.Background(), event)
+	if !handled {
+		t.Error("Expected handler to run once the capability is enabled")
+	}
+}
+
+// TestAgentReceivesCallerRole tests that the caller role set by the orchestrator reaches handlers for guardrail checks
+func TestAgentReceivesCallerRole(t *testing.T) {
+	// Arrange
+	registry := agentRegistry.NewInMemoryAgentRegistry()
+	var role string
+
+	agent, err := NewAgent("guarded-agent").
+		WithEventHandler(func(ctx context.Context, event *events.Event) (*events.Event, error) {
+			role = guardrails.RoleFromContext(ctx)
+			return nil, nil
+		}).
+		Build(AgentDependencies{Registry: registry})
+	if err != nil {
+		t.Fatalf("Expected no error creating agent, got: %v", err)
+	}
+
+	// Act
+	agent.(*BaseAgent).ProcessEvent(context.Background(), &events.Event{
+		Subject: "guarded.run",
+		Payload: map[string]interface{}{"caller_role": "developer"},
+	})
+
+	// Assert
+	if role != "developer" {
+		t.Errorf("Expected caller role developer in handler context, got: %q", role)
+	}
+}
+
+// TestAgentReceivesConversation tests that handlers can bind state to the conversation the request came from
+func TestAgentReceivesConversation(t *testing.T) {
+	// Arrange
+	registry := agentRegistry.NewInMemoryAgentRegistry()
+	var evalCtx features.EvaluationContext
+
+	agent, err := NewAgent("conversational-agent").
+		WithEventHandler(func(ctx context.Context, event *events.Event) (*events.Event, error) {
+			evalCtx = features.EvaluationContextFrom(ctx)
+			return nil, nil
+		}).
+		Build(AgentDependencies{Registry: registry})
+	if err != nil {
+		t.Fatalf("Expected no error creating agent, got: %v", err)
+	}
+
+	// Act
+	agent.(*BaseAgent).ProcessEvent(context.Background(), &events.Event{
+		Subject: "plan.edit",
+		Payload: map[string]interface{}{"conversation_id": "conv-1", "tenant": "payments"},
+	})
+
+	// Assert
+	if evalCtx.ConversationID != "conv-1" || evalCtx.Tenant != "payments" {
+		t.Errorf("Expected conversation conv-1 and tenant payments in handler context, got: %+v", evalCtx)
+	}
+}
+
+// TestAgentRegistersRequestSchemas tests that agents give their routing keys the request envelope schema
+func TestAgentRegistersRequestSchemas(t *testing.T) {
+	registry := agentRegistry.NewInMemoryAgentRegistry()
+	eventBus := events.NewEventBus(nil, false)
+	eventBus.SetSchemaRegistry(events.NewSchemaRegistry())
+
+	_, err := NewAgent("schema-agent").
+		WithCapabilities([]agentRegistry.AgentCapability{{Name: "schema_test", RoutingKeys: []string{"schema.test"}}}).
+		WithEventHandler(func(ctx context.Context, event *events.Event) (*events.Event, error) { return nil, nil }).
+		Build(AgentDependencies{Registry: registry, EventBus: eventBus})
+	if err != nil {
+		t.Fatalf("Expected no error creating agent, got: %v", err)
+	}
+
+	if _, ok := eventBus.Schemas().Latest("schema.test"); !ok {
+		t.Fatal("Expected the routing key to have a request schema")
+	}
+	err = eventBus.Emit(events.EventTypeRequest, "test", "schema.test", map[string]interface{}{"intent": "test", "context": "not an object"})
+	if err == nil {
+		t.Error("Expected a request with a malformed context to be rejected")
+	}
+}
@@ -0,0 +1,148 @@
+package agentframework
+
+import (
+	"context"
+	"sync"
+
+	"github.com/krzachariassen/ZTDP/internal/events"
+)
+
+// Broadcast subjects that interrupt the work for a correlation ID in every agent handling it
+const (
+	InterruptCancel = "orchestration.cancel"
+	InterruptPause  = "orchestration.pause"
+	InterruptResume = "orchestration.resume"
+)
+
+// runningEvent is an event an agent is handling that can be cancelled or paused
+type runningEvent struct {
+	cancel context.CancelFunc
+	mu     sync.Mutex
+	paused chan struct{} // non-nil while paused; closed on resume
+}
+
+// runningEvents indexes the events an agent is handling by correlation ID
+type runningEvents struct {
+	once sync.Once
+	mu   sync.Mutex
+	byID map[string][]*runningEvent
+}
+
+type runningEventKey struct{}
+
+// trackEvent makes the event interruptible under its correlation ID and the correlation ID
+// of the request that caused it, so interrupting a chat reaches agents it queried
+func (a *BaseAgent) trackEvent(ctx context.Context, event *events.Event) (context.Context, func()) {
+	var ids []string
+	for _, key := range []string{"correlation_id", "parent_correlation_id"} {
+		if id, ok := event.Payload[key].(string); ok && id != "" {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 || a.eventBus == nil {
+		return ctx, func() {}
+	}
+	a.running.once.Do(func() {
+		a.running.byID = make(map[string][]*runningEvent)
+		a.eventBus.Subscribe(events.EventTypeBroadcast, a.handleInterrupt)
+	})
+
+	ctx, cancel := context.WithCancel(ctx)
+	running := &runningEvent{cancel: cancel}
+	a.running.mu.Lock()
+	for _, id := range ids {
+		a.running.byID[id] = append(a.running.byID[id], running)
+	}
+	a.running.mu.Unlock()
+
+	return context.WithValue(ctx, runningEventKey{}, running), func() {
+		a.running.mu.Lock()
+		for _, id := range ids {
+			remaining := a.running.byID[id][:0]
+			for _, r := range a.running.byID[id] {
+				if r != running {
+					remaining = append(remaining, r)
+				}
+			}
+			if len(remaining) == 0 {
+				delete(a.running.byID, id)
+			} else {
+				a.running.byID[id] = remaining
+			}
+		}
+		a.running.mu.Unlock()
+		running.resume()
+		cancel()
+	}
+}
+
+// handleInterrupt applies cancel, pause and resume broadcasts to the matching running events
+func (a *BaseAgent) handleInterrupt(event events.Event) error {
+	if event.Subject != InterruptCancel && event.Subject != InterruptPause && event.Subject != InterruptResume {
+		return nil
+	}
+	correlationID, _ := event.Payload["correlation_id"].(string)
+	if correlationID == "" {
+		return nil
+	}
+	a.running.mu.Lock()
+	matching := append([]*runningEvent(nil), a.running.byID[correlationID]...)
+	a.running.mu.Unlock()
+
+	for _, running := range matching {
+		switch event.Subject {
+		case InterruptCancel:
+			a.logger.Info("🛑 Cancelling work for %s", correlationID)
+			running.cancel()
+		case InterruptPause:
+			a.logger.Info("⏸️ Pausing work for %s", correlationID)
+			running.pause()
+		case InterruptResume:
+			a.logger.Info("▶️ Resuming work for %s", correlationID)
+			running.resume()
+		}
+	}
+	return nil
+}
+
+func (r *runningEvent) pause() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.paused == nil {
+		r.paused = make(chan struct{})
+	}
+}
+
+func (r *runningEvent) resume() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.paused != nil {
+		close(r.paused)
+		r.paused = nil
+	}
+}
+
+// Checkpoint marks a point where a handler may be interrupted: it returns the context's error
+// once the work was cancelled, and blocks while the work is paused. Handlers call it between
+// steps that are safe to stop at.
+func Checkpoint(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	running, ok := ctx.Value(runningEventKey{}).(*runningEvent)
+	if !ok {
+		return nil
+	}
+	running.mu.Lock()
+	paused := running.paused
+	running.mu.Unlock()
+	if paused == nil {
+		return nil
+	}
+	select {
+	case <-paused:
+		return ctx.Err()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
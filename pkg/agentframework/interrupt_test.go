@@ -0,0 +1,49 @@
+package agentframework
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+	"github.com/krzachariassen/ZTDP/internal/events"
+)
+
+func TestCheckpointWaitsWhilePaused(t *testing.T) {
+	registry := agentRegistry.NewInMemoryAgentRegistry()
+	eventBus := events.NewEventBus(nil, true)
+
+	reachedCheckpoint := make(chan struct{})
+	passed := make(chan error, 1)
+	buildQueryTestAgent(t, registry, eventBus, "pausable", "pausing", func(ctx context.Context, event *events.Event) (*events.Event, error) {
+		close(reachedCheckpoint)
+		// Give the test time to pause before the checkpoint is evaluated
+		time.Sleep(20 * time.Millisecond)
+		passed <- Checkpoint(ctx)
+		return nil, nil
+	})
+
+	eventBus.Emit(events.EventTypeRequest, "test", "pausable.request", map[string]interface{}{"correlation_id": "corr-1"})
+	<-reachedCheckpoint
+	eventBus.Emit(events.EventTypeBroadcast, "test", InterruptPause, map[string]interface{}{"correlation_id": "corr-1"})
+
+	select {
+	case err := <-passed:
+		t.Fatalf("Expected the checkpoint to wait while paused, returned %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	eventBus.Emit(events.EventTypeBroadcast, "test", InterruptResume, map[string]interface{}{"correlation_id": "corr-1"})
+	select {
+	case err := <-passed:
+		if err != nil {
+			t.Errorf("Expected the checkpoint to pass after resume, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Checkpoint never resumed")
+	}
+
+	if err := Checkpoint(context.Background()); err != nil {
+		t.Errorf("Expected checkpoints outside agent handlers to pass, got %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+package agentframework_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/pkg/agentframework"
+)
+
+// TestPublicAPI builds an agent the way code outside ZTDP does, through this package alone:
+// the builder, capabilities, the clarification protocol and the retry and dedup middleware
+func TestPublicAPI(t *testing.T) {
+	registry := agentframework.NewRegistry()
+	bus := agentframework.NewEventBus()
+
+	agent, err := agentframework.NewAgent("cost-agent").
+		WithType("finops").
+		WithCapabilities([]agentframework.Capability{{
+			Name:        "cost_estimation",
+			Description: "Estimates the monthly cost of an application",
+			Intents:     []string{"estimate cost"},
+			RoutingKeys: []string{"cost.estimate"},
+		}}).
+		WithEventHandler(func(ctx context.Context, event *agentframework.Event) (*agentframework.Event, error) {
+			app, _ := event.Payload["application"].(string)
+			if app == "" {
+				return nil, agentframework.Permanent(errors.New("application is required"))
+			}
+			confidence := 0.3 // e.g. from an AI extraction
+			if agentframework.NeedsClarification(ctx, confidence) {
+				return agentframework.ClarificationResponse(ctx, event, "Which environment should I estimate?", confidence), nil
+			}
+			return &agentframework.Event{Type: agentframework.EventTypeResponse, Payload: map[string]interface{}{"estimate": 120}}, nil
+		}).
+		WithRetry(agentframework.RetryPolicy{MaxAttempts: 3, Backoff: 100 * time.Millisecond}).
+		Build(agentframework.AgentDependencies{
+			Registry: registry,
+			EventBus: bus,
+			Dedup:    agentframework.NewMemoryDedupStore(time.Hour),
+		})
+	if err != nil {
+		t.Fatalf("Expected no error creating agent, got: %v", err)
+	}
+
+	response, _ := agent.(*agentframework.BaseAgent).ProcessEvent(context.Background(), &agentframework.Event{
+		Type:    agentframework.EventTypeRequest,
+		Subject: "cost.estimate",
+		Payload: map[string]interface{}{"application": "checkout"},
+	})
+	clarification, ok := agentframework.ClarificationFrom(response)
+	if !ok || clarification.Question != "Which environment should I estimate?" {
+		t.Errorf("Expected a clarification question, got %+v", response.Payload)
+	}
+
+	response, _ = agent.(*agentframework.BaseAgent).ProcessEvent(context.Background(), &agentframework.Event{
+		Type:    agentframework.EventTypeRequest,
+		Subject: "cost.estimate",
+		Payload: map[string]interface{}{},
+	})
+	if response.Payload["error"] != "application is required" {
+		t.Errorf("Expected the permanent error without retries, got %+v", response.Payload)
+	}
+}
@@ -0,0 +1,333 @@
+package agentframework
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/features"
+	"github.com/krzachariassen/ZTDP/internal/guardrails"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// DefaultQueryTimeout bounds a direct query when the caller passes no timeout
+const DefaultQueryTimeout = 10 * time.Second
+
+// MaxQueryDepth is the longest chain of nested agent queries before one is refused
+const MaxQueryDepth = 5
+
+// queryChainKey carries the IDs of the agents that are waiting on a query, outermost first
+const queryChainKey = "query_chain"
+
+var (
+	// ErrNoAgentForCapability is returned when no registered agent serves the capability over a routing key
+	ErrNoAgentForCapability = errors.New("no agent available for capability")
+	// ErrQueryLoop is returned instead of querying an agent that is already waiting on the chain
+	ErrQueryLoop = errors.New("agent query loop detected")
+	// ErrQueryTimeout is returned when the queried agent does not reply in time
+	ErrQueryTimeout = errors.New("agent query timed out")
+	// ErrQueryFailed is returned when the queried agent replies with an error response
+	ErrQueryFailed = errors.New("agent query failed")
+	// ErrNoQueryingAgent is returned by QueryAgent outside an agent's event handler
+	ErrNoQueryingAgent = errors.New("no agent in context to query from")
+)
+
+// QueryResult is the reply to a direct agent query
+type QueryResult struct {
+	AgentID       string
+	CorrelationID string
+	Payload       map[string]interface{}
+	Latency       time.Duration
+}
+
+// pendingQueries matches responses on the bus to the queries waiting for them
+type pendingQueries struct {
+	once    sync.Once
+	mu      sync.Mutex
+	waiting map[string]chan events.Event
+}
+
+type queryingAgentKey struct{}
+type queryChainCtxKey struct{}
+
+// QueryAgent sends a request to an agent with the given capability on behalf of the agent
+// whose event handler is running, and waits for its reply
+func QueryAgent(ctx context.Context, capability string, payload map[string]interface{}, timeout time.Duration) (*QueryResult, error) {
+	agent, ok := ctx.Value(queryingAgentKey{}).(*BaseAgent)
+	if !ok {
+		return nil, ErrNoQueryingAgent
+	}
+	return agent.QueryAgent(ctx, capability, payload, timeout)
+}
+
+// QueryAgent sends a request to an agent with the given capability and waits for its reply.
+// The reply is correlated by a fresh correlation ID so it never completes an outer request,
+// and the chain of waiting agents travels with the request so cycles are refused.
+func (a *BaseAgent) QueryAgent(ctx context.Context, capability string, payload map[string]interface{}, timeout time.Duration) (*QueryResult, error) {
+	if a.eventBus == nil || a.registry == nil {
+		return nil, fmt.Errorf("agent %s cannot query other agents without an event bus and registry", a.id)
+	}
+	if timeout <= 0 {
+		timeout = DefaultQueryTimeout
+	}
+	logger := a.logger.ForContext(ctx)
+
+	chain := append(queryChainFromContext(ctx), a.id)
+	if len(chain) > MaxQueryDepth {
+		recordQueryLoop(capability)
+		return nil, fmt.Errorf("%w: chain %v exceeds %d agents", ErrQueryLoop, chain, MaxQueryDepth)
+	}
+
+	targetID, routingKey, err := a.selectQueryTarget(ctx, capability, chain)
+	if err != nil {
+		if errors.Is(err, ErrQueryLoop) {
+			recordQueryLoop(capability)
+		}
+		return nil, err
+	}
+
+	correlationID := "query-" + uuid.New().String()
+	requestPayload := make(map[string]interface{}, len(payload)+8)
+	for k, v := range payload {
+		requestPayload[k] = v
+	}
+	requestPayload["correlation_id"] = correlationID
+	requestPayload["source_agent"] = a.id
+	requestPayload[queryChainKey] = chain
+	if parent := logging.CorrelationIDFromContext(ctx); parent != "" {
+		requestPayload["parent_correlation_id"] = parent
+	}
+	// The queried agent acts for the same caller as the agent asking
+	if role := guardrails.RoleFromContext(ctx); role != "" {
+		requestPayload["caller_role"] = role
+	}
+	if evalCtx := features.EvaluationContextFrom(ctx); evalCtx.ConversationID != "" || evalCtx.Tenant != "" {
+		requestPayload["conversation_id"] = evalCtx.ConversationID
+		requestPayload["tenant"] = evalCtx.Tenant
+	}
+	if userID := logging.UserIDFromContext(ctx); userID != "" {
+		requestPayload["user_id"] = userID
+	}
+
+	// Register before emitting: on a synchronous bus the reply arrives during Emit
+	replies := a.awaitReply(correlationID)
+	defer a.forgetReply(correlationID)
+
+	start := time.Now()
+	recordQuerySent(capability)
+	logger.Info("📨 Querying %s (%s) via %s", targetID, capability, routingKey)
+	if err := a.eventBus.Emit(events.EventTypeRequest, a.id, routingKey, requestPayload); err != nil {
+		recordQueryDone(capability, time.Since(start), err)
+		return nil, fmt.Errorf("failed to send query to %s: %w", targetID, err)
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case reply := <-replies:
+		result := &QueryResult{AgentID: reply.Source, CorrelationID: correlationID, Payload: reply.Payload, Latency: time.Since(start)}
+		if status, _ := reply.Payload["status"].(string); status == "error" {
+			message, _ := reply.Payload["error"].(string)
+			err := fmt.Errorf("%w: %s: %s", ErrQueryFailed, reply.Source, message)
+			recordQueryDone(capability, result.Latency, err)
+			return result, err
+		}
+		recordQueryDone(capability, result.Latency, nil)
+		logger.Info("✅ Query answered by %s in %s", reply.Source, result.Latency)
+		return result, nil
+	case <-timer.C:
+		recordQueryDone(capability, time.Since(start), ErrQueryTimeout)
+		logger.Warn("⏰ Query to %s (%s) timed out after %s", targetID, capability, timeout)
+		return nil, fmt.Errorf("%w: %s did not reply within %s", ErrQueryTimeout, targetID, timeout)
+	case <-ctx.Done():
+		recordQueryDone(capability, time.Since(start), ctx.Err())
+		return nil, ctx.Err()
+	}
+}
+
+// selectQueryTarget picks an agent serving the capability that is not already waiting on the chain
+func (a *BaseAgent) selectQueryTarget(ctx context.Context, capability string, chain []string) (string, string, error) {
+	statuses, err := a.registry.FindAgentsByCapability(ctx, capability)
+	if err != nil {
+		return "", "", fmt.Errorf("agent discovery failed for capability %s: %w", capability, err)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].ID < statuses[j].ID })
+
+	looped := false
+	for _, status := range statuses {
+		if containsString(chain, status.ID) {
+			looped = true
+			continue
+		}
+		agent, err := a.registry.FindAgentByID(ctx, status.ID)
+		if err != nil {
+			continue
+		}
+		for _, cap := range agent.GetCapabilities() {
+			if cap.Name == capability && len(cap.RoutingKeys) > 0 {
+				return status.ID, cap.RoutingKeys[0], nil
+			}
+		}
+	}
+	if looped {
+		return "", "", fmt.Errorf("%w: every agent with capability %s is already in chain %v", ErrQueryLoop, capability, chain)
+	}
+	return "", "", fmt.Errorf("%w: %s", ErrNoAgentForCapability, capability)
+}
+
+// awaitReply returns the channel the reply with correlationID will be delivered on
+func (a *BaseAgent) awaitReply(correlationID string) <-chan events.Event {
+	a.queries.once.Do(func() {
+		a.queries.waiting = make(map[string]chan events.Event)
+		a.eventBus.Subscribe(events.EventTypeResponse, a.deliverReply)
+	})
+	replies := make(chan events.Event, 1)
+	a.queries.mu.Lock()
+	a.queries.waiting[correlationID] = replies
+	a.queries.mu.Unlock()
+	return replies
+}
+
+func (a *BaseAgent) forgetReply(correlationID string) {
+	a.queries.mu.Lock()
+	delete(a.queries.waiting, correlationID)
+	a.queries.mu.Unlock()
+}
+
+// deliverReply hands a response to the query waiting for its correlation ID, if any
+func (a *BaseAgent) deliverReply(event events.Event) error {
+	correlationID, _ := event.Payload["correlation_id"].(string)
+	if correlationID == "" {
+		return nil
+	}
+	a.queries.mu.Lock()
+	replies, ok := a.queries.waiting[correlationID]
+	a.queries.mu.Unlock()
+	if ok {
+		select {
+		case replies <- event:
+		default:
+			// Only the first reply counts
+		}
+	}
+	return nil
+}
+
+// withQueryChain records which agents are waiting on the request being handled
+func withQueryChain(ctx context.Context, event *events.Event) context.Context {
+	var chain []string
+	switch raw := event.Payload[queryChainKey].(type) {
+	case []string:
+		chain = raw
+	case []interface{}: // decoded from JSON by a transport
+		for _, id := range raw {
+			if s, ok := id.(string); ok {
+				chain = append(chain, s)
+			}
+		}
+	}
+	if len(chain) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, queryChainCtxKey{}, chain)
+}
+
+func queryChainFromContext(ctx context.Context) []string {
+	chain, _ := ctx.Value(queryChainCtxKey{}).([]string)
+	// Copy so appending for a nested query never shares the caller's backing array
+	return append([]string(nil), chain...)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ==================================================================================
+// QUERY METRICS
+// ==================================================================================
+
+// QueryStats counts direct agent queries for one capability
+type QueryStats struct {
+	Sent          int64         `json:"sent"`
+	Succeeded     int64         `json:"succeeded"`
+	Failed        int64         `json:"failed"`
+	TimedOut      int64         `json:"timed_out"`
+	LoopsRejected int64         `json:"loops_rejected"`
+	AvgLatency    time.Duration `json:"avg_latency"`
+
+	latencySum time.Duration
+	answered   int64
+}
+
+var (
+	queryMetricsMu sync.Mutex
+	queryMetrics   = make(map[string]*QueryStats)
+)
+
+func queryStatsFor(capability string) *QueryStats {
+	stats, ok := queryMetrics[capability]
+	if !ok {
+		stats = &QueryStats{}
+		queryMetrics[capability] = stats
+	}
+	return stats
+}
+
+func recordQuerySent(capability string) {
+	queryMetricsMu.Lock()
+	defer queryMetricsMu.Unlock()
+	queryStatsFor(capability).Sent++
+}
+
+func recordQueryLoop(capability string) {
+	queryMetricsMu.Lock()
+	defer queryMetricsMu.Unlock()
+	queryStatsFor(capability).LoopsRejected++
+}
+
+func recordQueryDone(capability string, latency time.Duration, err error) {
+	queryMetricsMu.Lock()
+	defer queryMetricsMu.Unlock()
+	stats := queryStatsFor(capability)
+	switch {
+	case err == nil:
+		stats.Succeeded++
+	case errors.Is(err, ErrQueryTimeout):
+		stats.TimedOut++
+	default:
+		stats.Failed++
+	}
+	if err == nil || errors.Is(err, ErrQueryFailed) {
+		stats.answered++
+		stats.latencySum += latency
+		stats.AvgLatency = stats.latencySum / time.Duration(stats.answered)
+	}
+}
+
+// GetQueryMetrics returns a snapshot of direct agent query counts per capability
+func GetQueryMetrics() map[string]QueryStats {
+	queryMetricsMu.Lock()
+	defer queryMetricsMu.Unlock()
+	snapshot := make(map[string]QueryStats, len(queryMetrics))
+	for capability, stats := range queryMetrics {
+		snapshot[capability] = *stats
+	}
+	return snapshot
+}
+
+// ResetQueryMetrics clears the query counters
+func ResetQueryMetrics() {
+	queryMetricsMu.Lock()
+	defer queryMetricsMu.Unlock()
+	queryMetrics = make(map[string]*QueryStats)
+}
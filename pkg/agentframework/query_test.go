@@ -0,0 +1,119 @@
+package agentframework
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+	"github.com/krzachariassen/ZTDP/internal/events"
+)
+
+func buildQueryTestAgent(t *testing.T, registry agentRegistry.AgentRegistry, bus *events.EventBus, id, capability string, handler func(ctx context.Context, event *events.Event) (*events.Event, error)) *BaseAgent {
+	t.Helper()
+	agent, err := NewAgent(id).
+		WithCapabilities([]agentRegistry.AgentCapability{{Name: capability, RoutingKeys: []string{id + ".request"}}}).
+		WithEventHandler(handler).
+		Build(AgentDependencies{Registry: registry, EventBus: bus})
+	if err != nil {
+		t.Fatalf("failed to build %s: %v", id, err)
+	}
+	return agent.(*BaseAgent)
+}
+
+func TestQueryAgentRequestReply(t *testing.T) {
+	ResetQueryMetrics()
+	registry := agentRegistry.NewInMemoryAgentRegistry()
+	bus := events.NewEventBus(nil, false)
+
+	var policy *BaseAgent
+	policy = buildQueryTestAgent(t, registry, bus, "policy", "policy_evaluation", func(ctx context.Context, event *events.Event) (*events.Event, error) {
+		if event.Payload["environment"] == "production" {
+			return nil, errors.New("production is frozen")
+		}
+		return policy.CreateResponse("ok", map[string]interface{}{"decision": "allowed"}, event), nil
+	})
+
+	var decision string
+	var queryErr error
+	buildQueryTestAgent(t, registry, bus, "deployer", "deployment", func(ctx context.Context, event *events.Event) (*events.Event, error) {
+		env, _ := event.Payload["environment"].(string)
+		result, err := QueryAgent(ctx, "policy_evaluation", map[string]interface{}{"intent": "evaluate", "environment": env}, time.Second)
+		queryErr = err
+		if err == nil {
+			decision, _ = result.Payload["decision"].(string)
+		}
+		return nil, nil
+	})
+
+	if err := bus.Emit(events.EventTypeRequest, "test", "deployer.request", map[string]interface{}{"correlation_id": "outer", "environment": "dev"}); err != nil {
+		t.Fatalf("emit failed: %v", err)
+	}
+	if queryErr != nil || decision != "allowed" {
+		t.Fatalf("expected the policy agent to allow, got %q / %v", decision, queryErr)
+	}
+
+	bus.Emit(events.EventTypeRequest, "test", "deployer.request", map[string]interface{}{"environment": "production"})
+	if !errors.Is(queryErr, ErrQueryFailed) {
+		t.Fatalf("expected ErrQueryFailed for an error reply, got %v", queryErr)
+	}
+
+	stats := GetQueryMetrics()["policy_evaluation"]
+	if stats.Sent != 2 || stats.Succeeded != 1 || stats.Failed != 1 {
+		t.Errorf("unexpected query metrics: %+v", stats)
+	}
+}
+
+func TestQueryAgentRejectsLoops(t *testing.T) {
+	ResetQueryMetrics()
+	registry := agentRegistry.NewInMemoryAgentRegistry()
+	bus := events.NewEventBus(nil, false)
+
+	var innerErr error
+	buildQueryTestAgent(t, registry, bus, "a", "cap_a", func(ctx context.Context, event *events.Event) (*events.Event, error) {
+		_, err := QueryAgent(ctx, "cap_b", nil, time.Second)
+		return nil, err
+	})
+	buildQueryTestAgent(t, registry, bus, "b", "cap_b", func(ctx context.Context, event *events.Event) (*events.Event, error) {
+		// b asks a back, which is still waiting on b
+		_, innerErr = QueryAgent(ctx, "cap_a", nil, time.Second)
+		return nil, innerErr
+	})
+
+	bus.Emit(events.EventTypeRequest, "test", "a.request", map[string]interface{}{})
+	if !errors.Is(innerErr, ErrQueryLoop) {
+		t.Fatalf("expected ErrQueryLoop, got %v", innerErr)
+	}
+	if GetQueryMetrics()["cap_a"].LoopsRejected != 1 {
+		t.Errorf("expected the loop to be counted, got %+v", GetQueryMetrics())
+	}
+}
+
+func TestQueryAgentTimeoutAndDiscovery(t *testing.T) {
+	ResetQueryMetrics()
+	registry := agentRegistry.NewInMemoryAgentRegistry()
+	bus := events.NewEventBus(nil, false)
+
+	buildQueryTestAgent(t, registry, bus, "silent", "slow", func(ctx context.Context, event *events.Event) (*events.Event, error) {
+		return nil, nil
+	})
+	caller := buildQueryTestAgent(t, registry, bus, "caller", "calling", func(ctx context.Context, event *events.Event) (*events.Event, error) {
+		return nil, nil
+	})
+
+	_, err := caller.QueryAgent(context.Background(), "slow", nil, 20*time.Millisecond)
+	if !errors.Is(err, ErrQueryTimeout) {
+		t.Fatalf("expected ErrQueryTimeout, got %v", err)
+	}
+	if GetQueryMetrics()["slow"].TimedOut != 1 {
+		t.Errorf("expected the timeout to be counted, got %+v", GetQueryMetrics())
+	}
+
+	if _, err := caller.QueryAgent(context.Background(), "missing", nil, time.Second); !errors.Is(err, ErrNoAgentForCapability) {
+		t.Errorf("expected ErrNoAgentForCapability, got %v", err)
+	}
+	if _, err := QueryAgent(context.Background(), "slow", nil, time.Second); !errors.Is(err, ErrNoQueryingAgent) {
+		t.Errorf("expected ErrNoQueryingAgent outside a handler, got %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package agentframework
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/events"
+)
+
+// DefaultRetryBackoff is the wait before the first retry when a RetryPolicy sets none
+const DefaultRetryBackoff = 200 * time.Millisecond
+
+// RetryPolicy retries an agent's event handler when it returns an error. Error responses the
+// handler builds itself are answers, not failures, and are never retried.
+type RetryPolicy struct {
+	MaxAttempts int           // including the first; 1 or less disables retries
+	Backoff     time.Duration // wait before the first retry, doubled after each; DefaultRetryBackoff when 0
+	MaxBackoff  time.Duration // cap on the wait; 0 leaves it uncapped
+}
+
+// WithRetry retries the event handler on errors according to policy. Handlers should only
+// opt in when retrying is safe; wrap errors with Permanent to stop retries early.
+func (b *AgentBuilder) WithRetry(policy RetryPolicy) *AgentBuilder {
+	b.retry = policy
+	return b
+}
+
+// permanentError is a handler error retrying cannot fix
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks a handler error as not worth retrying, e.g. invalid input
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent
+func IsPermanent(err error) bool {
+	var permanent permanentError
+	return errors.As(err, &permanent)
+}
+
+// handle runs the event handler, retrying errors as the agent's retry policy allows. Retries
+// stop early for permanent errors and once the event is cancelled.
+func (a *BaseAgent) handle(ctx context.Context, event *events.Event) (*events.Event, error) {
+	backoff := a.retry.Backoff
+	if backoff <= 0 {
+		backoff = DefaultRetryBackoff
+	}
+	for attempt := 1; ; attempt++ {
+		response, err := a.eventHandler(ctx, event)
+		if err == nil || attempt >= a.retry.MaxAttempts || IsPermanent(err) || ctx.Err() != nil {
+			return response, err
+		}
+		a.logger.ForContext(ctx).Warn("🔁 Attempt %d/%d failed, retrying in %s: %v", attempt, a.retry.MaxAttempts, backoff, err)
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(backoff):
+		}
+		if err := Checkpoint(ctx); err != nil {
+			return nil, err
+		}
+		backoff *= 2
+		if a.retry.MaxBackoff > 0 && backoff > a.retry.MaxBackoff {
+			backoff = a.retry.MaxBackoff
+		}
+	}
+}
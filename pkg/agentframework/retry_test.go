@@ -0,0 +1,62 @@
+package agentframework
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+	"github.com/krzachariassen/ZTDP/internal/events"
+)
+
+func newRetryingAgent(t *testing.T, id string, handler func(ctx context.Context, event *events.Event) (*events.Event, error)) agentRegistry.AgentInterface {
+	t.Helper()
+	agent, err := NewAgent(id).
+		WithCapabilities([]agentRegistry.AgentCapability{{Name: "retry_test", RoutingKeys: []string{id + ".test"}}}).
+		WithEventHandler(handler).
+		WithRetry(RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}).
+		Build(AgentDependencies{Registry: agentRegistry.NewInMemoryAgentRegistry(), EventBus: events.NewEventBus(nil, false)})
+	if err != nil {
+		t.Fatalf("Expected no error creating agent, got: %v", err)
+	}
+	return agent
+}
+
+func TestAgentRetriesFailingHandlers(t *testing.T) {
+	attempts := 0
+	agent := newRetryingAgent(t, "retry-agent", func(ctx context.Context, event *events.Event) (*events.Event, error) {
+		attempts++
+		if attempts < 3 {
+			return nil, errors.New("registry unavailable")
+		}
+		return &events.Event{Type: events.EventTypeResponse, Payload: map[string]interface{}{"status": "success"}}, nil
+	})
+
+	response, err := agent.(*BaseAgent).ProcessEvent(context.Background(), &events.Event{Subject: "retry-agent.test", Payload: map[string]interface{}{}})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts)
+	}
+	if response.Payload["status"] != "success" {
+		t.Errorf("Expected the successful attempt's response, got %+v", response.Payload)
+	}
+}
+
+func TestAgentDoesNotRetryPermanentErrors(t *testing.T) {
+	attempts := 0
+	agent := newRetryingAgent(t, "permanent-agent", func(ctx context.Context, event *events.Event) (*events.Event, error) {
+		attempts++
+		return nil, Permanent(errors.New("invalid request"))
+	})
+
+	response, _ := agent.(*BaseAgent).ProcessEvent(context.Background(), &events.Event{Subject: "permanent-agent.test", Payload: map[string]interface{}{}})
+	if attempts != 1 {
+		t.Errorf("Expected a single attempt, got %d", attempts)
+	}
+	if response.Payload["error"] != "invalid request" {
+		t.Errorf("Expected the error response to carry the handler error, got %+v", response.Payload)
+	}
+}
@@ -0,0 +1,70 @@
+package agentframework
+
+import (
+	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+	"github.com/krzachariassen/ZTDP/internal/events"
+)
+
+// AgentRequestSchemaVersion is the version of the request envelope agents accept
+const AgentRequestSchemaVersion = 1
+
+// AgentRequestSchema is the payload contract for requests sent to an agent's routing key.
+// It pins down where the orchestrator and other agents put the common fields: the intent is
+// always "intent" (never "action") and the user's words are a top-level "user_message".
+// Agent-specific fields are allowed alongside.
+func AgentRequestSchema(routingKey string) events.PayloadSchema {
+	return events.PayloadSchema{
+		Subject: routingKey,
+		Version: AgentRequestSchemaVersion,
+		Fields: map[string]events.FieldSchema{
+			"correlation_id":        {Type: events.FieldString, Description: "Matches the response to the request"},
+			"parent_correlation_id": {Type: events.FieldString, Description: "Request that caused a direct agent query"},
+			"request_id":            {Type: events.FieldString},
+			"intent":                {Type: events.FieldString, Description: "What the caller wants done"},
+			"user_message":          {Type: events.FieldString, Description: "The user's original words"},
+			OriginalMessageKey:      {Type: events.FieldString, Description: "The message a clarification was asked for"},
+			ClarificationAnswerKey:  {Type: events.FieldString, Description: "The user's answer to the clarification"},
+			"message":               {Type: events.FieldString, Description: "Deprecated alias of user_message"},
+			"query":                 {Type: events.FieldString, Description: "Deprecated alias of user_message"},
+			"context":               {Type: events.FieldObject, Description: "Orchestrator context for the intent"},
+			ContextEnvelopeKey:      {Type: events.FieldObject, Description: "Entities and conversation state the orchestrator already extracted"},
+			"source_agent":          {Type: events.FieldString},
+			TargetAgentKey:          {Type: events.FieldString, Description: "The one agent that should handle the request"},
+			"caller_role":           {Type: events.FieldString},
+			"conversation_id":       {Type: events.FieldString},
+			"tenant":                {Type: events.FieldString},
+			"user_id":               {Type: events.FieldString},
+			queryChainKey:           {Type: events.FieldArray, Description: "Agents waiting on a direct query, outermost first"},
+			BidRequestKey:           {Type: events.FieldBool, Description: "Asks the agent for a bid instead of the work"},
+		},
+	}
+}
+
+// registerRequestSchemas gives each of the agent's routing keys the request envelope schema
+// when the bus validates payloads and no other schema was registered for the key, and
+// registers the clarification response schema the first time an agent is built
+func (a *BaseAgent) registerRequestSchemas(capabilities []agentRegistry.AgentCapability) error {
+	if a.eventBus == nil {
+		return nil
+	}
+	schemas := a.eventBus.Schemas()
+	if schemas == nil {
+		return nil
+	}
+	if _, exists := schemas.Latest(ClarificationSubject); !exists {
+		if err := schemas.Register(ClarificationSchema()); err != nil {
+			return err
+		}
+	}
+	for _, capability := range capabilities {
+		for _, routingKey := range capability.RoutingKeys {
+			if _, exists := schemas.Latest(routingKey); exists {
+				continue
+			}
+			if err := schemas.Register(AgentRequestSchema(routingKey)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package agentframework
+
+import (
+	"context"
+
+	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+	"github.com/krzachariassen/ZTDP/internal/ai"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/features"
+	"github.com/krzachariassen/ZTDP/internal/logging"
+)
+
+// The framework's API is expressed in these names. They alias ZTDP's own types, so agents
+// written against them interoperate with the platform's agents unchanged, while code outside
+// this module can name types it could not import from internal packages.
+type (
+	// Agent is what Build returns and the registry tracks
+	Agent = agentRegistry.AgentInterface
+	// Capability describes what an agent can do and the routing keys it serves
+	Capability   = agentRegistry.AgentCapability
+	AgentStatus  = agentRegistry.AgentStatus
+	HealthStatus = agentRegistry.HealthStatus
+	// Registry is where agents register and are discovered by capability
+	Registry = agentRegistry.AgentRegistry
+
+	Event         = events.Event
+	EventType     = events.EventType
+	EventBus      = events.EventBus
+	PayloadSchema = events.PayloadSchema
+
+	AIProvider   = ai.AIProvider
+	Logger       = logging.Logger
+	FeatureFlags = features.Service
+
+	// Handler handles the events routed to an agent, see AgentBuilder.WithEventHandler
+	Handler = func(ctx context.Context, event *Event) (*Event, error)
+)
+
+// Event types
+const (
+	EventTypeRequest   = events.EventTypeRequest
+	EventTypeResponse  = events.EventTypeResponse
+	EventTypeBroadcast = events.EventTypeBroadcast
+	EventTypeNotify    = events.EventTypeNotify
+)
+
+// NewEventBus creates an in-process event bus, for running agents outside the platform such
+// as in tests
+func NewEventBus() *EventBus {
+	return events.NewEventBus(events.NewMemoryTransport(), false)
+}
+
+// NewRegistry creates an in-memory agent registry, for running agents outside the platform
+// such as in tests
+func NewRegistry() Registry {
+	return agentRegistry.NewInMemoryAgentRegistry()
+}
@@ -0,0 +1,224 @@
+// Package scenario drives complete orchestration flows (chat → plan → deploy → policy)
+// through the real orchestrator, event bus and agents. AI calls are served from recorded
+// cassettes, so scenarios run deterministically in CI without an OpenAI key.
+//
+// Re-record a cassette against the real provider with:
+//
+//	ZTDP_AI_RECORD=1 OPENAI_API_KEY=... go test ./test/scenario/...
+package scenario
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/krzachariassen/ZTDP/internal/agentRegistry"
+	"github.com/krzachariassen/ZTDP/internal/agents/orchestrator"
+	"github.com/krzachariassen/ZTDP/internal/ai"
+	"github.com/krzachariassen/ZTDP/internal/application"
+	"github.com/krzachariassen/ZTDP/internal/deployments"
+	"github.com/krzachariassen/ZTDP/internal/events"
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/plans"
+	"github.com/krzachariassen/ZTDP/internal/policies"
+)
+
+// RecordEnv switches the harness from replaying cassettes to recording them
+const RecordEnv = "ZTDP_AI_RECORD"
+
+// Step is one user turn in a scenario
+type Step struct {
+	Name           string
+	Message        string
+	ExpectIntent   string
+	ExpectContains []string                   // substrings expected in the response message
+	Check          func(*testing.T, *Harness) // additional assertions, e.g. on the graph
+}
+
+// Harness wires the platform the way cmd/api does, with a recorded or replayed AI provider
+type Harness struct {
+	Graph        *graph.GlobalGraph
+	EventBus     *events.EventBus
+	Registry     agentRegistry.AgentRegistry
+	Orchestrator *orchestrator.Orchestrator
+	Plans        *plans.Service
+	Provider     ai.AIProvider
+
+	// Agents write the graph from event handlers after answering; checks wait for these before
+	// reading it
+	proposed  chan *plans.Plan
+	completed chan events.Event
+}
+
+// New builds a harness whose AI calls are replayed from (or, with ZTDP_AI_RECORD=1,
+// recorded to) the cassette at path
+func New(t *testing.T, cassette string) *Harness {
+	t.Helper()
+
+	var provider ai.AIProvider
+	if os.Getenv(RecordEnv) == "1" {
+		apiKey := os.Getenv("OPENAI_API_KEY")
+		if apiKey == "" {
+			t.Fatalf("%s=1 requires OPENAI_API_KEY", RecordEnv)
+		}
+		upstream, err := ai.NewOpenAIProvider(ai.DefaultOpenAIConfig(), apiKey)
+		if err != nil {
+			t.Fatalf("failed to create OpenAI provider: %v", err)
+		}
+		provider = ai.NewRecordingProvider(upstream, cassette)
+		t.Logf("🎙️ Recording AI responses to %s", cassette)
+	} else {
+		replay, err := ai.NewReplayProvider(cassette)
+		if err != nil {
+			t.Fatalf("failed to load cassette: %v", err)
+		}
+		provider = replay
+		t.Cleanup(func() {
+			for _, unused := range replay.Unused() {
+				t.Errorf("recorded interaction was never replayed (re-record the cassette?): %q", unused.UserPrompt)
+			}
+		})
+	}
+
+	return NewWithProvider(t, provider)
+}
+
+// NewWithProvider builds a harness around any AI provider. Agents are started and
+// everything is shut down when the test finishes.
+func NewWithProvider(t *testing.T, provider ai.AIProvider) *Harness {
+	t.Helper()
+
+	h := &Harness{
+		Graph:    graph.NewGlobalGraph(graph.NewMemoryGraph()),
+		EventBus: events.NewEventBus(events.NewMemoryTransport(), true),
+		Registry: agentRegistry.NewInMemoryAgentRegistry(),
+		Provider: provider,
+	}
+	h.Plans = plans.NewService(h.Graph, provider)
+	h.Plans.Attach(h.EventBus)
+	h.proposed = make(chan *plans.Plan, 16)
+	h.Plans.OnChange(func(plan *plans.Plan, change string) {
+		if change == plans.ChangeProposed {
+			h.proposed <- plan
+		}
+	})
+	h.completed = make(chan events.Event, 16)
+	h.EventBus.Subscribe(events.EventTypeNotify, func(event events.Event) error {
+		if event.Subject == "deployment.completed" {
+			h.completed <- event
+		}
+		return nil
+	})
+
+	applicationAgent, err := application.NewApplicationAgent(h.Graph, provider, h.EventBus, h.Registry)
+	if err != nil {
+		t.Fatalf("failed to create application agent: %v", err)
+	}
+	deploymentAgent, err := deployments.NewDeploymentAgent(h.Graph, provider, h.EventBus, h.Registry, nil)
+	if err != nil {
+		t.Fatalf("failed to create deployment agent: %v", err)
+	}
+	policyAgent, err := policies.NewPolicyAgent(nil, h.Graph, nil, h.EventBus, h.Registry)
+	if err != nil {
+		t.Fatalf("failed to create policy agent: %v", err)
+	}
+
+	planAgent, err := plans.NewPlanAgent(h.Graph, h.Plans, provider, h.EventBus, h.Registry)
+	if err != nil {
+		t.Fatalf("failed to create plan agent: %v", err)
+	}
+
+	ctx := context.Background()
+	agents := []agentRegistry.AgentInterface{applicationAgent, deploymentAgent, policyAgent, planAgent}
+	for _, agent := range agents {
+		if err := agent.Start(ctx); err != nil {
+			t.Fatalf("failed to start %s: %v", agent.GetID(), err)
+		}
+	}
+
+	h.Orchestrator = orchestrator.NewOrchestrator(provider, h.Graph, h.EventBus, h.Registry)
+
+	t.Cleanup(func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		h.Orchestrator.Drain(shutdownCtx)
+		for i := len(agents) - 1; i >= 0; i-- {
+			agents[i].Stop(shutdownCtx)
+		}
+		h.EventBus.Shutdown(shutdownCtx)
+		if err := provider.Close(); err != nil {
+			t.Errorf("failed to close AI provider: %v", err)
+		}
+	})
+
+	return h
+}
+
+// Chat sends one message through the orchestrator
+func (h *Harness) Chat(t *testing.T, message string) *orchestrator.ConversationalResponse {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	response, err := h.Orchestrator.Chat(ctx, message)
+	if err != nil {
+		t.Fatalf("chat %q failed: %v", message, err)
+	}
+	return response
+}
+
+// AwaitProposedPlan waits until a proposed plan has been stored
+func (h *Harness) AwaitProposedPlan(t *testing.T) *plans.Plan {
+	t.Helper()
+
+	select {
+	case plan := <-h.proposed:
+		return plan
+	case <-time.After(2 * time.Second):
+		t.Fatal("no plan was proposed")
+		return nil
+	}
+}
+
+// AwaitDeploymentCompleted waits for the next deployment.completed event
+func (h *Harness) AwaitDeploymentCompleted(t *testing.T) events.Event {
+	t.Helper()
+
+	select {
+	case event := <-h.completed:
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("no deployment completed")
+		return events.Event{}
+	}
+}
+
+// Run executes the steps in order; a failed step stops the scenario because later
+// steps depend on the state it should have produced
+func (h *Harness) Run(t *testing.T, steps []Step) {
+	t.Helper()
+
+	for _, step := range steps {
+		ok := t.Run(step.Name, func(t *testing.T) {
+			response := h.Chat(t, step.Message)
+
+			if step.ExpectIntent != "" && response.Intent != step.ExpectIntent {
+				t.Errorf("expected intent %q, got %q (message: %s)", step.ExpectIntent, response.Intent, response.Message)
+			}
+			for _, want := range step.ExpectContains {
+				if !strings.Contains(response.Message, want) {
+					t.Errorf("expected response to contain %q, got: %s", want, response.Message)
+				}
+			}
+			if step.Check != nil {
+				step.Check(t, h)
+			}
+		})
+		if !ok {
+			t.FailNow()
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package scenario
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/krzachariassen/ZTDP/internal/graph"
+	"github.com/krzachariassen/ZTDP/internal/plans"
+)
+
+// deploymentStatuses returns the status of every deployment edge into env
+func deploymentStatuses(t *testing.T, h *Harness, env string) []string {
+	t.Helper()
+
+	edges, err := h.Graph.Edges()
+	if err != nil {
+		t.Fatalf("failed to read edges: %v", err)
+	}
+	var statuses []string
+	for _, list := range edges {
+		for _, edge := range list {
+			if edge.Type == "deployment" && edge.To == env {
+				status, _ := edge.Metadata["status"].(string)
+				statuses = append(statuses, status)
+			}
+		}
+	}
+	return statuses
+}
+
+func TestScenario_CreatePlanDeployAndCheckPolicy(t *testing.T) {
+	h := New(t, filepath.Join("testdata", "create_plan_deploy_policy.json"))
+
+	h.Run(t, []Step{
+		{
+			Name:         "create application",
+			Message:      "Create an application called checkout",
+			ExpectIntent: "create application",
+			Check: func(t *testing.T, h *Harness) {
+				node, _ := h.Graph.GetNode("checkout")
+				if node == nil || node.Kind != graph.KindApplication {
+					t.Fatalf("expected application node checkout, got %+v", node)
+				}
+			},
+		},
+		{
+			Name:           "plan deployment",
+			Message:        "Plan a deployment of checkout to dev",
+			ExpectIntent:   "plan deployment",
+			ExpectContains: []string{"deploy checkout"},
+			Check: func(t *testing.T, h *Harness) {
+				// Proposed plans are stored by an event subscriber, which runs asynchronously
+				h.AwaitProposedPlan(t)
+				drafts, err := h.Plans.List(plans.ListFilter{Status: plans.StatusDraft})
+				if err != nil || len(drafts) != 1 {
+					t.Fatalf("expected the proposed plan to be stored as a draft, got %d plans (err: %v)", len(drafts), err)
+				}
+				if statuses := deploymentStatuses(t, h, "dev"); len(statuses) != 0 {
+					t.Errorf("expected planning not to deploy, got deployment statuses %v", statuses)
+				}
+			},
+		},
+		{
+			Name:           "deploy",
+			Message:        "Deploy checkout to dev",
+			ExpectIntent:   "deploy application",
+			ExpectContains: []string{"completed"},
+			Check: func(t *testing.T, h *Harness) {
+				if event := h.AwaitDeploymentCompleted(t); event.Payload["environment"] != "dev" {
+					t.Errorf("expected the deployment to dev to complete, got %v", event.Payload)
+				}
+				statuses := deploymentStatuses(t, h, "dev")
+				if len(statuses) == 0 {
+					t.Fatal("expected a deployment edge to dev")
+				}
+				for _, status := range statuses {
+					if status != "succeeded" {
+						t.Errorf("expected every deployment to dev to succeed, got statuses %v", statuses)
+						break
+					}
+				}
+			},
+		},
+		{
+			Name:           "policy check",
+			Message:        "Check if checkout is allowed to deploy to production",
+			ExpectIntent:   "policy check",
+			ExpectContains: []string{"Decision: allowed"},
+		},
+	})
+}